@@ -0,0 +1,248 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go.pinniped.dev/internal/configarchive"
+	"go.pinniped.dev/internal/groupsuffix"
+	"go.pinniped.dev/internal/here"
+	"go.pinniped.dev/internal/pversion"
+)
+
+//nolint:gochecknoglobals
+var adminCmd = &cobra.Command{
+	Use:          "admin",
+	Short:        "Administers one of [config]",
+	SilenceUsage: true, // Do not print usage message when commands fail.
+}
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(adminCmd)
+	adminCmd.AddCommand(newAdminConfigCommand(adminConfigRealDeps()))
+}
+
+type adminConfigDeps struct {
+	getClientset getSupervisorClientsetFunc
+	now          func() time.Time
+}
+
+func adminConfigRealDeps() adminConfigDeps {
+	return adminConfigDeps{
+		getClientset: getRealSupervisorClientset,
+		now:          time.Now,
+	}
+}
+
+type adminConfigCommonFlags struct {
+	kubeconfigPath            string
+	kubeconfigContextOverride string
+	apiGroupSuffix            string
+	namespace                 string
+	signingKeyFile            string
+	timeout                   time.Duration
+}
+
+func (f *adminConfigCommonFlags) addFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.StringVar(&f.kubeconfigPath, "kubeconfig", os.Getenv("KUBECONFIG"), "Path to kubeconfig file")
+	flags.StringVar(&f.kubeconfigContextOverride, "kubeconfig-context", "", "Kubeconfig context name (default: current active context)")
+	flags.StringVar(&f.apiGroupSuffix, "api-group-suffix", groupsuffix.PinnipedDefaultSuffix, "Supervisor API group suffix")
+	flags.StringVarP(&f.namespace, "namespace", "n", "pinniped-supervisor", "Namespace in which the Supervisor is installed")
+	flags.StringVar(&f.signingKeyFile, "signing-key-file", "", "Path to a file containing the secret key used to sign and verify the archive")
+	flags.DurationVar(&f.timeout, "timeout", 0, "Timeout for the Supervisor API requests (default: 0, meaning no timeout)")
+	mustMarkRequired(cmd, "signing-key-file")
+}
+
+func (f *adminConfigCommonFlags) context() (context.Context, context.CancelFunc) {
+	if f.timeout > 0 {
+		return context.WithTimeout(context.Background(), f.timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+func (f *adminConfigCommonFlags) readSigningKey() ([]byte, error) {
+	key, err := os.ReadFile(f.signingKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not read --signing-key-file: %w", err)
+	}
+	key = bytes.TrimSpace(key)
+	if len(key) == 0 {
+		return nil, fmt.Errorf("--signing-key-file %q is empty", f.signingKeyFile)
+	}
+	return key, nil
+}
+
+func newAdminConfigCommand(deps adminConfigDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Export or import the Supervisor's configuration",
+		Long: here.Doc(
+			`Export or import the Supervisor's configuration
+
+			The archive contains the FederationDomains (including their identity
+			transformations), identity providers, OIDCClients, and ClusterAudiences
+			from the Supervisor's namespace. The archive never contains any Secrets. Resources
+			in the archive refer to Secrets by name, so those Secrets must be created
+			separately in the target environment.
+
+			The archive is signed using the secret key from --signing-key-file. The same
+			key must be used to import the archive.`,
+		),
+		SilenceUsage: true, // Do not print usage message when commands fail.
+	}
+	cmd.AddCommand(newAdminConfigExportCommand(deps))
+	cmd.AddCommand(newAdminConfigImportCommand(deps))
+	return cmd
+}
+
+type adminConfigExportFlags struct {
+	adminConfigCommonFlags
+	outputPath string
+}
+
+func newAdminConfigExportCommand(deps adminConfigDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Args:         cobra.NoArgs,
+		Use:          "export",
+		Short:        "Export the Supervisor's configuration to a signed archive",
+		SilenceUsage: true,
+	}
+	flags := &adminConfigExportFlags{}
+	flags.addFlags(cmd)
+	cmd.Flags().StringVarP(&flags.outputPath, "output", "o", "", "Output file path (default: stdout)")
+
+	cmd.RunE = func(cmd *cobra.Command, _ []string) error {
+		return runAdminConfigExport(cmd.OutOrStdout(), deps, flags)
+	}
+	return cmd
+}
+
+func runAdminConfigExport(out io.Writer, deps adminConfigDeps, flags *adminConfigExportFlags) error {
+	key, err := flags.readSigningKey()
+	if err != nil {
+		return err
+	}
+
+	clientset, err := deps.getClientset(newClientConfig(flags.kubeconfigPath, flags.kubeconfigContextOverride), flags.apiGroupSuffix)
+	if err != nil {
+		return fmt.Errorf("could not configure Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := flags.context()
+	defer cancel()
+
+	archive, err := configarchive.Export(ctx, clientset, flags.namespace, deps.now(), pversion.Get().GitVersion)
+	if err != nil {
+		return fmt.Errorf("could not export configuration: %w", err)
+	}
+
+	signed, err := configarchive.Sign(archive, key)
+	if err != nil {
+		return fmt.Errorf("could not sign archive: %w", err)
+	}
+
+	if flags.outputPath != "" {
+		if err := os.WriteFile(flags.outputPath, signed, 0600); err != nil {
+			return fmt.Errorf("could not write archive: %w", err)
+		}
+		return nil
+	}
+
+	_, err = out.Write(signed)
+	return err
+}
+
+type adminConfigImportFlags struct {
+	adminConfigCommonFlags
+	inputPath string
+	dryRun    bool
+}
+
+func newAdminConfigImportCommand(deps adminConfigDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "import",
+		Short: "Import the Supervisor's configuration from a signed archive",
+		Long: here.Doc(
+			`Import the Supervisor's configuration from a signed archive
+
+			Resources from the archive are created, or are updated when they already exist.
+			Resources which are not in the archive are not changed or deleted.
+
+			Every change is validated by the Kubernetes API server before any change is
+			made. Use --dry-run to only validate the changes and print what would change.`,
+		),
+		SilenceUsage: true,
+	}
+	flags := &adminConfigImportFlags{}
+	flags.addFlags(cmd)
+	cmd.Flags().StringVarP(&flags.inputPath, "filename", "f", "", "Path to the archive file")
+	cmd.Flags().BoolVar(&flags.dryRun, "dry-run", false, "Only validate the archive and print the changes which would be made")
+	mustMarkRequired(cmd, "filename")
+
+	cmd.RunE = func(cmd *cobra.Command, _ []string) error {
+		return runAdminConfigImport(cmd.OutOrStdout(), deps, flags)
+	}
+	return cmd
+}
+
+func runAdminConfigImport(out io.Writer, deps adminConfigDeps, flags *adminConfigImportFlags) error {
+	key, err := flags.readSigningKey()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(flags.inputPath)
+	if err != nil {
+		return fmt.Errorf("could not read archive: %w", err)
+	}
+
+	archive, err := configarchive.Verify(data, key)
+	if err != nil {
+		return fmt.Errorf("could not verify archive: %w", err)
+	}
+
+	clientset, err := deps.getClientset(newClientConfig(flags.kubeconfigPath, flags.kubeconfigContextOverride), flags.apiGroupSuffix)
+	if err != nil {
+		return fmt.Errorf("could not configure Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := flags.context()
+	defer cancel()
+
+	changes, err := configarchive.Import(ctx, clientset, flags.namespace, archive, flags.dryRun)
+	if err != nil {
+		return fmt.Errorf("could not import configuration: %w", err)
+	}
+
+	for _, change := range changes {
+		if flags.dryRun && change.Action == configarchive.ActionUnchanged {
+			continue
+		}
+		verb := map[configarchive.Action]string{
+			configarchive.ActionCreate:    "created",
+			configarchive.ActionUpdate:    "updated",
+			configarchive.ActionUnchanged: "unchanged",
+		}[change.Action]
+		if flags.dryRun {
+			verb += " (dry run)"
+		}
+		fmt.Fprintf(out, "%s/%s %s\n", change.Kind, change.Name, verb)
+		if flags.dryRun && change.Diff != "" {
+			fmt.Fprintln(out, change.Diff)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubetesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/clientcmd"
+
+	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
+	supervisorclientset "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned"
+	supervisorfake "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/fake"
+)
+
+func TestAdminConfigExportAndImport(t *testing.T) {
+	tempDir := t.TempDir()
+	keyFile := filepath.Join(tempDir, "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("some-signing-key\n"), 0600))
+	emptyKeyFile := filepath.Join(tempDir, "empty-key")
+	require.NoError(t, os.WriteFile(emptyKeyFile, []byte("\n"), 0600))
+	archiveFile := filepath.Join(tempDir, "archive.yaml")
+
+	sourceClient := supervisorfake.NewSimpleClientset(&idpv1alpha1.OIDCIdentityProvider{
+		ObjectMeta: metav1.ObjectMeta{Name: "some-idp", Namespace: "pinniped-supervisor"},
+		Spec:       idpv1alpha1.OIDCIdentityProviderSpec{Issuer: "https://new-issuer.example.com"},
+	})
+	targetClient := supervisorfake.NewSimpleClientset(&idpv1alpha1.OIDCIdentityProvider{
+		ObjectMeta: metav1.ObjectMeta{Name: "some-idp", Namespace: "pinniped-supervisor"},
+		Spec:       idpv1alpha1.OIDCIdentityProviderSpec{Issuer: "https://old-issuer.example.com"},
+	})
+	// The fake clientset does not support server-side dry runs, so ignore all updates.
+	targetClient.PrependReactor("update", "*", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		return true, action.(kubetesting.UpdateAction).GetObject(), nil
+	})
+
+	depsFor := func(client supervisorclientset.Interface) adminConfigDeps {
+		return adminConfigDeps{
+			getClientset: func(_ clientcmd.ClientConfig, apiGroupSuffix string) (supervisorclientset.Interface, error) {
+				require.Equal(t, "pinniped.dev", apiGroupSuffix)
+				return client, nil
+			},
+			now: func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) },
+		}
+	}
+
+	run := func(deps adminConfigDeps, args ...string) (string, error) {
+		cmd := newAdminConfigCommand(deps)
+		var stdout bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		return stdout.String(), err
+	}
+
+	_, err := run(depsFor(sourceClient), "export", "--signing-key-file", emptyKeyFile, "--output", archiveFile)
+	require.EqualError(t, err, `--signing-key-file "`+emptyKeyFile+`" is empty`)
+
+	stdout, err := run(depsFor(sourceClient), "export", "--signing-key-file", keyFile, "--output", archiveFile)
+	require.NoError(t, err)
+	require.Empty(t, stdout)
+
+	stdout, err = run(depsFor(sourceClient), "export", "--signing-key-file", keyFile)
+	require.NoError(t, err)
+	archiveFromStdout, err := os.ReadFile(archiveFile)
+	require.NoError(t, err)
+	require.Equal(t, string(archiveFromStdout), stdout)
+
+	stdout, err = run(depsFor(targetClient), "import", "--signing-key-file", keyFile, "--filename", archiveFile, "--dry-run")
+	require.NoError(t, err)
+	require.Contains(t, stdout, "OIDCIdentityProvider/some-idp updated (dry run)\n")
+	require.Contains(t, stdout, `"https://old-issuer.example.com"`)
+	require.Contains(t, stdout, `"https://new-issuer.example.com"`)
+
+	wrongKeyFile := filepath.Join(tempDir, "wrong-key")
+	require.NoError(t, os.WriteFile(wrongKeyFile, []byte("wrong-key"), 0600))
+	_, err = run(depsFor(targetClient), "import", "--signing-key-file", wrongKeyFile, "--filename", archiveFile)
+	require.EqualError(t, err, "could not verify archive: archive signature is invalid: the archive was modified or was signed with a different key")
+
+	_, err = run(depsFor(targetClient), "import", "--signing-key-file", keyFile)
+	require.EqualError(t, err, `required flag(s) "filename" not set`)
+}
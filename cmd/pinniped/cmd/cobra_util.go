@@ -28,3 +28,11 @@ func mustMarkDeprecated(cmd *cobra.Command, flag, usageMessage string) {
 		panic(err)
 	}
 }
+
+// mustRegisterFlagCompletionFunc registers the completion function of the given flag on the provided cobra.Command.
+// If the name is wrong, it panics.
+func mustRegisterFlagCompletionFunc(cmd *cobra.Command, flag string, f completionFunc) {
+	if err := cmd.RegisterFlagCompletionFunc(flag, f); err != nil {
+		panic(err)
+	}
+}
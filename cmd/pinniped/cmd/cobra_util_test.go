@@ -19,3 +19,12 @@ func TestMustMarkRequired(t *testing.T) {
 	})
 	require.Panics(t, func() { mustMarkRequired(&cobra.Command{}, "unknown-flag") })
 }
+
+func TestMustRegisterFlagCompletionFunc(t *testing.T) {
+	require.NotPanics(t, func() {
+		cmd := &cobra.Command{}
+		cmd.Flags().String("known-flag", "", "")
+		mustRegisterFlagCompletionFunc(cmd, "known-flag", completeValues("a", "b"))
+	})
+	require.Panics(t, func() { mustRegisterFlagCompletionFunc(&cobra.Command{}, "unknown-flag", completeValues("a")) })
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	idpdiscoveryv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idpdiscovery/v1alpha1"
+	"go.pinniped.dev/internal/execcredcache"
+	"go.pinniped.dev/pkg/oidcclient/filesession"
+)
+
+// completionFunc is the signature of the functions which cobra calls to complete the values of flags and arguments.
+type completionFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// completeValues completes a flag whose value is one of a fixed set of values.
+func completeValues(values ...string) completionFunc {
+	return func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+//nolint:gochecknoglobals
+var (
+	completeUpstreamIDPTypes = completeValues(
+		idpdiscoveryv1alpha1.IDPTypeOIDC.String(),
+		idpdiscoveryv1alpha1.IDPTypeLDAP.String(),
+		idpdiscoveryv1alpha1.IDPTypeActiveDirectory.String(),
+		idpdiscoveryv1alpha1.IDPTypeGitHub.String(),
+	)
+	completeUpstreamIDPFlows = completeValues(
+		idpdiscoveryv1alpha1.IDPFlowBrowserAuthcode.String(),
+		idpdiscoveryv1alpha1.IDPFlowCLIPassword.String(),
+	)
+	completeAuthenticatorTypes = completeValues("jwt", "webhook")
+	completeConciergeModes     = completeValues("TokenCredentialRequestAPI", "ImpersonationProxy")
+	completeOutputFormats      = completeValues(outputFormatText, outputFormatJSON, outputFormatYAML)
+)
+
+// completeKubeconfigContexts completes the names of the contexts of the kubeconfig given by the --kubeconfig flag.
+func completeKubeconfigContexts(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	kubeconfigPath, _ := cmd.Flags().GetString("kubeconfig")
+	config, err := newClientConfig(kubeconfigPath, "").RawConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	contexts := make([]string, 0, len(config.Contexts))
+	for name := range config.Contexts {
+		contexts = append(contexts, name)
+	}
+	slices.Sort(contexts)
+	return contexts, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSessionIDs completes the IDs of the cached sessions and cluster credentials, described by their
+// issuer or username. IDs which were already given are not completed again.
+func completeSessionIDs(cmd *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	var completions []string
+	encryption, _ := cacheEncryption(os.LookupEnv)
+	if path, _ := cmd.Flags().GetString("session-cache"); path != "" {
+		sessions, _ := filesession.ReadSessionSummaries(path, filesession.WithEncryption(encryption))
+		for _, session := range sessions {
+			if !slices.Contains(args, session.ID) {
+				completions = append(completions, session.ID+"\tsession of "+session.Key.Issuer)
+			}
+		}
+	}
+	if path, _ := cmd.Flags().GetString("credential-cache"); path != "" {
+		credentials, _ := execcredcache.ReadSummaries(path, execcredcache.WithEncryption(encryption))
+		for _, credential := range credentials {
+			if !slices.Contains(args, credential.ID) {
+				completions = append(completions, credential.ID+"\tcluster credential of "+orDash(credential.Username))
+			}
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSessionIssuers completes the issuers of the cached sessions.
+func completeSessionIssuers(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	path, _ := cmd.Flags().GetString("session-cache")
+	if path == "" {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	encryption, _ := cacheEncryption(os.LookupEnv)
+	sessions, _ := filesession.ReadSessionSummaries(path, filesession.WithEncryption(encryption))
+	var issuers []string
+	for _, session := range sessions {
+		if !slices.Contains(issuers, session.Key.Issuer) {
+			issuers = append(issuers, session.Key.Issuer)
+		}
+	}
+	return issuers, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompleteKubeconfigContexts(t *testing.T) {
+	cmd := kubeconfigCommand(kubeconfigRealDeps())
+	require.NoError(t, cmd.Flags().Set("kubeconfig", "./testdata/kubeconfig.yaml"))
+
+	contexts, directive := completeKubeconfigContexts(cmd, nil, "")
+	require.Equal(t, []string{
+		"invalid-context-no-such-cluster",
+		"invalid-context-no-such-user",
+		"kind-context",
+		"some-other-context",
+	}, contexts)
+	require.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+
+	require.NoError(t, cmd.Flags().Set("kubeconfig", "./testdata/does-not-exist.yaml"))
+	contexts, directive = completeKubeconfigContexts(cmd, nil, "")
+	require.Empty(t, contexts)
+	require.Equal(t, cobra.ShellCompDirectiveError, directive)
+}
+
+func TestCompleteSessions(t *testing.T) {
+	now := time.Now()
+	sessionCachePath := filepath.Join(t.TempDir(), "sessions.yaml")
+	credentialCachePath := filepath.Join(t.TempDir(), "credentials.yaml")
+	writeTestSessions(t, sessionCachePath, now)
+	writeTestCredentials(t, credentialCachePath, now)
+	sessionIDs, credentialIDs := testCacheIDs(t, sessionCachePath, credentialCachePath)
+
+	cmd := sessionDeleteCommand()
+	require.NoError(t, cmd.Flags().Set("session-cache", sessionCachePath))
+	require.NoError(t, cmd.Flags().Set("credential-cache", credentialCachePath))
+
+	ids, directive := completeSessionIDs(cmd, nil, "")
+	require.Equal(t, []string{
+		sessionIDs[0] + "\tsession of https://issuer.example.com",
+		sessionIDs[1] + "\tsession of https://other-issuer.example.com",
+		credentialIDs[0] + "\tcluster credential of -",
+	}, ids)
+	require.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+
+	// IDs which were already given are not completed again.
+	ids, _ = completeSessionIDs(cmd, []string{sessionIDs[0], credentialIDs[0]}, "")
+	require.Equal(t, []string{sessionIDs[1] + "\tsession of https://other-issuer.example.com"}, ids)
+
+	issuers, directive := completeSessionIssuers(cmd, nil, "")
+	require.Equal(t, []string{"https://issuer.example.com", "https://other-issuer.example.com"}, issuers)
+	require.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+
+	// Skipped caches are not read.
+	require.NoError(t, cmd.Flags().Set("session-cache", ""))
+	require.NoError(t, cmd.Flags().Set("credential-cache", ""))
+	ids, _ = completeSessionIDs(cmd, nil, "")
+	require.Empty(t, ids)
+	issuers, _ = completeSessionIssuers(cmd, nil, "")
+	require.Empty(t, issuers)
+}
+
+func TestCompleteValues(t *testing.T) {
+	values, directive := completeOutputFormats(nil, nil, "")
+	require.Equal(t, []string{"text", "json", "yaml"}, values)
+	require.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+}
@@ -0,0 +1,563 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/net/http/httpproxy"
+	clientauthenticationv1 "k8s.io/client-go/pkg/apis/clientauthentication/v1"
+	clientauthenticationv1beta1 "k8s.io/client-go/pkg/apis/clientauthentication/v1beta1"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"go.pinniped.dev/internal/here"
+	"go.pinniped.dev/internal/net/phttp"
+)
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(doctorCommand(doctorRealDeps()))
+}
+
+// maxClockSkew is the largest difference between the local clock and the Supervisor's clock which is reported as
+// healthy. Larger differences can cause tokens to be rejected as expired or as not yet valid.
+const maxClockSkew = time.Minute
+
+type doctorDeps struct {
+	lookupEnv func(string) (string, bool)
+	lookPath  func(string) (string, error)
+	now       func() time.Time
+	goos      string
+}
+
+func doctorRealDeps() doctorDeps {
+	return doctorDeps{
+		lookupEnv: os.LookupEnv,
+		lookPath:  exec.LookPath,
+		now:       time.Now,
+		goos:      runtime.GOOS,
+	}
+}
+
+type doctorFlags struct {
+	kubeconfigPath            string
+	kubeconfigContextOverride string
+	timeout                   time.Duration
+	outputFormat              string
+}
+
+// pinnipedLoginConfig is what the doctor learns about the Pinniped login command in the exec plugin config.
+type pinnipedLoginConfig struct {
+	issuer              string
+	caBundlePaths       []string
+	caBundleData        []string
+	caBundleFromCluster bool
+	caBundleSystemRoots bool
+	sessionCachePath    string
+	credentialCachePath string
+
+	conciergeEnabled        bool
+	conciergeAPIGroupSuffix string
+}
+
+func doctorCommand(deps doctorDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "doctor",
+		Short: "Diagnose common problems with logging in to a Pinniped-enabled cluster",
+		Long: here.Doc(
+			`Diagnose common problems with logging in to a Pinniped-enabled cluster
+
+				Inspects the Pinniped login configuration of the current kubeconfig context, probes
+				the OpenID Connect issuer, and checks the local environment (clock, session cache
+				file permissions, and proxy settings). Prints the outcome of each check, along with
+				steps to fix any problems which were found.`,
+		),
+		SilenceUsage: true, // do not print usage message when commands fail
+	}
+	flags := &doctorFlags{}
+
+	f := cmd.Flags()
+	f.StringVar(&flags.kubeconfigPath, "kubeconfig", os.Getenv("KUBECONFIG"), "Path to kubeconfig file")
+	f.StringVar(&flags.kubeconfigContextOverride, "kubeconfig-context", "", "Kubeconfig context name (default: current active context)")
+	f.DurationVar(&flags.timeout, "timeout", 30*time.Second, "Timeout for probing the OpenID Connect issuer")
+	f.StringVarP(&flags.outputFormat, "output", "o", outputFormatText, "Output format (e.g., 'yaml', 'json', 'text')")
+
+	mustRegisterFlagCompletionFunc(cmd, "kubeconfig-context", completeKubeconfigContexts)
+	mustRegisterFlagCompletionFunc(cmd, "output", completeOutputFormats)
+
+	cmd.RunE = func(cmd *cobra.Command, _ []string) error {
+		if err := validateOutputFormat(flags.outputFormat, outputFormatText, outputFormatJSON, outputFormatYAML); err != nil {
+			return err
+		}
+		return runDoctor(cmd.Context(), cmd.OutOrStdout(), deps, flags)
+	}
+	return cmd
+}
+
+func runDoctor(ctx context.Context, out io.Writer, deps doctorDeps, flags *doctorFlags) error {
+	report := &doctorReport{}
+
+	cluster, execConfig := doctorCheckKubeconfig(report, flags)
+	login := doctorCheckExecPlugin(report, deps, execConfig)
+
+	var clusterServer string
+	if cluster != nil {
+		clusterServer = cluster.Server
+	}
+	var issuer string
+	if login != nil {
+		issuer = login.issuer
+	}
+	doctorCheckProxies(report, deps, clusterServer, issuer)
+
+	var issuerTime time.Time
+	if issuer != "" {
+		issuerTime = doctorCheckIssuer(ctx, report, flags.timeout, login, cluster)
+	}
+	doctorCheckClockSkew(report, deps, issuerTime)
+
+	if login != nil {
+		doctorCheckCacheFile(report, deps, "Session cache", login.sessionCachePath)
+		doctorCheckCacheFile(report, deps, "Credential cache", login.credentialCachePath)
+	}
+
+	if err := report.writeAs(out, flags.outputFormat, "DoctorReport"); err != nil {
+		return err
+	}
+	if problems := report.problems(); problems > 0 {
+		return fmt.Errorf("found %d problem(s)", problems)
+	}
+	return nil
+}
+
+// doctorCheckKubeconfig finds the cluster and exec plugin config of the current context, either of which may be nil.
+func doctorCheckKubeconfig(report *doctorReport, flags *doctorFlags) (*clientcmdapi.Cluster, *clientcmdapi.ExecConfig) {
+	const check = "Kubeconfig"
+
+	kubeconfig, err := newClientConfig(flags.kubeconfigPath, flags.kubeconfigContextOverride).RawConfig()
+	if err != nil {
+		report.fail(check, fmt.Sprintf("could not load kubeconfig: %v", err),
+			"set --kubeconfig (or $KUBECONFIG) to the path of the kubeconfig which you use to access the cluster")
+		return nil, nil
+	}
+
+	contextName := kubeconfig.CurrentContext
+	if flags.kubeconfigContextOverride != "" {
+		contextName = flags.kubeconfigContextOverride
+	}
+	kubeContext := kubeconfig.Contexts[contextName]
+	if kubeContext == nil {
+		report.fail(check, fmt.Sprintf("context %q does not exist", contextName),
+			"use --kubeconfig-context, or `kubectl config use-context`, to choose an existing context")
+		return nil, nil
+	}
+
+	cluster := kubeconfig.Clusters[kubeContext.Cluster]
+	if cluster == nil {
+		report.fail(check, fmt.Sprintf("cluster %q of context %q does not exist", kubeContext.Cluster, contextName),
+			"regenerate the kubeconfig with `pinniped get kubeconfig`")
+		return nil, nil
+	}
+
+	authInfo := kubeconfig.AuthInfos[kubeContext.AuthInfo]
+	if authInfo == nil {
+		report.fail(check, fmt.Sprintf("user %q of context %q does not exist", kubeContext.AuthInfo, contextName),
+			"regenerate the kubeconfig with `pinniped get kubeconfig`")
+		return cluster, nil
+	}
+
+	report.pass(check, fmt.Sprintf("using context %q with server %s", contextName, cluster.Server))
+	if authInfo.Exec == nil {
+		report.fail("Exec plugin", fmt.Sprintf("user %q does not use a client-go credential plugin", kubeContext.AuthInfo),
+			"generate a Pinniped kubeconfig with `pinniped get kubeconfig`, or choose a context which uses one")
+	}
+	return cluster, authInfo.Exec
+}
+
+// doctorCheckExecPlugin checks that the exec plugin config runs an installed Pinniped CLI, and returns the
+// configuration of its login command. It returns nil when the config cannot be used.
+func doctorCheckExecPlugin(report *doctorReport, deps doctorDeps, execConfig *clientcmdapi.ExecConfig) *pinnipedLoginConfig {
+	const check = "Exec plugin"
+
+	if execConfig == nil {
+		return nil
+	}
+
+	switch execConfig.APIVersion {
+	case clientauthenticationv1beta1.SchemeGroupVersion.String(), clientauthenticationv1.SchemeGroupVersion.String():
+	default:
+		report.fail(check, fmt.Sprintf("unsupported apiVersion %q", execConfig.APIVersion),
+			"regenerate the kubeconfig with `pinniped get kubeconfig`")
+		return nil
+	}
+
+	path, err := deps.lookPath(execConfig.Command)
+	if err != nil {
+		remediation := "install the Pinniped CLI, see https://pinniped.dev/docs/howto/install-cli/"
+		if execConfig.InstallHint != "" {
+			remediation = execConfig.InstallHint
+		}
+		report.fail(check, fmt.Sprintf("command %q was not found", execConfig.Command), remediation)
+		return nil
+	}
+
+	// Parse the arguments using the flags of the login commands themselves. The values of the arguments are never
+	// printed, since they may include a static token.
+	if len(execConfig.Args) < 2 || execConfig.Args[0] != "login" {
+		report.fail(check, fmt.Sprintf("command %q is not a Pinniped login command", execConfig.Command),
+			"regenerate the kubeconfig with `pinniped get kubeconfig`")
+		return nil
+	}
+	var loginCmd *cobra.Command
+	switch execConfig.Args[1] {
+	case "oidc":
+		loginCmd = oidcLoginCommand(oidcLoginCommandDeps{})
+	case "static":
+		loginCmd = staticLoginCommand(staticLoginDeps{})
+	default:
+		report.fail(check, fmt.Sprintf("unknown login command %q", execConfig.Args[1]),
+			"regenerate the kubeconfig with `pinniped get kubeconfig`, or upgrade the Pinniped CLI")
+		return nil
+	}
+	loginFlags := loginCmd.Flags()
+	if err := loginFlags.Parse(execConfig.Args[2:]); err != nil {
+		report.fail(check, fmt.Sprintf("invalid arguments for `pinniped login %s`: %v", execConfig.Args[1], err),
+			"regenerate the kubeconfig with `pinniped get kubeconfig` using the same version of the Pinniped CLI, or upgrade the Pinniped CLI")
+		return nil
+	}
+
+	login := &pinnipedLoginConfig{}
+	login.credentialCachePath, _ = loginFlags.GetString("credential-cache")
+	if execConfig.Args[1] == "oidc" {
+		login.issuer, _ = loginFlags.GetString("issuer")
+		login.sessionCachePath, _ = loginFlags.GetString("session-cache")
+		login.caBundlePaths, _ = loginFlags.GetStringSlice("ca-bundle")
+		login.caBundleData, _ = loginFlags.GetStringSlice("ca-bundle-data")
+		login.caBundleFromCluster, _ = loginFlags.GetBool("ca-bundle-from-kubeconfig")
+		login.caBundleSystemRoots, _ = loginFlags.GetBool("ca-bundle-include-system-roots")
+	}
+	login.conciergeEnabled, _ = loginFlags.GetBool("enable-concierge")
+	login.conciergeAPIGroupSuffix, _ = loginFlags.GetString("concierge-api-group-suffix")
+
+	report.pass(check, fmt.Sprintf("runs `pinniped login %s` using %s", execConfig.Args[1], path))
+	return login
+}
+
+// doctorCheckProxies reports conflicting proxy environment variables, and which proxy is used to reach the cluster
+// and the issuer. Proxy URLs are redacted since they may include a password.
+func doctorCheckProxies(report *doctorReport, deps doctorDeps, clusterServer string, issuer string) {
+	const check = "Proxy"
+
+	getenv := func(name string) string {
+		value, _ := deps.lookupEnv(name)
+		return value
+	}
+
+	conflicts := false
+	for _, name := range []string{"HTTPS_PROXY", "HTTP_PROXY", "NO_PROXY"} {
+		upper, lower := getenv(name), getenv(strings.ToLower(name))
+		if upper != "" && lower != "" && upper != lower {
+			conflicts = true
+			report.warn(check, fmt.Sprintf("%s is %q but %s is %q", name, redactProxy(upper), strings.ToLower(name), redactProxy(lower)),
+				fmt.Sprintf("unset one of them, since some programs use %s and others use %s", name, strings.ToLower(name)))
+		}
+	}
+
+	// This is the same precedence which is used by Go programs, including kubectl and the Pinniped CLI.
+	firstOf := func(names ...string) string {
+		for _, name := range names {
+			if value := getenv(name); value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+	proxyFunc := (&httpproxy.Config{
+		HTTPProxy:  firstOf("HTTP_PROXY", "http_proxy"),
+		HTTPSProxy: firstOf("HTTPS_PROXY", "https_proxy"),
+		NoProxy:    firstOf("NO_PROXY", "no_proxy"),
+	}).ProxyFunc()
+
+	var details []string
+	for _, target := range []struct{ name, url string }{{"the cluster", clusterServer}, {"the OIDC issuer", issuer}} {
+		u, err := url.Parse(target.url)
+		if target.url == "" || err != nil {
+			continue
+		}
+		proxyURL, err := proxyFunc(u)
+		switch {
+		case err != nil:
+			report.fail(check, fmt.Sprintf("invalid proxy for %s: %v", target.name, err),
+				"fix the HTTPS_PROXY and HTTP_PROXY environment variables")
+			return
+		case proxyURL != nil:
+			details = append(details, fmt.Sprintf("%s is reached through proxy %s", target.name, proxyURL.Redacted()))
+		default:
+			details = append(details, fmt.Sprintf("%s is reached directly", target.name))
+		}
+	}
+
+	if !conflicts && len(details) > 0 {
+		report.pass(check, strings.Join(details, ", "))
+	}
+}
+
+func redactProxy(proxy string) string {
+	u, err := url.Parse(proxy)
+	if err != nil || u.User == nil {
+		return proxy
+	}
+	return u.Redacted()
+}
+
+// doctorIssuerClient returns an HTTP client which trusts the same CA bundles as the login command.
+func doctorIssuerClient(login *pinnipedLoginConfig, cluster *clientcmdapi.Cluster) (*http.Client, error) {
+	pemBundles, err := readCABundles(login.caBundlePaths, login.caBundleData)
+	if err != nil {
+		return nil, err
+	}
+	if login.caBundleFromCluster {
+		if cluster == nil || len(cluster.CertificateAuthorityData) == 0 {
+			return nil, fmt.Errorf("could not read --ca-bundle-from-kubeconfig: the cluster in the kubeconfig has no certificate-authority-data")
+		}
+		pemBundles = append(pemBundles, cluster.CertificateAuthorityData)
+	}
+	if len(pemBundles) == 0 {
+		return phttp.Default(nil), nil
+	}
+	return makeClient(login.caBundleSystemRoots, pemBundles)
+}
+
+// doctorCheckIssuer probes the discovery endpoint of the OIDC issuer. It returns the time of the issuer's clock,
+// or the zero time when it could not be determined.
+func doctorCheckIssuer(ctx context.Context, report *doctorReport, timeout time.Duration, login *pinnipedLoginConfig, cluster *clientcmdapi.Cluster) time.Time {
+	const check = "OIDC issuer"
+
+	httpClient, err := doctorIssuerClient(login, cluster)
+	if err != nil {
+		report.fail(check, err.Error(), "fix the --ca-bundle, --ca-bundle-data, and --ca-bundle-from-kubeconfig arguments of the login command, or regenerate the kubeconfig with `pinniped get kubeconfig`")
+		return time.Time{}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	discoveryURL := strings.TrimSuffix(login.issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		report.fail(check, fmt.Sprintf("invalid issuer %q: %v", login.issuer, err), "regenerate the kubeconfig with `pinniped get kubeconfig`")
+		return time.Time{}
+	}
+
+	resp, err := httpClient.Do(req)
+	switch {
+	case err != nil && isCertificateVerificationError(err):
+		report.fail(check, err.Error(),
+			"the issuer's certificate is not trusted by the login command's CA bundle; regenerate the kubeconfig with `pinniped get kubeconfig`, or ask your administrator whether the issuer's certificate was changed")
+		return time.Time{}
+	case err != nil:
+		report.fail(check, err.Error(),
+			"check that this machine can reach the issuer, including through any VPN, proxy, or firewall")
+		return time.Time{}
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	issuerTime, _ := http.ParseTime(resp.Header.Get("Date"))
+
+	if resp.StatusCode != http.StatusOK {
+		report.fail(check, fmt.Sprintf("discovery endpoint %s returned status %d", discoveryURL, resp.StatusCode),
+			"check that the issuer URL is correct and that the FederationDomain is ready, using `kubectl get federationdomains`")
+		return issuerTime
+	}
+
+	var discovery struct {
+		Issuer string `json:"issuer"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&discovery); err != nil || discovery.Issuer != login.issuer {
+		report.fail(check, fmt.Sprintf("discovery endpoint %s did not describe issuer %q", discoveryURL, login.issuer),
+			"check that the issuer URL is correct, including its path, and that nothing between this machine and the issuer is modifying its responses")
+		return issuerTime
+	}
+
+	report.pass(check, fmt.Sprintf("discovered %s", login.issuer))
+	return issuerTime
+}
+
+func doctorCheckClockSkew(report *doctorReport, deps doctorDeps, issuerTime time.Time) {
+	const check = "Clock"
+
+	if issuerTime.IsZero() {
+		report.skip(check, "could not compare the local clock with the OIDC issuer's clock")
+		return
+	}
+
+	// The Date header only has a resolution of one second.
+	skew := deps.now().Truncate(time.Second).Sub(issuerTime)
+	direction := "ahead of"
+	if skew < 0 {
+		skew, direction = -skew, "behind"
+	}
+	if skew > maxClockSkew {
+		report.fail(check, fmt.Sprintf("the local clock is %s %s the OIDC issuer's clock", skew, direction),
+			"synchronize the local clock, e.g. by enabling NTP, since tokens may otherwise be rejected as expired or not yet valid")
+		return
+	}
+	report.pass(check, fmt.Sprintf("the local clock is within %s of the OIDC issuer's clock", maxClockSkew))
+}
+
+// doctorCheckCacheFile checks that a cache file of the login command can be written, and that it is not accessible
+// by other users, since it contains tokens.
+func doctorCheckCacheFile(report *doctorReport, deps doctorDeps, check string, path string) {
+	switch path {
+	case "":
+		report.skip(check, "disabled")
+		return
+	case cacheInMemory:
+		report.pass(check, "kept in memory only")
+		return
+	}
+
+	info, err := os.Stat(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		report.pass(check, fmt.Sprintf("%s will be created during the next login", path))
+		return
+	case err != nil:
+		report.fail(check, err.Error(), fmt.Sprintf("fix the permissions of the directories containing %s", path))
+		return
+	case !info.Mode().IsRegular():
+		report.fail(check, fmt.Sprintf("%s is not a regular file", path), fmt.Sprintf("remove %s", path))
+		return
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		report.fail(check, fmt.Sprintf("%s cannot be written: %v", path, err),
+			fmt.Sprintf("make sure that you own %s, e.g. by deleting it so that it is recreated during the next login", path))
+		return
+	}
+	_ = f.Close()
+
+	// File modes are not meaningful on Windows.
+	if deps.goos != "windows" && info.Mode().Perm()&0o077 != 0 {
+		report.warn(check, fmt.Sprintf("%s can be accessed by other users (mode %s)", path, info.Mode().Perm()),
+			fmt.Sprintf("run `chmod 600 %s`", filepath.Clean(path)))
+		return
+	}
+	report.pass(check, fmt.Sprintf("%s is only accessible by its owner", path))
+}
+
+type doctorReport struct {
+	checks []doctorCheck
+}
+
+type doctorCheck struct {
+	status      string
+	name        string
+	detail      string
+	remediation string
+}
+
+const (
+	doctorStatusPass = "PASS"
+	doctorStatusWarn = "WARN"
+	doctorStatusFail = "FAIL"
+	doctorStatusSkip = "SKIP"
+)
+
+func (r *doctorReport) pass(name, detail string) {
+	r.checks = append(r.checks, doctorCheck{status: doctorStatusPass, name: name, detail: detail})
+}
+
+func (r *doctorReport) skip(name, detail string) {
+	r.checks = append(r.checks, doctorCheck{status: doctorStatusSkip, name: name, detail: detail})
+}
+
+func (r *doctorReport) warn(name, detail, remediation string) {
+	r.checks = append(r.checks, doctorCheck{status: doctorStatusWarn, name: name, detail: detail, remediation: remediation})
+}
+
+func (r *doctorReport) fail(name, detail, remediation string) {
+	r.checks = append(r.checks, doctorCheck{status: doctorStatusFail, name: name, detail: detail, remediation: remediation})
+}
+
+func (r *doctorReport) problems() int {
+	problems := 0
+	for _, check := range r.checks {
+		if check.status == doctorStatusFail {
+			problems++
+		}
+	}
+	return problems
+}
+
+// doctorReportOutput is the schema of the JSON and YAML output of a doctorReport.
+type doctorReportOutput struct {
+	outputTypeMeta `json:",inline"`
+	Checks         []doctorCheckOutput `json:"checks"`
+	Problems       int                 `json:"problems"`
+}
+
+type doctorCheckOutput struct {
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	Detail      string `json:"detail"`
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// writeAs prints the report as text, or as a JSON or YAML document of the given kind.
+func (r *doctorReport) writeAs(out io.Writer, format string, kind string) error {
+	if format == outputFormatText {
+		return r.write(out)
+	}
+	output := doctorReportOutput{
+		outputTypeMeta: newOutputTypeMeta(kind),
+		Checks:         make([]doctorCheckOutput, 0, len(r.checks)),
+		Problems:       r.problems(),
+	}
+	for _, check := range r.checks {
+		output.Checks = append(output.Checks, doctorCheckOutput{
+			Name:        check.name,
+			Status:      check.status,
+			Detail:      check.detail,
+			Remediation: check.remediation,
+		})
+	}
+	return writeStructuredOutput(out, format, output)
+}
+
+func (r *doctorReport) write(out io.Writer) error {
+	var b strings.Builder
+	for _, check := range r.checks {
+		fmt.Fprintf(&b, "[%s] %s: %s\n", check.status, check.name, check.detail)
+		if check.remediation != "" {
+			fmt.Fprintf(&b, "       To fix: %s\n", check.remediation)
+		}
+	}
+	if problems := r.problems(); problems > 0 {
+		fmt.Fprintf(&b, "Found %d problem(s).\n", problems)
+	} else {
+		fmt.Fprintf(&b, "No problems found.\n")
+	}
+
+	if _, err := io.WriteString(out, b.String()); err != nil {
+		return fmt.Errorf("could not write output: %w", err)
+	}
+	return nil
+}
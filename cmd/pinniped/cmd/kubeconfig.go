@@ -4,14 +4,18 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"os"
+	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
@@ -20,10 +24,14 @@ import (
 	coreosoidc "github.com/coreos/go-oidc/v3/oidc"
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
 	clientauthenticationv1beta1 "k8s.io/client-go/pkg/apis/clientauthentication/v1beta1"
 	_ "k8s.io/client-go/plugin/pkg/client/auth" // Adds handlers for various dynamic auth plugins in client-go
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	clientcmdlatest "k8s.io/client-go/tools/clientcmd/api/latest"
+	"sigs.k8s.io/yaml"
 
 	authenticationv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/authentication/v1alpha1"
 	conciergeconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/config/v1alpha1"
@@ -33,19 +41,22 @@ import (
 	"go.pinniped.dev/internal/groupsuffix"
 	"go.pinniped.dev/internal/net/phttp"
 	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/pkg/oidcclient/idpdiscovery"
 )
 
 type kubeconfigDeps struct {
-	getPathToSelf func() (string, error)
-	getClientset  getConciergeClientsetFunc
-	log           plog.MinLogger
+	getPathToSelf   func() (string, error)
+	getClientset    getConciergeClientsetFunc
+	log             plog.MinLogger
+	promptForChoice promptForChoiceFunc
 }
 
 func kubeconfigRealDeps() kubeconfigDeps {
 	return kubeconfigDeps{
-		getPathToSelf: os.Executable,
-		getClientset:  getRealConciergeClientset,
-		log:           plog.New(),
+		getPathToSelf:   os.Executable,
+		getClientset:    getRealConciergeClientset,
+		log:             plog.New(),
+		promptForChoice: promptForChoice,
 	}
 }
 
@@ -55,19 +66,40 @@ func init() {
 }
 
 type getKubeconfigOIDCParams struct {
-	issuer            string
-	clientID          string
-	listenPort        uint16
-	scopes            []string
-	skipBrowser       bool
-	skipListen        bool
-	sessionCachePath  string
-	debugSessionCache bool
-	caBundle          caBundleFlag
-	requestAudience   string
-	upstreamIDPName   string
-	upstreamIDPType   string
-	upstreamIDPFlow   string
+	issuer                 string
+	clientID               string
+	listenPort             uint16
+	scopes                 []string
+	skipBrowser            bool
+	skipListen             bool
+	sessionCachePath       string
+	debugSessionCache      bool
+	caBundle               caBundleFlag
+	caBundleFromKubeconfig bool
+	caBundleSystemRoots    bool
+	requestAudience        string
+	enableDPoP             bool
+	upstreamIDPName        string
+	upstreamIDPType        string
+	upstreamIDPFlow        string
+
+	// clusterAudiences are discovered from the Supervisor rather than set by a flag.
+	clusterAudiences []idpdiscoveryv1alpha1.PinnipedClusterAudience
+
+	// kubeconfigCABundle is the CA bundle of the cluster in the generated kubeconfig, when caBundleFromKubeconfig is true.
+	kubeconfigCABundle []byte
+}
+
+// issuerCABundle returns all the CA bundles which the login command will trust when connecting to the issuer,
+// or nil when it will only trust the system's roots.
+func (p *getKubeconfigOIDCParams) issuerCABundle() caBundleFlag {
+	if len(p.kubeconfigCABundle) == 0 {
+		return p.caBundle
+	}
+	if len(p.caBundle) == 0 {
+		return p.kubeconfigCABundle
+	}
+	return bytes.Join([][]byte{p.caBundle, p.kubeconfigCABundle}, []byte("\n"))
 }
 
 type getKubeconfigConciergeParams struct {
@@ -85,9 +117,13 @@ type getKubeconfigConciergeParams struct {
 type getKubeconfigParams struct {
 	kubeconfigPath            string
 	kubeconfigContextOverride string
+	kubeconfigContexts        []string
+	fleetFilePath             string
 	skipValidate              bool
+	validate                  bool
 	timeout                   time.Duration
 	outputPath                string
+	outputFormat              string
 	staticToken               string
 	staticTokenEnvName        string
 	oidc                      getKubeconfigOIDCParams
@@ -99,6 +135,33 @@ type getKubeconfigParams struct {
 	pinnipedCliPath           string
 }
 
+// fleetInventory is the format of the file passed to --fleet-file.
+type fleetInventory struct {
+	Clusters []fleetInventoryCluster `json:"clusters"`
+}
+
+type fleetInventoryCluster struct {
+	// Name is used to name the generated cluster, context, and user entries. Defaults to the context name.
+	Name string `json:"name,omitempty"`
+	// Kubeconfig is the path to the kubeconfig of the cluster, relative to the fleet file. Defaults to --kubeconfig.
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+	// Context is the name of the context in the kubeconfig. Defaults to its current context.
+	Context string `json:"context,omitempty"`
+}
+
+// kubeconfigTarget is a cluster for which a kubeconfig will be generated.
+type kubeconfigTarget struct {
+	// name is used to name the generated entries when generating a kubeconfig for multiple clusters,
+	// and is empty when generating a kubeconfig for a single cluster.
+	name           string
+	kubeconfigPath string
+	contextName    string
+}
+
+// supervisorDiscoveryCache remembers the results of Supervisor discovery by issuer and CA bundle, so that discovery
+// is only performed once per Supervisor when generating a kubeconfig for multiple clusters.
+type supervisorDiscoveryCache map[string]getKubeconfigOIDCParams
+
 type discoveryResponseScopesSupported struct {
 	// Same as ScopesSupported in the Supervisor's discovery handler's struct.
 	ScopesSupported []string `json:"scopes_supported"`
@@ -140,8 +203,11 @@ func kubeconfigCommand(deps kubeconfigDeps) *cobra.Command {
 	f.BoolVar(&flags.oidc.skipListen, "oidc-skip-listen", false, "During OpenID Connect login, skip starting a localhost callback listener (manual copy/paste flow only)")
 	f.StringVar(&flags.oidc.sessionCachePath, "oidc-session-cache", "", "Path to OpenID Connect session cache file")
 	f.Var(&flags.oidc.caBundle, "oidc-ca-bundle", "Path to TLS certificate authority bundle (PEM format, optional, can be repeated)")
+	f.BoolVar(&flags.oidc.caBundleFromKubeconfig, "oidc-ca-bundle-from-kubeconfig", false, "Also trust the certificate authority data of the cluster in the generated kubeconfig when connecting to the OpenID Connect issuer, e.g. when the Supervisor and the cluster share a private CA")
+	f.BoolVar(&flags.oidc.caBundleSystemRoots, "oidc-ca-bundle-include-system-roots", false, "Also trust the system's roots when connecting to the OpenID Connect issuer, in addition to the CA bundles")
 	f.BoolVar(&flags.oidc.debugSessionCache, "oidc-debug-session-cache", false, "Print debug logs related to the OpenID Connect session cache")
 	f.StringVar(&flags.oidc.requestAudience, "oidc-request-audience", "", "Request a token with an alternate audience using RFC8693 token exchange")
+	f.BoolVar(&flags.oidc.enableDPoP, "oidc-enable-dpop", false, "Request DPoP-bound tokens (RFC9449) from the OpenID Connect issuer")
 	f.StringVar(&flags.oidc.upstreamIDPName, "upstream-identity-provider-name", "", "The name of the upstream identity provider used during login with a Supervisor")
 	f.StringVar(
 		&flags.oidc.upstreamIDPType,
@@ -158,9 +224,13 @@ func kubeconfigCommand(deps kubeconfigDeps) *cobra.Command {
 	f.StringVar(&flags.oidc.upstreamIDPFlow, "upstream-identity-provider-flow", "", fmt.Sprintf("The type of client flow to use with the upstream identity provider during login with a Supervisor (e.g. '%s', '%s')", idpdiscoveryv1alpha1.IDPFlowCLIPassword, idpdiscoveryv1alpha1.IDPFlowBrowserAuthcode))
 	f.StringVar(&flags.kubeconfigPath, "kubeconfig", os.Getenv("KUBECONFIG"), "Path to kubeconfig file")
 	f.StringVar(&flags.kubeconfigContextOverride, "kubeconfig-context", "", "Kubeconfig context name (default: current active context)")
+	f.StringSliceVar(&flags.kubeconfigContexts, "kubeconfig-contexts", nil, "Kubeconfig context names of multiple clusters for which to generate a single kubeconfig (can be repeated)")
+	f.StringVar(&flags.fleetFilePath, "fleet-file", "", "Path to a fleet inventory file which lists multiple clusters for which to generate a single kubeconfig")
 	f.BoolVar(&flags.skipValidate, "skip-validation", false, "Skip final validation of the kubeconfig (default: false)")
+	f.BoolVar(&flags.validate, "validate", false, "Instead of printing a kubeconfig, perform a dry run of autodiscovery and validation and print a diagnostic report (default: false)")
 	f.DurationVar(&flags.timeout, "timeout", 10*time.Minute, "Timeout for autodiscovery and validation")
 	f.StringVarP(&flags.outputPath, "output", "o", "", "Output file path (default: stdout)")
+	f.StringVar(&flags.outputFormat, "output-format", "", "Output format, one of 'yaml' or 'json' (default: YAML for the kubeconfig, and text for the report of --validate)")
 	f.StringVar(&flags.generatedNameSuffix, "generated-name-suffix", "-pinniped", "Suffix to append to generated cluster, context, user kubeconfig entries")
 	f.StringVar(&flags.credentialCachePath, "credential-cache", "", "Path to cluster-specific credentials cache")
 	f.StringVar(&flags.pinnipedCliPath, "pinniped-cli-path", "", "Full path or executable name for the Pinniped CLI binary to be embedded in the resulting kubeconfig output (e.g. 'pinniped') (default: full path of the binary used to execute this command)")
@@ -174,6 +244,14 @@ func kubeconfigCommand(deps kubeconfigDeps) *cobra.Command {
 
 	mustMarkDeprecated(cmd, "concierge-namespace", "not needed anymore")
 
+	mustRegisterFlagCompletionFunc(cmd, "concierge-authenticator-type", completeAuthenticatorTypes)
+	mustRegisterFlagCompletionFunc(cmd, "concierge-mode", completeConciergeModes)
+	mustRegisterFlagCompletionFunc(cmd, "upstream-identity-provider-type", completeUpstreamIDPTypes)
+	mustRegisterFlagCompletionFunc(cmd, "upstream-identity-provider-flow", completeUpstreamIDPFlows)
+	mustRegisterFlagCompletionFunc(cmd, "kubeconfig-context", completeKubeconfigContexts)
+	mustRegisterFlagCompletionFunc(cmd, "kubeconfig-contexts", completeKubeconfigContexts)
+	mustRegisterFlagCompletionFunc(cmd, "output-format", completeValues(outputFormatYAML, outputFormatJSON))
+
 	cmd.RunE = func(cmd *cobra.Command, _args []string) error {
 		if flags.outputPath != "" {
 			out, err := os.Create(flags.outputPath)
@@ -203,19 +281,185 @@ func runGetKubeconfig(ctx context.Context, out io.Writer, deps kubeconfigDeps, f
 		return fmt.Errorf("invalid API group suffix: %w", err)
 	}
 
-	clientConfig := newClientConfig(flags.kubeconfigPath, flags.kubeconfigContextOverride)
+	if flags.validate && flags.skipValidate {
+		return fmt.Errorf("--validate and --skip-validation cannot be used together")
+	}
+
+	if err := validateOutputFormat(flags.outputFormat, "", outputFormatYAML, outputFormatJSON); err != nil {
+		return err
+	}
+
+	targets, err := getKubeconfigTargets(flags)
+	if err != nil {
+		return err
+	}
+
+	if flags.validate {
+		return runKubeconfigValidation(ctx, out, deps, flags, targets)
+	}
+
+	// When there is only one cluster, then generate the kubeconfig for it exactly as usual.
+	if len(targets) == 1 && targets[0].name == "" {
+		kubeconfig, err := generateKubeconfig(ctx, deps, flags, targets[0], supervisorDiscoveryCache{}, nil)
+		if err != nil {
+			return err
+		}
+		return writeConfig(out, flags.outputFormat, *kubeconfig)
+	}
+
+	// Otherwise, generate a kubeconfig for each cluster and merge them. The current context of the merged
+	// kubeconfig is the context of the first cluster.
+	discoveryCache := supervisorDiscoveryCache{}
+	merged := clientcmdapi.Config{
+		Kind:       "Config",
+		APIVersion: clientcmdapi.SchemeGroupVersion.Version,
+		Clusters:   map[string]*clientcmdapi.Cluster{},
+		AuthInfos:  map[string]*clientcmdapi.AuthInfo{},
+		Contexts:   map[string]*clientcmdapi.Context{},
+	}
+	for _, target := range targets {
+		deps.log.Info("generating kubeconfig for cluster", "name", target.name)
+		kubeconfig, err := generateKubeconfig(ctx, deps, flags, target, discoveryCache, nil)
+		if err != nil {
+			return fmt.Errorf("could not generate kubeconfig for cluster %q: %w", target.name, err)
+		}
+		if _, exists := merged.Contexts[kubeconfig.CurrentContext]; exists {
+			return fmt.Errorf("more than one cluster is named %q", target.name)
+		}
+		maps.Copy(merged.Clusters, kubeconfig.Clusters)
+		maps.Copy(merged.AuthInfos, kubeconfig.AuthInfos)
+		maps.Copy(merged.Contexts, kubeconfig.Contexts)
+		if merged.CurrentContext == "" {
+			merged.CurrentContext = kubeconfig.CurrentContext
+		}
+	}
+
+	return writeConfig(out, flags.outputFormat, merged)
+}
+
+// runKubeconfigValidation performs all the steps of generating a kubeconfig for each cluster, and then prints a
+// diagnostic report for each cluster instead of printing the kubeconfig. The report describes the first step which
+// failed and how to fix it, so that problems are found before the kubeconfig is handed to users.
+func runKubeconfigValidation(ctx context.Context, out io.Writer, deps kubeconfigDeps, flags getKubeconfigParams, targets []kubeconfigTarget) error {
+	discoveryCache := supervisorDiscoveryCache{}
+	failed := false
+	output := kubeconfigValidationOutput{outputTypeMeta: newOutputTypeMeta("KubeconfigValidation")}
+	for _, target := range targets {
+		diagnostics := &kubeconfigDiagnostics{}
+		_, err := generateKubeconfig(ctx, deps, flags, target, discoveryCache, diagnostics)
+		diagnostics.finish(err)
+		if flags.outputFormat != "" {
+			output.Clusters = append(output.Clusters, diagnostics.output(target.name))
+		} else if err := diagnostics.writeReport(out, target.name); err != nil {
+			return err
+		}
+		failed = failed || diagnostics.failed()
+	}
+	if flags.outputFormat != "" {
+		if err := writeStructuredOutput(out, flags.outputFormat, output); err != nil {
+			return err
+		}
+	}
+	if failed {
+		return fmt.Errorf("kubeconfig validation failed")
+	}
+	return nil
+}
+
+// getKubeconfigTargets returns the clusters chosen by the --kubeconfig-context, --kubeconfig-contexts,
+// and --fleet-file flags.
+func getKubeconfigTargets(flags getKubeconfigParams) ([]kubeconfigTarget, error) {
+	multiClusterFlags := 0
+	for _, set := range []bool{flags.kubeconfigContextOverride != "", len(flags.kubeconfigContexts) > 0, flags.fleetFilePath != ""} {
+		if set {
+			multiClusterFlags++
+		}
+	}
+	if multiClusterFlags > 1 {
+		return nil, fmt.Errorf("only one of --kubeconfig-context, --kubeconfig-contexts, and --fleet-file can be specified")
+	}
+
+	switch {
+	case len(flags.kubeconfigContexts) > 0:
+		targets := make([]kubeconfigTarget, 0, len(flags.kubeconfigContexts))
+		for _, contextName := range flags.kubeconfigContexts {
+			targets = append(targets, kubeconfigTarget{name: contextName, kubeconfigPath: flags.kubeconfigPath, contextName: contextName})
+		}
+		return targets, nil
+	case flags.fleetFilePath != "":
+		return readFleetFile(flags.fleetFilePath, flags.kubeconfigPath)
+	default:
+		return []kubeconfigTarget{{kubeconfigPath: flags.kubeconfigPath, contextName: flags.kubeconfigContextOverride}}, nil
+	}
+}
+
+func readFleetFile(path string, defaultKubeconfigPath string) ([]kubeconfigTarget, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read --fleet-file: %w", err)
+	}
+
+	var inventory fleetInventory
+	if err := yaml.UnmarshalStrict(data, &inventory); err != nil {
+		return nil, fmt.Errorf("could not parse --fleet-file: %w", err)
+	}
+	if len(inventory.Clusters) == 0 {
+		return nil, fmt.Errorf("--fleet-file does not list any clusters")
+	}
+
+	targets := make([]kubeconfigTarget, 0, len(inventory.Clusters))
+	for i, cluster := range inventory.Clusters {
+		target := kubeconfigTarget{name: cluster.Name, kubeconfigPath: defaultKubeconfigPath, contextName: cluster.Context}
+		if cluster.Kubeconfig != "" {
+			target.kubeconfigPath = cluster.Kubeconfig
+			if !filepath.IsAbs(target.kubeconfigPath) {
+				target.kubeconfigPath = filepath.Join(filepath.Dir(path), target.kubeconfigPath)
+			}
+		}
+		if target.name == "" {
+			target.name = cluster.Context
+		}
+		if target.name == "" {
+			return nil, fmt.Errorf("--fleet-file cluster %d must specify a name or a context", i)
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// generateKubeconfig generates a kubeconfig for one cluster. The flags are passed by value so that any
+// autodiscovered values only apply to this cluster. The outcome of each step is recorded in the diagnostics,
+// which may be nil.
+func generateKubeconfig(
+	ctx context.Context,
+	deps kubeconfigDeps,
+	flags getKubeconfigParams,
+	target kubeconfigTarget,
+	discoveryCache supervisorDiscoveryCache,
+	diagnostics *kubeconfigDiagnostics,
+) (*clientcmdapi.Config, error) {
+	// Autodiscovery may remove items from the scopes, so do not share them with other clusters.
+	flags.oidc.scopes = slices.Clone(flags.oidc.scopes)
+	flags.kubeconfigContextOverride = target.contextName
+
+	clientConfig := newClientConfig(target.kubeconfigPath, target.contextName)
 	currentKubeConfig, err := clientConfig.RawConfig()
 	if err != nil {
-		return fmt.Errorf("could not load --kubeconfig: %w", err)
+		return nil, diagnostics.fail(checkKubeconfig, fmt.Errorf("could not load --kubeconfig: %w", err),
+			"check that --kubeconfig (or $KUBECONFIG) is the path of a valid kubeconfig file")
 	}
 	currentKubeconfigNames, err := getCurrentContext(currentKubeConfig, flags)
 	if err != nil {
-		return fmt.Errorf("could not load --kubeconfig/--kubeconfig-context: %w", err)
+		return nil, diagnostics.fail(checkKubeconfig, fmt.Errorf("could not load --kubeconfig/--kubeconfig-context: %w", err),
+			"check that the context exists in the kubeconfig and refers to an existing cluster and user")
 	}
 	cluster := currentKubeConfig.Clusters[currentKubeconfigNames.ClusterName]
+	diagnostics.pass(checkKubeconfig, "using context %q with server %s", currentKubeconfigNames.ContextName, cluster.Server)
+	// Remember the server of the cluster before it may be replaced by the Concierge endpoint below.
+	clusterServer := cluster.Server
 	clientset, err := deps.getClientset(clientConfig, flags.concierge.apiGroupSuffix)
 	if err != nil {
-		return fmt.Errorf("could not configure Kubernetes client: %w", err)
+		return nil, fmt.Errorf("could not configure Kubernetes client: %w", err)
 	}
 
 	// Generate the new context/cluster/user names by appending the --generated-name-suffix to the original values.
@@ -224,11 +468,21 @@ func runGetKubeconfig(ctx context.Context, out io.Writer, deps kubeconfigDeps, f
 		UserName:    currentKubeconfigNames.UserName + flags.generatedNameSuffix,
 		ClusterName: currentKubeconfigNames.ClusterName + flags.generatedNameSuffix,
 	}
+	// When generating a kubeconfig for multiple clusters, the original cluster and user names are often
+	// the same for every cluster (e.g. "kubernetes-admin"), so name all the entries after the cluster instead.
+	if target.name != "" {
+		newKubeconfigNames = &kubeconfigNames{
+			ContextName: target.name + flags.generatedNameSuffix,
+			UserName:    target.name + flags.generatedNameSuffix,
+			ClusterName: target.name + flags.generatedNameSuffix,
+		}
+	}
 
 	if !flags.concierge.disabled {
 		credentialIssuer, err := waitForCredentialIssuer(ctx, clientset, flags, deps)
 		if err != nil {
-			return err
+			return nil, diagnostics.fail(checkConciergeStrategy, err,
+				"check that the Concierge is installed on the cluster and that --concierge-api-group-suffix and --concierge-credential-issuer are correct, or use --no-concierge")
 		}
 
 		authenticator, err := lookupAuthenticator(
@@ -238,14 +492,20 @@ func runGetKubeconfig(ctx context.Context, out io.Writer, deps kubeconfigDeps, f
 			deps.log,
 		)
 		if err != nil {
-			return err
+			return nil, diagnostics.fail(checkConciergeAuthenticator, err,
+				"create a JWTAuthenticator or WebhookAuthenticator, or use --concierge-authenticator-type and --concierge-authenticator-name to choose one")
 		}
 		if err := discoverConciergeParams(credentialIssuer, &flags, cluster, deps.log); err != nil {
-			return err
+			return nil, diagnostics.fail(checkConciergeStrategy, err,
+				fmt.Sprintf("none of the usable strategies of CredentialIssuer %q are healthy, see its status for the reason", credentialIssuer.Name))
 		}
+		diagnostics.pass(checkConciergeStrategy, "using %s mode at %s", flags.concierge.mode.String(), flags.concierge.endpoint)
+
 		if err := discoverAuthenticatorParams(authenticator, &flags, deps.log); err != nil {
-			return err
+			return nil, diagnostics.fail(checkConciergeAuthenticator, err,
+				"fix the authenticator's spec, or set the corresponding --oidc-* flags explicitly")
 		}
+		diagnostics.pass(checkConciergeAuthenticator, "using %s authenticator %q", flags.concierge.authenticatorType, flags.concierge.authenticatorName)
 
 		// Point kubectl at the concierge endpoint.
 		cluster.Server = flags.concierge.endpoint
@@ -253,23 +513,91 @@ func runGetKubeconfig(ctx context.Context, out io.Writer, deps kubeconfigDeps, f
 	}
 
 	if len(flags.oidc.issuer) > 0 {
-		err = pinnipedSupervisorDiscovery(ctx, &flags, deps.log)
-		if err != nil {
-			return err
+		if flags.oidc.caBundleFromKubeconfig {
+			if len(cluster.CertificateAuthorityData) == 0 {
+				return nil, diagnostics.fail(checkOIDCCABundle,
+					fmt.Errorf("could not use --oidc-ca-bundle-from-kubeconfig: the cluster in the kubeconfig has no certificate-authority-data"),
+					"embed the cluster's CA bundle in the kubeconfig, or use --oidc-ca-bundle instead")
+			}
+			flags.oidc.kubeconfigCABundle = cluster.CertificateAuthorityData
+		}
+		err = cachedPinnipedSupervisorDiscovery(ctx, &flags, deps, discoveryCache)
+		if err := diagnoseOIDCIssuer(diagnostics, flags, err); err != nil {
+			return nil, err
+		}
+		if flags.oidc.requestAudience == "" {
+			selectClusterAudience(&flags, clusterServer, deps.log)
+		}
+		if diagnostics != nil && flags.oidc.requestAudience != "" {
+			if err := checkRequestAudience(flags.oidc.requestAudience, flags.oidc.scopes); err != nil {
+				return nil, diagnostics.fail(checkOIDCAudience, err,
+					"set spec.audience of the JWTAuthenticator (or --oidc-request-audience) to a unique value for this cluster, and include the required scope in --oidc-scopes")
+			}
+			diagnostics.pass(checkOIDCAudience, "requesting tokens for audience %q", flags.oidc.requestAudience)
 		}
 	}
 
 	execConfig, err := newExecConfig(deps, flags)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	kubeconfig := newExecKubeconfig(cluster, execConfig, newKubeconfigNames)
 	if err := validateKubeconfig(ctx, flags, kubeconfig, deps.log); err != nil {
+		return nil, diagnostics.fail(checkClusterConnection, err,
+			"check that the cluster's endpoint is reachable from this machine and that its certificate authority data is correct")
+	}
+	diagnostics.pass(checkClusterConnection, "connected to %s", kubeconfig.Clusters[newKubeconfigNames.ClusterName].Server)
+
+	return &kubeconfig, nil
+}
+
+// diagnoseOIDCIssuer records the outcome of OIDC issuer discovery, distinguishing problems with the CA bundle
+// from other problems reaching the issuer. It returns the discovery error.
+func diagnoseOIDCIssuer(diagnostics *kubeconfigDiagnostics, flags getKubeconfigParams, err error) error {
+	const caBundleHint = "set spec.tls.certificateAuthorityData of the JWTAuthenticator (or --oidc-ca-bundle) to the CA bundle which issued the issuer's serving certificate"
+
+	switch {
+	case err == nil:
+		diagnostics.pass(checkOIDCIssuer, "discovered %s", flags.oidc.issuer)
+		if caBundle := flags.oidc.issuerCABundle(); len(caBundle) == 0 {
+			diagnostics.pass(checkOIDCCABundle, "the issuer's certificate is trusted by the system's roots")
+		} else {
+			diagnostics.pass(checkOIDCCABundle, "the issuer's certificate is trusted by %d root(s)", countCACerts(caBundle))
+		}
+		return nil
+	case len(flags.oidc.caBundle) > 0 && countCACerts(flags.oidc.caBundle) == 0:
+		return diagnostics.fail(checkOIDCCABundle, err, caBundleHint)
+	case isCertificateVerificationError(err):
+		diagnostics.pass(checkOIDCIssuer, "reached %s", flags.oidc.issuer)
+		return diagnostics.fail(checkOIDCCABundle, err, caBundleHint)
+	default:
+		return diagnostics.fail(checkOIDCIssuer, err,
+			"check that the issuer (spec.issuer of the JWTAuthenticator, or --oidc-issuer) is correct and reachable from this machine")
+	}
+}
+
+// cachedPinnipedSupervisorDiscovery performs pinnipedSupervisorDiscovery, unless it was already performed
+// for the same issuer and CA bundle, in which case the previously discovered values are reused.
+func cachedPinnipedSupervisorDiscovery(ctx context.Context, flags *getKubeconfigParams, deps kubeconfigDeps, cache supervisorDiscoveryCache) error {
+	key := flags.oidc.issuer + "\n" + strconv.FormatBool(flags.oidc.caBundleSystemRoots) + "\n" + string(flags.oidc.issuerCABundle())
+
+	if discovered, ok := cache[key]; ok {
+		deps.log.Info("reusing previously discovered Supervisor settings", "issuer", flags.oidc.issuer)
+		flags.oidc.scopes = slices.Clone(discovered.scopes)
+		flags.oidc.upstreamIDPName = discovered.upstreamIDPName
+		flags.oidc.upstreamIDPType = discovered.upstreamIDPType
+		flags.oidc.upstreamIDPFlow = discovered.upstreamIDPFlow
+		flags.oidc.clusterAudiences = discovered.clusterAudiences
+		return nil
+	}
+
+	if err := pinnipedSupervisorDiscovery(ctx, flags, deps.log, deps.promptForChoice); err != nil {
 		return err
 	}
 
-	return writeConfigAsYAML(out, kubeconfig)
+	cache[key] = flags.oidc
+	return nil
 }
 
 func newExecConfig(deps kubeconfigDeps, flags getKubeconfigParams) (*clientcmdapi.ExecConfig, error) {
@@ -346,6 +674,13 @@ func newExecConfig(deps kubeconfigDeps, flags getKubeconfigParams) (*clientcmdap
 	if len(flags.oidc.caBundle) != 0 {
 		execConfig.Args = append(execConfig.Args, "--ca-bundle-data="+base64.StdEncoding.EncodeToString(flags.oidc.caBundle))
 	}
+	// Refer to the cluster's CA bundle rather than copying it, so that the login keeps working when it is rotated.
+	if flags.oidc.caBundleFromKubeconfig {
+		execConfig.Args = append(execConfig.Args, "--ca-bundle-from-kubeconfig")
+	}
+	if flags.oidc.caBundleSystemRoots {
+		execConfig.Args = append(execConfig.Args, "--ca-bundle-include-system-roots")
+	}
 	if flags.oidc.sessionCachePath != "" {
 		execConfig.Args = append(execConfig.Args, "--session-cache="+flags.oidc.sessionCachePath)
 	}
@@ -358,6 +693,9 @@ func newExecConfig(deps kubeconfigDeps, flags getKubeconfigParams) (*clientcmdap
 		}
 		execConfig.Args = append(execConfig.Args, "--request-audience="+flags.oidc.requestAudience)
 	}
+	if flags.oidc.enableDPoP {
+		execConfig.Args = append(execConfig.Args, "--enable-dpop")
+	}
 	if flags.oidc.upstreamIDPName != "" {
 		execConfig.Args = append(execConfig.Args, "--upstream-identity-provider-name="+flags.oidc.upstreamIDPName)
 	}
@@ -660,8 +998,15 @@ func lookupAuthenticator(clientset conciergeclientset.Interface, authType, authN
 	return results[0], nil
 }
 
-func writeConfigAsYAML(out io.Writer, config clientcmdapi.Config) error {
-	output, err := clientcmd.Write(config)
+// writeConfig prints the kubeconfig as YAML, or as indented JSON when the format is "json".
+func writeConfig(out io.Writer, format string, config clientcmdapi.Config) error {
+	var output []byte
+	var err error
+	if format == outputFormatJSON {
+		output, err = encodeConfigAsJSON(config)
+	} else {
+		output, err = clientcmd.Write(config)
+	}
 	if err != nil {
 		return err
 	}
@@ -672,6 +1017,20 @@ func writeConfigAsYAML(out io.Writer, config clientcmdapi.Config) error {
 	return nil
 }
 
+func encodeConfigAsJSON(config clientcmdapi.Config) ([]byte, error) {
+	// clientcmdlatest.Codec always encodes as YAML, so use a JSON codec for the same scheme and version.
+	codec := serializer.NewCodecFactory(clientcmdlatest.Scheme).LegacyCodec(clientcmdlatest.ExternalVersion)
+	encoded, err := runtime.Encode(codec, &config)
+	if err != nil {
+		return nil, err
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, encoded, "", "  "); err != nil {
+		return nil, err
+	}
+	return indented.Bytes(), nil
+}
+
 func validateKubeconfig(ctx context.Context, flags getKubeconfigParams, kubeconfig clientcmdapi.Config, log plog.MinLogger) error {
 	if flags.skipValidate {
 		return nil
@@ -756,9 +1115,9 @@ func hasPendingStrategy(credentialIssuer *conciergeconfigv1alpha1.CredentialIssu
 	return false
 }
 
-func pinnipedSupervisorDiscovery(ctx context.Context, flags *getKubeconfigParams, log plog.MinLogger) error {
+func pinnipedSupervisorDiscovery(ctx context.Context, flags *getKubeconfigParams, log plog.MinLogger, prompt promptForChoiceFunc) error {
 	// Make a client suitable for calling the provider, which may or may not be a Pinniped Supervisor.
-	oidcProviderHTTPClient, err := newDiscoveryHTTPClient(flags.oidc.caBundle)
+	oidcProviderHTTPClient, err := newDiscoveryHTTPClient(flags.oidc.issuerCABundle(), flags.oidc.caBundleSystemRoots)
 	if err != nil {
 		return err
 	}
@@ -808,7 +1167,21 @@ func pinnipedSupervisorDiscovery(ctx context.Context, flags *getKubeconfigParams
 	// Maybe they know something that we can't know, like the name of an IDP that they are going to define in the
 	// future.
 	if flags.oidc.upstreamIDPType == "" || flags.oidc.upstreamIDPName == "" || flags.oidc.upstreamIDPFlow == "" {
-		if err := discoverSupervisorUpstreamIDP(ctx, pinnipedIDPsEndpoint, oidcProviderHTTPClient, flags, log); err != nil {
+		if err := discoverSupervisorUpstreamIDP(ctx, pinnipedIDPsEndpoint, oidcProviderHTTPClient, flags, log, prompt); err != nil {
+			return err
+		}
+	}
+
+	// Newer Supervisors also list their ClusterAudiences, which are used to choose the audience of each cluster
+	// when none was specified. The audience is chosen later, because the result of this discovery is shared by
+	// all clusters which use the same Supervisor.
+	clusterAudiencesEndpoint, err := discoverClusterAudiencesDiscoveryEndpointURL(discoveredProvider)
+	if err != nil {
+		return err
+	}
+	if clusterAudiencesEndpoint != "" {
+		flags.oidc.clusterAudiences, err = idpdiscovery.New(idpdiscovery.WithHTTPClient(oidcProviderHTTPClient)).ClusterAudiences(ctx, clusterAudiencesEndpoint)
+		if err != nil {
 			return err
 		}
 	}
@@ -824,8 +1197,8 @@ func discoverOIDCProvider(ctx context.Context, issuer string, httpClient *http.C
 	return discoveredProvider, nil
 }
 
-func discoverSupervisorUpstreamIDP(ctx context.Context, pinnipedIDPsEndpoint string, httpClient *http.Client, flags *getKubeconfigParams, log plog.MinLogger) error {
-	discoveredUpstreamIDPs, err := discoverAllAvailableSupervisorUpstreamIDPs(ctx, pinnipedIDPsEndpoint, httpClient)
+func discoverSupervisorUpstreamIDP(ctx context.Context, pinnipedIDPsEndpoint string, httpClient *http.Client, flags *getKubeconfigParams, log plog.MinLogger, prompt promptForChoiceFunc) error {
+	discoveredUpstreamIDPs, err := idpdiscovery.New(idpdiscovery.WithHTTPClient(httpClient)).IdentityProviders(ctx, pinnipedIDPsEndpoint)
 	if err != nil {
 		return err
 	}
@@ -837,6 +1210,12 @@ func discoverSupervisorUpstreamIDP(ctx context.Context, pinnipedIDPsEndpoint str
 		return nil
 	}
 
+	if flags.oidc.upstreamIDPName == "" && flags.oidc.upstreamIDPType == "" && len(discoveredUpstreamIDPs) > 1 {
+		if err := promptForUpstreamIDP(ctx, discoveredUpstreamIDPs, flags, log, prompt); err != nil {
+			return err
+		}
+	}
+
 	selectedIDPName, selectedIDPType, discoveredIDPFlows, err := selectUpstreamIDPNameAndType(discoveredUpstreamIDPs, flags.oidc.upstreamIDPName, flags.oidc.upstreamIDPType)
 	if err != nil {
 		return err
@@ -853,10 +1232,40 @@ func discoverSupervisorUpstreamIDP(ctx context.Context, pinnipedIDPsEndpoint str
 	return nil
 }
 
-func newDiscoveryHTTPClient(caBundleFlag caBundleFlag) (*http.Client, error) {
+// promptForUpstreamIDP asks the user to choose one of the discovered upstream IDPs when none was specified.
+// When the user cannot be asked, the flags are left unchanged, so the selection fails as before.
+func promptForUpstreamIDP(ctx context.Context, pinnipedIDPs []idpdiscoveryv1alpha1.PinnipedIDP, flags *getKubeconfigParams, log plog.MinLogger, prompt promptForChoiceFunc) error {
+	if prompt == nil {
+		return nil
+	}
+	choices := make([]string, 0, len(pinnipedIDPs))
+	for _, idp := range pinnipedIDPs {
+		choices = append(choices, fmt.Sprintf("%s (%s)", idp.Name, idp.Type))
+	}
+	chosen, err := prompt(ctx, "Multiple Supervisor upstream identity providers were found. Choose one:", choices)
+	if errors.Is(err, errNotInteractive) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not choose a Supervisor upstream identity provider: %w", err)
+	}
+	flags.oidc.upstreamIDPName = pinnipedIDPs[chosen].Name
+	flags.oidc.upstreamIDPType = pinnipedIDPs[chosen].Type.String()
+	log.Info("chose Supervisor upstream identity provider", "name", flags.oidc.upstreamIDPName, "type", flags.oidc.upstreamIDPType)
+	return nil
+}
+
+func newDiscoveryHTTPClient(caBundleFlag caBundleFlag, includeSystemRoots bool) (*http.Client, error) {
 	var rootCAs *x509.CertPool
 	if caBundleFlag != nil {
 		rootCAs = x509.NewCertPool()
+		if includeSystemRoots {
+			systemRoots, err := x509.SystemCertPool()
+			if err != nil {
+				return nil, fmt.Errorf("unable to fetch OIDC discovery data from issuer: could not load the system's roots: %w", err)
+			}
+			rootCAs = systemRoots
+		}
 		if ok := rootCAs.AppendCertsFromPEM(caBundleFlag); !ok {
 			return nil, fmt.Errorf("unable to fetch OIDC discovery data from issuer: could not parse CA bundle")
 		}
@@ -873,108 +1282,74 @@ func discoverIDPsDiscoveryEndpointURL(discoveredProvider *coreosoidc.Provider) (
 	return body.SupervisorDiscovery.PinnipedIDPsEndpoint, nil
 }
 
-func discoverScopesSupportedIncludesBothUsernameAndGroups(discoveredProvider *coreosoidc.Provider) (bool, error) {
-	var body discoveryResponseScopesSupported
+func discoverClusterAudiencesDiscoveryEndpointURL(discoveredProvider *coreosoidc.Provider) (string, error) {
+	var body idpdiscoveryv1alpha1.OIDCDiscoveryResponse
 	err := discoveredProvider.Claims(&body)
 	if err != nil {
-		return false, fmt.Errorf("while fetching OIDC discovery data from issuer: %w", err)
+		return "", fmt.Errorf("while fetching OIDC discovery data from issuer: %w", err)
 	}
-	return slices.Contains(body.ScopesSupported, oidcapi.ScopeUsername) && slices.Contains(body.ScopesSupported, oidcapi.ScopeGroups), nil
+	return body.SupervisorDiscovery.PinnipedClusterAudiencesEndpoint, nil
 }
 
-func discoverAllAvailableSupervisorUpstreamIDPs(ctx context.Context, pinnipedIDPsEndpoint string, httpClient *http.Client) ([]idpdiscoveryv1alpha1.PinnipedIDP, error) {
-	request, err := http.NewRequestWithContext(ctx, http.MethodGet, pinnipedIDPsEndpoint, nil)
-	if err != nil {
-		return nil, fmt.Errorf("while forming request to IDP discovery URL: %w", err)
-	}
-
-	response, err := httpClient.Do(request)
-	if err != nil {
-		return nil, fmt.Errorf("unable to fetch IDP discovery data from issuer: %w", err)
-	}
-	defer func() {
-		_ = response.Body.Close()
-	}()
-	if response.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unable to fetch IDP discovery data from issuer: unexpected http response status: %s", response.Status)
-	}
-
-	rawBody, err := io.ReadAll(response.Body)
-	if err != nil {
-		return nil, fmt.Errorf("unable to fetch IDP discovery data from issuer: could not read response body: %w", err)
+// selectClusterAudience sets the request audience to the audience of the Supervisor's ClusterAudience whose server
+// is the server of the cluster, when there is one.
+func selectClusterAudience(flags *getKubeconfigParams, clusterServer string, log plog.MinLogger) {
+	for _, clusterAudience := range flags.oidc.clusterAudiences {
+		if clusterAudience.Server != "" && strings.TrimSuffix(clusterAudience.Server, "/") == strings.TrimSuffix(clusterServer, "/") {
+			log.Info("discovered OIDC audience from Supervisor ClusterAudience", "name", clusterAudience.Name, "audience", clusterAudience.Audience)
+			flags.oidc.requestAudience = clusterAudience.Audience
+			return
+		}
 	}
+}
 
-	var body idpdiscoveryv1alpha1.IDPDiscoveryResponse
-	err = json.Unmarshal(rawBody, &body)
+func discoverScopesSupportedIncludesBothUsernameAndGroups(discoveredProvider *coreosoidc.Provider) (bool, error) {
+	var body discoveryResponseScopesSupported
+	err := discoveredProvider.Claims(&body)
 	if err != nil {
-		return nil, fmt.Errorf("unable to fetch IDP discovery data from issuer: could not parse response JSON: %w", err)
+		return false, fmt.Errorf("while fetching OIDC discovery data from issuer: %w", err)
 	}
-
-	return body.PinnipedIDPs, nil
+	return slices.Contains(body.ScopesSupported, oidcapi.ScopeUsername) && slices.Contains(body.ScopesSupported, oidcapi.ScopeGroups), nil
 }
 
 func selectUpstreamIDPNameAndType(pinnipedIDPs []idpdiscoveryv1alpha1.PinnipedIDP, specifiedIDPName, specifiedIDPType string) (string, idpdiscoveryv1alpha1.IDPType, []idpdiscoveryv1alpha1.IDPFlow, error) {
 	pinnipedIDPsString, _ := json.Marshal(pinnipedIDPs)
-	var discoveredFlows []idpdiscoveryv1alpha1.IDPFlow
+	found := idpdiscovery.Find(pinnipedIDPs, specifiedIDPName, specifiedIDPType)
 	switch {
+	case len(found) == 1:
+		// Exactly one IDP matches the specified name and/or type, or there is only one IDP when neither was specified.
+		return found[0].Name, found[0].Type, found[0].Flows, nil
 	case specifiedIDPName != "" && specifiedIDPType != "":
-		// The user specified both name and type, so check to see if there exists an exact match.
-		for _, idp := range pinnipedIDPs {
-			if idp.Name == specifiedIDPName && idp.Type.Equals(specifiedIDPType) {
-				return specifiedIDPName, idp.Type, idp.Flows, nil
-			}
+		// The user specified both name and type, so there must be an exact match.
+		if len(found) > 1 {
+			return found[0].Name, found[0].Type, found[0].Flows, nil
 		}
 		return "", "", nil, fmt.Errorf(
 			"no Supervisor upstream identity providers with name %q of type %q were found. "+
 				"Found these upstreams: %s", specifiedIDPName, specifiedIDPType, pinnipedIDPsString)
 	case specifiedIDPType != "":
-		// The user specified only a type, so check if there is only one of that type found.
-		discoveredName := ""
-		var discoveredType idpdiscoveryv1alpha1.IDPType
-		for _, idp := range pinnipedIDPs {
-			if idp.Type.Equals(specifiedIDPType) {
-				if discoveredName != "" {
-					return "", "", nil, fmt.Errorf(
-						"multiple Supervisor upstream identity providers of type %q were found, "+
-							"so the --upstream-identity-provider-name flag must be specified. "+
-							"Found these upstreams: %s",
-						specifiedIDPType, pinnipedIDPsString)
-				}
-				discoveredName = idp.Name
-				discoveredType = idp.Type
-				discoveredFlows = idp.Flows
-			}
-		}
-		if discoveredName == "" {
+		// The user specified only a type, so there must be only one of that type.
+		if len(found) > 1 {
 			return "", "", nil, fmt.Errorf(
-				"no Supervisor upstream identity providers of type %q were found. "+
-					"Found these upstreams: %s", specifiedIDPType, pinnipedIDPsString)
+				"multiple Supervisor upstream identity providers of type %q were found, "+
+					"so the --upstream-identity-provider-name flag must be specified. "+
+					"Found these upstreams: %s",
+				specifiedIDPType, pinnipedIDPsString)
 		}
-		return discoveredName, discoveredType, discoveredFlows, nil
+		return "", "", nil, fmt.Errorf(
+			"no Supervisor upstream identity providers of type %q were found. "+
+				"Found these upstreams: %s", specifiedIDPType, pinnipedIDPsString)
 	case specifiedIDPName != "":
-		// The user specified only a name, so check if there is only one of that name found.
-		var discoveredType idpdiscoveryv1alpha1.IDPType
-		for _, idp := range pinnipedIDPs {
-			if idp.Name == specifiedIDPName {
-				if discoveredType != "" {
-					return "", "", nil, fmt.Errorf(
-						"multiple Supervisor upstream identity providers with name %q were found, "+
-							"so the --upstream-identity-provider-type flag must be specified. Found these upstreams: %s",
-						specifiedIDPName, pinnipedIDPsString)
-				}
-				discoveredType = idp.Type
-				discoveredFlows = idp.Flows
-			}
-		}
-		if discoveredType == "" {
+		// The user specified only a name, so there must be only one of that name.
+		if len(found) > 1 {
 			return "", "", nil, fmt.Errorf(
-				"no Supervisor upstream identity providers with name %q were found. "+
-					"Found these upstreams: %s", specifiedIDPName, pinnipedIDPsString)
+				"multiple Supervisor upstream identity providers with name %q were found, "+
+					"so the --upstream-identity-provider-type flag must be specified. Found these upstreams: %s",
+				specifiedIDPName, pinnipedIDPsString)
 		}
-		return specifiedIDPName, discoveredType, discoveredFlows, nil
-	case len(pinnipedIDPs) == 1:
-		// The user did not specify any name or type, but there is only one found, so select it.
-		return pinnipedIDPs[0].Name, pinnipedIDPs[0].Type, pinnipedIDPs[0].Flows, nil
+		return "", "", nil, fmt.Errorf(
+			"no Supervisor upstream identity providers with name %q were found. "+
+				"Found these upstreams: %s", specifiedIDPName, pinnipedIDPsString)
 	default:
 		// The user did not specify any name or type, and there is more than one found.
 		return "", "", nil, fmt.Errorf(
@@ -0,0 +1,174 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	oidcapi "go.pinniped.dev/generated/latest/apis/supervisor/oidc"
+)
+
+// The names of the checks which are performed by "pinniped get kubeconfig --validate", in the order in which they
+// are usually performed.
+const (
+	checkKubeconfig             = "Kubeconfig"
+	checkConciergeStrategy      = "Concierge strategy"
+	checkConciergeAuthenticator = "Concierge authenticator"
+	checkOIDCIssuer             = "OIDC issuer"
+	checkOIDCCABundle           = "OIDC CA bundle"
+	checkOIDCAudience           = "OIDC audience"
+	checkClusterConnection      = "Cluster connection"
+	checkOther                  = "Kubeconfig generation"
+)
+
+// kubeconfigDiagnostics records the outcome of each step of kubeconfig generation when running with --validate.
+// A nil *kubeconfigDiagnostics ignores everything, so the steps can record their outcome unconditionally.
+type kubeconfigDiagnostics struct {
+	checks []kubeconfigCheck
+}
+
+type kubeconfigCheck struct {
+	name   string
+	detail string // describes what was found when the check passed
+	err    error  // why the check failed
+	hint   string // what the user can do about the failure
+}
+
+// pass records that the named check passed.
+func (d *kubeconfigDiagnostics) pass(name string, detail string, args ...any) {
+	if d == nil {
+		return
+	}
+	d.checks = append(d.checks, kubeconfigCheck{name: name, detail: fmt.Sprintf(detail, args...)})
+}
+
+// fail records that the named check failed and returns the error, so that it can be used in a return statement.
+func (d *kubeconfigDiagnostics) fail(name string, err error, hint string) error {
+	if d == nil {
+		return err
+	}
+	d.checks = append(d.checks, kubeconfigCheck{name: name, err: err, hint: hint})
+	return err
+}
+
+// finish records the error returned by kubeconfig generation when it was not already recorded by a check.
+func (d *kubeconfigDiagnostics) finish(err error) {
+	if err == nil || d.failed() {
+		return
+	}
+	_ = d.fail(checkOther, err, "")
+}
+
+func (d *kubeconfigDiagnostics) failed() bool {
+	for _, check := range d.checks {
+		if check.err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// writeReport prints the outcome of each check. The report is indented under the cluster name when a name is given.
+func (d *kubeconfigDiagnostics) writeReport(out io.Writer, clusterName string) error {
+	var b strings.Builder
+	indent := ""
+	if clusterName != "" {
+		fmt.Fprintf(&b, "Cluster %q:\n", clusterName)
+		indent = "  "
+	}
+
+	for _, check := range d.checks {
+		if check.err == nil {
+			fmt.Fprintf(&b, "%s[PASS] %s: %s\n", indent, check.name, check.detail)
+			continue
+		}
+		fmt.Fprintf(&b, "%s[FAIL] %s: %s\n", indent, check.name, check.err)
+		if check.hint != "" {
+			fmt.Fprintf(&b, "%s       Hint: %s\n", indent, check.hint)
+		}
+	}
+
+	if d.failed() {
+		fmt.Fprintf(&b, "%sThe remaining checks were skipped. The generated kubeconfig would not work.\n", indent)
+	} else {
+		fmt.Fprintf(&b, "%sAll checks passed.\n", indent)
+	}
+
+	if _, err := io.WriteString(out, b.String()); err != nil {
+		return fmt.Errorf("could not write output: %w", err)
+	}
+	return nil
+}
+
+// kubeconfigValidationOutput is the schema of the JSON and YAML output of "pinniped get kubeconfig --validate".
+type kubeconfigValidationOutput struct {
+	outputTypeMeta `json:",inline"`
+	Clusters       []kubeconfigValidationClusterOutput `json:"clusters"`
+}
+
+type kubeconfigValidationClusterOutput struct {
+	// Name is empty when the kubeconfig is generated for a single cluster.
+	Name   string                            `json:"name,omitempty"`
+	Checks []kubeconfigValidationCheckOutput `json:"checks"`
+	Passed bool                              `json:"passed"`
+}
+
+type kubeconfigValidationCheckOutput struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Error  string `json:"error,omitempty"`
+	Hint   string `json:"hint,omitempty"`
+}
+
+// output returns the outcome of each check in the schema of the JSON and YAML output.
+func (d *kubeconfigDiagnostics) output(clusterName string) kubeconfigValidationClusterOutput {
+	result := kubeconfigValidationClusterOutput{
+		Name:   clusterName,
+		Checks: make([]kubeconfigValidationCheckOutput, 0, len(d.checks)),
+		Passed: !d.failed(),
+	}
+	for _, check := range d.checks {
+		if check.err == nil {
+			result.Checks = append(result.Checks, kubeconfigValidationCheckOutput{Name: check.name, Status: "PASS", Detail: check.detail})
+			continue
+		}
+		result.Checks = append(result.Checks, kubeconfigValidationCheckOutput{Name: check.name, Status: "FAIL", Error: check.err.Error(), Hint: check.hint})
+	}
+	return result
+}
+
+// checkRequestAudience returns an error when the Supervisor would refuse to issue a cluster-scoped token for the
+// audience, which would otherwise only be noticed the first time that the kubeconfig is used.
+func checkRequestAudience(audience string, scopes []string) error {
+	switch {
+	case strings.Contains(audience, ".pinniped.dev"):
+		return fmt.Errorf("audience %q is not allowed because it contains '.pinniped.dev'", audience)
+	case audience == oidcapi.ClientIDPinnipedCLI:
+		return fmt.Errorf("audience %q is not allowed because it is the client ID of the pinniped CLI", audience)
+	}
+	for _, scope := range scopes {
+		if scope == oidcapi.ScopeRequestAudience {
+			return nil
+		}
+	}
+	return fmt.Errorf("the %q scope is required to request a token for audience %q, but it is not in --oidc-scopes", oidcapi.ScopeRequestAudience, audience)
+}
+
+// isCertificateVerificationError returns true when the error was caused by an untrusted or mismatched TLS certificate.
+func isCertificateVerificationError(err error) bool {
+	var verificationErr *tls.CertificateVerificationError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	return errors.As(err, &verificationErr) ||
+		errors.As(err, &unknownAuthorityErr) ||
+		errors.As(err, &hostnameErr) ||
+		errors.As(err, &invalidErr)
+}
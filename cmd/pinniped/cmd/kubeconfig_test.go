@@ -5,7 +5,10 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -20,6 +23,8 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	kubetesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	clientcmdv1 "k8s.io/client-go/tools/clientcmd/api/v1"
 	"k8s.io/utils/ptr"
 
 	authenticationv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/authentication/v1alpha1"
@@ -97,6 +102,31 @@ func TestGetKubeconfig(t *testing.T) {
 		}`, issuerURL)
 	}
 
+	// A cluster which responds to the final validation of the kubeconfig, unlike the clusters in testdata/kubeconfig.yaml.
+	reachableCluster, reachableClusterCA := tlsserver.TestServerIPv4(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), nil)
+	reachableClusterKubeconfigPath := filepath.Join(tmpdir, "reachable-kubeconfig.yaml")
+	require.NoError(t, os.WriteFile(reachableClusterKubeconfigPath, []byte(here.Docf(`
+		apiVersion: v1
+		clusters:
+		- cluster:
+		    certificate-authority-data: %s
+		    server: %s
+		  name: reachable-cluster
+		contexts:
+		- context:
+		    cluster: reachable-cluster
+		    user: reachable-user
+		  name: reachable-context
+		current-context: reachable-context
+		kind: Config
+		users:
+		- name: reachable-user
+		  user:
+		    token: some-token
+		`, base64.StdEncoding.EncodeToString(reachableClusterCA), reachableCluster.URL)), 0600))
+
 	tests := []struct {
 		name                    string
 		args                    func(string, string) []string
@@ -109,12 +139,16 @@ func TestGetKubeconfig(t *testing.T) {
 		oidcDiscoveryStatusCode int
 		idpsDiscoveryResponse   string
 		idpsDiscoveryStatusCode int
+		promptChoice            *int
+		promptErr               error
 		wantLogs                func(string, string) []string
 		wantError               bool
 		wantStdout              func(string, string) string
 		wantStderr              func(string, string) testutil.RequireErrorStringFunc
 		wantOptionsCount        int
 		wantAPIGroupSuffix      string
+
+		clusterAudiencesDiscoveryResponse string
 	}{
 		{
 			name: "help flag passed",
@@ -136,14 +170,19 @@ func TestGetKubeconfig(t *testing.T) {
 				      --concierge-mode mode                      Concierge mode of operation (default TokenCredentialRequestAPI)
 				      --concierge-skip-wait                      Skip waiting for any pending Concierge strategies to become ready (default: false)
 				      --credential-cache string                  Path to cluster-specific credentials cache
+				      --fleet-file string                        Path to a fleet inventory file which lists multiple clusters for which to generate a single kubeconfig
 				      --generated-name-suffix string             Suffix to append to generated cluster, context, user kubeconfig entries (default "-pinniped")
 				  -h, --help                                     help for kubeconfig
 				      --install-hint string                      This text is shown to the user when the pinniped CLI is not installed. (default "The pinniped CLI does not appear to be installed.  See https://get.pinniped.dev/cli for more details")
 				      --kubeconfig string                        Path to kubeconfig file
 				      --kubeconfig-context string                Kubeconfig context name (default: current active context)
+				      --kubeconfig-contexts strings              Kubeconfig context names of multiple clusters for which to generate a single kubeconfig (can be repeated)
 				      --no-concierge                             Generate a configuration which does not use the Concierge, but sends the credential to the cluster directly
 				      --oidc-ca-bundle path                      Path to TLS certificate authority bundle (PEM format, optional, can be repeated)
+				      --oidc-ca-bundle-from-kubeconfig           Also trust the certificate authority data of the cluster in the generated kubeconfig when connecting to the OpenID Connect issuer, e.g. when the Supervisor and the cluster share a private CA
+				      --oidc-ca-bundle-include-system-roots      Also trust the system's roots when connecting to the OpenID Connect issuer, in addition to the CA bundles
 				      --oidc-client-id string                    OpenID Connect client ID (default: autodiscover) (default "pinniped-cli")
+				      --oidc-enable-dpop                         Request DPoP-bound tokens (RFC9449) from the OpenID Connect issuer
 				      --oidc-issuer string                       OpenID Connect issuer URL (default: autodiscover)
 				      --oidc-listen-port uint16                  TCP port for localhost listener (authorization code flow only)
 				      --oidc-request-audience string             Request a token with an alternate audience using RFC8693 token exchange
@@ -151,6 +190,7 @@ func TestGetKubeconfig(t *testing.T) {
 				      --oidc-session-cache string                Path to OpenID Connect session cache file
 				      --oidc-skip-browser                        During OpenID Connect login, skip opening the browser (just print the URL)
 				  -o, --output string                            Output file path (default: stdout)
+				      --output-format string                     Output format, one of 'yaml' or 'json' (default: YAML for the kubeconfig, and text for the report of --validate)
 				      --pinniped-cli-path string                 Full path or executable name for the Pinniped CLI binary to be embedded in the resulting kubeconfig output (e.g. 'pinniped') (default: full path of the binary used to execute this command)
 				      --skip-validation                          Skip final validation of the kubeconfig (default: false)
 				      --static-token string                      Instead of doing an OIDC-based login, specify a static token
@@ -159,6 +199,7 @@ func TestGetKubeconfig(t *testing.T) {
 				      --upstream-identity-provider-flow string   The type of client flow to use with the upstream identity provider during login with a Supervisor (e.g. 'cli_password', 'browser_authcode')
 				      --upstream-identity-provider-name string   The name of the upstream identity provider used during login with a Supervisor
 				      --upstream-identity-provider-type string   The type of the upstream identity provider used during login with a Supervisor (e.g. 'oidc', 'ldap', 'activedirectory', 'github')
+				      --validate                                 Instead of printing a kubeconfig, perform a dry run of autodiscovery and validation and print a diagnostic report (default: false)
 			`)
 			},
 		},
@@ -933,6 +974,129 @@ func TestGetKubeconfig(t *testing.T) {
 					`Found these upstreams: [{"name":"some-ldap-idp","type":"ldap"},{"name":"some-oidc-idp","type":"oidc","flows":["flow1","flow2"]},{"name":"some-github-idp","type":"github"}]` + "\n")
 			},
 		},
+		{
+			name: "when IDP discovery document contains multiple IDPs and no name or type flags are given, prompt for the IDP",
+			args: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					"--kubeconfig", "./testdata/kubeconfig.yaml",
+					"--skip-validation",
+				}
+			},
+			conciergeObjects: func(issuerCABundle string, issuerURL string) []runtime.Object {
+				return []runtime.Object{
+					credentialIssuer(),
+					jwtAuthenticator(issuerCABundle, issuerURL),
+				}
+			},
+			oidcDiscoveryResponse: happyOIDCDiscoveryResponse,
+			promptChoice:          ptr.To(0),
+			idpsDiscoveryResponse: here.Docf(`{
+				"pinniped_identity_providers": [
+					{"name": "some-ldap-idp", "type": "ldap"},
+					{"name": "some-oidc-idp", "type": "oidc", "flows": ["flow1", "flow2"]},
+					{"name": "some-github-idp", "type": "github"}
+				]
+			}`),
+			wantLogs: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered CredentialIssuer  {"name": "test-credential-issuer"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge operating in TokenCredentialRequest API mode`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge endpoint  {"endpoint": "https://fake-server-url-value"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge certificate authority bundle  {"roots": 0}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered JWTAuthenticator  {"name": "test-authenticator"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered OIDC issuer  {"issuer": "` + issuerURL + `"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered OIDC audience  {"audience": "test-audience"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered OIDC CA bundle  {"roots": 1}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  chose Supervisor upstream identity provider  {"name": "some-ldap-idp", "type": "ldap"}`,
+				}
+			},
+			wantStdout: func(issuerCABundle string, issuerURL string) string {
+				return here.Docf(`
+					apiVersion: v1
+					clusters:
+					- cluster:
+						certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+						server: https://fake-server-url-value
+					  name: kind-cluster-pinniped
+					contexts:
+					- context:
+						cluster: kind-cluster-pinniped
+						user: kind-user-pinniped
+					  name: kind-context-pinniped
+					current-context: kind-context-pinniped
+					kind: Config
+					preferences: {}
+					users:
+					- name: kind-user-pinniped
+					  user:
+						exec:
+						  apiVersion: client.authentication.k8s.io/v1beta1
+						  args:
+						  - login
+						  - oidc
+						  - --enable-concierge
+						  - --concierge-api-group-suffix=pinniped.dev
+						  - --concierge-authenticator-name=test-authenticator
+						  - --concierge-authenticator-type=jwt
+						  - --concierge-endpoint=https://fake-server-url-value
+						  - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+						  - --issuer=%s
+						  - --client-id=pinniped-cli
+						  - --scopes=offline_access,openid,pinniped:request-audience,username,groups
+						  - --ca-bundle-data=%s
+						  - --request-audience=test-audience
+						  - --upstream-identity-provider-name=some-ldap-idp
+						  - --upstream-identity-provider-type=ldap
+						  command: '.../path/to/pinniped'
+						  env: []
+						  installHint: The pinniped CLI does not appear to be installed.  See https://get.pinniped.dev/cli
+						    for more details
+						  provideClusterInfo: true
+					`,
+					issuerURL,
+					base64.StdEncoding.EncodeToString([]byte(issuerCABundle)))
+			},
+		},
+		{
+			name: "when IDP discovery document contains multiple IDPs and no name or type flags are given, and the prompt fails",
+			args: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					"--kubeconfig", "./testdata/kubeconfig.yaml",
+					"--skip-validation",
+				}
+			},
+			conciergeObjects: func(issuerCABundle string, issuerURL string) []runtime.Object {
+				return []runtime.Object{
+					credentialIssuer(),
+					jwtAuthenticator(issuerCABundle, issuerURL),
+				}
+			},
+			oidcDiscoveryResponse: happyOIDCDiscoveryResponse,
+			promptErr:             errors.New(`invalid choice "4", expected a number from 1 to 3`),
+			idpsDiscoveryResponse: here.Docf(`{
+				"pinniped_identity_providers": [
+					{"name": "some-ldap-idp", "type": "ldap"},
+					{"name": "some-oidc-idp", "type": "oidc", "flows": ["flow1", "flow2"]},
+					{"name": "some-github-idp", "type": "github"}
+				]
+			}`),
+			wantLogs: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered CredentialIssuer  {"name": "test-credential-issuer"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge operating in TokenCredentialRequest API mode`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge endpoint  {"endpoint": "https://fake-server-url-value"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge certificate authority bundle  {"roots": 0}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered JWTAuthenticator  {"name": "test-authenticator"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered OIDC issuer  {"issuer": "` + issuerURL + `"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered OIDC audience  {"audience": "test-audience"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered OIDC CA bundle  {"roots": 1}`,
+				}
+			},
+			wantError: true,
+			wantStderr: func(issuerCABundle string, issuerURL string) testutil.RequireErrorStringFunc {
+				return testutil.WantExactErrorString(`Error: could not choose a Supervisor upstream identity provider: invalid choice "4", expected a number from 1 to 3` + "\n")
+			},
+		},
 		{
 			name: "when OIDC discovery document is not valid JSON",
 			args: func(issuerCABundle string, issuerURL string) []string {
@@ -1452,6 +1616,85 @@ func TestGetKubeconfig(t *testing.T) {
 				`)
 			},
 		},
+		{
+			name: "valid static token as JSON",
+			args: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					"--kubeconfig", "./testdata/kubeconfig.yaml",
+					"--static-token", "test-token",
+					"--skip-validation",
+					"--output-format", "json",
+				}
+			},
+			conciergeObjects: func(issuerCABundle string, issuerURL string) []runtime.Object {
+				return []runtime.Object{
+					credentialIssuer(),
+					&authenticationv1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
+				}
+			},
+			wantLogs: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered CredentialIssuer  {"name": "test-credential-issuer"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge operating in TokenCredentialRequest API mode`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge endpoint  {"endpoint": "https://fake-server-url-value"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge certificate authority bundle  {"roots": 0}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered WebhookAuthenticator  {"name": "test-authenticator"}`,
+				}
+			},
+			wantStdout: func(issuerCABundle string, issuerURL string) string {
+				return here.Doc(`
+					{
+					  "kind": "Config",
+					  "apiVersion": "v1",
+					  "preferences": {},
+					  "clusters": [
+					    {
+					      "name": "kind-cluster-pinniped",
+					      "cluster": {
+					        "server": "https://fake-server-url-value",
+					        "certificate-authority-data": "ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ=="
+					      }
+					    }
+					  ],
+					  "users": [
+					    {
+					      "name": "kind-user-pinniped",
+					      "user": {
+					        "exec": {
+					          "command": ".../path/to/pinniped",
+					          "args": [
+					            "login",
+					            "static",
+					            "--enable-concierge",
+					            "--concierge-api-group-suffix=pinniped.dev",
+					            "--concierge-authenticator-name=test-authenticator",
+					            "--concierge-authenticator-type=webhook",
+					            "--concierge-endpoint=https://fake-server-url-value",
+					            "--concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==",
+					            "--token=test-token"
+					          ],
+					          "env": [],
+					          "apiVersion": "client.authentication.k8s.io/v1beta1",
+					          "installHint": "The pinniped CLI does not appear to be installed.  See https://get.pinniped.dev/cli for more details",
+					          "provideClusterInfo": true
+					        }
+					      }
+					    }
+					  ],
+					  "contexts": [
+					    {
+					      "name": "kind-context-pinniped",
+					      "context": {
+					        "cluster": "kind-cluster-pinniped",
+					        "user": "kind-user-pinniped"
+					      }
+					    }
+					  ],
+					  "current-context": "kind-context-pinniped"
+					}
+				`)
+			},
+		},
 		{
 			name: "valid static token from env var",
 			args: func(issuerCABundle string, issuerURL string) []string {
@@ -1610,6 +1853,7 @@ func TestGetKubeconfig(t *testing.T) {
 					"--oidc-session-cache", "/path/to/cache/dir/sessions.yaml",
 					"--oidc-debug-session-cache",
 					"--oidc-request-audience", "test-audience",
+					"--oidc-enable-dpop",
 					"--skip-validation",
 					"--generated-name-suffix", "-sso",
 					"--credential-cache", "/path/to/cache/dir/credentials.yaml",
@@ -1665,6 +1909,7 @@ func TestGetKubeconfig(t *testing.T) {
 						  - --session-cache=/path/to/cache/dir/sessions.yaml
 						  - --debug-session-cache
 						  - --request-audience=test-audience
+						  - --enable-dpop
 						  command: /some/path/to/command-exe
 						  env: []
 						  installHint: The pinniped CLI does not appear to be installed.  See https://get.pinniped.dev/cli
@@ -2769,7 +3014,7 @@ func TestGetKubeconfig(t *testing.T) {
 			},
 		},
 		{
-			name: "supervisor upstream IDP discovery resolves ambiguity when type is specified but name is not",
+			name: "CA bundle of the cluster in the kubeconfig and the system's roots are trusted along with --oidc-ca-bundle",
 			args: func(issuerCABundle string, issuerURL string) []string {
 				f := testutil.WriteStringToTempFile(t, "testca-*.pem", issuerCABundle)
 				return []string{
@@ -2778,15 +3023,14 @@ func TestGetKubeconfig(t *testing.T) {
 					"--no-concierge",
 					"--oidc-issuer", issuerURL,
 					"--oidc-ca-bundle", f.Name(),
-					"--upstream-identity-provider-type", "ldap",
+					"--oidc-ca-bundle-from-kubeconfig",
+					"--oidc-ca-bundle-include-system-roots",
 				}
 			},
 			oidcDiscoveryResponse: happyOIDCDiscoveryResponse,
 			idpsDiscoveryResponse: here.Docf(`{
 				"pinniped_identity_providers": [
-					{"name": "some-ldap-idp", "type": "ldap"},
-					{"name": "some-oidc-idp", "type": "oidc"},
-					{"name": "some-other-oidc-idp", "type": "oidc"}
+					{"name": "some-ldap-idp", "type": "ldap"}
 				]
 			}`),
 			wantStdout: func(issuerCABundle string, issuerURL string) string {
@@ -2817,6 +3061,8 @@ func TestGetKubeconfig(t *testing.T) {
 						  - --client-id=pinniped-cli
 						  - --scopes=offline_access,openid,pinniped:request-audience,username,groups
 						  - --ca-bundle-data=%s
+						  - --ca-bundle-from-kubeconfig
+						  - --ca-bundle-include-system-roots
 						  - --upstream-identity-provider-name=some-ldap-idp
 						  - --upstream-identity-provider-type=ldap
 						  command: '.../path/to/pinniped'
@@ -2830,7 +3076,7 @@ func TestGetKubeconfig(t *testing.T) {
 			},
 		},
 		{
-			name: "supervisor upstream IDP discovery resolves ambiguity when name is specified but type is not",
+			name: "supervisor ClusterAudience discovery finds the audience of the cluster when --no-concierge is used",
 			args: func(issuerCABundle string, issuerURL string) []string {
 				f := testutil.WriteStringToTempFile(t, "testca-*.pem", issuerCABundle)
 				return []string{
@@ -2839,17 +3085,34 @@ func TestGetKubeconfig(t *testing.T) {
 					"--no-concierge",
 					"--oidc-issuer", issuerURL,
 					"--oidc-ca-bundle", f.Name(),
-					"--upstream-identity-provider-name", "some-ldap-idp",
 				}
 			},
-			oidcDiscoveryResponse: happyOIDCDiscoveryResponse,
+			oidcDiscoveryResponse: func(issuerURL string) string {
+				return here.Docf(`{
+					"issuer": "%s",
+					"discovery.supervisor.pinniped.dev/v1alpha1": {
+						"pinniped_identity_providers_endpoint": "%s/v1alpha1/pinniped_identity_providers",
+						"pinniped_cluster_audiences_endpoint": "%s/v1alpha1/pinniped_cluster_audiences"
+					},
+					"scopes_supported": ["openid", "offline_access", "pinniped:request-audience", "username", "groups"]
+				}`, issuerURL, issuerURL, issuerURL)
+			},
 			idpsDiscoveryResponse: here.Docf(`{
 				"pinniped_identity_providers": [
-					{"name": "some-ldap-idp", "type": "ldap"},
-					{"name": "some-oidc-idp", "type": "oidc"},
-					{"name": "some-other-oidc-idp", "type": "oidc"}
+					{"name": "some-ldap-idp", "type": "ldap"}
 				]
 			}`),
+			clusterAudiencesDiscoveryResponse: here.Docf(`{
+				"pinniped_cluster_audiences": [
+					{"name": "other-cluster", "audience": "other-audience", "server": "https://other-server-url-value"},
+					{"name": "this-cluster", "audience": "this-audience", "server": "https://fake-server-url-value/"}
+				]
+			}`),
+			wantLogs: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered OIDC audience from Supervisor ClusterAudience  {"name": "this-cluster", "audience": "this-audience"}`,
+				}
+			},
 			wantStdout: func(issuerCABundle string, issuerURL string) string {
 				return here.Docf(`
 					apiVersion: v1
@@ -2878,6 +3141,7 @@ func TestGetKubeconfig(t *testing.T) {
 						  - --client-id=pinniped-cli
 						  - --scopes=offline_access,openid,pinniped:request-audience,username,groups
 						  - --ca-bundle-data=%s
+						  - --request-audience=this-audience
 						  - --upstream-identity-provider-name=some-ldap-idp
 						  - --upstream-identity-provider-type=ldap
 						  command: '.../path/to/pinniped'
@@ -2889,9 +3153,9 @@ func TestGetKubeconfig(t *testing.T) {
 					issuerURL,
 					base64.StdEncoding.EncodeToString([]byte(issuerCABundle)))
 			},
-		}, // TODO make sure there are active directory tests for various flows
+		},
 		{
-			name: "supervisor upstream IDP discovery when both name and type are specified but flow is not and a matching IDP is found",
+			name: "supervisor upstream IDP discovery resolves ambiguity when type is specified but name is not",
 			args: func(issuerCABundle string, issuerURL string) []string {
 				f := testutil.WriteStringToTempFile(t, "testca-*.pem", issuerCABundle)
 				return []string{
@@ -2900,7 +3164,6 @@ func TestGetKubeconfig(t *testing.T) {
 					"--no-concierge",
 					"--oidc-issuer", issuerURL,
 					"--oidc-ca-bundle", f.Name(),
-					"--upstream-identity-provider-name", "some-ldap-idp",
 					"--upstream-identity-provider-type", "ldap",
 				}
 			},
@@ -2953,7 +3216,7 @@ func TestGetKubeconfig(t *testing.T) {
 			},
 		},
 		{
-			name: "supervisor upstream IDP discovery when flow is specified and no flows were returned by discovery uses the specified flow",
+			name: "supervisor upstream IDP discovery resolves ambiguity when name is specified but type is not",
 			args: func(issuerCABundle string, issuerURL string) []string {
 				f := testutil.WriteStringToTempFile(t, "testca-*.pem", issuerCABundle)
 				return []string{
@@ -2962,8 +3225,7 @@ func TestGetKubeconfig(t *testing.T) {
 					"--no-concierge",
 					"--oidc-issuer", issuerURL,
 					"--oidc-ca-bundle", f.Name(),
-					"--upstream-identity-provider-flow", "foobar",
-					"--upstream-identity-provider-type", "ldap",
+					"--upstream-identity-provider-name", "some-ldap-idp",
 				}
 			},
 			oidcDiscoveryResponse: happyOIDCDiscoveryResponse,
@@ -3004,7 +3266,6 @@ func TestGetKubeconfig(t *testing.T) {
 						  - --ca-bundle-data=%s
 						  - --upstream-identity-provider-name=some-ldap-idp
 						  - --upstream-identity-provider-type=ldap
-						  - --upstream-identity-provider-flow=foobar
 						  command: '.../path/to/pinniped'
 						  env: []
 						  installHint: The pinniped CLI does not appear to be installed.  See https://get.pinniped.dev/cli
@@ -3014,9 +3275,9 @@ func TestGetKubeconfig(t *testing.T) {
 					issuerURL,
 					base64.StdEncoding.EncodeToString([]byte(issuerCABundle)))
 			},
-		},
+		}, // TODO make sure there are active directory tests for various flows
 		{
-			name: "supervisor upstream IDP discovery when flow is specified and it matches a flow returned by discovery uses the specified flow",
+			name: "supervisor upstream IDP discovery when both name and type are specified but flow is not and a matching IDP is found",
 			args: func(issuerCABundle string, issuerURL string) []string {
 				f := testutil.WriteStringToTempFile(t, "testca-*.pem", issuerCABundle)
 				return []string{
@@ -3025,14 +3286,16 @@ func TestGetKubeconfig(t *testing.T) {
 					"--no-concierge",
 					"--oidc-issuer", issuerURL,
 					"--oidc-ca-bundle", f.Name(),
-					"--upstream-identity-provider-flow", "cli_password",
+					"--upstream-identity-provider-name", "some-ldap-idp",
 					"--upstream-identity-provider-type", "ldap",
 				}
 			},
 			oidcDiscoveryResponse: happyOIDCDiscoveryResponse,
 			idpsDiscoveryResponse: here.Docf(`{
 				"pinniped_identity_providers": [
-					{"name": "some-ldap-idp", "type": "ldap", "flows": ["some_flow", "cli_password", "some_other_flow"]}
+					{"name": "some-ldap-idp", "type": "ldap"},
+					{"name": "some-oidc-idp", "type": "oidc"},
+					{"name": "some-other-oidc-idp", "type": "oidc"}
 				]
 			}`),
 			wantStdout: func(issuerCABundle string, issuerURL string) string {
@@ -3065,7 +3328,6 @@ func TestGetKubeconfig(t *testing.T) {
 						  - --ca-bundle-data=%s
 						  - --upstream-identity-provider-name=some-ldap-idp
 						  - --upstream-identity-provider-type=ldap
-						  - --upstream-identity-provider-flow=cli_password
 						  command: '.../path/to/pinniped'
 						  env: []
 						  installHint: The pinniped CLI does not appear to be installed.  See https://get.pinniped.dev/cli
@@ -3077,7 +3339,7 @@ func TestGetKubeconfig(t *testing.T) {
 			},
 		},
 		{
-			name: "supervisor upstream IDP discovery when no flow is specified but there is only one flow returned by discovery uses the discovered flow",
+			name: "supervisor upstream IDP discovery when flow is specified and no flows were returned by discovery uses the specified flow",
 			args: func(issuerCABundle string, issuerURL string) []string {
 				f := testutil.WriteStringToTempFile(t, "testca-*.pem", issuerCABundle)
 				return []string{
@@ -3086,13 +3348,16 @@ func TestGetKubeconfig(t *testing.T) {
 					"--no-concierge",
 					"--oidc-issuer", issuerURL,
 					"--oidc-ca-bundle", f.Name(),
+					"--upstream-identity-provider-flow", "foobar",
 					"--upstream-identity-provider-type", "ldap",
 				}
 			},
 			oidcDiscoveryResponse: happyOIDCDiscoveryResponse,
 			idpsDiscoveryResponse: here.Docf(`{
 				"pinniped_identity_providers": [
-					{"name": "some-ldap-idp", "type": "ldap", "flows": ["cli_password"]}
+					{"name": "some-ldap-idp", "type": "ldap"},
+					{"name": "some-oidc-idp", "type": "oidc"},
+					{"name": "some-other-oidc-idp", "type": "oidc"}
 				]
 			}`),
 			wantStdout: func(issuerCABundle string, issuerURL string) string {
@@ -3125,7 +3390,7 @@ func TestGetKubeconfig(t *testing.T) {
 						  - --ca-bundle-data=%s
 						  - --upstream-identity-provider-name=some-ldap-idp
 						  - --upstream-identity-provider-type=ldap
-						  - --upstream-identity-provider-flow=cli_password
+						  - --upstream-identity-provider-flow=foobar
 						  command: '.../path/to/pinniped'
 						  env: []
 						  installHint: The pinniped CLI does not appear to be installed.  See https://get.pinniped.dev/cli
@@ -3137,32 +3402,27 @@ func TestGetKubeconfig(t *testing.T) {
 			},
 		},
 		{
-			name: "user specified message for install-hint flag",
+			name: "supervisor upstream IDP discovery when flow is specified and it matches a flow returned by discovery uses the specified flow",
 			args: func(issuerCABundle string, issuerURL string) []string {
+				f := testutil.WriteStringToTempFile(t, "testca-*.pem", issuerCABundle)
 				return []string{
 					"--kubeconfig", "./testdata/kubeconfig.yaml",
-					"--install-hint", "Test installHint message",
-					"--static-token", "test-token",
 					"--skip-validation",
+					"--no-concierge",
+					"--oidc-issuer", issuerURL,
+					"--oidc-ca-bundle", f.Name(),
+					"--upstream-identity-provider-flow", "cli_password",
+					"--upstream-identity-provider-type", "ldap",
 				}
 			},
-			conciergeObjects: func(issuerCABundle string, issuerURL string) []runtime.Object {
-				return []runtime.Object{
-					credentialIssuer(),
-					&authenticationv1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
-				}
-			},
-			wantLogs: func(issuerCABundle string, issuerURL string) []string {
-				return []string{
-					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered CredentialIssuer  {"name": "test-credential-issuer"}`,
-					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge operating in TokenCredentialRequest API mode`,
-					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge endpoint  {"endpoint": "https://fake-server-url-value"}`,
-					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge certificate authority bundle  {"roots": 0}`,
-					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered WebhookAuthenticator  {"name": "test-authenticator"}`,
-				}
-			},
+			oidcDiscoveryResponse: happyOIDCDiscoveryResponse,
+			idpsDiscoveryResponse: here.Docf(`{
+				"pinniped_identity_providers": [
+					{"name": "some-ldap-idp", "type": "ldap", "flows": ["some_flow", "cli_password", "some_other_flow"]}
+				]
+			}`),
 			wantStdout: func(issuerCABundle string, issuerURL string) string {
-				return here.Doc(`
+				return here.Docf(`
 					apiVersion: v1
 					clusters:
 					- cluster:
@@ -3184,18 +3444,725 @@ func TestGetKubeconfig(t *testing.T) {
 						  apiVersion: client.authentication.k8s.io/v1beta1
 						  args:
 						  - login
-						  - static
-						  - --enable-concierge
-						  - --concierge-api-group-suffix=pinniped.dev
-						  - --concierge-authenticator-name=test-authenticator
-						  - --concierge-authenticator-type=webhook
-						  - --concierge-endpoint=https://fake-server-url-value
-						  - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
-						  - --token=test-token
+						  - oidc
+						  - --issuer=%s
+						  - --client-id=pinniped-cli
+						  - --scopes=offline_access,openid,pinniped:request-audience,username,groups
+						  - --ca-bundle-data=%s
+						  - --upstream-identity-provider-name=some-ldap-idp
+						  - --upstream-identity-provider-type=ldap
+						  - --upstream-identity-provider-flow=cli_password
 						  command: '.../path/to/pinniped'
 						  env: []
-						  installHint: Test installHint message
-						  provideClusterInfo: true
+						  installHint: The pinniped CLI does not appear to be installed.  See https://get.pinniped.dev/cli
+						    for more details
+						  provideClusterInfo: true
+					`,
+					issuerURL,
+					base64.StdEncoding.EncodeToString([]byte(issuerCABundle)))
+			},
+		},
+		{
+			name: "supervisor upstream IDP discovery when no flow is specified but there is only one flow returned by discovery uses the discovered flow",
+			args: func(issuerCABundle string, issuerURL string) []string {
+				f := testutil.WriteStringToTempFile(t, "testca-*.pem", issuerCABundle)
+				return []string{
+					"--kubeconfig", "./testdata/kubeconfig.yaml",
+					"--skip-validation",
+					"--no-concierge",
+					"--oidc-issuer", issuerURL,
+					"--oidc-ca-bundle", f.Name(),
+					"--upstream-identity-provider-type", "ldap",
+				}
+			},
+			oidcDiscoveryResponse: happyOIDCDiscoveryResponse,
+			idpsDiscoveryResponse: here.Docf(`{
+				"pinniped_identity_providers": [
+					{"name": "some-ldap-idp", "type": "ldap", "flows": ["cli_password"]}
+				]
+			}`),
+			wantStdout: func(issuerCABundle string, issuerURL string) string {
+				return here.Docf(`
+					apiVersion: v1
+					clusters:
+					- cluster:
+						certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+						server: https://fake-server-url-value
+					  name: kind-cluster-pinniped
+					contexts:
+					- context:
+						cluster: kind-cluster-pinniped
+						user: kind-user-pinniped
+					  name: kind-context-pinniped
+					current-context: kind-context-pinniped
+					kind: Config
+					preferences: {}
+					users:
+					- name: kind-user-pinniped
+					  user:
+						exec:
+						  apiVersion: client.authentication.k8s.io/v1beta1
+						  args:
+						  - login
+						  - oidc
+						  - --issuer=%s
+						  - --client-id=pinniped-cli
+						  - --scopes=offline_access,openid,pinniped:request-audience,username,groups
+						  - --ca-bundle-data=%s
+						  - --upstream-identity-provider-name=some-ldap-idp
+						  - --upstream-identity-provider-type=ldap
+						  - --upstream-identity-provider-flow=cli_password
+						  command: '.../path/to/pinniped'
+						  env: []
+						  installHint: The pinniped CLI does not appear to be installed.  See https://get.pinniped.dev/cli
+						    for more details
+						  provideClusterInfo: true
+					`,
+					issuerURL,
+					base64.StdEncoding.EncodeToString([]byte(issuerCABundle)))
+			},
+		},
+		{
+			name: "multiple cluster flags",
+			args: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					"--kubeconfig", "./testdata/kubeconfig.yaml",
+					"--kubeconfig-context", "kind-context",
+					"--kubeconfig-contexts", "kind-context,some-other-context",
+				}
+			},
+			wantError: true,
+			wantStderr: func(issuerCABundle string, issuerURL string) testutil.RequireErrorStringFunc {
+				return testutil.WantExactErrorString("Error: only one of --kubeconfig-context, --kubeconfig-contexts, and --fleet-file can be specified\n")
+			},
+		},
+		{
+			name: "multiple clusters, one of which has an invalid context",
+			args: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					"--kubeconfig", "./testdata/kubeconfig.yaml",
+					"--kubeconfig-contexts", "kind-context,invalid-context-no-such-user",
+					"--static-token", "test-token",
+					"--skip-validation",
+				}
+			},
+			conciergeObjects: func(issuerCABundle string, issuerURL string) []runtime.Object {
+				return []runtime.Object{
+					credentialIssuer(),
+					&authenticationv1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
+				}
+			},
+			wantLogs: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  generating kubeconfig for cluster  {"name": "kind-context"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered CredentialIssuer  {"name": "test-credential-issuer"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge operating in TokenCredentialRequest API mode`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge endpoint  {"endpoint": "https://fake-server-url-value"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge certificate authority bundle  {"roots": 0}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered WebhookAuthenticator  {"name": "test-authenticator"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  generating kubeconfig for cluster  {"name": "invalid-context-no-such-user"}`,
+				}
+			},
+			wantError: true,
+			wantStderr: func(issuerCABundle string, issuerURL string) testutil.RequireErrorStringFunc {
+				return testutil.WantExactErrorString(`Error: could not generate kubeconfig for cluster "invalid-context-no-such-user": could not load --kubeconfig/--kubeconfig-context: no such user "invalid-user"` + "\n")
+			},
+		},
+		{
+			name: "fleet file which does not exist",
+			args: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					"--kubeconfig", "./testdata/kubeconfig.yaml",
+					"--fleet-file", "./testdata/does-not-exist.yaml",
+				}
+			},
+			wantError: true,
+			wantStderr: func(issuerCABundle string, issuerURL string) testutil.RequireErrorStringFunc {
+				return testutil.WantExactErrorString("Error: could not read --fleet-file: open ./testdata/does-not-exist.yaml: no such file or directory\n")
+			},
+		},
+		{
+			name: "fleet file with an unknown field",
+			args: func(issuerCABundle string, issuerURL string) []string {
+				f := testutil.WriteStringToTempFile(t, "fleet-*.yaml", "clusters:\n- name: some-cluster\n  contxt: kind-context\n")
+				return []string{
+					"--kubeconfig", "./testdata/kubeconfig.yaml",
+					"--fleet-file", f.Name(),
+				}
+			},
+			wantError: true,
+			wantStderr: func(issuerCABundle string, issuerURL string) testutil.RequireErrorStringFunc {
+				return testutil.WantExactErrorString(`Error: could not parse --fleet-file: error unmarshaling JSON: while decoding JSON: json: unknown field "contxt"` + "\n")
+			},
+		},
+		{
+			name: "fleet file without any clusters",
+			args: func(issuerCABundle string, issuerURL string) []string {
+				f := testutil.WriteStringToTempFile(t, "fleet-*.yaml", "clusters: []\n")
+				return []string{
+					"--kubeconfig", "./testdata/kubeconfig.yaml",
+					"--fleet-file", f.Name(),
+				}
+			},
+			wantError: true,
+			wantStderr: func(issuerCABundle string, issuerURL string) testutil.RequireErrorStringFunc {
+				return testutil.WantExactErrorString("Error: --fleet-file does not list any clusters\n")
+			},
+		},
+		{
+			name: "fleet file with the same name for more than one cluster",
+			args: func(issuerCABundle string, issuerURL string) []string {
+				f := testutil.WriteStringToTempFile(t, "fleet-*.yaml", "clusters:\n- context: kind-context\n- context: kind-context\n")
+				return []string{
+					"--kubeconfig", "./testdata/kubeconfig.yaml",
+					"--fleet-file", f.Name(),
+					"--no-concierge",
+					"--static-token", "test-token",
+					"--skip-validation",
+				}
+			},
+			wantLogs: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  generating kubeconfig for cluster  {"name": "kind-context"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  generating kubeconfig for cluster  {"name": "kind-context"}`,
+				}
+			},
+			wantError: true,
+			wantStderr: func(issuerCABundle string, issuerURL string) testutil.RequireErrorStringFunc {
+				return testutil.WantExactErrorString(`Error: more than one cluster is named "kind-context"` + "\n")
+			},
+		},
+		{
+			name: "multiple clusters with autodetected JWT authenticator reuse Supervisor discovery",
+			args: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					"--kubeconfig", "./testdata/kubeconfig.yaml",
+					"--kubeconfig-contexts", "kind-context,some-other-context",
+					"--skip-validation",
+				}
+			},
+			conciergeObjects: func(issuerCABundle string, issuerURL string) []runtime.Object {
+				return []runtime.Object{
+					credentialIssuer(),
+					jwtAuthenticator(issuerCABundle, issuerURL),
+				}
+			},
+			oidcDiscoveryResponse: happyOIDCDiscoveryResponse,
+			idpsDiscoveryResponse: here.Docf(`{
+				"pinniped_identity_providers": [
+					{"name": "some-ldap-idp", "type": "ldap"}
+				]
+			}`),
+			wantLogs: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  generating kubeconfig for cluster  {"name": "kind-context"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered CredentialIssuer  {"name": "test-credential-issuer"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge operating in TokenCredentialRequest API mode`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge endpoint  {"endpoint": "https://fake-server-url-value"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge certificate authority bundle  {"roots": 0}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered JWTAuthenticator  {"name": "test-authenticator"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered OIDC issuer  {"issuer": "` + issuerURL + `"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered OIDC audience  {"audience": "test-audience"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered OIDC CA bundle  {"roots": 1}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  generating kubeconfig for cluster  {"name": "some-other-context"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered CredentialIssuer  {"name": "test-credential-issuer"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge operating in TokenCredentialRequest API mode`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge endpoint  {"endpoint": "https://some-other-fake-server-url-value"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge certificate authority bundle  {"roots": 0}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered JWTAuthenticator  {"name": "test-authenticator"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered OIDC issuer  {"issuer": "` + issuerURL + `"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered OIDC audience  {"audience": "test-audience"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered OIDC CA bundle  {"roots": 1}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  reusing previously discovered Supervisor settings  {"issuer": "` + issuerURL + `"}`,
+				}
+			},
+			wantStdout: func(issuerCABundle string, issuerURL string) string {
+				return here.Docf(`
+					apiVersion: v1
+					clusters:
+					- cluster:
+						certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+						server: https://fake-server-url-value
+					  name: kind-context-pinniped
+					- cluster:
+						certificate-authority-data: c29tZS1vdGhlci1mYWtlLWNlcnRpZmljYXRlLWF1dGhvcml0eS1kYXRhLXZhbHVl
+						server: https://some-other-fake-server-url-value
+					  name: some-other-context-pinniped
+					contexts:
+					- context:
+						cluster: kind-context-pinniped
+						user: kind-context-pinniped
+					  name: kind-context-pinniped
+					- context:
+						cluster: some-other-context-pinniped
+						user: some-other-context-pinniped
+					  name: some-other-context-pinniped
+					current-context: kind-context-pinniped
+					kind: Config
+					preferences: {}
+					users:
+					- name: kind-context-pinniped
+					  user:
+						exec:
+						  apiVersion: client.authentication.k8s.io/v1beta1
+						  args:
+						  - login
+						  - oidc
+						  - --enable-concierge
+						  - --concierge-api-group-suffix=pinniped.dev
+						  - --concierge-authenticator-name=test-authenticator
+						  - --concierge-authenticator-type=jwt
+						  - --concierge-endpoint=https://fake-server-url-value
+						  - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+						  - --issuer=%s
+						  - --client-id=pinniped-cli
+						  - --scopes=offline_access,openid,pinniped:request-audience,username,groups
+						  - --ca-bundle-data=%s
+						  - --request-audience=test-audience
+						  - --upstream-identity-provider-name=some-ldap-idp
+						  - --upstream-identity-provider-type=ldap
+						  command: '.../path/to/pinniped'
+						  env: []
+						  installHint: The pinniped CLI does not appear to be installed.  See https://get.pinniped.dev/cli
+						    for more details
+						  provideClusterInfo: true
+					- name: some-other-context-pinniped
+					  user:
+						exec:
+						  apiVersion: client.authentication.k8s.io/v1beta1
+						  args:
+						  - login
+						  - oidc
+						  - --enable-concierge
+						  - --concierge-api-group-suffix=pinniped.dev
+						  - --concierge-authenticator-name=test-authenticator
+						  - --concierge-authenticator-type=jwt
+						  - --concierge-endpoint=https://some-other-fake-server-url-value
+						  - --concierge-ca-bundle-data=c29tZS1vdGhlci1mYWtlLWNlcnRpZmljYXRlLWF1dGhvcml0eS1kYXRhLXZhbHVl
+						  - --issuer=%s
+						  - --client-id=pinniped-cli
+						  - --scopes=offline_access,openid,pinniped:request-audience,username,groups
+						  - --ca-bundle-data=%s
+						  - --request-audience=test-audience
+						  - --upstream-identity-provider-name=some-ldap-idp
+						  - --upstream-identity-provider-type=ldap
+						  command: '.../path/to/pinniped'
+						  env: []
+						  installHint: The pinniped CLI does not appear to be installed.  See https://get.pinniped.dev/cli
+						    for more details
+						  provideClusterInfo: true
+					`,
+					issuerURL,
+					base64.StdEncoding.EncodeToString([]byte(issuerCABundle)),
+					issuerURL,
+					base64.StdEncoding.EncodeToString([]byte(issuerCABundle)))
+			},
+		},
+		{
+			name: "fleet file with static tokens",
+			args: func(issuerCABundle string, issuerURL string) []string {
+				kubeconfigPath, err := filepath.Abs("./testdata/kubeconfig.yaml")
+				require.NoError(t, err)
+				f := testutil.WriteStringToTempFile(t, "fleet-*.yaml", here.Docf(`
+					clusters:
+					- name: cluster-a
+					  kubeconfig: %s
+					  context: some-other-context
+					- name: cluster-b
+					`, kubeconfigPath))
+				return []string{
+					"--kubeconfig", "./testdata/kubeconfig.yaml",
+					"--fleet-file", f.Name(),
+					"--no-concierge",
+					"--static-token", "test-token",
+					"--generated-name-suffix", "-fleet",
+					"--skip-validation",
+				}
+			},
+			wantLogs: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  generating kubeconfig for cluster  {"name": "cluster-a"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  generating kubeconfig for cluster  {"name": "cluster-b"}`,
+				}
+			},
+			wantStdout: func(issuerCABundle string, issuerURL string) string {
+				return here.Doc(`
+					apiVersion: v1
+					clusters:
+					- cluster:
+						certificate-authority-data: c29tZS1vdGhlci1mYWtlLWNlcnRpZmljYXRlLWF1dGhvcml0eS1kYXRhLXZhbHVl
+						server: https://some-other-fake-server-url-value
+					  name: cluster-a-fleet
+					- cluster:
+						certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+						server: https://fake-server-url-value
+					  name: cluster-b-fleet
+					contexts:
+					- context:
+						cluster: cluster-a-fleet
+						user: cluster-a-fleet
+					  name: cluster-a-fleet
+					- context:
+						cluster: cluster-b-fleet
+						user: cluster-b-fleet
+					  name: cluster-b-fleet
+					current-context: cluster-a-fleet
+					kind: Config
+					preferences: {}
+					users:
+					- name: cluster-a-fleet
+					  user:
+						exec:
+						  apiVersion: client.authentication.k8s.io/v1beta1
+						  args:
+						  - login
+						  - static
+						  - --token=test-token
+						  command: '.../path/to/pinniped'
+						  env: []
+						  installHint: The pinniped CLI does not appear to be installed.  See https://get.pinniped.dev/cli
+						    for more details
+						  provideClusterInfo: true
+					- name: cluster-b-fleet
+					  user:
+						exec:
+						  apiVersion: client.authentication.k8s.io/v1beta1
+						  args:
+						  - login
+						  - static
+						  - --token=test-token
+						  command: '.../path/to/pinniped'
+						  env: []
+						  installHint: The pinniped CLI does not appear to be installed.  See https://get.pinniped.dev/cli
+						    for more details
+						  provideClusterInfo: true
+				`)
+			},
+		},
+		{
+			name: "validate and skip validation",
+			args: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					"--kubeconfig", "./testdata/kubeconfig.yaml",
+					"--validate",
+					"--skip-validation",
+				}
+			},
+			wantError: true,
+			wantStderr: func(issuerCABundle string, issuerURL string) testutil.RequireErrorStringFunc {
+				return testutil.WantExactErrorString("Error: --validate and --skip-validation cannot be used together\n")
+			},
+		},
+		{
+			name: "validate with all checks passing",
+			args: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					"--kubeconfig", reachableClusterKubeconfigPath,
+					"--validate",
+				}
+			},
+			conciergeObjects: func(issuerCABundle string, issuerURL string) []runtime.Object {
+				return []runtime.Object{
+					credentialIssuer(),
+					jwtAuthenticator(issuerCABundle, issuerURL),
+				}
+			},
+			oidcDiscoveryResponse: happyOIDCDiscoveryResponse,
+			idpsDiscoveryResponse: here.Docf(`{
+				"pinniped_identity_providers": [
+					{"name": "some-ldap-idp", "type": "ldap"}
+				]
+			}`),
+			wantLogs: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered CredentialIssuer  {"name": "test-credential-issuer"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge operating in TokenCredentialRequest API mode`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge endpoint  {"endpoint": "` + reachableCluster.URL + `"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge certificate authority bundle  {"roots": 1}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered JWTAuthenticator  {"name": "test-authenticator"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered OIDC issuer  {"issuer": "` + issuerURL + `"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered OIDC audience  {"audience": "test-audience"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered OIDC CA bundle  {"roots": 1}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  validated connection to the cluster`,
+				}
+			},
+			wantStdout: func(issuerCABundle string, issuerURL string) string {
+				return here.Docf(`
+					[PASS] Kubeconfig: using context "reachable-context" with server %s
+					[PASS] Concierge strategy: using TokenCredentialRequestAPI mode at %s
+					[PASS] Concierge authenticator: using jwt authenticator "test-authenticator"
+					[PASS] OIDC issuer: discovered %s
+					[PASS] OIDC CA bundle: the issuer's certificate is trusted by 1 root(s)
+					[PASS] OIDC audience: requesting tokens for audience "test-audience"
+					[PASS] Cluster connection: connected to %s
+					All checks passed.
+					`,
+					reachableCluster.URL, reachableCluster.URL, issuerURL, reachableCluster.URL)
+			},
+		},
+		{
+			name: "validate with all checks passing as JSON",
+			args: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					"--kubeconfig", reachableClusterKubeconfigPath,
+					"--validate",
+					"--output-format", "json",
+				}
+			},
+			conciergeObjects: func(issuerCABundle string, issuerURL string) []runtime.Object {
+				return []runtime.Object{
+					credentialIssuer(),
+					jwtAuthenticator(issuerCABundle, issuerURL),
+				}
+			},
+			oidcDiscoveryResponse: happyOIDCDiscoveryResponse,
+			idpsDiscoveryResponse: here.Docf(`{
+				"pinniped_identity_providers": [
+					{"name": "some-ldap-idp", "type": "ldap"}
+				]
+			}`),
+			wantLogs: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered CredentialIssuer  {"name": "test-credential-issuer"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge operating in TokenCredentialRequest API mode`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge endpoint  {"endpoint": "` + reachableCluster.URL + `"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge certificate authority bundle  {"roots": 1}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered JWTAuthenticator  {"name": "test-authenticator"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered OIDC issuer  {"issuer": "` + issuerURL + `"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered OIDC audience  {"audience": "test-audience"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered OIDC CA bundle  {"roots": 1}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  validated connection to the cluster`,
+				}
+			},
+			wantStdout: func(issuerCABundle string, issuerURL string) string {
+				return here.Docf(`
+					{
+					  "apiVersion": "cli.pinniped.dev/v1alpha1",
+					  "kind": "KubeconfigValidation",
+					  "clusters": [
+					    {
+					      "checks": [
+					        {
+					          "name": "Kubeconfig",
+					          "status": "PASS",
+					          "detail": "using context \"reachable-context\" with server %s"
+					        },
+					        {
+					          "name": "Concierge strategy",
+					          "status": "PASS",
+					          "detail": "using TokenCredentialRequestAPI mode at %s"
+					        },
+					        {
+					          "name": "Concierge authenticator",
+					          "status": "PASS",
+					          "detail": "using jwt authenticator \"test-authenticator\""
+					        },
+					        {
+					          "name": "OIDC issuer",
+					          "status": "PASS",
+					          "detail": "discovered %s"
+					        },
+					        {
+					          "name": "OIDC CA bundle",
+					          "status": "PASS",
+					          "detail": "the issuer's certificate is trusted by 1 root(s)"
+					        },
+					        {
+					          "name": "OIDC audience",
+					          "status": "PASS",
+					          "detail": "requesting tokens for audience \"test-audience\""
+					        },
+					        {
+					          "name": "Cluster connection",
+					          "status": "PASS",
+					          "detail": "connected to %s"
+					        }
+					      ],
+					      "passed": true
+					    }
+					  ]
+					}
+					`,
+					reachableCluster.URL, reachableCluster.URL, issuerURL, reachableCluster.URL)
+			},
+		},
+		{
+			name: "invalid output format",
+			args: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					"--kubeconfig", "./testdata/kubeconfig.yaml",
+					"--output-format", "text",
+				}
+			},
+			wantError: true,
+			wantStderr: func(issuerCABundle string, issuerURL string) testutil.RequireErrorStringFunc {
+				return testutil.WantExactErrorString("Error: unknown output format: \"text\"\n")
+			},
+		},
+		{
+			name: "validate with an OIDC CA bundle which does not trust the issuer",
+			args: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					"--kubeconfig", "./testdata/kubeconfig.yaml",
+					"--validate",
+				}
+			},
+			conciergeObjects: func(issuerCABundle string, issuerURL string) []runtime.Object {
+				return []runtime.Object{
+					credentialIssuer(),
+					jwtAuthenticator(string(testOIDCCA.Bundle()), issuerURL),
+				}
+			},
+			oidcDiscoveryResponse: happyOIDCDiscoveryResponse,
+			wantLogs: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered CredentialIssuer  {"name": "test-credential-issuer"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge operating in TokenCredentialRequest API mode`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge endpoint  {"endpoint": "https://fake-server-url-value"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge certificate authority bundle  {"roots": 0}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered JWTAuthenticator  {"name": "test-authenticator"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered OIDC issuer  {"issuer": "` + issuerURL + `"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered OIDC audience  {"audience": "test-audience"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered OIDC CA bundle  {"roots": 1}`,
+				}
+			},
+			wantStdout: func(issuerCABundle string, issuerURL string) string {
+				return here.Docf(`
+					[PASS] Kubeconfig: using context "kind-context" with server https://fake-server-url-value
+					[PASS] Concierge strategy: using TokenCredentialRequestAPI mode at https://fake-server-url-value
+					[PASS] Concierge authenticator: using jwt authenticator "test-authenticator"
+					[PASS] OIDC issuer: reached %s
+					[FAIL] OIDC CA bundle: while fetching OIDC discovery data from issuer: Get "%s/.well-known/openid-configuration": tls: failed to verify certificate: x509: certificate signed by unknown authority
+					       Hint: set spec.tls.certificateAuthorityData of the JWTAuthenticator (or --oidc-ca-bundle) to the CA bundle which issued the issuer's serving certificate
+					The remaining checks were skipped. The generated kubeconfig would not work.
+					`,
+					issuerURL, issuerURL)
+			},
+			wantError: true,
+			wantStderr: func(issuerCABundle string, issuerURL string) testutil.RequireErrorStringFunc {
+				return testutil.WantExactErrorString("Error: kubeconfig validation failed\n")
+			},
+		},
+		{
+			name: "validate multiple clusters with a reserved audience",
+			args: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					"--kubeconfig", "./testdata/kubeconfig.yaml",
+					"--kubeconfig-contexts", "kind-context,invalid-context-no-such-cluster",
+					"--validate",
+				}
+			},
+			conciergeObjects: func(issuerCABundle string, issuerURL string) []runtime.Object {
+				return []runtime.Object{
+					credentialIssuer(),
+					&authenticationv1alpha1.JWTAuthenticator{
+						ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"},
+						Spec: authenticationv1alpha1.JWTAuthenticatorSpec{
+							Issuer:   issuerURL,
+							Audience: "pinniped-cli",
+							TLS: &authenticationv1alpha1.TLSSpec{
+								CertificateAuthorityData: base64.StdEncoding.EncodeToString([]byte(issuerCABundle)),
+							},
+						},
+					},
+				}
+			},
+			oidcDiscoveryResponse: happyOIDCDiscoveryResponse,
+			wantLogs: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered CredentialIssuer  {"name": "test-credential-issuer"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge operating in TokenCredentialRequest API mode`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge endpoint  {"endpoint": "https://fake-server-url-value"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge certificate authority bundle  {"roots": 0}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered JWTAuthenticator  {"name": "test-authenticator"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered OIDC issuer  {"issuer": "` + issuerURL + `"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered OIDC audience  {"audience": "pinniped-cli"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered OIDC CA bundle  {"roots": 1}`,
+				}
+			},
+			wantStdout: func(issuerCABundle string, issuerURL string) string {
+				return here.Docf(`
+					Cluster "kind-context":
+					  [PASS] Kubeconfig: using context "kind-context" with server https://fake-server-url-value
+					  [PASS] Concierge strategy: using TokenCredentialRequestAPI mode at https://fake-server-url-value
+					  [PASS] Concierge authenticator: using jwt authenticator "test-authenticator"
+					  [PASS] OIDC issuer: discovered %s
+					  [PASS] OIDC CA bundle: the issuer's certificate is trusted by 1 root(s)
+					  [FAIL] OIDC audience: audience "pinniped-cli" is not allowed because it is the client ID of the pinniped CLI
+					         Hint: set spec.audience of the JWTAuthenticator (or --oidc-request-audience) to a unique value for this cluster, and include the required scope in --oidc-scopes
+					  The remaining checks were skipped. The generated kubeconfig would not work.
+					Cluster "invalid-context-no-such-cluster":
+					  [FAIL] Kubeconfig: could not load --kubeconfig/--kubeconfig-context: no such cluster "invalid-cluster"
+					         Hint: check that the context exists in the kubeconfig and refers to an existing cluster and user
+					  The remaining checks were skipped. The generated kubeconfig would not work.
+					`,
+					issuerURL)
+			},
+			wantError: true,
+			wantStderr: func(issuerCABundle string, issuerURL string) testutil.RequireErrorStringFunc {
+				return testutil.WantExactErrorString("Error: kubeconfig validation failed\n")
+			},
+		},
+		{
+			name: "user specified message for install-hint flag",
+			args: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					"--kubeconfig", "./testdata/kubeconfig.yaml",
+					"--install-hint", "Test installHint message",
+					"--static-token", "test-token",
+					"--skip-validation",
+				}
+			},
+			conciergeObjects: func(issuerCABundle string, issuerURL string) []runtime.Object {
+				return []runtime.Object{
+					credentialIssuer(),
+					&authenticationv1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
+				}
+			},
+			wantLogs: func(issuerCABundle string, issuerURL string) []string {
+				return []string{
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered CredentialIssuer  {"name": "test-credential-issuer"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge operating in TokenCredentialRequest API mode`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge endpoint  {"endpoint": "https://fake-server-url-value"}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered Concierge certificate authority bundle  {"roots": 0}`,
+					`2099-08-08T13:57:36.123456Z  info  cmd/kubeconfig.go:<line>  discovered WebhookAuthenticator  {"name": "test-authenticator"}`,
+				}
+			},
+			wantStdout: func(issuerCABundle string, issuerURL string) string {
+				return here.Doc(`
+					apiVersion: v1
+					clusters:
+					- cluster:
+						certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+						server: https://fake-server-url-value
+					  name: kind-cluster-pinniped
+					contexts:
+					- context:
+						cluster: kind-cluster-pinniped
+						user: kind-user-pinniped
+					  name: kind-context-pinniped
+					current-context: kind-context-pinniped
+					kind: Config
+					preferences: {}
+					users:
+					- name: kind-user-pinniped
+					  user:
+						exec:
+						  apiVersion: client.authentication.k8s.io/v1beta1
+						  args:
+						  - login
+						  - static
+						  - --enable-concierge
+						  - --concierge-api-group-suffix=pinniped.dev
+						  - --concierge-authenticator-name=test-authenticator
+						  - --concierge-authenticator-type=webhook
+						  - --concierge-endpoint=https://fake-server-url-value
+						  - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+						  - --token=test-token
+						  command: '.../path/to/pinniped'
+						  env: []
+						  installHint: Test installHint message
+						  provideClusterInfo: true
 				`)
 			},
 		},
@@ -3228,6 +4195,9 @@ func TestGetKubeconfig(t *testing.T) {
 					w.WriteHeader(tt.idpsDiscoveryStatusCode)
 					_, err = w.Write([]byte(jsonResponseBody))
 					require.NoError(t, err)
+				case "/v1alpha1/pinniped_cluster_audiences":
+					_, err = w.Write([]byte(tt.clusterAudiencesDiscoveryResponse))
+					require.NoError(t, err)
 				default:
 					t.Fatalf("tried to call issuer at a path that wasn't one of the expected discovery endpoints.")
 				}
@@ -3262,6 +4232,14 @@ func TestGetKubeconfig(t *testing.T) {
 					return fake, nil
 				},
 				log: plog.TestConsoleLogger(t, &log),
+				promptForChoice: func(ctx context.Context, label string, choices []string) (int, error) {
+					require.Equal(t, "Multiple Supervisor upstream identity providers were found. Choose one:", label)
+					require.Equal(t, []string{"some-ldap-idp (ldap)", "some-oidc-idp (oidc)", "some-github-idp (github)"}, choices)
+					if tt.promptChoice == nil && tt.promptErr == nil {
+						return 0, errNotInteractive
+					}
+					return ptr.Deref(tt.promptChoice, 0), tt.promptErr
+				},
 			})
 			require.NotNil(t, cmd)
 
@@ -3302,3 +4280,78 @@ func TestGetKubeconfig(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteConfigAsJSON(t *testing.T) {
+	config := clientcmdapi.Config{
+		Kind:       "Config",
+		APIVersion: clientcmdapi.SchemeGroupVersion.Version,
+		Clusters: map[string]*clientcmdapi.Cluster{
+			"some-cluster": {
+				Server:                   "https://some-server.example.com",
+				CertificateAuthorityData: []byte("some-ca-bundle"),
+			},
+		},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{
+			"some-user": {
+				Exec: &clientcmdapi.ExecConfig{
+					APIVersion:         "client.authentication.k8s.io/v1beta1",
+					Command:            "pinniped",
+					Args:               []string{"login", "static", "--token=some-token"},
+					InstallHint:        "some-install-hint",
+					ProvideClusterInfo: true,
+					InteractiveMode:    clientcmdapi.IfAvailableExecInteractiveMode,
+				},
+			},
+		},
+		Contexts: map[string]*clientcmdapi.Context{
+			"some-context": {Cluster: "some-cluster", AuthInfo: "some-user"},
+		},
+		CurrentContext: "some-context",
+	}
+
+	var jsonOutput bytes.Buffer
+	require.NoError(t, writeConfig(&jsonOutput, outputFormatJSON, config))
+
+	// The output must be a valid kubeconfig in JSON, which tools other than kubectl can unmarshal.
+	var unmarshalled clientcmdv1.Config
+	require.NoError(t, json.Unmarshal(jsonOutput.Bytes(), &unmarshalled))
+	require.Equal(t, clientcmdv1.Config{
+		Kind:        "Config",
+		APIVersion:  "v1",
+		Preferences: clientcmdv1.Preferences{},
+		Clusters: []clientcmdv1.NamedCluster{{
+			Name: "some-cluster",
+			Cluster: clientcmdv1.Cluster{
+				Server:                   "https://some-server.example.com",
+				CertificateAuthorityData: []byte("some-ca-bundle"),
+			},
+		}},
+		AuthInfos: []clientcmdv1.NamedAuthInfo{{
+			Name: "some-user",
+			AuthInfo: clientcmdv1.AuthInfo{
+				Exec: &clientcmdv1.ExecConfig{
+					APIVersion:         "client.authentication.k8s.io/v1beta1",
+					Command:            "pinniped",
+					Args:               []string{"login", "static", "--token=some-token"},
+					InstallHint:        "some-install-hint",
+					ProvideClusterInfo: true,
+					InteractiveMode:    clientcmdv1.IfAvailableExecInteractiveMode,
+				},
+			},
+		}},
+		Contexts: []clientcmdv1.NamedContext{{
+			Name:    "some-context",
+			Context: clientcmdv1.Context{Cluster: "some-cluster", AuthInfo: "some-user"},
+		}},
+		CurrentContext: "some-context",
+	}, unmarshalled)
+
+	// The JSON and YAML output must describe the same kubeconfig.
+	var yamlOutput bytes.Buffer
+	require.NoError(t, writeConfig(&yamlOutput, outputFormatYAML, config))
+	fromJSON, err := clientcmd.Load(jsonOutput.Bytes())
+	require.NoError(t, err)
+	fromYAML, err := clientcmd.Load(yamlOutput.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, fromYAML, fromJSON)
+}
@@ -1,4 +1,4 @@
-// Copyright 2020-2023 the Pinniped contributors. All Rights Reserved.
+// Copyright 2020-2024 the Pinniped contributors. All Rights Reserved.
 // SPDX-License-Identifier: Apache-2.0
 
 package cmd
@@ -14,9 +14,9 @@ import (
 //nolint:gochecknoglobals
 var loginCmd = &cobra.Command{
 	Use:   "login",
-	Short: "Authenticates with one of [oidc, static]",
+	Short: "Authenticates with one of [oidc, static, request]",
 	Long: here.Doc(
-		`Authenticates with one of [oidc, static]
+		`Authenticates with one of [oidc, static, request]
 
 			Use "pinniped get kubeconfig" to generate a kubeconfig file which will include
 			one of these login subcommands in its configuration. The oidc and static
@@ -25,7 +25,11 @@ var loginCmd = &cobra.Command{
 			The oidc and static subcommands are Kubernetes client-go credential plugins
 			which are meant to be configured inside a kubeconfig file. (See the Kubernetes
 			authentication documentation for more information about client-go credential
-			plugins.)`,
+			plugins.)
+
+			The request subcommand is meant to be invoked directly by API clients which are
+			not kubectl, such as dashboards and continuous delivery systems, to get
+			credentials for a cluster non-interactively.`,
 	),
 	SilenceUsage: true, // Do not print usage message when commands fail.
 }
@@ -9,9 +9,12 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -25,6 +28,7 @@ import (
 	"go.pinniped.dev/internal/here"
 	"go.pinniped.dev/internal/net/phttp"
 	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/pkg/cacheencryption"
 	"go.pinniped.dev/pkg/conciergeclient"
 	"go.pinniped.dev/pkg/oidcclient"
 	"go.pinniped.dev/pkg/oidcclient/filesession"
@@ -48,6 +52,10 @@ const (
 	// Set this env var to "true" to cause debug logs to be printed to stderr.
 	debugEnvVarName = "PINNIPED_DEBUG"
 
+	// Set this env var to the path of a file containing a base64 encoded 32 byte key to encrypt the session and
+	// credential cache files with AES-256-GCM. Such a key can be generated with `openssl rand -base64 32`.
+	cacheEncryptionKeyFileEnvVarName = "PINNIPED_CACHE_ENCRYPTION_KEY_FILE"
+
 	// The value to use for true/false env vars to enable the behavior caused by the env var.
 	envVarTruthyValue = "true"
 )
@@ -85,8 +93,12 @@ type oidcLoginFlags struct {
 	sessionCachePath             string
 	caBundlePaths                []string
 	caBundleData                 []string
+	caBundleFromKubeconfig       bool
+	caBundleSystemRoots          bool
 	debugSessionCache            bool
 	requestAudience              string
+	enableDPoP                   bool
+	clientAssertionKeyFile       string
 	conciergeEnabled             bool
 	conciergeAuthenticatorType   string
 	conciergeAuthenticatorName   string
@@ -97,6 +109,8 @@ type oidcLoginFlags struct {
 	upstreamIdentityProviderName string
 	upstreamIdentityProviderType string
 	upstreamIdentityProviderFlow string
+	language                     string
+	credentialOutput             string
 }
 
 func oidcLoginCommand(deps oidcLoginCommandDeps) *cobra.Command {
@@ -127,11 +141,15 @@ func oidcLoginCommand(deps oidcLoginCommandDeps) *cobra.Command {
 	cmd.Flags().StringSliceVar(&flags.scopes, "scopes", []string{oidcapi.ScopeOfflineAccess, oidcapi.ScopeOpenID, oidcapi.ScopeRequestAudience, oidcapi.ScopeUsername, oidcapi.ScopeGroups}, "OIDC scopes to request during login")
 	cmd.Flags().BoolVar(&flags.skipBrowser, "skip-browser", false, "Skip opening the browser (just print the URL)")
 	cmd.Flags().BoolVar(&flags.skipListen, "skip-listen", false, "Skip starting a localhost callback listener (manual copy/paste flow only)")
-	cmd.Flags().StringVar(&flags.sessionCachePath, "session-cache", filepath.Join(mustGetConfigDir(), "sessions.yaml"), "Path to session cache file")
+	cmd.Flags().StringVar(&flags.sessionCachePath, "session-cache", filepath.Join(mustGetConfigDir(), "sessions.yaml"), "Path to session cache file (\"memory\" keeps the cache in memory only)")
 	cmd.Flags().StringSliceVar(&flags.caBundlePaths, "ca-bundle", nil, "Path to TLS certificate authority bundle (PEM format, optional, can be repeated)")
 	cmd.Flags().StringSliceVar(&flags.caBundleData, "ca-bundle-data", nil, "Base64 encoded TLS certificate authority bundle (base64 encoded PEM format, optional, can be repeated)")
+	cmd.Flags().BoolVar(&flags.caBundleFromKubeconfig, "ca-bundle-from-kubeconfig", false, "Also trust the certificate authority data of the cluster in the kubeconfig when connecting to the issuer (requires provideClusterInfo in the kubeconfig)")
+	cmd.Flags().BoolVar(&flags.caBundleSystemRoots, "ca-bundle-include-system-roots", false, "Also trust the system's roots when connecting to the issuer, in addition to the CA bundles (the system's roots are always trusted when no CA bundle is given)")
 	cmd.Flags().BoolVar(&flags.debugSessionCache, "debug-session-cache", false, "Print debug logs related to the session cache")
 	cmd.Flags().StringVar(&flags.requestAudience, "request-audience", "", "Request a token with an alternate audience using RFC8693 token exchange")
+	cmd.Flags().BoolVar(&flags.enableDPoP, "enable-dpop", false, "Request DPoP-bound tokens (RFC9449) from the issuer")
+	cmd.Flags().StringVar(&flags.clientAssertionKeyFile, "client-assertion-key-file", "", "Path to the ECDSA P-256 private key (PEM format) with which a confidential client authenticates to the issuer (private_key_jwt)")
 	cmd.Flags().BoolVar(&flags.conciergeEnabled, "enable-concierge", false, "Use the Concierge to login")
 	cmd.Flags().StringVar(&conciergeNamespace, "concierge-namespace", "pinniped-concierge", "Namespace in which the Concierge was installed")
 	cmd.Flags().StringVar(&flags.conciergeAuthenticatorType, "concierge-authenticator-type", "", "Concierge authenticator type (e.g., 'webhook', 'jwt')")
@@ -139,7 +157,7 @@ func oidcLoginCommand(deps oidcLoginCommandDeps) *cobra.Command {
 	cmd.Flags().StringVar(&flags.conciergeEndpoint, "concierge-endpoint", "", "API base for the Concierge endpoint")
 	cmd.Flags().StringVar(&flags.conciergeCABundle, "concierge-ca-bundle-data", "", "CA bundle to use when connecting to the Concierge")
 	cmd.Flags().StringVar(&flags.conciergeAPIGroupSuffix, "concierge-api-group-suffix", groupsuffix.PinnipedDefaultSuffix, "Concierge API group suffix")
-	cmd.Flags().StringVar(&flags.credentialCachePath, "credential-cache", filepath.Join(mustGetConfigDir(), "credentials.yaml"), "Path to cluster-specific credentials cache (\"\" disables the cache)")
+	cmd.Flags().StringVar(&flags.credentialCachePath, "credential-cache", filepath.Join(mustGetConfigDir(), "credentials.yaml"), "Path to cluster-specific credentials cache (\"\" disables the cache, \"memory\" keeps the cache in memory only)")
 	cmd.Flags().StringVar(&flags.upstreamIdentityProviderName, "upstream-identity-provider-name", "", "The name of the upstream identity provider used during login with a Supervisor")
 	cmd.Flags().StringVar(&flags.upstreamIdentityProviderType,
 		"upstream-identity-provider-type",
@@ -153,10 +171,16 @@ func oidcLoginCommand(deps oidcLoginCommandDeps) *cobra.Command {
 		))
 	cmd.Flags().StringVar(&flags.upstreamIdentityProviderFlow, "upstream-identity-provider-flow", "", fmt.Sprintf("The type of client flow to use with the upstream identity provider during login with a Supervisor (e.g. '%s', '%s')", idpdiscoveryv1alpha1.IDPFlowBrowserAuthcode, idpdiscoveryv1alpha1.IDPFlowCLIPassword))
 
+	cmd.Flags().StringVar(&flags.credentialOutput, "credential-output", "", "Where to write the ExecCredential: a file path, or 'fd:N' for a file descriptor which is already open (default: stdout)")
+	cmd.Flags().StringVar(&flags.language, "lang", "", "The language of the interactive login prompts (e.g. 'de', 'es'), when supported (default: English)")
+
 	// --skip-listen is mainly needed for testing. We'll leave it hidden until we have a non-testing use case.
 	mustMarkHidden(cmd, "skip-listen")
 	mustMarkHidden(cmd, "debug-session-cache")
 	mustMarkRequired(cmd, "issuer")
+	mustRegisterFlagCompletionFunc(cmd, "concierge-authenticator-type", completeAuthenticatorTypes)
+	mustRegisterFlagCompletionFunc(cmd, "upstream-identity-provider-type", completeUpstreamIDPTypes)
+	mustRegisterFlagCompletionFunc(cmd, "upstream-identity-provider-flow", completeUpstreamIDPFlows)
 	cmd.RunE = func(cmd *cobra.Command, _args []string) error { return runOIDCLogin(cmd, deps, flags) }
 
 	mustMarkDeprecated(cmd, "concierge-namespace", "not needed anymore")
@@ -171,8 +195,18 @@ func runOIDCLogin(cmd *cobra.Command, deps oidcLoginCommandDeps, flags oidcLogin
 		plog.WarningErr("Received error while setting log level", err)
 	}
 
+	// Check --credential-output before logging in, so that a mistake in it does not waste a login.
+	writeCredential, err := newCredentialWriter(cmd.OutOrStdout(), flags.credentialOutput)
+	if err != nil {
+		return err
+	}
+
 	// Initialize the session cache.
-	var sessionOptions []filesession.Option
+	encryption, err := cacheEncryption(deps.lookupEnv)
+	if err != nil {
+		return err
+	}
+	sessionOptions := []filesession.Option{filesession.WithEncryption(encryption)}
 
 	// If the hidden --debug-session-cache option is passed, log all the errors from the session cache.
 	if flags.debugSessionCache {
@@ -180,6 +214,10 @@ func runOIDCLogin(cmd *cobra.Command, deps oidcLoginCommandDeps, flags oidcLogin
 			pLogger.Error("error during session cache operation", err)
 		}))
 	}
+	// If --session-cache=memory is passed, never write the session cache to disk.
+	if flags.sessionCachePath == cacheInMemory {
+		sessionOptions = append(sessionOptions, filesession.WithMemoryOnly())
+	}
 	sessionCache := filesession.New(flags.sessionCachePath, sessionOptions...)
 
 	// Initialize the login handler.
@@ -203,6 +241,18 @@ func runOIDCLogin(cmd *cobra.Command, deps oidcLoginCommandDeps, flags oidcLogin
 		opts = append(opts, deps.optionsFactory.WithRequestAudience(flags.requestAudience))
 	}
 
+	if flags.enableDPoP {
+		opts = append(opts, deps.optionsFactory.WithDPoP())
+	}
+
+	if flags.clientAssertionKeyFile != "" {
+		opts = append(opts, deps.optionsFactory.WithClientAssertionKeyFile(flags.clientAssertionKeyFile))
+	}
+
+	if flags.language != "" {
+		opts = append(opts, deps.optionsFactory.WithLanguage(flags.language))
+	}
+
 	if flags.upstreamIdentityProviderName != "" {
 		opts = append(opts, deps.optionsFactory.WithUpstreamIdentityProvider(
 			flags.upstreamIdentityProviderName, flags.upstreamIdentityProviderType))
@@ -240,12 +290,21 @@ func runOIDCLogin(cmd *cobra.Command, deps oidcLoginCommandDeps, flags oidcLogin
 		opts = append(opts, deps.optionsFactory.WithSkipListen())
 	}
 
-	if len(flags.caBundlePaths) > 0 || len(flags.caBundleData) > 0 {
-		client, err := makeClient(flags.caBundlePaths, flags.caBundleData)
+	// All CA bundles are merged into one pool, which is only used for the issuer. The Concierge has its own.
+	clusterInfo := loadClusterInfo()
+	if len(flags.caBundlePaths) > 0 || len(flags.caBundleData) > 0 || flags.caBundleFromKubeconfig {
+		pemBundles, err := readCABundles(flags.caBundlePaths, flags.caBundleData)
 		if err != nil {
 			return err
 		}
-		opts = append(opts, deps.optionsFactory.WithClient(client))
+		if flags.caBundleFromKubeconfig {
+			clusterCABundle, err := clusterCABundle(clusterInfo)
+			if err != nil {
+				return err
+			}
+			pemBundles = append(pemBundles, clusterCABundle)
+		}
+		opts = append(opts, deps.optionsFactory.WithCABundles(flags.caBundleSystemRoots, pemBundles))
 	}
 	// Look up cached credentials based on a hash of all the CLI arguments and the cluster info.
 	cacheKey := struct {
@@ -253,14 +312,14 @@ func runOIDCLogin(cmd *cobra.Command, deps oidcLoginCommandDeps, flags oidcLogin
 		ClusterInfo *clientauthv1beta1.Cluster `json:"cluster"`
 	}{
 		Args:        os.Args[1:],
-		ClusterInfo: loadClusterInfo(),
+		ClusterInfo: clusterInfo,
 	}
 	var credCache *execcredcache.Cache
 	if flags.credentialCachePath != "" {
-		credCache = execcredcache.New(flags.credentialCachePath)
+		credCache = newCredentialCache(flags.credentialCachePath, encryption)
 		if cred := credCache.Get(cacheKey); cred != nil {
 			pLogger.Debug("using cached cluster credential.")
-			return json.NewEncoder(cmd.OutOrStdout()).Encode(cred)
+			return writeCredential(cred)
 		}
 	}
 
@@ -292,23 +351,53 @@ func runOIDCLogin(cmd *cobra.Command, deps oidcLoginCommandDeps, flags oidcLogin
 		pLogger.Debug("caching cluster credential for future use.")
 		credCache.Put(cacheKey, cred)
 	}
-	return json.NewEncoder(cmd.OutOrStdout()).Encode(cred)
+	return writeCredential(cred)
 }
 
-func makeClient(caBundlePaths []string, caBundleData []string) (*http.Client, error) {
-	pool := x509.NewCertPool()
+// readCABundles reads the PEM-encoded CA bundles of the --ca-bundle and --ca-bundle-data flags.
+func readCABundles(caBundlePaths []string, caBundleData []string) ([][]byte, error) {
+	pemBundles := make([][]byte, 0, len(caBundlePaths)+len(caBundleData))
 	for _, p := range caBundlePaths {
 		pem, err := os.ReadFile(p)
 		if err != nil {
 			return nil, fmt.Errorf("could not read --ca-bundle: %w", err)
 		}
-		pool.AppendCertsFromPEM(pem)
+		pemBundles = append(pemBundles, pem)
 	}
 	for _, d := range caBundleData {
 		pem, err := base64.StdEncoding.DecodeString(d)
 		if err != nil {
 			return nil, fmt.Errorf("could not read --ca-bundle-data: %w", err)
 		}
+		pemBundles = append(pemBundles, pem)
+	}
+	return pemBundles, nil
+}
+
+// clusterCABundle returns the CA bundle of the cluster for --ca-bundle-from-kubeconfig. The cluster info is only
+// available when the kubeconfig sets provideClusterInfo, which is the case for kubeconfigs from `pinniped get kubeconfig`.
+func clusterCABundle(cluster *clientauthv1beta1.Cluster) ([]byte, error) {
+	if cluster == nil {
+		return nil, fmt.Errorf("could not read --ca-bundle-from-kubeconfig: the cluster info is not available, ensure that provideClusterInfo is true in the kubeconfig")
+	}
+	if len(cluster.CertificateAuthorityData) == 0 {
+		return nil, fmt.Errorf("could not read --ca-bundle-from-kubeconfig: the cluster in the kubeconfig has no certificate-authority-data")
+	}
+	return cluster.CertificateAuthorityData, nil
+}
+
+// makeClient returns an HTTP client which trusts the certificate authorities of the PEM-encoded bundles, along with
+// the system's roots when includeSystemRoots is true.
+func makeClient(includeSystemRoots bool, pemBundles [][]byte) (*http.Client, error) {
+	pool := x509.NewCertPool()
+	if includeSystemRoots {
+		systemRoots, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("could not load the system's roots: %w", err)
+		}
+		pool = systemRoots
+	}
+	for _, pem := range pemBundles {
 		pool.AppendCertsFromPEM(pem)
 	}
 	return phttp.Default(pool), nil
@@ -330,6 +419,87 @@ func tokenCredential(idToken *oidctypes.IDToken) *clientauthv1beta1.ExecCredenti
 	return &cred
 }
 
+// newCredentialWriter returns a function which writes an ExecCredential as JSON to the destination chosen by
+// --credential-output: stdout when it is empty, a file descriptor which was opened by the parent process when it is
+// "fd:N", or otherwise a file path. The credential is never included in any error or log message.
+func newCredentialWriter(stdout io.Writer, credentialOutput string) (func(*clientauthv1beta1.ExecCredential) error, error) {
+	write := func(w io.Writer, cred *clientauthv1beta1.ExecCredential) error {
+		return json.NewEncoder(w).Encode(cred)
+	}
+
+	fdString, isFD := strings.CutPrefix(credentialOutput, "fd:")
+	switch {
+	case credentialOutput == "":
+		return func(cred *clientauthv1beta1.ExecCredential) error { return write(stdout, cred) }, nil
+
+	case isFD:
+		fd, err := strconv.ParseUint(fdString, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --credential-output %q: the file descriptor must be a non-negative integer", credentialOutput)
+		}
+		switch fd {
+		case 0:
+			return nil, fmt.Errorf("invalid --credential-output %q: cannot write to stdin", credentialOutput)
+		case 1:
+			return func(cred *clientauthv1beta1.ExecCredential) error { return write(stdout, cred) }, nil
+		case 2:
+			return nil, fmt.Errorf("invalid --credential-output %q: cannot write the credential to stderr, which is often logged", credentialOutput)
+		}
+		return func(cred *clientauthv1beta1.ExecCredential) error {
+			// Close the file descriptor after writing, so that the reader sees the end of the credential.
+			f := os.NewFile(uintptr(fd), credentialOutput)
+			if err := write(f, cred); err != nil {
+				_ = f.Close()
+				return fmt.Errorf("could not write --credential-output: %w", err)
+			}
+			if err := f.Close(); err != nil {
+				return fmt.Errorf("could not write --credential-output: %w", err)
+			}
+			return nil
+		}, nil
+
+	default:
+		return func(cred *clientauthv1beta1.ExecCredential) error {
+			if err := writeCredentialFile(credentialOutput, func(w io.Writer) error { return write(w, cred) }); err != nil {
+				return fmt.Errorf("could not write --credential-output: %w", err)
+			}
+			return nil
+		}, nil
+	}
+}
+
+// writeCredentialFile replaces the file at path with a new file which is only readable by its owner, so that
+// other processes can never read a partially written credential. Something other than a regular file, such as
+// a named pipe, cannot be replaced, so it is written to directly.
+func writeCredentialFile(path string, write func(io.Writer) error) error {
+	if info, err := os.Stat(path); err == nil && !info.Mode().IsRegular() {
+		f, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return err
+		}
+		if err := write(f); err != nil {
+			_ = f.Close()
+			return err
+		}
+		return f.Close()
+	}
+
+	// CreateTemp creates the file with 0600 permissions.
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(f.Name()) }() // fails harmlessly after a successful rename
+	if err := write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
 func SetLogLevel(ctx context.Context, lookupEnv func(string) (string, bool)) (plog.Logger, error) {
 	debug, _ := lookupEnv(debugEnvVarName)
 	if debug == envVarTruthyValue {
@@ -341,6 +511,33 @@ func SetLogLevel(ctx context.Context, lookupEnv func(string) (string, bool)) (pl
 	return plog.New(), nil
 }
 
+// cacheInMemory is a special value for the --session-cache and --credential-cache flags which keeps the cache in
+// memory only, so that nothing is persisted to disk, e.g. in ephemeral CI environments.
+const cacheInMemory = "memory"
+
+// newCredentialCache returns a cluster-specific credentials cache for the value of the --credential-cache flag.
+func newCredentialCache(path string, encryption cacheencryption.Provider) *execcredcache.Cache {
+	if path == cacheInMemory {
+		return execcredcache.New(path, execcredcache.WithMemoryOnly())
+	}
+	return execcredcache.New(path, execcredcache.WithEncryption(encryption))
+}
+
+// cacheEncryption returns the encryption of the session and credential cache files which is configured by the
+// PINNIPED_CACHE_ENCRYPTION_KEY_FILE env var, or nil when the cache files are not encrypted. A key file is the only
+// supported source of the key, see the cacheencryption package.
+func cacheEncryption(lookupEnv func(string) (string, bool)) (cacheencryption.Provider, error) {
+	keyFile, _ := lookupEnv(cacheEncryptionKeyFileEnvVarName)
+	if keyFile == "" {
+		return nil, nil
+	}
+	encryption, err := cacheencryption.NewAESGCMFromKeyFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", cacheEncryptionKeyFileEnvVarName, err)
+	}
+	return encryption, nil
+}
+
 /*
 mustGetConfigDir returns a directory that follows the XDG base directory convention:
 
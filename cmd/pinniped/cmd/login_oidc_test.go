@@ -8,6 +8,7 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
@@ -40,6 +41,9 @@ func TestLoginOIDCCommand(t *testing.T) {
 	testCABundlePath := filepath.Join(tmpdir, "testca.pem")
 	require.NoError(t, os.WriteFile(testCABundlePath, testCA.Bundle(), 0600))
 
+	invalidCacheEncryptionKeyPath := filepath.Join(tmpdir, "invalid-key")
+	require.NoError(t, os.WriteFile(invalidCacheEncryptionKeyPath, []byte("invalid-base64"), 0600))
+
 	time1 := time.Date(3020, 10, 12, 13, 14, 15, 16, time.UTC)
 
 	now, err := time.Parse(time.RFC3339Nano, "2028-10-11T23:37:26.953313745Z")
@@ -59,6 +63,7 @@ func TestLoginOIDCCommand(t *testing.T) {
 		loginErr         error
 		conciergeErr     error
 		env              map[string]string
+		execInfo         string
 		wantError        bool
 		wantStdout       string
 		wantStderr       string
@@ -86,20 +91,26 @@ func TestLoginOIDCCommand(t *testing.T) {
 				Flags:
 				      --ca-bundle strings                        Path to TLS certificate authority bundle (PEM format, optional, can be repeated)
 				      --ca-bundle-data strings                   Base64 encoded TLS certificate authority bundle (base64 encoded PEM format, optional, can be repeated)
+				      --ca-bundle-from-kubeconfig                Also trust the certificate authority data of the cluster in the kubeconfig when connecting to the issuer (requires provideClusterInfo in the kubeconfig)
+				      --ca-bundle-include-system-roots           Also trust the system's roots when connecting to the issuer, in addition to the CA bundles (the system's roots are always trusted when no CA bundle is given)
+				      --client-assertion-key-file string         Path to the ECDSA P-256 private key (PEM format) with which a confidential client authenticates to the issuer (private_key_jwt)
 				      --client-id string                         OpenID Connect client ID (default "pinniped-cli")
 				      --concierge-api-group-suffix string        Concierge API group suffix (default "pinniped.dev")
 				      --concierge-authenticator-name string      Concierge authenticator name
 				      --concierge-authenticator-type string      Concierge authenticator type (e.g., 'webhook', 'jwt')
 				      --concierge-ca-bundle-data string          CA bundle to use when connecting to the Concierge
 				      --concierge-endpoint string                API base for the Concierge endpoint
-				      --credential-cache string                  Path to cluster-specific credentials cache ("" disables the cache) (default "` + cfgDir + `/credentials.yaml")
+				      --credential-cache string                  Path to cluster-specific credentials cache ("" disables the cache, "memory" keeps the cache in memory only) (default "` + cfgDir + `/credentials.yaml")
+				      --credential-output string                 Where to write the ExecCredential: a file path, or 'fd:N' for a file descriptor which is already open (default: stdout)
 				      --enable-concierge                         Use the Concierge to login
+				      --enable-dpop                              Request DPoP-bound tokens (RFC9449) from the issuer
 				  -h, --help                                     help for oidc
 				      --issuer string                            OpenID Connect issuer URL
+				      --lang string                              The language of the interactive login prompts (e.g. 'de', 'es'), when supported (default: English)
 				      --listen-port uint16                       TCP port for localhost listener (authorization code flow only)
 				      --request-audience string                  Request a token with an alternate audience using RFC8693 token exchange
 				      --scopes strings                           OIDC scopes to request during login (default [offline_access,openid,pinniped:request-audience,username,groups])
-				      --session-cache string                     Path to session cache file (default "` + cfgDir + `/sessions.yaml")
+				      --session-cache string                     Path to session cache file ("memory" keeps the cache in memory only) (default "` + cfgDir + `/sessions.yaml")
 				      --skip-browser                             Skip opening the browser (just print the URL)
 					  --upstream-identity-provider-flow string   The type of client flow to use with the upstream identity provider during login with a Supervisor (e.g. 'browser_authcode', 'cli_password')
 					  --upstream-identity-provider-name string   The name of the upstream identity provider used during login with a Supervisor
@@ -153,6 +164,45 @@ func TestLoginOIDCCommand(t *testing.T) {
 				Error: could not read --ca-bundle-data: illegal base64 data at input byte 7
 			`),
 		},
+		{
+			name: "CA bundle from kubeconfig without cluster info",
+			args: []string{
+				"--client-id", "test-client-id",
+				"--issuer", "test-issuer",
+				"--ca-bundle-from-kubeconfig",
+			},
+			wantOptions: defaultWantedOptions,
+			wantError:   true,
+			wantStderr: here.Doc(`
+				Error: could not read --ca-bundle-from-kubeconfig: the cluster info is not available, ensure that provideClusterInfo is true in the kubeconfig
+			`),
+		},
+		{
+			name: "CA bundle from kubeconfig when the cluster has no CA bundle",
+			args: []string{
+				"--client-id", "test-client-id",
+				"--issuer", "test-issuer",
+				"--ca-bundle-from-kubeconfig",
+			},
+			execInfo:    `{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1beta1","spec":{"cluster":{"server":"https://cluster.example.com"},"interactive":false}}`,
+			wantOptions: defaultWantedOptions,
+			wantError:   true,
+			wantStderr: here.Doc(`
+				Error: could not read --ca-bundle-from-kubeconfig: the cluster in the kubeconfig has no certificate-authority-data
+			`),
+		},
+		{
+			name: "invalid PINNIPED_CACHE_ENCRYPTION_KEY_FILE",
+			args: []string{
+				"--client-id", "test-client-id",
+				"--issuer", "test-issuer",
+			},
+			env:       map[string]string{"PINNIPED_CACHE_ENCRYPTION_KEY_FILE": invalidCacheEncryptionKeyPath},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: invalid PINNIPED_CACHE_ENCRYPTION_KEY_FILE: could not decode cache encryption key file ` + invalidCacheEncryptionKeyPath + `: illegal base64 data at input byte 7
+			`),
+		},
 		{
 			name: "invalid API group suffix",
 			args: []string{
@@ -262,6 +312,29 @@ func TestLoginOIDCCommand(t *testing.T) {
 				Error: could not complete Concierge credential exchange: some concierge error
 			`),
 		},
+		{
+			name: "invalid credential output",
+			args: []string{
+				"--client-id", "test-client-id",
+				"--issuer", "test-issuer",
+				"--credential-output", "fd:2",
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: invalid --credential-output "fd:2": cannot write the credential to stderr, which is often logged
+			`),
+		},
+		{
+			name: "success writing the credential to a file",
+			args: []string{
+				"--client-id", "test-client-id",
+				"--issuer", "test-issuer",
+				"--credential-cache", "", // must specify --credential-cache or else the cache file on disk causes test pollution
+				"--credential-output", filepath.Join(tmpdir, "credential.json"),
+			},
+			wantOptions:      defaultWantedOptions,
+			wantOptionsCount: 4,
+		},
 		{
 			name: "success with minimal options",
 			args: []string{
@@ -274,8 +347,55 @@ func TestLoginOIDCCommand(t *testing.T) {
 			wantOptionsCount: 4,
 			wantStdout:       `{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1beta1","spec":{"interactive":false},"status":{"expirationTimestamp":"3020-10-12T13:14:15Z","token":"test-id-token"}}` + "\n",
 			wantLogs: []string{
-				nowStr + `  cmd/login_oidc.go:267  Performing OIDC login  {"issuer": "test-issuer", "client id": "test-client-id"}`,
-				nowStr + `  cmd/login_oidc.go:287  No concierge configured, skipping token credential exchange`,
+				nowStr + `  cmd/login_oidc.go:326  Performing OIDC login  {"issuer": "test-issuer", "client id": "test-client-id"}`,
+				nowStr + `  cmd/login_oidc.go:346  No concierge configured, skipping token credential exchange`,
+			},
+		},
+		{
+			name: "success with CA bundles from several sources",
+			args: []string{
+				"--client-id", "test-client-id",
+				"--issuer", "test-issuer",
+				"--ca-bundle", testCABundlePath,
+				"--ca-bundle-from-kubeconfig",
+				"--ca-bundle-include-system-roots",
+				"--credential-cache", "", // must specify --credential-cache or else the cache file on disk causes test pollution
+			},
+			execInfo: `{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1beta1","spec":{"cluster":{"server":"https://cluster.example.com","certificate-authority-data":"` +
+				base64.StdEncoding.EncodeToString([]byte("some-cluster-ca")) + `"},"interactive":false}}`,
+			env: map[string]string{"PINNIPED_DEBUG": "true"},
+			wantOptions: func(f *mockoidcclientoptions.MockOIDCClientOptions) {
+				defaultWantedOptions(f)
+				f.EXPECT().WithCABundles(true, [][]byte{testCA.Bundle(), []byte("some-cluster-ca")})
+			},
+			wantOptionsCount: 5,
+			wantStdout:       `{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1beta1","spec":{"interactive":false},"status":{"expirationTimestamp":"3020-10-12T13:14:15Z","token":"test-id-token"}}` + "\n",
+			wantLogs: []string{
+				nowStr + `  cmd/login_oidc.go:326  Performing OIDC login  {"issuer": "test-issuer", "client id": "test-client-id"}`,
+				nowStr + `  cmd/login_oidc.go:346  No concierge configured, skipping token credential exchange`,
+			},
+		},
+		{
+			name: "success with in-memory session and credential caches",
+			args: []string{
+				"--client-id", "test-client-id",
+				"--issuer", "test-issuer",
+				"--session-cache", "memory",
+				"--credential-cache", "memory",
+				"--enable-concierge",
+				"--concierge-authenticator-type", "webhook",
+				"--concierge-authenticator-name", "test-authenticator",
+				"--concierge-endpoint", "https://127.0.0.1:1234/",
+			},
+			env:              map[string]string{"PINNIPED_DEBUG": "true"},
+			wantOptions:      defaultWantedOptions,
+			wantOptionsCount: 4,
+			wantStdout:       `{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1beta1","spec":{"interactive":false},"status":{"token":"exchanged-token"}}` + "\n",
+			wantLogs: []string{
+				nowStr + `  cmd/login_oidc.go:326  Performing OIDC login  {"issuer": "test-issuer", "client id": "test-client-id"}`,
+				nowStr + `  cmd/login_oidc.go:336  Exchanging token for cluster credential  {"endpoint": "https://127.0.0.1:1234/", "authenticator type": "webhook", "authenticator name": "test-authenticator"}`,
+				nowStr + `  cmd/login_oidc.go:344  Successfully exchanged token for cluster credential.`,
+				nowStr + `  cmd/login_oidc.go:351  caching cluster credential for future use.`,
 			},
 		},
 		{
@@ -288,6 +408,8 @@ func TestLoginOIDCCommand(t *testing.T) {
 				"--listen-port", "1234",
 				"--debug-session-cache",
 				"--request-audience", "cluster-1234",
+				"--enable-dpop",
+				"--client-assertion-key-file", "some/key.pem",
 				"--ca-bundle-data", base64.StdEncoding.EncodeToString(testCA.Bundle()),
 				"--ca-bundle", testCABundlePath,
 				"--enable-concierge",
@@ -300,6 +422,7 @@ func TestLoginOIDCCommand(t *testing.T) {
 				"--upstream-identity-provider-name", "some-upstream-name",
 				"--upstream-identity-provider-type", "ldap",
 				"--upstream-identity-provider-flow", "some-flow-type",
+				"--lang", "de",
 			},
 			env: map[string]string{"PINNIPED_DEBUG": "true", "PINNIPED_SKIP_PRINT_LOGIN_URL": "true"},
 			wantOptions: func(f *mockoidcclientoptions.MockOIDCClientOptions) {
@@ -311,23 +434,30 @@ func TestLoginOIDCCommand(t *testing.T) {
 				f.EXPECT().WithSkipBrowserOpen()
 				f.EXPECT().WithSkipListen()
 				f.EXPECT().WithSkipPrintLoginURL()
-				f.EXPECT().WithClient(gomock.Any())
+				f.EXPECT().WithCABundles(false, [][]byte{testCA.Bundle(), testCA.Bundle()})
 				f.EXPECT().WithRequestAudience("cluster-1234")
+				f.EXPECT().WithDPoP()
+				f.EXPECT().WithClientAssertionKeyFile("some/key.pem")
 				f.EXPECT().WithLoginFlow(idpdiscoveryv1alpha1.IDPFlow("some-flow-type"), "--upstream-identity-provider-flow")
 				f.EXPECT().WithUpstreamIdentityProvider("some-upstream-name", "ldap")
+				f.EXPECT().WithLanguage("de")
 			},
-			wantOptionsCount: 12,
+			wantOptionsCount: 15,
 			wantStdout:       `{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1beta1","spec":{"interactive":false},"status":{"token":"exchanged-token"}}` + "\n",
 			wantLogs: []string{
-				nowStr + `  cmd/login_oidc.go:267  Performing OIDC login  {"issuer": "test-issuer", "client id": "test-client-id"}`,
-				nowStr + `  cmd/login_oidc.go:277  Exchanging token for cluster credential  {"endpoint": "https://127.0.0.1:1234/", "authenticator type": "webhook", "authenticator name": "test-authenticator"}`,
-				nowStr + `  cmd/login_oidc.go:285  Successfully exchanged token for cluster credential.`,
-				nowStr + `  cmd/login_oidc.go:292  caching cluster credential for future use.`,
+				nowStr + `  cmd/login_oidc.go:326  Performing OIDC login  {"issuer": "test-issuer", "client id": "test-client-id"}`,
+				nowStr + `  cmd/login_oidc.go:336  Exchanging token for cluster credential  {"endpoint": "https://127.0.0.1:1234/", "authenticator type": "webhook", "authenticator name": "test-authenticator"}`,
+				nowStr + `  cmd/login_oidc.go:344  Successfully exchanged token for cluster credential.`,
+				nowStr + `  cmd/login_oidc.go:351  caching cluster credential for future use.`,
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			if tt.execInfo != "" {
+				t.Setenv("KUBERNETES_EXEC_INFO", tt.execInfo)
+			}
+
 			var buf bytes.Buffer
 			ctx := plog.AddZapOverridesToContext(context.Background(), t, &buf, nil, clocktesting.NewFakeClock(now))
 
@@ -395,3 +525,84 @@ func TestLoginOIDCCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestNewCredentialWriter(t *testing.T) {
+	cred := &clientauthv1beta1.ExecCredential{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ExecCredential",
+			APIVersion: "client.authentication.k8s.io/v1beta1",
+		},
+		Status: &clientauthv1beta1.ExecCredentialStatus{Token: "some-token"},
+	}
+	const wantJSON = `{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1beta1","spec":{"interactive":false},"status":{"token":"some-token"}}` + "\n"
+
+	t.Run("stdout", func(t *testing.T) {
+		for _, credentialOutput := range []string{"", "fd:1"} {
+			var stdout bytes.Buffer
+			write, err := newCredentialWriter(&stdout, credentialOutput)
+			require.NoError(t, err)
+			require.NoError(t, write(cred))
+			require.Equal(t, wantJSON, stdout.String())
+		}
+	})
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "credential.json")
+		require.NoError(t, os.WriteFile(path, []byte("some previous credential which is longer than the new one"), 0644))
+
+		var stdout bytes.Buffer
+		write, err := newCredentialWriter(&stdout, path)
+		require.NoError(t, err)
+		require.NoError(t, write(cred))
+		require.Empty(t, stdout.String())
+
+		got, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, wantJSON, string(got))
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		entries, err := os.ReadDir(filepath.Dir(path))
+		require.NoError(t, err)
+		require.Len(t, entries, 1, "temporary files should be cleaned up")
+	})
+
+	t.Run("file in a directory which does not exist", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "does-not-exist", "credential.json")
+		write, err := newCredentialWriter(nil, path)
+		require.NoError(t, err)
+		err = write(cred)
+		require.ErrorContains(t, err, "could not write --credential-output: ")
+		require.NotContains(t, err.Error(), "some-token")
+	})
+
+	t.Run("file descriptor", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = r.Close() })
+
+		write, err := newCredentialWriter(nil, fmt.Sprintf("fd:%d", w.Fd()))
+		require.NoError(t, err)
+		require.NoError(t, write(cred))
+
+		// The writer closes the file descriptor, so the reader sees the end of the credential.
+		got, err := io.ReadAll(r)
+		require.Error(t, w.Close(), "the file descriptor should already be closed")
+		require.NoError(t, err)
+		require.Equal(t, wantJSON, string(got))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for credentialOutput, wantErr := range map[string]string{
+			"fd:0":   `invalid --credential-output "fd:0": cannot write to stdin`,
+			"fd:2":   `invalid --credential-output "fd:2": cannot write the credential to stderr, which is often logged`,
+			"fd:abc": `invalid --credential-output "fd:abc": the file descriptor must be a non-negative integer`,
+			"fd:-3":  `invalid --credential-output "fd:-3": the file descriptor must be a non-negative integer`,
+		} {
+			_, err := newCredentialWriter(nil, credentialOutput)
+			require.EqualError(t, err, wantErr)
+		}
+	})
+}
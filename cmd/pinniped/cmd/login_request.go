@@ -0,0 +1,220 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"go.pinniped.dev/internal/groupsuffix"
+	"go.pinniped.dev/internal/here"
+	"go.pinniped.dev/pkg/conciergeclient"
+	"go.pinniped.dev/pkg/loginrequest"
+)
+
+//nolint:gochecknoinits
+func init() {
+	loginCmd.AddCommand(requestLoginCommand(requestLoginRealDeps()))
+}
+
+type requestLoginDeps struct {
+	lookupEnv     func(string) (string, bool)
+	getRESTConfig func(cluster *rest.Config, opts ...loginrequest.Option) (*rest.Config, error)
+}
+
+func requestLoginRealDeps() requestLoginDeps {
+	return requestLoginDeps{
+		lookupEnv:     os.LookupEnv,
+		getRESTConfig: loginrequest.RESTConfig,
+	}
+}
+
+type requestLoginParams struct {
+	kubeconfigPath            string
+	kubeconfigContextOverride string
+
+	issuer                       string
+	clientID                     string
+	caBundlePaths                []string
+	caBundleData                 []string
+	username                     string
+	upstreamIdentityProviderName string
+	upstreamIdentityProviderType string
+	requestAudience              string
+	workloadIdentityTokenFile    string
+
+	conciergeEnabled           bool
+	conciergeAuthenticatorType string
+	conciergeAuthenticatorName string
+	conciergeEndpoint          string
+	conciergeCABundle          string
+	conciergeAPIGroupSuffix    string
+
+	timeout time.Duration
+}
+
+func requestLoginCommand(deps requestLoginDeps) *cobra.Command {
+	var (
+		cmd = &cobra.Command{
+			Args:  cobra.NoArgs,
+			Use:   "request --kubeconfig FILE (--issuer ISSUER --username USERNAME | --workload-identity-token-file FILE)",
+			Short: "Login non-interactively and print a kubeconfig with cluster credentials",
+			Long: here.Doc(
+				`Login non-interactively and print a kubeconfig with cluster credentials
+
+					This login command is meant to be invoked directly by API clients which are not
+					kubectl, such as dashboards and continuous delivery systems. It logs in using
+					either the username and password of a service account, or using a workload
+					identity token file, optionally exchanges the resulting token for a
+					cluster-specific credential using the Concierge, and prints a kubeconfig which
+					contains the resulting credential.
+
+					The password must be provided by the PINNIPED_PASSWORD environment variable.
+
+					The printed credential is not refreshed automatically. Run this command again
+					to get a new credential before it expires.
+
+					Go programs can use the go.pinniped.dev/pkg/loginrequest package instead.`,
+			),
+			SilenceUsage: true, // do not print usage message when commands fail
+		}
+		flags requestLoginParams
+	)
+	cmd.Flags().StringVar(&flags.kubeconfigPath, "kubeconfig", os.Getenv("KUBECONFIG"), "Path to kubeconfig file which describes the target cluster")
+	cmd.Flags().StringVar(&flags.kubeconfigContextOverride, "kubeconfig-context", "", "Kubeconfig context name (default: current active context)")
+	cmd.Flags().StringVar(&flags.issuer, "issuer", "", "Supervisor issuer URL")
+	cmd.Flags().StringVar(&flags.clientID, "client-id", "pinniped-cli", "Supervisor OAuth client ID")
+	cmd.Flags().StringSliceVar(&flags.caBundlePaths, "ca-bundle", nil, "Path to TLS certificate authority bundle (PEM format, optional, can be repeated)")
+	cmd.Flags().StringSliceVar(&flags.caBundleData, "ca-bundle-data", nil, "Base64 encoded TLS certificate authority bundle (base64 encoded PEM format, optional, can be repeated)")
+	cmd.Flags().StringVar(&flags.username, "username", "", "Username of the service account (default: value of the PINNIPED_USERNAME environment variable)")
+	cmd.Flags().StringVar(&flags.upstreamIdentityProviderName, "upstream-identity-provider-name", "", "The name of the upstream identity provider used during login with a Supervisor")
+	cmd.Flags().StringVar(&flags.upstreamIdentityProviderType, "upstream-identity-provider-type", "", "The type of the upstream identity provider used during login with a Supervisor (e.g. 'ldap', 'activedirectory')")
+	cmd.Flags().StringVar(&flags.requestAudience, "request-audience", "", "Request a token with an alternate audience using RFC8693 token exchange")
+	cmd.Flags().StringVar(&flags.workloadIdentityTokenFile, "workload-identity-token-file", "", "Path to a workload identity token to use instead of logging in to a Supervisor")
+	cmd.Flags().BoolVar(&flags.conciergeEnabled, "enable-concierge", false, "Use the Concierge to login")
+	cmd.Flags().StringVar(&flags.conciergeAuthenticatorType, "concierge-authenticator-type", "", "Concierge authenticator type (e.g., 'webhook', 'jwt')")
+	cmd.Flags().StringVar(&flags.conciergeAuthenticatorName, "concierge-authenticator-name", "", "Concierge authenticator name")
+	cmd.Flags().StringVar(&flags.conciergeEndpoint, "concierge-endpoint", "", "API base for the Concierge endpoint (default: the cluster's server from the kubeconfig)")
+	cmd.Flags().StringVar(&flags.conciergeCABundle, "concierge-ca-bundle-data", "", "CA bundle to use when connecting to the Concierge (default: the cluster's CA bundle from the kubeconfig)")
+	cmd.Flags().StringVar(&flags.conciergeAPIGroupSuffix, "concierge-api-group-suffix", groupsuffix.PinnipedDefaultSuffix, "Concierge API group suffix")
+	cmd.Flags().DurationVar(&flags.timeout, "timeout", 5*time.Minute, "Timeout for the whole login")
+
+	cmd.RunE = func(cmd *cobra.Command, _args []string) error { return runRequestLogin(cmd, deps, flags) }
+
+	return cmd
+}
+
+func runRequestLogin(cmd *cobra.Command, deps requestLoginDeps, flags requestLoginParams) error {
+	clientConfig := newClientConfig(flags.kubeconfigPath, flags.kubeconfigContextOverride)
+	cluster, err := clientConfig.ClientConfig()
+	if err != nil {
+		return fmt.Errorf("could not load --kubeconfig: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), flags.timeout)
+	defer cancel()
+	opts := []loginrequest.Option{loginrequest.WithContext(ctx)}
+
+	switch {
+	case flags.workloadIdentityTokenFile != "" && flags.issuer != "":
+		return fmt.Errorf("only one of --issuer or --workload-identity-token-file may be set")
+	case flags.workloadIdentityTokenFile != "":
+		opts = append(opts, loginrequest.WithWorkloadIdentityTokenFile(flags.workloadIdentityTokenFile))
+	case flags.issuer != "":
+		username := flags.username
+		if username == "" {
+			username, _ = deps.lookupEnv("PINNIPED_USERNAME")
+		}
+		password, _ := deps.lookupEnv("PINNIPED_PASSWORD")
+		if username == "" || password == "" {
+			return fmt.Errorf("--username (or PINNIPED_USERNAME) and PINNIPED_PASSWORD must be set when using --issuer")
+		}
+		opts = append(opts,
+			loginrequest.WithClientCredentials(flags.issuer, flags.clientID, username, password),
+			loginrequest.WithUpstreamIdentityProvider(flags.upstreamIdentityProviderName, flags.upstreamIdentityProviderType),
+			loginrequest.WithRequestAudience(flags.requestAudience),
+		)
+		if len(flags.caBundlePaths) > 0 || len(flags.caBundleData) > 0 {
+			pemBundles, err := readCABundles(flags.caBundlePaths, flags.caBundleData)
+			if err != nil {
+				return err
+			}
+			client, err := makeClient(false, pemBundles)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, loginrequest.WithClient(client))
+		}
+	default:
+		return fmt.Errorf("one of --issuer or --workload-identity-token-file must be set")
+	}
+
+	if flags.conciergeEnabled {
+		conciergeEndpoint := flags.conciergeEndpoint
+		if conciergeEndpoint == "" {
+			conciergeEndpoint = cluster.Host
+		}
+		conciergeCABundle := flags.conciergeCABundle
+		if conciergeCABundle == "" {
+			conciergeCABundle = base64.StdEncoding.EncodeToString(cluster.CAData)
+		}
+		concierge, err := conciergeclient.New(
+			conciergeclient.WithEndpoint(conciergeEndpoint),
+			conciergeclient.WithBase64CABundle(conciergeCABundle),
+			conciergeclient.WithAuthenticator(flags.conciergeAuthenticatorType, flags.conciergeAuthenticatorName),
+			conciergeclient.WithAPIGroupSuffix(flags.conciergeAPIGroupSuffix),
+		)
+		if err != nil {
+			return fmt.Errorf("invalid Concierge parameters: %w", err)
+		}
+		opts = append(opts, loginrequest.WithConcierge(concierge))
+	}
+
+	restConfig, err := deps.getRESTConfig(cluster, opts...)
+	if err != nil {
+		return err
+	}
+
+	kubeconfig, err := clientcmd.Write(restConfigToKubeconfig(restConfig))
+	if err != nil {
+		return fmt.Errorf("could not write kubeconfig: %w", err)
+	}
+	_, err = cmd.OutOrStdout().Write(kubeconfig)
+	return err
+}
+
+func restConfigToKubeconfig(restConfig *rest.Config) clientcmdapi.Config {
+	const name = "pinniped-login-request"
+	return clientcmdapi.Config{
+		Kind:       "Config",
+		APIVersion: clientcmdapi.SchemeGroupVersion.Version,
+		Clusters: map[string]*clientcmdapi.Cluster{
+			name: {
+				Server:                   restConfig.Host,
+				CertificateAuthorityData: restConfig.CAData,
+				TLSServerName:            restConfig.ServerName,
+				InsecureSkipTLSVerify:    restConfig.Insecure,
+			},
+		},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{
+			name: {
+				Token:                 restConfig.BearerToken,
+				ClientCertificateData: restConfig.CertData,
+				ClientKeyData:         restConfig.KeyData,
+			},
+		},
+		Contexts: map[string]*clientcmdapi.Context{
+			name: {Cluster: name, AuthInfo: name},
+		},
+		CurrentContext: name,
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+
+	"go.pinniped.dev/internal/certauthority"
+	"go.pinniped.dev/internal/here"
+	"go.pinniped.dev/pkg/loginrequest"
+)
+
+func TestLoginRequestCommand(t *testing.T) {
+	testCA, err := certauthority.New("Test CA", 1*time.Hour)
+	require.NoError(t, err)
+	testCABundleData := base64.StdEncoding.EncodeToString(testCA.Bundle())
+
+	tests := []struct {
+		name             string
+		args             []string
+		env              map[string]string
+		getRESTConfigErr error
+		wantError        bool
+		wantStdout       string
+		wantStderr       string
+		wantOptionsCount int
+	}{
+		{
+			name:      "missing issuer and workload identity token file",
+			args:      []string{"--kubeconfig", "./testdata/kubeconfig.yaml"},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: one of --issuer or --workload-identity-token-file must be set
+			`),
+		},
+		{
+			name: "both issuer and workload identity token file",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--issuer", "https://supervisor.example.com",
+				"--workload-identity-token-file", "some-file",
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: only one of --issuer or --workload-identity-token-file may be set
+			`),
+		},
+		{
+			name: "issuer without password",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--issuer", "https://supervisor.example.com",
+				"--username", "some-username",
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: --username (or PINNIPED_USERNAME) and PINNIPED_PASSWORD must be set when using --issuer
+			`),
+		},
+		{
+			name: "invalid kubeconfig",
+			args: []string{
+				"--kubeconfig", "./testdata/does-not-exist.yaml",
+				"--workload-identity-token-file", "some-file",
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: could not load --kubeconfig: stat ./testdata/does-not-exist.yaml: no such file or directory
+			`),
+		},
+		{
+			name: "invalid Concierge parameters",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--workload-identity-token-file", "some-file",
+				"--enable-concierge",
+				"--concierge-ca-bundle-data", testCABundleData,
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: invalid Concierge parameters: authenticator name must not be empty
+			`),
+		},
+		{
+			name: "login error",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--workload-identity-token-file", "some-file",
+			},
+			getRESTConfigErr: fmt.Errorf("some login error"),
+			wantOptionsCount: 2,
+			wantError:        true,
+			wantStderr: here.Doc(`
+				Error: some login error
+			`),
+		},
+		{
+			name: "workload identity token success",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--workload-identity-token-file", "some-file",
+			},
+			wantOptionsCount: 2,
+			wantStdout: here.Doc(`
+				apiVersion: v1
+				clusters:
+				- cluster:
+				    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+				    server: https://fake-server-url-value
+				  name: pinniped-login-request
+				contexts:
+				- context:
+				    cluster: pinniped-login-request
+				    user: pinniped-login-request
+				  name: pinniped-login-request
+				current-context: pinniped-login-request
+				kind: Config
+				preferences: {}
+				users:
+				- name: pinniped-login-request
+				  user:
+				    token: some-token
+			`),
+		},
+		{
+			name: "client credentials with Concierge success",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--kubeconfig-context", "some-other-context",
+				"--issuer", "https://supervisor.example.com",
+				"--ca-bundle-data", testCABundleData,
+				"--upstream-identity-provider-name", "some-ldap-idp",
+				"--upstream-identity-provider-type", "ldap",
+				"--request-audience", "some-cluster-audience",
+				"--enable-concierge",
+				"--concierge-authenticator-type", "jwt",
+				"--concierge-authenticator-name", "some-authenticator",
+				"--concierge-ca-bundle-data", testCABundleData,
+			},
+			env: map[string]string{
+				"PINNIPED_USERNAME": "some-username",
+				"PINNIPED_PASSWORD": "some-password",
+			},
+			wantOptionsCount: 6,
+			wantStdout: here.Doc(`
+				apiVersion: v1
+				clusters:
+				- cluster:
+				    certificate-authority-data: c29tZS1vdGhlci1mYWtlLWNlcnRpZmljYXRlLWF1dGhvcml0eS1kYXRhLXZhbHVl
+				    server: https://some-other-fake-server-url-value
+				  name: pinniped-login-request
+				contexts:
+				- context:
+				    cluster: pinniped-login-request
+				    user: pinniped-login-request
+				  name: pinniped-login-request
+				current-context: pinniped-login-request
+				kind: Config
+				preferences: {}
+				users:
+				- name: pinniped-login-request
+				  user:
+				    token: some-token
+			`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := requestLoginCommand(requestLoginDeps{
+				lookupEnv: func(s string) (string, bool) {
+					v, ok := tt.env[s]
+					return v, ok
+				},
+				getRESTConfig: func(cluster *rest.Config, opts ...loginrequest.Option) (*rest.Config, error) {
+					require.Len(t, opts, tt.wantOptionsCount)
+					if tt.getRESTConfigErr != nil {
+						return nil, tt.getRESTConfigErr
+					}
+					config := rest.AnonymousClientConfig(cluster)
+					config.BearerToken = "some-token"
+					return config, nil
+				},
+			})
+			require.NotNil(t, cmd)
+
+			var stdout, stderr bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			cmd.SetArgs(tt.args)
+			err := cmd.Execute()
+			if tt.wantError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.wantStdout, stdout.String(), "unexpected stdout")
+			require.Equal(t, tt.wantStderr, stderr.String(), "unexpected stderr")
+		})
+	}
+}
@@ -24,10 +24,14 @@ type OIDCClientOptions interface {
 	WithSkipPrintLoginURL() oidcclient.Option
 	WithSessionCache(cache oidcclient.SessionCache) oidcclient.Option
 	WithClient(httpClient *http.Client) oidcclient.Option
+	WithCABundles(includeSystemRoots bool, pemBundles [][]byte) oidcclient.Option
 	WithScopes(scopes []string) oidcclient.Option
 	WithRequestAudience(audience string) oidcclient.Option
+	WithDPoP() oidcclient.Option
+	WithClientAssertionKeyFile(path string) oidcclient.Option
 	WithLoginFlow(loginFlow v1alpha1.IDPFlow, flowSource string) oidcclient.Option
 	WithUpstreamIdentityProvider(upstreamName, upstreamType string) oidcclient.Option
+	WithLanguage(lang string) oidcclient.Option
 }
 
 // clientOptions implements OIDCClientOptions for production use.
@@ -67,6 +71,10 @@ func (o *clientOptions) WithClient(httpClient *http.Client) oidcclient.Option {
 	return oidcclient.WithClient(httpClient)
 }
 
+func (o *clientOptions) WithCABundles(includeSystemRoots bool, pemBundles [][]byte) oidcclient.Option {
+	return oidcclient.WithCABundles(includeSystemRoots, pemBundles)
+}
+
 func (o *clientOptions) WithScopes(scopes []string) oidcclient.Option {
 	return oidcclient.WithScopes(scopes)
 }
@@ -75,6 +83,14 @@ func (o *clientOptions) WithRequestAudience(audience string) oidcclient.Option {
 	return oidcclient.WithRequestAudience(audience)
 }
 
+func (o *clientOptions) WithDPoP() oidcclient.Option {
+	return oidcclient.WithDPoP()
+}
+
+func (o *clientOptions) WithClientAssertionKeyFile(path string) oidcclient.Option {
+	return oidcclient.WithClientAssertionKeyFile(path)
+}
+
 func (o *clientOptions) WithLoginFlow(loginFlow v1alpha1.IDPFlow, flowSource string) oidcclient.Option {
 	return oidcclient.WithLoginFlow(loginFlow, flowSource)
 }
@@ -82,3 +98,7 @@ func (o *clientOptions) WithLoginFlow(loginFlow v1alpha1.IDPFlow, flowSource str
 func (o *clientOptions) WithUpstreamIdentityProvider(upstreamName, upstreamType string) oidcclient.Option {
 	return oidcclient.WithUpstreamIdentityProvider(upstreamName, upstreamType)
 }
+
+func (o *clientOptions) WithLanguage(lang string) oidcclient.Option {
+	return oidcclient.WithLanguage(lang)
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	outputFormatText = "text"
+	outputFormatJSON = "json"
+	outputFormatYAML = "yaml"
+
+	// outputAPIVersion is the version of the schemas of the JSON and YAML output of the CLI commands which do not
+	// print a Kubernetes object. Fields may be added within a version, but are never renamed or removed.
+	outputAPIVersion = "cli.pinniped.dev/v1alpha1"
+)
+
+// outputTypeMeta identifies the schema of a JSON or YAML document printed by a CLI command.
+type outputTypeMeta struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+}
+
+func newOutputTypeMeta(kind string) outputTypeMeta {
+	return outputTypeMeta{APIVersion: outputAPIVersion, Kind: kind}
+}
+
+// validateOutputFormat returns an error when the value of an --output flag is not one of the supported formats.
+func validateOutputFormat(format string, supported ...string) error {
+	for _, s := range supported {
+		if format == s {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output format: %q", format)
+}
+
+// writeStructuredOutput prints obj as indented JSON or as YAML, depending on the format.
+func writeStructuredOutput(out io.Writer, format string, obj any) error {
+	var data []byte
+	var err error
+	switch format {
+	case outputFormatJSON:
+		data, err = json.MarshalIndent(obj, "", "  ")
+		data = append(data, '\n')
+	case outputFormatYAML:
+		data, err = yaml.Marshal(obj)
+	default:
+		return fmt.Errorf("unknown output format: %q", format)
+	}
+	if err != nil {
+		return fmt.Errorf("could not encode output: %w", err)
+	}
+	if _, err := out.Write(data); err != nil {
+		return fmt.Errorf("could not write output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// errNotInteractive is returned by a promptForChoiceFunc when the user cannot be asked to choose.
+var errNotInteractive = errors.New("stdin is not connected to a terminal")
+
+// promptForChoiceFunc asks the user to choose one of the choices, and returns the index of the chosen one.
+type promptForChoiceFunc func(ctx context.Context, label string, choices []string) (int, error)
+
+// promptForChoice lists the numbered choices on stderr and reads the number of the chosen one from stdin.
+func promptForChoice(ctx context.Context, label string, choices []string) (int, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return 0, errNotInteractive
+	}
+	return readChoice(ctx, os.Stdin, os.Stderr, label, choices)
+}
+
+func readChoice(ctx context.Context, in io.Reader, out io.Writer, label string, choices []string) (int, error) {
+	if _, err := fmt.Fprintln(out, label); err != nil {
+		return 0, fmt.Errorf("could not print prompt to stderr: %w", err)
+	}
+	for i, choice := range choices {
+		_, _ = fmt.Fprintf(out, "  %d) %s\n", i+1, choice)
+	}
+	_, _ = fmt.Fprintf(out, "Enter a number [1-%d]: ", len(choices))
+
+	type readResult struct {
+		text string
+		err  error
+	}
+	// Buffer the channel so the background goroutine can always finish sending, even after we stop listening.
+	readResults := make(chan readResult, 1)
+	go func() {
+		text, err := bufio.NewReader(in).ReadString('\n')
+		readResults <- readResult{text, err}
+		close(readResults)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case r := <-readResults:
+		if r.err != nil && !(errors.Is(r.err, io.EOF) && r.text != "") {
+			return 0, fmt.Errorf("could not read choice: %w", r.err)
+		}
+		choice, err := strconv.Atoi(strings.TrimSpace(r.text))
+		if err != nil || choice < 1 || choice > len(choices) {
+			return 0, fmt.Errorf("invalid choice %q, expected a number from 1 to %d", strings.TrimSpace(r.text), len(choices))
+		}
+		return choice - 1, nil
+	}
+}
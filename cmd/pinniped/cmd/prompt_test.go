@@ -0,0 +1,66 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadChoice(t *testing.T) {
+	choices := []string{"some-ldap-idp (ldap)", "some-oidc-idp (oidc)"}
+
+	tests := []struct {
+		name      string
+		input     string
+		want      int
+		wantError string
+	}{
+		{
+			name:  "first choice",
+			input: "1\n",
+			want:  0,
+		},
+		{
+			name:  "last choice with whitespace and without a newline",
+			input: " 2 ",
+			want:  1,
+		},
+		{
+			name:      "out of range",
+			input:     "3\n",
+			wantError: `invalid choice "3", expected a number from 1 to 2`,
+		},
+		{
+			name:      "not a number",
+			input:     "some-ldap-idp\n",
+			wantError: `invalid choice "some-ldap-idp", expected a number from 1 to 2`,
+		},
+		{
+			name:      "no input",
+			input:     "",
+			wantError: "could not read choice: EOF",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := readChoice(context.Background(), strings.NewReader(tt.input), &out, "Choose one:", choices)
+			if tt.wantError != "" {
+				require.EqualError(t, err, tt.wantError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.want, got)
+			}
+			require.Equal(t, "Choose one:\n"+
+				"  1) some-ldap-idp (ldap)\n"+
+				"  2) some-oidc-idp (oidc)\n"+
+				"Enter a number [1-2]: ", out.String())
+		})
+	}
+}
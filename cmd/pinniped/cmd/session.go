@@ -0,0 +1,45 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"go.pinniped.dev/internal/here"
+)
+
+//nolint:gochecknoglobals
+var sessionCmd = &cobra.Command{
+	Use:   "session",
+	Short: "Inspects or deletes cached sessions with one of [list, delete]",
+	Long: here.Doc(
+		`Inspects or deletes cached sessions with one of [list, delete]
+
+			The Pinniped login commands cache OpenID Connect sessions in a session cache file,
+			and the cluster credentials which they receive from the Concierge in a credential
+			cache file. These subcommands operate on both files, and never print any tokens.`,
+	),
+	SilenceUsage: true, // Do not print usage message when commands fail.
+}
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(sessionCmd)
+}
+
+// sessionCacheFlags are the flags which choose the cache files and the output format of the session subcommands.
+type sessionCacheFlags struct {
+	sessionCachePath    string
+	credentialCachePath string
+	outputFormat        string
+}
+
+func (f *sessionCacheFlags) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.sessionCachePath, "session-cache", filepath.Join(mustGetConfigDir(), "sessions.yaml"), "Path to session cache file (\"\" skips the session cache)")
+	cmd.Flags().StringVar(&f.credentialCachePath, "credential-cache", filepath.Join(mustGetConfigDir(), "credentials.yaml"), "Path to cluster-specific credentials cache (\"\" skips the credential cache)")
+	cmd.Flags().StringVarP(&f.outputFormat, "output", "o", outputFormatText, "Output format (e.g., 'yaml', 'json', 'text')")
+	mustRegisterFlagCompletionFunc(cmd, "output", completeOutputFormats)
+}
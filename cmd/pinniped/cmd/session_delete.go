@@ -0,0 +1,143 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"go.pinniped.dev/internal/execcredcache"
+	"go.pinniped.dev/internal/here"
+	"go.pinniped.dev/pkg/oidcclient/filesession"
+)
+
+//nolint:gochecknoinits
+func init() {
+	sessionCmd.AddCommand(sessionDeleteCommand())
+}
+
+type sessionDeleteFlags struct {
+	sessionCacheFlags
+	issuer string
+	all    bool
+}
+
+func sessionDeleteCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete [ID...]",
+		Short: "Delete cached sessions and cluster credentials",
+		Long: here.Doc(
+			`Delete cached sessions and cluster credentials
+
+				Deletes the sessions and cluster credentials with the given IDs, as printed by
+				"pinniped session list", or all sessions of an issuer when --issuer is used. The
+				next login which would have used a deleted session starts a new session instead.
+
+				Cluster credentials are cached under a hash of the login command's arguments, so
+				they cannot be traced back to the session which they were created from. Whenever
+				a session is deleted, all cluster credentials are also deleted, so that they are
+				not used by kubectl until they expire.`,
+		),
+		SilenceUsage: true, // do not print usage message when commands fail
+	}
+	flags := &sessionDeleteFlags{}
+	flags.addFlags(cmd)
+	cmd.Flags().StringVar(&flags.issuer, "issuer", "", "Delete all sessions of this OpenID Connect issuer")
+	cmd.Flags().BoolVar(&flags.all, "all", false, "Delete all sessions and cluster credentials")
+	mustRegisterFlagCompletionFunc(cmd, "issuer", completeSessionIssuers)
+	cmd.ValidArgsFunction = completeSessionIDs
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(flags.outputFormat, outputFormatText, outputFormatJSON, outputFormatYAML); err != nil {
+			return err
+		}
+		return runSessionDelete(cmd.OutOrStdout(), flags, args)
+	}
+	return cmd
+}
+
+// sessionDeleteOutput is the schema of the JSON and YAML output of "pinniped session delete".
+type sessionDeleteOutput struct {
+	outputTypeMeta     `json:",inline"`
+	DeletedSessions    int `json:"deletedSessions"`
+	DeletedCredentials int `json:"deletedCredentials"`
+}
+
+func runSessionDelete(out io.Writer, flags *sessionDeleteFlags, ids []string) error {
+	if len(ids) == 0 && flags.issuer == "" && !flags.all {
+		return fmt.Errorf("specify the IDs of the sessions or cluster credentials to delete, or use --issuer or --all")
+	}
+	if flags.all && (len(ids) > 0 || flags.issuer != "") {
+		return fmt.Errorf("--all cannot be used with IDs or --issuer")
+	}
+
+	encryption, err := cacheEncryption(os.LookupEnv)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	reportError := func(err error) { errs = append(errs, err) }
+	found := map[string]bool{}
+
+	deletedSessions := 0
+	if flags.sessionCachePath != "" {
+		sessionCache := filesession.New(flags.sessionCachePath,
+			filesession.WithErrorReporter(reportError),
+			filesession.WithEncryption(encryption),
+		)
+		deletedSessions = sessionCache.DeleteSessions(func(s filesession.SessionSummary) bool {
+			if slices.Contains(ids, s.ID) {
+				found[s.ID] = true
+				return true
+			}
+			return flags.all || (flags.issuer != "" && s.Key.Issuer == flags.issuer)
+		})
+	}
+
+	deletedCredentials := 0
+	if flags.credentialCachePath != "" {
+		credentialCache := execcredcache.New(flags.credentialCachePath, execcredcache.WithEncryption(encryption))
+		deletedCredentials = credentialCache.Delete(func(c execcredcache.Summary) bool {
+			if slices.Contains(ids, c.ID) {
+				found[c.ID] = true
+				return true
+			}
+			return flags.all || deletedSessions > 0
+		})
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("could not update session cache: %w", errs[0])
+	}
+
+	if flags.outputFormat == outputFormatText {
+		_, _ = fmt.Fprintf(out, "Deleted %d session(s) and %d cluster credential(s).\n", deletedSessions, deletedCredentials)
+	} else {
+		err := writeStructuredOutput(out, flags.outputFormat, sessionDeleteOutput{
+			outputTypeMeta:     newOutputTypeMeta("SessionDeletion"),
+			DeletedSessions:    deletedSessions,
+			DeletedCredentials: deletedCredentials,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	var notFound []string
+	for _, id := range ids {
+		if !found[id] {
+			notFound = append(notFound, id)
+		}
+	}
+	if len(notFound) > 0 {
+		return fmt.Errorf("no cached session or cluster credential has ID %s", strings.Join(notFound, ", "))
+	}
+	return nil
+}
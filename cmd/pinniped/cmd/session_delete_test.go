@@ -0,0 +1,125 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go.pinniped.dev/internal/here"
+)
+
+func TestSessionDelete(t *testing.T) {
+	now := time.Now().Round(time.Second)
+
+	tests := []struct {
+		name string
+		// args receives the IDs of the sessions and of the credentials which were written before the test.
+		args              func(sessionIDs, credentialIDs []string) []string
+		wantError         string
+		wantStdout        string
+		wantSessionIDs    func(sessionIDs []string) []string
+		wantCredentialIDs func(credentialIDs []string) []string
+	}{
+		{
+			name:      "no arguments",
+			args:      func(_, _ []string) []string { return nil },
+			wantError: "specify the IDs of the sessions or cluster credentials to delete, or use --issuer or --all",
+		},
+		{
+			name:      "--all with an ID",
+			args:      func(sessionIDs, _ []string) []string { return []string{"--all", sessionIDs[0]} },
+			wantError: "--all cannot be used with IDs or --issuer",
+		},
+		{
+			name:       "delete a session by ID also deletes the credentials",
+			args:       func(sessionIDs, _ []string) []string { return []string{sessionIDs[1]} },
+			wantStdout: "Deleted 1 session(s) and 1 cluster credential(s).\n",
+			wantSessionIDs: func(sessionIDs []string) []string {
+				return sessionIDs[:1]
+			},
+		},
+		{
+			name:           "delete a credential by ID",
+			args:           func(_, credentialIDs []string) []string { return []string{credentialIDs[0]} },
+			wantStdout:     "Deleted 0 session(s) and 1 cluster credential(s).\n",
+			wantSessionIDs: func(sessionIDs []string) []string { return sessionIDs },
+		},
+		{
+			name:       "delete the sessions of an issuer",
+			args:       func(_, _ []string) []string { return []string{"--issuer", "https://issuer.example.com"} },
+			wantStdout: "Deleted 1 session(s) and 1 cluster credential(s).\n",
+			wantSessionIDs: func(sessionIDs []string) []string {
+				return sessionIDs[1:]
+			},
+		},
+		{
+			name:       "delete everything",
+			args:       func(_, _ []string) []string { return []string{"--all"} },
+			wantStdout: "Deleted 2 session(s) and 1 cluster credential(s).\n",
+		},
+		{
+			name: "delete everything as YAML",
+			args: func(_, _ []string) []string { return []string{"--all", "-o", "yaml"} },
+			wantStdout: here.Doc(`
+				apiVersion: cli.pinniped.dev/v1alpha1
+				deletedCredentials: 1
+				deletedSessions: 2
+				kind: SessionDeletion
+			`),
+		},
+		{
+			name:           "unknown ID",
+			args:           func(_, _ []string) []string { return []string{"00000000"} },
+			wantStdout:     "Deleted 0 session(s) and 0 cluster credential(s).\n",
+			wantError:      "no cached session or cluster credential has ID 00000000",
+			wantSessionIDs: func(sessionIDs []string) []string { return sessionIDs },
+			wantCredentialIDs: func(credentialIDs []string) []string {
+				return credentialIDs
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessionCachePath := filepath.Join(t.TempDir(), "sessions.yaml")
+			credentialCachePath := filepath.Join(t.TempDir(), "credentials.yaml")
+			writeTestSessions(t, sessionCachePath, now)
+			writeTestCredentials(t, credentialCachePath, now)
+			sessionIDs, credentialIDs := testCacheIDs(t, sessionCachePath, credentialCachePath)
+			require.Len(t, sessionIDs, 2)
+			require.Len(t, credentialIDs, 1)
+
+			cmd := sessionDeleteCommand()
+			var stdout, stderr bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			cmd.SetArgs(append([]string{"--session-cache", sessionCachePath, "--credential-cache", credentialCachePath}, tt.args(sessionIDs, credentialIDs)...))
+			err := cmd.Execute()
+			if tt.wantError != "" {
+				require.EqualError(t, err, tt.wantError)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.wantStdout, stdout.String())
+
+			wantSessionIDs, wantCredentialIDs := sessionIDs, credentialIDs
+			if tt.wantStdout != "" {
+				wantSessionIDs, wantCredentialIDs = nil, nil
+				if tt.wantSessionIDs != nil {
+					wantSessionIDs = tt.wantSessionIDs(sessionIDs)
+				}
+				if tt.wantCredentialIDs != nil {
+					wantCredentialIDs = tt.wantCredentialIDs(credentialIDs)
+				}
+			}
+			gotSessionIDs, gotCredentialIDs := testCacheIDs(t, sessionCachePath, credentialCachePath)
+			require.Equal(t, wantSessionIDs, gotSessionIDs)
+			require.Equal(t, wantCredentialIDs, gotCredentialIDs)
+		})
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go.pinniped.dev/internal/execcredcache"
+	"go.pinniped.dev/pkg/oidcclient/filesession"
+)
+
+//nolint:gochecknoinits
+func init() {
+	sessionCmd.AddCommand(sessionListCommand())
+}
+
+func sessionListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Args:         cobra.NoArgs,
+		Use:          "list",
+		Short:        "List the cached sessions and cluster credentials",
+		SilenceUsage: true, // do not print usage message when commands fail
+	}
+	flags := &sessionCacheFlags{}
+	flags.addFlags(cmd)
+
+	cmd.RunE = func(cmd *cobra.Command, _ []string) error {
+		if err := validateOutputFormat(flags.outputFormat, outputFormatText, outputFormatJSON, outputFormatYAML); err != nil {
+			return err
+		}
+		return runSessionList(cmd.OutOrStdout(), flags)
+	}
+	return cmd
+}
+
+// sessionListOutput is the schema of the JSON and YAML output of "pinniped session list". A cache which was
+// skipped is omitted.
+type sessionListOutput struct {
+	outputTypeMeta  `json:",inline"`
+	SessionCache    *sessionCacheOutput    `json:"sessionCache,omitempty"`
+	CredentialCache *credentialCacheOutput `json:"credentialCache,omitempty"`
+}
+
+type sessionCacheOutput struct {
+	Path     string          `json:"path"`
+	Sessions []sessionOutput `json:"sessions"`
+}
+
+type sessionOutput struct {
+	ID                   string     `json:"id"`
+	Issuer               string     `json:"issuer"`
+	ClientID             string     `json:"clientID"`
+	Scopes               []string   `json:"scopes,omitempty"`
+	UpstreamProviderName string     `json:"upstreamProviderName,omitempty"`
+	IDTokenExpiry        *time.Time `json:"idTokenExpiry,omitempty"`
+	AccessTokenExpiry    *time.Time `json:"accessTokenExpiry,omitempty"`
+	Refreshable          bool       `json:"refreshable"`
+	LastUsed             time.Time  `json:"lastUsed"`
+}
+
+type credentialCacheOutput struct {
+	Path        string             `json:"path"`
+	Credentials []credentialOutput `json:"credentials"`
+}
+
+type credentialOutput struct {
+	ID       string    `json:"id"`
+	Username string    `json:"username,omitempty"`
+	Expiry   time.Time `json:"expiry"`
+	Created  time.Time `json:"created"`
+	LastUsed time.Time `json:"lastUsed"`
+}
+
+func runSessionList(out io.Writer, flags *sessionCacheFlags) error {
+	encryption, err := cacheEncryption(os.LookupEnv)
+	if err != nil {
+		return err
+	}
+
+	var sessions []filesession.SessionSummary
+	if flags.sessionCachePath != "" {
+		if sessions, err = filesession.ReadSessionSummaries(flags.sessionCachePath, filesession.WithEncryption(encryption)); err != nil {
+			return fmt.Errorf("could not read session cache: %w", err)
+		}
+	}
+	var credentials []execcredcache.Summary
+	if flags.credentialCachePath != "" {
+		if credentials, err = execcredcache.ReadSummaries(flags.credentialCachePath, execcredcache.WithEncryption(encryption)); err != nil {
+			return fmt.Errorf("could not read credential cache: %w", err)
+		}
+	}
+
+	if flags.outputFormat != outputFormatText {
+		return writeStructuredOutput(out, flags.outputFormat, newSessionListOutput(flags, sessions, credentials))
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+
+	if flags.sessionCachePath != "" {
+		if len(sessions) == 0 {
+			_, _ = fmt.Fprintf(w, "No cached sessions in %s.\n", flags.sessionCachePath)
+		} else {
+			_, _ = fmt.Fprintf(w, "Sessions in %s:\n", flags.sessionCachePath)
+			_, _ = fmt.Fprintln(w, "ID\tISSUER\tCLIENT ID\tSCOPES\tUPSTREAM IDP\tID TOKEN EXPIRES\tREFRESHABLE")
+			for _, s := range sessions {
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+					s.ID, s.Key.Issuer, s.Key.ClientID, orDash(strings.Join(s.Key.Scopes, " ")),
+					orDash(s.Key.UpstreamProviderName), formatExpiry(s.IDTokenExpiry), yesOrNo(s.HasRefreshToken))
+			}
+		}
+	}
+
+	if flags.credentialCachePath != "" {
+		if len(credentials) == 0 {
+			_, _ = fmt.Fprintf(w, "No cached cluster credentials in %s.\n", flags.credentialCachePath)
+		} else {
+			_, _ = fmt.Fprintf(w, "Cluster credentials in %s:\n", flags.credentialCachePath)
+			_, _ = fmt.Fprintln(w, "ID\tUSERNAME\tEXPIRES")
+			for _, c := range credentials {
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", c.ID, orDash(c.Username), formatExpiry(c.Expiry))
+			}
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("could not write output: %w", err)
+	}
+	return nil
+}
+
+func newSessionListOutput(flags *sessionCacheFlags, sessions []filesession.SessionSummary, credentials []execcredcache.Summary) *sessionListOutput {
+	output := &sessionListOutput{outputTypeMeta: newOutputTypeMeta("SessionList")}
+	if flags.sessionCachePath != "" {
+		output.SessionCache = &sessionCacheOutput{Path: flags.sessionCachePath, Sessions: make([]sessionOutput, 0, len(sessions))}
+		for _, s := range sessions {
+			output.SessionCache.Sessions = append(output.SessionCache.Sessions, sessionOutput{
+				ID:                   s.ID,
+				Issuer:               s.Key.Issuer,
+				ClientID:             s.Key.ClientID,
+				Scopes:               s.Key.Scopes,
+				UpstreamProviderName: s.Key.UpstreamProviderName,
+				IDTokenExpiry:        nonZeroTime(s.IDTokenExpiry),
+				AccessTokenExpiry:    nonZeroTime(s.AccessTokenExpiry),
+				Refreshable:          s.HasRefreshToken,
+				LastUsed:             s.LastUsed,
+			})
+		}
+	}
+	if flags.credentialCachePath != "" {
+		output.CredentialCache = &credentialCacheOutput{Path: flags.credentialCachePath, Credentials: make([]credentialOutput, 0, len(credentials))}
+		for _, c := range credentials {
+			output.CredentialCache.Credentials = append(output.CredentialCache.Credentials, credentialOutput{
+				ID:       c.ID,
+				Username: c.Username,
+				Expiry:   c.Expiry,
+				Created:  c.Created,
+				LastUsed: c.LastUsed,
+			})
+		}
+	}
+	return output
+}
+
+func nonZeroTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func formatExpiry(t time.Time) string {
+	if t.IsZero() {
+		return "expired"
+	}
+	return t.Local().Format(time.RFC3339)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func yesOrNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,200 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientauthv1beta1 "k8s.io/client-go/pkg/apis/clientauthentication/v1beta1"
+
+	"go.pinniped.dev/internal/execcredcache"
+	"go.pinniped.dev/internal/here"
+	"go.pinniped.dev/pkg/oidcclient"
+	"go.pinniped.dev/pkg/oidcclient/filesession"
+	"go.pinniped.dev/pkg/oidcclient/oidctypes"
+)
+
+func TestSessionList(t *testing.T) {
+	now := time.Now().Round(time.Second)
+	expiry := now.Add(time.Hour).Local().Format(time.RFC3339)
+
+	tests := []struct {
+		name       string
+		args       func(t *testing.T, sessionCachePath, credentialCachePath string) []string
+		wantError  string
+		wantStdout func(sessionCachePath, credentialCachePath string, sessionIDs, credentialIDs []string) string
+	}{
+		{
+			name: "sessions and credentials",
+			args: func(t *testing.T, sessionCachePath, credentialCachePath string) []string {
+				writeTestSessions(t, sessionCachePath, now)
+				writeTestCredentials(t, credentialCachePath, now)
+				return []string{"--session-cache", sessionCachePath, "--credential-cache", credentialCachePath}
+			},
+			wantStdout: func(sessionCachePath, credentialCachePath string, sessionIDs, credentialIDs []string) string {
+				return here.Docf(`
+					Sessions in %s:
+					ID | ISSUER | CLIENT ID | SCOPES | UPSTREAM IDP | ID TOKEN EXPIRES | REFRESHABLE
+					%s | https://issuer.example.com | pinniped-cli | openid pinniped:request-audience | some-idp | %s | yes
+					%s | https://other-issuer.example.com | pinniped-cli | - | - | expired | yes
+					Cluster credentials in %s:
+					ID | USERNAME | EXPIRES
+					%s | - | %s
+				`, sessionCachePath, sessionIDs[0], expiry, sessionIDs[1], credentialCachePath, credentialIDs[0], expiry)
+			},
+		},
+		{
+			name: "empty caches",
+			args: func(t *testing.T, sessionCachePath, credentialCachePath string) []string {
+				return []string{"--session-cache", sessionCachePath, "--credential-cache", credentialCachePath}
+			},
+			wantStdout: func(sessionCachePath, credentialCachePath string, _, _ []string) string {
+				return here.Docf(`
+					No cached sessions in %s.
+					No cached cluster credentials in %s.
+				`, sessionCachePath, credentialCachePath)
+			},
+		},
+		{
+			name: "credential cache skipped",
+			args: func(t *testing.T, sessionCachePath, _ string) []string {
+				return []string{"--session-cache", sessionCachePath, "--credential-cache", ""}
+			},
+			wantStdout: func(sessionCachePath, _ string, _, _ []string) string {
+				return here.Docf(`
+					No cached sessions in %s.
+				`, sessionCachePath)
+			},
+		},
+		{
+			name: "invalid session cache",
+			args: func(t *testing.T, _, credentialCachePath string) []string {
+				return []string{"--session-cache", "./testdata/kubeconfig.yaml", "--credential-cache", credentialCachePath}
+			},
+			wantError: "could not read session cache: unsupported session version: v1.TypeMeta{Kind:\"Config\", APIVersion:\"v1\"}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessionCachePath := filepath.Join(t.TempDir(), "sessions.yaml")
+			credentialCachePath := filepath.Join(t.TempDir(), "credentials.yaml")
+
+			cmd := sessionListCommand()
+			var stdout, stderr bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			cmd.SetArgs(tt.args(t, sessionCachePath, credentialCachePath))
+			err := cmd.Execute()
+			if tt.wantError != "" {
+				require.EqualError(t, err, tt.wantError)
+				return
+			}
+			require.NoError(t, err)
+			require.Empty(t, stderr.String())
+
+			sessionIDs, credentialIDs := testCacheIDs(t, sessionCachePath, credentialCachePath)
+			require.Equal(t, tt.wantStdout(sessionCachePath, credentialCachePath, sessionIDs, credentialIDs), columns(stdout.String()))
+			require.NotContains(t, stdout.String(), "some-secret-token")
+		})
+	}
+}
+
+func TestSessionListJSON(t *testing.T) {
+	now := time.Now().Round(time.Second)
+	sessionCachePath := filepath.Join(t.TempDir(), "sessions.yaml")
+	credentialCachePath := filepath.Join(t.TempDir(), "credentials.yaml")
+	writeTestSessions(t, sessionCachePath, now)
+	writeTestCredentials(t, credentialCachePath, now)
+	sessionIDs, credentialIDs := testCacheIDs(t, sessionCachePath, credentialCachePath)
+
+	cmd := sessionListCommand()
+	var stdout bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetArgs([]string{"--session-cache", sessionCachePath, "--credential-cache", credentialCachePath, "-o", "json"})
+	require.NoError(t, cmd.Execute())
+	require.NotContains(t, stdout.String(), "some-secret-token")
+
+	var output sessionListOutput
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &output))
+	require.Equal(t, newOutputTypeMeta("SessionList"), output.outputTypeMeta)
+
+	require.Equal(t, sessionCachePath, output.SessionCache.Path)
+	require.Len(t, output.SessionCache.Sessions, 2)
+	first, second := output.SessionCache.Sessions[0], output.SessionCache.Sessions[1]
+	require.Equal(t, sessionIDs[0], first.ID)
+	require.Equal(t, "https://issuer.example.com", first.Issuer)
+	require.Equal(t, "pinniped-cli", first.ClientID)
+	require.Equal(t, []string{"openid", "pinniped:request-audience"}, first.Scopes)
+	require.Equal(t, "some-idp", first.UpstreamProviderName)
+	require.True(t, first.IDTokenExpiry.Equal(now.Add(time.Hour)))
+	require.Nil(t, first.AccessTokenExpiry)
+	require.True(t, first.Refreshable)
+	require.Equal(t, sessionIDs[1], second.ID)
+	require.Nil(t, second.IDTokenExpiry)
+	require.True(t, second.Refreshable)
+
+	require.Equal(t, credentialCachePath, output.CredentialCache.Path)
+	require.Len(t, output.CredentialCache.Credentials, 1)
+	require.Equal(t, credentialIDs[0], output.CredentialCache.Credentials[0].ID)
+	require.True(t, output.CredentialCache.Credentials[0].Expiry.Equal(now.Add(time.Hour)))
+}
+
+// writeTestSessions writes one healthy session, and one session which can only be refreshed.
+func writeTestSessions(t *testing.T, path string, now time.Time) {
+	t.Helper()
+	cache := filesession.New(path)
+	cache.PutToken(oidcclient.SessionCacheKey{
+		Issuer:               "https://issuer.example.com",
+		ClientID:             "pinniped-cli",
+		Scopes:               []string{"openid", "pinniped:request-audience"},
+		UpstreamProviderName: "some-idp",
+	}, &oidctypes.Token{
+		IDToken:      &oidctypes.IDToken{Token: "some-secret-token", Expiry: metav1.NewTime(now.Add(time.Hour))},
+		RefreshToken: &oidctypes.RefreshToken{Token: "some-secret-token"},
+	})
+	cache.PutToken(oidcclient.SessionCacheKey{
+		Issuer:   "https://other-issuer.example.com",
+		ClientID: "pinniped-cli",
+	}, &oidctypes.Token{
+		RefreshToken: &oidctypes.RefreshToken{Token: "some-secret-token"},
+	})
+}
+
+func writeTestCredentials(t *testing.T, path string, now time.Time) {
+	t.Helper()
+	expiry := metav1.NewTime(now.Add(time.Hour))
+	execcredcache.New(path).Put("some-key", &clientauthv1beta1.ExecCredential{
+		Status: &clientauthv1beta1.ExecCredentialStatus{Token: "some-secret-token", ExpirationTimestamp: &expiry},
+	})
+}
+
+func testCacheIDs(t *testing.T, sessionCachePath, credentialCachePath string) ([]string, []string) {
+	t.Helper()
+	sessions, err := filesession.ReadSessionSummaries(sessionCachePath)
+	require.NoError(t, err)
+	credentials, err := execcredcache.ReadSummaries(credentialCachePath)
+	require.NoError(t, err)
+
+	var sessionIDs, credentialIDs []string
+	for _, s := range sessions {
+		sessionIDs = append(sessionIDs, s.ID)
+	}
+	for _, c := range credentials {
+		credentialIDs = append(credentialIDs, c.ID)
+	}
+	return sessionIDs, credentialIDs
+}
+
+// columns replaces the padding between the columns of a table with " | ", so that tables can be compared
+// without depending on the widths of their columns.
+func columns(s string) string {
+	return regexp.MustCompile(` {3,}`).ReplaceAllString(s, " | ")
+}
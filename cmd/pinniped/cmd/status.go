@@ -0,0 +1,211 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	conciergeconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/config/v1alpha1"
+	"go.pinniped.dev/internal/here"
+	"go.pinniped.dev/pkg/oidcclient/filesession"
+)
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(statusCommand(statusDeps{doctorDeps: doctorRealDeps(), getClientset: getRealConciergeClientset}))
+}
+
+type statusDeps struct {
+	doctorDeps
+	getClientset getConciergeClientsetFunc
+}
+
+func statusCommand(deps statusDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "status",
+		Short: "Print a health summary of the Pinniped login of the current kubeconfig context",
+		Long: here.Doc(
+			`Print a health summary of the Pinniped login of the current kubeconfig context
+
+				Reads the readiness of the strategies of the Concierge's CredentialIssuer, checks
+				that the OpenID Connect issuer can be reached, and prints when the cached sessions
+				of that issuer expire. Reading the CredentialIssuer uses the credentials of the
+				kubeconfig context, so it may start a login, and it requires permission to read
+				CredentialIssuers, which is usually only granted to cluster administrators.`,
+		),
+		SilenceUsage: true, // do not print usage message when commands fail
+	}
+	flags := &doctorFlags{}
+
+	f := cmd.Flags()
+	f.StringVar(&flags.kubeconfigPath, "kubeconfig", os.Getenv("KUBECONFIG"), "Path to kubeconfig file")
+	f.StringVar(&flags.kubeconfigContextOverride, "kubeconfig-context", "", "Kubeconfig context name (default: current active context)")
+	f.DurationVar(&flags.timeout, "timeout", 30*time.Second, "Timeout for reading the CredentialIssuer and for probing the OpenID Connect issuer")
+	f.StringVarP(&flags.outputFormat, "output", "o", outputFormatText, "Output format (e.g., 'yaml', 'json', 'text')")
+
+	mustRegisterFlagCompletionFunc(cmd, "kubeconfig-context", completeKubeconfigContexts)
+	mustRegisterFlagCompletionFunc(cmd, "output", completeOutputFormats)
+
+	cmd.RunE = func(cmd *cobra.Command, _ []string) error {
+		if err := validateOutputFormat(flags.outputFormat, outputFormatText, outputFormatJSON, outputFormatYAML); err != nil {
+			return err
+		}
+		return runStatus(cmd.Context(), cmd.OutOrStdout(), deps, flags)
+	}
+	return cmd
+}
+
+func runStatus(ctx context.Context, out io.Writer, deps statusDeps, flags *doctorFlags) error {
+	report := &doctorReport{}
+
+	cluster, execConfig := doctorCheckKubeconfig(report, flags)
+	login := doctorCheckExecPlugin(report, deps.doctorDeps, execConfig)
+
+	if login != nil {
+		statusCheckConcierge(ctx, report, deps, flags, login)
+		if login.issuer != "" {
+			doctorCheckIssuer(ctx, report, flags.timeout, login, cluster)
+			statusCheckSessions(report, deps, login)
+		}
+	}
+
+	if err := report.writeAs(out, flags.outputFormat, "StatusReport"); err != nil {
+		return err
+	}
+	if problems := report.problems(); problems > 0 {
+		return fmt.Errorf("found %d problem(s)", problems)
+	}
+	return nil
+}
+
+// statusCheckConcierge reports the strategies of the CredentialIssuers of the Concierge.
+func statusCheckConcierge(ctx context.Context, report *doctorReport, deps statusDeps, flags *doctorFlags, login *pinnipedLoginConfig) {
+	const check = "Concierge"
+
+	if !login.conciergeEnabled {
+		report.skip(check, "not used by this kubeconfig")
+		return
+	}
+
+	clientConfig := newClientConfig(flags.kubeconfigPath, flags.kubeconfigContextOverride)
+	clientset, err := deps.getClientset(clientConfig, login.conciergeAPIGroupSuffix)
+	if err != nil {
+		report.fail(check, fmt.Sprintf("could not configure Kubernetes client: %v", err),
+			"regenerate the kubeconfig with `pinniped get kubeconfig`")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, flags.timeout)
+	defer cancel()
+
+	credentialIssuers, err := clientset.ConfigV1alpha1().CredentialIssuers().List(ctx, metav1.ListOptions{})
+	switch {
+	case apierrors.IsForbidden(err):
+		report.skip(check, "not allowed to read CredentialIssuers, which is normal for users who are not cluster administrators")
+		return
+	case err != nil:
+		report.fail(check, fmt.Sprintf("could not read CredentialIssuers: %v", err),
+			"check that you can log in and that the Concierge is installed, or ask your administrator to check the Concierge")
+		return
+	case len(credentialIssuers.Items) == 0:
+		report.fail(check, "no CredentialIssuers were found",
+			"ask your administrator to check that the Concierge is installed")
+		return
+	}
+
+	for _, credentialIssuer := range credentialIssuers.Items {
+		ready := false
+		strategies := make([]string, 0, len(credentialIssuer.Status.Strategies))
+		for _, strategy := range credentialIssuer.Status.Strategies {
+			strategies = append(strategies, fmt.Sprintf("%s is %s (%s)", strategy.Type, strategy.Status, strategy.Reason))
+			if strategy.Status == conciergeconfigv1alpha1.SuccessStrategyStatus {
+				ready = true
+			}
+		}
+		if len(strategies) == 0 {
+			strategies = append(strategies, "no strategies were reported yet")
+		}
+
+		detail := fmt.Sprintf("CredentialIssuer %q: %s", credentialIssuer.Name, strings.Join(strategies, ", "))
+		if !ready {
+			report.fail(check, detail,
+				fmt.Sprintf("ask your administrator to check the messages in `kubectl get credentialissuer %s -o yaml`", credentialIssuer.Name))
+			continue
+		}
+		report.pass(check, detail)
+	}
+}
+
+// statusCheckSessions reports when the cached sessions of the OIDC issuer expire. It never prints any tokens.
+func statusCheckSessions(report *doctorReport, deps statusDeps, login *pinnipedLoginConfig) {
+	const check = "Session"
+
+	switch login.sessionCachePath {
+	case "":
+		report.skip(check, "the session cache is disabled, so every login starts a new session")
+		return
+	case cacheInMemory:
+		report.skip(check, "the session cache is kept in memory only, so every login starts a new session")
+		return
+	}
+
+	encryption, err := cacheEncryption(deps.lookupEnv)
+	if err != nil {
+		report.fail(check, err.Error(), fmt.Sprintf("fix or unset %s", cacheEncryptionKeyFileEnvVarName))
+		return
+	}
+
+	sessions, err := filesession.ReadSessionSummaries(login.sessionCachePath, filesession.WithEncryption(encryption))
+	if err != nil {
+		report.fail(check, err.Error(),
+			fmt.Sprintf("delete %s so that it is recreated during the next login", login.sessionCachePath))
+		return
+	}
+
+	now := deps.now()
+	found := false
+	for _, session := range sessions {
+		if session.Key.Issuer != login.issuer {
+			continue
+		}
+		found = true
+
+		name := fmt.Sprintf("client %s", session.Key.ClientID)
+		if session.Key.UpstreamProviderName != "" {
+			name += fmt.Sprintf(" with identity provider %s", session.Key.UpstreamProviderName)
+		}
+
+		var details []string
+		idTokenValid := session.IDTokenExpiry.After(now)
+		if idTokenValid {
+			details = append(details, fmt.Sprintf("ID token expires in %s", session.IDTokenExpiry.Sub(now).Round(time.Second)))
+		} else {
+			details = append(details, "ID token expired")
+		}
+		if session.HasRefreshToken {
+			details = append(details, "can be refreshed without logging in again")
+		}
+		detail := fmt.Sprintf("%s: %s", name, strings.Join(details, ", "))
+
+		if !idTokenValid && !session.HasRefreshToken {
+			report.warn(check, detail, "the next kubectl command will start a new login")
+			continue
+		}
+		report.pass(check, detail)
+	}
+
+	if !found {
+		report.pass(check, fmt.Sprintf("no cached session for %s, so the next kubectl command will start a new login", login.issuer))
+	}
+}
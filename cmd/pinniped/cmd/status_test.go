@@ -0,0 +1,292 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubetesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/clientcmd"
+
+	conciergeconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/config/v1alpha1"
+	conciergeclientset "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned"
+	conciergefake "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned/fake"
+	"go.pinniped.dev/internal/here"
+	"go.pinniped.dev/internal/testutil/tlsserver"
+	"go.pinniped.dev/pkg/oidcclient"
+	"go.pinniped.dev/pkg/oidcclient/filesession"
+	"go.pinniped.dev/pkg/oidcclient/oidctypes"
+)
+
+func TestStatus(t *testing.T) {
+	issuerServer, issuerCA := tlsserver.TestServerIPv4(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/some/path/.well-known/openid-configuration", r.URL.Path)
+		w.Header().Set("content-type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"issuer": "https://%s/some/path"}`, r.Host)
+	}), nil)
+	issuer := issuerServer.URL + "/some/path"
+
+	now := time.Now().Round(time.Second)
+
+	writeKubeconfig := func(t *testing.T, execArgs string) string {
+		path := filepath.Join(t.TempDir(), "kubeconfig.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(here.Docf(`
+			apiVersion: v1
+			kind: Config
+			clusters:
+			- name: some-cluster
+			  cluster:
+			    server: https://cluster.example.com
+			contexts:
+			- name: some-context
+			  context:
+			    cluster: some-cluster
+			    user: some-user
+			current-context: some-context
+			users:
+			- name: some-user
+			  user:
+			    exec:
+			      apiVersion: client.authentication.k8s.io/v1beta1
+			      command: pinniped
+			      args: %s
+			`, execArgs)), 0600))
+		return path
+	}
+
+	oidcArgs := func(t *testing.T, sessionCachePath string, enableConcierge bool) string {
+		return fmt.Sprintf(`["login", "oidc", "--issuer=%s", "--ca-bundle-data=%s", "--session-cache=%s", "--enable-concierge=%t"]`,
+			issuer, base64.StdEncoding.EncodeToString(issuerCA), sessionCachePath, enableConcierge)
+	}
+
+	type cachedToken struct {
+		key   oidcclient.SessionCacheKey
+		token *oidctypes.Token
+	}
+
+	writeSessionCache := func(t *testing.T, tokens ...cachedToken) string {
+		path := filepath.Join(t.TempDir(), "sessions.yaml")
+		cache := filesession.New(path)
+		for _, cached := range tokens {
+			cache.PutToken(cached.key, cached.token)
+		}
+		return path
+	}
+
+	credentialIssuer := func(strategies ...conciergeconfigv1alpha1.CredentialIssuerStrategy) *conciergeconfigv1alpha1.CredentialIssuer {
+		return &conciergeconfigv1alpha1.CredentialIssuer{
+			ObjectMeta: metav1.ObjectMeta{Name: "pinniped-concierge-config"},
+			Status:     conciergeconfigv1alpha1.CredentialIssuerStatus{Strategies: strategies},
+		}
+	}
+
+	foundPinniped := func(string) (string, error) { return "/usr/local/bin/pinniped", nil }
+
+	tests := []struct {
+		name              string
+		args              func(t *testing.T) []string
+		credentialIssuers []runtime.Object
+		listErr           error
+		wantError         string
+		wantStdout        string
+	}{
+		{
+			name: "healthy login with a cached session",
+			args: func(t *testing.T) []string {
+				sessionCachePath := writeSessionCache(t,
+					cachedToken{
+						key: oidcclient.SessionCacheKey{Issuer: issuer, ClientID: "pinniped-cli", UpstreamProviderName: "some-idp"},
+						token: &oidctypes.Token{
+							IDToken:      &oidctypes.IDToken{Token: "some-secret-token", Expiry: metav1.NewTime(now.Add(time.Hour))},
+							RefreshToken: &oidctypes.RefreshToken{Token: "some-secret-token"},
+						},
+					},
+					cachedToken{
+						key: oidcclient.SessionCacheKey{Issuer: "https://other-issuer.example.com", ClientID: "pinniped-cli"},
+						token: &oidctypes.Token{
+							IDToken: &oidctypes.IDToken{Token: "some-secret-token", Expiry: metav1.NewTime(now.Add(time.Hour))},
+						},
+					},
+				)
+				return []string{"--kubeconfig", writeKubeconfig(t, oidcArgs(t, sessionCachePath, true))}
+			},
+			credentialIssuers: []runtime.Object{credentialIssuer(
+				conciergeconfigv1alpha1.CredentialIssuerStrategy{
+					Type:   conciergeconfigv1alpha1.KubeClusterSigningCertificateStrategyType,
+					Status: conciergeconfigv1alpha1.ErrorStrategyStatus,
+					Reason: conciergeconfigv1alpha1.CouldNotFetchKeyStrategyReason,
+				},
+				conciergeconfigv1alpha1.CredentialIssuerStrategy{
+					Type:   conciergeconfigv1alpha1.ImpersonationProxyStrategyType,
+					Status: conciergeconfigv1alpha1.SuccessStrategyStatus,
+					Reason: conciergeconfigv1alpha1.ListeningStrategyReason,
+				},
+			)},
+			wantStdout: here.Docf(`
+				[PASS] Kubeconfig: using context "some-context" with server https://cluster.example.com
+				[PASS] Exec plugin: runs `+"`pinniped login oidc`"+` using /usr/local/bin/pinniped
+				[PASS] Concierge: CredentialIssuer "pinniped-concierge-config": KubeClusterSigningCertificate is Error (CouldNotFetchKey), ImpersonationProxy is Success (Listening)
+				[PASS] OIDC issuer: discovered %s
+				[PASS] Session: client pinniped-cli with identity provider some-idp: ID token expires in 1h0m0s, can be refreshed without logging in again
+				No problems found.
+			`, issuer),
+		},
+		{
+			name: "Concierge is not used and there is no cached session",
+			args: func(t *testing.T) []string {
+				sessionCachePath := filepath.Join(t.TempDir(), "sessions.yaml")
+				return []string{"--kubeconfig", writeKubeconfig(t, oidcArgs(t, sessionCachePath, false))}
+			},
+			wantStdout: here.Docf(`
+				[PASS] Kubeconfig: using context "some-context" with server https://cluster.example.com
+				[PASS] Exec plugin: runs `+"`pinniped login oidc`"+` using /usr/local/bin/pinniped
+				[SKIP] Concierge: not used by this kubeconfig
+				[PASS] OIDC issuer: discovered %s
+				[PASS] Session: no cached session for %s, so the next kubectl command will start a new login
+				No problems found.
+			`, issuer, issuer),
+		},
+		{
+			name: "not allowed to read CredentialIssuers and an expired session",
+			args: func(t *testing.T) []string {
+				sessionCachePath := writeSessionCache(t, cachedToken{
+					key: oidcclient.SessionCacheKey{Issuer: issuer, ClientID: "pinniped-cli"},
+					token: &oidctypes.Token{
+						AccessToken: &oidctypes.AccessToken{Token: "some-secret-token", Expiry: metav1.NewTime(now.Add(time.Hour))},
+					},
+				})
+				return []string{"--kubeconfig", writeKubeconfig(t, oidcArgs(t, sessionCachePath, true))}
+			},
+			listErr: apierrors.NewForbidden(conciergeconfigv1alpha1.SchemeGroupVersion.WithResource("credentialissuers").GroupResource(), "", fmt.Errorf("some error")),
+			wantStdout: here.Docf(`
+				[PASS] Kubeconfig: using context "some-context" with server https://cluster.example.com
+				[PASS] Exec plugin: runs `+"`pinniped login oidc`"+` using /usr/local/bin/pinniped
+				[SKIP] Concierge: not allowed to read CredentialIssuers, which is normal for users who are not cluster administrators
+				[PASS] OIDC issuer: discovered %s
+				[WARN] Session: client pinniped-cli: ID token expired
+				       To fix: the next kubectl command will start a new login
+				No problems found.
+			`, issuer),
+		},
+		{
+			name: "no strategy is ready",
+			args: func(t *testing.T) []string {
+				return []string{"--kubeconfig", writeKubeconfig(t, oidcArgs(t, "", true))}
+			},
+			credentialIssuers: []runtime.Object{credentialIssuer(
+				conciergeconfigv1alpha1.CredentialIssuerStrategy{
+					Type:   conciergeconfigv1alpha1.KubeClusterSigningCertificateStrategyType,
+					Status: conciergeconfigv1alpha1.ErrorStrategyStatus,
+					Reason: conciergeconfigv1alpha1.CouldNotFetchKeyStrategyReason,
+				},
+			)},
+			wantError: "found 1 problem(s)",
+			wantStdout: here.Docf(`
+				[PASS] Kubeconfig: using context "some-context" with server https://cluster.example.com
+				[PASS] Exec plugin: runs `+"`pinniped login oidc`"+` using /usr/local/bin/pinniped
+				[FAIL] Concierge: CredentialIssuer "pinniped-concierge-config": KubeClusterSigningCertificate is Error (CouldNotFetchKey)
+				       To fix: ask your administrator to check the messages in `+"`kubectl get credentialissuer pinniped-concierge-config -o yaml`"+`
+				[PASS] OIDC issuer: discovered %s
+				[SKIP] Session: the session cache is disabled, so every login starts a new session
+				Found 1 problem(s).
+			`, issuer),
+		},
+		{
+			name: "Concierge is not installed",
+			args: func(t *testing.T) []string {
+				return []string{"--kubeconfig", writeKubeconfig(t, `["login", "static", "--token=some-secret-token", "--enable-concierge"]`)}
+			},
+			wantError: "found 1 problem(s)",
+			wantStdout: here.Doc(`
+				[PASS] Kubeconfig: using context "some-context" with server https://cluster.example.com
+				[PASS] Exec plugin: runs ` + "`pinniped login static`" + ` using /usr/local/bin/pinniped
+				[FAIL] Concierge: no CredentialIssuers were found
+				       To fix: ask your administrator to check that the Concierge is installed
+				Found 1 problem(s).
+			`),
+		},
+		{
+			name: "Concierge is not installed as JSON",
+			args: func(t *testing.T) []string {
+				return []string{"--kubeconfig", writeKubeconfig(t, `["login", "static", "--token=some-secret-token", "--enable-concierge"]`), "--output", "json"}
+			},
+			wantError: "found 1 problem(s)",
+			wantStdout: here.Doc(`
+				{
+				  "apiVersion": "cli.pinniped.dev/v1alpha1",
+				  "kind": "StatusReport",
+				  "checks": [
+				    {
+				      "name": "Kubeconfig",
+				      "status": "PASS",
+				      "detail": "using context \"some-context\" with server https://cluster.example.com"
+				    },
+				    {
+				      "name": "Exec plugin",
+				      "status": "PASS",
+				      "detail": "runs ` + "`pinniped login static`" + ` using /usr/local/bin/pinniped"
+				    },
+				    {
+				      "name": "Concierge",
+				      "status": "FAIL",
+				      "detail": "no CredentialIssuers were found",
+				      "remediation": "ask your administrator to check that the Concierge is installed"
+				    }
+				  ],
+				  "problems": 1
+				}
+			`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getClientset := func(clientConfig clientcmd.ClientConfig, apiGroupSuffix string) (conciergeclientset.Interface, error) {
+				require.Equal(t, "pinniped.dev", apiGroupSuffix)
+				clientset := conciergefake.NewSimpleClientset(tt.credentialIssuers...)
+				if tt.listErr != nil {
+					clientset.PrependReactor("list", "credentialissuers", func(_ kubetesting.Action) (bool, runtime.Object, error) {
+						return true, nil, tt.listErr
+					})
+				}
+				return clientset, nil
+			}
+
+			cmd := statusCommand(statusDeps{
+				doctorDeps: doctorDeps{
+					lookupEnv: func(string) (string, bool) { return "", false },
+					lookPath:  foundPinniped,
+					now:       func() time.Time { return now },
+					goos:      "linux",
+				},
+				getClientset: getClientset,
+			})
+
+			var stdout, stderr bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			cmd.SetArgs(tt.args(t))
+			err := cmd.Execute()
+			if tt.wantError != "" {
+				require.EqualError(t, err, tt.wantError)
+				require.Equal(t, "Error: "+tt.wantError+"\n", stderr.String())
+			} else {
+				require.NoError(t, err)
+				require.Empty(t, stderr.String())
+			}
+			require.Equal(t, tt.wantStdout, stdout.String())
+			require.NotContains(t, stdout.String(), "some-secret-token")
+		})
+	}
+}
@@ -61,6 +61,9 @@ func newWhoamiCommand(getClientset getConciergeClientsetFunc) *cobra.Command {
 	f.StringVar(&flags.apiGroupSuffix, "api-group-suffix", groupsuffix.PinnipedDefaultSuffix, "Concierge API group suffix")
 	f.DurationVar(&flags.timeout, "timeout", 0, "Timeout for the WhoAmI API request (default: 0, meaning no timeout)")
 
+	mustRegisterFlagCompletionFunc(cmd, "kubeconfig-context", completeKubeconfigContexts)
+	mustRegisterFlagCompletionFunc(cmd, "output", completeOutputFormats)
+
 	cmd.RunE = func(cmd *cobra.Command, _ []string) error {
 		return runWhoami(cmd.OutOrStdout(), getClientset, flags)
 	}
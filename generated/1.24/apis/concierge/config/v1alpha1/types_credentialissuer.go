@@ -1,4 +1,4 @@
-// Copyright 2020-2023 the Pinniped contributors. All Rights Reserved.
+// Copyright 2020-2024 the Pinniped contributors. All Rights Reserved.
 // SPDX-License-Identifier: Apache-2.0
 
 package v1alpha1
@@ -8,7 +8,7 @@ import (
 )
 
 // StrategyType enumerates a type of "strategy" used to implement credential access on a cluster.
-// +kubebuilder:validation:Enum=KubeClusterSigningCertificate;ImpersonationProxy
+// +kubebuilder:validation:Enum=KubeClusterSigningCertificate;ImpersonationProxy;CertificateSigner
 type StrategyType string
 
 // FrontendType enumerates a type of "frontend" used to provide access to users of a cluster.
@@ -20,12 +20,13 @@ type FrontendType string
 type StrategyStatus string
 
 // StrategyReason enumerates the detailed reason why a strategy is in a particular status.
-// +kubebuilder:validation:Enum=Listening;Pending;Disabled;ErrorDuringSetup;CouldNotFetchKey;CouldNotGetClusterInfo;FetchedKey
+// +kubebuilder:validation:Enum=Listening;Pending;Disabled;ErrorDuringSetup;CouldNotFetchKey;CouldNotGetClusterInfo;FetchedKey;SignerConfigured
 type StrategyReason string
 
 const (
 	KubeClusterSigningCertificateStrategyType = StrategyType("KubeClusterSigningCertificate")
 	ImpersonationProxyStrategyType            = StrategyType("ImpersonationProxy")
+	CertificateSignerStrategyType             = StrategyType("CertificateSigner")
 
 	TokenCredentialRequestAPIFrontendType = FrontendType("TokenCredentialRequestAPI")
 	ImpersonationProxyFrontendType        = FrontendType("ImpersonationProxy")
@@ -40,12 +41,137 @@ const (
 	CouldNotFetchKeyStrategyReason       = StrategyReason("CouldNotFetchKey")
 	CouldNotGetClusterInfoStrategyReason = StrategyReason("CouldNotGetClusterInfo")
 	FetchedKeyStrategyReason             = StrategyReason("FetchedKey")
+	SignerConfiguredStrategyReason       = StrategyReason("SignerConfigured")
 )
 
 // CredentialIssuerSpec describes the intended configuration of the Concierge.
 type CredentialIssuerSpec struct {
 	// ImpersonationProxy describes the intended configuration of the Concierge impersonation proxy.
 	ImpersonationProxy *ImpersonationProxySpec `json:"impersonationProxy"`
+
+	// TokenCredentialRequestAPI describes the intended configuration of the TokenCredentialRequest API.
+	//
+	// +optional
+	TokenCredentialRequestAPI *TokenCredentialRequestAPISpec `json:"tokenCredentialRequestAPI,omitempty"`
+}
+
+// TokenCredentialRequestAPICredentialType enumerates the types of credentials that can be returned by the
+// TokenCredentialRequest API.
+//
+// +kubebuilder:validation:Enum=ClientCertificate;ServiceAccountToken
+type TokenCredentialRequestAPICredentialType string
+
+const (
+	// TokenCredentialRequestAPICredentialTypeClientCertificate returns short-lived x509 client certificates.
+	TokenCredentialRequestAPICredentialTypeClientCertificate = TokenCredentialRequestAPICredentialType("ClientCertificate")
+
+	// TokenCredentialRequestAPICredentialTypeServiceAccountToken returns short-lived bound ServiceAccount tokens.
+	TokenCredentialRequestAPICredentialTypeServiceAccountToken = TokenCredentialRequestAPICredentialType("ServiceAccountToken")
+)
+
+// TokenCredentialRequestAPISpec describes the intended configuration of the TokenCredentialRequest API.
+type TokenCredentialRequestAPISpec struct {
+	// CredentialType configures which type of credential is returned by the TokenCredentialRequest API:
+	// - "ClientCertificate" returns a short-lived x509 client certificate for the user. This is the default.
+	// - "ServiceAccountToken" returns a short-lived token for a ServiceAccount in the namespace of the Concierge,
+	//   which the Concierge creates for each user. This can be used on clusters where the Kubernetes API server
+	//   does not accept client certificates. The Kubernetes API server will authenticate the user as the
+	//   ServiceAccount, so RBAC policies must grant permissions to those ServiceAccounts. The username and groups of
+	//   the user are recorded in annotations of the ServiceAccount.
+	//
+	// +kubebuilder:default:=ClientCertificate
+	// +optional
+	CredentialType TokenCredentialRequestAPICredentialType `json:"credentialType,omitempty"`
+
+	// Signer configures how the client certificates which are returned by the TokenCredentialRequest API are signed.
+	// When it is not set, they are signed using the cluster signing keypair which is loaded by the kube-cert-agent.
+	//
+	// +optional
+	Signer *TokenCredentialRequestAPISignerSpec `json:"signer,omitempty"`
+}
+
+// TokenCredentialRequestAPISignerType enumerates the ways to sign the client certificates which are returned by the
+// TokenCredentialRequest API.
+//
+// +kubebuilder:validation:Enum=KubeCertAgent;CertificateSigningRequest;External
+type TokenCredentialRequestAPISignerType string
+
+const (
+	// TokenCredentialRequestAPISignerTypeKubeCertAgent signs client certificates using the cluster signing keypair,
+	// which is loaded from the kube-controller-manager by the kube-cert-agent.
+	TokenCredentialRequestAPISignerTypeKubeCertAgent = TokenCredentialRequestAPISignerType("KubeCertAgent")
+
+	// TokenCredentialRequestAPISignerTypeCertificateSigningRequest signs client certificates using the Kubernetes
+	// CertificateSigningRequest API.
+	TokenCredentialRequestAPISignerTypeCertificateSigningRequest = TokenCredentialRequestAPISignerType("CertificateSigningRequest")
+
+	// TokenCredentialRequestAPISignerTypeExternal signs client certificates using an external signing service.
+	TokenCredentialRequestAPISignerTypeExternal = TokenCredentialRequestAPISignerType("External")
+)
+
+// TokenCredentialRequestAPISignerSpec describes how the client certificates which are returned by the
+// TokenCredentialRequest API are signed.
+//
+// +kubebuilder:validation:XValidation:message="certificateSigningRequest is required when type is CertificateSigningRequest",rule="self.type != 'CertificateSigningRequest' || has(self.certificateSigningRequest)"
+// +kubebuilder:validation:XValidation:message="external is required when type is External",rule="self.type != 'External' || has(self.external)"
+type TokenCredentialRequestAPISignerSpec struct {
+	// Type configures which signer is used:
+	// - "KubeCertAgent" signs client certificates using the cluster signing keypair, which is loaded from the
+	//   kube-controller-manager by the kube-cert-agent. This is the default.
+	// - "CertificateSigningRequest" signs client certificates using the Kubernetes CertificateSigningRequest API.
+	//   The Concierge creates and approves a CertificateSigningRequest for each client certificate, so it works on
+	//   clusters where the kube-controller-manager and its keypair are not accessible, e.g. most managed clusters.
+	// - "External" signs client certificates using an external signing service, e.g. one which is backed by an HSM.
+	// The kube-cert-agent is not deployed unless the type is "KubeCertAgent".
+	//
+	// +kubebuilder:default:=KubeCertAgent
+	Type TokenCredentialRequestAPISignerType `json:"type"`
+
+	// CertificateSigningRequest configures the "CertificateSigningRequest" signer.
+	//
+	// +optional
+	CertificateSigningRequest *CertificateSigningRequestSignerSpec `json:"certificateSigningRequest,omitempty"`
+
+	// External configures the "External" signer.
+	//
+	// +optional
+	External *ExternalSignerSpec `json:"external,omitempty"`
+}
+
+// CertificateSigningRequestSignerSpec configures how client certificates are signed using the Kubernetes
+// CertificateSigningRequest API.
+type CertificateSigningRequestSignerSpec struct {
+	// SignerName is the spec.signerName of the CertificateSigningRequests. The signer must issue client certificates
+	// which are trusted by the Kubernetes API server, and the Concierge must be allowed to approve requests for it.
+	// The Concierge is only allowed to approve requests for the default signer unless more permissions are granted.
+	// Note that the default signer issues certificates which are valid for at least ten minutes.
+	//
+	// +kubebuilder:default:="kubernetes.io/kube-apiserver-client"
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	SignerName string `json:"signerName,omitempty"`
+}
+
+// ExternalSignerSpec configures how client certificates are signed using an external signing service.
+//
+// The Concierge sends a POST request with a JSON body to the endpoint for each client certificate. The body has
+// a "csr" member, which is a PEM-encoded PKCS#10 certificate signing request, and an "expirationSeconds" member,
+// which is the requested lifetime of the certificate. The subject of the request contains the username as its
+// common name and the groups as its organizations. The service must respond with a JSON body which has a
+// "certificate" member, which is the PEM-encoded certificate (chain), and the certificate must be trusted by the
+// Kubernetes API server for client authentication.
+type ExternalSignerSpec struct {
+	// Endpoint is the HTTPS URL of the signing service.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern=`^https://`
+	Endpoint string `json:"endpoint"`
+
+	// X.509 Certificate Authority (base64-encoded PEM bundle) to trust when connecting to the endpoint.
+	// If omitted, a default set of system roots will be trusted.
+	//
+	// +optional
+	CertificateAuthorityData string `json:"certificateAuthorityData,omitempty"`
 }
 
 // ImpersonationProxyMode enumerates the configuration modes for the impersonation proxy.
@@ -123,6 +249,15 @@ type ImpersonationProxySpec struct {
 	// +optional
 	ExternalEndpoint string `json:"externalEndpoint,omitempty"`
 
+	// AdditionalExternalNames are extra DNS names or IP addresses by which clients may reach the proxy, e.g.
+	// custom domain names which point to the load balancer. When the impersonation proxy generates its own
+	// TLS certificate, each name is included as a subject alternative name, in addition to the name of the
+	// externalEndpoint or of the Service.
+	//
+	// +optional
+	// +listType=set
+	AdditionalExternalNames []string `json:"additionalExternalNames,omitempty"`
+
 	// TLS contains information about how the Concierge impersonation proxy should serve TLS.
 	//
 	// If this field is empty, the impersonation proxy will generate its own TLS certificate.
@@ -141,6 +276,15 @@ type ImpersonationProxyServiceSpec struct {
 	// +kubebuilder:default:="LoadBalancer"
 	Type ImpersonationProxyServiceType `json:"type,omitempty"`
 
+	// Name optionally specifies the name of an existing Service in the Concierge namespace which exposes the
+	// impersonation proxy. When set, the Concierge does not provision a Service, and instead uses the existing
+	// Service of the given type, which must be "LoadBalancer" or "ClusterIP", to find the name of the endpoint.
+	// The loadBalancerIP and annotations fields are ignored in this case.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	Name string `json:"name,omitempty"`
+
 	// LoadBalancerIP specifies the IP address to set in the spec.loadBalancerIP field of the provisioned Service.
 	// This is not supported on all cloud providers.
 	//
@@ -150,6 +294,8 @@ type ImpersonationProxyServiceSpec struct {
 	LoadBalancerIP string `json:"loadBalancerIP,omitempty"`
 
 	// Annotations specifies zero or more key/value pairs to set as annotations on the provisioned Service.
+	// For example, the "external-dns.alpha.kubernetes.io/hostname" annotation can be used to have external-dns
+	// create DNS records for the load balancer.
 	//
 	// +optional
 	Annotations map[string]string `json:"annotations,omitempty"`
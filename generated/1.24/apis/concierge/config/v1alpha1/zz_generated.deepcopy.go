@@ -12,6 +12,22 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateSigningRequestSignerSpec) DeepCopyInto(out *CertificateSigningRequestSignerSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateSigningRequestSignerSpec.
+func (in *CertificateSigningRequestSignerSpec) DeepCopy() *CertificateSigningRequestSignerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateSigningRequestSignerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CredentialIssuer) DeepCopyInto(out *CredentialIssuer) {
 	*out = *in
@@ -123,6 +139,11 @@ func (in *CredentialIssuerSpec) DeepCopyInto(out *CredentialIssuerSpec) {
 		*out = new(ImpersonationProxySpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.TokenCredentialRequestAPI != nil {
+		in, out := &in.TokenCredentialRequestAPI, &out.TokenCredentialRequestAPI
+		*out = new(TokenCredentialRequestAPISpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -186,6 +207,22 @@ func (in *CredentialIssuerStrategy) DeepCopy() *CredentialIssuerStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalSignerSpec) DeepCopyInto(out *ExternalSignerSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalSignerSpec.
+func (in *ExternalSignerSpec) DeepCopy() *ExternalSignerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalSignerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ImpersonationProxyInfo) DeepCopyInto(out *ImpersonationProxyInfo) {
 	*out = *in
@@ -229,6 +266,11 @@ func (in *ImpersonationProxyServiceSpec) DeepCopy() *ImpersonationProxyServiceSp
 func (in *ImpersonationProxySpec) DeepCopyInto(out *ImpersonationProxySpec) {
 	*out = *in
 	in.Service.DeepCopyInto(&out.Service)
+	if in.AdditionalExternalNames != nil {
+		in, out := &in.AdditionalExternalNames, &out.AdditionalExternalNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.TLS != nil {
 		in, out := &in.TLS, &out.TLS
 		*out = new(ImpersonationProxyTLSSpec)
@@ -278,3 +320,50 @@ func (in *TokenCredentialRequestAPIInfo) DeepCopy() *TokenCredentialRequestAPIIn
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TokenCredentialRequestAPISignerSpec) DeepCopyInto(out *TokenCredentialRequestAPISignerSpec) {
+	*out = *in
+	if in.CertificateSigningRequest != nil {
+		in, out := &in.CertificateSigningRequest, &out.CertificateSigningRequest
+		*out = new(CertificateSigningRequestSignerSpec)
+		**out = **in
+	}
+	if in.External != nil {
+		in, out := &in.External, &out.External
+		*out = new(ExternalSignerSpec)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TokenCredentialRequestAPISignerSpec.
+func (in *TokenCredentialRequestAPISignerSpec) DeepCopy() *TokenCredentialRequestAPISignerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TokenCredentialRequestAPISignerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TokenCredentialRequestAPISpec) DeepCopyInto(out *TokenCredentialRequestAPISpec) {
+	*out = *in
+	if in.Signer != nil {
+		in, out := &in.Signer, &out.Signer
+		*out = new(TokenCredentialRequestAPISignerSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TokenCredentialRequestAPISpec.
+func (in *TokenCredentialRequestAPISpec) DeepCopy() *TokenCredentialRequestAPISpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TokenCredentialRequestAPISpec)
+	in.DeepCopyInto(out)
+	return out
+}
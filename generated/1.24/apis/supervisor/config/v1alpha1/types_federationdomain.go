@@ -1,4 +1,4 @@
-// Copyright 2020-2023 the Pinniped contributors. All Rights Reserved.
+// Copyright 2020-2024 the Pinniped contributors. All Rights Reserved.
 // SPDX-License-Identifier: Apache-2.0
 
 package v1alpha1
@@ -21,12 +21,38 @@ const (
 	FederationDomainPhaseError FederationDomainPhase = "Error"
 )
 
+// FederationDomainReadinessPolicy determines how problems with individual identity providers affect a FederationDomain.
+// +kubebuilder:validation:Enum=AllIDPsReady;AnyIDPReady
+type FederationDomainReadinessPolicy string
+
+const (
+	// FederationDomainReadinessPolicyAllIDPsReady means that the FederationDomain is only ready, and its endpoints
+	// are only available, when every one of its identity providers is ready.
+	FederationDomainReadinessPolicyAllIDPsReady FederationDomainReadinessPolicy = "AllIDPsReady"
+
+	// FederationDomainReadinessPolicyAnyIDPReady means that the FederationDomain is ready, and its endpoints are
+	// available, when at least one of its identity providers is ready. The identity providers which are not ready
+	// cannot be used to log in.
+	FederationDomainReadinessPolicyAnyIDPReady FederationDomainReadinessPolicy = "AnyIDPReady"
+)
+
+type FederationDomainIdentityProviderPhase string
+
+const (
+	// FederationDomainIdentityProviderPhaseReady is the phase for an identity provider which can be used to log in.
+	FederationDomainIdentityProviderPhaseReady FederationDomainIdentityProviderPhase = "Ready"
+
+	// FederationDomainIdentityProviderPhaseError is the phase for an identity provider which cannot be used to log in.
+	FederationDomainIdentityProviderPhaseError FederationDomainIdentityProviderPhase = "Error"
+)
+
 // FederationDomainTLSSpec is a struct that describes the TLS configuration for an OIDC Provider.
 type FederationDomainTLSSpec struct {
 	// SecretName is an optional name of a Secret in the same namespace, of type `kubernetes.io/tls`, which contains
 	// the TLS serving certificate for the HTTPS endpoints served by this FederationDomain. When provided, the TLS Secret
 	// named here must contain keys named `tls.crt` and `tls.key` that contain the certificate and private key to use
-	// for TLS.
+	// for TLS. Secrets of other types are never read. Whether the Secret could be loaded is reported by the TLSSecretValid
+	// condition of this FederationDomain.
 	//
 	// Server Name Indication (SNI) is an extension to the Transport Layer Security (TLS) supported by all major browsers.
 	//
@@ -41,8 +67,159 @@ type FederationDomainTLSSpec struct {
 	//
 	// When your Issuer URL's host is an IP address, then this field is ignored. SNI does not work for IP addresses.
 	//
+	// SecretName is required when CertManager or ACME is specified.
+	//
 	// +optional
 	SecretName string `json:"secretName,omitempty"`
+
+	// CertManager optionally configures the Supervisor to obtain the TLS serving certificate of this FederationDomain
+	// from cert-manager (https://cert-manager.io), instead of requiring the Secret named by SecretName to be managed
+	// by hand. cert-manager must be installed in the cluster. cert-manager writes the certificate to the Secret named
+	// by SecretName and renews it before it expires. The readiness of the certificate is reported by the
+	// TLSCertificateReady condition of the FederationDomain.
+	// +optional
+	CertManager *FederationDomainCertManagerSpec `json:"certManager,omitempty"`
+
+	// ACME optionally configures the Supervisor to obtain the TLS serving certificate of this FederationDomain
+	// directly from a certificate authority which implements the ACME protocol (RFC 8555), such as Let's Encrypt,
+	// for environments without cert-manager. The Supervisor writes the certificate to the Secret named by SecretName
+	// and renews it before it expires. Its ACME account key and its state are kept in a Secret named like the
+	// FederationDomain with the suffix "-acme". The readiness of the certificate is reported by the
+	// TLSCertificateReady condition of the FederationDomain. ACME cannot be used together with CertManager.
+	// +optional
+	ACME *FederationDomainACMESpec `json:"acme,omitempty"`
+}
+
+// FederationDomainACMEChallengeType is the type of ACME challenge used to prove control of the issuer hostname.
+// +kubebuilder:validation:Enum=HTTP01;DNS01
+type FederationDomainACMEChallengeType string
+
+const (
+	// FederationDomainACMEChallengeTypeHTTP01 uses the ACME HTTP-01 challenge. The certificate authority makes an
+	// HTTP request to port 80 of the issuer hostname, which must be routed to the ACME HTTP-01 listener of the
+	// Supervisor pods. That listener is disabled by default, and is enabled using the endpoints.acmeHTTP01 setting
+	// of the static configuration of the Supervisor.
+	FederationDomainACMEChallengeTypeHTTP01 FederationDomainACMEChallengeType = "HTTP01"
+
+	// FederationDomainACMEChallengeTypeDNS01 uses the ACME DNS-01 challenge. The TXT record which is requested by
+	// the certificate authority is published by the DNS provider configured in spec.tls.acme.dns01.
+	FederationDomainACMEChallengeTypeDNS01 FederationDomainACMEChallengeType = "DNS01"
+)
+
+// FederationDomainACMESpec describes how to obtain the TLS serving certificate of a FederationDomain from an
+// ACME certificate authority.
+// +kubebuilder:validation:XValidation:message="dns01 must be specified if and only if challengeType is DNS01",rule="(self.challengeType == 'DNS01') == has(self.dns01)"
+type FederationDomainACMESpec struct {
+	// DirectoryURL is the URL of the ACME directory of the certificate authority.
+	// Defaults to the production directory of Let's Encrypt.
+	// +kubebuilder:default="https://acme-v02.api.letsencrypt.org/directory"
+	// +kubebuilder:validation:Pattern=`^https://`
+	// +optional
+	DirectoryURL string `json:"directoryURL,omitempty"`
+
+	// Email is an optional contact email address for the ACME account, which the certificate authority may use
+	// to send notices, e.g. about certificates which are about to expire.
+	// +optional
+	Email string `json:"email,omitempty"`
+
+	// AcceptTermsOfService must be true to indicate that the terms of service of the certificate authority
+	// have been accepted, which is required to create an ACME account.
+	// +kubebuilder:validation:XValidation:message="the terms of service of the certificate authority must be accepted",rule="self == true"
+	AcceptTermsOfService bool `json:"acceptTermsOfService"`
+
+	// ChallengeType is the type of ACME challenge used to prove control of the issuer hostname.
+	// Defaults to HTTP01.
+	// +kubebuilder:default=HTTP01
+	// +optional
+	ChallengeType FederationDomainACMEChallengeType `json:"challengeType,omitempty"`
+
+	// DNS01 configures the DNS provider which publishes the TXT records of DNS-01 challenges.
+	// It is required when ChallengeType is DNS01.
+	// +optional
+	DNS01 *FederationDomainACMEDNS01Spec `json:"dns01,omitempty"`
+}
+
+// FederationDomainACMEDNS01Spec configures the DNS provider used for ACME DNS-01 challenges.
+// Exactly one provider must be specified.
+// +kubebuilder:validation:XValidation:message="exactly one DNS provider must be specified",rule="has(self.webhook)"
+type FederationDomainACMEDNS01Spec struct {
+	// Webhook delegates the publication of TXT records to a webhook, which can integrate with any DNS provider.
+	// +optional
+	Webhook *FederationDomainACMEDNS01Webhook `json:"webhook,omitempty"`
+}
+
+// FederationDomainACMEDNS01Webhook configures a webhook which publishes the TXT records of DNS-01 challenges.
+type FederationDomainACMEDNS01Webhook struct {
+	// Endpoint is the HTTPS URL of the webhook. The Supervisor sends an HTTP POST request with a JSON body
+	// describing the TXT record which should be published or removed. The webhook should only respond after
+	// the record has been published by the authoritative DNS servers of the zone.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern=`^https://`
+	Endpoint string `json:"endpoint"`
+
+	// CertificateAuthorityData is an optional base64 encoded PEM bundle of CA certificates to trust when
+	// calling the webhook. When not specified, the system trust store is used.
+	// +optional
+	CertificateAuthorityData string `json:"certificateAuthorityData,omitempty"`
+}
+
+// FederationDomainCertManagerSpec describes how to obtain the TLS serving certificate of a FederationDomain
+// from cert-manager.
+// +kubebuilder:validation:XValidation:message="exactly one of issuerRef or certificateName must be specified",rule="has(self.issuerRef) != has(self.certificateName)"
+type FederationDomainCertManagerSpec struct {
+	// IssuerRef refers to the cert-manager Issuer or ClusterIssuer which should issue the certificate.
+	// The Supervisor creates a cert-manager Certificate with the same name and namespace as the FederationDomain,
+	// for the hostname or IP address of the issuer URL, and keeps it up to date with this FederationDomain.
+	// The Certificate is deleted along with the FederationDomain.
+	// +optional
+	IssuerRef *FederationDomainCertManagerIssuerRef `json:"issuerRef,omitempty"`
+
+	// CertificateName is the name of an existing cert-manager Certificate in the same namespace which is managed
+	// outside the Supervisor. Its spec.secretName must be the same as the SecretName of this FederationDomain.
+	// The Supervisor only reports the readiness of the Certificate.
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	CertificateName string `json:"certificateName,omitempty"`
+}
+
+// FederationDomainCertManagerIssuerRef refers to a cert-manager Issuer or ClusterIssuer.
+type FederationDomainCertManagerIssuerRef struct {
+	// Name is the name of the Issuer or ClusterIssuer.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Kind is the kind of the issuer, i.e. Issuer for an Issuer in the same namespace, or ClusterIssuer.
+	// Defaults to Issuer.
+	// +kubebuilder:default=Issuer
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Group is the API group of the issuer. Defaults to cert-manager.io. Other groups may be used for
+	// external issuers of cert-manager.
+	// +kubebuilder:default=cert-manager.io
+	// +optional
+	Group string `json:"group,omitempty"`
+}
+
+// FederationDomainBrandingSpec describes the branding of the web pages served by a FederationDomain.
+type FederationDomainBrandingSpec struct {
+	// ConfigMapName is the name of a ConfigMap in the same namespace which contains the branding of the login page
+	// and of the login success and error pages shown by the browser during a login. All keys are optional:
+	//
+	// - `logo` (in binaryData): a PNG, JPEG, GIF, or WebP image which is shown at the top of the pages.
+	//
+	// - `primaryColor` and `backgroundColor`: colors in hexadecimal notation, e.g. `#1a73e8`.
+	//
+	// - `customCSS`: a stylesheet which is applied to the pages after all other styles.
+	//
+	// - `footerText`: text which is shown at the bottom of the pages.
+	//
+	// - `incorrectUsernameOrPasswordMessage`, `internalErrorMessage`, and `loginFailedMessage`: text which
+	//   replaces the default error messages shown on the pages.
+	//
+	// When the ConfigMap cannot be found or is invalid, the default branding is used and an error is logged.
+	// +kubebuilder:validation:MinLength=1
+	ConfigMapName string `json:"configMapName"`
 }
 
 // FederationDomainTransformsConstant defines a constant variable and its value which will be made available to
@@ -184,6 +361,27 @@ type FederationDomainIdentityProvider struct {
 	// +kubebuilder:validation:MinLength=1
 	DisplayName string `json:"displayName"`
 
+	// Description is an optional sentence describing this identity provider to end users, e.g. "Log in with your
+	// corporate account". It is shown on the Supervisor's identity provider chooser page and is included in the
+	// FederationDomain's identity provider discovery document.
+	// +kubebuilder:validation:MaxLength=256
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// IconURL is an optional URL of an image to be shown next to this identity provider on the Supervisor's
+	// identity provider chooser page. It is also included in the FederationDomain's identity provider discovery
+	// document. The image is loaded by the end user's browser, so it must be reachable by end users.
+	// +kubebuilder:validation:XValidation:message="iconURL must have \"https\" scheme",rule="self.startsWith('https://')"
+	// +optional
+	IconURL string `json:"iconURL,omitempty"`
+
+	// Order optionally controls the position of this identity provider on the Supervisor's identity provider
+	// chooser page and in the FederationDomain's identity provider discovery document. Identity providers are listed
+	// in ascending order, and identity providers with the same order are listed alphabetically by displayName.
+	// When not specified, the order is 0.
+	// +optional
+	Order int32 `json:"order,omitempty"`
+
 	// ObjectRef is a reference to a Pinniped identity provider resource. A valid reference is required.
 	// If the reference cannot be resolved then the identity provider will not be made available.
 	// Must refer to a resource of one of the Pinniped identity provider types, e.g. OIDCIdentityProvider,
@@ -197,6 +395,7 @@ type FederationDomainIdentityProvider struct {
 }
 
 // FederationDomainSpec is a struct that describes an OIDC Provider.
+// +kubebuilder:validation:XValidation:message="previousIssuer.issuer must be different from issuer",rule="!has(self.previousIssuer) || self.previousIssuer.issuer != self.issuer"
 type FederationDomainSpec struct {
 	// Issuer is the OIDC Provider's issuer, per the OIDC Discovery Metadata document, as well as the
 	// identifier that it will use for the iss claim in issued JWTs. This field will also be used as
@@ -206,10 +405,34 @@ type FederationDomainSpec struct {
 	//
 	// See
 	// https://openid.net/specs/openid-connect-discovery-1_0.html#rfc.section.3 for more information.
+	//
+	// The shape of the issuer URL is validated by the API server when the FederationDomain is written, but conflicts
+	// with the issuers of other FederationDomains cannot be, since validation rules can only see the FederationDomain
+	// which is being written. Those conflicts, e.g. two FederationDomains with the same issuer, or issuers with the same
+	// hostname but different TLS Secrets, are reported by the IssuerIsUnique and OneTLSSecretPerIssuerHostname status
+	// conditions of the FederationDomains instead.
 	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:XValidation:message="issuer must have \"https\" scheme",rule="self.startsWith('https://')"
+	// +kubebuilder:validation:XValidation:message="issuer must not have username or password",rule="!self.matches('^https://[^/]*@')"
+	// +kubebuilder:validation:XValidation:message="issuer must not have trailing slash in path",rule="!self.endsWith('/')"
+	// +kubebuilder:validation:XValidation:message="issuer must not have query or fragment",rule="!self.contains('?') && !self.contains('#')"
 	Issuer string `json:"issuer"`
 
+	// PreviousIssuer optionally declares the issuer which this FederationDomain used before spec.issuer was changed,
+	// to migrate existing clients and sessions to the new issuer gradually instead of all at once. The previous issuer
+	// keeps serving its discovery and JWKS endpoints, and its discovery document tells the Pinniped CLI to log in again
+	// at the new issuer, after which the CLI caches the new session under the new issuer. Refresh tokens which were
+	// issued by the previous issuer can still be redeemed at the previous issuer until the end of the grace period.
+	// Other clients must be reconfigured to use the new issuer. Note that JWTAuthenticators and other relying parties
+	// must trust both issuers during the migration, and that the TLS certificate of the FederationDomain should also
+	// be valid for the hostname of the previous issuer. Remove this setting when the migration is complete.
+	// +optional
+	PreviousIssuer *FederationDomainPreviousIssuer `json:"previousIssuer,omitempty"`
+
 	// TLS specifies a secret which will contain Transport Layer Security (TLS) configuration for the FederationDomain.
+	// +kubebuilder:validation:XValidation:message="secretName must be specified when certManager is specified",rule="!has(self.certManager) || (has(self.secretName) && size(self.secretName) > 0)"
+	// +kubebuilder:validation:XValidation:message="secretName must be specified when acme is specified",rule="!has(self.acme) || (has(self.secretName) && size(self.secretName) > 0)"
+	// +kubebuilder:validation:XValidation:message="only one of certManager or acme may be specified",rule="!(has(self.certManager) && has(self.acme))"
 	// +optional
 	TLS *FederationDomainTLSSpec `json:"tls,omitempty"`
 
@@ -238,6 +461,574 @@ type FederationDomainSpec struct {
 	//
 	// +optional
 	IdentityProviders []FederationDomainIdentityProvider `json:"identityProviders,omitempty"`
+
+	// ReadinessPolicy determines how problems with the identity providers listed in IdentityProviders affect this
+	// FederationDomain. An identity provider is not ready when its entry in IdentityProviders is invalid, e.g. when
+	// its objectRef cannot be resolved or its transforms are invalid. With AllIDPsReady, any identity provider
+	// which is not ready makes the whole FederationDomain unavailable. With AnyIDPReady, the FederationDomain
+	// remains available as long as at least one identity provider is ready, and attempts to log in using the other
+	// identity providers are rejected. The readiness of each identity provider is reported in
+	// status.identityProviders. This setting has no effect when IdentityProviders is empty.
+	// Defaults to AllIDPsReady.
+	// +kubebuilder:default=AllIDPsReady
+	// +optional
+	ReadinessPolicy FederationDomainReadinessPolicy `json:"readinessPolicy,omitempty"`
+
+	// LoginRateLimits optionally throttles requests to the authorization, pushed authorization and login endpoints
+	// of this FederationDomain, and temporarily locks out usernames for the source IP addresses from which there were
+	// repeated failed logins, using either the login page or the CLI-based login flow. When not specified, no throttling
+	// is applied. The token endpoint is not throttled, since most of its requests are refreshes and token exchanges
+	// rather than logins. Use TokenEndpointLoadShedding to protect it from overload instead.
+	// The counts of requests and failed logins are kept in the memory of each Supervisor pod, and are not shared
+	// between pods. When the Supervisor runs N pods, the effective limits are therefore up to N times the configured
+	// limits, depending on how requests are load balanced between the pods. The counts are also reset when a pod
+	// restarts.
+	// +optional
+	LoginRateLimits *FederationDomainLoginRateLimits `json:"loginRateLimits,omitempty"`
+
+	// TokenEndpointLoadShedding optionally limits the number of requests which are processed concurrently by the
+	// token endpoint of this FederationDomain, so that it degrades gracefully when it is overloaded. Requests beyond
+	// the limit wait in a queue in which interactive requests, i.e. the authorization code and refresh token grants,
+	// are always served before bulk requests, e.g. the client credentials and RFC8693 token exchange grants. Requests
+	// which wait for longer than the queue timeout receive an HTTP 503 response with a Retry-After header.
+	// When not specified, the number of concurrent requests is not limited.
+	// +optional
+	TokenEndpointLoadShedding *FederationDomainTokenEndpointLoadShedding `json:"tokenEndpointLoadShedding,omitempty"`
+
+	// Branding optionally customizes the look of the web pages served by this FederationDomain to match
+	// your organization's branding.
+	// +optional
+	Branding *FederationDomainBrandingSpec `json:"branding,omitempty"`
+
+	// AccessLog optionally enables HTTP access logs for the endpoints of this FederationDomain. The format
+	// and destination of the access logs are configured in the static configuration of the Supervisor.
+	// These access logs are separate from the Supervisor's audit logs.
+	// The access logs may also be delivered as CloudEvents, either to an HTTP endpoint or to a Kafka topic. Kafka is only
+	// supported through a Kafka REST Proxy, since the Supervisor does not speak the Kafka protocol. The Supervisor's
+	// audit logs are not delivered as CloudEvents.
+	// +optional
+	AccessLog *FederationDomainAccessLogSpec `json:"accessLog,omitempty"`
+
+	// TokenEnrichmentWebhook optionally configures a webhook which is called by the token endpoint of this
+	// FederationDomain before it issues tokens for the authorization code and refresh token grants. The webhook
+	// can deny the issuance of the tokens, replace the user's downstream groups, and add claims to the
+	// additionalClaims claim of the ID token, e.g. to implement conditional access policies based on the time
+	// of day or on device posture. When not specified, no webhook is called.
+	// +optional
+	TokenEnrichmentWebhook *FederationDomainTokenEnrichmentWebhook `json:"tokenEnrichmentWebhook,omitempty"`
+
+	// AccessTokens optionally configures the format of the access tokens which are issued by this FederationDomain.
+	// By default, access tokens are opaque, so they can only be used at the endpoints of this FederationDomain.
+	// Alternatively, access tokens can be JWTs which are signed by the signing keys of this FederationDomain,
+	// so that workloads can validate them locally using the JWKS of this FederationDomain. Note that JWT access
+	// tokens cannot be revoked before they expire, and that their claims can be read by anyone who holds them.
+	// +optional
+	AccessTokens *FederationDomainAccessTokens `json:"accessTokens,omitempty"`
+
+	// WorkloadIdentity optionally allows the workloads which run in the same Kubernetes cluster as the Supervisor
+	// to exchange their projected ServiceAccount tokens for cluster-scoped ID tokens at the token endpoint of this
+	// FederationDomain, using the RFC8693 token exchange grant with a subject_token_type of
+	// "urn:ietf:params:oauth:token-type:jwt". This allows controllers to call other clusters which trust this
+	// FederationDomain without any human login. The ServiceAccount tokens are validated using the ServiceAccount
+	// issuer discovery document and keys of the Supervisor's cluster, and must have the issuer of this
+	// FederationDomain as their audience. When not specified, ServiceAccount tokens are not accepted.
+	// +optional
+	WorkloadIdentity *FederationDomainWorkloadIdentity `json:"workloadIdentity,omitempty"`
+
+	// SigningKeys optionally configures the algorithm of the keys which sign the ID tokens, JWT access tokens and
+	// JWT authorization responses of this FederationDomain, and how often those keys are rotated. When not specified,
+	// the keys use ES256 and are only rotated on request, by adding the supervisor.pinniped.dev/rotate-jwks annotation
+	// to the JWKS Secret of this FederationDomain.
+	// +optional
+	SigningKeys *FederationDomainSigningKeys `json:"signingKeys,omitempty"`
+
+	// CORS optionally allows web pages of other origins to call the discovery, JWKS and token endpoints of this
+	// FederationDomain, so that single-page applications which are registered as OIDCClients can complete their
+	// logins in the browser without a proxy. When not specified, browsers do not allow web pages of other origins
+	// to read the responses of those endpoints.
+	// +optional
+	CORS *FederationDomainCORSSpec `json:"cors,omitempty"`
+
+	// SingleSignOn optionally remembers the upstream logins of browsers with a cookie, so that users who recently
+	// logged in to one client of this FederationDomain, e.g. to one cluster using the Pinniped CLI, can log in to
+	// its other clients without logging in at the upstream identity provider again. Such logins reuse the upstream
+	// session data of the remembered login, e.g. its upstream refresh token, and the identity transformations and
+	// policies are applied again for each login. Authorization requests with prompt=login always log in at the
+	// upstream identity provider. When not specified, browsers always log in at the upstream identity provider.
+	// +optional
+	SingleSignOn *FederationDomainSingleSignOn `json:"singleSignOn,omitempty"`
+
+	// WebAuthn optionally requires the users of the LDAP and Active Directory identity providers of this
+	// FederationDomain to use a WebAuthn credential, e.g. a passkey or a security key, in addition to their password
+	// when they log in using the Supervisor's login page. Each user registers a credential during their first login
+	// after this was configured, and has their own credential for each identity provider. The credentials are stored
+	// in Secrets in the namespace of the Supervisor. Deleting the Secret of a user resets their registration.
+	// When configured, logins using the CLI-based password flow are not allowed for those identity providers.
+	// When not specified, users never need a WebAuthn credential.
+	// +optional
+	WebAuthn *FederationDomainWebAuthn `json:"webAuthn,omitempty"`
+
+	// Exposure optionally makes the Supervisor create and maintain an Ingress, a Gateway API HTTPRoute, or a
+	// Gateway API TLSRoute which routes the hostname and path of the issuer to the Supervisor's Service, optionally
+	// annotated for external-dns so that a DNS record is created for the hostname of the issuer. The Ingress or route
+	// has the same name and namespace as the FederationDomain, and is deleted along with the FederationDomain.
+	// The compatibility of the listeners of the Gateways and the acceptance of a route by its Gateways are reported
+	// in the ExposureReady condition. Whether the issuer can be reached at its URL is checked periodically and
+	// reported in the IssuerExternallyReachable condition.
+	// When not specified, DNS and routing to the Supervisor must be configured by other means.
+	// +optional
+	Exposure *FederationDomainExposureSpec `json:"exposure,omitempty"`
+
+	// Listener optionally names one of the additional HTTPS listeners which are configured by the
+	// endpoints.additionalHTTPS setting of the static configuration of the Supervisor. When specified, the endpoints
+	// of this FederationDomain are only served by that listener, which may bind to a different port or IP address
+	// and use different TLS settings than the other listeners, to isolate this issuer from other issuers. When not
+	// specified, the endpoints are only served by the default HTTPS and HTTP listeners. When no listener of the
+	// given name is configured, the endpoints of this FederationDomain are not served at all.
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +optional
+	Listener string `json:"listener,omitempty"`
+}
+
+// FederationDomainPreviousIssuer describes the previous issuer of a FederationDomain whose issuer was changed.
+type FederationDomainPreviousIssuer struct {
+	// Issuer is the previous issuer URL of the FederationDomain. It must be a valid issuer URL, like spec.issuer,
+	// and must be different from spec.issuer.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:XValidation:message="issuer must have \"https\" scheme",rule="self.startsWith('https://')"
+	// +kubebuilder:validation:XValidation:message="issuer must not have username or password",rule="!self.matches('^https://[^/]*@')"
+	// +kubebuilder:validation:XValidation:message="issuer must not have trailing slash in path",rule="!self.endsWith('/')"
+	// +kubebuilder:validation:XValidation:message="issuer must not have query or fragment",rule="!self.contains('?') && !self.contains('#')"
+	Issuer string `json:"issuer"`
+
+	// RefreshGracePeriodEnd is the time until which the refresh tokens which were issued by the previous issuer
+	// may still be redeemed at the previous issuer. After this time, the token endpoint of the previous issuer
+	// refuses all requests, so the users of the previous issuer must log in again at the new issuer.
+	RefreshGracePeriodEnd metav1.Time `json:"refreshGracePeriodEnd"`
+}
+
+// FederationDomainExposureSpec describes how the Supervisor should make a FederationDomain reachable from
+// outside the cluster.
+// +kubebuilder:validation:XValidation:message="exactly one of ingress, httpRoute or tlsRoute must be specified",rule="[has(self.ingress), has(self.httpRoute), has(self.tlsRoute)].filter(x, x).size() == 1"
+type FederationDomainExposureSpec struct {
+	// Service refers to the Service in the same namespace which targets the HTTPS endpoint of the Supervisor pods.
+	Service FederationDomainExposureServiceRef `json:"service"`
+
+	// ExternalDNS optionally adds the annotations which are read by external-dns to the Ingress or route.
+	// The hostname annotation is always set to the hostname of the issuer. It is ignored when the issuer URL
+	// uses an IP address.
+	// +optional
+	ExternalDNS *FederationDomainExternalDNSSpec `json:"externalDNS,omitempty"`
+
+	// Ingress causes an Ingress to be created. Note that the Supervisor only serves HTTPS, so the ingress
+	// controller must be configured to use HTTPS to connect to the Service, usually by using annotations.
+	// +optional
+	Ingress *FederationDomainIngressSpec `json:"ingress,omitempty"`
+
+	// HTTPRoute causes a Gateway API HTTPRoute to be created. The Gateway terminates TLS, so each selected listener
+	// of the Gateways must use the HTTPS protocol and the Terminate TLS mode. Note that the Supervisor only serves
+	// HTTPS, so the Gateway must also be configured to use TLS to connect to the Service.
+	// +optional
+	HTTPRoute *FederationDomainHTTPRouteSpec `json:"httpRoute,omitempty"`
+
+	// TLSRoute causes a Gateway API TLSRoute to be created, which passes the TLS connections for the hostname of
+	// the issuer through to the Supervisor, so that TLS is terminated by the Supervisor using the TLS certificate
+	// of the FederationDomain. Each selected listener of the Gateways must use the TLS protocol and the Passthrough
+	// TLS mode. The TLSRoute matches on the SNI of the connections, so the issuer URL must use a hostname rather
+	// than an IP address. Requires the experimental channel of the Gateway API, which includes TLSRoutes.
+	// +optional
+	TLSRoute *FederationDomainTLSRouteSpec `json:"tlsRoute,omitempty"`
+}
+
+// FederationDomainExposureServiceRef refers to a port of a Service.
+type FederationDomainExposureServiceRef struct {
+	// Name is the name of the Service.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Port is the number of the port of the Service.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+}
+
+// FederationDomainExternalDNSSpec describes the external-dns annotations of an Ingress or route.
+type FederationDomainExternalDNSSpec struct {
+	// Target optionally overrides the target of the DNS record, which external-dns otherwise reads from
+	// the status of the Ingress or Gateway. It is written to the external-dns.alpha.kubernetes.io/target annotation.
+	// +optional
+	Target string `json:"target,omitempty"`
+
+	// TTL optionally sets the time to live of the DNS record, in seconds. It is written to the
+	// external-dns.alpha.kubernetes.io/ttl annotation.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TTL int32 `json:"ttl,omitempty"`
+}
+
+// FederationDomainIngressSpec describes the Ingress of a FederationDomain.
+type FederationDomainIngressSpec struct {
+	// IngressClassName is the name of the IngressClass of the Ingress. When not specified, the default
+	// IngressClass of the cluster is used.
+	// +optional
+	IngressClassName string `json:"ingressClassName,omitempty"`
+
+	// Annotations are added to the Ingress, e.g. to configure the ingress controller to use HTTPS to connect
+	// to the Service.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// FederationDomainHTTPRouteSpec describes the Gateway API HTTPRoute of a FederationDomain.
+type FederationDomainHTTPRouteSpec struct {
+	// ParentRefs are the Gateways to which the HTTPRoute should be attached.
+	// +kubebuilder:validation:MinItems=1
+	ParentRefs []FederationDomainGatewayParentRef `json:"parentRefs"`
+
+	// Annotations are added to the HTTPRoute.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// FederationDomainTLSRouteSpec describes the Gateway API TLSRoute of a FederationDomain.
+type FederationDomainTLSRouteSpec struct {
+	// ParentRefs are the Gateways to which the TLSRoute should be attached.
+	// +kubebuilder:validation:MinItems=1
+	ParentRefs []FederationDomainGatewayParentRef `json:"parentRefs"`
+
+	// Annotations are added to the TLSRoute.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// FederationDomainGatewayParentRef refers to a Gateway.
+type FederationDomainGatewayParentRef struct {
+	// Name is the name of the Gateway.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Namespace is the namespace of the Gateway. Defaults to the namespace of the FederationDomain.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// SectionName optionally selects a listener of the Gateway. When not specified, the route is attached to all
+	// listeners of the Gateway which are compatible with the route.
+	// +optional
+	SectionName string `json:"sectionName,omitempty"`
+}
+
+// FederationDomainTokenEnrichmentFailurePolicy determines what happens when a token enrichment webhook
+// cannot be called, or returns an invalid response.
+// +kubebuilder:validation:Enum=Fail;Ignore
+type FederationDomainTokenEnrichmentFailurePolicy string
+
+const (
+	// FederationDomainTokenEnrichmentFailurePolicyFail means that the token request is rejected.
+	FederationDomainTokenEnrichmentFailurePolicyFail FederationDomainTokenEnrichmentFailurePolicy = "Fail"
+
+	// FederationDomainTokenEnrichmentFailurePolicyIgnore means that the tokens are issued without enrichment.
+	FederationDomainTokenEnrichmentFailurePolicyIgnore FederationDomainTokenEnrichmentFailurePolicy = "Ignore"
+)
+
+// FederationDomainTokenEnrichmentWebhook configures a token enrichment webhook for a FederationDomain.
+type FederationDomainTokenEnrichmentWebhook struct {
+	// Endpoint is the HTTPS URL of the webhook. The token endpoint sends an HTTP POST request with a JSON
+	// body describing the user and the token request to this URL.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern=`^https://`
+	Endpoint string `json:"endpoint"`
+
+	// CertificateAuthorityData is an optional base64 encoded PEM bundle of CA certificates to trust when
+	// calling the webhook. When not specified, the system trust store is used.
+	// +optional
+	CertificateAuthorityData string `json:"certificateAuthorityData,omitempty"`
+
+	// TimeoutSeconds is the maximum time to wait for a response from the webhook. Defaults to 10.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=30
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+
+	// FailurePolicy determines what happens when the webhook cannot be called, or returns an invalid response.
+	// With Fail, the token request is rejected. With Ignore, the tokens are issued without enrichment.
+	// Defaults to Fail.
+	// +kubebuilder:default=Fail
+	// +optional
+	FailurePolicy FederationDomainTokenEnrichmentFailurePolicy `json:"failurePolicy,omitempty"`
+}
+
+// FederationDomainAccessTokenFormat is the format of the access tokens which are issued by a FederationDomain.
+// +kubebuilder:validation:Enum=Opaque;JWT
+type FederationDomainAccessTokenFormat string
+
+const (
+	// FederationDomainAccessTokenFormatOpaque means that access tokens are random strings which can only be
+	// validated by the Supervisor.
+	FederationDomainAccessTokenFormatOpaque FederationDomainAccessTokenFormat = "Opaque"
+
+	// FederationDomainAccessTokenFormatJWT means that access tokens are JWTs as described by RFC9068, which can be
+	// validated by anyone using the JWKS of the FederationDomain.
+	FederationDomainAccessTokenFormatJWT FederationDomainAccessTokenFormat = "JWT"
+)
+
+// FederationDomainAccessTokenClaim is the name of an optional claim of the JWT access tokens.
+// +kubebuilder:validation:Enum=username;groups
+type FederationDomainAccessTokenClaim string
+
+const (
+	// FederationDomainAccessTokenClaimUsername is the downstream username of the user.
+	FederationDomainAccessTokenClaimUsername FederationDomainAccessTokenClaim = "username"
+
+	// FederationDomainAccessTokenClaimGroups is the downstream group memberships of the user.
+	FederationDomainAccessTokenClaimGroups FederationDomainAccessTokenClaim = "groups"
+)
+
+// FederationDomainAccessTokens configures the access tokens which are issued by a FederationDomain.
+// +kubebuilder:validation:XValidation:message="audience and claims may only be specified when format is JWT",rule="self.format == 'JWT' || (!has(self.audience) && !has(self.claims))"
+type FederationDomainAccessTokens struct {
+	// Format is the format of the access tokens. With Opaque, the access tokens can only be used at the endpoints
+	// of this FederationDomain. With JWT, the access tokens are JWTs as described by RFC9068 which are signed by
+	// the signing keys of this FederationDomain. Access tokens which were issued before the format was changed
+	// remain valid until they expire.
+	// Defaults to Opaque.
+	// +kubebuilder:default=Opaque
+	// +optional
+	Format FederationDomainAccessTokenFormat `json:"format,omitempty"`
+
+	// Audience is the list of audiences of the JWT access tokens, i.e. the workloads which should accept them.
+	// Each workload should only accept the access tokens which include its own audience. These values must not
+	// contain ".pinniped.dev", and should not be the same as any audience which is used for cluster-scoped ID
+	// tokens, e.g. the audience of a JWTAuthenticator of the Concierge. When not specified, the audience of each
+	// access token is the client ID of the client to which it was issued.
+	// +kubebuilder:validation:MaxItems=10
+	// +listType=set
+	// +optional
+	Audience []string `json:"audience,omitempty"`
+
+	// Claims is the list of optional claims which are added to the JWT access tokens, in addition to the claims
+	// required by RFC9068. The username and groups claims have the same values as in the ID tokens. Only include
+	// the claims which are needed by the workloads, since anyone who holds an access token can read its claims.
+	// +listType=set
+	// +optional
+	Claims []FederationDomainAccessTokenClaim `json:"claims,omitempty"`
+}
+
+// FederationDomainWorkloadIdentity configures which ServiceAccounts may exchange their tokens at a FederationDomain,
+// and the downstream identities which they are given.
+type FederationDomainWorkloadIdentity struct {
+	// AllowedNamespaces are the namespaces of the Supervisor's cluster whose ServiceAccounts may exchange their
+	// tokens. The tokens of the ServiceAccounts of all other namespaces are rejected.
+	// +kubebuilder:validation:MinItems=1
+	// +listType=set
+	AllowedNamespaces []string `json:"allowedNamespaces"`
+
+	// UsernamePrefix is prepended to the downstream username and group names of each ServiceAccount, to avoid
+	// conflicts with the ServiceAccounts of the clusters which trust this FederationDomain. The downstream username
+	// is the prefix followed by "system:serviceaccount:<namespace>:<name>", and the downstream groups are the prefix
+	// followed by "system:serviceaccounts" and "system:serviceaccounts:<namespace>". Defaults to "workload:".
+	// +kubebuilder:default="workload:"
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:XValidation:message="usernamePrefix must not start with 'system:'",rule="!self.startsWith('system:')"
+	// +optional
+	UsernamePrefix string `json:"usernamePrefix,omitempty"`
+}
+
+// FederationDomainSigningAlgorithm is the JWS algorithm of the signing keys of a FederationDomain.
+// +kubebuilder:validation:Enum=ES256;RS256;EdDSA
+type FederationDomainSigningAlgorithm string
+
+const (
+	// FederationDomainSigningAlgorithmES256 means ECDSA using the P-256 curve and SHA-256.
+	FederationDomainSigningAlgorithmES256 FederationDomainSigningAlgorithm = "ES256"
+
+	// FederationDomainSigningAlgorithmRS256 means RSASSA-PKCS1-v1_5 using 2048 bit keys and SHA-256.
+	FederationDomainSigningAlgorithmRS256 FederationDomainSigningAlgorithm = "RS256"
+
+	// FederationDomainSigningAlgorithmEdDSA means EdDSA using the Ed25519 curve.
+	FederationDomainSigningAlgorithmEdDSA FederationDomainSigningAlgorithm = "EdDSA"
+)
+
+// FederationDomainSigningKeys configures the signing keys of a FederationDomain and their rotation.
+type FederationDomainSigningKeys struct {
+	// Algorithm is the JWS algorithm of the signing keys. When it is changed, the current key is rotated to a new
+	// key of the new algorithm. Note that clients must support the algorithm to validate the tokens, e.g. the
+	// JWTAuthenticators of the Concierge accept ES256 and RS256, but not EdDSA.
+	// Defaults to ES256.
+	// +kubebuilder:default=ES256
+	// +optional
+	Algorithm FederationDomainSigningAlgorithm `json:"algorithm,omitempty"`
+
+	// RotationIntervalSeconds is how long each signing key is used to sign tokens before it is automatically
+	// rotated, in seconds. When not specified, the signing keys are only rotated on request.
+	// +kubebuilder:validation:Minimum=3600
+	// +optional
+	RotationIntervalSeconds *int32 `json:"rotationIntervalSeconds,omitempty"`
+
+	// OverlapSeconds is how long the public key of a new signing key is published in the JWKS of this
+	// FederationDomain before the new key is used to sign tokens, in seconds. This gives clients time to refresh
+	// their cached copy of the JWKS. After a rotation, the public key of the previous signing key stays in the JWKS
+	// until all of the tokens which it signed have expired. Defaults to 300 (five minutes).
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=86400
+	// +optional
+	OverlapSeconds *int32 `json:"overlapSeconds,omitempty"`
+
+	// External optionally configures an external signing service which holds the signing keys of this
+	// FederationDomain, e.g. a service which is backed by a PKCS#11 HSM or by a cloud KMS, so that the private keys
+	// are never stored in Kubernetes Secrets. The signing service is responsible for rotating its keys, so
+	// RotationIntervalSeconds and OverlapSeconds are ignored, and Algorithm must be the algorithm of its keys.
+	// +optional
+	External *FederationDomainExternalSigningKeys `json:"external,omitempty"`
+}
+
+// FederationDomainExternalSigningKeys configures an external signing service which holds the signing keys of a
+// FederationDomain.
+//
+// The Supervisor sends a GET request to "<endpoint>/keys" to fetch the public keys, which the service must return
+// as a JSON Web Key Set. Each key must have a "kid" and an "alg" member. The first key of the set is used to sign
+// tokens, and the other keys are only published in the JWKS of the FederationDomain, e.g. a new key before the
+// service starts to return it first, or a previous key until the tokens which it signed have expired. The Supervisor
+// fetches the keys every minute, so the service should publish a new key for at least a few minutes before it
+// returns it first.
+//
+// The Supervisor sends a POST request with a JSON body to "<endpoint>/sign" for each token. The body has a "keyID"
+// member, which is the "kid" of the key to sign with, an "algorithm" member, which is its "alg", and a "payload"
+// member, which is the base64url-encoded JWS signing input. The service must respond with a JSON body which has a
+// "signature" member, which is the base64url-encoded JWS signature, e.g. the concatenation of R and S for ES256.
+// The service must keep signing with each of its keys for as long as the key is in its key set.
+type FederationDomainExternalSigningKeys struct {
+	// Endpoint is the HTTPS URL of the signing service.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern=`^https://`
+	Endpoint string `json:"endpoint"`
+
+	// X.509 Certificate Authority (base64-encoded PEM bundle) to trust when connecting to the endpoint.
+	// If omitted, a default set of system roots will be trusted.
+	//
+	// +optional
+	CertificateAuthorityData string `json:"certificateAuthorityData,omitempty"`
+
+	// CredentialsSecretName is the name of a Secret in the same namespace which holds the credentials that the
+	// Supervisor uses to authenticate to the signing service. A Secret of type "kubernetes.io/tls" holds a client
+	// certificate and its private key in the keys "tls.crt" and "tls.key", and a Secret of type
+	// "secrets.pinniped.dev/bearer-token" holds a bearer token in the key "token". When omitted, the requests to the
+	// signing service are not authenticated, so the signing service must only be reachable by the Supervisor.
+	// +optional
+	CredentialsSecretName string `json:"credentialsSecretName,omitempty"`
+}
+
+// FederationDomainAccessLogSpec configures the HTTP access logs of a FederationDomain.
+type FederationDomainAccessLogSpec struct {
+	// Enabled causes an access log entry to be written for each request to the endpoints of this FederationDomain.
+	// The values of query parameters which may contain tokens or personal information are redacted.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// FederationDomainCORSSpec configures cross-origin resource sharing (CORS) for the discovery, JWKS and token
+// endpoints of a FederationDomain.
+type FederationDomainCORSSpec struct {
+	// AllowedOrigins are the origins of the web pages which may call the endpoints, e.g. "https://app.example.com".
+	// An origin is the scheme, hostname and optional port of a URL, without a path. The single origin "*" allows web
+	// pages of any origin. Note that the token endpoint still only issues tokens to the OIDCClients which present
+	// a valid authorization code, refresh token or client secret.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:items:Pattern=`^(\*|https?://[^/?#\s]+)$`
+	// +listType=set
+	AllowedOrigins []string `json:"allowedOrigins"`
+
+	// AllowedHeaders are the request headers which the web pages may send, in addition to the headers which browsers
+	// always allow. Defaults to Authorization, Content-Type and DPoP.
+	// +optional
+	// +listType=set
+	AllowedHeaders []string `json:"allowedHeaders,omitempty"`
+}
+
+// FederationDomainSingleSignOn configures single sign-on across the clients of a FederationDomain.
+type FederationDomainSingleSignOn struct {
+	// CookieLifetimeSeconds is how long the login of a browser is remembered after the user logged in at the
+	// upstream identity provider, in seconds. Defaults to 28800 (eight hours).
+	// +kubebuilder:validation:Minimum=60
+	// +kubebuilder:validation:Maximum=604800
+	// +optional
+	CookieLifetimeSeconds *int32 `json:"cookieLifetimeSeconds,omitempty"`
+}
+
+// FederationDomainWebAuthnEnforcement determines whether users must register a WebAuthn credential.
+// +kubebuilder:validation:Enum=Required;Optional
+type FederationDomainWebAuthnEnforcement string
+
+const (
+	// FederationDomainWebAuthnEnforcementRequired means that users who have not registered a credential must
+	// register one when they log in.
+	FederationDomainWebAuthnEnforcementRequired FederationDomainWebAuthnEnforcement = "Required"
+
+	// FederationDomainWebAuthnEnforcementOptional means that users who have not registered a credential are asked
+	// to register one when they log in, but may skip it.
+	FederationDomainWebAuthnEnforcementOptional FederationDomainWebAuthnEnforcement = "Optional"
+)
+
+// FederationDomainWebAuthn configures WebAuthn credentials as a second factor for the users of a FederationDomain.
+type FederationDomainWebAuthn struct {
+	// Enforcement determines whether users who have not registered a credential may log in without one.
+	// Users who registered a credential must always use it. Defaults to Required.
+	// +kubebuilder:default=Required
+	// +optional
+	Enforcement FederationDomainWebAuthnEnforcement `json:"enforcement,omitempty"`
+}
+
+// FederationDomainLoginRateLimits configures throttling of login attempts for a FederationDomain.
+type FederationDomainLoginRateLimits struct {
+	// RequestsPerMinutePerSourceIP is the maximum number of requests per minute which will be accepted from
+	// a single source IP address by each of the throttled endpoints. Requests beyond the limit receive
+	// an HTTP 429 response. Defaults to 60. Each Supervisor pod applies this limit separately.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	RequestsPerMinutePerSourceIP *int32 `json:"requestsPerMinutePerSourceIP,omitempty"`
+
+	// FailedAttemptsBeforeLockout is the number of consecutive failed logins for a single username of an identity
+	// provider from a single source IP address after which that username will be temporarily locked out for that source
+	// IP address. Logins for the same username from other source IP addresses are not affected, so that anyone who knows
+	// a username cannot lock out its user by failing to log in as them. Defaults to 5. Each Supervisor pod counts failed
+	// logins separately.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	FailedAttemptsBeforeLockout *int32 `json:"failedAttemptsBeforeLockout,omitempty"`
+
+	// LockoutSeconds is how long a username remains locked out, in seconds. Defaults to 300 (five minutes).
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=86400
+	// +optional
+	LockoutSeconds *int32 `json:"lockoutSeconds,omitempty"`
+
+	// TrustedProxies optionally lists the IP address ranges, in CIDR notation, of the reverse proxies and load
+	// balancers in front of the Supervisor which are trusted to report the IP address of their client in the
+	// X-Forwarded-For header, e.g. "10.0.0.0/8". When the remote address of a request is in one of these ranges,
+	// the source IP address of the request is the rightmost address in its X-Forwarded-For header which is not in
+	// one of these ranges. When not specified, the X-Forwarded-For header is ignored and the source IP address is
+	// always the remote address of the request, so all requests which are forwarded by the same proxy share one limit.
+	// +optional
+	// +kubebuilder:validation:items:Format=cidr
+	// +listType=set
+	TrustedProxies []string `json:"trustedProxies,omitempty"`
+}
+
+// FederationDomainTokenEndpointLoadShedding configures load-shedding for the token endpoint of a FederationDomain.
+type FederationDomainTokenEndpointLoadShedding struct {
+	// MaxConcurrentRequests is the maximum number of requests which are processed concurrently by the token
+	// endpoint of each Supervisor pod. Defaults to 50.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxConcurrentRequests *int32 `json:"maxConcurrentRequests,omitempty"`
+
+	// QueueTimeoutSeconds is how long a request may wait in the queue before it is rejected, in seconds.
+	// Defaults to 5.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=60
+	// +optional
+	QueueTimeoutSeconds *int32 `json:"queueTimeoutSeconds,omitempty"`
 }
 
 // FederationDomainSecrets holds information about this OIDC Provider's secrets.
@@ -264,6 +1055,21 @@ type FederationDomainSecrets struct {
 	StateEncryptionKey corev1.LocalObjectReference `json:"stateEncryptionKey,omitempty"`
 }
 
+// FederationDomainIdentityProviderStatus describes the readiness of one of the identity providers listed in
+// the spec of a FederationDomain.
+type FederationDomainIdentityProviderStatus struct {
+	// DisplayName is the displayName of the identity provider, as listed in spec.identityProviders.
+	DisplayName string `json:"displayName"`
+
+	// Phase summarizes whether the identity provider can be used to log in.
+	// +kubebuilder:validation:Enum=Ready;Error
+	Phase FederationDomainIdentityProviderPhase `json:"phase"`
+
+	// Message is a human-readable explanation of the phase.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
 // FederationDomainStatus is a struct that describes the actual state of an OIDC Provider.
 type FederationDomainStatus struct {
 	// Phase summarizes the overall status of the FederationDomain.
@@ -278,6 +1084,11 @@ type FederationDomainStatus struct {
 	// +listMapKey=type
 	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 
+	// IdentityProviders reports the readiness of each identity provider listed in spec.identityProviders,
+	// in the same order.
+	// +optional
+	IdentityProviders []FederationDomainIdentityProviderStatus `json:"identityProviders,omitempty"`
+
 	// Secrets contains information about this OIDC Provider's secrets.
 	// +optional
 	Secrets FederationDomainSecrets `json:"secrets,omitempty"`
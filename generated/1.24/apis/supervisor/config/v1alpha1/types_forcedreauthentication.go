@@ -0,0 +1,69 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// ForcedReauthenticationSubjectKind is the kind of subject which is required to reauthenticate.
+// +kubebuilder:validation:Enum=User;Group
+type ForcedReauthenticationSubjectKind string
+
+const (
+	// ForcedReauthenticationSubjectKindUser selects the sessions of a single user by their downstream username.
+	ForcedReauthenticationSubjectKindUser ForcedReauthenticationSubjectKind = "User"
+
+	// ForcedReauthenticationSubjectKindGroup selects the sessions of all members of a downstream group.
+	ForcedReauthenticationSubjectKindGroup ForcedReauthenticationSubjectKind = "Group"
+)
+
+// ForcedReauthenticationSubject selects the users whose sessions are affected by a ForcedReauthentication.
+type ForcedReauthenticationSubject struct {
+	// kind is the kind of the subject, which is either User or Group.
+	Kind ForcedReauthenticationSubjectKind `json:"kind"`
+
+	// name is the downstream username or downstream group name of the subject, i.e. the name after the identity
+	// transformations of the FederationDomain have been applied, as it would appear in the user's cluster credentials.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// ForcedReauthenticationSpec is a struct that describes a ForcedReauthentication.
+type ForcedReauthenticationSpec struct {
+	// subject selects the users who must reauthenticate.
+	Subject ForcedReauthenticationSubject `json:"subject"`
+
+	// notBefore is the time before which the subject's sessions must not be refreshed. Refresh grants for sessions
+	// of the subject which were started by an interactive login before this time are rejected, so the user must log
+	// in again using their external identity provider. Sessions started by a login after this time are not affected.
+	// When not set, the creation time of the ForcedReauthentication is used.
+	// +optional
+	NotBefore *metav1.Time `json:"notBefore,omitempty"`
+}
+
+// ForcedReauthentication requires a user, or all members of a group, to log in again using their external identity
+// provider before they can continue to use the Supervisor. It applies to the sessions of all FederationDomains.
+// The resource may be deleted once the affected sessions have expired or the users have logged in again.
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:categories=pinniped
+// +kubebuilder:printcolumn:name="Kind",type=string,JSONPath=`.spec.subject.kind`
+// +kubebuilder:printcolumn:name="Name",type=string,JSONPath=`.spec.subject.name`
+// +kubebuilder:printcolumn:name="Not Before",type=date,JSONPath=`.spec.notBefore`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type ForcedReauthentication struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec of the forced reauthentication.
+	Spec ForcedReauthenticationSpec `json:"spec"`
+}
+
+// List of ForcedReauthentication objects.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ForcedReauthenticationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ForcedReauthentication `json:"items"`
+}
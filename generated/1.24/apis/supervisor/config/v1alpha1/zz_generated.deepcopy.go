@@ -13,6 +13,82 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterAudience) DeepCopyInto(out *ClusterAudience) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAudience.
+func (in *ClusterAudience) DeepCopy() *ClusterAudience {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterAudience)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterAudience) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterAudienceList) DeepCopyInto(out *ClusterAudienceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterAudience, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAudienceList.
+func (in *ClusterAudienceList) DeepCopy() *ClusterAudienceList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterAudienceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterAudienceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterAudienceSpec) DeepCopyInto(out *ClusterAudienceSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAudienceSpec.
+func (in *ClusterAudienceSpec) DeepCopy() *ClusterAudienceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterAudienceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FederationDomain) DeepCopyInto(out *FederationDomain) {
 	*out = *in
@@ -41,6 +117,314 @@ func (in *FederationDomain) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainACMEDNS01Spec) DeepCopyInto(out *FederationDomainACMEDNS01Spec) {
+	*out = *in
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(FederationDomainACMEDNS01Webhook)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainACMEDNS01Spec.
+func (in *FederationDomainACMEDNS01Spec) DeepCopy() *FederationDomainACMEDNS01Spec {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainACMEDNS01Spec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainACMEDNS01Webhook) DeepCopyInto(out *FederationDomainACMEDNS01Webhook) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainACMEDNS01Webhook.
+func (in *FederationDomainACMEDNS01Webhook) DeepCopy() *FederationDomainACMEDNS01Webhook {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainACMEDNS01Webhook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainACMESpec) DeepCopyInto(out *FederationDomainACMESpec) {
+	*out = *in
+	if in.DNS01 != nil {
+		in, out := &in.DNS01, &out.DNS01
+		*out = new(FederationDomainACMEDNS01Spec)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainACMESpec.
+func (in *FederationDomainACMESpec) DeepCopy() *FederationDomainACMESpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainACMESpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainAccessLogSpec) DeepCopyInto(out *FederationDomainAccessLogSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainAccessLogSpec.
+func (in *FederationDomainAccessLogSpec) DeepCopy() *FederationDomainAccessLogSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainAccessLogSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainAccessTokens) DeepCopyInto(out *FederationDomainAccessTokens) {
+	*out = *in
+	if in.Audience != nil {
+		in, out := &in.Audience, &out.Audience
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Claims != nil {
+		in, out := &in.Claims, &out.Claims
+		*out = make([]FederationDomainAccessTokenClaim, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainAccessTokens.
+func (in *FederationDomainAccessTokens) DeepCopy() *FederationDomainAccessTokens {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainAccessTokens)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainBrandingSpec) DeepCopyInto(out *FederationDomainBrandingSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainBrandingSpec.
+func (in *FederationDomainBrandingSpec) DeepCopy() *FederationDomainBrandingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainBrandingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainCORSSpec) DeepCopyInto(out *FederationDomainCORSSpec) {
+	*out = *in
+	if in.AllowedOrigins != nil {
+		in, out := &in.AllowedOrigins, &out.AllowedOrigins
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedHeaders != nil {
+		in, out := &in.AllowedHeaders, &out.AllowedHeaders
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainCORSSpec.
+func (in *FederationDomainCORSSpec) DeepCopy() *FederationDomainCORSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainCORSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainCertManagerIssuerRef) DeepCopyInto(out *FederationDomainCertManagerIssuerRef) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainCertManagerIssuerRef.
+func (in *FederationDomainCertManagerIssuerRef) DeepCopy() *FederationDomainCertManagerIssuerRef {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainCertManagerIssuerRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainCertManagerSpec) DeepCopyInto(out *FederationDomainCertManagerSpec) {
+	*out = *in
+	if in.IssuerRef != nil {
+		in, out := &in.IssuerRef, &out.IssuerRef
+		*out = new(FederationDomainCertManagerIssuerRef)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainCertManagerSpec.
+func (in *FederationDomainCertManagerSpec) DeepCopy() *FederationDomainCertManagerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainCertManagerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainExposureServiceRef) DeepCopyInto(out *FederationDomainExposureServiceRef) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainExposureServiceRef.
+func (in *FederationDomainExposureServiceRef) DeepCopy() *FederationDomainExposureServiceRef {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainExposureServiceRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainExposureSpec) DeepCopyInto(out *FederationDomainExposureSpec) {
+	*out = *in
+	out.Service = in.Service
+	if in.ExternalDNS != nil {
+		in, out := &in.ExternalDNS, &out.ExternalDNS
+		*out = new(FederationDomainExternalDNSSpec)
+		**out = **in
+	}
+	if in.Ingress != nil {
+		in, out := &in.Ingress, &out.Ingress
+		*out = new(FederationDomainIngressSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HTTPRoute != nil {
+		in, out := &in.HTTPRoute, &out.HTTPRoute
+		*out = new(FederationDomainHTTPRouteSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TLSRoute != nil {
+		in, out := &in.TLSRoute, &out.TLSRoute
+		*out = new(FederationDomainTLSRouteSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainExposureSpec.
+func (in *FederationDomainExposureSpec) DeepCopy() *FederationDomainExposureSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainExposureSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainExternalDNSSpec) DeepCopyInto(out *FederationDomainExternalDNSSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainExternalDNSSpec.
+func (in *FederationDomainExternalDNSSpec) DeepCopy() *FederationDomainExternalDNSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainExternalDNSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainExternalSigningKeys) DeepCopyInto(out *FederationDomainExternalSigningKeys) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainExternalSigningKeys.
+func (in *FederationDomainExternalSigningKeys) DeepCopy() *FederationDomainExternalSigningKeys {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainExternalSigningKeys)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainGatewayParentRef) DeepCopyInto(out *FederationDomainGatewayParentRef) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainGatewayParentRef.
+func (in *FederationDomainGatewayParentRef) DeepCopy() *FederationDomainGatewayParentRef {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainGatewayParentRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainHTTPRouteSpec) DeepCopyInto(out *FederationDomainHTTPRouteSpec) {
+	*out = *in
+	if in.ParentRefs != nil {
+		in, out := &in.ParentRefs, &out.ParentRefs
+		*out = make([]FederationDomainGatewayParentRef, len(*in))
+		copy(*out, *in)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainHTTPRouteSpec.
+func (in *FederationDomainHTTPRouteSpec) DeepCopy() *FederationDomainHTTPRouteSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainHTTPRouteSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FederationDomainIdentityProvider) DeepCopyInto(out *FederationDomainIdentityProvider) {
 	*out = *in
@@ -59,6 +443,45 @@ func (in *FederationDomainIdentityProvider) DeepCopy() *FederationDomainIdentity
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainIdentityProviderStatus) DeepCopyInto(out *FederationDomainIdentityProviderStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainIdentityProviderStatus.
+func (in *FederationDomainIdentityProviderStatus) DeepCopy() *FederationDomainIdentityProviderStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainIdentityProviderStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainIngressSpec) DeepCopyInto(out *FederationDomainIngressSpec) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainIngressSpec.
+func (in *FederationDomainIngressSpec) DeepCopy() *FederationDomainIngressSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainIngressSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FederationDomainList) DeepCopyInto(out *FederationDomainList) {
 	*out = *in
@@ -74,40 +497,145 @@ func (in *FederationDomainList) DeepCopyInto(out *FederationDomainList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainList.
-func (in *FederationDomainList) DeepCopy() *FederationDomainList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainList.
+func (in *FederationDomainList) DeepCopy() *FederationDomainList {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FederationDomainList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainLoginRateLimits) DeepCopyInto(out *FederationDomainLoginRateLimits) {
+	*out = *in
+	if in.RequestsPerMinutePerSourceIP != nil {
+		in, out := &in.RequestsPerMinutePerSourceIP, &out.RequestsPerMinutePerSourceIP
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailedAttemptsBeforeLockout != nil {
+		in, out := &in.FailedAttemptsBeforeLockout, &out.FailedAttemptsBeforeLockout
+		*out = new(int32)
+		**out = **in
+	}
+	if in.LockoutSeconds != nil {
+		in, out := &in.LockoutSeconds, &out.LockoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TrustedProxies != nil {
+		in, out := &in.TrustedProxies, &out.TrustedProxies
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainLoginRateLimits.
+func (in *FederationDomainLoginRateLimits) DeepCopy() *FederationDomainLoginRateLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainLoginRateLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainPreviousIssuer) DeepCopyInto(out *FederationDomainPreviousIssuer) {
+	*out = *in
+	in.RefreshGracePeriodEnd.DeepCopyInto(&out.RefreshGracePeriodEnd)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainPreviousIssuer.
+func (in *FederationDomainPreviousIssuer) DeepCopy() *FederationDomainPreviousIssuer {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainPreviousIssuer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainSecrets) DeepCopyInto(out *FederationDomainSecrets) {
+	*out = *in
+	out.JWKS = in.JWKS
+	out.TokenSigningKey = in.TokenSigningKey
+	out.StateSigningKey = in.StateSigningKey
+	out.StateEncryptionKey = in.StateEncryptionKey
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainSecrets.
+func (in *FederationDomainSecrets) DeepCopy() *FederationDomainSecrets {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainSecrets)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainSigningKeys) DeepCopyInto(out *FederationDomainSigningKeys) {
+	*out = *in
+	if in.RotationIntervalSeconds != nil {
+		in, out := &in.RotationIntervalSeconds, &out.RotationIntervalSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.OverlapSeconds != nil {
+		in, out := &in.OverlapSeconds, &out.OverlapSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.External != nil {
+		in, out := &in.External, &out.External
+		*out = new(FederationDomainExternalSigningKeys)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainSigningKeys.
+func (in *FederationDomainSigningKeys) DeepCopy() *FederationDomainSigningKeys {
 	if in == nil {
 		return nil
 	}
-	out := new(FederationDomainList)
+	out := new(FederationDomainSigningKeys)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *FederationDomainList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *FederationDomainSecrets) DeepCopyInto(out *FederationDomainSecrets) {
+func (in *FederationDomainSingleSignOn) DeepCopyInto(out *FederationDomainSingleSignOn) {
 	*out = *in
-	out.JWKS = in.JWKS
-	out.TokenSigningKey = in.TokenSigningKey
-	out.StateSigningKey = in.StateSigningKey
-	out.StateEncryptionKey = in.StateEncryptionKey
+	if in.CookieLifetimeSeconds != nil {
+		in, out := &in.CookieLifetimeSeconds, &out.CookieLifetimeSeconds
+		*out = new(int32)
+		**out = **in
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainSecrets.
-func (in *FederationDomainSecrets) DeepCopy() *FederationDomainSecrets {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainSingleSignOn.
+func (in *FederationDomainSingleSignOn) DeepCopy() *FederationDomainSingleSignOn {
 	if in == nil {
 		return nil
 	}
-	out := new(FederationDomainSecrets)
+	out := new(FederationDomainSingleSignOn)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -115,10 +643,15 @@ func (in *FederationDomainSecrets) DeepCopy() *FederationDomainSecrets {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FederationDomainSpec) DeepCopyInto(out *FederationDomainSpec) {
 	*out = *in
+	if in.PreviousIssuer != nil {
+		in, out := &in.PreviousIssuer, &out.PreviousIssuer
+		*out = new(FederationDomainPreviousIssuer)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.TLS != nil {
 		in, out := &in.TLS, &out.TLS
 		*out = new(FederationDomainTLSSpec)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.IdentityProviders != nil {
 		in, out := &in.IdentityProviders, &out.IdentityProviders
@@ -127,6 +660,66 @@ func (in *FederationDomainSpec) DeepCopyInto(out *FederationDomainSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.LoginRateLimits != nil {
+		in, out := &in.LoginRateLimits, &out.LoginRateLimits
+		*out = new(FederationDomainLoginRateLimits)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TokenEndpointLoadShedding != nil {
+		in, out := &in.TokenEndpointLoadShedding, &out.TokenEndpointLoadShedding
+		*out = new(FederationDomainTokenEndpointLoadShedding)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Branding != nil {
+		in, out := &in.Branding, &out.Branding
+		*out = new(FederationDomainBrandingSpec)
+		**out = **in
+	}
+	if in.AccessLog != nil {
+		in, out := &in.AccessLog, &out.AccessLog
+		*out = new(FederationDomainAccessLogSpec)
+		**out = **in
+	}
+	if in.TokenEnrichmentWebhook != nil {
+		in, out := &in.TokenEnrichmentWebhook, &out.TokenEnrichmentWebhook
+		*out = new(FederationDomainTokenEnrichmentWebhook)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AccessTokens != nil {
+		in, out := &in.AccessTokens, &out.AccessTokens
+		*out = new(FederationDomainAccessTokens)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WorkloadIdentity != nil {
+		in, out := &in.WorkloadIdentity, &out.WorkloadIdentity
+		*out = new(FederationDomainWorkloadIdentity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SigningKeys != nil {
+		in, out := &in.SigningKeys, &out.SigningKeys
+		*out = new(FederationDomainSigningKeys)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CORS != nil {
+		in, out := &in.CORS, &out.CORS
+		*out = new(FederationDomainCORSSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SingleSignOn != nil {
+		in, out := &in.SingleSignOn, &out.SingleSignOn
+		*out = new(FederationDomainSingleSignOn)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WebAuthn != nil {
+		in, out := &in.WebAuthn, &out.WebAuthn
+		*out = new(FederationDomainWebAuthn)
+		**out = **in
+	}
+	if in.Exposure != nil {
+		in, out := &in.Exposure, &out.Exposure
+		*out = new(FederationDomainExposureSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -150,6 +743,11 @@ func (in *FederationDomainStatus) DeepCopyInto(out *FederationDomainStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.IdentityProviders != nil {
+		in, out := &in.IdentityProviders, &out.IdentityProviders
+		*out = make([]FederationDomainIdentityProviderStatus, len(*in))
+		copy(*out, *in)
+	}
 	out.Secrets = in.Secrets
 	return
 }
@@ -164,9 +762,47 @@ func (in *FederationDomainStatus) DeepCopy() *FederationDomainStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainTLSRouteSpec) DeepCopyInto(out *FederationDomainTLSRouteSpec) {
+	*out = *in
+	if in.ParentRefs != nil {
+		in, out := &in.ParentRefs, &out.ParentRefs
+		*out = make([]FederationDomainGatewayParentRef, len(*in))
+		copy(*out, *in)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainTLSRouteSpec.
+func (in *FederationDomainTLSRouteSpec) DeepCopy() *FederationDomainTLSRouteSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainTLSRouteSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FederationDomainTLSSpec) DeepCopyInto(out *FederationDomainTLSSpec) {
 	*out = *in
+	if in.CertManager != nil {
+		in, out := &in.CertManager, &out.CertManager
+		*out = new(FederationDomainCertManagerSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ACME != nil {
+		in, out := &in.ACME, &out.ACME
+		*out = new(FederationDomainACMESpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -180,6 +816,53 @@ func (in *FederationDomainTLSSpec) DeepCopy() *FederationDomainTLSSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainTokenEndpointLoadShedding) DeepCopyInto(out *FederationDomainTokenEndpointLoadShedding) {
+	*out = *in
+	if in.MaxConcurrentRequests != nil {
+		in, out := &in.MaxConcurrentRequests, &out.MaxConcurrentRequests
+		*out = new(int32)
+		**out = **in
+	}
+	if in.QueueTimeoutSeconds != nil {
+		in, out := &in.QueueTimeoutSeconds, &out.QueueTimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainTokenEndpointLoadShedding.
+func (in *FederationDomainTokenEndpointLoadShedding) DeepCopy() *FederationDomainTokenEndpointLoadShedding {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainTokenEndpointLoadShedding)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainTokenEnrichmentWebhook) DeepCopyInto(out *FederationDomainTokenEnrichmentWebhook) {
+	*out = *in
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainTokenEnrichmentWebhook.
+func (in *FederationDomainTokenEnrichmentWebhook) DeepCopy() *FederationDomainTokenEnrichmentWebhook {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainTokenEnrichmentWebhook)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FederationDomainTransforms) DeepCopyInto(out *FederationDomainTransforms) {
 	*out = *in
@@ -295,6 +978,140 @@ func (in *FederationDomainTransformsExpression) DeepCopy() *FederationDomainTran
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainWebAuthn) DeepCopyInto(out *FederationDomainWebAuthn) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainWebAuthn.
+func (in *FederationDomainWebAuthn) DeepCopy() *FederationDomainWebAuthn {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainWebAuthn)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDomainWorkloadIdentity) DeepCopyInto(out *FederationDomainWorkloadIdentity) {
+	*out = *in
+	if in.AllowedNamespaces != nil {
+		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDomainWorkloadIdentity.
+func (in *FederationDomainWorkloadIdentity) DeepCopy() *FederationDomainWorkloadIdentity {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationDomainWorkloadIdentity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ForcedReauthentication) DeepCopyInto(out *ForcedReauthentication) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForcedReauthentication.
+func (in *ForcedReauthentication) DeepCopy() *ForcedReauthentication {
+	if in == nil {
+		return nil
+	}
+	out := new(ForcedReauthentication)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ForcedReauthentication) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ForcedReauthenticationList) DeepCopyInto(out *ForcedReauthenticationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ForcedReauthentication, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForcedReauthenticationList.
+func (in *ForcedReauthenticationList) DeepCopy() *ForcedReauthenticationList {
+	if in == nil {
+		return nil
+	}
+	out := new(ForcedReauthenticationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ForcedReauthenticationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ForcedReauthenticationSpec) DeepCopyInto(out *ForcedReauthenticationSpec) {
+	*out = *in
+	out.Subject = in.Subject
+	if in.NotBefore != nil {
+		in, out := &in.NotBefore, &out.NotBefore
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForcedReauthenticationSpec.
+func (in *ForcedReauthenticationSpec) DeepCopy() *ForcedReauthenticationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ForcedReauthenticationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ForcedReauthenticationSubject) DeepCopyInto(out *ForcedReauthenticationSubject) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForcedReauthenticationSubject.
+func (in *ForcedReauthenticationSubject) DeepCopy() *ForcedReauthenticationSubject {
+	if in == nil {
+		return nil
+	}
+	out := new(ForcedReauthenticationSubject)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OIDCClient) DeepCopyInto(out *OIDCClient) {
 	*out = *in
@@ -323,6 +1140,27 @@ func (in *OIDCClient) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCClientDelegation) DeepCopyInto(out *OIDCClientDelegation) {
+	*out = *in
+	if in.AllowedActors != nil {
+		in, out := &in.AllowedActors, &out.AllowedActors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCClientDelegation.
+func (in *OIDCClientDelegation) DeepCopy() *OIDCClientDelegation {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCClientDelegation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OIDCClientList) DeepCopyInto(out *OIDCClientList) {
 	*out = *in
@@ -356,6 +1194,43 @@ func (in *OIDCClientList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCClientPrivateKeyJWT) DeepCopyInto(out *OIDCClientPrivateKeyJWT) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCClientPrivateKeyJWT.
+func (in *OIDCClientPrivateKeyJWT) DeepCopy() *OIDCClientPrivateKeyJWT {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCClientPrivateKeyJWT)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCClientScopePolicy) DeepCopyInto(out *OIDCClientScopePolicy) {
+	*out = *in
+	if in.RequiredGroups != nil {
+		in, out := &in.RequiredGroups, &out.RequiredGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCClientScopePolicy.
+func (in *OIDCClientScopePolicy) DeepCopy() *OIDCClientScopePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCClientScopePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OIDCClientSpec) DeepCopyInto(out *OIDCClientSpec) {
 	*out = *in
@@ -374,6 +1249,33 @@ func (in *OIDCClientSpec) DeepCopyInto(out *OIDCClientSpec) {
 		*out = make([]Scope, len(*in))
 		copy(*out, *in)
 	}
+	if in.ScopePolicies != nil {
+		in, out := &in.ScopePolicies, &out.ScopePolicies
+		*out = make([]OIDCClientScopePolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RequiredACRValues != nil {
+		in, out := &in.RequiredACRValues, &out.RequiredACRValues
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedResources != nil {
+		in, out := &in.AllowedResources, &out.AllowedResources
+		*out = make([]ResourceURI, len(*in))
+		copy(*out, *in)
+	}
+	if in.PrivateKeyJWT != nil {
+		in, out := &in.PrivateKeyJWT, &out.PrivateKeyJWT
+		*out = new(OIDCClientPrivateKeyJWT)
+		**out = **in
+	}
+	if in.Delegation != nil {
+		in, out := &in.Delegation, &out.Delegation
+		*out = new(OIDCClientDelegation)
+		(*in).DeepCopyInto(*out)
+	}
 	in.TokenLifetimes.DeepCopyInto(&out.TokenLifetimes)
 	return
 }
@@ -398,6 +1300,10 @@ func (in *OIDCClientStatus) DeepCopyInto(out *OIDCClientStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.LastSuccessfulAuthTimestamp != nil {
+		in, out := &in.LastSuccessfulAuthTimestamp, &out.LastSuccessfulAuthTimestamp
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -188,6 +188,11 @@ type ActiveDirectoryIdentityProviderSpec struct {
 
 	// GroupSearch contains the configuration for searching for a user's group membership in ActiveDirectory.
 	GroupSearch ActiveDirectoryIdentityProviderGroupSearch `json:"groupSearch,omitempty"`
+
+	// SecondFactor optionally requires users to provide a second factor in addition to their password when they log in
+	// using a web browser. When configured, logins using the CLI-based password flow are not allowed.
+	// +optional
+	SecondFactor *SecondFactorSpec `json:"secondFactor,omitempty"`
 }
 
 // ActiveDirectoryIdentityProvider describes the configuration of an upstream Microsoft Active Directory identity provider.
@@ -166,6 +166,11 @@ type LDAPIdentityProviderSpec struct {
 	// TLS contains the connection settings for how to establish the connection to the Host.
 	TLS *TLSSpec `json:"tls,omitempty"`
 
+	// Proxy configures the outbound proxy used to connect to the Host. When omitted, connections are made
+	// directly to the Host.
+	// +optional
+	Proxy *ProxySpec `json:"proxy,omitempty"`
+
 	// Bind contains the configuration for how to provide access credentials during an initial bind to the LDAP server
 	// to be allowed to perform searches and binds to validate a user's credentials during a user's authentication attempt.
 	Bind LDAPIdentityProviderBind `json:"bind,omitempty"`
@@ -175,6 +180,11 @@ type LDAPIdentityProviderSpec struct {
 
 	// GroupSearch contains the configuration for searching for a user's group membership in the LDAP provider.
 	GroupSearch LDAPIdentityProviderGroupSearch `json:"groupSearch,omitempty"`
+
+	// SecondFactor optionally requires users to provide a second factor in addition to their password when they log in
+	// using a web browser. When configured, logins using the CLI-based password flow are not allowed.
+	// +optional
+	SecondFactor *SecondFactorSpec `json:"secondFactor,omitempty"`
 }
 
 // LDAPIdentityProvider describes the configuration of an upstream Lightweight Directory Access
@@ -134,6 +134,11 @@ func (in *ActiveDirectoryIdentityProviderSpec) DeepCopyInto(out *ActiveDirectory
 	out.Bind = in.Bind
 	out.UserSearch = in.UserSearch
 	out.GroupSearch = in.GroupSearch
+	if in.SecondFactor != nil {
+		in, out := &in.SecondFactor, &out.SecondFactor
+		*out = new(SecondFactorSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -203,6 +208,139 @@ func (in *ActiveDirectoryIdentityProviderUserSearchAttributes) DeepCopy() *Activ
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientCertificateClaims) DeepCopyInto(out *ClientCertificateClaims) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientCertificateClaims.
+func (in *ClientCertificateClaims) DeepCopy() *ClientCertificateClaims {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientCertificateClaims)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientCertificateIdentityProvider) DeepCopyInto(out *ClientCertificateIdentityProvider) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientCertificateIdentityProvider.
+func (in *ClientCertificateIdentityProvider) DeepCopy() *ClientCertificateIdentityProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientCertificateIdentityProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClientCertificateIdentityProvider) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientCertificateIdentityProviderList) DeepCopyInto(out *ClientCertificateIdentityProviderList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClientCertificateIdentityProvider, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientCertificateIdentityProviderList.
+func (in *ClientCertificateIdentityProviderList) DeepCopy() *ClientCertificateIdentityProviderList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientCertificateIdentityProviderList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClientCertificateIdentityProviderList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientCertificateIdentityProviderSpec) DeepCopyInto(out *ClientCertificateIdentityProviderSpec) {
+	*out = *in
+	out.Claims = in.Claims
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientCertificateIdentityProviderSpec.
+func (in *ClientCertificateIdentityProviderSpec) DeepCopy() *ClientCertificateIdentityProviderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientCertificateIdentityProviderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientCertificateIdentityProviderStatus) DeepCopyInto(out *ClientCertificateIdentityProviderStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientCertificateIdentityProviderStatus.
+func (in *ClientCertificateIdentityProviderStatus) DeepCopy() *ClientCertificateIdentityProviderStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientCertificateIdentityProviderStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientSecretRef) DeepCopyInto(out *ClientSecretRef) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientSecretRef.
+func (in *ClientSecretRef) DeepCopy() *ClientSecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientSecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitHubAPIConfig) DeepCopyInto(out *GitHubAPIConfig) {
 	*out = *in
@@ -216,6 +354,16 @@ func (in *GitHubAPIConfig) DeepCopyInto(out *GitHubAPIConfig) {
 		*out = new(TLSSpec)
 		**out = **in
 	}
+	if in.ConnectionPool != nil {
+		in, out := &in.ConnectionPool, &out.ConnectionPool
+		*out = new(HTTPConnectionPoolSpec)
+		**out = **in
+	}
+	if in.Proxy != nil {
+		in, out := &in.Proxy, &out.Proxy
+		*out = new(ProxySpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -275,6 +423,11 @@ func (in *GitHubClaims) DeepCopy() *GitHubClaims {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitHubClientSpec) DeepCopyInto(out *GitHubClientSpec) {
 	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(ClientSecretRef)
+		**out = **in
+	}
 	return
 }
 
@@ -355,7 +508,7 @@ func (in *GitHubIdentityProviderSpec) DeepCopyInto(out *GitHubIdentityProviderSp
 	in.GitHubAPI.DeepCopyInto(&out.GitHubAPI)
 	in.Claims.DeepCopyInto(&out.Claims)
 	in.AllowAuthentication.DeepCopyInto(&out.AllowAuthentication)
-	out.Client = in.Client
+	in.Client.DeepCopyInto(&out.Client)
 	return
 }
 
@@ -418,6 +571,22 @@ func (in *GitHubOrganizationsSpec) DeepCopy() *GitHubOrganizationsSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPConnectionPoolSpec) DeepCopyInto(out *HTTPConnectionPoolSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPConnectionPoolSpec.
+func (in *HTTPConnectionPoolSpec) DeepCopy() *HTTPConnectionPoolSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPConnectionPoolSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LDAPIdentityProvider) DeepCopyInto(out *LDAPIdentityProvider) {
 	*out = *in
@@ -536,9 +705,19 @@ func (in *LDAPIdentityProviderSpec) DeepCopyInto(out *LDAPIdentityProviderSpec)
 		*out = new(TLSSpec)
 		**out = **in
 	}
+	if in.Proxy != nil {
+		in, out := &in.Proxy, &out.Proxy
+		*out = new(ProxySpec)
+		(*in).DeepCopyInto(*out)
+	}
 	out.Bind = in.Bind
 	out.UserSearch = in.UserSearch
 	out.GroupSearch = in.GroupSearch
+	if in.SecondFactor != nil {
+		in, out := &in.SecondFactor, &out.SecondFactor
+		*out = new(SecondFactorSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -608,6 +787,123 @@ func (in *LDAPIdentityProviderUserSearchAttributes) DeepCopy() *LDAPIdentityProv
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MockIdentityProvider) DeepCopyInto(out *MockIdentityProvider) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MockIdentityProvider.
+func (in *MockIdentityProvider) DeepCopy() *MockIdentityProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(MockIdentityProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MockIdentityProvider) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MockIdentityProviderList) DeepCopyInto(out *MockIdentityProviderList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MockIdentityProvider, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MockIdentityProviderList.
+func (in *MockIdentityProviderList) DeepCopy() *MockIdentityProviderList {
+	if in == nil {
+		return nil
+	}
+	out := new(MockIdentityProviderList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MockIdentityProviderList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MockIdentityProviderSpec) DeepCopyInto(out *MockIdentityProviderSpec) {
+	*out = *in
+	out.Users = in.Users
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MockIdentityProviderSpec.
+func (in *MockIdentityProviderSpec) DeepCopy() *MockIdentityProviderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MockIdentityProviderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MockIdentityProviderStatus) DeepCopyInto(out *MockIdentityProviderStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MockIdentityProviderStatus.
+func (in *MockIdentityProviderStatus) DeepCopy() *MockIdentityProviderStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MockIdentityProviderStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MockIdentityProviderUsersSpec) DeepCopyInto(out *MockIdentityProviderUsersSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MockIdentityProviderUsersSpec.
+func (in *MockIdentityProviderUsersSpec) DeepCopy() *MockIdentityProviderUsersSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MockIdentityProviderUsersSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OIDCAuthorizationConfig) DeepCopyInto(out *OIDCAuthorizationConfig) {
 	*out = *in
@@ -660,6 +956,11 @@ func (in *OIDCClaims) DeepCopy() *OIDCClaims {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OIDCClient) DeepCopyInto(out *OIDCClient) {
 	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(ClientSecretRef)
+		**out = **in
+	}
 	return
 }
 
@@ -673,6 +974,48 @@ func (in *OIDCClient) DeepCopy() *OIDCClient {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCGoogleWorkspaceGroupsLookup) DeepCopyInto(out *OIDCGoogleWorkspaceGroupsLookup) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCGoogleWorkspaceGroupsLookup.
+func (in *OIDCGoogleWorkspaceGroupsLookup) DeepCopy() *OIDCGoogleWorkspaceGroupsLookup {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCGoogleWorkspaceGroupsLookup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCGroupsLookup) DeepCopyInto(out *OIDCGroupsLookup) {
+	*out = *in
+	if in.MicrosoftGraph != nil {
+		in, out := &in.MicrosoftGraph, &out.MicrosoftGraph
+		*out = new(OIDCMicrosoftGraphGroupsLookup)
+		**out = **in
+	}
+	if in.GoogleWorkspace != nil {
+		in, out := &in.GoogleWorkspace, &out.GoogleWorkspace
+		*out = new(OIDCGoogleWorkspaceGroupsLookup)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCGroupsLookup.
+func (in *OIDCGroupsLookup) DeepCopy() *OIDCGroupsLookup {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCGroupsLookup)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OIDCIdentityProvider) DeepCopyInto(out *OIDCIdentityProvider) {
 	*out = *in
@@ -742,9 +1085,24 @@ func (in *OIDCIdentityProviderSpec) DeepCopyInto(out *OIDCIdentityProviderSpec)
 		*out = new(TLSSpec)
 		**out = **in
 	}
+	if in.ConnectionPool != nil {
+		in, out := &in.ConnectionPool, &out.ConnectionPool
+		*out = new(HTTPConnectionPoolSpec)
+		**out = **in
+	}
+	if in.Proxy != nil {
+		in, out := &in.Proxy, &out.Proxy
+		*out = new(ProxySpec)
+		(*in).DeepCopyInto(*out)
+	}
 	in.AuthorizationConfig.DeepCopyInto(&out.AuthorizationConfig)
 	in.Claims.DeepCopyInto(&out.Claims)
-	out.Client = in.Client
+	if in.GroupsLookup != nil {
+		in, out := &in.GroupsLookup, &out.GroupsLookup
+		*out = new(OIDCGroupsLookup)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Client.DeepCopyInto(&out.Client)
 	return
 }
 
@@ -781,6 +1139,200 @@ func (in *OIDCIdentityProviderStatus) DeepCopy() *OIDCIdentityProviderStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCMicrosoftGraphGroupsLookup) DeepCopyInto(out *OIDCMicrosoftGraphGroupsLookup) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCMicrosoftGraphGroupsLookup.
+func (in *OIDCMicrosoftGraphGroupsLookup) DeepCopy() *OIDCMicrosoftGraphGroupsLookup {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCMicrosoftGraphGroupsLookup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenShiftAPIServerSpec) DeepCopyInto(out *OpenShiftAPIServerSpec) {
+	*out = *in
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(TLSSpec)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenShiftAPIServerSpec.
+func (in *OpenShiftAPIServerSpec) DeepCopy() *OpenShiftAPIServerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenShiftAPIServerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenShiftClaims) DeepCopyInto(out *OpenShiftClaims) {
+	*out = *in
+	if in.Username != nil {
+		in, out := &in.Username, &out.Username
+		*out = new(OpenShiftUsernameAttribute)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenShiftClaims.
+func (in *OpenShiftClaims) DeepCopy() *OpenShiftClaims {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenShiftClaims)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenShiftClientSpec) DeepCopyInto(out *OpenShiftClientSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenShiftClientSpec.
+func (in *OpenShiftClientSpec) DeepCopy() *OpenShiftClientSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenShiftClientSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenShiftGroupsSpec) DeepCopyInto(out *OpenShiftGroupsSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenShiftGroupsSpec.
+func (in *OpenShiftGroupsSpec) DeepCopy() *OpenShiftGroupsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenShiftGroupsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenShiftIdentityProvider) DeepCopyInto(out *OpenShiftIdentityProvider) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenShiftIdentityProvider.
+func (in *OpenShiftIdentityProvider) DeepCopy() *OpenShiftIdentityProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenShiftIdentityProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OpenShiftIdentityProvider) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenShiftIdentityProviderList) DeepCopyInto(out *OpenShiftIdentityProviderList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]OpenShiftIdentityProvider, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenShiftIdentityProviderList.
+func (in *OpenShiftIdentityProviderList) DeepCopy() *OpenShiftIdentityProviderList {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenShiftIdentityProviderList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OpenShiftIdentityProviderList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenShiftIdentityProviderSpec) DeepCopyInto(out *OpenShiftIdentityProviderSpec) {
+	*out = *in
+	in.APIServer.DeepCopyInto(&out.APIServer)
+	in.Claims.DeepCopyInto(&out.Claims)
+	out.Groups = in.Groups
+	out.Client = in.Client
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenShiftIdentityProviderSpec.
+func (in *OpenShiftIdentityProviderSpec) DeepCopy() *OpenShiftIdentityProviderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenShiftIdentityProviderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenShiftIdentityProviderStatus) DeepCopyInto(out *OpenShiftIdentityProviderStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenShiftIdentityProviderStatus.
+func (in *OpenShiftIdentityProviderStatus) DeepCopy() *OpenShiftIdentityProviderStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenShiftIdentityProviderStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Parameter) DeepCopyInto(out *Parameter) {
 	*out = *in
@@ -797,6 +1349,48 @@ func (in *Parameter) DeepCopy() *Parameter {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxySpec) DeepCopyInto(out *ProxySpec) {
+	*out = *in
+	if in.NoProxy != nil {
+		in, out := &in.NoProxy, &out.NoProxy
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxySpec.
+func (in *ProxySpec) DeepCopy() *ProxySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecondFactorSpec) DeepCopyInto(out *SecondFactorSpec) {
+	*out = *in
+	if in.TOTP != nil {
+		in, out := &in.TOTP, &out.TOTP
+		*out = new(TOTPSpec)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecondFactorSpec.
+func (in *SecondFactorSpec) DeepCopy() *SecondFactorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SecondFactorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TLSSpec) DeepCopyInto(out *TLSSpec) {
 	*out = *in
@@ -812,3 +1406,19 @@ func (in *TLSSpec) DeepCopy() *TLSSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TOTPSpec) DeepCopyInto(out *TOTPSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TOTPSpec.
+func (in *TOTPSpec) DeepCopy() *TOTPSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TOTPSpec)
+	in.DeepCopyInto(out)
+	return out
+}
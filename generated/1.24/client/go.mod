@@ -10,4 +10,5 @@ require (
 	k8s.io/apimachinery v0.24.17
 	k8s.io/client-go v0.24.17
 	k8s.io/kube-openapi v0.0.0-20220328201542-3ee0da9b0b42
+	sigs.k8s.io/structured-merge-diff/v4 v4.2.3
 )
@@ -5,6 +5,10 @@
 
 package v1alpha1
 
+type ClusterAudienceExpansion interface{}
+
 type FederationDomainExpansion interface{}
 
+type ForcedReauthenticationExpansion interface{}
+
 type OIDCClientExpansion interface{}
@@ -7,8 +7,11 @@ package fake
 
 import (
 	"context"
+	json "encoding/json"
+	"fmt"
 
 	v1alpha1 "go.pinniped.dev/generated/1.24/apis/supervisor/idp/v1alpha1"
+	idpv1alpha1 "go.pinniped.dev/generated/1.24/client/supervisor/applyconfiguration/idp/v1alpha1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	labels "k8s.io/apimachinery/pkg/labels"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
@@ -127,3 +130,48 @@ func (c *FakeGitHubIdentityProviders) Patch(ctx context.Context, name string, pt
 	}
 	return obj.(*v1alpha1.GitHubIdentityProvider), err
 }
+
+// Apply takes the given apply declarative configuration, applies it and returns the applied gitHubIdentityProvider.
+func (c *FakeGitHubIdentityProviders) Apply(ctx context.Context, gitHubIdentityProvider *idpv1alpha1.GitHubIdentityProviderApplyConfiguration, opts v1.ApplyOptions) (result *v1alpha1.GitHubIdentityProvider, err error) {
+	if gitHubIdentityProvider == nil {
+		return nil, fmt.Errorf("gitHubIdentityProvider provided to Apply must not be nil")
+	}
+	data, err := json.Marshal(gitHubIdentityProvider)
+	if err != nil {
+		return nil, err
+	}
+	name := gitHubIdentityProvider.Name
+	if name == nil {
+		return nil, fmt.Errorf("gitHubIdentityProvider.Name must be provided to Apply")
+	}
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(githubidentityprovidersResource, c.ns, *name, types.ApplyPatchType, data), &v1alpha1.GitHubIdentityProvider{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.GitHubIdentityProvider), err
+}
+
+// ApplyStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating ApplyStatus().
+func (c *FakeGitHubIdentityProviders) ApplyStatus(ctx context.Context, gitHubIdentityProvider *idpv1alpha1.GitHubIdentityProviderApplyConfiguration, opts v1.ApplyOptions) (result *v1alpha1.GitHubIdentityProvider, err error) {
+	if gitHubIdentityProvider == nil {
+		return nil, fmt.Errorf("gitHubIdentityProvider provided to Apply must not be nil")
+	}
+	data, err := json.Marshal(gitHubIdentityProvider)
+	if err != nil {
+		return nil, err
+	}
+	name := gitHubIdentityProvider.Name
+	if name == nil {
+		return nil, fmt.Errorf("gitHubIdentityProvider.Name must be provided to Apply")
+	}
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(githubidentityprovidersResource, c.ns, *name, types.ApplyPatchType, data, "status"), &v1alpha1.GitHubIdentityProvider{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.GitHubIdentityProvider), err
+}
@@ -34,6 +34,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&WebhookAuthenticatorList{},
 		&JWTAuthenticator{},
 		&JWTAuthenticatorList{},
+		&ClusterTokenDenylist{},
+		&ClusterTokenDenylistList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -13,6 +13,117 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterTokenDenylist) DeepCopyInto(out *ClusterTokenDenylist) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterTokenDenylist.
+func (in *ClusterTokenDenylist) DeepCopy() *ClusterTokenDenylist {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterTokenDenylist)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterTokenDenylist) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterTokenDenylistEntry) DeepCopyInto(out *ClusterTokenDenylistEntry) {
+	*out = *in
+	if in.IssuedAfter != nil {
+		in, out := &in.IssuedAfter, &out.IssuedAfter
+		*out = (*in).DeepCopy()
+	}
+	if in.IssuedBefore != nil {
+		in, out := &in.IssuedBefore, &out.IssuedBefore
+		*out = (*in).DeepCopy()
+	}
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterTokenDenylistEntry.
+func (in *ClusterTokenDenylistEntry) DeepCopy() *ClusterTokenDenylistEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterTokenDenylistEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterTokenDenylistList) DeepCopyInto(out *ClusterTokenDenylistList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterTokenDenylist, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterTokenDenylistList.
+func (in *ClusterTokenDenylistList) DeepCopy() *ClusterTokenDenylistList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterTokenDenylistList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterTokenDenylistList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterTokenDenylistSpec) DeepCopyInto(out *ClusterTokenDenylistSpec) {
+	*out = *in
+	if in.Entries != nil {
+		in, out := &in.Entries, &out.Entries
+		*out = make([]ClusterTokenDenylistEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterTokenDenylistSpec.
+func (in *ClusterTokenDenylistSpec) DeepCopy() *ClusterTokenDenylistSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterTokenDenylistSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *JWTAuthenticator) DeepCopyInto(out *JWTAuthenticator) {
 	*out = *in
@@ -106,6 +217,10 @@ func (in *JWTAuthenticatorStatus) DeepCopyInto(out *JWTAuthenticatorStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.LastSuccessfulAuthTimestamp != nil {
+		in, out := &in.LastSuccessfulAuthTimestamp, &out.LastSuccessfulAuthTimestamp
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -243,6 +358,10 @@ func (in *WebhookAuthenticatorStatus) DeepCopyInto(out *WebhookAuthenticatorStat
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.LastSuccessfulAuthTimestamp != nil {
+		in, out := &in.LastSuccessfulAuthTimestamp, &out.LastSuccessfulAuthTimestamp
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -38,6 +38,12 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&ActiveDirectoryIdentityProviderList{},
 		&GitHubIdentityProvider{},
 		&GitHubIdentityProviderList{},
+		&ClientCertificateIdentityProvider{},
+		&ClientCertificateIdentityProviderList{},
+		&MockIdentityProvider{},
+		&MockIdentityProviderList{},
+		&OpenShiftIdentityProvider{},
+		&OpenShiftIdentityProviderList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -0,0 +1,98 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type MockIdentityProviderPhase string
+
+const (
+	// MockPhasePending is the default phase for newly-created MockIdentityProvider resources.
+	MockPhasePending MockIdentityProviderPhase = "Pending"
+
+	// MockPhaseReady is the phase for an MockIdentityProvider resource in a healthy state.
+	MockPhaseReady MockIdentityProviderPhase = "Ready"
+
+	// MockPhaseError is the phase for an MockIdentityProvider in an unhealthy state.
+	MockPhaseError MockIdentityProviderPhase = "Error"
+)
+
+// MockIdentityProviderStatus is the status of a mock identity provider.
+type MockIdentityProviderStatus struct {
+	// Phase summarizes the overall status of the MockIdentityProvider.
+	//
+	// +kubebuilder:default=Pending
+	// +kubebuilder:validation:Enum=Pending;Ready;Error
+	Phase MockIdentityProviderPhase `json:"phase,omitempty"`
+
+	// Conditions represents the observations of an identity provider's current state.
+	//
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// MockIdentityProviderUsersSpec contains information about where to find the users of a mock identity provider.
+type MockIdentityProviderUsersSpec struct {
+	// SecretName contains the name of a namespace-local Secret object that provides the static users.
+	//
+	// This secret must be of type "secrets.pinniped.dev/mock-identity-provider-users" with the key "users".
+	// The value of the "users" key must be a YAML list of users, each having a "username", a "password",
+	// and an optional list of "groups", for example:
+	//
+	//   - username: alice
+	//     password: some-password
+	//     groups: [developers, admins]
+	//
+	// The passwords are stored in plain text, which is one of the reasons that this identity provider
+	// must never be used outside of demo and test environments.
+	//
+	// +kubebuilder:validation:MinLength=1
+	SecretName string `json:"secretName"`
+}
+
+// MockIdentityProviderSpec is the spec for configuring a mock identity provider.
+type MockIdentityProviderSpec struct {
+	// Users configures where to find the static users of this identity provider.
+	Users MockIdentityProviderUsersSpec `json:"users"`
+}
+
+// MockIdentityProvider describes the configuration of an identity provider which authenticates a static list of
+// users, whose usernames, passwords, and groups are read from a Secret. The users log in using the Supervisor's
+// own login form, or by using the CLI-based password flow, so no external identity provider is required.
+//
+// This identity provider is FOR DEMO AND TEST ENVIRONMENTS ONLY. It is ignored by the Supervisor unless the
+// Supervisor's "MockIdentityProvider" feature gate is enabled.
+//
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:categories=pinniped;pinniped-idp;pinniped-idps
+// +kubebuilder:printcolumn:name="Users Secret",type=string,JSONPath=`.spec.users.secretName`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:subresource:status
+type MockIdentityProvider struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec for configuring the identity provider.
+	Spec MockIdentityProviderSpec `json:"spec"`
+
+	// Status of the identity provider.
+	Status MockIdentityProviderStatus `json:"status,omitempty"`
+}
+
+// MockIdentityProviderList lists MockIdentityProvider objects.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type MockIdentityProviderList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []MockIdentityProvider `json:"items"`
+}
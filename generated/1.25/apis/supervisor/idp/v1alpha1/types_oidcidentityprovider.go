@@ -93,6 +93,17 @@ type OIDCAuthorizationConfig struct {
 	// +listMapKey=name
 	AdditionalAuthorizeParameters []Parameter `json:"additionalAuthorizeParameters,omitempty"`
 
+	// resource is a resource indicator (see https://datatracker.ietf.org/doc/html/rfc8707) which will be sent as the
+	// "resource" parameter in the authorize request and in every token request to your OIDC provider, including the
+	// token requests of the Resource Owner Password Credentials Grant and of refreshes. Some OIDC providers, such as
+	// Microsoft Active Directory Federation Services (AD FS), require this parameter to determine which resource the
+	// issued tokens are for. It must be an absolute URI without a fragment, e.g. "urn:microsoft:userinfo" or
+	// "https://kubernetes.example.com". By default, no resource parameter is sent. When this setting is used, the
+	// "resource" parameter cannot also be included in the additionalAuthorizeParameters setting.
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z][a-zA-Z0-9+.-]*:[^#]*$`
+	// +optional
+	Resource string `json:"resource,omitempty"`
+
 	// allowPasswordGrant, when true, will allow the use of OAuth 2.0's Resource Owner Password Credentials Grant
 	// (see https://datatracker.ietf.org/doc/html/rfc6749#section-4.3) to authenticate to the OIDC provider using a
 	// username and password without a web browser, in addition to the usual browser-based OIDC Authorization Code Flow.
@@ -111,6 +122,15 @@ type OIDCAuthorizationConfig struct {
 	// allowPasswordGrant defaults to false.
 	// +optional
 	AllowPasswordGrant bool `json:"allowPasswordGrant,omitempty"`
+
+	// useJWTSecuredAuthorizationResponses, when true, will make the Supervisor request a JWT Secured Authorization Response
+	// (JARM) from your OIDC provider by sending "response_mode=query.jwt" in the authorize request. The Supervisor will then
+	// only accept authorization responses which are JWTs signed by your OIDC provider's keys, as published by its jwks_uri,
+	// and which are issued by your OIDC provider for the client ID of this OIDCIdentityProvider. Your OIDC provider must
+	// support JARM. When this setting is used, the "response_mode" parameter cannot also be included in the
+	// additionalAuthorizeParameters setting. useJWTSecuredAuthorizationResponses defaults to false.
+	// +optional
+	UseJWTSecuredAuthorizationResponses bool `json:"useJWTSecuredAuthorizationResponses,omitempty"`
 }
 
 // Parameter is a key/value pair which represents a parameter in an HTTP request.
@@ -125,6 +145,7 @@ type Parameter struct {
 }
 
 // OIDCClaims provides a mapping from upstream claims into identities.
+// +kubebuilder:validation:XValidation:message="groups must be specified when groupsExpression is specified",rule="!has(self.groupsExpression) || (has(self.groups) && size(self.groups) > 0)"
 type OIDCClaims struct {
 	// Groups provides the name of the ID token claim or userinfo endpoint response claim that will be used to ascertain
 	// the groups to which an identity belongs. By default, the identities will not include any group memberships when
@@ -132,6 +153,15 @@ type OIDCClaims struct {
 	// +optional
 	Groups string `json:"groups"`
 
+	// GroupsExpression is an optional CEL expression which computes the groups to which an identity belongs from the
+	// claims of the ID token and the userinfo endpoint response, which are available as the map variable "claims".
+	// It must return a list of strings. Use it to extract and flatten nested claims, e.g. the client roles of Keycloak
+	// with `claims.resource_access.kubernetes.roles`. Use has() to handle claims which may be absent, e.g.
+	// `has(claims.resource_access) ? claims.resource_access.kubernetes.roles : []`. The result replaces the value of
+	// the claim which is named by groups, so groups must also be specified.
+	// +optional
+	GroupsExpression string `json:"groupsExpression,omitempty"`
+
 	// Username provides the name of the ID token claim or userinfo endpoint response claim that will be used to
 	// ascertain an identity's username. When not set, the username will be an automatically constructed unique string
 	// which will include the issuer URL of your OIDC provider along with the value of the "sub" (subject) claim from
@@ -152,27 +182,142 @@ type OIDCClaims struct {
 }
 
 // OIDCClient contains information about an OIDC client (e.g., client ID and client
-// secret).
+// secret). Exactly one of secretName or secretRef must be specified.
+// +kubebuilder:validation:XValidation:message="exactly one of spec.client.secretName or spec.client.secretRef must be specified",rule="has(self.secretName) != has(self.secretRef)"
 type OIDCClient struct {
 	// SecretName contains the name of a namespace-local Secret object that provides the clientID and
 	// clientSecret for an OIDC client. If only the SecretName is specified in an OIDCClient
 	// struct, then it is expected that the Secret is of type "secrets.pinniped.dev/oidc-client" with keys
 	// "clientID" and "clientSecret".
+	//
+	// To rotate the client secret without downtime, the Secret may also contain the key "clientSecretNext".
+	// When the OIDC identity provider rejects one of the client secrets, the Supervisor tries the other one.
+	// The ClientCredentialsSecretValid status condition reports which of the client secrets is active.
+	// Once the new client secret is active, move it to "clientSecret" and remove "clientSecretNext".
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// SecretRef references a clientID and clientSecret which are stored outside of Kubernetes Secrets.
+	// +optional
+	SecretRef *ClientSecretRef `json:"secretRef,omitempty"`
+
+	// CertificateSecretName contains the name of a namespace-local Secret object of type "kubernetes.io/tls"
+	// with the keys "tls.crt" and "tls.key". When specified, the Supervisor presents this client certificate
+	// in the TLS handshake of every request to the OIDC identity provider, for providers which require mutual
+	// TLS client authentication or certificate-bound access tokens (RFC 8705). When the discovery document
+	// of the provider advertises mtls_endpoint_aliases, the aliased token and revocation endpoints are used.
+	// +optional
+	CertificateSecretName string `json:"certificateSecretName,omitempty"`
+}
+
+// OIDCGroupsLookup configures looking up the group memberships of users in a directory, for OIDC identity providers
+// which do not always include all of them in the ID token. The groups which are looked up become the value of the
+// claim which is named by spec.claims.groups, so spec.claims.groups must be specified.
+// Exactly one of microsoftGraph or googleWorkspace must be specified.
+// +kubebuilder:validation:XValidation:message="exactly one of microsoftGraph or googleWorkspace must be specified",rule="has(self.microsoftGraph) != has(self.googleWorkspace)"
+type OIDCGroupsLookup struct {
+	// MicrosoftGraph looks up the group memberships of Microsoft Entra ID (formerly Azure AD) users using the
+	// Microsoft Graph API whenever the ID token contains a groups overage indicator instead of the groups claim.
+	// Entra ID leaves the groups claim out of the ID token when the user is a member of more than 200 groups.
+	// +optional
+	MicrosoftGraph *OIDCMicrosoftGraphGroupsLookup `json:"microsoftGraph,omitempty"`
+
+	// GoogleWorkspace looks up the group memberships of Google Workspace users using the Google Workspace Admin SDK
+	// Directory API. Google never includes group memberships in ID tokens. Users whose ID token does not contain the
+	// "hd" (hosted domain) claim, e.g. users of personal Google accounts, do not have any groups.
+	// +optional
+	GoogleWorkspace *OIDCGoogleWorkspaceGroupsLookup `json:"googleWorkspace,omitempty"`
+}
+
+// OIDCGoogleWorkspaceGroupsLookup configures how to look up the group memberships of Google Workspace users
+// using the Directory API.
+type OIDCGoogleWorkspaceGroupsLookup struct {
+	// SecretName contains the name of a namespace-local Secret object of type
+	// "secrets.pinniped.dev/google-service-account" with the key "serviceAccountKey", which contains the JSON key of
+	// a Google Cloud service account. The service account must be granted domain-wide delegation in the Google
+	// Workspace Admin console for the scope "https://www.googleapis.com/auth/admin.directory.group.readonly".
+	// +kubebuilder:validation:MinLength=1
+	SecretName string `json:"secretName"`
+
+	// AdminEmail is the email address of a Google Workspace user which the service account impersonates to call the
+	// Directory API. The user must be allowed to read the groups of all users, e.g. by having the Groups Reader admin role.
+	// +kubebuilder:validation:MinLength=1
+	AdminEmail string `json:"adminEmail"`
+
+	// GroupsAttribute is the attribute of the groups which is used as the name of the groups.
+	// Defaults to "email". Allowed values are "email" and "name".
+	// +kubebuilder:default=email
+	// +kubebuilder:validation:Enum=email;name
+	// +optional
+	GroupsAttribute GoogleWorkspaceGroupsAttribute `json:"groupsAttribute,omitempty"`
+}
+
+// GoogleWorkspaceGroupsAttribute is the attribute of Google Workspace groups which is used as the name of the groups.
+type GoogleWorkspaceGroupsAttribute string
+
+const (
+	// GoogleWorkspaceGroupsEmail uses the email address of the groups, e.g. "engineering@example.com".
+	GoogleWorkspaceGroupsEmail GoogleWorkspaceGroupsAttribute = "email"
+
+	// GoogleWorkspaceGroupsName uses the display name of the groups, e.g. "Engineering".
+	GoogleWorkspaceGroupsName GoogleWorkspaceGroupsAttribute = "name"
+)
+
+// OIDCMicrosoftGraphGroupsLookup configures how to look up the group memberships of Microsoft Entra ID users
+// using the Microsoft Graph API.
+type OIDCMicrosoftGraphGroupsLookup struct {
+	// SecretName contains the name of a namespace-local Secret object of type
+	// "secrets.pinniped.dev/microsoft-graph-client" with the keys "clientID" and "clientSecret". They are the
+	// credentials of an app registration which has been granted the GroupMember.Read.All application permission
+	// of the Microsoft Graph API in the tenant of the users. This may be the same app registration as spec.client.
+	// +kubebuilder:validation:MinLength=1
 	SecretName string `json:"secretName"`
+
+	// URL of the Microsoft Graph API. Defaults to "https://graph.microsoft.com". Change it only for
+	// national clouds, e.g. "https://graph.microsoft.us".
+	// +kubebuilder:default="https://graph.microsoft.com"
+	// +kubebuilder:validation:Pattern=`^https://`
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// AuthorityURL is the URL of the Microsoft identity platform from which the Supervisor gets access tokens for
+	// the Microsoft Graph API, using the client credentials grant in the tenant of the user. Defaults to
+	// "https://login.microsoftonline.com". Change it only for national clouds, e.g. "https://login.microsoftonline.us".
+	// +kubebuilder:default="https://login.microsoftonline.com"
+	// +kubebuilder:validation:Pattern=`^https://`
+	// +optional
+	AuthorityURL string `json:"authorityURL,omitempty"`
+
+	// SecurityEnabledOnly, when true, only looks up the security groups of the user. Set it to true when the
+	// groups claim of the app registration of spec.client is configured to include only security groups, so that
+	// users get the same groups regardless of how many groups they are a member of.
+	// +optional
+	SecurityEnabledOnly bool `json:"securityEnabledOnly,omitempty"`
 }
 
 // OIDCIdentityProviderSpec is the spec for configuring an OIDC identity provider.
+// +kubebuilder:validation:XValidation:message="spec.claims.groups must be specified when spec.groupsLookup is specified",rule="!has(self.groupsLookup) || (has(self.claims) && has(self.claims.groups) && size(self.claims.groups) > 0)"
 type OIDCIdentityProviderSpec struct {
 	// Issuer is the issuer URL of this OIDC identity provider, i.e., where to fetch
 	// /.well-known/openid-configuration.
 	// +kubebuilder:validation:MinLength=1
 	// +kubebuilder:validation:Pattern=`^https://`
+	// +kubebuilder:validation:XValidation:message="issuer must not have query or fragment",rule="!self.contains('?') && !self.contains('#')"
 	Issuer string `json:"issuer"`
 
 	// TLS configuration for discovery/JWKS requests to the issuer.
 	// +optional
 	TLS *TLSSpec `json:"tls,omitempty"`
 
+	// ConnectionPool configures the pool of HTTP connections used to make requests to the issuer.
+	// +optional
+	ConnectionPool *HTTPConnectionPoolSpec `json:"connectionPool,omitempty"`
+
+	// Proxy configures the outbound proxy used to make requests to the issuer. When omitted, the proxy
+	// environment variables of the Supervisor pods are used.
+	// +optional
+	Proxy *ProxySpec `json:"proxy,omitempty"`
+
 	// AuthorizationConfig holds information about how to form the OAuth2 authorization request
 	// parameters to be used with this OIDC identity provider.
 	// +optional
@@ -183,6 +328,11 @@ type OIDCIdentityProviderSpec struct {
 	// +optional
 	Claims OIDCClaims `json:"claims"`
 
+	// GroupsLookup optionally configures looking up the group memberships of users in a directory, for when
+	// the OIDC identity provider does not include all of them in the ID token.
+	// +optional
+	GroupsLookup *OIDCGroupsLookup `json:"groupsLookup,omitempty"`
+
 	// OIDCClient contains OIDC client information to be used used with this OIDC identity
 	// provider.
 	Client OIDCClient `json:"client"`
@@ -0,0 +1,180 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type OpenShiftIdentityProviderPhase string
+
+const (
+	// OpenShiftPhasePending is the default phase for newly-created OpenShiftIdentityProvider resources.
+	OpenShiftPhasePending OpenShiftIdentityProviderPhase = "Pending"
+
+	// OpenShiftPhaseReady is the phase for an OpenShiftIdentityProvider resource in a healthy state.
+	OpenShiftPhaseReady OpenShiftIdentityProviderPhase = "Ready"
+
+	// OpenShiftPhaseError is the phase for an OpenShiftIdentityProvider in an unhealthy state.
+	OpenShiftPhaseError OpenShiftIdentityProviderPhase = "Error"
+)
+
+// OpenShiftIdentityProviderStatus is the status of an OpenShift identity provider.
+type OpenShiftIdentityProviderStatus struct {
+	// Phase summarizes the overall status of the OpenShiftIdentityProvider.
+	//
+	// +kubebuilder:default=Pending
+	// +kubebuilder:validation:Enum=Pending;Ready;Error
+	Phase OpenShiftIdentityProviderPhase `json:"phase,omitempty"`
+
+	// Conditions represents the observations of an identity provider's current state.
+	//
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// OpenShiftAPIServerSpec describes how to connect to the API server of an OpenShift cluster.
+type OpenShiftAPIServerSpec struct {
+	// URL of the Kubernetes API server of the OpenShift cluster, e.g. "https://api.my-cluster.example.com:6443".
+	//
+	// The endpoints of the internal OAuth server of the cluster are discovered from the
+	// "/.well-known/oauth-authorization-server" endpoint of the API server. Note that the issuer of that
+	// OAuth server is usually the URL of the "oauth-openshift" route of the cluster, not the URL of the API server,
+	// so the issuer is not required to match this URL.
+	//
+	// The username and groups of the user are read from the "/apis/user.openshift.io/v1/users/~" endpoint of
+	// the API server, using the access token which was issued to the user by the OAuth server.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern=`^https://`
+	URL string `json:"url"`
+
+	// TLS configuration for the API server and the OAuth server of the OpenShift cluster.
+	//
+	// +optional
+	TLS *TLSSpec `json:"tls,omitempty"`
+}
+
+// OpenShiftUsernameAttribute allows the user to specify which attribute(s) of the OpenShift user to use for the
+// username to present to Kubernetes.
+type OpenShiftUsernameAttribute string
+
+const (
+	// OpenShiftUsernameName specifies using the name of the OpenShift user as the username to present to Kubernetes.
+	OpenShiftUsernameName OpenShiftUsernameAttribute = "name"
+
+	// OpenShiftUsernameUID specifies using the UID of the OpenShift user as the username to present to Kubernetes.
+	OpenShiftUsernameUID OpenShiftUsernameAttribute = "uid"
+
+	// OpenShiftUsernameNameAndUID specifies combining the name and the UID of the OpenShift user as the
+	// username to present to Kubernetes, separated by a colon. Example: "my-name:0b9a8b5f-d4c6-4a5e-9e6f-5bd0e4b3a6d1"
+	OpenShiftUsernameNameAndUID OpenShiftUsernameAttribute = "name:uid"
+)
+
+// OpenShiftClaims allows customization of the username claim.
+type OpenShiftClaims struct {
+	// Username configures which property of the OpenShift user shall determine the username in Kubernetes.
+	//
+	// Can be either "name", "uid", or "name:uid". Defaults to "name", which matches the username of the user
+	// within the OpenShift cluster itself, so that RBAC policies can be shared between OpenShift clusters and
+	// the other clusters of the fleet.
+	//
+	// When an OpenShift user is deleted, then a new user with the same name may be created later, so it is not as
+	// safe to make authorization decisions based only on the name of the user. The UID of a user is never reused.
+	//
+	// +kubebuilder:default="name"
+	// +kubebuilder:validation:Enum={"name","uid","name:uid"}
+	// +optional
+	Username *OpenShiftUsernameAttribute `json:"username"`
+}
+
+// OpenShiftGroupsSpec allows customization of the group memberships which are presented to Kubernetes.
+type OpenShiftGroupsSpec struct {
+	// IncludeSystemGroups, when true, also presents the virtual groups of the OpenShift cluster whose names start with
+	// "system:", such as "system:authenticated:oauth", to Kubernetes. These groups are usually only meaningful within
+	// the OpenShift cluster itself, and the other clusters of the fleet may give special meaning to some of them, so
+	// they are not included by default.
+	//
+	// +optional
+	IncludeSystemGroups bool `json:"includeSystemGroups,omitempty"`
+}
+
+// OpenShiftClientSpec contains information about the OAuthClient of the OpenShift cluster that this identity provider
+// will use for web-based login flows.
+type OpenShiftClientSpec struct {
+	// SecretName contains the name of a namespace-local Secret object that provides the clientID and
+	// clientSecret of an OAuthClient of the OpenShift cluster. The clientID is the name of the OAuthClient.
+	// The redirectURIs of the OAuthClient must allow the callback endpoints of the FederationDomains which use this
+	// identity provider.
+	//
+	// This secret must be of type "secrets.pinniped.dev/openshift-client" with keys "clientID" and "clientSecret".
+	//
+	// +kubebuilder:validation:MinLength=1
+	SecretName string `json:"secretName"`
+}
+
+// OpenShiftIdentityProviderSpec is the spec for configuring an OpenShift identity provider.
+type OpenShiftIdentityProviderSpec struct {
+	// APIServer describes how to connect to the API server of the OpenShift cluster.
+	APIServer OpenShiftAPIServerSpec `json:"apiServer"`
+
+	// Claims allows customization of the username claim.
+	//
+	// +kubebuilder:default={}
+	Claims OpenShiftClaims `json:"claims,omitempty"`
+
+	// Groups allows customization of the group memberships which are presented to Kubernetes. The groups of a user
+	// are the OpenShift groups which the user belongs to, including those which are kept in sync with an LDAP
+	// directory by "oc adm groups sync", and they are read again from the OpenShift cluster during each refresh
+	// of the user's session.
+	//
+	// +optional
+	Groups OpenShiftGroupsSpec `json:"groups,omitempty"`
+
+	// Client identifies the secret with credentials for an OAuthClient of the OpenShift cluster.
+	Client OpenShiftClientSpec `json:"client"`
+}
+
+// OpenShiftIdentityProvider describes the configuration of an upstream OpenShift identity provider, which is the
+// internal OAuth server of an OpenShift cluster. This allows fleets of OpenShift and other Kubernetes clusters to
+// share the identities which are already configured in an OpenShift cluster.
+//
+// The internal OAuth server of OpenShift does not implement OpenID Connect, so it cannot be configured as an
+// OIDCIdentityProvider. It does not issue refresh tokens either, so the access token which is issued by the OAuth
+// server is used again to read the user's identity and groups during each refresh of the user's session, until that
+// access token expires. After that, the user must log in again.
+//
+// Right now, only web-based logins are supported, for both the pinniped-cli client and clients configured
+// as OIDCClients.
+//
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:categories=pinniped;pinniped-idp;pinniped-idps
+// +kubebuilder:printcolumn:name="API Server",type=string,JSONPath=`.spec.apiServer.url`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:subresource:status
+type OpenShiftIdentityProvider struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec for configuring the identity provider.
+	Spec OpenShiftIdentityProviderSpec `json:"spec"`
+
+	// Status of the identity provider.
+	Status OpenShiftIdentityProviderStatus `json:"status,omitempty"`
+}
+
+// OpenShiftIdentityProviderList lists OpenShiftIdentityProvider objects.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type OpenShiftIdentityProviderList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []OpenShiftIdentityProvider `json:"items"`
+}
@@ -11,6 +11,8 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// ClusterTokenDenylists returns a ClusterTokenDenylistInformer.
+	ClusterTokenDenylists() ClusterTokenDenylistInformer
 	// JWTAuthenticators returns a JWTAuthenticatorInformer.
 	JWTAuthenticators() JWTAuthenticatorInformer
 	// WebhookAuthenticators returns a WebhookAuthenticatorInformer.
@@ -28,6 +30,11 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// ClusterTokenDenylists returns a ClusterTokenDenylistInformer.
+func (v *version) ClusterTokenDenylists() ClusterTokenDenylistInformer {
+	return &clusterTokenDenylistInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // JWTAuthenticators returns a JWTAuthenticatorInformer.
 func (v *version) JWTAuthenticators() JWTAuthenticatorInformer {
 	return &jWTAuthenticatorInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
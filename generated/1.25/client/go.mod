@@ -10,4 +10,5 @@ require (
 	k8s.io/apimachinery v0.25.16
 	k8s.io/client-go v0.25.16
 	k8s.io/kube-openapi v0.0.0-20220803162953-67bda5d908f1
+	sigs.k8s.io/structured-merge-diff/v4 v4.2.3
 )
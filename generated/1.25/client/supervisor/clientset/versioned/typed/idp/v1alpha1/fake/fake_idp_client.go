@@ -19,6 +19,10 @@ func (c *FakeIDPV1alpha1) ActiveDirectoryIdentityProviders(namespace string) v1a
 	return &FakeActiveDirectoryIdentityProviders{c, namespace}
 }
 
+func (c *FakeIDPV1alpha1) ClientCertificateIdentityProviders(namespace string) v1alpha1.ClientCertificateIdentityProviderInterface {
+	return &FakeClientCertificateIdentityProviders{c, namespace}
+}
+
 func (c *FakeIDPV1alpha1) GitHubIdentityProviders(namespace string) v1alpha1.GitHubIdentityProviderInterface {
 	return &FakeGitHubIdentityProviders{c, namespace}
 }
@@ -27,10 +31,18 @@ func (c *FakeIDPV1alpha1) LDAPIdentityProviders(namespace string) v1alpha1.LDAPI
 	return &FakeLDAPIdentityProviders{c, namespace}
 }
 
+func (c *FakeIDPV1alpha1) MockIdentityProviders(namespace string) v1alpha1.MockIdentityProviderInterface {
+	return &FakeMockIdentityProviders{c, namespace}
+}
+
 func (c *FakeIDPV1alpha1) OIDCIdentityProviders(namespace string) v1alpha1.OIDCIdentityProviderInterface {
 	return &FakeOIDCIdentityProviders{c, namespace}
 }
 
+func (c *FakeIDPV1alpha1) OpenShiftIdentityProviders(namespace string) v1alpha1.OpenShiftIdentityProviderInterface {
+	return &FakeOpenShiftIdentityProviders{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeIDPV1alpha1) RESTClient() rest.Interface {
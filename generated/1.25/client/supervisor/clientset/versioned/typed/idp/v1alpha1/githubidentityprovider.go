@@ -7,9 +7,12 @@ package v1alpha1
 
 import (
 	"context"
+	json "encoding/json"
+	"fmt"
 	"time"
 
 	v1alpha1 "go.pinniped.dev/generated/1.25/apis/supervisor/idp/v1alpha1"
+	idpv1alpha1 "go.pinniped.dev/generated/1.25/client/supervisor/applyconfiguration/idp/v1alpha1"
 	scheme "go.pinniped.dev/generated/1.25/client/supervisor/clientset/versioned/scheme"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	types "k8s.io/apimachinery/pkg/types"
@@ -34,6 +37,8 @@ type GitHubIdentityProviderInterface interface {
 	List(ctx context.Context, opts v1.ListOptions) (*v1alpha1.GitHubIdentityProviderList, error)
 	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
 	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.GitHubIdentityProvider, err error)
+	Apply(ctx context.Context, gitHubIdentityProvider *idpv1alpha1.GitHubIdentityProviderApplyConfiguration, opts v1.ApplyOptions) (result *v1alpha1.GitHubIdentityProvider, err error)
+	ApplyStatus(ctx context.Context, gitHubIdentityProvider *idpv1alpha1.GitHubIdentityProviderApplyConfiguration, opts v1.ApplyOptions) (result *v1alpha1.GitHubIdentityProvider, err error)
 	GitHubIdentityProviderExpansion
 }
 
@@ -180,3 +185,59 @@ func (c *gitHubIdentityProviders) Patch(ctx context.Context, name string, pt typ
 		Into(result)
 	return
 }
+
+// Apply takes the given apply declarative configuration, applies it and returns the applied gitHubIdentityProvider.
+func (c *gitHubIdentityProviders) Apply(ctx context.Context, gitHubIdentityProvider *idpv1alpha1.GitHubIdentityProviderApplyConfiguration, opts v1.ApplyOptions) (result *v1alpha1.GitHubIdentityProvider, err error) {
+	if gitHubIdentityProvider == nil {
+		return nil, fmt.Errorf("gitHubIdentityProvider provided to Apply must not be nil")
+	}
+	patchOpts := opts.ToPatchOptions()
+	data, err := json.Marshal(gitHubIdentityProvider)
+	if err != nil {
+		return nil, err
+	}
+	name := gitHubIdentityProvider.Name
+	if name == nil {
+		return nil, fmt.Errorf("gitHubIdentityProvider.Name must be provided to Apply")
+	}
+	result = &v1alpha1.GitHubIdentityProvider{}
+	err = c.client.Patch(types.ApplyPatchType).
+		Namespace(c.ns).
+		Resource("githubidentityproviders").
+		Name(*name).
+		VersionedParams(&patchOpts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// ApplyStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating ApplyStatus().
+func (c *gitHubIdentityProviders) ApplyStatus(ctx context.Context, gitHubIdentityProvider *idpv1alpha1.GitHubIdentityProviderApplyConfiguration, opts v1.ApplyOptions) (result *v1alpha1.GitHubIdentityProvider, err error) {
+	if gitHubIdentityProvider == nil {
+		return nil, fmt.Errorf("gitHubIdentityProvider provided to Apply must not be nil")
+	}
+	patchOpts := opts.ToPatchOptions()
+	data, err := json.Marshal(gitHubIdentityProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	name := gitHubIdentityProvider.Name
+	if name == nil {
+		return nil, fmt.Errorf("gitHubIdentityProvider.Name must be provided to Apply")
+	}
+
+	result = &v1alpha1.GitHubIdentityProvider{}
+	err = c.client.Patch(types.ApplyPatchType).
+		Namespace(c.ns).
+		Resource("githubidentityproviders").
+		Name(*name).
+		SubResource("status").
+		VersionedParams(&patchOpts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
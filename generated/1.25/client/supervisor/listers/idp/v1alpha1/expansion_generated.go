@@ -13,6 +13,14 @@ type ActiveDirectoryIdentityProviderListerExpansion interface{}
 // ActiveDirectoryIdentityProviderNamespaceLister.
 type ActiveDirectoryIdentityProviderNamespaceListerExpansion interface{}
 
+// ClientCertificateIdentityProviderListerExpansion allows custom methods to be added to
+// ClientCertificateIdentityProviderLister.
+type ClientCertificateIdentityProviderListerExpansion interface{}
+
+// ClientCertificateIdentityProviderNamespaceListerExpansion allows custom methods to be added to
+// ClientCertificateIdentityProviderNamespaceLister.
+type ClientCertificateIdentityProviderNamespaceListerExpansion interface{}
+
 // GitHubIdentityProviderListerExpansion allows custom methods to be added to
 // GitHubIdentityProviderLister.
 type GitHubIdentityProviderListerExpansion interface{}
@@ -29,6 +37,14 @@ type LDAPIdentityProviderListerExpansion interface{}
 // LDAPIdentityProviderNamespaceLister.
 type LDAPIdentityProviderNamespaceListerExpansion interface{}
 
+// MockIdentityProviderListerExpansion allows custom methods to be added to
+// MockIdentityProviderLister.
+type MockIdentityProviderListerExpansion interface{}
+
+// MockIdentityProviderNamespaceListerExpansion allows custom methods to be added to
+// MockIdentityProviderNamespaceLister.
+type MockIdentityProviderNamespaceListerExpansion interface{}
+
 // OIDCIdentityProviderListerExpansion allows custom methods to be added to
 // OIDCIdentityProviderLister.
 type OIDCIdentityProviderListerExpansion interface{}
@@ -36,3 +52,11 @@ type OIDCIdentityProviderListerExpansion interface{}
 // OIDCIdentityProviderNamespaceListerExpansion allows custom methods to be added to
 // OIDCIdentityProviderNamespaceLister.
 type OIDCIdentityProviderNamespaceListerExpansion interface{}
+
+// OpenShiftIdentityProviderListerExpansion allows custom methods to be added to
+// OpenShiftIdentityProviderLister.
+type OpenShiftIdentityProviderListerExpansion interface{}
+
+// OpenShiftIdentityProviderNamespaceListerExpansion allows custom methods to be added to
+// OpenShiftIdentityProviderNamespaceLister.
+type OpenShiftIdentityProviderNamespaceListerExpansion interface{}
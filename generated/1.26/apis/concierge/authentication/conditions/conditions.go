@@ -0,0 +1,97 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package conditions contains the types and reasons of the status conditions of the resources in the
+// authentication.concierge.pinniped.dev API group, and helpers to inspect those conditions.
+package conditions
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition types which are shared by the JWTAuthenticator and the WebhookAuthenticator.
+const (
+	// TypeReady summarizes all other conditions.
+	TypeReady                 = "Ready"
+	TypeTLSConfigurationValid = "TLSConfigurationValid"
+	TypeAuthenticatorValid    = "AuthenticatorValid"
+)
+
+// Condition types of the JWTAuthenticator.
+const (
+	TypeIssuerURLValid = "IssuerURLValid"
+	TypeDiscoveryValid = "DiscoveryURLValid"
+	TypeJWKSURLValid   = "JWKSURLValid"
+	TypeJWKSFetchValid = "JWKSFetchValid"
+)
+
+// Condition types of the WebhookAuthenticator.
+const (
+	TypeWebhookConnectionValid = "WebhookConnectionValid"
+	TypeEndpointURLValid       = "EndpointURLValid"
+)
+
+// Condition reasons which are shared by the JWTAuthenticator and the WebhookAuthenticator.
+const (
+	ReasonSuccess                 = "Success"
+	ReasonNotReady                = "NotReady"
+	ReasonUnableToValidate        = "UnableToValidate"
+	ReasonInvalidTLSConfiguration = "InvalidTLSConfiguration"
+)
+
+// Condition reasons of the JWTAuthenticator.
+const (
+	ReasonInvalidIssuerURL                          = "InvalidIssuerURL"
+	ReasonInvalidIssuerURLScheme                    = "InvalidIssuerURLScheme"
+	ReasonInvalidIssuerURLContainsFragment          = "InvalidIssuerURLContainsFragment"
+	ReasonInvalidIssuerURLContainsQueryParams       = "InvalidIssuerURLContainsQueryParams"
+	ReasonInvalidIssuerURLContainsWellKnownEndpoint = "InvalidIssuerURLContainsWellKnownEndpoint"
+	ReasonInvalidProviderJWKSURL                    = "InvalidProviderJWKSURL"
+	ReasonInvalidProviderJWKSURLScheme              = "InvalidProviderJWKSURLScheme"
+	ReasonInvalidDiscoveryProbe                     = "InvalidDiscoveryProbe"
+	ReasonInvalidAuthenticator                      = "InvalidAuthenticator"
+	ReasonInvalidCouldNotFetchJWKS                  = "InvalidCouldNotFetchJWKS"
+)
+
+// Condition reasons of the WebhookAuthenticator.
+const (
+	ReasonUnableToCreateClient       = "UnableToCreateClient"
+	ReasonUnableToInstantiateWebhook = "UnableToInstantiateWebhook"
+	ReasonInvalidEndpointURL         = "InvalidEndpointURL"
+	ReasonInvalidEndpointURLScheme   = "InvalidEndpointURLScheme"
+	ReasonUnableToDialServer         = "UnableToDialServer"
+)
+
+// FindCondition returns the condition of the given type, or nil when there is no condition of that type.
+func FindCondition(conditions []metav1.Condition, conditionType string) *metav1.Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsTrue returns true when there is a condition of the given type and its status is True.
+func IsTrue(conditions []metav1.Condition, conditionType string) bool {
+	condition := FindCondition(conditions, conditionType)
+	return condition != nil && condition.Status == metav1.ConditionTrue
+}
+
+// IsReady returns true when the conditions describe a resource which is ready to be used.
+// When there is a Ready condition, then it decides. Otherwise, the resource is ready when
+// it has at least one condition and all of its conditions are True.
+func IsReady(conditions []metav1.Condition) bool {
+	if FindCondition(conditions, TypeReady) != nil {
+		return IsTrue(conditions, TypeReady)
+	}
+	if len(conditions) == 0 {
+		return false
+	}
+	for _, condition := range conditions {
+		if condition.Status != metav1.ConditionTrue {
+			return false
+		}
+	}
+	return true
+}
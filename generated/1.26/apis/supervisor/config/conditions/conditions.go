@@ -0,0 +1,130 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package conditions contains the types and reasons of the status conditions of the resources in the
+// config.supervisor.pinniped.dev API group, and helpers to inspect those conditions.
+package conditions
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition types of the FederationDomain.
+const (
+	// TypeReady summarizes all other conditions.
+	TypeReady                                         = "Ready"
+	TypeIssuerURLValid                                = "IssuerURLValid"
+	TypeOneTLSSecretPerIssuerHostname                 = "OneTLSSecretPerIssuerHostname"
+	TypeIssuerIsUnique                                = "IssuerIsUnique"
+	TypeIdentityProvidersFound                        = "IdentityProvidersFound"
+	TypeIdentityProvidersDisplayNamesUnique           = "IdentityProvidersDisplayNamesUnique"
+	TypeIdentityProvidersObjectRefAPIGroupSuffixValid = "IdentityProvidersObjectRefAPIGroupSuffixValid"
+	TypeIdentityProvidersObjectRefKindValid           = "IdentityProvidersObjectRefKindValid"
+	TypeTransformsExpressionsValid                    = "TransformsExpressionsValid"
+	TypeTransformsExamplesPassed                      = "TransformsExamplesPassed"
+	// TypeTLSCertificateReady is only present when spec.tls.certManager or spec.tls.acme is specified. It does not affect TypeReady.
+	TypeTLSCertificateReady = "TLSCertificateReady"
+	// TypeTLSSecretValid is only present when spec.tls.secretName is specified. It does not affect TypeReady.
+	TypeTLSSecretValid = "TLSSecretValid"
+	// TypeExposureReady and TypeIssuerExternallyReachable are only present when spec.exposure is specified.
+	// They do not affect TypeReady. For an HTTPRoute or TLSRoute, TypeExposureReady is only true when the
+	// listeners of its Gateways are compatible with the route, and all of its Gateways have accepted the route.
+	TypeExposureReady             = "ExposureReady"
+	TypeIssuerExternallyReachable = "IssuerExternallyReachable"
+	// TypeAccessTokensValid is only present when spec.accessTokens.format is JWT. Its message describes the security
+	// implications of JWT access tokens.
+	TypeAccessTokensValid = "AccessTokensValid"
+)
+
+// Condition types of the OIDCClient.
+const (
+	TypeClientSecretExists     = "ClientSecretExists"
+	TypeAllowedGrantTypesValid = "AllowedGrantTypesValid"
+	TypeAllowedScopesValid     = "AllowedScopesValid"
+	// TypePrivateKeyJWTValid is only present when spec.privateKeyJWT is configured.
+	TypePrivateKeyJWTValid = "PrivateKeyJWTValid"
+)
+
+// Condition reasons which are shared by the FederationDomain and the OIDCClient.
+const (
+	ReasonSuccess = "Success"
+)
+
+// Condition reasons of the FederationDomain.
+const (
+	ReasonNotReady                                    = "NotReady"
+	ReasonUnableToValidate                            = "UnableToValidate"
+	ReasonInvalidIssuerURL                            = "InvalidIssuerURL"
+	ReasonDuplicateIssuer                             = "DuplicateIssuer"
+	ReasonDifferentSecretRefsFound                    = "DifferentSecretRefsFound"
+	ReasonLegacyConfigurationSuccess                  = "LegacyConfigurationSuccess"
+	ReasonLegacyConfigurationIdentityProviderNotFound = "LegacyConfigurationIdentityProviderNotFound"
+	ReasonIdentityProvidersObjectRefsNotFound         = "IdentityProvidersObjectRefsNotFound"
+	ReasonIdentityProvidersNotAllowed                 = "IdentityProvidersNotAllowed"
+	ReasonIdentityProviderNotSpecified                = "IdentityProviderNotSpecified"
+	ReasonDuplicateDisplayNames                       = "DuplicateDisplayNames"
+	ReasonAPIGroupUnrecognized                        = "APIGroupUnrecognized"
+	ReasonKindUnrecognized                            = "KindUnrecognized"
+	ReasonInvalidTransformsExpressions                = "InvalidTransformsExpressions"
+	ReasonTransformsExamplesFailed                    = "TransformsExamplesFailed"
+	ReasonCertManagerNotInstalled                     = "CertManagerNotInstalled"
+	ReasonCertificateNotFound                         = "CertificateNotFound"
+	ReasonCertificateNotOwned                         = "CertificateNotOwned"
+	ReasonCertificateSecretNameMismatch               = "CertificateSecretNameMismatch"
+	ReasonCertificateNotReady                         = "CertificateNotReady"
+	ReasonACMEOrderFailed                             = "ACMEOrderFailed"
+	ReasonTLSSecretNotOwned                           = "TLSSecretNotOwned"
+	ReasonTLSSecretNotFound                           = "TLSSecretNotFound"
+	ReasonTLSSecretWrongType                          = "TLSSecretWrongType"
+	ReasonInvalidTLSSecret                            = "InvalidTLSSecret"
+	ReasonExposureNotOwned                            = "ExposureNotOwned"
+	ReasonGatewayAPINotInstalled                      = "GatewayAPINotInstalled"
+	ReasonGatewayNotFound                             = "GatewayNotFound"
+	ReasonGatewayListenerIncompatible                 = "GatewayListenerIncompatible"
+	ReasonRouteNotAccepted                            = "RouteNotAccepted"
+	ReasonIssuerUnreachable                           = "IssuerUnreachable"
+	ReasonInvalidAccessTokenAudience                  = "InvalidAccessTokenAudience"
+)
+
+// Condition reasons of the OIDCClient.
+const (
+	ReasonMissingRequiredValue        = "MissingRequiredValue"
+	ReasonNoClientSecretFound         = "NoClientSecretFound"
+	ReasonInvalidClientSecretFound    = "InvalidClientSecretFound"
+	ReasonPrivateKeyJWTSecretNotFound = "PrivateKeyJWTSecretNotFound"
+	ReasonInvalidPrivateKeyJWTSecret  = "InvalidPrivateKeyJWTSecret"
+)
+
+// FindCondition returns the condition of the given type, or nil when there is no condition of that type.
+func FindCondition(conditions []metav1.Condition, conditionType string) *metav1.Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsTrue returns true when there is a condition of the given type and its status is True.
+func IsTrue(conditions []metav1.Condition, conditionType string) bool {
+	condition := FindCondition(conditions, conditionType)
+	return condition != nil && condition.Status == metav1.ConditionTrue
+}
+
+// IsReady returns true when the conditions describe a resource which is ready to be used.
+// When there is a Ready condition, then it decides. Otherwise, the resource is ready when
+// it has at least one condition and all of its conditions are True.
+func IsReady(conditions []metav1.Condition) bool {
+	if FindCondition(conditions, TypeReady) != nil {
+		return IsTrue(conditions, TypeReady)
+	}
+	if len(conditions) == 0 {
+		return false
+	}
+	for _, condition := range conditions {
+		if condition.Status != metav1.ConditionTrue {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,129 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type ClientCertificateIdentityProviderPhase string
+
+const (
+	// ClientCertificatePhasePending is the default phase for newly-created ClientCertificateIdentityProvider resources.
+	ClientCertificatePhasePending ClientCertificateIdentityProviderPhase = "Pending"
+
+	// ClientCertificatePhaseReady is the phase for an ClientCertificateIdentityProvider resource in a healthy state.
+	ClientCertificatePhaseReady ClientCertificateIdentityProviderPhase = "Ready"
+
+	// ClientCertificatePhaseError is the phase for an ClientCertificateIdentityProvider in an unhealthy state.
+	ClientCertificatePhaseError ClientCertificateIdentityProviderPhase = "Error"
+)
+
+// ClientCertificateSubjectField names a field of the subject of an X.509 client certificate.
+type ClientCertificateSubjectField string
+
+const (
+	// ClientCertificateSubjectCommonName specifies using the subject's common name (CN).
+	ClientCertificateSubjectCommonName ClientCertificateSubjectField = "CommonName"
+
+	// ClientCertificateSubjectEmailAddress specifies using the first email address from the
+	// subject alternative names of the certificate.
+	ClientCertificateSubjectEmailAddress ClientCertificateSubjectField = "EmailAddress"
+
+	// ClientCertificateSubjectOrganization specifies using the subject's organizations (O).
+	ClientCertificateSubjectOrganization ClientCertificateSubjectField = "Organization"
+
+	// ClientCertificateSubjectOrganizationalUnit specifies using the subject's organizational units (OU).
+	ClientCertificateSubjectOrganizationalUnit ClientCertificateSubjectField = "OrganizationalUnit"
+)
+
+// ClientCertificateIdentityProviderStatus is the status of a client certificate identity provider.
+type ClientCertificateIdentityProviderStatus struct {
+	// Phase summarizes the overall status of the ClientCertificateIdentityProvider.
+	//
+	// +kubebuilder:default=Pending
+	// +kubebuilder:validation:Enum=Pending;Ready;Error
+	Phase ClientCertificateIdentityProviderPhase `json:"phase,omitempty"`
+
+	// Conditions represents the observations of an identity provider's current state.
+	//
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// ClientCertificateClaims allows customization of how the username and groups are determined
+// from the subject of the client certificate.
+type ClientCertificateClaims struct {
+	// Username configures which field of the certificate shall determine the username in Kubernetes.
+	//
+	// Can be either "CommonName" or "EmailAddress". Defaults to "CommonName".
+	//
+	// +kubebuilder:default=CommonName
+	// +kubebuilder:validation:Enum=CommonName;EmailAddress
+	// +optional
+	Username ClientCertificateSubjectField `json:"username,omitempty"`
+
+	// Groups configures which field of the certificate's subject shall determine the group names in Kubernetes.
+	//
+	// Can be either "Organization" or "OrganizationalUnit". Defaults to "Organization", which mirrors how
+	// Kubernetes itself interprets client certificates.
+	//
+	// +kubebuilder:default=Organization
+	// +kubebuilder:validation:Enum=Organization;OrganizationalUnit
+	// +optional
+	Groups ClientCertificateSubjectField `json:"groups,omitempty"`
+}
+
+// ClientCertificateIdentityProviderSpec is the spec for configuring a client certificate identity provider.
+type ClientCertificateIdentityProviderSpec struct {
+	// CertificateAuthorityData is the X.509 Certificate Authority (base64-encoded PEM bundle) which must
+	// have signed the client certificate presented by the user during the TLS handshake.
+	//
+	// +kubebuilder:validation:MinLength=1
+	CertificateAuthorityData string `json:"certificateAuthorityData"`
+
+	// Claims allows customization of the username and groups claims.
+	//
+	// +kubebuilder:default={}
+	// +optional
+	Claims ClientCertificateClaims `json:"claims,omitempty"`
+}
+
+// ClientCertificateIdentityProvider describes the configuration of an identity provider which authenticates
+// users by asking them to present an X.509 client certificate during the TLS handshake with the Supervisor
+// (mutual TLS). The certificate must be signed by the configured certificate authority.
+//
+// Right now, only web-based logins are supported, for both the pinniped-cli client and clients configured
+// as OIDCClients.
+//
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:categories=pinniped;pinniped-idp;pinniped-idps
+// +kubebuilder:printcolumn:name="Username",type=string,JSONPath=`.spec.claims.username`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:subresource:status
+type ClientCertificateIdentityProvider struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec for configuring the identity provider.
+	Spec ClientCertificateIdentityProviderSpec `json:"spec"`
+
+	// Status of the identity provider.
+	Status ClientCertificateIdentityProviderStatus `json:"status,omitempty"`
+}
+
+// ClientCertificateIdentityProviderList lists ClientCertificateIdentityProvider objects.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ClientCertificateIdentityProviderList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ClientCertificateIdentityProvider `json:"items"`
+}
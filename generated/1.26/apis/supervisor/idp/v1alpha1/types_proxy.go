@@ -0,0 +1,22 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+// ProxySpec configures the outbound proxy which the Supervisor uses to connect to an identity provider.
+// When a ProxySpec is given, it is used instead of the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment
+// variables of the Supervisor pods for all connections to that identity provider.
+type ProxySpec struct {
+	// URL of the proxy, e.g. "http://proxy.example.com:3128", "https://proxy.example.com:3129" or
+	// "socks5://proxy.example.com:1080". HTTP and HTTPS proxies must support the CONNECT method.
+	// The URL may contain a username and password for basic authentication to the proxy.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern=`^(https?|socks5)://`
+	URL string `json:"url"`
+
+	// NoProxy is a list of hosts which are connected to directly instead of through the proxy, using the same
+	// syntax as the entries of the NO_PROXY environment variable, e.g. "idp.internal.example.com",
+	// ".example.com", "10.0.0.0/8" or "*". Connections to localhost and loopback addresses never use the proxy.
+	// +optional
+	NoProxy []string `json:"noProxy,omitempty"`
+}
@@ -10,4 +10,5 @@ require (
 	k8s.io/apimachinery v0.26.15
 	k8s.io/client-go v0.26.15
 	k8s.io/kube-openapi v0.0.0-20221012153701-172d655c2280
+	sigs.k8s.io/structured-merge-diff/v4 v4.2.3
 )
@@ -0,0 +1,69 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// ClusterTokenDenylistEntry describes which tokens should be rejected. Exactly one of jti or subject must be set.
+// +kubebuilder:validation:XValidation:message="exactly one of jti or subject must be set",rule="has(self.jti) != has(self.subject)"
+// +kubebuilder:validation:XValidation:message="issuedAfter and issuedBefore may only be used with subject",rule="has(self.subject) || (!has(self.issuedAfter) && !has(self.issuedBefore))"
+type ClusterTokenDenylistEntry struct {
+	// JTI rejects the single token whose "jti" claim exactly matches this value.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	JTI string `json:"jti,omitempty"`
+
+	// Subject rejects tokens whose "sub" claim exactly matches this value. By default, all tokens for the
+	// subject are rejected. Use issuedAfter and/or issuedBefore to only reject the tokens whose "iat" claim
+	// falls within a range of time.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	Subject string `json:"subject,omitempty"`
+
+	// IssuedAfter limits a subject entry to tokens issued at or after this time.
+	// +optional
+	IssuedAfter *metav1.Time `json:"issuedAfter,omitempty"`
+
+	// IssuedBefore limits a subject entry to tokens issued at or before this time.
+	// +optional
+	IssuedBefore *metav1.Time `json:"issuedBefore,omitempty"`
+
+	// ExpiresAt is the time after which this entry is ignored. Since expired tokens are always rejected,
+	// this is typically set to the expiration time of the token(s) being denied, so that entries do not
+	// need to be cleaned up to keep the denylist small. When not set, the entry never expires.
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+}
+
+// Spec for configuring a token denylist.
+type ClusterTokenDenylistSpec struct {
+	// Entries describe the tokens which should be rejected by all JWTAuthenticators, even when
+	// they are otherwise valid.
+	// +kubebuilder:validation:MinItems=1
+	Entries []ClusterTokenDenylistEntry `json:"entries"`
+}
+
+// ClusterTokenDenylist describes tokens which should be rejected by all JWTAuthenticators before they expire,
+// e.g. because they were leaked. All ClusterTokenDenylists in the cluster are combined.
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:categories=pinniped;pinniped-authenticator;pinniped-authenticators,scope=Cluster
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type ClusterTokenDenylist struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec for configuring the denylist.
+	Spec ClusterTokenDenylistSpec `json:"spec"`
+}
+
+// List of ClusterTokenDenylist objects.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ClusterTokenDenylistList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ClusterTokenDenylist `json:"items"`
+}
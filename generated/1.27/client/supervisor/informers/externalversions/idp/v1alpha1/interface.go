@@ -13,12 +13,18 @@ import (
 type Interface interface {
 	// ActiveDirectoryIdentityProviders returns a ActiveDirectoryIdentityProviderInformer.
 	ActiveDirectoryIdentityProviders() ActiveDirectoryIdentityProviderInformer
+	// ClientCertificateIdentityProviders returns a ClientCertificateIdentityProviderInformer.
+	ClientCertificateIdentityProviders() ClientCertificateIdentityProviderInformer
 	// GitHubIdentityProviders returns a GitHubIdentityProviderInformer.
 	GitHubIdentityProviders() GitHubIdentityProviderInformer
 	// LDAPIdentityProviders returns a LDAPIdentityProviderInformer.
 	LDAPIdentityProviders() LDAPIdentityProviderInformer
+	// MockIdentityProviders returns a MockIdentityProviderInformer.
+	MockIdentityProviders() MockIdentityProviderInformer
 	// OIDCIdentityProviders returns a OIDCIdentityProviderInformer.
 	OIDCIdentityProviders() OIDCIdentityProviderInformer
+	// OpenShiftIdentityProviders returns a OpenShiftIdentityProviderInformer.
+	OpenShiftIdentityProviders() OpenShiftIdentityProviderInformer
 }
 
 type version struct {
@@ -37,6 +43,11 @@ func (v *version) ActiveDirectoryIdentityProviders() ActiveDirectoryIdentityProv
 	return &activeDirectoryIdentityProviderInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// ClientCertificateIdentityProviders returns a ClientCertificateIdentityProviderInformer.
+func (v *version) ClientCertificateIdentityProviders() ClientCertificateIdentityProviderInformer {
+	return &clientCertificateIdentityProviderInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // GitHubIdentityProviders returns a GitHubIdentityProviderInformer.
 func (v *version) GitHubIdentityProviders() GitHubIdentityProviderInformer {
 	return &gitHubIdentityProviderInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -47,7 +58,17 @@ func (v *version) LDAPIdentityProviders() LDAPIdentityProviderInformer {
 	return &lDAPIdentityProviderInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// MockIdentityProviders returns a MockIdentityProviderInformer.
+func (v *version) MockIdentityProviders() MockIdentityProviderInformer {
+	return &mockIdentityProviderInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // OIDCIdentityProviders returns a OIDCIdentityProviderInformer.
 func (v *version) OIDCIdentityProviders() OIDCIdentityProviderInformer {
 	return &oIDCIdentityProviderInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// OpenShiftIdentityProviders returns a OpenShiftIdentityProviderInformer.
+func (v *version) OpenShiftIdentityProviders() OpenShiftIdentityProviderInformer {
+	return &openShiftIdentityProviderInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
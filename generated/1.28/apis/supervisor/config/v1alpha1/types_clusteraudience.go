@@ -0,0 +1,57 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// ClusterAudienceSpec is a struct that describes a ClusterAudience.
+type ClusterAudienceSpec struct {
+	// audience is the audience of the cluster, i.e. the value which clients request at the token endpoints of the
+	// FederationDomains using RFC8693 token exchange, and which the cluster expects in the aud claim of the ID tokens,
+	// e.g. the spec.audience of its Concierge JWTAuthenticator. It must be unique among the ClusterAudiences.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:XValidation:message="audience must not contain '.pinniped.dev'",rule="!self.contains('.pinniped.dev')"
+	// +kubebuilder:validation:XValidation:message="audience must not equal 'pinniped-cli'",rule="self != 'pinniped-cli'"
+	Audience string `json:"audience"`
+
+	// server is the URL of the Kubernetes API server of the cluster, as it appears in the kubeconfigs of the cluster.
+	// It is not used by the Supervisor, but it is published along with the audience, so that `pinniped get kubeconfig`
+	// can choose the audience of the cluster for which it generates a kubeconfig.
+	// +kubebuilder:validation:Pattern=`^https://`
+	// +optional
+	Server string `json:"server,omitempty"`
+
+	// certificateAuthorityData is the base64 encoded PEM CA bundle of the Kubernetes API server of the cluster.
+	// Like server, it is only published for clients.
+	// +optional
+	CertificateAuthorityData string `json:"certificateAuthorityData,omitempty"`
+}
+
+// ClusterAudience registers a cluster for which clients may request tokens at the FederationDomains using RFC8693
+// token exchange. Once any ClusterAudience exists, the token endpoints of all FederationDomains only exchange tokens
+// for the audiences of ClusterAudiences, and reject all other requested audiences. While there are none, any audience
+// which is not reserved by the Supervisor may be requested. The ClusterAudiences are also listed by the cluster
+// audience discovery endpoint of each FederationDomain.
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:categories=pinniped
+// +kubebuilder:printcolumn:name="Audience",type=string,JSONPath=`.spec.audience`
+// +kubebuilder:printcolumn:name="Server",type=string,JSONPath=`.spec.server`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type ClusterAudience struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec of the cluster audience.
+	Spec ClusterAudienceSpec `json:"spec"`
+}
+
+// List of ClusterAudience objects.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ClusterAudienceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ClusterAudience `json:"items"`
+}
@@ -21,6 +21,10 @@ const (
 // +kubebuilder:validation:Pattern=`^https://.+|^http://(127\.0\.0\.1|\[::1\])(:\d+)?/`
 type RedirectURI string
 
+// ResourceURI is an RFC 8707 resource indicator, which must be an absolute URI without a fragment.
+// +kubebuilder:validation:Pattern=`^[a-zA-Z][a-zA-Z0-9+.-]*:[^#]+$`
+type ResourceURI string
+
 // +kubebuilder:validation:Enum="authorization_code";"refresh_token";"urn:ietf:params:oauth:grant-type:token-exchange"
 type GrantType string
 
@@ -72,11 +76,117 @@ type OIDCClientSpec struct {
 	// +kubebuilder:validation:MinItems=1
 	AllowedScopes []Scope `json:"allowedScopes"`
 
+	// scopePolicies is an optional list of policies which restrict the users who may be granted some of the
+	// allowedScopes by this client. A scope which has a policy is only granted to users who belong to at least one of
+	// the requiredGroups of the policy, using the downstream groups of the user after the identity transformations
+	// of the FederationDomain were applied. A login which requests a scope whose policy is not met is rejected, and
+	// refresh grants are rejected once the user no longer meets the policies of the
+	// scopes which were granted to their session. Each scope must also be listed in allowedScopes, and the openid
+	// scope may not have a policy.
+	// +listType=map
+	// +listMapKey=scope
+	// +optional
+	ScopePolicies []OIDCClientScopePolicy `json:"scopePolicies,omitempty"`
+
+	// requiredACRValues is an optional list of Authentication Context Class Reference (ACR) values, one of which must be
+	// asserted by the acr claim of the upstream ID token for a login to this client to succeed, e.g. to require multi-factor
+	// authentication at the upstream identity provider. They are requested from upstream OIDC identity providers using the
+	// acr_values param of the upstream authorization request. Logins using identity providers which do not assert an acr
+	// claim, e.g. LDAP identity providers, are rejected. Refresh grants are rejected when the session of the user no longer
+	// meets this list, and remembered single sign-on logins are only used when they meet it. The acr and amr claims of the
+	// upstream ID token are always copied into the downstream ID tokens. When empty, logins do not require any ACR value.
+	// +listType=set
+	// +optional
+	RequiredACRValues []string `json:"requiredACRValues,omitempty"`
+
+	// allowedResources is an optional list of the RFC 8707 resource indicators which this client may request using
+	// the resource param of the authorization endpoint. The requested resources are granted as audience restrictions
+	// of the access token, and may be narrowed using the resource param of the token endpoint. When an access token
+	// was issued for resources, a RFC8693 token exchange using that access token may only request one of those
+	// resources as the audience of the new ID token. When empty, the client may not request any resources.
+	// Each must be an absolute URI without a fragment.
+	// +listType=set
+	// +optional
+	AllowedResources []ResourceURI `json:"allowedResources,omitempty"`
+
+	// requireDPoP requires the DPoP proofs which are described by RFC 9449 on all requests which this client makes to
+	// the token endpoint. The tokens which are issued in response to a request with a DPoP proof are bound to the public
+	// key of the proof, so they can only be refreshed or exchanged using proofs which are signed by the same key. When
+	// false, DPoP proofs are optional, and tokens are only bound when a proof was provided.
+	// +optional
+	RequireDPoP bool `json:"requireDPoP,omitempty"`
+
+	// allowJWTSecuredAuthorizationResponses allows this client to request a JWT Secured Authorization Response (JARM)
+	// by sending the response_mode param with a value of "jwt" or "query.jwt" to the authorization endpoint. The params
+	// of the authorization response are then returned in a single "response" param, whose value is a JWT which is signed by
+	// the FederationDomain's signing key, and which the client can validate using the FederationDomain's JWKS. When false,
+	// the client may only use the default query response mode.
+	// +optional
+	AllowJWTSecuredAuthorizationResponses bool `json:"allowJWTSecuredAuthorizationResponses,omitempty"`
+
+	// requireConsent requires the user to approve this client on a consent page during their first login to it, before the
+	// client receives an authorization code. The page shows the name of the client and the scopes which it requested, and the
+	// user's approval is remembered for that user and client for 30 days, after which they are asked again. A denied
+	// approval is returned to the client as an access_denied error. This is recommended for web applications which are not
+	// trusted to silently receive the cluster identity of the user. When false, no consent page is shown.
+	// +optional
+	RequireConsent bool `json:"requireConsent,omitempty"`
+
+	// privateKeyJWT configures this client to authenticate to the token endpoint with a JWT which is signed by its own
+	// private key, as described by the private_key_jwt method of OpenID Connect Core 1.0 section 9, instead of with a
+	// client secret. This is recommended for automation clients, since no shared secret needs to be distributed to them.
+	// When configured, the client secrets of this client are not used.
+	// +optional
+	PrivateKeyJWT *OIDCClientPrivateKeyJWT `json:"privateKeyJWT,omitempty"`
+
+	// delegation optionally allows the RFC8693 token exchanges of this client to include the actor_token param, so that
+	// another party, e.g. a service which calls a cluster on behalf of the user, can get a cluster-scoped ID token for the
+	// user whose act claim identifies that party. The actor_token must be a Supervisor access token of the actor, with an
+	// actor_token_type of urn:ietf:params:oauth:token-type:access_token, or the ServiceAccount token of a workload, with an
+	// actor_token_type of urn:ietf:params:oauth:token-type:jwt, when the FederationDomain enables workload identity.
+	// The access token of an actor must have been issued to this client with the pinniped:request-audience and username scopes.
+	// When not specified, token exchanges which include an actor_token are rejected.
+	// +optional
+	Delegation *OIDCClientDelegation `json:"delegation,omitempty"`
+
 	// tokenLifetimes are the optional overrides of token lifetimes for an OIDCClient.
 	// +optional
 	TokenLifetimes OIDCClientTokenLifetimes `json:"tokenLifetimes,omitempty"`
 }
 
+// OIDCClientScopePolicy describes which users may be granted a scope by an OIDCClient.
+type OIDCClientScopePolicy struct {
+	// scope is the scope to which this policy applies.
+	Scope Scope `json:"scope"`
+
+	// requiredGroups is the list of downstream groups of which the user must belong to at least one to be
+	// granted the scope.
+	// +listType=set
+	// +kubebuilder:validation:MinItems=1
+	RequiredGroups []string `json:"requiredGroups"`
+}
+
+// OIDCClientPrivateKeyJWT describes the public keys with which an OIDCClient's private_key_jwt client assertions
+// are verified.
+type OIDCClientPrivateKeyJWT struct {
+	// secretName is the name of a Secret of type "secrets.pinniped.dev/oidc-client-public-keys" in the same namespace
+	// as the OIDCClient. Its "publicKeys" key must contain one or more PEM-encoded ECDSA P-256 public keys, which may be
+	// used to verify client assertions signed with the ES256 algorithm. Several keys may be listed while the client's key
+	// is being rotated. Each assertion's "kid" header, when present, must be the RFC 7638 JWK thumbprint of its key.
+	// +kubebuilder:validation:MinLength=1
+	SecretName string `json:"secretName"`
+}
+
+// OIDCClientDelegation describes which actors may act on behalf of the users of an OIDCClient.
+type OIDCClientDelegation struct {
+	// allowedActors are the downstream usernames of the actors which may act on behalf of the users of this client,
+	// i.e. the usernames of the actors after the identity transformations of the FederationDomain were applied, or the
+	// usernames of workloads including the workload identity username prefix of the FederationDomain.
+	// +listType=set
+	// +kubebuilder:validation:MinItems=1
+	AllowedActors []string `json:"allowedActors"`
+}
+
 // OIDCClientTokenLifetimes describes the optional overrides of token lifetimes for an OIDCClient.
 type OIDCClientTokenLifetimes struct {
 	// idTokenSeconds is the lifetime of ID tokens issued to this client, in seconds. This will choose the lifetime of
@@ -112,6 +222,18 @@ type OIDCClientStatus struct {
 	// totalClientSecrets is the current number of client secrets that are detected for this OIDCClient.
 	// +optional
 	TotalClientSecrets int32 `json:"totalClientSecrets"` // do not omitempty to allow it to show in the printer column even when it is 0
+
+	// observedGeneration is the metadata.generation of the spec which was most recently validated by the
+	// Supervisor. The conditions and phase describe that generation, so automation should wait for it to
+	// equal metadata.generation before relying on the phase.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// lastSuccessfulAuthTimestamp is the approximate time at which this client most recently authenticated
+	// successfully at the token endpoint of a FederationDomain. It is published periodically by the Supervisor,
+	// so it may be a few minutes behind.
+	// +optional
+	LastSuccessfulAuthTimestamp *metav1.Time `json:"lastSuccessfulAuthTimestamp,omitempty"`
 }
 
 // OIDCClient describes the configuration of an OIDC client.
@@ -0,0 +1,29 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+// ClientSecretRefType is the type of store from which the client credentials of an identity provider are read.
+// +kubebuilder:validation:Enum=file
+type ClientSecretRefType string
+
+const (
+	// ClientSecretRefTypeFile reads the client credentials from files in the Supervisor pods.
+	ClientSecretRefTypeFile ClientSecretRefType = "file"
+)
+
+// ClientSecretRef references client credentials which are stored outside of Kubernetes Secrets, e.g. for
+// organizations which do not allow long-lived identity provider client secrets to be stored in etcd.
+type ClientSecretRef struct {
+	// Type is the type of store. Only "file" is currently supported, which reads the client credentials from
+	// files in the Supervisor pods, e.g. as mounted by a CSI driver such as the Secrets Store CSI Driver.
+	Type ClientSecretRefType `json:"type"`
+
+	// Path is the absolute path of a directory in the Supervisor pods which contains the files "clientID" and
+	// "clientSecret". The directory must be inside one of the directories which are allowed by the
+	// externalClientSecrets.allowedDirectories setting of the Supervisor's static configuration.
+	// The files are read again when their contents change.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern=`^/`
+	Path string `json:"path"`
+}
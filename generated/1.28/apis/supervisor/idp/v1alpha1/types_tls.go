@@ -7,5 +7,6 @@ package v1alpha1
 type TLSSpec struct {
 	// X.509 Certificate Authority (base64-encoded PEM bundle). If omitted, a default set of system roots will be trusted.
 	// +optional
+	// +kubebuilder:validation:XValidation:message="certificateAuthorityData must be base64-encoded",rule="self.matches(r'^[A-Za-z0-9+/=\\s]*$')"
 	CertificateAuthorityData string `json:"certificateAuthorityData,omitempty"`
 }
@@ -5,6 +5,8 @@
 
 package v1alpha1
 
+type ClusterTokenDenylistExpansion interface{}
+
 type JWTAuthenticatorExpansion interface{}
 
 type WebhookAuthenticatorExpansion interface{}
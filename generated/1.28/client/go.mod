@@ -10,4 +10,5 @@ require (
 	k8s.io/apimachinery v0.28.11
 	k8s.io/client-go v0.28.11
 	k8s.io/kube-openapi v0.0.0-20230717233707-2695361300d9
+	sigs.k8s.io/structured-merge-diff/v4 v4.2.3
 )
@@ -0,0 +1,22 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+// SecondFactorSpec configures a second factor which users must provide in addition to their password.
+type SecondFactorSpec struct {
+	// TOTP requires users to enter a time-based one-time code from an authenticator app. Each user is asked to
+	// enroll an authenticator app during their first browser-based login after this was configured. The secret of
+	// each user is stored encrypted in a Secret in the namespace of the Supervisor, using a key derived from the
+	// Supervisor's own key. Deleting the Secret of a user resets their enrollment.
+	// +optional
+	TOTP *TOTPSpec `json:"totp,omitempty"`
+}
+
+// TOTPSpec configures time-based one-time codes (TOTP) as a second factor.
+type TOTPSpec struct {
+	// Issuer is the name which authenticator apps show next to the account of the user, e.g. "Example Corp".
+	// When omitted, "Pinniped" is used.
+	// +optional
+	Issuer string `json:"issuer,omitempty"`
+}
@@ -15,6 +15,7 @@ import (
 
 type AuthenticationV1alpha1Interface interface {
 	RESTClient() rest.Interface
+	ClusterTokenDenylistsGetter
 	JWTAuthenticatorsGetter
 	WebhookAuthenticatorsGetter
 }
@@ -24,6 +25,10 @@ type AuthenticationV1alpha1Client struct {
 	restClient rest.Interface
 }
 
+func (c *AuthenticationV1alpha1Client) ClusterTokenDenylists() ClusterTokenDenylistInterface {
+	return newClusterTokenDenylists(c)
+}
+
 func (c *AuthenticationV1alpha1Client) JWTAuthenticators() JWTAuthenticatorInterface {
 	return newJWTAuthenticators(c)
 }
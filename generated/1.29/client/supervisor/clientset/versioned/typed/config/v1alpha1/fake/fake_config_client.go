@@ -15,10 +15,18 @@ type FakeConfigV1alpha1 struct {
 	*testing.Fake
 }
 
+func (c *FakeConfigV1alpha1) ClusterAudiences(namespace string) v1alpha1.ClusterAudienceInterface {
+	return &FakeClusterAudiences{c, namespace}
+}
+
 func (c *FakeConfigV1alpha1) FederationDomains(namespace string) v1alpha1.FederationDomainInterface {
 	return &FakeFederationDomains{c, namespace}
 }
 
+func (c *FakeConfigV1alpha1) ForcedReauthentications(namespace string) v1alpha1.ForcedReauthenticationInterface {
+	return &FakeForcedReauthentications{c, namespace}
+}
+
 func (c *FakeConfigV1alpha1) OIDCClients(namespace string) v1alpha1.OIDCClientInterface {
 	return &FakeOIDCClients{c, namespace}
 }
@@ -11,8 +11,12 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// ClusterAudiences returns a ClusterAudienceInformer.
+	ClusterAudiences() ClusterAudienceInformer
 	// FederationDomains returns a FederationDomainInformer.
 	FederationDomains() FederationDomainInformer
+	// ForcedReauthentications returns a ForcedReauthenticationInformer.
+	ForcedReauthentications() ForcedReauthenticationInformer
 	// OIDCClients returns a OIDCClientInformer.
 	OIDCClients() OIDCClientInformer
 }
@@ -28,11 +32,21 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// ClusterAudiences returns a ClusterAudienceInformer.
+func (v *version) ClusterAudiences() ClusterAudienceInformer {
+	return &clusterAudienceInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // FederationDomains returns a FederationDomainInformer.
 func (v *version) FederationDomains() FederationDomainInformer {
 	return &federationDomainInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// ForcedReauthentications returns a ForcedReauthenticationInformer.
+func (v *version) ForcedReauthentications() ForcedReauthenticationInformer {
+	return &forcedReauthenticationInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // OIDCClients returns a OIDCClientInformer.
 func (v *version) OIDCClients() OIDCClientInformer {
 	return &oIDCClientInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
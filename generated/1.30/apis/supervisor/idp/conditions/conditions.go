@@ -0,0 +1,120 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package conditions contains the types and reasons of the status conditions of the resources in the
+// idp.supervisor.pinniped.dev API group, and helpers to inspect those conditions.
+package conditions
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition types which are shared by several kinds of identity providers.
+const (
+	// TypeReady is not currently used by any identity provider. Their readiness is instead reported by
+	// their phase, which is Ready when all of their conditions are True. See IsReady.
+	TypeReady                        = "Ready"
+	TypeTLSConfigurationValid        = "TLSConfigurationValid"
+	TypeClientCredentialsSecretValid = "ClientCredentialsSecretValid" //nolint:gosec // this is not a credential
+	TypeClaimsValid                  = "ClaimsValid"
+)
+
+// Condition types of the OIDCIdentityProvider.
+const (
+	TypeOIDCDiscoverySucceeded             = "OIDCDiscoverySucceeded"
+	TypeAdditionalAuthorizeParametersValid = "AdditionalAuthorizeParametersValid"
+)
+
+// Condition types of the LDAPIdentityProvider and the ActiveDirectoryIdentityProvider.
+const (
+	TypeBindSecretValid     = "BindSecretValid"
+	TypeLDAPConnectionValid = "LDAPConnectionValid"
+	TypeSearchBaseFound     = "SearchBaseFound"
+)
+
+// Condition types of the GitHubIdentityProvider.
+const (
+	TypeHostValid                = "HostValid"
+	TypeOrganizationsPolicyValid = "OrganizationsPolicyValid"
+	TypeGitHubConnectionValid    = "GitHubConnectionValid"
+)
+
+// Condition types of the MockIdentityProvider.
+const (
+	TypeUsersSecretValid = "UsersSecretValid"
+)
+
+// Condition types of the OpenShiftIdentityProvider.
+const (
+	TypeOAuthDiscoverySucceeded = "OAuthDiscoverySucceeded"
+)
+
+// Condition reasons which are shared by several kinds of identity providers.
+const (
+	ReasonSuccess           = "Success"
+	ReasonInvalid           = "Invalid"
+	ReasonSecretNotFound    = "SecretNotFound"
+	ReasonSecretWrongType   = "SecretWrongType"
+	ReasonSecretMissingKeys = "SecretMissingKeys"
+	ReasonInvalidTLSConfig  = "InvalidTLSConfig"
+)
+
+// Condition reasons of the OIDCIdentityProvider.
+const (
+	ReasonUnreachable             = "Unreachable"
+	ReasonInvalidResponse         = "InvalidResponse"
+	ReasonDisallowedParameterName = "DisallowedParameterName"
+)
+
+// Condition reasons of the LDAPIdentityProvider and the ActiveDirectoryIdentityProvider.
+const (
+	ReasonLDAPConnectionError        = "LDAPConnectionError"
+	ReasonUsingConfigurationFromSpec = "UsingConfigurationFromSpec"
+	ReasonErrorFetchingSearchBase    = "ErrorFetchingSearchBase"
+)
+
+// Condition reasons of the GitHubIdentityProvider.
+const (
+	ReasonInvalidHost        = "InvalidHost"
+	ReasonUnableToValidate   = "UnableToValidate"
+	ReasonUnableToDialServer = "UnableToDialServer"
+)
+
+// Condition reasons of the MockIdentityProvider.
+const (
+	ReasonInvalidUsers = "InvalidUsers"
+)
+
+// FindCondition returns the condition of the given type, or nil when there is no condition of that type.
+func FindCondition(conditions []metav1.Condition, conditionType string) *metav1.Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsTrue returns true when there is a condition of the given type and its status is True.
+func IsTrue(conditions []metav1.Condition, conditionType string) bool {
+	condition := FindCondition(conditions, conditionType)
+	return condition != nil && condition.Status == metav1.ConditionTrue
+}
+
+// IsReady returns true when the conditions describe a resource which is ready to be used.
+// When there is a Ready condition, then it decides. Otherwise, the resource is ready when
+// it has at least one condition and all of its conditions are True.
+func IsReady(conditions []metav1.Condition) bool {
+	if FindCondition(conditions, TypeReady) != nil {
+		return IsTrue(conditions, TypeReady)
+	}
+	if len(conditions) == 0 {
+		return false
+	}
+	for _, condition := range conditions {
+		if condition.Status != metav1.ConditionTrue {
+			return false
+		}
+	}
+	return true
+}
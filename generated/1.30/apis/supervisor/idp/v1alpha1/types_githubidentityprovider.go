@@ -68,6 +68,17 @@ type GitHubAPIConfig struct {
 	//
 	// +optional
 	TLS *TLSSpec `json:"tls,omitempty"`
+
+	// ConnectionPool configures the pool of HTTP connections used to make requests to GitHub.
+	//
+	// +optional
+	ConnectionPool *HTTPConnectionPoolSpec `json:"connectionPool,omitempty"`
+
+	// Proxy configures the outbound proxy used to connect to GitHub. When omitted, the proxy environment
+	// variables of the Supervisor pods are used.
+	//
+	// +optional
+	Proxy *ProxySpec `json:"proxy,omitempty"`
 }
 
 // GitHubUsernameAttribute allows the user to specify which attribute(s) from GitHub to use for the username to present
@@ -155,7 +166,8 @@ type GitHubClaims struct {
 }
 
 // GitHubClientSpec contains information about the GitHub client that this identity provider will use
-// for web-based login flows.
+// for web-based login flows. Exactly one of secretName or secretRef must be specified.
+// +kubebuilder:validation:XValidation:message="exactly one of spec.client.secretName or spec.client.secretRef must be specified",rule="has(self.secretName) != has(self.secretRef)"
 type GitHubClientSpec struct {
 	// SecretName contains the name of a namespace-local Secret object that provides the clientID and
 	// clientSecret for an GitHub App or GitHub OAuth2 client.
@@ -163,7 +175,13 @@ type GitHubClientSpec struct {
 	// This secret must be of type "secrets.pinniped.dev/github-client" with keys "clientID" and "clientSecret".
 	//
 	// +kubebuilder:validation:MinLength=1
-	SecretName string `json:"secretName"`
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// SecretRef references a clientID and clientSecret for a GitHub App or GitHub OAuth2 client which are stored
+	// outside of Kubernetes Secrets.
+	// +optional
+	SecretRef *ClientSecretRef `json:"secretRef,omitempty"`
 }
 
 type GitHubOrganizationsSpec struct {
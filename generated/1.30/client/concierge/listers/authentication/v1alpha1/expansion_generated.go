@@ -5,6 +5,10 @@
 
 package v1alpha1
 
+// ClusterTokenDenylistListerExpansion allows custom methods to be added to
+// ClusterTokenDenylistLister.
+type ClusterTokenDenylistListerExpansion interface{}
+
 // JWTAuthenticatorListerExpansion allows custom methods to be added to
 // JWTAuthenticatorLister.
 type JWTAuthenticatorListerExpansion interface{}
@@ -15,7 +15,9 @@ import (
 
 type ConfigV1alpha1Interface interface {
 	RESTClient() rest.Interface
+	ClusterAudiencesGetter
 	FederationDomainsGetter
+	ForcedReauthenticationsGetter
 	OIDCClientsGetter
 }
 
@@ -24,10 +26,18 @@ type ConfigV1alpha1Client struct {
 	restClient rest.Interface
 }
 
+func (c *ConfigV1alpha1Client) ClusterAudiences(namespace string) ClusterAudienceInterface {
+	return newClusterAudiences(c, namespace)
+}
+
 func (c *ConfigV1alpha1Client) FederationDomains(namespace string) FederationDomainInterface {
 	return newFederationDomains(c, namespace)
 }
 
+func (c *ConfigV1alpha1Client) ForcedReauthentications(namespace string) ForcedReauthenticationInterface {
+	return newForcedReauthentications(c, namespace)
+}
+
 func (c *ConfigV1alpha1Client) OIDCClients(namespace string) OIDCClientInterface {
 	return newOIDCClients(c, namespace)
 }
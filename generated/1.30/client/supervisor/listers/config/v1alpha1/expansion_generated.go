@@ -5,6 +5,14 @@
 
 package v1alpha1
 
+// ClusterAudienceListerExpansion allows custom methods to be added to
+// ClusterAudienceLister.
+type ClusterAudienceListerExpansion interface{}
+
+// ClusterAudienceNamespaceListerExpansion allows custom methods to be added to
+// ClusterAudienceNamespaceLister.
+type ClusterAudienceNamespaceListerExpansion interface{}
+
 // FederationDomainListerExpansion allows custom methods to be added to
 // FederationDomainLister.
 type FederationDomainListerExpansion interface{}
@@ -13,6 +21,14 @@ type FederationDomainListerExpansion interface{}
 // FederationDomainNamespaceLister.
 type FederationDomainNamespaceListerExpansion interface{}
 
+// ForcedReauthenticationListerExpansion allows custom methods to be added to
+// ForcedReauthenticationLister.
+type ForcedReauthenticationListerExpansion interface{}
+
+// ForcedReauthenticationNamespaceListerExpansion allows custom methods to be added to
+// ForcedReauthenticationNamespaceLister.
+type ForcedReauthenticationNamespaceListerExpansion interface{}
+
 // OIDCClientListerExpansion allows custom methods to be added to
 // OIDCClientLister.
 type OIDCClientListerExpansion interface{}
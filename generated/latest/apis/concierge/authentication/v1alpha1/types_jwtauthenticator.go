@@ -30,6 +30,15 @@ type JWTAuthenticatorStatus struct {
 	// +kubebuilder:default=Pending
 	// +kubebuilder:validation:Enum=Pending;Ready;Error
 	Phase JWTAuthenticatorPhase `json:"phase,omitempty"`
+	// ObservedGeneration is the metadata.generation of the spec which was most recently observed by the
+	// controller. The conditions and phase describe that generation, so automation should wait for it to
+	// equal metadata.generation before relying on the Ready condition.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastSuccessfulAuthTimestamp is the approximate time of the most recent successful authentication by the
+	// JWTAuthenticator. It is published periodically by the Concierge, so it may be a few minutes behind.
+	// +optional
+	LastSuccessfulAuthTimestamp *metav1.Time `json:"lastSuccessfulAuthTimestamp,omitempty"`
 }
 
 // Spec for configuring a JWT authenticator.
@@ -38,6 +47,7 @@ type JWTAuthenticatorSpec struct {
 	// also used to validate the "iss" JWT claim.
 	// +kubebuilder:validation:MinLength=1
 	// +kubebuilder:validation:Pattern=`^https://`
+	// +kubebuilder:validation:XValidation:message="issuer must not have query or fragment",rule="!self.contains('?') && !self.contains('#')"
 	Issuer string `json:"issuer"`
 
 	// Audience is the required value of the "aud" JWT claim.
@@ -34,6 +34,10 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&FederationDomainList{},
 		&OIDCClient{},
 		&OIDCClientList{},
+		&ForcedReauthentication{},
+		&ForcedReauthenticationList{},
+		&ClusterAudience{},
+		&ClusterAudienceList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -0,0 +1,29 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+// HTTPConnectionPoolSpec configures the pool of HTTP connections which the Supervisor uses to make requests to an
+// identity provider. Connections are reused across logins, and HTTP/2 is used when the identity provider supports it.
+// The defaults are suitable for most identity providers, but the limits may be lowered for identity providers which
+// rate limit connections.
+type HTTPConnectionPoolSpec struct {
+	// MaxConnectionsPerHost limits the total number of connections to each host of the identity provider, including
+	// connections which are in use, idle, or being established. When the limit is reached, requests wait for a
+	// connection to become available. When omitted, the number of connections is not limited.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxConnectionsPerHost int32 `json:"maxConnectionsPerHost,omitempty"`
+
+	// MaxIdleConnectionsPerHost is the number of idle connections to keep open to each host of the identity provider
+	// for reuse by later requests. Defaults to 25 when omitted.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxIdleConnectionsPerHost int32 `json:"maxIdleConnectionsPerHost,omitempty"`
+
+	// IdleConnectionTimeoutSeconds is how long an idle connection is kept open before it is closed.
+	// Defaults to 90 seconds when omitted.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	IdleConnectionTimeoutSeconds int32 `json:"idleConnectionTimeoutSeconds,omitempty"`
+}
@@ -12,10 +12,13 @@ type IDPType string
 type IDPFlow string
 
 const (
-	IDPTypeOIDC            IDPType = "oidc"
-	IDPTypeLDAP            IDPType = "ldap"
-	IDPTypeActiveDirectory IDPType = "activedirectory"
-	IDPTypeGitHub          IDPType = "github"
+	IDPTypeOIDC              IDPType = "oidc"
+	IDPTypeLDAP              IDPType = "ldap"
+	IDPTypeActiveDirectory   IDPType = "activedirectory"
+	IDPTypeGitHub            IDPType = "github"
+	IDPTypeClientCertificate IDPType = "clientcertificate"
+	IDPTypeMock              IDPType = "mock"
+	IDPTypeOpenShift         IDPType = "openshift"
 
 	IDPFlowCLIPassword     IDPFlow = "cli_password"
 	IDPFlowBrowserAuthcode IDPFlow = "browser_authcode"
@@ -51,6 +54,14 @@ type OIDCDiscoveryResponse struct {
 // OIDCDiscoveryResponseIDPEndpoint contains the URL for the identity provider discovery endpoint.
 type OIDCDiscoveryResponseIDPEndpoint struct {
 	PinnipedIDPsEndpoint string `json:"pinniped_identity_providers_endpoint"`
+
+	// PinnipedClusterAudiencesEndpoint is the URL of the cluster audience discovery endpoint. It is not included
+	// by older Supervisors, nor in the discovery document of the previous issuer of a FederationDomain.
+	PinnipedClusterAudiencesEndpoint string `json:"pinniped_cluster_audiences_endpoint,omitempty"`
+
+	// IssuerMigratedTo is only included in the discovery document of the previous issuer of a FederationDomain
+	// whose issuer was changed. It is the new issuer, at which clients should log in again.
+	IssuerMigratedTo string `json:"issuer_migrated_to,omitempty"`
 }
 
 // IDPDiscoveryResponse is the response of a FederationDomain's identity provider discovery endpoint.
@@ -62,12 +73,28 @@ type IDPDiscoveryResponse struct {
 // PinnipedIDP describes a single identity provider as included in the response of a FederationDomain's
 // identity provider discovery endpoint.
 type PinnipedIDP struct {
-	Name  string    `json:"name"`
-	Type  IDPType   `json:"type"`
-	Flows []IDPFlow `json:"flows,omitempty"`
+	Name        string    `json:"name"`
+	Type        IDPType   `json:"type"`
+	Flows       []IDPFlow `json:"flows,omitempty"`
+	Description string    `json:"description,omitempty"`
+	IconURL     string    `json:"icon_url,omitempty"`
 }
 
 // PinnipedSupportedIDPType describes a single identity provider type.
 type PinnipedSupportedIDPType struct {
 	Type IDPType `json:"type"`
 }
+
+// ClusterAudiencesDiscoveryResponse is the response of a FederationDomain's cluster audience discovery endpoint.
+type ClusterAudiencesDiscoveryResponse struct {
+	PinnipedClusterAudiences []PinnipedClusterAudience `json:"pinniped_cluster_audiences"`
+}
+
+// PinnipedClusterAudience describes a single ClusterAudience as included in the response of a FederationDomain's
+// cluster audience discovery endpoint.
+type PinnipedClusterAudience struct {
+	Name                     string `json:"name"`
+	Audience                 string `json:"audience"`
+	Server                   string `json:"server,omitempty"`
+	CertificateAuthorityData string `json:"certificate_authority_data,omitempty"`
+}
@@ -15,6 +15,10 @@ type FakeAuthenticationV1alpha1 struct {
 	*testing.Fake
 }
 
+func (c *FakeAuthenticationV1alpha1) ClusterTokenDenylists() v1alpha1.ClusterTokenDenylistInterface {
+	return &FakeClusterTokenDenylists{c}
+}
+
 func (c *FakeAuthenticationV1alpha1) JWTAuthenticators() v1alpha1.JWTAuthenticatorInterface {
 	return &FakeJWTAuthenticators{c}
 }
@@ -7,8 +7,14 @@ package v1alpha1
 
 type ActiveDirectoryIdentityProviderExpansion interface{}
 
+type ClientCertificateIdentityProviderExpansion interface{}
+
 type GitHubIdentityProviderExpansion interface{}
 
 type LDAPIdentityProviderExpansion interface{}
 
+type MockIdentityProviderExpansion interface{}
+
 type OIDCIdentityProviderExpansion interface{}
+
+type OpenShiftIdentityProviderExpansion interface{}
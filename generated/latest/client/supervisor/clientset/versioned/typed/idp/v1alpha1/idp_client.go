@@ -16,9 +16,12 @@ import (
 type IDPV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	ActiveDirectoryIdentityProvidersGetter
+	ClientCertificateIdentityProvidersGetter
 	GitHubIdentityProvidersGetter
 	LDAPIdentityProvidersGetter
+	MockIdentityProvidersGetter
 	OIDCIdentityProvidersGetter
+	OpenShiftIdentityProvidersGetter
 }
 
 // IDPV1alpha1Client is used to interact with features provided by the idp.supervisor.pinniped.dev group.
@@ -30,6 +33,10 @@ func (c *IDPV1alpha1Client) ActiveDirectoryIdentityProviders(namespace string) A
 	return newActiveDirectoryIdentityProviders(c, namespace)
 }
 
+func (c *IDPV1alpha1Client) ClientCertificateIdentityProviders(namespace string) ClientCertificateIdentityProviderInterface {
+	return newClientCertificateIdentityProviders(c, namespace)
+}
+
 func (c *IDPV1alpha1Client) GitHubIdentityProviders(namespace string) GitHubIdentityProviderInterface {
 	return newGitHubIdentityProviders(c, namespace)
 }
@@ -38,10 +45,18 @@ func (c *IDPV1alpha1Client) LDAPIdentityProviders(namespace string) LDAPIdentity
 	return newLDAPIdentityProviders(c, namespace)
 }
 
+func (c *IDPV1alpha1Client) MockIdentityProviders(namespace string) MockIdentityProviderInterface {
+	return newMockIdentityProviders(c, namespace)
+}
+
 func (c *IDPV1alpha1Client) OIDCIdentityProviders(namespace string) OIDCIdentityProviderInterface {
 	return newOIDCIdentityProviders(c, namespace)
 }
 
+func (c *IDPV1alpha1Client) OpenShiftIdentityProviders(namespace string) OpenShiftIdentityProviderInterface {
+	return newOpenShiftIdentityProviders(c, namespace)
+}
+
 // NewForConfig creates a new IDPV1alpha1Client for the given config.
 // NewForConfig is equivalent to NewForConfigAndClient(c, httpClient),
 // where httpClient was generated with rest.HTTPClientFor(c).
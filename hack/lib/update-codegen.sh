@@ -226,9 +226,13 @@ if [[ "$KUBE_1_30_OR_NEWER" == "yes" ]]; then
 
   echo "generating API clients and openapi..."
 
+  # The clients also get apply configurations and Apply() methods, so that controllers
+  # and other tools can manage Pinniped resources using server-side apply.
+
   pushd "${OUTPUT_DIR}/apis/concierge" > /dev/null
   kube::codegen::gen_client "${OUTPUT_DIR}/apis/concierge" \
     --with-watch \
+    --with-applyconfig \
     --output-dir "${OUTPUT_DIR}/client/concierge" \
     --output-pkg "${BASE_PKG}/generated/${KUBE_MINOR_VERSION}/client/concierge" \
     --boilerplate "${ROOT}/hack/boilerplate.go.txt" 2>&1 | sed "s|^|gen-client-concierge > |"
@@ -247,6 +251,7 @@ if [[ "$KUBE_1_30_OR_NEWER" == "yes" ]]; then
   pushd "${OUTPUT_DIR}/apis/supervisor" > /dev/null
   kube::codegen::gen_client "${OUTPUT_DIR}/apis/supervisor" \
     --with-watch \
+    --with-applyconfig \
     --output-dir "${OUTPUT_DIR}/client/supervisor" \
     --output-pkg "${BASE_PKG}/generated/${KUBE_MINOR_VERSION}/client/supervisor" \
     --boilerplate "${ROOT}/hack/boilerplate.go.txt" 2>&1 | sed "s|^|gen-client-supervisor > |"
@@ -308,11 +313,12 @@ else
   echo "tidying ${OUTPUT_DIR}/apis/go.mod..."
   (cd apis && go mod tidy 2>&1 | sed "s|^|go-mod-tidy > |")
 
-  # Generate client code for our public API groups
+  # Generate client code for our public API groups, including the apply configurations
+  # which are used by the Apply() methods of the clients.
   echo "generating client code for our public API groups..."
   (cd client &&
       bash "${GOPATH}/src/k8s.io/code-generator/generate-groups.sh" \
-          "client,lister,informer" \
+          "applyconfiguration,client,lister,informer" \
           "${BASE_PKG}/generated/${KUBE_MINOR_VERSION}/client/concierge" \
           "${BASE_PKG}/generated/${KUBE_MINOR_VERSION}/apis" \
           "concierge/config:v1alpha1 concierge/authentication:v1alpha1 concierge/login:v1alpha1 concierge/identity:v1alpha1" \
@@ -320,7 +326,7 @@ else
   )
   (cd client &&
       bash "${GOPATH}/src/k8s.io/code-generator/generate-groups.sh" \
-          "client,lister,informer" \
+          "applyconfiguration,client,lister,informer" \
           "${BASE_PKG}/generated/${KUBE_MINOR_VERSION}/client/supervisor" \
           "${BASE_PKG}/generated/${KUBE_MINOR_VERSION}/apis" \
           "supervisor/config:v1alpha1 supervisor/idp:v1alpha1 supervisor/clientsecret:v1alpha1" \
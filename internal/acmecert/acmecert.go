@@ -0,0 +1,252 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package acmecert obtains TLS serving certificates from certificate authorities which implement the
+// ACME protocol (RFC 8555), such as Let's Encrypt.
+package acmecert
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/acme"
+
+	"go.pinniped.dev/internal/net/phttp"
+	"go.pinniped.dev/internal/plog"
+)
+
+const (
+	// ChallengeTypeHTTP01 and ChallengeTypeDNS01 are the types of ACME challenges which are supported.
+	ChallengeTypeHTTP01 = "http-01"
+	ChallengeTypeDNS01  = "dns-01"
+
+	userAgent = "pinniped-supervisor"
+)
+
+// Challenge is an ACME challenge which must be presented by a Solver.
+type Challenge struct {
+	// Type is the type of the challenge, i.e. ChallengeTypeHTTP01 or ChallengeTypeDNS01.
+	Type string
+	// Hostname is the hostname for which control must be proven.
+	Hostname string
+	// Token is the token of the challenge, as chosen by the certificate authority.
+	Token string
+	// Value is the key authorization which must be served for an HTTP-01 challenge, or the value of the
+	// TXT record which must be published for a DNS-01 challenge.
+	Value string
+}
+
+// Solver presents the responses to ACME challenges of one type.
+type Solver interface {
+	// ChallengeType returns the type of the challenges which can be presented by this Solver.
+	ChallengeType() string
+	// Present makes the response to the challenge available to the certificate authority.
+	Present(ctx context.Context, challenge *Challenge) error
+	// CleanUp removes the response to the challenge after the challenge was completed.
+	CleanUp(ctx context.Context, challenge *Challenge) error
+}
+
+// Request describes a certificate which should be obtained.
+type Request struct {
+	// DirectoryURL is the URL of the ACME directory of the certificate authority.
+	DirectoryURL string
+	// Email is the optional contact email address of the ACME account.
+	Email string
+	// AccountKey is the private key of the ACME account. The account is registered when it does not exist yet.
+	AccountKey crypto.Signer
+	// Hostname is the DNS name or IP address for which the certificate is requested.
+	Hostname string
+	// Solver presents the responses to the challenges of the certificate authority.
+	Solver Solver
+	// HTTPClient is used to make requests to the certificate authority. Defaults to phttp.Default(nil).
+	HTTPClient *http.Client
+}
+
+// Certificate is a certificate which was obtained from a certificate authority.
+type Certificate struct {
+	// CertificatePEM is the certificate chain, starting with the leaf certificate.
+	CertificatePEM []byte
+	// PrivateKeyPEM is the private key of the leaf certificate.
+	PrivateKeyPEM []byte
+	// NotBefore and NotAfter are the validity period of the leaf certificate.
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+// GenerateAccountKey returns a new private key for an ACME account, in PEM format.
+func GenerateAccountKey() ([]byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate ACME account key: %w", err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode ACME account key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+}
+
+// ParseAccountKey parses a private key which was returned by GenerateAccountKey.
+func ParseAccountKey(keyPEM []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil || block.Type != "EC PRIVATE KEY" {
+		return nil, errors.New("ACME account key is not a PEM encoded EC private key")
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse ACME account key: %w", err)
+	}
+	return key, nil
+}
+
+// Obtain registers the ACME account if needed, proves control of the hostname using the Solver,
+// and returns the certificate which was issued for the hostname.
+func Obtain(ctx context.Context, req *Request) (*Certificate, error) {
+	httpClient := req.HTTPClient
+	if httpClient == nil {
+		httpClient = phttp.Default(nil)
+	}
+	client := &acme.Client{
+		Key:          req.AccountKey,
+		DirectoryURL: req.DirectoryURL,
+		HTTPClient:   httpClient,
+		UserAgent:    userAgent,
+	}
+
+	account := &acme.Account{}
+	if req.Email != "" {
+		account.Contact = []string{"mailto:" + req.Email}
+	}
+	// The terms of service were accepted by the admin in the spec of the FederationDomain.
+	if _, err := client.Register(ctx, account, acme.AcceptTOS); err != nil && !errors.Is(err, acme.ErrAccountAlreadyExists) {
+		return nil, fmt.Errorf("could not register ACME account: %w", err)
+	}
+
+	ids := acme.DomainIDs(req.Hostname)
+	if net.ParseIP(req.Hostname) != nil {
+		ids = acme.IPIDs(req.Hostname)
+	}
+	order, err := client.AuthorizeOrder(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("could not create ACME order: %w", err)
+	}
+
+	for _, authzURL := range order.AuthzURLs {
+		if err := authorize(ctx, client, authzURL, req.Hostname, req.Solver); err != nil {
+			return nil, err
+		}
+	}
+
+	order, err = client.WaitOrder(ctx, order.URI)
+	if err != nil {
+		return nil, fmt.Errorf("ACME order did not become ready: %w", err)
+	}
+
+	certKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate certificate key: %w", err)
+	}
+	csrTemplate := &x509.CertificateRequest{}
+	if ip := net.ParseIP(req.Hostname); ip != nil {
+		csrTemplate.IPAddresses = []net.IP{ip}
+	} else {
+		csrTemplate.DNSNames = []string{req.Hostname}
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, csrTemplate, certKey)
+	if err != nil {
+		return nil, fmt.Errorf("could not create certificate signing request: %w", err)
+	}
+
+	chain, _, err := client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return nil, fmt.Errorf("could not finalize ACME order: %w", err)
+	}
+	if len(chain) == 0 {
+		return nil, errors.New("ACME server returned an empty certificate chain")
+	}
+	leaf, err := x509.ParseCertificate(chain[0])
+	if err != nil {
+		return nil, fmt.Errorf("ACME server returned an invalid certificate: %w", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(certKey)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode certificate key: %w", err)
+	}
+	var certPEM []byte
+	for _, der := range chain {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+
+	return &Certificate{
+		CertificatePEM: certPEM,
+		PrivateKeyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		NotBefore:      leaf.NotBefore,
+		NotAfter:       leaf.NotAfter,
+	}, nil
+}
+
+// authorize completes one authorization of an order, unless it is already valid.
+func authorize(ctx context.Context, client *acme.Client, authzURL, hostname string, solver Solver) error {
+	authz, err := client.GetAuthorization(ctx, authzURL)
+	if err != nil {
+		return fmt.Errorf("could not get ACME authorization: %w", err)
+	}
+	if authz.Status == acme.StatusValid {
+		return nil
+	}
+
+	var acmeChallenge *acme.Challenge
+	for _, c := range authz.Challenges {
+		if c.Type == solver.ChallengeType() {
+			acmeChallenge = c
+			break
+		}
+	}
+	if acmeChallenge == nil {
+		return fmt.Errorf("ACME server did not offer a %s challenge for %q", solver.ChallengeType(), hostname)
+	}
+
+	challenge := &Challenge{Type: acmeChallenge.Type, Hostname: hostname, Token: acmeChallenge.Token}
+	switch acmeChallenge.Type {
+	case ChallengeTypeHTTP01:
+		challenge.Value, err = client.HTTP01ChallengeResponse(acmeChallenge.Token)
+	case ChallengeTypeDNS01:
+		challenge.Value, err = client.DNS01ChallengeRecord(acmeChallenge.Token)
+	default:
+		err = fmt.Errorf("unsupported challenge type %q", acmeChallenge.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("could not compute ACME challenge response: %w", err)
+	}
+
+	if err := solver.Present(ctx, challenge); err != nil {
+		return fmt.Errorf("could not present ACME %s challenge: %w", challenge.Type, err)
+	}
+	defer func() {
+		// Use a fresh context, because the challenge should be cleaned up even when ctx was canceled.
+		cleanUpCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := solver.CleanUp(cleanUpCtx, challenge); err != nil {
+			plog.WarningErr("could not clean up ACME challenge", err, "hostname", hostname, "type", challenge.Type)
+		}
+	}()
+
+	if _, err := client.Accept(ctx, acmeChallenge); err != nil {
+		return fmt.Errorf("could not accept ACME %s challenge: %w", challenge.Type, err)
+	}
+	if _, err := client.WaitAuthorization(ctx, authz.URI); err != nil {
+		return fmt.Errorf("ACME %s challenge for %q failed: %w", challenge.Type, hostname, err)
+	}
+	return nil
+}
@@ -0,0 +1,362 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package acmecert
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeACMEServer implements just enough of RFC 8555 for Obtain. It does not verify the signatures of requests.
+type fakeACMEServer struct {
+	t      *testing.T
+	server *httptest.Server
+
+	caKey  *ecdsa.PrivateKey
+	caCert *x509.Certificate
+
+	// failAuthorization makes the authorization invalid after its challenge was accepted.
+	failAuthorization bool
+	// offeredChallengeTypes are the types of the challenges offered for the authorization.
+	offeredChallengeTypes []string
+
+	mu                    sync.Mutex
+	registered            bool
+	orderedIdentifiers    []map[string]string
+	acceptedChallengeType string
+	issuedChain           []byte
+}
+
+func newFakeACMEServer(t *testing.T) *fakeACMEServer {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fake ACME CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	s := &fakeACMEServer{
+		t:                     t,
+		caKey:                 caKey,
+		caCert:                caCert,
+		offeredChallengeTypes: []string{ChallengeTypeHTTP01, ChallengeTypeDNS01},
+	}
+	s.server = httptest.NewTLSServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+func (s *fakeACMEServer) url(path string) string { return s.server.URL + path }
+
+func (s *fakeACMEServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set("Replay-Nonce", "some-nonce")
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.URL.Path {
+	case "/directory":
+		s.writeJSON(w, http.StatusOK, map[string]any{
+			"newNonce":   s.url("/nonce"),
+			"newAccount": s.url("/account"),
+			"newOrder":   s.url("/order"),
+		})
+	case "/nonce":
+		w.WriteHeader(http.StatusOK)
+	case "/account":
+		w.Header().Set("Location", s.url("/account/1"))
+		status := http.StatusCreated
+		if s.registered {
+			status = http.StatusOK
+		}
+		s.registered = true
+		s.writeJSON(w, status, map[string]any{"status": "valid"})
+	case "/order":
+		var payload struct {
+			Identifiers []map[string]string `json:"identifiers"`
+		}
+		s.decodePayload(r, &payload)
+		s.orderedIdentifiers = payload.Identifiers
+		w.Header().Set("Location", s.url("/order/1"))
+		s.writeJSON(w, http.StatusCreated, s.order("pending"))
+	case "/order/1":
+		s.writeJSON(w, http.StatusOK, s.order("ready"))
+	case "/authz/1":
+		s.writeJSON(w, http.StatusOK, s.authorization())
+	case "/challenge/" + ChallengeTypeHTTP01, "/challenge/" + ChallengeTypeDNS01:
+		s.acceptedChallengeType = r.URL.Path[len("/challenge/"):]
+		s.writeJSON(w, http.StatusOK, map[string]any{
+			"type": s.acceptedChallengeType, "url": s.url(r.URL.Path), "token": "some-token", "status": "processing",
+		})
+	case "/finalize/1":
+		var payload struct {
+			CSR string `json:"csr"`
+		}
+		s.decodePayload(r, &payload)
+		s.issue(payload.CSR)
+		s.writeJSON(w, http.StatusOK, s.order("valid"))
+	case "/certificate/1":
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		_, _ = w.Write(s.issuedChain)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *fakeACMEServer) order(status string) map[string]any {
+	order := map[string]any{
+		"status":         status,
+		"identifiers":    s.orderedIdentifiers,
+		"authorizations": []string{s.url("/authz/1")},
+		"finalize":       s.url("/finalize/1"),
+	}
+	if status == "valid" {
+		order["certificate"] = s.url("/certificate/1")
+	}
+	return order
+}
+
+func (s *fakeACMEServer) authorization() map[string]any {
+	status := "pending"
+	if s.acceptedChallengeType != "" {
+		status = "valid"
+		if s.failAuthorization {
+			status = "invalid"
+		}
+	}
+	challenges := []map[string]any{}
+	for _, challengeType := range s.offeredChallengeTypes {
+		challenges = append(challenges, map[string]any{
+			"type": challengeType, "url": s.url("/challenge/" + challengeType), "token": "some-token", "status": "pending",
+		})
+	}
+	return map[string]any{
+		"status":     status,
+		"identifier": s.orderedIdentifiers[0],
+		"challenges": challenges,
+	}
+}
+
+func (s *fakeACMEServer) issue(csrBase64 string) {
+	csrDER, err := base64.RawURLEncoding.DecodeString(csrBase64)
+	require.NoError(s.t, err)
+	csr, err := x509.ParseCertificateRequest(csrDER)
+	require.NoError(s.t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		DNSNames:     csr.DNSNames,
+		IPAddresses:  csr.IPAddresses,
+		NotBefore:    time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, template, s.caCert, csr.PublicKey, s.caKey)
+	require.NoError(s.t, err)
+	s.issuedChain = append(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.caCert.Raw})...,
+	)
+}
+
+func (s *fakeACMEServer) decodePayload(r *http.Request, into any) {
+	var jws struct {
+		Payload string `json:"payload"`
+	}
+	require.NoError(s.t, json.NewDecoder(r.Body).Decode(&jws))
+	payload, err := base64.RawURLEncoding.DecodeString(jws.Payload)
+	require.NoError(s.t, err)
+	require.NoError(s.t, json.Unmarshal(payload, into))
+}
+
+func (s *fakeACMEServer) writeJSON(w http.ResponseWriter, status int, body any) {
+	w.WriteHeader(status)
+	require.NoError(s.t, json.NewEncoder(w).Encode(body))
+}
+
+type recordingSolver struct {
+	challengeType string
+	presentErr    error
+
+	presented []Challenge
+	cleanedUp []Challenge
+}
+
+func (s *recordingSolver) ChallengeType() string { return s.challengeType }
+
+func (s *recordingSolver) Present(_ context.Context, challenge *Challenge) error {
+	s.presented = append(s.presented, *challenge)
+	return s.presentErr
+}
+
+func (s *recordingSolver) CleanUp(_ context.Context, challenge *Challenge) error {
+	s.cleanedUp = append(s.cleanedUp, *challenge)
+	return nil
+}
+
+func TestObtain(t *testing.T) {
+	accountKeyPEM, err := GenerateAccountKey()
+	require.NoError(t, err)
+	accountKey, err := ParseAccountKey(accountKeyPEM)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name                  string
+		hostname              string
+		challengeType         string
+		alreadyRegistered     bool
+		failAuthorization     bool
+		offeredChallengeTypes []string
+		presentErr            error
+		wantIdentifier        map[string]string
+		wantErr               string
+		wantPresented         bool
+	}{
+		{
+			name:           "HTTP-01 challenge for a DNS name",
+			hostname:       "issuer.example.com",
+			challengeType:  ChallengeTypeHTTP01,
+			wantIdentifier: map[string]string{"type": "dns", "value": "issuer.example.com"},
+			wantPresented:  true,
+		},
+		{
+			name:              "DNS-01 challenge with an account which was already registered",
+			hostname:          "issuer.example.com",
+			challengeType:     ChallengeTypeDNS01,
+			alreadyRegistered: true,
+			wantIdentifier:    map[string]string{"type": "dns", "value": "issuer.example.com"},
+			wantPresented:     true,
+		},
+		{
+			name:           "HTTP-01 challenge for an IP address",
+			hostname:       "10.1.2.3",
+			challengeType:  ChallengeTypeHTTP01,
+			wantIdentifier: map[string]string{"type": "ip", "value": "10.1.2.3"},
+			wantPresented:  true,
+		},
+		{
+			name:                  "challenge type is not offered",
+			hostname:              "issuer.example.com",
+			challengeType:         ChallengeTypeDNS01,
+			offeredChallengeTypes: []string{ChallengeTypeHTTP01},
+			wantIdentifier:        map[string]string{"type": "dns", "value": "issuer.example.com"},
+			wantErr:               `ACME server did not offer a dns-01 challenge for "issuer.example.com"`,
+		},
+		{
+			name:           "solver cannot present the challenge",
+			hostname:       "issuer.example.com",
+			challengeType:  ChallengeTypeHTTP01,
+			presentErr:     errors.New("some error"),
+			wantIdentifier: map[string]string{"type": "dns", "value": "issuer.example.com"},
+			wantErr:        "could not present ACME http-01 challenge: some error",
+			wantPresented:  true,
+		},
+		{
+			name:              "authorization fails",
+			hostname:          "issuer.example.com",
+			challengeType:     ChallengeTypeHTTP01,
+			failAuthorization: true,
+			wantIdentifier:    map[string]string{"type": "dns", "value": "issuer.example.com"},
+			wantErr:           `ACME http-01 challenge for "issuer.example.com" failed: `,
+			wantPresented:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			server := newFakeACMEServer(t)
+			server.registered = tt.alreadyRegistered
+			server.failAuthorization = tt.failAuthorization
+			if tt.offeredChallengeTypes != nil {
+				server.offeredChallengeTypes = tt.offeredChallengeTypes
+			}
+			solver := &recordingSolver{challengeType: tt.challengeType, presentErr: tt.presentErr}
+
+			certificate, err := Obtain(ctx, &Request{
+				DirectoryURL: server.url("/directory"),
+				Email:        "admin@example.com",
+				AccountKey:   accountKey,
+				Hostname:     tt.hostname,
+				Solver:       solver,
+				HTTPClient:   server.server.Client(),
+			})
+
+			require.Equal(t, []map[string]string{tt.wantIdentifier}, server.orderedIdentifiers)
+			if tt.wantPresented {
+				require.Len(t, solver.presented, 1)
+				require.Equal(t, tt.challengeType, solver.presented[0].Type)
+				require.Equal(t, tt.hostname, solver.presented[0].Hostname)
+				require.Equal(t, "some-token", solver.presented[0].Token)
+				require.NotEmpty(t, solver.presented[0].Value)
+			} else {
+				require.Empty(t, solver.presented)
+			}
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				require.Nil(t, certificate)
+				if tt.presentErr == nil {
+					require.Equal(t, solver.presented, solver.cleanedUp)
+				}
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, solver.presented, solver.cleanedUp)
+			require.Equal(t, tt.challengeType, server.acceptedChallengeType)
+
+			keyPair, err := tls.X509KeyPair(certificate.CertificatePEM, certificate.PrivateKeyPEM)
+			require.NoError(t, err)
+			require.Len(t, keyPair.Certificate, 2)
+			leaf, err := x509.ParseCertificate(keyPair.Certificate[0])
+			require.NoError(t, err)
+			require.NoError(t, leaf.VerifyHostname(tt.hostname))
+			require.Equal(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), certificate.NotBefore)
+			require.Equal(t, time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC), certificate.NotAfter)
+		})
+	}
+}
+
+func TestParseAccountKey(t *testing.T) {
+	keyPEM, err := GenerateAccountKey()
+	require.NoError(t, err)
+	key, err := ParseAccountKey(keyPEM)
+	require.NoError(t, err)
+	require.IsType(t, &ecdsa.PrivateKey{}, key)
+
+	_, err = ParseAccountKey([]byte("not a key"))
+	require.EqualError(t, err, "ACME account key is not a PEM encoded EC private key")
+
+	_, err = ParseAccountKey(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: []byte("garbage")}))
+	require.ErrorContains(t, err, "could not parse ACME account key: ")
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package acmecert
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.pinniped.dev/internal/net/phttp"
+)
+
+const (
+	// DNS01WebhookAPIVersion is sent in each request to a DNS-01 webhook, so that the webhook can detect future
+	// changes to the format of the requests.
+	DNS01WebhookAPIVersion = "acme.supervisor.pinniped.dev/v1alpha1"
+
+	// DNS01WebhookActionPresent and DNS01WebhookActionCleanUp are the actions which are requested from a DNS-01 webhook.
+	DNS01WebhookActionPresent = "present"
+	DNS01WebhookActionCleanUp = "cleanup"
+
+	// dns01WebhookTimeout is long because the webhook should only respond after the TXT record was published.
+	dns01WebhookTimeout = 5 * time.Minute
+)
+
+// DNS01WebhookRequest is the JSON body which is sent to a DNS-01 webhook.
+type DNS01WebhookRequest struct {
+	APIVersion string `json:"apiVersion"`
+
+	// Action is either DNS01WebhookActionPresent or DNS01WebhookActionCleanUp.
+	Action string `json:"action"`
+	// FQDN is the fully qualified name of the TXT record, including the trailing dot.
+	FQDN string `json:"fqdn"`
+	// Value is the value of the TXT record.
+	Value string `json:"value"`
+}
+
+// NewDNS01WebhookSolver returns a Solver for DNS-01 challenges which delegates the publication of the TXT records
+// to a webhook. The webhook must respond with a 2xx status code once the request was fulfilled.
+func NewDNS01WebhookSolver(endpoint, certificateAuthorityData string) (Solver, error) {
+	var rootCAs *x509.CertPool
+	if certificateAuthorityData != "" {
+		bundle, err := base64.StdEncoding.DecodeString(certificateAuthorityData)
+		if err != nil {
+			return nil, fmt.Errorf("certificateAuthorityData is not valid base64: %w", err)
+		}
+		rootCAs = x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(bundle) {
+			return nil, errors.New("certificateAuthorityData does not contain any valid PEM certificates")
+		}
+	}
+
+	client := phttp.Default(rootCAs)
+	client.Timeout = dns01WebhookTimeout
+	return &dns01WebhookSolver{endpoint: endpoint, client: client}, nil
+}
+
+type dns01WebhookSolver struct {
+	endpoint string
+	client   *http.Client
+}
+
+func (s *dns01WebhookSolver) ChallengeType() string { return ChallengeTypeDNS01 }
+
+func (s *dns01WebhookSolver) Present(ctx context.Context, challenge *Challenge) error {
+	return s.call(ctx, DNS01WebhookActionPresent, challenge)
+}
+
+func (s *dns01WebhookSolver) CleanUp(ctx context.Context, challenge *Challenge) error {
+	return s.call(ctx, DNS01WebhookActionCleanUp, challenge)
+}
+
+func (s *dns01WebhookSolver) call(ctx context.Context, action string, challenge *Challenge) error {
+	body, err := json.Marshal(&DNS01WebhookRequest{
+		APIVersion: DNS01WebhookAPIVersion,
+		Action:     action,
+		FQDN:       "_acme-challenge." + challenge.Hostname + ".",
+		Value:      challenge.Value,
+	})
+	if err != nil {
+		return fmt.Errorf("could not encode DNS-01 webhook request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("could not build DNS-01 webhook request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := s.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("could not call DNS-01 webhook: %w", err)
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		return fmt.Errorf("DNS-01 webhook returned unexpected status code %d", httpResp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package acmecert
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go.pinniped.dev/internal/testutil/tlsserver"
+)
+
+func TestDNS01WebhookSolver(t *testing.T) {
+	var requests []DNS01WebhookRequest
+	status := http.StatusOK
+	server, serverCA := tlsserver.TestServerIPv4(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var req DNS01WebhookRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests = append(requests, req)
+		w.WriteHeader(status)
+	}), nil)
+	caData := base64.StdEncoding.EncodeToString(serverCA)
+
+	solver, err := NewDNS01WebhookSolver(server.URL, caData)
+	require.NoError(t, err)
+	require.Equal(t, ChallengeTypeDNS01, solver.ChallengeType())
+
+	challenge := &Challenge{Type: ChallengeTypeDNS01, Hostname: "issuer.example.com", Token: "some-token", Value: "some-record"}
+	require.NoError(t, solver.Present(context.Background(), challenge))
+	require.NoError(t, solver.CleanUp(context.Background(), challenge))
+	require.Equal(t, []DNS01WebhookRequest{
+		{APIVersion: DNS01WebhookAPIVersion, Action: "present", FQDN: "_acme-challenge.issuer.example.com.", Value: "some-record"},
+		{APIVersion: DNS01WebhookAPIVersion, Action: "cleanup", FQDN: "_acme-challenge.issuer.example.com.", Value: "some-record"},
+	}, requests)
+
+	status = http.StatusInternalServerError
+	require.EqualError(t, solver.Present(context.Background(), challenge), "DNS-01 webhook returned unexpected status code 500")
+
+	untrustingSolver, err := NewDNS01WebhookSolver(server.URL, "")
+	require.NoError(t, err)
+	require.ErrorContains(t, untrustingSolver.Present(context.Background(), challenge), "could not call DNS-01 webhook: ")
+
+	_, err = NewDNS01WebhookSolver(server.URL, "not base64!")
+	require.ErrorContains(t, err, "certificateAuthorityData is not valid base64: ")
+
+	_, err = NewDNS01WebhookSolver(server.URL, base64.StdEncoding.EncodeToString([]byte("not PEM")))
+	require.EqualError(t, err, "certificateAuthorityData does not contain any valid PEM certificates")
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package acmecert
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/util/retry"
+
+	"go.pinniped.dev/internal/plog"
+)
+
+const (
+	// SecretType is the type of the Secrets which hold the ACME state of a FederationDomain, including the
+	// responses to its pending HTTP-01 challenges.
+	SecretType corev1.SecretType = "secrets.pinniped.dev/federation-domain-acme"
+
+	// http01ChallengeKeyPrefix is the prefix of the keys of the Secret data which hold the responses to HTTP-01
+	// challenges. The rest of the key is the token of the challenge.
+	http01ChallengeKeyPrefix = "http01-challenge."
+
+	http01ChallengePathPrefix = "/.well-known/acme-challenge/"
+
+	// http01CachePollInterval and http01CacheTimeout bound how long Present waits for the response to a challenge
+	// to be observed by the informer cache which is used by the HTTP01Handler.
+	http01CachePollInterval = 100 * time.Millisecond
+	http01CacheTimeout      = 30 * time.Second
+)
+
+// tokenRegexp matches the base64url alphabet which is used by ACME tokens, which is also safe to use in the
+// keys of the data of a Secret.
+var tokenRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`) //nolint:gochecknoglobals
+
+// NewHTTP01SecretSolver returns a Solver for HTTP-01 challenges which stores the responses to the challenges in
+// the named Secret of type SecretType, from where they are served by the HTTP01Handler of every Supervisor pod.
+// The Secret must already exist.
+func NewHTTP01SecretSolver(secrets corev1client.SecretInterface, secretLister corev1listers.SecretNamespaceLister, secretName string) Solver {
+	return &http01SecretSolver{secrets: secrets, secretLister: secretLister, secretName: secretName}
+}
+
+type http01SecretSolver struct {
+	secrets      corev1client.SecretInterface
+	secretLister corev1listers.SecretNamespaceLister
+	secretName   string
+}
+
+func (s *http01SecretSolver) ChallengeType() string { return ChallengeTypeHTTP01 }
+
+func (s *http01SecretSolver) Present(ctx context.Context, challenge *Challenge) error {
+	if !tokenRegexp.MatchString(challenge.Token) {
+		return fmt.Errorf("invalid HTTP-01 challenge token %q", challenge.Token)
+	}
+	key := http01ChallengeKeyPrefix + challenge.Token
+	err := s.updateSecret(ctx, func(data map[string][]byte) {
+		data[key] = []byte(challenge.Value)
+	})
+	if err != nil {
+		return err
+	}
+
+	// The HTTP01Handler reads from the informer cache, so wait until the response can be served.
+	return wait.PollUntilContextTimeout(ctx, http01CachePollInterval, http01CacheTimeout, true, func(_ context.Context) (bool, error) {
+		secret, err := s.secretLister.Get(s.secretName)
+		if err != nil {
+			return false, nil //nolint:nilerr // keep waiting for the cache
+		}
+		return string(secret.Data[key]) == challenge.Value, nil
+	})
+}
+
+func (s *http01SecretSolver) CleanUp(ctx context.Context, challenge *Challenge) error {
+	return s.updateSecret(ctx, func(data map[string][]byte) {
+		delete(data, http01ChallengeKeyPrefix+challenge.Token)
+	})
+}
+
+func (s *http01SecretSolver) updateSecret(ctx context.Context, mutate func(data map[string][]byte)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret, err := s.secrets.Get(ctx, s.secretName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("could not get secret %q: %w", s.secretName, err)
+		}
+		if secret.Type != SecretType {
+			return fmt.Errorf("secret %q does not have type %q", s.secretName, SecretType)
+		}
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		mutate(secret.Data)
+		_, err = s.secrets.Update(ctx, secret, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// NewHTTP01Handler returns an http.Handler which serves the responses to the HTTP-01 challenges which were
+// presented by the HTTP-01 Solvers of any Supervisor pod. It responds with 404 to all other requests.
+func NewHTTP01Handler(secretLister corev1listers.SecretNamespaceLister) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.URL.Path, http01ChallengePathPrefix)
+		if !ok || !tokenRegexp.MatchString(token) {
+			http.NotFound(w, r)
+			return
+		}
+
+		secrets, err := secretLister.List(labels.Everything())
+		if err != nil {
+			plog.WarningErr("could not list secrets to serve ACME HTTP-01 challenge", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		for _, secret := range secrets {
+			if secret.Type != SecretType {
+				continue
+			}
+			if value, ok := secret.Data[http01ChallengeKeyPrefix+token]; ok {
+				w.Header().Set("Content-Type", "text/plain")
+				_, _ = w.Write(value)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	})
+}
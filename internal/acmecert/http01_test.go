@@ -0,0 +1,84 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package acmecert
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestHTTP01SecretSolverAndHandler(t *testing.T) {
+	const namespace = "some-namespace"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	kubeClient := kubernetesfake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "some-fd-acme", Namespace: namespace},
+			Type:       SecretType,
+			Data:       map[string][]byte{"accountKey": []byte("some-key")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-secret", Namespace: namespace},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       map[string][]byte{http01ChallengeKeyPrefix + "other-token": []byte("must not be served")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "wrong-type", Namespace: namespace},
+			Type:       corev1.SecretTypeOpaque,
+		},
+	)
+	informers := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 0, kubeinformers.WithNamespace(namespace))
+	secretInformer := informers.Core().V1().Secrets()
+	secretLister := secretInformer.Lister().Secrets(namespace)
+	informers.Start(ctx.Done())
+	require.True(t, cache.WaitForCacheSync(ctx.Done(), secretInformer.Informer().HasSynced))
+
+	handler := NewHTTP01Handler(secretLister)
+	get := func(method, path string) *httptest.ResponseRecorder {
+		rsp := httptest.NewRecorder()
+		handler.ServeHTTP(rsp, httptest.NewRequest(method, path, nil))
+		return rsp
+	}
+
+	solver := NewHTTP01SecretSolver(kubeClient.CoreV1().Secrets(namespace), secretLister, "some-fd-acme")
+	require.Equal(t, ChallengeTypeHTTP01, solver.ChallengeType())
+	challenge := &Challenge{Type: ChallengeTypeHTTP01, Hostname: "issuer.example.com", Token: "some-token", Value: "some-token.some-thumbprint"}
+
+	require.Equal(t, http.StatusNotFound, get(http.MethodGet, "/.well-known/acme-challenge/some-token").Code)
+
+	require.NoError(t, solver.Present(ctx, challenge))
+
+	rsp := get(http.MethodGet, "/.well-known/acme-challenge/some-token")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	require.Equal(t, "some-token.some-thumbprint", rsp.Body.String())
+	require.Equal(t, "text/plain", rsp.Header().Get("Content-Type"))
+
+	require.Equal(t, http.StatusNotFound, get(http.MethodGet, "/.well-known/acme-challenge/other-token").Code)
+	require.Equal(t, http.StatusNotFound, get(http.MethodGet, "/.well-known/acme-challenge/some.token").Code)
+	require.Equal(t, http.StatusNotFound, get(http.MethodGet, "/some-token").Code)
+	require.Equal(t, http.StatusMethodNotAllowed, get(http.MethodPost, "/.well-known/acme-challenge/some-token").Code)
+
+	require.NoError(t, solver.CleanUp(ctx, challenge))
+	secret, err := kubeClient.CoreV1().Secrets(namespace).Get(ctx, "some-fd-acme", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"accountKey": []byte("some-key")}, secret.Data)
+
+	require.EqualError(t, solver.Present(ctx, &Challenge{Token: "bad/token"}), `invalid HTTP-01 challenge token "bad/token"`)
+
+	wrongTypeSolver := NewHTTP01SecretSolver(kubeClient.CoreV1().Secrets(namespace), secretLister, "wrong-type")
+	require.EqualError(t, wrongTypeSolver.Present(ctx, challenge),
+		`secret "wrong-type" does not have type "secrets.pinniped.dev/federation-domain-acme"`)
+}
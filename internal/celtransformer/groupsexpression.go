@@ -0,0 +1,99 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package celtransformer
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+)
+
+const claimsVariableName = "claims"
+
+//nolint:gochecknoglobals // the environment is immutable and expensive to create, so it is created once
+var claimsEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(
+		// The claims of an upstream OIDC identity provider may contain values of any JSON type.
+		cel.Variable(claimsVariableName, cel.MapType(cel.StringType, cel.DynType)),
+		ext.Strings(),
+		cel.DefaultUTCTimeZone(true),
+		cel.EagerlyValidateDeclarations(true),
+	)
+})
+
+// GroupsExpression is a compiled CEL expression which computes the groups of an identity from the claims of an
+// upstream OIDC identity provider, e.g. to flatten nested claims such as the client roles of Keycloak.
+// It is stateless and thread-safe.
+type GroupsExpression struct {
+	expression           string
+	program              cel.Program
+	maxExpressionRuntime time.Duration
+}
+
+// CompileGroupsExpression compiles a CEL expression which can refer to the claims as the map variable "claims",
+// and which returns a list of strings.
+func CompileGroupsExpression(expr string, maxExpressionRuntime time.Duration) (*GroupsExpression, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, fmt.Errorf("cannot compile empty CEL expression")
+	}
+
+	env, err := claimsEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	ast, issues := env.Compile(expr)
+	if issues != nil {
+		return nil, fmt.Errorf("CEL expression compile error: %s", issues.String())
+	}
+
+	// Values which are selected from the claims have type dyn, so their type can only be checked at evaluation time.
+	switch ast.OutputType().String() {
+	case cel.ListType(cel.StringType).String(), cel.ListType(cel.DynType).String(), cel.DynType.String():
+	default:
+		return nil, fmt.Errorf("CEL expression should return type %q but returns type %q", cel.ListType(cel.StringType), ast.OutputType())
+	}
+
+	program, err := env.Program(ast,
+		cel.InterruptCheckFrequency(100),
+		cel.EvalOptions(cel.OptOptimize),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("CEL expression program construction error: %w", err)
+	}
+
+	return &GroupsExpression{expression: expr, program: program, maxExpressionRuntime: maxExpressionRuntime}, nil
+}
+
+// Expression returns the source of the compiled expression.
+func (e *GroupsExpression) Expression() string {
+	return e.expression
+}
+
+// EvaluateGroups returns the groups which are computed by the expression from the given claims.
+func (e *GroupsExpression) EvaluateGroups(ctx context.Context, claims map[string]any) ([]string, error) {
+	// Limit the runtime of a CEL expression to avoid accidental very expensive expressions.
+	timeoutCtx, cancel := context.WithTimeout(ctx, e.maxExpressionRuntime)
+	defer cancel()
+
+	val, _, err := e.program.ContextEval(timeoutCtx, map[string]any{claimsVariableName: claims})
+	if err != nil {
+		return nil, err
+	}
+	nativeValue, err := val.ConvertToNative(reflect.TypeOf([]string{}))
+	if err != nil {
+		return nil, fmt.Errorf("could not convert expression result to []string: %w", err)
+	}
+	stringSliceValue, ok := nativeValue.([]string)
+	if !ok {
+		return nil, fmt.Errorf("could not convert expression result to []string")
+	}
+	return stringSliceValue, nil
+}
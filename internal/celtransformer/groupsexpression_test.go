@@ -0,0 +1,95 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package celtransformer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupsExpression(t *testing.T) {
+	keycloakClaims := map[string]any{
+		"sub": "some-subject",
+		"realm_access": map[string]any{
+			"roles": []any{"offline_access", "uma_authorization"},
+		},
+		"resource_access": map[string]any{
+			"kubernetes": map[string]any{"roles": []any{"admin", "viewer"}},
+			"account":    map[string]any{"roles": []any{"manage-account"}},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		claims     map[string]any
+		wantGroups []string
+		wantErr    string
+	}{
+		{
+			name:       "client roles of Keycloak",
+			expression: `claims.resource_access.kubernetes.roles`,
+			claims:     keycloakClaims,
+			wantGroups: []string{"admin", "viewer"},
+		},
+		{
+			name:       "concatenate realm roles and client roles",
+			expression: `claims.realm_access.roles + claims.resource_access.kubernetes.roles.map(role, "kubernetes:" + role)`,
+			claims:     keycloakClaims,
+			wantGroups: []string{"offline_access", "uma_authorization", "kubernetes:admin", "kubernetes:viewer"},
+		},
+		{
+			name:       "absent claim is handled with has()",
+			expression: `has(claims.resource_access) ? claims.resource_access.kubernetes.roles : []`,
+			claims:     map[string]any{"sub": "some-subject"},
+			wantGroups: []string{},
+		},
+		{
+			name:       "absent claim is an error without has()",
+			expression: `claims.resource_access.kubernetes.roles`,
+			claims:     map[string]any{"sub": "some-subject"},
+			wantErr:    "no such key: resource_access",
+		},
+		{
+			name:       "result is not a list of strings at evaluation time",
+			expression: `claims.sub`,
+			claims:     keycloakClaims,
+			wantErr:    "could not convert expression result to []string",
+		},
+		{
+			name:       "result is not a list at compile time",
+			expression: `"some-group"`,
+			wantErr:    `CEL expression should return type "list(string)" but returns type "string"`,
+		},
+		{
+			name:       "empty expression",
+			expression: "  ",
+			wantErr:    "cannot compile empty CEL expression",
+		},
+		{
+			name:       "unknown variable",
+			expression: `groups`,
+			wantErr:    "CEL expression compile error: ERROR: <input>:1:1: undeclared reference to 'groups'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expression, err := CompileGroupsExpression(tt.expression, 5*time.Second)
+			if err == nil {
+				require.Equal(t, tt.expression, expression.Expression())
+				var groups []string
+				groups, err = expression.EvaluateGroups(context.Background(), tt.claims)
+				if tt.wantErr == "" {
+					require.NoError(t, err)
+					require.Equal(t, tt.wantGroups, groups)
+					return
+				}
+			}
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package certsigner implements a ClientCertIssuer which does not hold the private key of a certificate authority.
+// Instead, it generates a private key and a certificate signing request for each client certificate, and asks a
+// Signer to sign the request, e.g. the Kubernetes CertificateSigningRequest API or an external signing service.
+package certsigner
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"go.pinniped.dev/internal/clientcertissuer"
+)
+
+// signTimeout limits how long the Signer may take to sign a single client certificate.
+const signTimeout = 30 * time.Second
+
+// Signer signs PEM-encoded PKCS#10 certificate signing requests for client certificates.
+type Signer interface {
+	// Name describes the Signer for logs and errors.
+	Name() string
+	// Sign returns the PEM-encoded certificate (chain) for the request, which should be valid for about the ttl.
+	Sign(ctx context.Context, csrPEM []byte, ttl time.Duration) (certPEM []byte, err error)
+}
+
+type issuer struct {
+	signer Signer
+}
+
+// NewClientCertIssuer returns a ClientCertIssuer which generates a new P256 keypair for each client certificate and
+// asks the Signer to sign it.
+func NewClientCertIssuer(signer Signer) clientcertissuer.ClientCertIssuer {
+	return &issuer{signer: signer}
+}
+
+func (i *issuer) Name() string {
+	return i.signer.Name()
+}
+
+// IssueClientCertPEM issues a new client certificate with the username and groups in the Kube-style certificate
+// subject, returning it as a pair of PEM-formatted byte slices for the certificate and private key.
+func (i *issuer) IssueClientCertPEM(username string, groups []string, ttl time.Duration) ([]byte, []byte, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not generate private key: %w", err)
+	}
+
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: username, Organization: groups},
+	}, privateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not create certificate signing request: %w", err)
+	}
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})
+
+	ctx, cancel := context.WithTimeout(context.Background(), signTimeout)
+	defer cancel()
+
+	certPEM, err := i.signer.Sign(ctx, csrPEM, ttl)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	privateKeyPKCS8, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal private key into PKCS8: %w", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateKeyPKCS8})
+
+	// Make sure that the signer signed our public key, so a misbehaving signer cannot cause us to return a
+	// certificate which is unusable with the private key.
+	if _, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		return nil, nil, fmt.Errorf("signer returned an invalid certificate: %w", err)
+	}
+
+	return certPEM, keyPEM, nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package certsigner
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testCA signs certificate signing requests like a real signer would.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) sign(t *testing.T, csrPEM []byte, ttl time.Duration) []byte {
+	t.Helper()
+
+	block, _ := pem.Decode(csrPEM)
+	require.NotNil(t, block)
+	require.Equal(t, "CERTIFICATE REQUEST", block.Type)
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	require.NoError(t, err)
+	require.NoError(t, csr.CheckSignature())
+
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      csr.Subject,
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(ttl),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca.cert, csr.PublicKey, ca.key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+//nolint:gochecknoglobals // Treated as a constant.
+var errFake = errors.New("some fake error")
+
+func csrForTest(t *testing.T) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "some-user", Organization: []string{"some-group"}},
+	}, key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+}
+
+type fakeSigner struct {
+	sign func(csrPEM []byte, ttl time.Duration) ([]byte, error)
+}
+
+func (f *fakeSigner) Name() string { return "fake-signer" }
+
+func (f *fakeSigner) Sign(_ context.Context, csrPEM []byte, ttl time.Duration) ([]byte, error) {
+	return f.sign(csrPEM, ttl)
+}
+
+func TestIssueClientCertPEM(t *testing.T) {
+	ca := newTestCA(t)
+
+	t.Run("success", func(t *testing.T) {
+		issuer := NewClientCertIssuer(&fakeSigner{sign: func(csrPEM []byte, ttl time.Duration) ([]byte, error) {
+			require.Equal(t, 5*time.Minute, ttl)
+			return ca.sign(t, csrPEM, ttl), nil
+		}})
+		require.Equal(t, "fake-signer", issuer.Name())
+
+		certPEM, keyPEM, err := issuer.IssueClientCertPEM("some-user", []string{"group1", "group2"}, 5*time.Minute)
+		require.NoError(t, err)
+
+		block, _ := pem.Decode(certPEM)
+		require.NotNil(t, block)
+		cert, err := x509.ParseCertificate(block.Bytes)
+		require.NoError(t, err)
+		require.Equal(t, "some-user", cert.Subject.CommonName)
+		require.Equal(t, []string{"group1", "group2"}, cert.Subject.Organization)
+		require.NoError(t, cert.CheckSignatureFrom(ca.cert))
+
+		block, _ = pem.Decode(keyPEM)
+		require.NotNil(t, block)
+		require.Equal(t, "PRIVATE KEY", block.Type)
+	})
+
+	t.Run("signer error", func(t *testing.T) {
+		issuer := NewClientCertIssuer(&fakeSigner{sign: func([]byte, time.Duration) ([]byte, error) {
+			return nil, errors.New("some signer error")
+		}})
+
+		_, _, err := issuer.IssueClientCertPEM("some-user", nil, 5*time.Minute)
+		require.EqualError(t, err, "some signer error")
+	})
+
+	t.Run("signer returns a certificate for another key", func(t *testing.T) {
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		otherCSR, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{}, otherKey)
+		require.NoError(t, err)
+
+		issuer := NewClientCertIssuer(&fakeSigner{sign: func(_ []byte, ttl time.Duration) ([]byte, error) {
+			return ca.sign(t, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: otherCSR}), ttl), nil
+		}})
+
+		_, _, err = issuer.IssueClientCertPEM("some-user", nil, 5*time.Minute)
+		require.ErrorContains(t, err, "signer returned an invalid certificate: ")
+	})
+}
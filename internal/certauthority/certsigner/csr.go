@@ -0,0 +1,120 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package certsigner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	certificatesv1client "k8s.io/client-go/kubernetes/typed/certificates/v1"
+	"k8s.io/utils/ptr"
+
+	"go.pinniped.dev/internal/plog"
+)
+
+const (
+	// DefaultCSRSignerName is the signer of the Kubernetes API server which issues client certificates that
+	// it trusts.
+	DefaultCSRSignerName = "kubernetes.io/kube-apiserver-client"
+
+	// minCSRExpiration is the shortest expirationSeconds which the Kubernetes API server accepts.
+	minCSRExpiration = 10 * time.Minute
+
+	csrPollInterval  = 250 * time.Millisecond
+	csrApproveReason = "PinnipedConciergeApproved"
+)
+
+type csrSigner struct {
+	client     certificatesv1client.CertificateSigningRequestInterface
+	signerName string
+	labels     map[string]string
+}
+
+// NewCSRSigner returns a Signer which uses the Kubernetes CertificateSigningRequest API. It creates and approves
+// a CertificateSigningRequest for the signer, waits for the signer to issue the certificate, and deletes the
+// CertificateSigningRequest. The labels are added to each CertificateSigningRequest.
+func NewCSRSigner(
+	client certificatesv1client.CertificateSigningRequestInterface,
+	signerName string,
+	labels map[string]string,
+) Signer {
+	if signerName == "" {
+		signerName = DefaultCSRSignerName
+	}
+	return &csrSigner{client: client, signerName: signerName, labels: labels}
+}
+
+func (s *csrSigner) Name() string {
+	return "certificatesigningrequest:" + s.signerName
+}
+
+func (s *csrSigner) Sign(ctx context.Context, csrPEM []byte, ttl time.Duration) ([]byte, error) {
+	// The client may use the certificate for longer than it asked for, but it would rather have a certificate than
+	// have the request rejected by the API server.
+	ttl = max(ttl, minCSRExpiration)
+
+	csr, err := s.client.Create(ctx, &certificatesv1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "pinniped-client-",
+			Labels:       s.labels,
+		},
+		Spec: certificatesv1.CertificateSigningRequestSpec{
+			Request:           csrPEM,
+			SignerName:        s.signerName,
+			ExpirationSeconds: ptr.To(int32(ttl.Seconds())),
+			Usages:            []certificatesv1.KeyUsage{certificatesv1.UsageDigitalSignature, certificatesv1.UsageClientAuth},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("could not create CertificateSigningRequest: %w", err)
+	}
+	defer func() {
+		// Use a fresh context so that the CertificateSigningRequest is deleted even when ctx timed out.
+		deleteCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := s.client.Delete(deleteCtx, csr.Name, metav1.DeleteOptions{}); err != nil {
+			plog.WarningErr("could not delete CertificateSigningRequest", err, "name", csr.Name)
+		}
+	}()
+
+	csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+		Type:           certificatesv1.CertificateApproved,
+		Status:         corev1.ConditionTrue,
+		Reason:         csrApproveReason,
+		Message:        "This CertificateSigningRequest was approved by the Pinniped Concierge for a TokenCredentialRequest.",
+		LastUpdateTime: metav1.Now(),
+	})
+	if _, err := s.client.UpdateApproval(ctx, csr.Name, csr, metav1.UpdateOptions{}); err != nil {
+		return nil, fmt.Errorf("could not approve CertificateSigningRequest %q: %w", csr.Name, err)
+	}
+
+	var certPEM []byte
+	err = wait.PollUntilContextCancel(ctx, csrPollInterval, true, func(ctx context.Context) (bool, error) {
+		current, err := s.client.Get(ctx, csr.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("could not get CertificateSigningRequest %q: %w", csr.Name, err)
+		}
+		for _, condition := range current.Status.Conditions {
+			if (condition.Type == certificatesv1.CertificateDenied || condition.Type == certificatesv1.CertificateFailed) &&
+				condition.Status == corev1.ConditionTrue {
+				return false, fmt.Errorf("CertificateSigningRequest %q was not signed: %s: %s", csr.Name, condition.Reason, condition.Message)
+			}
+		}
+		certPEM = current.Status.Certificate
+		return len(certPEM) > 0, nil
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("timed out waiting for CertificateSigningRequest %q to be signed by %s", csr.Name, s.signerName)
+		}
+		return nil, err
+	}
+
+	return certPEM, nil
+}
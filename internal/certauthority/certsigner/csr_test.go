@@ -0,0 +1,123 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package certsigner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	kubetesting "k8s.io/client-go/testing"
+)
+
+func TestCSRSigner(t *testing.T) {
+	ca := newTestCA(t)
+	labels := map[string]string{"app": "pinniped-concierge"}
+
+	tests := []struct {
+		name       string
+		signerName string
+		onApproval func(t *testing.T, csr *certificatesv1.CertificateSigningRequest)
+		wantSigner string
+		wantErr    string
+	}{
+		{
+			name: "the signer issues the certificate",
+			onApproval: func(t *testing.T, csr *certificatesv1.CertificateSigningRequest) {
+				csr.Status.Certificate = ca.sign(t, csr.Spec.Request, time.Duration(*csr.Spec.ExpirationSeconds)*time.Second)
+			},
+			wantSigner: DefaultCSRSignerName,
+		},
+		{
+			name:       "a custom signer issues the certificate",
+			signerName: "example.com/some-signer",
+			onApproval: func(t *testing.T, csr *certificatesv1.CertificateSigningRequest) {
+				csr.Status.Certificate = ca.sign(t, csr.Spec.Request, time.Duration(*csr.Spec.ExpirationSeconds)*time.Second)
+			},
+			wantSigner: "example.com/some-signer",
+		},
+		{
+			name: "the signer fails",
+			onApproval: func(t *testing.T, csr *certificatesv1.CertificateSigningRequest) {
+				csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+					Type:    certificatesv1.CertificateFailed,
+					Status:  corev1.ConditionTrue,
+					Reason:  "SignerValidationFailure",
+					Message: "some failure",
+				})
+			},
+			wantSigner: DefaultCSRSignerName,
+			wantErr:    `CertificateSigningRequest "pinniped-client-abc12" was not signed: SignerValidationFailure: some failure`,
+		},
+		{
+			name:       "the signer never issues the certificate",
+			onApproval: func(*testing.T, *certificatesv1.CertificateSigningRequest) {},
+			wantSigner: DefaultCSRSignerName,
+			wantErr:    `timed out waiting for CertificateSigningRequest "pinniped-client-abc12" to be signed by kubernetes.io/kube-apiserver-client`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := kubefake.NewSimpleClientset()
+			client.PrependReactor("create", "certificatesigningrequests", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				csr := action.(kubetesting.CreateAction).GetObject().(*certificatesv1.CertificateSigningRequest)
+				require.Equal(t, "pinniped-client-", csr.GenerateName)
+				require.Equal(t, labels, csr.Labels)
+				require.Equal(t, tt.wantSigner, csr.Spec.SignerName)
+				require.Equal(t, int32(600), *csr.Spec.ExpirationSeconds)
+				require.Equal(t, []certificatesv1.KeyUsage{certificatesv1.UsageDigitalSignature, certificatesv1.UsageClientAuth}, csr.Spec.Usages)
+				csr.Name = "pinniped-client-abc12"
+				return false, nil, nil
+			})
+			client.PrependReactor("update", "certificatesigningrequests", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				if action.GetSubresource() != "approval" {
+					return false, nil, nil
+				}
+				csr := action.(kubetesting.UpdateAction).GetObject().(*certificatesv1.CertificateSigningRequest)
+				require.Len(t, csr.Status.Conditions, 1)
+				require.Equal(t, certificatesv1.CertificateApproved, csr.Status.Conditions[0].Type)
+				require.Equal(t, corev1.ConditionTrue, csr.Status.Conditions[0].Status)
+				tt.onApproval(t, csr)
+				return false, nil, nil
+			})
+
+			signer := NewCSRSigner(client.CertificatesV1().CertificateSigningRequests(), tt.signerName, labels)
+			require.Equal(t, "certificatesigningrequest:"+tt.wantSigner, signer.Name())
+
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+
+			// The requested ttl is shorter than the API server accepts, so it is extended.
+			certPEM, err := signer.Sign(ctx, csrForTest(t), 5*time.Minute)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				require.NotEmpty(t, certPEM)
+			}
+
+			// The CertificateSigningRequest is always deleted.
+			csrs, err := client.CertificatesV1().CertificateSigningRequests().List(context.Background(), metav1.ListOptions{})
+			require.NoError(t, err)
+			require.Empty(t, csrs.Items)
+		})
+	}
+}
+
+func TestCSRSignerCreateError(t *testing.T) {
+	client := kubefake.NewSimpleClientset()
+	client.PrependReactor("create", "certificatesigningrequests", func(kubetesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errFake
+	})
+
+	_, err := NewCSRSigner(client.CertificatesV1().CertificateSigningRequests(), "", nil).
+		Sign(context.Background(), csrForTest(t), time.Hour)
+	require.EqualError(t, err, "could not create CertificateSigningRequest: some fake error")
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package certsigner
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.pinniped.dev/internal/net/phttp"
+)
+
+// maxExternalResponseSize limits how much of the response of the signing service is read.
+const maxExternalResponseSize = 1 << 20
+
+type externalSignRequest struct {
+	CSR               string `json:"csr"`
+	ExpirationSeconds int64  `json:"expirationSeconds"`
+}
+
+type externalSignResponse struct {
+	Certificate string `json:"certificate"`
+}
+
+type externalSigner struct {
+	endpoint   string
+	httpClient *http.Client
+}
+
+// NewExternalSigner returns a Signer which POSTs each certificate signing request to the HTTPS endpoint of an
+// external signing service. The caBundleBase64 is the base64-encoded PEM CA bundle of the endpoint, or empty to
+// trust the system roots.
+func NewExternalSigner(endpoint string, caBundleBase64 string) (Signer, error) {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	if endpointURL.Scheme != "https" {
+		return nil, fmt.Errorf("invalid endpoint %q: must use https", endpoint)
+	}
+
+	var rootCAs *x509.CertPool
+	if caBundleBase64 != "" {
+		caBundle, err := base64.StdEncoding.DecodeString(caBundleBase64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificateAuthorityData: %w", err)
+		}
+		rootCAs = x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(caBundle) {
+			return nil, errors.New("invalid certificateAuthorityData: no certificates found")
+		}
+	}
+
+	return &externalSigner{endpoint: endpoint, httpClient: phttp.Default(rootCAs)}, nil
+}
+
+func (s *externalSigner) Name() string {
+	return "external:" + s.endpoint
+}
+
+func (s *externalSigner) Sign(ctx context.Context, csrPEM []byte, ttl time.Duration) ([]byte, error) {
+	body, err := json.Marshal(&externalSignRequest{CSR: string(csrPEM), ExpirationSeconds: int64(ttl.Seconds())})
+	if err != nil {
+		return nil, fmt.Errorf("could not encode signing request: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("could not create signing request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := s.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("signing request failed: %w", err)
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("signing request failed: unexpected http response status: %s", response.Status)
+	}
+
+	rawBody, err := io.ReadAll(io.LimitReader(response.Body, maxExternalResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("signing request failed: could not read response body: %w", err)
+	}
+
+	var signed externalSignResponse
+	if err := json.Unmarshal(rawBody, &signed); err != nil {
+		return nil, fmt.Errorf("signing request failed: could not parse response JSON: %w", err)
+	}
+	if signed.Certificate == "" {
+		return nil, errors.New("signing request failed: response did not contain a certificate")
+	}
+
+	return []byte(signed.Certificate), nil
+}
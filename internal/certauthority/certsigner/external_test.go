@@ -0,0 +1,110 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package certsigner
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go.pinniped.dev/internal/testutil/tlsserver"
+)
+
+func TestNewExternalSigner(t *testing.T) {
+	_, err := NewExternalSigner("http://signer.example.com", "")
+	require.EqualError(t, err, `invalid endpoint "http://signer.example.com": must use https`)
+
+	_, err = NewExternalSigner("https://signer.example.com", "not base64!")
+	require.ErrorContains(t, err, "invalid certificateAuthorityData: illegal base64 data")
+
+	_, err = NewExternalSigner("https://signer.example.com", base64.StdEncoding.EncodeToString([]byte("not PEM")))
+	require.EqualError(t, err, "invalid certificateAuthorityData: no certificates found")
+
+	signer, err := NewExternalSigner("https://signer.example.com/sign", "")
+	require.NoError(t, err)
+	require.Equal(t, "external:https://signer.example.com/sign", signer.Name())
+}
+
+func TestExternalSigner(t *testing.T) {
+	ca := newTestCA(t)
+
+	tests := []struct {
+		name    string
+		handler func(t *testing.T, w http.ResponseWriter, r *http.Request)
+		wantErr string
+	}{
+		{
+			name: "success",
+			handler: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodPost, r.Method)
+				require.Equal(t, "/sign", r.URL.Path)
+				require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+				var body externalSignRequest
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				require.Equal(t, int64(300), body.ExpirationSeconds)
+
+				w.Header().Set("Content-Type", "application/json")
+				require.NoError(t, json.NewEncoder(w).Encode(&externalSignResponse{
+					Certificate: string(ca.sign(t, []byte(body.CSR), time.Duration(body.ExpirationSeconds)*time.Second)),
+				}))
+			},
+		},
+		{
+			name: "error status",
+			handler: func(_ *testing.T, w http.ResponseWriter, _ *http.Request) {
+				http.Error(w, "nope", http.StatusForbidden)
+			},
+			wantErr: "signing request failed: unexpected http response status: 403 Forbidden",
+		},
+		{
+			name: "invalid JSON",
+			handler: func(_ *testing.T, w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte("not JSON"))
+			},
+			wantErr: "signing request failed: could not parse response JSON: invalid character 'o' in literal null (expecting 'u')",
+		},
+		{
+			name: "no certificate",
+			handler: func(_ *testing.T, w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte("{}"))
+			},
+			wantErr: "signing request failed: response did not contain a certificate",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, serverCA := tlsserver.TestServerIPv4(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tt.handler(t, w, r)
+			}), nil)
+
+			signer, err := NewExternalSigner(server.URL+"/sign", base64.StdEncoding.EncodeToString(serverCA))
+			require.NoError(t, err)
+
+			certPEM, _, err := NewClientCertIssuer(signer).IssueClientCertPEM("some-user", []string{"some-group"}, 5*time.Minute)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NotEmpty(t, certPEM)
+		})
+	}
+
+	t.Run("untrusted server", func(t *testing.T) {
+		server, _ := tlsserver.TestServerIPv4(t, http.NotFoundHandler(), nil)
+
+		signer, err := NewExternalSigner(server.URL, "")
+		require.NoError(t, err)
+
+		_, err = signer.Sign(context.Background(), csrForTest(t), time.Minute)
+		require.ErrorContains(t, err, "signing request failed: ")
+		require.ErrorContains(t, err, "certificate signed by unknown authority")
+	})
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package clientcredentials loads the credentials which Pinniped uses to authenticate to external services, e.g. to
+// external signing services, from Secrets.
+package clientcredentials
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/transport"
+
+	"go.pinniped.dev/internal/net/phttp"
+)
+
+const (
+	// SecretTypeBearerToken is the type of the Secrets which hold a bearer token.
+	SecretTypeBearerToken corev1.SecretType = "secrets.pinniped.dev/bearer-token"
+
+	// BearerTokenKey is the key of the bearer token in Secrets of type SecretTypeBearerToken.
+	BearerTokenKey = "token"
+)
+
+// Credentials authenticate the requests of a client, either using a TLS client certificate or using a bearer token.
+type Credentials struct {
+	clientCertificate *tls.Certificate
+	bearerToken       string
+}
+
+// IsCredentialsSecret returns true when the Secret has one of the types which FromSecret accepts.
+func IsCredentialsSecret(secret *corev1.Secret) bool {
+	return secret.Type == corev1.SecretTypeTLS || secret.Type == SecretTypeBearerToken
+}
+
+// FromSecret returns the credentials which are held by the Secret. A Secret of type kubernetes.io/tls holds a TLS
+// client certificate and its private key, and a Secret of type secrets.pinniped.dev/bearer-token holds a bearer token.
+func FromSecret(secret *corev1.Secret) (*Credentials, error) {
+	switch secret.Type {
+	case corev1.SecretTypeTLS:
+		certPEM := secret.Data[corev1.TLSCertKey]
+		keyPEM := secret.Data[corev1.TLSPrivateKeyKey]
+		if len(certPEM) == 0 || len(keyPEM) == 0 {
+			return nil, fmt.Errorf("secret %q is missing required keys %q",
+				secret.Name, []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey})
+		}
+		certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("secret %q does not contain a valid client certificate and private key: %w", secret.Name, err)
+		}
+		return &Credentials{clientCertificate: &certificate}, nil
+
+	case SecretTypeBearerToken:
+		token := string(secret.Data[BearerTokenKey])
+		if token == "" {
+			return nil, fmt.Errorf("secret %q is missing required key %q", secret.Name, BearerTokenKey)
+		}
+		return &Credentials{bearerToken: token}, nil
+
+	default:
+		return nil, fmt.Errorf("secret %q has wrong type %q (should be %q or %q)",
+			secret.Name, secret.Type, corev1.SecretTypeTLS, SecretTypeBearerToken)
+	}
+}
+
+// Client returns an HTTP client which trusts the rootCAs, or the system roots when rootCAs is nil, and which
+// authenticates each request using the credentials. Nil credentials do not authenticate the requests.
+func (c *Credentials) Client(rootCAs *x509.CertPool) *http.Client {
+	if c == nil {
+		return phttp.Default(rootCAs)
+	}
+	client := phttp.DefaultWithClientCertificate(rootCAs, c.clientCertificate)
+	if c.bearerToken != "" {
+		client.Transport = transport.NewBearerAuthRoundTripper(c.bearerToken, client.Transport)
+	}
+	return client
+}
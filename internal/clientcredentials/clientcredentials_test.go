@@ -0,0 +1,162 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package clientcredentials
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"go.pinniped.dev/internal/certauthority"
+)
+
+func TestFromSecret(t *testing.T) {
+	ca, err := certauthority.New("client-ca", time.Hour)
+	require.NoError(t, err)
+	certPEM, keyPEM, err := ca.IssueClientCertPEM("some-client", nil, time.Hour)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		secret          *corev1.Secret
+		wantErr         string
+		wantClientCert  bool
+		wantBearerToken string
+	}{
+		{
+			name: "TLS secret",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "some-secret"},
+				Type:       corev1.SecretTypeTLS,
+				Data:       map[string][]byte{corev1.TLSCertKey: certPEM, corev1.TLSPrivateKeyKey: keyPEM},
+			},
+			wantClientCert: true,
+		},
+		{
+			name: "TLS secret without private key",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "some-secret"},
+				Type:       corev1.SecretTypeTLS,
+				Data:       map[string][]byte{corev1.TLSCertKey: certPEM},
+			},
+			wantErr: `secret "some-secret" is missing required keys ["tls.crt" "tls.key"]`,
+		},
+		{
+			name: "TLS secret with an invalid certificate",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "some-secret"},
+				Type:       corev1.SecretTypeTLS,
+				Data:       map[string][]byte{corev1.TLSCertKey: []byte("not a cert"), corev1.TLSPrivateKeyKey: keyPEM},
+			},
+			wantErr: `secret "some-secret" does not contain a valid client certificate and private key: tls: failed to find any PEM data in certificate input`,
+		},
+		{
+			name: "bearer token secret",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "some-secret"},
+				Type:       SecretTypeBearerToken,
+				Data:       map[string][]byte{BearerTokenKey: []byte("some-token")},
+			},
+			wantBearerToken: "some-token",
+		},
+		{
+			name: "bearer token secret without token",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "some-secret"},
+				Type:       SecretTypeBearerToken,
+			},
+			wantErr: `secret "some-secret" is missing required key "token"`,
+		},
+		{
+			name: "wrong type",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "some-secret"},
+				Type:       corev1.SecretTypeOpaque,
+			},
+			wantErr: `secret "some-secret" has wrong type "Opaque" (should be "kubernetes.io/tls" or "secrets.pinniped.dev/bearer-token")`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.secret.Type != corev1.SecretTypeOpaque, IsCredentialsSecret(tt.secret))
+
+			credentials, err := FromSecret(tt.secret)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				require.Nil(t, credentials)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantClientCert, credentials.clientCertificate != nil)
+			require.Equal(t, tt.wantBearerToken, credentials.bearerToken)
+		})
+	}
+}
+
+func TestClient(t *testing.T) {
+	clientCA, err := certauthority.New("client-ca", time.Hour)
+	require.NoError(t, err)
+	certPEM, keyPEM, err := clientCA.IssueClientCertPEM("some-client", nil, time.Hour)
+	require.NoError(t, err)
+
+	var gotAuthorization string
+	var gotClientCerts int
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuthorization = r.Header.Get("Authorization")
+		gotClientCerts = len(r.TLS.PeerCertificates)
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.VerifyClientCertIfGiven, ClientCAs: clientCA.Pool()} //nolint:gosec // this is a test server
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	do := func(t *testing.T, credentials *Credentials) {
+		t.Helper()
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		rsp, err := credentials.Client(server.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs).Do(req)
+		require.NoError(t, err)
+		assert.NoError(t, rsp.Body.Close())
+		require.Equal(t, http.StatusOK, rsp.StatusCode)
+	}
+
+	fromSecret := func(t *testing.T, secret *corev1.Secret) *Credentials {
+		t.Helper()
+		credentials, err := FromSecret(secret)
+		require.NoError(t, err)
+		return credentials
+	}
+
+	t.Run("TLS client certificate", func(t *testing.T) {
+		do(t, fromSecret(t, &corev1.Secret{
+			Type: corev1.SecretTypeTLS,
+			Data: map[string][]byte{corev1.TLSCertKey: certPEM, corev1.TLSPrivateKeyKey: keyPEM},
+		}))
+		require.Empty(t, gotAuthorization)
+		require.Equal(t, 1, gotClientCerts)
+	})
+
+	t.Run("bearer token", func(t *testing.T) {
+		do(t, fromSecret(t, &corev1.Secret{
+			Type: SecretTypeBearerToken,
+			Data: map[string][]byte{BearerTokenKey: []byte("some-token")},
+		}))
+		require.Equal(t, "Bearer some-token", gotAuthorization)
+		require.Zero(t, gotClientCerts)
+	})
+
+	t.Run("nil credentials", func(t *testing.T) {
+		do(t, nil)
+		require.Empty(t, gotAuthorization)
+		require.Zero(t, gotClientCerts)
+	})
+}
@@ -6,7 +6,7 @@ package apiserver
 import (
 	"context"
 	"fmt"
-	"sync"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -17,13 +17,18 @@ import (
 
 	"go.pinniped.dev/internal/clientcertissuer"
 	"go.pinniped.dev/internal/controllerinit"
+	"go.pinniped.dev/internal/lifecycle"
 	"go.pinniped.dev/internal/plog"
 	"go.pinniped.dev/internal/pversion"
 	"go.pinniped.dev/internal/registry/credentialrequest"
 	"go.pinniped.dev/internal/registry/whoamirequest"
+	"go.pinniped.dev/internal/serviceaccounttokenissuer"
 	"go.pinniped.dev/internal/tokenclient"
 )
 
+// controllersShutdownDeadline is how long the controllers may take to stop before shutdown continues without them.
+const controllersShutdownDeadline = time.Minute
+
 type Config struct {
 	GenericConfig *genericapiserver.RecommendedConfig
 	ExtraConfig   ExtraConfig
@@ -32,6 +37,8 @@ type Config struct {
 type ExtraConfig struct {
 	Authenticator                 credentialrequest.TokenCredentialRequestAuthenticator
 	Issuer                        clientcertissuer.ClientCertIssuer
+	ServiceAccountTokenIssuer     serviceaccounttokenissuer.ServiceAccountTokenIssuer
+	CredentialType                credentialrequest.CredentialTypeProvider
 	BuildControllersPostStartHook controllerinit.RunnerBuilder
 	Scheme                        *runtime.Scheme
 	NegotiatedSerializer          runtime.NegotiatedSerializer
@@ -82,7 +89,13 @@ func (c completedConfig) New() (*PinnipedServer, error) {
 	for _, f := range []func() (schema.GroupVersionResource, rest.Storage){
 		func() (schema.GroupVersionResource, rest.Storage) {
 			tokenCredReqGVR := c.ExtraConfig.LoginConciergeGroupVersion.WithResource("tokencredentialrequests")
-			tokenCredStorage := credentialrequest.NewREST(c.ExtraConfig.Authenticator, c.ExtraConfig.Issuer, tokenCredReqGVR.GroupResource())
+			tokenCredStorage := credentialrequest.NewREST(
+				c.ExtraConfig.Authenticator,
+				c.ExtraConfig.Issuer,
+				c.ExtraConfig.ServiceAccountTokenIssuer,
+				c.ExtraConfig.CredentialType,
+				tokenCredReqGVR.GroupResource(),
+			)
 			return tokenCredReqGVR, tokenCredStorage
 		},
 		func() (schema.GroupVersionResource, rest.Storage) {
@@ -109,7 +122,8 @@ func (c completedConfig) New() (*PinnipedServer, error) {
 		return nil, fmt.Errorf("could not install API groups: %w", err)
 	}
 
-	controllersShutdownWaitGroup := &sync.WaitGroup{}
+	// The informers of the controllers use controllersCtx, so it is cancelled only after the controllers have stopped.
+	controllers := lifecycle.New()
 	controllersCtx, cancelControllerCtx := context.WithCancel(context.Background())
 
 	s.GenericAPIServer.AddPostStartHookOrDie("start-controllers",
@@ -122,15 +136,12 @@ func (c completedConfig) New() (*PinnipedServer, error) {
 				return fmt.Errorf("cannot create run controller func: %w", err)
 			}
 
-			controllersShutdownWaitGroup.Add(1)
-			go func() {
-				// When this goroutine ends, then also end the WaitGroup, allowing anyone who called Wait() to proceed.
-				defer controllersShutdownWaitGroup.Done()
-
+			controllers.Go(controllersCtx, "controllers", controllersShutdownDeadline, func(ctx context.Context) error {
 				// Start the controllers and block until their context is cancelled and they have shut down.
-				runControllers(controllersCtx)
+				runControllers(ctx)
 				plog.Debug("start-controllers post start hook's background goroutine saw runControllers() finish")
-			}()
+				return nil
+			})
 
 			return nil
 		},
@@ -141,14 +152,12 @@ func (c completedConfig) New() (*PinnipedServer, error) {
 			plog.Debug("fetch-impersonation-proxy-tokens start hook starting")
 			defer plog.Debug("fetch-impersonation-proxy-tokens start hook completed")
 
-			controllersShutdownWaitGroup.Add(1)
-			go func() {
-				defer controllersShutdownWaitGroup.Done()
-
+			controllers.Go(controllersCtx, "impersonation proxy token client", controllersShutdownDeadline, func(ctx context.Context) error {
 				// Start the token client
-				c.ExtraConfig.TokenClient.Start(controllersCtx)
+				c.ExtraConfig.TokenClient.Start(ctx)
 				plog.Debug("fetch-impersonation-proxy-tokens start hook's background goroutine has finished")
-			}()
+				return nil
+			})
 
 			return nil
 		},
@@ -160,14 +169,15 @@ func (c completedConfig) New() (*PinnipedServer, error) {
 			defer plog.Debug("stop-controllers pre shutdown hook completed")
 
 			// The generic api server is telling us that it wants to shut down, so tell our controllers that we
-			// want them to shut down by cancelling their context.
-			cancelControllerCtx()
+			// want them to shut down and wait for them to finish shutting down. By blocking here, we prevent the
+			// generic api server's graceful shutdown process from continuing until we are finished shutting down
+			// our own controllers.
+			err := controllers.Shutdown()
 
-			// Now wait for the controllers to finish shutting down. By blocking here, we prevent the generic api server's
-			// graceful shutdown process from continuing until we are finished shutting down our own controllers.
-			controllersShutdownWaitGroup.Wait()
+			// Now that the controllers are stopped, also stop their informers.
+			cancelControllerCtx()
 
-			return nil
+			return err
 		},
 	)
 
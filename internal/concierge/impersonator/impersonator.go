@@ -56,6 +56,7 @@ import (
 	"go.pinniped.dev/internal/kubeclient"
 	"go.pinniped.dev/internal/plog"
 	"go.pinniped.dev/internal/tokenclient"
+	"go.pinniped.dev/internal/tracing"
 	"go.pinniped.dev/internal/valuelesscontext"
 )
 
@@ -230,6 +231,9 @@ func newInternal(
 			return handler
 		}
 
+		// The standard Kube handler chain starts a span for each request when tracing is configured.
+		serverConfig.TracerProvider = tracing.TracerProvider()
+
 		// wire up a fake audit backend at the metadata level so we can preserve the original user during nested impersonation
 		serverConfig.AuditPolicyRuleEvaluator = policy.NewFakePolicyRuleEvaluator(auditinternal.LevelMetadata, nil)
 		serverConfig.AuditBackend = &auditfake.Backend{}
@@ -8,6 +8,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"time"
 
@@ -19,8 +20,10 @@ import (
 	openapinamer "k8s.io/apiserver/pkg/endpoints/openapi"
 	"k8s.io/apiserver/pkg/features"
 	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/apiserver/pkg/server/healthz"
 	genericoptions "k8s.io/apiserver/pkg/server/options"
 	"k8s.io/client-go/rest"
+	"k8s.io/utils/clock"
 
 	conciergeopenapi "go.pinniped.dev/generated/latest/client/concierge/openapi"
 	"go.pinniped.dev/internal/admissionpluginconfig"
@@ -31,19 +34,28 @@ import (
 	"go.pinniped.dev/internal/config/concierge"
 	"go.pinniped.dev/internal/config/featuregates"
 	"go.pinniped.dev/internal/controller/authenticator/authncache"
+	"go.pinniped.dev/internal/controller/tokencredentialrequestconfig"
 	"go.pinniped.dev/internal/controllerinit"
 	"go.pinniped.dev/internal/controllermanager"
 	"go.pinniped.dev/internal/crypto/ptls"
 	"go.pinniped.dev/internal/downward"
 	"go.pinniped.dev/internal/dynamiccert"
+	"go.pinniped.dev/internal/healthcheck"
 	"go.pinniped.dev/internal/here"
+	"go.pinniped.dev/internal/httputil/requestlog"
 	"go.pinniped.dev/internal/kubeclient"
+	"go.pinniped.dev/internal/lifecycle"
 	"go.pinniped.dev/internal/plog"
 	"go.pinniped.dev/internal/pversion"
 	"go.pinniped.dev/internal/registry/credentialrequest"
+	"go.pinniped.dev/internal/serviceaccounttokenissuer"
 	"go.pinniped.dev/internal/tokenclient"
+	"go.pinniped.dev/internal/tracing"
 )
 
+// apiServerShutdownDeadline is how long the aggregated API server, including its controllers, may take to stop.
+const apiServerShutdownDeadline = 2 * time.Minute
+
 // App is an object that represents the pinniped-concierge application.
 type App struct {
 	cmd *cobra.Command
@@ -112,7 +124,7 @@ func (a *App) runServer(ctx context.Context) error {
 	featuregates.DisableKubeFeatureGate(features.UnauthenticatedHTTP2DOSMitigation)
 
 	// Read the server config file.
-	cfg, err := concierge.FromPath(ctx, a.configPath, ptls.SetUserConfiguredAllowedCipherSuitesForTLSOneDotTwo)
+	cfg, err := concierge.FromPath(ctx, a.configPath, ptls.SetUserConfiguredSettings)
 	if err != nil {
 		return fmt.Errorf("could not load config: %w", err)
 	}
@@ -120,6 +132,12 @@ func (a *App) runServer(ctx context.Context) error {
 	// The above server config should have set the allowed ciphers global, so now log the ciphers for all profiles.
 	ptls.LogAllProfiles(plog.New())
 
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing, "pinniped-concierge")
+	if err != nil {
+		return fmt.Errorf("could not set up tracing: %w", err)
+	}
+	defer shutdownTracing()
+
 	// Discover in which namespace we are installed.
 	podInfo, err := downward.Load(a.downwardAPIPath)
 	if err != nil {
@@ -150,15 +168,30 @@ func (a *App) runServer(ctx context.Context) error {
 
 	impersonationProxyTokenCache := tokenclient.NewExpiringSingletonTokenCache()
 
+	// This holds the type of credential returned by the TokenCredentialRequest API, and
+	// will be mutated by a controller to keep it up to date with the CredentialIssuer.
+	tokenCredentialRequestCredentialType := tokencredentialrequestconfig.NewDynamicCredentialType()
+
+	// This issues client certs using the signer which is configured on the CredentialIssuer, if any, and will be
+	// mutated by the same controller. Its k8s client does not use leader election because all pods issue certs.
+	signerK8sClient, err := kubeclient.New()
+	if err != nil {
+		return fmt.Errorf("could not create default kubernetes client: %w", err)
+	}
+	tokenCredentialRequestSigner := tokencredentialrequestconfig.NewDynamicClientCertIssuer()
+
 	// Prepare to start the controllers, but defer actually starting them until the
 	// post start hook of the aggregated API server.
-	buildControllers, err := controllermanager.PrepareControllers(
+	buildControllers, controllersReadyzChecks, err := controllermanager.PrepareControllers(
 		&controllermanager.Config{
 			ServerInstallationInfo:           podInfo,
 			APIGroupSuffix:                   *cfg.APIGroupSuffix,
 			NamesConfig:                      &cfg.NamesConfig,
 			Labels:                           cfg.Labels,
 			KubeCertAgentConfig:              &cfg.KubeCertAgentConfig,
+			ControllersConfig:                &cfg.Controllers,
+			LeaderElectionConfig:             &cfg.LeaderElection,
+			InformersConfig:                  &cfg.Informers,
 			DiscoveryURLOverride:             cfg.DiscoveryInfo.URL,
 			DynamicServingCertProvider:       dynamicServingCertProvider,
 			DynamicSigningCertProvider:       dynamicSigningCertProvider,
@@ -167,8 +200,11 @@ func (a *App) runServer(ctx context.Context) error {
 			ServingCertRenewBefore:           time.Duration(*cfg.APIConfig.ServingCertificateConfig.RenewBeforeSeconds) * time.Second,
 			AuthenticatorCache:               authenticators,
 			// This port should be safe to cast because the config reader already validated it.
-			ImpersonationProxyServerPort: int(*cfg.ImpersonationProxyServerPort),
-			ImpersonationProxyTokenCache: impersonationProxyTokenCache,
+			ImpersonationProxyServerPort:         int(*cfg.ImpersonationProxyServerPort),
+			ImpersonationProxyTokenCache:         impersonationProxyTokenCache,
+			TokenCredentialRequestCredentialType: tokenCredentialRequestCredentialType,
+			TokenCredentialRequestSigner:         tokenCredentialRequestSigner,
+			CertificateSigningRequests:           signerK8sClient.Kubernetes.CertificatesV1().CertificateSigningRequests(),
 		},
 	)
 	if err != nil {
@@ -176,6 +212,7 @@ func (a *App) runServer(ctx context.Context) error {
 	}
 
 	certIssuer := clientcertissuer.ClientCertIssuers{
+		tokenCredentialRequestSigner,                                    // use the configured signer, if any
 		dynamiccertauthority.New(dynamicSigningCertProvider),            // attempt to use the real Kube CA if possible
 		dynamiccertauthority.New(impersonationProxySigningCertProvider), // fallback to our internal CA if we need to
 	}
@@ -191,6 +228,7 @@ func (a *App) runServer(ctx context.Context) error {
 		scheme,
 		loginGV,
 		identityGV,
+		requestlog.New(cfg.RequestLog, plog.New(), clock.RealClock{}),
 	)
 	if err != nil {
 		return fmt.Errorf("could not configure aggregated API server: %w", err)
@@ -213,16 +251,46 @@ func (a *App) runServer(ctx context.Context) error {
 		plog.New(),
 		tokenclient.WithExpirationSeconds(oneDayInSeconds))
 
+	// Configure the issuer of ServiceAccount tokens for the TokenCredentialRequest API. It also uses a k8s client
+	// without leader election because every pod must be able to serve the TokenCredentialRequest API.
+	serviceAccountTokenK8sClient, err := kubeclient.New()
+	if err != nil {
+		return fmt.Errorf("could not create default kubernetes client: %w", err)
+	}
+	aggregatedAPIServerConfig.ExtraConfig.ServiceAccountTokenIssuer = serviceaccounttokenissuer.New(
+		serviceAccountTokenK8sClient.Kubernetes.CoreV1().ServiceAccounts(podInfo.Namespace),
+		cfg.Labels,
+	)
+	aggregatedAPIServerConfig.ExtraConfig.CredentialType = tokenCredentialRequestCredentialType
+
 	// Complete the aggregated API server config and make a server instance.
 	server, err := aggregatedAPIServerConfig.Complete().New()
 	if err != nil {
 		return fmt.Errorf("could not create aggregated API server: %w", err)
 	}
 
-	// Run the server. Its post-start hook will start the controllers. Its pre shutdown hook will be called when ctx is
-	// cancelled, and that hook should graceful stop the controllers and give up the leader election lease. See the
+	// The lifecycle manager owns the background goroutines of the server and stops them upon shutdown.
+	// Readiness fails as soon as shutdown starts. It also fails until the informers of the controllers have synced,
+	// the leader has been observed, and the serving certificate is valid.
+	lifecycleManager := lifecycle.New()
+	readyzChecks := append([]healthz.HealthChecker{
+		lifecycleManager.ReadyzCheck(),
+		healthcheck.ServingCert(dynamicServingCertProvider, clock.RealClock{}),
+	}, controllersReadyzChecks...)
+	if err := server.GenericAPIServer.AddReadyzChecks(readyzChecks...); err != nil {
+		return fmt.Errorf("could not add readyz checks to aggregated API server: %w", err)
+	}
+
+	// Run the server. Its post-start hook will start the controllers. Its pre shutdown hook will be called when it is
+	// stopped, and that hook should graceful stop the controllers and give up the leader election lease. See the
 	// code for these hooks in internal/concierge/apiserver.go.
-	return server.GenericAPIServer.PrepareRun().Run(ctx.Done())
+	preparedServer := server.GenericAPIServer.PrepareRun()
+	lifecycleManager.Go(context.Background(), "aggregated API server", apiServerShutdownDeadline, func(ctx context.Context) error {
+		return preparedServer.Run(ctx.Done())
+	})
+
+	// Block until ctx is cancelled, and then stop the aggregated API server and its controllers.
+	return lifecycleManager.Run(ctx)
 }
 
 // Create a configuration for the aggregated API server.
@@ -235,6 +303,7 @@ func getAggregatedAPIServerConfig(
 	aggregatedAPIServerPort int64,
 	scheme *runtime.Scheme,
 	loginConciergeGroupVersion, identityConciergeGroupVersion schema.GroupVersion,
+	requestLogger *requestlog.Logger,
 ) (*apiserver.Config, error) {
 	codecs := serializer.NewCodecFactory(scheme)
 
@@ -291,6 +360,17 @@ func getAggregatedAPIServerConfig(
 		return nil, fmt.Errorf("failed to apply recommended options: %w", err)
 	}
 
+	// The standard Kube handler chain starts a span for each request when tracing is configured.
+	serverConfig.TracerProvider = tracing.TracerProvider()
+
+	// Log the requests outside of the standard Kube handler chain, so the requests which it rejects are logged too.
+	if requestLogger != nil {
+		defaultBuildHandlerChainFunc := serverConfig.BuildHandlerChainFunc
+		serverConfig.BuildHandlerChainFunc = func(apiHandler http.Handler, c *genericapiserver.Config) http.Handler {
+			return requestLogger.WrapHandler("aggregated API server", defaultBuildHandlerChainFunc(apiHandler, c))
+		}
+	}
+
 	apiServerConfig := &apiserver.Config{
 		GenericConfig: serverConfig,
 		ExtraConfig: apiserver.ExtraConfig{
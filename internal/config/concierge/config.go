@@ -17,7 +17,10 @@ import (
 	"go.pinniped.dev/internal/constable"
 	"go.pinniped.dev/internal/crypto/ptls"
 	"go.pinniped.dev/internal/groupsuffix"
+	"go.pinniped.dev/internal/httputil/requestlog"
 	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/internal/resourcelabels"
+	"go.pinniped.dev/internal/tracing"
 )
 
 const (
@@ -34,6 +37,12 @@ const (
 	// impersonation proxy, and has been the value since. It was originally selected because the
 	// aggregated API server used to run on 8443 (has since changed), so 8444 was the next available port.
 	impersonationProxyPortDefault = 8444
+
+	// The default leader election timings are the ones which were used before they were configurable,
+	// which were copied from OpenShift.
+	leaseDurationSecondsDefault = 137
+	renewDeadlineSecondsDefault = 107
+	retryPeriodSecondsDefault   = 26
 )
 
 // FromPath loads a Config from a provided local file path, inserts any
@@ -43,7 +52,7 @@ const (
 // Note! The Config file should contain base64-encoded WebhookCABundle data.
 // This function will decode that base64-encoded data to PEM bytes to be stored
 // in the Config.
-func FromPath(ctx context.Context, path string, setAllowedCiphers ptls.SetAllowedCiphersFunc) (*Config, error) {
+func FromPath(ctx context.Context, path string, setTLSSettings ptls.SetUserConfiguredSettingsFunc) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read file: %w", err)
@@ -59,6 +68,8 @@ func FromPath(ctx context.Context, path string, setAllowedCiphers ptls.SetAllowe
 	maybeSetImpersonationProxyServerPortDefaults(&config.ImpersonationProxyServerPort)
 	maybeSetAPIGroupSuffixDefault(&config.APIGroupSuffix)
 	maybeSetKubeCertAgentDefaults(&config.KubeCertAgentConfig)
+	maybeSetControllersDefaults(&config.Controllers)
+	maybeSetLeaderElectionDefaults(&config.LeaderElection)
 
 	if err := validateAPI(&config.APIConfig); err != nil {
 		return nil, fmt.Errorf("validate api: %w", err)
@@ -80,11 +91,27 @@ func FromPath(ctx context.Context, path string, setAllowedCiphers ptls.SetAllowe
 		return nil, fmt.Errorf("validate names: %w", err)
 	}
 
+	if err := validateLeaderElection(&config.LeaderElection); err != nil {
+		return nil, fmt.Errorf("validate leaderElection: %w", err)
+	}
+
+	if err := validateInformers(config.Informers); err != nil {
+		return nil, fmt.Errorf("validate informers: %w", err)
+	}
+
 	if err := plog.ValidateAndSetLogLevelAndFormatGlobally(ctx, config.Log); err != nil {
 		return nil, fmt.Errorf("validate log level: %w", err)
 	}
 
-	if err := setAllowedCiphers(config.TLS.OneDotTwo.AllowedCiphers); err != nil {
+	if err := requestlog.Validate(config.RequestLog); err != nil {
+		return nil, fmt.Errorf("validate requestLog: %w", err)
+	}
+
+	if err := tracing.Validate(config.Tracing); err != nil {
+		return nil, fmt.Errorf("validate tracing: %w", err)
+	}
+
+	if err := validateTLS(config.TLS, setTLSSettings); err != nil {
 		return nil, fmt.Errorf("validate tls: %w", err)
 	}
 
@@ -133,6 +160,30 @@ func maybeSetKubeCertAgentDefaults(cfg *KubeCertAgentSpec) {
 	}
 }
 
+func maybeSetControllersDefaults(cfg *ControllersSpec) {
+	if cfg.KubeCertAgent == nil {
+		cfg.KubeCertAgent = ptr.To(true)
+	}
+
+	if cfg.ImpersonationProxy == nil {
+		cfg.ImpersonationProxy = ptr.To(true)
+	}
+}
+
+func maybeSetLeaderElectionDefaults(cfg *LeaderElectionSpec) {
+	if cfg.LeaseDurationSeconds == nil {
+		cfg.LeaseDurationSeconds = ptr.To[int64](leaseDurationSecondsDefault)
+	}
+
+	if cfg.RenewDeadlineSeconds == nil {
+		cfg.RenewDeadlineSeconds = ptr.To[int64](renewDeadlineSecondsDefault)
+	}
+
+	if cfg.RetryPeriodSeconds == nil {
+		cfg.RetryPeriodSeconds = ptr.To[int64](retryPeriodSecondsDefault)
+	}
+}
+
 func validateNames(names *NamesConfigSpec) error {
 	missingNames := []string{}
 	if names == nil {
@@ -189,6 +240,30 @@ func validateAPI(apiConfig *APIConfigSpec) error {
 	return nil
 }
 
+func validateInformers(cfg InformersSpec) error {
+	if _, err := resourcelabels.ParseSelector(cfg.SecretLabelSelector); err != nil {
+		return fmt.Errorf("invalid secretLabelSelector: %w", err)
+	}
+	return nil
+}
+
+func validateLeaderElection(cfg *LeaderElectionSpec) error {
+	if *cfg.LeaseDurationSeconds <= 0 || *cfg.RenewDeadlineSeconds <= 0 || *cfg.RetryPeriodSeconds <= 0 {
+		return constable.Error("leaseDurationSeconds, renewDeadlineSeconds and retryPeriodSeconds must be positive")
+	}
+
+	if *cfg.LeaseDurationSeconds <= *cfg.RenewDeadlineSeconds {
+		return constable.Error("leaseDurationSeconds must be greater than renewDeadlineSeconds")
+	}
+
+	// This is the same check as the one in k8s.io/client-go/tools/leaderelection, which uses a JitterFactor of 1.2.
+	if *cfg.RenewDeadlineSeconds*10 <= *cfg.RetryPeriodSeconds*12 {
+		return constable.Error("renewDeadlineSeconds must be greater than 1.2 times retryPeriodSeconds")
+	}
+
+	return nil
+}
+
 func validateAPIGroupSuffix(apiGroupSuffix string) error {
 	return groupsuffix.Validate(apiGroupSuffix)
 }
@@ -200,3 +275,15 @@ func validateServerPort(port *int64) error {
 	}
 	return nil
 }
+
+func validateTLS(tlsSpec TLSSpec, setTLSSettings ptls.SetUserConfiguredSettingsFunc) error {
+	minVersion, err := ptls.ParseVersion(tlsSpec.MinVersion)
+	if err != nil {
+		return fmt.Errorf("minVersion: %w", err)
+	}
+	return setTLSSettings(ptls.UserConfiguredSettings{
+		AllowedCipherSuitesForTLSOneDotTwo: tlsSpec.OneDotTwo.AllowedCiphers,
+		MinVersion:                         minVersion,
+		FIPSRestricted:                     tlsSpec.FIPSRestricted,
+	})
+}
@@ -5,6 +5,7 @@ package concierge
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"os"
 	"testing"
@@ -12,20 +13,23 @@ import (
 	"github.com/stretchr/testify/require"
 	"k8s.io/utils/ptr"
 
+	"go.pinniped.dev/internal/crypto/ptls"
 	"go.pinniped.dev/internal/here"
 	"go.pinniped.dev/internal/plog"
 )
 
 func TestFromPath(t *testing.T) {
 	tests := []struct {
-		name                string
-		yaml                string
-		allowedCiphersError error
-		wantConfig          *Config
-		wantError           string
+		name              string
+		yaml              string
+		tlsSettingsError  error
+		wantConfig        *Config
+		wantError         string
+		wantTLSMinVersion uint16
 	}{
 		{
-			name: "Fully filled out",
+			name:              "Fully filled out",
+			wantTLSMinVersion: tls.VersionTLS12,
 			yaml: here.Doc(`
 				---
 				discovery:
@@ -59,6 +63,22 @@ func TestFromPath(t *testing.T) {
 				  namePrefix: kube-cert-agent-name-prefix-
 				  image: kube-cert-agent-image
 				  imagePullSecrets: [kube-cert-agent-image-pull-secret]
+				  controlPlane:
+				    namespace: some-namespace
+				    podLabels:
+				      k8s-app: kube-controller-manager
+				    certPathTemplate: '{{ flag "some-cert-flag" "/some/cert" }}'
+				    keyPathTemplate: /some/key
+				    hostPathVolumes: [/some]
+				controllers:
+				  kubeCertAgent: false
+				  impersonationProxy: false
+				leaderElection:
+				  leaseDurationSeconds: 60
+				  renewDeadlineSeconds: 40
+				  retryPeriodSeconds: 10
+				informers:
+				  secretLabelSelector: pinniped.dev/managed=true
 				log:
 				  level: debug
 				tls:
@@ -67,6 +87,8 @@ func TestFromPath(t *testing.T) {
 				    - foo
 				    - bar
 					- TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305
+				  minVersion: "1.2"
+				  fipsRestricted: true
 			`),
 			wantConfig: &Config{
 				DiscoveryInfo: DiscoveryInfoSpec{
@@ -102,6 +124,25 @@ func TestFromPath(t *testing.T) {
 					NamePrefix:       ptr.To("kube-cert-agent-name-prefix-"),
 					Image:            ptr.To("kube-cert-agent-image"),
 					ImagePullSecrets: []string{"kube-cert-agent-image-pull-secret"},
+					ControlPlane: KubeCertAgentControlPlaneSpec{
+						Namespace:        "some-namespace",
+						PodLabels:        map[string]string{"k8s-app": "kube-controller-manager"},
+						CertPathTemplate: `{{ flag "some-cert-flag" "/some/cert" }}`,
+						KeyPathTemplate:  "/some/key",
+						HostPathVolumes:  []string{"/some"},
+					},
+				},
+				Controllers: ControllersSpec{
+					KubeCertAgent:      ptr.To(false),
+					ImpersonationProxy: ptr.To(false),
+				},
+				LeaderElection: LeaderElectionSpec{
+					LeaseDurationSeconds: ptr.To[int64](60),
+					RenewDeadlineSeconds: ptr.To[int64](40),
+					RetryPeriodSeconds:   ptr.To[int64](10),
+				},
+				Informers: InformersSpec{
+					SecretLabelSelector: "pinniped.dev/managed=true",
 				},
 				Log: plog.LogSpec{
 					Level: plog.LevelDebug,
@@ -114,6 +155,8 @@ func TestFromPath(t *testing.T) {
 							"TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305",
 						},
 					},
+					MinVersion:     "1.2",
+					FIPSRestricted: true,
 				},
 			},
 		},
@@ -191,6 +234,15 @@ func TestFromPath(t *testing.T) {
 					Image:            ptr.To("kube-cert-agent-image"),
 					ImagePullSecrets: []string{"kube-cert-agent-image-pull-secret"},
 				},
+				Controllers: ControllersSpec{
+					KubeCertAgent:      ptr.To(true),
+					ImpersonationProxy: ptr.To(true),
+				},
+				LeaderElection: LeaderElectionSpec{
+					LeaseDurationSeconds: ptr.To[int64](137),
+					RenewDeadlineSeconds: ptr.To[int64](107),
+					RetryPeriodSeconds:   ptr.To[int64](26),
+				},
 				Log: plog.LogSpec{
 					Level:  plog.LevelAll,
 					Format: plog.FormatJSON,
@@ -218,6 +270,28 @@ func TestFromPath(t *testing.T) {
 			`),
 			wantError: "decode yaml: error unmarshaling JSON: while decoding JSON: invalid log format, valid choices are the empty string or 'json'",
 		},
+		{
+			name: "invalid request log level",
+			yaml: here.Doc(`
+				---
+				names:
+				  servingCertificateSecret: pinniped-concierge-api-tls-serving-certificate
+				  credentialIssuer: pinniped-config
+				  apiService: pinniped-api
+				  impersonationLoadBalancerService: impersonationLoadBalancerService-value
+				  impersonationClusterIPService: impersonationClusterIPService-value
+				  impersonationTLSCertificateSecret: impersonationTLSCertificateSecret-value
+				  impersonationCACertificateSecret: impersonationCACertificateSecret-value
+				  impersonationSignerSecret: impersonationSignerSecret-value
+				  agentServiceAccount: agentServiceAccount-value
+				  impersonationProxyServiceAccount: impersonationProxyServiceAccount-value
+				  impersonationProxyLegacySecret: impersonationProxyLegacySecret-value
+				requestLog:
+				  enabled: true
+				  level: all
+			`),
+			wantError: `validate requestLog: invalid level "all", valid choices are the empty string, info, debug and trace`,
+		},
 		{
 			name: "cli is a bad log format when configured by the user",
 			yaml: here.Doc(`
@@ -287,6 +361,15 @@ func TestFromPath(t *testing.T) {
 					NamePrefix: ptr.To("pinniped-kube-cert-agent-"),
 					Image:      ptr.To("debian:latest"),
 				},
+				Controllers: ControllersSpec{
+					KubeCertAgent:      ptr.To(true),
+					ImpersonationProxy: ptr.To(true),
+				},
+				LeaderElection: LeaderElectionSpec{
+					LeaseDurationSeconds: ptr.To[int64](137),
+					RenewDeadlineSeconds: ptr.To[int64](107),
+					RetryPeriodSeconds:   ptr.To[int64](26),
+				},
 			},
 		},
 		{
@@ -605,7 +688,117 @@ func TestFromPath(t *testing.T) {
 			wantError: "validate apiGroupSuffix: a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')",
 		},
 		{
-			name: "returns setAllowedCiphers errors",
+			name: "non-positive leader election retryPeriodSeconds",
+			yaml: here.Doc(`
+				---
+				names:
+				  servingCertificateSecret: pinniped-concierge-api-tls-serving-certificate
+				  credentialIssuer: pinniped-config
+				  apiService: pinniped-api
+				  impersonationLoadBalancerService: impersonationLoadBalancerService-value
+				  impersonationClusterIPService: impersonationClusterIPService-value
+				  impersonationTLSCertificateSecret: impersonationTLSCertificateSecret-value
+				  impersonationCACertificateSecret: impersonationCACertificateSecret-value
+				  impersonationSignerSecret: impersonationSignerSecret-value
+				  agentServiceAccount: agentServiceAccount-value
+				  impersonationProxyServiceAccount: impersonationProxyServiceAccount-value
+				  impersonationProxyLegacySecret: impersonationProxyLegacySecret-value
+				leaderElection:
+				  retryPeriodSeconds: 0
+			`),
+			wantError: "validate leaderElection: leaseDurationSeconds, renewDeadlineSeconds and retryPeriodSeconds must be positive",
+		},
+		{
+			name: "leader election leaseDurationSeconds not greater than renewDeadlineSeconds",
+			yaml: here.Doc(`
+				---
+				names:
+				  servingCertificateSecret: pinniped-concierge-api-tls-serving-certificate
+				  credentialIssuer: pinniped-config
+				  apiService: pinniped-api
+				  impersonationLoadBalancerService: impersonationLoadBalancerService-value
+				  impersonationClusterIPService: impersonationClusterIPService-value
+				  impersonationTLSCertificateSecret: impersonationTLSCertificateSecret-value
+				  impersonationCACertificateSecret: impersonationCACertificateSecret-value
+				  impersonationSignerSecret: impersonationSignerSecret-value
+				  agentServiceAccount: agentServiceAccount-value
+				  impersonationProxyServiceAccount: impersonationProxyServiceAccount-value
+				  impersonationProxyLegacySecret: impersonationProxyLegacySecret-value
+				leaderElection:
+				  leaseDurationSeconds: 107
+			`),
+			wantError: "validate leaderElection: leaseDurationSeconds must be greater than renewDeadlineSeconds",
+		},
+		{
+			name: "leader election renewDeadlineSeconds too small for retryPeriodSeconds",
+			yaml: here.Doc(`
+				---
+				names:
+				  servingCertificateSecret: pinniped-concierge-api-tls-serving-certificate
+				  credentialIssuer: pinniped-config
+				  apiService: pinniped-api
+				  impersonationLoadBalancerService: impersonationLoadBalancerService-value
+				  impersonationClusterIPService: impersonationClusterIPService-value
+				  impersonationTLSCertificateSecret: impersonationTLSCertificateSecret-value
+				  impersonationCACertificateSecret: impersonationCACertificateSecret-value
+				  impersonationSignerSecret: impersonationSignerSecret-value
+				  agentServiceAccount: agentServiceAccount-value
+				  impersonationProxyServiceAccount: impersonationProxyServiceAccount-value
+				  impersonationProxyLegacySecret: impersonationProxyLegacySecret-value
+				leaderElection:
+				  renewDeadlineSeconds: 12
+				  retryPeriodSeconds: 10
+			`),
+			wantError: "validate leaderElection: renewDeadlineSeconds must be greater than 1.2 times retryPeriodSeconds",
+		},
+		{
+			name: "set-based secret label selector",
+			yaml: here.Doc(`
+				---
+				names:
+				  servingCertificateSecret: pinniped-concierge-api-tls-serving-certificate
+				  credentialIssuer: pinniped-config
+				  apiService: pinniped-api
+				  impersonationLoadBalancerService: impersonationLoadBalancerService-value
+				  impersonationClusterIPService: impersonationClusterIPService-value
+				  impersonationTLSCertificateSecret: impersonationTLSCertificateSecret-value
+				  impersonationCACertificateSecret: impersonationCACertificateSecret-value
+				  impersonationSignerSecret: impersonationSignerSecret-value
+				  agentServiceAccount: agentServiceAccount-value
+				  impersonationProxyServiceAccount: impersonationProxyServiceAccount-value
+				  impersonationProxyLegacySecret: impersonationProxyLegacySecret-value
+				informers:
+				  secretLabelSelector: app in (pinniped)
+			`),
+			wantError: "validate informers: invalid secretLabelSelector: invalid selector: [app in (pinniped)]",
+		},
+		{
+			name: "returns setTLSSettings errors",
+			yaml: here.Doc(`
+				---
+				names:
+				  servingCertificateSecret: pinniped-concierge-api-tls-serving-certificate
+				  credentialIssuer: pinniped-config
+				  apiService: pinniped-api
+				  impersonationLoadBalancerService: impersonationLoadBalancerService-value
+				  impersonationClusterIPService: impersonationClusterIPService-value
+				  impersonationTLSCertificateSecret: impersonationTLSCertificateSecret-value
+				  impersonationCACertificateSecret: impersonationCACertificateSecret-value
+				  impersonationSignerSecret: impersonationSignerSecret-value
+				  impersonationSignerSecret: impersonationSignerSecret-value
+				  agentServiceAccount: agentServiceAccount-value
+				  impersonationProxyServiceAccount: impersonationProxyServiceAccount-value
+				  impersonationProxyLegacySecret: impersonationProxyLegacySecret-value
+				tls:
+				  onedottwo:
+				    allowedCiphers:
+				    - TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256
+			`),
+			tlsSettingsError: fmt.Errorf("some error from setTLSSettings"),
+			wantError:        "validate tls: some error from setTLSSettings",
+		},
+		{
+			name: "invalid tls minVersion",
 			yaml: here.Doc(`
 				---
 				names:
@@ -625,9 +818,9 @@ func TestFromPath(t *testing.T) {
 				  onedottwo:
 				    allowedCiphers:
 				    - TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256
+				  minVersion: "1.0"
 			`),
-			allowedCiphersError: fmt.Errorf("some error from setAllowedCiphers"),
-			wantError:           "validate tls: some error from setAllowedCiphers",
+			wantError: `validate tls: minVersion: unknown TLS version "1.0", must be "1.2" or "1.3"`,
 		},
 	}
 	for _, test := range tests {
@@ -650,13 +843,13 @@ func TestFromPath(t *testing.T) {
 			ctx, cancel := context.WithCancel(context.Background())
 			t.Cleanup(cancel)
 
-			var actualCiphers []string
-			setAllowedCiphers := func(ciphers []string) error {
-				actualCiphers = ciphers
-				return test.allowedCiphersError
+			var actualTLSSettings ptls.UserConfiguredSettings
+			setTLSSettings := func(settings ptls.UserConfiguredSettings) error {
+				actualTLSSettings = settings
+				return test.tlsSettingsError
 			}
 
-			config, err := FromPath(ctx, f.Name(), setAllowedCiphers)
+			config, err := FromPath(ctx, f.Name(), setTLSSettings)
 
 			if test.wantError != "" {
 				require.EqualError(t, err, test.wantError)
@@ -665,7 +858,9 @@ func TestFromPath(t *testing.T) {
 
 			require.NoError(t, err)
 			require.Equal(t, test.wantConfig, config)
-			require.Equal(t, test.wantConfig.TLS.OneDotTwo.AllowedCiphers, actualCiphers)
+			require.Equal(t, test.wantConfig.TLS.OneDotTwo.AllowedCiphers, actualTLSSettings.AllowedCipherSuitesForTLSOneDotTwo)
+			require.Equal(t, test.wantTLSMinVersion, actualTLSSettings.MinVersion)
+			require.Equal(t, test.wantConfig.TLS.FIPSRestricted, actualTLSSettings.FIPSRestricted)
 		})
 	}
 }
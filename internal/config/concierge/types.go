@@ -3,24 +3,41 @@
 
 package concierge
 
-import "go.pinniped.dev/internal/plog"
+import (
+	tracingapiv1 "k8s.io/component-base/tracing/api/v1"
+
+	"go.pinniped.dev/internal/httputil/requestlog"
+	"go.pinniped.dev/internal/plog"
+)
 
 // Config contains knobs to set up an instance of the Pinniped Concierge.
 type Config struct {
-	DiscoveryInfo                DiscoveryInfoSpec `json:"discovery"`
-	APIConfig                    APIConfigSpec     `json:"api"`
-	APIGroupSuffix               *string           `json:"apiGroupSuffix,omitempty"`
-	AggregatedAPIServerPort      *int64            `json:"aggregatedAPIServerPort"`
-	ImpersonationProxyServerPort *int64            `json:"impersonationProxyServerPort"`
-	NamesConfig                  NamesConfigSpec   `json:"names"`
-	KubeCertAgentConfig          KubeCertAgentSpec `json:"kubeCertAgent"`
-	Labels                       map[string]string `json:"labels"`
-	Log                          plog.LogSpec      `json:"log"`
-	TLS                          TLSSpec           `json:"tls"`
+	DiscoveryInfo                DiscoveryInfoSpec                  `json:"discovery"`
+	APIConfig                    APIConfigSpec                      `json:"api"`
+	APIGroupSuffix               *string                            `json:"apiGroupSuffix,omitempty"`
+	AggregatedAPIServerPort      *int64                             `json:"aggregatedAPIServerPort"`
+	ImpersonationProxyServerPort *int64                             `json:"impersonationProxyServerPort"`
+	NamesConfig                  NamesConfigSpec                    `json:"names"`
+	KubeCertAgentConfig          KubeCertAgentSpec                  `json:"kubeCertAgent"`
+	Controllers                  ControllersSpec                    `json:"controllers"`
+	LeaderElection               LeaderElectionSpec                 `json:"leaderElection"`
+	Informers                    InformersSpec                      `json:"informers"`
+	Labels                       map[string]string                  `json:"labels"`
+	Log                          plog.LogSpec                       `json:"log"`
+	RequestLog                   requestlog.Spec                    `json:"requestLog"`
+	Tracing                      *tracingapiv1.TracingConfiguration `json:"tracing,omitempty"`
+	TLS                          TLSSpec                            `json:"tls"`
 }
 
 type TLSSpec struct {
 	OneDotTwo TLSProtocolSpec `json:"onedottwo"`
+	// MinVersion is the minimum TLS version, either "1.2" or "1.3", of all servers and clients, including the
+	// aggregated API server and outbound connections to upstreams. When empty, TLS 1.2 is allowed wherever
+	// clients might reasonably need it.
+	MinVersion string `json:"minVersion,omitempty"`
+	// FIPSRestricted limits all servers and clients to TLS 1.2 with FIPS-approved ciphers, like when Pinniped
+	// is compiled in FIPS-only mode. It cannot be combined with a MinVersion of "1.3".
+	FIPSRestricted bool `json:"fipsRestricted,omitempty"`
 }
 
 type TLSProtocolSpec struct {
@@ -91,4 +108,73 @@ type KubeCertAgentSpec struct {
 	// ImagePullSecrets is a list of names of Kubernetes Secret objects that will be used as
 	// ImagePullSecrets on the kube-cert-agent pods.
 	ImagePullSecrets []string
+
+	// ControlPlane describes where the kube-cert-agent finds the signing keypair of the cluster. The defaults
+	// work for kubeadm-style clusters, so this is only needed for other control plane layouts.
+	ControlPlane KubeCertAgentControlPlaneSpec `json:"controlPlane,omitempty"`
+}
+
+type KubeCertAgentControlPlaneSpec struct {
+	// Namespace is the namespace of the control plane pods which run on the nodes that hold the signing keypair.
+	// The Concierge needs permission to watch pods in this namespace. The default is "kube-system".
+	Namespace string `json:"namespace,omitempty"`
+
+	// PodLabels select the control plane pods. The kube-cert-agent pod is scheduled onto the node of the newest
+	// of them. The default is "component: kube-controller-manager".
+	PodLabels map[string]string `json:"podLabels,omitempty"`
+
+	// CertPathTemplate is a Go text/template which renders the path of the signing certificate on the node. It is
+	// executed with the selected control plane pod as its data, and it may call `flag "name" "fallback"` to read a
+	// command-line flag of the pod's containers. The default is
+	// `{{ flag "cluster-signing-cert-file" "/etc/kubernetes/ca/ca.pem" }}`.
+	CertPathTemplate string `json:"certPathTemplate,omitempty"`
+
+	// KeyPathTemplate is like CertPathTemplate, but for the signing key. The default is
+	// `{{ flag "cluster-signing-key-file" "/etc/kubernetes/ca/ca.key" }}`.
+	KeyPathTemplate string `json:"keyPathTemplate,omitempty"`
+
+	// HostPathVolumes are directories of the node which are mounted read-only into the kube-cert-agent pod at the
+	// same paths. When empty, the kube-cert-agent pod copies the volumes of the selected control plane pod.
+	HostPathVolumes []string `json:"hostPathVolumes,omitempty"`
+}
+
+// ControllersSpec selects which optional controllers the Concierge runs. Disabling the controllers of a feature
+// which is not used avoids the API requests and watches which they would otherwise make.
+type ControllersSpec struct {
+	// KubeCertAgent enables the controllers which run the kube-cert-agent pod to find the signing keypair of the
+	// cluster. When disabled, the Concierge does not watch pods in the control plane namespace nor the cluster-info
+	// ConfigMap in kube-public, and the KubeClusterSigningCertificate strategy is not used. The default is true.
+	KubeCertAgent *bool `json:"kubeCertAgent,omitempty"`
+
+	// ImpersonationProxy enables the controllers which run the impersonation proxy and manage its Services,
+	// certificates and signing CA. When disabled, the impersonation proxy never runs, regardless of the
+	// CredentialIssuer configuration. The default is true.
+	ImpersonationProxy *bool `json:"impersonationProxy,omitempty"`
+}
+
+// InformersSpec restricts the objects which are cached by the informers of the Concierge, which reduces their memory
+// usage and watch traffic on clusters where the namespace of the Concierge holds many unrelated objects.
+type InformersSpec struct {
+	// SecretLabelSelector selects the Secrets in the namespace of the Concierge which are watched. When empty, all
+	// Secrets are watched. It may only use equality-based requirements, e.g. "pinniped.dev/managed=true", because
+	// the Concierge adds the selected labels to the Secrets which it creates. There is no equivalent for ConfigMaps,
+	// because the only ConfigMap which the Concierge watches is the cluster-info ConfigMap of the kube-public
+	// namespace, which is not created by the Concierge.
+	SecretLabelSelector string `json:"secretLabelSelector"`
+}
+
+// LeaderElectionSpec tunes the leader election of the Concierge pods. Longer durations make fewer requests
+// to renew the lease, at the cost of a slower failover when the leader pod stops.
+type LeaderElectionSpec struct {
+	// LeaseDurationSeconds is how long the other pods wait before taking over the lease after it was last
+	// renewed. It must be greater than RenewDeadlineSeconds. The default is 137 seconds.
+	LeaseDurationSeconds *int64 `json:"leaseDurationSeconds,omitempty"`
+
+	// RenewDeadlineSeconds is how long the leader keeps retrying to renew the lease before it gives up
+	// leadership. It must be greater than 1.2 times RetryPeriodSeconds. The default is 107 seconds.
+	RenewDeadlineSeconds *int64 `json:"renewDeadlineSeconds,omitempty"`
+
+	// RetryPeriodSeconds is how long the pods wait between attempts to acquire or renew the lease.
+	// The default is 26 seconds.
+	RetryPeriodSeconds *int64 `json:"retryPeriodSeconds,omitempty"`
 }
@@ -7,18 +7,28 @@ package supervisor
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/yaml"
 
 	"go.pinniped.dev/internal/constable"
 	"go.pinniped.dev/internal/crypto/ptls"
+	"go.pinniped.dev/internal/federationdomain/accesslog"
 	"go.pinniped.dev/internal/groupsuffix"
+	"go.pinniped.dev/internal/httputil/requestlog"
 	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/internal/resourcelabels"
+	"go.pinniped.dev/internal/tracing"
 )
 
 const (
@@ -31,12 +41,19 @@ const (
 	// allow traffic from the control plane to most ports, but do allow traffic to port 10250. This allows
 	// the Concierge to work without additional configuration on these types of clusters.
 	aggregatedAPIServerPortDefault = 10250
+
+	accessLogMaxSizeMegabytesDefault = 100
+
+	accessLogCloudEventsSourceDefault               = "pinniped-supervisor"
+	accessLogCloudEventsMaxBatchSizeDefault         = 100
+	accessLogCloudEventsFlushIntervalSecondsDefault = 5
+	accessLogCloudEventsMaxRetriesDefault           = 3
 )
 
 // FromPath loads an Config from a provided local file path, inserts any
 // defaults (from the Config documentation), and verifies that the config is
 // valid (Config documentation).
-func FromPath(ctx context.Context, path string, setAllowedCiphers ptls.SetAllowedCiphersFunc) (*Config, error) {
+func FromPath(ctx context.Context, path string, setTLSSettings ptls.SetUserConfiguredSettingsFunc) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read file: %w", err)
@@ -83,6 +100,12 @@ func FromPath(ctx context.Context, path string, setAllowedCiphers ptls.SetAllowe
 	maybeSetEndpointDefault(&config.Endpoints.HTTP, Endpoint{
 		Network: NetworkDisabled,
 	})
+	maybeSetEndpointDefault(&config.Endpoints.ACMEHTTP01, Endpoint{
+		Network: NetworkDisabled,
+	})
+	maybeSetEndpointDefault(&config.Endpoints.Operational, Endpoint{
+		Network: NetworkDisabled,
+	})
 
 	if err := validateEndpoint(*config.Endpoints.HTTPS); err != nil {
 		return nil, fmt.Errorf("validate https endpoint: %w", err)
@@ -93,12 +116,61 @@ func FromPath(ctx context.Context, path string, setAllowedCiphers ptls.SetAllowe
 	if err := validateAdditionalHTTPEndpointRequirements(*config.Endpoints.HTTP); err != nil {
 		return nil, fmt.Errorf("validate http endpoint: %w", err)
 	}
-	if err := validateAtLeastOneEnabledEndpoint(*config.Endpoints.HTTPS, *config.Endpoints.HTTP); err != nil {
+	if err := validateEndpoint(*config.Endpoints.ACMEHTTP01); err != nil {
+		return nil, fmt.Errorf("validate acmeHTTP01 endpoint: %w", err)
+	}
+	if err := validateEndpoint(*config.Endpoints.Operational); err != nil {
+		return nil, fmt.Errorf("validate operational endpoint: %w", err)
+	}
+	if err := validateAdditionalHTTPEndpointRequirements(*config.Endpoints.Operational); err != nil {
+		return nil, fmt.Errorf("validate operational endpoint: %w", err)
+	}
+	if err := validateAdditionalHTTPSEndpoints(config.Endpoints.AdditionalHTTPS); err != nil {
+		return nil, fmt.Errorf("validate additionalHTTPS endpoints: %w", err)
+	}
+	enabledEndpoints := []Endpoint{*config.Endpoints.HTTPS, *config.Endpoints.HTTP}
+	for _, e := range config.Endpoints.AdditionalHTTPS {
+		enabledEndpoints = append(enabledEndpoints, e.Endpoint)
+	}
+	if err := validateAtLeastOneEnabledEndpoint(enabledEndpoints...); err != nil {
 		return nil, fmt.Errorf("validate endpoints: %w", err)
 	}
-	if err := setAllowedCiphers(config.TLS.OneDotTwo.AllowedCiphers); err != nil {
+	if err := validateTLS(config.TLS, setTLSSettings); err != nil {
 		return nil, fmt.Errorf("validate tls: %w", err)
 	}
+	for i := range config.Endpoints.AdditionalHTTPS {
+		e := &config.Endpoints.AdditionalHTTPS[i]
+		if e.TLS.MinVersion == "" {
+			e.TLS.MinVersion = TLSVersionOneDotTwo
+		}
+		if _, err := e.TLSConfig(ptls.Default(nil)); err != nil {
+			return nil, fmt.Errorf("validate additionalHTTPS endpoint %q tls: %w", e.Name, err)
+		}
+	}
+	if err := validateFeatureGates(config.FeatureGates); err != nil {
+		return nil, fmt.Errorf("validate featureGates: %w", err)
+	}
+
+	maybeSetAccessLogDefaults(&config.AccessLog)
+
+	if err := validateAccessLog(config.AccessLog); err != nil {
+		return nil, fmt.Errorf("validate accessLog: %w", err)
+	}
+	if err := requestlog.Validate(config.RequestLog); err != nil {
+		return nil, fmt.Errorf("validate requestLog: %w", err)
+	}
+	if err := tracing.Validate(config.Tracing); err != nil {
+		return nil, fmt.Errorf("validate tracing: %w", err)
+	}
+	if err := validateExternalClientSecrets(config.ExternalClientSecrets); err != nil {
+		return nil, fmt.Errorf("validate externalClientSecrets: %w", err)
+	}
+	if err := validateStatusPage(config.StatusPage, *config.Endpoints.Operational); err != nil {
+		return nil, fmt.Errorf("validate statusPage: %w", err)
+	}
+	if err := validateInformers(config.Informers); err != nil {
+		return nil, fmt.Errorf("validate informers: %w", err)
+	}
 
 	return &config, nil
 }
@@ -164,6 +236,35 @@ func validateAdditionalHTTPEndpointRequirements(endpoint Endpoint) error {
 	return nil
 }
 
+func validateAdditionalHTTPSEndpoints(endpoints []NamedHTTPSEndpoint) error {
+	names := sets.New[string]()
+	for _, e := range endpoints {
+		if errs := validation.IsDNS1123Label(e.Name); len(errs) > 0 {
+			return fmt.Errorf("invalid name %q: %s", e.Name, strings.Join(errs, ", "))
+		}
+		if names.Has(e.Name) {
+			return fmt.Errorf("duplicate name %q", e.Name)
+		}
+		names.Insert(e.Name)
+		if e.Network == NetworkDisabled {
+			return fmt.Errorf("endpoint %q may not be disabled, remove it instead", e.Name)
+		}
+		if err := validateEndpoint(e.Endpoint); err != nil {
+			return fmt.Errorf("endpoint %q: %w", e.Name, err)
+		}
+	}
+	return nil
+}
+
+// TLSConfig returns a copy of the given server tls.Config which is constrained by the TLS settings of this endpoint.
+func (e *NamedHTTPSEndpoint) TLSConfig(c *tls.Config) (*tls.Config, error) {
+	minVersion, err := ptls.ParseVersion(e.TLS.MinVersion)
+	if err != nil {
+		return nil, fmt.Errorf("minVersion: %w", err)
+	}
+	return ptls.ForListener(c, minVersion, e.TLS.AllowedCiphers)
+}
+
 func validateAtLeastOneEnabledEndpoint(endpoints ...Endpoint) error {
 	for _, endpoint := range endpoints {
 		if endpoint.Network != NetworkDisabled {
@@ -207,6 +308,148 @@ func addrIsOnlyOnLoopback(addr string) bool {
 	return ip.IsLoopback()
 }
 
+func validateFeatureGates(featureGates map[string]bool) error {
+	for name := range featureGates {
+		if !slices.Contains(knownFeatureGates, name) {
+			return fmt.Errorf("unknown feature gate %q, the known feature gates are %q", name, knownFeatureGates)
+		}
+	}
+	return nil
+}
+
+func maybeSetAccessLogDefaults(accessLog *AccessLogSpec) {
+	if accessLog.Format == "" {
+		accessLog.Format = string(accesslog.FormatJSON)
+	}
+	if accessLog.Sink.Type == "" {
+		accessLog.Sink.Type = AccessLogSinkStdout
+	}
+	if accessLog.Sink.File != nil && accessLog.Sink.File.MaxSizeMegabytes == 0 {
+		accessLog.Sink.File.MaxSizeMegabytes = accessLogMaxSizeMegabytesDefault
+	}
+	if cloudEvents := accessLog.Sink.CloudEvents; cloudEvents != nil {
+		if cloudEvents.Source == "" {
+			cloudEvents.Source = accessLogCloudEventsSourceDefault
+		}
+		if cloudEvents.MaxBatchSize == 0 {
+			cloudEvents.MaxBatchSize = accessLogCloudEventsMaxBatchSizeDefault
+		}
+		if cloudEvents.FlushIntervalSeconds == 0 {
+			cloudEvents.FlushIntervalSeconds = accessLogCloudEventsFlushIntervalSecondsDefault
+		}
+		if cloudEvents.MaxRetries == 0 {
+			cloudEvents.MaxRetries = accessLogCloudEventsMaxRetriesDefault
+		}
+	}
+}
+
+func validateAccessLog(accessLog AccessLogSpec) error {
+	if !slices.Contains(accesslog.AllFormats(), accesslog.Format(accessLog.Format)) {
+		return fmt.Errorf("unknown format %q, the known formats are %q", accessLog.Format, accesslog.AllFormats())
+	}
+	for _, field := range accessLog.Fields {
+		if !slices.Contains(accesslog.AllFields(), field) {
+			return fmt.Errorf("unknown field %q, the known fields are %q", field, accesslog.AllFields())
+		}
+	}
+	if len(accessLog.Fields) > 0 && accessLog.Format != string(accesslog.FormatJSON) {
+		return fmt.Errorf("fields can only be configured when the format is %q", accesslog.FormatJSON)
+	}
+
+	if accessLog.Sink.File != nil && accessLog.Sink.Type != AccessLogSinkFile {
+		return fmt.Errorf("sink.file can only be configured when sink.type is %q", AccessLogSinkFile)
+	}
+	if accessLog.Sink.CloudEvents != nil && accessLog.Sink.Type != AccessLogSinkCloudEvents {
+		return fmt.Errorf("sink.cloudEvents can only be configured when sink.type is %q", AccessLogSinkCloudEvents)
+	}
+
+	switch accessLog.Sink.Type {
+	case AccessLogSinkStdout:
+	case AccessLogSinkFile:
+		file := accessLog.Sink.File
+		if file == nil || file.Path == "" {
+			return constable.Error("sink.file.path is required when sink.type is \"file\"")
+		}
+		if file.MaxSizeMegabytes < 0 || file.MaxBackups < 0 || file.MaxAgeDays < 0 {
+			return constable.Error("sink.file.maxSizeMegabytes, sink.file.maxBackups, and sink.file.maxAgeDays must not be negative")
+		}
+	case AccessLogSinkCloudEvents:
+		// Each entry becomes the data of a CloudEvent, so it must be JSON.
+		if accessLog.Format != string(accesslog.FormatJSON) {
+			return fmt.Errorf("sink.type %q requires the format to be %q", AccessLogSinkCloudEvents, accesslog.FormatJSON)
+		}
+		if err := validateAccessLogCloudEventsSink(accessLog.Sink.CloudEvents); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown sink.type %q, the known types are %q", accessLog.Sink.Type, []string{AccessLogSinkStdout, AccessLogSinkFile, AccessLogSinkCloudEvents})
+	}
+	return nil
+}
+
+func validateAccessLogCloudEventsSink(cloudEvents *AccessLogCloudEventsSinkSpec) error {
+	if cloudEvents == nil || (cloudEvents.HTTP == nil) == (cloudEvents.Kafka == nil) {
+		return constable.Error("exactly one of sink.cloudEvents.http or sink.cloudEvents.kafka is required when sink.type is \"cloudEvents\"")
+	}
+	if cloudEvents.HTTP != nil {
+		if err := validateAccessLogCloudEventsURL(cloudEvents.HTTP.URL); err != nil {
+			return fmt.Errorf("sink.cloudEvents.http.url %w", err)
+		}
+	}
+	if cloudEvents.Kafka != nil {
+		if err := validateAccessLogCloudEventsURL(cloudEvents.Kafka.RESTProxyURL); err != nil {
+			return fmt.Errorf("sink.cloudEvents.kafka.restProxyURL %w", err)
+		}
+		if cloudEvents.Kafka.Topic == "" {
+			return constable.Error("sink.cloudEvents.kafka.topic is required")
+		}
+	}
+	if cloudEvents.MaxBatchSize < 0 || cloudEvents.FlushIntervalSeconds < 0 || cloudEvents.MaxRetries < 0 {
+		return constable.Error("sink.cloudEvents.maxBatchSize, sink.cloudEvents.flushIntervalSeconds, and sink.cloudEvents.maxRetries must not be negative")
+	}
+	return nil
+}
+
+func validateAccessLogCloudEventsURL(rawURL string) error {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return fmt.Errorf("must be an http or https URL, but got %q", rawURL)
+	}
+	return nil
+}
+
+func validateExternalClientSecrets(externalClientSecrets ExternalClientSecretsSpec) error {
+	for _, dir := range externalClientSecrets.AllowedDirectories {
+		if !filepath.IsAbs(dir) || filepath.Clean(dir) == "/" {
+			return fmt.Errorf("allowedDirectories must be absolute paths other than the root directory, but got %q", dir)
+		}
+	}
+	return nil
+}
+
+func validateStatusPage(statusPage StatusPageSpec, operational Endpoint) error {
+	if !statusPage.Enabled {
+		return nil
+	}
+	if operational.Network == NetworkDisabled {
+		return constable.Error("the status page requires the operational endpoint to be enabled")
+	}
+	if statusPage.CredentialsSecretName == "" {
+		return constable.Error("credentialsSecretName is required when the status page is enabled")
+	}
+	return nil
+}
+
+func validateInformers(informers InformersSpec) error {
+	if _, err := resourcelabels.ParseSelector(informers.SecretLabelSelector); err != nil {
+		return fmt.Errorf("invalid secretLabelSelector: %w", err)
+	}
+	if _, err := resourcelabels.ParseSelector(informers.ConfigMapLabelSelector); err != nil {
+		return fmt.Errorf("invalid configMapLabelSelector: %w", err)
+	}
+	return nil
+}
+
 func validateServerPort(port *int64) error {
 	// It cannot be below 1024 because the container is not running as root.
 	if *port < 1024 || *port > 65535 {
@@ -214,3 +457,15 @@ func validateServerPort(port *int64) error {
 	}
 	return nil
 }
+
+func validateTLS(tlsSpec TLSSpec, setTLSSettings ptls.SetUserConfiguredSettingsFunc) error {
+	minVersion, err := ptls.ParseVersion(tlsSpec.MinVersion)
+	if err != nil {
+		return fmt.Errorf("minVersion: %w", err)
+	}
+	return setTLSSettings(ptls.UserConfiguredSettings{
+		AllowedCipherSuitesForTLSOneDotTwo: tlsSpec.OneDotTwo.AllowedCiphers,
+		MinVersion:                         minVersion,
+		FIPSRestricted:                     tlsSpec.FIPSRestricted,
+	})
+}
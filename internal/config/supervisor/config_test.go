@@ -5,6 +5,7 @@ package supervisor
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"os"
 	"testing"
@@ -12,20 +13,23 @@ import (
 	"github.com/stretchr/testify/require"
 	"k8s.io/utils/ptr"
 
+	"go.pinniped.dev/internal/crypto/ptls"
 	"go.pinniped.dev/internal/here"
 	"go.pinniped.dev/internal/plog"
 )
 
 func TestFromPath(t *testing.T) {
 	tests := []struct {
-		name                string
-		yaml                string
-		allowedCiphersError error
-		wantConfig          *Config
-		wantError           string
+		name              string
+		yaml              string
+		tlsSettingsError  error
+		wantConfig        *Config
+		wantError         string
+		wantTLSMinVersion uint16
 	}{
 		{
-			name: "Happy",
+			name:              "Happy",
+			wantTLSMinVersion: tls.VersionTLS12,
 			yaml: here.Doc(`
 				---
 				apiGroupSuffix: some.suffix.com
@@ -41,6 +45,25 @@ func TestFromPath(t *testing.T) {
 				  http:
 				    network: tcp
 				    address: 127.0.0.1:1234
+				  acmeHTTP01:
+				    network: tcp
+				    address: :8080
+				  operational:
+				    network: tcp
+				    address: 127.0.0.1:8081
+				  additionalHTTPS:
+				  - name: tenant-a
+				    network: tcp
+				    address: 10.0.0.1:8443
+				    tls:
+				      minVersion: "1.3"
+				  - name: tenant-b
+				    network: tcp
+				    address: :9443
+				    tls:
+				      allowedCiphers:
+				      - TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256
+				      - TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256
 				insecureAcceptExternalUnencryptedHttpRequests: false
 				log:
 				  level: info
@@ -52,6 +75,31 @@ func TestFromPath(t *testing.T) {
 				    - foo
 				    - bar
 				    - TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305
+				  minVersion: "1.2"
+				  fipsRestricted: true
+				audit:
+				  sensitiveGroups:
+				  - cluster-admins
+				  - security-team
+				featureGates:
+				  MockIdentityProvider: true
+				accessLog:
+				  format: combined
+				  sink:
+				    type: file
+				    file:
+				      path: /var/log/pinniped/access.log
+				      maxBackups: 3
+				      maxAgeDays: 7
+				externalClientSecrets:
+				  allowedDirectories:
+				  - /mnt/secrets-store
+				statusPage:
+				  enabled: true
+				  credentialsSecretName: status-page-credentials
+				informers:
+				  secretLabelSelector: pinniped.dev/managed=true
+				  configMapLabelSelector: pinniped.dev/managed=true,app=branding
 			`),
 			wantConfig: &Config{
 				APIGroupSuffix: ptr.To("some.suffix.com"),
@@ -71,6 +119,32 @@ func TestFromPath(t *testing.T) {
 						Network: "tcp",
 						Address: "127.0.0.1:1234",
 					},
+					ACMEHTTP01: &Endpoint{
+						Network: "tcp",
+						Address: ":8080",
+					},
+					Operational: &Endpoint{
+						Network: "tcp",
+						Address: "127.0.0.1:8081",
+					},
+					AdditionalHTTPS: []NamedHTTPSEndpoint{
+						{
+							Name:     "tenant-a",
+							Endpoint: Endpoint{Network: "tcp", Address: "10.0.0.1:8443"},
+							TLS:      ListenerTLSSpec{MinVersion: "1.3"},
+						},
+						{
+							Name:     "tenant-b",
+							Endpoint: Endpoint{Network: "tcp", Address: ":9443"},
+							TLS: ListenerTLSSpec{
+								MinVersion: "1.2",
+								AllowedCiphers: []string{
+									"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+									"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+								},
+							},
+						},
+					},
 				},
 				Log: plog.LogSpec{
 					Level:  plog.LevelInfo,
@@ -85,6 +159,35 @@ func TestFromPath(t *testing.T) {
 							"TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305",
 						},
 					},
+					MinVersion:     "1.2",
+					FIPSRestricted: true,
+				},
+				Audit: AuditSpec{
+					SensitiveGroups: []string{"cluster-admins", "security-team"},
+				},
+				AccessLog: AccessLogSpec{
+					Format: "combined",
+					Sink: AccessLogSinkSpec{
+						Type: "file",
+						File: &AccessLogFileSinkSpec{
+							Path:             "/var/log/pinniped/access.log",
+							MaxSizeMegabytes: 100,
+							MaxBackups:       3,
+							MaxAgeDays:       7,
+						},
+					},
+				},
+				FeatureGates: map[string]bool{"MockIdentityProvider": true},
+				ExternalClientSecrets: ExternalClientSecretsSpec{
+					AllowedDirectories: []string{"/mnt/secrets-store"},
+				},
+				StatusPage: StatusPageSpec{
+					Enabled:               true,
+					CredentialsSecretName: "status-page-credentials",
+				},
+				Informers: InformersSpec{
+					SecretLabelSelector:    "pinniped.dev/managed=true",
+					ConfigMapLabelSelector: "pinniped.dev/managed=true,app=branding",
 				},
 			},
 		},
@@ -121,8 +224,110 @@ func TestFromPath(t *testing.T) {
 					HTTP: &Endpoint{
 						Network: "disabled",
 					},
+					ACMEHTTP01: &Endpoint{
+						Network: "disabled",
+					},
+					Operational: &Endpoint{
+						Network: "disabled",
+					},
 				},
 				AggregatedAPIServerPort: ptr.To[int64](10250),
+				AccessLog: AccessLogSpec{
+					Format: "json",
+					Sink:   AccessLogSinkSpec{Type: "stdout"},
+				},
+			},
+		},
+		{
+			name: "access log with json fields",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				accessLog:
+				  fields: [time, status, uri]
+			`),
+			wantConfig: &Config{
+				APIGroupSuffix: ptr.To("pinniped.dev"),
+				Labels:         map[string]string{},
+				NamesConfig: NamesConfigSpec{
+					DefaultTLSCertificateSecret: "my-secret-name",
+				},
+				Endpoints: &Endpoints{
+					HTTPS: &Endpoint{
+						Network: "tcp",
+						Address: ":8443",
+					},
+					HTTP: &Endpoint{
+						Network: "disabled",
+					},
+					ACMEHTTP01: &Endpoint{
+						Network: "disabled",
+					},
+					Operational: &Endpoint{
+						Network: "disabled",
+					},
+				},
+				AggregatedAPIServerPort: ptr.To[int64](10250),
+				AccessLog: AccessLogSpec{
+					Format: "json",
+					Fields: []string{"time", "status", "uri"},
+					Sink:   AccessLogSinkSpec{Type: "stdout"},
+				},
+			},
+		},
+		{
+			name: "access log with a cloudEvents sink uses defaults",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				accessLog:
+				  sink:
+				    type: cloudEvents
+				    cloudEvents:
+				      kafka:
+				        restProxyURL: https://kafka-rest.example.com
+				        topic: access-logs
+			`),
+			wantConfig: &Config{
+				APIGroupSuffix: ptr.To("pinniped.dev"),
+				Labels:         map[string]string{},
+				NamesConfig: NamesConfigSpec{
+					DefaultTLSCertificateSecret: "my-secret-name",
+				},
+				Endpoints: &Endpoints{
+					HTTPS: &Endpoint{
+						Network: "tcp",
+						Address: ":8443",
+					},
+					HTTP: &Endpoint{
+						Network: "disabled",
+					},
+					ACMEHTTP01: &Endpoint{
+						Network: "disabled",
+					},
+					Operational: &Endpoint{
+						Network: "disabled",
+					},
+				},
+				AggregatedAPIServerPort: ptr.To[int64](10250),
+				AccessLog: AccessLogSpec{
+					Format: "json",
+					Sink: AccessLogSinkSpec{
+						Type: "cloudEvents",
+						CloudEvents: &AccessLogCloudEventsSinkSpec{
+							Source: "pinniped-supervisor",
+							Kafka: &AccessLogCloudEventsKafkaSpec{
+								RESTProxyURL: "https://kafka-rest.example.com",
+								Topic:        "access-logs",
+							},
+							MaxBatchSize:         100,
+							FlushIntervalSeconds: 5,
+							MaxRetries:           3,
+						},
+					},
+				},
 			},
 		},
 		{
@@ -197,6 +402,188 @@ func TestFromPath(t *testing.T) {
 			`),
 			wantError: `validate http endpoint: http listener address ":8080" for "tcp" network may only bind to loopback interfaces`,
 		},
+		{
+			name: "invalid acmeHTTP01 endpoint",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				endpoints:
+				  acmeHTTP01:
+				    network: tcp
+			`),
+			wantError: `validate acmeHTTP01 endpoint: address must be set with "tcp" network`,
+		},
+		{
+			name: "operational endpoint binds to more than only loopback interfaces",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				endpoints:
+				  operational:
+				    network: tcp
+				    address: :8081
+			`),
+			wantError: `validate operational endpoint: http listener address ":8081" for "tcp" network may only bind to loopback interfaces`,
+		},
+		{
+			name: "status page without the operational endpoint",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				statusPage:
+				  enabled: true
+				  credentialsSecretName: status-page-credentials
+			`),
+			wantError: "validate statusPage: the status page requires the operational endpoint to be enabled",
+		},
+		{
+			name: "status page without credentials",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				endpoints:
+				  operational:
+				    network: tcp
+				    address: 127.0.0.1:8081
+				statusPage:
+				  enabled: true
+			`),
+			wantError: "validate statusPage: credentialsSecretName is required when the status page is enabled",
+		},
+		{
+			name: "set-based secret label selector",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				informers:
+				  secretLabelSelector: app in (pinniped)
+			`),
+			wantError: "validate informers: invalid secretLabelSelector: invalid selector: [app in (pinniped)]",
+		},
+		{
+			name: "existence-based config map label selector",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				informers:
+				  configMapLabelSelector: app
+			`),
+			wantError: "validate informers: invalid configMapLabelSelector: invalid selector: [app]",
+		},
+		{
+			name: "additionalHTTPS endpoint with an invalid name",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				endpoints:
+				  additionalHTTPS:
+				  - name: Tenant_A
+				    network: tcp
+				    address: :9443
+			`),
+			wantError: `validate additionalHTTPS endpoints: invalid name "Tenant_A": a lowercase RFC 1123 label must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character (e.g. 'my-name',  or '123-abc', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?')`,
+		},
+		{
+			name: "additionalHTTPS endpoints with duplicate names",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				endpoints:
+				  additionalHTTPS:
+				  - name: tenant-a
+				    network: tcp
+				    address: :9443
+				  - name: tenant-a
+				    network: tcp
+				    address: :9444
+			`),
+			wantError: `validate additionalHTTPS endpoints: duplicate name "tenant-a"`,
+		},
+		{
+			name: "additionalHTTPS endpoint which is disabled",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				endpoints:
+				  additionalHTTPS:
+				  - name: tenant-a
+				    network: disabled
+			`),
+			wantError: `validate additionalHTTPS endpoints: endpoint "tenant-a" may not be disabled, remove it instead`,
+		},
+		{
+			name: "additionalHTTPS endpoint without an address",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				endpoints:
+				  additionalHTTPS:
+				  - name: tenant-a
+				    network: tcp
+			`),
+			wantError: `validate additionalHTTPS endpoints: endpoint "tenant-a": address must be set with "tcp" network`,
+		},
+		{
+			name: "additionalHTTPS endpoint with an unknown minVersion",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				endpoints:
+				  additionalHTTPS:
+				  - name: tenant-a
+				    network: tcp
+				    address: :9443
+				    tls:
+				      minVersion: "1.1"
+			`),
+			wantError: `validate additionalHTTPS endpoint "tenant-a" tls: minVersion: unknown TLS version "1.1", must be "1.2" or "1.3"`,
+		},
+		{
+			name: "additionalHTTPS endpoint with allowedCiphers for TLS 1.3",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				endpoints:
+				  additionalHTTPS:
+				  - name: tenant-a
+				    network: tcp
+				    address: :9443
+				    tls:
+				      minVersion: "1.3"
+				      allowedCiphers:
+				      - TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256
+			`),
+			wantError: `validate additionalHTTPS endpoint "tenant-a" tls: allowed ciphers cannot be configured for TLS 1.3`,
+		},
+		{
+			name: "additionalHTTPS endpoint with only ciphers which are not used by the TLS profile",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				endpoints:
+				  additionalHTTPS:
+				  - name: tenant-a
+				    network: tcp
+				    address: :9443
+				    tls:
+				      allowedCiphers:
+				      - TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA
+			`),
+			wantError: `validate additionalHTTPS endpoint "tenant-a" tls: none of the allowed ciphers [TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA] are used by the TLS profile`,
+		},
 		{
 			name: "endpoint disabled with non-empty address",
 			yaml: here.Doc(`
@@ -268,7 +655,7 @@ func TestFromPath(t *testing.T) {
 			wantError: "validate aggregatedAPIServerPort: must be within range 1024 to 65535",
 		},
 		{
-			name: "returns setAllowedCiphers errors",
+			name: "returns setTLSSettings errors",
 			yaml: here.Doc(`
 				---
 				names:
@@ -278,8 +665,264 @@ func TestFromPath(t *testing.T) {
 				    allowedCiphers:
 				    - TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256
 			`),
-			allowedCiphersError: fmt.Errorf("some error from setAllowedCiphers"),
-			wantError:           "validate tls: some error from setAllowedCiphers",
+			tlsSettingsError: fmt.Errorf("some error from setTLSSettings"),
+			wantError:        "validate tls: some error from setTLSSettings",
+		},
+		{
+			name: "invalid tls minVersion",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				tls:
+				  onedottwo:
+				    allowedCiphers:
+				    - TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256
+				  minVersion: "1.0"
+			`),
+			wantError: `validate tls: minVersion: unknown TLS version "1.0", must be "1.2" or "1.3"`,
+		},
+		{
+			name: "unknown feature gate",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				featureGates:
+				  SomeFutureFeature: true
+			`),
+			wantError: `validate featureGates: unknown feature gate "SomeFutureFeature", the known feature gates are ["MockIdentityProvider"]`,
+		},
+		{
+			name: "unknown access log format",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				accessLog:
+				  format: xml
+			`),
+			wantError: `validate accessLog: unknown format "xml", the known formats are ["common" "combined" "json"]`,
+		},
+		{
+			name: "unknown access log field",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				accessLog:
+				  fields: [password]
+			`),
+			wantError: `validate accessLog: unknown field "password", the known fields are ` +
+				`["time" "federationDomain" "remoteAddr" "host" "method" "uri" "proto" "status" "bytes" "durationMillis" "referer" "userAgent"]`,
+		},
+		{
+			name: "request log with an invalid sample rate",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				requestLog:
+				  enabled: true
+				  sampleRate: 2
+			`),
+			wantError: `validate requestLog: sampleRate must be between 0 and 1`,
+		},
+		{
+			name: "tracing with an invalid sampling rate",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				tracing:
+				  samplingRatePerMillion: 2000000
+			`),
+			wantError: `validate tracing: tracing.samplingRatePerMillion: Invalid value: 2000000: sampling rate per million must be less than or equal to one million`,
+		},
+		{
+			name: "access log fields with a non-json format",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				accessLog:
+				  format: common
+				  fields: [status]
+			`),
+			wantError: `validate accessLog: fields can only be configured when the format is "json"`,
+		},
+		{
+			name: "unknown access log sink type",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				accessLog:
+				  sink:
+				    type: syslog
+			`),
+			wantError: `validate accessLog: unknown sink.type "syslog", the known types are ["stdout" "file" "cloudEvents"]`,
+		},
+		{
+			name: "access log file sink without a path",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				accessLog:
+				  sink:
+				    type: file
+			`),
+			wantError: `validate accessLog: sink.file.path is required when sink.type is "file"`,
+		},
+		{
+			name: "access log file settings with the stdout sink",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				accessLog:
+				  sink:
+				    file:
+				      path: /tmp/access.log
+			`),
+			wantError: `validate accessLog: sink.file can only be configured when sink.type is "file"`,
+		},
+		{
+			name: "access log cloudEvents settings with the file sink",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				accessLog:
+				  sink:
+				    type: file
+				    file:
+				      path: /tmp/access.log
+				    cloudEvents:
+				      http:
+				        url: https://collector.example.com
+			`),
+			wantError: `validate accessLog: sink.cloudEvents can only be configured when sink.type is "cloudEvents"`,
+		},
+		{
+			name: "access log cloudEvents sink with a non-json format",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				accessLog:
+				  format: common
+				  sink:
+				    type: cloudEvents
+				    cloudEvents:
+				      http:
+				        url: https://collector.example.com
+			`),
+			wantError: `validate accessLog: sink.type "cloudEvents" requires the format to be "json"`,
+		},
+		{
+			name: "access log cloudEvents sink with both transports",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				accessLog:
+				  sink:
+				    type: cloudEvents
+				    cloudEvents:
+				      http:
+				        url: https://collector.example.com
+				      kafka:
+				        restProxyURL: https://kafka-rest.example.com
+				        topic: access-logs
+			`),
+			wantError: `validate accessLog: exactly one of sink.cloudEvents.http or sink.cloudEvents.kafka is required when sink.type is "cloudEvents"`,
+		},
+		{
+			name: "access log cloudEvents sink with an invalid URL",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				accessLog:
+				  sink:
+				    type: cloudEvents
+				    cloudEvents:
+				      http:
+				        url: ftp://collector.example.com
+			`),
+			wantError: `validate accessLog: sink.cloudEvents.http.url must be an http or https URL, but got "ftp://collector.example.com"`,
+		},
+		{
+			name: "access log cloudEvents sink without a kafka topic",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				accessLog:
+				  sink:
+				    type: cloudEvents
+				    cloudEvents:
+				      kafka:
+				        restProxyURL: https://kafka-rest.example.com
+			`),
+			wantError: `validate accessLog: sink.cloudEvents.kafka.topic is required`,
+		},
+		{
+			name: "access log cloudEvents sink with negative settings",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				accessLog:
+				  sink:
+				    type: cloudEvents
+				    cloudEvents:
+				      http:
+				        url: https://collector.example.com
+				      maxRetries: -1
+			`),
+			wantError: `validate accessLog: sink.cloudEvents.maxBatchSize, sink.cloudEvents.flushIntervalSeconds, and sink.cloudEvents.maxRetries must not be negative`,
+		},
+		{
+			name: "access log file sink with negative rotation settings",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				accessLog:
+				  sink:
+				    type: file
+				    file:
+				      path: /tmp/access.log
+				      maxBackups: -1
+			`),
+			wantError: `validate accessLog: sink.file.maxSizeMegabytes, sink.file.maxBackups, and sink.file.maxAgeDays must not be negative`,
+		},
+		{
+			name: "external client secrets directory is relative",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				externalClientSecrets:
+				  allowedDirectories:
+				  - mnt/secrets-store
+			`),
+			wantError: `validate externalClientSecrets: allowedDirectories must be absolute paths other than the root directory, but got "mnt/secrets-store"`,
+		},
+		{
+			name: "external client secrets directory is the root directory",
+			yaml: here.Doc(`
+				---
+				names:
+				  defaultTLSCertificateSecret: my-secret-name
+				externalClientSecrets:
+				  allowedDirectories:
+				  - /
+			`),
+			wantError: `validate externalClientSecrets: allowedDirectories must be absolute paths other than the root directory, but got "/"`,
 		},
 	}
 	for _, test := range tests {
@@ -302,13 +945,13 @@ func TestFromPath(t *testing.T) {
 			ctx, cancel := context.WithCancel(context.Background())
 			t.Cleanup(cancel)
 
-			var actualCiphers []string
-			setAllowedCiphers := func(ciphers []string) error {
-				actualCiphers = ciphers
-				return test.allowedCiphersError
+			var actualTLSSettings ptls.UserConfiguredSettings
+			setTLSSettings := func(settings ptls.UserConfiguredSettings) error {
+				actualTLSSettings = settings
+				return test.tlsSettingsError
 			}
 
-			config, err := FromPath(ctx, f.Name(), setAllowedCiphers)
+			config, err := FromPath(ctx, f.Name(), setTLSSettings)
 
 			if test.wantError != "" {
 				require.EqualError(t, err, test.wantError)
@@ -317,7 +960,9 @@ func TestFromPath(t *testing.T) {
 
 			require.NoError(t, err)
 			require.Equal(t, test.wantConfig, config)
-			require.Equal(t, test.wantConfig.TLS.OneDotTwo.AllowedCiphers, actualCiphers)
+			require.Equal(t, test.wantConfig.TLS.OneDotTwo.AllowedCiphers, actualTLSSettings.AllowedCipherSuitesForTLSOneDotTwo)
+			require.Equal(t, test.wantTLSMinVersion, actualTLSSettings.MinVersion)
+			require.Equal(t, test.wantConfig.TLS.FIPSRestricted, actualTLSSettings.FIPSRestricted)
 		})
 	}
 }
@@ -4,22 +4,161 @@
 package supervisor
 
 import (
+	tracingapiv1 "k8s.io/component-base/tracing/api/v1"
+
+	"go.pinniped.dev/internal/httputil/requestlog"
 	"go.pinniped.dev/internal/plog"
 )
 
 // Config contains knobs to setup an instance of the Pinniped Supervisor.
 type Config struct {
-	APIGroupSuffix          *string           `json:"apiGroupSuffix,omitempty"`
-	Labels                  map[string]string `json:"labels"`
-	NamesConfig             NamesConfigSpec   `json:"names"`
-	Log                     plog.LogSpec      `json:"log"`
-	Endpoints               *Endpoints        `json:"endpoints"`
-	AggregatedAPIServerPort *int64            `json:"aggregatedAPIServerPort"`
-	TLS                     TLSSpec           `json:"tls"`
+	APIGroupSuffix          *string                            `json:"apiGroupSuffix,omitempty"`
+	Labels                  map[string]string                  `json:"labels"`
+	NamesConfig             NamesConfigSpec                    `json:"names"`
+	Log                     plog.LogSpec                       `json:"log"`
+	Endpoints               *Endpoints                         `json:"endpoints"`
+	AggregatedAPIServerPort *int64                             `json:"aggregatedAPIServerPort"`
+	TLS                     TLSSpec                            `json:"tls"`
+	Audit                   AuditSpec                          `json:"audit"`
+	AccessLog               AccessLogSpec                      `json:"accessLog"`
+	RequestLog              requestlog.Spec                    `json:"requestLog"`
+	Tracing                 *tracingapiv1.TracingConfiguration `json:"tracing,omitempty"`
+	FeatureGates            map[string]bool                    `json:"featureGates"`
+
+	ExternalClientSecrets ExternalClientSecretsSpec `json:"externalClientSecrets"`
+	StatusPage            StatusPageSpec            `json:"statusPage"`
+	Informers             InformersSpec             `json:"informers"`
+}
+
+// FeatureMockIdentityProvider enables the MockIdentityProvider, which is only intended for demo and test environments.
+const FeatureMockIdentityProvider = "MockIdentityProvider"
+
+// knownFeatureGates lists the names of the features which can be enabled using FeatureGates, all of which are
+// disabled by default.
+//
+//nolint:gochecknoglobals // This is a constant lookup table.
+var knownFeatureGates = []string{FeatureMockIdentityProvider}
+
+// FeatureEnabled returns true when the named feature was enabled using FeatureGates.
+func (c *Config) FeatureEnabled(feature string) bool {
+	return c.FeatureGates[feature]
+}
+
+// AuditSpec configures the events which the Supervisor emits for auditing purposes.
+type AuditSpec struct {
+	// SensitiveGroups lists downstream group names for which any change in a user's membership,
+	// as detected during a refresh, should be reported at a higher severity.
+	SensitiveGroups []string `json:"sensitiveGroups"`
+}
+
+// AccessLogSpec configures the HTTP access logs of the FederationDomains. These are only written for the
+// FederationDomains which enable them using spec.accessLog.enabled.
+type AccessLogSpec struct {
+	// Format is one of "common", "combined", or "json". Defaults to "json".
+	Format string `json:"format"`
+	// Fields lists the fields to include in each "json" entry. If empty, all fields are included.
+	Fields []string `json:"fields"`
+	// Sink configures where the access logs are written.
+	Sink AccessLogSinkSpec `json:"sink"`
+}
+
+const (
+	AccessLogSinkStdout      = "stdout"
+	AccessLogSinkFile        = "file"
+	AccessLogSinkCloudEvents = "cloudEvents"
+)
+
+// AccessLogSinkSpec configures where the access logs are written.
+type AccessLogSinkSpec struct {
+	// Type is one of "stdout", "file", or "cloudEvents". Defaults to "stdout".
+	Type string `json:"type"`
+	// File configures the file sink. Required when Type is "file".
+	File *AccessLogFileSinkSpec `json:"file,omitempty"`
+	// CloudEvents configures the CloudEvents sink. Required when Type is "cloudEvents".
+	CloudEvents *AccessLogCloudEventsSinkSpec `json:"cloudEvents,omitempty"`
+}
+
+// AccessLogFileSinkSpec configures a file to which access logs are written, and its rotation.
+type AccessLogFileSinkSpec struct {
+	Path string `json:"path"`
+	// MaxSizeMegabytes is the size at which the file is rotated. Defaults to 100.
+	MaxSizeMegabytes int `json:"maxSizeMegabytes"`
+	// MaxBackups is the number of rotated files to keep. Zero means keep all of them.
+	MaxBackups int `json:"maxBackups"`
+	// MaxAgeDays is the number of days to keep rotated files. Zero means keep them regardless of age.
+	MaxAgeDays int `json:"maxAgeDays"`
+}
+
+// AccessLogCloudEventsSinkSpec configures a sink which wraps each access log entry in a CloudEvent, and delivers
+// the events in batches to either an HTTP endpoint or a Kafka topic. Exactly one of HTTP or Kafka is required.
+// Only the access logs are delivered as CloudEvents. The Supervisor's audit logs are still written to its pod logs.
+type AccessLogCloudEventsSinkSpec struct {
+	// Source is the source attribute of the events. Defaults to "pinniped-supervisor".
+	Source string `json:"source"`
+	// HTTP delivers the events to an HTTP endpoint.
+	HTTP *AccessLogCloudEventsHTTPSpec `json:"http,omitempty"`
+	// Kafka delivers the events to a Kafka topic using a Kafka REST Proxy.
+	Kafka *AccessLogCloudEventsKafkaSpec `json:"kafka,omitempty"`
+	// MaxBatchSize is the maximum number of events delivered at once. Defaults to 100.
+	MaxBatchSize int `json:"maxBatchSize"`
+	// FlushIntervalSeconds is how long events may wait to be delivered in a partial batch. Defaults to 5.
+	FlushIntervalSeconds int `json:"flushIntervalSeconds"`
+	// MaxRetries is how many times the delivery of a batch is retried before its events are written to the
+	// Supervisor's log instead. Defaults to 3.
+	MaxRetries int `json:"maxRetries"`
+}
+
+// AccessLogCloudEventsHTTPSpec configures an HTTP endpoint which receives batches of CloudEvents.
+type AccessLogCloudEventsHTTPSpec struct {
+	URL string `json:"url"`
+}
+
+// AccessLogCloudEventsKafkaSpec configures a Kafka REST Proxy which produces CloudEvents to a topic. Kafka is only
+// supported through a Kafka REST Proxy, since the Supervisor does not speak the Kafka protocol.
+type AccessLogCloudEventsKafkaSpec struct {
+	// RESTProxyURL is the base URL of a Kafka REST Proxy which supports its v2 API.
+	RESTProxyURL string `json:"restProxyURL"`
+	Topic        string `json:"topic"`
+}
+
+// ExternalClientSecretsSpec configures where identity providers may read their client credentials from files,
+// e.g. files which are mounted into the Supervisor pods by the Secrets Store CSI driver.
+type ExternalClientSecretsSpec struct {
+	// AllowedDirectories lists the absolute paths of the directories which may be referenced by the
+	// spec.client.secretRef of an identity provider. When empty, no identity provider may use a secretRef.
+	AllowedDirectories []string `json:"allowedDirectories"`
+}
+
+// StatusPageSpec configures the read-only status page which is served at /status by the operational listener.
+type StatusPageSpec struct {
+	// Enabled turns on the status page. It requires the operational listener to be enabled.
+	Enabled bool `json:"enabled"`
+	// CredentialsSecretName is the name of a Secret of type kubernetes.io/basic-auth in the namespace of the
+	// Supervisor, which holds the only username and password which may view the status page.
+	CredentialsSecretName string `json:"credentialsSecretName"`
+}
+
+// InformersSpec restricts the objects which are cached by the informers of the Supervisor, which reduces their memory
+// usage and watch traffic on clusters where the namespace of the Supervisor holds many unrelated objects.
+// Each selector may only use equality-based requirements, e.g. "pinniped.dev/managed=true", because the Supervisor
+// adds the selected labels to the objects of that kind which it creates. Objects which are created by others, like
+// the client secrets of identity providers, must be labeled by their creators to be visible to the Supervisor.
+type InformersSpec struct {
+	// SecretLabelSelector selects the Secrets which are watched. When empty, all Secrets are watched.
+	SecretLabelSelector string `json:"secretLabelSelector"`
+	// ConfigMapLabelSelector selects the ConfigMaps which are watched. When empty, all ConfigMaps are watched.
+	ConfigMapLabelSelector string `json:"configMapLabelSelector"`
 }
 
 type TLSSpec struct {
 	OneDotTwo TLSProtocolSpec `json:"onedottwo"`
+	// MinVersion is the minimum TLS version, either "1.2" or "1.3", of all servers and clients, including the
+	// aggregated API server and outbound connections to upstreams. When empty, TLS 1.2 is allowed wherever
+	// clients might reasonably need it.
+	MinVersion string `json:"minVersion,omitempty"`
+	// FIPSRestricted limits all servers and clients to TLS 1.2 with FIPS-approved ciphers, like when Pinniped
+	// is compiled in FIPS-only mode. It cannot be combined with a MinVersion of "1.3".
+	FIPSRestricted bool `json:"fipsRestricted,omitempty"`
 }
 
 type TLSProtocolSpec struct {
@@ -38,6 +177,40 @@ type NamesConfigSpec struct {
 type Endpoints struct {
 	HTTPS *Endpoint `json:"https,omitempty"`
 	HTTP  *Endpoint `json:"http,omitempty"`
+	// ACMEHTTP01 is a plain HTTP listener which only serves the responses to the ACME HTTP-01 challenges of
+	// FederationDomains which use spec.tls.acme. Unlike the HTTP listener, it may bind to any interface.
+	ACMEHTTP01 *Endpoint `json:"acmeHTTP01,omitempty"`
+	// AdditionalHTTPS lists named HTTPS listeners, each of which only serves the FederationDomains which select it
+	// by name using spec.listener. This isolates those issuers from the issuers which are served by the HTTPS and
+	// HTTP listeners, e.g. by binding them to a different port or IP address with different TLS settings.
+	AdditionalHTTPS []NamedHTTPSEndpoint `json:"additionalHTTPS,omitempty"`
+	// Operational is a plain HTTP listener which serves the health checks and the optional status page. Like the
+	// HTTP listener, it may only bind to loopback interfaces, so it is typically reached using a port-forward.
+	Operational *Endpoint `json:"operational,omitempty"`
+}
+
+// NamedHTTPSEndpoint is an additional HTTPS listener of the Supervisor.
+type NamedHTTPSEndpoint struct {
+	// Name is referenced by the spec.listener of FederationDomains. It must be a valid DNS label.
+	Name     string `json:"name"`
+	Endpoint `json:",inline"`
+	// TLS optionally configures the TLS settings of this listener.
+	TLS ListenerTLSSpec `json:"tls"`
+}
+
+const (
+	TLSVersionOneDotTwo   = "1.2"
+	TLSVersionOneDotThree = "1.3"
+)
+
+// ListenerTLSSpec configures the TLS settings of a single HTTPS listener.
+type ListenerTLSSpec struct {
+	// MinVersion is either "1.2" or "1.3". Defaults to "1.2".
+	MinVersion string `json:"minVersion"`
+	// AllowedCiphers further constrains the ciphers used by this listener for TLS 1.2, in addition to the
+	// ciphers allowed by tls.onedottwo.allowedCiphers. It may not be used when MinVersion is "1.3", because
+	// the ciphers of TLS 1.3 are not configurable.
+	AllowedCiphers []string `json:"allowedCiphers"`
 }
 
 type Endpoint struct {
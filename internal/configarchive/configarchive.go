@@ -0,0 +1,162 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package configarchive exports and imports the Supervisor's configuration resources as a signed archive,
+// so that a configuration can be promoted from one environment to another.
+//
+// The archive contains FederationDomains (including their identity transformations), all types of identity
+// provider resources, OIDCClients, and ClusterAudiences. Secrets are never included in the archive. Resources in the archive
+// continue to refer to Secrets by name, so those Secrets must be created separately in the target environment.
+package configarchive
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
+	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
+)
+
+const (
+	// FormatVersion is the version of the archive format written by this version of Pinniped.
+	// Increment this when making a change to the Archive type which older versions could not read correctly.
+	FormatVersion = 1
+
+	// signatureAlgorithm is the only supported signature algorithm.
+	signatureAlgorithm = "HMAC-SHA256"
+
+	// lastAppliedConfigAnnotation is added by kubectl and is not meaningful in another environment.
+	lastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+)
+
+// Archive is the content of an exported Supervisor configuration.
+type Archive struct {
+	FormatVersion int       `json:"formatVersion"`
+	ExportedAt    time.Time `json:"exportedAt"`
+	// ExportedBy is the version of Pinniped which created the archive. It is informational only.
+	ExportedBy string    `json:"exportedBy,omitempty"`
+	Resources  Resources `json:"resources"`
+}
+
+// Resources holds the Supervisor configuration resources, with their status and server-generated metadata removed.
+type Resources struct {
+	FederationDomains                  []supervisorconfigv1alpha1.FederationDomain     `json:"federationDomains,omitempty"`
+	OIDCClients                        []supervisorconfigv1alpha1.OIDCClient           `json:"oidcClients,omitempty"`
+	ClusterAudiences                   []supervisorconfigv1alpha1.ClusterAudience      `json:"clusterAudiences,omitempty"`
+	OIDCIdentityProviders              []idpv1alpha1.OIDCIdentityProvider              `json:"oidcIdentityProviders,omitempty"`
+	LDAPIdentityProviders              []idpv1alpha1.LDAPIdentityProvider              `json:"ldapIdentityProviders,omitempty"`
+	ActiveDirectoryIdentityProviders   []idpv1alpha1.ActiveDirectoryIdentityProvider   `json:"activeDirectoryIdentityProviders,omitempty"`
+	GitHubIdentityProviders            []idpv1alpha1.GitHubIdentityProvider            `json:"githubIdentityProviders,omitempty"`
+	ClientCertificateIdentityProviders []idpv1alpha1.ClientCertificateIdentityProvider `json:"clientCertificateIdentityProviders,omitempty"`
+	OpenShiftIdentityProviders         []idpv1alpha1.OpenShiftIdentityProvider         `json:"openShiftIdentityProviders,omitempty"`
+	MockIdentityProviders              []idpv1alpha1.MockIdentityProvider              `json:"mockIdentityProviders,omitempty"`
+}
+
+// signedArchive is the serialized form of an Archive. The signature is computed over the exact bytes of
+// the payload, so the payload is kept as an opaque string to avoid any re-encoding before verification.
+type signedArchive struct {
+	FormatVersion      int    `json:"formatVersion"`
+	SignatureAlgorithm string `json:"signatureAlgorithm"`
+	Signature          string `json:"signature"`
+	Payload            string `json:"payload"`
+}
+
+// Sign serializes the archive and signs it using the given key.
+func Sign(archive *Archive, key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, errors.New("signing key must not be empty")
+	}
+
+	payload, err := json.Marshal(archive)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode archive: %w", err)
+	}
+
+	signed, err := yaml.Marshal(&signedArchive{
+		FormatVersion:      archive.FormatVersion,
+		SignatureAlgorithm: signatureAlgorithm,
+		Signature:          hex.EncodeToString(computeSignature(payload, key)),
+		Payload:            string(payload),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not encode signed archive: %w", err)
+	}
+
+	return signed, nil
+}
+
+// Verify checks the signature of a serialized archive using the given key, and returns the archive.
+func Verify(data []byte, key []byte) (*Archive, error) {
+	if len(key) == 0 {
+		return nil, errors.New("signing key must not be empty")
+	}
+
+	var signed signedArchive
+	if err := yaml.UnmarshalStrict(data, &signed); err != nil {
+		return nil, fmt.Errorf("could not decode signed archive: %w", err)
+	}
+
+	if signed.SignatureAlgorithm != signatureAlgorithm {
+		return nil, fmt.Errorf("unsupported signature algorithm %q", signed.SignatureAlgorithm)
+	}
+
+	signature, err := hex.DecodeString(signed.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode signature: %w", err)
+	}
+
+	if !hmac.Equal(signature, computeSignature([]byte(signed.Payload), key)) {
+		return nil, errors.New("archive signature is invalid: the archive was modified or was signed with a different key")
+	}
+
+	var archive Archive
+	if err := json.Unmarshal([]byte(signed.Payload), &archive); err != nil {
+		return nil, fmt.Errorf("could not decode archive: %w", err)
+	}
+
+	if archive.FormatVersion != signed.FormatVersion {
+		return nil, fmt.Errorf("archive format version %d does not match signed format version %d",
+			archive.FormatVersion, signed.FormatVersion)
+	}
+
+	if archive.FormatVersion != FormatVersion {
+		return nil, fmt.Errorf("unsupported archive format version %d (this version of Pinniped supports version %d)",
+			archive.FormatVersion, FormatVersion)
+	}
+
+	return &archive, nil
+}
+
+func computeSignature(payload []byte, key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// portableObjectMeta keeps only the metadata which is meaningful in another environment.
+func portableObjectMeta(meta metav1.ObjectMeta) metav1.ObjectMeta {
+	annotations := make(map[string]string, len(meta.Annotations))
+	for k, v := range meta.Annotations {
+		if k == lastAppliedConfigAnnotation {
+			continue
+		}
+		annotations[k] = v
+	}
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+
+	return metav1.ObjectMeta{
+		Name:        meta.Name,
+		Labels:      meta.Labels,
+		Annotations: annotations,
+	}
+}
@@ -0,0 +1,414 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package configarchive
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	coretesting "k8s.io/client-go/testing"
+
+	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
+	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
+	supervisorfake "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/fake"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	archive := &Archive{
+		FormatVersion: FormatVersion,
+		ExportedAt:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		ExportedBy:    "v1.2.3",
+		Resources: Resources{
+			OIDCIdentityProviders: []idpv1alpha1.OIDCIdentityProvider{
+				{ObjectMeta: metav1.ObjectMeta{Name: "some-idp"}, Spec: idpv1alpha1.OIDCIdentityProviderSpec{Issuer: "https://issuer.example.com"}},
+			},
+		},
+	}
+
+	signed, err := Sign(archive, []byte("some-key"))
+	require.NoError(t, err)
+
+	verified, err := Verify(signed, []byte("some-key"))
+	require.NoError(t, err)
+	require.Equal(t, archive, verified)
+
+	_, err = Verify(signed, []byte("some-other-key"))
+	require.EqualError(t, err, "archive signature is invalid: the archive was modified or was signed with a different key")
+
+	tampered := strings.Replace(string(signed), "issuer.example.com", "evil.example.com", 1)
+	require.NotEqual(t, string(signed), tampered)
+	_, err = Verify([]byte(tampered), []byte("some-key"))
+	require.EqualError(t, err, "archive signature is invalid: the archive was modified or was signed with a different key")
+
+	_, err = Sign(archive, nil)
+	require.EqualError(t, err, "signing key must not be empty")
+
+	_, err = Verify(signed, nil)
+	require.EqualError(t, err, "signing key must not be empty")
+
+	_, err = Verify([]byte(strings.Replace(string(signed), "HMAC-SHA256", "MD5", 1)), []byte("some-key"))
+	require.EqualError(t, err, `unsupported signature algorithm "MD5"`)
+
+	futureArchive := *archive
+	futureArchive.FormatVersion = FormatVersion + 1
+	signedFuture, err := Sign(&futureArchive, []byte("some-key"))
+	require.NoError(t, err)
+	_, err = Verify(signedFuture, []byte("some-key"))
+	require.EqualError(t, err, "unsupported archive format version 2 (this version of Pinniped supports version 1)")
+}
+
+func TestExportAndImport(t *testing.T) {
+	const namespace = "some-namespace"
+
+	sourceObjects := []runtime.Object{
+		&supervisorconfigv1alpha1.FederationDomain{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "fd",
+				Namespace:       namespace,
+				UID:             "some-uid",
+				ResourceVersion: "42",
+				Generation:      3,
+				Labels:          map[string]string{"some-label": "some-value"},
+				Annotations: map[string]string{
+					"some-annotation":           "some-value",
+					lastAppliedConfigAnnotation: "{}",
+				},
+			},
+			Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+				Issuer: "https://issuer.example.com",
+				IdentityProviders: []supervisorconfigv1alpha1.FederationDomainIdentityProvider{
+					{DisplayName: "ldap", ObjectRef: corev1TypedRef("LDAPIdentityProvider", "ldap-idp")},
+				},
+			},
+			Status: supervisorconfigv1alpha1.FederationDomainStatus{Phase: supervisorconfigv1alpha1.FederationDomainPhaseReady},
+		},
+		&idpv1alpha1.LDAPIdentityProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "ldap-idp", Namespace: namespace},
+			Spec: idpv1alpha1.LDAPIdentityProviderSpec{
+				Host: "ldap.example.com",
+				Bind: idpv1alpha1.LDAPIdentityProviderBind{SecretName: "ldap-bind-secret"},
+			},
+			Status: idpv1alpha1.LDAPIdentityProviderStatus{Phase: idpv1alpha1.LDAPPhaseReady},
+		},
+		&idpv1alpha1.LDAPIdentityProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "ldap-idp-in-other-namespace", Namespace: "other-namespace"},
+		},
+		&idpv1alpha1.MockIdentityProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "mock-idp", Namespace: namespace},
+			Spec: idpv1alpha1.MockIdentityProviderSpec{
+				Users: idpv1alpha1.MockIdentityProviderUsersSpec{SecretName: "mock-users-secret"},
+			},
+			Status: idpv1alpha1.MockIdentityProviderStatus{Phase: idpv1alpha1.MockPhaseReady},
+		},
+		&supervisorconfigv1alpha1.ClusterAudience{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: namespace, UID: "some-other-uid"},
+			Spec: supervisorconfigv1alpha1.ClusterAudienceSpec{
+				Audience: "some-cluster-audience",
+				Server:   "https://cluster.example.com",
+			},
+		},
+	}
+
+	sourceClient := supervisorfake.NewSimpleClientset(sourceObjects...)
+	exportedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	archive, err := Export(context.Background(), sourceClient, namespace, exportedAt, "v1.2.3")
+	require.NoError(t, err)
+
+	require.Equal(t, &Archive{
+		FormatVersion: FormatVersion,
+		ExportedAt:    exportedAt,
+		ExportedBy:    "v1.2.3",
+		Resources: Resources{
+			FederationDomains: []supervisorconfigv1alpha1.FederationDomain{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "fd",
+					Labels:      map[string]string{"some-label": "some-value"},
+					Annotations: map[string]string{"some-annotation": "some-value"},
+				},
+				Spec: sourceObjects[0].(*supervisorconfigv1alpha1.FederationDomain).Spec,
+			}},
+			OIDCClients: []supervisorconfigv1alpha1.OIDCClient{},
+			ClusterAudiences: []supervisorconfigv1alpha1.ClusterAudience{{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Spec:       sourceObjects[4].(*supervisorconfigv1alpha1.ClusterAudience).Spec,
+			}},
+			OIDCIdentityProviders: []idpv1alpha1.OIDCIdentityProvider{},
+			LDAPIdentityProviders: []idpv1alpha1.LDAPIdentityProvider{{
+				ObjectMeta: metav1.ObjectMeta{Name: "ldap-idp"},
+				Spec:       sourceObjects[1].(*idpv1alpha1.LDAPIdentityProvider).Spec,
+			}},
+			ActiveDirectoryIdentityProviders:   []idpv1alpha1.ActiveDirectoryIdentityProvider{},
+			GitHubIdentityProviders:            []idpv1alpha1.GitHubIdentityProvider{},
+			ClientCertificateIdentityProviders: []idpv1alpha1.ClientCertificateIdentityProvider{},
+			OpenShiftIdentityProviders:         []idpv1alpha1.OpenShiftIdentityProvider{},
+			MockIdentityProviders: []idpv1alpha1.MockIdentityProvider{{
+				ObjectMeta: metav1.ObjectMeta{Name: "mock-idp"},
+				Spec:       sourceObjects[3].(*idpv1alpha1.MockIdentityProvider).Spec,
+			}},
+		},
+	}, archive)
+
+	t.Run("import into an empty namespace creates everything", func(t *testing.T) {
+		targetClient := supervisorfake.NewSimpleClientset()
+		simulateDryRunForFirstCreate(targetClient)
+
+		changes, err := Import(context.Background(), targetClient, "target-namespace", archive, false)
+		require.NoError(t, err)
+		require.Equal(t, []Change{
+			{Kind: "LDAPIdentityProvider", Name: "ldap-idp", Action: ActionCreate},
+			{Kind: "MockIdentityProvider", Name: "mock-idp", Action: ActionCreate},
+			{Kind: "FederationDomain", Name: "fd", Action: ActionCreate},
+			{Kind: "ClusterAudience", Name: "cluster", Action: ActionCreate},
+		}, changes)
+
+		// Each create is performed twice: first as a server-side dry run, and then for real.
+		verbs := []string{}
+		for _, action := range targetClient.Actions() {
+			verbs = append(verbs, action.GetVerb())
+		}
+		require.Equal(t, []string{"get", "get", "get", "get", "create", "create", "create", "create", "create", "create", "create", "create"}, verbs)
+
+		fd, err := targetClient.ConfigV1alpha1().FederationDomains("target-namespace").Get(context.Background(), "fd", metav1.GetOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "https://issuer.example.com", fd.Spec.Issuer)
+
+		clusterAudience, err := targetClient.ConfigV1alpha1().ClusterAudiences("target-namespace").Get(context.Background(), "cluster", metav1.GetOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "some-cluster-audience", clusterAudience.Spec.Audience)
+	})
+
+	t.Run("dry run import reports updates with a diff and does not change anything", func(t *testing.T) {
+		targetClient := supervisorfake.NewSimpleClientset(
+			&idpv1alpha1.LDAPIdentityProvider{
+				ObjectMeta: metav1.ObjectMeta{Name: "ldap-idp", Namespace: "target-namespace"},
+				Spec:       sourceObjects[1].(*idpv1alpha1.LDAPIdentityProvider).Spec,
+			},
+			&idpv1alpha1.MockIdentityProvider{
+				ObjectMeta: metav1.ObjectMeta{Name: "mock-idp", Namespace: "target-namespace"},
+				Spec:       sourceObjects[3].(*idpv1alpha1.MockIdentityProvider).Spec,
+			},
+			&supervisorconfigv1alpha1.FederationDomain{
+				ObjectMeta: metav1.ObjectMeta{Name: "fd", Namespace: "target-namespace"},
+				Spec:       supervisorconfigv1alpha1.FederationDomainSpec{Issuer: "https://old-issuer.example.com"},
+			},
+			&supervisorconfigv1alpha1.ClusterAudience{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "target-namespace"},
+				Spec:       sourceObjects[4].(*supervisorconfigv1alpha1.ClusterAudience).Spec,
+			},
+		)
+		targetClient.ClearActions()
+		targetClient.PrependReactor("update", "*", func(action coretesting.Action) (bool, runtime.Object, error) {
+			// Simulate a server-side dry run, since the fake clientset does not support dry runs.
+			return true, action.(coretesting.UpdateAction).GetObject(), nil
+		})
+
+		changes, err := Import(context.Background(), targetClient, "target-namespace", archive, true)
+		require.NoError(t, err)
+		require.Len(t, changes, 4)
+		require.Equal(t, Change{Kind: "LDAPIdentityProvider", Name: "ldap-idp", Action: ActionUnchanged}, changes[0])
+		require.Equal(t, Change{Kind: "MockIdentityProvider", Name: "mock-idp", Action: ActionUnchanged}, changes[1])
+		require.Equal(t, "FederationDomain", changes[2].Kind)
+		require.Equal(t, ActionUpdate, changes[2].Action)
+		require.Contains(t, changes[2].Diff, `"https://old-issuer.example.com"`)
+		require.Contains(t, changes[2].Diff, `"https://issuer.example.com"`)
+		require.Equal(t, Change{Kind: "ClusterAudience", Name: "cluster", Action: ActionUnchanged}, changes[3])
+
+		// Only gets and the dry run update were performed.
+		verbs := []string{}
+		for _, action := range targetClient.Actions() {
+			verbs = append(verbs, action.GetVerb())
+		}
+		require.Equal(t, []string{"get", "get", "get", "get", "update"}, verbs)
+
+		fd, err := targetClient.ConfigV1alpha1().FederationDomains("target-namespace").Get(context.Background(), "fd", metav1.GetOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "https://old-issuer.example.com", fd.Spec.Issuer)
+	})
+
+	t.Run("server-side validation failures prevent any changes", func(t *testing.T) {
+		targetClient := supervisorfake.NewSimpleClientset()
+		targetClient.PrependReactor("create", "federationdomains", func(_ coretesting.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.New("spec.issuer: Invalid value")
+		})
+
+		changes, err := Import(context.Background(), targetClient, "target-namespace", archive, false)
+		require.EqualError(t, err, `server-side validation failed for FederationDomain "fd": spec.issuer: Invalid value`)
+		require.Nil(t, changes)
+	})
+}
+
+func corev1TypedRef(kind, name string) corev1.TypedLocalObjectReference {
+	apiGroup := "idp.supervisor.pinniped.dev"
+	return corev1.TypedLocalObjectReference{APIGroup: &apiGroup, Kind: kind, Name: name}
+}
+
+// simulateDryRunForFirstCreate makes the first create of each object behave like a server-side dry run,
+// because the fake clientset does not support dry runs.
+func simulateDryRunForFirstCreate(client *supervisorfake.Clientset) {
+	seen := map[string]bool{}
+	client.PrependReactor("create", "*", func(action coretesting.Action) (bool, runtime.Object, error) {
+		obj := action.(coretesting.CreateAction).GetObject()
+		key := action.GetResource().Resource + "/" + obj.(metav1.Object).GetName()
+		if seen[key] {
+			return false, nil, nil
+		}
+		seen[key] = true
+		return true, obj, nil
+	})
+}
+
+func TestRoundTripOfEveryKind(t *testing.T) {
+	const namespace = "some-namespace"
+
+	portableMeta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Labels: map[string]string{"some-label": name}}
+	}
+	sourceMeta := func(name string) metav1.ObjectMeta {
+		meta := portableMeta(name)
+		meta.Namespace = namespace
+		meta.UID = "uid-of-" + types.UID(name)
+		meta.ResourceVersion = "42"
+		return meta
+	}
+
+	// Each kind which is planned by Import, with a spec which is unique to it.
+	want := Resources{
+		FederationDomains: []supervisorconfigv1alpha1.FederationDomain{{
+			ObjectMeta: portableMeta("fd"),
+			Spec:       supervisorconfigv1alpha1.FederationDomainSpec{Issuer: "https://issuer.example.com"},
+		}},
+		OIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
+			ObjectMeta: portableMeta("client.oauth.pinniped.dev-some-client"),
+			Spec: supervisorconfigv1alpha1.OIDCClientSpec{
+				AllowedRedirectURIs: []supervisorconfigv1alpha1.RedirectURI{"https://app.example.com/callback"},
+				AllowedGrantTypes:   []supervisorconfigv1alpha1.GrantType{"authorization_code"},
+				AllowedScopes:       []supervisorconfigv1alpha1.Scope{"openid"},
+			},
+		}},
+		ClusterAudiences: []supervisorconfigv1alpha1.ClusterAudience{{
+			ObjectMeta: portableMeta("cluster"),
+			Spec:       supervisorconfigv1alpha1.ClusterAudienceSpec{Audience: "some-cluster-audience", Server: "https://cluster.example.com"},
+		}},
+		OIDCIdentityProviders: []idpv1alpha1.OIDCIdentityProvider{{
+			ObjectMeta: portableMeta("oidc-idp"),
+			Spec:       idpv1alpha1.OIDCIdentityProviderSpec{Issuer: "https://oidc.example.com"},
+		}},
+		LDAPIdentityProviders: []idpv1alpha1.LDAPIdentityProvider{{
+			ObjectMeta: portableMeta("ldap-idp"),
+			Spec:       idpv1alpha1.LDAPIdentityProviderSpec{Host: "ldap.example.com"},
+		}},
+		ActiveDirectoryIdentityProviders: []idpv1alpha1.ActiveDirectoryIdentityProvider{{
+			ObjectMeta: portableMeta("ad-idp"),
+			Spec:       idpv1alpha1.ActiveDirectoryIdentityProviderSpec{Host: "ad.example.com"},
+		}},
+		GitHubIdentityProviders: []idpv1alpha1.GitHubIdentityProvider{{
+			ObjectMeta: portableMeta("github-idp"),
+			Spec:       idpv1alpha1.GitHubIdentityProviderSpec{Client: idpv1alpha1.GitHubClientSpec{SecretName: "github-client-secret"}},
+		}},
+		ClientCertificateIdentityProviders: []idpv1alpha1.ClientCertificateIdentityProvider{{
+			ObjectMeta: portableMeta("client-cert-idp"),
+			Spec:       idpv1alpha1.ClientCertificateIdentityProviderSpec{CertificateAuthorityData: "some-ca-bundle"},
+		}},
+		OpenShiftIdentityProviders: []idpv1alpha1.OpenShiftIdentityProvider{{
+			ObjectMeta: portableMeta("openshift-idp"),
+			Spec:       idpv1alpha1.OpenShiftIdentityProviderSpec{Client: idpv1alpha1.OpenShiftClientSpec{SecretName: "openshift-client-secret"}},
+		}},
+		MockIdentityProviders: []idpv1alpha1.MockIdentityProvider{{
+			ObjectMeta: portableMeta("mock-idp"),
+			Spec:       idpv1alpha1.MockIdentityProviderSpec{Users: idpv1alpha1.MockIdentityProviderUsersSpec{SecretName: "mock-users-secret"}},
+		}},
+	}
+
+	sourceObjects := []runtime.Object{}
+	for _, r := range want.FederationDomains {
+		r.ObjectMeta = sourceMeta(r.Name)
+		sourceObjects = append(sourceObjects, &r)
+	}
+	for _, r := range want.OIDCClients {
+		r.ObjectMeta = sourceMeta(r.Name)
+		sourceObjects = append(sourceObjects, &r)
+	}
+	for _, r := range want.ClusterAudiences {
+		r.ObjectMeta = sourceMeta(r.Name)
+		sourceObjects = append(sourceObjects, &r)
+	}
+	for _, r := range want.OIDCIdentityProviders {
+		r.ObjectMeta = sourceMeta(r.Name)
+		sourceObjects = append(sourceObjects, &r)
+	}
+	for _, r := range want.LDAPIdentityProviders {
+		r.ObjectMeta = sourceMeta(r.Name)
+		sourceObjects = append(sourceObjects, &r)
+	}
+	for _, r := range want.ActiveDirectoryIdentityProviders {
+		r.ObjectMeta = sourceMeta(r.Name)
+		sourceObjects = append(sourceObjects, &r)
+	}
+	for _, r := range want.GitHubIdentityProviders {
+		r.ObjectMeta = sourceMeta(r.Name)
+		sourceObjects = append(sourceObjects, &r)
+	}
+	for _, r := range want.ClientCertificateIdentityProviders {
+		r.ObjectMeta = sourceMeta(r.Name)
+		sourceObjects = append(sourceObjects, &r)
+	}
+	for _, r := range want.OpenShiftIdentityProviders {
+		r.ObjectMeta = sourceMeta(r.Name)
+		sourceObjects = append(sourceObjects, &r)
+	}
+	for _, r := range want.MockIdentityProviders {
+		r.ObjectMeta = sourceMeta(r.Name)
+		sourceObjects = append(sourceObjects, &r)
+	}
+
+	exportedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	archive, err := Export(context.Background(), supervisorfake.NewSimpleClientset(sourceObjects...), namespace, exportedAt, "v1.2.3")
+	require.NoError(t, err)
+	require.Equal(t, want, archive.Resources)
+
+	// The archive survives signing and verification unchanged.
+	signed, err := Sign(archive, []byte("some-key"))
+	require.NoError(t, err)
+	verified, err := Verify(signed, []byte("some-key"))
+	require.NoError(t, err)
+	require.Equal(t, archive, verified)
+
+	targetClient := supervisorfake.NewSimpleClientset()
+	simulateDryRunForFirstCreate(targetClient)
+	changes, err := Import(context.Background(), targetClient, "target-namespace", verified, false)
+	require.NoError(t, err)
+	require.Equal(t, []Change{
+		{Kind: "OIDCIdentityProvider", Name: "oidc-idp", Action: ActionCreate},
+		{Kind: "LDAPIdentityProvider", Name: "ldap-idp", Action: ActionCreate},
+		{Kind: "ActiveDirectoryIdentityProvider", Name: "ad-idp", Action: ActionCreate},
+		{Kind: "GitHubIdentityProvider", Name: "github-idp", Action: ActionCreate},
+		{Kind: "ClientCertificateIdentityProvider", Name: "client-cert-idp", Action: ActionCreate},
+		{Kind: "OpenShiftIdentityProvider", Name: "openshift-idp", Action: ActionCreate},
+		{Kind: "MockIdentityProvider", Name: "mock-idp", Action: ActionCreate},
+		{Kind: "FederationDomain", Name: "fd", Action: ActionCreate},
+		{Kind: "OIDCClient", Name: "client.oauth.pinniped.dev-some-client", Action: ActionCreate},
+		{Kind: "ClusterAudience", Name: "cluster", Action: ActionCreate},
+	}, changes)
+
+	// Exporting the imported resources results in the same resources.
+	reexported, err := Export(context.Background(), targetClient, "target-namespace", exportedAt, "v1.2.3")
+	require.NoError(t, err)
+	require.Equal(t, want, reexported.Resources)
+
+	// Importing the same archive again changes nothing.
+	changes, err = Import(context.Background(), targetClient, "target-namespace", verified, false)
+	require.NoError(t, err)
+	require.Len(t, changes, 10)
+	for _, change := range changes {
+		require.Equal(t, ActionUnchanged, change.Action, "%s %s", change.Kind, change.Name)
+	}
+}
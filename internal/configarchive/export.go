@@ -0,0 +1,136 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package configarchive
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
+	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
+	supervisorclientset "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned"
+)
+
+// Export reads all Supervisor configuration resources from the given namespace and returns them as an Archive.
+func Export(
+	ctx context.Context,
+	client supervisorclientset.Interface,
+	namespace string,
+	exportedAt time.Time,
+	exportedBy string,
+) (*Archive, error) {
+	archive := &Archive{
+		FormatVersion: FormatVersion,
+		ExportedAt:    exportedAt.UTC(),
+		ExportedBy:    exportedBy,
+	}
+
+	configClient := client.ConfigV1alpha1()
+	idpClient := client.IDPV1alpha1()
+	listOptions := metav1.ListOptions{}
+
+	federationDomains, err := configClient.FederationDomains(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("could not list FederationDomains: %w", err)
+	}
+	archive.Resources.FederationDomains = exportItems(federationDomains.Items, func(r *supervisorconfigv1alpha1.FederationDomain) {
+		r.TypeMeta, r.ObjectMeta, r.Status = metav1.TypeMeta{}, portableObjectMeta(r.ObjectMeta), supervisorconfigv1alpha1.FederationDomainStatus{}
+	})
+
+	oidcClients, err := configClient.OIDCClients(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("could not list OIDCClients: %w", err)
+	}
+	archive.Resources.OIDCClients = exportItems(oidcClients.Items, func(r *supervisorconfigv1alpha1.OIDCClient) {
+		r.TypeMeta, r.ObjectMeta, r.Status = metav1.TypeMeta{}, portableObjectMeta(r.ObjectMeta), supervisorconfigv1alpha1.OIDCClientStatus{}
+	})
+
+	clusterAudiences, err := configClient.ClusterAudiences(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("could not list ClusterAudiences: %w", err)
+	}
+	archive.Resources.ClusterAudiences = exportItems(clusterAudiences.Items, func(r *supervisorconfigv1alpha1.ClusterAudience) {
+		r.TypeMeta, r.ObjectMeta = metav1.TypeMeta{}, portableObjectMeta(r.ObjectMeta)
+	})
+
+	oidcIDPs, err := idpClient.OIDCIdentityProviders(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("could not list OIDCIdentityProviders: %w", err)
+	}
+	archive.Resources.OIDCIdentityProviders = exportItems(oidcIDPs.Items, func(r *idpv1alpha1.OIDCIdentityProvider) {
+		r.TypeMeta, r.ObjectMeta, r.Status = metav1.TypeMeta{}, portableObjectMeta(r.ObjectMeta), idpv1alpha1.OIDCIdentityProviderStatus{}
+	})
+
+	ldapIDPs, err := idpClient.LDAPIdentityProviders(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("could not list LDAPIdentityProviders: %w", err)
+	}
+	archive.Resources.LDAPIdentityProviders = exportItems(ldapIDPs.Items, func(r *idpv1alpha1.LDAPIdentityProvider) {
+		r.TypeMeta, r.ObjectMeta, r.Status = metav1.TypeMeta{}, portableObjectMeta(r.ObjectMeta), idpv1alpha1.LDAPIdentityProviderStatus{}
+	})
+
+	adIDPs, err := idpClient.ActiveDirectoryIdentityProviders(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("could not list ActiveDirectoryIdentityProviders: %w", err)
+	}
+	archive.Resources.ActiveDirectoryIdentityProviders = exportItems(adIDPs.Items, func(r *idpv1alpha1.ActiveDirectoryIdentityProvider) {
+		r.TypeMeta, r.ObjectMeta, r.Status = metav1.TypeMeta{}, portableObjectMeta(r.ObjectMeta), idpv1alpha1.ActiveDirectoryIdentityProviderStatus{}
+	})
+
+	githubIDPs, err := idpClient.GitHubIdentityProviders(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("could not list GitHubIdentityProviders: %w", err)
+	}
+	archive.Resources.GitHubIdentityProviders = exportItems(githubIDPs.Items, func(r *idpv1alpha1.GitHubIdentityProvider) {
+		r.TypeMeta, r.ObjectMeta, r.Status = metav1.TypeMeta{}, portableObjectMeta(r.ObjectMeta), idpv1alpha1.GitHubIdentityProviderStatus{}
+	})
+
+	clientCertIDPs, err := idpClient.ClientCertificateIdentityProviders(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("could not list ClientCertificateIdentityProviders: %w", err)
+	}
+	archive.Resources.ClientCertificateIdentityProviders = exportItems(clientCertIDPs.Items, func(r *idpv1alpha1.ClientCertificateIdentityProvider) {
+		r.TypeMeta, r.ObjectMeta, r.Status = metav1.TypeMeta{}, portableObjectMeta(r.ObjectMeta), idpv1alpha1.ClientCertificateIdentityProviderStatus{}
+	})
+
+	openShiftIDPs, err := idpClient.OpenShiftIdentityProviders(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("could not list OpenShiftIdentityProviders: %w", err)
+	}
+	archive.Resources.OpenShiftIdentityProviders = exportItems(openShiftIDPs.Items, func(r *idpv1alpha1.OpenShiftIdentityProvider) {
+		r.TypeMeta, r.ObjectMeta, r.Status = metav1.TypeMeta{}, portableObjectMeta(r.ObjectMeta), idpv1alpha1.OpenShiftIdentityProviderStatus{}
+	})
+
+	mockIDPs, err := idpClient.MockIdentityProviders(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("could not list MockIdentityProviders: %w", err)
+	}
+	archive.Resources.MockIdentityProviders = exportItems(mockIDPs.Items, func(r *idpv1alpha1.MockIdentityProvider) {
+		r.TypeMeta, r.ObjectMeta, r.Status = metav1.TypeMeta{}, portableObjectMeta(r.ObjectMeta), idpv1alpha1.MockIdentityProviderStatus{}
+	})
+
+	return archive, nil
+}
+
+// exportItems returns sanitized copies of the items, sorted by name so that the archive content is stable.
+func exportItems[T any, PT interface {
+	*T
+	metav1.Object
+}](items []T, sanitize func(PT)) []T {
+	exported := make([]T, 0, len(items))
+	for i := range items {
+		item := items[i]
+		sanitize(PT(&item))
+		exported = append(exported, item)
+	}
+	slices.SortFunc(exported, func(a, b T) int {
+		return strings.Compare(PT(&a).GetName(), PT(&b).GetName())
+	})
+	return exported
+}
@@ -0,0 +1,225 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package configarchive
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
+	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
+	supervisorclientset "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned"
+)
+
+// Action describes what an import will do to a single resource.
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionUpdate    Action = "update"
+	ActionUnchanged Action = "unchanged"
+)
+
+// Change describes the effect of an import on a single resource.
+type Change struct {
+	Kind   string
+	Name   string
+	Action Action
+	// Diff is a human-readable diff of the labels, annotations, and spec of the resource.
+	// It is only set for updates.
+	Diff string
+}
+
+// plannedChange is a Change along with the API call which will make that change.
+type plannedChange struct {
+	Change
+	apply func(ctx context.Context, dryRun bool) error
+}
+
+// Import creates or updates the resources from the archive in the given namespace. Resources which exist in the
+// namespace but are not in the archive are left untouched.
+//
+// All changes are validated by the server using a server-side dry run before any change is made, so an archive
+// which contains any invalid resource will not be partially applied. When dryRun is true, the changes are only
+// validated and returned, and nothing is changed.
+func Import(
+	ctx context.Context,
+	client supervisorclientset.Interface,
+	namespace string,
+	archive *Archive,
+	dryRun bool,
+) ([]Change, error) {
+	configClient := client.ConfigV1alpha1()
+	idpClient := client.IDPV1alpha1()
+	r := archive.Resources
+
+	var planned []plannedChange
+	var errs []error
+	plan := func(changes []plannedChange, err error) {
+		planned = append(planned, changes...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Create the IDPs before the FederationDomains which refer to them.
+	plan(planKind(ctx, "OIDCIdentityProvider", namespace, r.OIDCIdentityProviders, idpClient.OIDCIdentityProviders(namespace),
+		func(o *idpv1alpha1.OIDCIdentityProvider) any { return o.Spec },
+		func(to, from *idpv1alpha1.OIDCIdentityProvider) { to.Spec = from.Spec }))
+	plan(planKind(ctx, "LDAPIdentityProvider", namespace, r.LDAPIdentityProviders, idpClient.LDAPIdentityProviders(namespace),
+		func(o *idpv1alpha1.LDAPIdentityProvider) any { return o.Spec },
+		func(to, from *idpv1alpha1.LDAPIdentityProvider) { to.Spec = from.Spec }))
+	plan(planKind(ctx, "ActiveDirectoryIdentityProvider", namespace, r.ActiveDirectoryIdentityProviders, idpClient.ActiveDirectoryIdentityProviders(namespace),
+		func(o *idpv1alpha1.ActiveDirectoryIdentityProvider) any { return o.Spec },
+		func(to, from *idpv1alpha1.ActiveDirectoryIdentityProvider) { to.Spec = from.Spec }))
+	plan(planKind(ctx, "GitHubIdentityProvider", namespace, r.GitHubIdentityProviders, idpClient.GitHubIdentityProviders(namespace),
+		func(o *idpv1alpha1.GitHubIdentityProvider) any { return o.Spec },
+		func(to, from *idpv1alpha1.GitHubIdentityProvider) { to.Spec = from.Spec }))
+	plan(planKind(ctx, "ClientCertificateIdentityProvider", namespace, r.ClientCertificateIdentityProviders, idpClient.ClientCertificateIdentityProviders(namespace),
+		func(o *idpv1alpha1.ClientCertificateIdentityProvider) any { return o.Spec },
+		func(to, from *idpv1alpha1.ClientCertificateIdentityProvider) { to.Spec = from.Spec }))
+	plan(planKind(ctx, "OpenShiftIdentityProvider", namespace, r.OpenShiftIdentityProviders, idpClient.OpenShiftIdentityProviders(namespace),
+		func(o *idpv1alpha1.OpenShiftIdentityProvider) any { return o.Spec },
+		func(to, from *idpv1alpha1.OpenShiftIdentityProvider) { to.Spec = from.Spec }))
+	plan(planKind(ctx, "MockIdentityProvider", namespace, r.MockIdentityProviders, idpClient.MockIdentityProviders(namespace),
+		func(o *idpv1alpha1.MockIdentityProvider) any { return o.Spec },
+		func(to, from *idpv1alpha1.MockIdentityProvider) { to.Spec = from.Spec }))
+	plan(planKind(ctx, "FederationDomain", namespace, r.FederationDomains, configClient.FederationDomains(namespace),
+		func(o *supervisorconfigv1alpha1.FederationDomain) any { return o.Spec },
+		func(to, from *supervisorconfigv1alpha1.FederationDomain) { to.Spec = from.Spec }))
+	plan(planKind(ctx, "OIDCClient", namespace, r.OIDCClients, configClient.OIDCClients(namespace),
+		func(o *supervisorconfigv1alpha1.OIDCClient) any { return o.Spec },
+		func(to, from *supervisorconfigv1alpha1.OIDCClient) { to.Spec = from.Spec }))
+	plan(planKind(ctx, "ClusterAudience", namespace, r.ClusterAudiences, configClient.ClusterAudiences(namespace),
+		func(o *supervisorconfigv1alpha1.ClusterAudience) any { return o.Spec },
+		func(to, from *supervisorconfigv1alpha1.ClusterAudience) { to.Spec = from.Spec }))
+
+	if len(errs) > 0 {
+		return nil, utilerrors.NewAggregate(errs)
+	}
+
+	// Ask the server to validate every change before making any change.
+	for _, change := range planned {
+		if err := change.apply(ctx, true); err != nil {
+			errs = append(errs, fmt.Errorf("server-side validation failed for %s %q: %w", change.Kind, change.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, utilerrors.NewAggregate(errs)
+	}
+
+	changes := make([]Change, 0, len(planned))
+	for _, change := range planned {
+		changes = append(changes, change.Change)
+	}
+
+	if dryRun {
+		return changes, nil
+	}
+
+	for _, change := range planned {
+		if err := change.apply(ctx, false); err != nil {
+			return nil, fmt.Errorf("could not %s %s %q: %w", change.Action, change.Kind, change.Name, err)
+		}
+	}
+
+	return changes, nil
+}
+
+// resourceClient is the subset of a generated typed client which is used during import.
+type resourceClient[PT any] interface {
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (PT, error)
+	Create(ctx context.Context, obj PT, opts metav1.CreateOptions) (PT, error)
+	Update(ctx context.Context, obj PT, opts metav1.UpdateOptions) (PT, error)
+}
+
+// comparableContent is the part of a resource which is imported.
+type comparableContent struct {
+	Labels      map[string]string
+	Annotations map[string]string
+	Spec        any
+}
+
+func planKind[T any, PT interface {
+	*T
+	metav1.Object
+}](
+	ctx context.Context,
+	kind string,
+	namespace string,
+	items []T,
+	client resourceClient[PT],
+	spec func(PT) any,
+	copySpec func(to, from PT),
+) ([]plannedChange, error) {
+	content := func(obj PT) comparableContent {
+		return comparableContent{
+			Labels:      obj.GetLabels(),
+			Annotations: portableObjectMeta(metav1.ObjectMeta{Annotations: obj.GetAnnotations()}).Annotations,
+			Spec:        spec(obj),
+		}
+	}
+
+	planned := make([]plannedChange, 0, len(items))
+	for _, item := range items {
+		desired := PT(&item)
+		desired.SetNamespace(namespace)
+		name := desired.GetName()
+
+		existing, err := client.Get(ctx, name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			planned = append(planned, plannedChange{
+				Change: Change{Kind: kind, Name: name, Action: ActionCreate},
+				apply: func(ctx context.Context, dryRun bool) error {
+					_, err := client.Create(ctx, desired, metav1.CreateOptions{DryRun: dryRunOption(dryRun)})
+					return err
+				},
+			})
+		case err != nil:
+			return nil, fmt.Errorf("could not get %s %q: %w", kind, name, err)
+		case equality.Semantic.DeepEqual(content(existing), content(desired)):
+			planned = append(planned, plannedChange{
+				Change: Change{Kind: kind, Name: name, Action: ActionUnchanged},
+				apply:  func(context.Context, bool) error { return nil },
+			})
+		default:
+			diff := cmp.Diff(content(existing), content(desired))
+			updated := existing
+			updated.SetLabels(desired.GetLabels())
+			annotations := desired.GetAnnotations()
+			if lastApplied, ok := existing.GetAnnotations()[lastAppliedConfigAnnotation]; ok {
+				annotations = make(map[string]string, len(desired.GetAnnotations())+1)
+				for k, v := range desired.GetAnnotations() {
+					annotations[k] = v
+				}
+				annotations[lastAppliedConfigAnnotation] = lastApplied
+			}
+			updated.SetAnnotations(annotations)
+			copySpec(updated, desired)
+			planned = append(planned, plannedChange{
+				Change: Change{Kind: kind, Name: name, Action: ActionUpdate, Diff: diff},
+				apply: func(ctx context.Context, dryRun bool) error {
+					_, err := client.Update(ctx, updated, metav1.UpdateOptions{DryRun: dryRunOption(dryRun)})
+					return err
+				},
+			})
+		}
+	}
+
+	return planned, nil
+}
+
+func dryRunOption(dryRun bool) []string {
+	if dryRun {
+		return []string{metav1.DryRunAll}
+	}
+	return nil
+}
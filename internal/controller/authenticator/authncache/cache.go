@@ -9,12 +9,15 @@ import (
 	"sort"
 	"sync"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apiserver/pkg/authentication/authenticator"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
 	loginapi "go.pinniped.dev/generated/latest/apis/concierge/login"
 	"go.pinniped.dev/internal/constable"
+	"go.pinniped.dev/internal/lastauth"
 	"go.pinniped.dev/internal/plog"
 	"go.pinniped.dev/internal/valuelesscontext"
 )
@@ -25,7 +28,8 @@ const ErrNoSuchAuthenticator = constable.Error("no such authenticator")
 // Cache implements the authenticator.Token interface by multiplexing across a dynamic set of authenticators
 // loaded from authenticator resources.
 type Cache struct {
-	cache sync.Map
+	cache               sync.Map
+	lastSuccessfulAuths *lastauth.Recorder[Key]
 }
 
 type Key struct {
@@ -40,7 +44,7 @@ type Value interface {
 
 // New returns an empty cache.
 func New() *Cache {
-	return &Cache{}
+	return &Cache{lastSuccessfulAuths: lastauth.New[Key](clock.RealClock{})}
 }
 
 // Get an authenticator by key.
@@ -60,6 +64,13 @@ func (c *Cache) Store(key Key, value Value) {
 // Delete an authenticator from the cache.
 func (c *Cache) Delete(key Key) {
 	c.cache.Delete(key)
+	c.lastSuccessfulAuths.Forget(key)
+}
+
+// LastSuccessfulAuth returns the time of the most recent successful authentication by the authenticator when it is
+// later than current, which is the timestamp in the status of the authenticator. Otherwise, it returns current.
+func (c *Cache) LastSuccessfulAuth(key Key, current *metav1.Time) *metav1.Time {
+	return c.lastSuccessfulAuths.Latest(key, current)
 }
 
 // Keys currently stored in the cache.
@@ -118,5 +129,8 @@ func (c *Cache) AuthenticateTokenCredentialRequest(ctx context.Context, req *log
 	if resp != nil {
 		respUser = resp.User
 	}
+	if respUser != nil {
+		c.lastSuccessfulAuths.Record(key)
+	}
 	return respUser, nil
 }
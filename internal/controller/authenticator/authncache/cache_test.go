@@ -118,6 +118,7 @@ func TestAuthenticateTokenCredentialRequest(t *testing.T) {
 		res, err := c.AuthenticateTokenCredentialRequest(context.Background(), validRequest.DeepCopy())
 		require.NoError(t, err)
 		require.Nil(t, res)
+		require.Nil(t, c.LastSuccessfulAuth(validRequestKey, nil))
 	})
 
 	t.Run("authenticator returns nil response without error", func(t *testing.T) {
@@ -179,6 +180,11 @@ func TestAuthenticateTokenCredentialRequest(t *testing.T) {
 		require.Equal(t, "test-uid", res.GetUID())
 		require.Equal(t, []string{"test-group-1", "test-group-2"}, res.GetGroups())
 		require.Equal(t, map[string][]string{"extra-key-1": {"extra-value-1", "extra-value-2"}}, res.GetExtra())
+
+		// The successful authentication is remembered until the authenticator is deleted.
+		require.NotNil(t, c.LastSuccessfulAuth(validRequestKey, nil))
+		c.Delete(validRequestKey)
+		require.Nil(t, c.LastSuccessfulAuth(validRequestKey, nil))
 	})
 }
 
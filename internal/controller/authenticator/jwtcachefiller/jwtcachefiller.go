@@ -29,6 +29,7 @@ import (
 	"k8s.io/utils/clock"
 	"k8s.io/utils/ptr"
 
+	authenticationconditions "go.pinniped.dev/generated/latest/apis/concierge/authentication/conditions"
 	authenticationv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/authentication/v1alpha1"
 	oidcapi "go.pinniped.dev/generated/latest/apis/supervisor/oidc"
 	conciergeclientset "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned"
@@ -36,6 +37,7 @@ import (
 	pinnipedcontroller "go.pinniped.dev/internal/controller"
 	pinnipedauthenticator "go.pinniped.dev/internal/controller/authenticator"
 	"go.pinniped.dev/internal/controller/authenticator/authncache"
+	"go.pinniped.dev/internal/controller/authenticator/tokendenylist"
 	"go.pinniped.dev/internal/controller/conditionsutil"
 	"go.pinniped.dev/internal/controllerlib"
 	"go.pinniped.dev/internal/net/phttp"
@@ -45,28 +47,28 @@ import (
 const (
 	controllerName = "jwtcachefiller-controller"
 
-	typeReady                 = "Ready"
-	typeTLSConfigurationValid = "TLSConfigurationValid"
-	typeIssuerURLValid        = "IssuerURLValid"
-	typeDiscoveryValid        = "DiscoveryURLValid"
-	typeJWKSURLValid          = "JWKSURLValid"
-	typeJWKSFetchValid        = "JWKSFetchValid"
-	typeAuthenticatorValid    = "AuthenticatorValid"
-
-	reasonSuccess                                   = "Success"
-	reasonNotReady                                  = "NotReady"
-	reasonUnableToValidate                          = "UnableToValidate"
-	reasonInvalidIssuerURL                          = "InvalidIssuerURL"
-	reasonInvalidIssuerURLScheme                    = "InvalidIssuerURLScheme"
-	reasonInvalidIssuerURLFragment                  = "InvalidIssuerURLContainsFragment"
-	reasonInvalidIssuerURLQueryParams               = "InvalidIssuerURLContainsQueryParams"
-	reasonInvalidIssuerURLContainsWellKnownEndpoint = "InvalidIssuerURLContainsWellKnownEndpoint"
-	reasonInvalidProviderJWKSURL                    = "InvalidProviderJWKSURL"
-	reasonInvalidProviderJWKSURLScheme              = "InvalidProviderJWKSURLScheme"
-	reasonInvalidTLSConfiguration                   = "InvalidTLSConfiguration"
-	reasonInvalidDiscoveryProbe                     = "InvalidDiscoveryProbe"
-	reasonInvalidAuthenticator                      = "InvalidAuthenticator"
-	reasonInvalidCouldNotFetchJWKS                  = "InvalidCouldNotFetchJWKS"
+	typeReady                 = authenticationconditions.TypeReady
+	typeTLSConfigurationValid = authenticationconditions.TypeTLSConfigurationValid
+	typeIssuerURLValid        = authenticationconditions.TypeIssuerURLValid
+	typeDiscoveryValid        = authenticationconditions.TypeDiscoveryValid
+	typeJWKSURLValid          = authenticationconditions.TypeJWKSURLValid
+	typeJWKSFetchValid        = authenticationconditions.TypeJWKSFetchValid
+	typeAuthenticatorValid    = authenticationconditions.TypeAuthenticatorValid
+
+	reasonSuccess                                   = authenticationconditions.ReasonSuccess
+	reasonNotReady                                  = authenticationconditions.ReasonNotReady
+	reasonUnableToValidate                          = authenticationconditions.ReasonUnableToValidate
+	reasonInvalidIssuerURL                          = authenticationconditions.ReasonInvalidIssuerURL
+	reasonInvalidIssuerURLScheme                    = authenticationconditions.ReasonInvalidIssuerURLScheme
+	reasonInvalidIssuerURLFragment                  = authenticationconditions.ReasonInvalidIssuerURLContainsFragment
+	reasonInvalidIssuerURLQueryParams               = authenticationconditions.ReasonInvalidIssuerURLContainsQueryParams
+	reasonInvalidIssuerURLContainsWellKnownEndpoint = authenticationconditions.ReasonInvalidIssuerURLContainsWellKnownEndpoint
+	reasonInvalidProviderJWKSURL                    = authenticationconditions.ReasonInvalidProviderJWKSURL
+	reasonInvalidProviderJWKSURLScheme              = authenticationconditions.ReasonInvalidProviderJWKSURLScheme
+	reasonInvalidTLSConfiguration                   = authenticationconditions.ReasonInvalidTLSConfiguration
+	reasonInvalidDiscoveryProbe                     = authenticationconditions.ReasonInvalidDiscoveryProbe
+	reasonInvalidAuthenticator                      = authenticationconditions.ReasonInvalidAuthenticator
+	reasonInvalidCouldNotFetchJWKS                  = authenticationconditions.ReasonInvalidCouldNotFetchJWKS
 
 	msgUnableToValidate = "unable to validate; see other conditions for details"
 
@@ -114,8 +116,10 @@ func (c *cachedJWTAuthenticator) Close() {
 var _ tokenAuthenticatorCloser = (*cachedJWTAuthenticator)(nil)
 
 // New instantiates a new controllerlib.Controller which will populate the provided authncache.Cache.
+// The authenticators that it creates reject any token which matches the provided tokendenylist.Denylist.
 func New(
 	cache *authncache.Cache,
+	denylist *tokendenylist.Denylist,
 	client conciergeclientset.Interface,
 	jwtAuthenticators authinformers.JWTAuthenticatorInformer,
 	clock clock.Clock,
@@ -126,6 +130,7 @@ func New(
 			Name: controllerName,
 			Syncer: &jwtCacheFillerController{
 				cache:             cache,
+				denylist:          denylist,
 				client:            client,
 				jwtAuthenticators: jwtAuthenticators,
 				clock:             clock,
@@ -142,6 +147,7 @@ func New(
 
 type jwtCacheFillerController struct {
 	cache             *authncache.Cache
+	denylist          *tokendenylist.Denylist
 	jwtAuthenticators authinformers.JWTAuthenticatorInformer
 	client            conciergeclientset.Interface
 	clock             clock.Clock
@@ -176,7 +182,7 @@ func (c *jwtCacheFillerController) Sync(ctx controllerlib.Context) error {
 		if jwtAuthenticator != nil {
 			if reflect.DeepEqual(jwtAuthenticator.spec, &obj.Spec) {
 				c.log.WithValues("jwtAuthenticator", klog.KObj(obj), "issuer", obj.Spec.Issuer).Info("actual jwt authenticator and desired jwt authenticator are the same")
-				return nil
+				return c.updateLastSuccessfulAuth(ctx.Context, obj, cacheKey)
 			}
 			jwtAuthenticator.Close()
 		}
@@ -221,7 +227,7 @@ func (c *jwtCacheFillerController) Sync(ctx controllerlib.Context) error {
 		c.log.Info("added new jwt authenticator", "jwtAuthenticator", klog.KObj(obj), "issuer", obj.Spec.Issuer)
 	}
 
-	err = c.updateStatus(ctx.Context, obj, conditions)
+	err = c.updateStatus(ctx.Context, obj, cacheKey, conditions)
 	errs = append(errs, err)
 
 	// Sync loop errors:
@@ -572,7 +578,7 @@ func (c *jwtCacheFillerController) newCachedJWTAuthenticator(client *http.Client
 		Message: msg,
 	})
 	return &cachedJWTAuthenticator{
-		Token:  oidcAuthenticator,
+		Token:  c.denylist.WrapTokenAuthenticator(oidcAuthenticator),
 		spec:   spec,
 		cancel: cancel,
 	}, conditions, nil
@@ -581,9 +587,12 @@ func (c *jwtCacheFillerController) newCachedJWTAuthenticator(client *http.Client
 func (c *jwtCacheFillerController) updateStatus(
 	ctx context.Context,
 	original *authenticationv1alpha1.JWTAuthenticator,
+	cacheKey authncache.Key,
 	conditions []*metav1.Condition,
 ) error {
 	updated := original.DeepCopy()
+	updated.Status.ObservedGeneration = original.Generation
+	updated.Status.LastSuccessfulAuthTimestamp = c.cache.LastSuccessfulAuth(cacheKey, original.Status.LastSuccessfulAuthTimestamp)
 
 	if conditionsutil.HadErrorCondition(conditions) {
 		updated.Status.Phase = authenticationv1alpha1.JWTAuthenticatorPhaseError
@@ -617,3 +626,21 @@ func (c *jwtCacheFillerController) updateStatus(
 	_, err := c.client.AuthenticationV1alpha1().JWTAuthenticators().UpdateStatus(ctx, updated, metav1.UpdateOptions{})
 	return err
 }
+
+// updateLastSuccessfulAuth publishes the time of the most recent successful authentication by an authenticator
+// whose spec did not change, so its conditions do not need to be validated again.
+func (c *jwtCacheFillerController) updateLastSuccessfulAuth(
+	ctx context.Context,
+	original *authenticationv1alpha1.JWTAuthenticator,
+	cacheKey authncache.Key,
+) error {
+	lastSuccessfulAuth := c.cache.LastSuccessfulAuth(cacheKey, original.Status.LastSuccessfulAuthTimestamp)
+	if lastSuccessfulAuth == original.Status.LastSuccessfulAuthTimestamp {
+		return nil
+	}
+
+	updated := original.DeepCopy()
+	updated.Status.LastSuccessfulAuthTimestamp = lastSuccessfulAuth
+	_, err := c.client.AuthenticationV1alpha1().JWTAuthenticators().UpdateStatus(ctx, updated, metav1.UpdateOptions{})
+	return err
+}
@@ -37,6 +37,7 @@ import (
 	conciergefake "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned/fake"
 	conciergeinformers "go.pinniped.dev/generated/latest/client/concierge/informers/externalversions"
 	"go.pinniped.dev/internal/controller/authenticator/authncache"
+	"go.pinniped.dev/internal/controller/authenticator/tokendenylist"
 	"go.pinniped.dev/internal/controllerlib"
 	"go.pinniped.dev/internal/crypto/ptls"
 	"go.pinniped.dev/internal/plog"
@@ -698,7 +699,8 @@ func TestController(t *testing.T) {
 								happyTLSConfigurationValidCAParsed(frozenTimeInThePast, 1234),
 							},
 						),
-						Phase: "Ready",
+						Phase:              "Ready",
+						ObservedGeneration: 1234,
 					},
 				})
 				updateStatusAction.Subresource = "status"
@@ -1680,8 +1682,12 @@ func TestController(t *testing.T) {
 				tt.cache(t, cache, tt.wantClose)
 			}
 
+			denylist := tokendenylist.NewDenylist(frozenClock)
+			denylist.Set([]authenticationv1alpha1.ClusterTokenDenylistEntry{{JTI: "some-denied-jti"}})
+
 			controller := New(
 				cache,
+				denylist,
 				pinnipedAPIClient,
 				pinnipedInformers.Authentication().V1alpha1().JWTAuthenticators(),
 				frozenClock,
@@ -1971,6 +1977,13 @@ func testTableForAuthenticateTokenTests(
 			},
 			wantErr: testutil.WantMatchingErrorString("oidc: parse groups claim \"" + expectedGroupsClaim + "\": json: cannot unmarshal object into Go value of type string"),
 		},
+		{
+			name: "good token which is denied by a ClusterTokenDenylist",
+			jwtClaims: func(claims *jwt.Claims, _ *any, username *string) {
+				claims.ID = "some-denied-jti"
+			},
+			wantErr: testutil.WantExactErrorString("token was rejected by a ClusterTokenDenylist"),
+		},
 		{
 			name: "bad token with wrong issuer",
 			jwtClaims: func(claims *jwt.Claims, _ *any, username *string) {
@@ -0,0 +1,153 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package tokendenylist implements an in-memory denylist of tokens, and a controller which fills it
+// from the ClusterTokenDenylists in the cluster.
+package tokendenylist
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v3/jwt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/utils/clock"
+
+	authenticationv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/authentication/v1alpha1"
+	"go.pinniped.dev/internal/constable"
+)
+
+// ErrTokenDenied is returned by authenticators wrapped by Denylist.WrapTokenAuthenticator when an otherwise
+// valid token matches an entry of the denylist.
+const ErrTokenDenied = constable.Error("token was rejected by a ClusterTokenDenylist")
+
+type subjectEntry struct {
+	issuedAfter  time.Time // zero means unbounded
+	issuedBefore time.Time // zero means unbounded
+	expiresAt    time.Time // zero means never
+}
+
+// Denylist is a thread-safe in-memory set of denied tokens. Its zero value is not usable, use NewDenylist.
+type Denylist struct {
+	clock clock.PassiveClock
+
+	lock     sync.RWMutex
+	jtis     map[string]time.Time // jti -> expiresAt, where zero means never
+	subjects map[string][]subjectEntry
+}
+
+// NewDenylist returns an empty Denylist which uses the provided clock to decide when entries have expired.
+func NewDenylist(clock clock.PassiveClock) *Denylist {
+	return &Denylist{clock: clock}
+}
+
+// Set replaces the contents of the denylist with the provided entries.
+func (d *Denylist) Set(entries []authenticationv1alpha1.ClusterTokenDenylistEntry) {
+	jtis := map[string]time.Time{}
+	subjects := map[string][]subjectEntry{}
+
+	for _, entry := range entries {
+		expiresAt := timeOrZero(entry.ExpiresAt)
+		switch {
+		case entry.JTI != "":
+			previous, seen := jtis[entry.JTI]
+			if !seen || (!previous.IsZero() && (expiresAt.IsZero() || expiresAt.After(previous))) {
+				jtis[entry.JTI] = expiresAt
+			}
+		case entry.Subject != "":
+			subjects[entry.Subject] = append(subjects[entry.Subject], subjectEntry{
+				issuedAfter:  timeOrZero(entry.IssuedAfter),
+				issuedBefore: timeOrZero(entry.IssuedBefore),
+				expiresAt:    expiresAt,
+			})
+		}
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.jtis = jtis
+	d.subjects = subjects
+}
+
+// Empty returns true when there are no entries in the denylist, including expired entries.
+func (d *Denylist) Empty() bool {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	return len(d.jtis) == 0 && len(d.subjects) == 0
+}
+
+// Denied returns true when an unexpired entry of the denylist matches a token with the given claims.
+// A zero issuedAt means that the token did not have an "iat" claim, which only matches subject entries
+// that are not limited to a range of time.
+func (d *Denylist) Denied(jti, subject string, issuedAt time.Time) bool {
+	now := d.clock.Now()
+
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	if jti != "" {
+		if expiresAt, found := d.jtis[jti]; found && (expiresAt.IsZero() || now.Before(expiresAt)) {
+			return true
+		}
+	}
+
+	if subject != "" {
+		for _, entry := range d.subjects[subject] {
+			if !entry.expiresAt.IsZero() && !now.Before(entry.expiresAt) {
+				continue
+			}
+			if issuedAt.IsZero() && (!entry.issuedAfter.IsZero() || !entry.issuedBefore.IsZero()) {
+				continue
+			}
+			if !entry.issuedAfter.IsZero() && issuedAt.Before(entry.issuedAfter) {
+				continue
+			}
+			if !entry.issuedBefore.IsZero() && issuedAt.After(entry.issuedBefore) {
+				continue
+			}
+			return true
+		}
+	}
+
+	return false
+}
+
+// WrapTokenAuthenticator returns an authenticator which rejects the tokens that match the denylist, after they
+// have been successfully authenticated by the delegate. Since the delegate has already verified the token,
+// its claims are read without verifying its signature again.
+func (d *Denylist) WrapTokenAuthenticator(delegate authenticator.Token) authenticator.Token {
+	return authenticator.TokenFunc(func(ctx context.Context, token string) (*authenticator.Response, bool, error) {
+		response, authenticated, err := delegate.AuthenticateToken(ctx, token)
+		if err != nil || !authenticated || d.Empty() {
+			return response, authenticated, err
+		}
+
+		parsed, err := jwt.ParseSigned(token)
+		if err != nil {
+			return nil, false, ErrTokenDenied // should not happen, since the delegate has already parsed the token
+		}
+		var claims jwt.Claims
+		if err := parsed.UnsafeClaimsWithoutVerification(&claims); err != nil {
+			return nil, false, ErrTokenDenied // should not happen, since the delegate has already parsed the token
+		}
+
+		var issuedAt time.Time
+		if claims.IssuedAt != nil {
+			issuedAt = claims.IssuedAt.Time()
+		}
+		if d.Denied(claims.ID, claims.Subject, issuedAt) {
+			return nil, false, ErrTokenDenied
+		}
+
+		return response, authenticated, nil
+	})
+}
+
+func timeOrZero(t *metav1.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.Time
+}
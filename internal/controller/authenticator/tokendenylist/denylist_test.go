@@ -0,0 +1,263 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package tokendenylist
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+
+	authenticationv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/authentication/v1alpha1"
+)
+
+func TestDenied(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	hourAgo := metav1.NewTime(now.Add(-time.Hour))
+	hourFromNow := metav1.NewTime(now.Add(time.Hour))
+
+	tests := []struct {
+		name     string
+		entries  []authenticationv1alpha1.ClusterTokenDenylistEntry
+		jti      string
+		subject  string
+		issuedAt time.Time
+		want     bool
+	}{
+		{
+			name: "empty denylist",
+			jti:  "some-jti",
+			want: false,
+		},
+		{
+			name:    "matching jti",
+			entries: []authenticationv1alpha1.ClusterTokenDenylistEntry{{JTI: "some-jti"}},
+			jti:     "some-jti",
+			want:    true,
+		},
+		{
+			name:    "different jti",
+			entries: []authenticationv1alpha1.ClusterTokenDenylistEntry{{JTI: "some-jti"}},
+			jti:     "other-jti",
+			subject: "some-jti",
+			want:    false,
+		},
+		{
+			name:    "matching jti which has not expired",
+			entries: []authenticationv1alpha1.ClusterTokenDenylistEntry{{JTI: "some-jti", ExpiresAt: &hourFromNow}},
+			jti:     "some-jti",
+			want:    true,
+		},
+		{
+			name:    "matching jti which has expired",
+			entries: []authenticationv1alpha1.ClusterTokenDenylistEntry{{JTI: "some-jti", ExpiresAt: &hourAgo}},
+			jti:     "some-jti",
+			want:    false,
+		},
+		{
+			name: "duplicate jti entries use the latest expiration",
+			entries: []authenticationv1alpha1.ClusterTokenDenylistEntry{
+				{JTI: "some-jti", ExpiresAt: &hourFromNow},
+				{JTI: "some-jti", ExpiresAt: &hourAgo},
+			},
+			jti:  "some-jti",
+			want: true,
+		},
+		{
+			name: "duplicate jti entries where one never expires",
+			entries: []authenticationv1alpha1.ClusterTokenDenylistEntry{
+				{JTI: "some-jti"},
+				{JTI: "some-jti", ExpiresAt: &hourAgo},
+			},
+			jti:  "some-jti",
+			want: true,
+		},
+		{
+			name:     "matching subject without a time range",
+			entries:  []authenticationv1alpha1.ClusterTokenDenylistEntry{{Subject: "some-subject"}},
+			subject:  "some-subject",
+			issuedAt: now,
+			want:     true,
+		},
+		{
+			name:    "matching subject without a time range and token without iat",
+			entries: []authenticationv1alpha1.ClusterTokenDenylistEntry{{Subject: "some-subject"}},
+			subject: "some-subject",
+			want:    true,
+		},
+		{
+			name:     "different subject",
+			entries:  []authenticationv1alpha1.ClusterTokenDenylistEntry{{Subject: "some-subject"}},
+			jti:      "some-subject",
+			subject:  "other-subject",
+			issuedAt: now,
+			want:     false,
+		},
+		{
+			name:     "matching subject which has expired",
+			entries:  []authenticationv1alpha1.ClusterTokenDenylistEntry{{Subject: "some-subject", ExpiresAt: &hourAgo}},
+			subject:  "some-subject",
+			issuedAt: now,
+			want:     false,
+		},
+		{
+			name:     "matching subject issued within the time range",
+			entries:  []authenticationv1alpha1.ClusterTokenDenylistEntry{{Subject: "some-subject", IssuedAfter: &hourAgo, IssuedBefore: &hourFromNow}},
+			subject:  "some-subject",
+			issuedAt: now,
+			want:     true,
+		},
+		{
+			name:     "matching subject issued exactly at the bounds of the time range",
+			entries:  []authenticationv1alpha1.ClusterTokenDenylistEntry{{Subject: "some-subject", IssuedAfter: &hourAgo, IssuedBefore: &hourAgo}},
+			subject:  "some-subject",
+			issuedAt: hourAgo.Time,
+			want:     true,
+		},
+		{
+			name:     "matching subject issued before the time range",
+			entries:  []authenticationv1alpha1.ClusterTokenDenylistEntry{{Subject: "some-subject", IssuedAfter: &hourAgo}},
+			subject:  "some-subject",
+			issuedAt: now.Add(-2 * time.Hour),
+			want:     false,
+		},
+		{
+			name:     "matching subject issued after the time range",
+			entries:  []authenticationv1alpha1.ClusterTokenDenylistEntry{{Subject: "some-subject", IssuedBefore: &hourAgo}},
+			subject:  "some-subject",
+			issuedAt: now,
+			want:     false,
+		},
+		{
+			name:    "matching subject with a time range and token without iat",
+			entries: []authenticationv1alpha1.ClusterTokenDenylistEntry{{Subject: "some-subject", IssuedBefore: &hourFromNow}},
+			subject: "some-subject",
+			want:    false,
+		},
+		{
+			name: "matching subject where only one of several entries matches",
+			entries: []authenticationv1alpha1.ClusterTokenDenylistEntry{
+				{Subject: "some-subject", IssuedBefore: &hourAgo},
+				{Subject: "some-subject", IssuedAfter: &hourAgo},
+			},
+			subject:  "some-subject",
+			issuedAt: now,
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			denylist := NewDenylist(clocktesting.NewFakeClock(now))
+			require.True(t, denylist.Empty())
+			denylist.Set(tt.entries)
+			require.Equal(t, len(tt.entries) == 0, denylist.Empty())
+			require.Equal(t, tt.want, denylist.Denied(tt.jti, tt.subject, tt.issuedAt))
+		})
+	}
+}
+
+func TestDeniedAfterEntryExpires(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	fakeClock := clocktesting.NewFakeClock(now)
+	denylist := NewDenylist(fakeClock)
+	denylist.Set([]authenticationv1alpha1.ClusterTokenDenylistEntry{
+		{JTI: "some-jti", ExpiresAt: ptr.To(metav1.NewTime(now.Add(time.Minute)))},
+	})
+
+	require.True(t, denylist.Denied("some-jti", "", time.Time{}))
+	fakeClock.Step(time.Minute)
+	require.False(t, denylist.Denied("some-jti", "", time.Time{}))
+
+	denylist.Set(nil)
+	require.True(t, denylist.Empty())
+}
+
+func TestWrapTokenAuthenticator(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: signingKey}, nil)
+	require.NoError(t, err)
+
+	signToken := func(claims jwt.Claims) string {
+		token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+		require.NoError(t, err)
+		return token
+	}
+	deniedJTIToken := signToken(jwt.Claims{ID: "denied-jti", Subject: "some-subject", IssuedAt: jwt.NewNumericDate(now)})
+	deniedSubjectToken := signToken(jwt.Claims{ID: "some-jti", Subject: "denied-subject", IssuedAt: jwt.NewNumericDate(now)})
+	allowedToken := signToken(jwt.Claims{ID: "some-jti", Subject: "some-subject", IssuedAt: jwt.NewNumericDate(now)})
+
+	wantResponse := &authenticator.Response{User: &user.DefaultInfo{Name: "some-user"}}
+	delegateErr := errors.New("some delegate error")
+	delegate := authenticator.TokenFunc(func(_ context.Context, token string) (*authenticator.Response, bool, error) {
+		switch token {
+		case "error":
+			return nil, false, delegateErr
+		case "unauthenticated", "not-a-jwt":
+			return nil, false, nil
+		}
+		return wantResponse, true, nil
+	})
+
+	denylist := NewDenylist(clocktesting.NewFakeClock(now))
+	wrapped := denylist.WrapTokenAuthenticator(delegate)
+
+	authenticate := func(token string) (*authenticator.Response, bool, error) {
+		return wrapped.AuthenticateToken(context.Background(), token)
+	}
+
+	// Tokens are not parsed at all when the denylist is empty.
+	response, authenticated, err := authenticate("authenticated-by-delegate-but-not-a-jwt")
+	require.NoError(t, err)
+	require.True(t, authenticated)
+	require.Equal(t, wantResponse, response)
+
+	denylist.Set([]authenticationv1alpha1.ClusterTokenDenylistEntry{
+		{JTI: "denied-jti"},
+		{Subject: "denied-subject", IssuedAfter: ptr.To(metav1.NewTime(now.Add(-time.Minute)))},
+	})
+
+	response, authenticated, err = authenticate(allowedToken)
+	require.NoError(t, err)
+	require.True(t, authenticated)
+	require.Equal(t, wantResponse, response)
+
+	for _, token := range []string{deniedJTIToken, deniedSubjectToken, "authenticated-by-delegate-but-not-a-jwt"} {
+		response, authenticated, err = authenticate(token)
+		require.ErrorIs(t, err, ErrTokenDenied)
+		require.False(t, authenticated)
+		require.Nil(t, response)
+	}
+
+	response, authenticated, err = authenticate("error")
+	require.ErrorIs(t, err, delegateErr)
+	require.False(t, authenticated)
+	require.Nil(t, response)
+
+	response, authenticated, err = authenticate("unauthenticated")
+	require.NoError(t, err)
+	require.False(t, authenticated)
+	require.Nil(t, response)
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package tokendenylist
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	authenticationv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/authentication/v1alpha1"
+	authinformers "go.pinniped.dev/generated/latest/client/concierge/informers/externalversions/authentication/v1alpha1"
+	pinnipedcontroller "go.pinniped.dev/internal/controller"
+	"go.pinniped.dev/internal/controllerlib"
+	"go.pinniped.dev/internal/plog"
+)
+
+const controllerName = "tokendenylist-controller"
+
+// New instantiates a new controllerlib.Controller which will keep the provided Denylist in sync with
+// all ClusterTokenDenylists in the cluster.
+func New(
+	denylist *Denylist,
+	denylists authinformers.ClusterTokenDenylistInformer,
+	log plog.Logger,
+) controllerlib.Controller {
+	return controllerlib.New(
+		controllerlib.Config{
+			Name: controllerName,
+			Syncer: &controller{
+				denylist:  denylist,
+				denylists: denylists,
+				log:       log.WithName(controllerName),
+			},
+		},
+		controllerlib.WithInformer(
+			denylists,
+			pinnipedcontroller.MatchAnythingFilter(pinnipedcontroller.SingletonQueue()),
+			controllerlib.InformerOption{},
+		),
+	)
+}
+
+type controller struct {
+	denylist  *Denylist
+	denylists authinformers.ClusterTokenDenylistInformer
+	log       plog.Logger
+}
+
+// Sync implements controllerlib.Syncer.
+func (c *controller) Sync(_ controllerlib.Context) error {
+	denylists, err := c.denylists.Lister().List(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("failed to list ClusterTokenDenylists: %w", err)
+	}
+
+	// Sort for stable logging.
+	sort.Slice(denylists, func(i, j int) bool { return denylists[i].Name < denylists[j].Name })
+
+	var entries []authenticationv1alpha1.ClusterTokenDenylistEntry
+	names := make([]string, 0, len(denylists))
+	for _, denylist := range denylists {
+		entries = append(entries, denylist.Spec.Entries...)
+		names = append(names, denylist.Name)
+	}
+
+	c.denylist.Set(entries)
+	c.log.Debug("updated token denylist", "clusterTokenDenylists", names, "entries", len(entries))
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package tokendenylist
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	authenticationv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/authentication/v1alpha1"
+	conciergefake "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned/fake"
+	conciergeinformers "go.pinniped.dev/generated/latest/client/concierge/informers/externalversions"
+	"go.pinniped.dev/internal/controllerlib"
+	"go.pinniped.dev/internal/plog"
+)
+
+func TestController(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		objects    []runtime.Object
+		initial    []authenticationv1alpha1.ClusterTokenDenylistEntry
+		wantDenied map[string]bool
+	}{
+		{
+			name:       "no ClusterTokenDenylists",
+			initial:    []authenticationv1alpha1.ClusterTokenDenylistEntry{{JTI: "stale-jti"}},
+			wantDenied: map[string]bool{"stale-jti": false},
+		},
+		{
+			name: "entries from all ClusterTokenDenylists are combined",
+			objects: []runtime.Object{
+				&authenticationv1alpha1.ClusterTokenDenylist{
+					ObjectMeta: metav1.ObjectMeta{Name: "denylist-one"},
+					Spec: authenticationv1alpha1.ClusterTokenDenylistSpec{
+						Entries: []authenticationv1alpha1.ClusterTokenDenylistEntry{{JTI: "jti-one"}, {JTI: "jti-two"}},
+					},
+				},
+				&authenticationv1alpha1.ClusterTokenDenylist{
+					ObjectMeta: metav1.ObjectMeta{Name: "denylist-two"},
+					Spec: authenticationv1alpha1.ClusterTokenDenylistSpec{
+						Entries: []authenticationv1alpha1.ClusterTokenDenylistEntry{{JTI: "jti-three"}},
+					},
+				},
+			},
+			initial:    []authenticationv1alpha1.ClusterTokenDenylistEntry{{JTI: "stale-jti"}},
+			wantDenied: map[string]bool{"jti-one": true, "jti-two": true, "jti-three": true, "stale-jti": false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pinnipedAPIClient := conciergefake.NewSimpleClientset(tt.objects...)
+			informers := conciergeinformers.NewSharedInformerFactory(pinnipedAPIClient, 0)
+			denylist := NewDenylist(clocktesting.NewFakeClock(now))
+			denylist.Set(tt.initial)
+
+			controller := New(
+				denylist,
+				informers.Authentication().V1alpha1().ClusterTokenDenylists(),
+				plog.New(),
+			)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			informers.Start(ctx.Done())
+			controllerlib.TestRunSynchronously(t, controller)
+
+			syncCtx := controllerlib.Context{Context: ctx}
+			require.NoError(t, controllerlib.TestSync(t, controller, syncCtx))
+
+			for jti, wantDenied := range tt.wantDenied {
+				require.Equal(t, wantDenied, denylist.Denied(jti, "", time.Time{}), "jti %q", jti)
+			}
+		})
+	}
+}
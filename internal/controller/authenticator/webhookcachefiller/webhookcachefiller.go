@@ -24,6 +24,7 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/utils/clock"
 
+	authenticationconditions "go.pinniped.dev/generated/latest/apis/concierge/authentication/conditions"
 	authenticationv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/authentication/v1alpha1"
 	conciergeclientset "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned"
 	authinformers "go.pinniped.dev/generated/latest/client/concierge/informers/externalversions/authentication/v1alpha1"
@@ -39,20 +40,20 @@ import (
 
 const (
 	controllerName                   = "webhookcachefiller-controller"
-	typeReady                        = "Ready"
-	typeTLSConfigurationValid        = "TLSConfigurationValid"
-	typeWebhookConnectionValid       = "WebhookConnectionValid"
-	typeEndpointURLValid             = "EndpointURLValid"
-	typeAuthenticatorValid           = "AuthenticatorValid"
-	reasonSuccess                    = "Success"
-	reasonNotReady                   = "NotReady"
-	reasonUnableToValidate           = "UnableToValidate"
-	reasonUnableToCreateClient       = "UnableToCreateClient"
-	reasonUnableToInstantiateWebhook = "UnableToInstantiateWebhook"
-	reasonInvalidTLSConfiguration    = "InvalidTLSConfiguration"
-	reasonInvalidEndpointURL         = "InvalidEndpointURL"
-	reasonInvalidEndpointURLScheme   = "InvalidEndpointURLScheme"
-	reasonUnableToDialServer         = "UnableToDialServer"
+	typeReady                        = authenticationconditions.TypeReady
+	typeTLSConfigurationValid        = authenticationconditions.TypeTLSConfigurationValid
+	typeWebhookConnectionValid       = authenticationconditions.TypeWebhookConnectionValid
+	typeEndpointURLValid             = authenticationconditions.TypeEndpointURLValid
+	typeAuthenticatorValid           = authenticationconditions.TypeAuthenticatorValid
+	reasonSuccess                    = authenticationconditions.ReasonSuccess
+	reasonNotReady                   = authenticationconditions.ReasonNotReady
+	reasonUnableToValidate           = authenticationconditions.ReasonUnableToValidate
+	reasonUnableToCreateClient       = authenticationconditions.ReasonUnableToCreateClient
+	reasonUnableToInstantiateWebhook = authenticationconditions.ReasonUnableToInstantiateWebhook
+	reasonInvalidTLSConfiguration    = authenticationconditions.ReasonInvalidTLSConfiguration
+	reasonInvalidEndpointURL         = authenticationconditions.ReasonInvalidEndpointURL
+	reasonInvalidEndpointURLScheme   = authenticationconditions.ReasonInvalidEndpointURLScheme
+	reasonUnableToDialServer         = authenticationconditions.ReasonUnableToDialServer
 	msgUnableToValidate              = "unable to validate; see other conditions for details"
 )
 
@@ -123,16 +124,17 @@ func (c *webhookCacheFillerController) Sync(ctx controllerlib.Context) error {
 	)
 	errs = append(errs, err)
 
+	cacheKey := authncache.Key{
+		APIGroup: authenticationv1alpha1.GroupName,
+		Kind:     "WebhookAuthenticator",
+		Name:     ctx.Key.Name,
+	}
 	if !conditionsutil.HadErrorCondition(conditions) {
-		c.cache.Store(authncache.Key{
-			APIGroup: authenticationv1alpha1.GroupName,
-			Kind:     "WebhookAuthenticator",
-			Name:     ctx.Key.Name,
-		}, webhookAuthenticator)
+		c.cache.Store(cacheKey, webhookAuthenticator)
 		c.log.WithValues("webhook", klog.KObj(obj), "endpoint", obj.Spec.Endpoint).Info("added new webhook authenticator")
 	}
 
-	err = c.updateStatus(ctx.Context, obj, conditions)
+	err = c.updateStatus(ctx.Context, obj, cacheKey, conditions)
 	errs = append(errs, err)
 
 	// sync loop errors:
@@ -337,9 +339,12 @@ func (c *webhookCacheFillerController) validateEndpoint(endpoint string, conditi
 func (c *webhookCacheFillerController) updateStatus(
 	ctx context.Context,
 	original *authenticationv1alpha1.WebhookAuthenticator,
+	cacheKey authncache.Key,
 	conditions []*metav1.Condition,
 ) error {
 	updated := original.DeepCopy()
+	updated.Status.ObservedGeneration = original.Generation
+	updated.Status.LastSuccessfulAuthTimestamp = c.cache.LastSuccessfulAuth(cacheKey, original.Status.LastSuccessfulAuthTimestamp)
 
 	if conditionsutil.HadErrorCondition(conditions) {
 		updated.Status.Phase = authenticationv1alpha1.WebhookAuthenticatorPhaseError
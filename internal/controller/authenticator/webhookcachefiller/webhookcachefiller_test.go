@@ -471,7 +471,8 @@ func TestController(t *testing.T) {
 								happyEndpointURLValid(frozenTimeInThePast, 1234),
 							},
 						),
-						Phase: "Ready",
+						Phase:              "Ready",
+						ObservedGeneration: 1234,
 					},
 				})
 				updateStatusAction.Subresource = "status"
@@ -4,10 +4,15 @@
 package conditionsutil
 
 import (
+	"fmt"
 	"sort"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
 
 	"go.pinniped.dev/internal/plog"
 )
@@ -71,6 +76,37 @@ func mergeCondition(existing *[]metav1.Condition, new *metav1.Condition) bool {
 	return false
 }
 
+// maxEventNoteLength is the maximum length of the note of an Event which is accepted by the Kubernetes API.
+const maxEventNoteLength = 1024
+
+// RecordConditionChanges emits an Event regarding the object for each of the newConditions which was not in
+// the oldConditions, or whose status, reason or message has changed. Conditions with status True are recorded
+// as Normal Events and all others as Warning Events, so that transitions such as failed discovery of an upstream
+// identity provider or a misconfigured Secret are visible to "kubectl describe" and "kubectl get events".
+// The Event's reason is the condition's reason and its action is the condition's type.
+// It should be called after the new conditions were successfully saved, to avoid repeating the Events
+// when the update is retried. A nil recorder records nothing.
+func RecordConditionChanges(recorder events.EventRecorder, regarding runtime.Object, oldConditions, newConditions []metav1.Condition) {
+	if recorder == nil {
+		return
+	}
+	for _, cond := range newConditions {
+		old := meta.FindStatusCondition(oldConditions, cond.Type)
+		if old != nil && old.Status == cond.Status && old.Reason == cond.Reason && old.Message == cond.Message {
+			continue
+		}
+		eventType := corev1.EventTypeNormal
+		if cond.Status != metav1.ConditionTrue {
+			eventType = corev1.EventTypeWarning
+		}
+		note := fmt.Sprintf("%s is %s: %s", cond.Type, cond.Status, cond.Message)
+		if len(note) > maxEventNoteLength {
+			note = note[:maxEventNoteLength-3] + "..."
+		}
+		recorder.Eventf(regarding, nil, eventType, cond.Reason, cond.Type, "%s", note)
+	}
+}
+
 func HadErrorCondition(conditions []*metav1.Condition) bool {
 	for _, c := range conditions {
 		if c.Status != metav1.ConditionTrue {
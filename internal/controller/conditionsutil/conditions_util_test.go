@@ -10,7 +10,9 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/events"
 
 	"go.pinniped.dev/internal/plog"
 )
@@ -188,3 +190,37 @@ func TestMergeIDPConditions(t *testing.T) {
 		})
 	}
 }
+
+func TestRecordConditionChanges(t *testing.T) {
+	regarding := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "some-namespace", Name: "some-name"}}
+	oldConditions := []metav1.Condition{
+		{Type: "UnchangedType", Status: metav1.ConditionTrue, Reason: "Success", Message: "unchanged message"},
+		{Type: "ObservedGenerationChangedType", Status: metav1.ConditionTrue, Reason: "Success", ObservedGeneration: 1},
+		{Type: "StatusChangedType", Status: metav1.ConditionTrue, Reason: "Success", Message: "it worked"},
+		{Type: "MessageChangedType", Status: metav1.ConditionFalse, Reason: "SecretNotFound", Message: "old message"},
+	}
+	newConditions := []metav1.Condition{
+		{Type: "UnchangedType", Status: metav1.ConditionTrue, Reason: "Success", Message: "unchanged message"},
+		{Type: "ObservedGenerationChangedType", Status: metav1.ConditionTrue, Reason: "Success", ObservedGeneration: 2},
+		{Type: "StatusChangedType", Status: metav1.ConditionFalse, Reason: "Unreachable", Message: "it failed"},
+		{Type: "MessageChangedType", Status: metav1.ConditionFalse, Reason: "SecretNotFound", Message: "new message"},
+		{Type: "NewType", Status: metav1.ConditionTrue, Reason: "Success", Message: strings.Repeat("a", 2000)},
+	}
+
+	recorder := events.NewFakeRecorder(10)
+	RecordConditionChanges(recorder, regarding, oldConditions, newConditions)
+	close(recorder.Events)
+
+	var got []string
+	for event := range recorder.Events {
+		got = append(got, event)
+	}
+	require.Equal(t, []string{
+		"Warning Unreachable StatusChangedType is False: it failed",
+		"Warning SecretNotFound MessageChangedType is False: new message",
+		"Normal Success NewType is True: " + strings.Repeat("a", 1024-len("NewType is True: ")-3) + "...",
+	}, got)
+
+	// A nil recorder records nothing.
+	RecordConditionChanges(nil, regarding, oldConditions, newConditions)
+}
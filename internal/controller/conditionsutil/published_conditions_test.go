@@ -0,0 +1,121 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package conditionsutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	authenticationconditions "go.pinniped.dev/generated/latest/apis/concierge/authentication/conditions"
+	configconditions "go.pinniped.dev/generated/latest/apis/supervisor/config/conditions"
+	idpconditions "go.pinniped.dev/generated/latest/apis/supervisor/idp/conditions"
+	"go.pinniped.dev/internal/plog"
+)
+
+// publishedHelpers are the helpers of each of the published conditions packages, which should all behave the same.
+type publishedHelpers struct {
+	findCondition func([]metav1.Condition, string) *metav1.Condition
+	isTrue        func([]metav1.Condition, string) bool
+	isReady       func([]metav1.Condition) bool
+}
+
+func TestPublishedConditionHelpers(t *testing.T) {
+	helpers := map[string]publishedHelpers{
+		"authentication": {authenticationconditions.FindCondition, authenticationconditions.IsTrue, authenticationconditions.IsReady},
+		"config":         {configconditions.FindCondition, configconditions.IsTrue, configconditions.IsReady},
+		"idp":            {idpconditions.FindCondition, idpconditions.IsTrue, idpconditions.IsReady},
+	}
+
+	condition := func(conditionType string, status metav1.ConditionStatus) metav1.Condition {
+		return metav1.Condition{Type: conditionType, Status: status, Reason: "SomeReason"}
+	}
+
+	tests := []struct {
+		name       string
+		conditions []metav1.Condition
+		wantFound  bool
+		wantTrue   bool
+		wantReady  bool
+	}{
+		{
+			name: "no conditions",
+		},
+		{
+			name:       "Ready is True even though another condition is False",
+			conditions: []metav1.Condition{condition("Ready", metav1.ConditionTrue), condition("SomeValid", metav1.ConditionFalse)},
+			wantFound:  true,
+			wantReady:  true,
+		},
+		{
+			name:       "Ready is False even though all other conditions are True",
+			conditions: []metav1.Condition{condition("SomeValid", metav1.ConditionTrue), condition("Ready", metav1.ConditionFalse)},
+			wantFound:  true,
+			wantTrue:   true,
+		},
+		{
+			name:       "no Ready condition and all conditions are True",
+			conditions: []metav1.Condition{condition("SomeValid", metav1.ConditionTrue), condition("OtherValid", metav1.ConditionTrue)},
+			wantFound:  true,
+			wantTrue:   true,
+			wantReady:  true,
+		},
+		{
+			name:       "no Ready condition and one condition is Unknown",
+			conditions: []metav1.Condition{condition("SomeValid", metav1.ConditionTrue), condition("OtherValid", metav1.ConditionUnknown)},
+			wantFound:  true,
+			wantTrue:   true,
+		},
+		{
+			name:       "no Ready condition and the condition of interest is False",
+			conditions: []metav1.Condition{condition("SomeValid", metav1.ConditionFalse)},
+			wantFound:  true,
+		},
+	}
+	for packageName, h := range helpers {
+		for _, tt := range tests {
+			t.Run(packageName+": "+tt.name, func(t *testing.T) {
+				found := h.findCondition(tt.conditions, "SomeValid")
+				if tt.wantFound {
+					require.NotNil(t, found)
+					require.Equal(t, "SomeValid", found.Type)
+					// The returned pointer refers to the original slice element.
+					require.Same(t, found, h.findCondition(tt.conditions, "SomeValid"))
+				} else {
+					require.Nil(t, found)
+				}
+				require.Equal(t, tt.wantTrue, h.isTrue(tt.conditions, "SomeValid"))
+				require.Equal(t, tt.wantReady, h.isReady(tt.conditions))
+			})
+		}
+	}
+}
+
+// The controllers for identity providers set the phase to Error whenever MergeConditions reports an error condition,
+// so the published IsReady helper must agree with MergeConditions about which conditions are errors.
+func TestPublishedIDPIsReadyAgreesWithMergeConditions(t *testing.T) {
+	now := metav1.Now()
+	for _, statuses := range [][]metav1.ConditionStatus{
+		{metav1.ConditionTrue},
+		{metav1.ConditionTrue, metav1.ConditionTrue},
+		{metav1.ConditionTrue, metav1.ConditionFalse},
+		{metav1.ConditionUnknown},
+		{metav1.ConditionTrue, metav1.ConditionUnknown},
+	} {
+		newConditions := make([]*metav1.Condition, 0, len(statuses))
+		for i, status := range statuses {
+			newConditions = append(newConditions, &metav1.Condition{
+				Type:   string(rune('A' + i)),
+				Status: status,
+				Reason: idpconditions.ReasonSuccess,
+			})
+		}
+
+		var merged []metav1.Condition
+		hadErrorCondition := MergeConditions(newConditions, 1, &merged, plog.New(), now)
+
+		require.Equal(t, !hadErrorCondition, idpconditions.IsReady(merged), "for statuses %v", statuses)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"net"
 	"sort"
@@ -44,6 +45,7 @@ import (
 	"go.pinniped.dev/internal/controllerlib"
 	"go.pinniped.dev/internal/dynamiccert"
 	"go.pinniped.dev/internal/endpointaddr"
+	"go.pinniped.dev/internal/leaderelection"
 	"go.pinniped.dev/internal/plog"
 	"go.pinniped.dev/internal/tokenclient"
 )
@@ -155,7 +157,12 @@ func NewImpersonatorConfigController(
 				case generatedLoadBalancerServiceName, generatedClusterIPServiceName:
 					return true
 				default:
-					return false
+					// Also watch the existing Service which is configured to expose the impersonation proxy, if any.
+					credIssuer, err := credentialIssuerInformer.Lister().Get(credentialIssuerResourceName)
+					if err != nil || credIssuer.Spec.ImpersonationProxy == nil {
+						return false
+					}
+					return credIssuer.Spec.ImpersonationProxy.Service.Name == obj.GetName()
 				}
 			}),
 			controllerlib.InformerOption{},
@@ -217,7 +224,7 @@ func (c *impersonatorConfigController) Sync(syncCtx controllerlib.Context) error
 // recover on a following sync.
 func strategyReasonForError(err error) conciergeconfigv1alpha1.StrategyReason {
 	switch {
-	case apierrors.IsConflict(err), apierrors.IsAlreadyExists(err):
+	case apierrors.IsConflict(err), apierrors.IsAlreadyExists(err), errors.Is(err, leaderelection.ErrNotLeader):
 		return conciergeconfigv1alpha1.PendingStrategyReason
 	default:
 		return conciergeconfigv1alpha1.ErrorDuringSetupStrategyReason
@@ -235,11 +242,29 @@ type certNameInfo struct {
 	selectedIPs      []net.IP
 	selectedHostname string
 
+	// Any additional IP addresses and hostnames from the configuration which should also be names in the cert.
+	additionalIPs       []net.IP
+	additionalHostnames []string
+
 	// The name of the endpoint to which a client should connect to talk to the impersonator.
 	// This may be a hostname or an IP, and may include a port number.
 	clientEndpoint string
 }
 
+// certIPs returns all IP addresses which should be names in the cert.
+func (n *certNameInfo) certIPs() []net.IP {
+	return append(append([]net.IP{}, n.selectedIPs...), n.additionalIPs...)
+}
+
+// certHostnames returns all hostnames which should be names in the cert.
+func (n *certNameInfo) certHostnames() []string {
+	var hostnames []string
+	if n.selectedHostname != "" {
+		hostnames = append(hostnames, n.selectedHostname)
+	}
+	return append(hostnames, n.additionalHostnames...)
+}
+
 func (c *impersonatorConfigController) doSync(syncCtx controllerlib.Context, credIssuer *conciergeconfigv1alpha1.CredentialIssuer) (*conciergeconfigv1alpha1.CredentialIssuerStrategy, error) {
 	ctx := syncCtx.Context
 
@@ -308,7 +333,7 @@ func (c *impersonatorConfigController) doSync(syncCtx controllerlib.Context, cre
 			return nil, err
 		}
 	} else {
-		if err = c.ensureTLSSecretIsRemoved(ctx); err != nil {
+		if err = c.ensureTLSSecretIsRemovedByLeader(ctx); err != nil {
 			return nil, err
 		}
 		c.clearTLSSecret()
@@ -361,7 +386,7 @@ func (c *impersonatorConfigController) evaluateExternallyProvidedTLSSecret(
 		"secretName", tlsSpec.SecretName)
 
 	// Ensure that any TLS secret generated by this controller is removed
-	err := c.ensureTLSSecretIsRemoved(ctx)
+	err := c.ensureTLSSecretIsRemovedByLeader(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("unable to remove generated TLS secret with name %s: %w", c.tlsSecretName, err)
 	}
@@ -429,11 +454,13 @@ func (c *impersonatorConfigController) disabledExplicitly(config *conciergeconfi
 }
 
 func (c *impersonatorConfigController) shouldHaveLoadBalancer(config *conciergeconfigv1alpha1.ImpersonationProxySpec) bool {
-	return c.shouldHaveImpersonator(config) && config.Service.Type == conciergeconfigv1alpha1.ImpersonationProxyServiceTypeLoadBalancer
+	return c.shouldHaveImpersonator(config) && config.Service.Name == "" &&
+		config.Service.Type == conciergeconfigv1alpha1.ImpersonationProxyServiceTypeLoadBalancer
 }
 
 func (c *impersonatorConfigController) shouldHaveClusterIPService(config *conciergeconfigv1alpha1.ImpersonationProxySpec) bool {
-	return c.shouldHaveImpersonator(config) && config.Service.Type == conciergeconfigv1alpha1.ImpersonationProxyServiceTypeClusterIP
+	return c.shouldHaveImpersonator(config) && config.Service.Name == "" &&
+		config.Service.Type == conciergeconfigv1alpha1.ImpersonationProxyServiceTypeClusterIP
 }
 
 func (c *impersonatorConfigController) serviceExists(serviceName string) (bool, *corev1.Service, error) {
@@ -758,6 +785,15 @@ func (c *impersonatorConfigController) ensureTLSSecret(ctx context.Context, name
 
 	if !notFound {
 		secretWasDeleted, err := c.deleteTLSSecretWhenCertificateDoesNotMatchDesiredState(ctx, nameInfo, ca, secretFromInformer)
+		if errors.Is(err, leaderelection.ErrNotLeader) {
+			// All replicas share the TLS Secret, and only the leader may replace it. Until the leader does, keep serving
+			// the certificate from the Secret, so that every replica behind the load balancer serves the same certificate.
+			// The informer will trigger another sync when the leader replaces the Secret.
+			c.log.Info("waiting for the leader to replace the TLS serving certificate for impersonation proxy",
+				"secret", klog.KObj(secretFromInformer),
+			)
+			return c.loadTLSCertFromSecret(secretFromInformer)
+		}
 		if err != nil {
 			return err
 		}
@@ -835,14 +871,14 @@ func (c *impersonatorConfigController) deleteTLSSecretWhenCertificateDoesNotMatc
 	actualIPs := actualCertFromSecret.IPAddresses
 	actualHostnames := actualCertFromSecret.DNSNames
 	c.log.Info("checking TLS certificate names",
-		"desiredIPs", nameInfo.selectedIPs,
-		"desiredHostname", nameInfo.selectedHostname,
+		"desiredIPs", nameInfo.certIPs(),
+		"desiredHostnames", nameInfo.certHostnames(),
 		"actualIPs", actualIPs,
 		"actualHostnames", actualHostnames,
 		"secret", klog.KObj(secret),
 	)
 
-	if certHostnameAndIPMatchDesiredState(nameInfo.selectedIPs, actualIPs, nameInfo.selectedHostname, actualHostnames) {
+	if certHostnameAndIPMatchDesiredState(nameInfo.certIPs(), actualIPs, nameInfo.certHostnames(), actualHostnames) {
 		// The cert already matches the desired state, so there is no need to delete/recreate it.
 		return false, nil
 	}
@@ -853,19 +889,24 @@ func (c *impersonatorConfigController) deleteTLSSecretWhenCertificateDoesNotMatc
 	return true, nil
 }
 
-func certHostnameAndIPMatchDesiredState(desiredIPs []net.IP, actualIPs []net.IP, desiredHostname string, actualHostnames []string) bool {
-	if len(desiredIPs) > 0 && len(actualIPs) > 0 && len(actualIPs) == len(desiredIPs) && len(actualHostnames) == 0 {
-		for i := range desiredIPs {
-			if !actualIPs[i].Equal(desiredIPs[i]) {
-				return false
-			}
+func certHostnameAndIPMatchDesiredState(desiredIPs []net.IP, actualIPs []net.IP, desiredHostnames []string, actualHostnames []string) bool {
+	if len(desiredIPs) == 0 && len(desiredHostnames) == 0 {
+		return false
+	}
+	if len(actualIPs) != len(desiredIPs) || len(actualHostnames) != len(desiredHostnames) {
+		return false
+	}
+	for i := range desiredIPs {
+		if !actualIPs[i].Equal(desiredIPs[i]) {
+			return false
 		}
-		return true
 	}
-	if desiredHostname != "" && len(actualHostnames) == 1 && desiredHostname == actualHostnames[0] && len(actualIPs) == 0 {
-		return true
+	for i := range desiredHostnames {
+		if actualHostnames[i] != desiredHostnames[i] {
+			return false
+		}
 	}
-	return false
+	return true
 }
 
 func (c *impersonatorConfigController) ensureTLSSecretIsCreatedAndLoaded(ctx context.Context, nameInfo *certNameInfo, secret *corev1.Secret, ca *certauthority.CA) error {
@@ -881,7 +922,7 @@ func (c *impersonatorConfigController) ensureTLSSecretIsCreatedAndLoaded(ctx con
 		return nil
 	}
 
-	newTLSSecret, err := c.createNewTLSSecret(ctx, ca, nameInfo.selectedIPs, nameInfo.selectedHostname)
+	newTLSSecret, err := c.createNewTLSSecret(ctx, ca, nameInfo.certIPs(), nameInfo.certHostnames())
 	if err != nil {
 		return err
 	}
@@ -950,12 +991,35 @@ func (c *impersonatorConfigController) createCASecret(ctx context.Context) (*cer
 }
 
 func (c *impersonatorConfigController) findDesiredTLSCertificateName(config *conciergeconfigv1alpha1.ImpersonationProxySpec) (*certNameInfo, error) {
+	nameInfo, err := c.findSelectedTLSCertificateName(config)
+	if err != nil || !nameInfo.ready {
+		return nameInfo, err
+	}
+
+	for _, name := range config.AdditionalExternalNames {
+		if ip := net.ParseIP(name); ip != nil {
+			nameInfo.additionalIPs = append(nameInfo.additionalIPs, ip)
+		} else {
+			nameInfo.additionalHostnames = append(nameInfo.additionalHostnames, name)
+		}
+	}
+	return nameInfo, nil
+}
+
+func (c *impersonatorConfigController) findSelectedTLSCertificateName(config *conciergeconfigv1alpha1.ImpersonationProxySpec) (*certNameInfo, error) {
+	serviceName := config.Service.Name
 	if config.ExternalEndpoint != "" {
 		return c.findTLSCertificateNameFromEndpointConfig(config), nil
 	} else if config.Service.Type == conciergeconfigv1alpha1.ImpersonationProxyServiceTypeClusterIP {
-		return c.findTLSCertificateNameFromClusterIPService()
+		if serviceName == "" {
+			serviceName = c.generatedClusterIPServiceName
+		}
+		return c.findTLSCertificateNameFromClusterIPService(serviceName)
 	}
-	return c.findTLSCertificateNameFromLoadBalancer()
+	if serviceName == "" {
+		serviceName = c.generatedLoadBalancerServiceName
+	}
+	return c.findTLSCertificateNameFromLoadBalancer(serviceName)
 }
 
 func (c *impersonatorConfigController) findTLSCertificateNameFromEndpointConfig(config *conciergeconfigv1alpha1.ImpersonationProxySpec) *certNameInfo {
@@ -968,8 +1032,8 @@ func (c *impersonatorConfigController) findTLSCertificateNameFromEndpointConfig(
 	return &certNameInfo{ready: true, selectedHostname: addr.Host, clientEndpoint: endpoint}
 }
 
-func (c *impersonatorConfigController) findTLSCertificateNameFromLoadBalancer() (*certNameInfo, error) {
-	lb, err := c.servicesInformer.Lister().Services(c.namespace).Get(c.generatedLoadBalancerServiceName)
+func (c *impersonatorConfigController) findTLSCertificateNameFromLoadBalancer(serviceName string) (*certNameInfo, error) {
+	lb, err := c.servicesInformer.Lister().Services(c.namespace).Get(serviceName)
 	notFound := apierrors.IsNotFound(err)
 	if notFound {
 		// We aren't ready and will try again later in this case.
@@ -1002,8 +1066,8 @@ func (c *impersonatorConfigController) findTLSCertificateNameFromLoadBalancer()
 	return nil, fmt.Errorf("could not find valid IP addresses or hostnames from load balancer %s/%s", c.namespace, lb.Name)
 }
 
-func (c *impersonatorConfigController) findTLSCertificateNameFromClusterIPService() (*certNameInfo, error) {
-	clusterIP, err := c.servicesInformer.Lister().Services(c.namespace).Get(c.generatedClusterIPServiceName)
+func (c *impersonatorConfigController) findTLSCertificateNameFromClusterIPService(serviceName string) (*certNameInfo, error) {
+	clusterIP, err := c.servicesInformer.Lister().Services(c.namespace).Get(serviceName)
 	notFound := apierrors.IsNotFound(err)
 	if notFound {
 		// We aren't ready and will try again later in this case.
@@ -1029,12 +1093,7 @@ func (c *impersonatorConfigController) findTLSCertificateNameFromClusterIPServic
 	return &certNameInfo{ready: false}, nil
 }
 
-func (c *impersonatorConfigController) createNewTLSSecret(ctx context.Context, ca *certauthority.CA, ips []net.IP, hostname string) (*corev1.Secret, error) {
-	var hostnames []string
-	if hostname != "" {
-		hostnames = []string{hostname}
-	}
-
+func (c *impersonatorConfigController) createNewTLSSecret(ctx context.Context, ca *certauthority.CA, ips []net.IP, hostnames []string) (*corev1.Secret, error) {
 	impersonationCert, err := ca.IssueServerCert(hostnames, ips, approximatelyOneHundredYears)
 	if err != nil {
 		return nil, fmt.Errorf("could not create impersonation cert: %w", err)
@@ -1104,6 +1163,14 @@ func (c *impersonatorConfigController) ensureTLSSecretIsRemoved(ctx context.Cont
 	return utilerrors.FilterOut(err, apierrors.IsNotFound)
 }
 
+// ensureTLSSecretIsRemovedByLeader is like ensureTLSSecretIsRemoved, but it does not fail on the pods which are not the
+// leader, because the leader will remove the TLS Secret.
+func (c *impersonatorConfigController) ensureTLSSecretIsRemovedByLeader(ctx context.Context) error {
+	return utilerrors.FilterOut(c.ensureTLSSecretIsRemoved(ctx), func(err error) bool {
+		return errors.Is(err, leaderelection.ErrNotLeader)
+	})
+}
+
 func (c *impersonatorConfigController) clearTLSSecret() {
 	c.log.Debug("clearing TLS serving certificate for impersonation proxy")
 	c.tlsServingCertDynamicCertProvider.UnsetCertKeyContent()
@@ -1208,6 +1275,16 @@ func validateCredentialIssuerSpec(spec *conciergeconfigv1alpha1.ImpersonationPro
 		return fmt.Errorf("invalid LoadBalancerIP %q", spec.Service.LoadBalancerIP)
 	}
 
+	// An existing Service must be of a type which the Concierge can find the endpoint of.
+	if name := spec.Service.Name; name != "" {
+		if spec.Service.Type == conciergeconfigv1alpha1.ImpersonationProxyServiceTypeNone {
+			return fmt.Errorf("service.name must not be set when service.type is None")
+		}
+		if errs := validation.IsDNS1035Label(name); len(errs) > 0 {
+			return fmt.Errorf("invalid service.name %q: %s", name, strings.Join(errs, ", "))
+		}
+	}
+
 	// If service is type "None", a non-empty external endpoint must be specified.
 	if spec.ExternalEndpoint == "" && spec.Service.Type == conciergeconfigv1alpha1.ImpersonationProxyServiceTypeNone {
 		return fmt.Errorf("externalEndpoint must be set when service.type is None")
@@ -1219,5 +1296,16 @@ func validateCredentialIssuerSpec(spec *conciergeconfigv1alpha1.ImpersonationPro
 		}
 	}
 
+	// Each additional external name must be an IP address or a DNS name, which may be a wildcard.
+	for _, name := range spec.AdditionalExternalNames {
+		if net.ParseIP(name) != nil {
+			continue
+		}
+		errs := validation.IsDNS1123Subdomain(strings.TrimPrefix(name, "*."))
+		if len(errs) > 0 {
+			return fmt.Errorf("invalid AdditionalExternalNames entry %q: %s", name, strings.Join(errs, ", "))
+		}
+	}
+
 	return nil
 }
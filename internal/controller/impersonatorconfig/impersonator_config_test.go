@@ -44,6 +44,7 @@ import (
 	"go.pinniped.dev/internal/controllerlib"
 	"go.pinniped.dev/internal/dynamiccert"
 	"go.pinniped.dev/internal/kubeclient"
+	"go.pinniped.dev/internal/leaderelection"
 	"go.pinniped.dev/internal/plog"
 	"go.pinniped.dev/internal/testutil"
 	"go.pinniped.dev/internal/tokenclient"
@@ -1234,6 +1235,97 @@ func TestImpersonatorConfigControllerSync(t *testing.T) {
 			})
 		})
 
+		when("the configuration has an endpoint and additional external names", func() {
+			const customHostname = "proxy.example.com"
+
+			it.Before(func() {
+				addSecretToTrackers(mTLSClientCertCASecret, kubeInformerClient)
+				addCredentialIssuerToTrackers(conciergeconfigv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: credentialIssuerResourceName},
+					Spec: conciergeconfigv1alpha1.CredentialIssuerSpec{
+						ImpersonationProxy: &conciergeconfigv1alpha1.ImpersonationProxySpec{
+							Mode:                    conciergeconfigv1alpha1.ImpersonationProxyModeEnabled,
+							ExternalEndpoint:        localhostIP,
+							AdditionalExternalNames: []string{customHostname, "127.0.0.43"},
+							Service: conciergeconfigv1alpha1.ImpersonationProxyServiceSpec{
+								Type: conciergeconfigv1alpha1.ImpersonationProxyServiceTypeNone,
+							},
+						},
+					},
+				}, pinnipedInformerClient, pinnipedAPIClient)
+				addNodeWithRoleToTracker("worker", kubeAPIClient)
+			})
+
+			it("starts the impersonator with certs that match the endpoint and each additional name", func() {
+				startInformersAndController()
+				r.NoError(runControllerSync())
+				r.Len(kubeAPIClient.Actions(), 3)
+				requireNodesListed(kubeAPIClient.Actions()[0])
+				ca := requireCASecretWasCreated(kubeAPIClient.Actions()[1])
+				requireTLSSecretWasCreated(kubeAPIClient.Actions()[2], ca)
+				requireTLSServerIsRunning(ca, testServerAddr(), nil)
+				requireTLSServerIsRunning(ca, customHostname, map[string]string{customHostname + httpsPort: testServerAddr()})
+				requireCredentialIssuer(newSuccessStrategy(localhostIP, ca))
+
+				createdSecret := kubeAPIClient.Actions()[2].(coretesting.CreateAction).GetObject().(*corev1.Secret)
+				block, _ := pem.Decode(createdSecret.Data[corev1.TLSCertKey])
+				r.NotNil(block)
+				cert, err := x509.ParseCertificate(block.Bytes)
+				r.NoError(err)
+				r.Equal([]string{customHostname}, cert.DNSNames)
+				r.Len(cert.IPAddresses, 2)
+				r.Equal(localhostIP, cert.IPAddresses[0].String())
+				r.Equal("127.0.0.43", cert.IPAddresses[1].String())
+
+				// Simulate the informer cache's background update from its watch.
+				addObjectFromCreateActionToInformerAndWait(kubeAPIClient.Actions()[1], kubeInformers.Core().V1().Secrets())
+				addObjectFromCreateActionToInformerAndWait(kubeAPIClient.Actions()[2], kubeInformers.Core().V1().Secrets())
+
+				// keeps the secret around after resync
+				r.NoError(runControllerSync())
+				r.Len(kubeAPIClient.Actions(), 3) // nothing changed
+				requireCredentialIssuer(newSuccessStrategy(localhostIP, ca))
+			})
+		})
+
+		when("the configuration refers to an existing load balancer Service", func() {
+			const (
+				existingServiceName = "some-existing-service"
+				hostname            = "existing.example.com"
+			)
+
+			it.Before(func() {
+				addSecretToTrackers(mTLSClientCertCASecret, kubeInformerClient)
+				addCredentialIssuerToTrackers(conciergeconfigv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: credentialIssuerResourceName},
+					Spec: conciergeconfigv1alpha1.CredentialIssuerSpec{
+						ImpersonationProxy: &conciergeconfigv1alpha1.ImpersonationProxySpec{
+							Mode: conciergeconfigv1alpha1.ImpersonationProxyModeEnabled,
+							Service: conciergeconfigv1alpha1.ImpersonationProxyServiceSpec{
+								Type: conciergeconfigv1alpha1.ImpersonationProxyServiceTypeLoadBalancer,
+								Name: existingServiceName,
+							},
+						},
+					},
+				}, pinnipedInformerClient, pinnipedAPIClient)
+				addNodeWithRoleToTracker("worker", kubeAPIClient)
+				addLoadBalancerServiceWithIngressToTracker(existingServiceName, []corev1.LoadBalancerIngress{{Hostname: hostname}}, kubeInformerClient)
+				addLoadBalancerServiceWithIngressToTracker(existingServiceName, []corev1.LoadBalancerIngress{{Hostname: hostname}}, kubeAPIClient)
+			})
+
+			it("does not provision a Service and starts the impersonator with certs that match the existing Service", func() {
+				startInformersAndController()
+				r.NoError(runControllerSync())
+				r.Len(kubeAPIClient.Actions(), 3)
+				requireNodesListed(kubeAPIClient.Actions()[0])
+				ca := requireCASecretWasCreated(kubeAPIClient.Actions()[1])
+				requireTLSSecretWasCreated(kubeAPIClient.Actions()[2], ca)
+				requireTLSServerIsRunning(ca, hostname, map[string]string{hostname + httpsPort: testServerAddr()})
+				requireCredentialIssuer(newSuccessStrategy(hostname, ca))
+				requireMTLSClientCertProviderHasLoadedCerts(mTLSClientCertCACertPEM, mTLSClientCertCAPrivateKeyPEM)
+			})
+		})
+
 		when("using external TLS secrets", func() {
 			when("the configuration is auto mode with an endpoint and service type none", func() {
 				it.Before(func() {
@@ -1733,6 +1825,34 @@ func TestImpersonatorConfigControllerSync(t *testing.T) {
 				})
 			})
 
+			when("the cert's name needs to change but this pod is not the leader", func() {
+				var caCrt []byte
+				it.Before(func() {
+					addNodeWithRoleToTracker("worker", kubeAPIClient)
+					addLoadBalancerServiceWithIngressToTracker(loadBalancerServiceName, []corev1.LoadBalancerIngress{{IP: "127.0.0.42"}}, kubeInformerClient)
+					addLoadBalancerServiceWithIngressToTracker(loadBalancerServiceName, []corev1.LoadBalancerIngress{{IP: "127.0.0.42"}}, kubeAPIClient)
+					ca := newCA()
+					caSecret := newActualCASecret(ca, internallyGeneratedTLSServingCASecretName)
+					caCrt = caSecret.Data["ca.crt"]
+					addSecretToTrackers(caSecret, kubeAPIClient, kubeInformerClient)
+					addSecretToTrackers(newActualTLSSecret(ca, internallyGeneratedTLSServingCertSecretName, localhostIP), kubeAPIClient, kubeInformerClient)
+					kubeAPIClient.PrependReactor("delete", "secrets", func(action coretesting.Action) (handled bool, ret runtime.Object, err error) {
+						return true, nil, leaderelection.ErrNotLeader
+					})
+				})
+
+				it("keeps serving the certificate from the existing Secret until the leader replaces it", func() {
+					startInformersAndController()
+					r.NoError(runControllerSync())
+					r.Len(kubeAPIClient.Actions(), 2)
+					requireNodesListed(kubeAPIClient.Actions()[0])
+					requireTLSSecretWasDeleted(kubeAPIClient.Actions()[1])
+					requireTLSServerIsRunning(caCrt, testServerAddr(), nil)
+					requireCredentialIssuer(newSuccessStrategy("127.0.0.42", caCrt))
+					requireMTLSClientCertProviderHasLoadedCerts(mTLSClientCertCACertPEM, mTLSClientCertCAPrivateKeyPEM)
+				})
+			})
+
 			when("the cert's name might need to change but there is an error while determining the new name", func() {
 				var caCrt []byte
 				it.Before(func() {
@@ -3522,6 +3642,57 @@ func TestImpersonatorConfigControllerSync(t *testing.T) {
 			})
 		})
 
+		when("the CredentialIssuer has a service name and service type None", func() {
+			it.Before(func() {
+				addCredentialIssuerToTrackers(conciergeconfigv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: credentialIssuerResourceName},
+					Spec: conciergeconfigv1alpha1.CredentialIssuerSpec{
+						ImpersonationProxy: &conciergeconfigv1alpha1.ImpersonationProxySpec{
+							Mode:             conciergeconfigv1alpha1.ImpersonationProxyModeEnabled,
+							ExternalEndpoint: localhostIP,
+							Service: conciergeconfigv1alpha1.ImpersonationProxyServiceSpec{
+								Type: conciergeconfigv1alpha1.ImpersonationProxyServiceTypeNone,
+								Name: "some-service",
+							},
+						},
+					},
+				}, pinnipedInformerClient, pinnipedAPIClient)
+			})
+
+			it("returns an error", func() {
+				startInformersAndController()
+				errString := `could not load CredentialIssuer spec.impersonationProxy: service.name must not be set when service.type is None`
+				r.EqualError(runControllerSync(), errString)
+				requireCredentialIssuer(newErrorStrategy(errString))
+				requireMTLSClientCertProviderIsEmpty()
+				requireTLSServerWasNeverStarted()
+			})
+		})
+
+		when("the CredentialIssuer has invalid AdditionalExternalNames", func() {
+			it.Before(func() {
+				addCredentialIssuerToTrackers(conciergeconfigv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: credentialIssuerResourceName},
+					Spec: conciergeconfigv1alpha1.CredentialIssuerSpec{
+						ImpersonationProxy: &conciergeconfigv1alpha1.ImpersonationProxySpec{
+							Mode:                    conciergeconfigv1alpha1.ImpersonationProxyModeEnabled,
+							AdditionalExternalNames: []string{"proxy.example.com", "not_valid"},
+						},
+					},
+				}, pinnipedInformerClient, pinnipedAPIClient)
+			})
+
+			it("returns an error", func() {
+				startInformersAndController()
+				errString := `could not load CredentialIssuer spec.impersonationProxy: invalid AdditionalExternalNames entry "not_valid": ` +
+					`a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')`
+				r.EqualError(runControllerSync(), errString)
+				requireCredentialIssuer(newErrorStrategy(errString))
+				requireMTLSClientCertProviderIsEmpty()
+				requireTLSServerWasNeverStarted()
+			})
+		})
+
 		when("the CredentialIssuer has invalid ExternalEndpoint", func() {
 			it.Before(func() {
 				addCredentialIssuerToTrackers(conciergeconfigv1alpha1.CredentialIssuer{
@@ -3721,6 +3892,41 @@ func TestImpersonatorConfigControllerSync(t *testing.T) {
 			})
 		})
 
+		when("the tls secret does not exist yet and this pod is not the leader", func() {
+			var caCrt []byte
+			it.Before(func() {
+				addCredentialIssuerToTrackers(conciergeconfigv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: credentialIssuerResourceName},
+					Spec: conciergeconfigv1alpha1.CredentialIssuerSpec{
+						ImpersonationProxy: &conciergeconfigv1alpha1.ImpersonationProxySpec{
+							Mode:             conciergeconfigv1alpha1.ImpersonationProxyModeEnabled,
+							ExternalEndpoint: "example.com",
+							Service: conciergeconfigv1alpha1.ImpersonationProxyServiceSpec{
+								Type: conciergeconfigv1alpha1.ImpersonationProxyServiceTypeNone,
+							},
+						},
+					},
+				}, pinnipedInformerClient, pinnipedAPIClient)
+				addNodeWithRoleToTracker("control-plane", kubeAPIClient)
+				caSecret := newActualCASecret(newCA(), internallyGeneratedTLSServingCASecretName)
+				caCrt = caSecret.Data["ca.crt"]
+				addSecretToTrackers(caSecret, kubeAPIClient, kubeInformerClient)
+				kubeAPIClient.PrependReactor("create", "secrets", func(action coretesting.Action) (handled bool, ret runtime.Object, err error) {
+					return true, nil, leaderelection.ErrNotLeader
+				})
+			})
+
+			it("starts the impersonator without certs and reports that it is pending", func() {
+				startInformersAndController()
+				r.EqualError(runControllerSync(), "write attempt rejected as client is not leader")
+				requireCredentialIssuer(newPendingStrategy("write attempt rejected as client is not leader"))
+				requireTLSServerIsRunningWithoutCerts()
+				r.Len(kubeAPIClient.Actions(), 2)
+				requireNodesListed(kubeAPIClient.Actions()[0])
+				requireTLSSecretWasCreated(kubeAPIClient.Actions()[1], caCrt)
+			})
+		})
+
 		when("there is an error creating the CA secret", func() {
 			it.Before(func() {
 				addCredentialIssuerToTrackers(conciergeconfigv1alpha1.CredentialIssuer{
@@ -16,11 +16,13 @@ import (
 	conciergeclientset "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned"
 )
 
-// Update a strategy on an existing CredentialIssuer, merging into any existing strategy entries.
-func Update(ctx context.Context, client conciergeclientset.Interface, issuer *conciergeconfigv1alpha1.CredentialIssuer, strategy conciergeconfigv1alpha1.CredentialIssuerStrategy) error {
-	// Update the existing object to merge in the new strategy.
+// Update strategies on an existing CredentialIssuer, merging into any existing strategy entries.
+func Update(ctx context.Context, client conciergeclientset.Interface, issuer *conciergeconfigv1alpha1.CredentialIssuer, strategies ...conciergeconfigv1alpha1.CredentialIssuerStrategy) error {
+	// Update the existing object to merge in the new strategies.
 	updated := issuer.DeepCopy()
-	mergeStrategy(&updated.Status, strategy)
+	for _, strategy := range strategies {
+		mergeStrategy(&updated.Status, strategy)
+	}
 
 	// If the status has not changed, we're done.
 	if apiequality.Semantic.DeepEqual(issuer.Status, updated.Status) {
@@ -61,7 +63,8 @@ func mergeStrategy(configToUpdate *conciergeconfigv1alpha1.CredentialIssuerStatu
 
 // weights are a set of priorities for each strategy type.
 var weights = map[conciergeconfigv1alpha1.StrategyType]int{ //nolint:gochecknoglobals
-	conciergeconfigv1alpha1.KubeClusterSigningCertificateStrategyType: 2, // most preferred strategy
+	conciergeconfigv1alpha1.CertificateSignerStrategyType:             3, // most preferred strategy, since it was explicitly configured
+	conciergeconfigv1alpha1.KubeClusterSigningCertificateStrategyType: 2,
 	conciergeconfigv1alpha1.ImpersonationProxyStrategyType:            1,
 	// unknown strategy types will have weight 0 by default
 }
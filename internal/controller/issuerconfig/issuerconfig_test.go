@@ -223,6 +223,7 @@ func TestMergeStrategy(t *testing.T) {
 
 func TestStrategySorting(t *testing.T) {
 	expected := []conciergeconfigv1alpha1.CredentialIssuerStrategy{
+		{Type: conciergeconfigv1alpha1.CertificateSignerStrategyType},
 		{Type: conciergeconfigv1alpha1.KubeClusterSigningCertificateStrategyType},
 		{Type: conciergeconfigv1alpha1.ImpersonationProxyStrategyType},
 		{Type: "Type1"},
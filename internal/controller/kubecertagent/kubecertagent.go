@@ -13,6 +13,7 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/spf13/pflag"
@@ -36,6 +37,7 @@ import (
 	configv1alpha1informers "go.pinniped.dev/generated/latest/client/concierge/informers/externalversions/config/v1alpha1"
 	pinnipedcontroller "go.pinniped.dev/internal/controller"
 	"go.pinniped.dev/internal/controller/issuerconfig"
+	"go.pinniped.dev/internal/controller/tokencredentialrequestconfig"
 	"go.pinniped.dev/internal/controllerlib"
 	"go.pinniped.dev/internal/dynamiccert"
 	"go.pinniped.dev/internal/kubeclient"
@@ -72,6 +74,10 @@ const (
 	clusterInfoConfigMapKey = "kubeconfig"
 
 	agentPodContainerName = "sleeper"
+
+	// defaultCertPathTemplate and defaultKeyPathTemplate find the signing keypair of kubeadm-style clusters.
+	defaultCertPathTemplate = `{{ flag "cluster-signing-cert-file" "/etc/kubernetes/ca/ca.pem" }}`
+	defaultKeyPathTemplate  = `{{ flag "cluster-signing-key-file" "/etc/kubernetes/ca/ca.key" }}`
 )
 
 // AgentConfig is the configuration for the kube-cert-agent controller.
@@ -101,6 +107,46 @@ type AgentConfig struct {
 	// DiscoveryURLOverride is the Kubernetes server endpoint to report in the CredentialIssuer, overriding any
 	// value discovered in the kube-public/cluster-info ConfigMap.
 	DiscoveryURLOverride *string
+
+	// ControlPlane describes where to find the signing keypair of the cluster. The zero value finds the
+	// kube-controller-manager pods of kubeadm-style clusters.
+	ControlPlane ControlPlaneConfig
+}
+
+// ControlPlaneConfig describes the control plane pods which run on the nodes that hold the signing keypair of the
+// cluster, and where the keypair is on those nodes. The agent pod is scheduled next to the newest of these pods.
+type ControlPlaneConfig struct {
+	// Namespace of the control plane pods. The default is ControllerManagerNamespace.
+	Namespace string
+
+	// PodLabels select the control plane pods in the Namespace. The default selects kube-controller-manager pods.
+	PodLabels map[string]string
+
+	// CertPathTemplate and KeyPathTemplate are text/template templates which render the paths of the signing
+	// certificate and key. They are executed with the selected control plane pod as their data, and they may call
+	// flag "name" "fallback" to read a command-line flag of the pod's containers. By default, they read the
+	// --cluster-signing-cert-file and --cluster-signing-key-file flags of kube-controller-manager.
+	CertPathTemplate string
+	KeyPathTemplate  string
+
+	// HostPathVolumes are directories of the node which are mounted read-only into the agent pod at the same
+	// paths. When empty, the agent pod uses the volumes and volume mounts of the selected control plane pod.
+	HostPathVolumes []string
+}
+
+// ControlPlaneNamespace returns the namespace in which to look for control plane pods.
+func (a *AgentConfig) ControlPlaneNamespace() string {
+	if a.ControlPlane.Namespace == "" {
+		return ControllerManagerNamespace
+	}
+	return a.ControlPlane.Namespace
+}
+
+func (a *AgentConfig) controlPlanePodSelector() labels.Selector {
+	if len(a.ControlPlane.PodLabels) == 0 {
+		return controllerManagerLabels
+	}
+	return labels.SelectorFromSet(a.ControlPlane.PodLabels)
 }
 
 // Only select using the unique label which will not match the pods of any other Deployment.
@@ -147,7 +193,7 @@ type agentController struct {
 }
 
 var (
-	// controllerManagerLabels are the Kubernetes labels we expect on the kube-controller-manager Pod.
+	// controllerManagerLabels are the Kubernetes labels we expect on the kube-controller-manager Pod by default.
 	controllerManagerLabels = labels.SelectorFromSet(map[string]string{ //nolint:gochecknoglobals
 		"component": "kube-controller-manager",
 	})
@@ -221,7 +267,8 @@ func newAgentController(
 		controllerlib.WithInformer(
 			kubeSystemPods,
 			pinnipedcontroller.SimpleFilterWithSingletonQueue(func(obj metav1.Object) bool {
-				return controllerManagerLabels.Matches(labels.Set(obj.GetLabels()))
+				return obj.GetNamespace() == cfg.ControlPlaneNamespace() &&
+					cfg.controlPlanePodSelector().Matches(labels.Set(obj.GetLabels()))
 			}),
 			controllerlib.InformerOption{},
 		),
@@ -268,8 +315,14 @@ func (c *agentController) Sync(ctx controllerlib.Context) error {
 		return fmt.Errorf("could not get CredentialIssuer to update: %w", err)
 	}
 
-	// Find the latest healthy kube-controller-manager Pod in kube-system.
-	controllerManagerPods, err := c.kubeSystemPods.Lister().Pods(ControllerManagerNamespace).List(controllerManagerLabels)
+	// When another signer is configured, the TokenCredentialRequest API does not need the cluster signing keypair,
+	// so there is no need for the kube-cert-agent.
+	if signerType := tokencredentialrequestconfig.SignerType(credIssuer); signerType != conciergeconfigv1alpha1.TokenCredentialRequestAPISignerTypeKubeCertAgent {
+		return c.reportSigner(ctx.Context, credIssuer, signerType)
+	}
+
+	// Find the latest healthy kube-controller-manager Pod (or other configured control plane Pod).
+	controllerManagerPods, err := c.kubeSystemPods.Lister().Pods(c.cfg.ControlPlaneNamespace()).List(c.cfg.controlPlanePodSelector())
 	if err != nil {
 		err := fmt.Errorf("could not list controller manager pods: %w", err)
 		return c.failStrategyAndErr(ctx.Context, credIssuer, err, conciergeconfigv1alpha1.CouldNotFetchKeyStrategyReason)
@@ -313,15 +366,8 @@ func (c *agentController) Sync(ctx controllerlib.Context) error {
 	}
 
 	// Load the Kubernetes API info from the kube-public/cluster-info ConfigMap.
-	configMap, err := c.kubePublicConfigMaps.Lister().ConfigMaps(ClusterInfoNamespace).Get(clusterInfoName)
-	if err != nil {
-		err := fmt.Errorf("failed to get %s/%s configmap: %w", ClusterInfoNamespace, clusterInfoName, err)
-		return c.failStrategyAndErr(ctx.Context, credIssuer, firstErr(depErr, err), conciergeconfigv1alpha1.CouldNotGetClusterInfoStrategyReason)
-	}
-
-	apiInfo, err := c.extractAPIInfo(configMap)
+	apiInfo, err := c.loadAPIInfo()
 	if err != nil {
-		err := fmt.Errorf("could not extract Kubernetes API endpoint info from %s/%s configmap: %w", ClusterInfoNamespace, clusterInfoName, err)
 		return c.failStrategyAndErr(ctx.Context, credIssuer, firstErr(depErr, err), conciergeconfigv1alpha1.CouldNotGetClusterInfoStrategyReason)
 	}
 
@@ -337,7 +383,7 @@ func (c *agentController) Sync(ctx controllerlib.Context) error {
 	}
 
 	// Set the CredentialIssuer strategy to successful.
-	return issuerconfig.Update(ctx.Context, c.client.PinnipedConcierge, credIssuer, conciergeconfigv1alpha1.CredentialIssuerStrategy{
+	return issuerconfig.Update(ctx.Context, c.client.PinnipedConcierge, credIssuer, c.withDisabledSignerStrategy(credIssuer, conciergeconfigv1alpha1.CredentialIssuerStrategy{
 		Type:           conciergeconfigv1alpha1.KubeClusterSigningCertificateStrategyType,
 		Status:         conciergeconfigv1alpha1.SuccessStrategyStatus,
 		Reason:         conciergeconfigv1alpha1.FetchedKeyStrategyReason,
@@ -347,7 +393,83 @@ func (c *agentController) Sync(ctx controllerlib.Context) error {
 			Type:                          conciergeconfigv1alpha1.TokenCredentialRequestAPIFrontendType,
 			TokenCredentialRequestAPIInfo: apiInfo,
 		},
-	})
+	})...)
+}
+
+// reportSigner updates the CredentialIssuer when client certificates are signed by a signer other than the
+// kube-cert-agent. Clients still use the TokenCredentialRequest API of the cluster, so they need its API info.
+func (c *agentController) reportSigner(
+	ctx context.Context,
+	credIssuer *conciergeconfigv1alpha1.CredentialIssuer,
+	signerType conciergeconfigv1alpha1.TokenCredentialRequestAPISignerType,
+) error {
+	disabled := conciergeconfigv1alpha1.CredentialIssuerStrategy{
+		Type:           conciergeconfigv1alpha1.KubeClusterSigningCertificateStrategyType,
+		Status:         conciergeconfigv1alpha1.ErrorStrategyStatus,
+		Reason:         conciergeconfigv1alpha1.DisabledStrategyReason,
+		Message:        fmt.Sprintf("the kube-cert-agent is disabled because client certificates are signed by the %s signer", signerType),
+		LastUpdateTime: metav1.NewTime(c.clock.Now()),
+	}
+
+	apiInfo, err := c.loadAPIInfo()
+	if err != nil {
+		updateErr := issuerconfig.Update(ctx, c.client.PinnipedConcierge, credIssuer, conciergeconfigv1alpha1.CredentialIssuerStrategy{
+			Type:           conciergeconfigv1alpha1.CertificateSignerStrategyType,
+			Status:         conciergeconfigv1alpha1.ErrorStrategyStatus,
+			Reason:         conciergeconfigv1alpha1.CouldNotGetClusterInfoStrategyReason,
+			Message:        err.Error(),
+			LastUpdateTime: metav1.NewTime(c.clock.Now()),
+		}, disabled)
+		return utilerrors.NewAggregate([]error{err, updateErr})
+	}
+
+	return issuerconfig.Update(ctx, c.client.PinnipedConcierge, credIssuer, conciergeconfigv1alpha1.CredentialIssuerStrategy{
+		Type:           conciergeconfigv1alpha1.CertificateSignerStrategyType,
+		Status:         conciergeconfigv1alpha1.SuccessStrategyStatus,
+		Reason:         conciergeconfigv1alpha1.SignerConfiguredStrategyReason,
+		Message:        fmt.Sprintf("client certificates are signed by the %s signer", signerType),
+		LastUpdateTime: metav1.NewTime(c.clock.Now()),
+		Frontend: &conciergeconfigv1alpha1.CredentialIssuerFrontend{
+			Type:                          conciergeconfigv1alpha1.TokenCredentialRequestAPIFrontendType,
+			TokenCredentialRequestAPIInfo: apiInfo,
+		},
+	}, disabled)
+}
+
+// withDisabledSignerStrategy adds a disabled CertificateSigner strategy to the given strategy when the CredentialIssuer
+// still reports one from when another signer was configured.
+func (c *agentController) withDisabledSignerStrategy(
+	credIssuer *conciergeconfigv1alpha1.CredentialIssuer,
+	strategy conciergeconfigv1alpha1.CredentialIssuerStrategy,
+) []conciergeconfigv1alpha1.CredentialIssuerStrategy {
+	strategies := []conciergeconfigv1alpha1.CredentialIssuerStrategy{strategy}
+	for _, existing := range credIssuer.Status.Strategies {
+		if existing.Type == conciergeconfigv1alpha1.CertificateSignerStrategyType {
+			strategies = append(strategies, conciergeconfigv1alpha1.CredentialIssuerStrategy{
+				Type:           conciergeconfigv1alpha1.CertificateSignerStrategyType,
+				Status:         conciergeconfigv1alpha1.ErrorStrategyStatus,
+				Reason:         conciergeconfigv1alpha1.DisabledStrategyReason,
+				Message:        "client certificates are signed by the kube-cert-agent",
+				LastUpdateTime: metav1.NewTime(c.clock.Now()),
+			})
+			break
+		}
+	}
+	return strategies
+}
+
+// loadAPIInfo loads the Kubernetes API info from the kube-public/cluster-info ConfigMap.
+func (c *agentController) loadAPIInfo() (*conciergeconfigv1alpha1.TokenCredentialRequestAPIInfo, error) {
+	configMap, err := c.kubePublicConfigMaps.Lister().ConfigMaps(ClusterInfoNamespace).Get(clusterInfoName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s/%s configmap: %w", ClusterInfoNamespace, clusterInfoName, err)
+	}
+
+	apiInfo, err := c.extractAPIInfo(configMap)
+	if err != nil {
+		return nil, fmt.Errorf("could not extract Kubernetes API endpoint info from %s/%s configmap: %w", ClusterInfoNamespace, clusterInfoName, err)
+	}
+	return apiInfo, nil
 }
 
 func (c *agentController) loadSigningKey(ctx context.Context, agentPod *corev1.Pod) error {
@@ -392,7 +514,10 @@ func (c *agentController) loadSigningKey(ctx context.Context, agentPod *corev1.P
 
 func (c *agentController) createOrUpdateDeployment(ctx controllerlib.Context, newestControllerManager *corev1.Pod) error {
 	// Build the expected Deployment based on the kube-controller-manager Pod as a template.
-	expectedDeployment := c.newAgentDeployment(newestControllerManager)
+	expectedDeployment, err := c.newAgentDeployment(newestControllerManager)
+	if err != nil {
+		return err
+	}
 
 	// Try to get the existing Deployment, if it exists.
 	existingDeployment, err := c.agentDeployments.Lister().Deployments(expectedDeployment.Namespace).Get(expectedDeployment.Name)
@@ -455,13 +580,13 @@ func (c *agentController) createOrUpdateDeployment(ctx controllerlib.Context, ne
 }
 
 func (c *agentController) failStrategyAndErr(ctx context.Context, credIssuer *conciergeconfigv1alpha1.CredentialIssuer, err error, reason conciergeconfigv1alpha1.StrategyReason) error {
-	updateErr := issuerconfig.Update(ctx, c.client.PinnipedConcierge, credIssuer, conciergeconfigv1alpha1.CredentialIssuerStrategy{
+	updateErr := issuerconfig.Update(ctx, c.client.PinnipedConcierge, credIssuer, c.withDisabledSignerStrategy(credIssuer, conciergeconfigv1alpha1.CredentialIssuerStrategy{
 		Type:           conciergeconfigv1alpha1.KubeClusterSigningCertificateStrategyType,
 		Status:         conciergeconfigv1alpha1.ErrorStrategyStatus,
 		Reason:         reason,
 		Message:        err.Error(),
 		LastUpdateTime: metav1.NewTime(c.clock.Now()),
-	})
+	})...)
 	return utilerrors.NewAggregate([]error{err, updateErr})
 }
 
@@ -509,11 +634,33 @@ func newestRunningPod(pods []*corev1.Pod) *corev1.Pod {
 	return result
 }
 
-func (c *agentController) newAgentDeployment(controllerManagerPod *corev1.Pod) *appsv1.Deployment {
+func (c *agentController) newAgentDeployment(controllerManagerPod *corev1.Pod) (*appsv1.Deployment, error) {
+	certPath, err := renderPathTemplate(c.cfg.ControlPlane.CertPathTemplate, defaultCertPathTemplate, controllerManagerPod)
+	if err != nil {
+		return nil, fmt.Errorf("could not render certificate path template: %w", err)
+	}
+	keyPath, err := renderPathTemplate(c.cfg.ControlPlane.KeyPathTemplate, defaultKeyPathTemplate, controllerManagerPod)
+	if err != nil {
+		return nil, fmt.Errorf("could not render key path template: %w", err)
+	}
+
+	volumes := controllerManagerPod.Spec.Volumes
 	var volumeMounts []corev1.VolumeMount
 	if len(controllerManagerPod.Spec.Containers) > 0 {
 		volumeMounts = controllerManagerPod.Spec.Containers[0].VolumeMounts
 	}
+	if len(c.cfg.ControlPlane.HostPathVolumes) > 0 {
+		volumes = make([]corev1.Volume, 0, len(c.cfg.ControlPlane.HostPathVolumes))
+		volumeMounts = make([]corev1.VolumeMount, 0, len(c.cfg.ControlPlane.HostPathVolumes))
+		for i, path := range c.cfg.ControlPlane.HostPathVolumes {
+			name := fmt.Sprintf("control-plane-%d", i)
+			volumes = append(volumes, corev1.Volume{
+				Name:         name,
+				VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: path}},
+			})
+			volumeMounts = append(volumeMounts, corev1.VolumeMount{Name: name, MountPath: path, ReadOnly: true})
+		}
+	}
 
 	var imagePullSecrets []corev1.LocalObjectReference
 	if len(c.cfg.ContainerImagePullSecrets) > 0 {
@@ -547,8 +694,8 @@ func (c *agentController) newAgentDeployment(controllerManagerPod *corev1.Pod) *
 							Command:         []string{"pinniped-concierge-kube-cert-agent", "sleep"},
 							VolumeMounts:    volumeMounts,
 							Env: []corev1.EnvVar{
-								{Name: "CERT_PATH", Value: getContainerArgByName(controllerManagerPod, "cluster-signing-cert-file", "/etc/kubernetes/ca/ca.pem")},
-								{Name: "KEY_PATH", Value: getContainerArgByName(controllerManagerPod, "cluster-signing-key-file", "/etc/kubernetes/ca/ca.key")},
+								{Name: "CERT_PATH", Value: certPath},
+								{Name: "KEY_PATH", Value: keyPath},
 							},
 							Resources: corev1.ResourceRequirements{
 								Limits: corev1.ResourceList{
@@ -563,7 +710,7 @@ func (c *agentController) newAgentDeployment(controllerManagerPod *corev1.Pod) *
 							},
 						},
 					},
-					Volumes:                      controllerManagerPod.Spec.Volumes,
+					Volumes:                      volumes,
 					RestartPolicy:                corev1.RestartPolicyAlways,
 					NodeSelector:                 controllerManagerPod.Spec.NodeSelector,
 					AutomountServiceAccountToken: ptr.To(false),
@@ -583,7 +730,39 @@ func (c *agentController) newAgentDeployment(controllerManagerPod *corev1.Pod) *
 			// Setting MinReadySeconds prevents the agent pods from being churned too quickly by the deployments controller.
 			MinReadySeconds: 10,
 		},
+	}, nil
+}
+
+// ParsePathTemplate parses a ControlPlaneConfig.CertPathTemplate or ControlPlaneConfig.KeyPathTemplate.
+func ParsePathTemplate(text string) (*template.Template, error) {
+	return template.New("path").Option("missingkey=error").Funcs(template.FuncMap{
+		// This is replaced by a function which reads the flags of the control plane pod in renderPathTemplate.
+		"flag": func(_, fallback string) string { return fallback },
+	}).Parse(text)
+}
+
+func renderPathTemplate(text, defaultText string, pod *corev1.Pod) (string, error) {
+	if text == "" {
+		text = defaultText
+	}
+	tmpl, err := ParsePathTemplate(text)
+	if err != nil {
+		return "", err
+	}
+
+	var rendered strings.Builder
+	err = tmpl.Funcs(template.FuncMap{
+		"flag": func(name, fallback string) string { return getContainerArgByName(pod, name, fallback) },
+	}).Execute(&rendered, pod)
+	if err != nil {
+		return "", err
+	}
+
+	path := strings.TrimSpace(rendered.String())
+	if path == "" {
+		return "", fmt.Errorf("template %q rendered an empty path", text)
 	}
+	return path, nil
 }
 
 func mergeLabelsAndAnnotations(existing metav1.ObjectMeta, desired metav1.ObjectMeta) metav1.ObjectMeta {
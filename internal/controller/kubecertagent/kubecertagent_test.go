@@ -50,6 +50,23 @@ func TestAgentController(t *testing.T) {
 		ObjectMeta: metav1.ObjectMeta{Name: "pinniped-concierge-config"},
 	}
 
+	csrSignerCredentialIssuer := initialCredentialIssuer.DeepCopy()
+	csrSignerCredentialIssuer.Spec.TokenCredentialRequestAPI = &conciergeconfigv1alpha1.TokenCredentialRequestAPISpec{
+		Signer: &conciergeconfigv1alpha1.TokenCredentialRequestAPISignerSpec{
+			Type:                      conciergeconfigv1alpha1.TokenCredentialRequestAPISignerTypeCertificateSigningRequest,
+			CertificateSigningRequest: &conciergeconfigv1alpha1.CertificateSigningRequestSignerSpec{SignerName: "kubernetes.io/kube-apiserver-client"},
+		},
+	}
+
+	previouslyCSRSignerCredentialIssuer := initialCredentialIssuer.DeepCopy()
+	previouslyCSRSignerCredentialIssuer.Status.Strategies = []conciergeconfigv1alpha1.CredentialIssuerStrategy{{
+		Type:           conciergeconfigv1alpha1.CertificateSignerStrategyType,
+		Status:         conciergeconfigv1alpha1.SuccessStrategyStatus,
+		Reason:         conciergeconfigv1alpha1.SignerConfiguredStrategyReason,
+		Message:        "client certificates are signed by the CertificateSigningRequest signer",
+		LastUpdateTime: metav1.NewTime(now.Add(-1 * time.Hour)),
+	}}
+
 	healthyKubeControllerManagerPod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace:         "kube-system",
@@ -190,6 +207,42 @@ func TestAgentController(t *testing.T) {
 		{Name: "KEY_PATH", Value: "/etc/kubernetes/ca/ca.key"},
 	}
 
+	// Make a control plane pod which is not laid out like kubeadm, in another namespace and with other labels, along with
+	// the control plane config which finds it and the agent deployment which we expect for it.
+	customControlPlane := ControlPlaneConfig{
+		Namespace:        "some-control-plane-namespace",
+		PodLabels:        map[string]string{"k8s-app": "kube-controller-manager"},
+		CertPathTemplate: `{{ flag "cluster-signing-kube-apiserver-client-cert-file" "/var/lib/some/default-ca.crt" }}`,
+		KeyPathTemplate:  "/var/lib/some/client-ca.key",
+		HostPathVolumes:  []string{"/var/lib/some"},
+	}
+	healthyCustomControlPlanePod := healthyKubeControllerManagerPod.DeepCopy()
+	healthyCustomControlPlanePod.Namespace = "some-control-plane-namespace"
+	healthyCustomControlPlanePod.Name = "some-controller-manager"
+	healthyCustomControlPlanePod.Labels = map[string]string{"k8s-app": "kube-controller-manager"}
+	healthyCustomControlPlanePod.Spec.Containers[0].Command = []string{
+		"kube-controller-manager",
+		"--cluster-signing-kube-apiserver-client-cert-file=/var/lib/some/client-ca.crt",
+	}
+	healthyAgentDeploymentForCustomControlPlane := healthyAgentDeployment.DeepCopy()
+	healthyAgentDeploymentForCustomControlPlane.Spec.Template.Spec.Containers[0].Env = []corev1.EnvVar{
+		{Name: "CERT_PATH", Value: "/var/lib/some/client-ca.crt"},
+		{Name: "KEY_PATH", Value: "/var/lib/some/client-ca.key"},
+	}
+	healthyAgentDeploymentForCustomControlPlane.Spec.Template.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{{
+		Name:      "control-plane-0",
+		ReadOnly:  true,
+		MountPath: "/var/lib/some",
+	}}
+	healthyAgentDeploymentForCustomControlPlane.Spec.Template.Spec.Volumes = []corev1.Volume{{
+		Name: "control-plane-0",
+		VolumeSource: corev1.VolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{
+				Path: "/var/lib/some",
+			},
+		},
+	}}
+
 	// If an admission controller sets extra labels or annotations, that's okay.
 	// We test this by ensuring that if a Deployment exists with extra labels, we don't try to delete them.
 	healthyAgentDeploymentWithExtraLabels := healthyAgentDeployment.DeepCopy()
@@ -238,6 +291,7 @@ func TestAgentController(t *testing.T) {
 	tests := []struct {
 		name                             string
 		discoveryURLOverride             *string
+		controlPlane                     ControlPlaneConfig
 		pinnipedObjects                  []runtime.Object
 		kubeObjects                      []runtime.Object
 		addKubeReactions                 func(*kubefake.Clientset)
@@ -249,6 +303,7 @@ func TestAgentController(t *testing.T) {
 		wantDeploymentActionVerbs        []string
 		wantDeploymentDeleteActionOpts   []metav1.DeleteOptions
 		wantStrategy                     *conciergeconfigv1alpha1.CredentialIssuerStrategy
+		wantSignerStrategy               *conciergeconfigv1alpha1.CredentialIssuerStrategy
 	}{
 		{
 			name: "no CredentialIssuer found",
@@ -451,6 +506,60 @@ func TestAgentController(t *testing.T) {
 				LastUpdateTime: metav1.NewTime(now),
 			},
 		},
+		{
+			name: "created new deployment for a custom control plane, no agent pods running yet",
+			pinnipedObjects: []runtime.Object{
+				initialCredentialIssuer,
+			},
+			kubeObjects: []runtime.Object{
+				healthyKubeControllerManagerPod, // ignored because it does not match the custom control plane
+				healthyCustomControlPlanePod,
+				pendingAgentPod,
+			},
+			controlPlane: customControlPlane,
+			wantDistinctErrors: []string{
+				"could not find a healthy agent pod (1 candidate)",
+			},
+			alsoAllowUndesiredDistinctErrors: []string{
+				// due to the high amount of nondeterminism in this test, this error will sometimes also happen, but is not required to happen
+				`could not ensure agent deployment: deployments.apps "pinniped-concierge-kube-cert-agent" already exists`,
+			},
+			wantDistinctLogs: []string{
+				`{"level":"info","timestamp":"2099-08-08T13:57:36.123456Z","logger":"kube-cert-agent-controller","caller":"kubecertagent/kubecertagent.go:<line>$kubecertagent.(*agentController).createOrUpdateDeployment","message":"creating new deployment","deployment":{"name":"pinniped-concierge-kube-cert-agent","namespace":"concierge"},"templatePod":{"name":"some-controller-manager","namespace":"some-control-plane-namespace"}}`,
+			},
+			wantAgentDeployment:       healthyAgentDeploymentForCustomControlPlane,
+			wantDeploymentActionVerbs: []string{"list", "watch", "create"},
+			wantStrategy: &conciergeconfigv1alpha1.CredentialIssuerStrategy{
+				Type:           conciergeconfigv1alpha1.KubeClusterSigningCertificateStrategyType,
+				Status:         conciergeconfigv1alpha1.ErrorStrategyStatus,
+				Reason:         conciergeconfigv1alpha1.CouldNotFetchKeyStrategyReason,
+				Message:        "could not find a healthy agent pod (1 candidate)",
+				LastUpdateTime: metav1.NewTime(now),
+			},
+		},
+		{
+			name: "custom control plane path template renders an empty path",
+			pinnipedObjects: []runtime.Object{
+				initialCredentialIssuer,
+			},
+			kubeObjects: []runtime.Object{
+				healthyKubeControllerManagerPod,
+			},
+			controlPlane: ControlPlaneConfig{
+				CertPathTemplate: `{{ flag "no-such-flag" "" }}`,
+			},
+			wantDistinctErrors: []string{
+				`could not ensure agent deployment: could not render certificate path template: template "{{ flag \"no-such-flag\" \"\" }}" rendered an empty path`,
+			},
+			wantDeploymentActionVerbs: []string{"list", "watch"},
+			wantStrategy: &conciergeconfigv1alpha1.CredentialIssuerStrategy{
+				Type:           conciergeconfigv1alpha1.KubeClusterSigningCertificateStrategyType,
+				Status:         conciergeconfigv1alpha1.ErrorStrategyStatus,
+				Reason:         conciergeconfigv1alpha1.CouldNotFetchKeyStrategyReason,
+				Message:        `could not ensure agent deployment: could not render certificate path template: template "{{ flag \"no-such-flag\" \"\" }}" rendered an empty path`,
+				LastUpdateTime: metav1.NewTime(now),
+			},
+		},
 		{
 			name: "to support upgrade from old versions, update to immutable selector field of existing deployment causes delete and recreate, no running agent pods yet",
 			pinnipedObjects: []runtime.Object{
@@ -1017,6 +1126,106 @@ func TestAgentController(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "CertificateSigningRequest signer is configured",
+			pinnipedObjects: []runtime.Object{
+				csrSignerCredentialIssuer,
+			},
+			kubeObjects: []runtime.Object{
+				healthyKubeControllerManagerPod,
+				validClusterInfoConfigMap,
+			},
+			wantDistinctErrors:        []string{""},
+			wantDeploymentActionVerbs: []string{"list", "watch"},
+			wantSignerStrategy: &conciergeconfigv1alpha1.CredentialIssuerStrategy{
+				Type:           conciergeconfigv1alpha1.CertificateSignerStrategyType,
+				Status:         conciergeconfigv1alpha1.SuccessStrategyStatus,
+				Reason:         conciergeconfigv1alpha1.SignerConfiguredStrategyReason,
+				Message:        "client certificates are signed by the CertificateSigningRequest signer",
+				LastUpdateTime: metav1.NewTime(now),
+				Frontend: &conciergeconfigv1alpha1.CredentialIssuerFrontend{
+					Type: conciergeconfigv1alpha1.TokenCredentialRequestAPIFrontendType,
+					TokenCredentialRequestAPIInfo: &conciergeconfigv1alpha1.TokenCredentialRequestAPIInfo{
+						Server:                   "https://test-kubernetes-endpoint.example.com",
+						CertificateAuthorityData: "dGVzdC1rdWJlcm5ldGVzLWNh",
+					},
+				},
+			},
+			wantStrategy: &conciergeconfigv1alpha1.CredentialIssuerStrategy{
+				Type:           conciergeconfigv1alpha1.KubeClusterSigningCertificateStrategyType,
+				Status:         conciergeconfigv1alpha1.ErrorStrategyStatus,
+				Reason:         conciergeconfigv1alpha1.DisabledStrategyReason,
+				Message:        "the kube-cert-agent is disabled because client certificates are signed by the CertificateSigningRequest signer",
+				LastUpdateTime: metav1.NewTime(now),
+			},
+		},
+		{
+			name: "CertificateSigningRequest signer is configured, no cluster-info configmap",
+			pinnipedObjects: []runtime.Object{
+				csrSignerCredentialIssuer,
+			},
+			kubeObjects: []runtime.Object{
+				healthyKubeControllerManagerPod,
+			},
+			wantDistinctErrors: []string{
+				"failed to get kube-public/cluster-info configmap: configmap \"cluster-info\" not found",
+			},
+			wantDeploymentActionVerbs: []string{"list", "watch"},
+			wantSignerStrategy: &conciergeconfigv1alpha1.CredentialIssuerStrategy{
+				Type:           conciergeconfigv1alpha1.CertificateSignerStrategyType,
+				Status:         conciergeconfigv1alpha1.ErrorStrategyStatus,
+				Reason:         conciergeconfigv1alpha1.CouldNotGetClusterInfoStrategyReason,
+				Message:        "failed to get kube-public/cluster-info configmap: configmap \"cluster-info\" not found",
+				LastUpdateTime: metav1.NewTime(now),
+			},
+			wantStrategy: &conciergeconfigv1alpha1.CredentialIssuerStrategy{
+				Type:           conciergeconfigv1alpha1.KubeClusterSigningCertificateStrategyType,
+				Status:         conciergeconfigv1alpha1.ErrorStrategyStatus,
+				Reason:         conciergeconfigv1alpha1.DisabledStrategyReason,
+				Message:        "the kube-cert-agent is disabled because client certificates are signed by the CertificateSigningRequest signer",
+				LastUpdateTime: metav1.NewTime(now),
+			},
+		},
+		{
+			name: "kube-cert-agent signer is configured again after another signer",
+			pinnipedObjects: []runtime.Object{
+				previouslyCSRSignerCredentialIssuer,
+			},
+			kubeObjects: []runtime.Object{
+				healthyKubeControllerManagerPod,
+				healthyAgentDeployment,
+				healthyAgentPod,
+				validClusterInfoConfigMap,
+			},
+			mocks:                     mockExecSucceeds,
+			wantDistinctErrors:        []string{""},
+			wantAgentDeployment:       healthyAgentDeployment,
+			wantDeploymentActionVerbs: []string{"list", "watch"},
+			wantDistinctLogs: []string{
+				`{"level":"info","timestamp":"2099-08-08T13:57:36.123456Z","logger":"kube-cert-agent-controller","caller":"kubecertagent/kubecertagent.go:<line>$kubecertagent.(*agentController).loadSigningKey","message":"successfully loaded signing key from agent pod into cache"}`,
+			},
+			wantSignerStrategy: &conciergeconfigv1alpha1.CredentialIssuerStrategy{
+				Type:           conciergeconfigv1alpha1.CertificateSignerStrategyType,
+				Status:         conciergeconfigv1alpha1.ErrorStrategyStatus,
+				Reason:         conciergeconfigv1alpha1.DisabledStrategyReason,
+				Message:        "client certificates are signed by the kube-cert-agent",
+				LastUpdateTime: metav1.NewTime(now),
+			},
+			wantStrategy: &conciergeconfigv1alpha1.CredentialIssuerStrategy{
+				Type:           conciergeconfigv1alpha1.KubeClusterSigningCertificateStrategyType,
+				Status:         conciergeconfigv1alpha1.SuccessStrategyStatus,
+				Reason:         conciergeconfigv1alpha1.FetchedKeyStrategyReason,
+				Message:        "key was fetched successfully",
+				LastUpdateTime: metav1.NewTime(now),
+				Frontend: &conciergeconfigv1alpha1.CredentialIssuerFrontend{
+					Type: conciergeconfigv1alpha1.TokenCredentialRequestAPIFrontendType,
+					TokenCredentialRequestAPIInfo: &conciergeconfigv1alpha1.TokenCredentialRequestAPIInfo{
+						Server:                   "https://test-kubernetes-endpoint.example.com",
+						CertificateAuthorityData: "dGVzdC1rdWJlcm5ldGVzLWNh",
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1060,6 +1269,7 @@ func TestAgentController(t *testing.T) {
 						"app": "anything",
 					},
 					DiscoveryURLOverride: tt.discoveryURLOverride,
+					ControlPlane:         tt.controlPlane,
 				},
 				&kubeclient.Client{Kubernetes: kubeClientset, PinnipedConcierge: conciergeClientset},
 				kubeInformers.Core().V1().Pods(),
@@ -1117,7 +1327,14 @@ func TestAgentController(t *testing.T) {
 			}
 
 			// Assert that the CredentialIssuer is in the expected final state
-			if tt.wantStrategy != nil {
+			if tt.wantSignerStrategy != nil {
+				credIssuer, err := conciergeClientset.ConfigV1alpha1().CredentialIssuers().Get(ctx, initialCredentialIssuer.Name, metav1.GetOptions{})
+				ok := assert.NoError(t, err)
+				if ok && assert.Len(t, credIssuer.Status.Strategies, 2, "expected two strategies in the CredentialIssuer") {
+					assert.Equal(t, tt.wantSignerStrategy, &credIssuer.Status.Strategies[0])
+					assert.Equal(t, tt.wantStrategy, &credIssuer.Status.Strategies[1])
+				}
+			} else if tt.wantStrategy != nil {
 				credIssuer, err := conciergeClientset.ConfigV1alpha1().CredentialIssuers().Get(ctx, initialCredentialIssuer.Name, metav1.GetOptions{})
 				ok := assert.NoError(t, err)
 				if ok && assert.Len(t, credIssuer.Status.Strategies, 1, "expected a single strategy in the CredentialIssuer") {
@@ -1128,6 +1345,22 @@ func TestAgentController(t *testing.T) {
 	}
 }
 
+func TestParsePathTemplate(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParsePathTemplate(`{{ flag "cluster-signing-cert-file" "/etc/kubernetes/ca/ca.pem" }}`)
+	require.NoError(t, err)
+
+	_, err = ParsePathTemplate("/some/literal/path")
+	require.NoError(t, err)
+
+	_, err = ParsePathTemplate(`{{ flag "unclosed"`)
+	require.EqualError(t, err, `template: path:1: unclosed action`)
+
+	_, err = ParsePathTemplate(`{{ unknownFunc }}`)
+	require.EqualError(t, err, `template: path:1: function "unknownFunc" not defined`)
+}
+
 func TestMergeLabelsAndAnnotations(t *testing.T) {
 	t.Parallel()
 
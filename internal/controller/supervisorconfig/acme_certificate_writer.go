@@ -0,0 +1,443 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package supervisorconfig
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/clock"
+
+	configconditions "go.pinniped.dev/generated/latest/apis/supervisor/config/conditions"
+	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
+	supervisorclientset "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned"
+	configinformers "go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions/config/v1alpha1"
+	"go.pinniped.dev/internal/acmecert"
+	pinnipedcontroller "go.pinniped.dev/internal/controller"
+	"go.pinniped.dev/internal/controller/conditionsutil"
+	"go.pinniped.dev/internal/controller/supervisorconfig/generator"
+	"go.pinniped.dev/internal/controllerlib"
+	"go.pinniped.dev/internal/leaderelection"
+	"go.pinniped.dev/internal/plog"
+)
+
+const (
+	acmeCertificateWriterControllerName = "acme-certificate-writer-controller"
+
+	// acmeSecretNameSuffix is appended to the name of a FederationDomain to name the Secret which holds its ACME state.
+	acmeSecretNameSuffix = "-acme"
+
+	// These are the keys in the Data map of the ACME state Secret.
+	acmeAccountKeyDataKey       = "accountKey"
+	acmeLastOrderAttemptDataKey = "lastOrderAttempt"
+
+	// acmeResyncInterval is the longest time between two checks of a certificate.
+	acmeResyncInterval = time.Hour
+
+	// acmeRetryInterval is the shortest time between two orders for the same FederationDomain. ACME certificate
+	// authorities limit the rate of failed validations, so failed orders must not be retried immediately.
+	acmeRetryInterval = 15 * time.Minute
+
+	// acmeOrderTimeout is the maximum duration of an order, including the validation of its challenges.
+	acmeOrderTimeout = 10 * time.Minute
+)
+
+// obtainACMECertificate is stubbed out for the purpose of testing.
+var obtainACMECertificate = acmecert.Obtain //nolint:gochecknoglobals
+
+type acmeCertificateWriterController struct {
+	secretLabels             map[string]string
+	kubeClient               kubernetes.Interface
+	pinnipedClient           supervisorclientset.Interface
+	secretInformer           corev1informers.SecretInformer
+	federationDomainInformer configinformers.FederationDomainInformer
+	clock                    clock.Clock
+}
+
+// NewACMECertificateWriterController returns a controllerlib.Controller which obtains the TLS serving certificates
+// requested by the spec.tls.acme of FederationDomains from ACME certificate authorities, writes them to the Secrets
+// which are loaded by the tlsCertObserverController, renews them when two thirds of their lifetime have passed,
+// and reports their readiness in the TLSCertificateReady condition of the FederationDomains.
+//
+// Orders are only placed by the leader. Before each order, the time of the order is written to the ACME state
+// Secret of the FederationDomain, which fails on the other pods, and which also prevents failed orders from
+// being retried more often than every acmeRetryInterval.
+func NewACMECertificateWriterController(
+	secretLabels map[string]string,
+	kubeClient kubernetes.Interface,
+	pinnipedClient supervisorclientset.Interface,
+	secretInformer corev1informers.SecretInformer,
+	federationDomainInformer configinformers.FederationDomainInformer,
+	clock clock.Clock,
+	withInformer pinnipedcontroller.WithInformerOptionFunc,
+) controllerlib.Controller {
+	isSecretToSync := func(obj metav1.Object) bool {
+		return generator.IsFederationDomainSecretOfType(obj, acmecert.SecretType) ||
+			generator.IsFederationDomainSecretOfType(obj, corev1.SecretTypeTLS)
+	}
+
+	return controllerlib.New(
+		controllerlib.Config{
+			Name: acmeCertificateWriterControllerName,
+			Syncer: &acmeCertificateWriterController{
+				secretLabels:             secretLabels,
+				kubeClient:               kubeClient,
+				pinnipedClient:           pinnipedClient,
+				secretInformer:           secretInformer,
+				federationDomainInformer: federationDomainInformer,
+				clock:                    clock,
+			},
+		},
+		// We want to be notified when the TLS Secret or the ACME state Secret of a FederationDomain changes.
+		withInformer(
+			secretInformer,
+			pinnipedcontroller.SimpleFilter(isSecretToSync, pinnipedcontroller.SecretIsControlledByParentFunc(isSecretToSync)),
+			controllerlib.InformerOption{},
+		),
+		withInformer(
+			federationDomainInformer,
+			pinnipedcontroller.MatchAnythingFilter(nil), // nil parent func is fine because each event is distinct
+			controllerlib.InformerOption{},
+		),
+	)
+}
+
+// Sync implements controllerlib.Syncer.
+func (c *acmeCertificateWriterController) Sync(ctx controllerlib.Context) error {
+	federationDomain, err := c.federationDomainInformer.Lister().FederationDomains(ctx.Key.Namespace).Get(ctx.Key.Name)
+	if apierrors.IsNotFound(err) {
+		// The Secrets which were created for this FederationDomain are garbage collected because of their owner references.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get %s/%s FederationDomain: %w", ctx.Key.Namespace, ctx.Key.Name, err)
+	}
+
+	if federationDomain.Spec.TLS == nil || federationDomain.Spec.TLS.ACME == nil {
+		if federationDomain.Spec.TLS != nil && federationDomain.Spec.TLS.CertManager != nil {
+			// The condition is managed by the certManagerCertificateWriterController.
+			return nil
+		}
+		return c.updateStatus(ctx.Context, federationDomain, nil)
+	}
+
+	issuerURL, err := url.Parse(federationDomain.Spec.Issuer)
+	if err != nil || issuerURL.Hostname() == "" {
+		return c.updateStatus(ctx.Context, federationDomain, &metav1.Condition{
+			Type:    configconditions.TypeTLSCertificateReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  configconditions.ReasonInvalidIssuerURL,
+			Message: "cannot request a certificate for an invalid issuer URL",
+		})
+	}
+	hostname := issuerURL.Hostname()
+	secretName := federationDomain.Spec.TLS.SecretName
+	secrets := c.secretInformer.Lister().Secrets(federationDomain.Namespace)
+
+	tlsSecret, err := secrets.Get(secretName)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("cannot get secret %s/%s: %w", federationDomain.Namespace, secretName, err)
+	}
+	if err == nil && !metav1.IsControlledBy(tlsSecret, federationDomain) {
+		if err := c.updateStatus(ctx.Context, federationDomain, &metav1.Condition{
+			Type:   configconditions.TypeTLSCertificateReady,
+			Status: metav1.ConditionFalse,
+			Reason: configconditions.ReasonTLSSecretNotOwned,
+			Message: fmt.Sprintf("the Secret %q already exists and was not created for this FederationDomain: "+
+				"delete it, or choose another spec.tls.secretName", secretName),
+		}); err != nil {
+			return err
+		}
+		ctx.Queue.AddAfter(ctx.Key, acmeResyncInterval)
+		return nil
+	}
+
+	now := c.clock.Now()
+	if leaf := parseTLSSecretLeaf(tlsSecret, hostname); leaf != nil {
+		renewAt := leaf.NotBefore.Add(leaf.NotAfter.Sub(leaf.NotBefore) * 2 / 3)
+		if now.Before(renewAt) {
+			if err := c.updateStatus(ctx.Context, federationDomain, &metav1.Condition{
+				Type:   configconditions.TypeTLSCertificateReady,
+				Status: metav1.ConditionTrue,
+				Reason: configconditions.ReasonSuccess,
+				Message: fmt.Sprintf("the certificate for %q was obtained from the ACME server and is stored in Secret %q, it expires at %s",
+					hostname, secretName, leaf.NotAfter.UTC().Format(time.RFC3339)),
+			}); err != nil {
+				return err
+			}
+			ctx.Queue.AddAfter(ctx.Key, min(renewAt.Sub(now), acmeResyncInterval))
+			return nil
+		}
+	}
+
+	stateSecretName := federationDomain.Name + acmeSecretNameSuffix
+	stateSecret, err := secrets.Get(stateSecretName)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("cannot get secret %s/%s: %w", federationDomain.Namespace, stateSecretName, err)
+	}
+	if stateSecret != nil {
+		if !metav1.IsControlledBy(stateSecret, federationDomain) {
+			return c.updateStatus(ctx.Context, federationDomain, &metav1.Condition{
+				Type:   configconditions.TypeTLSCertificateReady,
+				Status: metav1.ConditionFalse,
+				Reason: configconditions.ReasonACMEOrderFailed,
+				Message: fmt.Sprintf("the Secret %q already exists and was not created for this FederationDomain: "+
+					"delete it so that it can be used to store the ACME account key", stateSecretName),
+			})
+		}
+		lastOrderAttempt, err := time.Parse(time.RFC3339, string(stateSecret.Data[acmeLastOrderAttemptDataKey]))
+		if retryAt := lastOrderAttempt.Add(acmeRetryInterval); err == nil && now.Before(retryAt) {
+			// Another order was placed recently, either by this pod or by a previous leader.
+			ctx.Queue.AddAfter(ctx.Key, retryAt.Sub(now))
+			return nil
+		}
+	}
+
+	stateSecret, err = c.claimOrder(ctx.Context, federationDomain, stateSecret, stateSecretName, now)
+	if errors.Is(err, leaderelection.ErrNotLeader) {
+		// The leader places the order, and this pod will observe the certificate in the TLS Secret.
+		ctx.Queue.AddAfter(ctx.Key, acmeRetryInterval)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	certificate, err := c.order(ctx.Context, federationDomain, stateSecret, hostname)
+	if err != nil {
+		plog.WarningErr("could not obtain certificate from ACME server", err,
+			"namespace", federationDomain.Namespace, "name", federationDomain.Name, "hostname", hostname)
+		if err := c.updateStatus(ctx.Context, federationDomain, &metav1.Condition{
+			Type:   configconditions.TypeTLSCertificateReady,
+			Status: metav1.ConditionFalse,
+			Reason: configconditions.ReasonACMEOrderFailed,
+			Message: fmt.Sprintf("could not obtain a certificate for %q from the ACME server, will retry in %s: %s",
+				hostname, acmeRetryInterval, err.Error()),
+		}); err != nil {
+			return err
+		}
+		ctx.Queue.AddAfter(ctx.Key, acmeRetryInterval)
+		return nil
+	}
+
+	if err := c.writeTLSSecret(ctx.Context, federationDomain, tlsSecret, secretName, certificate); err != nil {
+		return err
+	}
+	plog.Info("obtained certificate from ACME server for FederationDomain",
+		"namespace", federationDomain.Namespace, "name", federationDomain.Name, "hostname", hostname,
+		"notAfter", certificate.NotAfter)
+
+	if err := c.updateStatus(ctx.Context, federationDomain, &metav1.Condition{
+		Type:   configconditions.TypeTLSCertificateReady,
+		Status: metav1.ConditionTrue,
+		Reason: configconditions.ReasonSuccess,
+		Message: fmt.Sprintf("the certificate for %q was obtained from the ACME server and is stored in Secret %q, it expires at %s",
+			hostname, secretName, certificate.NotAfter.UTC().Format(time.RFC3339)),
+	}); err != nil {
+		return err
+	}
+	ctx.Queue.AddAfter(ctx.Key, acmeResyncInterval)
+	return nil
+}
+
+// claimOrder records the time of a new order in the ACME state Secret, creating the Secret and the ACME account key
+// when needed. It fails with leaderelection.ErrNotLeader on the pods which are not the leader.
+func (c *acmeCertificateWriterController) claimOrder(
+	ctx context.Context,
+	federationDomain *supervisorconfigv1alpha1.FederationDomain,
+	stateSecret *corev1.Secret,
+	stateSecretName string,
+	now time.Time,
+) (*corev1.Secret, error) {
+	secretClient := c.kubeClient.CoreV1().Secrets(federationDomain.Namespace)
+
+	if stateSecret == nil {
+		accountKey, err := acmecert.GenerateAccountKey()
+		if err != nil {
+			return nil, err
+		}
+		stateSecret = &corev1.Secret{
+			ObjectMeta: c.ownedSecretMeta(federationDomain, stateSecretName),
+			Type:       acmecert.SecretType,
+			Data: map[string][]byte{
+				acmeAccountKeyDataKey:       accountKey,
+				acmeLastOrderAttemptDataKey: []byte(now.UTC().Format(time.RFC3339)),
+			},
+		}
+		created, err := secretClient.Create(ctx, stateSecret, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("cannot create secret %s/%s: %w", federationDomain.Namespace, stateSecretName, err)
+		}
+		return created, nil
+	}
+
+	updated := stateSecret.DeepCopy()
+	if updated.Data == nil {
+		updated.Data = map[string][]byte{}
+	}
+	if _, err := acmecert.ParseAccountKey(updated.Data[acmeAccountKeyDataKey]); err != nil {
+		accountKey, err := acmecert.GenerateAccountKey()
+		if err != nil {
+			return nil, err
+		}
+		updated.Data[acmeAccountKeyDataKey] = accountKey
+	}
+	updated.Data[acmeLastOrderAttemptDataKey] = []byte(now.UTC().Format(time.RFC3339))
+	// The update is conditional on the resource version which was read from the informer cache.
+	updated, err := secretClient.Update(ctx, updated, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("cannot update secret %s/%s: %w", federationDomain.Namespace, stateSecretName, err)
+	}
+	return updated, nil
+}
+
+func (c *acmeCertificateWriterController) order(
+	ctx context.Context,
+	federationDomain *supervisorconfigv1alpha1.FederationDomain,
+	stateSecret *corev1.Secret,
+	hostname string,
+) (*acmecert.Certificate, error) {
+	acmeSpec := federationDomain.Spec.TLS.ACME
+
+	accountKey, err := acmecert.ParseAccountKey(stateSecret.Data[acmeAccountKeyDataKey])
+	if err != nil {
+		return nil, err
+	}
+
+	var solver acmecert.Solver
+	switch {
+	case acmeSpec.ChallengeType == supervisorconfigv1alpha1.FederationDomainACMEChallengeTypeDNS01 &&
+		acmeSpec.DNS01 != nil && acmeSpec.DNS01.Webhook != nil:
+		solver, err = acmecert.NewDNS01WebhookSolver(acmeSpec.DNS01.Webhook.Endpoint, acmeSpec.DNS01.Webhook.CertificateAuthorityData)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DNS-01 webhook: %w", err)
+		}
+	case acmeSpec.ChallengeType == supervisorconfigv1alpha1.FederationDomainACMEChallengeTypeDNS01:
+		return nil, errors.New("no DNS provider is configured for DNS-01 challenges")
+	default:
+		solver = acmecert.NewHTTP01SecretSolver(
+			c.kubeClient.CoreV1().Secrets(federationDomain.Namespace),
+			c.secretInformer.Lister().Secrets(federationDomain.Namespace),
+			stateSecret.Name,
+		)
+	}
+
+	orderCtx, cancel := context.WithTimeout(ctx, acmeOrderTimeout)
+	defer cancel()
+
+	return obtainACMECertificate(orderCtx, &acmecert.Request{
+		DirectoryURL: acmeSpec.DirectoryURL,
+		Email:        acmeSpec.Email,
+		AccountKey:   accountKey,
+		Hostname:     hostname,
+		Solver:       solver,
+	})
+}
+
+func (c *acmeCertificateWriterController) writeTLSSecret(
+	ctx context.Context,
+	federationDomain *supervisorconfigv1alpha1.FederationDomain,
+	tlsSecret *corev1.Secret,
+	secretName string,
+	certificate *acmecert.Certificate,
+) error {
+	secretClient := c.kubeClient.CoreV1().Secrets(federationDomain.Namespace)
+	data := map[string][]byte{
+		corev1.TLSCertKey:       certificate.CertificatePEM,
+		corev1.TLSPrivateKeyKey: certificate.PrivateKeyPEM,
+	}
+
+	if tlsSecret == nil {
+		_, err := secretClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: c.ownedSecretMeta(federationDomain, secretName),
+			Type:       corev1.SecretTypeTLS,
+			Data:       data,
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("cannot create secret %s/%s: %w", federationDomain.Namespace, secretName, err)
+		}
+		return nil
+	}
+
+	updated := tlsSecret.DeepCopy()
+	updated.Data = data
+	if _, err := secretClient.Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("cannot update secret %s/%s: %w", federationDomain.Namespace, secretName, err)
+	}
+	return nil
+}
+
+func (c *acmeCertificateWriterController) ownedSecretMeta(
+	federationDomain *supervisorconfigv1alpha1.FederationDomain,
+	name string,
+) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: federationDomain.Namespace,
+		Labels:    c.secretLabels,
+		OwnerReferences: []metav1.OwnerReference{
+			*metav1.NewControllerRef(federationDomain, schema.GroupVersionKind{
+				Group:   supervisorconfigv1alpha1.SchemeGroupVersion.Group,
+				Version: supervisorconfigv1alpha1.SchemeGroupVersion.Version,
+				Kind:    federationDomainKind,
+			}),
+		},
+	}
+}
+
+func (c *acmeCertificateWriterController) updateStatus(
+	ctx context.Context,
+	federationDomain *supervisorconfigv1alpha1.FederationDomain,
+	condition *metav1.Condition, // nil removes the condition
+) error {
+	updated := federationDomain.DeepCopy()
+	if condition == nil {
+		updated.Status.Conditions = slices.DeleteFunc(updated.Status.Conditions, func(c metav1.Condition) bool {
+			return c.Type == configconditions.TypeTLSCertificateReady
+		})
+	} else {
+		_ = conditionsutil.MergeConditions([]*metav1.Condition{condition}, federationDomain.Generation, &updated.Status.Conditions,
+			plog.New().WithName(acmeCertificateWriterControllerName), metav1.NewTime(c.clock.Now()))
+	}
+
+	if equality.Semantic.DeepEqual(federationDomain, updated) {
+		return nil
+	}
+
+	_, err := c.pinnipedClient.ConfigV1alpha1().FederationDomains(federationDomain.Namespace).UpdateStatus(ctx, updated, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("cannot update status of FederationDomain %s/%s: %w", federationDomain.Namespace, federationDomain.Name, err)
+	}
+	return nil
+}
+
+// parseTLSSecretLeaf returns the leaf certificate of the TLS Secret when it is valid for the hostname,
+// or nil otherwise.
+func parseTLSSecretLeaf(tlsSecret *corev1.Secret, hostname string) *x509.Certificate {
+	if tlsSecret == nil {
+		return nil
+	}
+	block, _ := pem.Decode(tlsSecret.Data[corev1.TLSCertKey])
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil
+	}
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	if err != nil || leaf.VerifyHostname(hostname) != nil {
+		return nil
+	}
+	return leaf
+}
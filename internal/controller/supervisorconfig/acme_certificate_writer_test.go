@@ -0,0 +1,436 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package supervisorconfig
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubeinformers "k8s.io/client-go/informers"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	kubetesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+
+	configconditions "go.pinniped.dev/generated/latest/apis/supervisor/config/conditions"
+	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
+	supervisorfake "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/fake"
+	supervisorinformers "go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions"
+	"go.pinniped.dev/internal/acmecert"
+	"go.pinniped.dev/internal/controllerlib"
+	"go.pinniped.dev/internal/leaderelection"
+	"go.pinniped.dev/internal/testutil"
+)
+
+type acmeTestQueue struct {
+	duration time.Duration
+
+	controllerlib.Queue // panic if any other methods called
+}
+
+func (q *acmeTestQueue) AddAfter(_ controllerlib.Key, duration time.Duration) {
+	q.duration = duration
+}
+
+func TestACMECertificateWriterControllerInformerFilters(t *testing.T) {
+	observableWithInformerOption := testutil.NewObservableWithInformerOption()
+	secretInformer := kubeinformers.NewSharedInformerFactory(nil, 0).Core().V1().Secrets()
+	federationDomainInformer := supervisorinformers.NewSharedInformerFactory(nil, 0).Config().V1alpha1().FederationDomains()
+	_ = NewACMECertificateWriterController(
+		nil,
+		nil,
+		nil,
+		secretInformer,
+		federationDomainInformer,
+		clocktesting.NewFakeClock(time.Now()),
+		observableWithInformerOption.WithInformer, // make it possible to observe the behavior of the Filters
+	)
+
+	ownedSecret := func(secretType corev1.SecretType) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "some-secret",
+				Namespace: "some-namespace",
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: supervisorconfigv1alpha1.SchemeGroupVersion.String(),
+					Kind:       "FederationDomain",
+					Name:       "some-fd",
+					Controller: ptr.To(true),
+				}},
+			},
+			Type: secretType,
+		}
+	}
+	unownedTLSSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "some-secret", Namespace: "some-namespace"},
+		Type:       corev1.SecretTypeTLS,
+	}
+
+	secretFilter := observableWithInformerOption.GetFilterForInformer(secretInformer)
+	require.True(t, secretFilter.Add(ownedSecret(acmecert.SecretType)))
+	require.True(t, secretFilter.Add(ownedSecret(corev1.SecretTypeTLS)))
+	require.True(t, secretFilter.Update(unownedTLSSecret, ownedSecret(corev1.SecretTypeTLS)))
+	require.True(t, secretFilter.Delete(ownedSecret(acmecert.SecretType)))
+	require.False(t, secretFilter.Add(ownedSecret(corev1.SecretTypeOpaque)))
+	require.False(t, secretFilter.Add(unownedTLSSecret))
+	require.Equal(t, controllerlib.Key{Namespace: "some-namespace", Name: "some-fd"},
+		secretFilter.Parent(ownedSecret(acmecert.SecretType)))
+
+	federationDomain := &supervisorconfigv1alpha1.FederationDomain{ObjectMeta: metav1.ObjectMeta{Name: "any-name", Namespace: "any-namespace"}}
+	otherFederationDomain := &supervisorconfigv1alpha1.FederationDomain{ObjectMeta: metav1.ObjectMeta{Name: "any-other-name", Namespace: "any-other-namespace"}}
+	federationDomainFilter := observableWithInformerOption.GetFilterForInformer(federationDomainInformer)
+	require.True(t, federationDomainFilter.Add(federationDomain))
+	require.True(t, federationDomainFilter.Update(federationDomain, otherFederationDomain))
+	require.True(t, federationDomainFilter.Delete(federationDomain))
+}
+
+func TestACMECertificateWriterControllerSync(t *testing.T) {
+	const (
+		namespace = "some-namespace"
+		fdName    = "some-fd"
+		fdUID     = types.UID("some-fd-uid")
+	)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	labels := map[string]string{"some-label": "some-value"}
+
+	federationDomain := func(issuer string, tls *supervisorconfigv1alpha1.FederationDomainTLSSpec, conditions ...metav1.Condition) *supervisorconfigv1alpha1.FederationDomain {
+		return &supervisorconfigv1alpha1.FederationDomain{
+			ObjectMeta: metav1.ObjectMeta{Name: fdName, Namespace: namespace, UID: fdUID, Generation: 3},
+			Spec:       supervisorconfigv1alpha1.FederationDomainSpec{Issuer: issuer, TLS: tls},
+			Status:     supervisorconfigv1alpha1.FederationDomainStatus{Conditions: conditions},
+		}
+	}
+	withACME := func(acme supervisorconfigv1alpha1.FederationDomainACMESpec) *supervisorconfigv1alpha1.FederationDomainTLSSpec {
+		return &supervisorconfigv1alpha1.FederationDomainTLSSpec{SecretName: "some-tls-secret", ACME: &acme}
+	}
+	withHTTP01 := withACME(supervisorconfigv1alpha1.FederationDomainACMESpec{
+		DirectoryURL:         "https://acme.example.com/directory",
+		Email:                "admin@example.com",
+		AcceptTermsOfService: true,
+		ChallengeType:        supervisorconfigv1alpha1.FederationDomainACMEChallengeTypeHTTP01,
+	})
+
+	ownerRefs := []metav1.OwnerReference{{
+		APIVersion:         "config.supervisor.pinniped.dev/v1alpha1",
+		Kind:               "FederationDomain",
+		Name:               fdName,
+		UID:                fdUID,
+		Controller:         ptr.To(true),
+		BlockOwnerDeletion: ptr.To(true),
+	}}
+
+	certificatePEM := func(hostname string, notBefore time.Time) []byte {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			DNSNames:     []string{hostname},
+			NotBefore:    notBefore,
+			NotAfter:     notBefore.Add(90 * 24 * time.Hour),
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		require.NoError(t, err)
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	}
+	tlsSecret := func(owned bool, certPEM []byte) *corev1.Secret {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "some-tls-secret", Namespace: namespace},
+			Type:       corev1.SecretTypeTLS,
+			Data:       map[string][]byte{"tls.crt": certPEM, "tls.key": []byte("some-key")},
+		}
+		if owned {
+			secret.OwnerReferences = ownerRefs
+		}
+		return secret
+	}
+	accountKey, err := acmecert.GenerateAccountKey()
+	require.NoError(t, err)
+	stateSecret := func(lastOrderAttempt time.Time) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: fdName + "-acme", Namespace: namespace, OwnerReferences: ownerRefs},
+			Type:       acmecert.SecretType,
+			Data: map[string][]byte{
+				"accountKey":       accountKey,
+				"lastOrderAttempt": []byte(lastOrderAttempt.Format(time.RFC3339)),
+			},
+		}
+	}
+
+	freshCertificatePEM := certificatePEM("issuer.example.com", now.Add(-24*time.Hour))
+	almostDueCertificatePEM := certificatePEM("issuer.example.com", now.Add(-60*24*time.Hour).Add(10*time.Minute))
+	obtainedCertificate := &acmecert.Certificate{
+		CertificatePEM: []byte("obtained-cert"),
+		PrivateKeyPEM:  []byte("obtained-key"),
+		NotBefore:      now,
+		NotAfter:       now.Add(90 * 24 * time.Hour),
+	}
+
+	condition := func(status metav1.ConditionStatus, reason, message string) metav1.Condition {
+		return metav1.Condition{
+			Type:               configconditions.TypeTLSCertificateReady,
+			Status:             status,
+			Reason:             reason,
+			Message:            message,
+			ObservedGeneration: 3,
+			LastTransitionTime: metav1.NewTime(now),
+		}
+	}
+	otherCondition := metav1.Condition{Type: "IssuerURLValid", Status: metav1.ConditionTrue, Reason: "Success", Message: "some message"}
+	obtainedCondition := condition(metav1.ConditionTrue, "Success",
+		`the certificate for "issuer.example.com" was obtained from the ACME server and is stored in Secret "some-tls-secret", it expires at 2024-08-30T12:00:00Z`)
+
+	tests := []struct {
+		name             string
+		federationDomain *supervisorconfigv1alpha1.FederationDomain
+		secrets          []runtime.Object
+		notLeader        bool
+		obtainErr        error
+		wantConditions   []metav1.Condition
+		wantOrder        bool
+		wantChallenge    string
+		wantTLSSecret    *corev1.Secret // nil when it should not exist
+		wantRequeue      time.Duration
+	}{
+		{
+			name:             "FederationDomain does not use ACME",
+			federationDomain: federationDomain("https://issuer.example.com", &supervisorconfigv1alpha1.FederationDomainTLSSpec{SecretName: "some-tls-secret"}, otherCondition),
+			wantConditions:   []metav1.Condition{otherCondition},
+		},
+		{
+			name: "removes the condition when the FederationDomain no longer uses ACME",
+			federationDomain: federationDomain("https://issuer.example.com", &supervisorconfigv1alpha1.FederationDomainTLSSpec{SecretName: "some-tls-secret"},
+				otherCondition, condition(metav1.ConditionTrue, "Success", "some message")),
+			wantConditions: []metav1.Condition{otherCondition},
+		},
+		{
+			name: "leaves the condition of a FederationDomain which uses cert-manager alone",
+			federationDomain: federationDomain("https://issuer.example.com", &supervisorconfigv1alpha1.FederationDomainTLSSpec{
+				SecretName:  "some-tls-secret",
+				CertManager: &supervisorconfigv1alpha1.FederationDomainCertManagerSpec{CertificateName: "some-cert"},
+			}, condition(metav1.ConditionTrue, "Success", "some message")),
+			wantConditions: []metav1.Condition{condition(metav1.ConditionTrue, "Success", "some message")},
+		},
+		{
+			name:             "obtains the first certificate using an HTTP-01 challenge",
+			federationDomain: federationDomain("https://issuer.example.com/some/path", withHTTP01, otherCondition),
+			wantConditions:   []metav1.Condition{otherCondition, obtainedCondition},
+			wantOrder:        true,
+			wantChallenge:    acmecert.ChallengeTypeHTTP01,
+			wantTLSSecret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "some-tls-secret", Namespace: namespace, Labels: labels, OwnerReferences: ownerRefs},
+				Type:       corev1.SecretTypeTLS,
+				Data:       map[string][]byte{"tls.crt": []byte("obtained-cert"), "tls.key": []byte("obtained-key")},
+			},
+			wantRequeue: acmeResyncInterval,
+		},
+		{
+			name: "obtains a certificate using a DNS-01 webhook",
+			federationDomain: federationDomain("https://issuer.example.com", withACME(supervisorconfigv1alpha1.FederationDomainACMESpec{
+				DirectoryURL:         "https://acme.example.com/directory",
+				Email:                "admin@example.com",
+				AcceptTermsOfService: true,
+				ChallengeType:        supervisorconfigv1alpha1.FederationDomainACMEChallengeTypeDNS01,
+				DNS01: &supervisorconfigv1alpha1.FederationDomainACMEDNS01Spec{
+					Webhook: &supervisorconfigv1alpha1.FederationDomainACMEDNS01Webhook{Endpoint: "https://dns.example.com"},
+				},
+			})),
+			wantConditions: []metav1.Condition{obtainedCondition},
+			wantOrder:      true,
+			wantChallenge:  acmecert.ChallengeTypeDNS01,
+			wantTLSSecret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "some-tls-secret", Namespace: namespace, Labels: labels, OwnerReferences: ownerRefs},
+				Type:       corev1.SecretTypeTLS,
+				Data:       map[string][]byte{"tls.crt": []byte("obtained-cert"), "tls.key": []byte("obtained-key")},
+			},
+			wantRequeue: acmeResyncInterval,
+		},
+		{
+			name:             "reports a valid certificate which does not need to be renewed yet",
+			federationDomain: federationDomain("https://issuer.example.com", withHTTP01),
+			secrets:          []runtime.Object{tlsSecret(true, freshCertificatePEM), stateSecret(now.Add(-24 * time.Hour))},
+			wantConditions: []metav1.Condition{condition(metav1.ConditionTrue, "Success",
+				`the certificate for "issuer.example.com" was obtained from the ACME server and is stored in Secret "some-tls-secret", it expires at 2024-08-29T12:00:00Z`)},
+			wantTLSSecret: tlsSecret(true, freshCertificatePEM),
+			wantRequeue:   acmeResyncInterval,
+		},
+		{
+			name:             "requeues at the renewal time when it is sooner than the resync interval",
+			federationDomain: federationDomain("https://issuer.example.com", withHTTP01),
+			secrets: []runtime.Object{
+				tlsSecret(true, almostDueCertificatePEM),
+				stateSecret(now.Add(-60 * 24 * time.Hour)),
+			},
+			wantConditions: []metav1.Condition{condition(metav1.ConditionTrue, "Success",
+				`the certificate for "issuer.example.com" was obtained from the ACME server and is stored in Secret "some-tls-secret", it expires at 2024-07-01T12:10:00Z`)},
+			wantTLSSecret: tlsSecret(true, almostDueCertificatePEM),
+			wantRequeue:   10 * time.Minute,
+		},
+		{
+			name:             "renews a certificate after two thirds of its lifetime",
+			federationDomain: federationDomain("https://issuer.example.com", withHTTP01),
+			secrets: []runtime.Object{
+				tlsSecret(true, certificatePEM("issuer.example.com", now.Add(-61*24*time.Hour))),
+				stateSecret(now.Add(-61 * 24 * time.Hour)),
+			},
+			wantConditions: []metav1.Condition{obtainedCondition},
+			wantOrder:      true,
+			wantChallenge:  acmecert.ChallengeTypeHTTP01,
+			wantTLSSecret:  tlsSecret(true, []byte("obtained-cert")),
+			wantRequeue:    acmeResyncInterval,
+		},
+		{
+			name:             "replaces a certificate for another hostname",
+			federationDomain: federationDomain("https://issuer.example.com", withHTTP01),
+			secrets: []runtime.Object{
+				tlsSecret(true, certificatePEM("old-issuer.example.com", now.Add(-24*time.Hour))),
+				stateSecret(now.Add(-24 * time.Hour)),
+			},
+			wantConditions: []metav1.Condition{obtainedCondition},
+			wantOrder:      true,
+			wantChallenge:  acmecert.ChallengeTypeHTTP01,
+			wantTLSSecret:  tlsSecret(true, []byte("obtained-cert")),
+			wantRequeue:    acmeResyncInterval,
+		},
+		{
+			name:             "does not overwrite a TLS Secret which was not created for the FederationDomain",
+			federationDomain: federationDomain("https://issuer.example.com", withHTTP01),
+			secrets:          []runtime.Object{tlsSecret(false, freshCertificatePEM)},
+			wantConditions: []metav1.Condition{condition(metav1.ConditionFalse, "TLSSecretNotOwned",
+				`the Secret "some-tls-secret" already exists and was not created for this FederationDomain: delete it, or choose another spec.tls.secretName`)},
+			wantTLSSecret: tlsSecret(false, freshCertificatePEM),
+			wantRequeue:   acmeResyncInterval,
+		},
+		{
+			name:             "reports an order which failed and retries it later",
+			federationDomain: federationDomain("https://issuer.example.com", withHTTP01),
+			obtainErr:        errors.New("some ACME error"),
+			wantConditions: []metav1.Condition{condition(metav1.ConditionFalse, "ACMEOrderFailed",
+				`could not obtain a certificate for "issuer.example.com" from the ACME server, will retry in 15m0s: some ACME error`)},
+			wantOrder:     true,
+			wantChallenge: acmecert.ChallengeTypeHTTP01,
+			wantRequeue:   acmeRetryInterval,
+		},
+		{
+			name: "does not place another order soon after the last one",
+			federationDomain: federationDomain("https://issuer.example.com", withHTTP01,
+				condition(metav1.ConditionFalse, "ACMEOrderFailed", "some message")),
+			secrets:        []runtime.Object{stateSecret(now.Add(-5 * time.Minute))},
+			wantConditions: []metav1.Condition{condition(metav1.ConditionFalse, "ACMEOrderFailed", "some message")},
+			wantRequeue:    10 * time.Minute,
+		},
+		{
+			name:             "does not place an order when it is not the leader",
+			federationDomain: federationDomain("https://issuer.example.com", withHTTP01),
+			notLeader:        true,
+			wantRequeue:      acmeRetryInterval,
+		},
+		{
+			name:             "invalid issuer URL",
+			federationDomain: federationDomain("https://", withHTTP01),
+			wantConditions: []metav1.Condition{condition(metav1.ConditionFalse, "InvalidIssuerURL",
+				"cannot request a certificate for an invalid issuer URL")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			pinnipedAPIClient := supervisorfake.NewSimpleClientset(tt.federationDomain)
+			pinnipedInformerClient := supervisorfake.NewSimpleClientset(tt.federationDomain)
+			pinnipedInformers := supervisorinformers.NewSharedInformerFactory(pinnipedInformerClient, 0)
+			kubeAPIClient := kubernetesfake.NewSimpleClientset(tt.secrets...)
+			kubeInformerClient := kubernetesfake.NewSimpleClientset(tt.secrets...)
+			kubeInformers := kubeinformers.NewSharedInformerFactory(kubeInformerClient, 0)
+			if tt.notLeader {
+				kubeAPIClient.PrependReactor("*", "*", func(action kubetesting.Action) (bool, runtime.Object, error) {
+					if action.GetVerb() == "get" || action.GetVerb() == "list" || action.GetVerb() == "watch" {
+						return false, nil, nil
+					}
+					return true, nil, leaderelection.ErrNotLeader
+				})
+			}
+
+			var orders []*acmecert.Request
+			originalObtain := obtainACMECertificate
+			t.Cleanup(func() { obtainACMECertificate = originalObtain })
+			obtainACMECertificate = func(_ context.Context, req *acmecert.Request) (*acmecert.Certificate, error) {
+				orders = append(orders, req)
+				// The ACME state Secret was written before the order was placed.
+				stateSecret, err := kubeAPIClient.CoreV1().Secrets(namespace).Get(ctx, fdName+"-acme", metav1.GetOptions{})
+				require.NoError(t, err)
+				require.Equal(t, now.Format(time.RFC3339), string(stateSecret.Data["lastOrderAttempt"]))
+				if tt.obtainErr != nil {
+					return nil, tt.obtainErr
+				}
+				return obtainedCertificate, nil
+			}
+
+			c := NewACMECertificateWriterController(
+				labels,
+				kubeAPIClient,
+				pinnipedAPIClient,
+				kubeInformers.Core().V1().Secrets(),
+				pinnipedInformers.Config().V1alpha1().FederationDomains(),
+				clocktesting.NewFakeClock(now),
+				controllerlib.WithInformer,
+			)
+
+			// Must start informers before calling TestRunSynchronously().
+			pinnipedInformers.Start(ctx.Done())
+			kubeInformers.Start(ctx.Done())
+			controllerlib.TestRunSynchronously(t, c)
+
+			queue := &acmeTestQueue{}
+			err := controllerlib.TestSync(t, c, controllerlib.Context{
+				Context: ctx,
+				Key:     controllerlib.Key{Namespace: namespace, Name: fdName},
+				Queue:   queue,
+			})
+			require.NoError(t, err)
+
+			updated, err := pinnipedAPIClient.ConfigV1alpha1().FederationDomains(namespace).Get(ctx, fdName, metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, tt.wantConditions, updated.Status.Conditions)
+
+			if tt.wantOrder {
+				require.Len(t, orders, 1)
+				require.Equal(t, tt.federationDomain.Spec.TLS.ACME.DirectoryURL, orders[0].DirectoryURL)
+				require.Equal(t, "admin@example.com", orders[0].Email)
+				require.Equal(t, "issuer.example.com", orders[0].Hostname)
+				require.NotNil(t, orders[0].AccountKey)
+				require.Equal(t, tt.wantChallenge, orders[0].Solver.ChallengeType())
+			} else {
+				require.Empty(t, orders)
+			}
+
+			actualTLSSecret, err := kubeAPIClient.CoreV1().Secrets(namespace).Get(ctx, "some-tls-secret", metav1.GetOptions{})
+			if tt.wantTLSSecret == nil {
+				require.True(t, apierrors.IsNotFound(err), "expected the TLS Secret to not exist, but got: %v", err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.wantTLSSecret.Type, actualTLSSecret.Type)
+				require.Equal(t, tt.wantTLSSecret.Labels, actualTLSSecret.Labels)
+				require.Equal(t, tt.wantTLSSecret.OwnerReferences, actualTLSSecret.OwnerReferences)
+				require.Equal(t, tt.wantTLSSecret.Data["tls.crt"], actualTLSSecret.Data["tls.crt"])
+			}
+
+			require.Equal(t, tt.wantRequeue, queue.duration)
+		})
+	}
+}
@@ -18,6 +18,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/events"
 
 	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
 	supervisorclientset "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned"
@@ -109,6 +110,14 @@ func (s *activeDirectoryUpstreamGenericLDAPSpec) TLSSpec() *idpv1alpha1.TLSSpec
 	return s.activeDirectoryIdentityProvider.Spec.TLS
 }
 
+func (s *activeDirectoryUpstreamGenericLDAPSpec) ProxySpec() *idpv1alpha1.ProxySpec {
+	return nil // ActiveDirectoryIdentityProviders do not support an outbound proxy
+}
+
+func (s *activeDirectoryUpstreamGenericLDAPSpec) SecondFactorSpec() *idpv1alpha1.SecondFactorSpec {
+	return s.activeDirectoryIdentityProvider.Spec.SecondFactor
+}
+
 func (s *activeDirectoryUpstreamGenericLDAPSpec) BindSecretName() string {
 	return s.activeDirectoryIdentityProvider.Spec.Bind.SecretName
 }
@@ -244,6 +253,7 @@ func New(
 	activeDirectoryIdentityProviderInformer idpinformers.ActiveDirectoryIdentityProviderInformer,
 	secretInformer corev1informers.SecretInformer,
 	withInformer pinnipedcontroller.WithInformerOptionFunc,
+	recorder events.EventRecorder,
 ) controllerlib.Controller {
 	return newInternal(
 		idpCache,
@@ -255,6 +265,7 @@ func New(
 		activeDirectoryIdentityProviderInformer,
 		secretInformer,
 		withInformer,
+		recorder,
 	)
 }
 
@@ -267,6 +278,7 @@ func newInternal(
 	activeDirectoryIdentityProviderInformer idpinformers.ActiveDirectoryIdentityProviderInformer,
 	secretInformer corev1informers.SecretInformer,
 	withInformer pinnipedcontroller.WithInformerOptionFunc,
+	recorder events.EventRecorder,
 ) controllerlib.Controller {
 	c := activeDirectoryWatcherController{
 		cache:                                   idpCache,
@@ -288,6 +300,7 @@ func newInternal(
 			pinnipedcontroller.MatchAnySecretOfTypeFilter(upstreamwatchers.LDAPBindAccountSecretType, pinnipedcontroller.SingletonQueue()),
 			controllerlib.InformerOption{},
 		),
+		controllerlib.WithRecorder(recorder),
 	)
 }
 
@@ -301,7 +314,7 @@ func (c *activeDirectoryWatcherController) Sync(ctx controllerlib.Context) error
 	requeue := false
 	validatedUpstreams := make([]upstreamprovider.UpstreamLDAPIdentityProviderI, 0, len(actualUpstreams))
 	for _, upstream := range actualUpstreams {
-		valid, requestedRequeue := c.validateUpstream(ctx.Context, upstream)
+		valid, requestedRequeue := c.validateUpstream(ctx, upstream)
 		if valid != nil {
 			validatedUpstreams = append(validatedUpstreams, valid)
 		}
@@ -318,7 +331,7 @@ func (c *activeDirectoryWatcherController) Sync(ctx controllerlib.Context) error
 	return nil
 }
 
-func (c *activeDirectoryWatcherController) validateUpstream(ctx context.Context, upstream *idpv1alpha1.ActiveDirectoryIdentityProvider) (p upstreamprovider.UpstreamLDAPIdentityProviderI, requeue bool) {
+func (c *activeDirectoryWatcherController) validateUpstream(ctx controllerlib.Context, upstream *idpv1alpha1.ActiveDirectoryIdentityProvider) (p upstreamprovider.UpstreamLDAPIdentityProviderI, requeue bool) {
 	spec := upstream.Spec
 
 	adUpstreamImpl := &activeDirectoryUpstreamGenericLDAPImpl{activeDirectoryIdentityProvider: *upstream}
@@ -357,14 +370,14 @@ func (c *activeDirectoryWatcherController) validateUpstream(ctx context.Context,
 		}
 	}
 
-	conditions := upstreamwatchers.ValidateGenericLDAP(ctx, adUpstreamImpl, c.secretInformer, c.validatedSettingsCache, config)
+	conditions := upstreamwatchers.ValidateGenericLDAP(ctx.Context, adUpstreamImpl, c.secretInformer, c.validatedSettingsCache, config)
 
 	c.updateStatus(ctx, upstream, conditions.Conditions())
 
 	return upstreamwatchers.EvaluateConditions(conditions, config)
 }
 
-func (c *activeDirectoryWatcherController) updateStatus(ctx context.Context, upstream *idpv1alpha1.ActiveDirectoryIdentityProvider, conditions []*metav1.Condition) {
+func (c *activeDirectoryWatcherController) updateStatus(ctx controllerlib.Context, upstream *idpv1alpha1.ActiveDirectoryIdentityProvider, conditions []*metav1.Condition) {
 	log := plog.WithValues("namespace", upstream.Namespace, "name", upstream.Name)
 	updated := upstream.DeepCopy()
 
@@ -382,10 +395,12 @@ func (c *activeDirectoryWatcherController) updateStatus(ctx context.Context, ups
 	_, err := c.client.
 		IDPV1alpha1().
 		ActiveDirectoryIdentityProviders(upstream.Namespace).
-		UpdateStatus(ctx, updated, metav1.UpdateOptions{})
+		UpdateStatus(ctx.Context, updated, metav1.UpdateOptions{})
 	if err != nil {
 		log.Error("failed to update status", err)
+		return
 	}
+	conditionsutil.RecordConditionChanges(ctx.Recorder, updated, upstream.Status.Conditions, updated.Status.Conditions)
 }
 
 //nolint:gochecknoglobals // this needs to be a global variable so that tests can check pointer equality
@@ -0,0 +1,105 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package supervisorconfig
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/labels"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+
+	"go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions/config/v1alpha1"
+	pinnipedcontroller "go.pinniped.dev/internal/controller"
+	"go.pinniped.dev/internal/controllerlib"
+	"go.pinniped.dev/internal/federationdomain/branding"
+	"go.pinniped.dev/internal/plog"
+)
+
+type brandingObserverController struct {
+	issuerToBrandingSetter   IssuerToBrandingMapSetter
+	federationDomainInformer v1alpha1.FederationDomainInformer
+	configMapInformer        corev1informers.ConfigMapInformer
+}
+
+type IssuerToBrandingMapSetter interface {
+	SetIssuerToBrandingMap(issuerToBrandingMap map[string]*branding.Branding)
+}
+
+// NewBrandingObserverController returns a controller which watches all of the FederationDomains and the ConfigMaps
+// referenced by their spec.branding, and fills an in-memory cache of the branding for each currently configured issuer.
+// This controller assumes that the informers passed to it are already scoped down to the appropriate namespace.
+// It also assumes that the IssuerToBrandingMapSetter passed to it has an underlying implementation which is thread-safe.
+func NewBrandingObserverController(
+	issuerToBrandingSetter IssuerToBrandingMapSetter,
+	configMapInformer corev1informers.ConfigMapInformer,
+	federationDomainInformer v1alpha1.FederationDomainInformer,
+	withInformer pinnipedcontroller.WithInformerOptionFunc,
+) controllerlib.Controller {
+	return controllerlib.New(
+		controllerlib.Config{
+			Name: "branding-observer-controller",
+			Syncer: &brandingObserverController{
+				issuerToBrandingSetter:   issuerToBrandingSetter,
+				federationDomainInformer: federationDomainInformer,
+				configMapInformer:        configMapInformer,
+			},
+		},
+		withInformer(
+			configMapInformer,
+			pinnipedcontroller.MatchAnythingFilter(nil),
+			controllerlib.InformerOption{},
+		),
+		withInformer(
+			federationDomainInformer,
+			pinnipedcontroller.MatchAnythingFilter(nil),
+			controllerlib.InformerOption{},
+		),
+	)
+}
+
+func (c *brandingObserverController) Sync(ctx controllerlib.Context) error {
+	ns := ctx.Key.Namespace
+	allProviders, err := c.federationDomainInformer.Lister().FederationDomains(ns).List(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("failed to list FederationDomains: %w", err)
+	}
+
+	// Rebuild the whole map on any change to any ConfigMap or FederationDomain, because either can have changes that
+	// can cause the map to need to be updated.
+	issuerToBrandingMap := map[string]*branding.Branding{}
+
+	for _, provider := range allProviders {
+		if provider.Spec.Branding == nil || provider.Spec.Branding.ConfigMapName == "" {
+			// No branding configured, so this issuer uses the default branding.
+			continue
+		}
+
+		b, err := c.brandingFromConfigMap(ns, provider.Spec.Branding.ConfigMapName)
+		if err != nil {
+			// The user configured branding on the FederationDomain but it could not be loaded, so log a message
+			// which is visible at the default log level. Any error here indicates a problem, including "not found"
+			// errors. The issuer falls back to using the default branding.
+			plog.Error("error loading branding from ConfigMap for FederationDomain.spec.branding.configMapName", err,
+				"FederationDomain.metadata.name", provider.Name,
+				"FederationDomain.spec.branding.configMapName", provider.Spec.Branding.ConfigMapName,
+			)
+			continue
+		}
+
+		issuerToBrandingMap[provider.Spec.Issuer] = b
+	}
+
+	plog.Debug("brandingObserverController Sync updated the branding cache", "issuerCount", len(issuerToBrandingMap))
+	c.issuerToBrandingSetter.SetIssuerToBrandingMap(issuerToBrandingMap)
+
+	return nil
+}
+
+func (c *brandingObserverController) brandingFromConfigMap(ns string, configMapName string) (*branding.Branding, error) {
+	configMap, err := c.configMapInformer.Lister().ConfigMaps(ns).Get(configMapName)
+	if err != nil {
+		return nil, err
+	}
+	return branding.FromConfigMap(configMap)
+}
@@ -0,0 +1,138 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package supervisorconfig
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sinformers "k8s.io/client-go/informers"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+
+	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
+	supervisorfake "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/fake"
+	supervisorinformers "go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions"
+	"go.pinniped.dev/internal/controllerlib"
+	"go.pinniped.dev/internal/federationdomain/branding"
+	"go.pinniped.dev/internal/testutil"
+)
+
+func TestBrandingObserverControllerInformerFilters(t *testing.T) {
+	observableWithInformerOption := testutil.NewObservableWithInformerOption()
+	configMapInformer := k8sinformers.NewSharedInformerFactory(nil, 0).Core().V1().ConfigMaps()
+	federationDomainInformer := supervisorinformers.NewSharedInformerFactory(nil, 0).Config().V1alpha1().FederationDomains()
+	_ = NewBrandingObserverController(
+		nil,
+		configMapInformer,
+		federationDomainInformer,
+		observableWithInformerOption.WithInformer, // make it possible to observe the behavior of the Filters
+	)
+
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "any-name", Namespace: "any-namespace"}}
+	otherConfigMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "any-other-name", Namespace: "any-other-namespace"}}
+	configMapFilter := observableWithInformerOption.GetFilterForInformer(configMapInformer)
+	require.True(t, configMapFilter.Add(configMap))
+	require.True(t, configMapFilter.Update(configMap, otherConfigMap))
+	require.True(t, configMapFilter.Delete(configMap))
+
+	federationDomain := &supervisorconfigv1alpha1.FederationDomain{ObjectMeta: metav1.ObjectMeta{Name: "any-name", Namespace: "any-namespace"}}
+	otherFederationDomain := &supervisorconfigv1alpha1.FederationDomain{ObjectMeta: metav1.ObjectMeta{Name: "any-other-name", Namespace: "any-other-namespace"}}
+	federationDomainFilter := observableWithInformerOption.GetFilterForInformer(federationDomainInformer)
+	require.True(t, federationDomainFilter.Add(federationDomain))
+	require.True(t, federationDomainFilter.Update(federationDomain, otherFederationDomain))
+	require.True(t, federationDomainFilter.Delete(federationDomain))
+}
+
+type fakeIssuerToBrandingMapSetter struct {
+	setIssuerToBrandingMapWasCalled bool
+	issuerToBrandingMapReceived     map[string]*branding.Branding
+}
+
+func (f *fakeIssuerToBrandingMapSetter) SetIssuerToBrandingMap(issuerToBrandingMap map[string]*branding.Branding) {
+	f.setIssuerToBrandingMapWasCalled = true
+	f.issuerToBrandingMapReceived = issuerToBrandingMap
+}
+
+func TestBrandingObserverControllerSync(t *testing.T) {
+	const installedInNamespace = "some-namespace"
+
+	federationDomain := func(name, issuer string, brandingSpec *supervisorconfigv1alpha1.FederationDomainBrandingSpec) *supervisorconfigv1alpha1.FederationDomain {
+		return &supervisorconfigv1alpha1.FederationDomain{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: installedInNamespace},
+			Spec:       supervisorconfigv1alpha1.FederationDomainSpec{Issuer: issuer, Branding: brandingSpec},
+		}
+	}
+
+	configMap := func(name string, data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: installedInNamespace},
+			Data:       data,
+		}
+	}
+
+	tests := []struct {
+		name                string
+		federationDomains   []runtime.Object
+		configMaps          []runtime.Object
+		wantIssuerBrandings map[string]*branding.Branding
+	}{
+		{
+			name:                "no FederationDomains",
+			configMaps:          []runtime.Object{configMap("unrelated", map[string]string{"footerText": "unused"})},
+			wantIssuerBrandings: map[string]*branding.Branding{},
+		},
+		{
+			name: "FederationDomains with valid, invalid, missing, and no branding",
+			federationDomains: []runtime.Object{
+				federationDomain("no-branding", "https://no-branding.com", nil),
+				federationDomain("empty-branding", "https://empty-branding.com", &supervisorconfigv1alpha1.FederationDomainBrandingSpec{}),
+				federationDomain("good-branding", "https://good-branding.com", &supervisorconfigv1alpha1.FederationDomainBrandingSpec{ConfigMapName: "good"}),
+				federationDomain("bad-branding", "https://bad-branding.com", &supervisorconfigv1alpha1.FederationDomainBrandingSpec{ConfigMapName: "bad"}),
+				federationDomain("missing-branding", "https://missing-branding.com", &supervisorconfigv1alpha1.FederationDomainBrandingSpec{ConfigMapName: "missing"}),
+			},
+			configMaps: []runtime.Object{
+				configMap("good", map[string]string{"footerText": "Acme Corp", "primaryColor": "#1a73e8"}),
+				configMap("bad", map[string]string{"primaryColor": "not-a-color"}),
+			},
+			wantIssuerBrandings: map[string]*branding.Branding{
+				"https://good-branding.com": {FooterText: "Acme Corp", PrimaryColor: "#1a73e8"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			kubeInformers := k8sinformers.NewSharedInformerFactory(kubernetesfake.NewSimpleClientset(tt.configMaps...), 0)
+			pinnipedInformers := supervisorinformers.NewSharedInformerFactory(supervisorfake.NewSimpleClientset(tt.federationDomains...), 0)
+			setter := &fakeIssuerToBrandingMapSetter{}
+
+			subject := NewBrandingObserverController(
+				setter,
+				kubeInformers.Core().V1().ConfigMaps(),
+				pinnipedInformers.Config().V1alpha1().FederationDomains(),
+				controllerlib.WithInformer,
+			)
+
+			kubeInformers.Start(ctx.Done())
+			pinnipedInformers.Start(ctx.Done())
+			controllerlib.TestRunSynchronously(t, subject)
+
+			err := controllerlib.TestSync(t, subject, controllerlib.Context{
+				Context: ctx,
+				Name:    subject.Name(),
+				Key:     controllerlib.Key{Namespace: installedInNamespace, Name: "any-name"},
+			})
+			require.NoError(t, err)
+
+			require.True(t, setter.setIssuerToBrandingMapWasCalled)
+			require.Equal(t, tt.wantIssuerBrandings, setter.issuerToBrandingMapReceived)
+		})
+	}
+}
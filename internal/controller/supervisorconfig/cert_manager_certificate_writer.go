@@ -0,0 +1,403 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package supervisorconfig
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/utils/clock"
+
+	configconditions "go.pinniped.dev/generated/latest/apis/supervisor/config/conditions"
+	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
+	supervisorclientset "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned"
+	configinformers "go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions/config/v1alpha1"
+	pinnipedcontroller "go.pinniped.dev/internal/controller"
+	"go.pinniped.dev/internal/controller/conditionsutil"
+	"go.pinniped.dev/internal/controllerlib"
+	"go.pinniped.dev/internal/groupsuffix"
+	"go.pinniped.dev/internal/plog"
+)
+
+const (
+	certManagerCertificateWriterControllerName = "cert-manager-certificate-writer-controller"
+
+	// certManagerCertificateResyncInterval is how often the Certificates are checked. They are polled rather than
+	// watched because cert-manager is optional, and an informer for a resource which does not exist would never sync.
+	certManagerCertificateResyncInterval = time.Minute
+
+	certManagerDefaultIssuerKind  = "Issuer"
+	certManagerDefaultIssuerGroup = "cert-manager.io"
+)
+
+//nolint:gochecknoglobals
+var certManagerCertificateGVR = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+
+// certManagerManagedSpecFields are the fields of the spec of a cert-manager Certificate which are written by this
+// controller. Any other fields may be set by the admin and are left alone.
+//
+//nolint:gochecknoglobals
+var certManagerManagedSpecFields = []string{"secretName", "issuerRef", "dnsNames", "ipAddresses"}
+
+type certManagerCertificateWriterController struct {
+	apiGroupSuffix           string
+	dynamicClient            dynamic.Interface
+	pinnipedClient           supervisorclientset.Interface
+	federationDomainInformer configinformers.FederationDomainInformer
+	clock                    clock.Clock
+}
+
+// NewCertManagerCertificateWriterController returns a controllerlib.Controller which creates and updates the
+// cert-manager Certificates requested by the spec.tls.certManager of FederationDomains, and reports their readiness
+// in the TLSCertificateReady condition of the FederationDomains. cert-manager itself issues and renews the
+// certificates, and writes them to the Secrets which are loaded by the tlsCertObserverController.
+//
+// The dynamicClient is not subject to leader election, so all writes made with it must be safe to be made by
+// every Supervisor pod. Creates of an existing Certificate fail, and updates are conditional on the resource
+// version of the read.
+func NewCertManagerCertificateWriterController(
+	apiGroupSuffix string,
+	dynamicClient dynamic.Interface,
+	pinnipedClient supervisorclientset.Interface,
+	federationDomainInformer configinformers.FederationDomainInformer,
+	clock clock.Clock,
+	withInformer pinnipedcontroller.WithInformerOptionFunc,
+) controllerlib.Controller {
+	return controllerlib.New(
+		controllerlib.Config{
+			Name: certManagerCertificateWriterControllerName,
+			Syncer: &certManagerCertificateWriterController{
+				apiGroupSuffix:           apiGroupSuffix,
+				dynamicClient:            dynamicClient,
+				pinnipedClient:           pinnipedClient,
+				federationDomainInformer: federationDomainInformer,
+				clock:                    clock,
+			},
+		},
+		withInformer(
+			federationDomainInformer,
+			pinnipedcontroller.MatchAnythingFilter(nil), // nil parent func is fine because each event is distinct
+			controllerlib.InformerOption{},
+		),
+	)
+}
+
+// Sync implements controllerlib.Syncer.
+func (c *certManagerCertificateWriterController) Sync(ctx controllerlib.Context) error {
+	federationDomain, err := c.federationDomainInformer.Lister().FederationDomains(ctx.Key.Namespace).Get(ctx.Key.Name)
+	if apierrors.IsNotFound(err) {
+		// Any Certificate which was created for this FederationDomain is garbage collected because of its owner reference.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get %s/%s FederationDomain: %w", ctx.Key.Namespace, ctx.Key.Name, err)
+	}
+
+	var certManager *supervisorconfigv1alpha1.FederationDomainCertManagerSpec
+	if federationDomain.Spec.TLS != nil {
+		certManager = federationDomain.Spec.TLS.CertManager
+	}
+
+	// Only look for a Certificate to clean up when this FederationDomain has used cert-manager before, to avoid
+	// making requests for the many FederationDomains which never use cert-manager.
+	hadCondition := configconditions.FindCondition(federationDomain.Status.Conditions, configconditions.TypeTLSCertificateReady) != nil
+	if hadCondition && (certManager == nil || certManager.IssuerRef == nil) {
+		if err := c.deleteOwnedCertificate(ctx.Context, federationDomain); err != nil {
+			return err
+		}
+	}
+
+	if certManager == nil {
+		if federationDomain.Spec.TLS != nil && federationDomain.Spec.TLS.ACME != nil {
+			// The condition is managed by the acmeCertificateWriterController.
+			return nil
+		}
+		return c.updateStatus(ctx.Context, federationDomain, nil)
+	}
+
+	var condition *metav1.Condition
+	if certManager.IssuerRef != nil {
+		condition, err = c.createOrUpdateCertificate(ctx.Context, federationDomain, certManager.IssuerRef)
+	} else {
+		condition, err = c.checkExistingCertificate(ctx.Context, federationDomain, certManager.CertificateName)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := c.updateStatus(ctx.Context, federationDomain, condition); err != nil {
+		return err
+	}
+
+	ctx.Queue.AddAfter(ctx.Key, certManagerCertificateResyncInterval)
+	return nil
+}
+
+func (c *certManagerCertificateWriterController) createOrUpdateCertificate(
+	ctx context.Context,
+	federationDomain *supervisorconfigv1alpha1.FederationDomain,
+	issuerRef *supervisorconfigv1alpha1.FederationDomainCertManagerIssuerRef,
+) (*metav1.Condition, error) {
+	issuerURL, err := url.Parse(federationDomain.Spec.Issuer)
+	if err != nil || issuerURL.Hostname() == "" {
+		return &metav1.Condition{
+			Type:    configconditions.TypeTLSCertificateReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  configconditions.ReasonInvalidIssuerURL,
+			Message: "cannot request a certificate for an invalid issuer URL",
+		}, nil
+	}
+	desiredSpec := desiredCertificateSpec(federationDomain.Spec.TLS.SecretName, issuerURL.Hostname(), issuerRef)
+
+	certificates := c.dynamicClient.Resource(certManagerCertificateGVR).Namespace(federationDomain.Namespace)
+
+	certificate, err := certificates.Get(ctx, federationDomain.Name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		certificate, err = certificates.Create(ctx, c.newCertificate(federationDomain, desiredSpec), metav1.CreateOptions{})
+		if apierrors.IsNotFound(err) {
+			return &metav1.Condition{
+				Type:    configconditions.TypeTLSCertificateReady,
+				Status:  metav1.ConditionFalse,
+				Reason:  configconditions.ReasonCertManagerNotInstalled,
+				Message: "cannot create a cert-manager Certificate: the Certificate API was not found, is cert-manager installed?",
+			}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot create cert-manager Certificate %s/%s: %w", federationDomain.Namespace, federationDomain.Name, err)
+		}
+		plog.Info("created cert-manager Certificate for FederationDomain",
+			"namespace", federationDomain.Namespace, "name", federationDomain.Name)
+
+	case err != nil:
+		return nil, fmt.Errorf("cannot get cert-manager Certificate %s/%s: %w", federationDomain.Namespace, federationDomain.Name, err)
+
+	case !metav1.IsControlledBy(certificate, federationDomain):
+		return &metav1.Condition{
+			Type:   configconditions.TypeTLSCertificateReady,
+			Status: metav1.ConditionFalse,
+			Reason: configconditions.ReasonCertificateNotOwned,
+			Message: fmt.Sprintf("the cert-manager Certificate %q already exists and was not created for this FederationDomain: "+
+				"delete it, or refer to it using spec.tls.certManager.certificateName", certificate.GetName()),
+		}, nil
+
+	default:
+		existingSpec, _, _ := unstructured.NestedMap(certificate.Object, "spec")
+		if !specHasManagedFields(certManagerManagedSpecFields, existingSpec, desiredSpec) {
+			updated := certificate.DeepCopy()
+			if existingSpec == nil {
+				existingSpec = map[string]any{}
+			}
+			for _, field := range certManagerManagedSpecFields {
+				delete(existingSpec, field)
+			}
+			for field, value := range desiredSpec {
+				existingSpec[field] = value
+			}
+			if err := unstructured.SetNestedMap(updated.Object, existingSpec, "spec"); err != nil {
+				return nil, fmt.Errorf("cannot update cert-manager Certificate %s/%s: %w", federationDomain.Namespace, federationDomain.Name, err)
+			}
+			// The update is conditional on the resource version which was read above.
+			certificate, err = certificates.Update(ctx, updated, metav1.UpdateOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("cannot update cert-manager Certificate %s/%s: %w", federationDomain.Namespace, federationDomain.Name, err)
+			}
+			plog.Info("updated cert-manager Certificate for FederationDomain",
+				"namespace", federationDomain.Namespace, "name", federationDomain.Name)
+		}
+	}
+
+	return certificateReadyCondition(certificate, federationDomain.Spec.TLS.SecretName), nil
+}
+
+func (c *certManagerCertificateWriterController) checkExistingCertificate(
+	ctx context.Context,
+	federationDomain *supervisorconfigv1alpha1.FederationDomain,
+	certificateName string,
+) (*metav1.Condition, error) {
+	certificate, err := c.dynamicClient.Resource(certManagerCertificateGVR).
+		Namespace(federationDomain.Namespace).
+		Get(ctx, certificateName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return &metav1.Condition{
+			Type:    configconditions.TypeTLSCertificateReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  configconditions.ReasonCertificateNotFound,
+			Message: fmt.Sprintf("the cert-manager Certificate %q was not found", certificateName),
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get cert-manager Certificate %s/%s: %w", federationDomain.Namespace, certificateName, err)
+	}
+
+	secretName, _, _ := unstructured.NestedString(certificate.Object, "spec", "secretName")
+	if secretName != federationDomain.Spec.TLS.SecretName {
+		return &metav1.Condition{
+			Type:   configconditions.TypeTLSCertificateReady,
+			Status: metav1.ConditionFalse,
+			Reason: configconditions.ReasonCertificateSecretNameMismatch,
+			Message: fmt.Sprintf("the spec.secretName %q of the cert-manager Certificate %q does not match spec.tls.secretName %q",
+				secretName, certificateName, federationDomain.Spec.TLS.SecretName),
+		}, nil
+	}
+
+	return certificateReadyCondition(certificate, secretName), nil
+}
+
+// deleteOwnedCertificate deletes the Certificate which was created for the FederationDomain, if any.
+func (c *certManagerCertificateWriterController) deleteOwnedCertificate(
+	ctx context.Context,
+	federationDomain *supervisorconfigv1alpha1.FederationDomain,
+) error {
+	certificates := c.dynamicClient.Resource(certManagerCertificateGVR).Namespace(federationDomain.Namespace)
+
+	certificate, err := certificates.Get(ctx, federationDomain.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot get cert-manager Certificate %s/%s: %w", federationDomain.Namespace, federationDomain.Name, err)
+	}
+	if !metav1.IsControlledBy(certificate, federationDomain) {
+		return nil
+	}
+
+	uid := certificate.GetUID()
+	err = certificates.Delete(ctx, certificate.GetName(), metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &uid}})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("cannot delete cert-manager Certificate %s/%s: %w", federationDomain.Namespace, federationDomain.Name, err)
+	}
+	plog.Info("deleted cert-manager Certificate which is no longer used by FederationDomain",
+		"namespace", federationDomain.Namespace, "name", federationDomain.Name)
+	return nil
+}
+
+func (c *certManagerCertificateWriterController) newCertificate(
+	federationDomain *supervisorconfigv1alpha1.FederationDomain,
+	spec map[string]any,
+) *unstructured.Unstructured {
+	// The dynamic client does not add the API group suffix to owner references, unlike the generated clients.
+	federationDomainGroup, _ := groupsuffix.Replace(supervisorconfigv1alpha1.SchemeGroupVersion.Group, c.apiGroupSuffix)
+
+	certificate := &unstructured.Unstructured{Object: map[string]any{"spec": spec}}
+	certificate.SetAPIVersion(certManagerCertificateGVR.GroupVersion().String())
+	certificate.SetKind("Certificate")
+	certificate.SetName(federationDomain.Name)
+	certificate.SetNamespace(federationDomain.Namespace)
+	certificate.SetOwnerReferences([]metav1.OwnerReference{
+		*metav1.NewControllerRef(federationDomain, schema.GroupVersionKind{
+			Group:   federationDomainGroup,
+			Version: supervisorconfigv1alpha1.SchemeGroupVersion.Version,
+			Kind:    federationDomainKind,
+		}),
+	})
+	return certificate
+}
+
+func (c *certManagerCertificateWriterController) updateStatus(
+	ctx context.Context,
+	federationDomain *supervisorconfigv1alpha1.FederationDomain,
+	condition *metav1.Condition, // nil removes the condition
+) error {
+	updated := federationDomain.DeepCopy()
+	if condition == nil {
+		updated.Status.Conditions = slices.DeleteFunc(updated.Status.Conditions, func(c metav1.Condition) bool {
+			return c.Type == configconditions.TypeTLSCertificateReady
+		})
+	} else {
+		_ = conditionsutil.MergeConditions([]*metav1.Condition{condition}, federationDomain.Generation, &updated.Status.Conditions,
+			plog.New().WithName(certManagerCertificateWriterControllerName), metav1.NewTime(c.clock.Now()))
+	}
+
+	if equality.Semantic.DeepEqual(federationDomain, updated) {
+		return nil
+	}
+
+	_, err := c.pinnipedClient.ConfigV1alpha1().FederationDomains(federationDomain.Namespace).UpdateStatus(ctx, updated, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("cannot update status of FederationDomain %s/%s: %w", federationDomain.Namespace, federationDomain.Name, err)
+	}
+	return nil
+}
+
+func desiredCertificateSpec(secretName, host string, issuerRef *supervisorconfigv1alpha1.FederationDomainCertManagerIssuerRef) map[string]any {
+	kind := issuerRef.Kind
+	if kind == "" {
+		kind = certManagerDefaultIssuerKind
+	}
+	group := issuerRef.Group
+	if group == "" {
+		group = certManagerDefaultIssuerGroup
+	}
+
+	spec := map[string]any{
+		"secretName": secretName,
+		"issuerRef": map[string]any{
+			"name":  issuerRef.Name,
+			"kind":  kind,
+			"group": group,
+		},
+	}
+	if net.ParseIP(host) != nil {
+		spec["ipAddresses"] = []any{host}
+	} else {
+		spec["dnsNames"] = []any{host}
+	}
+	return spec
+}
+
+// specHasManagedFields returns true when the managed fields of the existing spec have the desired values.
+func specHasManagedFields(managedFields []string, existingSpec, desiredSpec map[string]any) bool {
+	for _, field := range managedFields {
+		existingValue, existingOK := existingSpec[field]
+		desiredValue, desiredOK := desiredSpec[field]
+		if existingOK != desiredOK || !equality.Semantic.DeepEqual(existingValue, desiredValue) {
+			return false
+		}
+	}
+	return true
+}
+
+// certificateReadyCondition translates the Ready condition of a cert-manager Certificate.
+func certificateReadyCondition(certificate *unstructured.Unstructured, secretName string) *metav1.Condition {
+	conditions, _, _ := unstructured.NestedSlice(certificate.Object, "status", "conditions")
+	for _, condition := range conditions {
+		condition, ok := condition.(map[string]any)
+		if !ok || condition["type"] != "Ready" {
+			continue
+		}
+		if condition["status"] == string(metav1.ConditionTrue) {
+			return &metav1.Condition{
+				Type:   configconditions.TypeTLSCertificateReady,
+				Status: metav1.ConditionTrue,
+				Reason: configconditions.ReasonSuccess,
+				Message: fmt.Sprintf("the cert-manager Certificate %q is ready and its certificate is stored in Secret %q",
+					certificate.GetName(), secretName),
+			}
+		}
+		return &metav1.Condition{
+			Type:   configconditions.TypeTLSCertificateReady,
+			Status: metav1.ConditionFalse,
+			Reason: configconditions.ReasonCertificateNotReady,
+			Message: fmt.Sprintf("the cert-manager Certificate %q is not ready: %s: %s",
+				certificate.GetName(), condition["reason"], condition["message"]),
+		}
+	}
+	return &metav1.Condition{
+		Type:    configconditions.TypeTLSCertificateReady,
+		Status:  metav1.ConditionFalse,
+		Reason:  configconditions.ReasonCertificateNotReady,
+		Message: fmt.Sprintf("the cert-manager Certificate %q is not ready yet", certificate.GetName()),
+	}
+}
@@ -0,0 +1,347 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package supervisorconfig
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubetesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	configconditions "go.pinniped.dev/generated/latest/apis/supervisor/config/conditions"
+	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
+	supervisorfake "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/fake"
+	supervisorinformers "go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions"
+	"go.pinniped.dev/internal/controllerlib"
+	"go.pinniped.dev/internal/testutil"
+)
+
+type certManagerTestQueue struct {
+	duration time.Duration
+
+	controllerlib.Queue // panic if any other methods called
+}
+
+func (q *certManagerTestQueue) AddAfter(_ controllerlib.Key, duration time.Duration) {
+	q.duration = duration
+}
+
+func TestCertManagerCertificateWriterControllerInformerFilters(t *testing.T) {
+	observableWithInformerOption := testutil.NewObservableWithInformerOption()
+	federationDomainInformer := supervisorinformers.NewSharedInformerFactory(nil, 0).Config().V1alpha1().FederationDomains()
+	_ = NewCertManagerCertificateWriterController(
+		"pinniped.dev",
+		nil,
+		nil,
+		federationDomainInformer,
+		clocktesting.NewFakeClock(time.Now()),
+		observableWithInformerOption.WithInformer, // make it possible to observe the behavior of the Filters
+	)
+
+	federationDomain := &supervisorconfigv1alpha1.FederationDomain{ObjectMeta: metav1.ObjectMeta{Name: "any-name", Namespace: "any-namespace"}}
+	otherFederationDomain := &supervisorconfigv1alpha1.FederationDomain{ObjectMeta: metav1.ObjectMeta{Name: "any-other-name", Namespace: "any-other-namespace"}}
+	federationDomainFilter := observableWithInformerOption.GetFilterForInformer(federationDomainInformer)
+	require.True(t, federationDomainFilter.Add(federationDomain))
+	require.True(t, federationDomainFilter.Update(federationDomain, otherFederationDomain))
+	require.True(t, federationDomainFilter.Delete(federationDomain))
+}
+
+func TestCertManagerCertificateWriterControllerSync(t *testing.T) {
+	const (
+		namespace = "some-namespace"
+		fdName    = "some-fd"
+		fdUID     = types.UID("some-fd-uid")
+	)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	federationDomain := func(issuer string, tls *supervisorconfigv1alpha1.FederationDomainTLSSpec, conditions ...metav1.Condition) *supervisorconfigv1alpha1.FederationDomain {
+		return &supervisorconfigv1alpha1.FederationDomain{
+			ObjectMeta: metav1.ObjectMeta{Name: fdName, Namespace: namespace, UID: fdUID, Generation: 3},
+			Spec:       supervisorconfigv1alpha1.FederationDomainSpec{Issuer: issuer, TLS: tls},
+			Status:     supervisorconfigv1alpha1.FederationDomainStatus{Conditions: conditions},
+		}
+	}
+	withIssuerRef := &supervisorconfigv1alpha1.FederationDomainTLSSpec{
+		SecretName: "some-tls-secret",
+		CertManager: &supervisorconfigv1alpha1.FederationDomainCertManagerSpec{
+			IssuerRef: &supervisorconfigv1alpha1.FederationDomainCertManagerIssuerRef{Name: "some-issuer"},
+		},
+	}
+	withCertificateName := &supervisorconfigv1alpha1.FederationDomainTLSSpec{
+		SecretName: "some-tls-secret",
+		CertManager: &supervisorconfigv1alpha1.FederationDomainCertManagerSpec{
+			CertificateName: "existing-cert",
+		},
+	}
+
+	ownerRefs := []any{map[string]any{
+		"apiVersion":         "config.supervisor.pinniped.dev/v1alpha1",
+		"kind":               "FederationDomain",
+		"name":               fdName,
+		"uid":                string(fdUID),
+		"controller":         true,
+		"blockOwnerDeletion": true,
+	}}
+	certificate := func(name string, owned bool, spec map[string]any, readyCondition map[string]any) *unstructured.Unstructured {
+		metadata := map[string]any{"name": name, "namespace": namespace, "uid": "some-cert-uid"}
+		if owned {
+			metadata["ownerReferences"] = ownerRefs
+		}
+		obj := map[string]any{
+			"apiVersion": "cert-manager.io/v1",
+			"kind":       "Certificate",
+			"metadata":   metadata,
+			"spec":       spec,
+		}
+		if readyCondition != nil {
+			obj["status"] = map[string]any{"conditions": []any{readyCondition}}
+		}
+		return &unstructured.Unstructured{Object: obj}
+	}
+	desiredSpec := map[string]any{
+		"secretName": "some-tls-secret",
+		"issuerRef":  map[string]any{"name": "some-issuer", "kind": "Issuer", "group": "cert-manager.io"},
+		"dnsNames":   []any{"issuer.example.com"},
+	}
+	readyTrue := map[string]any{"type": "Ready", "status": "True", "reason": "Ready", "message": "Certificate is up to date and has not expired"}
+	readyFalse := map[string]any{"type": "Ready", "status": "False", "reason": "DoesNotExist", "message": "Issuing certificate as Secret does not exist"}
+
+	condition := func(status metav1.ConditionStatus, reason, message string) metav1.Condition {
+		return metav1.Condition{
+			Type:               configconditions.TypeTLSCertificateReady,
+			Status:             status,
+			Reason:             reason,
+			Message:            message,
+			ObservedGeneration: 3,
+			LastTransitionTime: metav1.NewTime(now),
+		}
+	}
+	otherCondition := metav1.Condition{Type: "IssuerURLValid", Status: metav1.ConditionTrue, Reason: "Success", Message: "some message"}
+
+	tests := []struct {
+		name             string
+		federationDomain *supervisorconfigv1alpha1.FederationDomain
+		certificates     []runtime.Object
+		certManagerGone  bool
+		wantConditions   []metav1.Condition
+		wantCertificate  *unstructured.Unstructured // the Certificate named like the FederationDomain, nil when it should not exist
+		wantRequeue      bool
+	}{
+		{
+			name:             "FederationDomain does not use cert-manager",
+			federationDomain: federationDomain("https://issuer.example.com", &supervisorconfigv1alpha1.FederationDomainTLSSpec{SecretName: "some-tls-secret"}, otherCondition),
+			wantConditions:   []metav1.Condition{otherCondition},
+		},
+		{
+			name:             "creates a Certificate for the issuer host",
+			federationDomain: federationDomain("https://issuer.example.com/some/path", withIssuerRef, otherCondition),
+			wantConditions: []metav1.Condition{
+				otherCondition,
+				condition(metav1.ConditionFalse, "CertificateNotReady", `the cert-manager Certificate "some-fd" is not ready yet`),
+			},
+			wantCertificate: certificate(fdName, true, desiredSpec, nil),
+			wantRequeue:     true,
+		},
+		{
+			name: "creates a Certificate for an issuer IP address using a ClusterIssuer",
+			federationDomain: federationDomain("https://10.1.2.3", &supervisorconfigv1alpha1.FederationDomainTLSSpec{
+				SecretName: "some-tls-secret",
+				CertManager: &supervisorconfigv1alpha1.FederationDomainCertManagerSpec{
+					IssuerRef: &supervisorconfigv1alpha1.FederationDomainCertManagerIssuerRef{Name: "some-issuer", Kind: "ClusterIssuer", Group: "example.com"},
+				},
+			}),
+			wantConditions: []metav1.Condition{
+				condition(metav1.ConditionFalse, "CertificateNotReady", `the cert-manager Certificate "some-fd" is not ready yet`),
+			},
+			wantCertificate: certificate(fdName, true, map[string]any{
+				"secretName":  "some-tls-secret",
+				"issuerRef":   map[string]any{"name": "some-issuer", "kind": "ClusterIssuer", "group": "example.com"},
+				"ipAddresses": []any{"10.1.2.3"},
+			}, nil),
+			wantRequeue: true,
+		},
+		{
+			name:             "cert-manager is not installed",
+			federationDomain: federationDomain("https://issuer.example.com", withIssuerRef),
+			certManagerGone:  true,
+			wantConditions: []metav1.Condition{
+				condition(metav1.ConditionFalse, "CertManagerNotInstalled", "cannot create a cert-manager Certificate: the Certificate API was not found, is cert-manager installed?"),
+			},
+			wantRequeue: true,
+		},
+		{
+			name:             "updates the managed fields of an outdated Certificate and keeps the other fields",
+			federationDomain: federationDomain("https://issuer.example.com", withIssuerRef),
+			certificates: []runtime.Object{certificate(fdName, true, map[string]any{
+				"secretName":  "old-secret",
+				"issuerRef":   map[string]any{"name": "old-issuer", "kind": "Issuer", "group": "cert-manager.io"},
+				"ipAddresses": []any{"10.1.2.3"},
+				"duration":    "2160h",
+			}, readyTrue)},
+			wantConditions: []metav1.Condition{
+				condition(metav1.ConditionTrue, "Success", `the cert-manager Certificate "some-fd" is ready and its certificate is stored in Secret "some-tls-secret"`),
+			},
+			wantCertificate: certificate(fdName, true, map[string]any{
+				"secretName": "some-tls-secret",
+				"issuerRef":  map[string]any{"name": "some-issuer", "kind": "Issuer", "group": "cert-manager.io"},
+				"dnsNames":   []any{"issuer.example.com"},
+				"duration":   "2160h",
+			}, readyTrue),
+			wantRequeue: true,
+		},
+		{
+			name:             "reports a Certificate which is not ready",
+			federationDomain: federationDomain("https://issuer.example.com", withIssuerRef),
+			certificates:     []runtime.Object{certificate(fdName, true, desiredSpec, readyFalse)},
+			wantConditions: []metav1.Condition{
+				condition(metav1.ConditionFalse, "CertificateNotReady", `the cert-manager Certificate "some-fd" is not ready: DoesNotExist: Issuing certificate as Secret does not exist`),
+			},
+			wantCertificate: certificate(fdName, true, desiredSpec, readyFalse),
+			wantRequeue:     true,
+		},
+		{
+			name:             "does not take over a Certificate which was not created for the FederationDomain",
+			federationDomain: federationDomain("https://issuer.example.com", withIssuerRef),
+			certificates:     []runtime.Object{certificate(fdName, false, map[string]any{"secretName": "other"}, readyTrue)},
+			wantConditions: []metav1.Condition{
+				condition(metav1.ConditionFalse, "CertificateNotOwned", `the cert-manager Certificate "some-fd" already exists and was not created `+
+					`for this FederationDomain: delete it, or refer to it using spec.tls.certManager.certificateName`),
+			},
+			wantCertificate: certificate(fdName, false, map[string]any{"secretName": "other"}, readyTrue),
+			wantRequeue:     true,
+		},
+		{
+			name:             "reports an existing Certificate which is ready",
+			federationDomain: federationDomain("https://issuer.example.com", withCertificateName),
+			certificates:     []runtime.Object{certificate("existing-cert", false, map[string]any{"secretName": "some-tls-secret"}, readyTrue)},
+			wantConditions: []metav1.Condition{
+				condition(metav1.ConditionTrue, "Success", `the cert-manager Certificate "existing-cert" is ready and its certificate is stored in Secret "some-tls-secret"`),
+			},
+			wantRequeue: true,
+		},
+		{
+			name:             "existing Certificate is not found",
+			federationDomain: federationDomain("https://issuer.example.com", withCertificateName),
+			wantConditions: []metav1.Condition{
+				condition(metav1.ConditionFalse, "CertificateNotFound", `the cert-manager Certificate "existing-cert" was not found`),
+			},
+			wantRequeue: true,
+		},
+		{
+			name:             "existing Certificate writes to a different Secret",
+			federationDomain: federationDomain("https://issuer.example.com", withCertificateName),
+			certificates:     []runtime.Object{certificate("existing-cert", false, map[string]any{"secretName": "other-secret"}, readyTrue)},
+			wantConditions: []metav1.Condition{
+				condition(metav1.ConditionFalse, "CertificateSecretNameMismatch",
+					`the spec.secretName "other-secret" of the cert-manager Certificate "existing-cert" does not match spec.tls.secretName "some-tls-secret"`),
+			},
+			wantRequeue: true,
+		},
+		{
+			name: "deletes the Certificate and removes the condition when the FederationDomain no longer uses cert-manager",
+			federationDomain: federationDomain("https://issuer.example.com",
+				&supervisorconfigv1alpha1.FederationDomainTLSSpec{SecretName: "some-tls-secret"},
+				otherCondition,
+				condition(metav1.ConditionTrue, "Success", "some message"),
+			),
+			certificates:   []runtime.Object{certificate(fdName, true, desiredSpec, readyTrue)},
+			wantConditions: []metav1.Condition{otherCondition},
+		},
+		{
+			name: "leaves the condition of a FederationDomain which uses ACME alone",
+			federationDomain: federationDomain("https://issuer.example.com",
+				&supervisorconfigv1alpha1.FederationDomainTLSSpec{
+					SecretName: "some-tls-secret",
+					ACME:       &supervisorconfigv1alpha1.FederationDomainACMESpec{AcceptTermsOfService: true},
+				},
+				condition(metav1.ConditionTrue, "Success", "some message"),
+			),
+			wantConditions: []metav1.Condition{condition(metav1.ConditionTrue, "Success", "some message")},
+		},
+		{
+			name: "does not delete a Certificate which was not created for the FederationDomain",
+			federationDomain: federationDomain("https://issuer.example.com", withCertificateName,
+				condition(metav1.ConditionTrue, "Success", "some message"),
+			),
+			certificates: []runtime.Object{
+				certificate(fdName, false, desiredSpec, readyTrue),
+				certificate("existing-cert", false, map[string]any{"secretName": "some-tls-secret"}, readyTrue),
+			},
+			wantConditions: []metav1.Condition{
+				condition(metav1.ConditionTrue, "Success", `the cert-manager Certificate "existing-cert" is ready and its certificate is stored in Secret "some-tls-secret"`),
+			},
+			wantCertificate: certificate(fdName, false, desiredSpec, readyTrue),
+			wantRequeue:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			pinnipedAPIClient := supervisorfake.NewSimpleClientset(tt.federationDomain)
+			pinnipedInformerClient := supervisorfake.NewSimpleClientset(tt.federationDomain)
+			pinnipedInformers := supervisorinformers.NewSharedInformerFactory(pinnipedInformerClient, 0)
+
+			dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{certManagerCertificateGVR: "CertificateList"},
+				tt.certificates...)
+			if tt.certManagerGone {
+				dynamicClient.PrependReactor("create", "certificates", func(_ kubetesting.Action) (bool, runtime.Object, error) {
+					return true, nil, apierrors.NewNotFound(certManagerCertificateGVR.GroupResource(), "")
+				})
+			}
+
+			c := NewCertManagerCertificateWriterController(
+				"pinniped.dev",
+				dynamicClient,
+				pinnipedAPIClient,
+				pinnipedInformers.Config().V1alpha1().FederationDomains(),
+				clocktesting.NewFakeClock(now),
+				controllerlib.WithInformer,
+			)
+
+			// Must start informers before calling TestRunSynchronously().
+			pinnipedInformers.Start(ctx.Done())
+			controllerlib.TestRunSynchronously(t, c)
+
+			queue := &certManagerTestQueue{}
+			err := controllerlib.TestSync(t, c, controllerlib.Context{
+				Context: ctx,
+				Key:     controllerlib.Key{Namespace: namespace, Name: fdName},
+				Queue:   queue,
+			})
+			require.NoError(t, err)
+
+			updated, err := pinnipedAPIClient.ConfigV1alpha1().FederationDomains(namespace).Get(ctx, fdName, metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, tt.wantConditions, updated.Status.Conditions)
+
+			actualCertificate, err := dynamicClient.Resource(certManagerCertificateGVR).Namespace(namespace).Get(ctx, fdName, metav1.GetOptions{})
+			if tt.wantCertificate == nil {
+				require.True(t, apierrors.IsNotFound(err), "expected the Certificate to not exist, but got: %v", err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.wantCertificate.Object["spec"], actualCertificate.Object["spec"])
+				require.Equal(t, tt.wantCertificate.GetOwnerReferences(), actualCertificate.GetOwnerReferences())
+			}
+
+			if tt.wantRequeue {
+				require.Equal(t, certManagerCertificateResyncInterval, queue.duration)
+			} else {
+				require.Zero(t, queue.duration)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package clientcertupstreamwatcher implements a controller which watches ClientCertificateIdentityProviders.
+package clientcertupstreamwatcher
+
+import (
+	"crypto/x509"
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/utils/clock"
+
+	idpconditions "go.pinniped.dev/generated/latest/apis/supervisor/idp/conditions"
+	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
+	supervisorclientset "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned"
+	idpinformers "go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions/idp/v1alpha1"
+	pinnipedcontroller "go.pinniped.dev/internal/controller"
+	"go.pinniped.dev/internal/controller/conditionsutil"
+	"go.pinniped.dev/internal/controller/supervisorconfig/upstreamwatchers"
+	"go.pinniped.dev/internal/controllerlib"
+	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
+	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/internal/upstreamclientcert"
+)
+
+const (
+	controllerName = "client-certificate-upstream-observer"
+
+	countExpectedConditions = 2
+
+	TLSConfigurationValid string = idpconditions.TypeTLSConfigurationValid
+	ClaimsValid           string = idpconditions.TypeClaimsValid
+)
+
+// UpstreamClientCertificateIdentityProviderICache is a thread safe cache that holds a list of validated upstream
+// client certificate IDP configurations.
+type UpstreamClientCertificateIdentityProviderICache interface {
+	SetClientCertificateIdentityProviders([]upstreamprovider.UpstreamClientCertificateIdentityProviderI)
+}
+
+type clientCertWatcherController struct {
+	namespace                   string
+	cache                       UpstreamClientCertificateIdentityProviderICache
+	log                         plog.Logger
+	client                      supervisorclientset.Interface
+	clientCertIdentityProviders idpinformers.ClientCertificateIdentityProviderInformer
+	clock                       clock.Clock
+}
+
+// New instantiates a new controllerlib.Controller which will populate the provided
+// UpstreamClientCertificateIdentityProviderICache.
+func New(
+	namespace string,
+	idpCache UpstreamClientCertificateIdentityProviderICache,
+	client supervisorclientset.Interface,
+	clientCertIdentityProviderInformer idpinformers.ClientCertificateIdentityProviderInformer,
+	log plog.Logger,
+	withInformer pinnipedcontroller.WithInformerOptionFunc,
+	recorder events.EventRecorder,
+	clock clock.Clock,
+) controllerlib.Controller {
+	c := clientCertWatcherController{
+		namespace:                   namespace,
+		cache:                       idpCache,
+		client:                      client,
+		log:                         log.WithName(controllerName),
+		clientCertIdentityProviders: clientCertIdentityProviderInformer,
+		clock:                       clock,
+	}
+
+	return controllerlib.New(
+		controllerlib.Config{Name: controllerName, Syncer: &c},
+		withInformer(
+			clientCertIdentityProviderInformer,
+			pinnipedcontroller.SimpleFilter(func(obj metav1.Object) bool {
+				idp, ok := obj.(*idpv1alpha1.ClientCertificateIdentityProvider)
+				return ok && idp.Namespace == namespace
+			}, pinnipedcontroller.SingletonQueue()),
+			controllerlib.InformerOption{},
+		),
+		controllerlib.WithRecorder(recorder),
+	)
+}
+
+// Sync implements controllerlib.Syncer.
+func (c *clientCertWatcherController) Sync(ctx controllerlib.Context) error {
+	actualUpstreams, err := c.clientCertIdentityProviders.Lister().List(labels.Everything())
+	if err != nil { // untested
+		return fmt.Errorf("failed to list ClientCertificateIdentityProviders: %w", err)
+	}
+
+	// Sort them by name just so that the logs output is consistent
+	slices.SortStableFunc(actualUpstreams, func(a, b *idpv1alpha1.ClientCertificateIdentityProvider) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	var applicationErrors []error
+	validatedUpstreams := make([]upstreamprovider.UpstreamClientCertificateIdentityProviderI, 0, len(actualUpstreams))
+	for _, upstream := range actualUpstreams {
+		if upstream.Namespace != c.namespace {
+			continue
+		}
+		validatedUpstream, applicationErr := c.validateUpstreamAndUpdateConditions(ctx, upstream)
+		if applicationErr != nil {
+			applicationErrors = append(applicationErrors, applicationErr)
+		} else if validatedUpstream != nil {
+			validatedUpstreams = append(validatedUpstreams, validatedUpstream)
+		}
+		// Else:
+		// If both validatedUpstream and applicationErr are nil, this must be because the upstream had configuration errors.
+		// This controller should take no action until the user has reconfigured the upstream.
+	}
+	c.cache.SetClientCertificateIdentityProviders(validatedUpstreams)
+
+	// If we have recoverable application errors, let's do a requeue and capture all the applicationErrors too
+	if len(applicationErrors) > 0 {
+		applicationErrors = append([]error{controllerlib.ErrSyntheticRequeue}, applicationErrors...)
+	}
+
+	return utilerrors.NewAggregate(applicationErrors)
+}
+
+func (c *clientCertWatcherController) validateUpstreamAndUpdateConditions(
+	ctx controllerlib.Context,
+	upstream *idpv1alpha1.ClientCertificateIdentityProvider,
+) (
+	*upstreamclientcert.Provider, // If validated, returns the config
+	error, // This error will only refer to programmatic errors such as failing to update the status, not configuration errors
+) {
+	conditions := make([]*metav1.Condition, 0)
+
+	tlsConfigCondition, certPool := validateTLSConfiguration(upstream.Spec.CertificateAuthorityData)
+	conditions = append(conditions, tlsConfigCondition)
+
+	claimsCondition := validateClaims(upstream.Spec.Claims)
+	conditions = append(conditions, claimsCondition)
+
+	// The critical pattern to maintain is that every run of the sync loop will populate the exact number of the exact
+	// same set of conditions.  Conditions depending on other conditions should get Status: metav1.ConditionUnknown, or
+	// Status: metav1.ConditionFalse, never be omitted.
+	if len(conditions) != countExpectedConditions { // untested since all code paths return the same number of conditions
+		return nil, fmt.Errorf("expected %d conditions but found %d conditions", countExpectedConditions, len(conditions))
+	}
+
+	hadErrorCondition, updateStatusErr := c.updateStatus(ctx, upstream, conditions)
+	// Any error condition means we will not add the IDP to the cache, so just return nil here
+	if hadErrorCondition {
+		return nil, updateStatusErr
+	}
+
+	provider := upstreamclientcert.New(upstreamclientcert.ProviderConfig{
+		Name:          upstream.Name,
+		ResourceUID:   upstream.UID,
+		Roots:         certPool,
+		UsernameField: upstream.Spec.Claims.Username,
+		GroupsField:   upstream.Spec.Claims.Groups,
+	})
+	return provider, updateStatusErr
+}
+
+func validateTLSConfiguration(certificateAuthorityData string) (*metav1.Condition, *x509.CertPool) {
+	buildInvalidCondition := func(reason string) *metav1.Condition {
+		return &metav1.Condition{
+			Type:    TLSConfigurationValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  upstreamwatchers.ReasonInvalidTLSConfig,
+			Message: fmt.Sprintf("spec.certificateAuthorityData is not valid: %s", reason),
+		}
+	}
+
+	// Should not happen due to CRD validation. An empty CA bundle must not fall back to the system roots.
+	if len(certificateAuthorityData) == 0 {
+		return buildInvalidCondition("must not be empty"), nil
+	}
+
+	certPool, _, buildCertPoolErr := pinnipedcontroller.BuildCertPoolIDP(&idpv1alpha1.TLSSpec{
+		CertificateAuthorityData: certificateAuthorityData,
+	})
+	if buildCertPoolErr != nil {
+		// buildCertPoolErr is not recoverable with a resync.
+		// It requires user interaction, so do not return the error.
+		return buildInvalidCondition(buildCertPoolErr.Error()), nil
+	}
+
+	return &metav1.Condition{
+		Type:    TLSConfigurationValid,
+		Status:  metav1.ConditionTrue,
+		Reason:  upstreamwatchers.ReasonSuccess,
+		Message: "spec.certificateAuthorityData is valid",
+	}, certPool
+}
+
+func validateClaims(claims idpv1alpha1.ClientCertificateClaims) *metav1.Condition {
+	buildInvalidCondition := func(message string) *metav1.Condition {
+		return &metav1.Condition{
+			Type:    ClaimsValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  idpconditions.ReasonInvalid,
+			Message: message,
+		}
+	}
+
+	switch claims.Username {
+	case idpv1alpha1.ClientCertificateSubjectCommonName, idpv1alpha1.ClientCertificateSubjectEmailAddress:
+	case idpv1alpha1.ClientCertificateSubjectOrganization, idpv1alpha1.ClientCertificateSubjectOrganizationalUnit:
+		fallthrough
+	default:
+		// Should not happen due to CRD defaulting and enum validation
+		return buildInvalidCondition(fmt.Sprintf("spec.claims.username (%q) is not valid", claims.Username))
+	}
+
+	switch claims.Groups {
+	case idpv1alpha1.ClientCertificateSubjectOrganization, idpv1alpha1.ClientCertificateSubjectOrganizationalUnit:
+	case idpv1alpha1.ClientCertificateSubjectCommonName, idpv1alpha1.ClientCertificateSubjectEmailAddress:
+		fallthrough
+	default:
+		// Should not happen due to CRD defaulting and enum validation
+		return buildInvalidCondition(fmt.Sprintf("spec.claims.groups (%q) is not valid", claims.Groups))
+	}
+
+	return &metav1.Condition{
+		Type:    ClaimsValid,
+		Status:  metav1.ConditionTrue,
+		Reason:  upstreamwatchers.ReasonSuccess,
+		Message: "spec.claims are valid",
+	}
+}
+
+func (c *clientCertWatcherController) updateStatus(
+	ctx controllerlib.Context,
+	upstream *idpv1alpha1.ClientCertificateIdentityProvider,
+	conditions []*metav1.Condition,
+) (bool, error) {
+	log := c.log.WithValues("namespace", upstream.Namespace, "name", upstream.Name)
+	updated := upstream.DeepCopy()
+
+	hadErrorCondition := conditionsutil.MergeConditions(
+		conditions,
+		upstream.Generation,
+		&updated.Status.Conditions,
+		log,
+		metav1.NewTime(c.clock.Now()),
+	)
+
+	updated.Status.Phase = idpv1alpha1.ClientCertificatePhaseReady
+	if hadErrorCondition {
+		updated.Status.Phase = idpv1alpha1.ClientCertificatePhaseError
+	}
+
+	if equality.Semantic.DeepEqual(upstream, updated) {
+		return hadErrorCondition, nil
+	}
+
+	log.Info("updating ClientCertificateIdentityProvider status", "phase", updated.Status.Phase)
+
+	_, updateStatusError := c.client.
+		IDPV1alpha1().
+		ClientCertificateIdentityProviders(upstream.Namespace).
+		UpdateStatus(ctx.Context, updated, metav1.UpdateOptions{})
+	if updateStatusError == nil {
+		conditionsutil.RecordConditionChanges(ctx.Recorder, updated, upstream.Status.Conditions, updated.Status.Conditions)
+	}
+	return hadErrorCondition, updateStatusError
+}
@@ -0,0 +1,290 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package clientcertupstreamwatcher
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	coretesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
+	supervisorfake "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/fake"
+	supervisorinformers "go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions"
+	"go.pinniped.dev/internal/certauthority"
+	"go.pinniped.dev/internal/controllerlib"
+	"go.pinniped.dev/internal/federationdomain/dynamicupstreamprovider"
+	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
+	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/internal/upstreamclientcert"
+)
+
+func TestController(t *testing.T) {
+	require.Equal(t, 2, countExpectedConditions)
+
+	const (
+		namespace = "some-namespace"
+		otherNS   = "other-namespace"
+	)
+
+	ca, err := certauthority.New("some-ca", time.Hour)
+	require.NoError(t, err)
+	caB64 := base64.StdEncoding.EncodeToString(ca.Bundle())
+
+	now := metav1.NewTime(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	earlier := metav1.NewTime(now.Add(-time.Hour))
+
+	validIDP := func(name string) *idpv1alpha1.ClientCertificateIdentityProvider {
+		return &idpv1alpha1.ClientCertificateIdentityProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, UID: types.UID("uid-" + name), Generation: 1234},
+			Spec: idpv1alpha1.ClientCertificateIdentityProviderSpec{
+				CertificateAuthorityData: caB64,
+				Claims: idpv1alpha1.ClientCertificateClaims{
+					Username: idpv1alpha1.ClientCertificateSubjectCommonName,
+					Groups:   idpv1alpha1.ClientCertificateSubjectOrganization,
+				},
+			},
+		}
+	}
+
+	condition := func(condType string, status metav1.ConditionStatus, reason, message string, lastTransitionTime metav1.Time) metav1.Condition {
+		return metav1.Condition{
+			Type:               condType,
+			Status:             status,
+			ObservedGeneration: 1234,
+			LastTransitionTime: lastTransitionTime,
+			Reason:             reason,
+			Message:            message,
+		}
+	}
+
+	happyTLSCondition := condition(TLSConfigurationValid, metav1.ConditionTrue, "Success", "spec.certificateAuthorityData is valid", now)
+	happyClaimsCondition := condition(ClaimsValid, metav1.ConditionTrue, "Success", "spec.claims are valid", now)
+
+	tests := []struct {
+		name                string
+		inputUpstreams      []runtime.Object
+		configureClient     func(*supervisorfake.Clientset)
+		wantErr             string
+		wantResultingCache  []string
+		wantResultingPhases map[string]idpv1alpha1.ClientCertificateIdentityProviderPhase
+		wantResultingConds  map[string][]metav1.Condition
+		wantNoStatusUpdates bool
+	}{
+		{
+			name:                "no upstreams",
+			wantResultingCache:  []string{},
+			wantNoStatusUpdates: true,
+		},
+		{
+			name:               "valid upstream is loaded into the cache and becomes Ready",
+			inputUpstreams:     []runtime.Object{validIDP("some-idp")},
+			wantResultingCache: []string{"some-idp"},
+			wantResultingPhases: map[string]idpv1alpha1.ClientCertificateIdentityProviderPhase{
+				"some-idp": idpv1alpha1.ClientCertificatePhaseReady,
+			},
+			wantResultingConds: map[string][]metav1.Condition{
+				"some-idp": {happyClaimsCondition, happyTLSCondition},
+			},
+		},
+		{
+			name: "upstreams in other namespaces are ignored",
+			inputUpstreams: []runtime.Object{func() runtime.Object {
+				idp := validIDP("some-idp")
+				idp.Namespace = otherNS
+				return idp
+			}()},
+			wantResultingCache:  []string{},
+			wantNoStatusUpdates: true,
+		},
+		{
+			name: "already up to date upstream does not get a status update",
+			inputUpstreams: []runtime.Object{func() runtime.Object {
+				idp := validIDP("some-idp")
+				idp.Status = idpv1alpha1.ClientCertificateIdentityProviderStatus{
+					Phase: idpv1alpha1.ClientCertificatePhaseReady,
+					Conditions: []metav1.Condition{
+						condition(ClaimsValid, metav1.ConditionTrue, "Success", "spec.claims are valid", earlier),
+						condition(TLSConfigurationValid, metav1.ConditionTrue, "Success", "spec.certificateAuthorityData is valid", earlier),
+					},
+				}
+				return idp
+			}()},
+			wantResultingCache:  []string{"some-idp"},
+			wantNoStatusUpdates: true,
+		},
+		{
+			name: "CA bundle which is not base64 encoded",
+			inputUpstreams: []runtime.Object{func() runtime.Object {
+				idp := validIDP("some-idp")
+				idp.Spec.CertificateAuthorityData = "not-base64"
+				return idp
+			}()},
+			wantResultingCache: []string{},
+			wantResultingPhases: map[string]idpv1alpha1.ClientCertificateIdentityProviderPhase{
+				"some-idp": idpv1alpha1.ClientCertificatePhaseError,
+			},
+			wantResultingConds: map[string][]metav1.Condition{
+				"some-idp": {
+					happyClaimsCondition,
+					condition(TLSConfigurationValid, metav1.ConditionFalse, "InvalidTLSConfig",
+						"spec.certificateAuthorityData is not valid: illegal base64 data at input byte 3", now),
+				},
+			},
+		},
+		{
+			name: "CA bundle which does not contain any certificates",
+			inputUpstreams: []runtime.Object{func() runtime.Object {
+				idp := validIDP("some-idp")
+				idp.Spec.CertificateAuthorityData = base64.StdEncoding.EncodeToString([]byte("not a pem bundle"))
+				return idp
+			}()},
+			wantResultingCache: []string{},
+			wantResultingPhases: map[string]idpv1alpha1.ClientCertificateIdentityProviderPhase{
+				"some-idp": idpv1alpha1.ClientCertificatePhaseError,
+			},
+			wantResultingConds: map[string][]metav1.Condition{
+				"some-idp": {
+					happyClaimsCondition,
+					condition(TLSConfigurationValid, metav1.ConditionFalse, "InvalidTLSConfig",
+						"spec.certificateAuthorityData is not valid: certificateAuthorityData is not valid PEM: data does not contain any valid RSA or ECDSA certificates", now),
+				},
+			},
+		},
+		{
+			name: "empty CA bundle",
+			inputUpstreams: []runtime.Object{func() runtime.Object {
+				idp := validIDP("some-idp")
+				idp.Spec.CertificateAuthorityData = ""
+				return idp
+			}()},
+			wantResultingCache: []string{},
+			wantResultingPhases: map[string]idpv1alpha1.ClientCertificateIdentityProviderPhase{
+				"some-idp": idpv1alpha1.ClientCertificatePhaseError,
+			},
+			wantResultingConds: map[string][]metav1.Condition{
+				"some-idp": {
+					happyClaimsCondition,
+					condition(TLSConfigurationValid, metav1.ConditionFalse, "InvalidTLSConfig",
+						"spec.certificateAuthorityData is not valid: must not be empty", now),
+				},
+			},
+		},
+		{
+			name: "invalid claims",
+			inputUpstreams: []runtime.Object{func() runtime.Object {
+				idp := validIDP("some-idp")
+				idp.Spec.Claims.Username = idpv1alpha1.ClientCertificateSubjectOrganization
+				return idp
+			}(), func() runtime.Object {
+				idp := validIDP("other-idp")
+				idp.Spec.Claims.Groups = idpv1alpha1.ClientCertificateSubjectEmailAddress
+				return idp
+			}()},
+			wantResultingCache: []string{},
+			wantResultingPhases: map[string]idpv1alpha1.ClientCertificateIdentityProviderPhase{
+				"some-idp":  idpv1alpha1.ClientCertificatePhaseError,
+				"other-idp": idpv1alpha1.ClientCertificatePhaseError,
+			},
+			wantResultingConds: map[string][]metav1.Condition{
+				"some-idp": {
+					condition(ClaimsValid, metav1.ConditionFalse, "Invalid", `spec.claims.username ("Organization") is not valid`, now),
+					happyTLSCondition,
+				},
+				"other-idp": {
+					condition(ClaimsValid, metav1.ConditionFalse, "Invalid", `spec.claims.groups ("EmailAddress") is not valid`, now),
+					happyTLSCondition,
+				},
+			},
+		},
+		{
+			name:           "error while updating status causes a requeue and the upstream is not cached",
+			inputUpstreams: []runtime.Object{validIDP("some-idp")},
+			configureClient: func(client *supervisorfake.Clientset) {
+				client.PrependReactor("update", "clientcertificateidentityproviders", func(action coretesting.Action) (bool, runtime.Object, error) {
+					return true, nil, errors.New("some update error")
+				})
+			},
+			wantErr:            "some update error",
+			wantResultingCache: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fakeSupervisorClient := supervisorfake.NewSimpleClientset(tt.inputUpstreams...)
+			supervisorInformers := supervisorinformers.NewSharedInformerFactory(fakeSupervisorClient, 0)
+			if tt.configureClient != nil {
+				tt.configureClient(fakeSupervisorClient)
+			}
+
+			cache := dynamicupstreamprovider.NewDynamicUpstreamIDPProvider()
+			cache.SetClientCertificateIdentityProviders([]upstreamprovider.UpstreamClientCertificateIdentityProviderI{
+				upstreamclientcert.New(upstreamclientcert.ProviderConfig{Name: "initial-entry-to-remove"}),
+			})
+
+			controller := New(
+				namespace,
+				cache,
+				fakeSupervisorClient,
+				supervisorInformers.IDP().V1alpha1().ClientCertificateIdentityProviders(),
+				plog.TestLogger(t, nil),
+				controllerlib.WithInformer,
+				nil,
+				clocktesting.NewFakeClock(now.Time),
+			)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			supervisorInformers.Start(ctx.Done())
+			controllerlib.TestRunSynchronously(t, controller)
+
+			syncCtx := controllerlib.Context{Context: ctx, Key: controllerlib.Key{}}
+
+			if err := controllerlib.TestSync(t, controller, syncCtx); len(tt.wantErr) > 0 {
+				require.ErrorContains(t, err, controllerlib.ErrSyntheticRequeue.Error())
+				require.ErrorContains(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			// Verify what's in the cache
+			actualCachedNames := []string{}
+			for _, p := range cache.GetClientCertificateIdentityProviders() {
+				actualProvider, ok := p.(*upstreamclientcert.Provider)
+				require.True(t, ok)
+				require.NotNil(t, actualProvider.GetConfig().Roots)
+				require.Equal(t, idpv1alpha1.ClientCertificateSubjectCommonName, actualProvider.GetConfig().UsernameField)
+				require.Equal(t, idpv1alpha1.ClientCertificateSubjectOrganization, actualProvider.GetConfig().GroupsField)
+				actualCachedNames = append(actualCachedNames, actualProvider.GetResourceName())
+			}
+			require.Equal(t, tt.wantResultingCache, actualCachedNames)
+
+			if tt.wantNoStatusUpdates {
+				for _, action := range fakeSupervisorClient.Actions() {
+					require.NotEqual(t, "update", action.GetVerb(), "should not have updated any status")
+				}
+			}
+
+			// Verify the status as reported in Kubernetes
+			for name, wantPhase := range tt.wantResultingPhases {
+				actual, err := fakeSupervisorClient.IDPV1alpha1().ClientCertificateIdentityProviders(namespace).Get(ctx, name, metav1.GetOptions{})
+				require.NoError(t, err)
+				require.Equal(t, wantPhase, actual.Status.Phase)
+				require.Equal(t, tt.wantResultingConds[name], actual.Status.Conditions)
+			}
+		})
+	}
+}
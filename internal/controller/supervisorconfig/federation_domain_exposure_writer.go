@@ -0,0 +1,836 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package supervisorconfig
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	networkingv1informers "k8s.io/client-go/informers/networking/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/clock"
+	"k8s.io/utils/ptr"
+
+	configconditions "go.pinniped.dev/generated/latest/apis/supervisor/config/conditions"
+	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
+	supervisorclientset "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned"
+	configinformers "go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions/config/v1alpha1"
+	pinnipedcontroller "go.pinniped.dev/internal/controller"
+	"go.pinniped.dev/internal/controller/conditionsutil"
+	"go.pinniped.dev/internal/controllerlib"
+	"go.pinniped.dev/internal/federationdomain/oidc"
+	"go.pinniped.dev/internal/groupsuffix"
+	"go.pinniped.dev/internal/plog"
+)
+
+const (
+	federationDomainExposureWriterControllerName = "federation-domain-exposure-writer-controller"
+
+	// federationDomainExposureResyncInterval is how often the reachability of the issuers is checked. HTTPRoutes,
+	// TLSRoutes and Gateways are also polled rather than watched, because the Gateway API is optional.
+	federationDomainExposureResyncInterval = time.Minute
+
+	// federationDomainReachabilityTimeout is the maximum duration of one reachability check.
+	federationDomainReachabilityTimeout = 10 * time.Second
+
+	externalDNSHostnameAnnotation = "external-dns.alpha.kubernetes.io/hostname"
+	externalDNSTargetAnnotation   = "external-dns.alpha.kubernetes.io/target"
+	externalDNSTTLAnnotation      = "external-dns.alpha.kubernetes.io/ttl"
+)
+
+//nolint:gochecknoglobals
+var (
+	httpRouteGVR = schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "httproutes"}
+	tlsRouteGVR  = schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1alpha2", Resource: "tlsroutes"}
+	gatewayGVR   = schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "gateways"}
+)
+
+// routeManagedSpecFields are the fields of the spec of an HTTPRoute or TLSRoute which are written by this controller.
+//
+//nolint:gochecknoglobals
+var routeManagedSpecFields = []string{"parentRefs", "hostnames", "rules"}
+
+// gatewayRoute describes a kind of Gateway API route which can be managed for a FederationDomain.
+type gatewayRoute struct {
+	kind string
+	gvr  schema.GroupVersionResource
+	// listenerProtocol and listenerTLSMode are required of the listeners of the Gateways to which the route is attached.
+	listenerProtocol string
+	listenerTLSMode  string
+	// installHint is added to the message of the condition when the API of the route is not installed.
+	installHint string
+}
+
+//nolint:gochecknoglobals
+var (
+	// The Gateway terminates TLS, and forwards the HTTP requests to the Service.
+	httpRouteKind = gatewayRoute{
+		kind:             "HTTPRoute",
+		gvr:              httpRouteGVR,
+		listenerProtocol: "HTTPS",
+		listenerTLSMode:  "Terminate",
+		installHint:      "is the Gateway API installed?",
+	}
+	// The Gateway forwards the TLS connections to the Service, so TLS is terminated by the Supervisor.
+	tlsRouteKind = gatewayRoute{
+		kind:             "TLSRoute",
+		gvr:              tlsRouteGVR,
+		listenerProtocol: "TLS",
+		listenerTLSMode:  "Passthrough",
+		installHint:      "is the experimental channel of the Gateway API installed?",
+	}
+)
+
+type federationDomainExposureWriterController struct {
+	apiGroupSuffix           string
+	kubeClient               kubernetes.Interface
+	dynamicClient            dynamic.Interface
+	pinnipedClient           supervisorclientset.Interface
+	ingressInformer          networkingv1informers.IngressInformer
+	federationDomainInformer configinformers.FederationDomainInformer
+	httpClient               *http.Client
+	clock                    clock.Clock
+}
+
+// NewFederationDomainExposureWriterController returns a controllerlib.Controller which creates and updates the
+// Ingresses, HTTPRoutes and TLSRoutes requested by the spec.exposure of FederationDomains, and periodically checks
+// whether the discovery endpoints of their issuers can be reached using the provided httpClient. The results are
+// reported in the ExposureReady and IssuerExternallyReachable conditions of the FederationDomains. For routes,
+// the ExposureReady condition also reports whether the listeners of their Gateways are compatible with the
+// route, and whether the Gateways have accepted the route.
+//
+// Ingresses are written with the kubeClient, which is subject to leader election. The dynamicClient is not,
+// so creates of an existing route fail, and updates are conditional on the resource version of the read.
+func NewFederationDomainExposureWriterController(
+	apiGroupSuffix string,
+	kubeClient kubernetes.Interface,
+	dynamicClient dynamic.Interface,
+	pinnipedClient supervisorclientset.Interface,
+	ingressInformer networkingv1informers.IngressInformer,
+	federationDomainInformer configinformers.FederationDomainInformer,
+	httpClient *http.Client,
+	clock clock.Clock,
+	withInformer pinnipedcontroller.WithInformerOptionFunc,
+) controllerlib.Controller {
+	return controllerlib.New(
+		controllerlib.Config{
+			Name: federationDomainExposureWriterControllerName,
+			Syncer: &federationDomainExposureWriterController{
+				apiGroupSuffix:           apiGroupSuffix,
+				kubeClient:               kubeClient,
+				dynamicClient:            dynamicClient,
+				pinnipedClient:           pinnipedClient,
+				ingressInformer:          ingressInformer,
+				federationDomainInformer: federationDomainInformer,
+				httpClient:               httpClient,
+				clock:                    clock,
+			},
+		},
+		// We want to be notified when an Ingress which was created for a FederationDomain changes.
+		withInformer(
+			ingressInformer,
+			pinnipedcontroller.SimpleFilter(isFederationDomainControllee, pinnipedcontroller.SecretIsControlledByParentFunc(isFederationDomainControllee)),
+			controllerlib.InformerOption{},
+		),
+		withInformer(
+			federationDomainInformer,
+			pinnipedcontroller.MatchAnythingFilter(nil), // nil parent func is fine because each event is distinct
+			controllerlib.InformerOption{},
+		),
+	)
+}
+
+// Sync implements controllerlib.Syncer.
+func (c *federationDomainExposureWriterController) Sync(ctx controllerlib.Context) error {
+	federationDomain, err := c.federationDomainInformer.Lister().FederationDomains(ctx.Key.Namespace).Get(ctx.Key.Name)
+	if apierrors.IsNotFound(err) {
+		// Any Ingress or route which was created for this FederationDomain is garbage collected because of its owner reference.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get %s/%s FederationDomain: %w", ctx.Key.Namespace, ctx.Key.Name, err)
+	}
+
+	exposure := federationDomain.Spec.Exposure
+	route, parentRefs, annotations := exposureRoute(exposure)
+
+	// Only look for an Ingress or route to clean up when this FederationDomain has used spec.exposure before,
+	// to avoid making requests for the many FederationDomains which never use it.
+	if configconditions.FindCondition(federationDomain.Status.Conditions, configconditions.TypeExposureReady) != nil {
+		if exposure == nil || exposure.Ingress == nil {
+			if err := c.deleteOwnedIngress(ctx.Context, federationDomain); err != nil {
+				return err
+			}
+		}
+		for _, unused := range []*gatewayRoute{&httpRouteKind, &tlsRouteKind} {
+			if unused == route {
+				continue
+			}
+			if err := c.deleteOwnedRoute(ctx.Context, federationDomain, unused); err != nil {
+				return err
+			}
+		}
+	}
+
+	if exposure == nil {
+		return c.updateStatus(ctx.Context, federationDomain, nil)
+	}
+
+	issuerURL, err := url.Parse(federationDomain.Spec.Issuer)
+	if err != nil || issuerURL.Hostname() == "" {
+		return c.updateStatus(ctx.Context, federationDomain, []*metav1.Condition{
+			{
+				Type:    configconditions.TypeExposureReady,
+				Status:  metav1.ConditionFalse,
+				Reason:  configconditions.ReasonInvalidIssuerURL,
+				Message: "cannot route traffic to an invalid issuer URL",
+			},
+			reachabilityUnknownCondition(),
+		})
+	}
+
+	var exposureCondition *metav1.Condition
+	if route == nil {
+		exposureCondition, err = c.createOrUpdateIngress(ctx.Context, federationDomain, issuerURL)
+	} else {
+		exposureCondition, err = c.createOrUpdateRoute(ctx.Context, federationDomain, issuerURL, route, parentRefs, annotations)
+	}
+	if err != nil {
+		return err
+	}
+
+	reachableCondition := reachabilityUnknownCondition()
+	if exposureCondition.Status == metav1.ConditionTrue {
+		reachableCondition = c.checkReachability(ctx.Context, federationDomain.Spec.Issuer)
+	}
+
+	if err := c.updateStatus(ctx.Context, federationDomain, []*metav1.Condition{exposureCondition, reachableCondition}); err != nil {
+		return err
+	}
+
+	ctx.Queue.AddAfter(ctx.Key, federationDomainExposureResyncInterval)
+	return nil
+}
+
+func (c *federationDomainExposureWriterController) createOrUpdateIngress(
+	ctx context.Context,
+	federationDomain *supervisorconfigv1alpha1.FederationDomain,
+	issuerURL *url.URL,
+) (*metav1.Condition, error) {
+	desired := c.desiredIngress(federationDomain, issuerURL)
+	ingresses := c.kubeClient.NetworkingV1().Ingresses(federationDomain.Namespace)
+
+	ingress, err := c.ingressInformer.Lister().Ingresses(federationDomain.Namespace).Get(federationDomain.Name)
+	switch {
+	case apierrors.IsNotFound(err):
+		if _, err := ingresses.Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+			return nil, fmt.Errorf("cannot create Ingress %s/%s: %w", federationDomain.Namespace, federationDomain.Name, err)
+		}
+		plog.Info("created Ingress for FederationDomain", "namespace", federationDomain.Namespace, "name", federationDomain.Name)
+
+	case err != nil:
+		return nil, fmt.Errorf("cannot get Ingress %s/%s: %w", federationDomain.Namespace, federationDomain.Name, err)
+
+	case !metav1.IsControlledBy(ingress, federationDomain):
+		return exposureNotOwnedCondition("Ingress", ingress.Name), nil
+
+	case !equality.Semantic.DeepEqual(ingress.Spec, desired.Spec) || !maps.Equal(ingress.Annotations, desired.Annotations):
+		updated := ingress.DeepCopy()
+		updated.Spec = desired.Spec
+		updated.Annotations = desired.Annotations
+		if _, err := ingresses.Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+			return nil, fmt.Errorf("cannot update Ingress %s/%s: %w", federationDomain.Namespace, federationDomain.Name, err)
+		}
+		plog.Info("updated Ingress for FederationDomain", "namespace", federationDomain.Namespace, "name", federationDomain.Name)
+	}
+
+	return &metav1.Condition{
+		Type:    configconditions.TypeExposureReady,
+		Status:  metav1.ConditionTrue,
+		Reason:  configconditions.ReasonSuccess,
+		Message: fmt.Sprintf("the Ingress %q routes the issuer to the Service %q", federationDomain.Name, federationDomain.Spec.Exposure.Service.Name),
+	}, nil
+}
+
+func (c *federationDomainExposureWriterController) createOrUpdateRoute(
+	ctx context.Context,
+	federationDomain *supervisorconfigv1alpha1.FederationDomain,
+	issuerURL *url.URL,
+	route *gatewayRoute,
+	parentRefs []supervisorconfigv1alpha1.FederationDomainGatewayParentRef,
+	annotations map[string]string,
+) (*metav1.Condition, error) {
+	if route == &tlsRouteKind && net.ParseIP(issuerURL.Hostname()) != nil {
+		return &metav1.Condition{
+			Type:    configconditions.TypeExposureReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  configconditions.ReasonInvalidIssuerURL,
+			Message: "a TLSRoute matches the server name of TLS connections, so it cannot route traffic to an issuer URL with an IP address",
+		}, nil
+	}
+
+	desiredSpec := desiredRouteSpec(federationDomain, issuerURL, route, parentRefs)
+	desiredAnnotations := desiredExposureAnnotations(federationDomain.Spec.Exposure, annotations, issuerURL)
+
+	routes := c.dynamicClient.Resource(route.gvr).Namespace(federationDomain.Namespace)
+
+	existing, err := routes.Get(ctx, federationDomain.Name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		existing, err = routes.Create(ctx, c.newRoute(federationDomain, route, desiredAnnotations, desiredSpec), metav1.CreateOptions{})
+		if apierrors.IsNotFound(err) {
+			return &metav1.Condition{
+				Type:    configconditions.TypeExposureReady,
+				Status:  metav1.ConditionFalse,
+				Reason:  configconditions.ReasonGatewayAPINotInstalled,
+				Message: fmt.Sprintf("cannot create the %s: the %s API was not found, %s", route.kind, route.kind, route.installHint),
+			}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot create %s %s/%s: %w", route.kind, federationDomain.Namespace, federationDomain.Name, err)
+		}
+		plog.Info("created "+route.kind+" for FederationDomain", "namespace", federationDomain.Namespace, "name", federationDomain.Name)
+
+	case err != nil:
+		return nil, fmt.Errorf("cannot get %s %s/%s: %w", route.kind, federationDomain.Namespace, federationDomain.Name, err)
+
+	case !metav1.IsControlledBy(existing, federationDomain):
+		return exposureNotOwnedCondition(route.kind, existing.GetName()), nil
+
+	default:
+		existingSpec, _, _ := unstructured.NestedMap(existing.Object, "spec")
+		if !specHasManagedFields(routeManagedSpecFields, existingSpec, desiredSpec) || !maps.Equal(existing.GetAnnotations(), desiredAnnotations) {
+			updated := existing.DeepCopy()
+			if existingSpec == nil {
+				existingSpec = map[string]any{}
+			}
+			for _, field := range routeManagedSpecFields {
+				delete(existingSpec, field)
+			}
+			for field, value := range desiredSpec {
+				existingSpec[field] = value
+			}
+			if err := unstructured.SetNestedMap(updated.Object, existingSpec, "spec"); err != nil {
+				return nil, fmt.Errorf("cannot update %s %s/%s: %w", route.kind, federationDomain.Namespace, federationDomain.Name, err)
+			}
+			updated.SetAnnotations(desiredAnnotations)
+			// The update is conditional on the resource version which was read above.
+			existing, err = routes.Update(ctx, updated, metav1.UpdateOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("cannot update %s %s/%s: %w", route.kind, federationDomain.Namespace, federationDomain.Name, err)
+			}
+			plog.Info("updated "+route.kind+" for FederationDomain", "namespace", federationDomain.Namespace, "name", federationDomain.Name)
+		}
+	}
+
+	return c.routeReadyCondition(ctx, federationDomain, issuerURL, route, parentRefs, existing)
+}
+
+// routeReadyCondition checks that each Gateway of the route has a listener which is compatible with the route,
+// and that each Gateway has accepted the route.
+func (c *federationDomainExposureWriterController) routeReadyCondition(
+	ctx context.Context,
+	federationDomain *supervisorconfigv1alpha1.FederationDomain,
+	issuerURL *url.URL,
+	route *gatewayRoute,
+	parentRefs []supervisorconfigv1alpha1.FederationDomainGatewayParentRef,
+	routeObj *unstructured.Unstructured,
+) (*metav1.Condition, error) {
+	notReady := func(reason, message string) *metav1.Condition {
+		return &metav1.Condition{
+			Type:    configconditions.TypeExposureReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  reason,
+			Message: message,
+		}
+	}
+
+	for _, parentRef := range parentRefs {
+		namespace := cmp.Or(parentRef.Namespace, federationDomain.Namespace)
+
+		gateway, err := c.dynamicClient.Resource(gatewayGVR).Namespace(namespace).Get(ctx, parentRef.Name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			return notReady(configconditions.ReasonGatewayNotFound,
+				fmt.Sprintf("the Gateway %s/%s was not found", namespace, parentRef.Name)), nil
+		case apierrors.IsForbidden(err):
+			// The Supervisor may only read the Gateways in its own namespace. The acceptance of the route
+			// by other Gateways is still checked below, which also fails when their listeners are incompatible.
+			plog.Debug("skipping the check of the listeners of a Gateway which cannot be read",
+				"namespace", namespace, "name", parentRef.Name, "error", err)
+		case err != nil:
+			return nil, fmt.Errorf("cannot get Gateway %s/%s: %w", namespace, parentRef.Name, err)
+		default:
+			if message := route.listenerIncompatibility(gateway, parentRef.SectionName, issuerURL.Hostname()); message != "" {
+				return notReady(configconditions.ReasonGatewayListenerIncompatible, message), nil
+			}
+		}
+
+		if message := routeNotAcceptedMessage(routeObj, route, namespace, parentRef); message != "" {
+			return notReady(configconditions.ReasonRouteNotAccepted, message), nil
+		}
+	}
+
+	return &metav1.Condition{
+		Type:   configconditions.TypeExposureReady,
+		Status: metav1.ConditionTrue,
+		Reason: configconditions.ReasonSuccess,
+		Message: fmt.Sprintf("the %s %q routes the issuer to the Service %q",
+			route.kind, federationDomain.Name, federationDomain.Spec.Exposure.Service.Name),
+	}, nil
+}
+
+// deleteOwnedIngress deletes the Ingress which was created for the FederationDomain, if any.
+func (c *federationDomainExposureWriterController) deleteOwnedIngress(
+	ctx context.Context,
+	federationDomain *supervisorconfigv1alpha1.FederationDomain,
+) error {
+	ingress, err := c.ingressInformer.Lister().Ingresses(federationDomain.Namespace).Get(federationDomain.Name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot get Ingress %s/%s: %w", federationDomain.Namespace, federationDomain.Name, err)
+	}
+	if !metav1.IsControlledBy(ingress, federationDomain) {
+		return nil
+	}
+
+	err = c.kubeClient.NetworkingV1().Ingresses(federationDomain.Namespace).Delete(ctx, ingress.Name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &ingress.UID},
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("cannot delete Ingress %s/%s: %w", federationDomain.Namespace, federationDomain.Name, err)
+	}
+	plog.Info("deleted Ingress which is no longer used by FederationDomain",
+		"namespace", federationDomain.Namespace, "name", federationDomain.Name)
+	return nil
+}
+
+// deleteOwnedRoute deletes the route of the given kind which was created for the FederationDomain, if any.
+func (c *federationDomainExposureWriterController) deleteOwnedRoute(
+	ctx context.Context,
+	federationDomain *supervisorconfigv1alpha1.FederationDomain,
+	route *gatewayRoute,
+) error {
+	routes := c.dynamicClient.Resource(route.gvr).Namespace(federationDomain.Namespace)
+
+	existing, err := routes.Get(ctx, federationDomain.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot get %s %s/%s: %w", route.kind, federationDomain.Namespace, federationDomain.Name, err)
+	}
+	if !metav1.IsControlledBy(existing, federationDomain) {
+		return nil
+	}
+
+	uid := existing.GetUID()
+	err = routes.Delete(ctx, existing.GetName(), metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &uid}})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("cannot delete %s %s/%s: %w", route.kind, federationDomain.Namespace, federationDomain.Name, err)
+	}
+	plog.Info("deleted "+route.kind+" which is no longer used by FederationDomain",
+		"namespace", federationDomain.Namespace, "name", federationDomain.Name)
+	return nil
+}
+
+// checkReachability fetches the discovery document of the issuer using its public URL.
+func (c *federationDomainExposureWriterController) checkReachability(ctx context.Context, issuer string) *metav1.Condition {
+	unreachable := func(format string, args ...any) *metav1.Condition {
+		return &metav1.Condition{
+			Type:    configconditions.TypeIssuerExternallyReachable,
+			Status:  metav1.ConditionFalse,
+			Reason:  configconditions.ReasonIssuerUnreachable,
+			Message: fmt.Sprintf("the discovery endpoint of the issuer "+format, args...),
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, federationDomainReachabilityTimeout)
+	defer cancel()
+
+	discoveryURL := strings.TrimSuffix(issuer, "/") + oidc.WellKnownEndpointPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return unreachable("could not be requested: %s", err)
+	}
+	rsp, err := c.httpClient.Do(req)
+	if err != nil {
+		return unreachable("could not be reached: %s", err)
+	}
+	defer func() { _ = rsp.Body.Close() }()
+
+	if rsp.StatusCode != http.StatusOK {
+		return unreachable("returned unexpected status code %d", rsp.StatusCode)
+	}
+	var discovery struct {
+		Issuer string `json:"issuer"`
+	}
+	if err := json.NewDecoder(rsp.Body).Decode(&discovery); err != nil {
+		return unreachable("returned an invalid response: %s", err)
+	}
+	if discovery.Issuer != issuer {
+		return unreachable("returned the discovery document of a different issuer %q, is the traffic routed to this Supervisor?", discovery.Issuer)
+	}
+
+	return &metav1.Condition{
+		Type:    configconditions.TypeIssuerExternallyReachable,
+		Status:  metav1.ConditionTrue,
+		Reason:  configconditions.ReasonSuccess,
+		Message: fmt.Sprintf("the discovery endpoint of the issuer was reached at %q", discoveryURL),
+	}
+}
+
+func (c *federationDomainExposureWriterController) desiredIngress(
+	federationDomain *supervisorconfigv1alpha1.FederationDomain,
+	issuerURL *url.URL,
+) *networkingv1.Ingress {
+	exposure := federationDomain.Spec.Exposure
+	host := issuerURL.Hostname()
+
+	rule := networkingv1.IngressRule{
+		IngressRuleValue: networkingv1.IngressRuleValue{
+			HTTP: &networkingv1.HTTPIngressRuleValue{
+				Paths: []networkingv1.HTTPIngressPath{{
+					Path:     issuerPathPrefix(issuerURL),
+					PathType: ptr.To(networkingv1.PathTypePrefix),
+					Backend: networkingv1.IngressBackend{
+						Service: &networkingv1.IngressServiceBackend{
+							Name: exposure.Service.Name,
+							Port: networkingv1.ServiceBackendPort{Number: exposure.Service.Port},
+						},
+					},
+				}},
+			},
+		},
+	}
+	// The host of an Ingress rule must be a DNS name, so a rule for an IP address matches all hosts.
+	if net.ParseIP(host) == nil {
+		rule.Host = host
+	}
+
+	spec := networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{rule}}
+	if exposure.Ingress.IngressClassName != "" {
+		spec.IngressClassName = ptr.To(exposure.Ingress.IngressClassName)
+	}
+	if federationDomain.Spec.TLS != nil && federationDomain.Spec.TLS.SecretName != "" && rule.Host != "" {
+		spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{host}, SecretName: federationDomain.Spec.TLS.SecretName}}
+	}
+
+	return &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        federationDomain.Name,
+			Namespace:   federationDomain.Namespace,
+			Annotations: desiredExposureAnnotations(exposure, exposure.Ingress.Annotations, issuerURL),
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(federationDomain, schema.GroupVersionKind{
+					Group:   supervisorconfigv1alpha1.SchemeGroupVersion.Group,
+					Version: supervisorconfigv1alpha1.SchemeGroupVersion.Version,
+					Kind:    federationDomainKind,
+				}),
+			},
+		},
+		Spec: spec,
+	}
+}
+
+func (c *federationDomainExposureWriterController) newRoute(
+	federationDomain *supervisorconfigv1alpha1.FederationDomain,
+	route *gatewayRoute,
+	annotations map[string]string,
+	spec map[string]any,
+) *unstructured.Unstructured {
+	// The dynamic client does not add the API group suffix to owner references, unlike the generated clients.
+	federationDomainGroup, _ := groupsuffix.Replace(supervisorconfigv1alpha1.SchemeGroupVersion.Group, c.apiGroupSuffix)
+
+	routeObj := &unstructured.Unstructured{Object: map[string]any{"spec": spec}}
+	routeObj.SetAPIVersion(route.gvr.GroupVersion().String())
+	routeObj.SetKind(route.kind)
+	routeObj.SetName(federationDomain.Name)
+	routeObj.SetNamespace(federationDomain.Namespace)
+	routeObj.SetAnnotations(annotations)
+	routeObj.SetOwnerReferences([]metav1.OwnerReference{
+		*metav1.NewControllerRef(federationDomain, schema.GroupVersionKind{
+			Group:   federationDomainGroup,
+			Version: supervisorconfigv1alpha1.SchemeGroupVersion.Version,
+			Kind:    federationDomainKind,
+		}),
+	})
+	return routeObj
+}
+
+func (c *federationDomainExposureWriterController) updateStatus(
+	ctx context.Context,
+	federationDomain *supervisorconfigv1alpha1.FederationDomain,
+	conditions []*metav1.Condition, // nil removes the conditions
+) error {
+	updated := federationDomain.DeepCopy()
+	if conditions == nil {
+		updated.Status.Conditions = slices.DeleteFunc(updated.Status.Conditions, func(c metav1.Condition) bool {
+			return c.Type == configconditions.TypeExposureReady || c.Type == configconditions.TypeIssuerExternallyReachable
+		})
+	} else {
+		_ = conditionsutil.MergeConditions(conditions, federationDomain.Generation, &updated.Status.Conditions,
+			plog.New().WithName(federationDomainExposureWriterControllerName), metav1.NewTime(c.clock.Now()))
+	}
+
+	if equality.Semantic.DeepEqual(federationDomain, updated) {
+		return nil
+	}
+
+	_, err := c.pinnipedClient.ConfigV1alpha1().FederationDomains(federationDomain.Namespace).UpdateStatus(ctx, updated, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("cannot update status of FederationDomain %s/%s: %w", federationDomain.Namespace, federationDomain.Name, err)
+	}
+	return nil
+}
+
+// desiredRouteSpec returns the managed fields of the spec of the HTTPRoute or TLSRoute. The values which would
+// otherwise be defaulted by the API server are included, so that the spec can be compared to the spec which was
+// read back.
+func desiredRouteSpec(
+	federationDomain *supervisorconfigv1alpha1.FederationDomain,
+	issuerURL *url.URL,
+	route *gatewayRoute,
+	parentRefs []supervisorconfigv1alpha1.FederationDomainGatewayParentRef,
+) map[string]any {
+	exposure := federationDomain.Spec.Exposure
+
+	refs := make([]any, 0, len(parentRefs))
+	for _, parentRef := range parentRefs {
+		ref := map[string]any{
+			"group": gatewayGVR.Group,
+			"kind":  "Gateway",
+			"name":  parentRef.Name,
+		}
+		if parentRef.Namespace != "" {
+			ref["namespace"] = parentRef.Namespace
+		}
+		if parentRef.SectionName != "" {
+			ref["sectionName"] = parentRef.SectionName
+		}
+		refs = append(refs, ref)
+	}
+
+	rule := map[string]any{
+		"backendRefs": []any{map[string]any{
+			"group":  "",
+			"kind":   "Service",
+			"name":   exposure.Service.Name,
+			"port":   int64(exposure.Service.Port),
+			"weight": int64(1),
+		}},
+	}
+	// A TLSRoute cannot match paths, because the Gateway does not look inside of the TLS connections.
+	if route == &httpRouteKind {
+		rule["matches"] = []any{map[string]any{
+			"path": map[string]any{"type": "PathPrefix", "value": issuerPathPrefix(issuerURL)},
+		}}
+	}
+
+	spec := map[string]any{
+		"parentRefs": refs,
+		"rules":      []any{rule},
+	}
+	// The hostnames of a route must be DNS names, so an HTTPRoute for an IP address matches all hosts.
+	if host := issuerURL.Hostname(); net.ParseIP(host) == nil {
+		spec["hostnames"] = []any{host}
+	}
+	return spec
+}
+
+// desiredExposureAnnotations returns the annotations of an Ingress or route, which are the annotations
+// configured by the admin and the external-dns annotations.
+func desiredExposureAnnotations(
+	exposure *supervisorconfigv1alpha1.FederationDomainExposureSpec,
+	configured map[string]string,
+	issuerURL *url.URL,
+) map[string]string {
+	annotations := maps.Clone(configured)
+	host := issuerURL.Hostname()
+	if exposure.ExternalDNS == nil || net.ParseIP(host) != nil {
+		return annotations
+	}
+
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[externalDNSHostnameAnnotation] = host
+	if exposure.ExternalDNS.Target != "" {
+		annotations[externalDNSTargetAnnotation] = exposure.ExternalDNS.Target
+	}
+	if exposure.ExternalDNS.TTL != 0 {
+		annotations[externalDNSTTLAnnotation] = strconv.Itoa(int(exposure.ExternalDNS.TTL))
+	}
+	return annotations
+}
+
+// issuerPathPrefix returns the path prefix under which all endpoints of the issuer are served.
+func issuerPathPrefix(issuerURL *url.URL) string {
+	path := strings.TrimSuffix(issuerURL.Path, "/")
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+// exposureRoute returns the kind, the parent references and the annotations of the route which is requested by
+// the exposure, or a nil route when no route is requested.
+func exposureRoute(exposure *supervisorconfigv1alpha1.FederationDomainExposureSpec) (
+	*gatewayRoute,
+	[]supervisorconfigv1alpha1.FederationDomainGatewayParentRef,
+	map[string]string,
+) {
+	switch {
+	case exposure == nil:
+		return nil, nil, nil
+	case exposure.HTTPRoute != nil:
+		return &httpRouteKind, exposure.HTTPRoute.ParentRefs, exposure.HTTPRoute.Annotations
+	case exposure.TLSRoute != nil:
+		return &tlsRouteKind, exposure.TLSRoute.ParentRefs, exposure.TLSRoute.Annotations
+	default:
+		return nil, nil, nil
+	}
+}
+
+// listenerIncompatibility returns why the listeners of the Gateway which are selected by the sectionName cannot
+// serve the route for the host, or an empty string when at least one of them can.
+func (r *gatewayRoute) listenerIncompatibility(gateway *unstructured.Unstructured, sectionName, host string) string {
+	gatewayName := gateway.GetNamespace() + "/" + gateway.GetName()
+	listeners, _, _ := unstructured.NestedSlice(gateway.Object, "spec", "listeners")
+
+	for _, l := range listeners {
+		listener, ok := l.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(listener, "name")
+		if sectionName != "" && name != sectionName {
+			continue
+		}
+
+		protocol, _, _ := unstructured.NestedString(listener, "protocol")
+		// Terminate is the default TLS mode of the Gateway API.
+		tlsMode := "Terminate"
+		if mode, found, _ := unstructured.NestedString(listener, "tls", "mode"); found && mode != "" {
+			tlsMode = mode
+		}
+		hostname, _, _ := unstructured.NestedString(listener, "hostname")
+
+		if protocol == r.listenerProtocol && tlsMode == r.listenerTLSMode && listenerHostnameMatches(hostname, host) {
+			return ""
+		}
+		if sectionName != "" {
+			return fmt.Sprintf("the listener %q of the Gateway %s cannot serve the %s for the hostname %q: "+
+				"a listener with protocol %s, TLS mode %s and a matching hostname is required, "+
+				"but the listener has protocol %s and TLS mode %s",
+				name, gatewayName, r.kind, host, r.listenerProtocol, r.listenerTLSMode, protocol, tlsMode)
+		}
+	}
+
+	if sectionName != "" {
+		return fmt.Sprintf("the Gateway %s has no listener named %q", gatewayName, sectionName)
+	}
+	return fmt.Sprintf("the Gateway %s has no listener which can serve the %s for the hostname %q: "+
+		"a listener with protocol %s, TLS mode %s and a matching hostname is required",
+		gatewayName, r.kind, host, r.listenerProtocol, r.listenerTLSMode)
+}
+
+// listenerHostnameMatches returns whether the hostname of a Gateway listener, which may be empty or a wildcard,
+// matches the host.
+func listenerHostnameMatches(listenerHostname, host string) bool {
+	switch {
+	case listenerHostname == "":
+		return true
+	case strings.HasPrefix(listenerHostname, "*."):
+		// A wildcard matches one or more labels.
+		return strings.HasSuffix(host, listenerHostname[1:])
+	default:
+		return listenerHostname == host
+	}
+}
+
+// routeNotAcceptedMessage returns why the status of the route does not show that it was accepted by the Gateway,
+// or an empty string when it was accepted.
+func routeNotAcceptedMessage(
+	routeObj *unstructured.Unstructured,
+	route *gatewayRoute,
+	gatewayNamespace string,
+	parentRef supervisorconfigv1alpha1.FederationDomainGatewayParentRef,
+) string {
+	parents, _, _ := unstructured.NestedSlice(routeObj.Object, "status", "parents")
+
+	for _, p := range parents {
+		parent, ok := p.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(parent, "parentRef", "name")
+		namespace, _, _ := unstructured.NestedString(parent, "parentRef", "namespace")
+		sectionName, _, _ := unstructured.NestedString(parent, "parentRef", "sectionName")
+		if name != parentRef.Name || cmp.Or(namespace, routeObj.GetNamespace()) != gatewayNamespace || sectionName != parentRef.SectionName {
+			continue
+		}
+
+		conditions, _, _ := unstructured.NestedSlice(parent, "conditions")
+		for _, cond := range conditions {
+			condition, ok := cond.(map[string]any)
+			if !ok || condition["type"] != "Accepted" {
+				continue
+			}
+			if condition["status"] == string(metav1.ConditionTrue) {
+				return ""
+			}
+			return fmt.Sprintf("the Gateway %s/%s did not accept the %s: %v: %v",
+				gatewayNamespace, parentRef.Name, route.kind, condition["reason"], condition["message"])
+		}
+	}
+
+	return fmt.Sprintf("the %s has not been accepted by the Gateway %s/%s yet", route.kind, gatewayNamespace, parentRef.Name)
+}
+
+func exposureNotOwnedCondition(kind, name string) *metav1.Condition {
+	return &metav1.Condition{
+		Type:   configconditions.TypeExposureReady,
+		Status: metav1.ConditionFalse,
+		Reason: configconditions.ReasonExposureNotOwned,
+		Message: fmt.Sprintf("the %s %q already exists and was not created for this FederationDomain: delete it, "+
+			"or remove spec.exposure and manage the routing to the Supervisor by other means", kind, name),
+	}
+}
+
+func reachabilityUnknownCondition() *metav1.Condition {
+	return &metav1.Condition{
+		Type:    configconditions.TypeIssuerExternallyReachable,
+		Status:  metav1.ConditionUnknown,
+		Reason:  configconditions.ReasonUnableToValidate,
+		Message: "unable to check the reachability of the issuer until the ExposureReady condition is true",
+	}
+}
+
+// isFederationDomainControllee returns whether the provided obj is controlled by a FederationDomain.
+func isFederationDomainControllee(obj metav1.Object) bool {
+	controller := metav1.GetControllerOf(obj)
+	return controller != nil &&
+		controller.APIVersion == supervisorconfigv1alpha1.SchemeGroupVersion.String() &&
+		controller.Kind == federationDomainKind
+}
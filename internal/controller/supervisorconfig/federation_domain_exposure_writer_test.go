@@ -0,0 +1,793 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package supervisorconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"maps"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubeinformers "k8s.io/client-go/informers"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	kubetesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+
+	configconditions "go.pinniped.dev/generated/latest/apis/supervisor/config/conditions"
+	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
+	supervisorfake "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/fake"
+	supervisorinformers "go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions"
+	"go.pinniped.dev/internal/controllerlib"
+	"go.pinniped.dev/internal/testutil"
+)
+
+func TestFederationDomainExposureWriterControllerInformerFilters(t *testing.T) {
+	observableWithInformerOption := testutil.NewObservableWithInformerOption()
+	ingressInformer := kubeinformers.NewSharedInformerFactory(nil, 0).Networking().V1().Ingresses()
+	federationDomainInformer := supervisorinformers.NewSharedInformerFactory(nil, 0).Config().V1alpha1().FederationDomains()
+	_ = NewFederationDomainExposureWriterController(
+		"pinniped.dev",
+		nil,
+		nil,
+		nil,
+		ingressInformer,
+		federationDomainInformer,
+		nil,
+		clocktesting.NewFakeClock(time.Now()),
+		observableWithInformerOption.WithInformer, // make it possible to observe the behavior of the Filters
+	)
+
+	federationDomain := &supervisorconfigv1alpha1.FederationDomain{ObjectMeta: metav1.ObjectMeta{Name: "any-name", Namespace: "any-namespace"}}
+	otherFederationDomain := &supervisorconfigv1alpha1.FederationDomain{ObjectMeta: metav1.ObjectMeta{Name: "any-other-name", Namespace: "any-other-namespace"}}
+	federationDomainFilter := observableWithInformerOption.GetFilterForInformer(federationDomainInformer)
+	require.True(t, federationDomainFilter.Add(federationDomain))
+	require.True(t, federationDomainFilter.Update(federationDomain, otherFederationDomain))
+	require.True(t, federationDomainFilter.Delete(federationDomain))
+
+	ownedIngress := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{
+		Name: "some-fd", Namespace: "some-namespace",
+		OwnerReferences: []metav1.OwnerReference{{
+			APIVersion: "config.supervisor.pinniped.dev/v1alpha1", Kind: "FederationDomain", Name: "some-fd", Controller: ptr.To(true),
+		}},
+	}}
+	unownedIngress := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "some-fd", Namespace: "some-namespace"}}
+	ingressFilter := observableWithInformerOption.GetFilterForInformer(ingressInformer)
+	require.True(t, ingressFilter.Add(ownedIngress))
+	require.True(t, ingressFilter.Update(unownedIngress, ownedIngress))
+	require.True(t, ingressFilter.Delete(ownedIngress))
+	require.False(t, ingressFilter.Add(unownedIngress))
+	require.False(t, ingressFilter.Update(unownedIngress, unownedIngress))
+	require.False(t, ingressFilter.Delete(unownedIngress))
+	require.Equal(t, controllerlib.Key{Namespace: "some-namespace", Name: "some-fd"}, ingressFilter.Parent(ownedIngress))
+}
+
+func TestFederationDomainExposureWriterControllerSync(t *testing.T) {
+	const (
+		namespace = "some-namespace"
+		fdName    = "some-fd"
+		fdUID     = types.UID("some-fd-uid")
+	)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// The discovery endpoint of every issuer is served by this server, except for the issuers with special paths.
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		issuerPath := strings.TrimSuffix(r.URL.Path, "/.well-known/openid-configuration")
+		switch issuerPath {
+		case "/not-found":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		case "/other-supervisor":
+			issuerPath = "/some-other-path"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"issuer": "https://" + r.Host + issuerPath})
+	}))
+	t.Cleanup(server.Close)
+	// Send the requests for all hosts to the server.
+	transport := server.Client().Transport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec // this is a test
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+	}
+	httpClient := &http.Client{Transport: transport}
+
+	federationDomain := func(issuer string, exposure *supervisorconfigv1alpha1.FederationDomainExposureSpec, conditions ...metav1.Condition) *supervisorconfigv1alpha1.FederationDomain {
+		return &supervisorconfigv1alpha1.FederationDomain{
+			ObjectMeta: metav1.ObjectMeta{Name: fdName, Namespace: namespace, UID: fdUID, Generation: 3},
+			Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+				Issuer:   issuer,
+				TLS:      &supervisorconfigv1alpha1.FederationDomainTLSSpec{SecretName: "some-tls-secret"},
+				Exposure: exposure,
+			},
+			Status: supervisorconfigv1alpha1.FederationDomainStatus{Conditions: conditions},
+		}
+	}
+	service := supervisorconfigv1alpha1.FederationDomainExposureServiceRef{Name: "some-service", Port: 443}
+	withIngress := &supervisorconfigv1alpha1.FederationDomainExposureSpec{
+		Service:     service,
+		ExternalDNS: &supervisorconfigv1alpha1.FederationDomainExternalDNSSpec{Target: "lb.example.com", TTL: 60},
+		Ingress: &supervisorconfigv1alpha1.FederationDomainIngressSpec{
+			IngressClassName: "some-class",
+			Annotations:      map[string]string{"nginx.ingress.kubernetes.io/backend-protocol": "HTTPS"},
+		},
+	}
+	parentRefs := []supervisorconfigv1alpha1.FederationDomainGatewayParentRef{
+		{Name: "some-gateway"},
+		{Name: "other-gateway", Namespace: "other-namespace", SectionName: "https"},
+	}
+	withHTTPRoute := &supervisorconfigv1alpha1.FederationDomainExposureSpec{
+		Service:   service,
+		HTTPRoute: &supervisorconfigv1alpha1.FederationDomainHTTPRouteSpec{ParentRefs: parentRefs},
+	}
+	withTLSRoute := &supervisorconfigv1alpha1.FederationDomainExposureSpec{
+		Service:  service,
+		TLSRoute: &supervisorconfigv1alpha1.FederationDomainTLSRouteSpec{ParentRefs: parentRefs},
+	}
+
+	ownerRefs := []metav1.OwnerReference{{
+		APIVersion:         "config.supervisor.pinniped.dev/v1alpha1",
+		Kind:               "FederationDomain",
+		Name:               fdName,
+		UID:                fdUID,
+		Controller:         ptr.To(true),
+		BlockOwnerDeletion: ptr.To(true),
+	}}
+	ingress := func(owned bool, annotations map[string]string, spec networkingv1.IngressSpec) *networkingv1.Ingress {
+		ingress := &networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: fdName, Namespace: namespace, UID: "some-ingress-uid", Annotations: annotations},
+			Spec:       spec,
+		}
+		if owned {
+			ingress.OwnerReferences = ownerRefs
+		}
+		return ingress
+	}
+	ingressSpec := func(host, path string, tls bool) networkingv1.IngressSpec {
+		spec := networkingv1.IngressSpec{
+			IngressClassName: ptr.To("some-class"),
+			Rules: []networkingv1.IngressRule{{
+				Host: host,
+				IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Path:     path,
+						PathType: ptr.To(networkingv1.PathTypePrefix),
+						Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+							Name: "some-service",
+							Port: networkingv1.ServiceBackendPort{Number: 443},
+						}},
+					}},
+				}},
+			}},
+		}
+		if tls {
+			spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{host}, SecretName: "some-tls-secret"}}
+		}
+		return spec
+	}
+	wantIngressAnnotations := map[string]string{
+		"nginx.ingress.kubernetes.io/backend-protocol": "HTTPS",
+		"external-dns.alpha.kubernetes.io/hostname":    "issuer.example.com",
+		"external-dns.alpha.kubernetes.io/target":      "lb.example.com",
+		"external-dns.alpha.kubernetes.io/ttl":         "60",
+	}
+
+	route := func(gvr schema.GroupVersionResource, kind string, owned bool, spec map[string]any, parents ...any) *unstructured.Unstructured {
+		metadata := map[string]any{"name": fdName, "namespace": namespace, "uid": "some-route-uid"}
+		if owned {
+			metadata["ownerReferences"] = []any{map[string]any{
+				"apiVersion":         "config.supervisor.pinniped.dev/v1alpha1",
+				"kind":               "FederationDomain",
+				"name":               fdName,
+				"uid":                string(fdUID),
+				"controller":         true,
+				"blockOwnerDeletion": true,
+			}}
+		}
+		obj := &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": gvr.GroupVersion().String(),
+			"kind":       kind,
+			"metadata":   metadata,
+			"spec":       spec,
+		}}
+		if len(parents) > 0 {
+			obj.Object["status"] = map[string]any{"parents": parents}
+		}
+		return obj
+	}
+	httpRoute := func(owned bool, spec map[string]any, parents ...any) *unstructured.Unstructured {
+		return route(httpRouteGVR, "HTTPRoute", owned, spec, parents...)
+	}
+	tlsRoute := func(owned bool, spec map[string]any, parents ...any) *unstructured.Unstructured {
+		return route(tlsRouteGVR, "TLSRoute", owned, spec, parents...)
+	}
+	// routeParentStatus returns an entry of the status.parents of a route, as written by a Gateway controller.
+	routeParentStatus := func(gatewayNamespace, gatewayName, sectionName, accepted, reason, message string) any {
+		parentRef := map[string]any{"group": "gateway.networking.k8s.io", "kind": "Gateway", "name": gatewayName, "namespace": gatewayNamespace}
+		if sectionName != "" {
+			parentRef["sectionName"] = sectionName
+		}
+		return map[string]any{
+			"parentRef":      parentRef,
+			"controllerName": "example.com/gateway-controller",
+			"conditions": []any{
+				map[string]any{"type": "ResolvedRefs", "status": "True", "reason": "ResolvedRefs", "message": ""},
+				map[string]any{"type": "Accepted", "status": accepted, "reason": reason, "message": message},
+			},
+		}
+	}
+	acceptedByAll := []any{
+		routeParentStatus(namespace, "some-gateway", "", "True", "Accepted", ""),
+		routeParentStatus("other-namespace", "other-gateway", "https", "True", "Accepted", ""),
+	}
+	desiredHTTPRouteSpec := map[string]any{
+		"parentRefs": []any{
+			map[string]any{"group": "gateway.networking.k8s.io", "kind": "Gateway", "name": "some-gateway"},
+			map[string]any{"group": "gateway.networking.k8s.io", "kind": "Gateway", "name": "other-gateway", "namespace": "other-namespace", "sectionName": "https"},
+		},
+		"hostnames": []any{"issuer.example.com"},
+		"rules": []any{map[string]any{
+			"matches": []any{map[string]any{"path": map[string]any{"type": "PathPrefix", "value": "/"}}},
+			"backendRefs": []any{map[string]any{
+				"group": "", "kind": "Service", "name": "some-service", "port": int64(443), "weight": int64(1),
+			}},
+		}},
+	}
+	desiredTLSRouteSpec := map[string]any{
+		"parentRefs": desiredHTTPRouteSpec["parentRefs"],
+		"hostnames":  []any{"issuer.example.com"},
+		"rules": []any{map[string]any{
+			"backendRefs": []any{map[string]any{
+				"group": "", "kind": "Service", "name": "some-service", "port": int64(443), "weight": int64(1),
+			}},
+		}},
+	}
+
+	gateway := func(gatewayNamespace, gatewayName string, listeners ...any) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "gateway.networking.k8s.io/v1",
+			"kind":       "Gateway",
+			"metadata":   map[string]any{"name": gatewayName, "namespace": gatewayNamespace},
+			"spec":       map[string]any{"gatewayClassName": "some-class", "listeners": listeners},
+		}}
+	}
+	listener := func(name, protocol, tlsMode, hostname string) any {
+		l := map[string]any{"name": name, "protocol": protocol, "port": int64(443)}
+		if tlsMode != "" {
+			l["tls"] = map[string]any{"mode": tlsMode}
+		}
+		if hostname != "" {
+			l["hostname"] = hostname
+		}
+		return l
+	}
+	terminatingGateways := []runtime.Object{
+		gateway(namespace, "some-gateway", listener("http", "HTTP", "", ""), listener("https", "HTTPS", "", "*.example.com")),
+		gateway("other-namespace", "other-gateway", listener("https", "HTTPS", "Terminate", "issuer.example.com")),
+	}
+	passthroughGateways := []runtime.Object{
+		gateway(namespace, "some-gateway", listener("https", "HTTPS", "", ""), listener("tls", "TLS", "Passthrough", "")),
+		gateway("other-namespace", "other-gateway", listener("https", "TLS", "Passthrough", "*.example.com")),
+	}
+
+	condition := func(conditionType string, status metav1.ConditionStatus, reason, message string) metav1.Condition {
+		return metav1.Condition{
+			Type:               conditionType,
+			Status:             status,
+			Reason:             reason,
+			Message:            message,
+			ObservedGeneration: 3,
+			LastTransitionTime: metav1.NewTime(now),
+		}
+	}
+	exposureReady := func(message string) metav1.Condition {
+		return condition(configconditions.TypeExposureReady, metav1.ConditionTrue, "Success", message)
+	}
+	reachable := func(issuer string) metav1.Condition {
+		return condition(configconditions.TypeIssuerExternallyReachable, metav1.ConditionTrue, "Success",
+			`the discovery endpoint of the issuer was reached at "`+issuer+`/.well-known/openid-configuration"`)
+	}
+	reachabilityUnknown := condition(configconditions.TypeIssuerExternallyReachable, metav1.ConditionUnknown, "UnableToValidate",
+		"unable to check the reachability of the issuer until the ExposureReady condition is true")
+	otherCondition := metav1.Condition{Type: "IssuerURLValid", Status: metav1.ConditionTrue, Reason: "Success", Message: "some message"}
+
+	tests := []struct {
+		name              string
+		federationDomain  *supervisorconfigv1alpha1.FederationDomain
+		ingresses         []runtime.Object
+		httpRoutes        []runtime.Object
+		tlsRoutes         []runtime.Object
+		gateways          []runtime.Object
+		gatewayAPIGone    bool
+		gatewayForbidden  bool // whether the Gateways in other namespaces cannot be read
+		wantConditions    []metav1.Condition
+		wantIngress       *networkingv1.Ingress      // the Ingress named like the FederationDomain, nil when it should not exist
+		wantHTTPRoute     *unstructured.Unstructured // the HTTPRoute named like the FederationDomain, nil when it should not exist
+		wantTLSRoute      *unstructured.Unstructured // the TLSRoute named like the FederationDomain, nil when it should not exist
+		wantRequeue       bool
+		wantKubeActions   int
+		wantNoHTTPRouteOp bool
+	}{
+		{
+			name:              "FederationDomain does not use spec.exposure",
+			federationDomain:  federationDomain("https://issuer.example.com", nil, otherCondition),
+			wantConditions:    []metav1.Condition{otherCondition},
+			wantNoHTTPRouteOp: true,
+		},
+		{
+			name:             "creates an Ingress for the issuer host and path",
+			federationDomain: federationDomain("https://issuer.example.com/some/path", withIngress, otherCondition),
+			wantConditions: []metav1.Condition{
+				exposureReady(`the Ingress "some-fd" routes the issuer to the Service "some-service"`),
+				reachable("https://issuer.example.com/some/path"),
+				otherCondition,
+			},
+			wantIngress:     ingress(true, wantIngressAnnotations, ingressSpec("issuer.example.com", "/some/path", true)),
+			wantRequeue:     true,
+			wantKubeActions: 1,
+		},
+		{
+			name: "creates an Ingress for an issuer IP address without external-dns annotations",
+			federationDomain: federationDomain("https://10.1.2.3", &supervisorconfigv1alpha1.FederationDomainExposureSpec{
+				Service:     service,
+				ExternalDNS: &supervisorconfigv1alpha1.FederationDomainExternalDNSSpec{},
+				Ingress:     &supervisorconfigv1alpha1.FederationDomainIngressSpec{IngressClassName: "some-class"},
+			}),
+			wantConditions: []metav1.Condition{
+				exposureReady(`the Ingress "some-fd" routes the issuer to the Service "some-service"`),
+				reachable("https://10.1.2.3"),
+			},
+			wantIngress:     ingress(true, nil, ingressSpec("", "/", false)),
+			wantRequeue:     true,
+			wantKubeActions: 1,
+		},
+		{
+			name:             "updates an outdated Ingress",
+			federationDomain: federationDomain("https://issuer.example.com/some/path", withIngress),
+			ingresses: []runtime.Object{
+				ingress(true, map[string]string{"old": "annotation"}, ingressSpec("old.example.com", "/", false)),
+			},
+			wantConditions: []metav1.Condition{
+				exposureReady(`the Ingress "some-fd" routes the issuer to the Service "some-service"`),
+				reachable("https://issuer.example.com/some/path"),
+			},
+			wantIngress:     ingress(true, wantIngressAnnotations, ingressSpec("issuer.example.com", "/some/path", true)),
+			wantRequeue:     true,
+			wantKubeActions: 1,
+		},
+		{
+			name:             "leaves an up to date Ingress alone",
+			federationDomain: federationDomain("https://issuer.example.com/some/path", withIngress),
+			ingresses: []runtime.Object{
+				ingress(true, wantIngressAnnotations, ingressSpec("issuer.example.com", "/some/path", true)),
+			},
+			wantConditions: []metav1.Condition{
+				exposureReady(`the Ingress "some-fd" routes the issuer to the Service "some-service"`),
+				reachable("https://issuer.example.com/some/path"),
+			},
+			wantIngress: ingress(true, wantIngressAnnotations, ingressSpec("issuer.example.com", "/some/path", true)),
+			wantRequeue: true,
+		},
+		{
+			name:             "does not touch an Ingress which was not created for the FederationDomain",
+			federationDomain: federationDomain("https://issuer.example.com", withIngress),
+			ingresses:        []runtime.Object{ingress(false, nil, ingressSpec("other.example.com", "/", false))},
+			wantConditions: []metav1.Condition{
+				condition(configconditions.TypeExposureReady, metav1.ConditionFalse, "ExposureNotOwned",
+					`the Ingress "some-fd" already exists and was not created for this FederationDomain: delete it, `+
+						`or remove spec.exposure and manage the routing to the Supervisor by other means`),
+				reachabilityUnknown,
+			},
+			wantIngress: ingress(false, nil, ingressSpec("other.example.com", "/", false)),
+			wantRequeue: true,
+		},
+		{
+			name:             "reports an issuer whose discovery endpoint returns an error",
+			federationDomain: federationDomain("https://issuer.example.com/not-found", withIngress),
+			wantConditions: []metav1.Condition{
+				exposureReady(`the Ingress "some-fd" routes the issuer to the Service "some-service"`),
+				condition(configconditions.TypeIssuerExternallyReachable, metav1.ConditionFalse, "IssuerUnreachable",
+					"the discovery endpoint of the issuer returned unexpected status code 404"),
+			},
+			wantIngress:     ingress(true, wantIngressAnnotations, ingressSpec("issuer.example.com", "/not-found", true)),
+			wantRequeue:     true,
+			wantKubeActions: 1,
+		},
+		{
+			name:             "reports an issuer whose traffic is routed elsewhere",
+			federationDomain: federationDomain("https://issuer.example.com/other-supervisor", withIngress),
+			wantConditions: []metav1.Condition{
+				exposureReady(`the Ingress "some-fd" routes the issuer to the Service "some-service"`),
+				condition(configconditions.TypeIssuerExternallyReachable, metav1.ConditionFalse, "IssuerUnreachable",
+					`the discovery endpoint of the issuer returned the discovery document of a different issuer `+
+						`"https://issuer.example.com/some-other-path", is the traffic routed to this Supervisor?`),
+			},
+			wantIngress:     ingress(true, wantIngressAnnotations, ingressSpec("issuer.example.com", "/other-supervisor", true)),
+			wantRequeue:     true,
+			wantKubeActions: 1,
+		},
+		{
+			name:             "invalid issuer URL",
+			federationDomain: federationDomain("https://", withIngress),
+			wantConditions: []metav1.Condition{
+				condition(configconditions.TypeExposureReady, metav1.ConditionFalse, "InvalidIssuerURL", "cannot route traffic to an invalid issuer URL"),
+				reachabilityUnknown,
+			},
+		},
+		{
+			name:             "creates an HTTPRoute which has not been accepted by its Gateways yet",
+			federationDomain: federationDomain("https://issuer.example.com", withHTTPRoute),
+			gateways:         terminatingGateways,
+			wantConditions: []metav1.Condition{
+				condition(configconditions.TypeExposureReady, metav1.ConditionFalse, "RouteNotAccepted",
+					"the HTTPRoute has not been accepted by the Gateway some-namespace/some-gateway yet"),
+				reachabilityUnknown,
+			},
+			wantHTTPRoute: httpRoute(true, desiredHTTPRouteSpec),
+			wantRequeue:   true,
+		},
+		{
+			name:             "leaves an up to date HTTPRoute which was accepted by its Gateways alone",
+			federationDomain: federationDomain("https://issuer.example.com", withHTTPRoute),
+			httpRoutes:       []runtime.Object{httpRoute(true, desiredHTTPRouteSpec, acceptedByAll...)},
+			gateways:         terminatingGateways,
+			wantConditions: []metav1.Condition{
+				exposureReady(`the HTTPRoute "some-fd" routes the issuer to the Service "some-service"`),
+				reachable("https://issuer.example.com"),
+			},
+			wantHTTPRoute: httpRoute(true, desiredHTTPRouteSpec),
+			wantRequeue:   true,
+		},
+		{
+			name:             "updates the managed fields of an outdated HTTPRoute and keeps the other fields",
+			federationDomain: federationDomain("https://issuer.example.com", withHTTPRoute),
+			httpRoutes: []runtime.Object{httpRoute(true, map[string]any{
+				"hostnames": []any{"old.example.com"},
+				"other":     "value",
+			}, acceptedByAll...)},
+			gateways: terminatingGateways,
+			wantConditions: []metav1.Condition{
+				exposureReady(`the HTTPRoute "some-fd" routes the issuer to the Service "some-service"`),
+				reachable("https://issuer.example.com"),
+			},
+			wantHTTPRoute: httpRoute(true, func() map[string]any {
+				spec := map[string]any{"other": "value"}
+				for k, v := range desiredHTTPRouteSpec {
+					spec[k] = v
+				}
+				return spec
+			}()),
+			wantRequeue: true,
+		},
+		{
+			name:             "does not touch an HTTPRoute which was not created for the FederationDomain",
+			federationDomain: federationDomain("https://issuer.example.com", withHTTPRoute),
+			httpRoutes:       []runtime.Object{httpRoute(false, map[string]any{"hostnames": []any{"other.example.com"}})},
+			wantConditions: []metav1.Condition{
+				condition(configconditions.TypeExposureReady, metav1.ConditionFalse, "ExposureNotOwned",
+					`the HTTPRoute "some-fd" already exists and was not created for this FederationDomain: delete it, `+
+						`or remove spec.exposure and manage the routing to the Supervisor by other means`),
+				reachabilityUnknown,
+			},
+			wantHTTPRoute: httpRoute(false, map[string]any{"hostnames": []any{"other.example.com"}}),
+			wantRequeue:   true,
+		},
+		{
+			name:             "the Gateway API is not installed",
+			federationDomain: federationDomain("https://issuer.example.com", withHTTPRoute),
+			gatewayAPIGone:   true,
+			wantConditions: []metav1.Condition{
+				condition(configconditions.TypeExposureReady, metav1.ConditionFalse, "GatewayAPINotInstalled",
+					"cannot create the HTTPRoute: the HTTPRoute API was not found, is the Gateway API installed?"),
+				reachabilityUnknown,
+			},
+			wantRequeue: true,
+		},
+		{
+			name: "switching from an Ingress to an HTTPRoute deletes the Ingress",
+			federationDomain: federationDomain("https://issuer.example.com", withHTTPRoute,
+				exposureReady(`the Ingress "some-fd" routes the issuer to the Service "some-service"`),
+				reachable("https://issuer.example.com"),
+			),
+			ingresses: []runtime.Object{ingress(true, nil, ingressSpec("issuer.example.com", "/", true))},
+			gateways:  terminatingGateways,
+			wantConditions: []metav1.Condition{
+				condition(configconditions.TypeExposureReady, metav1.ConditionFalse, "RouteNotAccepted",
+					"the HTTPRoute has not been accepted by the Gateway some-namespace/some-gateway yet"),
+				reachabilityUnknown,
+			},
+			wantHTTPRoute:   httpRoute(true, desiredHTTPRouteSpec),
+			wantRequeue:     true,
+			wantKubeActions: 1,
+		},
+		{
+			name:             "reports a Gateway which was not found",
+			federationDomain: federationDomain("https://issuer.example.com", withHTTPRoute),
+			gateways:         terminatingGateways[:1],
+			httpRoutes:       []runtime.Object{httpRoute(true, desiredHTTPRouteSpec, acceptedByAll...)},
+			wantConditions: []metav1.Condition{
+				condition(configconditions.TypeExposureReady, metav1.ConditionFalse, "GatewayNotFound",
+					"the Gateway other-namespace/other-gateway was not found"),
+				reachabilityUnknown,
+			},
+			wantHTTPRoute: httpRoute(true, desiredHTTPRouteSpec),
+			wantRequeue:   true,
+		},
+		{
+			name:             "reports a Gateway which has no listener for the hostname of the issuer",
+			federationDomain: federationDomain("https://issuer.other.com", withHTTPRoute),
+			gateways:         terminatingGateways,
+			wantConditions: []metav1.Condition{
+				condition(configconditions.TypeExposureReady, metav1.ConditionFalse, "GatewayListenerIncompatible",
+					`the Gateway some-namespace/some-gateway has no listener which can serve the HTTPRoute for the hostname "issuer.other.com": `+
+						"a listener with protocol HTTPS, TLS mode Terminate and a matching hostname is required"),
+				reachabilityUnknown,
+			},
+			wantHTTPRoute: httpRoute(true, func() map[string]any {
+				spec := maps.Clone(desiredHTTPRouteSpec)
+				spec["hostnames"] = []any{"issuer.other.com"}
+				return spec
+			}()),
+			wantRequeue: true,
+		},
+		{
+			name:             "reports a route which was not accepted by a Gateway",
+			federationDomain: federationDomain("https://issuer.example.com", withHTTPRoute),
+			httpRoutes: []runtime.Object{httpRoute(true, desiredHTTPRouteSpec,
+				routeParentStatus(namespace, "some-gateway", "", "True", "Accepted", ""),
+				routeParentStatus("other-namespace", "other-gateway", "https", "False", "NotAllowedByListeners", "some message"),
+			)},
+			gateways: terminatingGateways,
+			wantConditions: []metav1.Condition{
+				condition(configconditions.TypeExposureReady, metav1.ConditionFalse, "RouteNotAccepted",
+					"the Gateway other-namespace/other-gateway did not accept the HTTPRoute: NotAllowedByListeners: some message"),
+				reachabilityUnknown,
+			},
+			wantHTTPRoute: httpRoute(true, desiredHTTPRouteSpec),
+			wantRequeue:   true,
+		},
+		{
+			name:             "relies on the acceptance of the route by a Gateway which cannot be read",
+			federationDomain: federationDomain("https://issuer.example.com", withHTTPRoute),
+			httpRoutes:       []runtime.Object{httpRoute(true, desiredHTTPRouteSpec, acceptedByAll...)},
+			gateways:         terminatingGateways[:1],
+			gatewayForbidden: true,
+			wantConditions: []metav1.Condition{
+				exposureReady(`the HTTPRoute "some-fd" routes the issuer to the Service "some-service"`),
+				reachable("https://issuer.example.com"),
+			},
+			wantHTTPRoute: httpRoute(true, desiredHTTPRouteSpec),
+			wantRequeue:   true,
+		},
+		{
+			name:             "creates a TLSRoute which has not been accepted by its Gateways yet",
+			federationDomain: federationDomain("https://issuer.example.com/some/path", withTLSRoute),
+			gateways:         passthroughGateways,
+			wantConditions: []metav1.Condition{
+				condition(configconditions.TypeExposureReady, metav1.ConditionFalse, "RouteNotAccepted",
+					"the TLSRoute has not been accepted by the Gateway some-namespace/some-gateway yet"),
+				reachabilityUnknown,
+			},
+			wantTLSRoute: tlsRoute(true, desiredTLSRouteSpec),
+			wantRequeue:  true,
+		},
+		{
+			name:             "leaves an up to date TLSRoute which was accepted by its Gateways alone",
+			federationDomain: federationDomain("https://issuer.example.com", withTLSRoute),
+			tlsRoutes:        []runtime.Object{tlsRoute(true, desiredTLSRouteSpec, acceptedByAll...)},
+			gateways:         passthroughGateways,
+			wantConditions: []metav1.Condition{
+				exposureReady(`the TLSRoute "some-fd" routes the issuer to the Service "some-service"`),
+				reachable("https://issuer.example.com"),
+			},
+			wantTLSRoute: tlsRoute(true, desiredTLSRouteSpec),
+			wantRequeue:  true,
+		},
+		{
+			name:             "reports a Gateway listener which terminates TLS for a TLSRoute",
+			federationDomain: federationDomain("https://issuer.example.com", withTLSRoute),
+			tlsRoutes:        []runtime.Object{tlsRoute(true, desiredTLSRouteSpec, acceptedByAll...)},
+			gateways: []runtime.Object{
+				passthroughGateways[0],
+				gateway("other-namespace", "other-gateway", listener("https", "HTTPS", "", "")),
+			},
+			wantConditions: []metav1.Condition{
+				condition(configconditions.TypeExposureReady, metav1.ConditionFalse, "GatewayListenerIncompatible",
+					`the listener "https" of the Gateway other-namespace/other-gateway cannot serve the TLSRoute for the hostname "issuer.example.com": `+
+						"a listener with protocol TLS, TLS mode Passthrough and a matching hostname is required, "+
+						"but the listener has protocol HTTPS and TLS mode Terminate"),
+				reachabilityUnknown,
+			},
+			wantTLSRoute: tlsRoute(true, desiredTLSRouteSpec),
+			wantRequeue:  true,
+		},
+		{
+			name:             "reports a Gateway which has no listener with the section name",
+			federationDomain: federationDomain("https://issuer.example.com", withTLSRoute),
+			tlsRoutes:        []runtime.Object{tlsRoute(true, desiredTLSRouteSpec, acceptedByAll...)},
+			gateways: []runtime.Object{
+				passthroughGateways[0],
+				gateway("other-namespace", "other-gateway", listener("tls", "TLS", "Passthrough", "")),
+			},
+			wantConditions: []metav1.Condition{
+				condition(configconditions.TypeExposureReady, metav1.ConditionFalse, "GatewayListenerIncompatible",
+					`the Gateway other-namespace/other-gateway has no listener named "https"`),
+				reachabilityUnknown,
+			},
+			wantTLSRoute: tlsRoute(true, desiredTLSRouteSpec),
+			wantRequeue:  true,
+		},
+		{
+			name:             "a TLSRoute cannot be used for an issuer IP address",
+			federationDomain: federationDomain("https://10.1.2.3", withTLSRoute),
+			gateways:         passthroughGateways,
+			wantConditions: []metav1.Condition{
+				condition(configconditions.TypeExposureReady, metav1.ConditionFalse, "InvalidIssuerURL",
+					"a TLSRoute matches the server name of TLS connections, so it cannot route traffic to an issuer URL with an IP address"),
+				reachabilityUnknown,
+			},
+			wantRequeue: true,
+		},
+		{
+			name: "switching from an HTTPRoute to a TLSRoute deletes the HTTPRoute",
+			federationDomain: federationDomain("https://issuer.example.com", withTLSRoute,
+				exposureReady(`the HTTPRoute "some-fd" routes the issuer to the Service "some-service"`),
+				reachable("https://issuer.example.com"),
+			),
+			httpRoutes: []runtime.Object{httpRoute(true, desiredHTTPRouteSpec, acceptedByAll...)},
+			gateways:   passthroughGateways,
+			wantConditions: []metav1.Condition{
+				condition(configconditions.TypeExposureReady, metav1.ConditionFalse, "RouteNotAccepted",
+					"the TLSRoute has not been accepted by the Gateway some-namespace/some-gateway yet"),
+				reachabilityUnknown,
+			},
+			wantTLSRoute: tlsRoute(true, desiredTLSRouteSpec),
+			wantRequeue:  true,
+		},
+		{
+			name: "removing spec.exposure deletes the owned HTTPRoute and removes the conditions",
+			federationDomain: federationDomain("https://issuer.example.com", nil,
+				otherCondition,
+				exposureReady(`the HTTPRoute "some-fd" routes the issuer to the Service "some-service"`),
+				reachable("https://issuer.example.com"),
+			),
+			httpRoutes:     []runtime.Object{httpRoute(true, desiredHTTPRouteSpec)},
+			wantConditions: []metav1.Condition{otherCondition},
+		},
+		{
+			name: "removing spec.exposure does not delete an Ingress which was not created for the FederationDomain",
+			federationDomain: federationDomain("https://issuer.example.com", nil,
+				exposureReady(`the Ingress "some-fd" routes the issuer to the Service "some-service"`),
+			),
+			ingresses:      []runtime.Object{ingress(false, nil, ingressSpec("other.example.com", "/", false))},
+			wantConditions: []metav1.Condition{},
+			wantIngress:    ingress(false, nil, ingressSpec("other.example.com", "/", false)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			pinnipedAPIClient := supervisorfake.NewSimpleClientset(tt.federationDomain)
+			pinnipedInformerClient := supervisorfake.NewSimpleClientset(tt.federationDomain)
+			pinnipedInformers := supervisorinformers.NewSharedInformerFactory(pinnipedInformerClient, 0)
+
+			kubeClient := kubernetesfake.NewSimpleClientset(tt.ingresses...)
+			kubeInformerClient := kubernetesfake.NewSimpleClientset(tt.ingresses...)
+			kubeInformers := kubeinformers.NewSharedInformerFactory(kubeInformerClient, 0)
+
+			dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{
+					httpRouteGVR: "HTTPRouteList",
+					tlsRouteGVR:  "TLSRouteList",
+					gatewayGVR:   "GatewayList",
+				},
+				slices.Concat(tt.httpRoutes, tt.tlsRoutes)...)
+			// The resource of a Gateway cannot be guessed from its kind, so the Gateways are added with their resource.
+			for _, gateway := range tt.gateways {
+				require.NoError(t, dynamicClient.Tracker().Create(gatewayGVR, gateway, gateway.(metav1.Object).GetNamespace()))
+			}
+			if tt.gatewayAPIGone {
+				dynamicClient.PrependReactor("create", "httproutes", func(_ kubetesting.Action) (bool, runtime.Object, error) {
+					return true, nil, apierrors.NewNotFound(httpRouteGVR.GroupResource(), "")
+				})
+			}
+			if tt.gatewayForbidden {
+				dynamicClient.PrependReactor("get", "gateways", func(action kubetesting.Action) (bool, runtime.Object, error) {
+					if action.GetNamespace() == namespace {
+						return false, nil, nil
+					}
+					return true, nil, apierrors.NewForbidden(gatewayGVR.GroupResource(), "", nil)
+				})
+			}
+
+			c := NewFederationDomainExposureWriterController(
+				"pinniped.dev",
+				kubeClient,
+				dynamicClient,
+				pinnipedAPIClient,
+				kubeInformers.Networking().V1().Ingresses(),
+				pinnipedInformers.Config().V1alpha1().FederationDomains(),
+				httpClient,
+				clocktesting.NewFakeClock(now),
+				controllerlib.WithInformer,
+			)
+
+			// Must start informers before calling TestRunSynchronously().
+			pinnipedInformers.Start(ctx.Done())
+			kubeInformers.Start(ctx.Done())
+			controllerlib.TestRunSynchronously(t, c)
+
+			queue := &certManagerTestQueue{}
+			err := controllerlib.TestSync(t, c, controllerlib.Context{
+				Context: ctx,
+				Key:     controllerlib.Key{Namespace: namespace, Name: fdName},
+				Queue:   queue,
+			})
+			require.NoError(t, err)
+			kubeActions := kubeClient.Actions()
+			dynamicActions := dynamicClient.Actions()
+
+			updated, err := pinnipedAPIClient.ConfigV1alpha1().FederationDomains(namespace).Get(ctx, fdName, metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, tt.wantConditions, updated.Status.Conditions)
+
+			actualIngress, err := kubeClient.NetworkingV1().Ingresses(namespace).Get(ctx, fdName, metav1.GetOptions{})
+			if tt.wantIngress == nil {
+				require.True(t, apierrors.IsNotFound(err), "expected the Ingress to not exist, but got: %v", err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.wantIngress.Spec, actualIngress.Spec)
+				require.Equal(t, tt.wantIngress.Annotations, actualIngress.Annotations)
+				require.Equal(t, tt.wantIngress.OwnerReferences, actualIngress.OwnerReferences)
+			}
+			// These are only writes, since the Ingresses are read from the informer.
+			require.Len(t, kubeActions, tt.wantKubeActions)
+
+			actualHTTPRoute, err := dynamicClient.Resource(httpRouteGVR).Namespace(namespace).Get(ctx, fdName, metav1.GetOptions{})
+			if tt.wantHTTPRoute == nil {
+				require.True(t, apierrors.IsNotFound(err), "expected the HTTPRoute to not exist, but got: %v", err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.wantHTTPRoute.Object["spec"], actualHTTPRoute.Object["spec"])
+				require.Equal(t, tt.wantHTTPRoute.GetOwnerReferences(), actualHTTPRoute.GetOwnerReferences())
+			}
+
+			actualTLSRoute, err := dynamicClient.Resource(tlsRouteGVR).Namespace(namespace).Get(ctx, fdName, metav1.GetOptions{})
+			if tt.wantTLSRoute == nil {
+				require.True(t, apierrors.IsNotFound(err), "expected the TLSRoute to not exist, but got: %v", err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.wantTLSRoute.Object["spec"], actualTLSRoute.Object["spec"])
+				require.Equal(t, tt.wantTLSRoute.GetOwnerReferences(), actualTLSRoute.GetOwnerReferences())
+			}
+			if tt.wantNoHTTPRouteOp {
+				require.Empty(t, dynamicActions)
+			}
+
+			if tt.wantRequeue {
+				require.Equal(t, federationDomainExposureResyncInterval, queue.duration)
+			} else {
+				require.Zero(t, queue.duration)
+			}
+		})
+	}
+}
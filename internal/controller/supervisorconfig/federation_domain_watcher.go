@@ -6,7 +6,9 @@ package supervisorconfig
 import (
 	"context"
 	"fmt"
+	"net/netip"
 	"net/url"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -19,8 +21,10 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/events"
 	"k8s.io/utils/clock"
 
+	configconditions "go.pinniped.dev/generated/latest/apis/supervisor/config/conditions"
 	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
 	supervisorclientset "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned"
 	configinformers "go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions/config/v1alpha1"
@@ -29,7 +33,16 @@ import (
 	pinnipedcontroller "go.pinniped.dev/internal/controller"
 	"go.pinniped.dev/internal/controller/conditionsutil"
 	"go.pinniped.dev/internal/controllerlib"
+	"go.pinniped.dev/internal/federationdomain/cors"
 	"go.pinniped.dev/internal/federationdomain/federationdomainproviders"
+	"go.pinniped.dev/internal/federationdomain/loadshed"
+	"go.pinniped.dev/internal/federationdomain/loginthrottle"
+	"go.pinniped.dev/internal/federationdomain/resolvedprovider"
+	"go.pinniped.dev/internal/federationdomain/secondfactor"
+	"go.pinniped.dev/internal/federationdomain/singlesignon"
+	"go.pinniped.dev/internal/federationdomain/strategy"
+	"go.pinniped.dev/internal/federationdomain/tokenenrichment"
+	"go.pinniped.dev/internal/federationdomain/workloadidentity"
 	"go.pinniped.dev/internal/idtransform"
 	"go.pinniped.dev/internal/plog"
 )
@@ -37,39 +50,64 @@ import (
 const (
 	controllerName = "FederationDomainWatcherController"
 
-	typeReady                                = "Ready"
-	typeIssuerURLValid                       = "IssuerURLValid"
-	typeOneTLSSecretPerIssuerHostname        = "OneTLSSecretPerIssuerHostname"
-	typeIssuerIsUnique                       = "IssuerIsUnique"
-	typeIdentityProvidersFound               = "IdentityProvidersFound"
-	typeIdentityProvidersDisplayNamesUnique  = "IdentityProvidersDisplayNamesUnique"
-	typeIdentityProvidersAPIGroupSuffixValid = "IdentityProvidersObjectRefAPIGroupSuffixValid"
-	typeIdentityProvidersObjectRefKindValid  = "IdentityProvidersObjectRefKindValid"
-	typeTransformsExpressionsValid           = "TransformsExpressionsValid"
-	typeTransformsExamplesPassed             = "TransformsExamplesPassed"
-
-	reasonSuccess                                     = "Success"
-	reasonNotReady                                    = "NotReady"
-	reasonUnableToValidate                            = "UnableToValidate"
-	reasonInvalidIssuerURL                            = "InvalidIssuerURL"
-	reasonDuplicateIssuer                             = "DuplicateIssuer"
-	reasonDifferentSecretRefsFound                    = "DifferentSecretRefsFound"
-	reasonLegacyConfigurationSuccess                  = "LegacyConfigurationSuccess"
-	reasonLegacyConfigurationIdentityProviderNotFound = "LegacyConfigurationIdentityProviderNotFound"
-	reasonIdentityProvidersObjectRefsNotFound         = "IdentityProvidersObjectRefsNotFound"
-	reasonIdentityProviderNotSpecified                = "IdentityProviderNotSpecified"
-	reasonDuplicateDisplayNames                       = "DuplicateDisplayNames"
-	reasonAPIGroupNameUnrecognized                    = "APIGroupUnrecognized"
-	reasonKindUnrecognized                            = "KindUnrecognized"
-	reasonInvalidTransformsExpressions                = "InvalidTransformsExpressions"
-	reasonTransformsExamplesFailed                    = "TransformsExamplesFailed"
-
-	kindLDAPIdentityProvider            = "LDAPIdentityProvider"
-	kindOIDCIdentityProvider            = "OIDCIdentityProvider"
-	kindActiveDirectoryIdentityProvider = "ActiveDirectoryIdentityProvider"
-	kindGitHubIdentityProvider          = "GitHubIdentityProvider"
+	typeReady                                = configconditions.TypeReady
+	typeIssuerURLValid                       = configconditions.TypeIssuerURLValid
+	typeOneTLSSecretPerIssuerHostname        = configconditions.TypeOneTLSSecretPerIssuerHostname
+	typeIssuerIsUnique                       = configconditions.TypeIssuerIsUnique
+	typeIdentityProvidersFound               = configconditions.TypeIdentityProvidersFound
+	typeIdentityProvidersDisplayNamesUnique  = configconditions.TypeIdentityProvidersDisplayNamesUnique
+	typeIdentityProvidersAPIGroupSuffixValid = configconditions.TypeIdentityProvidersObjectRefAPIGroupSuffixValid
+	typeIdentityProvidersObjectRefKindValid  = configconditions.TypeIdentityProvidersObjectRefKindValid
+	typeTransformsExpressionsValid           = configconditions.TypeTransformsExpressionsValid
+	typeTransformsExamplesPassed             = configconditions.TypeTransformsExamplesPassed
+	typeAccessTokensValid                    = configconditions.TypeAccessTokensValid
+
+	reasonSuccess                                     = configconditions.ReasonSuccess
+	reasonNotReady                                    = configconditions.ReasonNotReady
+	reasonUnableToValidate                            = configconditions.ReasonUnableToValidate
+	reasonInvalidIssuerURL                            = configconditions.ReasonInvalidIssuerURL
+	reasonDuplicateIssuer                             = configconditions.ReasonDuplicateIssuer
+	reasonDifferentSecretRefsFound                    = configconditions.ReasonDifferentSecretRefsFound
+	reasonLegacyConfigurationSuccess                  = configconditions.ReasonLegacyConfigurationSuccess
+	reasonLegacyConfigurationIdentityProviderNotFound = configconditions.ReasonLegacyConfigurationIdentityProviderNotFound
+	reasonIdentityProvidersObjectRefsNotFound         = configconditions.ReasonIdentityProvidersObjectRefsNotFound
+	reasonIdentityProvidersNotAllowed                 = configconditions.ReasonIdentityProvidersNotAllowed
+	reasonIdentityProviderNotSpecified                = configconditions.ReasonIdentityProviderNotSpecified
+	reasonDuplicateDisplayNames                       = configconditions.ReasonDuplicateDisplayNames
+	reasonAPIGroupNameUnrecognized                    = configconditions.ReasonAPIGroupUnrecognized
+	reasonKindUnrecognized                            = configconditions.ReasonKindUnrecognized
+	reasonInvalidTransformsExpressions                = configconditions.ReasonInvalidTransformsExpressions
+	reasonTransformsExamplesFailed                    = configconditions.ReasonTransformsExamplesFailed
+	reasonInvalidAccessTokenAudience                  = configconditions.ReasonInvalidAccessTokenAudience
+
+	kindLDAPIdentityProvider              = "LDAPIdentityProvider"
+	kindOIDCIdentityProvider              = "OIDCIdentityProvider"
+	kindActiveDirectoryIdentityProvider   = "ActiveDirectoryIdentityProvider"
+	kindGitHubIdentityProvider            = "GitHubIdentityProvider"
+	kindClientCertificateIdentityProvider = "ClientCertificateIdentityProvider"
+	kindMockIdentityProvider              = "MockIdentityProvider"
+	kindOpenShiftIdentityProvider         = "OpenShiftIdentityProvider"
 
 	celTransformerMaxExpressionRuntime = 5 * time.Second
+
+	// AllowedFederationDomainsAnnotation may be added to an identity provider resource to restrict which
+	// FederationDomains may use it. Its value is a comma-separated list of FederationDomain names. Without it,
+	// every FederationDomain may use the identity provider. This allows the identity providers to be owned by
+	// a different team than the FederationDomains, since their kinds can be given different RBAC permissions.
+	AllowedFederationDomainsAnnotation = "supervisor.pinniped.dev/allowed-federation-domains"
+)
+
+// identityProviderConditionTypes are the types of the conditions which report problems with individual entries
+// of spec.identityProviders. These problems are tolerated by the AnyIDPReady readiness policy.
+//
+//nolint:gochecknoglobals // This is effectively a constant.
+var identityProviderConditionTypes = sets.New(
+	typeIdentityProvidersFound,
+	typeIdentityProvidersDisplayNamesUnique,
+	typeIdentityProvidersAPIGroupSuffixValid,
+	typeIdentityProvidersObjectRefKindValid,
+	typeTransformsExpressionsValid,
+	typeTransformsExamplesPassed,
 )
 
 // FederationDomainsSetter can be notified of all known valid providers with its SetFederationDomains function.
@@ -85,11 +123,14 @@ type federationDomainWatcherController struct {
 	clock                   clock.Clock
 	client                  supervisorclientset.Interface
 
-	federationDomainInformer                configinformers.FederationDomainInformer
-	oidcIdentityProviderInformer            idpinformers.OIDCIdentityProviderInformer
-	ldapIdentityProviderInformer            idpinformers.LDAPIdentityProviderInformer
-	activeDirectoryIdentityProviderInformer idpinformers.ActiveDirectoryIdentityProviderInformer
-	githubIdentityProviderInformer          idpinformers.GitHubIdentityProviderInformer
+	federationDomainInformer                  configinformers.FederationDomainInformer
+	oidcIdentityProviderInformer              idpinformers.OIDCIdentityProviderInformer
+	ldapIdentityProviderInformer              idpinformers.LDAPIdentityProviderInformer
+	activeDirectoryIdentityProviderInformer   idpinformers.ActiveDirectoryIdentityProviderInformer
+	githubIdentityProviderInformer            idpinformers.GitHubIdentityProviderInformer
+	clientCertificateIdentityProviderInformer idpinformers.ClientCertificateIdentityProviderInformer
+	openShiftIdentityProviderInformer         idpinformers.OpenShiftIdentityProviderInformer
+	mockIdentityProviderInformer              idpinformers.MockIdentityProviderInformer
 
 	celTransformer *celtransformer.CELTransformer
 	allowedKinds   sets.Set[string]
@@ -97,6 +138,8 @@ type federationDomainWatcherController struct {
 
 // NewFederationDomainWatcherController creates a controllerlib.Controller that watches
 // FederationDomain objects and notifies a callback object of the collection of provider configs.
+// The mockIdentityProviderInformer may be nil, in which case MockIdentityProviders are not allowed
+// to be used by FederationDomains.
 func NewFederationDomainWatcherController(
 	federationDomainsSetter FederationDomainsSetter,
 	apiGroupSuffix string,
@@ -107,58 +150,66 @@ func NewFederationDomainWatcherController(
 	ldapIdentityProviderInformer idpinformers.LDAPIdentityProviderInformer,
 	activeDirectoryIdentityProviderInformer idpinformers.ActiveDirectoryIdentityProviderInformer,
 	githubProviderInformer idpinformers.GitHubIdentityProviderInformer,
+	clientCertificateProviderInformer idpinformers.ClientCertificateIdentityProviderInformer,
+	openShiftProviderInformer idpinformers.OpenShiftIdentityProviderInformer,
+	mockIdentityProviderInformer idpinformers.MockIdentityProviderInformer,
 	withInformer pinnipedcontroller.WithInformerOptionFunc,
+	recorder events.EventRecorder,
 ) controllerlib.Controller {
-	allowedKinds := sets.New(kindActiveDirectoryIdentityProvider, kindLDAPIdentityProvider, kindOIDCIdentityProvider, kindGitHubIdentityProvider)
-	return controllerlib.New(
-		controllerlib.Config{
-			Name: controllerName,
-			Syncer: &federationDomainWatcherController{
-				federationDomainsSetter:                 federationDomainsSetter,
-				apiGroup:                                fmt.Sprintf("idp.supervisor.%s", apiGroupSuffix),
-				clock:                                   clock,
-				client:                                  client,
-				federationDomainInformer:                federationDomainInformer,
-				oidcIdentityProviderInformer:            oidcIdentityProviderInformer,
-				ldapIdentityProviderInformer:            ldapIdentityProviderInformer,
-				activeDirectoryIdentityProviderInformer: activeDirectoryIdentityProviderInformer,
-				githubIdentityProviderInformer:          githubProviderInformer,
-				allowedKinds:                            allowedKinds,
-			},
-		},
+	allowedKinds := sets.New(kindActiveDirectoryIdentityProvider, kindLDAPIdentityProvider, kindOIDCIdentityProvider, kindGitHubIdentityProvider, kindClientCertificateIdentityProvider, kindOpenShiftIdentityProvider)
+	idpInformers := []controllerlib.InformerGetter{
+		oidcIdentityProviderInformer,
+		ldapIdentityProviderInformer,
+		activeDirectoryIdentityProviderInformer,
+		githubProviderInformer,
+		clientCertificateProviderInformer,
+		openShiftProviderInformer,
+	}
+	if mockIdentityProviderInformer != nil {
+		allowedKinds.Insert(kindMockIdentityProvider)
+		idpInformers = append(idpInformers, mockIdentityProviderInformer)
+	}
+
+	opts := []controllerlib.Option{
 		withInformer(
 			federationDomainInformer,
 			pinnipedcontroller.MatchAnythingFilter(pinnipedcontroller.SingletonQueue()),
 			controllerlib.InformerOption{},
 		),
-		withInformer(
-			oidcIdentityProviderInformer,
-			// Since this controller only cares about IDP metadata names and UIDs (immutable fields),
-			// we only need to trigger Sync on creates and deletes.
-			pinnipedcontroller.MatchAnythingIgnoringUpdatesFilter(pinnipedcontroller.SingletonQueue()),
-			controllerlib.InformerOption{},
-		),
-		withInformer(
-			ldapIdentityProviderInformer,
-			// Since this controller only cares about IDP metadata names and UIDs (immutable fields),
-			// we only need to trigger Sync on creates and deletes.
-			pinnipedcontroller.MatchAnythingIgnoringUpdatesFilter(pinnipedcontroller.SingletonQueue()),
-			controllerlib.InformerOption{},
-		),
-		withInformer(
-			activeDirectoryIdentityProviderInformer,
-			// Since this controller only cares about IDP metadata names and UIDs (immutable fields),
-			// we only need to trigger Sync on creates and deletes.
-			pinnipedcontroller.MatchAnythingIgnoringUpdatesFilter(pinnipedcontroller.SingletonQueue()),
-			controllerlib.InformerOption{},
-		),
-		withInformer(
-			githubProviderInformer,
+		// Sync once at startup even when there are no FederationDomains, so the endpoints are always set at least once.
+		controllerlib.WithInitialEvent(controllerlib.Key{}),
+		controllerlib.WithRecorder(recorder),
+	}
+	for _, idpInformer := range idpInformers {
+		opts = append(opts, withInformer(
+			idpInformer,
 			// Since this controller only cares about IDP metadata names and UIDs (immutable fields),
 			// we only need to trigger Sync on creates and deletes.
 			pinnipedcontroller.MatchAnythingIgnoringUpdatesFilter(pinnipedcontroller.SingletonQueue()),
 			controllerlib.InformerOption{},
-		),
+		))
+	}
+
+	return controllerlib.New(
+		controllerlib.Config{
+			Name: controllerName,
+			Syncer: &federationDomainWatcherController{
+				federationDomainsSetter:                   federationDomainsSetter,
+				apiGroup:                                  fmt.Sprintf("idp.supervisor.%s", apiGroupSuffix),
+				clock:                                     clock,
+				client:                                    client,
+				federationDomainInformer:                  federationDomainInformer,
+				oidcIdentityProviderInformer:              oidcIdentityProviderInformer,
+				ldapIdentityProviderInformer:              ldapIdentityProviderInformer,
+				activeDirectoryIdentityProviderInformer:   activeDirectoryIdentityProviderInformer,
+				githubIdentityProviderInformer:            githubProviderInformer,
+				clientCertificateIdentityProviderInformer: clientCertificateProviderInformer,
+				openShiftIdentityProviderInformer:         openShiftProviderInformer,
+				mockIdentityProviderInformer:              mockIdentityProviderInformer,
+				allowedKinds:                              allowedKinds,
+			},
+		},
+		opts...,
 	)
 }
 
@@ -177,7 +228,7 @@ func (c *federationDomainWatcherController) Sync(ctx controllerlib.Context) erro
 	}
 
 	// Process each FederationDomain to validate its spec and to turn it into a FederationDomainIssuer.
-	federationDomainIssuers, fdToConditionsMap, err := c.processAllFederationDomains(ctx.Context, federationDomains)
+	federationDomainIssuers, fdToStatusMap, err := c.processAllFederationDomains(ctx.Context, federationDomains)
 	if err != nil {
 		return err
 	}
@@ -190,8 +241,8 @@ func (c *federationDomainWatcherController) Sync(ctx controllerlib.Context) erro
 	// statuses. This allows clients to wait for Ready without any race conditions in the
 	// endpoints being available.
 	var errs []error
-	for federationDomain, conditions := range fdToConditionsMap {
-		if err = c.updateStatus(ctx.Context, federationDomain, conditions); err != nil {
+	for federationDomain, status := range fdToStatusMap {
+		if err = c.updateStatus(ctx, federationDomain, status); err != nil {
 			errs = append(errs, fmt.Errorf("could not update status: %w", err))
 		}
 	}
@@ -202,9 +253,9 @@ func (c *federationDomainWatcherController) Sync(ctx controllerlib.Context) erro
 func (c *federationDomainWatcherController) processAllFederationDomains(
 	ctx context.Context,
 	federationDomains []*supervisorconfigv1alpha1.FederationDomain,
-) ([]*federationdomainproviders.FederationDomainIssuer, map[*supervisorconfigv1alpha1.FederationDomain][]*metav1.Condition, error) {
+) ([]*federationdomainproviders.FederationDomainIssuer, map[*supervisorconfigv1alpha1.FederationDomain]*federationDomainStatus, error) {
 	federationDomainIssuers := make([]*federationdomainproviders.FederationDomainIssuer, 0)
-	fdToConditionsMap := map[*supervisorconfigv1alpha1.FederationDomain][]*metav1.Condition{}
+	fdToStatusMap := map[*supervisorconfigv1alpha1.FederationDomain]*federationDomainStatus{}
 	crossDomainConfigValidator := newCrossFederationDomainConfigValidator(federationDomains)
 
 	for _, federationDomain := range federationDomains {
@@ -212,7 +263,7 @@ func (c *federationDomainWatcherController) processAllFederationDomains(
 
 		conditions = crossDomainConfigValidator.Validate(federationDomain, conditions)
 
-		federationDomainIssuer, conditions, err := c.makeFederationDomainIssuer(ctx, federationDomain, conditions)
+		federationDomainIssuer, conditions, idpStatuses, err := c.makeFederationDomainIssuer(ctx, federationDomain, conditions)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -220,40 +271,259 @@ func (c *federationDomainWatcherController) processAllFederationDomains(
 		// Now that we have determined the conditions, save them for after the loop.
 		// For a valid FederationDomain, want to update the conditions after we have
 		// made the FederationDomain's endpoints available.
-		fdToConditionsMap[federationDomain] = conditions
+		status := &federationDomainStatus{conditions: conditions, identityProviders: idpStatuses}
+		fdToStatusMap[federationDomain] = status
 
-		if !conditionsutil.HadErrorCondition(conditions) {
+		if !status.hadErrorCondition(federationDomain) {
 			// Successfully validated the FederationDomain, so allow it to be loaded.
 			federationDomainIssuers = append(federationDomainIssuers, federationDomainIssuer)
 		}
 	}
 
-	return federationDomainIssuers, fdToConditionsMap, nil
+	return federationDomainIssuers, fdToStatusMap, nil
+}
+
+// federationDomainStatus holds the results of validating a FederationDomain, to be written to its status.
+type federationDomainStatus struct {
+	conditions        []*metav1.Condition
+	identityProviders []supervisorconfigv1alpha1.FederationDomainIdentityProviderStatus
+}
+
+// hadErrorCondition returns true when the FederationDomain should not be loaded. Problems with individual identity
+// providers are tolerated when the FederationDomain's readiness policy is AnyIDPReady and at least one of its
+// identity providers is ready.
+func (s *federationDomainStatus) hadErrorCondition(federationDomain *supervisorconfigv1alpha1.FederationDomain) bool {
+	if !s.toleratesNotReadyIdentityProviders(federationDomain) {
+		return conditionsutil.HadErrorCondition(s.conditions)
+	}
+	for _, condition := range s.conditions {
+		if condition.Status != metav1.ConditionTrue && !identityProviderConditionTypes.Has(condition.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *federationDomainStatus) toleratesNotReadyIdentityProviders(federationDomain *supervisorconfigv1alpha1.FederationDomain) bool {
+	if federationDomain.Spec.ReadinessPolicy != supervisorconfigv1alpha1.FederationDomainReadinessPolicyAnyIDPReady {
+		return false
+	}
+	return len(notReadyIdentityProviderDisplayNames(s.identityProviders)) < len(s.identityProviders)
+}
+
+func notReadyIdentityProviderDisplayNames(idpStatuses []supervisorconfigv1alpha1.FederationDomainIdentityProviderStatus) []string {
+	var displayNames []string
+	for _, idpStatus := range idpStatuses {
+		if idpStatus.Phase != supervisorconfigv1alpha1.FederationDomainIdentityProviderPhaseReady {
+			displayNames = append(displayNames, idpStatus.DisplayName)
+		}
+	}
+	return displayNames
 }
 
 func (c *federationDomainWatcherController) makeFederationDomainIssuer(
 	ctx context.Context,
 	federationDomain *supervisorconfigv1alpha1.FederationDomain,
 	conditions []*metav1.Condition,
-) (*federationdomainproviders.FederationDomainIssuer, []*metav1.Condition, error) {
+) (*federationdomainproviders.FederationDomainIssuer, []*metav1.Condition, []supervisorconfigv1alpha1.FederationDomainIdentityProviderStatus, error) {
 	var err error
 	// Create the list of IDPs for this FederationDomain.
 	// Don't worry if the IDP CRs themselves is phase=Ready because those which are not ready will not be loaded
 	// into the provider cache, so they cannot actually be used to authenticate.
 	var federationDomainIssuer *federationdomainproviders.FederationDomainIssuer
+	var idpStatuses []supervisorconfigv1alpha1.FederationDomainIdentityProviderStatus
 	if len(federationDomain.Spec.IdentityProviders) == 0 {
 		federationDomainIssuer, conditions, err = c.makeLegacyFederationDomainIssuer(federationDomain, conditions)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 	} else {
-		federationDomainIssuer, conditions, err = c.makeFederationDomainIssuerWithExplicitIDPs(ctx, federationDomain, conditions)
+		federationDomainIssuer, conditions, idpStatuses, err = c.makeFederationDomainIssuerWithExplicitIDPs(ctx, federationDomain, conditions)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 	}
 
-	return federationDomainIssuer, conditions, nil
+	// The issuer will be nil when the issuer URL was invalid, which is reported by the conditions.
+	if federationDomainIssuer != nil {
+		federationDomainIssuer.SetLoginRateLimits(loginRateLimitsConfig(federationDomain.Spec.LoginRateLimits))
+		federationDomainIssuer.SetTokenEndpointLoadShedding(tokenEndpointLoadSheddingConfig(federationDomain.Spec.TokenEndpointLoadShedding))
+		federationDomainIssuer.SetAccessLogEnabled(federationDomain.Spec.AccessLog != nil && federationDomain.Spec.AccessLog.Enabled)
+		federationDomainIssuer.SetTokenEnrichmentWebhook(tokenEnrichmentWebhookConfig(federationDomain.Spec.TokenEnrichmentWebhook))
+		federationDomainIssuer.SetJWTAccessTokens(jwtAccessTokensConfig(federationDomain.Spec.AccessTokens))
+		federationDomainIssuer.SetWorkloadIdentity(workloadIdentityConfig(federationDomain.Spec.WorkloadIdentity))
+		if signingKeys := federationDomain.Spec.SigningKeys; signingKeys != nil {
+			federationDomainIssuer.SetSigningAlgorithm(string(signingKeys.Algorithm))
+		}
+		federationDomainIssuer.SetCORS(corsConfig(federationDomain.Spec.CORS))
+		federationDomainIssuer.SetSingleSignOn(singleSignOnConfig(federationDomain.Spec.SingleSignOn))
+		federationDomainIssuer.SetWebAuthn(webAuthnConfig(federationDomain.Spec.WebAuthn))
+		federationDomainIssuer.SetListener(federationDomain.Spec.Listener)
+		federationDomainIssuer.SetNotReadyIdentityProviderDisplayNames(notReadyIdentityProviderDisplayNames(idpStatuses))
+		if previousIssuer := federationDomain.Spec.PreviousIssuer; previousIssuer != nil {
+			err = federationDomainIssuer.SetPreviousIssuer(previousIssuer.Issuer, previousIssuer.RefreshGracePeriodEnd.Time)
+			if err != nil {
+				conditions = replaceIssuerURLValidConditionForPreviousIssuer(err, conditions)
+			}
+		}
+	}
+
+	conditions = appendAccessTokensValidCondition(federationDomain.Spec.AccessTokens, conditions)
+
+	return federationDomainIssuer, conditions, idpStatuses, nil
+}
+
+// replaceIssuerURLValidConditionForPreviousIssuer replaces the successful IssuerURLValid condition with an
+// unsuccessful one, because the spec.previousIssuer.issuer URL was invalid.
+func replaceIssuerURLValidConditionForPreviousIssuer(err error, conditions []*metav1.Condition) []*metav1.Condition {
+	for _, condition := range conditions {
+		if condition.Type == typeIssuerURLValid {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = reasonInvalidIssuerURL
+			condition.Message = fmt.Sprintf("spec.previousIssuer.issuer is invalid: %s", err.Error())
+		}
+	}
+	return conditions
+}
+
+// loginRateLimitsConfig returns the throttling config for the spec, applying defaults for any unspecified settings.
+// Returns nil when the spec is nil, which means that login attempts should not be throttled.
+func loginRateLimitsConfig(spec *supervisorconfigv1alpha1.FederationDomainLoginRateLimits) *loginthrottle.Config {
+	if spec == nil {
+		return nil
+	}
+	config := &loginthrottle.Config{
+		RequestsPerMinutePerSourceIP: loginthrottle.DefaultRequestsPerMinutePerSourceIP,
+		FailedAttemptsBeforeLockout:  loginthrottle.DefaultFailedAttemptsBeforeLockout,
+		LockoutDuration:              loginthrottle.DefaultLockoutDuration,
+	}
+	if spec.RequestsPerMinutePerSourceIP != nil {
+		config.RequestsPerMinutePerSourceIP = int(*spec.RequestsPerMinutePerSourceIP)
+	}
+	if spec.FailedAttemptsBeforeLockout != nil {
+		config.FailedAttemptsBeforeLockout = int(*spec.FailedAttemptsBeforeLockout)
+	}
+	if spec.LockoutSeconds != nil {
+		config.LockoutDuration = time.Duration(*spec.LockoutSeconds) * time.Second
+	}
+	for _, cidr := range spec.TrustedProxies {
+		// The CRD only allows CIDRs, so this should not fail. Ignore any invalid CIDR, which would then not be trusted.
+		if prefix, err := netip.ParsePrefix(cidr); err == nil {
+			config.TrustedProxies = append(config.TrustedProxies, prefix.Masked())
+		}
+	}
+	return config
+}
+
+// tokenEndpointLoadSheddingConfig returns the load-shedding config for the spec, applying defaults for any
+// unspecified settings. Returns nil when the spec is nil, which means that concurrent token requests are not limited.
+func tokenEndpointLoadSheddingConfig(spec *supervisorconfigv1alpha1.FederationDomainTokenEndpointLoadShedding) *loadshed.Config {
+	if spec == nil {
+		return nil
+	}
+	config := &loadshed.Config{
+		MaxConcurrentRequests: loadshed.DefaultMaxConcurrentRequests,
+		QueueTimeout:          loadshed.DefaultQueueTimeout,
+	}
+	if spec.MaxConcurrentRequests != nil {
+		config.MaxConcurrentRequests = int(*spec.MaxConcurrentRequests)
+	}
+	if spec.QueueTimeoutSeconds != nil {
+		config.QueueTimeout = time.Duration(*spec.QueueTimeoutSeconds) * time.Second
+	}
+	return config
+}
+
+// tokenEnrichmentWebhookConfig returns the webhook config for the spec, applying defaults for any unspecified
+// settings. Returns nil when the spec is nil, which means that no token enrichment webhook should be called.
+func tokenEnrichmentWebhookConfig(spec *supervisorconfigv1alpha1.FederationDomainTokenEnrichmentWebhook) *tokenenrichment.Config {
+	if spec == nil {
+		return nil
+	}
+	config := &tokenenrichment.Config{
+		Endpoint:                 spec.Endpoint,
+		CertificateAuthorityData: spec.CertificateAuthorityData,
+		Timeout:                  tokenenrichment.DefaultTimeout,
+		FailurePolicy:            tokenenrichment.FailurePolicyFail,
+	}
+	if spec.TimeoutSeconds != nil {
+		config.Timeout = time.Duration(*spec.TimeoutSeconds) * time.Second
+	}
+	if spec.FailurePolicy == supervisorconfigv1alpha1.FederationDomainTokenEnrichmentFailurePolicyIgnore {
+		config.FailurePolicy = tokenenrichment.FailurePolicyIgnore
+	}
+	return config
+}
+
+// corsConfig returns the CORS config for the spec, applying defaults for any unspecified settings. Returns nil when
+// the spec is nil, which means that web pages of other origins may not call the endpoints.
+func corsConfig(spec *supervisorconfigv1alpha1.FederationDomainCORSSpec) *cors.Config {
+	if spec == nil {
+		return nil
+	}
+	config := &cors.Config{
+		AllowedOrigins: spec.AllowedOrigins,
+		AllowedHeaders: cors.DefaultAllowedHeaders(),
+	}
+	if len(spec.AllowedHeaders) > 0 {
+		config.AllowedHeaders = spec.AllowedHeaders
+	}
+	return config
+}
+
+// singleSignOnConfig returns the single sign-on config for the spec, applying the default cookie lifetime when it is
+// unspecified. Returns nil when the spec is nil, which means that browsers always log in at the upstream.
+func singleSignOnConfig(spec *supervisorconfigv1alpha1.FederationDomainSingleSignOn) *singlesignon.Config {
+	if spec == nil {
+		return nil
+	}
+	config := &singlesignon.Config{CookieLifetime: singlesignon.DefaultCookieLifetime}
+	if spec.CookieLifetimeSeconds != nil {
+		config.CookieLifetime = time.Duration(*spec.CookieLifetimeSeconds) * time.Second
+	}
+	return config
+}
+
+// webAuthnConfig returns the WebAuthn config for the spec, applying the default enforcement when it is unspecified.
+// Returns nil when the spec is nil, which means that users never need a WebAuthn credential.
+func webAuthnConfig(spec *supervisorconfigv1alpha1.FederationDomainWebAuthn) *secondfactor.WebAuthnConfig {
+	if spec == nil {
+		return nil
+	}
+	config := &secondfactor.WebAuthnConfig{Enforcement: secondfactor.WebAuthnRequired}
+	if spec.Enforcement == supervisorconfigv1alpha1.FederationDomainWebAuthnEnforcementOptional {
+		config.Enforcement = secondfactor.WebAuthnOptional
+	}
+	return config
+}
+
+// jwtAccessTokensConfig returns the JWT access token config for the spec. Returns nil when the spec is nil or when
+// its format is not JWT, which means that opaque access tokens should be issued.
+func jwtAccessTokensConfig(spec *supervisorconfigv1alpha1.FederationDomainAccessTokens) *strategy.JWTAccessTokenConfig {
+	if spec == nil || spec.Format != supervisorconfigv1alpha1.FederationDomainAccessTokenFormatJWT {
+		return nil
+	}
+	config := &strategy.JWTAccessTokenConfig{Audience: spec.Audience}
+	for _, claim := range spec.Claims {
+		config.Claims = append(config.Claims, string(claim))
+	}
+	return config
+}
+
+// workloadIdentityConfig returns the workload identity config for the spec, applying the default username prefix
+// when it is unspecified. Returns nil when the spec is nil, which means that ServiceAccount tokens are not accepted.
+func workloadIdentityConfig(spec *supervisorconfigv1alpha1.FederationDomainWorkloadIdentity) *workloadidentity.Config {
+	if spec == nil {
+		return nil
+	}
+	config := &workloadidentity.Config{
+		AllowedNamespaces: spec.AllowedNamespaces,
+		UsernamePrefix:    spec.UsernamePrefix,
+	}
+	if config.UsernamePrefix == "" {
+		config.UsernamePrefix = workloadidentity.DefaultUsernamePrefix
+	}
+	return config
 }
 
 func (c *federationDomainWatcherController) makeLegacyFederationDomainIssuer(
@@ -279,35 +549,54 @@ func (c *federationDomainWatcherController) makeLegacyFederationDomainIssuer(
 	if err != nil {
 		return nil, nil, err
 	}
+	clientCertificateIdentityProviders, err := c.clientCertificateIdentityProviderInformer.Lister().List(labels.Everything())
+	if err != nil {
+		return nil, nil, err
+	}
+	openShiftIdentityProviders, err := c.openShiftIdentityProviderInformer.Lister().List(labels.Everything())
+	if err != nil {
+		return nil, nil, err
+	}
 	// Check if that there is exactly one IDP defined in the Supervisor namespace of any IDP CRD type.
-	idpCRsCount := len(oidcIdentityProviders) + len(ldapIdentityProviders) + len(activeDirectoryIdentityProviders) + len(githubIdentityProviders)
+	idpCRsCount := len(oidcIdentityProviders) + len(ldapIdentityProviders) + len(activeDirectoryIdentityProviders) +
+		len(githubIdentityProviders) + len(clientCertificateIdentityProviders) + len(openShiftIdentityProviders)
 
 	switch {
 	case idpCRsCount == 1:
-		foundIDPName := ""
-		// If so, default that IDP's DisplayName to be the same as its resource Name.
-		defaultFederationDomainIdentityProvider = &federationdomainproviders.FederationDomainIdentityProvider{}
+		var foundIDP metav1.Object
 		switch {
 		case len(oidcIdentityProviders) == 1:
-			defaultFederationDomainIdentityProvider.DisplayName = oidcIdentityProviders[0].Name
-			defaultFederationDomainIdentityProvider.UID = oidcIdentityProviders[0].UID
-			foundIDPName = oidcIdentityProviders[0].Name
+			foundIDP = oidcIdentityProviders[0]
 		case len(ldapIdentityProviders) == 1:
-			defaultFederationDomainIdentityProvider.DisplayName = ldapIdentityProviders[0].Name
-			defaultFederationDomainIdentityProvider.UID = ldapIdentityProviders[0].UID
-			foundIDPName = ldapIdentityProviders[0].Name
+			foundIDP = ldapIdentityProviders[0]
 		case len(activeDirectoryIdentityProviders) == 1:
-			defaultFederationDomainIdentityProvider.DisplayName = activeDirectoryIdentityProviders[0].Name
-			defaultFederationDomainIdentityProvider.UID = activeDirectoryIdentityProviders[0].UID
-			foundIDPName = activeDirectoryIdentityProviders[0].Name
+			foundIDP = activeDirectoryIdentityProviders[0]
 		case len(githubIdentityProviders) == 1:
-			defaultFederationDomainIdentityProvider.DisplayName = githubIdentityProviders[0].Name
-			defaultFederationDomainIdentityProvider.UID = githubIdentityProviders[0].UID
-			foundIDPName = githubIdentityProviders[0].Name
+			foundIDP = githubIdentityProviders[0]
+		case len(clientCertificateIdentityProviders) == 1:
+			foundIDP = clientCertificateIdentityProviders[0]
+		case len(openShiftIdentityProviders) == 1:
+			foundIDP = openShiftIdentityProviders[0]
+		}
+		if !identityProviderAllowsFederationDomain(foundIDP, federationDomain.Name) {
+			conditions = append(conditions, &metav1.Condition{
+				Type:   typeIdentityProvidersFound,
+				Status: metav1.ConditionFalse,
+				Reason: reasonIdentityProvidersNotAllowed,
+				Message: fmt.Sprintf("no resources were specified by .spec.identityProviders[].objectRef and the only "+
+					"identity provider resource %q does not allow this FederationDomain to use it: see its %s annotation",
+					foundIDP.GetName(), AllowedFederationDomainsAnnotation),
+			})
+			break
 		}
+		// If so, default that IDP's DisplayName to be the same as its resource Name.
 		// Backwards compatibility mode always uses an empty identity transformation pipeline since no
 		// transformations are defined on the FederationDomain.
-		defaultFederationDomainIdentityProvider.Transforms = idtransform.NewTransformationPipeline()
+		defaultFederationDomainIdentityProvider = &federationdomainproviders.FederationDomainIdentityProvider{
+			DisplayName: foundIDP.GetName(),
+			UID:         foundIDP.GetUID(),
+			Transforms:  idtransform.NewTransformationPipeline(),
+		}
 		conditions = append(conditions, &metav1.Condition{
 			Type:   typeIdentityProvidersFound,
 			Status: metav1.ConditionTrue,
@@ -315,7 +604,7 @@ func (c *federationDomainWatcherController) makeLegacyFederationDomainIssuer(
 			Message: fmt.Sprintf("no resources were specified by .spec.identityProviders[].objectRef but exactly one "+
 				"identity provider resource has been found: using %q as "+
 				"identity provider: please explicitly list identity providers in .spec.identityProviders "+
-				"(this legacy configuration mode may be removed in a future version of Pinniped)", foundIDPName),
+				"(this legacy configuration mode may be removed in a future version of Pinniped)", foundIDP.GetName()),
 		})
 	case idpCRsCount > 1:
 		conditions = append(conditions, &metav1.Condition{
@@ -357,9 +646,11 @@ func (c *federationDomainWatcherController) makeFederationDomainIssuerWithExplic
 	ctx context.Context,
 	federationDomain *supervisorconfigv1alpha1.FederationDomain,
 	conditions []*metav1.Condition,
-) (*federationdomainproviders.FederationDomainIssuer, []*metav1.Condition, error) {
+) (*federationdomainproviders.FederationDomainIssuer, []*metav1.Condition, []supervisorconfigv1alpha1.FederationDomainIdentityProviderStatus, error) {
 	federationDomainIdentityProviders := []*federationdomainproviders.FederationDomainIdentityProvider{}
+	idpStatuses := []supervisorconfigv1alpha1.FederationDomainIdentityProviderStatus{}
 	idpNotFoundIndices := []int{}
+	idpNotAllowedIndices := []int{}
 	displayNames := sets.Set[string]{}
 	duplicateDisplayNames := sets.Set[string]{}
 	badAPIGroupNames := []string{}
@@ -367,13 +658,14 @@ func (c *federationDomainWatcherController) makeFederationDomainIssuerWithExplic
 	validationErrorMessages := &transformsValidationErrorMessages{}
 
 	for index, idp := range federationDomain.Spec.IdentityProviders {
-		idpIsValid := true
+		// Collect the reasons why this IDP is not valid, to be reported in the status.
+		var idpProblems []string
 
 		// The CRD requires the displayName field, and validates that it has at least one character,
 		// so here we only need to validate that they are unique.
 		if displayNames.Has(idp.DisplayName) {
 			duplicateDisplayNames.Insert(idp.DisplayName)
-			idpIsValid = false
+			idpProblems = append(idpProblems, "the displayName is not unique")
 		}
 		displayNames.Insert(idp.DisplayName)
 
@@ -387,10 +679,12 @@ func (c *federationDomainWatcherController) makeFederationDomainIssuerWithExplic
 		}
 		if apiGroup != c.apiGroup {
 			badAPIGroupNames = append(badAPIGroupNames, apiGroup)
+			idpProblems = append(idpProblems, fmt.Sprintf("the objectRef.apiGroup %q is not recognized", apiGroup))
 			canTryToFindIDP = false
 		}
 		if !c.allowedKinds.Has(idp.ObjectRef.Kind) {
 			badKinds = append(badKinds, idp.ObjectRef.Kind)
+			idpProblems = append(idpProblems, fmt.Sprintf("the objectRef.kind %q is not recognized", idp.ObjectRef.Kind))
 			canTryToFindIDP = false
 		}
 
@@ -398,18 +692,26 @@ func (c *federationDomainWatcherController) makeFederationDomainIssuerWithExplic
 		var idpResourceUID types.UID
 		idpWasFound := false
 		if canTryToFindIDP {
-			var err error
 			// Validate that each objectRef resolves to an existing IDP. It does not matter if the IDP itself
 			// is phase=Ready, because it will not be loaded into the cache if not ready. For each objectRef
 			// that does not resolve, put an error on the FederationDomain status.
-			idpResourceUID, idpWasFound, err = c.findIDPsUIDByObjectRef(idp.ObjectRef, federationDomain.Namespace)
+			foundIDP, err := c.findIDPByObjectRef(idp.ObjectRef, federationDomain.Namespace)
 			if err != nil {
-				return nil, nil, err
+				return nil, nil, nil, err
+			}
+			idpWasFound = foundIDP != nil
+			switch {
+			case !idpWasFound:
+				idpProblems = append(idpProblems, fmt.Sprintf("cannot find resource specified by objectRef (with name %q)", idp.ObjectRef.Name))
+			case !identityProviderAllowsFederationDomain(foundIDP, federationDomain.Name):
+				idpNotAllowedIndices = append(idpNotAllowedIndices, index)
+				idpProblems = append(idpProblems, fmt.Sprintf("the resource specified by objectRef does not allow this FederationDomain to use it: see its %s annotation", AllowedFederationDomainsAnnotation))
+			default:
+				idpResourceUID = foundIDP.GetUID()
 			}
 		}
 		if !canTryToFindIDP || !idpWasFound {
 			idpNotFoundIndices = append(idpNotFoundIndices, index)
-			idpIsValid = false
 		}
 
 		var err error
@@ -418,22 +720,38 @@ func (c *federationDomainWatcherController) makeFederationDomainIssuerWithExplic
 		pipeline, allExamplesPassed, err = c.makeTransformationPipelineAndEvaluateExamplesForIdentityProvider(
 			ctx, idp, index, validationErrorMessages)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 		if !allExamplesPassed {
-			idpIsValid = false
+			idpProblems = append(idpProblems, "the transforms are not valid: see the TransformsExpressionsValid and TransformsExamplesPassed conditions")
 		}
 
-		if !idpIsValid {
+		if len(idpProblems) > 0 {
 			// Something about the IDP was not valid. Don't add it.
+			idpStatuses = append(idpStatuses, supervisorconfigv1alpha1.FederationDomainIdentityProviderStatus{
+				DisplayName: idp.DisplayName,
+				Phase:       supervisorconfigv1alpha1.FederationDomainIdentityProviderPhaseError,
+				Message:     strings.Join(idpProblems, "; "),
+			})
 			continue
 		}
 
+		idpStatuses = append(idpStatuses, supervisorconfigv1alpha1.FederationDomainIdentityProviderStatus{
+			DisplayName: idp.DisplayName,
+			Phase:       supervisorconfigv1alpha1.FederationDomainIdentityProviderPhaseReady,
+			Message:     "the identity provider is ready to be used by this FederationDomain",
+		})
+
 		// For a valid IDP (unique displayName, valid objectRef, valid transforms), add it to the list.
 		federationDomainIdentityProviders = append(federationDomainIdentityProviders, &federationdomainproviders.FederationDomainIdentityProvider{
 			DisplayName: idp.DisplayName,
 			UID:         idpResourceUID,
 			Transforms:  pipeline,
+			Presentation: resolvedprovider.Presentation{
+				Description: idp.Description,
+				IconURL:     idp.IconURL,
+				Order:       idp.Order,
+			},
 		})
 	}
 
@@ -441,7 +759,7 @@ func (c *federationDomainWatcherController) makeFederationDomainIssuerWithExplic
 	federationDomainIssuer, err := federationdomainproviders.NewFederationDomainIssuer(federationDomain.Spec.Issuer, federationDomainIdentityProviders)
 	conditions = appendIssuerURLValidCondition(err, conditions)
 
-	conditions = appendIdentityProvidersFoundCondition(idpNotFoundIndices, federationDomain.Spec.IdentityProviders, conditions)
+	conditions = appendIdentityProvidersFoundCondition(idpNotFoundIndices, idpNotAllowedIndices, federationDomain.Spec.IdentityProviders, conditions)
 	conditions = appendIdentityProviderDuplicateDisplayNamesCondition(duplicateDisplayNames, conditions)
 	conditions = appendIdentityProviderObjectRefAPIGroupSuffixCondition(c.apiGroup, badAPIGroupNames, conditions)
 	conditions = appendIdentityProviderObjectRefKindCondition(c.sortedAllowedKinds(), badKinds, conditions)
@@ -449,11 +767,11 @@ func (c *federationDomainWatcherController) makeFederationDomainIssuerWithExplic
 	conditions = appendTransformsExpressionsValidCondition(validationErrorMessages.errorsForExpressions, conditions)
 	conditions = appendTransformsExamplesPassedCondition(validationErrorMessages.errorsForExamples, conditions)
 
-	return federationDomainIssuer, conditions, nil
+	return federationDomainIssuer, conditions, idpStatuses, nil
 }
 
-func (c *federationDomainWatcherController) findIDPsUIDByObjectRef(objectRef corev1.TypedLocalObjectReference, namespace string) (types.UID, bool, error) {
-	var idpResourceUID types.UID
+// findIDPByObjectRef returns the identity provider resource specified by the objectRef, or nil when it does not exist.
+func (c *federationDomainWatcherController) findIDPByObjectRef(objectRef corev1.TypedLocalObjectReference, namespace string) (metav1.Object, error) {
 	var foundIDP metav1.Object
 	var err error
 
@@ -466,20 +784,41 @@ func (c *federationDomainWatcherController) findIDPsUIDByObjectRef(objectRef cor
 		foundIDP, err = c.oidcIdentityProviderInformer.Lister().OIDCIdentityProviders(namespace).Get(objectRef.Name)
 	case kindGitHubIdentityProvider:
 		foundIDP, err = c.githubIdentityProviderInformer.Lister().GitHubIdentityProviders(namespace).Get(objectRef.Name)
+	case kindClientCertificateIdentityProvider:
+		foundIDP, err = c.clientCertificateIdentityProviderInformer.Lister().ClientCertificateIdentityProviders(namespace).Get(objectRef.Name)
+	case kindOpenShiftIdentityProvider:
+		foundIDP, err = c.openShiftIdentityProviderInformer.Lister().OpenShiftIdentityProviders(namespace).Get(objectRef.Name)
+	case kindMockIdentityProvider:
+		// This kind is only in allowedKinds when the informer is not nil.
+		foundIDP, err = c.mockIdentityProviderInformer.Lister().MockIdentityProviders(namespace).Get(objectRef.Name)
 	default:
 		// This shouldn't happen because this helper function is not called when the kind is invalid.
-		return "", false, fmt.Errorf("unexpected kind: %s", objectRef.Kind)
+		return nil, fmt.Errorf("unexpected kind: %s", objectRef.Kind)
 	}
 
 	switch {
 	case err == nil:
-		idpResourceUID = foundIDP.GetUID()
+		return foundIDP, nil
 	case apierrors.IsNotFound(err):
-		return "", false, nil
+		return nil, nil
 	default:
-		return "", false, err // unexpected error from the informer
+		return nil, err // unexpected error from the informer
+	}
+}
+
+// identityProviderAllowsFederationDomain returns true unless the identity provider resource has the
+// AllowedFederationDomainsAnnotation and the named FederationDomain is not listed by it.
+func identityProviderAllowsFederationDomain(idp metav1.Object, federationDomainName string) bool {
+	allowed, ok := idp.GetAnnotations()[AllowedFederationDomainsAnnotation]
+	if !ok {
+		return true
+	}
+	for _, name := range strings.Split(allowed, ",") {
+		if strings.TrimSpace(name) == federationDomainName {
+			return true
+		}
 	}
-	return idpResourceUID, true, nil
+	return false
 }
 
 func (c *federationDomainWatcherController) makeTransformationPipelineAndEvaluateExamplesForIdentityProvider(
@@ -682,19 +1021,30 @@ func appendIdentityProviderObjectRefKindCondition(expectedKinds []string, badSuf
 
 func appendIdentityProvidersFoundCondition(
 	idpNotFoundIndices []int,
+	idpNotAllowedIndices []int,
 	federationDomainIdentityProviders []supervisorconfigv1alpha1.FederationDomainIdentityProvider,
 	conditions []*metav1.Condition,
 ) []*metav1.Condition {
-	if len(idpNotFoundIndices) != 0 {
+	if len(idpNotFoundIndices) != 0 || len(idpNotAllowedIndices) != 0 {
 		messages := []string{}
 		for _, idpNotFoundIndex := range idpNotFoundIndices {
 			messages = append(messages, fmt.Sprintf("cannot find resource specified by .spec.identityProviders[%d].objectRef (with name %q)",
 				idpNotFoundIndex, federationDomainIdentityProviders[idpNotFoundIndex].ObjectRef.Name))
 		}
+		for _, idpNotAllowedIndex := range idpNotAllowedIndices {
+			messages = append(messages, fmt.Sprintf("the resource specified by .spec.identityProviders[%d].objectRef (with name %q) "+
+				"does not allow this FederationDomain to use it: see its %s annotation",
+				idpNotAllowedIndex, federationDomainIdentityProviders[idpNotAllowedIndex].ObjectRef.Name, AllowedFederationDomainsAnnotation))
+		}
+		// Missing resources are reported with priority, since they are the more fundamental problem.
+		reason := reasonIdentityProvidersObjectRefsNotFound
+		if len(idpNotFoundIndices) == 0 {
+			reason = reasonIdentityProvidersNotAllowed
+		}
 		conditions = append(conditions, &metav1.Condition{
 			Type:    typeIdentityProvidersFound,
 			Status:  metav1.ConditionFalse,
-			Reason:  reasonIdentityProvidersObjectRefsNotFound,
+			Reason:  reason,
 			Message: strings.Join(messages, "\n\n"),
 		})
 	} else if len(federationDomainIdentityProviders) != 0 {
@@ -766,6 +1116,40 @@ func appendTransformsExamplesPassedCondition(messages []string, conditions []*me
 	return conditions
 }
 
+// appendAccessTokensValidCondition only appends the condition when JWT access tokens are configured, so that its
+// message can warn about their security implications.
+func appendAccessTokensValidCondition(spec *supervisorconfigv1alpha1.FederationDomainAccessTokens, conditions []*metav1.Condition) []*metav1.Condition {
+	if jwtAccessTokensConfig(spec) == nil {
+		return conditions
+	}
+
+	var reservedAudiences []string
+	for _, audience := range spec.Audience {
+		if strings.Contains(audience, ".pinniped.dev") {
+			reservedAudiences = append(reservedAudiences, audience)
+		}
+	}
+	if len(reservedAudiences) > 0 {
+		return append(conditions, &metav1.Condition{
+			Type:   typeAccessTokensValid,
+			Status: metav1.ConditionFalse,
+			Reason: reasonInvalidAccessTokenAudience,
+			Message: fmt.Sprintf("the audiences specified by .spec.accessTokens.audience must not contain '.pinniped.dev': [%s]",
+				strings.Join(sortAndQuote(reservedAudiences), ", ")),
+		})
+	}
+
+	return append(conditions, &metav1.Condition{
+		Type:   typeAccessTokensValid,
+		Status: metav1.ConditionTrue,
+		Reason: reasonSuccess,
+		Message: "access tokens are issued as JWTs which any workload can validate using the JWKS of this FederationDomain: " +
+			"these access tokens cannot be revoked before they expire, and anyone who holds one can read its claims, " +
+			"so only send them to the workloads in their audience, and ensure that those workloads check the audience " +
+			"and the 'at+jwt' type of the access tokens, so that they cannot be used in place of ID tokens",
+	})
+}
+
 func appendIdentityProviderDuplicateDisplayNamesCondition(duplicateDisplayNames sets.Set[string], conditions []*metav1.Condition) []*metav1.Condition {
 	if duplicateDisplayNames.Len() > 0 {
 		conditions = append(conditions, &metav1.Condition{
@@ -808,13 +1192,15 @@ func appendIssuerURLValidCondition(err error, conditions []*metav1.Condition) []
 }
 
 func (c *federationDomainWatcherController) updateStatus(
-	ctx context.Context,
+	ctx controllerlib.Context,
 	federationDomain *supervisorconfigv1alpha1.FederationDomain,
-	conditions []*metav1.Condition,
+	status *federationDomainStatus,
 ) error {
 	updated := federationDomain.DeepCopy()
+	updated.Status.IdentityProviders = status.identityProviders
+	conditions := status.conditions
 
-	if conditionsutil.HadErrorCondition(conditions) {
+	if status.hadErrorCondition(federationDomain) {
 		updated.Status.Phase = supervisorconfigv1alpha1.FederationDomainPhaseError
 		conditions = append(conditions, &metav1.Condition{
 			Type:    typeReady,
@@ -831,6 +1217,18 @@ func (c *federationDomainWatcherController) updateStatus(
 			Message: fmt.Sprintf("the FederationDomain is ready and its endpoints are available: "+
 				"the discovery endpoint is %s/.well-known/openid-configuration", federationDomain.Spec.Issuer),
 		})
+		if notReady := notReadyIdentityProviderDisplayNames(status.identityProviders); len(notReady) > 0 {
+			conditions[len(conditions)-1].Message += fmt.Sprintf(
+				"; some identity providers are not ready and cannot be used: [%s] (see status.identityProviders for details)",
+				strings.Join(sortAndQuote(notReady), ", "))
+		}
+	}
+
+	// The AccessTokensValid condition is only present when JWT access tokens are configured.
+	if !slices.ContainsFunc(conditions, func(c *metav1.Condition) bool { return c.Type == typeAccessTokensValid }) {
+		updated.Status.Conditions = slices.DeleteFunc(updated.Status.Conditions, func(c metav1.Condition) bool {
+			return c.Type == typeAccessTokensValid
+		})
 	}
 
 	_ = conditionsutil.MergeConditions(conditions,
@@ -843,8 +1241,13 @@ func (c *federationDomainWatcherController) updateStatus(
 	_, err := c.client.
 		ConfigV1alpha1().
 		FederationDomains(federationDomain.Namespace).
-		UpdateStatus(ctx, updated, metav1.UpdateOptions{})
-	return err
+		UpdateStatus(ctx.Context, updated, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+
+	conditionsutil.RecordConditionChanges(ctx.Recorder, updated, federationDomain.Status.Conditions, updated.Status.Conditions)
+	return nil
 }
 
 func sortAndQuote(strs []string) []string {
@@ -867,6 +1270,7 @@ type transformsValidationErrorMessages struct {
 
 type crossFederationDomainConfigValidator struct {
 	issuerCounts                      map[string]int
+	previousIssuerCounts              map[string]int
 	uniqueSecretNamesPerIssuerAddress map[string]map[string]bool
 }
 
@@ -905,6 +1309,13 @@ func (v *crossFederationDomainConfigValidator) Validate(federationDomain *superv
 			Reason:  reasonDuplicateIssuer,
 			Message: "multiple FederationDomains have the same spec.issuer URL: these URLs must be unique (can use different hosts or paths)",
 		})
+	} else if v.previousIssuerIsDuplicated(federationDomain, issuerURL) {
+		conditions = append(conditions, &metav1.Condition{
+			Type:    typeIssuerIsUnique,
+			Status:  metav1.ConditionFalse,
+			Reason:  reasonDuplicateIssuer,
+			Message: "the spec.issuer and spec.previousIssuer.issuer URLs of all FederationDomains must be unique: found a duplicate spec.previousIssuer.issuer URL",
+		})
 	} else {
 		conditions = append(conditions, &metav1.Condition{
 			Type:    typeIssuerIsUnique,
@@ -933,11 +1344,33 @@ func (v *crossFederationDomainConfigValidator) Validate(federationDomain *superv
 	return conditions
 }
 
+// previousIssuerIsDuplicated returns true when the issuer of the FederationDomain is used as the previous issuer of
+// another FederationDomain, or when its previous issuer is used as the issuer or previous issuer of another one.
+func (v *crossFederationDomainConfigValidator) previousIssuerIsDuplicated(federationDomain *supervisorconfigv1alpha1.FederationDomain, issuerURL *url.URL) bool {
+	if v.previousIssuerCounts[issuerURLToIssuerKey(issuerURL)] > 0 {
+		return true
+	}
+	if federationDomain.Spec.PreviousIssuer == nil {
+		return false
+	}
+	previousIssuerURL, err := url.Parse(federationDomain.Spec.PreviousIssuer.Issuer)
+	if err != nil {
+		return false // Invalid URLs are reported by the IssuerURLValid condition.
+	}
+	previousIssuerKey := issuerURLToIssuerKey(previousIssuerURL)
+	if previousIssuerKey == issuerURLToIssuerKey(issuerURL) {
+		return false // A previous issuer which is the same as the issuer is reported by the IssuerURLValid condition.
+	}
+	return v.issuerCounts[previousIssuerKey] > 0 || v.previousIssuerCounts[previousIssuerKey] > 1
+}
+
 func newCrossFederationDomainConfigValidator(federationDomains []*supervisorconfigv1alpha1.FederationDomain) *crossFederationDomainConfigValidator {
 	// Make a map of issuer strings -> count of how many times we saw that issuer string.
 	// This will help us complain when there are duplicate issuer strings.
 	// Also make a helper function for forming keys into this map.
 	issuerCounts := make(map[string]int)
+	// Also count the previous issuers, which must not collide with any issuer or other previous issuer.
+	previousIssuerCounts := make(map[string]int)
 
 	// Make a map of issuer hostnames -> set of unique secret names. This will help us complain when
 	// multiple FederationDomains have the same issuer hostname (excluding port) but specify
@@ -954,6 +1387,14 @@ func newCrossFederationDomainConfigValidator(federationDomains []*supervisorconf
 
 		issuerCounts[issuerURLToIssuerKey(issuerURL)]++
 
+		if federationDomain.Spec.PreviousIssuer != nil {
+			previousIssuerURL, err := url.Parse(federationDomain.Spec.PreviousIssuer.Issuer)
+			// Don't count a previous issuer which is the same as the issuer, since that is reported by the Validate function.
+			if err == nil && issuerURLToIssuerKey(previousIssuerURL) != issuerURLToIssuerKey(issuerURL) {
+				previousIssuerCounts[issuerURLToIssuerKey(previousIssuerURL)]++
+			}
+		}
+
 		setOfSecretNames := uniqueSecretNamesPerIssuerAddress[issuerURLToHostnameKey(issuerURL)]
 		if setOfSecretNames == nil {
 			setOfSecretNames = make(map[string]bool)
@@ -966,6 +1407,7 @@ func newCrossFederationDomainConfigValidator(federationDomains []*supervisorconf
 
 	return &crossFederationDomainConfigValidator{
 		issuerCounts:                      issuerCounts,
+		previousIssuerCounts:              previousIssuerCounts,
 		uniqueSecretNamesPerIssuerAddress: uniqueSecretNamesPerIssuerAddress,
 	}
 }
@@ -7,6 +7,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/netip"
 	"net/url"
 	"sort"
 	"testing"
@@ -26,9 +27,19 @@ import (
 	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
 	supervisorfake "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/fake"
 	supervisorinformers "go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions"
+	idpinformers "go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions/idp/v1alpha1"
 	"go.pinniped.dev/internal/celtransformer"
 	"go.pinniped.dev/internal/controllerlib"
+	"go.pinniped.dev/internal/federationdomain/cors"
 	"go.pinniped.dev/internal/federationdomain/federationdomainproviders"
+	"go.pinniped.dev/internal/federationdomain/loadshed"
+	"go.pinniped.dev/internal/federationdomain/loginthrottle"
+	"go.pinniped.dev/internal/federationdomain/resolvedprovider"
+	"go.pinniped.dev/internal/federationdomain/secondfactor"
+	"go.pinniped.dev/internal/federationdomain/singlesignon"
+	"go.pinniped.dev/internal/federationdomain/strategy"
+	"go.pinniped.dev/internal/federationdomain/tokenenrichment"
+	"go.pinniped.dev/internal/federationdomain/workloadidentity"
 	"go.pinniped.dev/internal/here"
 	"go.pinniped.dev/internal/idtransform"
 	"go.pinniped.dev/internal/testutil"
@@ -43,6 +54,9 @@ func TestFederationDomainWatcherControllerInformerFilters(t *testing.T) {
 	ldapIdentityProviderInformer := supervisorinformers.NewSharedInformerFactoryWithOptions(nil, 0).IDP().V1alpha1().LDAPIdentityProviders()
 	adIdentityProviderInformer := supervisorinformers.NewSharedInformerFactoryWithOptions(nil, 0).IDP().V1alpha1().ActiveDirectoryIdentityProviders()
 	githubIdentityProviderInformer := supervisorinformers.NewSharedInformerFactoryWithOptions(nil, 0).IDP().V1alpha1().GitHubIdentityProviders()
+	clientCertIdentityProviderInformer := supervisorinformers.NewSharedInformerFactoryWithOptions(nil, 0).IDP().V1alpha1().ClientCertificateIdentityProviders()
+	openShiftIdentityProviderInformer := supervisorinformers.NewSharedInformerFactoryWithOptions(nil, 0).IDP().V1alpha1().OpenShiftIdentityProviders()
+	mockIdentityProviderInformer := supervisorinformers.NewSharedInformerFactoryWithOptions(nil, 0).IDP().V1alpha1().MockIdentityProviders()
 
 	tests := []struct {
 		name       string
@@ -91,6 +105,30 @@ func TestFederationDomainWatcherControllerInformerFilters(t *testing.T) {
 			wantUpdate: false,
 			wantDelete: true,
 		},
+		{
+			name:       "any ClientCertificateIdentityProvider adds or deletes, but updates are ignored",
+			obj:        &idpv1alpha1.ClientCertificateIdentityProvider{},
+			informer:   clientCertIdentityProviderInformer,
+			wantAdd:    true,
+			wantUpdate: false,
+			wantDelete: true,
+		},
+		{
+			name:       "any OpenShiftIdentityProvider adds or deletes, but updates are ignored",
+			obj:        &idpv1alpha1.OpenShiftIdentityProvider{},
+			informer:   openShiftIdentityProviderInformer,
+			wantAdd:    true,
+			wantUpdate: false,
+			wantDelete: true,
+		},
+		{
+			name:       "any MockIdentityProvider adds or deletes, but updates are ignored",
+			obj:        &idpv1alpha1.MockIdentityProvider{},
+			informer:   mockIdentityProviderInformer,
+			wantAdd:    true,
+			wantUpdate: false,
+			wantDelete: true,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -108,7 +146,11 @@ func TestFederationDomainWatcherControllerInformerFilters(t *testing.T) {
 				ldapIdentityProviderInformer,
 				adIdentityProviderInformer,
 				githubIdentityProviderInformer,
+				clientCertIdentityProviderInformer,
+				openShiftIdentityProviderInformer,
+				mockIdentityProviderInformer,
 				withInformer.WithInformer, // make it possible to observe the behavior of the Filters
+				nil,
 			)
 
 			unrelatedObj := corev1.Secret{}
@@ -179,6 +221,14 @@ func TestTestFederationDomainWatcherControllerSync(t *testing.T) {
 		},
 	}
 
+	mockIdentityProvider := &idpv1alpha1.MockIdentityProvider{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-mock-idp",
+			Namespace: namespace,
+			UID:       "some-mock-uid",
+		},
+	}
+
 	federationDomain1 := &supervisorconfigv1alpha1.FederationDomain{
 		ObjectMeta: metav1.ObjectMeta{Name: "config1", Namespace: namespace, Generation: 123},
 		Spec:       supervisorconfigv1alpha1.FederationDomainSpec{Issuer: "https://issuer1.com"},
@@ -267,6 +317,17 @@ func TestTestFederationDomainWatcherControllerSync(t *testing.T) {
 		}
 	}
 
+	sadIssuerIsUniqueConditionDuplicatePreviousIssuer := func(time metav1.Time, observedGeneration int64) metav1.Condition {
+		return metav1.Condition{
+			Type:               "IssuerIsUnique",
+			Status:             "False",
+			ObservedGeneration: observedGeneration,
+			LastTransitionTime: time,
+			Reason:             "DuplicateIssuer",
+			Message:            "the spec.issuer and spec.previousIssuer.issuer URLs of all FederationDomains must be unique: found a duplicate spec.previousIssuer.issuer URL",
+		}
+	}
+
 	happyOneTLSSecretPerIssuerHostnameCondition := func(time metav1.Time, observedGeneration int64) metav1.Condition {
 		return metav1.Condition{
 			Type:               "OneTLSSecretPerIssuerHostname",
@@ -395,6 +456,17 @@ func TestTestFederationDomainWatcherControllerSync(t *testing.T) {
 		}
 	}
 
+	sadIdentityProvidersFoundConditionIdentityProvidersNotAllowed := func(errorMessages string, time metav1.Time, observedGeneration int64) metav1.Condition {
+		return metav1.Condition{
+			Type:               "IdentityProvidersFound",
+			Status:             "False",
+			ObservedGeneration: observedGeneration,
+			LastTransitionTime: time,
+			Reason:             "IdentityProvidersNotAllowed",
+			Message:            errorMessages,
+		}
+	}
+
 	happyDisplayNamesUniqueCondition := func(time metav1.Time, observedGeneration int64) metav1.Condition {
 		return metav1.Condition{
 			Type:               "IdentityProvidersDisplayNamesUnique",
@@ -450,6 +522,20 @@ func TestTestFederationDomainWatcherControllerSync(t *testing.T) {
 		}
 	}
 
+	happyAccessTokensValidCondition := func(time metav1.Time, observedGeneration int64) metav1.Condition {
+		return metav1.Condition{
+			Type:               "AccessTokensValid",
+			Status:             "True",
+			ObservedGeneration: observedGeneration,
+			LastTransitionTime: time,
+			Reason:             "Success",
+			Message: "access tokens are issued as JWTs which any workload can validate using the JWKS of this FederationDomain: " +
+				"these access tokens cannot be revoked before they expire, and anyone who holds one can read its claims, " +
+				"so only send them to the workloads in their audience, and ensure that those workloads check the audience " +
+				"and the 'at+jwt' type of the access tokens, so that they cannot be used in place of ID tokens",
+		}
+	}
+
 	sadTransformationExamplesCondition := func(errorMessages string, time metav1.Time, observedGeneration int64) metav1.Condition {
 		return metav1.Condition{
 			Type:               "TransformsExamplesPassed",
@@ -503,7 +589,7 @@ func TestTestFederationDomainWatcherControllerSync(t *testing.T) {
 			LastTransitionTime: time,
 			Reason:             "KindUnrecognized",
 			Message: fmt.Sprintf(`some kinds specified by .spec.identityProviders[].objectRef.kind are `+
-				`not recognized (should be one of "ActiveDirectoryIdentityProvider", "GitHubIdentityProvider", "LDAPIdentityProvider", "OIDCIdentityProvider"): %s`, badKinds),
+				`not recognized (should be one of "ActiveDirectoryIdentityProvider", "ClientCertificateIdentityProvider", "GitHubIdentityProvider", "LDAPIdentityProvider", "OIDCIdentityProvider", "OpenShiftIdentityProvider"): %s`, badKinds),
 		}
 	}
 
@@ -539,9 +625,11 @@ func TestTestFederationDomainWatcherControllerSync(t *testing.T) {
 		name              string
 		inputObjects      []runtime.Object
 		configClient      func(*supervisorfake.Clientset)
+		enableMockIDPs    bool
 		wantErr           string
 		wantStatusUpdates []*supervisorconfigv1alpha1.FederationDomain
 		wantFDIssuers     []*federationdomainproviders.FederationDomainIssuer
+		wantIDPStatuses   map[string][]supervisorconfigv1alpha1.FederationDomainIdentityProviderStatus
 	}{
 		{
 			name:          "when there are no FederationDomains, no update actions happen and the list of FederationDomainIssuers is set to the empty list",
@@ -573,271 +661,942 @@ func TestTestFederationDomainWatcherControllerSync(t *testing.T) {
 			},
 		},
 		{
-			name: "legacy config: when no identity provider is specified on federation domains, but exactly one LDAP identity " +
-				"provider resource exists on cluster, the controller will set a default IDP on each federation domain " +
-				"matching the only identity provider found",
+			name: "legacy config: when a federation domain specifies login rate limits, the unspecified settings are defaulted " +
+				"on the FederationDomainIssuer",
 			inputObjects: []runtime.Object{
-				federationDomain1,
-				federationDomain2,
-				ldapIdentityProvider,
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: federationDomain1.ObjectMeta,
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer:          federationDomain1.Spec.Issuer,
+						LoginRateLimits: &supervisorconfigv1alpha1.FederationDomainLoginRateLimits{LockoutSeconds: ptr.To[int32](42)},
+					},
+				},
+				oidcIdentityProvider,
 			},
 			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
-				federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, ldapIdentityProvider.ObjectMeta),
-				federationDomainIssuerWithDefaultIDP(t, federationDomain2.Spec.Issuer, ldapIdentityProvider.ObjectMeta),
+				func() *federationdomainproviders.FederationDomainIssuer {
+					fdIssuer := federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, oidcIdentityProvider.ObjectMeta)
+					fdIssuer.SetLoginRateLimits(&loginthrottle.Config{
+						RequestsPerMinutePerSourceIP: 60,
+						FailedAttemptsBeforeLockout:  5,
+						LockoutDuration:              42 * time.Second,
+					})
+					return fdIssuer
+				}(),
 			},
 			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
 				expectedFederationDomainStatusUpdate(federationDomain1,
 					supervisorconfigv1alpha1.FederationDomainPhaseReady,
-					allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, ldapIdentityProvider.Name, frozenMetav1Now, 123),
-				),
-				expectedFederationDomainStatusUpdate(federationDomain2,
-					supervisorconfigv1alpha1.FederationDomainPhaseReady,
-					allHappyConditionsLegacyConfigurationSuccess(federationDomain2.Spec.Issuer, ldapIdentityProvider.Name, frozenMetav1Now, 123),
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
 				),
 			},
 		},
 		{
-			name: "legacy config: when no identity provider is specified on federation domains, but exactly one AD identity " +
-				"provider resource exists on cluster, the controller will set a default IDP on each federation domain " +
-				"matching the only identity provider found",
+			name: "legacy config: when a federation domain specifies trusted proxies for login rate limits, they are parsed " +
+				"for the FederationDomainIssuer",
 			inputObjects: []runtime.Object{
-				federationDomain1,
-				federationDomain2,
-				adIdentityProvider,
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: federationDomain1.ObjectMeta,
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer: federationDomain1.Spec.Issuer,
+						LoginRateLimits: &supervisorconfigv1alpha1.FederationDomainLoginRateLimits{
+							TrustedProxies: []string{"10.1.2.3/8", "fd00::/8"},
+						},
+					},
+				},
+				oidcIdentityProvider,
 			},
 			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
-				federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, adIdentityProvider.ObjectMeta),
-				federationDomainIssuerWithDefaultIDP(t, federationDomain2.Spec.Issuer, adIdentityProvider.ObjectMeta),
+				func() *federationdomainproviders.FederationDomainIssuer {
+					fdIssuer := federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, oidcIdentityProvider.ObjectMeta)
+					fdIssuer.SetLoginRateLimits(&loginthrottle.Config{
+						RequestsPerMinutePerSourceIP: 60,
+						FailedAttemptsBeforeLockout:  5,
+						LockoutDuration:              5 * time.Minute,
+						TrustedProxies:               []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("fd00::/8")},
+					})
+					return fdIssuer
+				}(),
 			},
 			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
 				expectedFederationDomainStatusUpdate(federationDomain1,
 					supervisorconfigv1alpha1.FederationDomainPhaseReady,
-					allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, adIdentityProvider.Name, frozenMetav1Now, 123),
-				),
-				expectedFederationDomainStatusUpdate(federationDomain2,
-					supervisorconfigv1alpha1.FederationDomainPhaseReady,
-					allHappyConditionsLegacyConfigurationSuccess(federationDomain2.Spec.Issuer, adIdentityProvider.Name, frozenMetav1Now, 123),
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
 				),
 			},
 		},
 		{
-			name: "legacy config: when no identity provider is specified on federation domains, but exactly one GitHub identity " +
-				"provider resource exists on cluster, the controller will set a default IDP on each federation domain " +
-				"matching the only identity provider found",
+			name: "legacy config: when a federation domain enables access logs, they are enabled on the FederationDomainIssuer",
 			inputObjects: []runtime.Object{
-				federationDomain1,
-				federationDomain2,
-				gitHubIdentityProvider,
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: federationDomain1.ObjectMeta,
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer:    federationDomain1.Spec.Issuer,
+						AccessLog: &supervisorconfigv1alpha1.FederationDomainAccessLogSpec{Enabled: true},
+					},
+				},
+				oidcIdentityProvider,
 			},
 			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
-				federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, gitHubIdentityProvider.ObjectMeta),
-				federationDomainIssuerWithDefaultIDP(t, federationDomain2.Spec.Issuer, gitHubIdentityProvider.ObjectMeta),
+				func() *federationdomainproviders.FederationDomainIssuer {
+					fdIssuer := federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, oidcIdentityProvider.ObjectMeta)
+					fdIssuer.SetAccessLogEnabled(true)
+					return fdIssuer
+				}(),
 			},
 			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
 				expectedFederationDomainStatusUpdate(federationDomain1,
 					supervisorconfigv1alpha1.FederationDomainPhaseReady,
-					allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, gitHubIdentityProvider.Name, frozenMetav1Now, 123),
-				),
-				expectedFederationDomainStatusUpdate(federationDomain2,
-					supervisorconfigv1alpha1.FederationDomainPhaseReady,
-					allHappyConditionsLegacyConfigurationSuccess(federationDomain2.Spec.Issuer, gitHubIdentityProvider.Name, frozenMetav1Now, 123),
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
 				),
 			},
 		},
 		{
-			name: "when there are two valid FederationDomains, but one is already up to date, the sync loop only updates " +
-				"the out-of-date FederationDomain",
+			name: "legacy config: when a federation domain selects a listener, it is set on the FederationDomainIssuer",
 			inputObjects: []runtime.Object{
-				oidcIdentityProvider,
 				&supervisorconfigv1alpha1.FederationDomain{
-					ObjectMeta: metav1.ObjectMeta{Name: federationDomain1.Name, Namespace: federationDomain1.Namespace, Generation: 123},
-					Spec:       supervisorconfigv1alpha1.FederationDomainSpec{Issuer: federationDomain1.Spec.Issuer},
-					Status: supervisorconfigv1alpha1.FederationDomainStatus{
-						Phase:      supervisorconfigv1alpha1.FederationDomainPhaseReady,
-						Conditions: allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
+					ObjectMeta: federationDomain1.ObjectMeta,
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer:   federationDomain1.Spec.Issuer,
+						Listener: "tenant-a",
 					},
 				},
-				federationDomain2,
+				oidcIdentityProvider,
 			},
 			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
-				federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, oidcIdentityProvider.ObjectMeta),
-				federationDomainIssuerWithDefaultIDP(t, federationDomain2.Spec.Issuer, oidcIdentityProvider.ObjectMeta),
+				func() *federationdomainproviders.FederationDomainIssuer {
+					fdIssuer := federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, oidcIdentityProvider.ObjectMeta)
+					fdIssuer.SetListener("tenant-a")
+					return fdIssuer
+				}(),
 			},
 			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
-				// only one update, because the other FederationDomain already had the right status
-				expectedFederationDomainStatusUpdate(federationDomain2,
+				expectedFederationDomainStatusUpdate(federationDomain1,
 					supervisorconfigv1alpha1.FederationDomainPhaseReady,
-					allHappyConditionsLegacyConfigurationSuccess(federationDomain2.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
 				),
 			},
 		},
 		{
-			name: "when the status of the FederationDomains is based on an old generation, it is updated",
+			name: "legacy config: when a federation domain selects a signing algorithm, it is set on the FederationDomainIssuer",
 			inputObjects: []runtime.Object{
-				oidcIdentityProvider,
 				&supervisorconfigv1alpha1.FederationDomain{
-					ObjectMeta: metav1.ObjectMeta{Name: federationDomain1.Name, Namespace: federationDomain1.Namespace, Generation: 123},
-					Spec:       supervisorconfigv1alpha1.FederationDomainSpec{Issuer: federationDomain1.Spec.Issuer},
-					Status: supervisorconfigv1alpha1.FederationDomainStatus{
-						Phase: supervisorconfigv1alpha1.FederationDomainPhaseReady,
-						Conditions: allHappyConditionsLegacyConfigurationSuccess(
-							federationDomain1.Spec.Issuer,
-							oidcIdentityProvider.Name,
-							frozenMetav1Now,
-							2, // this is an older generation
-						),
+					ObjectMeta: federationDomain1.ObjectMeta,
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer: federationDomain1.Spec.Issuer,
+						SigningKeys: &supervisorconfigv1alpha1.FederationDomainSigningKeys{
+							Algorithm: supervisorconfigv1alpha1.FederationDomainSigningAlgorithmEdDSA,
+						},
 					},
 				},
+				oidcIdentityProvider,
 			},
 			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
-				federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, oidcIdentityProvider.ObjectMeta),
+				func() *federationdomainproviders.FederationDomainIssuer {
+					fdIssuer := federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, oidcIdentityProvider.ObjectMeta)
+					fdIssuer.SetSigningAlgorithm("EdDSA")
+					return fdIssuer
+				}(),
 			},
 			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
-				// only one update, because the other FederationDomain already had the right status
 				expectedFederationDomainStatusUpdate(federationDomain1,
 					supervisorconfigv1alpha1.FederationDomainPhaseReady,
-					allHappyConditionsLegacyConfigurationSuccess(
-						federationDomain1.Spec.Issuer,
-						oidcIdentityProvider.Name,
-						frozenMetav1Now,
-						123, // all conditions are updated to the new observed generation
-					),
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
 				),
 			},
 		},
 		{
-			name: "when there are two valid FederationDomains, but updating one fails, the status on the FederationDomain will not change",
+			name: "legacy config: when a federation domain configures CORS, it is set on the FederationDomainIssuer with defaults",
 			inputObjects: []runtime.Object{
-				federationDomain1,
-				federationDomain2,
-				oidcIdentityProvider,
-			},
-			configClient: func(client *supervisorfake.Clientset) {
-				client.PrependReactor(
-					"update",
-					"federationdomains",
-					func(action coretesting.Action) (bool, runtime.Object, error) {
-						fd := action.(coretesting.UpdateAction).GetObject().(*supervisorconfigv1alpha1.FederationDomain)
-						if fd.Name == federationDomain1.Name {
-							return true, nil, errors.New("some update error")
-						}
-						return false, nil, nil
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: federationDomain1.ObjectMeta,
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer: federationDomain1.Spec.Issuer,
+						CORS: &supervisorconfigv1alpha1.FederationDomainCORSSpec{
+							AllowedOrigins: []string{"https://app.example.com"},
+						},
 					},
-				)
+				},
+				oidcIdentityProvider,
 			},
-			wantErr: "could not update status: some update error",
 			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
-				federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, oidcIdentityProvider.ObjectMeta),
-				federationDomainIssuerWithDefaultIDP(t, federationDomain2.Spec.Issuer, oidcIdentityProvider.ObjectMeta),
+				func() *federationdomainproviders.FederationDomainIssuer {
+					fdIssuer := federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, oidcIdentityProvider.ObjectMeta)
+					fdIssuer.SetCORS(&cors.Config{
+						AllowedOrigins: []string{"https://app.example.com"},
+						AllowedHeaders: []string{"Authorization", "Content-Type", "DPoP"},
+					})
+					return fdIssuer
+				}(),
 			},
 			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
 				expectedFederationDomainStatusUpdate(federationDomain1,
 					supervisorconfigv1alpha1.FederationDomainPhaseReady,
 					allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
 				),
-				expectedFederationDomainStatusUpdate(federationDomain2,
-					supervisorconfigv1alpha1.FederationDomainPhaseReady,
-					allHappyConditionsLegacyConfigurationSuccess(federationDomain2.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
-				),
 			},
 		},
 		{
-			name: "when there are both valid and invalid FederationDomains, the status will be correctly set on each " +
-				"FederationDomain individually",
+			name: "legacy config: when a federation domain enables single sign-on, it is set on the FederationDomainIssuer with defaults",
 			inputObjects: []runtime.Object{
-				invalidIssuerURLFederationDomain,
-				federationDomain2,
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: federationDomain1.ObjectMeta,
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer:       federationDomain1.Spec.Issuer,
+						SingleSignOn: &supervisorconfigv1alpha1.FederationDomainSingleSignOn{},
+					},
+				},
 				oidcIdentityProvider,
 			},
 			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
-				// only the valid FederationDomain
-				federationDomainIssuerWithDefaultIDP(t, federationDomain2.Spec.Issuer, oidcIdentityProvider.ObjectMeta),
+				func() *federationdomainproviders.FederationDomainIssuer {
+					fdIssuer := federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, oidcIdentityProvider.ObjectMeta)
+					fdIssuer.SetSingleSignOn(&singlesignon.Config{CookieLifetime: 8 * time.Hour})
+					return fdIssuer
+				}(),
 			},
 			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
-				expectedFederationDomainStatusUpdate(invalidIssuerURLFederationDomain,
-					supervisorconfigv1alpha1.FederationDomainPhaseError,
-					conditionstestutil.Replace(
-						allHappyConditionsLegacyConfigurationSuccess(federationDomain2.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
-						[]metav1.Condition{
-							sadIssuerURLValidConditionCannotHaveQuery(frozenMetav1Now, 123),
-							sadReadyCondition(frozenMetav1Now, 123),
-						}),
-				),
-				expectedFederationDomainStatusUpdate(federationDomain2,
+				expectedFederationDomainStatusUpdate(federationDomain1,
 					supervisorconfigv1alpha1.FederationDomainPhaseReady,
-					allHappyConditionsLegacyConfigurationSuccess(federationDomain2.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
 				),
 			},
 		},
 		{
-			name: "when there are both valid and invalid FederationDomains, but updating the invalid one fails, the " +
-				"existing status will be unchanged",
+			name: "legacy config: when a federation domain configures the single sign-on cookie lifetime, it is set on the FederationDomainIssuer",
 			inputObjects: []runtime.Object{
-				invalidIssuerURLFederationDomain,
-				federationDomain2,
-				oidcIdentityProvider,
-			},
-			configClient: func(client *supervisorfake.Clientset) {
-				client.PrependReactor(
-					"update",
-					"federationdomains",
-					func(action coretesting.Action) (bool, runtime.Object, error) {
-						fd := action.(coretesting.UpdateAction).GetObject().(*supervisorconfigv1alpha1.FederationDomain)
-						if fd.Name == invalidIssuerURLFederationDomain.Name {
-							return true, nil, errors.New("some update error")
-						}
-						return false, nil, nil
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: federationDomain1.ObjectMeta,
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer: federationDomain1.Spec.Issuer,
+						SingleSignOn: &supervisorconfigv1alpha1.FederationDomainSingleSignOn{
+							CookieLifetimeSeconds: ptr.To[int32](3600),
+						},
 					},
-				)
+				},
+				oidcIdentityProvider,
 			},
-			wantErr: "could not update status: some update error",
 			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
-				// only the valid FederationDomain
-				federationDomainIssuerWithDefaultIDP(t, federationDomain2.Spec.Issuer, oidcIdentityProvider.ObjectMeta),
+				func() *federationdomainproviders.FederationDomainIssuer {
+					fdIssuer := federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, oidcIdentityProvider.ObjectMeta)
+					fdIssuer.SetSingleSignOn(&singlesignon.Config{CookieLifetime: time.Hour})
+					return fdIssuer
+				}(),
 			},
 			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
-				expectedFederationDomainStatusUpdate(invalidIssuerURLFederationDomain,
-					supervisorconfigv1alpha1.FederationDomainPhaseError,
-					conditionstestutil.Replace(
-						allHappyConditionsLegacyConfigurationSuccess(federationDomain2.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
-						[]metav1.Condition{
-							sadIssuerURLValidConditionCannotHaveQuery(frozenMetav1Now, 123),
-							sadReadyCondition(frozenMetav1Now, 123),
-						}),
-				),
-				expectedFederationDomainStatusUpdate(federationDomain2,
+				expectedFederationDomainStatusUpdate(federationDomain1,
 					supervisorconfigv1alpha1.FederationDomainPhaseReady,
-					allHappyConditionsLegacyConfigurationSuccess(federationDomain2.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
 				),
 			},
 		},
 		{
-			name: "when there are FederationDomains with duplicate issuer strings these particular FederationDomains " +
-				"will report error on IssuerUnique conditions",
+			name: "legacy config: when a federation domain enables WebAuthn, it is set on the FederationDomainIssuer with defaults",
 			inputObjects: []runtime.Object{
 				&supervisorconfigv1alpha1.FederationDomain{
-					ObjectMeta: metav1.ObjectMeta{Name: "duplicate1", Namespace: namespace, Generation: 123},
-					Spec:       supervisorconfigv1alpha1.FederationDomainSpec{Issuer: "https://iSSueR-duPlicAte.cOm/a"},
-				},
-				&supervisorconfigv1alpha1.FederationDomain{
-					ObjectMeta: metav1.ObjectMeta{Name: "duplicate2", Namespace: namespace, Generation: 123},
-					Spec:       supervisorconfigv1alpha1.FederationDomainSpec{Issuer: "https://issuer-duplicate.com/a"},
-				},
-				&supervisorconfigv1alpha1.FederationDomain{
-					ObjectMeta: metav1.ObjectMeta{Name: "not-duplicate", Namespace: namespace, Generation: 123},
-					Spec:       supervisorconfigv1alpha1.FederationDomainSpec{Issuer: "https://issuer-duplicate.com/A"}, // different path (paths are case-sensitive)
+					ObjectMeta: federationDomain1.ObjectMeta,
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer:   federationDomain1.Spec.Issuer,
+						WebAuthn: &supervisorconfigv1alpha1.FederationDomainWebAuthn{},
+					},
 				},
 				oidcIdentityProvider,
 			},
 			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
-				federationDomainIssuerWithDefaultIDP(t, "https://issuer-duplicate.com/A", oidcIdentityProvider.ObjectMeta),
+				func() *federationdomainproviders.FederationDomainIssuer {
+					fdIssuer := federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, oidcIdentityProvider.ObjectMeta)
+					fdIssuer.SetWebAuthn(&secondfactor.WebAuthnConfig{Enforcement: secondfactor.WebAuthnRequired})
+					return fdIssuer
+				}(),
 			},
 			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
-				expectedFederationDomainStatusUpdate(
-					&supervisorconfigv1alpha1.FederationDomain{
-						ObjectMeta: metav1.ObjectMeta{Name: "duplicate1", Namespace: namespace, Generation: 123},
-					},
-					supervisorconfigv1alpha1.FederationDomainPhaseError,
+				expectedFederationDomainStatusUpdate(federationDomain1,
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
+				),
+			},
+		},
+		{
+			name: "legacy config: when a federation domain makes WebAuthn optional, it is set on the FederationDomainIssuer",
+			inputObjects: []runtime.Object{
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: federationDomain1.ObjectMeta,
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer: federationDomain1.Spec.Issuer,
+						WebAuthn: &supervisorconfigv1alpha1.FederationDomainWebAuthn{
+							Enforcement: supervisorconfigv1alpha1.FederationDomainWebAuthnEnforcementOptional,
+						},
+					},
+				},
+				oidcIdentityProvider,
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
+				func() *federationdomainproviders.FederationDomainIssuer {
+					fdIssuer := federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, oidcIdentityProvider.ObjectMeta)
+					fdIssuer.SetWebAuthn(&secondfactor.WebAuthnConfig{Enforcement: secondfactor.WebAuthnOptional})
+					return fdIssuer
+				}(),
+			},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(federationDomain1,
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
+				),
+			},
+		},
+		{
+			name: "legacy config: when a federation domain specifies a previous issuer, it is set on the FederationDomainIssuer",
+			inputObjects: []runtime.Object{
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: federationDomain1.ObjectMeta,
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer: federationDomain1.Spec.Issuer,
+						PreviousIssuer: &supervisorconfigv1alpha1.FederationDomainPreviousIssuer{
+							Issuer:                "https://old-issuer.com",
+							RefreshGracePeriodEnd: frozenMetav1Now,
+						},
+					},
+				},
+				oidcIdentityProvider,
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
+				func() *federationdomainproviders.FederationDomainIssuer {
+					fdIssuer := federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, oidcIdentityProvider.ObjectMeta)
+					require.NoError(t, fdIssuer.SetPreviousIssuer("https://old-issuer.com", frozenMetav1Now.Time))
+					return fdIssuer
+				}(),
+			},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(federationDomain1,
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
+				),
+			},
+		},
+		{
+			name: "legacy config: when a federation domain specifies an invalid previous issuer, the FederationDomain is not loaded",
+			inputObjects: []runtime.Object{
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: federationDomain1.ObjectMeta,
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer: federationDomain1.Spec.Issuer,
+						PreviousIssuer: &supervisorconfigv1alpha1.FederationDomainPreviousIssuer{
+							Issuer:                federationDomain1.Spec.Issuer,
+							RefreshGracePeriodEnd: frozenMetav1Now,
+						},
+					},
+				},
+				oidcIdentityProvider,
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(federationDomain1,
+					supervisorconfigv1alpha1.FederationDomainPhaseError,
+					conditionstestutil.Replace(
+						allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
+						[]metav1.Condition{
+							{
+								Type:               "IssuerURLValid",
+								Status:             "False",
+								ObservedGeneration: 123,
+								LastTransitionTime: frozenMetav1Now,
+								Reason:             "InvalidIssuerURL",
+								Message:            "spec.previousIssuer.issuer is invalid: previous issuer must be different from the issuer",
+							},
+							sadReadyCondition(frozenMetav1Now, 123),
+						}),
+				),
+			},
+		},
+		{
+			name: "when the previous issuer of a FederationDomain is the issuer of another FederationDomain, " +
+				"both report an error on their IssuerIsUnique conditions",
+			inputObjects: []runtime.Object{
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: namespace, Generation: 123},
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer: "https://new-issuer.com",
+						PreviousIssuer: &supervisorconfigv1alpha1.FederationDomainPreviousIssuer{
+							Issuer:                "https://old-issuer.com",
+							RefreshGracePeriodEnd: frozenMetav1Now,
+						},
+					},
+				},
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: metav1.ObjectMeta{Name: "old", Namespace: namespace, Generation: 123},
+					Spec:       supervisorconfigv1alpha1.FederationDomainSpec{Issuer: "https://OLD-issuer.com"},
+				},
+				oidcIdentityProvider,
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(
+					&supervisorconfigv1alpha1.FederationDomain{
+						ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: namespace, Generation: 123},
+					},
+					supervisorconfigv1alpha1.FederationDomainPhaseError,
+					conditionstestutil.Replace(
+						allHappyConditionsLegacyConfigurationSuccess("https://new-issuer.com", oidcIdentityProvider.Name, frozenMetav1Now, 123),
+						[]metav1.Condition{
+							sadIssuerIsUniqueConditionDuplicatePreviousIssuer(frozenMetav1Now, 123),
+							sadReadyCondition(frozenMetav1Now, 123),
+						}),
+				),
+				expectedFederationDomainStatusUpdate(
+					&supervisorconfigv1alpha1.FederationDomain{
+						ObjectMeta: metav1.ObjectMeta{Name: "old", Namespace: namespace, Generation: 123},
+					},
+					supervisorconfigv1alpha1.FederationDomainPhaseError,
+					conditionstestutil.Replace(
+						allHappyConditionsLegacyConfigurationSuccess("https://OLD-issuer.com", oidcIdentityProvider.Name, frozenMetav1Now, 123),
+						[]metav1.Condition{
+							sadIssuerIsUniqueConditionDuplicatePreviousIssuer(frozenMetav1Now, 123),
+							sadReadyCondition(frozenMetav1Now, 123),
+						}),
+				),
+			},
+		},
+		{
+			name: "legacy config: when a federation domain specifies token endpoint load shedding, the unspecified settings " +
+				"are defaulted on the FederationDomainIssuer",
+			inputObjects: []runtime.Object{
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: federationDomain1.ObjectMeta,
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer: federationDomain1.Spec.Issuer,
+						TokenEndpointLoadShedding: &supervisorconfigv1alpha1.FederationDomainTokenEndpointLoadShedding{
+							MaxConcurrentRequests: ptr.To[int32](7),
+						},
+					},
+				},
+				oidcIdentityProvider,
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
+				func() *federationdomainproviders.FederationDomainIssuer {
+					fdIssuer := federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, oidcIdentityProvider.ObjectMeta)
+					fdIssuer.SetTokenEndpointLoadShedding(&loadshed.Config{
+						MaxConcurrentRequests: 7,
+						QueueTimeout:          5 * time.Second,
+					})
+					return fdIssuer
+				}(),
+			},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(federationDomain1,
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
+				),
+			},
+		},
+		{
+			name: "legacy config: when a federation domain specifies JWT access tokens, the config is set on the " +
+				"FederationDomainIssuer and the AccessTokensValid condition describes their security implications",
+			inputObjects: []runtime.Object{
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: federationDomain1.ObjectMeta,
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer: federationDomain1.Spec.Issuer,
+						AccessTokens: &supervisorconfigv1alpha1.FederationDomainAccessTokens{
+							Format:   supervisorconfigv1alpha1.FederationDomainAccessTokenFormatJWT,
+							Audience: []string{"some-workload"},
+							Claims: []supervisorconfigv1alpha1.FederationDomainAccessTokenClaim{
+								supervisorconfigv1alpha1.FederationDomainAccessTokenClaimUsername,
+								supervisorconfigv1alpha1.FederationDomainAccessTokenClaimGroups,
+							},
+						},
+					},
+				},
+				oidcIdentityProvider,
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
+				func() *federationdomainproviders.FederationDomainIssuer {
+					fdIssuer := federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, oidcIdentityProvider.ObjectMeta)
+					fdIssuer.SetJWTAccessTokens(&strategy.JWTAccessTokenConfig{
+						Audience: []string{"some-workload"},
+						Claims:   []string{"username", "groups"},
+					})
+					return fdIssuer
+				}(),
+			},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(federationDomain1,
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					conditionstestutil.SortByType(append(
+						allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
+						happyAccessTokensValidCondition(frozenMetav1Now, 123),
+					)),
+				),
+			},
+		},
+		{
+			name: "legacy config: when a federation domain specifies JWT access tokens with a reserved audience, " +
+				"the AccessTokensValid condition is false and the FederationDomain is not loaded",
+			inputObjects: []runtime.Object{
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: federationDomain1.ObjectMeta,
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer: federationDomain1.Spec.Issuer,
+						AccessTokens: &supervisorconfigv1alpha1.FederationDomainAccessTokens{
+							Format:   supervisorconfigv1alpha1.FederationDomainAccessTokenFormatJWT,
+							Audience: []string{"some-workload", "pinniped.dev-audience", "client.oauth.pinniped.dev-some-client"},
+						},
+					},
+				},
+				oidcIdentityProvider,
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(federationDomain1,
+					supervisorconfigv1alpha1.FederationDomainPhaseError,
+					conditionstestutil.SortByType(append(
+						conditionstestutil.Replace(
+							allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
+							[]metav1.Condition{
+								sadReadyCondition(frozenMetav1Now, 123),
+							},
+						),
+						metav1.Condition{
+							Type:               "AccessTokensValid",
+							Status:             "False",
+							ObservedGeneration: 123,
+							LastTransitionTime: frozenMetav1Now,
+							Reason:             "InvalidAccessTokenAudience",
+							Message: "the audiences specified by .spec.accessTokens.audience must not contain '.pinniped.dev': " +
+								`["client.oauth.pinniped.dev-some-client"]`,
+						},
+					)),
+				),
+			},
+		},
+		{
+			name: "legacy config: when a federation domain no longer specifies JWT access tokens, the AccessTokensValid " +
+				"condition is removed",
+			inputObjects: []runtime.Object{
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: federationDomain1.ObjectMeta,
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer: federationDomain1.Spec.Issuer,
+						AccessTokens: &supervisorconfigv1alpha1.FederationDomainAccessTokens{
+							Format: supervisorconfigv1alpha1.FederationDomainAccessTokenFormatOpaque,
+						},
+					},
+					Status: supervisorconfigv1alpha1.FederationDomainStatus{
+						Conditions: []metav1.Condition{happyAccessTokensValidCondition(frozenMetav1Now, 122)},
+					},
+				},
+				oidcIdentityProvider,
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
+				federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, oidcIdentityProvider.ObjectMeta),
+			},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(federationDomain1,
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
+				),
+			},
+		},
+		{
+			name: "legacy config: when a federation domain specifies a token enrichment webhook, the unspecified settings are " +
+				"defaulted on the FederationDomainIssuer",
+			inputObjects: []runtime.Object{
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: federationDomain1.ObjectMeta,
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer: federationDomain1.Spec.Issuer,
+						TokenEnrichmentWebhook: &supervisorconfigv1alpha1.FederationDomainTokenEnrichmentWebhook{
+							Endpoint:                 "https://webhook.example.com/enrich",
+							CertificateAuthorityData: "some-ca-data",
+						},
+					},
+				},
+				oidcIdentityProvider,
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
+				func() *federationdomainproviders.FederationDomainIssuer {
+					fdIssuer := federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, oidcIdentityProvider.ObjectMeta)
+					fdIssuer.SetTokenEnrichmentWebhook(&tokenenrichment.Config{
+						Endpoint:                 "https://webhook.example.com/enrich",
+						CertificateAuthorityData: "some-ca-data",
+						Timeout:                  10 * time.Second,
+						FailurePolicy:            tokenenrichment.FailurePolicyFail,
+					})
+					return fdIssuer
+				}(),
+			},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(federationDomain1,
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
+				),
+			},
+		},
+		{
+			name: "legacy config: when a federation domain specifies all token enrichment webhook settings, they are used " +
+				"on the FederationDomainIssuer",
+			inputObjects: []runtime.Object{
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: federationDomain1.ObjectMeta,
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer: federationDomain1.Spec.Issuer,
+						TokenEnrichmentWebhook: &supervisorconfigv1alpha1.FederationDomainTokenEnrichmentWebhook{
+							Endpoint:       "https://webhook.example.com/enrich",
+							TimeoutSeconds: ptr.To[int32](3),
+							FailurePolicy:  supervisorconfigv1alpha1.FederationDomainTokenEnrichmentFailurePolicyIgnore,
+						},
+					},
+				},
+				oidcIdentityProvider,
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
+				func() *federationdomainproviders.FederationDomainIssuer {
+					fdIssuer := federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, oidcIdentityProvider.ObjectMeta)
+					fdIssuer.SetTokenEnrichmentWebhook(&tokenenrichment.Config{
+						Endpoint:      "https://webhook.example.com/enrich",
+						Timeout:       3 * time.Second,
+						FailurePolicy: tokenenrichment.FailurePolicyIgnore,
+					})
+					return fdIssuer
+				}(),
+			},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(federationDomain1,
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
+				),
+			},
+		},
+		{
+			name: "legacy config: when a federation domain enables workload identity, the unspecified username prefix is " +
+				"defaulted on the FederationDomainIssuer",
+			inputObjects: []runtime.Object{
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: federationDomain1.ObjectMeta,
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer: federationDomain1.Spec.Issuer,
+						WorkloadIdentity: &supervisorconfigv1alpha1.FederationDomainWorkloadIdentity{
+							AllowedNamespaces: []string{"some-namespace", "other-namespace"},
+						},
+					},
+				},
+				oidcIdentityProvider,
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
+				func() *federationdomainproviders.FederationDomainIssuer {
+					fdIssuer := federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, oidcIdentityProvider.ObjectMeta)
+					fdIssuer.SetWorkloadIdentity(&workloadidentity.Config{
+						AllowedNamespaces: []string{"some-namespace", "other-namespace"},
+						UsernamePrefix:    "workload:",
+					})
+					return fdIssuer
+				}(),
+			},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(federationDomain1,
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
+				),
+			},
+		},
+		{
+			name: "legacy config: when a federation domain enables workload identity with a username prefix, it is used " +
+				"on the FederationDomainIssuer",
+			inputObjects: []runtime.Object{
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: federationDomain1.ObjectMeta,
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer: federationDomain1.Spec.Issuer,
+						WorkloadIdentity: &supervisorconfigv1alpha1.FederationDomainWorkloadIdentity{
+							AllowedNamespaces: []string{"some-namespace"},
+							UsernamePrefix:    "cluster-a:",
+						},
+					},
+				},
+				oidcIdentityProvider,
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
+				func() *federationdomainproviders.FederationDomainIssuer {
+					fdIssuer := federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, oidcIdentityProvider.ObjectMeta)
+					fdIssuer.SetWorkloadIdentity(&workloadidentity.Config{
+						AllowedNamespaces: []string{"some-namespace"},
+						UsernamePrefix:    "cluster-a:",
+					})
+					return fdIssuer
+				}(),
+			},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(federationDomain1,
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
+				),
+			},
+		},
+		{
+			name: "legacy config: when no identity provider is specified on federation domains, but exactly one LDAP identity " +
+				"provider resource exists on cluster, the controller will set a default IDP on each federation domain " +
+				"matching the only identity provider found",
+			inputObjects: []runtime.Object{
+				federationDomain1,
+				federationDomain2,
+				ldapIdentityProvider,
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
+				federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, ldapIdentityProvider.ObjectMeta),
+				federationDomainIssuerWithDefaultIDP(t, federationDomain2.Spec.Issuer, ldapIdentityProvider.ObjectMeta),
+			},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(federationDomain1,
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, ldapIdentityProvider.Name, frozenMetav1Now, 123),
+				),
+				expectedFederationDomainStatusUpdate(federationDomain2,
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain2.Spec.Issuer, ldapIdentityProvider.Name, frozenMetav1Now, 123),
+				),
+			},
+		},
+		{
+			name: "legacy config: when no identity provider is specified on federation domains, but exactly one AD identity " +
+				"provider resource exists on cluster, the controller will set a default IDP on each federation domain " +
+				"matching the only identity provider found",
+			inputObjects: []runtime.Object{
+				federationDomain1,
+				federationDomain2,
+				adIdentityProvider,
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
+				federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, adIdentityProvider.ObjectMeta),
+				federationDomainIssuerWithDefaultIDP(t, federationDomain2.Spec.Issuer, adIdentityProvider.ObjectMeta),
+			},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(federationDomain1,
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, adIdentityProvider.Name, frozenMetav1Now, 123),
+				),
+				expectedFederationDomainStatusUpdate(federationDomain2,
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain2.Spec.Issuer, adIdentityProvider.Name, frozenMetav1Now, 123),
+				),
+			},
+		},
+		{
+			name: "legacy config: when no identity provider is specified on federation domains, but exactly one GitHub identity " +
+				"provider resource exists on cluster, the controller will set a default IDP on each federation domain " +
+				"matching the only identity provider found",
+			inputObjects: []runtime.Object{
+				federationDomain1,
+				federationDomain2,
+				gitHubIdentityProvider,
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
+				federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, gitHubIdentityProvider.ObjectMeta),
+				federationDomainIssuerWithDefaultIDP(t, federationDomain2.Spec.Issuer, gitHubIdentityProvider.ObjectMeta),
+			},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(federationDomain1,
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, gitHubIdentityProvider.Name, frozenMetav1Now, 123),
+				),
+				expectedFederationDomainStatusUpdate(federationDomain2,
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain2.Spec.Issuer, gitHubIdentityProvider.Name, frozenMetav1Now, 123),
+				),
+			},
+		},
+		{
+			name: "when there are two valid FederationDomains, but one is already up to date, the sync loop only updates " +
+				"the out-of-date FederationDomain",
+			inputObjects: []runtime.Object{
+				oidcIdentityProvider,
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: metav1.ObjectMeta{Name: federationDomain1.Name, Namespace: federationDomain1.Namespace, Generation: 123},
+					Spec:       supervisorconfigv1alpha1.FederationDomainSpec{Issuer: federationDomain1.Spec.Issuer},
+					Status: supervisorconfigv1alpha1.FederationDomainStatus{
+						Phase:      supervisorconfigv1alpha1.FederationDomainPhaseReady,
+						Conditions: allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
+					},
+				},
+				federationDomain2,
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
+				federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, oidcIdentityProvider.ObjectMeta),
+				federationDomainIssuerWithDefaultIDP(t, federationDomain2.Spec.Issuer, oidcIdentityProvider.ObjectMeta),
+			},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				// only one update, because the other FederationDomain already had the right status
+				expectedFederationDomainStatusUpdate(federationDomain2,
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain2.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
+				),
+			},
+		},
+		{
+			name: "when the status of the FederationDomains is based on an old generation, it is updated",
+			inputObjects: []runtime.Object{
+				oidcIdentityProvider,
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: metav1.ObjectMeta{Name: federationDomain1.Name, Namespace: federationDomain1.Namespace, Generation: 123},
+					Spec:       supervisorconfigv1alpha1.FederationDomainSpec{Issuer: federationDomain1.Spec.Issuer},
+					Status: supervisorconfigv1alpha1.FederationDomainStatus{
+						Phase: supervisorconfigv1alpha1.FederationDomainPhaseReady,
+						Conditions: allHappyConditionsLegacyConfigurationSuccess(
+							federationDomain1.Spec.Issuer,
+							oidcIdentityProvider.Name,
+							frozenMetav1Now,
+							2, // this is an older generation
+						),
+					},
+				},
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
+				federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, oidcIdentityProvider.ObjectMeta),
+			},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				// only one update, because the other FederationDomain already had the right status
+				expectedFederationDomainStatusUpdate(federationDomain1,
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					allHappyConditionsLegacyConfigurationSuccess(
+						federationDomain1.Spec.Issuer,
+						oidcIdentityProvider.Name,
+						frozenMetav1Now,
+						123, // all conditions are updated to the new observed generation
+					),
+				),
+			},
+		},
+		{
+			name: "when there are two valid FederationDomains, but updating one fails, the status on the FederationDomain will not change",
+			inputObjects: []runtime.Object{
+				federationDomain1,
+				federationDomain2,
+				oidcIdentityProvider,
+			},
+			configClient: func(client *supervisorfake.Clientset) {
+				client.PrependReactor(
+					"update",
+					"federationdomains",
+					func(action coretesting.Action) (bool, runtime.Object, error) {
+						fd := action.(coretesting.UpdateAction).GetObject().(*supervisorconfigv1alpha1.FederationDomain)
+						if fd.Name == federationDomain1.Name {
+							return true, nil, errors.New("some update error")
+						}
+						return false, nil, nil
+					},
+				)
+			},
+			wantErr: "could not update status: some update error",
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
+				federationDomainIssuerWithDefaultIDP(t, federationDomain1.Spec.Issuer, oidcIdentityProvider.ObjectMeta),
+				federationDomainIssuerWithDefaultIDP(t, federationDomain2.Spec.Issuer, oidcIdentityProvider.ObjectMeta),
+			},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(federationDomain1,
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
+				),
+				expectedFederationDomainStatusUpdate(federationDomain2,
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain2.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
+				),
+			},
+		},
+		{
+			name: "when there are both valid and invalid FederationDomains, the status will be correctly set on each " +
+				"FederationDomain individually",
+			inputObjects: []runtime.Object{
+				invalidIssuerURLFederationDomain,
+				federationDomain2,
+				oidcIdentityProvider,
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
+				// only the valid FederationDomain
+				federationDomainIssuerWithDefaultIDP(t, federationDomain2.Spec.Issuer, oidcIdentityProvider.ObjectMeta),
+			},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(invalidIssuerURLFederationDomain,
+					supervisorconfigv1alpha1.FederationDomainPhaseError,
+					conditionstestutil.Replace(
+						allHappyConditionsLegacyConfigurationSuccess(federationDomain2.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
+						[]metav1.Condition{
+							sadIssuerURLValidConditionCannotHaveQuery(frozenMetav1Now, 123),
+							sadReadyCondition(frozenMetav1Now, 123),
+						}),
+				),
+				expectedFederationDomainStatusUpdate(federationDomain2,
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain2.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
+				),
+			},
+		},
+		{
+			name: "when there are both valid and invalid FederationDomains, but updating the invalid one fails, the " +
+				"existing status will be unchanged",
+			inputObjects: []runtime.Object{
+				invalidIssuerURLFederationDomain,
+				federationDomain2,
+				oidcIdentityProvider,
+			},
+			configClient: func(client *supervisorfake.Clientset) {
+				client.PrependReactor(
+					"update",
+					"federationdomains",
+					func(action coretesting.Action) (bool, runtime.Object, error) {
+						fd := action.(coretesting.UpdateAction).GetObject().(*supervisorconfigv1alpha1.FederationDomain)
+						if fd.Name == invalidIssuerURLFederationDomain.Name {
+							return true, nil, errors.New("some update error")
+						}
+						return false, nil, nil
+					},
+				)
+			},
+			wantErr: "could not update status: some update error",
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
+				// only the valid FederationDomain
+				federationDomainIssuerWithDefaultIDP(t, federationDomain2.Spec.Issuer, oidcIdentityProvider.ObjectMeta),
+			},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(invalidIssuerURLFederationDomain,
+					supervisorconfigv1alpha1.FederationDomainPhaseError,
+					conditionstestutil.Replace(
+						allHappyConditionsLegacyConfigurationSuccess(federationDomain2.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
+						[]metav1.Condition{
+							sadIssuerURLValidConditionCannotHaveQuery(frozenMetav1Now, 123),
+							sadReadyCondition(frozenMetav1Now, 123),
+						}),
+				),
+				expectedFederationDomainStatusUpdate(federationDomain2,
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain2.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
+				),
+			},
+		},
+		{
+			name: "when there are FederationDomains with duplicate issuer strings these particular FederationDomains " +
+				"will report error on IssuerUnique conditions",
+			inputObjects: []runtime.Object{
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: metav1.ObjectMeta{Name: "duplicate1", Namespace: namespace, Generation: 123},
+					Spec:       supervisorconfigv1alpha1.FederationDomainSpec{Issuer: "https://iSSueR-duPlicAte.cOm/a"},
+				},
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: metav1.ObjectMeta{Name: "duplicate2", Namespace: namespace, Generation: 123},
+					Spec:       supervisorconfigv1alpha1.FederationDomainSpec{Issuer: "https://issuer-duplicate.com/a"},
+				},
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: metav1.ObjectMeta{Name: "not-duplicate", Namespace: namespace, Generation: 123},
+					Spec:       supervisorconfigv1alpha1.FederationDomainSpec{Issuer: "https://issuer-duplicate.com/A"}, // different path (paths are case-sensitive)
+				},
+				oidcIdentityProvider,
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
+				federationDomainIssuerWithDefaultIDP(t, "https://issuer-duplicate.com/A", oidcIdentityProvider.ObjectMeta),
+			},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(
+					&supervisorconfigv1alpha1.FederationDomain{
+						ObjectMeta: metav1.ObjectMeta{Name: "duplicate1", Namespace: namespace, Generation: 123},
+					},
+					supervisorconfigv1alpha1.FederationDomainPhaseError,
 					conditionstestutil.Replace(
 						allHappyConditionsLegacyConfigurationSuccess("https://iSSueR-duPlicAte.cOm/a", oidcIdentityProvider.Name, frozenMetav1Now, 123),
 						[]metav1.Condition{
@@ -981,6 +1740,41 @@ func TestTestFederationDomainWatcherControllerSync(t *testing.T) {
 				),
 			},
 		},
+		{
+			name: "legacy config: no identity provider specified in federation domain and the only identity provider does not " +
+				"allow the federation domain to use it results in not allowed status",
+			inputObjects: []runtime.Object{
+				federationDomain1,
+				federationDomain2,
+				func() runtime.Object {
+					idp := oidcIdentityProvider.DeepCopy()
+					idp.Annotations = map[string]string{AllowedFederationDomainsAnnotation: "config2"}
+					return idp
+				}(),
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
+				federationDomainIssuerWithDefaultIDP(t, federationDomain2.Spec.Issuer, oidcIdentityProvider.ObjectMeta),
+			},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(federationDomain1,
+					supervisorconfigv1alpha1.FederationDomainPhaseError,
+					conditionstestutil.Replace(
+						allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, "", frozenMetav1Now, 123),
+						[]metav1.Condition{
+							sadIdentityProvidersFoundConditionIdentityProvidersNotAllowed(
+								`no resources were specified by .spec.identityProviders[].objectRef and the only identity provider `+
+									`resource "some-oidc-idp" does not allow this FederationDomain to use it: `+
+									`see its supervisor.pinniped.dev/allowed-federation-domains annotation`,
+								frozenMetav1Now, 123),
+							sadReadyCondition(frozenMetav1Now, 123),
+						}),
+				),
+				expectedFederationDomainStatusUpdate(federationDomain2,
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					allHappyConditionsLegacyConfigurationSuccess(federationDomain2.Spec.Issuer, oidcIdentityProvider.Name, frozenMetav1Now, 123),
+				),
+			},
+		},
 		{
 			name: "legacy config: no identity provider specified in federation domain and multiple identity providers found results in not specified status",
 			inputObjects: []runtime.Object{
@@ -992,55 +1786,350 @@ func TestTestFederationDomainWatcherControllerSync(t *testing.T) {
 			},
 			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{},
 			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
-				expectedFederationDomainStatusUpdate(federationDomain1,
+				expectedFederationDomainStatusUpdate(federationDomain1,
+					supervisorconfigv1alpha1.FederationDomainPhaseError,
+					conditionstestutil.Replace(
+						allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, "", frozenMetav1Now, 123),
+						[]metav1.Condition{
+							sadIdentityProvidersFoundConditionIdentityProviderNotSpecified(4, frozenMetav1Now, 123),
+							sadReadyCondition(frozenMetav1Now, 123),
+						}),
+				),
+			},
+		},
+		{
+			name: "the federation domain specifies identity providers that cannot be found",
+			inputObjects: []runtime.Object{
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: metav1.ObjectMeta{Name: "config1", Namespace: namespace, Generation: 123},
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer: "https://issuer1.com",
+						IdentityProviders: []supervisorconfigv1alpha1.FederationDomainIdentityProvider{
+							{
+								DisplayName: "cant-find-me",
+								ObjectRef: corev1.TypedLocalObjectReference{
+									APIGroup: ptr.To(apiGroupSupervisor),
+									Kind:     "OIDCIdentityProvider",
+									Name:     "cant-find-me-name",
+								},
+							},
+							{
+								DisplayName: "cant-find-me-either",
+								ObjectRef: corev1.TypedLocalObjectReference{
+									APIGroup: ptr.To(apiGroupSupervisor),
+									Kind:     "OIDCIdentityProvider",
+									Name:     "cant-find-me-either-name",
+								},
+							},
+							{
+								DisplayName: "cant-find-me-still",
+								ObjectRef: corev1.TypedLocalObjectReference{
+									APIGroup: ptr.To(apiGroupSupervisor),
+									Kind:     "ActiveDirectoryIdentityProvider",
+									Name:     "cant-find-me-still-name",
+								},
+							},
+							{
+								DisplayName: "cant-find-me-again",
+								ObjectRef: corev1.TypedLocalObjectReference{
+									APIGroup: ptr.To(apiGroupSupervisor),
+									Kind:     "GitHubIdentityProvider",
+									Name:     "cant-find-me-again-name",
+								},
+							},
+						},
+					},
+				},
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(
+					&supervisorconfigv1alpha1.FederationDomain{
+						ObjectMeta: metav1.ObjectMeta{Name: "config1", Namespace: namespace, Generation: 123},
+					},
+					supervisorconfigv1alpha1.FederationDomainPhaseError,
+					conditionstestutil.Replace(
+						allHappyConditionsSuccess("https://issuer1.com", frozenMetav1Now, 123),
+						[]metav1.Condition{
+							sadIdentityProvidersFoundConditionIdentityProvidersObjectRefsNotFound(here.Doc(
+								`cannot find resource specified by .spec.identityProviders[0].objectRef (with name "cant-find-me-name")
+
+								 cannot find resource specified by .spec.identityProviders[1].objectRef (with name "cant-find-me-either-name")
+
+								 cannot find resource specified by .spec.identityProviders[2].objectRef (with name "cant-find-me-still-name")
+
+								 cannot find resource specified by .spec.identityProviders[3].objectRef (with name "cant-find-me-again-name")`,
+							), frozenMetav1Now, 123),
+							sadReadyCondition(frozenMetav1Now, 123),
+						}),
+				),
+			},
+		},
+		{
+			name: "the federation domain specifies identity providers that all exist",
+			inputObjects: []runtime.Object{
+				oidcIdentityProvider,
+				ldapIdentityProvider,
+				adIdentityProvider,
+				gitHubIdentityProvider,
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: metav1.ObjectMeta{Name: "config1", Namespace: namespace, Generation: 123},
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer: "https://issuer1.com",
+						IdentityProviders: []supervisorconfigv1alpha1.FederationDomainIdentityProvider{
+							{
+								DisplayName: "can-find-me",
+								Description: "Log in with your corporate account",
+								IconURL:     "https://example.com/icon.png",
+								Order:       1,
+								ObjectRef: corev1.TypedLocalObjectReference{
+									APIGroup: ptr.To(apiGroupSupervisor),
+									Kind:     "OIDCIdentityProvider",
+									Name:     oidcIdentityProvider.Name,
+								},
+							},
+							{
+								DisplayName: "can-find-me-too",
+								ObjectRef: corev1.TypedLocalObjectReference{
+									APIGroup: ptr.To(apiGroupSupervisor),
+									Kind:     "LDAPIdentityProvider",
+									Name:     ldapIdentityProvider.Name,
+								},
+							},
+							{
+								DisplayName: "can-find-me-three",
+								ObjectRef: corev1.TypedLocalObjectReference{
+									APIGroup: ptr.To(apiGroupSupervisor),
+									Kind:     "ActiveDirectoryIdentityProvider",
+									Name:     adIdentityProvider.Name,
+								},
+							},
+							{
+								DisplayName: "can-find-me-four",
+								ObjectRef: corev1.TypedLocalObjectReference{
+									APIGroup: ptr.To(apiGroupSupervisor),
+									Kind:     "GitHubIdentityProvider",
+									Name:     gitHubIdentityProvider.Name,
+								},
+							},
+						},
+					},
+				},
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
+				federationDomainIssuerWithIDPs(t, "https://issuer1.com",
+					[]*federationdomainproviders.FederationDomainIdentityProvider{
+						{
+							DisplayName: "can-find-me",
+							UID:         oidcIdentityProvider.UID,
+							Transforms:  idtransform.NewTransformationPipeline(),
+							Presentation: resolvedprovider.Presentation{
+								Description: "Log in with your corporate account",
+								IconURL:     "https://example.com/icon.png",
+								Order:       1,
+							},
+						},
+						{
+							DisplayName: "can-find-me-too",
+							UID:         ldapIdentityProvider.UID,
+							Transforms:  idtransform.NewTransformationPipeline(),
+						},
+						{
+							DisplayName: "can-find-me-three",
+							UID:         adIdentityProvider.UID,
+							Transforms:  idtransform.NewTransformationPipeline(),
+						},
+						{
+							DisplayName: "can-find-me-four",
+							UID:         gitHubIdentityProvider.UID,
+							Transforms:  idtransform.NewTransformationPipeline(),
+						},
+					}),
+			},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(
+					&supervisorconfigv1alpha1.FederationDomain{
+						ObjectMeta: metav1.ObjectMeta{Name: "config1", Namespace: namespace, Generation: 123},
+					},
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					allHappyConditionsSuccess("https://issuer1.com", frozenMetav1Now, 123),
+				),
+			},
+		},
+		{
+			name: "the federation domain has the AllIDPsReady readiness policy and some identity providers cannot be found",
+			inputObjects: []runtime.Object{
+				oidcIdentityProvider,
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: metav1.ObjectMeta{Name: "config1", Namespace: namespace, Generation: 123},
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer:          "https://issuer1.com",
+						ReadinessPolicy: supervisorconfigv1alpha1.FederationDomainReadinessPolicyAllIDPsReady,
+						IdentityProviders: []supervisorconfigv1alpha1.FederationDomainIdentityProvider{
+							{
+								DisplayName: "can-find-me",
+								ObjectRef: corev1.TypedLocalObjectReference{
+									APIGroup: ptr.To(apiGroupSupervisor),
+									Kind:     "OIDCIdentityProvider",
+									Name:     oidcIdentityProvider.Name,
+								},
+							},
+							{
+								DisplayName: "cant-find-me",
+								ObjectRef: corev1.TypedLocalObjectReference{
+									APIGroup: ptr.To(apiGroupSupervisor),
+									Kind:     "LDAPIdentityProvider",
+									Name:     "cant-find-me-name",
+								},
+							},
+						},
+					},
+				},
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(
+					&supervisorconfigv1alpha1.FederationDomain{
+						ObjectMeta: metav1.ObjectMeta{Name: "config1", Namespace: namespace, Generation: 123},
+					},
 					supervisorconfigv1alpha1.FederationDomainPhaseError,
 					conditionstestutil.Replace(
-						allHappyConditionsLegacyConfigurationSuccess(federationDomain1.Spec.Issuer, "", frozenMetav1Now, 123),
+						allHappyConditionsSuccess("https://issuer1.com", frozenMetav1Now, 123),
 						[]metav1.Condition{
-							sadIdentityProvidersFoundConditionIdentityProviderNotSpecified(4, frozenMetav1Now, 123),
+							sadIdentityProvidersFoundConditionIdentityProvidersObjectRefsNotFound(
+								`cannot find resource specified by .spec.identityProviders[1].objectRef (with name "cant-find-me-name")`,
+								frozenMetav1Now, 123),
 							sadReadyCondition(frozenMetav1Now, 123),
 						}),
 				),
 			},
+			wantIDPStatuses: map[string][]supervisorconfigv1alpha1.FederationDomainIdentityProviderStatus{
+				"config1": {
+					{
+						DisplayName: "can-find-me",
+						Phase:       supervisorconfigv1alpha1.FederationDomainIdentityProviderPhaseReady,
+						Message:     "the identity provider is ready to be used by this FederationDomain",
+					},
+					{
+						DisplayName: "cant-find-me",
+						Phase:       supervisorconfigv1alpha1.FederationDomainIdentityProviderPhaseError,
+						Message:     `cannot find resource specified by objectRef (with name "cant-find-me-name")`,
+					},
+				},
+			},
 		},
 		{
-			name: "the federation domain specifies identity providers that cannot be found",
+			name: "the federation domain has the AnyIDPReady readiness policy and some identity providers cannot be found",
 			inputObjects: []runtime.Object{
+				oidcIdentityProvider,
 				&supervisorconfigv1alpha1.FederationDomain{
 					ObjectMeta: metav1.ObjectMeta{Name: "config1", Namespace: namespace, Generation: 123},
 					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
-						Issuer: "https://issuer1.com",
+						Issuer:          "https://issuer1.com",
+						ReadinessPolicy: supervisorconfigv1alpha1.FederationDomainReadinessPolicyAnyIDPReady,
 						IdentityProviders: []supervisorconfigv1alpha1.FederationDomainIdentityProvider{
 							{
-								DisplayName: "cant-find-me",
+								DisplayName: "can-find-me",
 								ObjectRef: corev1.TypedLocalObjectReference{
 									APIGroup: ptr.To(apiGroupSupervisor),
 									Kind:     "OIDCIdentityProvider",
-									Name:     "cant-find-me-name",
+									Name:     oidcIdentityProvider.Name,
 								},
 							},
 							{
-								DisplayName: "cant-find-me-either",
+								DisplayName: "cant-find-me",
 								ObjectRef: corev1.TypedLocalObjectReference{
 									APIGroup: ptr.To(apiGroupSupervisor),
-									Kind:     "OIDCIdentityProvider",
-									Name:     "cant-find-me-either-name",
+									Kind:     "LDAPIdentityProvider",
+									Name:     "cant-find-me-name",
 								},
 							},
+						},
+					},
+				},
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
+				func() *federationdomainproviders.FederationDomainIssuer {
+					fdi := federationDomainIssuerWithIDPs(t, "https://issuer1.com",
+						[]*federationdomainproviders.FederationDomainIdentityProvider{
 							{
-								DisplayName: "cant-find-me-still",
+								DisplayName: "can-find-me",
+								UID:         oidcIdentityProvider.UID,
+								Transforms:  idtransform.NewTransformationPipeline(),
+							},
+						})
+					fdi.SetNotReadyIdentityProviderDisplayNames([]string{"cant-find-me"})
+					return fdi
+				}(),
+			},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(
+					&supervisorconfigv1alpha1.FederationDomain{
+						ObjectMeta: metav1.ObjectMeta{Name: "config1", Namespace: namespace, Generation: 123},
+					},
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					conditionstestutil.Replace(
+						allHappyConditionsSuccess("https://issuer1.com", frozenMetav1Now, 123),
+						[]metav1.Condition{
+							sadIdentityProvidersFoundConditionIdentityProvidersObjectRefsNotFound(
+								`cannot find resource specified by .spec.identityProviders[1].objectRef (with name "cant-find-me-name")`,
+								frozenMetav1Now, 123),
+							func() metav1.Condition {
+								c := happyReadyCondition("https://issuer1.com", frozenMetav1Now, 123)
+								c.Message += `; some identity providers are not ready and cannot be used: ["cant-find-me"] (see status.identityProviders for details)`
+								return c
+							}(),
+						}),
+				),
+			},
+			wantIDPStatuses: map[string][]supervisorconfigv1alpha1.FederationDomainIdentityProviderStatus{
+				"config1": {
+					{
+						DisplayName: "can-find-me",
+						Phase:       supervisorconfigv1alpha1.FederationDomainIdentityProviderPhaseReady,
+						Message:     "the identity provider is ready to be used by this FederationDomain",
+					},
+					{
+						DisplayName: "cant-find-me",
+						Phase:       supervisorconfigv1alpha1.FederationDomainIdentityProviderPhaseError,
+						Message:     `cannot find resource specified by objectRef (with name "cant-find-me-name")`,
+					},
+				},
+			},
+		},
+		{
+			name: "the federation domain lists identity providers which do not allow the federation domain to use them",
+			inputObjects: []runtime.Object{
+				func() runtime.Object {
+					idp := oidcIdentityProvider.DeepCopy()
+					idp.Annotations = map[string]string{AllowedFederationDomainsAnnotation: "config2, config1"}
+					return idp
+				}(),
+				func() runtime.Object {
+					idp := ldapIdentityProvider.DeepCopy()
+					idp.Annotations = map[string]string{AllowedFederationDomainsAnnotation: "config2"}
+					return idp
+				}(),
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: metav1.ObjectMeta{Name: "config1", Namespace: namespace, Generation: 123},
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer:          "https://issuer1.com",
+						ReadinessPolicy: supervisorconfigv1alpha1.FederationDomainReadinessPolicyAllIDPsReady,
+						IdentityProviders: []supervisorconfigv1alpha1.FederationDomainIdentityProvider{
+							{
+								DisplayName: "allowed",
 								ObjectRef: corev1.TypedLocalObjectReference{
 									APIGroup: ptr.To(apiGroupSupervisor),
-									Kind:     "ActiveDirectoryIdentityProvider",
-									Name:     "cant-find-me-still-name",
+									Kind:     "OIDCIdentityProvider",
+									Name:     oidcIdentityProvider.Name,
 								},
 							},
 							{
-								DisplayName: "cant-find-me-again",
+								DisplayName: "not-allowed",
 								ObjectRef: corev1.TypedLocalObjectReference{
 									APIGroup: ptr.To(apiGroupSupervisor),
-									Kind:     "GitHubIdentityProvider",
-									Name:     "cant-find-me-again-name",
+									Kind:     "LDAPIdentityProvider",
+									Name:     ldapIdentityProvider.Name,
 								},
 							},
 						},
@@ -1057,31 +2146,39 @@ func TestTestFederationDomainWatcherControllerSync(t *testing.T) {
 					conditionstestutil.Replace(
 						allHappyConditionsSuccess("https://issuer1.com", frozenMetav1Now, 123),
 						[]metav1.Condition{
-							sadIdentityProvidersFoundConditionIdentityProvidersObjectRefsNotFound(here.Doc(
-								`cannot find resource specified by .spec.identityProviders[0].objectRef (with name "cant-find-me-name")
-
-								 cannot find resource specified by .spec.identityProviders[1].objectRef (with name "cant-find-me-either-name")
-
-								 cannot find resource specified by .spec.identityProviders[2].objectRef (with name "cant-find-me-still-name")
-
-								 cannot find resource specified by .spec.identityProviders[3].objectRef (with name "cant-find-me-again-name")`,
-							), frozenMetav1Now, 123),
+							sadIdentityProvidersFoundConditionIdentityProvidersNotAllowed(
+								`the resource specified by .spec.identityProviders[1].objectRef (with name "some-ldap-idp") `+
+									`does not allow this FederationDomain to use it: `+
+									`see its supervisor.pinniped.dev/allowed-federation-domains annotation`,
+								frozenMetav1Now, 123),
 							sadReadyCondition(frozenMetav1Now, 123),
 						}),
 				),
 			},
+			wantIDPStatuses: map[string][]supervisorconfigv1alpha1.FederationDomainIdentityProviderStatus{
+				"config1": {
+					{
+						DisplayName: "allowed",
+						Phase:       supervisorconfigv1alpha1.FederationDomainIdentityProviderPhaseReady,
+						Message:     "the identity provider is ready to be used by this FederationDomain",
+					},
+					{
+						DisplayName: "not-allowed",
+						Phase:       supervisorconfigv1alpha1.FederationDomainIdentityProviderPhaseError,
+						Message: "the resource specified by objectRef does not allow this FederationDomain to use it: " +
+							"see its supervisor.pinniped.dev/allowed-federation-domains annotation",
+					},
+				},
+			},
 		},
 		{
-			name: "the federation domain specifies identity providers that all exist",
+			name: "the federation domain has the AnyIDPReady readiness policy and none of its identity providers can be found",
 			inputObjects: []runtime.Object{
-				oidcIdentityProvider,
-				ldapIdentityProvider,
-				adIdentityProvider,
-				gitHubIdentityProvider,
 				&supervisorconfigv1alpha1.FederationDomain{
 					ObjectMeta: metav1.ObjectMeta{Name: "config1", Namespace: namespace, Generation: 123},
 					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
-						Issuer: "https://issuer1.com",
+						Issuer:          "https://issuer1.com",
+						ReadinessPolicy: supervisorconfigv1alpha1.FederationDomainReadinessPolicyAnyIDPReady,
 						IdentityProviders: []supervisorconfigv1alpha1.FederationDomainIdentityProvider{
 							{
 								DisplayName: "can-find-me",
@@ -1092,67 +2189,50 @@ func TestTestFederationDomainWatcherControllerSync(t *testing.T) {
 								},
 							},
 							{
-								DisplayName: "can-find-me-too",
+								DisplayName: "cant-find-me",
 								ObjectRef: corev1.TypedLocalObjectReference{
 									APIGroup: ptr.To(apiGroupSupervisor),
 									Kind:     "LDAPIdentityProvider",
-									Name:     ldapIdentityProvider.Name,
-								},
-							},
-							{
-								DisplayName: "can-find-me-three",
-								ObjectRef: corev1.TypedLocalObjectReference{
-									APIGroup: ptr.To(apiGroupSupervisor),
-									Kind:     "ActiveDirectoryIdentityProvider",
-									Name:     adIdentityProvider.Name,
-								},
-							},
-							{
-								DisplayName: "can-find-me-four",
-								ObjectRef: corev1.TypedLocalObjectReference{
-									APIGroup: ptr.To(apiGroupSupervisor),
-									Kind:     "GitHubIdentityProvider",
-									Name:     gitHubIdentityProvider.Name,
+									Name:     "cant-find-me-name",
 								},
 							},
 						},
 					},
 				},
 			},
-			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
-				federationDomainIssuerWithIDPs(t, "https://issuer1.com",
-					[]*federationdomainproviders.FederationDomainIdentityProvider{
-						{
-							DisplayName: "can-find-me",
-							UID:         oidcIdentityProvider.UID,
-							Transforms:  idtransform.NewTransformationPipeline(),
-						},
-						{
-							DisplayName: "can-find-me-too",
-							UID:         ldapIdentityProvider.UID,
-							Transforms:  idtransform.NewTransformationPipeline(),
-						},
-						{
-							DisplayName: "can-find-me-three",
-							UID:         adIdentityProvider.UID,
-							Transforms:  idtransform.NewTransformationPipeline(),
-						},
-						{
-							DisplayName: "can-find-me-four",
-							UID:         gitHubIdentityProvider.UID,
-							Transforms:  idtransform.NewTransformationPipeline(),
-						},
-					}),
-			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{},
 			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
 				expectedFederationDomainStatusUpdate(
 					&supervisorconfigv1alpha1.FederationDomain{
 						ObjectMeta: metav1.ObjectMeta{Name: "config1", Namespace: namespace, Generation: 123},
 					},
-					supervisorconfigv1alpha1.FederationDomainPhaseReady,
-					allHappyConditionsSuccess("https://issuer1.com", frozenMetav1Now, 123),
+					supervisorconfigv1alpha1.FederationDomainPhaseError,
+					conditionstestutil.Replace(
+						allHappyConditionsSuccess("https://issuer1.com", frozenMetav1Now, 123),
+						[]metav1.Condition{
+							sadIdentityProvidersFoundConditionIdentityProvidersObjectRefsNotFound(here.Doc(
+								`cannot find resource specified by .spec.identityProviders[0].objectRef (with name "some-oidc-idp")
+
+								 cannot find resource specified by .spec.identityProviders[1].objectRef (with name "cant-find-me-name")`,
+							), frozenMetav1Now, 123),
+							sadReadyCondition(frozenMetav1Now, 123),
+						}),
 				),
 			},
+			wantIDPStatuses: map[string][]supervisorconfigv1alpha1.FederationDomainIdentityProviderStatus{
+				"config1": {
+					{
+						DisplayName: "can-find-me",
+						Phase:       supervisorconfigv1alpha1.FederationDomainIdentityProviderPhaseError,
+						Message:     `cannot find resource specified by objectRef (with name "some-oidc-idp")`,
+					},
+					{
+						DisplayName: "cant-find-me",
+						Phase:       supervisorconfigv1alpha1.FederationDomainIdentityProviderPhaseError,
+						Message:     `cannot find resource specified by objectRef (with name "cant-find-me-name")`,
+					},
+				},
+			},
 		},
 		{
 			name: "the federation domain has duplicate display names for IDPs",
@@ -1321,6 +2401,88 @@ func TestTestFederationDomainWatcherControllerSync(t *testing.T) {
 				),
 			},
 		},
+		{
+			name:           "the federation domain specifies a MockIdentityProvider when MockIdentityProviders are enabled",
+			enableMockIDPs: true,
+			inputObjects: []runtime.Object{
+				mockIdentityProvider,
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: metav1.ObjectMeta{Name: "config1", Namespace: namespace, Generation: 123},
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer: "https://issuer1.com",
+						IdentityProviders: []supervisorconfigv1alpha1.FederationDomainIdentityProvider{
+							{
+								DisplayName: "can-find-me",
+								ObjectRef: corev1.TypedLocalObjectReference{
+									APIGroup: ptr.To(apiGroupSupervisor),
+									Kind:     "MockIdentityProvider",
+									Name:     mockIdentityProvider.Name,
+								},
+							},
+						},
+					},
+				},
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{
+				federationDomainIssuerWithIDPs(t, "https://issuer1.com",
+					[]*federationdomainproviders.FederationDomainIdentityProvider{
+						{
+							DisplayName: "can-find-me",
+							UID:         mockIdentityProvider.UID,
+							Transforms:  idtransform.NewTransformationPipeline(),
+						},
+					}),
+			},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(
+					&supervisorconfigv1alpha1.FederationDomain{
+						ObjectMeta: metav1.ObjectMeta{Name: "config1", Namespace: namespace, Generation: 123},
+					},
+					supervisorconfigv1alpha1.FederationDomainPhaseReady,
+					allHappyConditionsSuccess("https://issuer1.com", frozenMetav1Now, 123),
+				),
+			},
+		},
+		{
+			name: "the federation domain specifies a MockIdentityProvider when MockIdentityProviders are not enabled",
+			inputObjects: []runtime.Object{
+				mockIdentityProvider,
+				&supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: metav1.ObjectMeta{Name: "config1", Namespace: namespace, Generation: 123},
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer: "https://issuer1.com",
+						IdentityProviders: []supervisorconfigv1alpha1.FederationDomainIdentityProvider{
+							{
+								DisplayName: "cannot-find-me",
+								ObjectRef: corev1.TypedLocalObjectReference{
+									APIGroup: ptr.To(apiGroupSupervisor),
+									Kind:     "MockIdentityProvider",
+									Name:     mockIdentityProvider.Name,
+								},
+							},
+						},
+					},
+				},
+			},
+			wantFDIssuers: []*federationdomainproviders.FederationDomainIssuer{},
+			wantStatusUpdates: []*supervisorconfigv1alpha1.FederationDomain{
+				expectedFederationDomainStatusUpdate(
+					&supervisorconfigv1alpha1.FederationDomain{
+						ObjectMeta: metav1.ObjectMeta{Name: "config1", Namespace: namespace, Generation: 123},
+					},
+					supervisorconfigv1alpha1.FederationDomainPhaseError,
+					conditionstestutil.Replace(
+						allHappyConditionsSuccess("https://issuer1.com", frozenMetav1Now, 123),
+						[]metav1.Condition{
+							sadKindCondition(`"MockIdentityProvider"`, frozenMetav1Now, 123),
+							sadIdentityProvidersFoundConditionIdentityProvidersObjectRefsNotFound(
+								`cannot find resource specified by .spec.identityProviders[0].objectRef (with name "some-mock-idp")`,
+								frozenMetav1Now, 123),
+							sadReadyCondition(frozenMetav1Now, 123),
+						}),
+				),
+			},
+		},
 		{
 			name: "the federation domain has unrecognized kind names in objectRefs",
 			inputObjects: []runtime.Object{
@@ -2098,6 +3260,11 @@ func TestTestFederationDomainWatcherControllerSync(t *testing.T) {
 			}
 			pinnipedInformers := supervisorinformers.NewSharedInformerFactory(pinnipedInformerClient, 0)
 
+			var mockIdentityProviderInformer idpinformers.MockIdentityProviderInformer
+			if tt.enableMockIDPs {
+				mockIdentityProviderInformer = pinnipedInformers.IDP().V1alpha1().MockIdentityProviders()
+			}
+
 			controller := NewFederationDomainWatcherController(
 				federationDomainsSetter,
 				apiGroupSuffix,
@@ -2108,7 +3275,11 @@ func TestTestFederationDomainWatcherControllerSync(t *testing.T) {
 				pinnipedInformers.IDP().V1alpha1().LDAPIdentityProviders(),
 				pinnipedInformers.IDP().V1alpha1().ActiveDirectoryIdentityProviders(),
 				pinnipedInformers.IDP().V1alpha1().GitHubIdentityProviders(),
+				pinnipedInformers.IDP().V1alpha1().ClientCertificateIdentityProviders(),
+				pinnipedInformers.IDP().V1alpha1().OpenShiftIdentityProviders(),
+				mockIdentityProviderInformer,
 				controllerlib.WithInformer,
+				nil,
 			)
 
 			ctx, cancel := context.WithCancel(context.Background())
@@ -2152,14 +3323,19 @@ func TestTestFederationDomainWatcherControllerSync(t *testing.T) {
 			} else {
 				require.Empty(t, pinnipedAPIClient.Actions())
 			}
+
+			if tt.wantIDPStatuses != nil {
+				require.Equal(t, tt.wantIDPStatuses, getFederationDomainIdentityProviderStatuses(t, pinnipedAPIClient.Actions()))
+			}
 		})
 	}
 }
 
 type comparableFederationDomainIssuer struct {
-	issuer                  string
-	identityProviders       []*comparableFederationDomainIdentityProvider
-	defaultIdentityProvider *comparableFederationDomainIdentityProvider
+	issuer                               string
+	identityProviders                    []*comparableFederationDomainIdentityProvider
+	defaultIdentityProvider              *comparableFederationDomainIdentityProvider
+	notReadyIdentityProviderDisplayNames []string
 }
 
 type comparableFederationDomainIdentityProvider struct {
@@ -2188,9 +3364,10 @@ func convertToComparableType(fdis []*federationdomainproviders.FederationDomainI
 			comparableFDIs[i] = makeFederationDomainIdentityProviderComparable(idp)
 		}
 		converted := &comparableFederationDomainIssuer{
-			issuer:                  fdi.Issuer(),
-			identityProviders:       comparableFDIs,
-			defaultIdentityProvider: makeFederationDomainIdentityProviderComparable(fdi.DefaultIdentityProvider()),
+			issuer:                               fdi.Issuer(),
+			identityProviders:                    comparableFDIs,
+			defaultIdentityProvider:              makeFederationDomainIdentityProviderComparable(fdi.DefaultIdentityProvider()),
+			notReadyIdentityProviderDisplayNames: fdi.NotReadyIdentityProviderDisplayNames(),
 		}
 		result = append(result, converted)
 	}
@@ -2228,9 +3405,11 @@ func getFederationDomainStatusUpdates(t *testing.T, actions []coretesting.Action
 		require.Equal(t, fd.Namespace, updateAction.GetNamespace(), "an update action might have been called on the wrong namespace for a FederationDomain")
 
 		// We don't care about the spec of a FederationDomain in an update status action,
-		// so clear it out to make it easier to write expected values.
+		// so clear it out to make it easier to write expected values. The per-identity provider
+		// statuses are asserted separately by the tests which care about them.
 		copyOfFD := fd.DeepCopy()
 		copyOfFD.Spec = supervisorconfigv1alpha1.FederationDomainSpec{}
+		copyOfFD.Status.IdentityProviders = nil
 
 		federationDomains = append(federationDomains, copyOfFD)
 	}
@@ -2238,6 +3417,22 @@ func getFederationDomainStatusUpdates(t *testing.T, actions []coretesting.Action
 	return federationDomains
 }
 
+func getFederationDomainIdentityProviderStatuses(t *testing.T, actions []coretesting.Action) map[string][]supervisorconfigv1alpha1.FederationDomainIdentityProviderStatus {
+	idpStatuses := map[string][]supervisorconfigv1alpha1.FederationDomainIdentityProviderStatus{}
+
+	for _, action := range actions {
+		updateAction, ok := action.(coretesting.UpdateAction)
+		require.True(t, ok, "failed to cast an action as an coretesting.UpdateAction: %#v", action)
+
+		fd, ok := updateAction.GetObject().(*supervisorconfigv1alpha1.FederationDomain)
+		require.True(t, ok, "failed to cast an action's object as a FederationDomain: %#v", updateAction.GetObject())
+
+		idpStatuses[fd.Name] = fd.Status.IdentityProviders
+	}
+
+	return idpStatuses
+}
+
 func sortFederationDomainsByName(federationDomains []*supervisorconfigv1alpha1.FederationDomain) {
 	sort.SliceStable(federationDomains, func(a, b int) bool {
 		return federationDomains[a].GetName() < federationDomains[b].GetName()
@@ -14,6 +14,7 @@ import (
 	"net/http"
 	"slices"
 	"strings"
+	"time"
 
 	"golang.org/x/oauth2"
 	corev1 "k8s.io/api/core/v1"
@@ -23,8 +24,10 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/events"
 	"k8s.io/utils/clock"
 
+	idpconditions "go.pinniped.dev/generated/latest/apis/supervisor/idp/conditions"
 	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
 	supervisorclientset "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned"
 	idpinformers "go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions/idp/v1alpha1"
@@ -50,12 +53,12 @@ const (
 
 	countExpectedConditions = 6
 
-	HostValid                    string = "HostValid"
-	TLSConfigurationValid        string = "TLSConfigurationValid"
-	OrganizationsPolicyValid     string = "OrganizationsPolicyValid"
-	ClientCredentialsSecretValid string = "ClientCredentialsSecretValid" //nolint:gosec // this is not a credential
-	GitHubConnectionValid        string = "GitHubConnectionValid"
-	ClaimsValid                  string = "ClaimsValid"
+	HostValid                    string = idpconditions.TypeHostValid
+	TLSConfigurationValid        string = idpconditions.TypeTLSConfigurationValid
+	OrganizationsPolicyValid     string = idpconditions.TypeOrganizationsPolicyValid
+	ClientCredentialsSecretValid string = idpconditions.TypeClientCredentialsSecretValid //nolint:gosec // this is not a credential
+	GitHubConnectionValid        string = idpconditions.TypeGitHubConnectionValid
+	ClaimsValid                  string = idpconditions.TypeClaimsValid
 
 	defaultHost       = "github.com"
 	defaultApiBaseURL = "https://api.github.com"
@@ -73,6 +76,7 @@ type gitHubWatcherController struct {
 	client                         supervisorclientset.Interface
 	gitHubIdentityProviderInformer idpinformers.GitHubIdentityProviderInformer
 	secretInformer                 corev1informers.SecretInformer
+	allowedSecretDirectories       []string
 	clock                          clock.Clock
 	dialFunc                       func(network, addr string, config *tls.Config) (*tls.Conn, error)
 }
@@ -86,8 +90,10 @@ func New(
 	secretInformer corev1informers.SecretInformer,
 	log plog.Logger,
 	withInformer pinnipedcontroller.WithInformerOptionFunc,
+	recorder events.EventRecorder,
 	clock clock.Clock,
 	dialFunc func(network, addr string, config *tls.Config) (*tls.Conn, error),
+	allowedSecretDirectories []string,
 ) controllerlib.Controller {
 	c := gitHubWatcherController{
 		namespace:                      namespace,
@@ -96,6 +102,7 @@ func New(
 		log:                            log.WithName(controllerName),
 		gitHubIdentityProviderInformer: gitHubIdentityProviderInformer,
 		secretInformer:                 secretInformer,
+		allowedSecretDirectories:       allowedSecretDirectories,
 		clock:                          clock,
 		dialFunc:                       dialFunc,
 	}
@@ -115,6 +122,7 @@ func New(
 			pinnipedcontroller.MatchAnySecretOfTypeFilter(gitHubClientSecretType, pinnipedcontroller.SingletonQueue(), namespace),
 			controllerlib.InformerOption{},
 		),
+		controllerlib.WithRecorder(recorder),
 	)
 }
 
@@ -131,8 +139,10 @@ func (c *gitHubWatcherController) Sync(ctx controllerlib.Context) error {
 	})
 
 	var applicationErrors []error
+	usesClientSecretFiles := false
 	validatedUpstreams := make([]upstreamprovider.UpstreamGithubIdentityProviderI, 0, len(actualUpstreams))
 	for _, upstream := range actualUpstreams {
+		usesClientSecretFiles = usesClientSecretFiles || upstreamwatchers.UsesClientSecretFiles(upstream.Spec.Client.SecretRef)
 		validatedUpstream, applicationErr := c.validateUpstreamAndUpdateConditions(ctx, upstream)
 		if applicationErr != nil {
 			applicationErrors = append(applicationErrors, applicationErr)
@@ -145,6 +155,11 @@ func (c *gitHubWatcherController) Sync(ctx controllerlib.Context) error {
 	}
 	c.cache.SetGitHubIdentityProviders(validatedUpstreams)
 
+	if usesClientSecretFiles {
+		// Changes to files are not observed by any informer, so re-read them periodically.
+		ctx.Queue.AddAfter(ctx.Key, upstreamwatchers.ClientSecretFilesResyncInterval)
+	}
+
 	// If we have recoverable application errors, let's do a requeue and capture all the applicationErrors too
 	if len(applicationErrors) > 0 {
 		applicationErrors = append([]error{controllerlib.ErrSyntheticRequeue}, applicationErrors...)
@@ -153,7 +168,12 @@ func (c *gitHubWatcherController) Sync(ctx controllerlib.Context) error {
 	return utilerrors.NewAggregate(applicationErrors)
 }
 
-func (c *gitHubWatcherController) validateClientSecret(secretName string) (*metav1.Condition, string, string, error) {
+func (c *gitHubWatcherController) validateClientSecret(clientSpec idpv1alpha1.GitHubClientSpec) (*metav1.Condition, string, string, error) {
+	if clientSpec.SecretRef != nil {
+		return c.validateClientSecretRef(clientSpec.SecretRef)
+	}
+
+	secretName := clientSpec.SecretName
 	secret, unableToRetrieveSecretErr := c.secretInformer.Lister().Secrets(c.namespace).Get(secretName)
 
 	// This error requires user interaction, so ignore it.
@@ -206,6 +226,26 @@ func (c *gitHubWatcherController) validateClientSecret(secretName string) (*meta
 	}, clientID, clientSecret, nil
 }
 
+func (c *gitHubWatcherController) validateClientSecretRef(secretRef *idpv1alpha1.ClientSecretRef) (*metav1.Condition, string, string, error) {
+	clientID, clientSecret, err := upstreamwatchers.ReadClientSecretFiles(secretRef, c.allowedSecretDirectories)
+	if err != nil {
+		// This error requires user interaction, so do not return it.
+		return &metav1.Condition{
+			Type:    ClientCredentialsSecretValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  upstreamwatchers.ReasonNotFound,
+			Message: fmt.Sprintf("%s: files from spec.client.secretRef must be readable with non-empty contents", err.Error()),
+		}, "", "", nil
+	}
+
+	return &metav1.Condition{
+		Type:    ClientCredentialsSecretValid,
+		Status:  metav1.ConditionTrue,
+		Reason:  upstreamwatchers.ReasonSuccess,
+		Message: fmt.Sprintf("clientID and clientSecret have been read from spec.client.secretRef (%q)", secretRef.Path),
+	}, clientID, clientSecret, nil
+}
+
 func validateOrganizationsPolicy(organizationsSpec *idpv1alpha1.GitHubOrganizationsSpec) *metav1.Condition {
 	var policy idpv1alpha1.GitHubAllowedAuthOrganizationsPolicy
 	if organizationsSpec.Policy != nil {
@@ -228,7 +268,7 @@ func validateOrganizationsPolicy(organizationsSpec *idpv1alpha1.GitHubOrganizati
 		return &metav1.Condition{
 			Type:    OrganizationsPolicyValid,
 			Status:  metav1.ConditionFalse,
-			Reason:  "Invalid",
+			Reason:  idpconditions.ReasonInvalid,
 			Message: "spec.allowAuthentication.organizations.policy must be 'OnlyUsersFromAllowedOrganizations' when spec.allowAuthentication.organizations.allowed has organizations listed",
 		}
 	}
@@ -236,7 +276,7 @@ func validateOrganizationsPolicy(organizationsSpec *idpv1alpha1.GitHubOrganizati
 	return &metav1.Condition{
 		Type:    OrganizationsPolicyValid,
 		Status:  metav1.ConditionFalse,
-		Reason:  "Invalid",
+		Reason:  idpconditions.ReasonInvalid,
 		Message: "spec.allowAuthentication.organizations.policy must be 'AllGitHubUsers' when spec.allowAuthentication.organizations.allowed is empty",
 	}
 }
@@ -248,7 +288,7 @@ func (c *gitHubWatcherController) validateUpstreamAndUpdateConditions(ctx contro
 	conditions := make([]*metav1.Condition, 0)
 	applicationErrors := make([]error, 0)
 
-	clientSecretCondition, clientID, clientSecret, clientSecretErr := c.validateClientSecret(upstream.Spec.Client.SecretName)
+	clientSecretCondition, clientID, clientSecret, clientSecretErr := c.validateClientSecret(upstream.Spec.Client)
 	conditions = append(conditions, clientSecretCondition)
 	if clientSecretErr != nil { // untested
 		applicationErrors = append(applicationErrors, clientSecretErr)
@@ -272,6 +312,8 @@ func (c *gitHubWatcherController) validateUpstreamAndUpdateConditions(ctx contro
 	githubConnectionCondition, hostURL, httpClient, githubConnectionErr := c.validateGitHubConnection(
 		hostPort,
 		certPool,
+		upstream.Spec.GitHubAPI.ConnectionPool,
+		upstream.Spec.GitHubAPI.Proxy,
 		hostCondition.Status == metav1.ConditionTrue && tlsConfigCondition.Status == metav1.ConditionTrue,
 	)
 	if githubConnectionErr != nil {
@@ -286,7 +328,7 @@ func (c *gitHubWatcherController) validateUpstreamAndUpdateConditions(ctx contro
 		applicationErrors = append(applicationErrors, fmt.Errorf("expected %d conditions but found %d conditions", countExpectedConditions, len(conditions)))
 		return nil, utilerrors.NewAggregate(applicationErrors)
 	}
-	hadErrorCondition, updateStatusErr := c.updateStatus(ctx.Context, upstream, conditions)
+	hadErrorCondition, updateStatusErr := c.updateStatus(ctx, upstream, conditions)
 	if updateStatusErr != nil {
 		applicationErrors = append(applicationErrors, updateStatusErr)
 	}
@@ -334,7 +376,7 @@ func validateHost(gitHubAPIConfig idpv1alpha1.GitHubAPIConfig) (*metav1.Conditio
 		return &metav1.Condition{
 			Type:    HostValid,
 			Status:  metav1.ConditionFalse,
-			Reason:  "InvalidHost",
+			Reason:  idpconditions.ReasonInvalidHost,
 			Message: fmt.Sprintf("spec.githubAPI.host (%q) is not valid: %s", host, reason),
 		}
 	}
@@ -367,7 +409,7 @@ func (c *gitHubWatcherController) validateTLSConfiguration(tlsSpec *idpv1alpha1.
 		return &metav1.Condition{
 			Type:    TLSConfigurationValid,
 			Status:  metav1.ConditionFalse,
-			Reason:  "InvalidTLSConfig",
+			Reason:  upstreamwatchers.ReasonInvalidTLSConfig,
 			Message: fmt.Sprintf("spec.githubAPI.tls.certificateAuthorityData is not valid: %s", buildCertPoolErr),
 		}, nil
 	}
@@ -383,33 +425,60 @@ func (c *gitHubWatcherController) validateTLSConfiguration(tlsSpec *idpv1alpha1.
 func (c *gitHubWatcherController) validateGitHubConnection(
 	hostPort *endpointaddr.HostPort,
 	certPool *x509.CertPool,
+	connectionPool *idpv1alpha1.HTTPConnectionPoolSpec,
+	proxySpec *idpv1alpha1.ProxySpec,
 	validSoFar bool,
 ) (*metav1.Condition, string, *http.Client, error) {
 	if !validSoFar {
 		return &metav1.Condition{
 			Type:    GitHubConnectionValid,
 			Status:  metav1.ConditionUnknown,
-			Reason:  "UnableToValidate",
+			Reason:  idpconditions.ReasonUnableToValidate,
 			Message: "unable to validate; see other conditions for details",
 		}, "", nil, nil
 	}
 
-	conn, tlsDialErr := c.dialFunc("tcp", hostPort.Endpoint(), ptls.Default(certPool))
+	proxy, proxyErr := upstreamwatchers.Proxy(proxySpec)
+	if proxyErr != nil {
+		// proxyErr is not recoverable with a resync.
+		// It requires user interaction, so do not return the error.
+		return &metav1.Condition{
+			Type:    GitHubConnectionValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  idpconditions.ReasonInvalid,
+			Message: fmt.Sprintf("spec.githubAPI.proxy.url is not valid: %s", proxyErr),
+		}, "", nil, nil
+	}
+
+	dialFunc := c.dialFunc
+	if proxy != nil {
+		dialFunc = func(network, addr string, config *tls.Config) (*tls.Conn, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			return proxy.DialTLSContext(ctx, &net.Dialer{}, network, addr, config)
+		}
+	}
+
+	conn, tlsDialErr := dialFunc("tcp", hostPort.Endpoint(), ptls.Default(certPool))
 	if tlsDialErr != nil {
 		return &metav1.Condition{
 			Type:    GitHubConnectionValid,
 			Status:  metav1.ConditionFalse,
-			Reason:  "UnableToDialServer",
+			Reason:  idpconditions.ReasonUnableToDialServer,
 			Message: fmt.Sprintf("cannot dial server spec.githubAPI.host (%q): %s", hostPort.Endpoint(), buildDialErrorMessage(tlsDialErr)),
 		}, "", nil, tlsDialErr
 	}
 
+	pool := upstreamwatchers.ConnectionPool(hostPort.Endpoint(), connectionPool)
+	pool.Proxy = proxy
+	httpClient := phttp.DefaultWithConnectionPool(certPool, pool)
+
 	return &metav1.Condition{
 		Type:    GitHubConnectionValid,
 		Status:  metav1.ConditionTrue,
 		Reason:  upstreamwatchers.ReasonSuccess,
 		Message: fmt.Sprintf("spec.githubAPI.host (%q) is reachable and TLS verification succeeds", hostPort.Endpoint()),
-	}, fmt.Sprintf("https://%s", hostPort.Endpoint()), phttp.Default(certPool), conn.Close()
+	}, fmt.Sprintf("https://%s", hostPort.Endpoint()), httpClient, conn.Close()
 }
 
 // buildDialErrorMessage standardizes DNS error messages that appear differently on different platforms, so that tests and log grepping is uniform.
@@ -432,7 +501,7 @@ func validateUserAndGroupAttributes(upstream *idpv1alpha1.GitHubIdentityProvider
 		return &metav1.Condition{
 			Type:    ClaimsValid,
 			Status:  metav1.ConditionFalse,
-			Reason:  "Invalid",
+			Reason:  idpconditions.ReasonInvalid,
 			Message: message,
 		}
 	}
@@ -477,7 +546,7 @@ func validateUserAndGroupAttributes(upstream *idpv1alpha1.GitHubIdentityProvider
 }
 
 func (c *gitHubWatcherController) updateStatus(
-	ctx context.Context,
+	ctx controllerlib.Context,
 	upstream *idpv1alpha1.GitHubIdentityProvider,
 	conditions []*metav1.Condition) (bool, error) {
 	log := c.log.WithValues("namespace", upstream.Namespace, "name", upstream.Name)
@@ -505,6 +574,9 @@ func (c *gitHubWatcherController) updateStatus(
 	_, updateStatusError := c.client.
 		IDPV1alpha1().
 		GitHubIdentityProviders(upstream.Namespace).
-		UpdateStatus(ctx, updated, metav1.UpdateOptions{})
+		UpdateStatus(ctx.Context, updated, metav1.UpdateOptions{})
+	if updateStatusError == nil {
+		conditionsutil.RecordConditionChanges(ctx.Recorder, updated, upstream.Status.Conditions, updated.Status.Conditions)
+	}
 	return hadErrorCondition, updateStatusError
 }
@@ -11,6 +11,8 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -129,6 +131,18 @@ func TestController(t *testing.T) {
 		},
 	}
 
+	// A directory of client credential files, as they might be mounted by a secret store CSI driver.
+	secretsDir := t.TempDir()
+	secretFilesDir := filepath.Join(secretsDir, "github-client")
+	require.NoError(t, os.Mkdir(secretFilesDir, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(secretFilesDir, "clientID"), []byte("some-client-id-from-file"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(secretFilesDir, "clientSecret"), []byte("some-client-secret-from-file"), 0o600))
+
+	validMinimalIDPWithSecretFiles := validMinimalIDP.DeepCopy()
+	validMinimalIDPWithSecretFiles.Spec.Client = idpv1alpha1.GitHubClientSpec{
+		SecretRef: &idpv1alpha1.ClientSecretRef{Type: idpv1alpha1.ClientSecretRefTypeFile, Path: secretFilesDir},
+	}
+
 	validFilledOutIDP := &idpv1alpha1.GitHubIdentityProvider{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:       "some-idp-name",
@@ -378,6 +392,7 @@ func TestController(t *testing.T) {
 		wantLogs                []string
 		wantResultingCache      []*upstreamgithub.ProviderConfig
 		wantResultingUpstreams  []idpv1alpha1.GitHubIdentityProvider
+		wantRequeueAfter        time.Duration
 	}{
 		{
 			name:                   "no GitHubIdentityProviders",
@@ -497,6 +512,69 @@ func TestController(t *testing.T) {
 				buildLogForUpdatingPhase("minimal-idp-name", "Ready"),
 			},
 		},
+		{
+			name: "happy path with client credentials from files",
+			githubIdentityProviders: []runtime.Object{
+				validMinimalIDPWithSecretFiles,
+			},
+			wantResultingCache: []*upstreamgithub.ProviderConfig{
+				{
+					Name:               "minimal-idp-name",
+					ResourceUID:        "minimal-uid",
+					APIBaseURL:         fmt.Sprintf("https://%s/api/v3", *validFilledOutIDP.Spec.GitHubAPI.Host),
+					UsernameAttribute:  "login",
+					GroupNameAttribute: "slug",
+					OAuth2Config: &oauth2.Config{
+						ClientID:     "some-client-id-from-file",
+						ClientSecret: "some-client-secret-from-file",
+						Endpoint: oauth2.Endpoint{
+							AuthURL:       fmt.Sprintf("https://%s/login/oauth/authorize", *validFilledOutIDP.Spec.GitHubAPI.Host),
+							DeviceAuthURL: "", // not used
+							TokenURL:      fmt.Sprintf("https://%s/login/oauth/access_token", *validFilledOutIDP.Spec.GitHubAPI.Host),
+							AuthStyle:     oauth2.AuthStyleInParams,
+						},
+						RedirectURL: "", // not used
+						Scopes:      []string{"read:user", "read:org"},
+					},
+					AllowedOrganizations: setutil.NewCaseInsensitiveSet(),
+					HttpClient:           nil, // let the test runner populate this for us
+				},
+			},
+			wantResultingUpstreams: []idpv1alpha1.GitHubIdentityProvider{
+				{
+					ObjectMeta: validMinimalIDPWithSecretFiles.ObjectMeta,
+					Spec:       validMinimalIDPWithSecretFiles.Spec,
+					Status: idpv1alpha1.GitHubIdentityProviderStatus{
+						Phase: idpv1alpha1.GitHubPhaseReady,
+						Conditions: []metav1.Condition{
+							buildClaimsValidatedTrue(t),
+							{
+								Type:               ClientCredentialsSecretValid,
+								Status:             metav1.ConditionTrue,
+								ObservedGeneration: wantObservedGeneration,
+								LastTransitionTime: wantLastTransitionTime,
+								Reason:             upstreamwatchers.ReasonSuccess,
+								Message:            fmt.Sprintf("clientID and clientSecret have been read from spec.client.secretRef (%q)", secretFilesDir),
+							},
+							buildGitHubConnectionValidTrue(t, *validMinimalIDP.Spec.GitHubAPI.Host),
+							buildHostValidTrue(t, *validMinimalIDP.Spec.GitHubAPI.Host),
+							buildOrganizationsPolicyValidTrue(t, *validMinimalIDP.Spec.AllowAuthentication.Organizations.Policy),
+							buildTLSConfigurationValidTrue(t),
+						},
+					},
+				},
+			},
+			wantLogs: []string{
+				buildLogForUpdatingClientCredentialsSecretValid("minimal-idp-name", "True", "Success", fmt.Sprintf(`clientID and clientSecret have been read from spec.client.secretRef (\"%s\")`, secretFilesDir)),
+				buildLogForUpdatingClaimsValidTrue("minimal-idp-name"),
+				buildLogForUpdatingOrganizationPolicyValid("minimal-idp-name", "True", "Success", fmt.Sprintf(`spec.allowAuthentication.organizations.policy (\"%s\") is valid`, string(*validMinimalIDP.Spec.AllowAuthentication.Organizations.Policy))),
+				buildLogForUpdatingHostValid("minimal-idp-name", "True", "Success", `spec.githubAPI.host (\"%s\") is valid`, *validMinimalIDP.Spec.GitHubAPI.Host),
+				buildLogForUpdatingTLSConfigurationValid("minimal-idp-name", "True", "Success", "spec.githubAPI.tls.certificateAuthorityData is valid"),
+				buildLogForUpdatingGitHubConnectionValid("minimal-idp-name", "True", "Success", `spec.githubAPI.host (\"%s\") is reachable and TLS verification succeeds`, *validMinimalIDP.Spec.GitHubAPI.Host),
+				buildLogForUpdatingPhase("minimal-idp-name", "Ready"),
+			},
+			wantRequeueAfter: time.Minute,
+		},
 		{
 			name:    "happy path using github.com",
 			secrets: []runtime.Object{goodSecret},
@@ -1855,8 +1933,10 @@ func TestController(t *testing.T) {
 				kubeInformers.Core().V1().Secrets(),
 				logger,
 				controllerlib.WithInformer,
+				nil,
 				frozenClockForLastTransitionTime,
 				dialer,
+				[]string{secretsDir},
 			)
 
 			ctx, cancel := context.WithCancel(context.Background())
@@ -1866,7 +1946,8 @@ func TestController(t *testing.T) {
 			kubeInformers.Start(ctx.Done())
 			controllerlib.TestRunSynchronously(t, controller)
 
-			syncCtx := controllerlib.Context{Context: ctx, Key: controllerlib.Key{}}
+			queue := &testQueue{}
+			syncCtx := controllerlib.Context{Context: ctx, Key: controllerlib.Key{}, Queue: queue}
 
 			if err := controllerlib.TestSync(t, controller, syncCtx); len(tt.wantErr) > 0 {
 				require.ErrorContains(t, err, controllerlib.ErrSyntheticRequeue.Error())
@@ -1874,6 +1955,7 @@ func TestController(t *testing.T) {
 			} else {
 				require.NoError(t, err)
 			}
+			require.Equal(t, tt.wantRequeueAfter, queue.duration)
 
 			// Verify what's in the cache
 			actualIDPList := cache.GetGitHubIdentityProviders()
@@ -1950,6 +2032,16 @@ func TestController(t *testing.T) {
 	}
 }
 
+type testQueue struct {
+	duration time.Duration
+
+	controllerlib.Queue // panic if any other methods called
+}
+
+func (q *testQueue) AddAfter(_ controllerlib.Key, duration time.Duration) {
+	q.duration = duration
+}
+
 func TestController_OnlyWantActions(t *testing.T) {
 	require.Equal(t, 6, countExpectedConditions)
 
@@ -2229,8 +2321,10 @@ func TestController_OnlyWantActions(t *testing.T) {
 				kubeInformers.Core().V1().Secrets(),
 				logger,
 				controllerlib.WithInformer,
+				nil,
 				frozenClockForLastTransitionTime,
 				tls.Dial,
+				nil,
 			)
 
 			ctx, cancel := context.WithCancel(context.Background())
@@ -2339,8 +2433,10 @@ func TestGitHubUpstreamWatcherControllerFilterSecret(t *testing.T) {
 				secretInformer,
 				logger,
 				observableInformers.WithInformer,
+				nil,
 				clock.RealClock{},
 				tls.Dial,
+				nil,
 			)
 
 			unrelated := &corev1.Secret{}
@@ -2408,8 +2504,10 @@ func TestGitHubUpstreamWatcherControllerFilterGitHubIDP(t *testing.T) {
 				k8sinformers.NewSharedInformerFactoryWithOptions(kubernetesfake.NewSimpleClientset(), 0).Core().V1().Secrets(),
 				logger,
 				observableInformers.WithInformer,
+				nil,
 				clock.RealClock{},
 				tls.Dial,
+				nil,
 			)
 
 			unrelated := &idpv1alpha1.GitHubIdentityProvider{}
@@ -1,4 +1,4 @@
-// Copyright 2020-2023 the Pinniped contributors. All Rights Reserved.
+// Copyright 2020-2024 the Pinniped contributors. All Rights Reserved.
 // SPDX-License-Identifier: Apache-2.0
 
 package supervisorconfig
@@ -8,10 +8,13 @@ import (
 	"fmt"
 
 	"github.com/go-jose/go-jose/v3"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	corev1informers "k8s.io/client-go/informers/core/v1"
 
 	"go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions/config/v1alpha1"
+	"go.pinniped.dev/internal/clientcredentials"
 	pinnipedcontroller "go.pinniped.dev/internal/controller"
 	"go.pinniped.dev/internal/controllerlib"
 	"go.pinniped.dev/internal/plog"
@@ -50,9 +53,13 @@ func NewJWKSObserverController(
 				secretInformer:           secretInformer,
 			},
 		},
+		// The credentials of external signing services are also watched, so that the signers use their latest values.
 		withInformer(
 			secretInformer,
-			pinnipedcontroller.MatchAnySecretOfTypeFilter(jwksSecretTypeValue, nil),
+			pinnipedcontroller.SimpleFilter(func(obj metav1.Object) bool {
+				secret, ok := obj.(*corev1.Secret)
+				return ok && (secret.Type == jwksSecretTypeValue || clientcredentials.IsCredentialsSecret(secret))
+			}, nil),
 			controllerlib.InformerOption{},
 		),
 		withInformer(
@@ -90,15 +97,40 @@ func (c *jwksObserverController) Sync(ctx controllerlib.Context) error {
 			continue
 		}
 
+		external := externalSigningKeysFor(provider)
+		activeJWKDataKey := activeJWKKey
+		if external != nil {
+			activeJWKDataKey = externalActiveJWKKey
+		}
 		activeJWKFromSecret := jose.JSONWebKey{}
-		err = json.Unmarshal(jwksSecret.Data[activeJWKKey], &activeJWKFromSecret)
+		err = json.Unmarshal(jwksSecret.Data[activeJWKDataKey], &activeJWKFromSecret)
 		if err != nil {
 			plog.Debug("jwksObserverController Sync found an active JWK secret with Data in an unexpected format", "namespace", ns, "secretName", secretRef.Name)
 			continue
 		}
 
+		if external != nil {
+			// The secret only has the public key, so the tokens are signed by the external signing service instead.
+			client, err := newExternalSignerClient(c.secretInformer.Lister(), ns, external)
+			if err != nil {
+				plog.Debug("jwksObserverController Sync found a FederationDomain with invalid external signing keys", "namespace", ns, "federationDomainName", provider.Name, "err", err)
+				continue
+			}
+			activeJWKFromSecret = jose.JSONWebKey{
+				Key:       client.Signer(activeJWKFromSecret),
+				KeyID:     activeJWKFromSecret.KeyID,
+				Algorithm: activeJWKFromSecret.Algorithm,
+				Use:       activeJWKFromSecret.Use,
+			}
+		}
+
 		issuerToJWKSMap[provider.Spec.Issuer] = &jwksFromSecret
 		issuerToActiveJWKMap[provider.Spec.Issuer] = &activeJWKFromSecret
+		// The previous issuer of a FederationDomain which is migrating to a new issuer still serves the same keys.
+		if provider.Spec.PreviousIssuer != nil {
+			issuerToJWKSMap[provider.Spec.PreviousIssuer.Issuer] = &jwksFromSecret
+			issuerToActiveJWKMap[provider.Spec.PreviousIssuer.Issuer] = &activeJWKFromSecret
+		}
 	}
 
 	plog.Debug(
@@ -69,6 +69,17 @@ func TestJWKSObserverControllerInformerFilters(t *testing.T) {
 				})
 			})
 
+			when("any Secret which holds the credentials of an external signing service changes", func() {
+				it("returns true to trigger the sync method", func() {
+					tlsSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "any-name", Namespace: "any-namespace"}, Type: corev1.SecretTypeTLS}
+					bearerTokenSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "any-name", Namespace: "any-namespace"}, Type: "secrets.pinniped.dev/bearer-token"}
+					r.True(subject.Add(tlsSecret))
+					r.True(subject.Update(tlsSecret, otherTypeSecret))
+					r.True(subject.Update(otherTypeSecret, bearerTokenSecret))
+					r.True(subject.Delete(bearerTokenSecret))
+				})
+			})
+
 			when("any Secret of some other type changes", func() {
 				it("returns false to skip the sync method", func() {
 					r.False(subject.Add(otherTypeSecret))
@@ -273,13 +284,75 @@ func TestJWKSObserverControllerSync(t *testing.T) {
 						Name:      "good-secret-federationdomain2",
 						Namespace: installedInNamespace,
 					},
-					Spec: supervisorconfigv1alpha1.FederationDomainSpec{Issuer: "https://issuer-with-good-secret2.com"},
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer: "https://issuer-with-good-secret2.com",
+						PreviousIssuer: &supervisorconfigv1alpha1.FederationDomainPreviousIssuer{
+							Issuer: "https://old-issuer-with-good-secret2.com",
+						},
+					},
 					Status: supervisorconfigv1alpha1.FederationDomainStatus{
 						Secrets: supervisorconfigv1alpha1.FederationDomainSecrets{
 							JWKS: corev1.LocalObjectReference{Name: "good-jwks-secret-name2"},
 						},
 					},
 				}
+				externalSigningKeys := &supervisorconfigv1alpha1.FederationDomainSigningKeys{
+					External: &supervisorconfigv1alpha1.FederationDomainExternalSigningKeys{
+						Endpoint:              "https://signer.example.com",
+						CredentialsSecretName: "signer-credentials-secret-name",
+					},
+				}
+				federationDomainWithMissingExternalSigningCredentials := &supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "missing-external-signing-credentials-federationdomain",
+						Namespace: installedInNamespace,
+					},
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer: "https://issuer-with-missing-external-signing-credentials.com",
+						SigningKeys: &supervisorconfigv1alpha1.FederationDomainSigningKeys{
+							External: &supervisorconfigv1alpha1.FederationDomainExternalSigningKeys{
+								Endpoint:              "https://signer.example.com",
+								CredentialsSecretName: "missing-secret-name",
+							},
+						},
+					},
+					Status: supervisorconfigv1alpha1.FederationDomainStatus{
+						Secrets: supervisorconfigv1alpha1.FederationDomainSecrets{
+							JWKS: corev1.LocalObjectReference{Name: "external-jwks-secret-name"},
+						},
+					},
+				}
+				federationDomainWithExternalSigningKeys := &supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "external-signing-keys-federationdomain",
+						Namespace: installedInNamespace,
+					},
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer:      "https://issuer-with-external-signing-keys.com",
+						SigningKeys: externalSigningKeys,
+					},
+					Status: supervisorconfigv1alpha1.FederationDomainStatus{
+						Secrets: supervisorconfigv1alpha1.FederationDomainSecrets{
+							JWKS: corev1.LocalObjectReference{Name: "external-jwks-secret-name"},
+						},
+					},
+				}
+				federationDomainWithExternalSigningKeysAndOldSecret := &supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "external-signing-keys-old-secret-federationdomain",
+						Namespace: installedInNamespace,
+					},
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer:      "https://issuer-with-external-signing-keys-and-old-secret.com",
+						SigningKeys: externalSigningKeys,
+					},
+					Status: supervisorconfigv1alpha1.FederationDomainStatus{
+						Secrets: supervisorconfigv1alpha1.FederationDomainSecrets{
+							// This secret has a private key which the jwks writer has not replaced yet.
+							JWKS: corev1.LocalObjectReference{Name: "good-jwks-secret-name1"},
+						},
+					},
+				}
 				expectedJWK1 = string(readJWKJSON(t, "testdata/public-jwk.json"))
 				r.NotEmpty(expectedJWK1)
 				expectedJWK2 = string(readJWKJSON(t, "testdata/public-jwk2.json"))
@@ -304,6 +377,24 @@ func TestJWKSObserverControllerSync(t *testing.T) {
 						"jwks":      []byte(`{"keys": [` + expectedJWK2 + `]}`),
 					},
 				}
+				externalJWKSSecret := &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "external-jwks-secret-name",
+						Namespace: installedInNamespace,
+					},
+					Data: map[string][]byte{
+						"externalActiveJWK": []byte(expectedJWK1),
+						"jwks":              []byte(`{"keys": [` + expectedJWK1 + `]}`),
+					},
+				}
+				signerCredentialsSecret := &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "signer-credentials-secret-name",
+						Namespace: installedInNamespace,
+					},
+					Type: "secrets.pinniped.dev/bearer-token",
+					Data: map[string][]byte{"token": []byte("some-token")},
+				}
 				badSecret := &corev1.Secret{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      "bad-secret-name",
@@ -338,8 +429,13 @@ func TestJWKSObserverControllerSync(t *testing.T) {
 				r.NoError(pinnipedInformerClient.Tracker().Add(federationDomainWithBadActiveJWKSecret))
 				r.NoError(pinnipedInformerClient.Tracker().Add(federationDomainWithGoodSecret1))
 				r.NoError(pinnipedInformerClient.Tracker().Add(federationDomainWithGoodSecret2))
+				r.NoError(pinnipedInformerClient.Tracker().Add(federationDomainWithExternalSigningKeys))
+				r.NoError(pinnipedInformerClient.Tracker().Add(federationDomainWithExternalSigningKeysAndOldSecret))
+				r.NoError(pinnipedInformerClient.Tracker().Add(federationDomainWithMissingExternalSigningCredentials))
 				r.NoError(kubeInformerClient.Tracker().Add(goodJWKSSecret1))
 				r.NoError(kubeInformerClient.Tracker().Add(goodJWKSSecret2))
+				r.NoError(kubeInformerClient.Tracker().Add(externalJWKSSecret))
+				r.NoError(kubeInformerClient.Tracker().Add(signerCredentialsSecret))
 				r.NoError(kubeInformerClient.Tracker().Add(badSecret))
 				r.NoError(kubeInformerClient.Tracker().Add(badJWKSSecret))
 				r.NoError(kubeInformerClient.Tracker().Add(badActiveJWKSecret))
@@ -366,14 +462,27 @@ func TestJWKSObserverControllerSync(t *testing.T) {
 				r.NoError(controllerlib.TestSync(t, subject, *syncContext))
 
 				r.True(issuerToJWKSSetter.setIssuerToJWKSMapWasCalled)
-				r.Len(issuerToJWKSSetter.issuerToJWKSMapReceived, 2)
-				r.Len(issuerToJWKSSetter.issuerToActiveJWKMapReceived, 2)
+				r.Len(issuerToJWKSSetter.issuerToJWKSMapReceived, 4)
+				r.Len(issuerToJWKSSetter.issuerToActiveJWKMapReceived, 4)
 
 				// the actual JWK should match the one from the test fixture that was put into the secret
 				requireJWKSJSON(expectedJWK1, issuerToJWKSSetter.issuerToJWKSMapReceived["https://issuer-with-good-secret1.com"])
 				requireJWKJSON(expectedJWK1, issuerToJWKSSetter.issuerToActiveJWKMapReceived["https://issuer-with-good-secret1.com"])
 				requireJWKSJSON(expectedJWK2, issuerToJWKSSetter.issuerToJWKSMapReceived["https://issuer-with-good-secret2.com"])
 				requireJWKJSON(expectedJWK2, issuerToJWKSSetter.issuerToActiveJWKMapReceived["https://issuer-with-good-secret2.com"])
+
+				// the previous issuer serves the same keys as the issuer
+				requireJWKSJSON(expectedJWK2, issuerToJWKSSetter.issuerToJWKSMapReceived["https://old-issuer-with-good-secret2.com"])
+				requireJWKJSON(expectedJWK2, issuerToJWKSSetter.issuerToActiveJWKMapReceived["https://old-issuer-with-good-secret2.com"])
+
+				// the active JWK of an external signing service signs using the service
+				requireJWKSJSON(expectedJWK1, issuerToJWKSSetter.issuerToJWKSMapReceived["https://issuer-with-external-signing-keys.com"])
+				externalActiveJWK := issuerToJWKSSetter.issuerToActiveJWKMapReceived["https://issuer-with-external-signing-keys.com"]
+				r.NotNil(externalActiveJWK)
+				r.Equal("ES256", externalActiveJWK.Algorithm)
+				signer, ok := externalActiveJWK.Key.(jose.OpaqueSigner)
+				r.True(ok)
+				requireJWKJSON(expectedJWK1, signer.Public())
 			})
 		})
 	}, spec.Parallel(), spec.Report(report.Terminal{}))
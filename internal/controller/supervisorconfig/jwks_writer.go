@@ -5,29 +5,39 @@ package supervisorconfig
 
 import (
 	"context"
+	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/go-jose/go-jose/v3"
 	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	corev1informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
 	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
 	supervisorclientset "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned"
 	configinformers "go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions/config/v1alpha1"
+	"go.pinniped.dev/internal/clientcredentials"
 	pinnipedcontroller "go.pinniped.dev/internal/controller"
 	"go.pinniped.dev/internal/controller/supervisorconfig/generator"
 	"go.pinniped.dev/internal/controllerlib"
+	"go.pinniped.dev/internal/federationdomain/externalsigner"
 	"go.pinniped.dev/internal/plog"
 )
 
@@ -41,19 +51,69 @@ const (
 	//
 	// Note! The value for this key will contain only public key material!
 	jwksKey = "jwks"
+	// nextJWKKey points to the private key which will replace the active JWK at the end of a key rotation.
+	// Its public key is already in the JWKS, so that all Supervisor pods publish it before any of them signs
+	// tokens with it.
+	//
+	// Note! The value for this key will contain private key material!
+	nextJWKKey = "nextJWK"
+	// nextJWKActivateAtKey points to the time, in RFC3339 format, after which the next JWK becomes the active JWK.
+	nextJWKActivateAtKey = "nextJWKActivateAt"
+	// activeJWKCreatedAtKey points to the time, in RFC3339 format, at which the active JWK started to sign tokens.
+	// When it is missing, the creation time of the Secret is used instead.
+	activeJWKCreatedAtKey = "activeJWKCreatedAt"
+	// retiredJWKsKey points to a JSON object which maps the key ID of each previous active JWK whose public key is
+	// still in the JWKS to the time at which it will be removed from the JWKS.
+	retiredJWKsKey = "retiredJWKs"
+	// externalActiveJWKKey points to the public key of the current key used for signing tokens, instead of
+	// activeJWKKey, when the signing keys of the FederationDomain are held by an external signing service.
+	//
+	// Note! The value for this key will contain only public key material!
+	externalActiveJWKKey = "externalActiveJWK"
 
 	jwksSecretTypeValue corev1.SecretType = "secrets.pinniped.dev/federation-domain-jwks"
 )
 
 const (
 	federationDomainKind = "FederationDomain"
-)
 
-// generateKey is stubbed out for the purpose of testing. The default behavior is to generate an EC key.
-var generateKey = generateECKey //nolint:gochecknoglobals
+	// RotateJWKSAnnotation may be added to the JWKS Secret of a FederationDomain to request a new signing key.
+	RotateJWKSAnnotation = "supervisor.pinniped.dev/rotate-jwks"
+
+	// defaultJWKSOverlap is how long the new key of a rotation is published before it is used to sign tokens, unless
+	// the FederationDomain configures it. It gives every Supervisor pod time to observe the new key, and clients time
+	// to refresh their cached JWKS.
+	defaultJWKSOverlap = 5 * time.Minute
 
-func generateECKey(r io.Reader) (any, error) {
-	return ecdsa.GenerateKey(elliptic.P256(), r)
+	// retiredJWKRetention is how long the public key of the previous active JWK stays in the JWKS after a rotation.
+	// It is longer than the lifetime of any token which the Supervisor signs, the longest of which are the ID tokens
+	// of OIDCClients, which may be configured to last for up to 30 minutes.
+	retiredJWKRetention = time.Hour
+
+	// jwksNotLeaderRetryInterval is how often the pods which are not the leader check whether they have become
+	// the leader while the Secret needs to be written.
+	jwksNotLeaderRetryInterval = 30 * time.Second
+
+	// externalJWKSRefreshInterval is how often the keys of an external signing service are fetched again, since the
+	// service rotates them.
+	externalJWKSRefreshInterval = time.Minute
+
+	jwkKeyID = "pinniped-supervisor-key"
+)
+
+// generateKey is stubbed out for the purpose of testing. The default behavior is to generate a key for the algorithm.
+var generateKey = generateKeyForAlgorithm //nolint:gochecknoglobals
+
+func generateKeyForAlgorithm(r io.Reader, alg jose.SignatureAlgorithm) (any, error) {
+	switch alg {
+	case jose.RS256:
+		return rsa.GenerateKey(r, 2048)
+	case jose.EdDSA:
+		_, key, err := ed25519.GenerateKey(r)
+		return key, err
+	default:
+		return ecdsa.GenerateKey(elliptic.P256(), r)
+	}
 }
 
 // jwkController holds the fields necessary for the JWKS controller to communicate with FederationDomains and
@@ -64,10 +124,15 @@ type jwksWriterController struct {
 	kubeClient               kubernetes.Interface
 	federationDomainInformer configinformers.FederationDomainInformer
 	secretInformer           corev1informers.SecretInformer
+	clock                    clock.PassiveClock
+	isLeader                 func() bool
 }
 
 // NewJWKSWriterController returns a controllerlib.Controller that ensures a FederationDomain has a corresponding
-// Secret that contains a valid active JWK and JWKS.
+// Secret that contains a valid active JWK and JWKS. It also rotates the active JWK according to the signing key
+// policy of the FederationDomain, or when the Secret has the RotateJWKSAnnotation. When the signing keys of the
+// FederationDomain are held by an external signing service, the Secret contains only the public keys of the service
+// instead. Only the leader writes the Secrets, as reported by isLeader.
 func NewJWKSWriterController(
 	jwksSecretLabels map[string]string,
 	kubeClient kubernetes.Interface,
@@ -75,6 +140,8 @@ func NewJWKSWriterController(
 	secretInformer corev1informers.SecretInformer,
 	federationDomainInformer configinformers.FederationDomainInformer,
 	withInformer pinnipedcontroller.WithInformerOptionFunc,
+	clock clock.PassiveClock,
+	isLeader func() bool,
 ) controllerlib.Controller {
 	isSecretToSync := func(obj metav1.Object) bool {
 		return generator.IsFederationDomainSecretOfType(obj, jwksSecretTypeValue)
@@ -89,6 +156,8 @@ func NewJWKSWriterController(
 				pinnipedClient:           pinnipedClient,
 				secretInformer:           secretInformer,
 				federationDomainInformer: federationDomainInformer,
+				clock:                    clock,
+				isLeader:                 isLeader,
 			},
 		},
 		// We want to be notified when a FederationDomain's secret gets updated or deleted. When this happens, we
@@ -131,17 +200,27 @@ func (c *jwksWriterController) Sync(ctx controllerlib.Context) error {
 		return nil
 	}
 
+	if external := externalSigningKeysFor(federationDomain); external != nil {
+		return c.syncExternalSigningKeys(ctx, federationDomain, external)
+	}
+
 	secretNeedsUpdate, err := c.secretNeedsUpdate(federationDomain)
 	if err != nil {
 		return fmt.Errorf("cannot determine secret status: %w", err)
 	}
 	if !secretNeedsUpdate {
-		// Secret is up to date - we are good to go.
+		// Secret is valid - we only need to continue a key rotation, if there is one.
 		plog.Debug(
 			"secret is up to date",
 			"federationdomain",
 			klog.KRef(ctx.Key.Namespace, ctx.Key.Name),
 		)
+		return c.maybeRotate(ctx, federationDomain)
+	}
+
+	if !c.isLeader() {
+		// The leader writes the secret, and this pod will observe it.
+		ctx.Queue.AddAfter(ctx.Key, jwksNotLeaderRetryInterval)
 		return nil
 	}
 
@@ -152,7 +231,16 @@ func (c *jwksWriterController) Sync(ctx controllerlib.Context) error {
 		return fmt.Errorf("cannot generate secret: %w", err)
 	}
 
-	if err := c.createOrUpdateSecret(ctx.Context, secret); err != nil {
+	return c.writeSecret(ctx.Context, federationDomain, secret)
+}
+
+// writeSecret creates or updates the secret, and ensures that the FederationDomain points to it.
+func (c *jwksWriterController) writeSecret(
+	ctx context.Context,
+	federationDomain *supervisorconfigv1alpha1.FederationDomain,
+	secret *corev1.Secret,
+) error {
+	if err := c.createOrUpdateSecret(ctx, secret); err != nil {
 		return fmt.Errorf("cannot create or update secret: %w", err)
 	}
 	plog.Debug("created/updated secret", "secret", klog.KObj(secret))
@@ -160,7 +248,7 @@ func (c *jwksWriterController) Sync(ctx controllerlib.Context) error {
 	// Ensure that the FederationDomain points to the secret.
 	newFederationDomain := federationDomain.DeepCopy()
 	newFederationDomain.Status.Secrets.JWKS.Name = secret.Name
-	if err := c.updateFederationDomainStatus(ctx.Context, newFederationDomain); err != nil {
+	if err := c.updateFederationDomainStatus(ctx, newFederationDomain); err != nil {
 		return fmt.Errorf("cannot update FederationDomain: %w", err)
 	}
 	plog.Debug("updated FederationDomain", "federationdomain", klog.KObj(newFederationDomain))
@@ -168,6 +256,91 @@ func (c *jwksWriterController) Sync(ctx controllerlib.Context) error {
 	return nil
 }
 
+// syncExternalSigningKeys publishes the public keys of the external signing service of the FederationDomain in its
+// Secret. The private keys stay in the signing service, which signs the tokens on request, so the Secret does not
+// contain any private key material. The keys are fetched again every externalJWKSRefreshInterval, and the Secret is
+// updated whenever the service returns different keys, e.g. because it started a key rotation.
+func (c *jwksWriterController) syncExternalSigningKeys(
+	ctx controllerlib.Context,
+	federationDomain *supervisorconfigv1alpha1.FederationDomain,
+	external *supervisorconfigv1alpha1.FederationDomainExternalSigningKeys,
+) error {
+	if !c.isLeader() {
+		// The leader fetches the keys and writes the secret, and this pod will observe it.
+		ctx.Queue.AddAfter(ctx.Key, jwksNotLeaderRetryInterval)
+		return nil
+	}
+
+	client, err := newExternalSignerClient(c.secretInformer.Lister(), federationDomain.Namespace, external)
+	if err != nil {
+		return fmt.Errorf("invalid external signing keys: %w", err)
+	}
+	keySet, err := client.Keys(ctx.Context)
+	if err != nil {
+		return fmt.Errorf("cannot get keys of external signing service: %w", err)
+	}
+	if alg := signingKeysPolicyFor(federationDomain).algorithm; keySet.Keys[0].Algorithm != string(alg) {
+		return fmt.Errorf("the active key of the external signing service has algorithm %q instead of %q",
+			keySet.Keys[0].Algorithm, alg)
+	}
+
+	activeJWKData, err := json.Marshal(keySet.Keys[0])
+	if err != nil {
+		return fmt.Errorf("cannot marshal active jwk: %w", err)
+	}
+	jwksData, err := json.Marshal(keySet)
+	if err != nil {
+		return fmt.Errorf("cannot marshal jwks: %w", err)
+	}
+	secret := c.newSecret(federationDomain, map[string][]byte{
+		externalActiveJWKKey: activeJWKData,
+		jwksKey:              jwksData,
+	})
+
+	// Only write when something changed, since the keys are fetched every externalJWKSRefreshInterval.
+	cachedSecret, err := c.secretInformer.Lister().Secrets(secret.Namespace).Get(secret.Name)
+	if err != nil || !secretIsUpToDate(cachedSecret, secret) || federationDomain.Status.Secrets.JWKS.Name != secret.Name {
+		if err := c.writeSecret(ctx.Context, federationDomain, secret); err != nil {
+			return err
+		}
+	}
+
+	ctx.Queue.AddAfter(ctx.Key, externalJWKSRefreshInterval)
+	return nil
+}
+
+// externalSigningKeysFor returns the external signing service of the FederationDomain, or nil when the
+// Supervisor generates its signing keys.
+func externalSigningKeysFor(
+	federationDomain *supervisorconfigv1alpha1.FederationDomain,
+) *supervisorconfigv1alpha1.FederationDomainExternalSigningKeys {
+	if federationDomain.Spec.SigningKeys == nil {
+		return nil
+	}
+	return federationDomain.Spec.SigningKeys.External
+}
+
+// newExternalSignerClient returns a client for the external signing service, which authenticates its requests using
+// the credentials in the Secret named by CredentialsSecretName, if any.
+func newExternalSignerClient(
+	secretLister corev1listers.SecretLister,
+	namespace string,
+	external *supervisorconfigv1alpha1.FederationDomainExternalSigningKeys,
+) (*externalsigner.Client, error) {
+	var credentials *clientcredentials.Credentials
+	if external.CredentialsSecretName != "" {
+		secret, err := secretLister.Secrets(namespace).Get(external.CredentialsSecretName)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get credentials secret %q: %w", external.CredentialsSecretName, err)
+		}
+		credentials, err = clientcredentials.FromSecret(secret)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return externalsigner.New(external.Endpoint, external.CertificateAuthorityData, credentials)
+}
+
 func (c *jwksWriterController) secretNeedsUpdate(federationDomain *supervisorconfigv1alpha1.FederationDomain) (bool, error) {
 	if federationDomain.Status.Secrets.JWKS.Name == "" {
 		// If the FederationDomain says it doesn't have a secret associated with it, then let's create one.
@@ -194,22 +367,12 @@ func (c *jwksWriterController) secretNeedsUpdate(federationDomain *supervisorcon
 }
 
 func (c *jwksWriterController) generateSecret(federationDomain *supervisorconfigv1alpha1.FederationDomain) (*corev1.Secret, error) {
-	// Note! This is where we could potentially add more handling of FederationDomain spec fields which tell us how
-	// this FederationDomain should sign and verify ID tokens (e.g., hardcoded token secret, gRPC
-	// connection to KMS, etc).
-	//
-	// For now, we just generate an new RSA keypair and put that in the secret.
+	// Generate a new keypair of the algorithm of the signing key policy and put that in the secret. The signing keys
+	// which are held by an external signing service are handled by syncExternalSigningKeys instead.
 
-	key, err := generateKey(rand.Reader)
+	jwk, err := generateJWK(jwkKeyID, signingKeysPolicyFor(federationDomain).algorithm)
 	if err != nil {
-		return nil, fmt.Errorf("cannot generate key: %w", err)
-	}
-
-	jwk := jose.JSONWebKey{
-		Key:       key,
-		KeyID:     "pinniped-supervisor-key",
-		Algorithm: "ES256",
-		Use:       "sig",
+		return nil, err
 	}
 	jwkData, err := json.Marshal(jwk)
 	if err != nil {
@@ -224,7 +387,14 @@ func (c *jwksWriterController) generateSecret(federationDomain *supervisorconfig
 		return nil, fmt.Errorf("cannot marshal jwks: %w", err)
 	}
 
-	s := corev1.Secret{
+	return c.newSecret(federationDomain, map[string][]byte{
+		activeJWKKey: jwkData,
+		jwksKey:      jwksData,
+	}), nil
+}
+
+func (c *jwksWriterController) newSecret(federationDomain *supervisorconfigv1alpha1.FederationDomain, data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      federationDomain.Name + "-jwks",
 			Namespace: federationDomain.Namespace,
@@ -237,14 +407,346 @@ func (c *jwksWriterController) generateSecret(federationDomain *supervisorconfig
 				}),
 			},
 		},
-		Data: map[string][]byte{
-			activeJWKKey: jwkData,
-			jwksKey:      jwksData,
-		},
+		Data: data,
 		Type: jwksSecretTypeValue,
 	}
+}
+
+// signingKeysPolicy is the signing key policy of a FederationDomain, with the defaults applied.
+type signingKeysPolicy struct {
+	algorithm jose.SignatureAlgorithm
+	// rotationInterval is zero when the keys are only rotated on request.
+	rotationInterval time.Duration
+	overlap          time.Duration
+}
+
+func signingKeysPolicyFor(federationDomain *supervisorconfigv1alpha1.FederationDomain) signingKeysPolicy {
+	policy := signingKeysPolicy{algorithm: jose.ES256, overlap: defaultJWKSOverlap}
+	spec := federationDomain.Spec.SigningKeys
+	if spec == nil {
+		return policy
+	}
+	if spec.Algorithm != "" {
+		policy.algorithm = jose.SignatureAlgorithm(spec.Algorithm)
+	}
+	if spec.RotationIntervalSeconds != nil {
+		policy.rotationInterval = time.Duration(*spec.RotationIntervalSeconds) * time.Second
+	}
+	if spec.OverlapSeconds != nil {
+		policy.overlap = time.Duration(*spec.OverlapSeconds) * time.Second
+	}
+	return policy
+}
+
+// maybeRotate continues the key rotation of the valid JWKS Secret of the FederationDomain. A rotation has two steps,
+// each of which is a single update of the Secret, so that no pod ever observes a partially rotated Secret:
+//  1. When the Secret has the RotateJWKSAnnotation, when the active JWK does not have the algorithm of the signing
+//     key policy of the FederationDomain, or when the rotation interval of the policy has passed, a new key is
+//     generated and stored as the next JWK, and its public key is added to the JWKS. The annotation is removed.
+//  2. After the overlap of the policy, the next JWK becomes the active JWK. The previous active JWK becomes a retired
+//     JWK, whose public key stays in the JWKS for retiredJWKRetention, so that the tokens which it signed can still
+//     be verified until they expire.
+func (c *jwksWriterController) maybeRotate(ctx controllerlib.Context, federationDomain *supervisorconfigv1alpha1.FederationDomain) error {
+	secret, err := c.secretInformer.Lister().Secrets(federationDomain.Namespace).Get(federationDomain.Status.Secrets.JWKS.Name)
+	if err != nil {
+		return fmt.Errorf("cannot get secret: %w", err)
+	}
+
+	policy := signingKeysPolicyFor(federationDomain)
+	state, err := parseRotationState(secret)
+	if err != nil {
+		return fmt.Errorf("cannot rotate jwks: %w", err)
+	}
+	plan := state.plan(policy, c.clock.Now())
+	if !plan.changesSecret() {
+		if plan.wait > 0 {
+			ctx.Queue.AddAfter(ctx.Key, plan.wait)
+		}
+		return nil
+	}
+
+	if !c.isLeader() {
+		// The leader rotates the key, and this pod will observe the result.
+		ctx.Queue.AddAfter(ctx.Key, jwksNotLeaderRetryInterval)
+		return nil
+	}
+
+	secretClient := c.kubeClient.CoreV1().Secrets(secret.Namespace)
+	var requeueAfter time.Duration
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		// Decide what to do based on the latest version of the Secret, since another pod may have been the
+		// leader since the cache was last updated. The update fails with a conflict if the Secret changed since.
+		latestSecret, err := secretClient.Get(ctx.Context, secret.Name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("cannot get secret: %w", err)
+		}
+		if !isValid(latestSecret) {
+			return nil // the next sync will replace it
+		}
+
+		state, err := parseRotationState(latestSecret)
+		if err != nil {
+			return err
+		}
+		now := c.clock.Now()
+		plan := state.plan(policy, now)
+		requeueAfter = plan.wait
+		if !plan.changesSecret() {
+			return nil
+		}
+		if err := state.apply(plan, policy, now); err != nil {
+			return err
+		}
+		if err := state.writeTo(latestSecret); err != nil {
+			return err
+		}
+		// Wait for whatever is due next after this update, e.g. the activation of a key which was just staged.
+		requeueAfter = state.plan(policy, now).wait
+
+		if _, err := secretClient.Update(ctx.Context, latestSecret, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		plog.Info("updated JWKS secret for key rotation",
+			"secret", klog.KObj(secret),
+			"federationdomain", klog.KObj(federationDomain),
+			"staged", plan.stage,
+			"activated", plan.activate,
+			"removedKeyIDs", plan.expired)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("cannot rotate jwks: %w", err)
+	}
+
+	if requeueAfter > 0 {
+		ctx.Queue.AddAfter(ctx.Key, requeueAfter)
+	}
+	return nil
+}
+
+// rotationState holds the data of a valid JWKS Secret which is relevant to key rotation.
+type rotationState struct {
+	activeJWK          jose.JSONWebKey
+	activeJWKCreatedAt time.Time
+	// nextJWK is nil when no rotation was started, or when the next JWK of the Secret is invalid.
+	nextJWK           *jose.JSONWebKey
+	nextJWKActivateAt time.Time
+	// retiredJWKs maps the key ID of each retired JWK to the time at which it is removed from the JWKS.
+	retiredJWKs       map[string]time.Time
+	jwks              jose.JSONWebKeySet
+	rotationRequested bool
+}
+
+func parseRotationState(secret *corev1.Secret) (*rotationState, error) {
+	state := &rotationState{
+		activeJWKCreatedAt: secret.CreationTimestamp.Time,
+		retiredJWKs:        map[string]time.Time{},
+	}
+	if err := json.Unmarshal(secret.Data[activeJWKKey], &state.activeJWK); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal active jwk: %w", err)
+	}
+	if err := json.Unmarshal(secret.Data[jwksKey], &state.jwks); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal jwks: %w", err)
+	}
+	if createdAt, err := time.Parse(time.RFC3339, string(secret.Data[activeJWKCreatedAtKey])); err == nil {
+		state.activeJWKCreatedAt = createdAt
+	}
+
+	_, state.rotationRequested = secret.Annotations[RotateJWKSAnnotation]
+	if nextJWKData, ok := secret.Data[nextJWKKey]; ok {
+		var nextJWK jose.JSONWebKey
+		if err := json.Unmarshal(nextJWKData, &nextJWK); err != nil || nextJWK.IsPublic() || !nextJWK.Valid() {
+			// Start over with a new key.
+			plog.Warning("jwks secret contains an invalid next jwk, generating a new one", "secret", klog.KObj(secret))
+			state.rotationRequested = true
+		} else {
+			state.nextJWK = &nextJWK
+			// An invalid time leaves the zero time, which activates the next JWK right away.
+			state.nextJWKActivateAt, _ = time.Parse(time.RFC3339, string(secret.Data[nextJWKActivateAtKey]))
+		}
+	}
+
+	if retiredJWKsData, ok := secret.Data[retiredJWKsKey]; ok {
+		if err := json.Unmarshal(retiredJWKsData, &state.retiredJWKs); err != nil {
+			// The keys which are not tracked are retired again, so they are kept for the full retention.
+			plog.Warning("jwks secret contains invalid retired jwks", "secret", klog.KObj(secret), "err", err)
+			state.retiredJWKs = map[string]time.Time{}
+		}
+	}
+
+	return state, nil
+}
 
-	return &s, nil
+// rotationPlan describes the changes to a JWKS Secret which are due.
+type rotationPlan struct {
+	// stage is true when a new key needs to be generated and published as the next JWK.
+	stage bool
+	// activate is true when the next JWK needs to become the active JWK.
+	activate bool
+	// untracked are the key IDs of the keys in the JWKS which are neither active, next nor retired, e.g. the previous
+	// active JWK of a Secret which was rotated by an older version of the Supervisor. They need to be retired.
+	untracked []string
+	// expired are the key IDs of the retired JWKs which need to be removed from the JWKS.
+	expired []string
+	// wait is how long until the next change is due, or zero when none is scheduled.
+	wait time.Duration
+}
+
+func (p *rotationPlan) changesSecret() bool {
+	return p.stage || p.activate || len(p.untracked) > 0 || len(p.expired) > 0
+}
+
+func (s *rotationState) plan(policy signingKeysPolicy, now time.Time) rotationPlan {
+	var p rotationPlan
+	waitUntil := func(t time.Time) {
+		if d := t.Sub(now); p.wait == 0 || d < p.wait {
+			p.wait = d
+		}
+	}
+
+	for _, key := range s.jwks.Keys {
+		removeAt, retired := s.retiredJWKs[key.KeyID]
+		switch {
+		case key.KeyID == s.activeJWK.KeyID || (s.nextJWK != nil && key.KeyID == s.nextJWK.KeyID):
+		case !retired:
+			p.untracked = append(p.untracked, key.KeyID)
+		case !now.Before(removeAt):
+			p.expired = append(p.expired, key.KeyID)
+		default:
+			waitUntil(removeAt)
+		}
+	}
+
+	switch {
+	case s.nextJWK != nil && s.nextJWK.Algorithm != string(policy.algorithm):
+		// The algorithm was changed during the rotation, so start over with a key of the new algorithm.
+		p.stage = true
+	case s.nextJWK != nil && !now.Before(s.nextJWKActivateAt):
+		p.activate = true
+	case s.nextJWK != nil:
+		waitUntil(s.nextJWKActivateAt)
+	case s.rotationRequested || s.activeJWK.Algorithm != string(policy.algorithm):
+		p.stage = true
+	case policy.rotationInterval > 0:
+		if rotateAt := s.activeJWKCreatedAt.Add(policy.rotationInterval); now.Before(rotateAt) {
+			waitUntil(rotateAt)
+		} else {
+			p.stage = true
+		}
+	}
+
+	return p
+}
+
+func (s *rotationState) apply(p rotationPlan, policy signingKeysPolicy, now time.Time) error {
+	for _, keyID := range p.untracked {
+		s.retiredJWKs[keyID] = now.Add(retiredJWKRetention).UTC()
+	}
+	for _, keyID := range p.expired {
+		delete(s.retiredJWKs, keyID)
+	}
+
+	if p.activate {
+		s.retiredJWKs[s.activeJWK.KeyID] = now.Add(retiredJWKRetention).UTC()
+		s.activeJWK, s.nextJWK = *s.nextJWK, nil
+		s.activeJWKCreatedAt = now
+		// When the annotation was added again during this rotation, the update of the Secret starts another rotation.
+	}
+
+	if p.stage {
+		key, err := generateJWK(jwkKeyID, policy.algorithm)
+		if err != nil {
+			return err
+		}
+		// Each key of a rotation needs its own key ID, so that clients can tell which key signed a token.
+		thumbprint, err := key.Thumbprint(crypto.SHA256)
+		if err != nil {
+			return fmt.Errorf("cannot compute jwk thumbprint: %w", err)
+		}
+		key.KeyID = jwkKeyID + "-" + base64.RawURLEncoding.EncodeToString(thumbprint)
+
+		s.nextJWK = &key
+		s.nextJWKActivateAt = now.Add(policy.overlap)
+		s.rotationRequested = false
+	}
+
+	return nil
+}
+
+// writeTo stores the state in the Secret. The JWKS holds the public keys of the active JWK, of the next JWK, if any,
+// and of the retired JWKs, in that order.
+func (s *rotationState) writeTo(secret *corev1.Secret) error {
+	keys := []jose.JSONWebKey{s.activeJWK.Public()}
+	if s.nextJWK != nil {
+		keys = append(keys, s.nextJWK.Public())
+	}
+	retiredJWKs := map[string]time.Time{}
+	for _, key := range s.jwks.Keys {
+		if removeAt, ok := s.retiredJWKs[key.KeyID]; ok && key.KeyID != s.activeJWK.KeyID {
+			keys = append(keys, key)
+			retiredJWKs[key.KeyID] = removeAt
+		}
+	}
+	s.jwks = jose.JSONWebKeySet{Keys: keys}
+	s.retiredJWKs = retiredJWKs
+
+	activeJWKData, err := json.Marshal(s.activeJWK)
+	if err != nil {
+		return fmt.Errorf("cannot marshal active jwk: %w", err)
+	}
+	jwksData, err := json.Marshal(s.jwks)
+	if err != nil {
+		return fmt.Errorf("cannot marshal jwks: %w", err)
+	}
+	secret.Data[activeJWKKey] = activeJWKData
+	secret.Data[jwksKey] = jwksData
+
+	if !s.activeJWKCreatedAt.IsZero() {
+		secret.Data[activeJWKCreatedAtKey] = []byte(s.activeJWKCreatedAt.UTC().Format(time.RFC3339))
+	}
+
+	if s.nextJWK != nil {
+		nextJWKData, err := json.Marshal(s.nextJWK)
+		if err != nil {
+			return fmt.Errorf("cannot marshal next jwk: %w", err)
+		}
+		secret.Data[nextJWKKey] = nextJWKData
+		secret.Data[nextJWKActivateAtKey] = []byte(s.nextJWKActivateAt.UTC().Format(time.RFC3339))
+	} else {
+		delete(secret.Data, nextJWKKey)
+		delete(secret.Data, nextJWKActivateAtKey)
+	}
+
+	if len(s.retiredJWKs) > 0 {
+		retiredJWKsData, err := json.Marshal(s.retiredJWKs)
+		if err != nil {
+			return fmt.Errorf("cannot marshal retired jwks: %w", err)
+		}
+		secret.Data[retiredJWKsKey] = retiredJWKsData
+	} else {
+		delete(secret.Data, retiredJWKsKey)
+	}
+
+	if !s.rotationRequested {
+		delete(secret.Annotations, RotateJWKSAnnotation)
+	}
+
+	return nil
+}
+
+// generateJWK generates a new private JWK for signing tokens using the algorithm.
+func generateJWK(keyID string, alg jose.SignatureAlgorithm) (jose.JSONWebKey, error) {
+	key, err := generateKey(rand.Reader, alg)
+	if err != nil {
+		return jose.JSONWebKey{}, fmt.Errorf("cannot generate key: %w", err)
+	}
+
+	return jose.JSONWebKey{
+		Key:       key,
+		KeyID:     keyID,
+		Algorithm: string(alg),
+		Use:       "sig",
+	}, nil
 }
 
 func (c *jwksWriterController) createOrUpdateSecret(
@@ -270,8 +772,7 @@ func (c *jwksWriterController) createOrUpdateSecret(
 
 		// New secret already exists, so ensure it is up to date.
 
-		if isValid(oldSecret) {
-			// If the secret already has valid JWK's, then we are good to go and we don't need an update.
+		if secretIsUpToDate(oldSecret, newSecret) {
 			return nil
 		}
 
@@ -304,6 +805,16 @@ func (c *jwksWriterController) updateFederationDomainStatus(
 	})
 }
 
+// secretIsUpToDate returns whether the existing secret does not need to be replaced by the new secret. A secret
+// which already has valid JWKs is kept, since its keys are only rotated by maybeRotate, but a secret which holds the
+// keys of an external signing service is replaced whenever the service returns different keys.
+func secretIsUpToDate(oldSecret, newSecret *corev1.Secret) bool {
+	if _, external := newSecret.Data[externalActiveJWKKey]; external {
+		return oldSecret.Type == jwksSecretTypeValue && apiequality.Semantic.DeepEqual(oldSecret.Data, newSecret.Data)
+	}
+	return isValid(oldSecret)
+}
+
 // isValid returns whether the provided secret contains a valid active JWK and verification JWKS.
 func isValid(secret *corev1.Secret) bool {
 	if secret.Type != jwksSecretTypeValue {
@@ -7,12 +7,17 @@ import (
 	"bytes"
 	"context"
 	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"io"
+	"net/http"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/go-jose/go-jose/v3"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -21,12 +26,15 @@ import (
 	k8sinformers "k8s.io/client-go/informers"
 	kubernetesfake "k8s.io/client-go/kubernetes/fake"
 	kubetesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
 
 	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
 	supervisorfake "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/fake"
 	supervisorinformers "go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions"
 	"go.pinniped.dev/internal/controllerlib"
 	"go.pinniped.dev/internal/testutil"
+	"go.pinniped.dev/internal/testutil/tlsserver"
 )
 
 func TestJWKSWriterControllerFilterSecret(t *testing.T) {
@@ -186,6 +194,8 @@ func TestJWKSWriterControllerFilterSecret(t *testing.T) {
 				secretInformer,
 				federationDomainInformer,
 				withInformer.WithInformer,
+				nil, // clock, not needed
+				nil, // isLeader, not needed
 			)
 
 			unrelated := corev1.Secret{}
@@ -239,6 +249,8 @@ func TestJWKSWriterControllerFilterFederationDomain(t *testing.T) {
 				secretInformer,
 				federationDomainInformer,
 				withInformer.WithInformer,
+				nil, // clock, not needed
+				nil, // isLeader, not needed
 			)
 
 			unrelated := supervisorconfigv1alpha1.FederationDomain{}
@@ -323,6 +335,86 @@ func TestJWKSWriterControllerSync(t *testing.T) {
 
 	goodSecret := newSecret("testdata/good-jwk.json", "testdata/good-jwks.json")
 
+	frozenNow := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	secretWithRotationRequested := goodSecret.DeepCopy()
+	secretWithRotationRequested.Annotations = map[string]string{"supervisor.pinniped.dev/rotate-jwks": ""}
+
+	nextJWK, err := json.Marshal(jose.JSONWebKey{Key: goodKey, KeyID: "pinniped-supervisor-key-next", Algorithm: "ES256", Use: "sig"})
+	require.NoError(t, err)
+	secretWithRotationStarted := func(activateAt time.Time) *corev1.Secret {
+		s := goodSecret.DeepCopy()
+		s.Data["nextJWK"] = nextJWK
+		s.Data["nextJWKActivateAt"] = []byte(activateAt.Format(time.RFC3339))
+		return s
+	}
+
+	secretWithActiveJWKCreatedAt := func(createdAt time.Time) *corev1.Secret {
+		s := goodSecret.DeepCopy()
+		s.Data["activeJWKCreatedAt"] = []byte(createdAt.Format(time.RFC3339))
+		return s
+	}
+
+	var goodJWKS jose.JSONWebKeySet
+	require.NoError(t, json.Unmarshal(goodSecret.Data["jwks"], &goodJWKS))
+	var otherPublicJWK jose.JSONWebKey
+	require.NoError(t, json.Unmarshal(readJWKJSON(t, "testdata/public-jwk2.json"), &otherPublicJWK))
+	jwksWithOtherKey, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{goodJWKS.Keys[0], otherPublicJWK}})
+	require.NoError(t, err)
+	secretWithRetiredKey := func(retiredJWKs string) *corev1.Secret {
+		s := goodSecret.DeepCopy()
+		s.Data["jwks"] = jwksWithOtherKey
+		if retiredJWKs != "" {
+			s.Data["retiredJWKs"] = []byte(retiredJWKs)
+		}
+		return s
+	}
+
+	federationDomainWithSigningKeys := func(signingKeys *supervisorconfigv1alpha1.FederationDomainSigningKeys) *supervisorconfigv1alpha1.FederationDomain {
+		fd := goodFederationDomainWithStatus.DeepCopy()
+		fd.Spec.SigningKeys = signingKeys
+		return fd
+	}
+
+	signingService, signingServiceCA := tlsserver.TestServerIPv4(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/good/keys":
+		case "/authenticated/keys":
+			if r.Header.Get("Authorization") != "Bearer some-token" {
+				http.Error(w, "nope", http.StatusUnauthorized)
+				return
+			}
+		default:
+			http.Error(w, "nope", http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(readJWKJSON(t, "testdata/good-jwks.json"))
+	}), nil)
+	federationDomainWithExternalSigningKeys := func(path string, alg supervisorconfigv1alpha1.FederationDomainSigningAlgorithm) *supervisorconfigv1alpha1.FederationDomain {
+		return federationDomainWithSigningKeys(&supervisorconfigv1alpha1.FederationDomainSigningKeys{
+			Algorithm: alg,
+			External: &supervisorconfigv1alpha1.FederationDomainExternalSigningKeys{
+				Endpoint:                 signingService.URL + path,
+				CertificateAuthorityData: base64.StdEncoding.EncodeToString(signingServiceCA),
+			},
+		})
+	}
+	federationDomainWithExternalSigningCredentials := func(path string, credentialsSecretName string) *supervisorconfigv1alpha1.FederationDomain {
+		fd := federationDomainWithExternalSigningKeys(path, "")
+		fd.Spec.SigningKeys.External.CredentialsSecretName = credentialsSecretName
+		return fd
+	}
+	signingServiceCredentialsSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "signing-service-credentials", Namespace: namespace},
+		Type:       "secrets.pinniped.dev/bearer-token",
+		Data:       map[string][]byte{"token": []byte("some-token")},
+	}
+	externalSecret := newSecret("", "testdata/good-jwks.json")
+	externalSecret.Data["externalActiveJWK"] = readJWKJSON(t, "testdata/public-jwk.json")
+	requireExternalSecret := func(t *testing.T, secret *corev1.Secret) {
+		require.Equal(t, externalSecret.Data, secret.Data)
+	}
+
 	secretWithWrongType := newSecret("testdata/good-jwk.json", "testdata/good-jwks.json")
 	secretWithWrongType.Type = "not-the-right-type"
 
@@ -333,10 +425,14 @@ func TestJWKSWriterControllerSync(t *testing.T) {
 		configKubeClient            func(*kubernetesfake.Clientset)
 		configPinnipedClient        func(*supervisorfake.Clientset)
 		federationDomains           []*supervisorconfigv1alpha1.FederationDomain
+		notLeader                   bool
 		generateKeyErr              error
 		wantGenerateKeyCount        int
+		wantGenerateKeyAlgorithm    jose.SignatureAlgorithm
 		wantSecretActions           []kubetesting.Action
 		wantFederationDomainActions []kubetesting.Action
+		wantSecret                  func(t *testing.T, secret *corev1.Secret)
+		wantRequeueAfter            time.Duration
 		wantError                   string
 	}{
 		{
@@ -398,6 +494,218 @@ func TestJWKSWriterControllerSync(t *testing.T) {
 				goodSecret,
 			},
 		},
+		{
+			name: "new federationDomain with no secret when not the leader",
+			key:  controllerlib.Key{Namespace: goodFederationDomain.Namespace, Name: goodFederationDomain.Name},
+			federationDomains: []*supervisorconfigv1alpha1.FederationDomain{
+				goodFederationDomain,
+			},
+			notLeader:                   true,
+			wantSecretActions:           []kubetesting.Action{},
+			wantFederationDomainActions: []kubetesting.Action{},
+			wantRequeueAfter:            30 * time.Second,
+		},
+		{
+			name: "existing secret with rotation requested",
+			key:  controllerlib.Key{Namespace: goodFederationDomain.Namespace, Name: goodFederationDomain.Name},
+			federationDomains: []*supervisorconfigv1alpha1.FederationDomain{
+				goodFederationDomainWithStatus,
+			},
+			secrets: []*corev1.Secret{
+				secretWithRotationRequested,
+			},
+			wantGenerateKeyCount:        1,
+			wantFederationDomainActions: []kubetesting.Action{},
+			wantSecret: func(t *testing.T, secret *corev1.Secret) {
+				require.Empty(t, secret.Annotations)
+				require.Equal(t, goodSecret.Data["activeJWK"], secret.Data["activeJWK"])
+				require.Equal(t, "2024-01-02T03:09:05Z", string(secret.Data["nextJWKActivateAt"]))
+
+				var next jose.JSONWebKey
+				require.NoError(t, json.Unmarshal(secret.Data["nextJWK"], &next))
+				require.False(t, next.IsPublic())
+				require.Regexp(t, "^pinniped-supervisor-key-[A-Za-z0-9_-]{43}$", next.KeyID)
+				requireJWKS(t, secret.Data["jwks"], "pinniped-supervisor-key", next.KeyID)
+			},
+			wantRequeueAfter: 5 * time.Minute,
+		},
+		{
+			name: "existing secret with rotation requested when not the leader",
+			key:  controllerlib.Key{Namespace: goodFederationDomain.Namespace, Name: goodFederationDomain.Name},
+			federationDomains: []*supervisorconfigv1alpha1.FederationDomain{
+				goodFederationDomainWithStatus,
+			},
+			secrets: []*corev1.Secret{
+				secretWithRotationRequested,
+			},
+			notLeader:         true,
+			wantSecretActions: []kubetesting.Action{},
+			wantRequeueAfter:  30 * time.Second,
+		},
+		{
+			name: "existing secret with rotation started which is not due yet",
+			key:  controllerlib.Key{Namespace: goodFederationDomain.Namespace, Name: goodFederationDomain.Name},
+			federationDomains: []*supervisorconfigv1alpha1.FederationDomain{
+				goodFederationDomainWithStatus,
+			},
+			secrets: []*corev1.Secret{
+				secretWithRotationStarted(frozenNow.Add(time.Minute)),
+			},
+			wantSecretActions: []kubetesting.Action{},
+			wantRequeueAfter:  time.Minute,
+		},
+		{
+			name: "existing secret with rotation started which is due",
+			key:  controllerlib.Key{Namespace: goodFederationDomain.Namespace, Name: goodFederationDomain.Name},
+			federationDomains: []*supervisorconfigv1alpha1.FederationDomain{
+				goodFederationDomainWithStatus,
+			},
+			secrets: []*corev1.Secret{
+				secretWithRotationStarted(frozenNow.Add(-time.Second)),
+			},
+			wantFederationDomainActions: []kubetesting.Action{},
+			wantSecret: func(t *testing.T, secret *corev1.Secret) {
+				require.Equal(t, nextJWK, secret.Data["activeJWK"])
+				require.NotContains(t, secret.Data, "nextJWK")
+				require.NotContains(t, secret.Data, "nextJWKActivateAt")
+				require.Equal(t, "2024-01-02T03:04:05Z", string(secret.Data["activeJWKCreatedAt"]))
+				require.JSONEq(t, `{"pinniped-supervisor-key": "2024-01-02T04:04:05Z"}`, string(secret.Data["retiredJWKs"]))
+				requireJWKS(t, secret.Data["jwks"], "pinniped-supervisor-key-next", "pinniped-supervisor-key")
+			},
+			wantRequeueAfter: time.Hour,
+		},
+		{
+			name: "existing secret with a different algorithm than the signing key policy",
+			key:  controllerlib.Key{Namespace: goodFederationDomain.Namespace, Name: goodFederationDomain.Name},
+			federationDomains: []*supervisorconfigv1alpha1.FederationDomain{
+				federationDomainWithSigningKeys(&supervisorconfigv1alpha1.FederationDomainSigningKeys{
+					Algorithm: supervisorconfigv1alpha1.FederationDomainSigningAlgorithmRS256,
+				}),
+			},
+			secrets: []*corev1.Secret{
+				goodSecret,
+			},
+			wantGenerateKeyCount:        1,
+			wantGenerateKeyAlgorithm:    jose.RS256,
+			wantFederationDomainActions: []kubetesting.Action{},
+			wantSecret: func(t *testing.T, secret *corev1.Secret) {
+				require.Equal(t, goodSecret.Data["activeJWK"], secret.Data["activeJWK"])
+				require.Equal(t, "2024-01-02T03:09:05Z", string(secret.Data["nextJWKActivateAt"]))
+
+				var next jose.JSONWebKey
+				require.NoError(t, json.Unmarshal(secret.Data["nextJWK"], &next))
+				require.Equal(t, "RS256", next.Algorithm)
+				requireJWKS(t, secret.Data["jwks"], "pinniped-supervisor-key", next.KeyID)
+			},
+			wantRequeueAfter: 5 * time.Minute,
+		},
+		{
+			name: "existing secret with rotation started for a different algorithm than the signing key policy",
+			key:  controllerlib.Key{Namespace: goodFederationDomain.Namespace, Name: goodFederationDomain.Name},
+			federationDomains: []*supervisorconfigv1alpha1.FederationDomain{
+				federationDomainWithSigningKeys(&supervisorconfigv1alpha1.FederationDomainSigningKeys{
+					Algorithm:      supervisorconfigv1alpha1.FederationDomainSigningAlgorithmEdDSA,
+					OverlapSeconds: ptr.To[int32](60),
+				}),
+			},
+			secrets: []*corev1.Secret{
+				secretWithRotationStarted(frozenNow.Add(-time.Second)),
+			},
+			wantGenerateKeyCount:        1,
+			wantGenerateKeyAlgorithm:    jose.EdDSA,
+			wantFederationDomainActions: []kubetesting.Action{},
+			wantSecret: func(t *testing.T, secret *corev1.Secret) {
+				require.Equal(t, goodSecret.Data["activeJWK"], secret.Data["activeJWK"])
+				require.Equal(t, "2024-01-02T03:05:05Z", string(secret.Data["nextJWKActivateAt"]))
+
+				var next jose.JSONWebKey
+				require.NoError(t, json.Unmarshal(secret.Data["nextJWK"], &next))
+				require.Equal(t, "EdDSA", next.Algorithm)
+				requireJWKS(t, secret.Data["jwks"], "pinniped-supervisor-key", next.KeyID)
+			},
+			wantRequeueAfter: time.Minute,
+		},
+		{
+			name: "existing secret whose rotation interval has passed",
+			key:  controllerlib.Key{Namespace: goodFederationDomain.Namespace, Name: goodFederationDomain.Name},
+			federationDomains: []*supervisorconfigv1alpha1.FederationDomain{
+				federationDomainWithSigningKeys(&supervisorconfigv1alpha1.FederationDomainSigningKeys{
+					RotationIntervalSeconds: ptr.To[int32](3600),
+					OverlapSeconds:          ptr.To[int32](60),
+				}),
+			},
+			secrets: []*corev1.Secret{
+				secretWithActiveJWKCreatedAt(frozenNow.Add(-2 * time.Hour)),
+			},
+			wantGenerateKeyCount:        1,
+			wantGenerateKeyAlgorithm:    jose.ES256,
+			wantFederationDomainActions: []kubetesting.Action{},
+			wantSecret: func(t *testing.T, secret *corev1.Secret) {
+				require.Equal(t, goodSecret.Data["activeJWK"], secret.Data["activeJWK"])
+				require.Equal(t, "2024-01-02T01:04:05Z", string(secret.Data["activeJWKCreatedAt"]))
+				require.Equal(t, "2024-01-02T03:05:05Z", string(secret.Data["nextJWKActivateAt"]))
+				require.Contains(t, secret.Data, "nextJWK")
+			},
+			wantRequeueAfter: time.Minute,
+		},
+		{
+			name: "existing secret whose rotation interval has not passed yet",
+			key:  controllerlib.Key{Namespace: goodFederationDomain.Namespace, Name: goodFederationDomain.Name},
+			federationDomains: []*supervisorconfigv1alpha1.FederationDomain{
+				federationDomainWithSigningKeys(&supervisorconfigv1alpha1.FederationDomainSigningKeys{
+					RotationIntervalSeconds: ptr.To[int32](3600),
+				}),
+			},
+			secrets: []*corev1.Secret{
+				secretWithActiveJWKCreatedAt(frozenNow.Add(-30 * time.Minute)),
+			},
+			wantSecretActions: []kubetesting.Action{},
+			wantRequeueAfter:  30 * time.Minute,
+		},
+		{
+			name: "existing secret with a retired key which is not due for removal yet",
+			key:  controllerlib.Key{Namespace: goodFederationDomain.Namespace, Name: goodFederationDomain.Name},
+			federationDomains: []*supervisorconfigv1alpha1.FederationDomain{
+				goodFederationDomainWithStatus,
+			},
+			secrets: []*corev1.Secret{
+				secretWithRetiredKey(`{"pinniped-supervisor-key2": "2024-01-02T03:14:05Z"}`),
+			},
+			wantSecretActions: []kubetesting.Action{},
+			wantRequeueAfter:  10 * time.Minute,
+		},
+		{
+			name: "existing secret with a retired key which is due for removal",
+			key:  controllerlib.Key{Namespace: goodFederationDomain.Namespace, Name: goodFederationDomain.Name},
+			federationDomains: []*supervisorconfigv1alpha1.FederationDomain{
+				goodFederationDomainWithStatus,
+			},
+			secrets: []*corev1.Secret{
+				secretWithRetiredKey(`{"pinniped-supervisor-key2": "2024-01-02T03:04:04Z"}`),
+			},
+			wantFederationDomainActions: []kubetesting.Action{},
+			wantSecret: func(t *testing.T, secret *corev1.Secret) {
+				require.Equal(t, goodSecret.Data["activeJWK"], secret.Data["activeJWK"])
+				require.NotContains(t, secret.Data, "retiredJWKs")
+				requireJWKS(t, secret.Data["jwks"], "pinniped-supervisor-key")
+			},
+		},
+		{
+			name: "existing secret with a previous key which is not retired yet",
+			key:  controllerlib.Key{Namespace: goodFederationDomain.Namespace, Name: goodFederationDomain.Name},
+			federationDomains: []*supervisorconfigv1alpha1.FederationDomain{
+				goodFederationDomainWithStatus,
+			},
+			secrets: []*corev1.Secret{
+				secretWithRetiredKey(""),
+			},
+			wantFederationDomainActions: []kubetesting.Action{},
+			wantSecret: func(t *testing.T, secret *corev1.Secret) {
+				require.JSONEq(t, `{"pinniped-supervisor-key2": "2024-01-02T04:04:05Z"}`, string(secret.Data["retiredJWKs"]))
+				requireJWKS(t, secret.Data["jwks"], "pinniped-supervisor-key", "pinniped-supervisor-key2")
+			},
+			wantRequeueAfter: time.Hour,
+		},
 		{
 			name: "deleted federationDomain",
 			key:  controllerlib.Key{Namespace: goodFederationDomain.Namespace, Name: goodFederationDomain.Name},
@@ -583,6 +891,113 @@ func TestJWKSWriterControllerSync(t *testing.T) {
 				kubetesting.NewGetAction(federationDomainGVR, namespace, goodFederationDomain.Name),
 			},
 		},
+		{
+			name: "external signing keys with no secret",
+			key:  controllerlib.Key{Namespace: goodFederationDomain.Namespace, Name: goodFederationDomain.Name},
+			federationDomains: []*supervisorconfigv1alpha1.FederationDomain{
+				federationDomainWithExternalSigningKeys("/good", ""),
+			},
+			wantSecretActions: []kubetesting.Action{
+				kubetesting.NewGetAction(secretGVR, namespace, goodSecret.Name),
+				kubetesting.NewCreateAction(secretGVR, namespace, externalSecret),
+			},
+			wantFederationDomainActions: []kubetesting.Action{
+				kubetesting.NewGetAction(federationDomainGVR, namespace, goodFederationDomain.Name),
+			},
+			wantSecret:       requireExternalSecret,
+			wantRequeueAfter: time.Minute,
+		},
+		{
+			name: "external signing keys replace the private key of an existing secret",
+			key:  controllerlib.Key{Namespace: goodFederationDomain.Namespace, Name: goodFederationDomain.Name},
+			federationDomains: []*supervisorconfigv1alpha1.FederationDomain{
+				federationDomainWithExternalSigningKeys("/good", "ES256"),
+			},
+			secrets: []*corev1.Secret{
+				goodSecret,
+			},
+			wantSecretActions: []kubetesting.Action{
+				kubetesting.NewGetAction(secretGVR, namespace, goodSecret.Name),
+				kubetesting.NewUpdateAction(secretGVR, namespace, externalSecret),
+			},
+			wantSecret:       requireExternalSecret,
+			wantRequeueAfter: time.Minute,
+		},
+		{
+			name: "external signing keys which are already in the secret",
+			key:  controllerlib.Key{Namespace: goodFederationDomain.Namespace, Name: goodFederationDomain.Name},
+			federationDomains: []*supervisorconfigv1alpha1.FederationDomain{
+				federationDomainWithExternalSigningKeys("/good", ""),
+			},
+			secrets: []*corev1.Secret{
+				externalSecret,
+			},
+			wantSecretActions:           []kubetesting.Action{},
+			wantFederationDomainActions: []kubetesting.Action{},
+			wantRequeueAfter:            time.Minute,
+		},
+		{
+			name: "external signing keys when not the leader",
+			key:  controllerlib.Key{Namespace: goodFederationDomain.Namespace, Name: goodFederationDomain.Name},
+			federationDomains: []*supervisorconfigv1alpha1.FederationDomain{
+				federationDomainWithExternalSigningKeys("/good", ""),
+			},
+			notLeader:                   true,
+			wantSecretActions:           []kubetesting.Action{},
+			wantFederationDomainActions: []kubetesting.Action{},
+			wantRequeueAfter:            30 * time.Second,
+		},
+		{
+			name: "external signing keys from a service which requires credentials",
+			key:  controllerlib.Key{Namespace: goodFederationDomain.Namespace, Name: goodFederationDomain.Name},
+			federationDomains: []*supervisorconfigv1alpha1.FederationDomain{
+				federationDomainWithExternalSigningCredentials("/authenticated", signingServiceCredentialsSecret.Name),
+			},
+			secrets: []*corev1.Secret{
+				signingServiceCredentialsSecret,
+			},
+			wantSecretActions: []kubetesting.Action{
+				kubetesting.NewGetAction(secretGVR, namespace, goodSecret.Name),
+				kubetesting.NewCreateAction(secretGVR, namespace, externalSecret),
+			},
+			wantFederationDomainActions: []kubetesting.Action{
+				kubetesting.NewGetAction(federationDomainGVR, namespace, goodFederationDomain.Name),
+			},
+			wantSecret:       requireExternalSecret,
+			wantRequeueAfter: time.Minute,
+		},
+		{
+			name: "external signing keys with a missing credentials secret",
+			key:  controllerlib.Key{Namespace: goodFederationDomain.Namespace, Name: goodFederationDomain.Name},
+			federationDomains: []*supervisorconfigv1alpha1.FederationDomain{
+				federationDomainWithExternalSigningCredentials("/authenticated", "missing-secret"),
+			},
+			wantError: `invalid external signing keys: cannot get credentials secret "missing-secret": secret "missing-secret" not found`,
+		},
+		{
+			name: "external signing keys without the credentials which the service requires",
+			key:  controllerlib.Key{Namespace: goodFederationDomain.Namespace, Name: goodFederationDomain.Name},
+			federationDomains: []*supervisorconfigv1alpha1.FederationDomain{
+				federationDomainWithExternalSigningKeys("/authenticated", ""),
+			},
+			wantError: "cannot get keys of external signing service: keys request failed: unexpected http response status: 401 Unauthorized",
+		},
+		{
+			name: "external signing service fails",
+			key:  controllerlib.Key{Namespace: goodFederationDomain.Namespace, Name: goodFederationDomain.Name},
+			federationDomains: []*supervisorconfigv1alpha1.FederationDomain{
+				federationDomainWithExternalSigningKeys("/broken", ""),
+			},
+			wantError: "cannot get keys of external signing service: keys request failed: unexpected http response status: 500 Internal Server Error",
+		},
+		{
+			name: "external signing service has a key of another algorithm",
+			key:  controllerlib.Key{Namespace: goodFederationDomain.Namespace, Name: goodFederationDomain.Name},
+			federationDomains: []*supervisorconfigv1alpha1.FederationDomain{
+				federationDomainWithExternalSigningKeys("/good", "RS256"),
+			},
+			wantError: `the active key of the external signing service has algorithm "ES256" instead of "RS256"`,
+		},
 		{
 			name: "generate key fails",
 			key:  controllerlib.Key{Namespace: goodFederationDomain.Namespace, Name: goodFederationDomain.Name},
@@ -665,8 +1080,10 @@ func TestJWKSWriterControllerSync(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			// We shouldn't run this test in parallel since it messes with a global function (generateKey).
 			generateKeyCount := 0
-			generateKey = func(_ io.Reader) (any, error) {
+			var generateKeyAlgorithm jose.SignatureAlgorithm
+			generateKey = func(_ io.Reader, alg jose.SignatureAlgorithm) (any, error) {
 				generateKeyCount++
+				generateKeyAlgorithm = alg
 				return goodKey, test.generateKeyErr
 			}
 
@@ -712,6 +1129,8 @@ func TestJWKSWriterControllerSync(t *testing.T) {
 				kubeInformers.Core().V1().Secrets(),
 				pinnipedInformers.Config().V1alpha1().FederationDomains(),
 				controllerlib.WithInformer,
+				clocktesting.NewFakeClock(frozenNow),
+				func() bool { return !test.notLeader },
 			)
 
 			// Must start informers before calling TestRunSynchronously().
@@ -719,9 +1138,11 @@ func TestJWKSWriterControllerSync(t *testing.T) {
 			pinnipedInformers.Start(ctx.Done())
 			controllerlib.TestRunSynchronously(t, c)
 
+			queue := &jwksTestQueue{}
 			err := controllerlib.TestSync(t, c, controllerlib.Context{
 				Context: ctx,
 				Key:     test.key,
+				Queue:   queue,
 			})
 			if test.wantError != "" {
 				require.EqualError(t, err, test.wantError)
@@ -730,6 +1151,10 @@ func TestJWKSWriterControllerSync(t *testing.T) {
 			require.NoError(t, err)
 
 			require.Equal(t, test.wantGenerateKeyCount, generateKeyCount)
+			if test.wantGenerateKeyAlgorithm != "" {
+				require.Equal(t, test.wantGenerateKeyAlgorithm, generateKeyAlgorithm)
+			}
+			require.Equal(t, test.wantRequeueAfter, queue.duration)
 
 			if test.wantSecretActions != nil {
 				require.Equal(t, test.wantSecretActions, kubeAPIClient.Actions())
@@ -737,10 +1162,38 @@ func TestJWKSWriterControllerSync(t *testing.T) {
 			if test.wantFederationDomainActions != nil {
 				require.Equal(t, test.wantFederationDomainActions, pinnipedAPIClient.Actions())
 			}
+			if test.wantSecret != nil {
+				secret, err := kubeAPIClient.CoreV1().Secrets(namespace).Get(ctx, goodSecret.Name, metav1.GetOptions{})
+				require.NoError(t, err)
+				test.wantSecret(t, secret)
+			}
 		})
 	}
 }
 
+func requireJWKS(t *testing.T, jwksData []byte, wantKeyIDs ...string) {
+	t.Helper()
+
+	var jwks jose.JSONWebKeySet
+	require.NoError(t, json.Unmarshal(jwksData, &jwks))
+	keyIDs := make([]string, 0, len(jwks.Keys))
+	for _, key := range jwks.Keys {
+		require.True(t, key.IsPublic())
+		keyIDs = append(keyIDs, key.KeyID)
+	}
+	require.Equal(t, wantKeyIDs, keyIDs)
+}
+
+type jwksTestQueue struct {
+	duration time.Duration
+
+	controllerlib.Queue // panic if any other methods called
+}
+
+func (q *jwksTestQueue) AddAfter(_ controllerlib.Key, duration time.Duration) {
+	q.duration = duration
+}
+
 func readJWKJSON(t *testing.T, path string) []byte {
 	t.Helper()
 
@@ -12,6 +12,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/events"
 
 	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
 	supervisorclientset "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned"
@@ -65,6 +66,14 @@ func (s *ldapUpstreamGenericLDAPSpec) TLSSpec() *idpv1alpha1.TLSSpec {
 	return s.ldapIdentityProvider.Spec.TLS
 }
 
+func (s *ldapUpstreamGenericLDAPSpec) ProxySpec() *idpv1alpha1.ProxySpec {
+	return s.ldapIdentityProvider.Spec.Proxy
+}
+
+func (s *ldapUpstreamGenericLDAPSpec) SecondFactorSpec() *idpv1alpha1.SecondFactorSpec {
+	return s.ldapIdentityProvider.Spec.SecondFactor
+}
+
 func (s *ldapUpstreamGenericLDAPSpec) BindSecretName() string {
 	return s.ldapIdentityProvider.Spec.Bind.SecretName
 }
@@ -152,6 +161,7 @@ func New(
 	ldapIdentityProviderInformer idpinformers.LDAPIdentityProviderInformer,
 	secretInformer corev1informers.SecretInformer,
 	withInformer pinnipedcontroller.WithInformerOptionFunc,
+	recorder events.EventRecorder,
 ) controllerlib.Controller {
 	return newInternal(
 		idpCache,
@@ -163,6 +173,7 @@ func New(
 		ldapIdentityProviderInformer,
 		secretInformer,
 		withInformer,
+		recorder,
 	)
 }
 
@@ -175,6 +186,7 @@ func newInternal(
 	ldapIdentityProviderInformer idpinformers.LDAPIdentityProviderInformer,
 	secretInformer corev1informers.SecretInformer,
 	withInformer pinnipedcontroller.WithInformerOptionFunc,
+	recorder events.EventRecorder,
 ) controllerlib.Controller {
 	c := ldapWatcherController{
 		cache:                        idpCache,
@@ -196,6 +208,7 @@ func newInternal(
 			pinnipedcontroller.MatchAnySecretOfTypeFilter(upstreamwatchers.LDAPBindAccountSecretType, pinnipedcontroller.SingletonQueue()),
 			controllerlib.InformerOption{},
 		),
+		controllerlib.WithRecorder(recorder),
 	)
 }
 
@@ -209,7 +222,7 @@ func (c *ldapWatcherController) Sync(ctx controllerlib.Context) error {
 	requeue := false
 	validatedUpstreams := make([]upstreamprovider.UpstreamLDAPIdentityProviderI, 0, len(actualUpstreams))
 	for _, upstream := range actualUpstreams {
-		validProvider, requestedRequeue := c.validateUpstream(ctx.Context, upstream)
+		validProvider, requestedRequeue := c.validateUpstream(ctx, upstream)
 		if validProvider != nil {
 			validatedUpstreams = append(validatedUpstreams, validProvider)
 		}
@@ -226,7 +239,7 @@ func (c *ldapWatcherController) Sync(ctx controllerlib.Context) error {
 	return nil
 }
 
-func (c *ldapWatcherController) validateUpstream(ctx context.Context, upstream *idpv1alpha1.LDAPIdentityProvider) (p upstreamprovider.UpstreamLDAPIdentityProviderI, requeue bool) {
+func (c *ldapWatcherController) validateUpstream(ctx controllerlib.Context, upstream *idpv1alpha1.LDAPIdentityProvider) (p upstreamprovider.UpstreamLDAPIdentityProviderI, requeue bool) {
 	spec := upstream.Spec
 
 	config := &upstreamldap.ProviderConfig{
@@ -249,14 +262,14 @@ func (c *ldapWatcherController) validateUpstream(ctx context.Context, upstream *
 		Dialer: c.ldapDialer,
 	}
 
-	conditions := upstreamwatchers.ValidateGenericLDAP(ctx, &ldapUpstreamGenericLDAPImpl{*upstream}, c.secretInformer, c.validatedSettingsCache, config)
+	conditions := upstreamwatchers.ValidateGenericLDAP(ctx.Context, &ldapUpstreamGenericLDAPImpl{*upstream}, c.secretInformer, c.validatedSettingsCache, config)
 
 	c.updateStatus(ctx, upstream, conditions.Conditions())
 
 	return upstreamwatchers.EvaluateConditions(conditions, config)
 }
 
-func (c *ldapWatcherController) updateStatus(ctx context.Context, upstream *idpv1alpha1.LDAPIdentityProvider, conditions []*metav1.Condition) {
+func (c *ldapWatcherController) updateStatus(ctx controllerlib.Context, upstream *idpv1alpha1.LDAPIdentityProvider, conditions []*metav1.Condition) {
 	log := plog.WithValues("namespace", upstream.Namespace, "name", upstream.Name)
 	updated := upstream.DeepCopy()
 
@@ -274,8 +287,10 @@ func (c *ldapWatcherController) updateStatus(ctx context.Context, upstream *idpv
 	_, err := c.client.
 		IDPV1alpha1().
 		LDAPIdentityProviders(upstream.Namespace).
-		UpdateStatus(ctx, updated, metav1.UpdateOptions{})
+		UpdateStatus(ctx.Context, updated, metav1.UpdateOptions{})
 	if err != nil {
 		log.Error("failed to update status", err)
+		return
 	}
+	conditionsutil.RecordConditionChanges(ctx.Recorder, updated, upstream.Status.Conditions, updated.Status.Conditions)
 }
@@ -0,0 +1,267 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package mockupstreamwatcher implements a controller which watches MockIdentityProviders.
+package mockupstreamwatcher
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/utils/clock"
+
+	idpconditions "go.pinniped.dev/generated/latest/apis/supervisor/idp/conditions"
+	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
+	supervisorclientset "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned"
+	idpinformers "go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions/idp/v1alpha1"
+	pinnipedcontroller "go.pinniped.dev/internal/controller"
+	"go.pinniped.dev/internal/controller/conditionsutil"
+	"go.pinniped.dev/internal/controller/supervisorconfig/upstreamwatchers"
+	"go.pinniped.dev/internal/controllerlib"
+	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
+	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/internal/upstreammock"
+)
+
+const (
+	controllerName = "mock-upstream-observer"
+
+	// Constants related to the users Secret.
+	mockUsersSecretType corev1.SecretType = "secrets.pinniped.dev/mock-identity-provider-users"
+	usersDataKey        string            = "users"
+
+	countExpectedConditions = 1
+
+	UsersSecretValid string = idpconditions.TypeUsersSecretValid
+)
+
+// UpstreamMockIdentityProviderICache is a thread safe cache that holds a list of validated upstream
+// mock IDP configurations.
+type UpstreamMockIdentityProviderICache interface {
+	SetMockIdentityProviders([]upstreamprovider.UpstreamMockIdentityProviderI)
+}
+
+type mockWatcherController struct {
+	namespace             string
+	cache                 UpstreamMockIdentityProviderICache
+	log                   plog.Logger
+	client                supervisorclientset.Interface
+	mockIdentityProviders idpinformers.MockIdentityProviderInformer
+	secretInformer        corev1informers.SecretInformer
+	clock                 clock.Clock
+}
+
+// New instantiates a new controllerlib.Controller which will populate the provided
+// UpstreamMockIdentityProviderICache.
+func New(
+	namespace string,
+	idpCache UpstreamMockIdentityProviderICache,
+	client supervisorclientset.Interface,
+	mockIdentityProviderInformer idpinformers.MockIdentityProviderInformer,
+	secretInformer corev1informers.SecretInformer,
+	log plog.Logger,
+	withInformer pinnipedcontroller.WithInformerOptionFunc,
+	recorder events.EventRecorder,
+	clock clock.Clock,
+) controllerlib.Controller {
+	c := mockWatcherController{
+		namespace:             namespace,
+		cache:                 idpCache,
+		client:                client,
+		log:                   log.WithName(controllerName),
+		mockIdentityProviders: mockIdentityProviderInformer,
+		secretInformer:        secretInformer,
+		clock:                 clock,
+	}
+
+	return controllerlib.New(
+		controllerlib.Config{Name: controllerName, Syncer: &c},
+		withInformer(
+			mockIdentityProviderInformer,
+			pinnipedcontroller.SimpleFilter(func(obj metav1.Object) bool {
+				idp, ok := obj.(*idpv1alpha1.MockIdentityProvider)
+				return ok && idp.Namespace == namespace
+			}, pinnipedcontroller.SingletonQueue()),
+			controllerlib.InformerOption{},
+		),
+		withInformer(
+			secretInformer,
+			pinnipedcontroller.MatchAnySecretOfTypeFilter(mockUsersSecretType, pinnipedcontroller.SingletonQueue(), namespace),
+			controllerlib.InformerOption{},
+		),
+		controllerlib.WithRecorder(recorder),
+	)
+}
+
+// Sync implements controllerlib.Syncer.
+func (c *mockWatcherController) Sync(ctx controllerlib.Context) error {
+	actualUpstreams, err := c.mockIdentityProviders.Lister().List(labels.Everything())
+	if err != nil { // untested
+		return fmt.Errorf("failed to list MockIdentityProviders: %w", err)
+	}
+
+	// Sort them by name just so that the logs output is consistent
+	slices.SortStableFunc(actualUpstreams, func(a, b *idpv1alpha1.MockIdentityProvider) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	var applicationErrors []error
+	validatedUpstreams := make([]upstreamprovider.UpstreamMockIdentityProviderI, 0, len(actualUpstreams))
+	for _, upstream := range actualUpstreams {
+		if upstream.Namespace != c.namespace {
+			continue
+		}
+		validatedUpstream, applicationErr := c.validateUpstreamAndUpdateConditions(ctx, upstream)
+		if applicationErr != nil {
+			applicationErrors = append(applicationErrors, applicationErr)
+		} else if validatedUpstream != nil {
+			validatedUpstreams = append(validatedUpstreams, validatedUpstream)
+		}
+		// Else:
+		// If both validatedUpstream and applicationErr are nil, this must be because the upstream had configuration errors.
+		// This controller should take no action until the user has reconfigured the upstream.
+	}
+	c.cache.SetMockIdentityProviders(validatedUpstreams)
+
+	// If we have recoverable application errors, let's do a requeue and capture all the applicationErrors too
+	if len(applicationErrors) > 0 {
+		applicationErrors = append([]error{controllerlib.ErrSyntheticRequeue}, applicationErrors...)
+	}
+
+	return utilerrors.NewAggregate(applicationErrors)
+}
+
+func (c *mockWatcherController) validateUpstreamAndUpdateConditions(
+	ctx controllerlib.Context,
+	upstream *idpv1alpha1.MockIdentityProvider,
+) (
+	*upstreammock.Provider, // If validated, returns the config
+	error, // This error will only refer to programmatic errors such as failing to update the status, not configuration errors
+) {
+	conditions := make([]*metav1.Condition, 0)
+
+	usersCondition, users, applicationErr := c.validateUsersSecret(upstream.Spec.Users.SecretName)
+	if applicationErr != nil { // untested
+		return nil, applicationErr
+	}
+	conditions = append(conditions, usersCondition)
+
+	// The critical pattern to maintain is that every run of the sync loop will populate the exact number of the exact
+	// same set of conditions.  Conditions depending on other conditions should get Status: metav1.ConditionUnknown, or
+	// Status: metav1.ConditionFalse, never be omitted.
+	if len(conditions) != countExpectedConditions { // untested since all code paths return the same number of conditions
+		return nil, fmt.Errorf("expected %d conditions but found %d conditions", countExpectedConditions, len(conditions))
+	}
+
+	hadErrorCondition, updateStatusErr := c.updateStatus(ctx, upstream, conditions)
+	// Any error condition means we will not add the IDP to the cache, so just return nil here
+	if hadErrorCondition {
+		return nil, updateStatusErr
+	}
+
+	provider := upstreammock.New(upstreammock.ProviderConfig{
+		Name:        upstream.Name,
+		ResourceUID: upstream.UID,
+		Users:       users,
+	})
+	return provider, updateStatusErr
+}
+
+func (c *mockWatcherController) validateUsersSecret(secretName string) (*metav1.Condition, []upstreammock.User, error) {
+	secret, unableToRetrieveSecretErr := c.secretInformer.Lister().Secrets(c.namespace).Get(secretName)
+
+	// This error requires user interaction, so ignore it.
+	if apierrors.IsNotFound(unableToRetrieveSecretErr) {
+		unableToRetrieveSecretErr = nil
+	}
+
+	buildFalseCondition := func(reason, prefix string) (*metav1.Condition, []upstreammock.User, error) {
+		return &metav1.Condition{
+			Type:   UsersSecretValid,
+			Status: metav1.ConditionFalse,
+			Reason: reason,
+			Message: fmt.Sprintf("%s: secret from spec.users.secretName (%q) must be found in namespace %q with type %q and key %q",
+				prefix,
+				secretName,
+				c.namespace,
+				mockUsersSecretType,
+				usersDataKey),
+		}, nil, unableToRetrieveSecretErr
+	}
+
+	if unableToRetrieveSecretErr != nil || secret == nil {
+		return buildFalseCondition(upstreamwatchers.ReasonNotFound, fmt.Sprintf("secret %q not found", secretName))
+	}
+
+	if secret.Type != mockUsersSecretType {
+		return buildFalseCondition(upstreamwatchers.ReasonWrongType, fmt.Sprintf("wrong secret type %q", secret.Type))
+	}
+
+	usersData, ok := secret.Data[usersDataKey]
+	if !ok {
+		return buildFalseCondition(upstreamwatchers.ReasonMissingKeys, fmt.Sprintf("missing key %q", usersDataKey))
+	}
+
+	users, err := upstreammock.ParseUsers(usersData)
+	if err != nil {
+		return &metav1.Condition{
+			Type:    UsersSecretValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  idpconditions.ReasonInvalidUsers,
+			Message: fmt.Sprintf("the users in spec.users.secretName (%q) are not valid: %s", secretName, err),
+		}, nil, nil
+	}
+
+	return &metav1.Condition{
+		Type:    UsersSecretValid,
+		Status:  metav1.ConditionTrue,
+		Reason:  upstreamwatchers.ReasonSuccess,
+		Message: fmt.Sprintf("%d users have been read from spec.users.secretName (%q)", len(users), secretName),
+	}, users, nil
+}
+
+func (c *mockWatcherController) updateStatus(
+	ctx controllerlib.Context,
+	upstream *idpv1alpha1.MockIdentityProvider,
+	conditions []*metav1.Condition,
+) (bool, error) {
+	log := c.log.WithValues("namespace", upstream.Namespace, "name", upstream.Name)
+	updated := upstream.DeepCopy()
+
+	hadErrorCondition := conditionsutil.MergeConditions(
+		conditions,
+		upstream.Generation,
+		&updated.Status.Conditions,
+		log,
+		metav1.NewTime(c.clock.Now()),
+	)
+
+	updated.Status.Phase = idpv1alpha1.MockPhaseReady
+	if hadErrorCondition {
+		updated.Status.Phase = idpv1alpha1.MockPhaseError
+	}
+
+	if equality.Semantic.DeepEqual(upstream, updated) {
+		return hadErrorCondition, nil
+	}
+
+	log.Info("updating MockIdentityProvider status", "phase", updated.Status.Phase)
+
+	_, updateStatusError := c.client.
+		IDPV1alpha1().
+		MockIdentityProviders(upstream.Namespace).
+		UpdateStatus(ctx.Context, updated, metav1.UpdateOptions{})
+	if updateStatusError == nil {
+		conditionsutil.RecordConditionChanges(ctx.Recorder, updated, upstream.Status.Conditions, updated.Status.Conditions)
+	}
+	return hadErrorCondition, updateStatusError
+}
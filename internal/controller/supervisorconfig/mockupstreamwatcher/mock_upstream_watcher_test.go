@@ -0,0 +1,313 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package mockupstreamwatcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8sinformers "k8s.io/client-go/informers"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	coretesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
+	supervisorfake "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/fake"
+	supervisorinformers "go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions"
+	"go.pinniped.dev/internal/controllerlib"
+	"go.pinniped.dev/internal/federationdomain/dynamicupstreamprovider"
+	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
+	"go.pinniped.dev/internal/here"
+	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/internal/upstreammock"
+)
+
+func TestController(t *testing.T) {
+	require.Equal(t, 1, countExpectedConditions)
+
+	const (
+		namespace = "some-namespace"
+		otherNS   = "other-namespace"
+	)
+
+	now := metav1.NewTime(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	earlier := metav1.NewTime(now.Add(-time.Hour))
+
+	validUsers := here.Doc(`
+		- username: alice
+		  password: alice-password
+		  groups: [developers]
+		- username: bob
+		  password: bob-password
+	`)
+
+	validIDP := func(name string) *idpv1alpha1.MockIdentityProvider {
+		return &idpv1alpha1.MockIdentityProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, UID: types.UID("uid-" + name), Generation: 1234},
+			Spec: idpv1alpha1.MockIdentityProviderSpec{
+				Users: idpv1alpha1.MockIdentityProviderUsersSpec{SecretName: "some-users-secret"},
+			},
+		}
+	}
+
+	validSecret := func(name string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Type:       mockUsersSecretType,
+			Data:       map[string][]byte{usersDataKey: []byte(validUsers)},
+		}
+	}
+
+	condition := func(status metav1.ConditionStatus, reason, message string, lastTransitionTime metav1.Time) metav1.Condition {
+		return metav1.Condition{
+			Type:               UsersSecretValid,
+			Status:             status,
+			ObservedGeneration: 1234,
+			LastTransitionTime: lastTransitionTime,
+			Reason:             reason,
+			Message:            message,
+		}
+	}
+
+	happyUsersCondition := condition(metav1.ConditionTrue, "Success",
+		`2 users have been read from spec.users.secretName ("some-users-secret")`, now)
+
+	tests := []struct {
+		name                string
+		inputUpstreams      []runtime.Object
+		inputSecrets        []runtime.Object
+		configureClient     func(*supervisorfake.Clientset)
+		wantErr             string
+		wantResultingCache  []string
+		wantResultingPhases map[string]idpv1alpha1.MockIdentityProviderPhase
+		wantResultingConds  map[string][]metav1.Condition
+		wantNoStatusUpdates bool
+	}{
+		{
+			name:                "no upstreams",
+			wantResultingCache:  []string{},
+			wantNoStatusUpdates: true,
+		},
+		{
+			name:               "valid upstream is loaded into the cache and becomes Ready",
+			inputUpstreams:     []runtime.Object{validIDP("some-idp")},
+			inputSecrets:       []runtime.Object{validSecret("some-users-secret")},
+			wantResultingCache: []string{"some-idp"},
+			wantResultingPhases: map[string]idpv1alpha1.MockIdentityProviderPhase{
+				"some-idp": idpv1alpha1.MockPhaseReady,
+			},
+			wantResultingConds: map[string][]metav1.Condition{
+				"some-idp": {happyUsersCondition},
+			},
+		},
+		{
+			name: "upstreams in other namespaces are ignored",
+			inputUpstreams: []runtime.Object{func() runtime.Object {
+				idp := validIDP("some-idp")
+				idp.Namespace = otherNS
+				return idp
+			}()},
+			inputSecrets:        []runtime.Object{validSecret("some-users-secret")},
+			wantResultingCache:  []string{},
+			wantNoStatusUpdates: true,
+		},
+		{
+			name: "already up to date upstream does not get a status update",
+			inputUpstreams: []runtime.Object{func() runtime.Object {
+				idp := validIDP("some-idp")
+				idp.Status = idpv1alpha1.MockIdentityProviderStatus{
+					Phase: idpv1alpha1.MockPhaseReady,
+					Conditions: []metav1.Condition{
+						condition(metav1.ConditionTrue, "Success",
+							`2 users have been read from spec.users.secretName ("some-users-secret")`, earlier),
+					},
+				}
+				return idp
+			}()},
+			inputSecrets:        []runtime.Object{validSecret("some-users-secret")},
+			wantResultingCache:  []string{"some-idp"},
+			wantNoStatusUpdates: true,
+		},
+		{
+			name:               "users secret not found",
+			inputUpstreams:     []runtime.Object{validIDP("some-idp")},
+			wantResultingCache: []string{},
+			wantResultingPhases: map[string]idpv1alpha1.MockIdentityProviderPhase{
+				"some-idp": idpv1alpha1.MockPhaseError,
+			},
+			wantResultingConds: map[string][]metav1.Condition{
+				"some-idp": {
+					condition(metav1.ConditionFalse, "SecretNotFound",
+						`secret "some-users-secret" not found: secret from spec.users.secretName ("some-users-secret") must be found in namespace "some-namespace" with type "secrets.pinniped.dev/mock-identity-provider-users" and key "users"`, now),
+				},
+			},
+		},
+		{
+			name:           "users secret in another namespace is not found",
+			inputUpstreams: []runtime.Object{validIDP("some-idp")},
+			inputSecrets: []runtime.Object{func() runtime.Object {
+				secret := validSecret("some-users-secret")
+				secret.Namespace = otherNS
+				return secret
+			}()},
+			wantResultingCache: []string{},
+			wantResultingPhases: map[string]idpv1alpha1.MockIdentityProviderPhase{
+				"some-idp": idpv1alpha1.MockPhaseError,
+			},
+			wantResultingConds: map[string][]metav1.Condition{
+				"some-idp": {
+					condition(metav1.ConditionFalse, "SecretNotFound",
+						`secret "some-users-secret" not found: secret from spec.users.secretName ("some-users-secret") must be found in namespace "some-namespace" with type "secrets.pinniped.dev/mock-identity-provider-users" and key "users"`, now),
+				},
+			},
+		},
+		{
+			name:           "users secret has the wrong type",
+			inputUpstreams: []runtime.Object{validIDP("some-idp")},
+			inputSecrets: []runtime.Object{func() runtime.Object {
+				secret := validSecret("some-users-secret")
+				secret.Type = corev1.SecretTypeOpaque
+				return secret
+			}()},
+			wantResultingCache: []string{},
+			wantResultingPhases: map[string]idpv1alpha1.MockIdentityProviderPhase{
+				"some-idp": idpv1alpha1.MockPhaseError,
+			},
+			wantResultingConds: map[string][]metav1.Condition{
+				"some-idp": {
+					condition(metav1.ConditionFalse, "SecretWrongType",
+						`wrong secret type "Opaque": secret from spec.users.secretName ("some-users-secret") must be found in namespace "some-namespace" with type "secrets.pinniped.dev/mock-identity-provider-users" and key "users"`, now),
+				},
+			},
+		},
+		{
+			name:           "users secret is missing the users key",
+			inputUpstreams: []runtime.Object{validIDP("some-idp")},
+			inputSecrets: []runtime.Object{func() runtime.Object {
+				secret := validSecret("some-users-secret")
+				secret.Data = map[string][]byte{"other-key": []byte(validUsers)}
+				return secret
+			}()},
+			wantResultingCache: []string{},
+			wantResultingPhases: map[string]idpv1alpha1.MockIdentityProviderPhase{
+				"some-idp": idpv1alpha1.MockPhaseError,
+			},
+			wantResultingConds: map[string][]metav1.Condition{
+				"some-idp": {
+					condition(metav1.ConditionFalse, "SecretMissingKeys",
+						`missing key "users": secret from spec.users.secretName ("some-users-secret") must be found in namespace "some-namespace" with type "secrets.pinniped.dev/mock-identity-provider-users" and key "users"`, now),
+				},
+			},
+		},
+		{
+			name:           "users secret contains invalid users",
+			inputUpstreams: []runtime.Object{validIDP("some-idp")},
+			inputSecrets: []runtime.Object{func() runtime.Object {
+				secret := validSecret("some-users-secret")
+				secret.Data = map[string][]byte{usersDataKey: []byte("- username: alice\n")}
+				return secret
+			}()},
+			wantResultingCache: []string{},
+			wantResultingPhases: map[string]idpv1alpha1.MockIdentityProviderPhase{
+				"some-idp": idpv1alpha1.MockPhaseError,
+			},
+			wantResultingConds: map[string][]metav1.Condition{
+				"some-idp": {
+					condition(metav1.ConditionFalse, "InvalidUsers",
+						`the users in spec.users.secretName ("some-users-secret") are not valid: user "alice" is missing a password`, now),
+				},
+			},
+		},
+		{
+			name:           "error while updating status causes a requeue and the upstream is not cached",
+			inputUpstreams: []runtime.Object{validIDP("some-idp")},
+			inputSecrets:   []runtime.Object{validSecret("some-users-secret")},
+			configureClient: func(client *supervisorfake.Clientset) {
+				client.PrependReactor("update", "mockidentityproviders", func(action coretesting.Action) (bool, runtime.Object, error) {
+					return true, nil, errors.New("some update error")
+				})
+			},
+			wantErr:            "some update error",
+			wantResultingCache: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fakeSupervisorClient := supervisorfake.NewSimpleClientset(tt.inputUpstreams...)
+			supervisorInformers := supervisorinformers.NewSharedInformerFactory(fakeSupervisorClient, 0)
+			if tt.configureClient != nil {
+				tt.configureClient(fakeSupervisorClient)
+			}
+			fakeKubeClient := kubernetesfake.NewSimpleClientset(tt.inputSecrets...)
+			kubeInformers := k8sinformers.NewSharedInformerFactoryWithOptions(fakeKubeClient, 0)
+
+			cache := dynamicupstreamprovider.NewDynamicUpstreamIDPProvider()
+			cache.SetMockIdentityProviders([]upstreamprovider.UpstreamMockIdentityProviderI{
+				upstreammock.New(upstreammock.ProviderConfig{Name: "initial-entry-to-remove"}),
+			})
+
+			controller := New(
+				namespace,
+				cache,
+				fakeSupervisorClient,
+				supervisorInformers.IDP().V1alpha1().MockIdentityProviders(),
+				kubeInformers.Core().V1().Secrets(),
+				plog.TestLogger(t, nil),
+				controllerlib.WithInformer,
+				nil,
+				clocktesting.NewFakeClock(now.Time),
+			)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			supervisorInformers.Start(ctx.Done())
+			kubeInformers.Start(ctx.Done())
+			controllerlib.TestRunSynchronously(t, controller)
+
+			syncCtx := controllerlib.Context{Context: ctx, Key: controllerlib.Key{}}
+
+			if err := controllerlib.TestSync(t, controller, syncCtx); len(tt.wantErr) > 0 {
+				require.ErrorContains(t, err, controllerlib.ErrSyntheticRequeue.Error())
+				require.ErrorContains(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			// Verify what's in the cache
+			actualCachedNames := []string{}
+			for _, p := range cache.GetMockIdentityProviders() {
+				actualProvider, ok := p.(*upstreammock.Provider)
+				require.True(t, ok)
+				require.Len(t, actualProvider.GetConfig().Users, 2)
+				actualCachedNames = append(actualCachedNames, actualProvider.GetResourceName())
+			}
+			require.Equal(t, tt.wantResultingCache, actualCachedNames)
+
+			if tt.wantNoStatusUpdates {
+				for _, action := range fakeSupervisorClient.Actions() {
+					require.NotEqual(t, "update", action.GetVerb(), "should not have updated any status")
+				}
+			}
+
+			// Verify the status as reported in Kubernetes
+			for name, wantPhase := range tt.wantResultingPhases {
+				actual, err := fakeSupervisorClient.IDPV1alpha1().MockIdentityProviders(namespace).Get(ctx, name, metav1.GetOptions{})
+				require.NoError(t, err)
+				require.Equal(t, wantPhase, actual.Status.Phase)
+				require.Equal(t, tt.wantResultingConds[name], actual.Status.Conditions)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"strings"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -22,6 +23,7 @@ import (
 	"go.pinniped.dev/internal/controller/conditionsutil"
 	"go.pinniped.dev/internal/controllerlib"
 	"go.pinniped.dev/internal/federationdomain/oidcclientvalidator"
+	"go.pinniped.dev/internal/lastauth"
 	"go.pinniped.dev/internal/oidcclientsecretstorage"
 	"go.pinniped.dev/internal/plog"
 )
@@ -35,14 +37,16 @@ type oidcClientWatcherController struct {
 	pinnipedClient     supervisorclientset.Interface
 	oidcClientInformer configInformers.OIDCClientInformer
 	secretInformer     corev1informers.SecretInformer
+	lastClientAuths    *lastauth.Recorder[string]
 }
 
 // NewOIDCClientWatcherController returns a controllerlib.Controller that watches OIDCClients and updates
-// their status with validation errors.
+// their status with validation errors and the time of their most recent successful token request.
 func NewOIDCClientWatcherController(
 	pinnipedClient supervisorclientset.Interface,
 	secretInformer corev1informers.SecretInformer,
 	oidcClientInformer configInformers.OIDCClientInformer,
+	lastClientAuths *lastauth.Recorder[string],
 	withInformer pinnipedcontroller.WithInformerOptionFunc,
 ) controllerlib.Controller {
 	return controllerlib.New(
@@ -52,12 +56,20 @@ func NewOIDCClientWatcherController(
 				pinnipedClient:     pinnipedClient,
 				secretInformer:     secretInformer,
 				oidcClientInformer: oidcClientInformer,
+				lastClientAuths:    lastClientAuths,
 			},
 		},
-		// We want to be notified when an OIDCClient's corresponding secret gets updated or deleted.
+		// We want to be notified when an OIDCClient's corresponding secret, or the secret which holds its
+		// private_key_jwt public keys, gets updated or deleted.
 		withInformer(
 			secretInformer,
-			pinnipedcontroller.MatchAnySecretOfTypeFilter(secretTypeToObserve, pinnipedcontroller.SingletonQueue()),
+			pinnipedcontroller.SimpleFilterWithSingletonQueue(func(obj metav1.Object) bool {
+				secret, ok := obj.(*corev1.Secret)
+				if !ok {
+					return false
+				}
+				return secret.Type == secretTypeToObserve || isPrivateKeyJWTSecret(oidcClientInformer, secret)
+			}),
 			controllerlib.InformerOption{},
 		),
 		// We want to be notified when anything happens to an OIDCClient.
@@ -71,6 +83,20 @@ func NewOIDCClientWatcherController(
 	)
 }
 
+// isPrivateKeyJWTSecret returns true when the Secret holds the private_key_jwt public keys of any OIDCClient.
+func isPrivateKeyJWTSecret(oidcClientInformer configInformers.OIDCClientInformer, secret *corev1.Secret) bool {
+	oidcClients, err := oidcClientInformer.Lister().OIDCClients(secret.Namespace).List(labels.Everything())
+	if err != nil {
+		return false
+	}
+	for _, oidcClient := range oidcClients {
+		if oidcClient.Spec.PrivateKeyJWT != nil && oidcClient.Spec.PrivateKeyJWT.SecretName == secret.Name {
+			return true
+		}
+	}
+	return false
+}
+
 // Sync implements controllerlib.Syncer.
 func (c *oidcClientWatcherController) Sync(ctx controllerlib.Context) error {
 	// Sync could be called on either a Secret or an OIDCClient, so to keep it simple, revalidate
@@ -108,7 +134,22 @@ func (c *oidcClientWatcherController) Sync(ctx controllerlib.Context) error {
 			secret = nil
 		}
 
-		_, conditions, clientSecrets := oidcclientvalidator.Validate(oidcClient, secret, oidcclientvalidator.DefaultMinBcryptCost)
+		var privateKeyJWTSecret *corev1.Secret
+		if oidcClient.Spec.PrivateKeyJWT != nil {
+			privateKeyJWTSecretName := oidcClient.Spec.PrivateKeyJWT.SecretName
+			privateKeyJWTSecret, err = c.secretInformer.Lister().Secrets(oidcClient.Namespace).Get(privateKeyJWTSecretName)
+			if err != nil {
+				if !apierrors.IsNotFound(err) {
+					// Anything other than a NotFound error is unexpected when reading from an informer.
+					return fmt.Errorf("failed to get %s/%s secret: %w", oidcClient.Namespace, privateKeyJWTSecretName, err)
+				}
+				// The validator will report that the Secret does not exist.
+				privateKeyJWTSecret = nil
+			}
+		}
+
+		_, conditions, clientSecrets, _ := oidcclientvalidator.ValidateWithPrivateKeyJWT(
+			oidcClient, secret, privateKeyJWTSecret, oidcclientvalidator.DefaultMinBcryptCost)
 
 		if err := c.updateStatus(ctx.Context, oidcClient, conditions, len(clientSecrets)); err != nil {
 			return fmt.Errorf("cannot update OIDCClient '%s/%s': %w", oidcClient.Namespace, oidcClient.Name, err)
@@ -142,6 +183,9 @@ func (c *oidcClientWatcherController) updateStatus(
 	}
 
 	updated.Status.TotalClientSecrets = int32(totalClientSecrets)
+	updated.Status.ObservedGeneration = upstream.Generation
+	// The client ID of an OIDCClient is its name.
+	updated.Status.LastSuccessfulAuthTimestamp = c.lastClientAuths.Latest(upstream.Name, upstream.Status.LastSuccessfulAuthTimestamp)
 
 	if equality.Semantic.DeepEqual(upstream, updated) {
 		return nil
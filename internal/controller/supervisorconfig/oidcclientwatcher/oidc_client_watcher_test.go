@@ -5,6 +5,11 @@ package oidcclientwatcher
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"testing"
 	"time"
@@ -75,6 +80,7 @@ func TestOIDCClientWatcherControllerFilterSecret(t *testing.T) {
 				nil, // pinnipedClient, not needed
 				secretInformer,
 				oidcClientsInformer,
+				nil,
 				withInformer.WithInformer,
 			)
 
@@ -144,6 +150,7 @@ func TestOIDCClientWatcherControllerFilterOIDCClient(t *testing.T) {
 				nil, // pinnipedClient, not needed
 				secretInformer,
 				oidcClientsInformer,
+				nil,
 				withInformer.WithInformer,
 			)
 
@@ -246,6 +253,32 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 		}
 	}
 
+	privateKeyJWTKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	privateKeyJWTPublicKeyDER, err := x509.MarshalPKIXPublicKey(privateKeyJWTKey.Public())
+	require.NoError(t, err)
+	privateKeyJWTSecretOfType := func(secretType corev1.SecretType, publicKeys []byte) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: "test-client-public-keys"},
+			Type:       secretType,
+			Data:       map[string][]byte{"publicKeys": publicKeys},
+		}
+	}
+	privateKeyJWTSecret := func(publicKeys []byte) *corev1.Secret {
+		return privateKeyJWTSecretOfType("secrets.pinniped.dev/oidc-client-public-keys", publicKeys)
+	}
+
+	clientSecretsNotUsedCondition := func(time metav1.Time, observedGeneration int64) metav1.Condition {
+		return metav1.Condition{
+			Type:               "ClientSecretExists",
+			Status:             "True",
+			LastTransitionTime: time,
+			Reason:             "Success",
+			Message:            "client secrets are not used because privateKeyJWT is configured",
+			ObservedGeneration: observedGeneration,
+		}
+	}
+
 	tests := []struct {
 		name                     string
 		inputObjects             []runtime.Object
@@ -291,7 +324,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 				{
 					ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
 					Status: supervisorconfigv1alpha1.OIDCClientStatus{
-						Phase: "Ready",
+						Phase:              "Ready",
+						ObservedGeneration: 1234,
 						Conditions: []metav1.Condition{
 							happyAllowedGrantTypesCondition(now, 1234),
 							happyAllowedScopesCondition(now, 1234),
@@ -316,7 +350,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Ready",
+					Phase:              "Ready",
+					ObservedGeneration: 1234,
 					Conditions: []metav1.Condition{
 						happyAllowedGrantTypesCondition(now, 1234),
 						happyAllowedScopesCondition(now, 1234),
@@ -335,7 +370,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 					AllowedScopes:     []supervisorconfigv1alpha1.Scope{"openid"},
 				},
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Ready",
+					Phase:              "Ready",
+					ObservedGeneration: 1234,
 					Conditions: []metav1.Condition{
 						happyAllowedGrantTypesCondition(earlier, 1234),
 						happyAllowedScopesCondition(earlier, 1234),
@@ -349,7 +385,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Ready",
+					Phase:              "Ready",
+					ObservedGeneration: 1234,
 					Conditions: []metav1.Condition{
 						happyAllowedGrantTypesCondition(earlier, 1234),
 						happyAllowedScopesCondition(earlier, 1234),
@@ -369,7 +406,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Error",
+					Phase:              "Error",
+					ObservedGeneration: 1234,
 					Conditions: []metav1.Condition{
 						sadAllowedGrantTypesCondition(now, 1234, `"authorization_code" must always be included in "allowedGrantTypes"`),
 						sadAllowedScopesCondition(now, 1234, `"openid" must always be included in "allowedScopes"`),
@@ -392,7 +430,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Error",
+					Phase:              "Error",
+					ObservedGeneration: 1234,
 					Conditions: []metav1.Condition{
 						happyAllowedGrantTypesCondition(now, 1234),
 						happyAllowedScopesCondition(now, 1234),
@@ -415,7 +454,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Error",
+					Phase:              "Error",
+					ObservedGeneration: 1234,
 					Conditions: []metav1.Condition{
 						happyAllowedGrantTypesCondition(now, 1234),
 						happyAllowedScopesCondition(now, 1234),
@@ -442,7 +482,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Error",
+					Phase:              "Error",
+					ObservedGeneration: 1234,
 					Conditions: []metav1.Condition{
 						happyAllowedGrantTypesCondition(now, 1234),
 						happyAllowedScopesCondition(now, 1234),
@@ -476,7 +517,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 				{
 					ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: "client.oauth.pinniped.dev-test1", Generation: 1234, UID: "uid1"},
 					Status: supervisorconfigv1alpha1.OIDCClientStatus{
-						Phase: "Ready",
+						Phase:              "Ready",
+						ObservedGeneration: 1234,
 						Conditions: []metav1.Condition{
 							happyAllowedGrantTypesCondition(now, 1234),
 							happyAllowedScopesCondition(now, 1234),
@@ -488,7 +530,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 				{
 					ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: "client.oauth.pinniped.dev-test2", Generation: 4567, UID: "uid2"},
 					Status: supervisorconfigv1alpha1.OIDCClientStatus{
-						Phase: "Error",
+						Phase:              "Error",
+						ObservedGeneration: 4567,
 						Conditions: []metav1.Condition{
 							sadAllowedGrantTypesCondition(now, 4567, `"authorization_code" must always be included in "allowedGrantTypes"`),
 							sadAllowedScopesCondition(now, 4567, `"openid" must always be included in "allowedScopes"`),
@@ -509,7 +552,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 				},
 				// was invalid on previous run of controller which observed an old generation at an earlier time
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Error",
+					Phase:              "Error",
+					ObservedGeneration: 1234,
 					Conditions: []metav1.Condition{
 						sadAllowedGrantTypesCondition(earlier, 1234, `"authorization_code" must always be included in "allowedGrantTypes"`),
 						sadAllowedScopesCondition(earlier, 1234, `"openid" must always be included in "allowedScopes"`),
@@ -524,7 +568,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 4567, UID: testUID},
 				// status was updated to reflect the current generation at the current time
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Ready",
+					Phase:              "Ready",
+					ObservedGeneration: 4567,
 					Conditions: []metav1.Condition{
 						happyAllowedGrantTypesCondition(now, 4567),
 						happyAllowedScopesCondition(now, 4567),
@@ -548,7 +593,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Error",
+					Phase:              "Error",
+					ObservedGeneration: 1234,
 					Conditions: []metav1.Condition{
 						sadAllowedGrantTypesCondition(now, 1234, `"refresh_token" must be included in "allowedGrantTypes" when "offline_access" is included in "allowedScopes"`),
 						happyAllowedScopesCondition(now, 1234),
@@ -572,7 +618,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Error",
+					Phase:              "Error",
+					ObservedGeneration: 1234,
 					Conditions: []metav1.Condition{
 						sadAllowedGrantTypesCondition(now, 1234,
 							`"authorization_code" must always be included in "allowedGrantTypes"; `+
@@ -601,7 +648,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Error",
+					Phase:              "Error",
+					ObservedGeneration: 1234,
 					Conditions: []metav1.Condition{
 						sadAllowedGrantTypesCondition(now, 1234,
 							`"authorization_code" must always be included in "allowedGrantTypes"; `+
@@ -629,7 +677,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Error",
+					Phase:              "Error",
+					ObservedGeneration: 1234,
 					Conditions: []metav1.Condition{
 						sadAllowedGrantTypesCondition(now, 1234, `"urn:ietf:params:oauth:grant-type:token-exchange" must be included in "allowedGrantTypes" when "pinniped:request-audience" is included in "allowedScopes"`),
 						happyAllowedScopesCondition(now, 1234),
@@ -639,6 +688,32 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 				},
 			}},
 		},
+		{
+			name: "urn:ietf:params:oauth:grant-type:token-exchange must be included in allowedGrantTypes when delegation is specified",
+			inputObjects: []runtime.Object{&supervisorconfigv1alpha1.OIDCClient{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
+				Spec: supervisorconfigv1alpha1.OIDCClientSpec{
+					AllowedGrantTypes: []supervisorconfigv1alpha1.GrantType{"authorization_code"},
+					AllowedScopes:     []supervisorconfigv1alpha1.Scope{"openid"},
+					Delegation:        &supervisorconfigv1alpha1.OIDCClientDelegation{AllowedActors: []string{"some-actor"}},
+				},
+			}},
+			inputSecrets:   []runtime.Object{testutil.OIDCClientSecretStorageSecretForUID(t, testNamespace, testUID, []string{testutil.HashedPassword1AtSupervisorMinCost})},
+			wantAPIActions: 1, // one update
+			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
+				Status: supervisorconfigv1alpha1.OIDCClientStatus{
+					Phase:              "Error",
+					ObservedGeneration: 1234,
+					Conditions: []metav1.Condition{
+						sadAllowedGrantTypesCondition(now, 1234, `"urn:ietf:params:oauth:grant-type:token-exchange" must be included in "allowedGrantTypes" when "delegation" is specified`),
+						happyAllowedScopesCondition(now, 1234),
+						happyClientSecretsCondition(1, now, 1234),
+					},
+					TotalClientSecrets: 1,
+				},
+			}},
+		},
 		{
 			name: "offline_access must be included in allowedScopes when refresh_token is included in allowedGrantTypes",
 			inputObjects: []runtime.Object{&supervisorconfigv1alpha1.OIDCClient{
@@ -653,7 +728,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Error",
+					Phase:              "Error",
+					ObservedGeneration: 1234,
 					Conditions: []metav1.Condition{
 						happyAllowedGrantTypesCondition(now, 1234),
 						sadAllowedScopesCondition(now, 1234, `"offline_access" must be included in "allowedScopes" when "refresh_token" is included in "allowedGrantTypes"`),
@@ -677,7 +753,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Error",
+					Phase:              "Error",
+					ObservedGeneration: 1234,
 					Conditions: []metav1.Condition{
 						happyAllowedGrantTypesCondition(now, 1234),
 						sadAllowedScopesCondition(now, 1234, `"username" and "groups" must be included in "allowedScopes" when "pinniped:request-audience" is included in "allowedScopes"`),
@@ -701,7 +778,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Error",
+					Phase:              "Error",
+					ObservedGeneration: 1234,
 					Conditions: []metav1.Condition{
 						happyAllowedGrantTypesCondition(now, 1234),
 						sadAllowedScopesCondition(now, 1234, `"username" and "groups" must be included in "allowedScopes" when "pinniped:request-audience" is included in "allowedScopes"`),
@@ -725,7 +803,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Error",
+					Phase:              "Error",
+					ObservedGeneration: 1234,
 					Conditions: []metav1.Condition{
 						happyAllowedGrantTypesCondition(now, 1234),
 						sadAllowedScopesCondition(now, 1234, `"username" and "groups" must be included in "allowedScopes" when "pinniped:request-audience" is included in "allowedScopes"`),
@@ -749,7 +828,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Error",
+					Phase:              "Error",
+					ObservedGeneration: 1234,
 					Conditions: []metav1.Condition{
 						happyAllowedGrantTypesCondition(now, 1234),
 						sadAllowedScopesCondition(now, 1234, `"pinniped:request-audience" must be included in "allowedScopes" when "urn:ietf:params:oauth:grant-type:token-exchange" is included in "allowedGrantTypes"`),
@@ -759,6 +839,65 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 				},
 			}},
 		},
+		{
+			name: "scopePolicies must not include openid or scopes which are not included in allowedScopes",
+			inputObjects: []runtime.Object{&supervisorconfigv1alpha1.OIDCClient{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
+				Spec: supervisorconfigv1alpha1.OIDCClientSpec{
+					AllowedGrantTypes: []supervisorconfigv1alpha1.GrantType{"authorization_code"},
+					AllowedScopes:     []supervisorconfigv1alpha1.Scope{"openid", "username"},
+					ScopePolicies: []supervisorconfigv1alpha1.OIDCClientScopePolicy{
+						{Scope: "openid", RequiredGroups: []string{"group1"}},
+						{Scope: "username", RequiredGroups: []string{"group1"}},
+						{Scope: "groups", RequiredGroups: []string{"group1"}},
+					},
+				},
+			}},
+			inputSecrets:   []runtime.Object{testutil.OIDCClientSecretStorageSecretForUID(t, testNamespace, testUID, []string{testutil.HashedPassword1AtSupervisorMinCost})},
+			wantAPIActions: 1, // one update
+			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
+				Status: supervisorconfigv1alpha1.OIDCClientStatus{
+					Phase:              "Error",
+					ObservedGeneration: 1234,
+					Conditions: []metav1.Condition{
+						happyAllowedGrantTypesCondition(now, 1234),
+						sadAllowedScopesCondition(now, 1234, `"openid" must not be included in "scopePolicies"; `+
+							`"groups" must be included in "allowedScopes" when it is included in "scopePolicies"`),
+						happyClientSecretsCondition(1, now, 1234),
+					},
+					TotalClientSecrets: 1,
+				},
+			}},
+		},
+		{
+			name: "successfully validate an OIDCClient with scopePolicies",
+			inputObjects: []runtime.Object{&supervisorconfigv1alpha1.OIDCClient{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
+				Spec: supervisorconfigv1alpha1.OIDCClientSpec{
+					AllowedGrantTypes: []supervisorconfigv1alpha1.GrantType{"authorization_code", "urn:ietf:params:oauth:grant-type:token-exchange", "refresh_token"},
+					AllowedScopes:     []supervisorconfigv1alpha1.Scope{"openid", "offline_access", "pinniped:request-audience", "username", "groups"},
+					ScopePolicies: []supervisorconfigv1alpha1.OIDCClientScopePolicy{
+						{Scope: "pinniped:request-audience", RequiredGroups: []string{"cluster-admins", "developers"}},
+					},
+				},
+			}},
+			inputSecrets:   []runtime.Object{testutil.OIDCClientSecretStorageSecretForUID(t, testNamespace, testUID, []string{testutil.HashedPassword1AtSupervisorMinCost})},
+			wantAPIActions: 1, // one update
+			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
+				Status: supervisorconfigv1alpha1.OIDCClientStatus{
+					Phase:              "Ready",
+					ObservedGeneration: 1234,
+					Conditions: []metav1.Condition{
+						happyAllowedGrantTypesCondition(now, 1234),
+						happyAllowedScopesCondition(now, 1234),
+						happyClientSecretsCondition(1, now, 1234),
+					},
+					TotalClientSecrets: 1,
+				},
+			}},
+		},
 		{
 			name: "successfully validate an OIDCClient with all allowedGrantTypes and all allowedScopes",
 			inputObjects: []runtime.Object{&supervisorconfigv1alpha1.OIDCClient{
@@ -773,7 +912,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Ready",
+					Phase:              "Ready",
+					ObservedGeneration: 1234,
 					Conditions: []metav1.Condition{
 						happyAllowedGrantTypesCondition(now, 1234),
 						happyAllowedScopesCondition(now, 1234),
@@ -797,7 +937,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Ready",
+					Phase:              "Ready",
+					ObservedGeneration: 1234,
 					Conditions: []metav1.Condition{
 						happyAllowedGrantTypesCondition(now, 1234),
 						happyAllowedScopesCondition(now, 1234),
@@ -821,7 +962,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Ready",
+					Phase:              "Ready",
+					ObservedGeneration: 1234,
 					Conditions: []metav1.Condition{
 						happyAllowedGrantTypesCondition(now, 1234),
 						happyAllowedScopesCondition(now, 1234),
@@ -845,7 +987,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Ready",
+					Phase:              "Ready",
+					ObservedGeneration: 1234,
 					Conditions: []metav1.Condition{
 						happyAllowedGrantTypesCondition(now, 1234),
 						happyAllowedScopesCondition(now, 1234),
@@ -869,7 +1012,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Ready",
+					Phase:              "Ready",
+					ObservedGeneration: 1234,
 					Conditions: []metav1.Condition{
 						happyAllowedGrantTypesCondition(now, 1234),
 						happyAllowedScopesCondition(now, 1234),
@@ -893,7 +1037,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Ready",
+					Phase:              "Ready",
+					ObservedGeneration: 1234,
 					Conditions: []metav1.Condition{
 						happyAllowedGrantTypesCondition(now, 1234),
 						happyAllowedScopesCondition(now, 1234),
@@ -917,7 +1062,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Ready",
+					Phase:              "Ready",
+					ObservedGeneration: 1234,
 					Conditions: []metav1.Condition{
 						happyAllowedGrantTypesCondition(now, 1234),
 						happyAllowedScopesCondition(now, 1234),
@@ -941,7 +1087,8 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
 				Status: supervisorconfigv1alpha1.OIDCClientStatus{
-					Phase: "Ready",
+					Phase:              "Ready",
+					ObservedGeneration: 1234,
 					Conditions: []metav1.Condition{
 						happyAllowedGrantTypesCondition(now, 1234),
 						happyAllowedScopesCondition(now, 1234),
@@ -951,6 +1098,140 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 				},
 			}},
 		},
+		{
+			name: "successfully validate an OIDCClient which uses private_key_jwt client authentication",
+			inputObjects: []runtime.Object{&supervisorconfigv1alpha1.OIDCClient{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
+				Spec: supervisorconfigv1alpha1.OIDCClientSpec{
+					AllowedGrantTypes: []supervisorconfigv1alpha1.GrantType{"authorization_code"},
+					AllowedScopes:     []supervisorconfigv1alpha1.Scope{"openid"},
+					PrivateKeyJWT:     &supervisorconfigv1alpha1.OIDCClientPrivateKeyJWT{SecretName: "test-client-public-keys"},
+				},
+			}},
+			inputSecrets: []runtime.Object{
+				privateKeyJWTSecret(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: privateKeyJWTPublicKeyDER})),
+			},
+			wantAPIActions: 1, // one update
+			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
+				Status: supervisorconfigv1alpha1.OIDCClientStatus{
+					Phase:              "Ready",
+					ObservedGeneration: 1234,
+					Conditions: []metav1.Condition{
+						happyAllowedGrantTypesCondition(now, 1234),
+						happyAllowedScopesCondition(now, 1234),
+						clientSecretsNotUsedCondition(now, 1234),
+						{
+							Type:               "PrivateKeyJWTValid",
+							Status:             "True",
+							LastTransitionTime: now,
+							Reason:             "Success",
+							Message:            `1 public key(s) found in secret "test-client-public-keys"`,
+							ObservedGeneration: 1234,
+						},
+					},
+					TotalClientSecrets: 0,
+				},
+			}},
+		},
+		{
+			name: "OIDCClient which uses private_key_jwt client authentication when its public keys secret does not exist",
+			inputObjects: []runtime.Object{&supervisorconfigv1alpha1.OIDCClient{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
+				Spec: supervisorconfigv1alpha1.OIDCClientSpec{
+					AllowedGrantTypes: []supervisorconfigv1alpha1.GrantType{"authorization_code"},
+					AllowedScopes:     []supervisorconfigv1alpha1.Scope{"openid"},
+					PrivateKeyJWT:     &supervisorconfigv1alpha1.OIDCClientPrivateKeyJWT{SecretName: "test-client-public-keys"},
+				},
+			}},
+			wantAPIActions: 1, // one update
+			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
+				Status: supervisorconfigv1alpha1.OIDCClientStatus{
+					Phase:              "Error",
+					ObservedGeneration: 1234,
+					Conditions: []metav1.Condition{
+						happyAllowedGrantTypesCondition(now, 1234),
+						happyAllowedScopesCondition(now, 1234),
+						clientSecretsNotUsedCondition(now, 1234),
+						{
+							Type:               "PrivateKeyJWTValid",
+							Status:             "False",
+							LastTransitionTime: now,
+							Reason:             "PrivateKeyJWTSecretNotFound",
+							Message:            `secret "test-client-public-keys" not found`,
+							ObservedGeneration: 1234,
+						},
+					},
+				},
+			}},
+		},
+		{
+			name: "OIDCClient which uses private_key_jwt client authentication when its public keys secret is invalid",
+			inputObjects: []runtime.Object{&supervisorconfigv1alpha1.OIDCClient{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
+				Spec: supervisorconfigv1alpha1.OIDCClientSpec{
+					AllowedGrantTypes: []supervisorconfigv1alpha1.GrantType{"authorization_code"},
+					AllowedScopes:     []supervisorconfigv1alpha1.Scope{"openid"},
+					PrivateKeyJWT:     &supervisorconfigv1alpha1.OIDCClientPrivateKeyJWT{SecretName: "test-client-public-keys"},
+				},
+			}},
+			inputSecrets:   []runtime.Object{privateKeyJWTSecret([]byte("not PEM"))},
+			wantAPIActions: 1, // one update
+			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
+				Status: supervisorconfigv1alpha1.OIDCClientStatus{
+					Phase:              "Error",
+					ObservedGeneration: 1234,
+					Conditions: []metav1.Condition{
+						happyAllowedGrantTypesCondition(now, 1234),
+						happyAllowedScopesCondition(now, 1234),
+						clientSecretsNotUsedCondition(now, 1234),
+						{
+							Type:               "PrivateKeyJWTValid",
+							Status:             "False",
+							LastTransitionTime: now,
+							Reason:             "InvalidPrivateKeyJWTSecret",
+							Message:            `secret "test-client-public-keys" has invalid "publicKeys": no PEM-encoded public keys found`,
+							ObservedGeneration: 1234,
+						},
+					},
+				},
+			}},
+		},
+		{
+			name: "OIDCClient which uses private_key_jwt client authentication when its public keys secret has the wrong type",
+			inputObjects: []runtime.Object{&supervisorconfigv1alpha1.OIDCClient{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
+				Spec: supervisorconfigv1alpha1.OIDCClientSpec{
+					AllowedGrantTypes: []supervisorconfigv1alpha1.GrantType{"authorization_code"},
+					AllowedScopes:     []supervisorconfigv1alpha1.Scope{"openid"},
+					PrivateKeyJWT:     &supervisorconfigv1alpha1.OIDCClientPrivateKeyJWT{SecretName: "test-client-public-keys"},
+				},
+			}},
+			inputSecrets:   []runtime.Object{privateKeyJWTSecretOfType(corev1.SecretTypeOpaque, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: privateKeyJWTPublicKeyDER}))},
+			wantAPIActions: 1, // one update
+			wantResultingOIDCClients: []supervisorconfigv1alpha1.OIDCClient{{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
+				Status: supervisorconfigv1alpha1.OIDCClientStatus{
+					Phase:              "Error",
+					ObservedGeneration: 1234,
+					Conditions: []metav1.Condition{
+						happyAllowedGrantTypesCondition(now, 1234),
+						happyAllowedScopesCondition(now, 1234),
+						clientSecretsNotUsedCondition(now, 1234),
+						{
+							Type:               "PrivateKeyJWTValid",
+							Status:             "False",
+							LastTransitionTime: now,
+							Reason:             "InvalidPrivateKeyJWTSecret",
+							Message:            `secret "test-client-public-keys" has wrong type "Opaque" (should be "secrets.pinniped.dev/oidc-client-public-keys")`,
+							ObservedGeneration: 1234,
+						},
+					},
+				},
+			}},
+		},
 	}
 
 	for _, tt := range tests {
@@ -966,6 +1247,7 @@ func TestOIDCClientWatcherControllerSync(t *testing.T) {
 				fakePinnipedClient,
 				kubeInformers.Core().V1().Secrets(),
 				pinnipedInformers.Config().V1alpha1().OIDCClients(),
+				nil,
 				controllerlib.WithInformer,
 			)
 
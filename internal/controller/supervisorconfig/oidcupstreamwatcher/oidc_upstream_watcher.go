@@ -6,8 +6,12 @@ package oidcupstreamwatcher
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -20,20 +24,26 @@ import (
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/cache"
 	"k8s.io/apimachinery/pkg/util/sets"
 	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/events"
 
+	idpconditions "go.pinniped.dev/generated/latest/apis/supervisor/idp/conditions"
 	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
 	oidcapi "go.pinniped.dev/generated/latest/apis/supervisor/oidc"
 	supervisorclientset "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned"
 	idpinformers "go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions/idp/v1alpha1"
+	"go.pinniped.dev/internal/celtransformer"
 	"go.pinniped.dev/internal/constable"
 	pinnipedcontroller "go.pinniped.dev/internal/controller"
 	"go.pinniped.dev/internal/controller/conditionsutil"
 	"go.pinniped.dev/internal/controller/supervisorconfig/upstreamwatchers"
 	"go.pinniped.dev/internal/controllerlib"
 	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
+	"go.pinniped.dev/internal/googledirectory"
+	"go.pinniped.dev/internal/msgraph"
 	"go.pinniped.dev/internal/net/phttp"
 	"go.pinniped.dev/internal/plog"
 	"go.pinniped.dev/internal/upstreamoidc"
@@ -46,21 +56,49 @@ const (
 	// Constants related to the client credentials Secret.
 	oidcClientSecretType corev1.SecretType = "secrets.pinniped.dev/oidc-client"
 
-	clientIDDataKey     = "clientID"
-	clientSecretDataKey = "clientSecret"
+	clientIDDataKey         = "clientID"
+	clientSecretDataKey     = "clientSecret"
+	clientSecretNextDataKey = "clientSecretNext"
+
+	// Constants related to the optional client certificate Secret.
+	clientCertificateSecretType = corev1.SecretTypeTLS
+
+	// Constants related to the optional Microsoft Graph client credentials Secret.
+	microsoftGraphClientSecretType corev1.SecretType = "secrets.pinniped.dev/microsoft-graph-client"
+
+	// Constants related to the optional Google service account Secret.
+	googleServiceAccountSecretType corev1.SecretType = "secrets.pinniped.dev/google-service-account"
+	serviceAccountKeyDataKey                         = "serviceAccountKey"
+
+	// clientSecretRotationResyncInterval is how often the status is updated during a client secret rotation, since
+	// the active client secret changes when the provider starts to reject the current one, not due to any informer.
+	clientSecretRotationResyncInterval = time.Minute
 
 	// Constants related to the OIDC provider discovery cache. These do not affect the cache of JWKS.
 	oidcValidatorCacheTTL = 15 * time.Minute
 
-	// Constants related to conditions.
-	typeClientCredentialsSecretValid       = "ClientCredentialsSecretValid" //nolint:gosec // this is not a credential
-	typeAdditionalAuthorizeParametersValid = "AdditionalAuthorizeParametersValid"
-	typeOIDCDiscoverySucceeded             = "OIDCDiscoverySucceeded"
+	// groupsExpressionMaxRuntime limits the runtime of the CEL expression of .spec.claims.groupsExpression.
+	groupsExpressionMaxRuntime = 5 * time.Second
 
-	reasonUnreachable             = "Unreachable"
-	reasonInvalidResponse         = "InvalidResponse"
-	reasonDisallowedParameterName = "DisallowedParameterName"
+	// Constants related to conditions.
+	typeClientCredentialsSecretValid       = idpconditions.TypeClientCredentialsSecretValid //nolint:gosec // this is not a credential
+	typeAdditionalAuthorizeParametersValid = idpconditions.TypeAdditionalAuthorizeParametersValid
+	typeClaimsValid                        = idpconditions.TypeClaimsValid
+	typeOIDCDiscoverySucceeded             = idpconditions.TypeOIDCDiscoverySucceeded
+
+	reasonUnreachable             = idpconditions.ReasonUnreachable
+	reasonInvalidResponse         = idpconditions.ReasonInvalidResponse
+	reasonDisallowedParameterName = idpconditions.ReasonDisallowedParameterName
+	reasonInvalid                 = idpconditions.ReasonInvalid
 	allParamNamesAllowedMsg       = "additionalAuthorizeParameters parameter names are allowed"
+	claimsValidMsg                = "spec.claims are valid"
+
+	// resourceParamName is the name of the RFC8707 resource indicator parameter.
+	resourceParamName = "resource"
+
+	// responseModeParamName is the name of the response_mode parameter, which is used to request JWT secured
+	// authorization responses (JARM).
+	responseModeParamName = "response_mode"
 
 	// Errors that are generated by our reconcile process.
 	errOIDCFailureStatus = constable.Error("OIDCIdentityProvider has a failing condition")
@@ -96,29 +134,64 @@ type UpstreamOIDCIdentityProviderICache interface {
 // lruValidatorCache caches the *coreosoidc.Provider associated with a particular issuer/TLS configuration.
 type lruValidatorCache struct{ cache *cache.Expiring }
 
+// clientCertificate is a client certificate which the Supervisor presents to an OIDC identity provider.
+type clientCertificate struct {
+	certificate tls.Certificate
+	fingerprint string // identifies the certificate and private key in the validatorCache
+}
+
+// groupsResolver remembers the settings which were used to create a groups resolver, so that the resolver
+// and its cached access tokens can be reused for as long as the settings do not change.
+type groupsResolver struct {
+	config            any // a msgraph.Config or a googledirectory.Config, which are comparable
+	connectionPool    idpv1alpha1.HTTPConnectionPoolSpec
+	proxyURL, noProxy string
+	resolver          upstreamoidc.GroupsResolver
+}
+
+func (r *groupsResolver) sameSettings(other *groupsResolver) bool {
+	return r.config == other.config && r.connectionPool == other.connectionPool &&
+		r.proxyURL == other.proxyURL && r.noProxy == other.noProxy
+}
+
 type lruValidatorCacheEntry struct {
 	provider *coreosoidc.Provider
 	client   *http.Client
 }
 
-func (c *lruValidatorCache) getProvider(spec *idpv1alpha1.OIDCIdentityProviderSpec) (*coreosoidc.Provider, *http.Client) {
-	if result, ok := c.cache.Get(c.cacheKey(spec)); ok {
+func (c *lruValidatorCache) getProvider(spec *idpv1alpha1.OIDCIdentityProviderSpec, clientCert *clientCertificate) (*coreosoidc.Provider, *http.Client) {
+	if result, ok := c.cache.Get(c.cacheKey(spec, clientCert)); ok {
 		entry := result.(*lruValidatorCacheEntry)
 		return entry.provider, entry.client
 	}
 	return nil, nil
 }
 
-func (c *lruValidatorCache) putProvider(spec *idpv1alpha1.OIDCIdentityProviderSpec, provider *coreosoidc.Provider, client *http.Client) {
-	c.cache.Set(c.cacheKey(spec), &lruValidatorCacheEntry{provider: provider, client: client}, oidcValidatorCacheTTL)
+func (c *lruValidatorCache) putProvider(spec *idpv1alpha1.OIDCIdentityProviderSpec, clientCert *clientCertificate, provider *coreosoidc.Provider, client *http.Client) {
+	c.cache.Set(c.cacheKey(spec, clientCert), &lruValidatorCacheEntry{provider: provider, client: client}, oidcValidatorCacheTTL)
 }
 
-func (c *lruValidatorCache) cacheKey(spec *idpv1alpha1.OIDCIdentityProviderSpec) any {
-	var key struct{ issuer, caBundle string }
+func (c *lruValidatorCache) cacheKey(spec *idpv1alpha1.OIDCIdentityProviderSpec, clientCert *clientCertificate) any {
+	var key struct {
+		issuer, caBundle  string
+		connectionPool    idpv1alpha1.HTTPConnectionPoolSpec
+		proxyURL, noProxy string
+		clientCertificate string
+	}
 	key.issuer = spec.Issuer
 	if spec.TLS != nil {
 		key.caBundle = spec.TLS.CertificateAuthorityData
 	}
+	if spec.ConnectionPool != nil {
+		key.connectionPool = *spec.ConnectionPool
+	}
+	if spec.Proxy != nil {
+		key.proxyURL = spec.Proxy.URL
+		key.noProxy = strings.Join(spec.Proxy.NoProxy, ",")
+	}
+	if clientCert != nil {
+		key.clientCertificate = clientCert.fingerprint
+	}
 	return key
 }
 
@@ -128,9 +201,13 @@ type oidcWatcherController struct {
 	client                       supervisorclientset.Interface
 	oidcIdentityProviderInformer idpinformers.OIDCIdentityProviderInformer
 	secretInformer               corev1informers.SecretInformer
+	allowedSecretDirectories     []string
+	clientSecretRotations        map[types.UID]*upstreamoidc.ClientSecretRotation
+	groupsResolvers              map[types.UID]*groupsResolver
+	groupsExpressions            map[types.UID]*celtransformer.GroupsExpression
 	validatorCache               interface {
-		getProvider(*idpv1alpha1.OIDCIdentityProviderSpec) (*coreosoidc.Provider, *http.Client)
-		putProvider(*idpv1alpha1.OIDCIdentityProviderSpec, *coreosoidc.Provider, *http.Client)
+		getProvider(*idpv1alpha1.OIDCIdentityProviderSpec, *clientCertificate) (*coreosoidc.Provider, *http.Client)
+		putProvider(*idpv1alpha1.OIDCIdentityProviderSpec, *clientCertificate, *coreosoidc.Provider, *http.Client)
 	}
 }
 
@@ -142,6 +219,8 @@ func New(
 	secretInformer corev1informers.SecretInformer,
 	log plog.Logger,
 	withInformer pinnipedcontroller.WithInformerOptionFunc,
+	recorder events.EventRecorder,
+	allowedSecretDirectories []string,
 ) controllerlib.Controller {
 	c := oidcWatcherController{
 		cache:                        idpCache,
@@ -149,6 +228,10 @@ func New(
 		client:                       client,
 		oidcIdentityProviderInformer: oidcIdentityProviderInformer,
 		secretInformer:               secretInformer,
+		allowedSecretDirectories:     allowedSecretDirectories,
+		clientSecretRotations:        map[types.UID]*upstreamoidc.ClientSecretRotation{},
+		groupsResolvers:              map[types.UID]*groupsResolver{},
+		groupsExpressions:            map[types.UID]*celtransformer.GroupsExpression{},
 		validatorCache:               &lruValidatorCache{cache: cache.NewExpiring()},
 	}
 	return controllerlib.New(
@@ -160,9 +243,16 @@ func New(
 		),
 		withInformer(
 			secretInformer,
-			pinnipedcontroller.MatchAnySecretOfTypeFilter(oidcClientSecretType, pinnipedcontroller.SingletonQueue()),
+			pinnipedcontroller.SimpleFilter(func(obj metav1.Object) bool {
+				secret, ok := obj.(*corev1.Secret)
+				return ok && (secret.Type == oidcClientSecretType ||
+					secret.Type == clientCertificateSecretType ||
+					secret.Type == microsoftGraphClientSecretType ||
+					secret.Type == googleServiceAccountSecretType)
+			}, pinnipedcontroller.SingletonQueue()),
 			controllerlib.InformerOption{},
 		),
+		controllerlib.WithRecorder(recorder),
 	)
 }
 
@@ -174,16 +264,45 @@ func (c *oidcWatcherController) Sync(ctx controllerlib.Context) error {
 	}
 
 	requeue := false
+	usesClientSecretFiles := false
+	rotatingClientSecrets := false
 	validatedUpstreams := make([]upstreamprovider.UpstreamOIDCIdentityProviderI, 0, len(actualUpstreams))
+	actualUIDs := sets.New[types.UID]()
 	for _, upstream := range actualUpstreams {
+		actualUIDs.Insert(upstream.UID)
+		usesClientSecretFiles = usesClientSecretFiles || upstreamwatchers.UsesClientSecretFiles(upstream.Spec.Client.SecretRef)
 		valid := c.validateUpstream(ctx, upstream)
 		if valid == nil {
 			requeue = true
 		} else {
+			rotatingClientSecrets = rotatingClientSecrets || valid.ClientSecretRotation != nil
 			validatedUpstreams = append(validatedUpstreams, upstreamprovider.UpstreamOIDCIdentityProviderI(valid))
 		}
 	}
 	c.cache.SetOIDCIdentityProviders(validatedUpstreams)
+	for uid := range c.clientSecretRotations {
+		if !actualUIDs.Has(uid) {
+			delete(c.clientSecretRotations, uid)
+		}
+	}
+	for uid := range c.groupsResolvers {
+		if !actualUIDs.Has(uid) {
+			delete(c.groupsResolvers, uid)
+		}
+	}
+	for uid := range c.groupsExpressions {
+		if !actualUIDs.Has(uid) {
+			delete(c.groupsExpressions, uid)
+		}
+	}
+	switch {
+	case usesClientSecretFiles:
+		// Changes to files are not observed by any informer, so re-read them periodically.
+		ctx.Queue.AddAfter(ctx.Key, upstreamwatchers.ClientSecretFilesResyncInterval)
+	case rotatingClientSecrets:
+		// Keep the status up to date with the active client secret.
+		ctx.Queue.AddAfter(ctx.Key, clientSecretRotationResyncInterval)
+	}
 	if requeue {
 		return controllerlib.ErrSyntheticRequeue
 	}
@@ -198,29 +317,42 @@ func (c *oidcWatcherController) validateUpstream(ctx controllerlib.Context, upst
 	additionalAuthcodeAuthorizeParameters := map[string]string{}
 	var rejectedAuthcodeAuthorizeParameters []string
 	for _, p := range authorizationConfig.AdditionalAuthorizeParameters {
-		if disallowedAdditionalAuthorizeParameters[p.Name] {
+		// When the resource setting is used, do not allow it to be overridden by an additionalAuthorizeParameter.
+		resourceConflict := p.Name == resourceParamName && authorizationConfig.Resource != ""
+		// Likewise, do not allow the response mode to be changed when JWT secured authorization responses are used.
+		responseModeConflict := p.Name == responseModeParamName && authorizationConfig.UseJWTSecuredAuthorizationResponses
+		if disallowedAdditionalAuthorizeParameters[p.Name] || resourceConflict || responseModeConflict {
 			rejectedAuthcodeAuthorizeParameters = append(rejectedAuthcodeAuthorizeParameters, p.Name)
 		} else {
 			additionalAuthcodeAuthorizeParameters[p.Name] = p.Value
 		}
 	}
+	if authorizationConfig.Resource != "" {
+		additionalAuthcodeAuthorizeParameters[resourceParamName] = authorizationConfig.Resource
+	}
+	if authorizationConfig.UseJWTSecuredAuthorizationResponses {
+		additionalAuthcodeAuthorizeParameters[responseModeParamName] = "query.jwt"
+	}
 
 	result := upstreamoidc.ProviderConfig{
 		Name: upstream.Name,
 		Config: &oauth2.Config{
 			Scopes: computeScopes(authorizationConfig.AdditionalScopes),
 		},
-		UsernameClaim:            upstream.Spec.Claims.Username,
-		GroupsClaim:              upstream.Spec.Claims.Groups,
-		AllowPasswordGrant:       authorizationConfig.AllowPasswordGrant,
-		AdditionalAuthcodeParams: additionalAuthcodeAuthorizeParameters,
-		AdditionalClaimMappings:  upstream.Spec.Claims.AdditionalClaimMappings,
-		ResourceUID:              upstream.UID,
+		UsernameClaim:                       upstream.Spec.Claims.Username,
+		GroupsClaim:                         upstream.Spec.Claims.Groups,
+		AllowPasswordGrant:                  authorizationConfig.AllowPasswordGrant,
+		UseJWTSecuredAuthorizationResponses: authorizationConfig.UseJWTSecuredAuthorizationResponses,
+		AdditionalAuthcodeParams:            additionalAuthcodeAuthorizeParameters,
+		AdditionalClaimMappings:             upstream.Spec.Claims.AdditionalClaimMappings,
+		Resource:                            authorizationConfig.Resource,
+		ResourceUID:                         upstream.UID,
 	}
 
+	clientCredentialsCondition, clientCert := c.validateClientCredentials(upstream, &result)
 	conditions := []*metav1.Condition{
-		c.validateSecret(upstream, &result),
-		c.validateIssuer(ctx.Context, upstream, &result),
+		clientCredentialsCondition,
+		c.validateIssuer(ctx.Context, upstream, clientCert, &result),
 	}
 	if len(rejectedAuthcodeAuthorizeParameters) > 0 {
 		conditions = append(conditions, &metav1.Condition{
@@ -238,8 +370,9 @@ func (c *oidcWatcherController) validateUpstream(ctx controllerlib.Context, upst
 			Message: allParamNamesAllowedMsg,
 		})
 	}
+	conditions = append(conditions, c.validateClaims(upstream, &result))
 
-	c.updateStatus(ctx.Context, upstream, conditions)
+	c.updateStatus(ctx, upstream, conditions)
 
 	valid := true
 	log := c.log.WithValues("namespace", upstream.Namespace, "name", upstream.Name)
@@ -259,8 +392,283 @@ func (c *oidcWatcherController) validateUpstream(ctx controllerlib.Context, upst
 	return nil
 }
 
+// validateClaims compiles the optional .spec.claims.groupsExpression field and returns the appropriate ClaimsValid
+// condition. Compiled expressions are reused for as long as the expression does not change.
+func (c *oidcWatcherController) validateClaims(upstream *idpv1alpha1.OIDCIdentityProvider, result *upstreamoidc.ProviderConfig) *metav1.Condition {
+	expression := upstream.Spec.Claims.GroupsExpression
+	if expression == "" {
+		delete(c.groupsExpressions, upstream.UID)
+	} else {
+		groupsExpression, ok := c.groupsExpressions[upstream.UID]
+		if !ok || groupsExpression.Expression() != expression {
+			var err error
+			groupsExpression, err = celtransformer.CompileGroupsExpression(expression, groupsExpressionMaxRuntime)
+			if err != nil {
+				delete(c.groupsExpressions, upstream.UID)
+				return &metav1.Condition{
+					Type:    typeClaimsValid,
+					Status:  metav1.ConditionFalse,
+					Reason:  reasonInvalid,
+					Message: fmt.Sprintf("spec.claims.groupsExpression is invalid: %s", err.Error()),
+				}
+			}
+			c.groupsExpressions[upstream.UID] = groupsExpression
+		}
+		result.GroupsExpression = groupsExpression
+	}
+
+	return &metav1.Condition{
+		Type:    typeClaimsValid,
+		Status:  metav1.ConditionTrue,
+		Reason:  upstreamwatchers.ReasonSuccess,
+		Message: claimsValidMsg,
+	}
+}
+
+// validateClientCredentials validates the client secret, the optional .spec.client.certificateSecretName field, and the
+// credentials of the optional .spec.groupsLookup field, and returns the appropriate ClientCredentialsSecretValid
+// condition and the client certificate, if any.
+func (c *oidcWatcherController) validateClientCredentials(upstream *idpv1alpha1.OIDCIdentityProvider, result *upstreamoidc.ProviderConfig) (*metav1.Condition, *clientCertificate) {
+	condition := c.validateSecret(upstream, result)
+	if condition.Status != metav1.ConditionTrue {
+		return condition, nil
+	}
+
+	var clientCert *clientCertificate
+	if certSecretName := upstream.Spec.Client.CertificateSecretName; certSecretName != "" {
+		var certCondition *metav1.Condition
+		clientCert, certCondition = c.validateClientCertificate(upstream.Namespace, certSecretName)
+		if certCondition != nil {
+			return certCondition, nil
+		}
+		condition.Message = fmt.Sprintf("%s; loaded client certificate from Secret %q", condition.Message, certSecretName)
+	}
+
+	groupsLookupMessage, groupsLookupCondition := c.validateGroupsLookup(upstream, result)
+	if groupsLookupCondition != nil {
+		return groupsLookupCondition, nil
+	}
+	if groupsLookupMessage != "" {
+		condition.Message = fmt.Sprintf("%s; %s", condition.Message, groupsLookupMessage)
+	}
+	return condition, clientCert
+}
+
+// validateGroupsLookup loads the credentials of the optional .spec.groupsLookup field and configures the groups
+// resolver of the result. It returns a message to add to the ClientCredentialsSecretValid condition, or a failed
+// ClientCredentialsSecretValid condition when the credentials are not usable.
+func (c *oidcWatcherController) validateGroupsLookup(upstream *idpv1alpha1.OIDCIdentityProvider, result *upstreamoidc.ProviderConfig) (string, *metav1.Condition) {
+	groupsLookup := upstream.Spec.GroupsLookup
+	if groupsLookup == nil {
+		delete(c.groupsResolvers, upstream.UID)
+		return "", nil
+	}
+
+	var settings groupsResolver
+	var host, message string
+	switch {
+	case groupsLookup.MicrosoftGraph != nil:
+		graph := groupsLookup.MicrosoftGraph
+		clientID, clientSecret, condition := c.validateMicrosoftGraphSecret(upstream.Namespace, graph.SecretName)
+		if condition != nil {
+			return "", condition
+		}
+		settings.config = msgraph.Config{
+			GraphURL:            graph.URL,
+			AuthorityURL:        graph.AuthorityURL,
+			ClientID:            clientID,
+			ClientSecret:        clientSecret,
+			SecurityEnabledOnly: graph.SecurityEnabledOnly,
+		}
+		host = graph.URL
+		message = fmt.Sprintf("loaded Microsoft Graph credentials from Secret %q", graph.SecretName)
+	case groupsLookup.GoogleWorkspace != nil:
+		google := groupsLookup.GoogleWorkspace
+		serviceAccountKey, condition := c.validateGoogleServiceAccountSecret(upstream.Namespace, google.SecretName)
+		if condition != nil {
+			return "", condition
+		}
+		settings.config = googledirectory.Config{
+			ServiceAccountKey: serviceAccountKey,
+			AdminEmail:        google.AdminEmail,
+			GroupsAttribute:   google.GroupsAttribute,
+		}
+		host = googledirectory.DefaultDirectoryURL
+		message = fmt.Sprintf("loaded Google service account key from Secret %q", google.SecretName)
+	default:
+		delete(c.groupsResolvers, upstream.UID)
+		return "", nil
+	}
+	if upstream.Spec.ConnectionPool != nil {
+		settings.connectionPool = *upstream.Spec.ConnectionPool
+	}
+	if upstream.Spec.Proxy != nil {
+		settings.proxyURL = upstream.Spec.Proxy.URL
+		settings.noProxy = strings.Join(upstream.Spec.Proxy.NoProxy, ",")
+	}
+
+	// Keep using the same resolver while its settings are unchanged, to reuse its cached access tokens.
+	if cached, ok := c.groupsResolvers[upstream.UID]; ok && cached.sameSettings(&settings) {
+		result.GroupsResolver = cached.resolver
+		return message, nil
+	}
+
+	// An invalid proxy is reported by the OIDCDiscoverySucceeded condition, which also makes the provider unusable.
+	proxy, _ := upstreamwatchers.Proxy(upstream.Spec.Proxy)
+	if parsedURL, err := url.Parse(host); err == nil && parsedURL.Host != "" {
+		host = parsedURL.Host
+	}
+	pool := upstreamwatchers.ConnectionPool(host, upstream.Spec.ConnectionPool)
+	pool.Proxy = proxy
+	httpClient := defaultClientShortTimeout(nil, pool)
+
+	switch config := settings.config.(type) {
+	case msgraph.Config:
+		settings.resolver = msgraph.New(config, httpClient)
+	case googledirectory.Config:
+		resolver, err := googledirectory.New(config, httpClient)
+		if err != nil {
+			delete(c.groupsResolvers, upstream.UID)
+			return "", &metav1.Condition{
+				Type:    typeClientCredentialsSecretValid,
+				Status:  metav1.ConditionFalse,
+				Reason:  reasonInvalid,
+				Message: fmt.Sprintf("referenced Secret %q does not contain a valid service account key: %s", groupsLookup.GoogleWorkspace.SecretName, err.Error()),
+			}
+		}
+		settings.resolver = resolver
+	}
+	c.groupsResolvers[upstream.UID] = &settings
+	result.GroupsResolver = settings.resolver
+	return message, nil
+}
+
+// validateMicrosoftGraphSecret loads the client credentials of the Microsoft Graph API from the referenced Secret.
+// It returns a failed ClientCredentialsSecretValid condition when the Secret is not usable.
+func (c *oidcWatcherController) validateMicrosoftGraphSecret(namespace, secretName string) (string, string, *metav1.Condition) {
+	secret, err := c.secretInformer.Lister().Secrets(namespace).Get(secretName)
+	if err != nil {
+		return "", "", &metav1.Condition{
+			Type:    typeClientCredentialsSecretValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  upstreamwatchers.ReasonNotFound,
+			Message: fmt.Sprintf("failed to get Microsoft Graph client credentials Secret: %s", err.Error()),
+		}
+	}
+
+	if secret.Type != microsoftGraphClientSecretType {
+		return "", "", &metav1.Condition{
+			Type:    typeClientCredentialsSecretValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  upstreamwatchers.ReasonWrongType,
+			Message: fmt.Sprintf("referenced Secret %q has wrong type %q (should be %q)", secretName, secret.Type, microsoftGraphClientSecretType),
+		}
+	}
+
+	clientID := secret.Data[clientIDDataKey]
+	clientSecret := secret.Data[clientSecretDataKey]
+	if len(clientID) == 0 || len(clientSecret) == 0 {
+		return "", "", &metav1.Condition{
+			Type:    typeClientCredentialsSecretValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  upstreamwatchers.ReasonMissingKeys,
+			Message: fmt.Sprintf("referenced Secret %q is missing required keys %q", secretName, []string{clientIDDataKey, clientSecretDataKey}),
+		}
+	}
+
+	return string(clientID), string(clientSecret), nil
+}
+
+// validateGoogleServiceAccountSecret loads the service account key for the Directory API from the referenced Secret.
+// It returns a failed ClientCredentialsSecretValid condition when the Secret is not usable.
+func (c *oidcWatcherController) validateGoogleServiceAccountSecret(namespace, secretName string) (string, *metav1.Condition) {
+	secret, err := c.secretInformer.Lister().Secrets(namespace).Get(secretName)
+	if err != nil {
+		return "", &metav1.Condition{
+			Type:    typeClientCredentialsSecretValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  upstreamwatchers.ReasonNotFound,
+			Message: fmt.Sprintf("failed to get Google service account Secret: %s", err.Error()),
+		}
+	}
+
+	if secret.Type != googleServiceAccountSecretType {
+		return "", &metav1.Condition{
+			Type:    typeClientCredentialsSecretValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  upstreamwatchers.ReasonWrongType,
+			Message: fmt.Sprintf("referenced Secret %q has wrong type %q (should be %q)", secretName, secret.Type, googleServiceAccountSecretType),
+		}
+	}
+
+	serviceAccountKey := secret.Data[serviceAccountKeyDataKey]
+	if len(serviceAccountKey) == 0 {
+		return "", &metav1.Condition{
+			Type:    typeClientCredentialsSecretValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  upstreamwatchers.ReasonMissingKeys,
+			Message: fmt.Sprintf("referenced Secret %q is missing required keys %q", secretName, []string{serviceAccountKeyDataKey}),
+		}
+	}
+
+	return string(serviceAccountKey), nil
+}
+
+// validateClientCertificate loads the client certificate and private key from the referenced Secret. It returns a
+// failed ClientCredentialsSecretValid condition when the Secret is not usable.
+func (c *oidcWatcherController) validateClientCertificate(namespace, secretName string) (*clientCertificate, *metav1.Condition) {
+	secret, err := c.secretInformer.Lister().Secrets(namespace).Get(secretName)
+	if err != nil {
+		return nil, &metav1.Condition{
+			Type:    typeClientCredentialsSecretValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  upstreamwatchers.ReasonNotFound,
+			Message: fmt.Sprintf("failed to get client certificate Secret: %s", err.Error()),
+		}
+	}
+
+	if secret.Type != clientCertificateSecretType {
+		return nil, &metav1.Condition{
+			Type:    typeClientCredentialsSecretValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  upstreamwatchers.ReasonWrongType,
+			Message: fmt.Sprintf("referenced Secret %q has wrong type %q (should be %q)", secretName, secret.Type, clientCertificateSecretType),
+		}
+	}
+
+	certPEM := secret.Data[corev1.TLSCertKey]
+	keyPEM := secret.Data[corev1.TLSPrivateKeyKey]
+	if len(certPEM) == 0 || len(keyPEM) == 0 {
+		return nil, &metav1.Condition{
+			Type:    typeClientCredentialsSecretValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  upstreamwatchers.ReasonMissingKeys,
+			Message: fmt.Sprintf("referenced Secret %q is missing required keys %q", secretName, []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey}),
+		}
+	}
+
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, &metav1.Condition{
+			Type:    typeClientCredentialsSecretValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  reasonInvalid,
+			Message: fmt.Sprintf("referenced Secret %q does not contain a valid client certificate and private key: %s", secretName, err.Error()),
+		}
+	}
+
+	fingerprint := sha256.New()
+	_, _ = fingerprint.Write(certPEM)
+	_, _ = fingerprint.Write(keyPEM)
+	return &clientCertificate{certificate: certificate, fingerprint: hex.EncodeToString(fingerprint.Sum(nil))}, nil
+}
+
 // validateSecret validates the .spec.client.secretName field and returns the appropriate ClientCredentialsSecretValid condition.
 func (c *oidcWatcherController) validateSecret(upstream *idpv1alpha1.OIDCIdentityProvider, result *upstreamoidc.ProviderConfig) *metav1.Condition {
+	if upstream.Spec.Client.SecretRef != nil {
+		return c.validateSecretRef(upstream.Spec.Client.SecretRef, result)
+	}
+
 	secretName := upstream.Spec.Client.SecretName
 
 	// Fetch the Secret from informer cache.
@@ -299,23 +707,88 @@ func (c *oidcWatcherController) validateSecret(upstream *idpv1alpha1.OIDCIdentit
 	// If everything is valid, update the result and set the condition to true.
 	result.Config.ClientID = string(clientID)
 	result.Config.ClientSecret = string(clientSecret)
+
+	clientSecretNext := string(secret.Data[clientSecretNextDataKey])
+	if clientSecretNext == "" || clientSecretNext == string(clientSecret) {
+		delete(c.clientSecretRotations, upstream.UID)
+		return &metav1.Condition{
+			Type:    typeClientCredentialsSecretValid,
+			Status:  metav1.ConditionTrue,
+			Reason:  upstreamwatchers.ReasonSuccess,
+			Message: "loaded client credentials",
+		}
+	}
+
+	// Keep using the same rotation while the client secrets are unchanged, to remember which one is active.
+	rotation, ok := c.clientSecretRotations[upstream.UID]
+	if !ok || !rotation.Matches(string(clientSecret), clientSecretNext) {
+		rotation = upstreamoidc.NewClientSecretRotation(string(clientSecret), clientSecretNext)
+		c.clientSecretRotations[upstream.UID] = rotation
+	}
+	result.ClientSecretRotation = rotation
+
+	activeKey := clientSecretDataKey
+	if rotation.NextIsActive() {
+		activeKey = clientSecretNextDataKey
+	}
+	return &metav1.Condition{
+		Type:    typeClientCredentialsSecretValid,
+		Status:  metav1.ConditionTrue,
+		Reason:  upstreamwatchers.ReasonSuccess,
+		Message: fmt.Sprintf("loaded client credentials for rotation, using active client secret from key %q", activeKey),
+	}
+}
+
+// validateSecretRef reads the client credentials from the files referenced by .spec.client.secretRef and returns
+// the appropriate ClientCredentialsSecretValid condition.
+func (c *oidcWatcherController) validateSecretRef(secretRef *idpv1alpha1.ClientSecretRef, result *upstreamoidc.ProviderConfig) *metav1.Condition {
+	clientID, clientSecret, err := upstreamwatchers.ReadClientSecretFiles(secretRef, c.allowedSecretDirectories)
+	if err != nil {
+		reason := upstreamwatchers.ReasonNotFound
+		if errors.Is(err, upstreamwatchers.ErrClientSecretFileEmpty) {
+			reason = upstreamwatchers.ReasonMissingKeys
+		}
+		return &metav1.Condition{
+			Type:    typeClientCredentialsSecretValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  reason,
+			Message: fmt.Sprintf("failed to read client credentials from spec.client.secretRef: %s", err.Error()),
+		}
+	}
+
+	result.Config.ClientID = clientID
+	result.Config.ClientSecret = clientSecret
 	return &metav1.Condition{
 		Type:    typeClientCredentialsSecretValid,
 		Status:  metav1.ConditionTrue,
 		Reason:  upstreamwatchers.ReasonSuccess,
-		Message: "loaded client credentials",
+		Message: fmt.Sprintf("loaded client credentials from files in %q", secretRef.Path),
 	}
 }
 
 // validateIssuer validates the .spec.issuer field, performs OIDC discovery, and returns the appropriate OIDCDiscoverySucceeded condition.
-func (c *oidcWatcherController) validateIssuer(ctx context.Context, upstream *idpv1alpha1.OIDCIdentityProvider, result *upstreamoidc.ProviderConfig) *metav1.Condition {
+func (c *oidcWatcherController) validateIssuer(
+	ctx context.Context,
+	upstream *idpv1alpha1.OIDCIdentityProvider,
+	clientCert *clientCertificate,
+	result *upstreamoidc.ProviderConfig,
+) *metav1.Condition {
 	// Get the provider and HTTP Client from cache if possible.
-	discoveredProvider, httpClient := c.validatorCache.getProvider(&upstream.Spec)
+	discoveredProvider, httpClient := c.validatorCache.getProvider(&upstream.Spec, clientCert)
 
 	// If the provider does not exist in the cache, do a fresh discovery lookup and save to the cache.
 	if discoveredProvider == nil {
-		var err error
-		httpClient, err = getClient(upstream)
+		proxy, err := upstreamwatchers.Proxy(upstream.Spec.Proxy)
+		if err != nil {
+			return &metav1.Condition{
+				Type:    typeOIDCDiscoverySucceeded,
+				Status:  metav1.ConditionFalse,
+				Reason:  reasonInvalid,
+				Message: fmt.Sprintf("spec.proxy.url is invalid: %s", err),
+			}
+		}
+
+		httpClient, err = getClient(upstream, proxy, clientCert)
 		if err != nil {
 			return &metav1.Condition{
 				Type:    typeOIDCDiscoverySucceeded,
@@ -346,13 +819,18 @@ func (c *oidcWatcherController) validateIssuer(ctx context.Context, upstream *id
 		}
 
 		// Update the cache with the newly discovered value.
-		c.validatorCache.putProvider(&upstream.Spec, discoveredProvider, httpClient)
+		c.validatorCache.putProvider(&upstream.Spec, clientCert, discoveredProvider, httpClient)
 	}
 
 	// Get the revocation endpoint, if there is one. Many providers do not offer a revocation endpoint.
 	var additionalDiscoveryClaims struct {
 		// "revocation_endpoint" is specified by https://datatracker.ietf.org/doc/html/rfc8414#section-2
 		RevocationEndpoint string `json:"revocation_endpoint"`
+		// "mtls_endpoint_aliases" is specified by https://datatracker.ietf.org/doc/html/rfc8705#section-5
+		MTLSEndpointAliases struct {
+			TokenEndpoint      string `json:"token_endpoint"`
+			RevocationEndpoint string `json:"revocation_endpoint"`
+		} `json:"mtls_endpoint_aliases"`
 	}
 	if err := discoveredProvider.Claims(&additionalDiscoveryClaims); err != nil {
 		// This shouldn't actually happen because the above call to NewProvider() would have already returned this error.
@@ -363,6 +841,17 @@ func (c *oidcWatcherController) validateIssuer(ctx context.Context, upstream *id
 			Message: fmt.Sprintf("failed to unmarshal OIDC discovery response from %q:\n%s", upstream.Spec.Issuer, pinnipedcontroller.TruncateMostLongErr(err)),
 		}
 	}
+	endpoint := discoveredProvider.Endpoint()
+	// When presenting a client certificate, the provider may require the use of different endpoints.
+	if clientCert != nil {
+		if alias := additionalDiscoveryClaims.MTLSEndpointAliases.TokenEndpoint; alias != "" {
+			endpoint.TokenURL = alias
+		}
+		if alias := additionalDiscoveryClaims.MTLSEndpointAliases.RevocationEndpoint; alias != "" {
+			additionalDiscoveryClaims.RevocationEndpoint = alias
+		}
+	}
+
 	if additionalDiscoveryClaims.RevocationEndpoint != "" {
 		// Found a revocation URL. Validate it.
 		revocationURL, revocationURLCondition := validateHTTPSURL(
@@ -378,7 +867,7 @@ func (c *oidcWatcherController) validateIssuer(ctx context.Context, upstream *id
 	}
 
 	_, authorizeURLCondition := validateHTTPSURL(
-		discoveredProvider.Endpoint().AuthURL,
+		endpoint.AuthURL,
 		"authorization endpoint",
 		reasonInvalidResponse,
 	)
@@ -387,7 +876,7 @@ func (c *oidcWatcherController) validateIssuer(ctx context.Context, upstream *id
 	}
 
 	_, tokenURLCondition := validateHTTPSURL(
-		discoveredProvider.Endpoint().TokenURL,
+		endpoint.TokenURL,
 		"token endpoint",
 		reasonInvalidResponse,
 	)
@@ -396,7 +885,7 @@ func (c *oidcWatcherController) validateIssuer(ctx context.Context, upstream *id
 	}
 
 	// If everything is valid, update the result and set the condition to true.
-	result.Config.Endpoint = discoveredProvider.Endpoint()
+	result.Config.Endpoint = endpoint
 	result.Provider = discoveredProvider
 	result.Client = httpClient
 	return &metav1.Condition{
@@ -407,7 +896,7 @@ func (c *oidcWatcherController) validateIssuer(ctx context.Context, upstream *id
 	}
 }
 
-func (c *oidcWatcherController) updateStatus(ctx context.Context, upstream *idpv1alpha1.OIDCIdentityProvider, conditions []*metav1.Condition) {
+func (c *oidcWatcherController) updateStatus(ctx controllerlib.Context, upstream *idpv1alpha1.OIDCIdentityProvider, conditions []*metav1.Condition) {
 	log := c.log.WithValues("namespace", upstream.Namespace, "name", upstream.Name)
 	updated := upstream.DeepCopy()
 
@@ -425,15 +914,28 @@ func (c *oidcWatcherController) updateStatus(ctx context.Context, upstream *idpv
 	_, err := c.client.
 		IDPV1alpha1().
 		OIDCIdentityProviders(upstream.Namespace).
-		UpdateStatus(ctx, updated, metav1.UpdateOptions{})
+		UpdateStatus(ctx.Context, updated, metav1.UpdateOptions{})
 	if err != nil {
 		log.Error("failed to update status", err)
+		return
 	}
+	conditionsutil.RecordConditionChanges(ctx.Recorder, updated, upstream.Status.Conditions, updated.Status.Conditions)
 }
 
-func getClient(upstream *idpv1alpha1.OIDCIdentityProvider) (*http.Client, error) {
+func getClient(upstream *idpv1alpha1.OIDCIdentityProvider, proxy *phttp.Proxy, clientCert *clientCertificate) (*http.Client, error) {
+	// The issuer URL is validated later, so just use its raw value when it cannot be parsed.
+	host := upstream.Spec.Issuer
+	if issuerURL, err := url.Parse(upstream.Spec.Issuer); err == nil && issuerURL.Host != "" {
+		host = issuerURL.Host
+	}
+	pool := upstreamwatchers.ConnectionPool(host, upstream.Spec.ConnectionPool)
+	pool.Proxy = proxy
+	if clientCert != nil {
+		pool.ClientCertificate = &clientCert.certificate
+	}
+
 	if upstream.Spec.TLS == nil || upstream.Spec.TLS.CertificateAuthorityData == "" {
-		return defaultClientShortTimeout(nil), nil
+		return defaultClientShortTimeout(nil, pool), nil
 	}
 
 	bundle, err := base64.StdEncoding.DecodeString(upstream.Spec.TLS.CertificateAuthorityData)
@@ -446,11 +948,11 @@ func getClient(upstream *idpv1alpha1.OIDCIdentityProvider) (*http.Client, error)
 		return nil, fmt.Errorf("spec.certificateAuthorityData is invalid: %w", upstreamwatchers.ErrNoCertificates)
 	}
 
-	return defaultClientShortTimeout(rootCAs), nil
+	return defaultClientShortTimeout(rootCAs, pool), nil
 }
 
-func defaultClientShortTimeout(rootCAs *x509.CertPool) *http.Client {
-	c := phttp.Default(rootCAs)
+func defaultClientShortTimeout(rootCAs *x509.CertPool, pool phttp.ConnectionPool) *http.Client {
+	c := phttp.DefaultWithConnectionPool(rootCAs, pool)
 	c.Timeout = time.Minute
 	return c
 }
@@ -0,0 +1,468 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package openshiftupstreamwatcher implements a controller which watches OpenShiftIdentityProviders.
+package openshiftupstreamwatcher
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/utils/clock"
+
+	idpconditions "go.pinniped.dev/generated/latest/apis/supervisor/idp/conditions"
+	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
+	supervisorclientset "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned"
+	idpinformers "go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions/idp/v1alpha1"
+	pinnipedcontroller "go.pinniped.dev/internal/controller"
+	"go.pinniped.dev/internal/controller/conditionsutil"
+	"go.pinniped.dev/internal/controller/supervisorconfig/upstreamwatchers"
+	"go.pinniped.dev/internal/controllerlib"
+	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
+	"go.pinniped.dev/internal/net/phttp"
+	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/internal/upstreamopenshift"
+)
+
+const (
+	controllerName = "openshift-upstream-observer"
+
+	// Constants related to the client credentials Secret.
+	openShiftClientSecretType            corev1.SecretType = "secrets.pinniped.dev/openshift-client"
+	clientIDDataKey, clientSecretDataKey string            = "clientID", "clientSecret"
+
+	countExpectedConditions = 4
+
+	ClientCredentialsSecretValid string = idpconditions.TypeClientCredentialsSecretValid //nolint:gosec // this is not a credential
+	ClaimsValid                  string = idpconditions.TypeClaimsValid
+	TLSConfigurationValid        string = idpconditions.TypeTLSConfigurationValid
+	OAuthDiscoverySucceeded      string = idpconditions.TypeOAuthDiscoverySucceeded
+
+	// oauthDiscoveryPath is the path of the endpoint of the OpenShift API server which describes the
+	// internal OAuth server of the cluster, as defined by RFC 8414.
+	oauthDiscoveryPath = "/.well-known/oauth-authorization-server"
+
+	// maxDiscoveryResponseBytes limits how much of the discovery response is read.
+	maxDiscoveryResponseBytes = 1 << 20
+
+	discoveryTimeout = 30 * time.Second
+)
+
+// defaultScopes are the scopes requested from the OAuth server of OpenShift. The "user:info" scope
+// allows the Supervisor to read the user's name, UID, and groups, and nothing else.
+var defaultScopes = []string{"user:info"}
+
+// UpstreamOpenShiftIdentityProviderICache is a thread safe cache that holds a list of validated upstream
+// OpenShift IDP configurations.
+type UpstreamOpenShiftIdentityProviderICache interface {
+	SetOpenShiftIdentityProviders([]upstreamprovider.UpstreamOpenShiftIdentityProviderI)
+}
+
+type openShiftWatcherController struct {
+	namespace                  string
+	cache                      UpstreamOpenShiftIdentityProviderICache
+	log                        plog.Logger
+	client                     supervisorclientset.Interface
+	openShiftIdentityProviders idpinformers.OpenShiftIdentityProviderInformer
+	secretInformer             corev1informers.SecretInformer
+	clock                      clock.Clock
+}
+
+// New instantiates a new controllerlib.Controller which will populate the provided
+// UpstreamOpenShiftIdentityProviderICache.
+func New(
+	namespace string,
+	idpCache UpstreamOpenShiftIdentityProviderICache,
+	client supervisorclientset.Interface,
+	openShiftIdentityProviderInformer idpinformers.OpenShiftIdentityProviderInformer,
+	secretInformer corev1informers.SecretInformer,
+	log plog.Logger,
+	withInformer pinnipedcontroller.WithInformerOptionFunc,
+	recorder events.EventRecorder,
+	clock clock.Clock,
+) controllerlib.Controller {
+	c := openShiftWatcherController{
+		namespace:                  namespace,
+		cache:                      idpCache,
+		client:                     client,
+		log:                        log.WithName(controllerName),
+		openShiftIdentityProviders: openShiftIdentityProviderInformer,
+		secretInformer:             secretInformer,
+		clock:                      clock,
+	}
+
+	return controllerlib.New(
+		controllerlib.Config{Name: controllerName, Syncer: &c},
+		withInformer(
+			openShiftIdentityProviderInformer,
+			pinnipedcontroller.SimpleFilter(func(obj metav1.Object) bool {
+				idp, ok := obj.(*idpv1alpha1.OpenShiftIdentityProvider)
+				return ok && idp.Namespace == namespace
+			}, pinnipedcontroller.SingletonQueue()),
+			controllerlib.InformerOption{},
+		),
+		withInformer(
+			secretInformer,
+			pinnipedcontroller.MatchAnySecretOfTypeFilter(openShiftClientSecretType, pinnipedcontroller.SingletonQueue(), namespace),
+			controllerlib.InformerOption{},
+		),
+		controllerlib.WithRecorder(recorder),
+	)
+}
+
+// Sync implements controllerlib.Syncer.
+func (c *openShiftWatcherController) Sync(ctx controllerlib.Context) error {
+	actualUpstreams, err := c.openShiftIdentityProviders.Lister().List(labels.Everything())
+	if err != nil { // untested
+		return fmt.Errorf("failed to list OpenShiftIdentityProviders: %w", err)
+	}
+
+	// Sort them by name just so that the logs output is consistent
+	slices.SortStableFunc(actualUpstreams, func(a, b *idpv1alpha1.OpenShiftIdentityProvider) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	var applicationErrors []error
+	validatedUpstreams := make([]upstreamprovider.UpstreamOpenShiftIdentityProviderI, 0, len(actualUpstreams))
+	for _, upstream := range actualUpstreams {
+		if upstream.Namespace != c.namespace {
+			continue
+		}
+		validatedUpstream, applicationErr := c.validateUpstreamAndUpdateConditions(ctx, upstream)
+		if applicationErr != nil {
+			applicationErrors = append(applicationErrors, applicationErr)
+		} else if validatedUpstream != nil {
+			validatedUpstreams = append(validatedUpstreams, validatedUpstream)
+		}
+		// Else:
+		// If both validatedUpstream and applicationErr are nil, this must be because the upstream had configuration errors.
+		// This controller should take no action until the user has reconfigured the upstream.
+	}
+	c.cache.SetOpenShiftIdentityProviders(validatedUpstreams)
+
+	// If we have recoverable application errors, let's do a requeue and capture all the applicationErrors too
+	if len(applicationErrors) > 0 {
+		applicationErrors = append([]error{controllerlib.ErrSyntheticRequeue}, applicationErrors...)
+	}
+
+	return utilerrors.NewAggregate(applicationErrors)
+}
+
+func (c *openShiftWatcherController) validateUpstreamAndUpdateConditions(
+	ctx controllerlib.Context,
+	upstream *idpv1alpha1.OpenShiftIdentityProvider,
+) (
+	*upstreamopenshift.Provider, // If validated, returns the config
+	error, // This error will only refer to programmatic errors such as failing to update the status, not configuration errors
+) {
+	conditions := make([]*metav1.Condition, 0)
+	applicationErrors := make([]error, 0)
+
+	clientSecretCondition, clientID, clientSecret, clientSecretErr := c.validateClientSecret(upstream.Spec.Client.SecretName)
+	conditions = append(conditions, clientSecretCondition)
+	if clientSecretErr != nil { // untested
+		applicationErrors = append(applicationErrors, clientSecretErr)
+	}
+
+	claimsCondition, usernameAttribute := validateClaims(upstream.Spec.Claims)
+	conditions = append(conditions, claimsCondition)
+
+	tlsConfigCondition, certPool := validateTLSConfiguration(upstream.Spec.APIServer.TLS)
+	conditions = append(conditions, tlsConfigCondition)
+
+	apiServerURL := strings.TrimSuffix(upstream.Spec.APIServer.URL, "/")
+	discoveryCondition, discovery, httpClient := validateOAuthDiscovery(
+		ctx.Context,
+		apiServerURL,
+		certPool,
+		tlsConfigCondition.Status == metav1.ConditionTrue,
+	)
+	conditions = append(conditions, discoveryCondition)
+
+	// The critical pattern to maintain is that every run of the sync loop will populate the exact number of the exact
+	// same set of conditions.  Conditions depending on other conditions should get Status: metav1.ConditionUnknown, or
+	// Status: metav1.ConditionFalse, never be omitted.
+	if len(conditions) != countExpectedConditions { // untested since all code paths return the same number of conditions
+		applicationErrors = append(applicationErrors, fmt.Errorf("expected %d conditions but found %d conditions", countExpectedConditions, len(conditions)))
+		return nil, utilerrors.NewAggregate(applicationErrors)
+	}
+	hadErrorCondition, updateStatusErr := c.updateStatus(ctx, upstream, conditions)
+	if updateStatusErr != nil {
+		applicationErrors = append(applicationErrors, updateStatusErr)
+	}
+	// Any error condition means we will not add the IDP to the cache, so just return nil here
+	if hadErrorCondition {
+		return nil, utilerrors.NewAggregate(applicationErrors)
+	}
+
+	provider := upstreamopenshift.New(upstreamopenshift.ProviderConfig{
+		Name:                upstream.Name,
+		ResourceUID:         upstream.UID,
+		APIServerURL:        apiServerURL,
+		UsernameAttribute:   usernameAttribute,
+		IncludeSystemGroups: upstream.Spec.Groups.IncludeSystemGroups,
+		UsePKCE:             slices.Contains(discovery.CodeChallengeMethodsSupported, "S256"),
+		HttpClient:          httpClient,
+		OAuth2Config: &oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:   discovery.AuthorizationEndpoint,
+				TokenURL:  discovery.TokenEndpoint,
+				AuthStyle: oauth2.AuthStyleInParams,
+			},
+			RedirectURL: "", // this will be different for each FederationDomain, so we do not set it here
+			Scopes:      defaultScopes,
+		},
+	})
+	return provider, utilerrors.NewAggregate(applicationErrors)
+}
+
+func (c *openShiftWatcherController) validateClientSecret(secretName string) (*metav1.Condition, string, string, error) {
+	secret, unableToRetrieveSecretErr := c.secretInformer.Lister().Secrets(c.namespace).Get(secretName)
+
+	// This error requires user interaction, so ignore it.
+	if apierrors.IsNotFound(unableToRetrieveSecretErr) {
+		unableToRetrieveSecretErr = nil
+	}
+
+	buildFalseCondition := func(prefix string) (*metav1.Condition, string, string, error) {
+		return &metav1.Condition{
+			Type:   ClientCredentialsSecretValid,
+			Status: metav1.ConditionFalse,
+			Reason: upstreamwatchers.ReasonNotFound,
+			Message: fmt.Sprintf("%s: secret from spec.client.SecretName (%q) must be found in namespace %q with type %q and keys %q and %q",
+				prefix,
+				secretName,
+				c.namespace,
+				openShiftClientSecretType,
+				clientIDDataKey,
+				clientSecretDataKey),
+		}, "", "", unableToRetrieveSecretErr
+	}
+
+	if unableToRetrieveSecretErr != nil || secret == nil {
+		return buildFalseCondition(fmt.Sprintf("secret %q not found", secretName))
+	}
+
+	if secret.Type != openShiftClientSecretType {
+		return buildFalseCondition(fmt.Sprintf("wrong secret type %q", secret.Type))
+	}
+
+	clientID := string(secret.Data[clientIDDataKey])
+	if len(clientID) < 1 {
+		return buildFalseCondition(fmt.Sprintf("missing key %q", clientIDDataKey))
+	}
+
+	clientSecret := string(secret.Data[clientSecretDataKey])
+	if len(clientSecret) < 1 {
+		return buildFalseCondition(fmt.Sprintf("missing key %q", clientSecretDataKey))
+	}
+
+	if len(secret.Data) != 2 {
+		return buildFalseCondition("extra keys found")
+	}
+
+	return &metav1.Condition{
+		Type:    ClientCredentialsSecretValid,
+		Status:  metav1.ConditionTrue,
+		Reason:  upstreamwatchers.ReasonSuccess,
+		Message: fmt.Sprintf("clientID and clientSecret have been read from spec.client.SecretName (%q)", secretName),
+	}, clientID, clientSecret, nil
+}
+
+func validateClaims(claims idpv1alpha1.OpenShiftClaims) (*metav1.Condition, idpv1alpha1.OpenShiftUsernameAttribute) {
+	// CRD defaulting and validation should eliminate the possibility of an invalid or missing value here.
+	usernameAttribute := idpv1alpha1.OpenShiftUsernameName
+	if claims.Username != nil {
+		usernameAttribute = *claims.Username
+	}
+
+	switch usernameAttribute {
+	case idpv1alpha1.OpenShiftUsernameName, idpv1alpha1.OpenShiftUsernameUID, idpv1alpha1.OpenShiftUsernameNameAndUID:
+		return &metav1.Condition{
+			Type:    ClaimsValid,
+			Status:  metav1.ConditionTrue,
+			Reason:  upstreamwatchers.ReasonSuccess,
+			Message: "spec.claims are valid",
+		}, usernameAttribute
+	default:
+		return &metav1.Condition{
+			Type:    ClaimsValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  idpconditions.ReasonInvalid,
+			Message: fmt.Sprintf("spec.claims.username (%q) is not valid", usernameAttribute),
+		}, ""
+	}
+}
+
+func validateTLSConfiguration(tlsSpec *idpv1alpha1.TLSSpec) (*metav1.Condition, *x509.CertPool) {
+	certPool, _, buildCertPoolErr := pinnipedcontroller.BuildCertPoolIDP(tlsSpec)
+	if buildCertPoolErr != nil {
+		// buildCertPoolErr is not recoverable with a resync.
+		// It requires user interaction, so do not return the error.
+		return &metav1.Condition{
+			Type:    TLSConfigurationValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  upstreamwatchers.ReasonInvalidTLSConfig,
+			Message: fmt.Sprintf("spec.apiServer.tls.certificateAuthorityData is not valid: %s", buildCertPoolErr),
+		}, nil
+	}
+
+	return &metav1.Condition{
+		Type:    TLSConfigurationValid,
+		Status:  metav1.ConditionTrue,
+		Reason:  upstreamwatchers.ReasonSuccess,
+		Message: "spec.apiServer.tls.certificateAuthorityData is valid",
+	}, certPool
+}
+
+// oauthDiscoveryResponse is the subset of the OAuth 2.0 authorization server metadata (RFC 8414)
+// which is needed to log in using the OAuth server of OpenShift.
+type oauthDiscoveryResponse struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported"`
+}
+
+func validateOAuthDiscovery(
+	ctx context.Context,
+	apiServerURL string,
+	certPool *x509.CertPool,
+	validSoFar bool,
+) (*metav1.Condition, *oauthDiscoveryResponse, *http.Client) {
+	if !validSoFar {
+		return &metav1.Condition{
+			Type:    OAuthDiscoverySucceeded,
+			Status:  metav1.ConditionUnknown,
+			Reason:  idpconditions.ReasonUnableToValidate,
+			Message: "unable to validate; see other conditions for details",
+		}, nil, nil
+	}
+
+	buildFalseCondition := func(reason, message string) (*metav1.Condition, *oauthDiscoveryResponse, *http.Client) {
+		// These errors require user interaction or will be retried on the next resync, so do not return them.
+		return &metav1.Condition{
+			Type:    OAuthDiscoverySucceeded,
+			Status:  metav1.ConditionFalse,
+			Reason:  reason,
+			Message: message,
+		}, nil, nil
+	}
+
+	parsedURL, err := url.Parse(apiServerURL)
+	if err != nil || parsedURL.Scheme != "https" || parsedURL.Host == "" {
+		return buildFalseCondition(idpconditions.ReasonUnreachable,
+			fmt.Sprintf("spec.apiServer.url (%q) must be an https URL", apiServerURL))
+	}
+
+	httpClient := phttp.DefaultWithConnectionPool(certPool, upstreamwatchers.ConnectionPool(parsedURL.Host, nil))
+
+	discoveryCtx, cancel := context.WithTimeout(ctx, discoveryTimeout)
+	defer cancel()
+
+	discoveryURL := apiServerURL + oauthDiscoveryPath
+	req, err := http.NewRequestWithContext(discoveryCtx, http.MethodGet, discoveryURL, nil)
+	if err != nil { // untested
+		return buildFalseCondition(idpconditions.ReasonUnreachable,
+			fmt.Sprintf("failed to build request to %q: %s", discoveryURL, err))
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return buildFalseCondition(idpconditions.ReasonUnreachable,
+			fmt.Sprintf("failed to perform OAuth discovery against %q: %s", discoveryURL, err))
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return buildFalseCondition(idpconditions.ReasonUnreachable,
+			fmt.Sprintf("failed to perform OAuth discovery against %q: unexpected response status %q", discoveryURL, resp.Status))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDiscoveryResponseBytes))
+	if err != nil { // untested
+		return buildFalseCondition(idpconditions.ReasonUnreachable,
+			fmt.Sprintf("failed to read OAuth discovery response from %q: %s", discoveryURL, err))
+	}
+
+	var discovery oauthDiscoveryResponse
+	if err := json.Unmarshal(body, &discovery); err != nil {
+		return buildFalseCondition(idpconditions.ReasonInvalidResponse,
+			fmt.Sprintf("failed to parse OAuth discovery response from %q: %s", discoveryURL, err))
+	}
+
+	for _, endpoint := range []struct{ name, value string }{
+		{name: "authorization_endpoint", value: discovery.AuthorizationEndpoint},
+		{name: "token_endpoint", value: discovery.TokenEndpoint},
+	} {
+		parsedEndpoint, err := url.Parse(endpoint.value)
+		if err != nil || parsedEndpoint.Scheme != "https" || parsedEndpoint.Host == "" {
+			return buildFalseCondition(idpconditions.ReasonInvalidResponse,
+				fmt.Sprintf("OAuth discovery response from %q has invalid %s (%q): must be an https URL", discoveryURL, endpoint.name, endpoint.value))
+		}
+	}
+
+	return &metav1.Condition{
+		Type:    OAuthDiscoverySucceeded,
+		Status:  metav1.ConditionTrue,
+		Reason:  upstreamwatchers.ReasonSuccess,
+		Message: fmt.Sprintf("discovered OAuth server with issuer %q from spec.apiServer.url (%q)", discovery.Issuer, apiServerURL),
+	}, &discovery, httpClient
+}
+
+func (c *openShiftWatcherController) updateStatus(
+	ctx controllerlib.Context,
+	upstream *idpv1alpha1.OpenShiftIdentityProvider,
+	conditions []*metav1.Condition,
+) (bool, error) {
+	log := c.log.WithValues("namespace", upstream.Namespace, "name", upstream.Name)
+	updated := upstream.DeepCopy()
+
+	hadErrorCondition := conditionsutil.MergeConditions(
+		conditions,
+		upstream.Generation,
+		&updated.Status.Conditions,
+		log,
+		metav1.NewTime(c.clock.Now()),
+	)
+
+	updated.Status.Phase = idpv1alpha1.OpenShiftPhaseReady
+	if hadErrorCondition {
+		updated.Status.Phase = idpv1alpha1.OpenShiftPhaseError
+	}
+
+	if equality.Semantic.DeepEqual(upstream, updated) {
+		return hadErrorCondition, nil
+	}
+
+	log.Info("updating OpenShiftIdentityProvider status", "phase", updated.Status.Phase)
+
+	_, updateStatusError := c.client.
+		IDPV1alpha1().
+		OpenShiftIdentityProviders(upstream.Namespace).
+		UpdateStatus(ctx.Context, updated, metav1.UpdateOptions{})
+	if updateStatusError == nil {
+		conditionsutil.RecordConditionChanges(ctx.Recorder, updated, upstream.Status.Conditions, updated.Status.Conditions)
+	}
+	return hadErrorCondition, updateStatusError
+}
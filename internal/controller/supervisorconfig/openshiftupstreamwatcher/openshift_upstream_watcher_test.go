@@ -0,0 +1,563 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package openshiftupstreamwatcher
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8sinformers "k8s.io/client-go/informers"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	coretesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+
+	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
+	supervisorfake "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/fake"
+	supervisorinformers "go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions"
+	"go.pinniped.dev/internal/controllerlib"
+	"go.pinniped.dev/internal/federationdomain/dynamicupstreamprovider"
+	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
+	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/internal/testutil/tlsserver"
+	"go.pinniped.dev/internal/upstreamopenshift"
+)
+
+func TestController(t *testing.T) {
+	require.Equal(t, 4, countExpectedConditions)
+
+	const (
+		namespace = "some-namespace"
+		otherNS   = "other-namespace"
+	)
+
+	now := metav1.NewTime(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	earlier := metav1.NewTime(now.Add(-time.Hour))
+
+	apiServerURL, apiServerCA := newTestAPIServer(t)
+	apiServerCABase64 := base64.StdEncoding.EncodeToString(apiServerCA)
+
+	validIDP := func(name string) *idpv1alpha1.OpenShiftIdentityProvider {
+		return &idpv1alpha1.OpenShiftIdentityProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, UID: types.UID("uid-" + name), Generation: 1234},
+			Spec: idpv1alpha1.OpenShiftIdentityProviderSpec{
+				APIServer: idpv1alpha1.OpenShiftAPIServerSpec{
+					URL: apiServerURL,
+					TLS: &idpv1alpha1.TLSSpec{CertificateAuthorityData: apiServerCABase64},
+				},
+				Claims: idpv1alpha1.OpenShiftClaims{Username: ptr.To(idpv1alpha1.OpenShiftUsernameNameAndUID)},
+				Client: idpv1alpha1.OpenShiftClientSpec{SecretName: "some-client-secret"},
+			},
+		}
+	}
+
+	idpWithAPIServerPath := func(name, path string) *idpv1alpha1.OpenShiftIdentityProvider {
+		idp := validIDP(name)
+		idp.Spec.APIServer.URL = apiServerURL + path
+		return idp
+	}
+
+	validSecret := func(name string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Type:       openShiftClientSecretType,
+			Data: map[string][]byte{
+				clientIDDataKey:     []byte("some-client-id"),
+				clientSecretDataKey: []byte("some-client-secret"),
+			},
+		}
+	}
+
+	condition := func(conditionType string, status metav1.ConditionStatus, reason, message string, lastTransitionTime metav1.Time) metav1.Condition {
+		return metav1.Condition{
+			Type:               conditionType,
+			Status:             status,
+			ObservedGeneration: 1234,
+			LastTransitionTime: lastTransitionTime,
+			Reason:             reason,
+			Message:            message,
+		}
+	}
+
+	happyClientSecretCondition := func(lastTransitionTime metav1.Time) metav1.Condition {
+		return condition(ClientCredentialsSecretValid, metav1.ConditionTrue, "Success",
+			`clientID and clientSecret have been read from spec.client.SecretName ("some-client-secret")`, lastTransitionTime)
+	}
+	happyClaimsCondition := func(lastTransitionTime metav1.Time) metav1.Condition {
+		return condition(ClaimsValid, metav1.ConditionTrue, "Success", "spec.claims are valid", lastTransitionTime)
+	}
+	happyTLSCondition := func(lastTransitionTime metav1.Time) metav1.Condition {
+		return condition(TLSConfigurationValid, metav1.ConditionTrue, "Success",
+			"spec.apiServer.tls.certificateAuthorityData is valid", lastTransitionTime)
+	}
+	happyDiscoveryCondition := func(url string, lastTransitionTime metav1.Time) metav1.Condition {
+		return condition(OAuthDiscoverySucceeded, metav1.ConditionTrue, "Success",
+			`discovered OAuth server with issuer "https://oauth-openshift.apps.example.com" from spec.apiServer.url ("`+url+`")`, lastTransitionTime)
+	}
+	unknownDiscoveryCondition := condition(OAuthDiscoverySucceeded, metav1.ConditionUnknown, "UnableToValidate",
+		"unable to validate; see other conditions for details", now)
+
+	// Conditions are sorted by type when they are saved.
+	happyConditions := func(url string, lastTransitionTime metav1.Time) []metav1.Condition {
+		return []metav1.Condition{
+			happyClaimsCondition(lastTransitionTime),
+			happyClientSecretCondition(lastTransitionTime),
+			happyDiscoveryCondition(url, lastTransitionTime),
+			happyTLSCondition(lastTransitionTime),
+		}
+	}
+
+	tests := []struct {
+		name                string
+		inputUpstreams      []runtime.Object
+		inputSecrets        []runtime.Object
+		configureClient     func(*supervisorfake.Clientset)
+		wantErr             string
+		wantResultingCache  []*upstreamopenshift.ProviderConfig
+		wantResultingPhases map[string]idpv1alpha1.OpenShiftIdentityProviderPhase
+		wantResultingConds  map[string][]metav1.Condition
+		wantNoStatusUpdates bool
+	}{
+		{
+			name:                "no upstreams",
+			wantResultingCache:  []*upstreamopenshift.ProviderConfig{},
+			wantNoStatusUpdates: true,
+		},
+		{
+			name:           "valid upstream is loaded into the cache and becomes Ready",
+			inputUpstreams: []runtime.Object{validIDP("some-idp")},
+			inputSecrets:   []runtime.Object{validSecret("some-client-secret")},
+			wantResultingCache: []*upstreamopenshift.ProviderConfig{
+				{
+					Name:              "some-idp",
+					ResourceUID:       "uid-some-idp",
+					APIServerURL:      apiServerURL,
+					UsernameAttribute: idpv1alpha1.OpenShiftUsernameNameAndUID,
+					UsePKCE:           true,
+				},
+			},
+			wantResultingPhases: map[string]idpv1alpha1.OpenShiftIdentityProviderPhase{
+				"some-idp": idpv1alpha1.OpenShiftPhaseReady,
+			},
+			wantResultingConds: map[string][]metav1.Condition{
+				"some-idp": happyConditions(apiServerURL, now),
+			},
+		},
+		{
+			name: "trailing slash of the API server URL is removed and the username attribute defaults to name",
+			inputUpstreams: []runtime.Object{func() runtime.Object {
+				idp := idpWithAPIServerPath("some-idp", "/")
+				idp.Spec.Claims.Username = nil
+				idp.Spec.Groups.IncludeSystemGroups = true
+				return idp
+			}()},
+			inputSecrets: []runtime.Object{validSecret("some-client-secret")},
+			wantResultingCache: []*upstreamopenshift.ProviderConfig{
+				{
+					Name:                "some-idp",
+					ResourceUID:         "uid-some-idp",
+					APIServerURL:        apiServerURL,
+					UsernameAttribute:   idpv1alpha1.OpenShiftUsernameName,
+					IncludeSystemGroups: true,
+					UsePKCE:             true,
+				},
+			},
+			wantResultingPhases: map[string]idpv1alpha1.OpenShiftIdentityProviderPhase{
+				"some-idp": idpv1alpha1.OpenShiftPhaseReady,
+			},
+			wantResultingConds: map[string][]metav1.Condition{
+				"some-idp": happyConditions(apiServerURL, now),
+			},
+		},
+		{
+			name:           "OAuth server which does not advertise PKCE is used without PKCE",
+			inputUpstreams: []runtime.Object{idpWithAPIServerPath("some-idp", "/no-pkce")},
+			inputSecrets:   []runtime.Object{validSecret("some-client-secret")},
+			wantResultingCache: []*upstreamopenshift.ProviderConfig{
+				{
+					Name:              "some-idp",
+					ResourceUID:       "uid-some-idp",
+					APIServerURL:      apiServerURL + "/no-pkce",
+					UsernameAttribute: idpv1alpha1.OpenShiftUsernameNameAndUID,
+					UsePKCE:           false,
+				},
+			},
+			wantResultingPhases: map[string]idpv1alpha1.OpenShiftIdentityProviderPhase{
+				"some-idp": idpv1alpha1.OpenShiftPhaseReady,
+			},
+			wantResultingConds: map[string][]metav1.Condition{
+				"some-idp": happyConditions(apiServerURL+"/no-pkce", now),
+			},
+		},
+		{
+			name: "upstreams in other namespaces are ignored",
+			inputUpstreams: []runtime.Object{func() runtime.Object {
+				idp := validIDP("some-idp")
+				idp.Namespace = otherNS
+				return idp
+			}()},
+			inputSecrets:        []runtime.Object{validSecret("some-client-secret")},
+			wantResultingCache:  []*upstreamopenshift.ProviderConfig{},
+			wantNoStatusUpdates: true,
+		},
+		{
+			name: "already up to date upstream does not get a status update",
+			inputUpstreams: []runtime.Object{func() runtime.Object {
+				idp := validIDP("some-idp")
+				idp.Status = idpv1alpha1.OpenShiftIdentityProviderStatus{
+					Phase:      idpv1alpha1.OpenShiftPhaseReady,
+					Conditions: happyConditions(apiServerURL, earlier),
+				}
+				return idp
+			}()},
+			inputSecrets: []runtime.Object{validSecret("some-client-secret")},
+			wantResultingCache: []*upstreamopenshift.ProviderConfig{
+				{
+					Name:              "some-idp",
+					ResourceUID:       "uid-some-idp",
+					APIServerURL:      apiServerURL,
+					UsernameAttribute: idpv1alpha1.OpenShiftUsernameNameAndUID,
+					UsePKCE:           true,
+				},
+			},
+			wantNoStatusUpdates: true,
+		},
+		{
+			name:               "client secret not found",
+			inputUpstreams:     []runtime.Object{validIDP("some-idp")},
+			wantResultingCache: []*upstreamopenshift.ProviderConfig{},
+			wantResultingPhases: map[string]idpv1alpha1.OpenShiftIdentityProviderPhase{
+				"some-idp": idpv1alpha1.OpenShiftPhaseError,
+			},
+			wantResultingConds: map[string][]metav1.Condition{
+				"some-idp": {
+					happyClaimsCondition(now),
+					condition(ClientCredentialsSecretValid, metav1.ConditionFalse, "SecretNotFound",
+						`secret "some-client-secret" not found: secret from spec.client.SecretName ("some-client-secret") must be found in namespace "some-namespace" with type "secrets.pinniped.dev/openshift-client" and keys "clientID" and "clientSecret"`, now),
+					happyDiscoveryCondition(apiServerURL, now),
+					happyTLSCondition(now),
+				},
+			},
+		},
+		{
+			name:           "client secret has the wrong type",
+			inputUpstreams: []runtime.Object{validIDP("some-idp")},
+			inputSecrets: []runtime.Object{func() runtime.Object {
+				secret := validSecret("some-client-secret")
+				secret.Type = corev1.SecretTypeOpaque
+				return secret
+			}()},
+			wantResultingCache: []*upstreamopenshift.ProviderConfig{},
+			wantResultingPhases: map[string]idpv1alpha1.OpenShiftIdentityProviderPhase{
+				"some-idp": idpv1alpha1.OpenShiftPhaseError,
+			},
+			wantResultingConds: map[string][]metav1.Condition{
+				"some-idp": {
+					happyClaimsCondition(now),
+					condition(ClientCredentialsSecretValid, metav1.ConditionFalse, "SecretNotFound",
+						`wrong secret type "Opaque": secret from spec.client.SecretName ("some-client-secret") must be found in namespace "some-namespace" with type "secrets.pinniped.dev/openshift-client" and keys "clientID" and "clientSecret"`, now),
+					happyDiscoveryCondition(apiServerURL, now),
+					happyTLSCondition(now),
+				},
+			},
+		},
+		{
+			name:           "client secret is missing the clientSecret key",
+			inputUpstreams: []runtime.Object{validIDP("some-idp")},
+			inputSecrets: []runtime.Object{func() runtime.Object {
+				secret := validSecret("some-client-secret")
+				delete(secret.Data, clientSecretDataKey)
+				return secret
+			}()},
+			wantResultingCache: []*upstreamopenshift.ProviderConfig{},
+			wantResultingPhases: map[string]idpv1alpha1.OpenShiftIdentityProviderPhase{
+				"some-idp": idpv1alpha1.OpenShiftPhaseError,
+			},
+			wantResultingConds: map[string][]metav1.Condition{
+				"some-idp": {
+					happyClaimsCondition(now),
+					condition(ClientCredentialsSecretValid, metav1.ConditionFalse, "SecretNotFound",
+						`missing key "clientSecret": secret from spec.client.SecretName ("some-client-secret") must be found in namespace "some-namespace" with type "secrets.pinniped.dev/openshift-client" and keys "clientID" and "clientSecret"`, now),
+					happyDiscoveryCondition(apiServerURL, now),
+					happyTLSCondition(now),
+				},
+			},
+		},
+		{
+			name: "invalid username attribute",
+			inputUpstreams: []runtime.Object{func() runtime.Object {
+				idp := validIDP("some-idp")
+				idp.Spec.Claims.Username = ptr.To(idpv1alpha1.OpenShiftUsernameAttribute("email"))
+				return idp
+			}()},
+			inputSecrets:       []runtime.Object{validSecret("some-client-secret")},
+			wantResultingCache: []*upstreamopenshift.ProviderConfig{},
+			wantResultingPhases: map[string]idpv1alpha1.OpenShiftIdentityProviderPhase{
+				"some-idp": idpv1alpha1.OpenShiftPhaseError,
+			},
+			wantResultingConds: map[string][]metav1.Condition{
+				"some-idp": {
+					condition(ClaimsValid, metav1.ConditionFalse, "Invalid", `spec.claims.username ("email") is not valid`, now),
+					happyClientSecretCondition(now),
+					happyDiscoveryCondition(apiServerURL, now),
+					happyTLSCondition(now),
+				},
+			},
+		},
+		{
+			name: "invalid CA bundle",
+			inputUpstreams: []runtime.Object{func() runtime.Object {
+				idp := validIDP("some-idp")
+				idp.Spec.APIServer.TLS.CertificateAuthorityData = "invalid-base64"
+				return idp
+			}()},
+			inputSecrets:       []runtime.Object{validSecret("some-client-secret")},
+			wantResultingCache: []*upstreamopenshift.ProviderConfig{},
+			wantResultingPhases: map[string]idpv1alpha1.OpenShiftIdentityProviderPhase{
+				"some-idp": idpv1alpha1.OpenShiftPhaseError,
+			},
+			wantResultingConds: map[string][]metav1.Condition{
+				"some-idp": {
+					happyClaimsCondition(now),
+					happyClientSecretCondition(now),
+					unknownDiscoveryCondition,
+					condition(TLSConfigurationValid, metav1.ConditionFalse, "InvalidTLSConfig",
+						"spec.apiServer.tls.certificateAuthorityData is not valid: illegal base64 data at input byte 7", now),
+				},
+			},
+		},
+		{
+			name: "API server which is not trusted by the CA bundle",
+			inputUpstreams: []runtime.Object{func() runtime.Object {
+				idp := validIDP("some-idp")
+				idp.Spec.APIServer.TLS = nil
+				return idp
+			}()},
+			inputSecrets:       []runtime.Object{validSecret("some-client-secret")},
+			wantResultingCache: []*upstreamopenshift.ProviderConfig{},
+			wantResultingPhases: map[string]idpv1alpha1.OpenShiftIdentityProviderPhase{
+				"some-idp": idpv1alpha1.OpenShiftPhaseError,
+			},
+			wantResultingConds: map[string][]metav1.Condition{
+				"some-idp": {
+					happyClaimsCondition(now),
+					happyClientSecretCondition(now),
+					condition(OAuthDiscoverySucceeded, metav1.ConditionFalse, "Unreachable",
+						`failed to perform OAuth discovery against "`+apiServerURL+`/.well-known/oauth-authorization-server": Get "`+apiServerURL+`/.well-known/oauth-authorization-server": tls: failed to verify certificate: x509: certificate signed by unknown authority`, now),
+					condition(TLSConfigurationValid, metav1.ConditionTrue, "Success",
+						"spec.apiServer.tls.certificateAuthorityData is valid", now),
+				},
+			},
+		},
+		{
+			name:               "discovery endpoint is not found",
+			inputUpstreams:     []runtime.Object{idpWithAPIServerPath("some-idp", "/not-found")},
+			inputSecrets:       []runtime.Object{validSecret("some-client-secret")},
+			wantResultingCache: []*upstreamopenshift.ProviderConfig{},
+			wantResultingPhases: map[string]idpv1alpha1.OpenShiftIdentityProviderPhase{
+				"some-idp": idpv1alpha1.OpenShiftPhaseError,
+			},
+			wantResultingConds: map[string][]metav1.Condition{
+				"some-idp": {
+					happyClaimsCondition(now),
+					happyClientSecretCondition(now),
+					condition(OAuthDiscoverySucceeded, metav1.ConditionFalse, "Unreachable",
+						`failed to perform OAuth discovery against "`+apiServerURL+`/not-found/.well-known/oauth-authorization-server": unexpected response status "404 Not Found"`, now),
+					happyTLSCondition(now),
+				},
+			},
+		},
+		{
+			name:               "discovery response is not JSON",
+			inputUpstreams:     []runtime.Object{idpWithAPIServerPath("some-idp", "/not-json")},
+			inputSecrets:       []runtime.Object{validSecret("some-client-secret")},
+			wantResultingCache: []*upstreamopenshift.ProviderConfig{},
+			wantResultingPhases: map[string]idpv1alpha1.OpenShiftIdentityProviderPhase{
+				"some-idp": idpv1alpha1.OpenShiftPhaseError,
+			},
+			wantResultingConds: map[string][]metav1.Condition{
+				"some-idp": {
+					happyClaimsCondition(now),
+					happyClientSecretCondition(now),
+					condition(OAuthDiscoverySucceeded, metav1.ConditionFalse, "InvalidResponse",
+						`failed to parse OAuth discovery response from "`+apiServerURL+`/not-json/.well-known/oauth-authorization-server": invalid character 'h' in literal true (expecting 'r')`, now),
+					happyTLSCondition(now),
+				},
+			},
+		},
+		{
+			name:               "discovery response has an insecure token endpoint",
+			inputUpstreams:     []runtime.Object{idpWithAPIServerPath("some-idp", "/insecure-token-endpoint")},
+			inputSecrets:       []runtime.Object{validSecret("some-client-secret")},
+			wantResultingCache: []*upstreamopenshift.ProviderConfig{},
+			wantResultingPhases: map[string]idpv1alpha1.OpenShiftIdentityProviderPhase{
+				"some-idp": idpv1alpha1.OpenShiftPhaseError,
+			},
+			wantResultingConds: map[string][]metav1.Condition{
+				"some-idp": {
+					happyClaimsCondition(now),
+					happyClientSecretCondition(now),
+					condition(OAuthDiscoverySucceeded, metav1.ConditionFalse, "InvalidResponse",
+						`OAuth discovery response from "`+apiServerURL+`/insecure-token-endpoint/.well-known/oauth-authorization-server" has invalid token_endpoint ("http://oauth-openshift.apps.example.com/oauth/token"): must be an https URL`, now),
+					happyTLSCondition(now),
+				},
+			},
+		},
+		{
+			name:           "error while updating status causes a requeue and the upstream is not cached",
+			inputUpstreams: []runtime.Object{validIDP("some-idp")},
+			inputSecrets:   []runtime.Object{validSecret("some-client-secret")},
+			configureClient: func(client *supervisorfake.Clientset) {
+				client.PrependReactor("update", "openshiftidentityproviders", func(action coretesting.Action) (bool, runtime.Object, error) {
+					return true, nil, errors.New("some update error")
+				})
+			},
+			wantErr:            "some update error",
+			wantResultingCache: []*upstreamopenshift.ProviderConfig{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fakeSupervisorClient := supervisorfake.NewSimpleClientset(tt.inputUpstreams...)
+			supervisorInformers := supervisorinformers.NewSharedInformerFactory(fakeSupervisorClient, 0)
+			if tt.configureClient != nil {
+				tt.configureClient(fakeSupervisorClient)
+			}
+			fakeKubeClient := kubernetesfake.NewSimpleClientset(tt.inputSecrets...)
+			kubeInformers := k8sinformers.NewSharedInformerFactoryWithOptions(fakeKubeClient, 0)
+
+			cache := dynamicupstreamprovider.NewDynamicUpstreamIDPProvider()
+			cache.SetOpenShiftIdentityProviders([]upstreamprovider.UpstreamOpenShiftIdentityProviderI{
+				upstreamopenshift.New(upstreamopenshift.ProviderConfig{Name: "initial-entry-to-remove"}),
+			})
+
+			controller := New(
+				namespace,
+				cache,
+				fakeSupervisorClient,
+				supervisorInformers.IDP().V1alpha1().OpenShiftIdentityProviders(),
+				kubeInformers.Core().V1().Secrets(),
+				plog.TestLogger(t, nil),
+				controllerlib.WithInformer,
+				nil,
+				clocktesting.NewFakeClock(now.Time),
+			)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			supervisorInformers.Start(ctx.Done())
+			kubeInformers.Start(ctx.Done())
+			controllerlib.TestRunSynchronously(t, controller)
+
+			syncCtx := controllerlib.Context{Context: ctx, Key: controllerlib.Key{}}
+
+			if err := controllerlib.TestSync(t, controller, syncCtx); len(tt.wantErr) > 0 {
+				require.ErrorContains(t, err, controllerlib.ErrSyntheticRequeue.Error())
+				require.ErrorContains(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			// Verify what's in the cache
+			actualProviderConfigs := []*upstreamopenshift.ProviderConfig{}
+			for _, p := range cache.GetOpenShiftIdentityProviders() {
+				actualProvider, ok := p.(*upstreamopenshift.Provider)
+				require.True(t, ok)
+				actualConfig := actualProvider.GetConfig()
+
+				require.NotNil(t, actualConfig.HttpClient)
+				require.Equal(t, "some-client-id", actualConfig.OAuth2Config.ClientID)
+				require.Equal(t, "some-client-secret", actualConfig.OAuth2Config.ClientSecret)
+				require.Equal(t, []string{"user:info"}, actualConfig.OAuth2Config.Scopes)
+				require.Equal(t, "https://oauth-openshift.apps.example.com/oauth/authorize", actualConfig.OAuth2Config.Endpoint.AuthURL)
+				require.Equal(t, "https://oauth-openshift.apps.example.com/oauth/token", actualConfig.OAuth2Config.Endpoint.TokenURL)
+
+				// Clear the fields which were asserted above to make the remaining fields easy to compare.
+				actualConfig.HttpClient = nil
+				actualConfig.OAuth2Config = nil
+				actualProviderConfigs = append(actualProviderConfigs, &actualConfig)
+			}
+			require.Equal(t, tt.wantResultingCache, actualProviderConfigs)
+
+			if tt.wantNoStatusUpdates {
+				for _, action := range fakeSupervisorClient.Actions() {
+					require.NotEqual(t, "update", action.GetVerb(), "should not have updated any status")
+				}
+			}
+
+			// Verify the status as reported in Kubernetes
+			for name, wantPhase := range tt.wantResultingPhases {
+				actual, err := fakeSupervisorClient.IDPV1alpha1().OpenShiftIdentityProviders(namespace).Get(ctx, name, metav1.GetOptions{})
+				require.NoError(t, err)
+				require.Equal(t, wantPhase, actual.Status.Phase)
+				require.Equal(t, tt.wantResultingConds[name], actual.Status.Conditions)
+			}
+		})
+	}
+}
+
+// newTestAPIServer starts a TLS server which mimics the OAuth discovery endpoint of an OpenShift API server
+// at several path prefixes, and returns its URL and CA bundle.
+func newTestAPIServer(t *testing.T) (string, []byte) {
+	mux := http.NewServeMux()
+	server, serverCA := tlsserver.TestServerIPv4(t, http.HandlerFunc(mux.ServeHTTP), nil)
+
+	type discoveryJSON struct {
+		Issuer                        string   `json:"issuer"`
+		AuthURL                       string   `json:"authorization_endpoint"`
+		TokenURL                      string   `json:"token_endpoint"`
+		CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported,omitempty"`
+	}
+
+	serveDiscovery := func(path string, response *discoveryJSON) {
+		mux.HandleFunc(path+"/.well-known/oauth-authorization-server", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("content-type", "application/json")
+			_ = json.NewEncoder(w).Encode(response)
+		})
+	}
+
+	// At the root of the server, serve a valid discovery response of an OAuth server which supports PKCE.
+	// Note that the issuer of the OAuth server of OpenShift does not match the URL of the API server.
+	serveDiscovery("", &discoveryJSON{
+		Issuer:                        "https://oauth-openshift.apps.example.com",
+		AuthURL:                       "https://oauth-openshift.apps.example.com/oauth/authorize",
+		TokenURL:                      "https://oauth-openshift.apps.example.com/oauth/token",
+		CodeChallengeMethodsSupported: []string{"plain", "S256"},
+	})
+
+	// At "/no-pkce", serve a valid discovery response of an OAuth server which does not support PKCE.
+	serveDiscovery("/no-pkce", &discoveryJSON{
+		Issuer:   "https://oauth-openshift.apps.example.com",
+		AuthURL:  "https://oauth-openshift.apps.example.com/oauth/authorize",
+		TokenURL: "https://oauth-openshift.apps.example.com/oauth/token",
+	})
+
+	// At "/insecure-token-endpoint", serve a discovery response with an http token endpoint.
+	serveDiscovery("/insecure-token-endpoint", &discoveryJSON{
+		Issuer:   "https://oauth-openshift.apps.example.com",
+		AuthURL:  "https://oauth-openshift.apps.example.com/oauth/authorize",
+		TokenURL: "http://oauth-openshift.apps.example.com/oauth/token",
+	})
+
+	// At "/not-json", serve a discovery response which is not JSON.
+	mux.HandleFunc("/not-json/.well-known/oauth-authorization-server", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("this is not json"))
+	})
+
+	return server.URL, serverCA
+}
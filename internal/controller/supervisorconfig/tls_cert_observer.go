@@ -4,27 +4,53 @@
 package supervisorconfig
 
 import (
+	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net/url"
+	"slices"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/utils/clock"
 
+	configconditions "go.pinniped.dev/generated/latest/apis/supervisor/config/conditions"
+	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
+	supervisorclientset "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned"
 	"go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions/config/v1alpha1"
 	pinnipedcontroller "go.pinniped.dev/internal/controller"
+	"go.pinniped.dev/internal/controller/conditionsutil"
 	"go.pinniped.dev/internal/controllerlib"
 	"go.pinniped.dev/internal/plog"
 )
 
+const tlsCertObserverControllerName = "tls-certs-observer-controller"
+
 type tlsCertObserverController struct {
 	issuerTLSCertSetter             IssuerTLSCertSetter
 	defaultTLSCertificateSecretName string
+	pinnipedClient                  supervisorclientset.Interface
 	federationDomainInformer        v1alpha1.FederationDomainInformer
 	secretInformer                  corev1informers.SecretInformer
+	clock                           clock.Clock
+}
+
+// wrongSecretTypeError is returned when a Secret which should hold a TLS certificate is not of type kubernetes.io/tls.
+// The contents of Secrets of other types are not cached by the Supervisor, so they could never be loaded.
+type wrongSecretTypeError struct {
+	secretName string
+	secretType corev1.SecretType
+}
+
+func (e *wrongSecretTypeError) Error() string {
+	return fmt.Sprintf("secret %q has wrong type %q (should be %q)", e.secretName, e.secretType, corev1.SecretTypeTLS)
 }
 
 type IssuerTLSCertSetter interface {
@@ -32,26 +58,39 @@ type IssuerTLSCertSetter interface {
 	SetDefaultTLSCert(certificate *tls.Certificate)
 }
 
+// NewTLSCertObserverController returns a controllerlib.Controller which loads the TLS certificates of the
+// FederationDomains and the default TLS certificate of the Supervisor from their Secrets, and reports in the
+// TLSSecretValid condition of each FederationDomain whether the Secret named by its spec.tls.secretName could be loaded.
 func NewTLSCertObserverController(
 	issuerTLSCertSetter IssuerTLSCertSetter,
 	defaultTLSCertificateSecretName string,
+	pinnipedClient supervisorclientset.Interface,
 	secretInformer corev1informers.SecretInformer,
 	federationDomainInformer v1alpha1.FederationDomainInformer,
+	clock clock.Clock,
 	withInformer pinnipedcontroller.WithInformerOptionFunc,
 ) controllerlib.Controller {
 	return controllerlib.New(
 		controllerlib.Config{
-			Name: "tls-certs-observer-controller",
+			Name: tlsCertObserverControllerName,
 			Syncer: &tlsCertObserverController{
 				issuerTLSCertSetter:             issuerTLSCertSetter,
 				defaultTLSCertificateSecretName: defaultTLSCertificateSecretName,
+				pinnipedClient:                  pinnipedClient,
 				federationDomainInformer:        federationDomainInformer,
 				secretInformer:                  secretInformer,
+				clock:                           clock,
 			},
 		},
 		withInformer(
 			secretInformer,
-			pinnipedcontroller.MatchAnySecretOfTypeFilter(corev1.SecretTypeTLS, nil),
+			// Also watch the referenced Secrets of other types, to report that they have the wrong type.
+			pinnipedcontroller.SimpleFilter(func(obj metav1.Object) bool {
+				secret, ok := obj.(*corev1.Secret)
+				return ok && (secret.Type == corev1.SecretTypeTLS ||
+					secret.Name == defaultTLSCertificateSecretName ||
+					isReferencedByAnyFederationDomain(federationDomainInformer, secret))
+			}, nil),
 			controllerlib.InformerOption{},
 		),
 		withInformer(
@@ -72,24 +111,32 @@ func (c *tlsCertObserverController) Sync(ctx controllerlib.Context) error {
 	// Rebuild the whole map on any change to any Secret or FederationDomain, because either can have changes that
 	// can cause the map to need to be updated.
 	issuerHostToTLSCertMap := map[string]*tls.Certificate{}
+	// The TLSSecretValid condition of each FederationDomain, or nil when it does not name a Secret.
+	tlsSecretConditions := map[*supervisorconfigv1alpha1.FederationDomain]*metav1.Condition{}
+	// The hosts of the previous issuers of FederationDomains which are migrating to a new issuer are served with
+	// the same certificate, unless the host is also the host of the issuer of any FederationDomain.
+	previousIssuerHostToTLSCertMap := map[string]*tls.Certificate{}
 
 	for _, provider := range allProviders {
-		issuerURL, err := url.Parse(provider.Spec.Issuer)
-		if err != nil {
-			plog.Debug("tlsCertObserverController Sync found an invalid issuer URL", "namespace", ns, "issuer", provider.Spec.Issuer)
-			continue
-		}
-
 		secretName := ""
 		if provider.Spec.TLS != nil {
 			secretName = provider.Spec.TLS.SecretName
 		}
 		if secretName == "" {
 			// No secret name provided, so no need to try to load any Secret.
+			tlsSecretConditions[provider] = nil
 			continue
 		}
 
 		certFromSecret, err := c.certFromSecret(ns, secretName)
+		tlsSecretConditions[provider] = tlsSecretValidCondition(err)
+
+		issuerURL, urlErr := url.Parse(provider.Spec.Issuer)
+		if urlErr != nil {
+			plog.Debug("tlsCertObserverController Sync found an invalid issuer URL", "namespace", ns, "issuer", provider.Spec.Issuer)
+			continue
+		}
+
 		if err != nil {
 			// The user configured a TLS secret on the FederationDomain but it could not be loaded,
 			// so log a message which is visible at the default log level. Any error here indicates a problem,
@@ -103,6 +150,18 @@ func (c *tlsCertObserverController) Sync(ctx controllerlib.Context) error {
 
 		// Lowercase the host part of the URL because hostnames should be treated as case-insensitive.
 		issuerHostToTLSCertMap[lowercaseHostWithoutPort(issuerURL)] = certFromSecret
+
+		if provider.Spec.PreviousIssuer != nil {
+			if previousIssuerURL, err := url.Parse(provider.Spec.PreviousIssuer.Issuer); err == nil {
+				previousIssuerHostToTLSCertMap[lowercaseHostWithoutPort(previousIssuerURL)] = certFromSecret
+			}
+		}
+	}
+
+	for host, cert := range previousIssuerHostToTLSCertMap {
+		if _, ok := issuerHostToTLSCertMap[host]; !ok {
+			issuerHostToTLSCertMap[host] = cert
+		}
 	}
 
 	plog.Debug("tlsCertObserverController Sync updated the TLS cert cache", "issuerHostCount", len(issuerHostToTLSCertMap))
@@ -122,6 +181,75 @@ func (c *tlsCertObserverController) Sync(ctx controllerlib.Context) error {
 		c.issuerTLSCertSetter.SetDefaultTLSCert(defaultCert)
 	}
 
+	// Now that the certificates are loaded, report in the status of each FederationDomain whether its Secret was loaded.
+	var errs []error
+	for provider, condition := range tlsSecretConditions {
+		if err := c.updateStatus(ctx.Context, provider, condition); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// tlsSecretValidCondition returns the TLSSecretValid condition for the result of loading the Secret named by
+// spec.tls.secretName.
+func tlsSecretValidCondition(loadErr error) *metav1.Condition {
+	var wrongTypeErr *wrongSecretTypeError
+	switch {
+	case apierrors.IsNotFound(loadErr):
+		return &metav1.Condition{
+			Type:    configconditions.TypeTLSSecretValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  configconditions.ReasonTLSSecretNotFound,
+			Message: fmt.Sprintf("the Secret named by spec.tls.secretName was not found: %s", loadErr.Error()),
+		}
+	case errors.As(loadErr, &wrongTypeErr):
+		return &metav1.Condition{
+			Type:    configconditions.TypeTLSSecretValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  configconditions.ReasonTLSSecretWrongType,
+			Message: fmt.Sprintf("the Secret named by spec.tls.secretName cannot be used: %s", loadErr.Error()),
+		}
+	case loadErr != nil:
+		return &metav1.Condition{
+			Type:    configconditions.TypeTLSSecretValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  configconditions.ReasonInvalidTLSSecret,
+			Message: fmt.Sprintf("the Secret named by spec.tls.secretName does not contain a valid certificate and private key: %s", loadErr.Error()),
+		}
+	default:
+		return &metav1.Condition{
+			Type:    configconditions.TypeTLSSecretValid,
+			Status:  metav1.ConditionTrue,
+			Reason:  configconditions.ReasonSuccess,
+			Message: "the Secret named by spec.tls.secretName contains a valid certificate and private key",
+		}
+	}
+}
+
+func (c *tlsCertObserverController) updateStatus(
+	ctx context.Context,
+	federationDomain *supervisorconfigv1alpha1.FederationDomain,
+	condition *metav1.Condition, // nil removes the condition
+) error {
+	updated := federationDomain.DeepCopy()
+	if condition == nil {
+		updated.Status.Conditions = slices.DeleteFunc(updated.Status.Conditions, func(c metav1.Condition) bool {
+			return c.Type == configconditions.TypeTLSSecretValid
+		})
+	} else {
+		_ = conditionsutil.MergeConditions([]*metav1.Condition{condition}, federationDomain.Generation, &updated.Status.Conditions,
+			plog.New().WithName(tlsCertObserverControllerName), metav1.NewTime(c.clock.Now()))
+	}
+
+	if equality.Semantic.DeepEqual(federationDomain, updated) {
+		return nil
+	}
+
+	_, err := c.pinnipedClient.ConfigV1alpha1().FederationDomains(federationDomain.Namespace).UpdateStatus(ctx, updated, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("cannot update status of FederationDomain %s/%s: %w", federationDomain.Namespace, federationDomain.Name, err)
+	}
 	return nil
 }
 
@@ -131,6 +259,10 @@ func (c *tlsCertObserverController) certFromSecret(ns string, secretName string)
 		plog.Debug("tlsCertObserverController Sync could not find TLS cert secret", "namespace", ns, "secretName", secretName)
 		return nil, err
 	}
+	if tlsSecret.Type != corev1.SecretTypeTLS {
+		plog.Debug("tlsCertObserverController Sync found a TLS secret with the wrong type", "namespace", ns, "secretName", secretName, "type", tlsSecret.Type)
+		return nil, &wrongSecretTypeError{secretName: secretName, secretType: tlsSecret.Type}
+	}
 	certFromSecret, err := tls.X509KeyPair(tlsSecret.Data["tls.crt"], tlsSecret.Data["tls.key"])
 	if err != nil {
 		plog.Debug("tlsCertObserverController Sync found a TLS secret with Data in an unexpected format", "namespace", ns, "secretName", secretName)
@@ -139,6 +271,17 @@ func (c *tlsCertObserverController) certFromSecret(ns string, secretName string)
 	return &certFromSecret, nil
 }
 
+// isReferencedByAnyFederationDomain returns true when the spec.tls.secretName of any FederationDomain names the Secret.
+func isReferencedByAnyFederationDomain(federationDomainInformer v1alpha1.FederationDomainInformer, secret *corev1.Secret) bool {
+	federationDomains, err := federationDomainInformer.Lister().FederationDomains(secret.Namespace).List(labels.Everything())
+	if err != nil {
+		return false
+	}
+	return slices.ContainsFunc(federationDomains, func(fd *supervisorconfigv1alpha1.FederationDomain) bool {
+		return fd.Spec.TLS != nil && fd.Spec.TLS.SecretName == secret.Name
+	})
+}
+
 func lowercaseHostWithoutPort(issuerURL *url.URL) string {
 	lowercaseHost := strings.ToLower(issuerURL.Hostname())
 	return lowercaseHost
@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
@@ -17,7 +18,9 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sinformers "k8s.io/client-go/informers"
 	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	clocktesting "k8s.io/utils/clock/testing"
 
+	configconditions "go.pinniped.dev/generated/latest/apis/supervisor/config/conditions"
 	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
 	supervisorfake "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/fake"
 	supervisorinformers "go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions"
@@ -39,11 +42,19 @@ func TestTLSCertObserverControllerInformerFilters(t *testing.T) {
 			observableWithInformerOption = testutil.NewObservableWithInformerOption()
 			secretsInformer := k8sinformers.NewSharedInformerFactory(nil, 0).Core().V1().Secrets()
 			federationDomainInformer := supervisorinformers.NewSharedInformerFactory(nil, 0).Config().V1alpha1().FederationDomains()
+			r.NoError(federationDomainInformer.Informer().GetIndexer().Add(&supervisorconfigv1alpha1.FederationDomain{
+				ObjectMeta: metav1.ObjectMeta{Name: "any-name", Namespace: "any-namespace"},
+				Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+					TLS: &supervisorconfigv1alpha1.FederationDomainTLSSpec{SecretName: "referenced-secret-name"},
+				},
+			}))
 			_ = NewTLSCertObserverController(
 				nil,
-				"", // don't care about the secret name for this test
+				"default-secret-name",
+				nil,
 				secretsInformer,
 				federationDomainInformer,
+				nil,
 				observableWithInformerOption.WithInformer, // make it possible to observe the behavior of the Filters
 			)
 			secretsInformerFilter = observableWithInformerOption.GetFilterForInformer(secretsInformer)
@@ -78,6 +89,27 @@ func TestTLSCertObserverControllerInformerFilters(t *testing.T) {
 					r.False(subject.Delete(otherSecret))
 				})
 			})
+
+			when("a Secret that is not of type TLS but is named by the spec.tls.secretName of a FederationDomain changes", func() {
+				it("returns true to trigger the sync method, to report its wrong type", func() {
+					referencedSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "referenced-secret-name", Namespace: "any-namespace"}, Type: corev1.SecretTypeOpaque}
+					r.True(subject.Add(referencedSecret))
+					r.True(subject.Update(referencedSecret, referencedSecret))
+					r.True(subject.Delete(referencedSecret))
+
+					secretInOtherNamespace := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "referenced-secret-name", Namespace: "any-other-namespace"}, Type: corev1.SecretTypeOpaque}
+					r.False(subject.Add(secretInOtherNamespace))
+				})
+			})
+
+			when("a Secret that is not of type TLS but has the name of the default TLS secret changes", func() {
+				it("returns true to trigger the sync method, to log its wrong type", func() {
+					defaultSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "default-secret-name", Namespace: "any-namespace"}, Type: corev1.SecretTypeOpaque}
+					r.True(subject.Add(defaultSecret))
+					r.True(subject.Update(defaultSecret, defaultSecret))
+					r.True(subject.Delete(defaultSecret))
+				})
+			})
 		})
 
 		when("watching FederationDomain objects", func() {
@@ -131,6 +163,7 @@ func TestTLSCertObserverControllerSync(t *testing.T) {
 		var (
 			r                       *require.Assertions
 			subject                 controllerlib.Controller
+			pinnipedAPIClient       *supervisorfake.Clientset
 			pinnipedInformerClient  *supervisorfake.Clientset
 			kubeInformerClient      *kubernetesfake.Clientset
 			pinnipedInformers       supervisorinformers.SharedInformerFactory
@@ -139,6 +172,7 @@ func TestTLSCertObserverControllerSync(t *testing.T) {
 			cancelContextCancelFunc context.CancelFunc
 			syncContext             *controllerlib.Context
 			issuerTLSCertSetter     *fakeIssuerTLSCertSetter
+			frozenNow               time.Time
 		)
 
 		// Defer starting the informers until the last possible moment so that the
@@ -148,8 +182,10 @@ func TestTLSCertObserverControllerSync(t *testing.T) {
 			subject = NewTLSCertObserverController(
 				issuerTLSCertSetter,
 				defaultTLSSecretName,
+				pinnipedAPIClient,
 				kubeInformers.Core().V1().Secrets(),
 				pinnipedInformers.Config().V1alpha1().FederationDomains(),
+				clocktesting.NewFakeClock(frozenNow),
 				controllerlib.WithInformer,
 			)
 
@@ -175,6 +211,18 @@ func TestTLSCertObserverControllerSync(t *testing.T) {
 			return data
 		}
 
+		var addFederationDomain = func(federationDomain *supervisorconfigv1alpha1.FederationDomain) {
+			r.NoError(pinnipedAPIClient.Tracker().Add(federationDomain))
+			r.NoError(pinnipedInformerClient.Tracker().Add(federationDomain))
+		}
+
+		var getTLSSecretValidCondition = func(federationDomainName string) *metav1.Condition {
+			federationDomain, err := pinnipedAPIClient.ConfigV1alpha1().FederationDomains(installedInNamespace).
+				Get(cancelContext, federationDomainName, metav1.GetOptions{})
+			r.NoError(err)
+			return configconditions.FindCondition(federationDomain.Status.Conditions, "TLSSecretValid")
+		}
+
 		it.Before(func() {
 			r = require.New(t)
 
@@ -182,9 +230,11 @@ func TestTLSCertObserverControllerSync(t *testing.T) {
 
 			kubeInformerClient = kubernetesfake.NewSimpleClientset()
 			kubeInformers = k8sinformers.NewSharedInformerFactory(kubeInformerClient, 0)
+			pinnipedAPIClient = supervisorfake.NewSimpleClientset()
 			pinnipedInformerClient = supervisorfake.NewSimpleClientset()
 			pinnipedInformers = supervisorinformers.NewSharedInformerFactory(pinnipedInformerClient, 0)
 			issuerTLSCertSetter = &fakeIssuerTLSCertSetter{}
+			frozenNow = time.Date(2020, time.September, 23, 7, 42, 0, 0, time.Local)
 
 			unrelatedSecret := &corev1.Secret{
 				ObjectMeta: metav1.ObjectMeta{
@@ -236,6 +286,26 @@ func TestTLSCertObserverControllerSync(t *testing.T) {
 						TLS:    &supervisorconfigv1alpha1.FederationDomainTLSSpec{SecretName: ""},
 					},
 				}
+				federationDomainWithMissingSecret := &supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "missing-secret-federationdomain",
+						Namespace: installedInNamespace,
+					},
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer: "https://missing-secret-issuer.com",
+						TLS:    &supervisorconfigv1alpha1.FederationDomainTLSSpec{SecretName: "missing-tls-secret-name"},
+					},
+				}
+				federationDomainWithWrongTypeSecret := &supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "wrong-type-secret-federationdomain",
+						Namespace: installedInNamespace,
+					},
+					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
+						Issuer: "https://wrong-type-secret-issuer.com",
+						TLS:    &supervisorconfigv1alpha1.FederationDomainTLSSpec{SecretName: "wrong-type-tls-secret-name"},
+					},
+				}
 				federationDomainWithBadSecret := &supervisorconfigv1alpha1.FederationDomain{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      "bad-secret-federationdomain",
@@ -263,9 +333,13 @@ func TestTLSCertObserverControllerSync(t *testing.T) {
 						Namespace: installedInNamespace,
 					},
 					// Issuer hostname should be treated in a case-insensitive way and SNI ignores port numbers. Test without a port number.
+					// The host of its previous issuer is also the host of another issuer, which takes precedence.
 					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
 						Issuer: "https://www.iSSuer-wiTh-goOd-secRet1.cOm/path",
 						TLS:    &supervisorconfigv1alpha1.FederationDomainTLSSpec{SecretName: "good-tls-secret-name1"},
+						PreviousIssuer: &supervisorconfigv1alpha1.FederationDomainPreviousIssuer{
+							Issuer: "https://www.issuer-with-good-secret2.com/old-path",
+						},
 					},
 				}
 				federationDomainWithGoodSecret2 := &supervisorconfigv1alpha1.FederationDomain{
@@ -274,9 +348,13 @@ func TestTLSCertObserverControllerSync(t *testing.T) {
 						Namespace: installedInNamespace,
 					},
 					// Issuer hostname should be treated in a case-insensitive way and SNI ignores port numbers. Test with a port number.
+					// The host of its previous issuer should be served with the same cert.
 					Spec: supervisorconfigv1alpha1.FederationDomainSpec{
 						Issuer: "https://www.issUEr-WIth-gOOd-seCret2.com:1234/path",
 						TLS:    &supervisorconfigv1alpha1.FederationDomainTLSSpec{SecretName: "good-tls-secret-name2"},
+						PreviousIssuer: &supervisorconfigv1alpha1.FederationDomainPreviousIssuer{
+							Issuer: "https://www.OLD-issuer-with-good-secret2.com/path",
+						},
 					},
 				}
 				federationDomainWithIPv6Issuer := &supervisorconfigv1alpha1.FederationDomain{
@@ -304,26 +382,39 @@ func TestTLSCertObserverControllerSync(t *testing.T) {
 				r.NoError(err)
 				goodTLSSecret1 := &corev1.Secret{
 					ObjectMeta: metav1.ObjectMeta{Name: "good-tls-secret-name1", Namespace: installedInNamespace},
+					Type:       corev1.SecretTypeTLS,
 					Data:       map[string][]byte{"tls.crt": testCrt1, "tls.key": testKey1},
 				}
 				goodTLSSecret2 := &corev1.Secret{
 					ObjectMeta: metav1.ObjectMeta{Name: "good-tls-secret-name2", Namespace: installedInNamespace},
+					Type:       corev1.SecretTypeTLS,
 					Data:       map[string][]byte{"tls.crt": testCrt2, "tls.key": testKey2},
 				}
 				badTLSSecret := &corev1.Secret{
 					ObjectMeta: metav1.ObjectMeta{Name: "bad-tls-secret-name", Namespace: installedInNamespace},
+					Type:       corev1.SecretTypeTLS,
 					Data:       map[string][]byte{"junk": nil},
 				}
-				r.NoError(pinnipedInformerClient.Tracker().Add(federationDomainWithoutSecret1))
-				r.NoError(pinnipedInformerClient.Tracker().Add(federationDomainWithoutSecret2))
-				r.NoError(pinnipedInformerClient.Tracker().Add(federationDomainWithBadSecret))
-				r.NoError(pinnipedInformerClient.Tracker().Add(federationDomainWithBadIssuer))
-				r.NoError(pinnipedInformerClient.Tracker().Add(federationDomainWithGoodSecret1))
-				r.NoError(pinnipedInformerClient.Tracker().Add(federationDomainWithGoodSecret2))
-				r.NoError(pinnipedInformerClient.Tracker().Add(federationDomainWithIPv6Issuer))
+				// The Supervisor does not cache the contents of Opaque Secrets, so this certificate can never be loaded,
+				// even though it would be valid.
+				wrongTypeTLSSecret := &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "wrong-type-tls-secret-name", Namespace: installedInNamespace},
+					Type:       corev1.SecretTypeOpaque,
+					Data:       map[string][]byte{"tls.crt": testCrt1, "tls.key": testKey1},
+				}
+				addFederationDomain(federationDomainWithoutSecret1)
+				addFederationDomain(federationDomainWithoutSecret2)
+				addFederationDomain(federationDomainWithMissingSecret)
+				addFederationDomain(federationDomainWithWrongTypeSecret)
+				addFederationDomain(federationDomainWithBadSecret)
+				addFederationDomain(federationDomainWithBadIssuer)
+				addFederationDomain(federationDomainWithGoodSecret1)
+				addFederationDomain(federationDomainWithGoodSecret2)
+				addFederationDomain(federationDomainWithIPv6Issuer)
 				r.NoError(kubeInformerClient.Tracker().Add(goodTLSSecret1))
 				r.NoError(kubeInformerClient.Tracker().Add(goodTLSSecret2))
 				r.NoError(kubeInformerClient.Tracker().Add(badTLSSecret))
+				r.NoError(kubeInformerClient.Tracker().Add(wrongTypeTLSSecret))
 			})
 
 			it("updates the issuerTLSCertSetter's map to include only the issuers that had valid certs", func() {
@@ -334,7 +425,7 @@ func TestTLSCertObserverControllerSync(t *testing.T) {
 				r.Nil(issuerTLSCertSetter.setDefaultTLSCertReceived)
 
 				r.True(issuerTLSCertSetter.setIssuerHostToTLSCertMapWasCalled)
-				r.Len(issuerTLSCertSetter.issuerHostToTLSCertMapReceived, 3)
+				r.Len(issuerTLSCertSetter.issuerHostToTLSCertMapReceived, 4)
 
 				// They keys in the map should be lower case and should not include the port numbers, because
 				// TLS SNI says that SNI hostnames must be DNS names (not ports) and must be case insensitive.
@@ -350,6 +441,54 @@ func TestTLSCertObserverControllerSync(t *testing.T) {
 				actualCertificate3 := issuerTLSCertSetter.issuerHostToTLSCertMapReceived["2001:db8::1"]
 				r.NotNil(actualCertificate3)
 				r.Equal(expectedCertificate1, *actualCertificate3)
+
+				actualCertificate4 := issuerTLSCertSetter.issuerHostToTLSCertMapReceived["www.old-issuer-with-good-secret2.com"]
+				r.NotNil(actualCertificate4)
+				r.Equal(expectedCertificate2, *actualCertificate4)
+			})
+
+			it("reports whether the Secret named by spec.tls.secretName could be loaded in the status of each FederationDomain", func() {
+				startInformersAndController()
+				r.NoError(controllerlib.TestSync(t, subject, *syncContext))
+
+				frozenMetav1Now := metav1.NewTime(frozenNow)
+				happyCondition := &metav1.Condition{
+					Type:               "TLSSecretValid",
+					Status:             "True",
+					Reason:             "Success",
+					Message:            "the Secret named by spec.tls.secretName contains a valid certificate and private key",
+					LastTransitionTime: frozenMetav1Now,
+				}
+				r.Equal(happyCondition, getTLSSecretValidCondition("good-secret-federationdomain1"))
+				r.Equal(happyCondition, getTLSSecretValidCondition("good-secret-federationdomain2"))
+				r.Equal(happyCondition, getTLSSecretValidCondition("ipv6-issuer-federationdomain"))
+
+				r.Equal(&metav1.Condition{
+					Type:               "TLSSecretValid",
+					Status:             "False",
+					Reason:             "TLSSecretWrongType",
+					Message:            `the Secret named by spec.tls.secretName cannot be used: secret "wrong-type-tls-secret-name" has wrong type "Opaque" (should be "kubernetes.io/tls")`,
+					LastTransitionTime: frozenMetav1Now,
+				}, getTLSSecretValidCondition("wrong-type-secret-federationdomain"))
+				r.Equal(&metav1.Condition{
+					Type:               "TLSSecretValid",
+					Status:             "False",
+					Reason:             "TLSSecretNotFound",
+					Message:            `the Secret named by spec.tls.secretName was not found: secret "missing-tls-secret-name" not found`,
+					LastTransitionTime: frozenMetav1Now,
+				}, getTLSSecretValidCondition("missing-secret-federationdomain"))
+				r.Equal(&metav1.Condition{
+					Type:               "TLSSecretValid",
+					Status:             "False",
+					Reason:             "InvalidTLSSecret",
+					Message:            "the Secret named by spec.tls.secretName does not contain a valid certificate and private key: tls: failed to find any PEM data in certificate input",
+					LastTransitionTime: frozenMetav1Now,
+				}, getTLSSecretValidCondition("bad-secret-federationdomain"))
+
+				// FederationDomains which do not name a Secret do not have the condition.
+				r.Nil(getTLSSecretValidCondition("no-secret-federationdomain1"))
+				r.Nil(getTLSSecretValidCondition("no-secret-federationdomain2"))
+				r.Nil(getTLSSecretValidCondition("bad-issuer-federationdomain"))
 			})
 
 			when("there is also a default TLS cert secret with the configured default TLS cert secret name", func() {
@@ -367,6 +506,7 @@ func TestTLSCertObserverControllerSync(t *testing.T) {
 					r.NoError(err)
 					defaultTLSCertSecret := &corev1.Secret{
 						ObjectMeta: metav1.ObjectMeta{Name: defaultTLSSecretName, Namespace: installedInNamespace},
+						Type:       corev1.SecretTypeTLS,
 						Data:       map[string][]byte{"tls.crt": testCrt, "tls.key": testKey},
 					}
 					r.NoError(kubeInformerClient.Tracker().Add(defaultTLSCertSecret))
@@ -382,7 +522,7 @@ func TestTLSCertObserverControllerSync(t *testing.T) {
 					r.Equal(expectedDefaultCertificate, *actualDefaultCertificate)
 
 					r.True(issuerTLSCertSetter.setIssuerHostToTLSCertMapWasCalled)
-					r.Len(issuerTLSCertSetter.issuerHostToTLSCertMapReceived, 3)
+					r.Len(issuerTLSCertSetter.issuerHostToTLSCertMapReceived, 4)
 				})
 			})
 		})
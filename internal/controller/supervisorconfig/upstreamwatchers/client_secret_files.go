@@ -0,0 +1,113 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package upstreamwatchers
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
+	"go.pinniped.dev/internal/constable"
+)
+
+const (
+	// ClientIDFileName and ClientSecretFileName are the names of the files which are read from the directory
+	// of a file ClientSecretRef.
+	ClientIDFileName     = "clientID"
+	ClientSecretFileName = "clientSecret"
+
+	// ClientSecretFilesResyncInterval is how often the watchers re-read the files of a ClientSecretRef. The files are
+	// polled rather than watched because secret store CSI drivers replace them by swapping symlinks, which is not
+	// reliably observed by file system notifications.
+	ClientSecretFilesResyncInterval = time.Minute
+
+	ErrClientSecretFileEmpty = constable.Error("file is empty")
+)
+
+// UsesClientSecretFiles returns true when the ClientSecretRef refers to files which must be re-read periodically.
+func UsesClientSecretFiles(ref *idpv1alpha1.ClientSecretRef) bool {
+	return ref != nil && ref.Type == idpv1alpha1.ClientSecretRefTypeFile
+}
+
+// ReadClientSecretFiles reads the client ID and client secret from the files in the directory of a ClientSecretRef.
+// The directory and the files, after resolving any symlinks, must be inside one of the allowedDirectories, so that
+// an identity provider cannot be used to read any other file of the Supervisor pod. Leading and trailing whitespace,
+// such as a trailing newline, is removed from the contents of the files.
+//
+// The returned error wraps ErrClientSecretFileEmpty when a file exists but is empty.
+func ReadClientSecretFiles(ref *idpv1alpha1.ClientSecretRef, allowedDirectories []string) (string, string, error) {
+	if ref.Type != idpv1alpha1.ClientSecretRefTypeFile {
+		return "", "", fmt.Errorf("unsupported secretRef type %q", ref.Type)
+	}
+
+	if _, err := resolveInAllowedDirectory(ref.Path, allowedDirectories); err != nil {
+		return "", "", err
+	}
+
+	clientID, err := readClientSecretFile(filepath.Join(ref.Path, ClientIDFileName), allowedDirectories)
+	if err != nil {
+		return "", "", err
+	}
+	clientSecret, err := readClientSecretFile(filepath.Join(ref.Path, ClientSecretFileName), allowedDirectories)
+	if err != nil {
+		return "", "", err
+	}
+	return clientID, clientSecret, nil
+}
+
+func readClientSecretFile(path string, allowedDirectories []string) (string, error) {
+	resolved, err := resolveInAllowedDirectory(path, allowedDirectories)
+	if err != nil {
+		return "", err
+	}
+
+	contents, err := os.ReadFile(resolved) //nolint:gosec // the path was checked to be inside an allowed directory
+	if err != nil {
+		return "", fmt.Errorf("could not read file %q: %w", path, withoutPath(err))
+	}
+
+	value := strings.TrimSpace(string(contents))
+	if value == "" {
+		return "", fmt.Errorf("could not read file %q: %w", path, ErrClientSecretFileEmpty)
+	}
+	return value, nil
+}
+
+// resolveInAllowedDirectory returns the path with all symlinks resolved, or an error when the resolved path
+// is not inside one of the allowedDirectories.
+func resolveInAllowedDirectory(path string, allowedDirectories []string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("could not read %q: %w", path, withoutPath(err))
+	}
+
+	for _, dir := range allowedDirectories {
+		resolvedDir, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			// The directory might not be mounted in this Supervisor pod, so it cannot contain the path.
+			continue
+		}
+		rel, err := filepath.Rel(resolvedDir, resolved)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return resolved, nil
+		}
+	}
+
+	return "", fmt.Errorf("path %q is not inside any of the allowed directories %q from the externalClientSecrets "+
+		"setting of the Supervisor's static configuration", path, allowedDirectories)
+}
+
+// withoutPath removes the path from a *fs.PathError, since the callers already include the path in their messages.
+func withoutPath(err error) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err
+	}
+	return err
+}
@@ -0,0 +1,166 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package upstreamwatchers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
+)
+
+func TestReadClientSecretFiles(t *testing.T) {
+	writeFile := func(t *testing.T, path, contents string) {
+		t.Helper()
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	}
+
+	tests := []struct {
+		name             string
+		setup            func(t *testing.T, allowedDir, otherDir string) string // returns the path of the secretRef
+		refType          idpv1alpha1.ClientSecretRefType
+		wantClientID     string
+		wantClientSecret string
+		wantErr          func(allowedDir, otherDir string) string
+		wantEmptyErr     bool
+	}{
+		{
+			name: "happy path",
+			setup: func(t *testing.T, allowedDir, _ string) string {
+				writeFile(t, filepath.Join(allowedDir, "idp", "clientID"), "some-client-id\n")
+				writeFile(t, filepath.Join(allowedDir, "idp", "clientSecret"), "some-client-secret\n")
+				return filepath.Join(allowedDir, "idp")
+			},
+			wantClientID:     "some-client-id",
+			wantClientSecret: "some-client-secret",
+		},
+		{
+			name: "files are symlinks within the allowed directory, as written by secret store CSI drivers",
+			setup: func(t *testing.T, allowedDir, _ string) string {
+				writeFile(t, filepath.Join(allowedDir, "idp", "..2024_01_01", "clientID"), "some-client-id")
+				writeFile(t, filepath.Join(allowedDir, "idp", "..2024_01_01", "clientSecret"), "some-client-secret")
+				require.NoError(t, os.Symlink("..2024_01_01", filepath.Join(allowedDir, "idp", "..data")))
+				require.NoError(t, os.Symlink(filepath.Join("..data", "clientID"), filepath.Join(allowedDir, "idp", "clientID")))
+				require.NoError(t, os.Symlink(filepath.Join("..data", "clientSecret"), filepath.Join(allowedDir, "idp", "clientSecret")))
+				return filepath.Join(allowedDir, "idp")
+			},
+			wantClientID:     "some-client-id",
+			wantClientSecret: "some-client-secret",
+		},
+		{
+			name:    "unsupported type",
+			refType: "vault",
+			setup: func(t *testing.T, allowedDir, _ string) string {
+				return allowedDir
+			},
+			wantErr: func(_, _ string) string {
+				return `unsupported secretRef type "vault"`
+			},
+		},
+		{
+			name: "directory is outside of the allowed directories",
+			setup: func(t *testing.T, _, otherDir string) string {
+				writeFile(t, filepath.Join(otherDir, "clientID"), "some-client-id")
+				writeFile(t, filepath.Join(otherDir, "clientSecret"), "some-client-secret")
+				return otherDir
+			},
+			wantErr: func(allowedDir, otherDir string) string {
+				return fmt.Sprintf(`path %q is not inside any of the allowed directories ["%s"] from the externalClientSecrets `+
+					`setting of the Supervisor's static configuration`, otherDir, allowedDir)
+			},
+		},
+		{
+			name: "directory uses dot dot to escape the allowed directories",
+			setup: func(t *testing.T, allowedDir, _ string) string {
+				return filepath.Join(allowedDir, "..")
+			},
+			wantErr: func(allowedDir, _ string) string {
+				return fmt.Sprintf(`path %q is not inside any of the allowed directories ["%s"] from the externalClientSecrets `+
+					`setting of the Supervisor's static configuration`, filepath.Dir(allowedDir), allowedDir)
+			},
+		},
+		{
+			name: "file is a symlink which escapes the allowed directories",
+			setup: func(t *testing.T, allowedDir, otherDir string) string {
+				writeFile(t, filepath.Join(allowedDir, "idp", "clientID"), "some-client-id")
+				writeFile(t, filepath.Join(otherDir, "token"), "some-other-secret")
+				require.NoError(t, os.Symlink(filepath.Join(otherDir, "token"), filepath.Join(allowedDir, "idp", "clientSecret")))
+				return filepath.Join(allowedDir, "idp")
+			},
+			wantErr: func(allowedDir, _ string) string {
+				return fmt.Sprintf(`path %q is not inside any of the allowed directories ["%s"] from the externalClientSecrets `+
+					`setting of the Supervisor's static configuration`, filepath.Join(allowedDir, "idp", "clientSecret"), allowedDir)
+			},
+		},
+		{
+			name: "directory does not exist",
+			setup: func(t *testing.T, allowedDir, _ string) string {
+				return filepath.Join(allowedDir, "idp")
+			},
+			wantErr: func(allowedDir, _ string) string {
+				return fmt.Sprintf(`could not read %q: no such file or directory`, filepath.Join(allowedDir, "idp"))
+			},
+		},
+		{
+			name: "client secret file does not exist",
+			setup: func(t *testing.T, allowedDir, _ string) string {
+				writeFile(t, filepath.Join(allowedDir, "idp", "clientID"), "some-client-id")
+				return filepath.Join(allowedDir, "idp")
+			},
+			wantErr: func(allowedDir, _ string) string {
+				return fmt.Sprintf(`could not read %q: no such file or directory`, filepath.Join(allowedDir, "idp", "clientSecret"))
+			},
+		},
+		{
+			name: "client ID file is empty",
+			setup: func(t *testing.T, allowedDir, _ string) string {
+				writeFile(t, filepath.Join(allowedDir, "idp", "clientID"), "\n")
+				writeFile(t, filepath.Join(allowedDir, "idp", "clientSecret"), "some-client-secret")
+				return filepath.Join(allowedDir, "idp")
+			},
+			wantErr: func(allowedDir, _ string) string {
+				return fmt.Sprintf(`could not read file %q: file is empty`, filepath.Join(allowedDir, "idp", "clientID"))
+			},
+			wantEmptyErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowedDir := t.TempDir()
+			otherDir := t.TempDir()
+
+			refType := tt.refType
+			if refType == "" {
+				refType = idpv1alpha1.ClientSecretRefTypeFile
+			}
+			ref := &idpv1alpha1.ClientSecretRef{Type: refType, Path: tt.setup(t, allowedDir, otherDir)}
+
+			clientID, clientSecret, err := ReadClientSecretFiles(ref, []string{allowedDir})
+			if tt.wantErr != nil {
+				require.EqualError(t, err, tt.wantErr(allowedDir, otherDir))
+				require.Equal(t, tt.wantEmptyErr, errors.Is(err, ErrClientSecretFileEmpty))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantClientID, clientID)
+			require.Equal(t, tt.wantClientSecret, clientSecret)
+		})
+	}
+}
+
+func TestReadClientSecretFilesWithoutAllowedDirectories(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "clientID"), []byte("some-client-id"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "clientSecret"), []byte("some-client-secret"), 0o600))
+
+	_, _, err := ReadClientSecretFiles(&idpv1alpha1.ClientSecretRef{Type: idpv1alpha1.ClientSecretRefTypeFile, Path: dir}, nil)
+	require.EqualError(t, err, fmt.Sprintf(`path %q is not inside any of the allowed directories [] from the externalClientSecrets `+
+		`setting of the Supervisor's static configuration`, dir))
+}
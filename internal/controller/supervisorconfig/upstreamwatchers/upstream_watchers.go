@@ -14,19 +14,22 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	corev1informers "k8s.io/client-go/informers/core/v1"
 
+	idpconditions "go.pinniped.dev/generated/latest/apis/supervisor/idp/conditions"
 	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
 	"go.pinniped.dev/internal/constable"
 	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
+	"go.pinniped.dev/internal/net/phttp"
 	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/internal/totp"
 	"go.pinniped.dev/internal/upstreamldap"
 )
 
 const (
-	ReasonNotFound         = "SecretNotFound"
-	ReasonWrongType        = "SecretWrongType"
-	ReasonMissingKeys      = "SecretMissingKeys"
-	ReasonSuccess          = "Success"
-	ReasonInvalidTLSConfig = "InvalidTLSConfig"
+	ReasonNotFound         = idpconditions.ReasonSecretNotFound
+	ReasonWrongType        = idpconditions.ReasonSecretWrongType
+	ReasonMissingKeys      = idpconditions.ReasonSecretMissingKeys
+	ReasonSuccess          = idpconditions.ReasonSuccess
+	ReasonInvalidTLSConfig = idpconditions.ReasonInvalidTLSConfig
 
 	ErrNoCertificates = constable.Error("no certificates found")
 
@@ -34,15 +37,15 @@ const (
 	probeLDAPTimeout          = 90 * time.Second
 
 	// Constants related to conditions.
-	typeBindSecretValid              = "BindSecretValid"
-	typeTLSConfigurationValid        = "TLSConfigurationValid"
-	typeLDAPConnectionValid          = "LDAPConnectionValid"
-	TypeSearchBaseFound              = "SearchBaseFound"
-	reasonLDAPConnectionError        = "LDAPConnectionError"
+	typeBindSecretValid              = idpconditions.TypeBindSecretValid
+	typeTLSConfigurationValid        = idpconditions.TypeTLSConfigurationValid
+	typeLDAPConnectionValid          = idpconditions.TypeLDAPConnectionValid
+	TypeSearchBaseFound              = idpconditions.TypeSearchBaseFound
+	reasonLDAPConnectionError        = idpconditions.ReasonLDAPConnectionError
 	noTLSConfigurationMessage        = "no TLS configuration provided"
 	loadedTLSConfigurationMessage    = "loaded TLS configuration"
-	ReasonUsingConfigurationFromSpec = "UsingConfigurationFromSpec"
-	ReasonErrorFetchingSearchBase    = "ErrorFetchingSearchBase"
+	ReasonUsingConfigurationFromSpec = idpconditions.ReasonUsingConfigurationFromSpec
+	ReasonErrorFetchingSearchBase    = idpconditions.ReasonErrorFetchingSearchBase
 )
 
 // ValidatedSettings is the struct which is cached by the ValidatedSettingsCacheI interface.
@@ -111,6 +114,8 @@ type UpstreamGenericLDAPIDP interface {
 type UpstreamGenericLDAPSpec interface {
 	Host() string
 	TLSSpec() *idpv1alpha1.TLSSpec
+	ProxySpec() *idpv1alpha1.ProxySpec
+	SecondFactorSpec() *idpv1alpha1.SecondFactorSpec
 	BindSecretName() string
 	UserSearch() UpstreamGenericLDAPUserSearch
 	GroupSearch() UpstreamGenericLDAPGroupSearch
@@ -297,6 +302,8 @@ func ValidateGenericLDAP(
 ) GradatedConditions {
 	conditions := GradatedConditions{}
 
+	config.TOTP = TOTP(upstream.Spec().SecondFactorSpec())
+
 	secretValidCondition, currentSecretVersion := ValidateSecret(secretInformer, upstream.Spec().BindSecretName(), upstream.Namespace(), config)
 	conditions.Append(secretValidCondition, true)
 
@@ -306,6 +313,18 @@ func ValidateGenericLDAP(
 	var ldapConnectionValidCondition, searchBaseFoundCondition *metav1.Condition
 	// No point in trying to connect to the server if the config was already determined to be invalid.
 	if secretValidCondition.Status == metav1.ConditionTrue && tlsValidCondition.Status == metav1.ConditionTrue {
+		proxy, err := Proxy(upstream.Spec().ProxySpec())
+		if err != nil {
+			conditions.Append(&metav1.Condition{
+				Type:    typeLDAPConnectionValid,
+				Status:  metav1.ConditionFalse,
+				Reason:  reasonLDAPConnectionError,
+				Message: fmt.Sprintf("spec.proxy.url is invalid: %s", err.Error()),
+			}, true)
+			return conditions
+		}
+		config.Proxy = proxy
+
 		ldapConnectionValidCondition, searchBaseFoundCondition = validateAndSetLDAPServerConnectivityAndSearchBase(ctx, validatedSettingsCache, upstream, config, currentSecretVersion)
 		conditions.Append(ldapConnectionValidCondition, false)
 		if searchBaseFoundCondition != nil { // currently, only used for AD, so may be nil
@@ -383,3 +402,37 @@ func EvaluateConditions(conditions GradatedConditions, config *upstreamldap.Prov
 	// Fully validated provider, so load it into the cache.
 	return upstreamldap.New(*config), false
 }
+
+// ConnectionPool returns the settings of the pool of HTTP connections to an upstream identity provider from its spec.
+// The host identifies the pool in metrics. A nil spec means the defaults.
+func ConnectionPool(host string, spec *idpv1alpha1.HTTPConnectionPoolSpec) phttp.ConnectionPool {
+	pool := phttp.ConnectionPool{Name: host}
+	if spec != nil {
+		pool.MaxConnsPerHost = int(spec.MaxConnectionsPerHost)
+		pool.MaxIdleConnsPerHost = int(spec.MaxIdleConnectionsPerHost)
+		pool.IdleConnTimeout = time.Duration(spec.IdleConnectionTimeoutSeconds) * time.Second
+	}
+	return pool
+}
+
+// Proxy returns the outbound proxy to an upstream identity provider from its spec. A nil spec means the proxy
+// environment variables, in which case nil is returned.
+func Proxy(spec *idpv1alpha1.ProxySpec) (*phttp.Proxy, error) {
+	if spec == nil {
+		return nil, nil
+	}
+	return phttp.NewProxy(spec.URL, spec.NoProxy)
+}
+
+// TOTP returns the configuration of the TOTP second factor of an upstream identity provider from its spec,
+// or nil when the identity provider does not require it.
+func TOTP(spec *idpv1alpha1.SecondFactorSpec) *totp.Config {
+	if spec == nil || spec.TOTP == nil {
+		return nil
+	}
+	issuer := spec.TOTP.Issuer
+	if issuer == "" {
+		issuer = totp.DefaultIssuer
+	}
+	return &totp.Config{Issuer: issuer}
+}
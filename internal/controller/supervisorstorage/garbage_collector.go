@@ -25,9 +25,13 @@ import (
 	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
 	"go.pinniped.dev/internal/fositestorage/accesstoken"
 	"go.pinniped.dev/internal/fositestorage/authorizationcode"
+	"go.pinniped.dev/internal/fositestorage/clientassertion"
+	"go.pinniped.dev/internal/fositestorage/consent"
 	"go.pinniped.dev/internal/fositestorage/openidconnect"
 	"go.pinniped.dev/internal/fositestorage/pkce"
+	"go.pinniped.dev/internal/fositestorage/pushedauthorizerequest"
 	"go.pinniped.dev/internal/fositestorage/refreshtoken"
+	"go.pinniped.dev/internal/fositestorage/singlesignon"
 	"go.pinniped.dev/internal/plog"
 	"go.pinniped.dev/internal/psession"
 )
@@ -39,6 +43,7 @@ type garbageCollectorController struct {
 	secretInformer        corev1informers.SecretInformer
 	kubeClient            kubernetes.Interface
 	clock                 clock.Clock
+	isLeader              func() bool
 	timeOfMostRecentSweep time.Time
 }
 
@@ -53,6 +58,7 @@ func GarbageCollectorController(
 	kubeClient kubernetes.Interface,
 	secretInformer corev1informers.SecretInformer,
 	withInformer pinnipedcontroller.WithInformerOptionFunc,
+	isLeader func() bool,
 ) controllerlib.Controller {
 	isSecretWithGCAnnotation := func(obj metav1.Object) bool {
 		secret, ok := obj.(*corev1.Secret)
@@ -70,6 +76,7 @@ func GarbageCollectorController(
 				secretInformer: secretInformer,
 				kubeClient:     kubeClient,
 				clock:          clock,
+				isLeader:       isLeader,
 			},
 		},
 		withInformer(
@@ -91,6 +98,13 @@ func (c *garbageCollectorController) Sync(ctx controllerlib.Context) error {
 	// make sure we have a consistent, static meaning for the current time during the sync loop
 	frozenClock := clocktesting.NewFakeClock(c.clock.Now())
 
+	// Only the leader collects garbage, so that the replicas do not all revoke the same upstream tokens and
+	// race to delete the same Secrets. The other replicas check back later in case they become the leader.
+	if !c.isLeader() {
+		ctx.Queue.AddAfter(ctx.Key, minimumRepeatInterval)
+		return nil
+	}
+
 	// The Sync method is triggered upon any change to any Secret, which would make this
 	// controller too chatty, so it rate limits itself to a more reasonable interval.
 	// Note that even during a period when no secrets are changing, it will still run
@@ -231,6 +245,43 @@ func (c *garbageCollectorController) maybeRevokeUpstreamOIDCToken(ctx context.Co
 		// be revoked by one of the other cases above.
 		return nil
 
+	case pushedauthorizerequest.TypeLabelValue:
+		// Pushed authorization requests do not hold any upstream tokens, since they are stored before the user
+		// has authenticated with the upstream IDP.
+		return nil
+
+	case consent.PendingRequestTypeLabelValue:
+		// A pending consent request which expired was never approved, so no downstream authcode was issued
+		// for its session. It therefore holds the only copy of the upstream token.
+		pendingRequest, err := consent.ReadPendingRequestFromSecret(secret)
+		if err != nil {
+			return err
+		}
+		return c.tryRevokeUpstreamOIDCToken(ctx, pendingRequest.Session.Custom, secret)
+
+	case consent.GrantTypeLabelValue:
+		// Consent grants do not hold any upstream tokens.
+		return nil
+
+	case clientassertion.TypeLabelValue:
+		// The IDs of used client assertions do not hold any upstream tokens.
+		return nil
+
+	case singlesignon.TypeLabelValue:
+		// Single sign-on sessions hold the latest upstream refresh token which is shared by the downstream sessions
+		// that they started. They are stored until those downstream sessions cannot be refreshed anymore, so always
+		// revoke their upstream tokens.
+		ssoSession, err := singlesignon.ReadFromSecret(secret)
+		if errors.Is(err, singlesignon.ErrInvalidSingleSignOnSessionVersion) {
+			// Older versions shared copies of their upstream tokens with the downstream sessions, which revoke
+			// those copies along with their own storage instead.
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return c.tryRevokeUpstreamOIDCToken(ctx, ssoSession.Custom, secret)
+
 	default:
 		// There are no other storage types, so this should never happen in practice.
 		return errors.New("garbage collector saw invalid label on Secret when trying to determine if upstream revocation was needed")
@@ -243,6 +294,12 @@ func (c *garbageCollectorController) tryRevokeUpstreamOIDCToken(ctx context.Cont
 		return nil
 	}
 
+	// When the session shares the upstream refresh token of a single sign-on session, the token is revoked along
+	// with the single sign-on session instead, since the other downstream sessions might still use it.
+	if customSessionData.SingleSignOnSessionID != "" {
+		return nil
+	}
+
 	// Try to find the provider that was originally used to create the stored session.
 	var foundOIDCIdentityProviderI upstreamprovider.UpstreamOIDCIdentityProviderI
 	for _, p := range c.idpCache.GetOIDCIdentityProviders() {
@@ -18,6 +18,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	k8sinformers "k8s.io/client-go/informers"
 	kubernetesfake "k8s.io/client-go/kubernetes/fake"
 	kubetesting "k8s.io/client-go/testing"
@@ -31,6 +32,7 @@ import (
 	"go.pinniped.dev/internal/fositestorage/accesstoken"
 	"go.pinniped.dev/internal/fositestorage/authorizationcode"
 	"go.pinniped.dev/internal/fositestorage/refreshtoken"
+	"go.pinniped.dev/internal/fositestorage/singlesignon"
 	"go.pinniped.dev/internal/psession"
 	"go.pinniped.dev/internal/testutil"
 	"go.pinniped.dev/internal/testutil/oidctestutil"
@@ -55,6 +57,7 @@ func TestGarbageCollectorControllerInformerFilters(t *testing.T) {
 				nil,
 				secretsInformer,
 				observableWithInformerOption.WithInformer, // make it possible to observe the behavior of the Filters
+				func() bool { return true },
 			)
 			secretsInformerFilter = observableWithInformerOption.GetFilterForInformer(secretsInformer)
 		})
@@ -122,7 +125,7 @@ func TestGarbageCollectorControllerSync(t *testing.T) {
 	spec.Run(t, "Sync", func(t *testing.T, when spec.G, it spec.S) {
 		const (
 			installedInNamespace         = "some-namespace"
-			currentSessionStorageVersion = "8" // update this when you update the storage version in the production code
+			currentSessionStorageVersion = "12" // update this when you update the storage version in the production code
 		)
 
 		var (
@@ -136,6 +139,7 @@ func TestGarbageCollectorControllerSync(t *testing.T) {
 			syncContext             *controllerlib.Context
 			fakeClock               *clocktesting.FakeClock
 			frozenNow               time.Time
+			isLeader                bool
 		)
 
 		// Defer starting the informers until the last possible moment so that the
@@ -148,6 +152,7 @@ func TestGarbageCollectorControllerSync(t *testing.T) {
 				kubeClient,
 				kubeInformers.Core().V1().Secrets(),
 				controllerlib.WithInformer,
+				func() bool { return isLeader },
 			)
 
 			// Set this at the last second to support calling subject.Name().
@@ -176,6 +181,7 @@ func TestGarbageCollectorControllerSync(t *testing.T) {
 			kubeInformers = k8sinformers.NewSharedInformerFactory(kubeInformerClient, 0)
 			frozenNow = time.Now().UTC()
 			fakeClock = clocktesting.NewFakeClock(frozenNow)
+			isLeader = true
 
 			unrelatedSecret := &corev1.Secret{
 				ObjectMeta: metav1.ObjectMeta{
@@ -245,6 +251,17 @@ func TestGarbageCollectorControllerSync(t *testing.T) {
 				r.NoError(kubeClient.Tracker().Add(unexpiredSecret))
 			})
 
+			it("should not delete any of them when it is not the leader, and should check again later", func() {
+				isLeader = false
+				startInformersAndController(nil)
+				r.NoError(controllerlib.TestSync(t, subject, *syncContext))
+
+				r.Empty(kubeClient.Actions())
+				r.True(syncContext.Queue.(*testQueue).called)
+				r.Equal(syncContext.Key, syncContext.Queue.(*testQueue).key)
+				r.Equal(30*time.Second, syncContext.Queue.(*testQueue).duration)
+			})
+
 			it("should delete any that are past their expiration", func() {
 				startInformersAndController(nil)
 				r.NoError(controllerlib.TestSync(t, subject, *syncContext))
@@ -1308,6 +1325,153 @@ func TestGarbageCollectorControllerSync(t *testing.T) {
 			})
 		})
 
+		when("there are valid, expired refresh secrets whose upstream refresh token is shared by a single sign-on session", func() {
+			it.Before(func() {
+				oidcRefreshSession := &refreshtoken.Session{
+					Version: currentSessionStorageVersion,
+					Request: &fosite.Request{
+						ID:     "request-id-1",
+						Client: &clientregistry.Client{},
+						Session: &psession.PinnipedSession{
+							Custom: &psession.CustomSessionData{
+								Username:     "should be ignored by garbage collector",
+								ProviderUID:  "upstream-oidc-provider-uid",
+								ProviderName: "upstream-oidc-provider-name",
+								ProviderType: psession.ProviderTypeOIDC,
+								OIDC: &psession.OIDCSessionData{
+									UpstreamRefreshToken: "fake-upstream-refresh-token",
+								},
+								SingleSignOnSessionID: "some-single-sign-on-session-id",
+							},
+						},
+					},
+				}
+				oidcRefreshSessionJSON, err := json.Marshal(oidcRefreshSession)
+				r.NoError(err)
+				oidcRefreshSessionSecret := &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            "oidcRefreshSession",
+						Namespace:       installedInNamespace,
+						UID:             "uid-123",
+						ResourceVersion: "rv-123",
+						Annotations: map[string]string{
+							"storage.pinniped.dev/garbage-collect-after": frozenNow.Add(-time.Second).Format(time.RFC3339),
+						},
+						Labels: map[string]string{
+							"storage.pinniped.dev/type": refreshtoken.TypeLabelValue,
+						},
+					},
+					Data: map[string][]byte{
+						"pinniped-storage-data":    oidcRefreshSessionJSON,
+						"pinniped-storage-version": []byte("1"),
+					},
+					Type: "storage.pinniped.dev/" + refreshtoken.TypeLabelValue,
+				}
+				_, err = refreshtoken.ReadFromSecret(oidcRefreshSessionSecret)
+				r.NoError(err, "the test author accidentally formed an invalid refresh token secret")
+				r.NoError(kubeInformerClient.Tracker().Add(oidcRefreshSessionSecret))
+				r.NoError(kubeClient.Tracker().Add(oidcRefreshSessionSecret))
+			})
+
+			it("should delete the secrets without revoking the upstream tokens which other sessions might still use", func() {
+				happyOIDCUpstream := oidctestutil.NewTestUpstreamOIDCIdentityProviderBuilder().
+					WithName("upstream-oidc-provider-name").
+					WithResourceUID("upstream-oidc-provider-uid").
+					WithRevokeTokenError(nil)
+				idpListerBuilder := testidplister.NewUpstreamIDPListerBuilder().WithOIDC(happyOIDCUpstream.Build())
+
+				startInformersAndController(idpListerBuilder.BuildDynamicUpstreamIDPProvider())
+				r.NoError(controllerlib.TestSync(t, subject, *syncContext))
+
+				// The upstream refresh token is revoked along with the single sign-on session instead.
+				idpListerBuilder.RequireExactlyZeroCallsToRevokeToken(t)
+
+				// The secret is deleted.
+				r.ElementsMatch(
+					[]kubetesting.Action{
+						kubetesting.NewDeleteActionWithOptions(secretsGVR, installedInNamespace, "oidcRefreshSession", testutil.NewPreconditions("uid-123", "rv-123")),
+					},
+					kubeClient.Actions(),
+				)
+			})
+		})
+
+		when("there are valid, expired single sign-on session secrets which contain upstream refresh tokens", func() {
+			it.Before(func() {
+				addSingleSignOnSessionSecret := func(name, uid, version string) {
+					ssoSessionJSON, err := json.Marshal(map[string]any{
+						"session": &singlesignon.Session{
+							Custom: &psession.CustomSessionData{
+								ProviderUID:  "upstream-oidc-provider-uid",
+								ProviderName: "upstream-oidc-provider-name",
+								ProviderType: psession.ProviderTypeOIDC,
+								OIDC: &psession.OIDCSessionData{
+									UpstreamRefreshToken: "fake-upstream-refresh-token-of-" + name,
+								},
+							},
+						},
+						"version": version,
+					})
+					r.NoError(err)
+					ssoSessionSecret := &corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:            name,
+							Namespace:       installedInNamespace,
+							UID:             types.UID(uid),
+							ResourceVersion: "rv-123",
+							Annotations: map[string]string{
+								"storage.pinniped.dev/garbage-collect-after": frozenNow.Add(-time.Second).Format(time.RFC3339),
+							},
+							Labels: map[string]string{
+								"storage.pinniped.dev/type": singlesignon.TypeLabelValue,
+							},
+						},
+						Data: map[string][]byte{
+							"pinniped-storage-data":    ssoSessionJSON,
+							"pinniped-storage-version": []byte("1"),
+						},
+						Type: "storage.pinniped.dev/" + singlesignon.TypeLabelValue,
+					}
+					r.NoError(kubeInformerClient.Tracker().Add(ssoSessionSecret))
+					r.NoError(kubeClient.Tracker().Add(ssoSessionSecret))
+				}
+
+				addSingleSignOnSessionSecret("ssoSession", "uid-123", "2") // update this when you update the storage version in the production code
+				// Older versions of single sign-on sessions shared copies of their upstream refresh tokens.
+				addSingleSignOnSessionSecret("oldSSOSession", "uid-456", "1")
+			})
+
+			it("should revoke upstream tokens only from the current version of the secrets and delete them all", func() {
+				happyOIDCUpstream := oidctestutil.NewTestUpstreamOIDCIdentityProviderBuilder().
+					WithName("upstream-oidc-provider-name").
+					WithResourceUID("upstream-oidc-provider-uid").
+					WithRevokeTokenError(nil)
+				idpListerBuilder := testidplister.NewUpstreamIDPListerBuilder().WithOIDC(happyOIDCUpstream.Build())
+
+				startInformersAndController(idpListerBuilder.BuildDynamicUpstreamIDPProvider())
+				r.NoError(controllerlib.TestSync(t, subject, *syncContext))
+
+				// The upstream refresh token is revoked.
+				idpListerBuilder.RequireExactlyOneCallToRevokeToken(t,
+					"upstream-oidc-provider-name",
+					&oidctestutil.RevokeTokenArgs{
+						Ctx:       syncContext.Context,
+						Token:     "fake-upstream-refresh-token-of-ssoSession",
+						TokenType: upstreamprovider.RefreshTokenType,
+					},
+				)
+
+				// The secrets are deleted.
+				r.ElementsMatch(
+					[]kubetesting.Action{
+						kubetesting.NewDeleteActionWithOptions(secretsGVR, installedInNamespace, "ssoSession", testutil.NewPreconditions("uid-123", "rv-123")),
+						kubetesting.NewDeleteActionWithOptions(secretsGVR, installedInNamespace, "oldSSOSession", testutil.NewPreconditions("uid-456", "rv-123")),
+					},
+					kubeClient.Actions(),
+				)
+			})
+		})
+
 		when("very little time has passed since the previous sync call", func() {
 			it.Before(func() {
 				// Add a secret that will expire in 20 seconds.
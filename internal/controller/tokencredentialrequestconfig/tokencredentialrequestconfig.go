@@ -0,0 +1,249 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package tokencredentialrequestconfig implements a controller which keeps the configuration of the
+// TokenCredentialRequest API up to date with the CredentialIssuer.
+package tokencredentialrequestconfig
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	certificatesv1client "k8s.io/client-go/kubernetes/typed/certificates/v1"
+
+	conciergeconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/config/v1alpha1"
+	conciergeconfiginformers "go.pinniped.dev/generated/latest/client/concierge/informers/externalversions/config/v1alpha1"
+	"go.pinniped.dev/internal/certauthority/certsigner"
+	"go.pinniped.dev/internal/clientcertissuer"
+	"go.pinniped.dev/internal/constable"
+	pinnipedcontroller "go.pinniped.dev/internal/controller"
+	"go.pinniped.dev/internal/controllerlib"
+	"go.pinniped.dev/internal/plog"
+)
+
+const controllerName = "tokencredentialrequestconfig-controller"
+
+// DynamicCredentialType holds the type of credential which the TokenCredentialRequest API should return.
+// It is safe for concurrent use.
+type DynamicCredentialType struct {
+	mu             sync.RWMutex
+	credentialType conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialType
+}
+
+// NewDynamicCredentialType returns a DynamicCredentialType which holds the default credential type.
+func NewDynamicCredentialType() *DynamicCredentialType {
+	return &DynamicCredentialType{
+		credentialType: conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialTypeClientCertificate,
+	}
+}
+
+// CredentialType returns the current credential type.
+func (d *DynamicCredentialType) CredentialType() conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialType {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.credentialType
+}
+
+func (d *DynamicCredentialType) set(credentialType conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialType) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.credentialType = credentialType
+}
+
+// ErrNoSigner is returned by a DynamicClientCertIssuer while the kube-cert-agent signs client certificates.
+const ErrNoSigner = constable.Error("no certificate signer is configured")
+
+// DynamicClientCertIssuer issues client certificates using the signer which is configured by
+// spec.tokenCredentialRequestAPI.signer of the CredentialIssuer. While the kube-cert-agent is configured,
+// it fails with ErrNoSigner, so that the ClientCertIssuer which uses the keypair of the kube-cert-agent is used.
+// It is safe for concurrent use.
+type DynamicClientCertIssuer struct {
+	mu     sync.RWMutex
+	spec   *conciergeconfigv1alpha1.TokenCredentialRequestAPISignerSpec
+	issuer clientcertissuer.ClientCertIssuer
+}
+
+var _ clientcertissuer.ClientCertIssuer = (*DynamicClientCertIssuer)(nil)
+
+// NewDynamicClientCertIssuer returns a DynamicClientCertIssuer which has no signer.
+func NewDynamicClientCertIssuer() *DynamicClientCertIssuer {
+	return &DynamicClientCertIssuer{}
+}
+
+// Name implements clientcertissuer.ClientCertIssuer.
+func (d *DynamicClientCertIssuer) Name() string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.issuer == nil {
+		return "unconfigured-signer"
+	}
+	return d.issuer.Name()
+}
+
+// IssueClientCertPEM implements clientcertissuer.ClientCertIssuer.
+func (d *DynamicClientCertIssuer) IssueClientCertPEM(username string, groups []string, ttl time.Duration) ([]byte, []byte, error) {
+	d.mu.RLock()
+	issuer := d.issuer
+	d.mu.RUnlock()
+	if issuer == nil {
+		return nil, nil, ErrNoSigner
+	}
+	return issuer.IssueClientCertPEM(username, groups, ttl)
+}
+
+func (d *DynamicClientCertIssuer) currentSpec() *conciergeconfigv1alpha1.TokenCredentialRequestAPISignerSpec {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.spec
+}
+
+func (d *DynamicClientCertIssuer) set(spec *conciergeconfigv1alpha1.TokenCredentialRequestAPISignerSpec, issuer clientcertissuer.ClientCertIssuer) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.spec = spec
+	d.issuer = issuer
+}
+
+// SignerType returns the type of signer which is configured on the CredentialIssuer.
+func SignerType(credIssuer *conciergeconfigv1alpha1.CredentialIssuer) conciergeconfigv1alpha1.TokenCredentialRequestAPISignerType {
+	if credIssuer == nil || credIssuer.Spec.TokenCredentialRequestAPI == nil ||
+		credIssuer.Spec.TokenCredentialRequestAPI.Signer == nil || credIssuer.Spec.TokenCredentialRequestAPI.Signer.Type == "" {
+		return conciergeconfigv1alpha1.TokenCredentialRequestAPISignerTypeKubeCertAgent
+	}
+	return credIssuer.Spec.TokenCredentialRequestAPI.Signer.Type
+}
+
+// New instantiates a new controllerlib.Controller which will keep the provided DynamicCredentialType in sync with
+// spec.tokenCredentialRequestAPI.credentialType of the CredentialIssuer, and the provided DynamicClientCertIssuer
+// in sync with spec.tokenCredentialRequestAPI.signer. The client is used to create the CertificateSigningRequests
+// of the "CertificateSigningRequest" signer, which are labeled with the labels.
+func New(
+	credentialIssuerResourceName string,
+	credentialType *DynamicCredentialType,
+	signer *DynamicClientCertIssuer,
+	csrClient certificatesv1client.CertificateSigningRequestInterface,
+	labels map[string]string,
+	credentialIssuerInformer conciergeconfiginformers.CredentialIssuerInformer,
+	withInformer pinnipedcontroller.WithInformerOptionFunc,
+	log plog.Logger,
+) controllerlib.Controller {
+	return controllerlib.New(
+		controllerlib.Config{
+			Name: controllerName,
+			Syncer: &controller{
+				credentialIssuerResourceName: credentialIssuerResourceName,
+				credentialType:               credentialType,
+				signer:                       signer,
+				csrClient:                    csrClient,
+				labels:                       labels,
+				credentialIssuerInformer:     credentialIssuerInformer,
+				log:                          log.WithName(controllerName),
+			},
+		},
+		withInformer(
+			credentialIssuerInformer,
+			pinnipedcontroller.SimpleFilterWithSingletonQueue(func(obj metav1.Object) bool {
+				return obj.GetName() == credentialIssuerResourceName
+			}),
+			controllerlib.InformerOption{},
+		),
+		controllerlib.WithInitialEvent(controllerlib.Key{}),
+	)
+}
+
+type controller struct {
+	credentialIssuerResourceName string
+	credentialType               *DynamicCredentialType
+	signer                       *DynamicClientCertIssuer
+	csrClient                    certificatesv1client.CertificateSigningRequestInterface
+	labels                       map[string]string
+	credentialIssuerInformer     conciergeconfiginformers.CredentialIssuerInformer
+	log                          plog.Logger
+}
+
+// Sync implements controllerlib.Syncer.
+func (c *controller) Sync(_ controllerlib.Context) error {
+	credentialType := conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialTypeClientCertificate
+	var signerSpec *conciergeconfigv1alpha1.TokenCredentialRequestAPISignerSpec
+
+	credIssuer, err := c.credentialIssuerInformer.Lister().Get(c.credentialIssuerResourceName)
+	switch {
+	case apierrors.IsNotFound(err):
+		// Without a CredentialIssuer, fall back to the default credential type and signer.
+	case err != nil:
+		return fmt.Errorf("could not get CredentialIssuer: %w", err)
+	case credIssuer.Spec.TokenCredentialRequestAPI != nil:
+		if credIssuer.Spec.TokenCredentialRequestAPI.CredentialType != "" {
+			credentialType = credIssuer.Spec.TokenCredentialRequestAPI.CredentialType
+		}
+		signerSpec = credIssuer.Spec.TokenCredentialRequestAPI.Signer
+	}
+
+	if credentialType != c.credentialType.CredentialType() {
+		c.log.Info("updated TokenCredentialRequest API credential type",
+			"credentialIssuer", c.credentialIssuerResourceName,
+			"credentialType", credentialType)
+	}
+	c.credentialType.set(credentialType)
+
+	return c.updateSigner(signerSpec)
+}
+
+// updateSigner replaces the signer of the DynamicClientCertIssuer when its configuration has changed.
+func (c *controller) updateSigner(spec *conciergeconfigv1alpha1.TokenCredentialRequestAPISignerSpec) error {
+	if spec != nil && spec.Type == conciergeconfigv1alpha1.TokenCredentialRequestAPISignerTypeKubeCertAgent {
+		spec = nil // this is the same as the default
+	}
+	if apiequality.Semantic.DeepEqual(spec, c.signer.currentSpec()) {
+		return nil
+	}
+
+	issuer, err := c.newClientCertIssuer(spec)
+	if err != nil {
+		// Stop using the previous signer, since it is no longer configured.
+		c.signer.set(nil, nil)
+		return fmt.Errorf("could not configure TokenCredentialRequest API signer: %w", err)
+	}
+
+	signerType := conciergeconfigv1alpha1.TokenCredentialRequestAPISignerTypeKubeCertAgent
+	if spec != nil {
+		signerType = spec.Type
+		spec = spec.DeepCopy()
+	}
+	c.log.Info("updated TokenCredentialRequest API signer",
+		"credentialIssuer", c.credentialIssuerResourceName,
+		"signerType", signerType)
+	c.signer.set(spec, issuer)
+	return nil
+}
+
+func (c *controller) newClientCertIssuer(spec *conciergeconfigv1alpha1.TokenCredentialRequestAPISignerSpec) (clientcertissuer.ClientCertIssuer, error) {
+	if spec == nil {
+		return nil, nil
+	}
+
+	switch spec.Type {
+	case conciergeconfigv1alpha1.TokenCredentialRequestAPISignerTypeCertificateSigningRequest:
+		signerName := ""
+		if spec.CertificateSigningRequest != nil {
+			signerName = spec.CertificateSigningRequest.SignerName
+		}
+		return certsigner.NewClientCertIssuer(certsigner.NewCSRSigner(c.csrClient, signerName, c.labels)), nil
+	case conciergeconfigv1alpha1.TokenCredentialRequestAPISignerTypeExternal:
+		if spec.External == nil {
+			return nil, errors.New("external must be set when type is External")
+		}
+		signer, err := certsigner.NewExternalSigner(spec.External.Endpoint, spec.External.CertificateAuthorityData)
+		if err != nil {
+			return nil, err
+		}
+		return certsigner.NewClientCertIssuer(signer), nil
+	default:
+		return nil, fmt.Errorf("unknown signer type %q", spec.Type)
+	}
+}
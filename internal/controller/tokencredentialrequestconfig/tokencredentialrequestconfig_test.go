@@ -0,0 +1,209 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package tokencredentialrequestconfig
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	conciergeconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/config/v1alpha1"
+	conciergefake "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned/fake"
+	conciergeinformers "go.pinniped.dev/generated/latest/client/concierge/informers/externalversions"
+	"go.pinniped.dev/internal/controllerlib"
+	"go.pinniped.dev/internal/plog"
+)
+
+func TestController(t *testing.T) {
+	t.Parallel()
+
+	const credentialIssuerName = "some-credential-issuer"
+
+	credentialIssuer := func(name string, spec *conciergeconfigv1alpha1.TokenCredentialRequestAPISpec) *conciergeconfigv1alpha1.CredentialIssuer {
+		return &conciergeconfigv1alpha1.CredentialIssuer{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       conciergeconfigv1alpha1.CredentialIssuerSpec{TokenCredentialRequestAPI: spec},
+		}
+	}
+
+	tests := []struct {
+		name               string
+		objects            []runtime.Object
+		initial            conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialType
+		wantCredentialType conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialType
+		wantSignerName     string
+		wantErr            string
+	}{
+		{
+			name:               "no CredentialIssuer",
+			initial:            conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialTypeServiceAccountToken,
+			wantCredentialType: conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialTypeClientCertificate,
+		},
+		{
+			name: "CredentialIssuer without TokenCredentialRequest API configuration",
+			objects: []runtime.Object{
+				credentialIssuer(credentialIssuerName, nil),
+			},
+			initial:            conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialTypeServiceAccountToken,
+			wantCredentialType: conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialTypeClientCertificate,
+		},
+		{
+			name: "CredentialIssuer configured for ServiceAccount tokens",
+			objects: []runtime.Object{
+				credentialIssuer(credentialIssuerName, &conciergeconfigv1alpha1.TokenCredentialRequestAPISpec{
+					CredentialType: conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialTypeServiceAccountToken,
+				}),
+			},
+			initial:            conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialTypeClientCertificate,
+			wantCredentialType: conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialTypeServiceAccountToken,
+		},
+		{
+			name: "other CredentialIssuers are ignored",
+			objects: []runtime.Object{
+				credentialIssuer("some-other-credential-issuer", &conciergeconfigv1alpha1.TokenCredentialRequestAPISpec{
+					CredentialType: conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialTypeServiceAccountToken,
+				}),
+			},
+			initial:            conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialTypeClientCertificate,
+			wantCredentialType: conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialTypeClientCertificate,
+		},
+		{
+			name: "CredentialIssuer configured for the kube-cert-agent signer",
+			objects: []runtime.Object{
+				credentialIssuer(credentialIssuerName, &conciergeconfigv1alpha1.TokenCredentialRequestAPISpec{
+					Signer: &conciergeconfigv1alpha1.TokenCredentialRequestAPISignerSpec{
+						Type: conciergeconfigv1alpha1.TokenCredentialRequestAPISignerTypeKubeCertAgent,
+					},
+				}),
+			},
+			initial:            conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialTypeClientCertificate,
+			wantCredentialType: conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialTypeClientCertificate,
+		},
+		{
+			name: "CredentialIssuer configured for the CertificateSigningRequest signer",
+			objects: []runtime.Object{
+				credentialIssuer(credentialIssuerName, &conciergeconfigv1alpha1.TokenCredentialRequestAPISpec{
+					Signer: &conciergeconfigv1alpha1.TokenCredentialRequestAPISignerSpec{
+						Type: conciergeconfigv1alpha1.TokenCredentialRequestAPISignerTypeCertificateSigningRequest,
+						CertificateSigningRequest: &conciergeconfigv1alpha1.CertificateSigningRequestSignerSpec{
+							SignerName: "example.com/some-signer",
+						},
+					},
+				}),
+			},
+			initial:            conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialTypeClientCertificate,
+			wantCredentialType: conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialTypeClientCertificate,
+			wantSignerName:     "certificatesigningrequest:example.com/some-signer",
+		},
+		{
+			name: "CredentialIssuer configured for the external signer",
+			objects: []runtime.Object{
+				credentialIssuer(credentialIssuerName, &conciergeconfigv1alpha1.TokenCredentialRequestAPISpec{
+					Signer: &conciergeconfigv1alpha1.TokenCredentialRequestAPISignerSpec{
+						Type:     conciergeconfigv1alpha1.TokenCredentialRequestAPISignerTypeExternal,
+						External: &conciergeconfigv1alpha1.ExternalSignerSpec{Endpoint: "https://signer.example.com/sign"},
+					},
+				}),
+			},
+			initial:            conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialTypeClientCertificate,
+			wantCredentialType: conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialTypeClientCertificate,
+			wantSignerName:     "external:https://signer.example.com/sign",
+		},
+		{
+			name: "CredentialIssuer configured for the external signer with an invalid CA bundle",
+			objects: []runtime.Object{
+				credentialIssuer(credentialIssuerName, &conciergeconfigv1alpha1.TokenCredentialRequestAPISpec{
+					Signer: &conciergeconfigv1alpha1.TokenCredentialRequestAPISignerSpec{
+						Type: conciergeconfigv1alpha1.TokenCredentialRequestAPISignerTypeExternal,
+						External: &conciergeconfigv1alpha1.ExternalSignerSpec{
+							Endpoint:                 "https://signer.example.com/sign",
+							CertificateAuthorityData: "not base64!",
+						},
+					},
+				}),
+			},
+			initial:            conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialTypeClientCertificate,
+			wantCredentialType: conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialTypeClientCertificate,
+			wantErr:            "could not configure TokenCredentialRequest API signer: invalid certificateAuthorityData: illegal base64 data at input byte 3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pinnipedAPIClient := conciergefake.NewSimpleClientset(tt.objects...)
+			informers := conciergeinformers.NewSharedInformerFactory(pinnipedAPIClient, 0)
+			credentialType := NewDynamicCredentialType()
+			credentialType.set(tt.initial)
+			signer := NewDynamicClientCertIssuer()
+
+			controller := New(
+				credentialIssuerName,
+				credentialType,
+				signer,
+				kubefake.NewSimpleClientset().CertificatesV1().CertificateSigningRequests(),
+				map[string]string{"app": "some-app"},
+				informers.Config().V1alpha1().CredentialIssuers(),
+				controllerlib.WithInformer,
+				plog.New(),
+			)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			informers.Start(ctx.Done())
+			controllerlib.TestRunSynchronously(t, controller)
+
+			syncCtx := controllerlib.Context{Context: ctx}
+			err := controllerlib.TestSync(t, controller, syncCtx)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tt.wantCredentialType, credentialType.CredentialType())
+
+			wantSignerName := tt.wantSignerName
+			if wantSignerName == "" {
+				wantSignerName = "unconfigured-signer"
+			}
+			require.Equal(t, wantSignerName, signer.Name())
+		})
+	}
+}
+
+func TestDynamicClientCertIssuer(t *testing.T) {
+	signer := NewDynamicClientCertIssuer()
+	require.Equal(t, "unconfigured-signer", signer.Name())
+
+	_, _, err := signer.IssueClientCertPEM("some-user", nil, time.Minute)
+	require.ErrorIs(t, err, ErrNoSigner)
+}
+
+func TestSignerType(t *testing.T) {
+	require.Equal(t, conciergeconfigv1alpha1.TokenCredentialRequestAPISignerTypeKubeCertAgent, SignerType(nil))
+	require.Equal(t, conciergeconfigv1alpha1.TokenCredentialRequestAPISignerTypeKubeCertAgent, SignerType(&conciergeconfigv1alpha1.CredentialIssuer{}))
+	require.Equal(t, conciergeconfigv1alpha1.TokenCredentialRequestAPISignerTypeExternal, SignerType(&conciergeconfigv1alpha1.CredentialIssuer{
+		Spec: conciergeconfigv1alpha1.CredentialIssuerSpec{
+			TokenCredentialRequestAPI: &conciergeconfigv1alpha1.TokenCredentialRequestAPISpec{
+				Signer: &conciergeconfigv1alpha1.TokenCredentialRequestAPISignerSpec{
+					Type: conciergeconfigv1alpha1.TokenCredentialRequestAPISignerTypeExternal,
+				},
+			},
+		},
+	}))
+}
+
+func TestNewDynamicCredentialType(t *testing.T) {
+	require.Equal(t,
+		conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialTypeClientCertificate,
+		NewDynamicCredentialType().CredentialType(),
+	)
+}
@@ -9,8 +9,12 @@ import (
 	"fmt"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/server/healthz"
 	k8sinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	certificatesv1client "k8s.io/client-go/kubernetes/typed/certificates/v1"
 	"k8s.io/utils/clock"
 
 	conciergeclientset "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned"
@@ -22,19 +26,23 @@ import (
 	"go.pinniped.dev/internal/controller/authenticator/authncache"
 	"go.pinniped.dev/internal/controller/authenticator/cachecleaner"
 	"go.pinniped.dev/internal/controller/authenticator/jwtcachefiller"
+	"go.pinniped.dev/internal/controller/authenticator/tokendenylist"
 	"go.pinniped.dev/internal/controller/authenticator/webhookcachefiller"
 	"go.pinniped.dev/internal/controller/impersonatorconfig"
 	"go.pinniped.dev/internal/controller/kubecertagent"
 	"go.pinniped.dev/internal/controller/serviceaccounttokencleanup"
+	"go.pinniped.dev/internal/controller/tokencredentialrequestconfig"
 	"go.pinniped.dev/internal/controllerinit"
 	"go.pinniped.dev/internal/controllerlib"
 	"go.pinniped.dev/internal/deploymentref"
 	"go.pinniped.dev/internal/downward"
 	"go.pinniped.dev/internal/dynamiccert"
 	"go.pinniped.dev/internal/groupsuffix"
+	"go.pinniped.dev/internal/healthcheck"
 	"go.pinniped.dev/internal/kubeclient"
 	"go.pinniped.dev/internal/leaderelection"
 	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/internal/resourcelabels"
 	"go.pinniped.dev/internal/tokenclient"
 )
 
@@ -61,6 +69,18 @@ type Config struct {
 	// the kubecertagent package's controllers should manage the agent pods.
 	KubeCertAgentConfig *concierge.KubeCertAgentSpec
 
+	// ControllersConfig comes from the Pinniped config API (see api.Config). It decides which of the
+	// optional controllers should run.
+	ControllersConfig *concierge.ControllersSpec
+
+	// LeaderElectionConfig comes from the Pinniped config API (see api.Config). It tunes the leader
+	// election of the controllers.
+	LeaderElectionConfig *concierge.LeaderElectionSpec
+
+	// InformersConfig comes from the Pinniped config API (see api.Config). It restricts the objects
+	// which are watched by the informers of the controllers.
+	InformersConfig *concierge.InformersSpec
+
 	// ImpersonationProxyServerPort decides which port the impersonation proxy should bind.
 	ImpersonationProxyServerPort int
 
@@ -97,38 +117,61 @@ type Config struct {
 	// AuthenticatorCache is a cache of authenticators shared amongst various authenticated-related controllers.
 	AuthenticatorCache *authncache.Cache
 
+	// TokenCredentialRequestCredentialType is kept up to date with the type of credential which the
+	// TokenCredentialRequest API should return, as configured on the CredentialIssuer.
+	TokenCredentialRequestCredentialType *tokencredentialrequestconfig.DynamicCredentialType
+
+	// TokenCredentialRequestSigner is kept up to date with the signer of client certificates which is configured
+	// on the CredentialIssuer.
+	TokenCredentialRequestSigner *tokencredentialrequestconfig.DynamicClientCertIssuer
+
+	// CertificateSigningRequests is used by the "CertificateSigningRequest" signer. It must not be subject to
+	// leader election, because every pod must be able to issue client certificates.
+	CertificateSigningRequests certificatesv1client.CertificateSigningRequestInterface
+
 	// Labels are labels that should be added to any resources created by the controllers.
 	Labels map[string]string
 }
 
 // PrepareControllers prepares the controllers and their informers and returns a function that will start them when called.
-func PrepareControllers(c *Config) (controllerinit.RunnerBuilder, error) { //nolint:funlen // Eh, fair, it is a really long function...but it is wiring the world...so...
+// It also returns the readiness checks which report whether the informers have synced and whether the leader has been
+// observed.
+func PrepareControllers(c *Config) (controllerinit.RunnerBuilder, []healthz.HealthChecker, error) { //nolint:funlen // Eh, fair, it is a really long function...but it is wiring the world...so...
 	loginConciergeGroupData, identityConciergeGroupData := groupsuffix.ConciergeAggregatedGroups(c.APIGroupSuffix)
 
 	dref, deployment, _, err := deploymentref.New(c.ServerInstallationInfo)
 	if err != nil {
-		return nil, fmt.Errorf("cannot create deployment ref: %w", err)
+		return nil, nil, fmt.Errorf("cannot create deployment ref: %w", err)
 	}
 
 	apiServiceRef, err := apiserviceref.New(loginConciergeGroupData.APIServiceName())
 	if err != nil {
-		return nil, fmt.Errorf("cannot create API service ref: %w", err)
+		return nil, nil, fmt.Errorf("cannot create API service ref: %w", err)
 	}
 
-	client, leaderElector, err := leaderelection.New(
+	secretLabels, err := resourcelabels.ParseSelector(c.InformersConfig.SecretLabelSelector)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid informers.secretLabelSelector: %w", err)
+	}
+
+	client, leaderElector, leaderObserved, err := leaderelection.New(
 		c.ServerInstallationInfo,
 		deployment,
+		leaderelection.LeaseTimings{
+			LeaseDuration: time.Duration(*c.LeaderElectionConfig.LeaseDurationSeconds) * time.Second,
+			RenewDeadline: time.Duration(*c.LeaderElectionConfig.RenewDeadlineSeconds) * time.Second,
+			RetryPeriod:   time.Duration(*c.LeaderElectionConfig.RetryPeriodSeconds) * time.Second,
+		},
 		dref,          // first try to use the deployment as an owner ref (for namespace scoped resources)
 		apiServiceRef, // fallback to our API service (for everything else we create)
 		kubeclient.WithMiddleware(groupsuffix.New(c.APIGroupSuffix)),
+		// label the Secrets that we create so that they are selected by our own Secret informer
+		kubeclient.WithMiddleware(resourcelabels.New(corev1.Resource("secrets"), secretLabels)),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("could not create clients for the controllers: %w", err)
+		return nil, nil, fmt.Errorf("could not create clients for the controllers: %w", err)
 	}
 
-	// Create informers. Don't forget to make sure they get started in the function returned below.
-	informers := createInformers(c.ServerInstallationInfo.Namespace, client.Kubernetes, client.PinnipedConcierge)
-
 	agentConfig := kubecertagent.AgentConfig{
 		Namespace:                 c.ServerInstallationInfo.Namespace,
 		ServiceAccountName:        c.NamesConfig.AgentServiceAccount,
@@ -138,8 +181,33 @@ func PrepareControllers(c *Config) (controllerinit.RunnerBuilder, error) { //nol
 		Labels:                    c.Labels,
 		CredentialIssuerName:      c.NamesConfig.CredentialIssuer,
 		DiscoveryURLOverride:      c.DiscoveryURLOverride,
+		ControlPlane: kubecertagent.ControlPlaneConfig{
+			Namespace:        c.KubeCertAgentConfig.ControlPlane.Namespace,
+			PodLabels:        c.KubeCertAgentConfig.ControlPlane.PodLabels,
+			CertPathTemplate: c.KubeCertAgentConfig.ControlPlane.CertPathTemplate,
+			KeyPathTemplate:  c.KubeCertAgentConfig.ControlPlane.KeyPathTemplate,
+			HostPathVolumes:  c.KubeCertAgentConfig.ControlPlane.HostPathVolumes,
+		},
+	}
+	if _, err := kubecertagent.ParsePathTemplate(agentConfig.ControlPlane.CertPathTemplate); err != nil {
+		return nil, nil, fmt.Errorf("invalid kubeCertAgent.controlPlane.certPathTemplate: %w", err)
+	}
+	if _, err := kubecertagent.ParsePathTemplate(agentConfig.ControlPlane.KeyPathTemplate); err != nil {
+		return nil, nil, fmt.Errorf("invalid kubeCertAgent.controlPlane.keyPathTemplate: %w", err)
 	}
 
+	// Create informers. Don't forget to make sure they get started in the function returned below.
+	informers := createInformers(
+		c.ServerInstallationInfo.Namespace,
+		agentConfig.ControlPlaneNamespace(),
+		secretLabels.AsSelector().String(),
+		client.Kubernetes,
+		client.PinnipedConcierge,
+	)
+
+	// The JWT authenticators consult this in-memory denylist, which is kept up to date from the ClusterTokenDenylists.
+	tokenDenylist := tokendenylist.NewDenylist(clock.RealClock{})
+
 	// Create controller manager.
 	controllerManager := controllerlib.
 		NewManager().
@@ -151,7 +219,7 @@ func PrepareControllers(c *Config) (controllerinit.RunnerBuilder, error) { //nol
 				c.NamesConfig.ServingCertificateSecret,
 				c.Labels,
 				client.Kubernetes,
-				informers.installationNamespaceK8s.Core().V1().Secrets(),
+				informers.installationNamespaceSecretsK8s.Core().V1().Secrets(),
 				controllerlib.WithInformer,
 				controllerlib.WithInitialEvent,
 				c.ServingCertDuration,
@@ -166,7 +234,7 @@ func PrepareControllers(c *Config) (controllerinit.RunnerBuilder, error) { //nol
 				c.NamesConfig.ServingCertificateSecret,
 				loginConciergeGroupData.APIServiceName(),
 				client.Aggregation,
-				informers.installationNamespaceK8s.Core().V1().Secrets(),
+				informers.installationNamespaceSecretsK8s.Core().V1().Secrets(),
 				controllerlib.WithInformer,
 			),
 			singletonWorker,
@@ -177,7 +245,7 @@ func PrepareControllers(c *Config) (controllerinit.RunnerBuilder, error) { //nol
 				c.NamesConfig.ServingCertificateSecret,
 				identityConciergeGroupData.APIServiceName(),
 				client.Aggregation,
-				informers.installationNamespaceK8s.Core().V1().Secrets(),
+				informers.installationNamespaceSecretsK8s.Core().V1().Secrets(),
 				controllerlib.WithInformer,
 			),
 			singletonWorker,
@@ -187,7 +255,7 @@ func PrepareControllers(c *Config) (controllerinit.RunnerBuilder, error) { //nol
 				c.ServerInstallationInfo.Namespace,
 				c.NamesConfig.ServingCertificateSecret,
 				c.DynamicServingCertProvider,
-				informers.installationNamespaceK8s.Core().V1().Secrets(),
+				informers.installationNamespaceSecretsK8s.Core().V1().Secrets(),
 				controllerlib.WithInformer,
 			),
 			singletonWorker,
@@ -197,7 +265,7 @@ func PrepareControllers(c *Config) (controllerinit.RunnerBuilder, error) { //nol
 				c.ServerInstallationInfo.Namespace,
 				c.NamesConfig.ServingCertificateSecret,
 				client.Kubernetes,
-				informers.installationNamespaceK8s.Core().V1().Secrets(),
+				informers.installationNamespaceSecretsK8s.Core().V1().Secrets(),
 				controllerlib.WithInformer,
 				c.ServingCertRenewBefore,
 				apicerts.TLSCertificateChainSecretKey,
@@ -205,32 +273,6 @@ func PrepareControllers(c *Config) (controllerinit.RunnerBuilder, error) { //nol
 			),
 			singletonWorker,
 		).
-		// The kube-cert-agent controller is responsible for finding the cluster's signing keys and keeping them
-		// up to date in memory, as well as reporting status on this cluster integration strategy.
-		WithController(
-			kubecertagent.NewAgentController(
-				agentConfig,
-				client,
-				informers.kubeSystemNamespaceK8s.Core().V1().Pods(),
-				informers.installationNamespaceK8s.Apps().V1().Deployments(),
-				informers.installationNamespaceK8s.Core().V1().Pods(),
-				informers.kubePublicNamespaceK8s.Core().V1().ConfigMaps(),
-				informers.pinniped.Config().V1alpha1().CredentialIssuers(),
-				c.DynamicSigningCertProvider,
-			),
-			singletonWorker,
-		).
-		// The kube-cert-agent legacy pod cleaner controller is responsible for cleaning up pods that were deployed by
-		// versions of Pinniped prior to v0.7.0. If we stop supporting upgrades from v0.7.0, we can safely remove this.
-		WithController(
-			kubecertagent.NewLegacyPodCleanerController(
-				agentConfig,
-				client,
-				informers.installationNamespaceK8s.Core().V1().Pods(),
-				plog.New(),
-			),
-			singletonWorker,
-		).
 		// The cache filler/cleaner controllers are responsible for keep an in-memory representation of active
 		// authenticators up to date.
 		WithController(
@@ -243,9 +285,18 @@ func PrepareControllers(c *Config) (controllerinit.RunnerBuilder, error) { //nol
 			),
 			singletonWorker,
 		).
+		WithController(
+			tokendenylist.New(
+				tokenDenylist,
+				informers.pinniped.Authentication().V1alpha1().ClusterTokenDenylists(),
+				plog.New(),
+			),
+			singletonWorker,
+		).
 		WithController(
 			jwtcachefiller.New(
 				c.AuthenticatorCache,
+				tokenDenylist,
 				client.PinnipedConcierge,
 				informers.pinniped.Authentication().V1alpha1().JWTAuthenticators(),
 				clock.RealClock{},
@@ -263,56 +314,17 @@ func PrepareControllers(c *Config) (controllerinit.RunnerBuilder, error) { //nol
 			singletonWorker,
 		).
 
-		// The impersonator configuration controller dynamically configures the impersonation proxy feature.
+		// The TokenCredentialRequest API configuration controller decides which type of credential is returned
+		// by the TokenCredentialRequest API, and how client certificates are signed.
 		WithController(
-			impersonatorconfig.NewImpersonatorConfigController(
-				c.ServerInstallationInfo.Namespace,
+			tokencredentialrequestconfig.New(
 				c.NamesConfig.CredentialIssuer,
-				client.Kubernetes,
-				client.PinnipedConcierge,
-				informers.pinniped.Config().V1alpha1().CredentialIssuers(),
-				informers.installationNamespaceK8s.Core().V1().Services(),
-				informers.installationNamespaceK8s.Core().V1().Secrets(),
-				controllerlib.WithInformer,
-				c.ImpersonationProxyServerPort,
-				c.NamesConfig.ImpersonationLoadBalancerService,
-				c.NamesConfig.ImpersonationClusterIPService,
-				c.NamesConfig.ImpersonationTLSCertificateSecret,
-				c.NamesConfig.ImpersonationCACertificateSecret,
+				c.TokenCredentialRequestCredentialType,
+				c.TokenCredentialRequestSigner,
+				c.CertificateSigningRequests,
 				c.Labels,
-				clock.RealClock{},
-				impersonator.New,
-				c.NamesConfig.ImpersonationSignerSecret,
-				c.ImpersonationSigningCertProvider,
-				plog.New(),
-				c.ImpersonationProxyTokenCache,
-			),
-			singletonWorker,
-		).
-		WithController(
-			apicerts.NewCertsManagerController(
-				c.ServerInstallationInfo.Namespace,
-				c.NamesConfig.ImpersonationSignerSecret,
-				c.Labels,
-				client.Kubernetes,
-				informers.installationNamespaceK8s.Core().V1().Secrets(),
-				controllerlib.WithInformer,
-				controllerlib.WithInitialEvent,
-				365*24*time.Hour, // 1 year hard coded value
-				"Pinniped Impersonation Proxy Signer CA",
-				"", // optional, means do not give me a serving cert
-			),
-			singletonWorker,
-		).
-		WithController(
-			apicerts.NewCertsExpirerController(
-				c.ServerInstallationInfo.Namespace,
-				c.NamesConfig.ImpersonationSignerSecret,
-				client.Kubernetes,
-				informers.installationNamespaceK8s.Core().V1().Secrets(),
+				informers.pinniped.Config().V1alpha1().CredentialIssuers(),
 				controllerlib.WithInformer,
-				365*24*time.Hour-time.Hour, // 1 year minus 1 hour hard coded value (i.e. wait until the last moment to break the signer)
-				apicerts.CACertificateSecretKey,
 				plog.New(),
 			),
 			singletonWorker,
@@ -322,31 +334,141 @@ func PrepareControllers(c *Config) (controllerinit.RunnerBuilder, error) { //nol
 				c.ServerInstallationInfo.Namespace,
 				c.NamesConfig.ImpersonationProxyLegacySecret,
 				client.Kubernetes,
-				informers.installationNamespaceK8s.Core().V1().Secrets(),
+				informers.installationNamespaceSecretsK8s.Core().V1().Secrets(),
 				controllerlib.WithInformer,
 				plog.New(),
 			),
 			singletonWorker,
 		)
 
-	return controllerinit.Prepare(controllerManager.Start, leaderElector,
-		informers.kubePublicNamespaceK8s,
-		informers.kubeSystemNamespaceK8s,
+	if *c.ControllersConfig.KubeCertAgent {
+		// The kube-cert-agent controller is responsible for finding the cluster's signing keys and keeping them
+		// up to date in memory, as well as reporting status on this cluster integration strategy.
+		controllerManager.
+			WithController(
+				kubecertagent.NewAgentController(
+					agentConfig,
+					client,
+					informers.kubeSystemNamespaceK8s.Core().V1().Pods(),
+					informers.installationNamespaceK8s.Apps().V1().Deployments(),
+					informers.installationNamespaceK8s.Core().V1().Pods(),
+					informers.kubePublicNamespaceK8s.Core().V1().ConfigMaps(),
+					informers.pinniped.Config().V1alpha1().CredentialIssuers(),
+					c.DynamicSigningCertProvider,
+				),
+				singletonWorker,
+			).
+			// The kube-cert-agent legacy pod cleaner controller is responsible for cleaning up pods that were deployed by
+			// versions of Pinniped prior to v0.7.0. If we stop supporting upgrades from v0.7.0, we can safely remove this.
+			WithController(
+				kubecertagent.NewLegacyPodCleanerController(
+					agentConfig,
+					client,
+					informers.installationNamespaceK8s.Core().V1().Pods(),
+					plog.New(),
+				),
+				singletonWorker,
+			)
+	}
+
+	if *c.ControllersConfig.ImpersonationProxy {
+		// The impersonator configuration controller dynamically configures the impersonation proxy feature.
+		controllerManager.
+			WithController(
+				impersonatorconfig.NewImpersonatorConfigController(
+					c.ServerInstallationInfo.Namespace,
+					c.NamesConfig.CredentialIssuer,
+					client.Kubernetes,
+					client.PinnipedConcierge,
+					informers.pinniped.Config().V1alpha1().CredentialIssuers(),
+					informers.installationNamespaceK8s.Core().V1().Services(),
+					informers.installationNamespaceSecretsK8s.Core().V1().Secrets(),
+					controllerlib.WithInformer,
+					c.ImpersonationProxyServerPort,
+					c.NamesConfig.ImpersonationLoadBalancerService,
+					c.NamesConfig.ImpersonationClusterIPService,
+					c.NamesConfig.ImpersonationTLSCertificateSecret,
+					c.NamesConfig.ImpersonationCACertificateSecret,
+					c.Labels,
+					clock.RealClock{},
+					impersonator.New,
+					c.NamesConfig.ImpersonationSignerSecret,
+					c.ImpersonationSigningCertProvider,
+					plog.New(),
+					c.ImpersonationProxyTokenCache,
+				),
+				singletonWorker,
+			).
+			WithController(
+				apicerts.NewCertsManagerController(
+					c.ServerInstallationInfo.Namespace,
+					c.NamesConfig.ImpersonationSignerSecret,
+					c.Labels,
+					client.Kubernetes,
+					informers.installationNamespaceSecretsK8s.Core().V1().Secrets(),
+					controllerlib.WithInformer,
+					controllerlib.WithInitialEvent,
+					365*24*time.Hour, // 1 year hard coded value
+					"Pinniped Impersonation Proxy Signer CA",
+					"", // optional, means do not give me a serving cert
+				),
+				singletonWorker,
+			).
+			WithController(
+				apicerts.NewCertsExpirerController(
+					c.ServerInstallationInfo.Namespace,
+					c.NamesConfig.ImpersonationSignerSecret,
+					client.Kubernetes,
+					informers.installationNamespaceSecretsK8s.Core().V1().Secrets(),
+					controllerlib.WithInformer,
+					365*24*time.Hour-time.Hour, // 1 year minus 1 hour hard coded value (i.e. wait until the last moment to break the signer)
+					apicerts.CACertificateSecretKey,
+					plog.New(),
+				),
+				singletonWorker,
+			)
+	}
+
+	informerFactories := []controllerinit.Informer{
 		informers.installationNamespaceK8s,
+		informers.installationNamespaceSecretsK8s,
 		informers.pinniped,
-	), nil
+	}
+	if *c.ControllersConfig.KubeCertAgent {
+		// Only the kube-cert-agent controller uses these factories. Without it, they have no informers to start.
+		informerFactories = append(informerFactories,
+			informers.kubePublicNamespaceK8s,
+			informers.kubeSystemNamespaceK8s,
+		)
+	}
+
+	cacheSyncWaiters := make([]healthcheck.CacheSyncWaiter, 0, len(informerFactories))
+	for _, factory := range informerFactories {
+		cacheSyncWaiters = append(cacheSyncWaiters, factory)
+	}
+
+	readyzChecks := []healthz.HealthChecker{
+		leaderObserved,
+		healthcheck.InformerSync(cacheSyncWaiters...),
+	}
+
+	return controllerinit.Prepare(controllerManager.Start, leaderElector, informerFactories...), readyzChecks, nil
 }
 
 type informers struct {
 	kubePublicNamespaceK8s   k8sinformers.SharedInformerFactory
 	kubeSystemNamespaceK8s   k8sinformers.SharedInformerFactory
 	installationNamespaceK8s k8sinformers.SharedInformerFactory
-	pinniped                 conciergeinformers.SharedInformerFactory
+	// installationNamespaceSecretsK8s only lists the Secrets which are selected by the configured label selector.
+	installationNamespaceSecretsK8s k8sinformers.SharedInformerFactory
+	pinniped                        conciergeinformers.SharedInformerFactory
 }
 
 // Create the informers that will be used by the controllers.
 func createInformers(
 	serverInstallationNamespace string,
+	controlPlaneNamespace string,
+	secretLabelSelector string,
 	k8sClient kubernetes.Interface,
 	pinnipedClient conciergeclientset.Interface,
 ) *informers {
@@ -359,13 +481,21 @@ func createInformers(
 		kubeSystemNamespaceK8s: k8sinformers.NewSharedInformerFactoryWithOptions(
 			k8sClient,
 			defaultResyncInterval,
-			k8sinformers.WithNamespace(kubecertagent.ControllerManagerNamespace),
+			k8sinformers.WithNamespace(controlPlaneNamespace),
 		),
 		installationNamespaceK8s: k8sinformers.NewSharedInformerFactoryWithOptions(
 			k8sClient,
 			defaultResyncInterval,
 			k8sinformers.WithNamespace(serverInstallationNamespace),
 		),
+		installationNamespaceSecretsK8s: k8sinformers.NewSharedInformerFactoryWithOptions(
+			k8sClient,
+			defaultResyncInterval,
+			k8sinformers.WithNamespace(serverInstallationNamespace),
+			k8sinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				options.LabelSelector = secretLabelSelector
+			}),
+		),
 		pinniped: conciergeinformers.NewSharedInformerFactoryWithOptions(
 			pinnipedClient,
 			defaultResyncInterval,
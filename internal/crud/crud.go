@@ -10,6 +10,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"strings"
 	"time"
 
@@ -43,6 +44,7 @@ type Storage interface {
 	Create(ctx context.Context, signature string, data JSON, additionalLabels map[string]string, ownerReferences []metav1.OwnerReference, lifetime time.Duration) (resourceVersion string, err error)
 	Get(ctx context.Context, signature string, data JSON) (resourceVersion string, err error)
 	Update(ctx context.Context, signature, resourceVersion string, data JSON) (newResourceVersion string, err error)
+	UpdateWithLifetime(ctx context.Context, signature, resourceVersion string, data JSON, lifetime time.Duration) (newResourceVersion string, err error)
 	Delete(ctx context.Context, signature string) error
 	DeleteByLabel(ctx context.Context, labelName string, labelValue string) error
 	GetName(signature string) string
@@ -94,7 +96,17 @@ func (s *secretsStorage) Get(ctx context.Context, signature string, data JSON) (
 // Update takes a resourceVersion because it assumes Get has been recently called to obtain the latest resource version.
 // This is to ensure that concurrent edits are treated as conflict errors (only one will win).
 func (s *secretsStorage) Update(ctx context.Context, signature, resourceVersion string, data JSON) (string, error) {
-	secret, err := s.toSecret(signature, resourceVersion, data, nil, nil, 0)
+	return s.update(ctx, signature, resourceVersion, data, 0)
+}
+
+// UpdateWithLifetime is like Update, but also sets the garbage collection time of the Secret to the given lifetime
+// from now, for data which should be kept for longer whenever it is updated.
+func (s *secretsStorage) UpdateWithLifetime(ctx context.Context, signature, resourceVersion string, data JSON, lifetime time.Duration) (string, error) {
+	return s.update(ctx, signature, resourceVersion, data, lifetime)
+}
+
+func (s *secretsStorage) update(ctx context.Context, signature, resourceVersion string, data JSON, lifetime time.Duration) (string, error) {
+	secret, err := s.toSecret(signature, resourceVersion, data, nil, nil, lifetime)
 	if err != nil {
 		return "", err
 	}
@@ -104,10 +116,18 @@ func (s *secretsStorage) Update(ctx context.Context, signature, resourceVersion
 		return "", fmt.Errorf("failed to get %s for signature %s: %w", s.resource, signature, err)
 	}
 
-	// preserve these fields - they are effectively immutable on update
+	// preserve these fields - they are effectively immutable on update, except for the lifetime when it is given
+	garbageCollectAfter, hasNewLifetime := secret.Annotations[SecretLifetimeAnnotationKey]
 	secret.Labels = oldSecret.Labels
 	secret.Annotations = oldSecret.Annotations
 	secret.OwnerReferences = oldSecret.OwnerReferences
+	if hasNewLifetime {
+		secret.Annotations = maps.Clone(oldSecret.Annotations)
+		if secret.Annotations == nil {
+			secret.Annotations = map[string]string{}
+		}
+		secret.Annotations[SecretLifetimeAnnotationKey] = garbageCollectAfter
+	}
 
 	secret, err = s.secrets.Update(ctx, secret, metav1.UpdateOptions{})
 	if err != nil {
@@ -13,6 +13,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/util/sets"
 
+	"go.pinniped.dev/internal/constable"
 	"go.pinniped.dev/internal/plog"
 )
 
@@ -23,10 +24,141 @@ import (
 //nolint:gochecknoglobals // this needs to be global because it will be set at application startup from configuration values
 var validatedUserConfiguredAllowedCipherSuitesForTLSOneDotTwo atomic.Value
 
-type SetAllowedCiphersFunc func([]string) error
+// validatedUserConfiguredProfileSettings is the validated configuration of the TLS versions provided by the user,
+// as set by SetUserConfiguredSettings().
+// This global variable is atomic so that it can not be set and read at the same time.
+//
+//nolint:gochecknoglobals // this needs to be global because it will be set at application startup from configuration values
+var validatedUserConfiguredProfileSettings atomic.Value
+
+// fipsApprovedCipherSuiteIDs are the TLS 1.2 cipher suites which are used by the FIPS-restricted profile.
+// These match the secure cipher suites of the profiles which are used when compiled in FIPS-only mode.
+//
+//nolint:gochecknoglobals // please treat this as a const
+var fipsApprovedCipherSuiteIDs = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+}
+
+// profileSettings are the parts of UserConfiguredSettings which are applied to every profile.
+type profileSettings struct {
+	minVersion     uint16
+	fipsRestricted bool
+}
+
+// UserConfiguredSettings are the TLS settings which the user may configure for all servers and clients.
+type UserConfiguredSettings struct {
+	// AllowedCipherSuitesForTLSOneDotTwo constrains the cipher suites of TLS 1.2 to only the named cipher suites.
+	// An empty list means that the cipher suites of each profile are not constrained.
+	AllowedCipherSuitesForTLSOneDotTwo []string
+
+	// MinVersion raises the minimum TLS version of every profile to this version. Zero means that the minimum
+	// version of each profile is not changed.
+	MinVersion uint16
+
+	// FIPSRestricted makes every profile behave like it does when Pinniped is compiled in FIPS-only mode, without
+	// requiring that build. TLS 1.2 is the maximum version, because the cipher suites of TLS 1.3 cannot be
+	// restricted, and only FIPS-approved cipher suites are used. Note that the cryptographic implementation is
+	// not a FIPS-validated module unless Pinniped was compiled in FIPS-only mode.
+	FIPSRestricted bool
+}
+
+// SetUserConfiguredSettingsFunc is implemented by SetUserConfiguredSettings.
+type SetUserConfiguredSettingsFunc func(UserConfiguredSettings) error
+
+// SetUserConfiguredSettings allows configuration/setup components to constrain the TLS versions and cipher suites
+// of all profiles. It implements SetUserConfiguredSettingsFunc.
+func SetUserConfiguredSettings(settings UserConfiguredSettings) error {
+	plog.Info("setting user-configured TLS settings",
+		"minVersion", tlsVersionName(settings.MinVersion),
+		"fipsRestricted", settings.FIPSRestricted,
+	)
+
+	switch settings.MinVersion {
+	case 0, tls.VersionTLS12, tls.VersionTLS13:
+	default:
+		return fmt.Errorf("unsupported minimum TLS version %q", tlsVersionName(settings.MinVersion))
+	}
+
+	if settings.MinVersion > maxVersion(settings.FIPSRestricted) {
+		return fmt.Errorf("minimum TLS version %q is not supported by the TLS profiles, which only support up to %q",
+			tlsVersionName(settings.MinVersion), tlsVersionName(maxVersion(settings.FIPSRestricted)))
+	}
+
+	if settings.FIPSRestricted {
+		if _, err := validateAllowedCiphers(
+			translateIDIntoSecureCipherSuites(fipsApprovedCipherSuiteIDs),
+			slices.Clone(settings.AllowedCipherSuitesForTLSOneDotTwo),
+		); err != nil {
+			return fmt.Errorf("FIPS-restricted profile: %w", err)
+		}
+	}
+
+	if err := SetUserConfiguredAllowedCipherSuitesForTLSOneDotTwo(settings.AllowedCipherSuitesForTLSOneDotTwo); err != nil {
+		return err
+	}
+
+	validatedUserConfiguredProfileSettings.Store(profileSettings{
+		minVersion:     settings.MinVersion,
+		fipsRestricted: settings.FIPSRestricted,
+	})
+	return nil
+}
+
+// getUserConfiguredProfileSettings returns the user-configured TLS versions.
+// It is not exported so that it is only available to this package.
+func getUserConfiguredProfileSettings() profileSettings {
+	settings, _ := (validatedUserConfiguredProfileSettings.Load()).(profileSettings)
+	return settings
+}
+
+// maxVersion returns the maximum TLS version which is supported by the profiles.
+func maxVersion(fipsRestricted bool) uint16 {
+	// When compiled in FIPS-only mode, the Secure profile is limited to TLS 1.2 just like all other profiles.
+	if fipsRestricted || SecureTLSConfigMinTLSVersion == tls.VersionTLS12 {
+		return tls.VersionTLS12
+	}
+	return tls.VersionTLS13
+}
+
+// ParseVersion parses a TLS version as configured by the user, which is either "1.2" or "1.3".
+// The empty string returns zero, which means that the version was not configured.
+func ParseVersion(version string) (uint16, error) {
+	switch version {
+	case "":
+		return 0, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unknown TLS version %q, must be %q or %q", version, "1.2", "1.3")
+	}
+}
+
+// applyUserConfiguredProfileSettings changes the given tls.Config according to the user-configured TLS versions.
+func applyUserConfiguredProfileSettings(c *tls.Config) {
+	settings := getUserConfiguredProfileSettings()
+
+	if settings.fipsRestricted {
+		c.MaxVersion = tls.VersionTLS12
+		c.CipherSuites = slices.DeleteFunc(c.CipherSuites, func(id uint16) bool {
+			return !slices.Contains(fipsApprovedCipherSuiteIDs, id)
+		})
+	}
+
+	if settings.minVersion > c.MinVersion {
+		c.MinVersion = settings.minVersion
+		if c.MinVersion >= tls.VersionTLS13 {
+			c.CipherSuites = nil // TLS 1.3 ciphers are not configurable
+		}
+	}
+}
 
 // SetUserConfiguredAllowedCipherSuitesForTLSOneDotTwo allows configuration/setup components to constrain the
-// allowed TLS ciphers for TLS1.2. It implements SetAllowedCiphersFunc.
+// allowed TLS ciphers for TLS1.2.
 func SetUserConfiguredAllowedCipherSuitesForTLSOneDotTwo(userConfiguredAllowedCipherSuitesForTLSOneDotTwo []string) error {
 	plog.Info("setting user-configured allowed ciphers for TLS 1.2", "userConfiguredAllowedCipherSuites", userConfiguredAllowedCipherSuitesForTLSOneDotTwo)
 
@@ -131,7 +263,7 @@ func buildTLSConfig(
 	cipherSuites []*tls.CipherSuite,
 	userConfiguredAllowedCipherSuites []*tls.CipherSuite,
 ) *tls.Config {
-	return &tls.Config{
+	c := &tls.Config{
 		// Can't use SSLv3 because of POODLE and BEAST
 		// Can't use TLSv1.0 because of POODLE and BEAST using CBC cipher
 		// Can't use TLSv1.1 because of RC4 cipher usage
@@ -151,6 +283,8 @@ func buildTLSConfig(
 		// optional root CAs, nil means use the host's root CA set
 		RootCAs: rootCAs,
 	}
+	applyUserConfiguredProfileSettings(c)
+	return c
 }
 
 // validateAllowedCiphers will take in the user-configured allowed cipher names and validate them against a list of
@@ -224,3 +358,44 @@ func allHardcodedAllowedCipherSuites() []*tls.CipherSuite {
 	}
 	return result
 }
+
+// ForListener returns a copy of the given server tls.Config which uses the given minimum TLS version and whose
+// TLS 1.2 cipher suites are further constrained to the named cipher suites, so that each listener of a server can
+// use stricter TLS settings than the profile. The cipher suite names are validated like those given to
+// SetUserConfiguredAllowedCipherSuitesForTLSOneDotTwo, and must include at least one of the cipher suites of the
+// given config. An empty list of names does not further constrain the cipher suites.
+func ForListener(c *tls.Config, minVersion uint16, allowedCipherSuiteNames []string) (*tls.Config, error) {
+	result := c.Clone()
+	if minVersion > result.MinVersion {
+		result.MinVersion = minVersion
+	}
+	if result.MaxVersion != 0 && result.MinVersion > result.MaxVersion {
+		return nil, fmt.Errorf("minimum TLS version %q is not supported by the TLS profile, which only supports up to %q",
+			tlsVersionName(result.MinVersion), tlsVersionName(result.MaxVersion))
+	}
+	if result.MinVersion >= tls.VersionTLS13 {
+		if len(allowedCipherSuiteNames) > 0 {
+			return nil, constable.Error("allowed ciphers cannot be configured for TLS 1.3")
+		}
+		result.CipherSuites = nil // TLS 1.3 ciphers are not configurable
+		return result, nil
+	}
+
+	// Validate a copy, because the validation may rewrite legacy names in place.
+	allowedCipherSuites, err := validateAllowedCiphers(allHardcodedAllowedCipherSuites(), slices.Clone(allowedCipherSuiteNames))
+	if err != nil {
+		return nil, err
+	}
+	if len(allowedCipherSuites) == 0 {
+		return result, nil
+	}
+
+	result.CipherSuites = slices.DeleteFunc(slices.Clone(result.CipherSuites), func(id uint16) bool {
+		return !slices.ContainsFunc(allowedCipherSuites, func(cipher *tls.CipherSuite) bool { return cipher.ID == id })
+	})
+	if len(result.CipherSuites) == 0 {
+		return nil, fmt.Errorf("none of the allowed ciphers [%s] are used by the TLS profile",
+			strings.Join(allowedCipherSuiteNames, ", "))
+	}
+	return result, nil
+}
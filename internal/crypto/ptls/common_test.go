@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"k8s.io/apiserver/pkg/server/options"
 )
 
 func TestSetAllowedCiphersForTLSOneDotTwo(t *testing.T) {
@@ -98,6 +99,124 @@ func TestSetAllowedCiphersForTLSOneDotTwo(t *testing.T) {
 	})
 }
 
+func TestSetUserConfiguredSettings(t *testing.T) {
+	resetSettings := func(t *testing.T) {
+		t.Helper()
+		t.Cleanup(func() {
+			require.NoError(t, SetUserConfiguredSettings(UserConfiguredSettings{}))
+			require.Equal(t, profileSettings{}, getUserConfiguredProfileSettings())
+			require.Nil(t, getUserConfiguredAllowedCipherSuitesForTLSOneDotTwo())
+		})
+	}
+
+	t.Run("with a minimum version of TLS 1.3, raises the minimum version of all profiles", func(t *testing.T) {
+		resetSettings(t)
+
+		require.NoError(t, SetUserConfiguredSettings(UserConfiguredSettings{MinVersion: tls.VersionTLS13}))
+
+		for _, c := range []*tls.Config{Default(nil), DefaultLDAP(nil), Secure(nil)} {
+			require.Equal(t, uint16(tls.VersionTLS13), c.MinVersion)
+			require.Zero(t, c.MaxVersion)
+			require.Empty(t, c.CipherSuites)
+		}
+
+		opts := options.NewSecureServingOptions().WithLoopback()
+		defaultServing(opts)
+		require.Equal(t, "VersionTLS13", opts.MinTLSVersion)
+		require.Empty(t, opts.CipherSuites)
+	})
+
+	t.Run("with the FIPS-restricted profile, limits all profiles to TLS 1.2 with FIPS-approved ciphers", func(t *testing.T) {
+		resetSettings(t)
+
+		require.NoError(t, SetUserConfiguredSettings(UserConfiguredSettings{
+			AllowedCipherSuitesForTLSOneDotTwo: []string{
+				"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+				"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+			},
+			MinVersion:     tls.VersionTLS12,
+			FIPSRestricted: true,
+		}))
+
+		for _, c := range []*tls.Config{Default(nil), DefaultLDAP(nil), Secure(nil)} {
+			require.Equal(t, uint16(tls.VersionTLS12), c.MinVersion)
+			require.Equal(t, uint16(tls.VersionTLS12), c.MaxVersion)
+			require.Equal(t, []uint16{
+				tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+				tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			}, c.CipherSuites)
+		}
+
+		opts := options.NewSecureServingOptions().WithLoopback()
+		SecureServing(opts)
+		require.Equal(t, "VersionTLS12", opts.MinTLSVersion)
+		require.Equal(t, []string{
+			"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+			"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+		}, opts.CipherSuites)
+	})
+
+	t.Run("with the FIPS-restricted profile and no allowed ciphers, uses all FIPS-approved ciphers", func(t *testing.T) {
+		resetSettings(t)
+
+		require.NoError(t, SetUserConfiguredSettings(UserConfiguredSettings{FIPSRestricted: true}))
+
+		require.Equal(t, []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		}, DefaultLDAP(nil).CipherSuites)
+	})
+
+	t.Run("with invalid settings, returns an error and does not mutate the global state", func(t *testing.T) {
+		for _, test := range []struct {
+			settings UserConfiguredSettings
+			wantErr  string
+		}{
+			{
+				settings: UserConfiguredSettings{MinVersion: tls.VersionTLS11},
+				wantErr:  `unsupported minimum TLS version "TLS 1.1"`,
+			},
+			{
+				settings: UserConfiguredSettings{MinVersion: tls.VersionTLS13, FIPSRestricted: true},
+				wantErr:  `minimum TLS version "TLS 1.3" is not supported by the TLS profiles, which only support up to "TLS 1.2"`,
+			},
+			{
+				settings: UserConfiguredSettings{
+					AllowedCipherSuitesForTLSOneDotTwo: []string{"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256"},
+					FIPSRestricted:                     true,
+				},
+				wantErr: "FIPS-restricted profile: unrecognized ciphers [TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256], ciphers must be from list [" +
+					"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, " +
+					"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384]",
+			},
+			{
+				settings: UserConfiguredSettings{AllowedCipherSuitesForTLSOneDotTwo: []string{"foo"}},
+				wantErr:  "unrecognized ciphers [foo], ciphers must be from list [",
+			},
+		} {
+			err := SetUserConfiguredSettings(test.settings)
+			require.ErrorContains(t, err, test.wantErr)
+			require.Equal(t, profileSettings{}, getUserConfiguredProfileSettings())
+			require.Nil(t, getUserConfiguredAllowedCipherSuitesForTLSOneDotTwo())
+		}
+	})
+}
+
+func TestParseVersion(t *testing.T) {
+	t.Parallel()
+
+	for version, want := range map[string]uint16{"": 0, "1.2": tls.VersionTLS12, "1.3": tls.VersionTLS13} {
+		got, err := ParseVersion(version)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	}
+
+	_, err := ParseVersion("1.1")
+	require.EqualError(t, err, `unknown TLS version "1.1", must be "1.2" or "1.3"`)
+}
+
 func TestConstrainCipherSuites(t *testing.T) {
 	tests := []struct {
 		name                              string
@@ -324,6 +443,111 @@ func TestBuildTLSConfig(t *testing.T) {
 	}
 }
 
+func TestForListener(t *testing.T) {
+	t.Parallel()
+
+	baseConfig := func() *tls.Config {
+		return &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			CipherSuites: []uint16{
+				tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+				tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+				tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			},
+			NextProtos: []string{"h2", "http/1.1"},
+		}
+	}
+
+	tests := []struct {
+		name                    string
+		config                  func(c *tls.Config)
+		minVersion              uint16
+		allowedCipherSuiteNames []string
+		wantConfig              *tls.Config
+		wantErr                 string
+	}{
+		{
+			name:       "with no allowed ciphers, returns a copy of the config",
+			minVersion: tls.VersionTLS12,
+			wantConfig: baseConfig(),
+		},
+		{
+			name:       "with a lower min version, keeps the min version of the config",
+			minVersion: tls.VersionTLS10,
+			wantConfig: baseConfig(),
+		},
+		{
+			name:       "with TLS 1.3, removes the cipher suites",
+			minVersion: tls.VersionTLS13,
+			wantConfig: &tls.Config{
+				MinVersion: tls.VersionTLS13,
+				NextProtos: []string{"h2", "http/1.1"},
+			},
+		},
+		{
+			name:                    "with TLS 1.3 and allowed ciphers",
+			minVersion:              tls.VersionTLS13,
+			allowedCipherSuiteNames: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+			wantErr:                 "allowed ciphers cannot be configured for TLS 1.3",
+		},
+		{
+			name:       "with allowed ciphers, restricts the cipher suites in the order from the config",
+			minVersion: tls.VersionTLS12,
+			allowedCipherSuiteNames: []string{
+				"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+				"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+				"TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305",
+			},
+			wantConfig: &tls.Config{
+				MinVersion: tls.VersionTLS12,
+				CipherSuites: []uint16{
+					tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+					tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+				},
+				NextProtos: []string{"h2", "http/1.1"},
+			},
+		},
+		{
+			name:       "with a min version which is higher than the max version of the config",
+			config:     func(c *tls.Config) { c.MaxVersion = tls.VersionTLS12 },
+			minVersion: tls.VersionTLS13,
+			wantErr:    `minimum TLS version "TLS 1.3" is not supported by the TLS profile, which only supports up to "TLS 1.2"`,
+		},
+		{
+			name:                    "with unrecognized ciphers",
+			minVersion:              tls.VersionTLS12,
+			allowedCipherSuiteNames: []string{"foo"},
+			wantErr:                 "unrecognized ciphers [foo], ciphers must be from list [",
+		},
+		{
+			name:                    "with only ciphers which are not used by the config",
+			minVersion:              tls.VersionTLS12,
+			allowedCipherSuiteNames: []string{"TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256"},
+			wantErr:                 "none of the allowed ciphers [TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256] are used by the TLS profile",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			config := baseConfig()
+			if test.config != nil {
+				test.config(config)
+			}
+			actualConfig, err := ForListener(config, test.minVersion, test.allowedCipherSuiteNames)
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+				require.Nil(t, actualConfig)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.wantConfig, actualConfig)
+			require.Equal(t, baseConfig(), config, "the given config should not be modified")
+		})
+	}
+}
+
 func TestValidateAllowedCiphers(t *testing.T) {
 	cipherSuites := tls.CipherSuites()
 
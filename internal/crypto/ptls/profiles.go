@@ -127,6 +127,9 @@ func Secure(rootCAs *x509.CertPool) *tls.Config {
 	// - Safari 12.1
 	// https://ssl-config.mozilla.org/#server=go&version=1.17.2&config=modern&guideline=5.6
 	c := Default(rootCAs)
+	if getUserConfiguredProfileSettings().fipsRestricted {
+		return c // like in FIPS mode, this is not any different from the Default profile
+	}
 	c.MinVersion = SecureTLSConfigMinTLSVersion // max out the security
 	c.CipherSuites = nil                        // TLS 1.3 ciphers are not configurable
 	return c
@@ -139,6 +142,11 @@ func Secure(rootCAs *x509.CertPool) *tls.Config {
 // This function is only public so we can integration test it in ptls_fips_test.go.
 // Note that this will behave differently when compiled in FIPS mode (see profiles_fips_strict.go).
 func SecureServing(opts *options.SecureServingOptionsWithLoopback) {
+	if getUserConfiguredProfileSettings().fipsRestricted {
+		defaultServing(opts) // like in FIPS mode
+		return
+	}
+
 	// secureServingOptionsMinTLSVersion is the minimum tls version in the format
 	// expected by SecureServingOptions.MinTLSVersion from
 	// k8s.io/apiserver/pkg/server/options.
@@ -32,6 +32,7 @@ func Merge(tlsConfigFunc ConfigFunc, tlsConfig *tls.Config) {
 	// override the core security knobs of the TLS config
 	// note that these have to be kept in sync with Default / Secure above
 	tlsConfig.MinVersion = secureTLSConfig.MinVersion
+	tlsConfig.MaxVersion = secureTLSConfig.MaxVersion
 	tlsConfig.CipherSuites = secureTLSConfig.CipherSuites
 
 	// if the TLS config already states what protocols it wants to use, honor that instead of overriding
@@ -73,6 +74,11 @@ func defaultServing(opts *options.SecureServingOptionsWithLoopback) {
 	opts.CipherSuites = cipherSuites
 
 	opts.MinTLSVersion = defaultServingOptionsMinTLSVersion
+	if c.MinVersion >= tls.VersionTLS13 {
+		// The user configured a higher minimum version.
+		opts.MinTLSVersion = "VersionTLS13"
+		opts.CipherSuites = nil
+	}
 }
 
 func secureClient(opts *options.RecommendedOptions, f RestConfigFunc) (PrepareServerConfigFunc, error) {
@@ -4,6 +4,8 @@
 package execcredcache
 
 import (
+	"crypto/x509"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"os"
@@ -13,6 +15,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clientauthenticationv1beta1 "k8s.io/client-go/pkg/apis/clientauthentication/v1beta1"
 	"sigs.k8s.io/yaml"
+
+	"go.pinniped.dev/pkg/cacheencryption"
 )
 
 var (
@@ -48,8 +52,9 @@ type (
 	}
 )
 
-// readCache loads a credCache from a path on disk. If the requested path does not exist, it returns an empty cache.
-func readCache(path string) (*credCache, error) {
+// readCache loads a credCache from a path on disk, decrypting it when encryption is not nil.
+// If the requested path does not exist, it returns an empty cache.
+func readCache(path string, encryption cacheencryption.Provider) (*credCache, error) {
 	cacheYAML, err := os.ReadFile(path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -60,6 +65,12 @@ func readCache(path string) (*credCache, error) {
 		return nil, fmt.Errorf("could not read cache file: %w", err)
 	}
 
+	if encryption != nil {
+		if cacheYAML, err = encryption.Decrypt(cacheYAML); err != nil {
+			return nil, fmt.Errorf("invalid cache file: %w", err)
+		}
+	}
+
 	// If we read the file successfully, unmarshal it from YAML.
 	var cache credCache
 	if err := yaml.Unmarshal(cacheYAML, &cache); err != nil {
@@ -81,10 +92,13 @@ func emptyCache() *credCache {
 	}
 }
 
-// writeTo writes the cache to the specified file path.
-func (c *credCache) writeTo(path string) error {
+// writeTo writes the cache to the specified file path, encrypting it when encryption is not nil.
+func (c *credCache) writeTo(path string, encryption cacheencryption.Provider) error {
 	// Marshal the cache back to YAML and save it to the file.
 	cacheYAML, err := yaml.Marshal(c)
+	if err == nil && encryption != nil {
+		cacheYAML, err = encryption.Encrypt(cacheYAML)
+	}
 	if err == nil {
 		err = os.WriteFile(path, cacheYAML, 0600)
 	}
@@ -124,3 +138,19 @@ func (c *credCache) normalized() *credCache {
 
 	return result
 }
+
+// summary describes the entry without its credential. normalized() guarantees that the credential has an expiry.
+func (e *entry) summary() Summary {
+	summary := Summary{
+		ID:       e.Key[:min(len(e.Key), 8)],
+		Created:  e.CreationTimestamp.Time,
+		LastUsed: e.LastUsedTimestamp.Time,
+		Expiry:   e.Credential.ExpirationTimestamp.Time,
+	}
+	if block, _ := pem.Decode([]byte(e.Credential.ClientCertificateData)); block != nil {
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			summary.Username = cert.Subject.CommonName
+		}
+	}
+	return summary
+}
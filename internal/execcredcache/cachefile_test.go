@@ -72,7 +72,7 @@ func TestReadCache(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			got, err := readCache(tt.path)
+			got, err := readCache(tt.path, nil)
 			if tt.wantErr != "" {
 				require.EqualError(t, err, tt.wantErr)
 				require.Nil(t, got)
@@ -99,13 +99,13 @@ func TestWriteTo(t *testing.T) {
 		t.Parallel()
 		tmp := t.TempDir() + "/credentials.yaml"
 		require.NoError(t, os.Mkdir(tmp, 0700))
-		err := validCache.writeTo(tmp)
+		err := validCache.writeTo(tmp, nil)
 		require.EqualError(t, err, "open "+tmp+": is a directory")
 	})
 
 	t.Run("success", func(t *testing.T) {
 		t.Parallel()
-		require.NoError(t, validCache.writeTo(t.TempDir()+"/credentials.yaml"))
+		require.NoError(t, validCache.writeTo(t.TempDir()+"/credentials.yaml", nil))
 	})
 }
 
@@ -13,11 +13,15 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/gofrs/flock"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clientauthenticationv1beta1 "k8s.io/client-go/pkg/apis/clientauthentication/v1beta1"
+
+	"go.pinniped.dev/pkg/cacheencryption"
 )
 
 const (
@@ -33,11 +37,36 @@ type Cache struct {
 	errReporter func(error)
 	trylockFunc func() error
 	unlockFunc  func() error
+	encryption  cacheencryption.Provider
+
+	// These are only used when memoryOnly is true.
+	memoryOnly  bool
+	memoryLock  sync.Mutex
+	memoryCache *credCache
+}
+
+// Option configures a cache in New().
+type Option func(*Cache)
+
+// WithMemoryOnly is an Option that keeps the credential cache in memory instead of in the file at the specified path.
+// Nothing is ever read from or written to disk, so cached credentials only last for the lifetime of the Cache.
+func WithMemoryOnly() Option {
+	return func(c *Cache) {
+		c.memoryOnly = true
+	}
 }
 
-func New(path string) *Cache {
+// WithEncryption is an Option that encrypts the credential cache file with the provided encryption. Credential cache
+// files which were not encrypted with it cannot be read, so they are replaced by the next update.
+func WithEncryption(encryption cacheencryption.Provider) Option {
+	return func(c *Cache) {
+		c.encryption = encryption
+	}
+}
+
+func New(path string, options ...Option) *Cache {
 	lock := flock.New(path + ".lock")
-	return &Cache{
+	c := Cache{
 		path: path,
 		trylockFunc: func() error {
 			ctx, cancel := context.WithTimeout(context.Background(), defaultFileLockTimeout)
@@ -48,12 +77,18 @@ func New(path string) *Cache {
 		unlockFunc:  lock.Unlock,
 		errReporter: func(_ error) {},
 	}
+	for _, opt := range options {
+		opt(&c)
+	}
+	return &c
 }
 
 func (c *Cache) Get(key any) *clientauthenticationv1beta1.ExecCredential {
 	// If the cache file does not exist, exit immediately with no error log
-	if _, err := os.Stat(c.path); errors.Is(err, os.ErrNotExist) {
-		return nil
+	if !c.memoryOnly {
+		if _, err := os.Stat(c.path); errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
 	}
 
 	// Read the cache and lookup the matching entry. If one exists, update its last used timestamp and return it.
@@ -82,9 +117,11 @@ func (c *Cache) Get(key any) *clientauthenticationv1beta1.ExecCredential {
 
 func (c *Cache) Put(key any, cred *clientauthenticationv1beta1.ExecCredential) {
 	// Create the cache directory if it does not exist.
-	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil && !errors.Is(err, os.ErrExist) {
-		c.errReporter(fmt.Errorf("could not create credential cache directory: %w", err))
-		return
+	if !c.memoryOnly {
+		if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil && !errors.Is(err, os.ErrExist) {
+			c.errReporter(fmt.Errorf("could not create credential cache directory: %w", err))
+			return
+		}
 	}
 
 	// Mutate the cache to upsert the new entry.
@@ -122,6 +159,11 @@ func jsonSHA256Hex(key any) string {
 // withCache is an internal helper which locks, reads the cache, processes/mutates it with the provided function, then
 // saves it back to the file.
 func (c *Cache) withCache(transact func(*credCache)) {
+	if c.memoryOnly {
+		c.withMemoryCache(transact)
+		return
+	}
+
 	// Grab the file lock so we have exclusive access to read the file.
 	if err := c.trylockFunc(); err != nil {
 		c.errReporter(fmt.Errorf("could not lock cache file: %w", err))
@@ -136,7 +178,7 @@ func (c *Cache) withCache(transact func(*credCache)) {
 	}()
 
 	// Try to read the existing cache.
-	cache, err := readCache(c.path)
+	cache, err := readCache(c.path, c.encryption)
 	if err != nil {
 		// If that fails, fall back to resetting to a blank slate.
 		c.errReporter(fmt.Errorf("failed to read cache, resetting: %w", err))
@@ -153,7 +195,72 @@ func (c *Cache) withCache(transact func(*credCache)) {
 	cache = cache.normalized()
 
 	// Marshal the cache back to YAML and save it to the file.
-	if err := cache.writeTo(c.path); err != nil {
+	if err := cache.writeTo(c.path, c.encryption); err != nil {
 		c.errReporter(fmt.Errorf("could not write cache: %w", err))
 	}
 }
+
+// withMemoryCache is like withCache, but processes/mutates the in-memory cache instead of the file.
+func (c *Cache) withMemoryCache(transact func(*credCache)) {
+	c.memoryLock.Lock()
+	defer c.memoryLock.Unlock()
+
+	if c.memoryCache == nil {
+		c.memoryCache = emptyCache()
+	}
+
+	cache := c.memoryCache.normalized()
+	transact(cache)
+	c.memoryCache = cache.normalized()
+}
+
+// Summary describes a cached credential without revealing the credential itself.
+type Summary struct {
+	// ID is the first 8 hex characters of the hash under which the credential is cached.
+	ID       string
+	Created  time.Time
+	LastUsed time.Time
+	Expiry   time.Time
+	// Username is the common name of the client certificate of the credential, or empty when the credential is a token.
+	Username string
+}
+
+// ReadSummaries returns summaries of the unexpired credentials in the credential cache file at path, in the order
+// in which they were created. It returns no credentials when the file does not exist. It never modifies the file.
+// Only the WithEncryption option applies.
+func ReadSummaries(path string, options ...Option) ([]Summary, error) {
+	cache, err := readCache(path, New(path, options...).encryption)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := cache.normalized().Entries
+	summaries := make([]Summary, 0, len(entries))
+	for _, e := range entries {
+		summaries = append(summaries, e.summary())
+	}
+	return summaries, nil
+}
+
+// Delete removes the credentials for which match returns true from the credential cache, and returns how many
+// credentials were removed. It does not return an error but may silently fail to update the credential cache.
+func (c *Cache) Delete(match func(Summary) bool) int {
+	// If the cache file does not exist, there is nothing to delete.
+	if !c.memoryOnly {
+		if _, err := os.Stat(c.path); errors.Is(err, os.ErrNotExist) {
+			return 0
+		}
+	}
+
+	deleted := 0
+	c.withCache(func(cache *credCache) {
+		cache.Entries = slices.DeleteFunc(cache.Entries, func(e entry) bool {
+			if match(e.summary()) {
+				deleted++
+				return true
+			}
+			return false
+		})
+	})
+	return deleted
+}
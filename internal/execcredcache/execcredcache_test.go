@@ -4,6 +4,7 @@
 package execcredcache
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -14,6 +15,9 @@ import (
 	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clientauthenticationv1beta1 "k8s.io/client-go/pkg/apis/clientauthentication/v1beta1"
+
+	"go.pinniped.dev/internal/certauthority"
+	"go.pinniped.dev/pkg/cacheencryption"
 )
 
 func TestNew(t *testing.T) {
@@ -26,6 +30,43 @@ func TestNew(t *testing.T) {
 	c.errReporter(fmt.Errorf("some error"))
 }
 
+func TestMemoryOnly(t *testing.T) {
+	t.Parallel()
+	oneHourFromNow := metav1.NewTime(time.Now().Round(1 * time.Second).Add(1 * time.Hour))
+	tmp := filepath.Join(t.TempDir(), "credentials.yaml")
+
+	var errs []error
+	c := New(tmp, WithMemoryOnly())
+	c.errReporter = func(err error) { errs = append(errs, err) }
+	c.trylockFunc = func() error { require.Fail(t, "should not be called"); return nil }
+	c.unlockFunc = func() error { require.Fail(t, "should not be called"); return nil }
+
+	type testKey struct{ K1, K2 string }
+	cred := &clientauthenticationv1beta1.ExecCredential{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ExecCredential",
+			APIVersion: "client.authentication.k8s.io/v1beta1",
+		},
+		Status: &clientauthenticationv1beta1.ExecCredentialStatus{
+			Token:               "test-token",
+			ExpirationTimestamp: &oneHourFromNow,
+		},
+	}
+
+	require.Nil(t, c.Get(testKey{K1: "v1", K2: "v2"}))
+	c.Put(testKey{K1: "v1", K2: "v2"}, cred)
+	require.Equal(t, cred, c.Get(testKey{K1: "v1", K2: "v2"}))
+	require.Nil(t, c.Get(testKey{K1: "v3", K2: "v4"}))
+
+	// Nothing should have been written to disk.
+	require.NoFileExists(t, tmp)
+	require.NoFileExists(t, tmp+".lock")
+	require.Empty(t, errs)
+
+	// A separate in-memory cache does not share any credentials.
+	require.Nil(t, New(tmp, WithMemoryOnly()).Get(testKey{K1: "v1", K2: "v2"}))
+}
+
 func TestGet(t *testing.T) {
 	t.Parallel()
 	now := time.Now().Round(1 * time.Second)
@@ -100,7 +141,7 @@ func TestGet(t *testing.T) {
 						ExpirationTimestamp: &oneHourFromNow,
 					},
 				}}
-				require.NoError(t, validCache.writeTo(tmp))
+				require.NoError(t, validCache.writeTo(tmp, nil))
 			},
 			key:        testKey{K1: "v1", K2: "v2"},
 			wantErrors: []string{},
@@ -119,7 +160,7 @@ func TestGet(t *testing.T) {
 						ExpirationTimestamp: &oneMinuteAgo,
 					},
 				}}
-				require.NoError(t, validCache.writeTo(tmp))
+				require.NoError(t, validCache.writeTo(tmp, nil))
 			},
 			key:        testKey{K1: "v1", K2: "v2"},
 			wantErrors: []string{},
@@ -138,7 +179,7 @@ func TestGet(t *testing.T) {
 						ExpirationTimestamp: &oneHourFromNow,
 					},
 				}}
-				require.NoError(t, validCache.writeTo(tmp))
+				require.NoError(t, validCache.writeTo(tmp, nil))
 			},
 			key:        testKey{K1: "v1", K2: "v2"},
 			wantErrors: []string{},
@@ -154,7 +195,7 @@ func TestGet(t *testing.T) {
 				},
 			},
 			wantTestFile: func(t *testing.T, tmp string) {
-				cache, err := readCache(tmp)
+				cache, err := readCache(tmp, nil)
 				require.NoError(t, err)
 				require.Len(t, cache.Entries, 1)
 				require.Less(t, time.Since(cache.Entries[0].LastUsedTimestamp.Time).Nanoseconds(), (5 * time.Second).Nanoseconds())
@@ -240,7 +281,7 @@ func TestPutToken(t *testing.T) {
 					},
 				}
 				require.NoError(t, os.MkdirAll(filepath.Dir(tmp), 0700))
-				require.NoError(t, validCache.writeTo(tmp))
+				require.NoError(t, validCache.writeTo(tmp, nil))
 			},
 			key: testKey{K1: "v1", K2: "v2"},
 			cred: &clientauthenticationv1beta1.ExecCredential{
@@ -254,7 +295,7 @@ func TestPutToken(t *testing.T) {
 				},
 			},
 			wantTestFile: func(t *testing.T, tmp string) {
-				cache, err := readCache(tmp)
+				cache, err := readCache(tmp, nil)
 				require.NoError(t, err)
 				require.Len(t, cache.Entries, 1)
 				require.Less(t, time.Since(cache.Entries[0].LastUsedTimestamp.Time).Nanoseconds(), (5 * time.Second).Nanoseconds())
@@ -280,7 +321,7 @@ func TestPutToken(t *testing.T) {
 					},
 				}
 				require.NoError(t, os.MkdirAll(filepath.Dir(tmp), 0700))
-				require.NoError(t, validCache.writeTo(tmp))
+				require.NoError(t, validCache.writeTo(tmp, nil))
 			},
 			key: testKey{K1: "v1", K2: "v2"},
 			cred: &clientauthenticationv1beta1.ExecCredential{
@@ -294,7 +335,7 @@ func TestPutToken(t *testing.T) {
 				},
 			},
 			wantTestFile: func(t *testing.T, tmp string) {
-				cache, err := readCache(tmp)
+				cache, err := readCache(tmp, nil)
 				require.NoError(t, err)
 				require.Len(t, cache.Entries, 2)
 				require.Less(t, time.Since(cache.Entries[1].LastUsedTimestamp.Time).Nanoseconds(), (5 * time.Second).Nanoseconds())
@@ -382,3 +423,95 @@ func timePtr(from time.Time) *metav1.Time {
 type unmarshalable struct{}
 
 func (*unmarshalable) MarshalJSON() ([]byte, error) { return nil, fmt.Errorf("some MarshalJSON error") }
+
+func TestReadSummariesAndDelete(t *testing.T) {
+	t.Parallel()
+	now := time.Now().Round(1 * time.Second)
+	oneHourFromNow := metav1.NewTime(now.Add(1 * time.Hour))
+	tmp := filepath.Join(t.TempDir(), "credentials.yaml")
+
+	summaries, err := ReadSummaries(tmp)
+	require.NoError(t, err)
+	require.Empty(t, summaries)
+
+	c := New(tmp)
+	require.Zero(t, c.Delete(func(Summary) bool { return true }))
+	require.NoFileExists(t, tmp)
+
+	ca, err := certauthority.New("test-ca", time.Hour)
+	require.NoError(t, err)
+	certPEM, keyPEM, err := ca.IssueClientCertPEM("test-username", []string{"test-group"}, time.Hour)
+	require.NoError(t, err)
+
+	type testKey struct{ K1, K2 string }
+	c.Put(testKey{K1: "v1", K2: "v2"}, &clientauthenticationv1beta1.ExecCredential{
+		Status: &clientauthenticationv1beta1.ExecCredentialStatus{
+			ClientCertificateData: string(certPEM),
+			ClientKeyData:         string(keyPEM),
+			ExpirationTimestamp:   &oneHourFromNow,
+		},
+	})
+	c.Put(testKey{K1: "v3", K2: "v4"}, &clientauthenticationv1beta1.ExecCredential{
+		Status: &clientauthenticationv1beta1.ExecCredentialStatus{
+			Token:               "test-token",
+			ExpirationTimestamp: &oneHourFromNow,
+		},
+	})
+
+	summaries, err = ReadSummaries(tmp)
+	require.NoError(t, err)
+	require.Len(t, summaries, 2)
+	require.Equal(t, jsonSHA256Hex(testKey{K1: "v1", K2: "v2"})[:8], summaries[0].ID)
+	require.Equal(t, "test-username", summaries[0].Username)
+	require.Equal(t, oneHourFromNow.UTC(), summaries[0].Expiry.UTC())
+	require.WithinDuration(t, now, summaries[0].Created, time.Minute)
+	require.Equal(t, jsonSHA256Hex(testKey{K1: "v3", K2: "v4"})[:8], summaries[1].ID)
+	require.Empty(t, summaries[1].Username)
+
+	require.Equal(t, 1, c.Delete(func(s Summary) bool { return s.ID == summaries[0].ID }))
+	require.Nil(t, c.Get(testKey{K1: "v1", K2: "v2"}))
+	require.NotNil(t, c.Get(testKey{K1: "v3", K2: "v4"}))
+
+	_, err = ReadSummaries("./testdata/invalid.yaml")
+	require.ErrorContains(t, err, "invalid cache file")
+}
+
+func TestEncryption(t *testing.T) {
+	t.Parallel()
+	oneHourFromNow := metav1.NewTime(time.Now().Add(1 * time.Hour))
+	tmp := filepath.Join(t.TempDir(), "credentials.yaml")
+	encryption, err := cacheencryption.NewAESGCM(bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+
+	type testKey struct{ K1, K2 string }
+	key := testKey{K1: "v1", K2: "v2"}
+	c := New(tmp, WithEncryption(encryption))
+	c.Put(key, &clientauthenticationv1beta1.ExecCredential{
+		Status: &clientauthenticationv1beta1.ExecCredentialStatus{
+			Token:               "test-token",
+			ExpirationTimestamp: &oneHourFromNow,
+		},
+	})
+	got := c.Get(key)
+	require.NotNil(t, got)
+	require.Equal(t, "test-token", got.Status.Token)
+
+	contents, err := os.ReadFile(tmp)
+	require.NoError(t, err)
+	require.NotContains(t, string(contents), "test-token")
+	require.NotContains(t, string(contents), "CredentialCache")
+
+	summaries, err := ReadSummaries(tmp, WithEncryption(encryption))
+	require.NoError(t, err)
+	require.Len(t, summaries, 1)
+
+	_, err = ReadSummaries(tmp)
+	require.ErrorContains(t, err, "invalid cache file: ")
+
+	// A cache without the key can not read the encrypted file, so it starts over.
+	var errs []error
+	plain := New(tmp)
+	plain.errReporter = func(err error) { errs = append(errs, err) }
+	require.Nil(t, plain.Get(key))
+	require.NotEmpty(t, errs)
+}
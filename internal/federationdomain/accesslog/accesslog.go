@@ -0,0 +1,367 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package accesslog writes HTTP access logs for the endpoints of FederationDomains. These access logs are
+// written in a standard format to their own sink, separately from the Supervisor's application logs and audit logs.
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/clock"
+)
+
+// Format is the format of each line of the access log.
+type Format string
+
+const (
+	// FormatCommon is the Common Log Format of the NCSA httpd and Apache servers.
+	FormatCommon Format = "common"
+	// FormatCombined is the Common Log Format with the addition of the Referer and User-Agent request headers.
+	FormatCombined Format = "combined"
+	// FormatJSON writes each access log entry as a JSON object on its own line.
+	FormatJSON Format = "json"
+)
+
+// Names of the fields which can be included in the access log entries when using FormatJSON.
+const (
+	FieldTime             = "time"
+	FieldFederationDomain = "federationDomain"
+	FieldRemoteAddr       = "remoteAddr"
+	FieldHost             = "host"
+	FieldMethod           = "method"
+	FieldURI              = "uri"
+	FieldProto            = "proto"
+	FieldStatus           = "status"
+	FieldBytes            = "bytes"
+	FieldDurationMillis   = "durationMillis"
+	FieldReferer          = "referer"
+	FieldUserAgent        = "userAgent"
+)
+
+// redactedValue replaces the values of sensitive query parameters.
+const redactedValue = "redacted"
+
+// commonLogTimeFormat is the timestamp format used by the Common Log Format.
+const commonLogTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// AllFields returns the names of all the fields which can be included in FormatJSON access log entries,
+// in the order in which they are written.
+func AllFields() []string {
+	return []string{
+		FieldTime,
+		FieldFederationDomain,
+		FieldRemoteAddr,
+		FieldHost,
+		FieldMethod,
+		FieldURI,
+		FieldProto,
+		FieldStatus,
+		FieldBytes,
+		FieldDurationMillis,
+		FieldReferer,
+		FieldUserAgent,
+	}
+}
+
+// AllFormats returns all the supported formats.
+func AllFormats() []Format {
+	return []Format{FormatCommon, FormatCombined, FormatJSON}
+}
+
+// sensitiveQueryParams are the names of the query parameters whose values may contain tokens, secrets, or
+// personally identifiable information, so they are never written to the access log.
+//
+//nolint:gochecknoglobals // This is a constant lookup table.
+var sensitiveQueryParams = sets.New(
+	"code",
+	"state",
+	"nonce",
+	"code_challenge",
+	"code_verifier",
+	"client_secret",
+	"client_assertion",
+	"access_token",
+	"id_token",
+	"refresh_token",
+	"token",
+	"subject_token",
+	"login_hint",
+	"username",
+	"password",
+)
+
+// Logger writes access log entries to a sink.
+//
+// It is thread-safe.
+type Logger struct {
+	format Format
+	fields []string
+	clock  clock.PassiveClock
+
+	mu   sync.Mutex
+	sink io.Writer
+}
+
+// New returns a Logger which writes to the sink using the format. The fields are only used by FormatJSON,
+// and an empty list of fields means all fields. The caller is responsible for validating the format and fields.
+func New(sink io.Writer, format Format, fields []string, clock clock.PassiveClock) *Logger {
+	if len(fields) == 0 {
+		fields = AllFields()
+	}
+	return &Logger{
+		format: format,
+		fields: fields,
+		clock:  clock,
+		sink:   sink,
+	}
+}
+
+// WrapHandler returns a handler which writes an access log entry for each request handled by the delegate.
+// The issuer of the FederationDomain which is serving the request is included in FormatJSON entries.
+func (l *Logger) WrapHandler(issuer string, delegate http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := l.clock.Now()
+		recorder := &responseRecorder{ResponseWriter: w}
+
+		delegate.ServeHTTP(recorder, r)
+
+		l.write(&entry{
+			issuer:   issuer,
+			request:  r,
+			start:    start,
+			duration: l.clock.Since(start),
+			status:   recorder.statusCode(),
+			bytes:    recorder.bytes,
+		})
+	})
+}
+
+type entry struct {
+	issuer   string
+	request  *http.Request
+	start    time.Time
+	duration time.Duration
+	status   int
+	bytes    int64
+}
+
+func (l *Logger) write(e *entry) {
+	var line []byte
+	switch l.format {
+	case FormatJSON:
+		line = l.jsonLine(e)
+	case FormatCombined:
+		line = combinedLine(e)
+	case FormatCommon:
+		fallthrough
+	default:
+		line = commonLine(e)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// There is nowhere better to report a failure to write an access log, so ignore it.
+	_, _ = l.sink.Write(line)
+}
+
+// commonLine formats an entry using the Common Log Format, e.g.
+// 127.0.0.1 - - [10/Oct/2024:13:55:36 -0700] "GET /issuer/oauth2/authorize?code=redacted HTTP/1.1" 200 2326
+func commonLine(e *entry) []byte {
+	var b bytes.Buffer
+	writeCommon(&b, e)
+	b.WriteByte('\n')
+	return b.Bytes()
+}
+
+// combinedLine formats an entry using the Combined Log Format, which is the Common Log Format followed by
+// the quoted Referer and User-Agent request headers.
+func combinedLine(e *entry) []byte {
+	var b bytes.Buffer
+	writeCommon(&b, e)
+	fmt.Fprintf(&b, " %s %s\n",
+		quote(redactURL(e.request.Referer())),
+		quote(e.request.UserAgent()),
+	)
+	return b.Bytes()
+}
+
+func writeCommon(b *bytes.Buffer, e *entry) {
+	size := "-"
+	if e.bytes > 0 {
+		size = strconv.FormatInt(e.bytes, 10)
+	}
+	fmt.Fprintf(b, "%s - - [%s] %s %d %s",
+		dashIfEmpty(remoteHost(e.request)),
+		e.start.Format(commonLogTimeFormat),
+		quote(e.request.Method+" "+redactRequestURI(e.request)+" "+e.request.Proto),
+		e.status,
+		size,
+	)
+}
+
+func (l *Logger) jsonLine(e *entry) []byte {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, field := range l.fields {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(field)
+		value, _ := json.Marshal(fieldValue(field, e))
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteString("}\n")
+	return b.Bytes()
+}
+
+func fieldValue(field string, e *entry) any {
+	switch field {
+	case FieldTime:
+		return e.start.UTC().Format(time.RFC3339Nano)
+	case FieldFederationDomain:
+		return e.issuer
+	case FieldRemoteAddr:
+		return remoteHost(e.request)
+	case FieldHost:
+		return e.request.Host
+	case FieldMethod:
+		return e.request.Method
+	case FieldURI:
+		return redactRequestURI(e.request)
+	case FieldProto:
+		return e.request.Proto
+	case FieldStatus:
+		return e.status
+	case FieldBytes:
+		return e.bytes
+	case FieldDurationMillis:
+		return e.duration.Milliseconds()
+	case FieldReferer:
+		return redactURL(e.request.Referer())
+	case FieldUserAgent:
+		return e.request.UserAgent()
+	default:
+		// This shouldn't happen because the fields are validated by the caller.
+		return nil
+	}
+}
+
+// remoteHost returns the IP address from the remote address of the request, without the port.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// redactRequestURI returns the path and query of the request, with the values of sensitive query parameters redacted.
+func redactRequestURI(r *http.Request) string {
+	u := *r.URL
+	u.RawQuery = redactQuery(u.RawQuery)
+	return u.RequestURI()
+}
+
+// redactURL returns the URL with any user info and the values of sensitive query parameters redacted.
+// Returns the empty string for an empty or invalid URL, since an invalid URL could contain anything.
+func redactURL(rawURL string) string {
+	if rawURL == "" {
+		return ""
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	if u.User != nil {
+		u.User = url.User(redactedValue)
+	}
+	u.RawQuery = redactQuery(u.RawQuery)
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String()
+}
+
+func redactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	parts := strings.Split(rawQuery, "&")
+	for i, part := range parts {
+		name, _, hasValue := strings.Cut(part, "=")
+		unescapedName, err := url.QueryUnescape(name)
+		if err != nil {
+			// Could not understand the name of this param, so don't risk writing its value.
+			parts[i] = name + "=" + redactedValue
+			continue
+		}
+		if hasValue && sensitiveQueryParams.Has(strings.ToLower(unescapedName)) {
+			parts[i] = name + "=" + redactedValue
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func quote(s string) string {
+	if s == "" {
+		return `"-"`
+	}
+	return strconv.Quote(s)
+}
+
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// responseRecorder remembers the status code and the size of the response body.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if r.status == 0 {
+		r.status = statusCode
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap allows http.ResponseController to find the original http.ResponseWriter.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *responseRecorder) statusCode() int {
+	if r.status == 0 {
+		// The handler did not write anything, so the server will send an empty 200 response.
+		return http.StatusOK
+	}
+	return r.status
+}
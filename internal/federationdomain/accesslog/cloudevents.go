@@ -0,0 +1,356 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"k8s.io/utils/clock"
+	"k8s.io/utils/ptr"
+
+	"go.pinniped.dev/internal/plog"
+)
+
+const (
+	// CloudEventsType is the type attribute of the CloudEvents which wrap the access log entries.
+	CloudEventsType = "dev.pinniped.supervisor.accesslog.v1"
+
+	cloudEventsSpecVersion      = "1.0"
+	cloudEventsBatchContentType = "application/cloudevents-batch+json"
+	kafkaRESTContentType        = "application/vnd.kafka.json.v2+json"
+	kafkaRESTAcceptType         = "application/vnd.kafka.v2+json"
+
+	cloudEventsDeliveryTimeout = 30 * time.Second
+	cloudEventsInitialBackoff  = time.Second
+
+	// cloudEventsMaxPendingBatches limits how many batches of undelivered events are held in memory,
+	// e.g. while the destination is unavailable. Any further events are written to the Supervisor's log instead.
+	cloudEventsMaxPendingBatches = 10
+
+	// cloudEventsMaxResponseBytes limits how much of the response to each delivery will be read.
+	cloudEventsMaxResponseBytes = 1 << 20
+)
+
+var errCloudEventsSinkClosed = errors.New("the CloudEvents sink is closed")
+
+// CloudEventsTransport delivers a batch of CloudEvents, each of which is encoded using the structured JSON format.
+type CloudEventsTransport interface {
+	Send(ctx context.Context, events []json.RawMessage) error
+}
+
+// CloudEventsConfig holds the settings for a CloudEvents sink.
+type CloudEventsConfig struct {
+	// Source is the source attribute of the events.
+	Source string
+	// Transport delivers the batches of events.
+	Transport CloudEventsTransport
+	// MaxBatchSize is the maximum number of events given to the Transport at once.
+	MaxBatchSize int
+	// FlushInterval is how long events may wait to be delivered in a partial batch.
+	FlushInterval time.Duration
+	// MaxRetries is how many times the delivery of a batch is retried, with exponential backoff, before its
+	// events are written to the Supervisor's log instead.
+	MaxRetries int
+}
+
+// cloudEvent is a CloudEvents 1.0 event in the structured JSON format.
+type cloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Time            string          `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+}
+
+type cloudEventsSink struct {
+	config         CloudEventsConfig
+	clock          clock.WithTicker
+	generateID     func() string
+	initialBackoff time.Duration
+	log            plog.Logger
+
+	mu      sync.Mutex
+	pending []json.RawMessage
+	closed  bool
+
+	flush     chan struct{}
+	stop      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+// NewCloudEventsSink returns a sink which wraps each FormatJSON access log entry in a CloudEvent, and which
+// delivers the events in batches using the transport of the config. It is only used for access logs. The audit
+// events of the Supervisor, e.g. about logins and token issuance, are written to the Supervisor's log. Events which cannot be delivered are written
+// to the Supervisor's log, so they are not lost silently. Close delivers any pending events.
+func NewCloudEventsSink(config CloudEventsConfig, clock clock.WithTicker) io.WriteCloser {
+	return newCloudEventsSink(config, clock, uuid.NewString, cloudEventsInitialBackoff, plog.New())
+}
+
+func newCloudEventsSink(
+	config CloudEventsConfig,
+	clock clock.WithTicker,
+	generateID func() string,
+	initialBackoff time.Duration,
+	log plog.Logger,
+) *cloudEventsSink {
+	s := &cloudEventsSink{
+		config:         config,
+		clock:          clock,
+		generateID:     generateID,
+		initialBackoff: initialBackoff,
+		log:            log,
+		flush:          make(chan struct{}, 1),
+		stop:           make(chan struct{}),
+		stopped:        make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+func (s *cloudEventsSink) Write(p []byte) (int, error) {
+	event, err := s.newEvent(p)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return 0, errCloudEventsSinkClosed
+	}
+	if len(s.pending) >= cloudEventsMaxPendingBatches*s.config.MaxBatchSize {
+		s.deadLetter("too many access log CloudEvents are waiting to be delivered", nil, []json.RawMessage{event})
+		return len(p), nil
+	}
+
+	s.pending = append(s.pending, event)
+	if len(s.pending) >= s.config.MaxBatchSize {
+		select {
+		case s.flush <- struct{}{}:
+		default: // a flush is already requested
+		}
+	}
+	return len(p), nil
+}
+
+// Close stops accepting events, and waits until the pending events have either been delivered or logged.
+func (s *cloudEventsSink) Close() error {
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+		close(s.stop)
+	})
+	<-s.stopped
+	return nil
+}
+
+func (s *cloudEventsSink) newEvent(line []byte) (json.RawMessage, error) {
+	data := json.RawMessage(bytes.TrimSpace(line))
+	if !json.Valid(data) {
+		// Only FormatJSON entries are expected, but do not drop anything else.
+		var err error
+		if data, err = json.Marshal(string(data)); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(&cloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		ID:              s.generateID(),
+		Source:          s.config.Source,
+		Type:            CloudEventsType,
+		Time:            s.clock.Now().UTC().Format(time.RFC3339Nano),
+		DataContentType: "application/json",
+		Data:            data,
+	})
+}
+
+func (s *cloudEventsSink) run() {
+	defer close(s.stopped)
+
+	ticker := s.clock.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			s.deliverPending()
+			return
+		case <-ticker.C():
+		case <-s.flush:
+		}
+		s.deliverPending()
+	}
+}
+
+func (s *cloudEventsSink) deliverPending() {
+	for {
+		batch := s.takeBatch()
+		if len(batch) == 0 {
+			return
+		}
+		s.deliver(batch)
+	}
+}
+
+func (s *cloudEventsSink) takeBatch() []json.RawMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := min(len(s.pending), s.config.MaxBatchSize)
+	batch := s.pending[:n:n]
+	s.pending = s.pending[n:]
+	return batch
+}
+
+func (s *cloudEventsSink) deliver(batch []json.RawMessage) {
+	backoff := s.initialBackoff
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), cloudEventsDeliveryTimeout)
+		err := s.config.Transport.Send(ctx, batch)
+		cancel()
+		if err == nil {
+			return
+		}
+
+		if attempt >= s.config.MaxRetries {
+			s.deadLetter("could not deliver access log CloudEvents", err, batch)
+			return
+		}
+		s.log.Debug("retrying delivery of access log CloudEvents", "error", err.Error(), "attempt", attempt+1, "events", len(batch))
+
+		select {
+		case <-s.clock.After(backoff):
+			backoff *= 2
+		case <-s.stop:
+			// Do not delay shutdown by waiting to retry.
+			s.deadLetter("could not deliver access log CloudEvents before shutdown", err, batch)
+			return
+		}
+	}
+}
+
+// deadLetter writes events which could not be delivered to the Supervisor's log, from which they can be recovered.
+func (s *cloudEventsSink) deadLetter(msg string, err error, events []json.RawMessage) {
+	for _, event := range events {
+		s.log.WarningErr(msg, err, "cloudEvent", string(event))
+	}
+}
+
+// NewCloudEventsHTTPTransport returns a transport which POSTs each batch of events to the URL, using the batched
+// content mode of the CloudEvents HTTP protocol binding. Any 2xx response means that the batch was delivered.
+func NewCloudEventsHTTPTransport(endpoint string, client *http.Client) CloudEventsTransport {
+	return &httpTransport{url: endpoint, client: client}
+}
+
+type httpTransport struct {
+	url    string
+	client *http.Client
+}
+
+func (t *httpTransport) Send(ctx context.Context, events []json.RawMessage) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	_, err = post(ctx, t.client, t.url, cloudEventsBatchContentType, "", body)
+	return err
+}
+
+// NewCloudEventsKafkaTransport returns a transport which produces each event as a record of the Kafka topic,
+// using the v2 API of a Kafka REST Proxy. This avoids the need for the Supervisor to speak the Kafka protocol,
+// but it also means that Kafka brokers can not be used without a REST Proxy.
+func NewCloudEventsKafkaTransport(restProxyURL, topic string, client *http.Client) CloudEventsTransport {
+	return &kafkaTransport{
+		url:    strings.TrimSuffix(restProxyURL, "/") + "/topics/" + url.PathEscape(topic),
+		client: client,
+	}
+}
+
+type kafkaTransport struct {
+	url    string
+	client *http.Client
+}
+
+type kafkaRecord struct {
+	Value json.RawMessage `json:"value"`
+}
+
+type kafkaProduceRequest struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		ErrorCode *int    `json:"error_code"`
+		Error     *string `json:"error"`
+	} `json:"offsets"`
+}
+
+func (t *kafkaTransport) Send(ctx context.Context, events []json.RawMessage) error {
+	request := kafkaProduceRequest{Records: make([]kafkaRecord, 0, len(events))}
+	for _, event := range events {
+		request.Records = append(request.Records, kafkaRecord{Value: event})
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	responseBody, err := post(ctx, t.client, t.url, kafkaRESTContentType, kafkaRESTAcceptType, body)
+	if err != nil {
+		return err
+	}
+
+	// The REST Proxy reports the failure of each record separately. Retrying the whole batch may duplicate some
+	// records, but consumers can use the id of each event to ignore duplicates.
+	var response kafkaProduceResponse
+	if err := json.Unmarshal(responseBody, &response); err != nil {
+		return fmt.Errorf("could not decode response of Kafka REST Proxy: %w", err)
+	}
+	for _, offset := range response.Offsets {
+		if offset.ErrorCode != nil || offset.Error != nil {
+			return fmt.Errorf("the Kafka REST Proxy could not produce all records: %s", ptr.Deref(offset.Error, "unknown error"))
+		}
+	}
+	return nil
+}
+
+func post(ctx context.Context, client *http.Client, endpoint, contentType, accept string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	responseBody, err := io.ReadAll(io.LimitReader(res.Body, cloudEventsMaxResponseBytes))
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected response status %s", res.Status)
+	}
+	return responseBody, err
+}
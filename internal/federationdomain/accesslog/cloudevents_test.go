@@ -0,0 +1,258 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/clock"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"go.pinniped.dev/internal/plog"
+)
+
+type fakeCloudEventsTransport struct {
+	mu      sync.Mutex
+	errs    []error // returned by successive calls to Send, after which Send succeeds
+	calls   int
+	batches [][]json.RawMessage
+}
+
+func (f *fakeCloudEventsTransport) Send(_ context.Context, events []json.RawMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls++
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		if err != nil {
+			return err
+		}
+	}
+	f.batches = append(f.batches, events)
+	return nil
+}
+
+func (f *fakeCloudEventsTransport) getCalls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func (f *fakeCloudEventsTransport) getBatches() [][]json.RawMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.batches
+}
+
+func TestCloudEventsSink(t *testing.T) {
+	now := time.Date(2024, 10, 10, 13, 55, 36, 0, time.UTC)
+
+	wantEvent := func(id, data string) json.RawMessage {
+		return json.RawMessage(`{"specversion":"1.0","id":"` + id + `","source":"some-source",` +
+			`"type":"dev.pinniped.supervisor.accesslog.v1","time":"2024-10-10T13:55:36Z",` +
+			`"datacontenttype":"application/json","data":` + data + `}`)
+	}
+
+	newSubject := func(t *testing.T, transport CloudEventsTransport, clock clock.WithTicker, maxBatchSize, maxRetries int) (*cloudEventsSink, *bytes.Buffer) {
+		t.Helper()
+		var log bytes.Buffer
+		nextID := 0
+		subject := newCloudEventsSink(
+			CloudEventsConfig{
+				Source:        "some-source",
+				Transport:     transport,
+				MaxBatchSize:  maxBatchSize,
+				FlushInterval: time.Minute,
+				MaxRetries:    maxRetries,
+			},
+			clock,
+			func() string { nextID++; return fmt.Sprintf("id-%d", nextID) },
+			time.Millisecond,
+			plog.TestLogger(t, &log),
+		)
+		return subject, &log
+	}
+
+	t.Run("full batches are delivered immediately, and the rest on close", func(t *testing.T) {
+		transport := &fakeCloudEventsTransport{}
+		subject, log := newSubject(t, transport, clocktesting.NewFakeClock(now), 2, 3)
+
+		for i := range 3 {
+			n, err := fmt.Fprintf(subject, `{"status":%d}`+"\n", 200+i)
+			require.NoError(t, err)
+			require.Equal(t, 15, n)
+		}
+
+		require.Eventually(t, func() bool { return len(transport.getBatches()) >= 1 }, 10*time.Second, 10*time.Millisecond)
+		require.NoError(t, subject.Close())
+
+		require.Equal(t, [][]json.RawMessage{
+			{wantEvent("id-1", `{"status":200}`), wantEvent("id-2", `{"status":201}`)},
+			{wantEvent("id-3", `{"status":202}`)},
+		}, transport.getBatches())
+		require.Empty(t, log.String())
+
+		_, err := subject.Write([]byte(`{"status":200}`))
+		require.EqualError(t, err, "the CloudEvents sink is closed")
+		require.NoError(t, subject.Close())
+	})
+
+	t.Run("partial batches are delivered after the flush interval", func(t *testing.T) {
+		transport := &fakeCloudEventsTransport{}
+		fakeClock := clocktesting.NewFakeClock(now)
+		subject, _ := newSubject(t, transport, fakeClock, 100, 3)
+		t.Cleanup(func() { _ = subject.Close() })
+
+		_, err := subject.Write([]byte(`not json` + "\n"))
+		require.NoError(t, err)
+
+		require.Eventually(t, fakeClock.HasWaiters, 10*time.Second, 10*time.Millisecond)
+		fakeClock.Step(time.Minute)
+
+		require.Eventually(t, func() bool { return len(transport.getBatches()) == 1 }, 10*time.Second, 10*time.Millisecond)
+		require.Equal(t, [][]json.RawMessage{{wantEvent("id-1", `"not json"`)}}, transport.getBatches())
+	})
+
+	t.Run("failed deliveries are retried", func(t *testing.T) {
+		transport := &fakeCloudEventsTransport{errs: []error{errors.New("some error"), errors.New("some error")}}
+		subject, log := newSubject(t, transport, clock.RealClock{}, 1, 3)
+
+		_, err := subject.Write([]byte(`{"status":200}` + "\n"))
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool { return len(transport.getBatches()) == 1 }, 10*time.Second, 10*time.Millisecond)
+		require.NoError(t, subject.Close())
+
+		require.Equal(t, 3, transport.getCalls())
+		require.NotContains(t, log.String(), "could not deliver")
+	})
+
+	t.Run("events are logged when they cannot be delivered after all retries", func(t *testing.T) {
+		transport := &fakeCloudEventsTransport{errs: []error{errors.New("some error"), errors.New("some error"), errors.New("some error")}}
+		subject, log := newSubject(t, transport, clock.RealClock{}, 1, 2)
+
+		_, err := subject.Write([]byte(`{"status":200}` + "\n"))
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool { return transport.getCalls() == 3 }, 10*time.Second, 10*time.Millisecond)
+		require.NoError(t, subject.Close())
+
+		require.Empty(t, transport.getBatches())
+		require.Contains(t, log.String(), "could not deliver access log CloudEvents")
+		require.Contains(t, log.String(), "some error")
+		require.Contains(t, log.String(), `id-1`)
+	})
+}
+
+func TestCloudEventsHTTPTransport(t *testing.T) {
+	events := []json.RawMessage{json.RawMessage(`{"id":"id-1"}`), json.RawMessage(`{"id":"id-2"}`)}
+
+	tests := []struct {
+		name       string
+		statusCode int
+		wantErr    string
+	}{
+		{
+			name:       "success",
+			statusCode: http.StatusAccepted,
+		},
+		{
+			name:       "error status",
+			statusCode: http.StatusInternalServerError,
+			wantErr:    "unexpected response status 500 Internal Server Error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodPost, r.Method)
+				require.Equal(t, "/events", r.URL.Path)
+				require.Equal(t, "application/cloudevents-batch+json", r.Header.Get("Content-Type"))
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				require.JSONEq(t, `[{"id":"id-1"},{"id":"id-2"}]`, string(body))
+				w.WriteHeader(tt.statusCode)
+			}))
+			t.Cleanup(server.Close)
+
+			err := NewCloudEventsHTTPTransport(server.URL+"/events", server.Client()).Send(context.Background(), events)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCloudEventsKafkaTransport(t *testing.T) {
+	events := []json.RawMessage{json.RawMessage(`{"id":"id-1"}`), json.RawMessage(`{"id":"id-2"}`)}
+
+	tests := []struct {
+		name       string
+		statusCode int
+		response   string
+		wantErr    string
+	}{
+		{
+			name:       "success",
+			statusCode: http.StatusOK,
+			response:   `{"offsets":[{"partition":0,"offset":10,"error_code":null,"error":null},{"partition":0,"offset":11}]}`,
+		},
+		{
+			name:       "some records could not be produced",
+			statusCode: http.StatusOK,
+			response:   `{"offsets":[{"partition":0,"offset":10},{"error_code":2,"error":"some kafka error"}]}`,
+			wantErr:    "the Kafka REST Proxy could not produce all records: some kafka error",
+		},
+		{
+			name:       "invalid response",
+			statusCode: http.StatusOK,
+			response:   `not json`,
+			wantErr:    "could not decode response of Kafka REST Proxy: invalid character 'o' in literal null (expecting 'u')",
+		},
+		{
+			name:       "error status",
+			statusCode: http.StatusNotFound,
+			response:   `{"error_code":40401,"message":"Topic not found."}`,
+			wantErr:    "unexpected response status 404 Not Found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodPost, r.Method)
+				require.Equal(t, "/kafka/topics/access-logs", r.URL.Path)
+				require.Equal(t, "application/vnd.kafka.json.v2+json", r.Header.Get("Content-Type"))
+				require.Equal(t, "application/vnd.kafka.v2+json", r.Header.Get("Accept"))
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				require.JSONEq(t, `{"records":[{"value":{"id":"id-1"}},{"value":{"id":"id-2"}}]}`, string(body))
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			t.Cleanup(server.Close)
+
+			err := NewCloudEventsKafkaTransport(server.URL+"/kafka/", "access-logs", server.Client()).Send(context.Background(), events)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package accesslog
+
+import (
+	"io"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// NewFileSink returns a sink which appends to the file at path, and which rotates the file once it grows beyond
+// maxSizeMegabytes. The rotated files are deleted when there are more than maxBackups of them, or when they are
+// older than maxAgeDays. A zero maxBackups or maxAgeDays disables that limit.
+func NewFileSink(path string, maxSizeMegabytes, maxBackups, maxAgeDays int) io.WriteCloser {
+	return &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    maxSizeMegabytes,
+		MaxBackups: maxBackups,
+		MaxAge:     maxAgeDays,
+	}
+}
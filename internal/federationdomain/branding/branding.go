@@ -0,0 +1,141 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package branding defines the customizable branding of the web pages served by a FederationDomain.
+package branding
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// Paths of the branding assets, relative to the issuer path of the FederationDomain.
+const (
+	StylesheetPath = "/branding/style.css"
+	LogoPath       = "/branding/logo"
+)
+
+// Keys of the ConfigMap which holds the branding.
+const (
+	LogoKey                               = "logo"
+	PrimaryColorKey                       = "primaryColor"
+	BackgroundColorKey                    = "backgroundColor"
+	CustomCSSKey                          = "customCSS"
+	FooterTextKey                         = "footerText"
+	IncorrectUsernameOrPasswordMessageKey = "incorrectUsernameOrPasswordMessage"
+	InternalErrorMessageKey               = "internalErrorMessage"
+	LoginFailedMessageKey                 = "loginFailedMessage"
+
+	maxLogoBytes = 256 * 1024
+)
+
+//nolint:gochecknoglobals // These are effectively constants.
+var (
+	hexColorRegexp = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+
+	allowedLogoContentTypes = sets.New("image/png", "image/jpeg", "image/gif", "image/webp")
+)
+
+// Branding holds the customizations of the web pages served by a FederationDomain.
+// Empty fields mean that the default should be used.
+type Branding struct {
+	Logo            []byte
+	LogoContentType string
+
+	PrimaryColor    string
+	BackgroundColor string
+	CustomCSS       string
+
+	FooterText string
+
+	IncorrectUsernameOrPasswordMessage string
+	InternalErrorMessage               string
+	LoginFailedMessage                 string
+}
+
+// PageBranding is the part of the Branding which is rendered into the HTML of a page.
+type PageBranding struct {
+	StylesheetPath     string
+	LogoPath           string
+	FooterText         string
+	LoginFailedMessage string
+}
+
+// FromConfigMap validates the contents of the ConfigMap and returns the Branding which it describes.
+func FromConfigMap(configMap *corev1.ConfigMap) (*Branding, error) {
+	b := &Branding{
+		PrimaryColor:                       strings.TrimSpace(configMap.Data[PrimaryColorKey]),
+		BackgroundColor:                    strings.TrimSpace(configMap.Data[BackgroundColorKey]),
+		CustomCSS:                          configMap.Data[CustomCSSKey],
+		FooterText:                         strings.TrimSpace(configMap.Data[FooterTextKey]),
+		IncorrectUsernameOrPasswordMessage: strings.TrimSpace(configMap.Data[IncorrectUsernameOrPasswordMessageKey]),
+		InternalErrorMessage:               strings.TrimSpace(configMap.Data[InternalErrorMessageKey]),
+		LoginFailedMessage:                 strings.TrimSpace(configMap.Data[LoginFailedMessageKey]),
+	}
+
+	for _, key := range []string{PrimaryColorKey, BackgroundColorKey} {
+		if color := strings.TrimSpace(configMap.Data[key]); color != "" && !hexColorRegexp.MatchString(color) {
+			return nil, fmt.Errorf("key %q must be a color in hexadecimal notation, e.g. #1a73e8", key)
+		}
+	}
+
+	if logo, ok := configMap.BinaryData[LogoKey]; ok {
+		if len(logo) > maxLogoBytes {
+			return nil, fmt.Errorf("binaryData key %q must be at most %d bytes", LogoKey, maxLogoBytes)
+		}
+		contentType := http.DetectContentType(logo)
+		if !allowedLogoContentTypes.Has(contentType) {
+			return nil, fmt.Errorf("binaryData key %q must be a PNG, JPEG, GIF, or WebP image, but was detected as %q", LogoKey, contentType)
+		}
+		b.Logo, b.LogoContentType = logo, contentType
+	}
+
+	return b, nil
+}
+
+// HasStylesheet returns true when the Branding customizes any styles.
+func (b *Branding) HasStylesheet() bool {
+	return b != nil && (b.PrimaryColor != "" || b.BackgroundColor != "" || b.CustomCSS != "")
+}
+
+// Stylesheet returns the CSS which applies the customized styles. The custom CSS comes last so that it
+// can override anything.
+func (b *Branding) Stylesheet() string {
+	if !b.HasStylesheet() {
+		return ""
+	}
+	var css strings.Builder
+	if b.BackgroundColor != "" {
+		fmt.Fprintf(&css, "body { background: %s; }\n", b.BackgroundColor)
+	}
+	if b.PrimaryColor != "" {
+		fmt.Fprintf(&css, "h1, a { color: %s; }\n", b.PrimaryColor)
+		fmt.Fprintf(&css, `.form-field input[type="submit"], .form-field input[type="submit"]:focus, .form-field input[type="submit"]:hover { background-color: %s; }`+"\n", b.PrimaryColor)
+	}
+	css.WriteString(b.CustomCSS)
+	return css.String()
+}
+
+// ForPage returns the PageBranding for a page served by a FederationDomain with the given issuer path,
+// or nil when b is nil.
+func (b *Branding) ForPage(issuerPath string) *PageBranding {
+	if b == nil {
+		return nil
+	}
+	page := &PageBranding{
+		FooterText:         b.FooterText,
+		LoginFailedMessage: b.LoginFailedMessage,
+	}
+	if b.HasStylesheet() {
+		page.StylesheetPath = issuerPath + StylesheetPath
+	}
+	if len(b.Logo) > 0 {
+		page.LogoPath = issuerPath + LogoPath
+	}
+	return page
+}
@@ -0,0 +1,48 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package branding
+
+import (
+	"net/http"
+
+	"go.pinniped.dev/internal/httputil/securityheader"
+)
+
+// NewStylesheetHandler returns an http.Handler that serves the custom stylesheet for a specific issuer.
+func NewStylesheetHandler(issuerName string, provider DynamicBrandingProvider) http.Handler {
+	return newAssetHandler(issuerName, provider, func(b *Branding) ([]byte, string) {
+		return []byte(b.Stylesheet()), "text/css; charset=utf-8"
+	})
+}
+
+// NewLogoHandler returns an http.Handler that serves the custom logo for a specific issuer.
+func NewLogoHandler(issuerName string, provider DynamicBrandingProvider) http.Handler {
+	return newAssetHandler(issuerName, provider, func(b *Branding) ([]byte, string) {
+		return b.Logo, b.LogoContentType
+	})
+}
+
+func newAssetHandler(issuerName string, provider DynamicBrandingProvider, asset func(b *Branding) ([]byte, string)) http.Handler {
+	return securityheader.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, `Method not allowed (try GET)`, http.StatusMethodNotAllowed)
+			return
+		}
+
+		b := provider.GetBranding(issuerName)
+		if b == nil {
+			http.Error(w, "branding not found for requested issuer", http.StatusNotFound)
+			return
+		}
+
+		content, contentType := asset(b)
+		if len(content) == 0 {
+			http.Error(w, "branding not found for requested issuer", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write(content)
+	}))
+}
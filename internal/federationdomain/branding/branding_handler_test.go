@@ -0,0 +1,101 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package branding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go.pinniped.dev/internal/testutil"
+)
+
+func TestBrandingEndpoints(t *testing.T) {
+	const issuer = "https://some-issuer.com/some/path"
+
+	provider := NewDynamicBrandingProvider()
+	provider.SetIssuerToBrandingMap(map[string]*Branding{
+		issuer: {
+			CustomCSS:       "h1 { color: red; }",
+			Logo:            testPNG,
+			LogoContentType: "image/png",
+		},
+		"https://other-issuer.com": {
+			FooterText: "no stylesheet or logo",
+		},
+	})
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		method  string
+
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "stylesheet happy path",
+			handler:         NewStylesheetHandler(issuer, provider),
+			method:          http.MethodGet,
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/css; charset=utf-8",
+			wantBody:        "h1 { color: red; }",
+		},
+		{
+			name:            "logo happy path",
+			handler:         NewLogoHandler(issuer, provider),
+			method:          http.MethodGet,
+			wantStatus:      http.StatusOK,
+			wantContentType: "image/png",
+			wantBody:        string(testPNG),
+		},
+		{
+			name:            "bad method",
+			handler:         NewStylesheetHandler(issuer, provider),
+			method:          http.MethodPost,
+			wantStatus:      http.StatusMethodNotAllowed,
+			wantContentType: "text/plain; charset=utf-8",
+			wantBody:        "Method not allowed (try GET)\n",
+		},
+		{
+			name:            "issuer without branding",
+			handler:         NewLogoHandler("https://unknown-issuer.com", provider),
+			method:          http.MethodGet,
+			wantStatus:      http.StatusNotFound,
+			wantContentType: "text/plain; charset=utf-8",
+			wantBody:        "branding not found for requested issuer\n",
+		},
+		{
+			name:            "issuer with branding but without a stylesheet",
+			handler:         NewStylesheetHandler("https://other-issuer.com", provider),
+			method:          http.MethodGet,
+			wantStatus:      http.StatusNotFound,
+			wantContentType: "text/plain; charset=utf-8",
+			wantBody:        "branding not found for requested issuer\n",
+		},
+		{
+			name:            "issuer with branding but without a logo",
+			handler:         NewLogoHandler("https://other-issuer.com", provider),
+			method:          http.MethodGet,
+			wantStatus:      http.StatusNotFound,
+			wantContentType: "text/plain; charset=utf-8",
+			wantBody:        "branding not found for requested issuer\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/some/path/branding/asset", nil)
+			rsp := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rsp, req)
+
+			require.Equal(t, tt.wantStatus, rsp.Code)
+			testutil.RequireEqualContentType(t, rsp.Header().Get("Content-Type"), tt.wantContentType)
+			require.Equal(t, tt.wantBody, rsp.Body.String())
+			testutil.RequireSecurityHeadersWithoutCustomCSPs(t, rsp)
+		})
+	}
+}
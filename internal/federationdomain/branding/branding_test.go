@@ -0,0 +1,128 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package branding
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// The first bytes of a PNG image, which are enough for content type detection.
+var testPNG = []byte("\x89PNG\x0D\x0A\x1A\x0A\x00\x00\x00\x0DIHDR")
+
+func TestFromConfigMap(t *testing.T) {
+	tests := []struct {
+		name         string
+		configMap    *corev1.ConfigMap
+		wantBranding *Branding
+		wantErr      string
+	}{
+		{
+			name:         "empty ConfigMap",
+			configMap:    &corev1.ConfigMap{},
+			wantBranding: &Branding{},
+		},
+		{
+			name: "all keys",
+			configMap: &corev1.ConfigMap{
+				Data: map[string]string{
+					"primaryColor":                       " #1a73e8 ",
+					"backgroundColor":                    "#FFF",
+					"customCSS":                          "h1 { font-size: 30px; }\n",
+					"footerText":                         " Acme Corp ",
+					"incorrectUsernameOrPasswordMessage": "Wrong!",
+					"internalErrorMessage":               "Call the help desk.",
+					"loginFailedMessage":                 "Please try again later.",
+				},
+				BinaryData: map[string][]byte{
+					"logo": testPNG,
+				},
+			},
+			wantBranding: &Branding{
+				Logo:                               testPNG,
+				LogoContentType:                    "image/png",
+				PrimaryColor:                       "#1a73e8",
+				BackgroundColor:                    "#FFF",
+				CustomCSS:                          "h1 { font-size: 30px; }\n",
+				FooterText:                         "Acme Corp",
+				IncorrectUsernameOrPasswordMessage: "Wrong!",
+				InternalErrorMessage:               "Call the help desk.",
+				LoginFailedMessage:                 "Please try again later.",
+			},
+		},
+		{
+			name: "invalid primary color",
+			configMap: &corev1.ConfigMap{Data: map[string]string{
+				"primaryColor":    "blue; } body { display: none",
+				"backgroundColor": "also-invalid",
+			}},
+			wantErr: `key "primaryColor" must be a color in hexadecimal notation, e.g. #1a73e8`,
+		},
+		{
+			name:      "invalid background color",
+			configMap: &corev1.ConfigMap{Data: map[string]string{"backgroundColor": "#12345"}},
+			wantErr:   `key "backgroundColor" must be a color in hexadecimal notation, e.g. #1a73e8`,
+		},
+		{
+			name:      "logo which is not an image",
+			configMap: &corev1.ConfigMap{BinaryData: map[string][]byte{"logo": []byte("<svg></svg>")}},
+			wantErr:   `binaryData key "logo" must be a PNG, JPEG, GIF, or WebP image, but was detected as "text/plain; charset=utf-8"`,
+		},
+		{
+			name:      "logo which is too large",
+			configMap: &corev1.ConfigMap{BinaryData: map[string][]byte{"logo": append(testPNG, bytes.Repeat([]byte{0}, maxLogoBytes)...)}},
+			wantErr:   `binaryData key "logo" must be at most 262144 bytes`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := FromConfigMap(tt.configMap)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				require.Nil(t, b)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantBranding, b)
+		})
+	}
+}
+
+func TestStylesheet(t *testing.T) {
+	require.False(t, (*Branding)(nil).HasStylesheet())
+	require.Empty(t, (*Branding)(nil).Stylesheet())
+	require.False(t, (&Branding{FooterText: "some footer"}).HasStylesheet())
+	require.Empty(t, (&Branding{FooterText: "some footer"}).Stylesheet())
+
+	require.Equal(t, "h1 { color: red; }", (&Branding{CustomCSS: "h1 { color: red; }"}).Stylesheet())
+
+	require.Equal(t,
+		"body { background: #eee; }\n"+
+			"h1, a { color: #1a73e8; }\n"+
+			`.form-field input[type="submit"], .form-field input[type="submit"]:focus, .form-field input[type="submit"]:hover { background-color: #1a73e8; }`+"\n"+
+			"h1 { color: red; }",
+		(&Branding{PrimaryColor: "#1a73e8", BackgroundColor: "#eee", CustomCSS: "h1 { color: red; }"}).Stylesheet(),
+	)
+}
+
+func TestForPage(t *testing.T) {
+	require.Nil(t, (*Branding)(nil).ForPage("/some/issuer"))
+
+	require.Equal(t, &PageBranding{
+		FooterText:         "some footer",
+		LoginFailedMessage: "some message",
+	}, (&Branding{FooterText: "some footer", LoginFailedMessage: "some message"}).ForPage("/some/issuer"))
+
+	require.Equal(t, &PageBranding{
+		StylesheetPath: "/some/issuer/branding/style.css",
+		LogoPath:       "/some/issuer/branding/logo",
+	}, (&Branding{PrimaryColor: "#fff", Logo: testPNG}).ForPage("/some/issuer"))
+
+	require.Equal(t, &PageBranding{
+		StylesheetPath: "/branding/style.css",
+	}, (&Branding{CustomCSS: "h1 {}"}).ForPage(""))
+}
@@ -7,17 +7,22 @@ package clientregistry
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	coreosoidc "github.com/coreos/go-oidc/v3/oidc"
+	"github.com/go-jose/go-jose/v3"
 	"github.com/ory/fosite"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 
 	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
 	oidcapi "go.pinniped.dev/generated/latest/apis/supervisor/oidc"
 	supervisorclient "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/typed/config/v1alpha1"
+	"go.pinniped.dev/internal/federationdomain/jarm"
 	"go.pinniped.dev/internal/federationdomain/oidcclientvalidator"
 	"go.pinniped.dev/internal/oidcclientsecretstorage"
 	"go.pinniped.dev/internal/plog"
@@ -33,12 +38,98 @@ type Client struct {
 	// via RFC8693 token exchange. When zero, the ID token lifetime will be determined by the defaults
 	// for the FederationDomain.
 	IDTokenLifetimeConfiguration time.Duration
+
+	// When true, all requests which this client makes to the token endpoint must have a DPoP proof.
+	// This is unexported so that it is not serialized into the session storage along with the client. The token
+	// endpoint always checks it on the client which was looked up for the current request.
+	requireDPoP bool
+
+	// When true, this client may request JWT Secured Authorization Responses using the JWT response modes.
+	// This is unexported for the same reason as requireDPoP.
+	allowJWTSecuredAuthorizationResponses bool
+
+	// The groups which a user must belong to at least one of to be granted each scope which has a policy.
+	// This is unexported for the same reason as requireDPoP.
+	scopePolicies map[string][]string
+
+	// The ACR values of which the upstream login of a user must assert one. When empty, any login is accepted.
+	// This is unexported for the same reason as requireDPoP.
+	requiredACRValues []string
+
+	// When true, the user must approve this client on the consent page before it receives an authorization code.
+	// This is unexported for the same reason as requireDPoP.
+	requireConsent bool
+
+	// The downstream usernames of the actors which may act on behalf of the users of this client during token
+	// exchanges. When nil, token exchanges by this client may not include an actor.
+	// This is unexported for the same reason as requireDPoP.
+	allowedActors []string
 }
 
 func (c *Client) GetIDTokenLifetimeConfiguration() time.Duration {
 	return c.IDTokenLifetimeConfiguration
 }
 
+// RequiresDPoP returns true when all requests which this client makes to the token endpoint must have a DPoP proof.
+func (c *Client) RequiresDPoP() bool {
+	return c.requireDPoP
+}
+
+// RequiresConsent returns true when the user must approve this client on the consent page during a login.
+func (c *Client) RequiresConsent() bool {
+	return c.requireConsent
+}
+
+// CheckScopePolicies returns an error when one of the granted scopes has a policy which requires the user to belong
+// to one of some groups, and the given downstream groups of the user do not include any of those groups.
+func (c *Client) CheckScopePolicies(grantedScopes []string, groups []string) error {
+	for _, scope := range grantedScopes {
+		requiredGroups, ok := c.scopePolicies[scope]
+		if !ok {
+			continue
+		}
+		if !slices.ContainsFunc(groups, func(group string) bool { return slices.Contains(requiredGroups, group) }) {
+			return fmt.Errorf("the user does not belong to any of the groups which are required to be granted the %q scope by client %q",
+				scope, c.GetID())
+		}
+	}
+	return nil
+}
+
+// RequiredACRValues returns the ACR values of which the upstream login of a user must assert one to log in to this
+// client. It returns nil when any login is accepted.
+func (c *Client) RequiredACRValues() []string {
+	return c.requiredACRValues
+}
+
+// CheckACRPolicy returns an error when this client requires the upstream login of a user to assert one of some
+// ACR values, and the given ACR value of the login is not one of them.
+func (c *Client) CheckACRPolicy(acr string) error {
+	if len(c.requiredACRValues) == 0 {
+		return nil
+	}
+	if acr == "" {
+		return fmt.Errorf("the upstream login did not assert any of the ACR values which are required by client %q", c.GetID())
+	}
+	if slices.Contains(c.requiredACRValues, acr) {
+		return nil
+	}
+	return fmt.Errorf("the upstream login asserted the ACR value %q, which is not one of the ACR values required by client %q",
+		acr, c.GetID())
+}
+
+// CheckActorPolicy returns an error when the actor with the given downstream username may not act on behalf of the
+// users of this client during a token exchange.
+func (c *Client) CheckActorPolicy(actorUsername string) error {
+	if c.allowedActors == nil {
+		return fmt.Errorf("client %q does not allow delegation", c.GetID())
+	}
+	if !slices.Contains(c.allowedActors, actorUsername) {
+		return fmt.Errorf("the actor %q may not act on behalf of the users of client %q", actorUsername, c.GetID())
+	}
+	return nil
+}
+
 // Client implements the base, OIDC, and response_mode client interfaces of Fosite.
 var (
 	_ fosite.Client              = (*Client)(nil)
@@ -51,14 +142,19 @@ func (c *Client) GetResponseModes() []fosite.ResponseModeType {
 		// The pinniped-cli client supports "" (unspecified), "query", and "form_post" response modes.
 		return []fosite.ResponseModeType{fosite.ResponseModeDefault, fosite.ResponseModeQuery, fosite.ResponseModeFormPost}
 	}
-	// For now, all other clients support only "" (unspecified) and "query" response modes.
-	return []fosite.ResponseModeType{fosite.ResponseModeDefault, fosite.ResponseModeQuery}
+	// All other clients support "" (unspecified) and "query" response modes, and optionally the JWT response modes.
+	responseModes := []fosite.ResponseModeType{fosite.ResponseModeDefault, fosite.ResponseModeQuery}
+	if c.allowJWTSecuredAuthorizationResponses {
+		responseModes = append(responseModes, jarm.ResponseModes()...)
+	}
+	return responseModes
 }
 
 // ClientManager is a fosite.ClientManager with a statically-defined client and with dynamically-defined clients.
 type ClientManager struct {
 	oidcClientsClient supervisorclient.OIDCClientInterface
 	storage           *oidcclientsecretstorage.OIDCClientSecretStorage
+	secrets           corev1client.SecretInterface
 	minBcryptCost     int
 }
 
@@ -67,11 +163,13 @@ var _ fosite.ClientManager = (*ClientManager)(nil)
 func NewClientManager(
 	oidcClientsClient supervisorclient.OIDCClientInterface,
 	storage *oidcclientsecretstorage.OIDCClientSecretStorage,
+	secrets corev1client.SecretInterface,
 	minBcryptCost int,
 ) *ClientManager {
 	return &ClientManager{
 		oidcClientsClient: oidcClientsClient,
 		storage:           storage,
+		secrets:           secrets,
 		minBcryptCost:     minBcryptCost,
 	}
 }
@@ -112,22 +210,37 @@ func (m *ClientManager) GetClient(ctx context.Context, id string) (fosite.Client
 		return nil, fmt.Errorf("failed to get storage secret for client %q", id)
 	}
 
-	// Check if the OIDCClient and its corresponding Secret are valid.
-	valid, conditions, clientSecrets := oidcclientvalidator.Validate(oidcClient, storageSecret, m.minBcryptCost)
+	// Try to find the Secret which holds the public keys of a client which uses private_key_jwt client authentication.
+	var privateKeyJWTSecret *corev1.Secret
+	if oidcClient.Spec.PrivateKeyJWT != nil {
+		privateKeyJWTSecret, err = m.secrets.Get(ctx, oidcClient.Spec.PrivateKeyJWT.SecretName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			privateKeyJWTSecret = nil
+		} else if err != nil {
+			// Log the error so an admin can see why the lookup failed at the time of the request.
+			plog.Error("OIDC client lookup GetClient() failed to get private key JWT secret for OIDCClient", err, "clientID", id)
+			return nil, fmt.Errorf("failed to get private key JWT secret for client %q", id)
+		}
+	}
+
+	// Check if the OIDCClient and its corresponding Secrets are valid.
+	valid, conditions, clientSecrets, publicKeys := oidcclientvalidator.ValidateWithPrivateKeyJWT(
+		oidcClient, storageSecret, privateKeyJWTSecret, m.minBcryptCost)
 	if !valid {
 		// Log the conditions so an admin can see exactly what was invalid at the time of the request.
 		plog.Debug("OIDC client lookup GetClient() found an invalid client", "clientID", id, "conditions", conditions)
 		return nil, fmt.Errorf("client %q exists but is invalid or not ready", id)
 	}
 
-	// Everything is valid, so return the client. Note that it has at least one client secret to be considered valid.
-	return oidcClientCRToFositeClient(oidcClient, clientSecrets), nil
+	// Everything is valid, so return the client. Note that it has at least one client secret or public key to be
+	// considered valid.
+	return oidcClientCRToFositeClient(oidcClient, clientSecrets, publicKeys), nil
 }
 
 // ClientAssertionJWTValid returns an error if the JTI is
 // known or the DB check failed and nil if the JTI is not known.
 //
-// This functionality is not supported by the ClientManager.
+// This functionality is not supported by the ClientManager. See the clientassertion storage instead.
 func (*ClientManager) ClientAssertionJWTValid(_ctx context.Context, _jti string) error {
 	return fmt.Errorf("not implemented")
 }
@@ -137,7 +250,7 @@ func (*ClientManager) ClientAssertionJWTValid(_ctx context.Context, _jti string)
 // up any existing JTIs that have expired as those tokens can
 // not be replayed due to the expiry.
 //
-// This functionality is not supported by the ClientManager.
+// This functionality is not supported by the ClientManager. See the clientassertion storage instead.
 func (*ClientManager) SetClientAssertionJWT(_ctx context.Context, _jti string, _exp time.Time) error {
 	return fmt.Errorf("not implemented")
 }
@@ -179,7 +292,11 @@ func PinnipedCLI() *Client {
 	}
 }
 
-func oidcClientCRToFositeClient(oidcClient *supervisorconfigv1alpha1.OIDCClient, clientSecrets []string) *Client {
+func oidcClientCRToFositeClient(
+	oidcClient *supervisorconfigv1alpha1.OIDCClient,
+	clientSecrets []string,
+	publicKeys *jose.JSONWebKeySet,
+) *Client {
 	// Allow the user to optionally override the default timeouts for these clients.
 	idTokenLifetimeOverrideInSeconds := oidcClient.Spec.TokenLifetimes.IDTokenSeconds
 	var idTokenLifetime time.Duration
@@ -188,6 +305,14 @@ func oidcClientCRToFositeClient(oidcClient *supervisorconfigv1alpha1.OIDCClient,
 		idTokenLifetime = time.Duration(*(idTokenLifetimeOverrideInSeconds)) * time.Second
 	}
 
+	// Clients authenticate with a client secret, unless they were configured to sign client assertions instead.
+	tokenEndpointAuthMethod := "client_secret_basic"
+	tokenEndpointAuthSigningAlgorithm := coreosoidc.RS256
+	if publicKeys != nil {
+		tokenEndpointAuthMethod = "private_key_jwt"
+		tokenEndpointAuthSigningAlgorithm = oidcclientvalidator.PrivateKeyJWTSigningAlgorithm
+	}
+
 	return &Client{
 		DefaultOpenIDConnectClient: fosite.DefaultOpenIDConnectClient{
 			DefaultClient: &fosite.DefaultClient{
@@ -201,20 +326,45 @@ func oidcClientCRToFositeClient(oidcClient *supervisorconfigv1alpha1.OIDCClient,
 				GrantTypes:     grantTypesToArguments(oidcClient.Spec.AllowedGrantTypes),
 				ResponseTypes:  []string{"code"},
 				Scopes:         scopesToArguments(oidcClient.Spec.AllowedScopes),
-				Audience:       nil,
+				Audience:       resourcesToStrings(oidcClient.Spec.AllowedResources), // the RFC 8707 resources which may be requested
 				Public:         false,
 			},
 			RequestURIs:                       nil,
-			JSONWebKeys:                       nil,
+			JSONWebKeys:                       publicKeys, // the public keys which verify private_key_jwt client assertions
 			JSONWebKeysURI:                    "",
 			RequestObjectSigningAlgorithm:     "",
-			TokenEndpointAuthSigningAlgorithm: coreosoidc.RS256,
-			TokenEndpointAuthMethod:           "client_secret_basic",
+			TokenEndpointAuthSigningAlgorithm: tokenEndpointAuthSigningAlgorithm,
+			TokenEndpointAuthMethod:           tokenEndpointAuthMethod,
 		},
-		IDTokenLifetimeConfiguration: idTokenLifetime,
+		IDTokenLifetimeConfiguration:          idTokenLifetime,
+		requireDPoP:                           oidcClient.Spec.RequireDPoP,
+		allowJWTSecuredAuthorizationResponses: oidcClient.Spec.AllowJWTSecuredAuthorizationResponses,
+		scopePolicies:                         scopePoliciesToMap(oidcClient.Spec.ScopePolicies),
+		requireConsent:                        oidcClient.Spec.RequireConsent,
+		requiredACRValues:                     oidcClient.Spec.RequiredACRValues,
+		allowedActors:                         allowedActors(oidcClient.Spec.Delegation),
 	}
 }
 
+func allowedActors(delegation *supervisorconfigv1alpha1.OIDCClientDelegation) []string {
+	if delegation == nil {
+		return nil
+	}
+	// Never return nil for a configured delegation, since nil means that delegation is not allowed.
+	return append([]string{}, delegation.AllowedActors...)
+}
+
+func scopePoliciesToMap(policies []supervisorconfigv1alpha1.OIDCClientScopePolicy) map[string][]string {
+	if len(policies) == 0 {
+		return nil
+	}
+	m := make(map[string][]string, len(policies))
+	for _, policy := range policies {
+		m[string(policy.Scope)] = policy.RequiredGroups
+	}
+	return m
+}
+
 func scopesToArguments(scopes []supervisorconfigv1alpha1.Scope) fosite.Arguments {
 	a := make(fosite.Arguments, len(scopes))
 	for i, scope := range scopes {
@@ -239,6 +389,17 @@ func redirectURIsToStrings(uris []supervisorconfigv1alpha1.RedirectURI) []string
 	return s
 }
 
+func resourcesToStrings(resources []supervisorconfigv1alpha1.ResourceURI) []string {
+	if len(resources) == 0 {
+		return nil
+	}
+	s := make([]string, len(resources))
+	for i, resource := range resources {
+		s[i] = string(resource)
+	}
+	return s
+}
+
 func stringSliceToByteSlices(s []string) [][]byte {
 	b := make([][]byte, len(s))
 	for i, str := range s {
@@ -5,7 +5,12 @@ package clientregistry
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"testing"
 	"time"
@@ -36,6 +41,18 @@ func TestClientManager(t *testing.T) {
 		testUID       = "test-uid-123"
 	)
 
+	privateKeyJWTKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	privateKeyJWTPublicKeyDER, err := x509.MarshalPKIXPublicKey(privateKeyJWTKey.Public())
+	require.NoError(t, err)
+	privateKeyJWTSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: "test-client-public-keys"},
+		Type:       "secrets.pinniped.dev/oidc-client-public-keys",
+		Data: map[string][]byte{
+			"publicKeys": pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: privateKeyJWTPublicKeyDER}),
+		},
+	}
+
 	tests := []struct {
 		name                   string
 		secrets                []*corev1.Secret
@@ -248,6 +265,235 @@ func TestClientManager(t *testing.T) {
 					[]string{"http://localhost:8080"},
 					4242*time.Second,
 				)
+				require.False(t, c.RequiresDPoP())
+				require.False(t, c.RequiresConsent())
+			},
+		},
+		{
+			name: "find a valid dynamic client which requires DPoP",
+			oidcClients: []*supervisorconfigv1alpha1.OIDCClient{
+				{
+					ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
+					Spec: supervisorconfigv1alpha1.OIDCClientSpec{
+						AllowedGrantTypes:   []supervisorconfigv1alpha1.GrantType{"authorization_code", "refresh_token"},
+						AllowedScopes:       []supervisorconfigv1alpha1.Scope{"openid", "offline_access", "username", "groups"},
+						AllowedRedirectURIs: []supervisorconfigv1alpha1.RedirectURI{"http://localhost:8080"},
+						RequireDPoP:         true,
+					},
+				},
+			},
+			secrets: []*corev1.Secret{
+				testutil.OIDCClientSecretStorageSecretForUID(t, testNamespace, testUID, []string{testutil.HashedPassword1AtSupervisorMinCost}),
+			},
+			run: func(t *testing.T, subject *ClientManager) {
+				got, err := subject.GetClient(ctx, testName)
+				require.NoError(t, err)
+				require.IsType(t, &Client{}, got)
+				c := got.(*Client)
+
+				requireDynamicOIDCClient(t, c,
+					testName,
+					[]string{testutil.HashedPassword1AtSupervisorMinCost},
+					fosite.Arguments{"authorization_code", "refresh_token"},
+					fosite.Arguments{"openid", "offline_access", "username", "groups"},
+					[]string{"http://localhost:8080"},
+					0*time.Second,
+				)
+				require.True(t, c.RequiresDPoP())
+			},
+		},
+		{
+			name: "find a valid dynamic client which requires consent",
+			oidcClients: []*supervisorconfigv1alpha1.OIDCClient{
+				{
+					ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
+					Spec: supervisorconfigv1alpha1.OIDCClientSpec{
+						AllowedGrantTypes:   []supervisorconfigv1alpha1.GrantType{"authorization_code", "refresh_token"},
+						AllowedScopes:       []supervisorconfigv1alpha1.Scope{"openid", "offline_access", "username", "groups"},
+						AllowedRedirectURIs: []supervisorconfigv1alpha1.RedirectURI{"http://localhost:8080"},
+						RequireConsent:      true,
+					},
+				},
+			},
+			secrets: []*corev1.Secret{
+				testutil.OIDCClientSecretStorageSecretForUID(t, testNamespace, testUID, []string{testutil.HashedPassword1AtSupervisorMinCost}),
+			},
+			run: func(t *testing.T, subject *ClientManager) {
+				got, err := subject.GetClient(ctx, testName)
+				require.NoError(t, err)
+				require.IsType(t, &Client{}, got)
+				c := got.(*Client)
+
+				require.True(t, c.RequiresConsent())
+				require.False(t, c.RequiresDPoP())
+			},
+		},
+		{
+			name: "find a valid dynamic client which allows JWT secured authorization responses",
+			oidcClients: []*supervisorconfigv1alpha1.OIDCClient{
+				{
+					ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
+					Spec: supervisorconfigv1alpha1.OIDCClientSpec{
+						AllowedGrantTypes:                     []supervisorconfigv1alpha1.GrantType{"authorization_code", "refresh_token"},
+						AllowedScopes:                         []supervisorconfigv1alpha1.Scope{"openid", "offline_access", "username", "groups"},
+						AllowedRedirectURIs:                   []supervisorconfigv1alpha1.RedirectURI{"http://localhost:8080"},
+						AllowJWTSecuredAuthorizationResponses: true,
+					},
+				},
+			},
+			secrets: []*corev1.Secret{
+				testutil.OIDCClientSecretStorageSecretForUID(t, testNamespace, testUID, []string{testutil.HashedPassword1AtSupervisorMinCost}),
+			},
+			run: func(t *testing.T, subject *ClientManager) {
+				got, err := subject.GetClient(ctx, testName)
+				require.NoError(t, err)
+				require.IsType(t, &Client{}, got)
+				c := got.(*Client)
+
+				require.Equal(t, []fosite.ResponseModeType{"", "query", "jwt", "query.jwt"}, c.GetResponseModes())
+			},
+		},
+		{
+			name: "find a valid dynamic client which has scope policies",
+			oidcClients: []*supervisorconfigv1alpha1.OIDCClient{
+				{
+					ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
+					Spec: supervisorconfigv1alpha1.OIDCClientSpec{
+						AllowedGrantTypes:   []supervisorconfigv1alpha1.GrantType{"authorization_code", "refresh_token", "urn:ietf:params:oauth:grant-type:token-exchange"},
+						AllowedScopes:       []supervisorconfigv1alpha1.Scope{"openid", "offline_access", "pinniped:request-audience", "username", "groups"},
+						AllowedRedirectURIs: []supervisorconfigv1alpha1.RedirectURI{"http://localhost:8080"},
+						ScopePolicies: []supervisorconfigv1alpha1.OIDCClientScopePolicy{
+							{Scope: "pinniped:request-audience", RequiredGroups: []string{"cluster-admins", "developers"}},
+						},
+					},
+				},
+			},
+			secrets: []*corev1.Secret{
+				testutil.OIDCClientSecretStorageSecretForUID(t, testNamespace, testUID, []string{testutil.HashedPassword1AtSupervisorMinCost}),
+			},
+			run: func(t *testing.T, subject *ClientManager) {
+				got, err := subject.GetClient(ctx, testName)
+				require.NoError(t, err)
+				require.IsType(t, &Client{}, got)
+				c := got.(*Client)
+
+				require.NoError(t, c.CheckScopePolicies([]string{"openid", "pinniped:request-audience"}, []string{"other", "developers"}))
+				require.NoError(t, c.CheckScopePolicies([]string{"openid", "groups"}, nil))
+				require.EqualError(t, c.CheckScopePolicies([]string{"openid", "pinniped:request-audience"}, []string{"other"}),
+					`the user does not belong to any of the groups which are required to be granted the "pinniped:request-audience" scope by client "client.oauth.pinniped.dev-test-name"`)
+			},
+		},
+		{
+			name: "find a valid dynamic client which requires ACR values",
+			oidcClients: []*supervisorconfigv1alpha1.OIDCClient{
+				{
+					ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
+					Spec: supervisorconfigv1alpha1.OIDCClientSpec{
+						AllowedGrantTypes:   []supervisorconfigv1alpha1.GrantType{"authorization_code", "refresh_token"},
+						AllowedScopes:       []supervisorconfigv1alpha1.Scope{"openid", "offline_access", "username", "groups"},
+						AllowedRedirectURIs: []supervisorconfigv1alpha1.RedirectURI{"http://localhost:8080"},
+						RequiredACRValues:   []string{"phr", "phrh"},
+					},
+				},
+			},
+			secrets: []*corev1.Secret{
+				testutil.OIDCClientSecretStorageSecretForUID(t, testNamespace, testUID, []string{testutil.HashedPassword1AtSupervisorMinCost}),
+			},
+			run: func(t *testing.T, subject *ClientManager) {
+				got, err := subject.GetClient(ctx, testName)
+				require.NoError(t, err)
+				require.IsType(t, &Client{}, got)
+				c := got.(*Client)
+
+				require.Equal(t, []string{"phr", "phrh"}, c.RequiredACRValues())
+				require.NoError(t, c.CheckACRPolicy("phr"))
+				require.NoError(t, c.CheckACRPolicy("phrh"))
+				require.EqualError(t, c.CheckACRPolicy("pwd"),
+					`the upstream login asserted the ACR value "pwd", which is not one of the ACR values required by client "client.oauth.pinniped.dev-test-name"`)
+				require.EqualError(t, c.CheckACRPolicy(""),
+					`the upstream login did not assert any of the ACR values which are required by client "client.oauth.pinniped.dev-test-name"`)
+				require.EqualError(t, c.CheckActorPolicy("some-actor"),
+					`client "client.oauth.pinniped.dev-test-name" does not allow delegation`)
+			},
+		},
+		{
+			name: "find a valid dynamic client which allows delegation",
+			oidcClients: []*supervisorconfigv1alpha1.OIDCClient{
+				{
+					ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
+					Spec: supervisorconfigv1alpha1.OIDCClientSpec{
+						AllowedGrantTypes:   []supervisorconfigv1alpha1.GrantType{"authorization_code", "urn:ietf:params:oauth:grant-type:token-exchange"},
+						AllowedScopes:       []supervisorconfigv1alpha1.Scope{"openid", "pinniped:request-audience", "username", "groups"},
+						AllowedRedirectURIs: []supervisorconfigv1alpha1.RedirectURI{"http://localhost:8080"},
+						Delegation: &supervisorconfigv1alpha1.OIDCClientDelegation{
+							AllowedActors: []string{"service-a", "workload:system:serviceaccount:some-namespace:some-service-account"},
+						},
+					},
+				},
+			},
+			secrets: []*corev1.Secret{
+				testutil.OIDCClientSecretStorageSecretForUID(t, testNamespace, testUID, []string{testutil.HashedPassword1AtSupervisorMinCost}),
+			},
+			run: func(t *testing.T, subject *ClientManager) {
+				got, err := subject.GetClient(ctx, testName)
+				require.NoError(t, err)
+				require.IsType(t, &Client{}, got)
+				c := got.(*Client)
+
+				require.NoError(t, c.CheckActorPolicy("service-a"))
+				require.NoError(t, c.CheckActorPolicy("workload:system:serviceaccount:some-namespace:some-service-account"))
+				require.EqualError(t, c.CheckActorPolicy("service-b"),
+					`the actor "service-b" may not act on behalf of the users of client "client.oauth.pinniped.dev-test-name"`)
+			},
+		},
+		{
+			name: "find a valid dynamic client which uses private_key_jwt client authentication",
+			oidcClients: []*supervisorconfigv1alpha1.OIDCClient{
+				{
+					ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
+					Spec: supervisorconfigv1alpha1.OIDCClientSpec{
+						AllowedGrantTypes:   []supervisorconfigv1alpha1.GrantType{"authorization_code", "refresh_token"},
+						AllowedScopes:       []supervisorconfigv1alpha1.Scope{"openid", "offline_access", "username", "groups"},
+						AllowedRedirectURIs: []supervisorconfigv1alpha1.RedirectURI{"http://localhost:8080"},
+						PrivateKeyJWT:       &supervisorconfigv1alpha1.OIDCClientPrivateKeyJWT{SecretName: "test-client-public-keys"},
+					},
+				},
+			},
+			secrets: []*corev1.Secret{privateKeyJWTSecret},
+			run: func(t *testing.T, subject *ClientManager) {
+				got, err := subject.GetClient(ctx, testName)
+				require.NoError(t, err)
+				require.IsType(t, &Client{}, got)
+				c := got.(*Client)
+
+				require.Empty(t, c.GetRotatedHashes())
+				require.Equal(t, "private_key_jwt", c.GetTokenEndpointAuthMethod())
+				require.Equal(t, "ES256", c.GetTokenEndpointAuthSigningAlgorithm())
+				require.Len(t, c.GetJSONWebKeys().Keys, 1)
+				jwk := c.GetJSONWebKeys().Keys[0]
+				require.Equal(t, &privateKeyJWTKey.PublicKey, jwk.Key)
+				require.Equal(t, "sig", jwk.Use)
+				require.Equal(t, "ES256", jwk.Algorithm)
+				require.NotEmpty(t, jwk.KeyID)
+			},
+		},
+		{
+			name: "find a dynamic client which uses private_key_jwt client authentication when its public keys secret does not exist",
+			oidcClients: []*supervisorconfigv1alpha1.OIDCClient{
+				{
+					ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName, Generation: 1234, UID: testUID},
+					Spec: supervisorconfigv1alpha1.OIDCClientSpec{
+						AllowedGrantTypes:   []supervisorconfigv1alpha1.GrantType{"authorization_code"},
+						AllowedScopes:       []supervisorconfigv1alpha1.Scope{"openid"},
+						AllowedRedirectURIs: []supervisorconfigv1alpha1.RedirectURI{"http://localhost:8080"},
+						PrivateKeyJWT:       &supervisorconfigv1alpha1.OIDCClientPrivateKeyJWT{SecretName: "test-client-public-keys"},
+					},
+				},
+			},
+			run: func(t *testing.T, subject *ClientManager) {
+				got, err := subject.GetClient(ctx, testName)
+				require.EqualError(t, err, `client "client.oauth.pinniped.dev-test-name" exists but is invalid or not ready`)
+				require.Nil(t, got)
 			},
 		},
 	}
@@ -263,6 +509,7 @@ func TestClientManager(t *testing.T) {
 			subject := NewClientManager(
 				oidcClientsClient,
 				oidcclientsecretstorage.New(secrets),
+				secrets,
 				oidcclientvalidator.DefaultMinBcryptCost,
 			)
 
@@ -305,6 +552,12 @@ func requireEqualsPinnipedCLI(t *testing.T, c *Client) {
 	require.Equal(t, "RS256", c.GetTokenEndpointAuthSigningAlgorithm())
 	require.Equal(t, []fosite.ResponseModeType{"", "query", "form_post"}, c.GetResponseModes())
 	require.Equal(t, 0*time.Second, c.GetIDTokenLifetimeConfiguration())
+	require.False(t, c.RequiresDPoP())
+	require.False(t, c.RequiresConsent())
+	require.NoError(t, c.CheckScopePolicies([]string{"openid", "pinniped:request-audience", "groups"}, nil))
+	require.Nil(t, c.RequiredACRValues())
+	require.NoError(t, c.CheckACRPolicy(""))
+	require.EqualError(t, c.CheckActorPolicy("some-actor"), `client "pinniped-cli" does not allow delegation`)
 
 	marshaled, err := json.Marshal(c)
 	require.NoError(t, err)
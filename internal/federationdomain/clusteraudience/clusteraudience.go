@@ -0,0 +1,82 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package clusteraudience decides which audiences may be requested using RFC8693 token exchange, based on the
+// ClusterAudiences which an admin created, and lists those ClusterAudiences for discovery by clients.
+package clusteraudience
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
+	idpdiscoveryv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idpdiscovery/v1alpha1"
+	configlisters "go.pinniped.dev/generated/latest/client/supervisor/listers/config/v1alpha1"
+)
+
+// Registry finds the ClusterAudiences. A nil Registry has none, so it allows any audience.
+type Registry struct {
+	lister configlisters.ClusterAudienceNamespaceLister
+}
+
+// NewRegistry returns a Registry which reads the ClusterAudiences from the lister of an informer.
+func NewRegistry(lister configlisters.ClusterAudienceNamespaceLister) *Registry {
+	return &Registry{lister: lister}
+}
+
+// Allows returns whether the audience may be requested. When there are no ClusterAudiences, any audience is allowed,
+// so that token exchange continues to work as before the first ClusterAudience is created. Otherwise, only the
+// audiences of the ClusterAudiences are allowed.
+func (r *Registry) Allows(audience string) (bool, error) {
+	if r == nil {
+		return true, nil
+	}
+
+	clusterAudiences, err := r.lister.List(labels.Everything())
+	if err != nil {
+		return false, fmt.Errorf("could not list ClusterAudiences: %w", err)
+	}
+	if len(clusterAudiences) == 0 {
+		return true, nil
+	}
+
+	for _, clusterAudience := range clusterAudiences {
+		if clusterAudience.Spec.Audience == audience {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// List returns the ClusterAudiences as they are published by the cluster audience discovery endpoint, sorted by name.
+func (r *Registry) List() ([]idpdiscoveryv1alpha1.PinnipedClusterAudience, error) {
+	if r == nil {
+		return []idpdiscoveryv1alpha1.PinnipedClusterAudience{}, nil
+	}
+
+	clusterAudiences, err := r.lister.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("could not list ClusterAudiences: %w", err)
+	}
+
+	sort.Slice(clusterAudiences, func(i, j int) bool {
+		return clusterAudiences[i].Name < clusterAudiences[j].Name
+	})
+
+	result := make([]idpdiscoveryv1alpha1.PinnipedClusterAudience, 0, len(clusterAudiences))
+	for _, clusterAudience := range clusterAudiences {
+		result = append(result, published(clusterAudience))
+	}
+	return result, nil
+}
+
+func published(clusterAudience *supervisorconfigv1alpha1.ClusterAudience) idpdiscoveryv1alpha1.PinnipedClusterAudience {
+	return idpdiscoveryv1alpha1.PinnipedClusterAudience{
+		Name:                     clusterAudience.Name,
+		Audience:                 clusterAudience.Spec.Audience,
+		Server:                   clusterAudience.Spec.Server,
+		CertificateAuthorityData: clusterAudience.Spec.CertificateAuthorityData,
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package clusteraudience
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
+	idpdiscoveryv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idpdiscovery/v1alpha1"
+	configlisters "go.pinniped.dev/generated/latest/client/supervisor/listers/config/v1alpha1"
+)
+
+func newRegistry(t *testing.T, namespace string, clusterAudiences ...*supervisorconfigv1alpha1.ClusterAudience) *Registry {
+	t.Helper()
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, clusterAudience := range clusterAudiences {
+		require.NoError(t, indexer.Add(clusterAudience))
+	}
+	return NewRegistry(configlisters.NewClusterAudienceLister(indexer).ClusterAudiences(namespace))
+}
+
+func clusterAudience(name, namespace, audience, server string) *supervisorconfigv1alpha1.ClusterAudience {
+	return &supervisorconfigv1alpha1.ClusterAudience{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: supervisorconfigv1alpha1.ClusterAudienceSpec{
+			Audience:                 audience,
+			Server:                   server,
+			CertificateAuthorityData: "some-ca-data",
+		},
+	}
+}
+
+func TestAllows(t *testing.T) {
+	const namespace = "some-namespace"
+
+	tests := []struct {
+		name     string
+		registry func(t *testing.T) *Registry
+		audience string
+		want     bool
+	}{
+		{
+			name:     "nil Registry allows any audience",
+			registry: func(t *testing.T) *Registry { return nil },
+			audience: "some-audience",
+			want:     true,
+		},
+		{
+			name:     "no ClusterAudiences allows any audience",
+			registry: func(t *testing.T) *Registry { return newRegistry(t, namespace) },
+			audience: "some-audience",
+			want:     true,
+		},
+		{
+			name: "ClusterAudiences of other namespaces are ignored",
+			registry: func(t *testing.T) *Registry {
+				return newRegistry(t, namespace, clusterAudience("cluster1", "other-namespace", "other-audience", ""))
+			},
+			audience: "some-audience",
+			want:     true,
+		},
+		{
+			name: "audience of a ClusterAudience is allowed",
+			registry: func(t *testing.T) *Registry {
+				return newRegistry(t, namespace,
+					clusterAudience("cluster1", namespace, "audience1", ""),
+					clusterAudience("cluster2", namespace, "audience2", ""),
+				)
+			},
+			audience: "audience2",
+			want:     true,
+		},
+		{
+			name: "other audiences are not allowed once a ClusterAudience exists",
+			registry: func(t *testing.T) *Registry {
+				return newRegistry(t, namespace, clusterAudience("cluster1", namespace, "audience1", ""))
+			},
+			audience: "some-audience",
+			want:     false,
+		},
+		{
+			name: "the name of a ClusterAudience is not its audience",
+			registry: func(t *testing.T) *Registry {
+				return newRegistry(t, namespace, clusterAudience("cluster1", namespace, "audience1", ""))
+			},
+			audience: "cluster1",
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.registry(t).Allows(tt.audience)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	const namespace = "some-namespace"
+
+	got, err := (*Registry)(nil).List()
+	require.NoError(t, err)
+	require.Empty(t, got)
+	require.NotNil(t, got)
+
+	got, err = newRegistry(t, namespace,
+		clusterAudience("cluster2", namespace, "audience2", ""),
+		clusterAudience("cluster1", namespace, "audience1", "https://cluster1.example.com"),
+		clusterAudience("cluster3", "other-namespace", "audience3", ""),
+	).List()
+	require.NoError(t, err)
+	require.Equal(t, []idpdiscoveryv1alpha1.PinnipedClusterAudience{
+		{Name: "cluster1", Audience: "audience1", Server: "https://cluster1.example.com", CertificateAuthorityData: "some-ca-data"},
+		{Name: "cluster2", Audience: "audience2", CertificateAuthorityData: "some-ca-data"},
+	}, got)
+}
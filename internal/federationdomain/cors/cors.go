@@ -0,0 +1,84 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package cors implements cross-origin resource sharing (CORS) for the endpoints of a FederationDomain which
+// browser-based clients call directly, i.e. the discovery, JWKS and token endpoints.
+package cors
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// AnyOrigin allows the web pages of any origin.
+	AnyOrigin = "*"
+
+	// preflightMaxAge is how long browsers may cache the response to a preflight request.
+	preflightMaxAge = 10 * time.Minute
+
+	// exposedHeaders are the response headers which the web pages may read, beyond those which browsers always
+	// allow. They tell clients why a request was rejected and when to retry it.
+	exposedHeaders = "WWW-Authenticate, Retry-After"
+)
+
+// DefaultAllowedHeaders are the request headers which are allowed when the config does not list any. They are the
+// headers which the token endpoint reads, beyond those which browsers always allow.
+func DefaultAllowedHeaders() []string {
+	return []string{"Authorization", "Content-Type", "DPoP"}
+}
+
+// Config holds the settings for WrapHandler.
+type Config struct {
+	// AllowedOrigins are the origins of the web pages which may call the endpoints, or AnyOrigin.
+	AllowedOrigins []string
+	// AllowedHeaders are the request headers which the web pages may send.
+	AllowedHeaders []string
+}
+
+// WrapHandler returns a handler which adds the CORS response headers to the responses for the allowed origins.
+// It responds to the preflight requests of the allowed origins by itself, allowing the methods. All other requests,
+// including those of the other origins, are passed to the handler, so their responses are unchanged, and browsers
+// do not allow web pages of the other origins to read them.
+func WrapHandler(config Config, methods []string, handler http.Handler) http.Handler {
+	allowAnyOrigin := slices.Contains(config.AllowedOrigins, AnyOrigin)
+	allowedMethods := strings.Join(methods, ", ")
+	allowedHeaders := strings.Join(config.AllowedHeaders, ", ")
+	maxAge := strconv.Itoa(int(preflightMaxAge.Seconds()))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if !allowAnyOrigin {
+			// The response depends on the origin, so caches must not reuse it for other origins.
+			w.Header().Add("Vary", "Origin")
+		}
+		if origin == "" || !(allowAnyOrigin || slices.ContainsFunc(config.AllowedOrigins, func(allowed string) bool {
+			return strings.EqualFold(allowed, origin)
+		})) {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		if allowAnyOrigin {
+			w.Header().Set("Access-Control-Allow-Origin", AnyOrigin)
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", allowedMethods)
+			if allowedHeaders != "" {
+				w.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
+			}
+			w.Header().Set("Access-Control-Max-Age", maxAge)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.Header().Set("Access-Control-Expose-Headers", exposedHeaders)
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,130 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		allowedOrigins []string
+		method         string
+		headers        map[string]string
+		wantStatus     int
+		wantHeaders    http.Header
+	}{
+		{
+			name:           "request without origin",
+			allowedOrigins: []string{"https://app.example.com"},
+			method:         http.MethodPost,
+			wantStatus:     http.StatusTeapot,
+			wantHeaders:    http.Header{"Vary": {"Origin"}},
+		},
+		{
+			name:           "request from another origin",
+			allowedOrigins: []string{"https://app.example.com"},
+			method:         http.MethodPost,
+			headers:        map[string]string{"Origin": "https://evil.example.com"},
+			wantStatus:     http.StatusTeapot,
+			wantHeaders:    http.Header{"Vary": {"Origin"}},
+		},
+		{
+			name:           "preflight request from another origin",
+			allowedOrigins: []string{"https://app.example.com"},
+			method:         http.MethodOptions,
+			headers:        map[string]string{"Origin": "https://evil.example.com", "Access-Control-Request-Method": "POST"},
+			wantStatus:     http.StatusTeapot,
+			wantHeaders:    http.Header{"Vary": {"Origin"}},
+		},
+		{
+			name:           "request from an allowed origin",
+			allowedOrigins: []string{"https://other.example.com", "https://app.example.com"},
+			method:         http.MethodPost,
+			headers:        map[string]string{"Origin": "https://APP.example.com"},
+			wantStatus:     http.StatusTeapot,
+			wantHeaders: http.Header{
+				"Vary":                          {"Origin"},
+				"Access-Control-Allow-Origin":   {"https://APP.example.com"},
+				"Access-Control-Expose-Headers": {"WWW-Authenticate, Retry-After"},
+			},
+		},
+		{
+			name:           "preflight request from an allowed origin",
+			allowedOrigins: []string{"https://app.example.com"},
+			method:         http.MethodOptions,
+			headers:        map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "POST"},
+			wantStatus:     http.StatusNoContent,
+			wantHeaders: http.Header{
+				"Vary":                         {"Origin"},
+				"Access-Control-Allow-Origin":  {"https://app.example.com"},
+				"Access-Control-Allow-Methods": {"GET, POST"},
+				"Access-Control-Allow-Headers": {"Authorization, DPoP"},
+				"Access-Control-Max-Age":       {"600"},
+			},
+		},
+		{
+			name:           "options request from an allowed origin which is not a preflight request",
+			allowedOrigins: []string{"https://app.example.com"},
+			method:         http.MethodOptions,
+			headers:        map[string]string{"Origin": "https://app.example.com"},
+			wantStatus:     http.StatusTeapot,
+			wantHeaders: http.Header{
+				"Vary":                          {"Origin"},
+				"Access-Control-Allow-Origin":   {"https://app.example.com"},
+				"Access-Control-Expose-Headers": {"WWW-Authenticate, Retry-After"},
+			},
+		},
+		{
+			name:           "request when any origin is allowed",
+			allowedOrigins: []string{"*"},
+			method:         http.MethodGet,
+			headers:        map[string]string{"Origin": "https://app.example.com"},
+			wantStatus:     http.StatusTeapot,
+			wantHeaders: http.Header{
+				"Access-Control-Allow-Origin":   {"*"},
+				"Access-Control-Expose-Headers": {"WWW-Authenticate, Retry-After"},
+			},
+		},
+		{
+			name:           "preflight request when any origin is allowed",
+			allowedOrigins: []string{"*"},
+			method:         http.MethodOptions,
+			headers:        map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "GET"},
+			wantStatus:     http.StatusNoContent,
+			wantHeaders: http.Header{
+				"Access-Control-Allow-Origin":  {"*"},
+				"Access-Control-Allow-Methods": {"GET, POST"},
+				"Access-Control-Allow-Headers": {"Authorization, DPoP"},
+				"Access-Control-Max-Age":       {"600"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := WrapHandler(
+				Config{AllowedOrigins: tt.allowedOrigins, AllowedHeaders: []string{"Authorization", "DPoP"}},
+				[]string{http.MethodGet, http.MethodPost},
+				http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					w.WriteHeader(http.StatusTeapot)
+				}),
+			)
+
+			req := httptest.NewRequest(tt.method, "/some/path", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rsp := httptest.NewRecorder()
+			handler.ServeHTTP(rsp, req)
+
+			require.Equal(t, tt.wantStatus, rsp.Code)
+			require.Equal(t, tt.wantHeaders, rsp.Header())
+		})
+	}
+}
@@ -30,8 +30,8 @@ const idTransformUnexpectedErr = constable.Error("configured identity transforma
 type SessionConfig struct {
 	UpstreamIdentity    *resolvedprovider.Identity
 	UpstreamLoginExtras *resolvedprovider.IdentityLoginExtras
-	// The ID of the client who started the new downstream session.
-	ClientID string
+	// The client who started the new downstream session.
+	Client fosite.Client
 	// The scopes that were granted for the new downstream session.
 	GrantedScopes []string
 }
@@ -51,6 +51,14 @@ func NewPinnipedSession(
 		return nil, err
 	}
 
+	if err := CheckScopePolicies(c.Client, c.GrantedScopes, downstreamGroups); err != nil {
+		return nil, err
+	}
+
+	if err := CheckACRPolicy(c.Client, c.UpstreamLoginExtras.AuthenticationContextClassReference); err != nil {
+		return nil, err
+	}
+
 	customSessionData := &psession.CustomSessionData{
 		Username:         downstreamUsername,
 		UpstreamUsername: c.UpstreamIdentity.UpstreamUsername,
@@ -68,6 +76,9 @@ func NewPinnipedSession(
 				Subject:     c.UpstreamIdentity.DownstreamSubject,
 				RequestedAt: now,
 				AuthTime:    now,
+				// Pass through how the user authenticated at the upstream identity provider, if it said so.
+				AuthenticationContextClassReference: c.UpstreamLoginExtras.AuthenticationContextClassReference,
+				AuthenticationMethodsReferences:     c.UpstreamLoginExtras.AuthenticationMethodsReferences,
 			},
 		},
 		Custom: customSessionData,
@@ -75,7 +86,7 @@ func NewPinnipedSession(
 
 	extras := map[string]any{}
 
-	extras[oidcapi.IDTokenClaimAuthorizedParty] = c.ClientID
+	extras[oidcapi.IDTokenClaimAuthorizedParty] = c.Client.GetID()
 
 	if slices.Contains(c.GrantedScopes, oidcapi.ScopeUsername) {
 		extras[oidcapi.IDTokenClaimUsername] = downstreamUsername
@@ -97,6 +108,47 @@ func NewPinnipedSession(
 	return pinnipedSession, nil
 }
 
+// scopePolicyClient is implemented by clients which may restrict the users to whom some scopes are granted.
+type scopePolicyClient interface {
+	CheckScopePolicies(grantedScopes []string, groups []string) error
+}
+
+// CheckScopePolicies returns an error when the client has a policy for one of the granted scopes which is not met
+// by a user with the given downstream groups.
+func CheckScopePolicies(client fosite.Client, grantedScopes []string, groups []string) error {
+	policyClient, ok := client.(scopePolicyClient)
+	if !ok {
+		return nil
+	}
+	return policyClient.CheckScopePolicies(grantedScopes, groups)
+}
+
+// acrPolicyClient is implemented by clients which may require the upstream login of the user to assert some ACR values.
+type acrPolicyClient interface {
+	RequiredACRValues() []string
+	CheckACRPolicy(acr string) error
+}
+
+// RequiredACRValues returns the ACR values of which the client requires the upstream login of the user to assert one,
+// or nil when the client accepts any login.
+func RequiredACRValues(client fosite.Client) []string {
+	policyClient, ok := client.(acrPolicyClient)
+	if !ok {
+		return nil
+	}
+	return policyClient.RequiredACRValues()
+}
+
+// CheckACRPolicy returns an error when the client requires the upstream login of the user to assert one of some
+// ACR values, and the given ACR value of the login is not one of them.
+func CheckACRPolicy(client fosite.Client, acr string) error {
+	policyClient, ok := client.(acrPolicyClient)
+	if !ok {
+		return nil
+	}
+	return policyClient.CheckACRPolicy(acr)
+}
+
 // AutoApproveScopes auto-grants the scopes which we support and for which we do not require end-user approval,
 // if they were requested. This should only be called after it has been validated that the client is allowed to request
 // the scopes that it requested (which is a check performed by fosite).
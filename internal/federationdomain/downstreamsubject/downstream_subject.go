@@ -32,3 +32,30 @@ func GitHub(apiBaseURL, idpDisplayName, login, id string) string {
 		url.QueryEscape(id),
 	)
 }
+
+func ClientCertificate(issuerDN, idpDisplayName, subjectDN string) string {
+	return fmt.Sprintf("x509:%s?%s=%s&%s=%s", url.QueryEscape(issuerDN),
+		oidc.IDTokenSubClaimIDPNameQueryParam, url.QueryEscape(idpDisplayName),
+		oidc.IDTokenClaimSubject, url.QueryEscape(subjectDN),
+	)
+}
+
+func Mock(resourceName, idpDisplayName, username string) string {
+	return fmt.Sprintf("mock:%s?%s=%s&%s=%s", url.QueryEscape(resourceName),
+		oidc.IDTokenSubClaimIDPNameQueryParam, url.QueryEscape(idpDisplayName),
+		oidc.IDTokenClaimSubject, url.QueryEscape(username),
+	)
+}
+
+func ServiceAccount(serviceAccountIssuer, serviceAccountUsername string) string {
+	return fmt.Sprintf("%s?%s=%s", serviceAccountIssuer,
+		oidc.IDTokenClaimSubject, url.QueryEscape(serviceAccountUsername),
+	)
+}
+
+func OpenShift(apiServerURL, idpDisplayName, uid string) string {
+	return fmt.Sprintf("%s?%s=%s&%s=%s", apiServerURL,
+		oidc.IDTokenSubClaimIDPNameQueryParam, url.QueryEscape(idpDisplayName),
+		oidc.IDTokenClaimSubject, url.QueryEscape(uid),
+	)
+}
@@ -127,3 +127,142 @@ func TestGitHub(t *testing.T) {
 		})
 	}
 }
+
+func TestClientCertificate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		issuerDN       string
+		idpDisplayName string
+		subjectDN      string
+		wantSubject    string
+	}{
+		{
+			name:           "simple display name",
+			issuerDN:       "CN=some-ca",
+			idpDisplayName: "simpleName",
+			subjectDN:      "CN=some-user,O=some-org",
+			wantSubject:    "x509:CN%3Dsome-ca?idpName=simpleName&sub=CN%3Dsome-user%2CO%3Dsome-org",
+		},
+		{
+			name:           "interesting display name",
+			issuerDN:       "CN=some other ca,O=some org",
+			idpDisplayName: "this is a 👍 display name that 🦭 can handle",
+			subjectDN:      "CN=some other user",
+			wantSubject:    "x509:CN%3Dsome+other+ca%2CO%3Dsome+org?idpName=this+is+a+%F0%9F%91%8D+display+name+that+%F0%9F%A6%AD+can+handle&sub=CN%3Dsome+other+user",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual := ClientCertificate(test.issuerDN, test.idpDisplayName, test.subjectDN)
+
+			require.Equal(t, test.wantSubject, actual)
+		})
+	}
+}
+
+func TestMock(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		resourceName   string
+		idpDisplayName string
+		username       string
+		wantSubject    string
+	}{
+		{
+			name:           "simple display name",
+			resourceName:   "some-mock-idp",
+			idpDisplayName: "simpleName",
+			username:       "alice",
+			wantSubject:    "mock:some-mock-idp?idpName=simpleName&sub=alice",
+		},
+		{
+			name:           "interesting display name and username",
+			resourceName:   "some-mock-idp",
+			idpDisplayName: "this is a 👍 display name that 🦭 can handle",
+			username:       "alice@example.com&more",
+			wantSubject:    "mock:some-mock-idp?idpName=this+is+a+%F0%9F%91%8D+display+name+that+%F0%9F%A6%AD+can+handle&sub=alice%40example.com%26more",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual := Mock(test.resourceName, test.idpDisplayName, test.username)
+
+			require.Equal(t, test.wantSubject, actual)
+		})
+	}
+}
+
+func TestOpenShift(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		apiServerURL   string
+		idpDisplayName string
+		uid            string
+		wantSubject    string
+	}{
+		{
+			name:           "simple display name",
+			apiServerURL:   "https://api.cluster.example.com:6443",
+			idpDisplayName: "simpleName",
+			uid:            "0b9a8b5f-d4c6-4a5e-9e6f-5bd0e4b3a6d1",
+			wantSubject:    "https://api.cluster.example.com:6443?idpName=simpleName&sub=0b9a8b5f-d4c6-4a5e-9e6f-5bd0e4b3a6d1",
+		},
+		{
+			name:           "interesting display name",
+			apiServerURL:   "https://api.cluster.example.com:6443",
+			idpDisplayName: "this is a 👍 display name that 🦭 can handle",
+			uid:            "0b9a8b5f-d4c6-4a5e-9e6f-5bd0e4b3a6d1",
+			wantSubject:    "https://api.cluster.example.com:6443?idpName=this+is+a+%F0%9F%91%8D+display+name+that+%F0%9F%A6%AD+can+handle&sub=0b9a8b5f-d4c6-4a5e-9e6f-5bd0e4b3a6d1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual := OpenShift(test.apiServerURL, test.idpDisplayName, test.uid)
+
+			require.Equal(t, test.wantSubject, actual)
+		})
+	}
+}
+
+func TestServiceAccount(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name                   string
+		serviceAccountIssuer   string
+		serviceAccountUsername string
+		wantSubject            string
+	}{
+		{
+			name:                   "in-cluster issuer",
+			serviceAccountIssuer:   "https://kubernetes.default.svc.cluster.local",
+			serviceAccountUsername: "system:serviceaccount:some-namespace:some-name",
+			wantSubject:            "https://kubernetes.default.svc.cluster.local?sub=system%3Aserviceaccount%3Asome-namespace%3Asome-name",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual := ServiceAccount(test.serviceAccountIssuer, test.serviceAccountUsername)
+
+			require.Equal(t, test.wantSubject, actual)
+		})
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package dpop implements the server side of OAuth 2.0 Demonstrating Proof of Possession (DPoP), as defined by
+// RFC 9449, for the downstream token endpoint. The tokens which are issued in response to a request with a valid
+// DPoP proof are bound to the public key of the proof, so they may only be refreshed or exchanged by a client which
+// can sign proofs with the corresponding private key.
+package dpop
+
+import (
+	"context"
+	"crypto"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/ory/fosite"
+	"github.com/pkg/errors"
+	"k8s.io/utils/clock"
+
+	"go.pinniped.dev/internal/federationdomain/errordetails"
+	"go.pinniped.dev/internal/psession"
+)
+
+const (
+	// HeaderName is the name of the request header which carries the DPoP proof.
+	HeaderName = "DPoP"
+
+	// TokenType is the token_type of the token responses for sessions which are bound to a DPoP key.
+	TokenType = "DPoP"
+
+	// ClaimConfirmation is the name of the confirmation claim defined by RFC 7800, which holds the JWK thumbprint
+	// of the key to which the tokens are bound.
+	ClaimConfirmation = "cnf"
+
+	claimConfirmationJWKThumbprint = "jkt"
+
+	proofType = "dpop+jwt"
+
+	// Proofs are only accepted for a short time after they were issued, and while they are accepted their jti
+	// values are remembered to detect replays.
+	maxProofAge = 5 * time.Minute
+
+	// Allow for the clock of the client to be somewhat ahead of the clock of the Supervisor.
+	maxClockSkew = time.Minute
+)
+
+// SupportedAlgorithms are the JWS algorithms which are accepted for DPoP proofs.
+//
+//nolint:gochecknoglobals // Treated as a constant.
+var SupportedAlgorithms = []string{
+	string(jose.ES256),
+	string(jose.ES384),
+	string(jose.ES512),
+	string(jose.RS256),
+	string(jose.PS256),
+	string(jose.EdDSA),
+}
+
+// ErrInvalidProof returns the error defined by RFC 9449 for invalid DPoP proofs.
+func ErrInvalidProof() *fosite.RFC6749Error {
+	return &fosite.RFC6749Error{
+		ErrorField:       "invalid_dpop_proof",
+		DescriptionField: "The DPoP proof is invalid.",
+		CodeField:        http.StatusBadRequest,
+	}
+}
+
+func errInvalidProof(hint string) error {
+	return errordetails.WithCode(errors.WithStack(ErrInvalidProof().WithHint(hint)), errordetails.DPoPProofInvalid)
+}
+
+type proofClaims struct {
+	HTM string `json:"htm"`
+	HTU string `json:"htu"`
+}
+
+// Validator validates DPoP proofs. The jti values of the recently accepted proofs are remembered in memory, so a
+// replayed proof is only detected when it is sent to the same Supervisor pod as the original. This limits, but does
+// not prevent, replays within the short lifetime of a proof.
+type Validator struct {
+	clock clock.PassiveClock
+
+	mu   sync.Mutex
+	seen map[string]time.Time // keys are the thumbprint and jti of the proofs, values are when they may be forgotten
+}
+
+// NewValidator returns a Validator.
+func NewValidator(clock clock.PassiveClock) *Validator {
+	return &Validator{clock: clock, seen: map[string]time.Time{}}
+}
+
+// Validate validates the DPoP proof of the request, which must have been made to the token endpoint at
+// tokenEndpointURL, and returns the JWK thumbprint of the public key of the proof. It returns an empty string
+// when the request does not have a DPoP proof.
+func (v *Validator) Validate(r *http.Request, tokenEndpointURL string) (string, error) {
+	proofs := r.Header.Values(HeaderName)
+	if len(proofs) == 0 {
+		return "", nil
+	}
+	if len(proofs) > 1 {
+		return "", errInvalidProof("Only one DPoP proof is allowed.")
+	}
+
+	token, err := jwt.ParseSigned(proofs[0])
+	if err != nil || len(token.Headers) != 1 {
+		return "", errInvalidProof("The DPoP proof is not a valid JWT.")
+	}
+	header := token.Headers[0]
+	if typ, _ := header.ExtraHeaders[jose.HeaderType].(string); typ != proofType {
+		return "", errInvalidProof(`The DPoP proof must have the "typ" header "dpop+jwt".`)
+	}
+	if !slices.Contains(SupportedAlgorithms, header.Algorithm) {
+		return "", errInvalidProof("The DPoP proof must be signed with one of the supported algorithms: " + strings.Join(SupportedAlgorithms, ", ") + ".")
+	}
+	jwk := header.JSONWebKey
+	if jwk == nil || !jwk.Valid() || !jwk.IsPublic() {
+		return "", errInvalidProof(`The DPoP proof must have a public key in the "jwk" header.`)
+	}
+
+	var claims jwt.Claims
+	var proof proofClaims
+	if err := token.Claims(jwk, &claims, &proof); err != nil {
+		return "", errInvalidProof("The signature of the DPoP proof is invalid.")
+	}
+	if claims.ID == "" {
+		return "", errInvalidProof(`The DPoP proof must have the "jti" claim.`)
+	}
+	if claims.IssuedAt == nil {
+		return "", errInvalidProof(`The DPoP proof must have the "iat" claim.`)
+	}
+	now := v.clock.Now()
+	issuedAt := claims.IssuedAt.Time()
+	if issuedAt.Before(now.Add(-maxProofAge)) || issuedAt.After(now.Add(maxClockSkew)) {
+		return "", errInvalidProof("The DPoP proof was not issued recently.")
+	}
+	if proof.HTM != r.Method {
+		return "", errInvalidProof(`The "htm" claim of the DPoP proof does not match the method of the request.`)
+	}
+	if !sameURL(proof.HTU, tokenEndpointURL) {
+		return "", errInvalidProof(`The "htu" claim of the DPoP proof does not match the URL of the token endpoint.`)
+	}
+
+	thumbprint, err := jwk.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", errInvalidProof(`The DPoP proof must have a public key in the "jwk" header.`)
+	}
+	jkt := base64.RawURLEncoding.EncodeToString(thumbprint)
+
+	if !v.remember(jkt+" "+claims.ID, issuedAt.Add(maxProofAge), now) {
+		return "", errInvalidProof("The DPoP proof was already used.")
+	}
+
+	return jkt, nil
+}
+
+// remember records the key until forgetAfter, and returns false when the key was already recorded.
+func (v *Validator) remember(key string, forgetAfter time.Time, now time.Time) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for k, t := range v.seen {
+		if now.After(t) {
+			delete(v.seen, k)
+		}
+	}
+	if _, seen := v.seen[key]; seen {
+		return false
+	}
+	v.seen[key] = forgetAfter
+	return true
+}
+
+// sameURL compares the URLs as described in RFC 9449 section 4.3, ignoring their query and fragment.
+func sameURL(a, b string) bool {
+	aURL, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	bURL, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(aURL.Scheme, bURL.Scheme) &&
+		strings.EqualFold(aURL.Host, bURL.Host) &&
+		aURL.EscapedPath() == bURL.EscapedPath()
+}
+
+// Bind binds the tokens of the session to the key which has the JWK thumbprint jkt, by adding the confirmation
+// claim to the ID token claims of the session. The claim is stored along with the session, so it is still present
+// when the tokens of the session are refreshed or exchanged.
+func Bind(session *psession.PinnipedSession, jkt string) {
+	claims := session.IDTokenClaims()
+	if claims.Extra == nil {
+		claims.Extra = map[string]any{}
+	}
+	claims.Extra[ClaimConfirmation] = map[string]any{claimConfirmationJWKThumbprint: jkt}
+}
+
+// BoundThumbprint returns the JWK thumbprint of the key to which the tokens of the session are bound, or an empty
+// string when they are not bound.
+func BoundThumbprint(session *psession.PinnipedSession) string {
+	confirmation, _ := session.IDTokenClaims().Extra[ClaimConfirmation].(map[string]any)
+	jkt, _ := confirmation[claimConfirmationJWKThumbprint].(string)
+	return jkt
+}
+
+// CheckBinding checks that the request which used the tokens of the session had a DPoP proof with the JWK
+// thumbprint jkt, when the tokens of the session are bound to a key.
+func CheckBinding(session *psession.PinnipedSession, jkt string) error {
+	if bound := BoundThumbprint(session); bound != "" && bound != jkt {
+		return errInvalidProof("The tokens are bound to a DPoP key, so a DPoP proof signed by that key is required.")
+	}
+	return nil
+}
+
+// CheckRequired checks that the request had a DPoP proof, i.e. that jkt is not empty, when the client requires DPoP.
+func CheckRequired(required bool, jkt string) error {
+	if required && jkt == "" {
+		return errInvalidProof("This client requires a DPoP proof.")
+	}
+	return nil
+}
+
+type contextKey struct{}
+
+// WithThumbprint returns a copy of ctx which carries the JWK thumbprint of the DPoP proof of the request, so that
+// fosite token endpoint handlers can check the binding of the tokens which they receive.
+func WithThumbprint(ctx context.Context, jkt string) context.Context {
+	return context.WithValue(ctx, contextKey{}, jkt)
+}
+
+// ThumbprintFromContext returns the JWK thumbprint which was added to ctx by WithThumbprint, or an empty string.
+func ThumbprintFromContext(ctx context.Context) string {
+	jkt, _ := ctx.Value(contextKey{}).(string)
+	return jkt
+}
@@ -0,0 +1,281 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package dpop
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/ory/fosite"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"go.pinniped.dev/internal/federationdomain/errordetails"
+	"go.pinniped.dev/internal/psession"
+)
+
+const tokenEndpointURL = "https://some-issuer.com/some/path/oauth2/token"
+
+func TestValidate(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	thumbprint, err := (&jose.JSONWebKey{Key: key.Public()}).Thumbprint(crypto.SHA256)
+	require.NoError(t, err)
+	wantJKT := base64.RawURLEncoding.EncodeToString(thumbprint)
+
+	type proofOptions struct {
+		typ       string
+		alg       jose.SignatureAlgorithm
+		signer    any
+		headerJWK any
+		claims    map[string]any
+	}
+	makeProof := func(t *testing.T, editOptions func(*proofOptions)) string {
+		t.Helper()
+		opts := proofOptions{
+			typ:       "dpop+jwt",
+			alg:       jose.ES256,
+			signer:    key,
+			headerJWK: jose.JSONWebKey{Key: key.Public()},
+			claims: map[string]any{
+				"jti": "some-jti",
+				"iat": now.Unix(),
+				"htm": http.MethodPost,
+				"htu": tokenEndpointURL,
+			},
+		}
+		if editOptions != nil {
+			editOptions(&opts)
+		}
+		signerOptions := (&jose.SignerOptions{}).WithType(jose.ContentType(opts.typ))
+		if opts.headerJWK != nil {
+			signerOptions = signerOptions.WithHeader("jwk", opts.headerJWK)
+		}
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: opts.alg, Key: opts.signer}, signerOptions)
+		require.NoError(t, err)
+		proof, err := jwt.Signed(signer).Claims(opts.claims).CompactSerialize()
+		require.NoError(t, err)
+		return proof
+	}
+
+	tests := []struct {
+		name          string
+		proofs        func(t *testing.T) []string
+		wantJKT       string
+		wantErrorHint string
+	}{
+		{
+			name:    "no proof",
+			proofs:  func(t *testing.T) []string { return nil },
+			wantJKT: "",
+		},
+		{
+			name:    "valid proof",
+			proofs:  func(t *testing.T) []string { return []string{makeProof(t, nil)} },
+			wantJKT: wantJKT,
+		},
+		{
+			name: "valid proof with a different case of the host and a query in the htu",
+			proofs: func(t *testing.T) []string {
+				return []string{makeProof(t, func(o *proofOptions) {
+					o.claims["htu"] = "https://SOME-issuer.com/some/path/oauth2/token?foo=bar"
+				})}
+			},
+			wantJKT: wantJKT,
+		},
+		{
+			name:          "multiple proofs",
+			proofs:        func(t *testing.T) []string { return []string{makeProof(t, nil), makeProof(t, nil)} },
+			wantErrorHint: "Only one DPoP proof is allowed.",
+		},
+		{
+			name:          "not a JWT",
+			proofs:        func(t *testing.T) []string { return []string{"not-a-jwt"} },
+			wantErrorHint: "The DPoP proof is not a valid JWT.",
+		},
+		{
+			name: "wrong typ",
+			proofs: func(t *testing.T) []string {
+				return []string{makeProof(t, func(o *proofOptions) { o.typ = "JWT" })}
+			},
+			wantErrorHint: `The DPoP proof must have the "typ" header "dpop+jwt".`,
+		},
+		{
+			name: "symmetric algorithm",
+			proofs: func(t *testing.T) []string {
+				return []string{makeProof(t, func(o *proofOptions) {
+					o.alg = jose.HS256
+					o.signer = []byte("some-shared-secret-which-is-long-enough")
+					o.headerJWK = nil
+				})}
+			},
+			wantErrorHint: "The DPoP proof must be signed with one of the supported algorithms: ES256, ES384, ES512, RS256, PS256, EdDSA.",
+		},
+		{
+			name: "no jwk header",
+			proofs: func(t *testing.T) []string {
+				return []string{makeProof(t, func(o *proofOptions) { o.headerJWK = nil })}
+			},
+			wantErrorHint: `The DPoP proof must have a public key in the "jwk" header.`,
+		},
+		{
+			name: "signed by a different key than the jwk header",
+			proofs: func(t *testing.T) []string {
+				return []string{makeProof(t, func(o *proofOptions) { o.signer = otherKey })}
+			},
+			wantErrorHint: "The signature of the DPoP proof is invalid.",
+		},
+		{
+			name: "no jti",
+			proofs: func(t *testing.T) []string {
+				return []string{makeProof(t, func(o *proofOptions) { delete(o.claims, "jti") })}
+			},
+			wantErrorHint: `The DPoP proof must have the "jti" claim.`,
+		},
+		{
+			name: "no iat",
+			proofs: func(t *testing.T) []string {
+				return []string{makeProof(t, func(o *proofOptions) { delete(o.claims, "iat") })}
+			},
+			wantErrorHint: `The DPoP proof must have the "iat" claim.`,
+		},
+		{
+			name: "too old",
+			proofs: func(t *testing.T) []string {
+				return []string{makeProof(t, func(o *proofOptions) { o.claims["iat"] = now.Add(-6 * time.Minute).Unix() })}
+			},
+			wantErrorHint: "The DPoP proof was not issued recently.",
+		},
+		{
+			name: "too far in the future",
+			proofs: func(t *testing.T) []string {
+				return []string{makeProof(t, func(o *proofOptions) { o.claims["iat"] = now.Add(2 * time.Minute).Unix() })}
+			},
+			wantErrorHint: "The DPoP proof was not issued recently.",
+		},
+		{
+			name: "wrong htm",
+			proofs: func(t *testing.T) []string {
+				return []string{makeProof(t, func(o *proofOptions) { o.claims["htm"] = http.MethodGet })}
+			},
+			wantErrorHint: `The "htm" claim of the DPoP proof does not match the method of the request.`,
+		},
+		{
+			name: "wrong htu",
+			proofs: func(t *testing.T) []string {
+				return []string{makeProof(t, func(o *proofOptions) { o.claims["htu"] = "https://some-issuer.com/other/oauth2/token" })}
+			},
+			wantErrorHint: `The "htu" claim of the DPoP proof does not match the URL of the token endpoint.`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := NewValidator(clocktesting.NewFakeClock(now))
+
+			req := httptest.NewRequest(http.MethodPost, tokenEndpointURL, nil)
+			for _, proof := range tt.proofs(t) {
+				req.Header.Add(HeaderName, proof)
+			}
+
+			jkt, err := validator.Validate(req, tokenEndpointURL)
+			if tt.wantErrorHint != "" {
+				requireInvalidProof(t, err, tt.wantErrorHint)
+				require.Empty(t, jkt)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantJKT, jkt)
+		})
+	}
+
+	t.Run("replayed proof", func(t *testing.T) {
+		fakeClock := clocktesting.NewFakeClock(now)
+		validator := NewValidator(fakeClock)
+		proof := makeProof(t, nil)
+
+		req := httptest.NewRequest(http.MethodPost, tokenEndpointURL, nil)
+		req.Header.Set(HeaderName, proof)
+
+		jkt, err := validator.Validate(req, tokenEndpointURL)
+		require.NoError(t, err)
+		require.Equal(t, wantJKT, jkt)
+
+		_, err = validator.Validate(req, tokenEndpointURL)
+		requireInvalidProof(t, err, "The DPoP proof was already used.")
+
+		// Another proof with a different jti is accepted.
+		req.Header.Set(HeaderName, makeProof(t, func(o *proofOptions) { o.claims["jti"] = "other-jti" }))
+		_, err = validator.Validate(req, tokenEndpointURL)
+		require.NoError(t, err)
+
+		// After the proof has expired, its jti is forgotten.
+		fakeClock.Step(6 * time.Minute)
+		_, err = validator.Validate(httptest.NewRequest(http.MethodPost, tokenEndpointURL, nil), tokenEndpointURL)
+		require.NoError(t, err)
+		require.Len(t, validator.seen, 2)
+		req.Header.Set(HeaderName, makeProof(t, func(o *proofOptions) { o.claims["iat"] = fakeClock.Now().Unix() }))
+		_, err = validator.Validate(req, tokenEndpointURL)
+		require.NoError(t, err)
+		require.Len(t, validator.seen, 1)
+	})
+}
+
+func TestBinding(t *testing.T) {
+	session := psession.NewPinnipedSession()
+	require.Empty(t, BoundThumbprint(session))
+	require.NoError(t, CheckBinding(session, ""))
+	require.NoError(t, CheckBinding(session, "some-jkt"))
+
+	Bind(session, "some-jkt")
+	require.Equal(t, "some-jkt", BoundThumbprint(session))
+	require.Equal(t, map[string]any{"jkt": "some-jkt"}, session.IDTokenClaims().Extra["cnf"])
+	require.NoError(t, CheckBinding(session, "some-jkt"))
+	requireInvalidProof(t, CheckBinding(session, "other-jkt"), "The tokens are bound to a DPoP key, so a DPoP proof signed by that key is required.")
+	requireInvalidProof(t, CheckBinding(session, ""), "The tokens are bound to a DPoP key, so a DPoP proof signed by that key is required.")
+
+	// The binding survives the session being stored and loaded.
+	stored, err := json.Marshal(session)
+	require.NoError(t, err)
+	loaded := psession.NewPinnipedSession()
+	require.NoError(t, json.Unmarshal(stored, loaded))
+	require.Equal(t, "some-jkt", BoundThumbprint(loaded))
+}
+
+func TestCheckRequired(t *testing.T) {
+	require.NoError(t, CheckRequired(false, ""))
+	require.NoError(t, CheckRequired(false, "some-jkt"))
+	require.NoError(t, CheckRequired(true, "some-jkt"))
+	requireInvalidProof(t, CheckRequired(true, ""), "This client requires a DPoP proof.")
+}
+
+func TestThumbprintFromContext(t *testing.T) {
+	require.Empty(t, ThumbprintFromContext(context.Background()))
+	require.Equal(t, "some-jkt", ThumbprintFromContext(WithThumbprint(context.Background(), "some-jkt")))
+}
+
+func requireInvalidProof(t *testing.T, err error, wantHint string) {
+	t.Helper()
+	var rfc6749Error *fosite.RFC6749Error
+	require.ErrorAs(t, err, &rfc6749Error)
+	require.Equal(t, "invalid_dpop_proof", rfc6749Error.ErrorField)
+	require.Equal(t, http.StatusBadRequest, rfc6749Error.CodeField)
+	require.Equal(t, wantHint, rfc6749Error.HintField)
+	require.Equal(t, errordetails.DPoPProofInvalid, errordetails.FromError(err))
+}
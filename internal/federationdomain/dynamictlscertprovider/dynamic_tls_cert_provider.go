@@ -1,11 +1,14 @@
-// Copyright 2020-2023 the Pinniped contributors. All Rights Reserved.
+// Copyright 2020-2024 the Pinniped contributors. All Rights Reserved.
 // SPDX-License-Identifier: Apache-2.0
 
 package dynamictlscertprovider
 
 import (
 	"crypto/tls"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type DynamicTLSCertProvider interface {
@@ -13,40 +16,76 @@ type DynamicTLSCertProvider interface {
 	SetDefaultTLSCert(certificate *tls.Certificate)
 	GetTLSCert(lowercaseIssuerHostName string) *tls.Certificate
 	GetDefaultTLSCert() *tls.Certificate
+
+	// LookupTLSCert returns the cert for the SNI server name of an incoming TLS connection, along with the default
+	// cert, both taken from the same snapshot of the certs. The server name is matched case-insensitively.
+	// Either may be nil. It is called once per TLS handshake, so it never blocks on the setters.
+	LookupTLSCert(serverName string) (serverNameCert *tls.Certificate, defaultCert *tls.Certificate)
 }
 
-type dynamicTLSCertProvider struct {
+// certSnapshot is never mutated after it is stored, so readers can use it without holding a lock.
+type certSnapshot struct {
 	issuerHostToTLSCertMap map[string]*tls.Certificate
 	defaultCert            *tls.Certificate
-	mutex                  sync.RWMutex
+}
+
+type dynamicTLSCertProvider struct {
+	snapshot atomic.Pointer[certSnapshot]
+	mutex    sync.Mutex // serializes the setters, which each replace the whole snapshot
 }
 
 func NewDynamicTLSCertProvider() DynamicTLSCertProvider {
-	return &dynamicTLSCertProvider{
-		issuerHostToTLSCertMap: map[string]*tls.Certificate{},
-	}
+	p := &dynamicTLSCertProvider{}
+	p.snapshot.Store(&certSnapshot{issuerHostToTLSCertMap: map[string]*tls.Certificate{}})
+	return p
 }
 
 func (p *dynamicTLSCertProvider) SetIssuerHostToTLSCertMap(issuerHostToTLSCertMap map[string]*tls.Certificate) {
-	p.mutex.Lock() // acquire a write lock
+	// Copy the map so the snapshot cannot be changed by the caller after it is stored.
+	certs := make(map[string]*tls.Certificate, len(issuerHostToTLSCertMap))
+	for host, cert := range issuerHostToTLSCertMap {
+		certs[host] = cert
+	}
+
+	p.mutex.Lock()
 	defer p.mutex.Unlock()
-	p.issuerHostToTLSCertMap = issuerHostToTLSCertMap
+	p.snapshot.Store(&certSnapshot{
+		issuerHostToTLSCertMap: certs,
+		defaultCert:            p.snapshot.Load().defaultCert,
+	})
 }
 
 func (p *dynamicTLSCertProvider) SetDefaultTLSCert(certificate *tls.Certificate) {
-	p.mutex.Lock() // acquire a write lock
+	p.mutex.Lock()
 	defer p.mutex.Unlock()
-	p.defaultCert = certificate
+	p.snapshot.Store(&certSnapshot{
+		issuerHostToTLSCertMap: p.snapshot.Load().issuerHostToTLSCertMap,
+		defaultCert:            certificate,
+	})
 }
 
 func (p *dynamicTLSCertProvider) GetTLSCert(issuerHostName string) *tls.Certificate {
-	p.mutex.RLock() // acquire a read lock
-	defer p.mutex.RUnlock()
-	return p.issuerHostToTLSCertMap[issuerHostName]
+	return p.snapshot.Load().issuerHostToTLSCertMap[issuerHostName]
 }
 
 func (p *dynamicTLSCertProvider) GetDefaultTLSCert() *tls.Certificate {
-	p.mutex.RLock() // acquire a read lock
-	defer p.mutex.RUnlock()
-	return p.defaultCert
+	return p.snapshot.Load().defaultCert
+}
+
+func (p *dynamicTLSCertProvider) LookupTLSCert(serverName string) (*tls.Certificate, *tls.Certificate) {
+	start := time.Now()
+
+	snapshot := p.snapshot.Load()
+	serverNameCert := snapshot.issuerHostToTLSCertMap[strings.ToLower(serverName)]
+
+	result := lookupResultNone
+	switch {
+	case serverNameCert != nil:
+		result = lookupResultServerName
+	case snapshot.defaultCert != nil:
+		result = lookupResultDefault
+	}
+	certLookupObservers[result].Observe(time.Since(start).Seconds())
+
+	return serverNameCert, snapshot.defaultCert
 }
@@ -0,0 +1,213 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package dynamictlscertprovider
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+func TestDynamicTLSCertProvider(t *testing.T) {
+	p := NewDynamicTLSCertProvider()
+
+	serverNameCert, defaultCert := p.LookupTLSCert("issuer.example.com")
+	require.Nil(t, serverNameCert)
+	require.Nil(t, defaultCert)
+	require.Nil(t, p.GetTLSCert("issuer.example.com"))
+	require.Nil(t, p.GetDefaultTLSCert())
+
+	issuerCert := &tls.Certificate{}
+	certs := map[string]*tls.Certificate{"issuer.example.com": issuerCert}
+	p.SetIssuerHostToTLSCertMap(certs)
+
+	// Changing the caller's map does not change the provider's snapshot.
+	certs["other.example.com"] = &tls.Certificate{}
+	require.Nil(t, p.GetTLSCert("other.example.com"))
+
+	require.Same(t, issuerCert, p.GetTLSCert("issuer.example.com"))
+	serverNameCert, defaultCert = p.LookupTLSCert("Issuer.Example.COM")
+	require.Same(t, issuerCert, serverNameCert)
+	require.Nil(t, defaultCert)
+
+	someDefaultCert := &tls.Certificate{}
+	p.SetDefaultTLSCert(someDefaultCert)
+	require.Same(t, someDefaultCert, p.GetDefaultTLSCert())
+	require.Same(t, issuerCert, p.GetTLSCert("issuer.example.com"), "setting the default cert should keep the issuer certs")
+
+	serverNameCert, defaultCert = p.LookupTLSCert("unknown.example.com")
+	require.Nil(t, serverNameCert)
+	require.Same(t, someDefaultCert, defaultCert)
+
+	p.SetIssuerHostToTLSCertMap(map[string]*tls.Certificate{})
+	require.Nil(t, p.GetTLSCert("issuer.example.com"))
+	require.Same(t, someDefaultCert, p.GetDefaultTLSCert(), "setting the issuer certs should keep the default cert")
+}
+
+func TestLookupTLSCertMetrics(t *testing.T) {
+	countsBefore := map[string]uint64{}
+	for _, result := range []string{lookupResultNone, lookupResultDefault, lookupResultServerName} {
+		count, err := testutil.GetHistogramMetricCount(certLookupObservers[result])
+		require.NoError(t, err)
+		countsBefore[result] = count
+	}
+
+	p := NewDynamicTLSCertProvider()
+	p.LookupTLSCert("issuer.example.com")
+	p.SetDefaultTLSCert(&tls.Certificate{})
+	p.LookupTLSCert("issuer.example.com")
+	p.LookupTLSCert("issuer.example.com")
+	p.SetIssuerHostToTLSCertMap(map[string]*tls.Certificate{"issuer.example.com": {}})
+	p.LookupTLSCert("issuer.example.com")
+
+	for result, wantCount := range map[string]uint64{
+		lookupResultNone:       1,
+		lookupResultDefault:    2,
+		lookupResultServerName: 1,
+	} {
+		count, err := testutil.GetHistogramMetricCount(certLookupObservers[result])
+		require.NoError(t, err)
+		require.Equal(t, wantCount, count-countsBefore[result], "unexpected count for result %q", result)
+	}
+}
+
+func TestConcurrentLookupsAndUpdates(t *testing.T) {
+	p := NewDynamicTLSCertProvider()
+	certs := newCertMap(10)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				p.SetIssuerHostToTLSCertMap(certs)
+				p.SetDefaultTLSCert(&tls.Certificate{})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				p.LookupTLSCert(issuerHost(j % 10))
+			}
+		}()
+	}
+	wg.Wait()
+
+	serverNameCert, defaultCert := p.LookupTLSCert(issuerHost(3))
+	require.Same(t, certs[issuerHost(3)], serverNameCert)
+	require.NotNil(t, defaultCert)
+}
+
+// The benchmarks below guard against regressions in the per-handshake cert lookup of busy Supervisors which have
+// many FederationDomains. To get a memory profile, run them with e.g.
+//
+//	go test ./internal/federationdomain/dynamictlscertprovider -run=XXX -bench=. -benchmem -memprofile=mem.out
+//	go tool pprof -sample_index=alloc_space mem.out
+
+func BenchmarkLookupTLSCert(b *testing.B) {
+	for _, numFederationDomains := range []int{1, 100, 10_000} {
+		p := NewDynamicTLSCertProvider()
+		p.SetIssuerHostToTLSCertMap(newCertMap(numFederationDomains))
+		p.SetDefaultTLSCert(&tls.Certificate{})
+
+		b.Run(fmt.Sprintf("federation_domains=%d/found", numFederationDomains), func(b *testing.B) {
+			serverName := issuerHost(numFederationDomains / 2)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if cert, _ := p.LookupTLSCert(serverName); cert == nil {
+					b.Fatal("expected a cert")
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("federation_domains=%d/default", numFederationDomains), func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, cert := p.LookupTLSCert("unknown.example.com"); cert == nil {
+					b.Fatal("expected a default cert")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkLookupTLSCertParallel(b *testing.B) {
+	p := NewDynamicTLSCertProvider()
+	p.SetIssuerHostToTLSCertMap(newCertMap(100))
+
+	hosts := issuerHosts(100)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			p.LookupTLSCert(hosts[i%len(hosts)])
+			i++
+		}
+	})
+}
+
+func BenchmarkLookupTLSCertParallelWithUpdates(b *testing.B) {
+	p := NewDynamicTLSCertProvider()
+	certs := newCertMap(100)
+	p.SetIssuerHostToTLSCertMap(certs)
+
+	stop := make(chan struct{})
+	updaterDone := make(chan struct{})
+	go func() {
+		defer close(updaterDone)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				p.SetIssuerHostToTLSCertMap(certs)
+			}
+		}
+	}()
+
+	hosts := issuerHosts(100)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			p.LookupTLSCert(hosts[i%len(hosts)])
+			i++
+		}
+	})
+	b.StopTimer()
+
+	close(stop)
+	<-updaterDone
+}
+
+func newCertMap(size int) map[string]*tls.Certificate {
+	certs := make(map[string]*tls.Certificate, size)
+	for i := 0; i < size; i++ {
+		certs[issuerHost(i)] = &tls.Certificate{}
+	}
+	return certs
+}
+
+func issuerHosts(size int) []string {
+	hosts := make([]string, size)
+	for i := range hosts {
+		hosts[i] = issuerHost(i)
+	}
+	return hosts
+}
+
+func issuerHost(i int) string {
+	return fmt.Sprintf("issuer-%d.example.com", i)
+}
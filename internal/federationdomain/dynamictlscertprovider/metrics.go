@@ -0,0 +1,45 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package dynamictlscertprovider
+
+import (
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	lookupResultServerName = "server_name"
+	lookupResultDefault    = "default"
+	lookupResultNone       = "none"
+)
+
+// certLookupDuration is served by the /metrics endpoint of the Supervisor's aggregated API server.
+//
+//nolint:gochecknoglobals // Metrics are registered once per process.
+var certLookupDuration = metrics.NewHistogramVec(
+	&metrics.HistogramOpts{
+		Namespace: "pinniped",
+		Subsystem: "supervisor",
+		Name:      "tls_cert_lookup_duration_seconds",
+		Help: "Time taken to choose the serving certificate for an incoming TLS connection, by result: " +
+			"server_name when a FederationDomain's certificate matched the SNI server name, " +
+			"default when the default certificate was chosen, and none when there was no certificate.",
+		Buckets:        []float64{0.000001, 0.0000025, 0.000005, 0.00001, 0.000025, 0.00005, 0.0001, 0.001},
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"result"},
+)
+
+// certLookupObservers holds the child of certLookupDuration for each result, so that the per-handshake lookup does
+// not need to allocate to find it.
+//
+//nolint:gochecknoglobals // Metrics are registered once per process.
+var certLookupObservers = map[string]metrics.ObserverMetric{}
+
+func init() { //nolint:gochecknoinits // This is the conventional way to register metrics with the legacy registry.
+	legacyregistry.MustRegister(certLookupDuration)
+	for _, result := range []string{lookupResultServerName, lookupResultDefault, lookupResultNone} {
+		certLookupObservers[result] = certLookupDuration.WithLabelValues(result)
+	}
+}
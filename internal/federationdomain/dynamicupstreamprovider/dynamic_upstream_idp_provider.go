@@ -19,6 +19,12 @@ type DynamicUpstreamIDPProvider interface {
 	GetActiveDirectoryIdentityProviders() []upstreamprovider.UpstreamLDAPIdentityProviderI
 	SetGitHubIdentityProviders(gitHubIDPs []upstreamprovider.UpstreamGithubIdentityProviderI)
 	GetGitHubIdentityProviders() []upstreamprovider.UpstreamGithubIdentityProviderI
+	SetClientCertificateIdentityProviders(clientCertIDPs []upstreamprovider.UpstreamClientCertificateIdentityProviderI)
+	GetClientCertificateIdentityProviders() []upstreamprovider.UpstreamClientCertificateIdentityProviderI
+	SetMockIdentityProviders(mockIDPs []upstreamprovider.UpstreamMockIdentityProviderI)
+	GetMockIdentityProviders() []upstreamprovider.UpstreamMockIdentityProviderI
+	SetOpenShiftIdentityProviders(openShiftIDPs []upstreamprovider.UpstreamOpenShiftIdentityProviderI)
+	GetOpenShiftIdentityProviders() []upstreamprovider.UpstreamOpenShiftIdentityProviderI
 }
 
 type dynamicUpstreamIDPProvider struct {
@@ -26,6 +32,9 @@ type dynamicUpstreamIDPProvider struct {
 	ldapUpstreams            []upstreamprovider.UpstreamLDAPIdentityProviderI
 	activeDirectoryUpstreams []upstreamprovider.UpstreamLDAPIdentityProviderI
 	gitHubUpstreams          []upstreamprovider.UpstreamGithubIdentityProviderI
+	clientCertUpstreams      []upstreamprovider.UpstreamClientCertificateIdentityProviderI
+	mockUpstreams            []upstreamprovider.UpstreamMockIdentityProviderI
+	openShiftUpstreams       []upstreamprovider.UpstreamOpenShiftIdentityProviderI
 	mutex                    sync.RWMutex
 }
 
@@ -35,6 +44,9 @@ func NewDynamicUpstreamIDPProvider() DynamicUpstreamIDPProvider {
 		ldapUpstreams:            []upstreamprovider.UpstreamLDAPIdentityProviderI{},
 		activeDirectoryUpstreams: []upstreamprovider.UpstreamLDAPIdentityProviderI{},
 		gitHubUpstreams:          []upstreamprovider.UpstreamGithubIdentityProviderI{},
+		clientCertUpstreams:      []upstreamprovider.UpstreamClientCertificateIdentityProviderI{},
+		mockUpstreams:            []upstreamprovider.UpstreamMockIdentityProviderI{},
+		openShiftUpstreams:       []upstreamprovider.UpstreamOpenShiftIdentityProviderI{},
 	}
 }
 
@@ -86,6 +98,42 @@ func (p *dynamicUpstreamIDPProvider) GetGitHubIdentityProviders() []upstreamprov
 	return p.gitHubUpstreams
 }
 
+func (p *dynamicUpstreamIDPProvider) SetClientCertificateIdentityProviders(clientCertIDPs []upstreamprovider.UpstreamClientCertificateIdentityProviderI) {
+	p.mutex.Lock() // acquire a write lock
+	defer p.mutex.Unlock()
+	p.clientCertUpstreams = clientCertIDPs
+}
+
+func (p *dynamicUpstreamIDPProvider) GetClientCertificateIdentityProviders() []upstreamprovider.UpstreamClientCertificateIdentityProviderI {
+	p.mutex.RLock() // acquire a read lock
+	defer p.mutex.RUnlock()
+	return p.clientCertUpstreams
+}
+
+func (p *dynamicUpstreamIDPProvider) SetMockIdentityProviders(mockIDPs []upstreamprovider.UpstreamMockIdentityProviderI) {
+	p.mutex.Lock() // acquire a write lock
+	defer p.mutex.Unlock()
+	p.mockUpstreams = mockIDPs
+}
+
+func (p *dynamicUpstreamIDPProvider) GetMockIdentityProviders() []upstreamprovider.UpstreamMockIdentityProviderI {
+	p.mutex.RLock() // acquire a read lock
+	defer p.mutex.RUnlock()
+	return p.mockUpstreams
+}
+
+func (p *dynamicUpstreamIDPProvider) SetOpenShiftIdentityProviders(openShiftIDPs []upstreamprovider.UpstreamOpenShiftIdentityProviderI) {
+	p.mutex.Lock() // acquire a write lock
+	defer p.mutex.Unlock()
+	p.openShiftUpstreams = openShiftIDPs
+}
+
+func (p *dynamicUpstreamIDPProvider) GetOpenShiftIdentityProviders() []upstreamprovider.UpstreamOpenShiftIdentityProviderI {
+	p.mutex.RLock() // acquire a read lock
+	defer p.mutex.RUnlock()
+	return p.openShiftUpstreams
+}
+
 type RetryableRevocationError struct {
 	wrapped error
 }
@@ -5,6 +5,7 @@
 package auth
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -17,14 +18,21 @@ import (
 	oidcapi "go.pinniped.dev/generated/latest/apis/supervisor/oidc"
 	"go.pinniped.dev/internal/federationdomain/csrftoken"
 	"go.pinniped.dev/internal/federationdomain/downstreamsession"
+	"go.pinniped.dev/internal/federationdomain/endpoints/consent"
 	"go.pinniped.dev/internal/federationdomain/federationdomainproviders"
 	"go.pinniped.dev/internal/federationdomain/formposthtml"
+	"go.pinniped.dev/internal/federationdomain/loginthrottle"
 	"go.pinniped.dev/internal/federationdomain/oidc"
 	"go.pinniped.dev/internal/federationdomain/resolvedprovider"
+	"go.pinniped.dev/internal/federationdomain/resourceindicator"
+	"go.pinniped.dev/internal/federationdomain/secondfactor"
+	"go.pinniped.dev/internal/federationdomain/singlesignon"
+	"go.pinniped.dev/internal/fositestorage/pushedauthorizerequest"
 	"go.pinniped.dev/internal/httputil/responseutil"
 	"go.pinniped.dev/internal/httputil/securityheader"
 	"go.pinniped.dev/internal/plog"
 	"go.pinniped.dev/internal/psession"
+	"go.pinniped.dev/internal/tracing"
 	"go.pinniped.dev/pkg/oidcclient/nonce"
 	"go.pinniped.dev/pkg/oidcclient/pkce"
 )
@@ -44,6 +52,11 @@ type authorizeHandler struct {
 	generateNonce             func() (nonce.Nonce, error)
 	upstreamStateEncoder      oidc.Encoder
 	cookieCodec               oidc.Codec
+	pushedAuthorizeRequests   pushedauthorizerequest.Storage
+	loginThrottle             *loginthrottle.Throttle
+	singleSignOn              *singlesignon.Sessions
+	consentPrompter           *consent.Prompter
+	webAuthn                  *secondfactor.WebAuthnVerifier
 }
 
 func NewHandler(
@@ -56,6 +69,11 @@ func NewHandler(
 	generateNonce func() (nonce.Nonce, error),
 	upstreamStateEncoder oidc.Encoder,
 	cookieCodec oidc.Codec,
+	pushedAuthorizeRequests pushedauthorizerequest.Storage,
+	loginThrottle *loginthrottle.Throttle, // may be nil, in which case failed logins are not throttled
+	singleSignOn *singlesignon.Sessions, // may be nil, in which case browsers always log in at the upstream
+	consentPrompter *consent.Prompter, // may be nil, in which case users are never asked for consent
+	webAuthn *secondfactor.WebAuthnVerifier, // may be nil, in which case users never need a WebAuthn credential
 ) http.Handler {
 	h := &authorizeHandler{
 		downstreamIssuerURL:       downstreamIssuerURL,
@@ -67,6 +85,11 @@ func NewHandler(
 		generateNonce:             generateNonce,
 		upstreamStateEncoder:      upstreamStateEncoder,
 		cookieCodec:               cookieCodec,
+		pushedAuthorizeRequests:   pushedAuthorizeRequests,
+		loginThrottle:             loginThrottle,
+		singleSignOn:              singleSignOn,
+		consentPrompter:           consentPrompter,
+		webAuthn:                  webAuthn,
 	}
 	// During a response_mode=form_post auth request using the browser flow, the custom form_post html page may
 	// be used to post certain errors back to the CLI from this handler's response, so allow the form_post
@@ -112,6 +135,17 @@ func (h *authorizeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// When the client pushed the params of this request to the pushed authorization request endpoint (RFC 9126),
+	// then use those params instead, so the rest of this handler works the same as for any other request.
+	if err := h.resolvePushedAuthorizeRequest(r); err != nil {
+		oidc.WriteAuthorizeError(r, w,
+			h.oauthHelperWithoutStorage,
+			fosite.NewAuthorizeRequest(),
+			err,
+			requestedBrowserlessFlow)
+		return
+	}
+
 	// Note that the client might have used oidcapi.AuthorizeUpstreamIDPNameParamName and
 	// oidcapi.AuthorizeUpstreamIDPTypeParamName query (or form) params to request a certain upstream IDP.
 	// The Pinniped CLI has been sending these params since v0.9.0.
@@ -144,6 +178,37 @@ func (h *authorizeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.authorize(w, r, requestedBrowserlessFlow, idpNameQueryParamValue, idp)
 }
 
+// resolvePushedAuthorizeRequest replaces the params of the request with the params of the pushed authorization
+// request to which its request_uri param refers, if any. Each pushed authorization request may only be used once.
+func (h *authorizeHandler) resolvePushedAuthorizeRequest(r *http.Request) error {
+	requestID, ok := pushedauthorizerequest.IDFromRequestURI(r.Form.Get("request_uri"))
+	if !ok {
+		return nil
+	}
+
+	pushedRequest, err := h.pushedAuthorizeRequests.Get(r.Context(), requestID)
+	if err == nil && r.Form.Get("client_id") != pushedRequest.ClientID {
+		return fosite.ErrInvalidRequest.WithHint("The 'client_id' must match the pushed authorization request.")
+	}
+	if err == nil {
+		err = h.pushedAuthorizeRequests.Delete(r.Context(), requestID)
+	}
+	if errors.Is(err, fosite.ErrNotFound) {
+		return fosite.ErrInvalidRequestURI.
+			WithHint("The pushed authorization request does not exist, has expired, or was already used.").
+			WithWrap(err).WithDebug(err.Error())
+	}
+	if err != nil {
+		return fosite.ErrServerError.
+			WithHint("Could not load the pushed authorization request.").
+			WithWrap(err).WithDebug(err.Error())
+	}
+
+	// Note that this also replaces the request_uri param, so fosite will not try to resolve it again.
+	r.Form = pushedRequest.Form
+	return nil
+}
+
 func (h *authorizeHandler) authorize(
 	w http.ResponseWriter,
 	r *http.Request,
@@ -175,6 +240,12 @@ func (h *authorizeHandler) authorize(
 	// an error if the client requested a scope that they are not allowed to request, so we don't need to worry about that here.
 	downstreamsession.AutoApproveScopes(authorizeRequester)
 
+	// Grant the RFC 8707 resources, if any were requested and the client is allowed to request them.
+	if err := resourceindicator.GrantIfRequested(authorizeRequester); err != nil {
+		oidc.WriteAuthorizeError(r, w, oauthHelper, authorizeRequester, err, requestedBrowserlessFlow)
+		return
+	}
+
 	if requestedBrowserlessFlow {
 		err = h.authorizeWithoutBrowser(r, w, oauthHelper, authorizeRequester, idp)
 	} else {
@@ -196,20 +267,39 @@ func (h *authorizeHandler) authorizeWithoutBrowser(
 		return err
 	}
 
+	if secondfactor.TOTPConfig(idp) != nil {
+		return fosite.ErrAccessDenied.WithHint("This identity provider requires a one-time code, so you must log in using a web browser.")
+	}
+	if h.webAuthn.AppliesTo(idp) {
+		return fosite.ErrAccessDenied.WithHint("This identity provider requires a passkey, so you must log in using a web browser.")
+	}
+
 	submittedUsername, submittedPassword, err := requireNonEmptyUsernameAndPasswordHeaders(r)
 	if err != nil {
 		return err
 	}
 
-	identity, loginExtras, err := idp.Login(r.Context(), submittedUsername, submittedPassword)
+	if h.loginThrottle != nil && h.loginThrottle.LockedOut(idp.GetDisplayName(), submittedUsername, h.loginThrottle.SourceIP(r)) {
+		return fosite.ErrAccessDenied.WithHint("Too many failed login attempts. Please try again later.")
+	}
+
+	upstreamCtx, span := tracing.Start(r.Context(), "upstream login", tracing.AttributeIdentityProvider.String(idp.GetDisplayName()))
+	identity, loginExtras, err := idp.Login(upstreamCtx, submittedUsername, submittedPassword)
+	tracing.EndWithError(span, err)
 	if err != nil {
+		if h.loginThrottle != nil && errors.Is(err, fosite.ErrAccessDenied) {
+			h.loginThrottle.RecordLoginFailure(idp.GetDisplayName(), submittedUsername, h.loginThrottle.SourceIP(r))
+		}
 		return err
 	}
+	if h.loginThrottle != nil {
+		h.loginThrottle.RecordLoginSuccess(idp.GetDisplayName(), submittedUsername, h.loginThrottle.SourceIP(r))
+	}
 
 	session, err := downstreamsession.NewPinnipedSession(r.Context(), idp, &downstreamsession.SessionConfig{
 		UpstreamIdentity:    identity,
 		UpstreamLoginExtras: loginExtras,
-		ClientID:            authorizeRequester.GetClient().GetID(),
+		Client:              authorizeRequester.GetClient(),
 		GrantedScopes:       authorizeRequester.GetGrantedScopes(),
 	})
 	if err != nil {
@@ -228,6 +318,11 @@ func (h *authorizeHandler) authorizeWithBrowser(
 	authorizeRequester fosite.AuthorizeRequester,
 	idp resolvedprovider.FederationDomainResolvedIdentityProvider,
 ) error {
+	// When the browser recently logged in with this IDP, then skip the upstream login.
+	if session := h.singleSignOn.Resume(r, idp, authorizeRequester); session != nil {
+		return h.authorizeWithSingleSignOn(r, w, authorizeRequester, session)
+	}
+
 	authRequestState, err := generateUpstreamAuthorizeRequestState(r, w,
 		authorizeRequester,
 		oauthHelper,
@@ -255,6 +350,44 @@ func (h *authorizeHandler) authorizeWithBrowser(
 	return nil
 }
 
+// authorizeWithSingleSignOn finishes a browser login using a session which was resumed from a previous upstream
+// login, in the same way that the callback endpoint finishes a login after the upstream login.
+func (h *authorizeHandler) authorizeWithSingleSignOn(
+	r *http.Request,
+	w http.ResponseWriter,
+	authorizeRequester fosite.AuthorizeRequester,
+	session *psession.PinnipedSession,
+) error {
+	// The consent page checks that the decision is made by the same browser, using the CSRF cookie.
+	csrfValue := readCSRFCookie(r, h.cookieCodec)
+	if csrfValue == "" {
+		var err error
+		csrfValue, err = h.generateCSRF()
+		if err != nil {
+			plog.Error("authorize generate error", err)
+			return fosite.ErrServerError.WithHint("Server could not generate necessary values.").WithWrap(err)
+		}
+		if err := addCSRFSetCookieHeader(w, csrfValue, h.cookieCodec); err != nil {
+			plog.Error("error setting CSRF cookie", err)
+			return fosite.ErrServerError.WithHint("Error encoding CSRF cookie.").WithWrap(err)
+		}
+	}
+
+	redirectedToConsentPage, err := h.consentPrompter.MaybeRedirectToConsentPage(w, r, authorizeRequester, session, csrfValue)
+	if err != nil {
+		plog.Error("error while requesting consent", err)
+		return fosite.ErrServerError.WithHint("Error while requesting consent.").WithWrap(err)
+	}
+	if redirectedToConsentPage {
+		return nil
+	}
+
+	// The authorization code must be stored, unlike the rest of the browser flow at this endpoint.
+	oidc.PerformAuthcodeRedirect(r, w, h.oauthHelperWithStorage, authorizeRequester, session, false)
+
+	return nil
+}
+
 func shouldShowIDPChooser(
 	idpFinder federationdomainproviders.FederationDomainIdentityProvidersFinderI,
 	idpNameQueryParamValue string,
@@ -414,6 +547,7 @@ func generateUpstreamAuthorizeRequestState(
 		EncodedStateParam: encodedStateParamValue,
 		PKCE:              pkceValue,
 		Nonce:             nonceValue,
+		ACRValues:         downstreamsession.RequiredACRValues(authorizeRequester.GetClient()),
 	}, nil
 }
 
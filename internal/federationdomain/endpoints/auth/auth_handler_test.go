@@ -25,6 +25,7 @@ import (
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/client-go/kubernetes/fake"
 	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	clocktesting "k8s.io/utils/clock/testing"
 	"k8s.io/utils/ptr"
 
 	supervisorfake "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/fake"
@@ -32,15 +33,23 @@ import (
 	"go.pinniped.dev/internal/authenticators"
 	"go.pinniped.dev/internal/federationdomain/csrftoken"
 	"go.pinniped.dev/internal/federationdomain/endpoints/jwks"
+	"go.pinniped.dev/internal/federationdomain/formposthtml"
+	"go.pinniped.dev/internal/federationdomain/loginthrottle"
 	"go.pinniped.dev/internal/federationdomain/oidc"
 	"go.pinniped.dev/internal/federationdomain/oidcclientvalidator"
+	"go.pinniped.dev/internal/federationdomain/secondfactor"
 	"go.pinniped.dev/internal/federationdomain/storage"
+	"go.pinniped.dev/internal/fositestorage/pushedauthorizerequest"
 	"go.pinniped.dev/internal/here"
+	"go.pinniped.dev/internal/plog"
 	"go.pinniped.dev/internal/psession"
 	"go.pinniped.dev/internal/testutil"
 	"go.pinniped.dev/internal/testutil/oidctestutil"
 	"go.pinniped.dev/internal/testutil/testidplister"
 	"go.pinniped.dev/internal/testutil/transformtestutil"
+	"go.pinniped.dev/internal/totp"
+	"go.pinniped.dev/internal/webauthn"
+	"go.pinniped.dev/internal/webauthncredentialstorage"
 	"go.pinniped.dev/pkg/oidcclient/nonce"
 	"go.pinniped.dev/pkg/oidcclient/pkce"
 )
@@ -144,6 +153,12 @@ func TestAuthorizationEndpoint(t *testing.T) { //nolint:gocyclo
 			"state":             happyState,
 		}
 
+		invalidTargetErrorQuery = map[string]string{
+			"error":             "invalid_target",
+			"error_description": "The requested resource is invalid, missing, unknown, or malformed. The resource 'https://cluster1.example.com' is not allowed.",
+			"state":             happyState,
+		}
+
 		fositeInvalidStateErrorQuery = map[string]string{
 			"error":             "invalid_state",
 			"error_description": "The state is missing or does not have enough characters and is therefore considered too weak. Request parameter 'state' must be at least be 8 characters long to ensure sufficient entropy.",
@@ -168,6 +183,12 @@ func TestAuthorizationEndpoint(t *testing.T) { //nolint:gocyclo
 			"state":             happyState,
 		}
 
+		fositeAccessDeniedWithOneTimeCodeRequiredHintErrorQuery = map[string]string{
+			"error":             "access_denied",
+			"error_description": "The resource owner or authorization server denied the request. This identity provider requires a one-time code, so you must log in using a web browser.",
+			"state":             happyState,
+		}
+
 		fositeAccessDeniedWithMissingUsernamePasswordHintErrorQuery = map[string]string{
 			"error":             "access_denied",
 			"error_description": "The resource owner or authorization server denied the request. Missing or blank username or password.",
@@ -268,14 +289,14 @@ func TestAuthorizationEndpoint(t *testing.T) { //nolint:gocyclo
 		// Inject this into our test subject at the last second so we get a fresh storage for every test.
 		// Use lower minimum required bcrypt cost than we would use in production to keep unit the tests fast.
 		kubeOauthStore := storage.NewKubeStorage(secretsClient, oidcClientsClient, timeoutsConfiguration, bcrypt.MinCost)
-		return oidc.FositeOauth2Helper(kubeOauthStore, downstreamIssuer, hmacSecretFunc, jwksProviderIsUnused, timeoutsConfiguration), kubeOauthStore
+		return oidc.FositeOauth2Helper(kubeOauthStore, downstreamIssuer, hmacSecretFunc, jwksProviderIsUnused, timeoutsConfiguration, formposthtml.TemplateForContext, nil, nil, nil), kubeOauthStore
 	}
 
 	createOauthHelperWithNullStorage := func(secretsClient v1.SecretInterface, oidcClientsClient v1alpha1.OIDCClientInterface) (fosite.OAuth2Provider, *storage.NullStorage) {
 		// Configure fosite the same way that the production code would, using NullStorage to turn off storage.
 		// Use lower minimum required bcrypt cost than we would use in production to keep unit the tests fast.
 		nullOauthStore := storage.NewNullStorage(secretsClient, oidcClientsClient, bcrypt.MinCost)
-		return oidc.FositeOauth2Helper(nullOauthStore, downstreamIssuer, hmacSecretFunc, jwksProviderIsUnused, timeoutsConfiguration), nullOauthStore
+		return oidc.FositeOauth2Helper(nullOauthStore, downstreamIssuer, hmacSecretFunc, jwksProviderIsUnused, timeoutsConfiguration, formposthtml.TemplateForContext, nil, nil, nil), nullOauthStore
 	}
 
 	upstreamAuthURL, err := url.Parse("https://some-upstream-idp:8443/auth")
@@ -1689,6 +1710,18 @@ func TestAuthorizationEndpoint(t *testing.T) { //nolint:gocyclo
 			wantLocationHeader: urlWithQuery(downstreamRedirectURI, fositeAccessDeniedErrorQuery),
 			wantBodyString:     "",
 		},
+		{
+			name:                 "LDAP upstream which requires a one-time code cannot be used without a browser",
+			idps:                 testidplister.NewUpstreamIDPListerBuilder().WithLDAP(upstreamLDAPIdentityProviderBuilder().WithTOTPConfig(&totp.Config{Issuer: "Pinniped"}).Build()),
+			method:               http.MethodGet,
+			path:                 happyGetRequestPathForLDAPUpstream,
+			customUsernameHeader: ptr.To(happyLDAPUsername),
+			customPasswordHeader: ptr.To(happyLDAPPassword),
+			wantStatus:           http.StatusFound,
+			wantContentType:      jsonContentType,
+			wantLocationHeader:   urlWithQuery(downstreamRedirectURI, fositeAccessDeniedWithOneTimeCodeRequiredHintErrorQuery),
+			wantBodyString:       "",
+		},
 		{
 			name:                 "wrong upstream password for LDAP authentication",
 			idps:                 testidplister.NewUpstreamIDPListerBuilder().WithLDAP(upstreamLDAPIdentityProviderBuilder().Build()),
@@ -2207,6 +2240,22 @@ func TestAuthorizationEndpoint(t *testing.T) { //nolint:gocyclo
 			wantLocationHeader: urlWithQuery(downstreamRedirectURI, fositeInvalidScopeErrorQuery),
 			wantBodyString:     "",
 		},
+		{
+			name:               "downstream resource is not allowed for dynamic client using OIDC upstream browser flow",
+			idps:               testidplister.NewUpstreamIDPListerBuilder().WithOIDC(upstreamOIDCIdentityProviderBuilder().Build()),
+			kubeResources:      addFullyCapableDynamicClientAndSecretToKubeResources,
+			generateCSRF:       happyCSRFGenerator,
+			generatePKCE:       happyPKCEGenerator,
+			generateNonce:      happyNonceGenerator,
+			stateEncoder:       happyStateEncoder,
+			cookieEncoder:      happyCookieEncoder,
+			method:             http.MethodGet,
+			path:               modifiedHappyGetRequestPathForOIDCUpstream(map[string]string{"client_id": dynamicClientID, "scope": "openid", "resource": "https://cluster1.example.com"}),
+			wantStatus:         http.StatusSeeOther,
+			wantContentType:    jsonContentType,
+			wantLocationHeader: urlWithQuery(downstreamRedirectURI, invalidTargetErrorQuery),
+			wantBodyString:     "",
+		},
 		{
 			name:                 "downstream scopes do not match what is configured for client using OIDC upstream password grant",
 			idps:                 testidplister.NewUpstreamIDPListerBuilder().WithOIDC(passwordGrantUpstreamOIDCIdentityProviderBuilder().Build()),
@@ -3483,7 +3532,7 @@ func TestAuthorizationEndpoint(t *testing.T) { //nolint:gocyclo
 			test.kubeResources(t, supervisorClient, kubeClient)
 		}
 
-		reqContext := context.WithValue(context.Background(), struct{ name string }{name: "test"}, "request-context")
+		reqContext := context.WithValue(context.Background(), testidplister.RequestContextKey{}, "request-context")
 		req := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body)).WithContext(reqContext)
 		req.Header.Set("Content-Type", test.contentType)
 		if test.csrfCookie != "" {
@@ -3624,6 +3673,8 @@ func TestAuthorizationEndpoint(t *testing.T) { //nolint:gocyclo
 				oauthHelperWithNullStorage, oauthHelperWithRealStorage,
 				test.generateCSRF, test.generatePKCE, test.generateNonce,
 				test.stateEncoder, test.cookieEncoder,
+				pushedauthorizerequest.New(secretsClient, time.Now),
+				nil, nil, nil, nil,
 			)
 			runOneTestCase(t, test, subject, kubeOauthStore, supervisorClient, kubeClient, secretsClient)
 		})
@@ -3647,6 +3698,8 @@ func TestAuthorizationEndpoint(t *testing.T) { //nolint:gocyclo
 			oauthHelperWithNullStorage, oauthHelperWithRealStorage,
 			test.generateCSRF, test.generatePKCE, test.generateNonce,
 			test.stateEncoder, test.cookieEncoder,
+			pushedauthorizerequest.New(secretsClient, time.Now),
+			nil, nil, nil, nil,
 		)
 
 		runOneTestCase(t, test, subject, kubeOauthStore, supervisorClient, kubeClient, secretsClient)
@@ -3694,6 +3747,149 @@ func TestAuthorizationEndpoint(t *testing.T) { //nolint:gocyclo
 		// on every request.
 		runOneTestCase(t, test, subject, kubeOauthStore, supervisorClient, kubeClient, secretsClient)
 	})
+
+	t.Run("locks out a username after too many failed logins when a login throttle is configured", func(t *testing.T) {
+		var test testCase
+		for _, tc := range tests {
+			if tc.name == "wrong upstream password for LDAP authentication" {
+				test = tc
+			}
+		}
+		require.NotEmpty(t, test.name)
+
+		kubeClient := fake.NewSimpleClientset()
+		supervisorClient := supervisorfake.NewSimpleClientset()
+		secretsClient := kubeClient.CoreV1().Secrets("some-namespace")
+		oidcClientsClient := supervisorClient.ConfigV1alpha1().OIDCClients("some-namespace")
+		oauthHelperWithRealStorage, kubeOauthStore := createOauthHelperWithRealStorage(secretsClient, oidcClientsClient)
+		oauthHelperWithNullStorage, _ := createOauthHelperWithNullStorage(secretsClient, oidcClientsClient)
+		loginThrottle := loginthrottle.New(downstreamIssuer, loginthrottle.Config{
+			RequestsPerMinutePerSourceIP: 100,
+			FailedAttemptsBeforeLockout:  2,
+			LockoutDuration:              time.Minute,
+		}, clocktesting.NewFakeClock(time.Now()), plog.New())
+		subject := NewHandler(
+			downstreamIssuer,
+			test.idps.BuildFederationDomainIdentityProvidersListerFinder(),
+			oauthHelperWithNullStorage, oauthHelperWithRealStorage,
+			test.generateCSRF, test.generatePKCE, test.generateNonce,
+			test.stateEncoder, test.cookieEncoder,
+			pushedauthorizerequest.New(secretsClient, time.Now),
+			loginThrottle, nil, nil, nil,
+		)
+
+		runOneTestCase(t, test, subject, kubeOauthStore, supervisorClient, kubeClient, secretsClient)
+		require.False(t, loginThrottle.LockedOut(ldapUpstreamName, happyLDAPUsername, "192.0.2.1"))
+		runOneTestCase(t, test, subject, kubeOauthStore, supervisorClient, kubeClient, secretsClient)
+		require.True(t, loginThrottle.LockedOut(ldapUpstreamName, happyLDAPUsername, "192.0.2.1"))
+
+		// While locked out, even the correct password is not checked with the upstream.
+		test.customPasswordHeader = ptr.To(happyLDAPPassword)
+		test.wantLocationHeader = urlWithQuery(downstreamRedirectURI, map[string]string{
+			"error":             "access_denied",
+			"error_description": "The resource owner or authorization server denied the request. Too many failed login attempts. Please try again later.",
+			"state":             happyState,
+		})
+		runOneTestCase(t, test, subject, kubeOauthStore, supervisorClient, kubeClient, secretsClient)
+	})
+
+	t.Run("rejects logins without a browser when the FederationDomain uses passkeys", func(t *testing.T) {
+		var test testCase
+		for _, tc := range tests {
+			if tc.name == "LDAP upstream which requires a one-time code cannot be used without a browser" {
+				test = tc
+			}
+		}
+		require.NotEmpty(t, test.name)
+		test.idps = testidplister.NewUpstreamIDPListerBuilder().WithLDAP(upstreamLDAPIdentityProviderBuilder().Build())
+		test.wantLocationHeader = urlWithQuery(downstreamRedirectURI, map[string]string{
+			"error":             "access_denied",
+			"error_description": "The resource owner or authorization server denied the request. This identity provider requires a passkey, so you must log in using a web browser.",
+			"state":             happyState,
+		})
+
+		kubeClient := fake.NewSimpleClientset()
+		supervisorClient := supervisorfake.NewSimpleClientset()
+		secretsClient := kubeClient.CoreV1().Secrets("some-namespace")
+		oidcClientsClient := supervisorClient.ConfigV1alpha1().OIDCClients("some-namespace")
+		oauthHelperWithRealStorage, kubeOauthStore := createOauthHelperWithRealStorage(secretsClient, oidcClientsClient)
+		oauthHelperWithNullStorage, _ := createOauthHelperWithNullStorage(secretsClient, oidcClientsClient)
+		relyingParty, err := webauthn.NewRelyingParty(downstreamIssuer)
+		require.NoError(t, err)
+		webAuthn := secondfactor.NewWebAuthnVerifier(secondfactor.WebAuthnConfig{Enforcement: secondfactor.WebAuthnOptional},
+			relyingParty, webauthncredentialstorage.New(secretsClient), securecookie.New([]byte("fake-hash-secret"), nil), time.Now)
+		subject := NewHandler(
+			downstreamIssuer,
+			test.idps.BuildFederationDomainIdentityProvidersListerFinder(),
+			oauthHelperWithNullStorage, oauthHelperWithRealStorage,
+			test.generateCSRF, test.generatePKCE, test.generateNonce,
+			test.stateEncoder, test.cookieEncoder,
+			pushedauthorizerequest.New(secretsClient, time.Now),
+			nil, nil, nil, webAuthn,
+		)
+
+		runOneTestCase(t, test, subject, kubeOauthStore, supervisorClient, kubeClient, secretsClient)
+	})
+
+	t.Run("uses the params of a pushed authorization request once", func(t *testing.T) {
+		test := tests[0]
+		// Double-check that we are re-using the happy path test case here as we intend.
+		require.Equal(t, "OIDC upstream browser flow happy path using GET without a CSRF cookie", test.name)
+
+		kubeClient := fake.NewSimpleClientset()
+		supervisorClient := supervisorfake.NewSimpleClientset()
+		secretsClient := kubeClient.CoreV1().Secrets("some-namespace")
+		oidcClientsClient := supervisorClient.ConfigV1alpha1().OIDCClients("some-namespace")
+		oauthHelperWithRealStorage, kubeOauthStore := createOauthHelperWithRealStorage(secretsClient, oidcClientsClient)
+		oauthHelperWithNullStorage, _ := createOauthHelperWithNullStorage(secretsClient, oidcClientsClient)
+		// Use a separate client for the pushed authorization requests, so the test case can assert that the
+		// authorize endpoint did not store anything else.
+		pushedAuthorizeRequests := pushedauthorizerequest.New(fake.NewSimpleClientset().CoreV1().Secrets("some-namespace"), time.Now)
+		subject := NewHandler(
+			downstreamIssuer,
+			test.idps.BuildFederationDomainIdentityProvidersListerFinder(),
+			oauthHelperWithNullStorage, oauthHelperWithRealStorage,
+			test.generateCSRF, test.generatePKCE, test.generateNonce,
+			test.stateEncoder, test.cookieEncoder,
+			pushedAuthorizeRequests,
+			nil, nil, nil, nil,
+		)
+
+		pushedForm, err := url.ParseQuery(strings.TrimPrefix(test.path, "/some/path?"))
+		require.NoError(t, err)
+		err = pushedAuthorizeRequests.Create(context.Background(), "some-request-id", &pushedauthorizerequest.Request{
+			ClientID:  pinnipedCLIClientID,
+			Form:      pushedForm,
+			ExpiresAt: time.Now().Add(pushedauthorizerequest.Lifetime),
+		})
+		require.NoError(t, err)
+		requestURI := pushedauthorizerequest.RequestURI("some-request-id")
+
+		requireError := func(t *testing.T, query map[string]string, wantError, wantDescriptionSubstring string) {
+			t.Helper()
+			rsp := httptest.NewRecorder()
+			subject.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, pathWithQuery("/some/path", query), nil))
+			require.Equal(t, http.StatusBadRequest, rsp.Code)
+			require.Contains(t, rsp.Body.String(), fmt.Sprintf(`"error":%q`, wantError))
+			require.Contains(t, rsp.Body.String(), wantDescriptionSubstring)
+		}
+
+		// Another client may not use the pushed authorization request.
+		requireError(t, map[string]string{"client_id": "some-other-client", "request_uri": requestURI},
+			"invalid_request", "The 'client_id' must match the pushed authorization request.")
+
+		// The pushed params are used instead of the params of the request.
+		test.path = pathWithQuery("/some/path", map[string]string{"client_id": pinnipedCLIClientID, "request_uri": requestURI})
+		runOneTestCase(t, test, subject, kubeOauthStore, supervisorClient, kubeClient, secretsClient)
+
+		// The pushed authorization request may not be used again.
+		requireError(t, map[string]string{"client_id": pinnipedCLIClientID, "request_uri": requestURI},
+			"invalid_request_uri", "The pushed authorization request does not exist, has expired, or was already used.")
+
+		// Unknown pushed authorization requests are rejected.
+		requireError(t, map[string]string{"client_id": pinnipedCLIClientID, "request_uri": pushedauthorizerequest.RequestURI("some-unknown-id")},
+			"invalid_request_uri", "The pushed authorization request does not exist, has expired, or was already used.")
+	})
 }
 
 type errorReturningEncoder struct {
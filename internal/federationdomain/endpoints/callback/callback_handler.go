@@ -5,18 +5,26 @@
 package callback
 
 import (
+	"context"
 	"net/http"
 	"net/url"
 
+	"github.com/go-jose/go-jose/v3/jwt"
 	"github.com/ory/fosite"
 
 	"go.pinniped.dev/internal/federationdomain/downstreamsession"
+	"go.pinniped.dev/internal/federationdomain/endpoints/consent"
 	"go.pinniped.dev/internal/federationdomain/federationdomainproviders"
 	"go.pinniped.dev/internal/federationdomain/formposthtml"
+	"go.pinniped.dev/internal/federationdomain/jarm"
 	"go.pinniped.dev/internal/federationdomain/oidc"
+	"go.pinniped.dev/internal/federationdomain/resolvedprovider"
+	"go.pinniped.dev/internal/federationdomain/resourceindicator"
+	"go.pinniped.dev/internal/federationdomain/singlesignon"
 	"go.pinniped.dev/internal/httputil/httperr"
 	"go.pinniped.dev/internal/httputil/securityheader"
 	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/internal/tracing"
 )
 
 func NewHandler(
@@ -24,6 +32,8 @@ func NewHandler(
 	oauthHelper fosite.OAuth2Provider,
 	stateDecoder, cookieDecoder oidc.Decoder,
 	redirectURI string,
+	consentPrompter *consent.Prompter, // may be nil, in which case users are never asked for consent
+	singleSignOn *singlesignon.Sessions, // may be nil, in which case logins are not remembered for other clients
 ) http.Handler {
 	handler := httperr.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
 		state, err := validateRequest(r, stateDecoder, cookieDecoder)
@@ -37,6 +47,13 @@ func NewHandler(
 			return httperr.New(http.StatusUnprocessableEntity, "upstream provider not found")
 		}
 
+		if err := validateJWTSecuredAuthorizationResponse(r, idp); err != nil {
+			plog.InfoErr("invalid JWT secured authorization response", err,
+				"identityProviderDisplayName", idp.GetDisplayName(),
+				"identityProviderResourceName", idp.GetProvider().GetResourceName())
+			return err
+		}
+
 		downstreamAuthParams, err := url.ParseQuery(state.AuthParams)
 		if err != nil {
 			plog.Error("error reading state downstream auth params", err)
@@ -60,7 +77,18 @@ func NewHandler(
 		// an error if the client requested a scope that they are not allowed to request, so we don't need to worry about that here.
 		downstreamsession.AutoApproveScopes(authorizeRequester)
 
-		identity, loginExtras, err := idp.LoginFromCallback(r.Context(), authcode(r), state.PKCECode, state.Nonce, redirectURI)
+		if err := resourceindicator.GrantIfRequested(authorizeRequester); err != nil {
+			// This shouldn't really happen because the authorization endpoint has already validated the resources,
+			// unless the client's allowed resources were changed in the meantime.
+			plog.Error("error granting resources from state downstream auth params", err,
+				"fositeErr", oidc.FositeErrorForLog(err))
+			return httperr.New(http.StatusBadRequest, "error using state downstream auth params")
+		}
+
+		// Exchanging the authcode calls the upstream, so it gets its own span.
+		upstreamCtx, span := tracing.Start(r.Context(), "upstream login", tracing.AttributeIdentityProvider.String(idp.GetDisplayName()))
+		identity, loginExtras, err := idp.LoginFromCallback(upstreamCtx, authcode(r), state.PKCECode, state.Nonce, redirectURI)
+		tracing.EndWithError(span, err)
 		if err != nil {
 			plog.InfoErr("unable to complete login from callback", err,
 				"identityProviderDisplayName", idp.GetDisplayName(),
@@ -72,7 +100,7 @@ func NewHandler(
 		session, err := downstreamsession.NewPinnipedSession(r.Context(), idp, &downstreamsession.SessionConfig{
 			UpstreamIdentity:    identity,
 			UpstreamLoginExtras: loginExtras,
-			ClientID:            authorizeRequester.GetClient().GetID(),
+			Client:              authorizeRequester.GetClient(),
 			GrantedScopes:       authorizeRequester.GetGrantedScopes(),
 		})
 		if err != nil {
@@ -83,6 +111,25 @@ func NewHandler(
 			return httperr.Wrap(http.StatusUnprocessableEntity, err.Error(), err)
 		}
 
+		if err := singleSignOn.Remember(w, r, idp, identity, loginExtras, session); err != nil {
+			// Single sign-on is only a convenience, so do not fail the login.
+			plog.Error("error remembering login for single sign-on", err,
+				"identityProviderDisplayName", idp.GetDisplayName(),
+				"identityProviderResourceName", idp.GetProvider().GetResourceName())
+		}
+
+		redirectedToConsentPage, err := consentPrompter.MaybeRedirectToConsentPage(w, r, authorizeRequester, session, state.CSRFToken)
+		if err != nil {
+			plog.Error("error while requesting consent", err,
+				"identityProviderDisplayName", idp.GetDisplayName(),
+				"identityProviderResourceName", idp.GetProvider().GetResourceName(),
+				"supervisorCallbackURL", redirectURI)
+			return httperr.Wrap(http.StatusInternalServerError, "error while requesting consent", err)
+		}
+		if redirectedToConsentPage {
+			return nil
+		}
+
 		authorizeResponder, err := oauthHelper.NewAuthorizeResponse(r.Context(), authorizeRequester, session)
 		if err != nil {
 			plog.WarningErr("error while generating and saving authcode", err,
@@ -109,6 +156,11 @@ func validateRequest(r *http.Request, stateDecoder, cookieDecoder oidc.Decoder)
 		return nil, httperr.Newf(http.StatusMethodNotAllowed, "%s (try GET)", r.Method)
 	}
 
+	if err := unpackJWTSecuredAuthorizationResponse(r); err != nil {
+		plog.InfoErr("error reading JWT secured authorization response", err)
+		return nil, err
+	}
+
 	_, decodedState, err := oidc.ReadStateParamAndValidateCSRFCookie(r, cookieDecoder, stateDecoder)
 	if err != nil {
 		plog.InfoErr("state or CSRF error", err)
@@ -122,3 +174,65 @@ func validateRequest(r *http.Request, stateDecoder, cookieDecoder oidc.Decoder)
 
 	return decodedState, nil
 }
+
+// jwtSecuredAuthorizationResponseValidator is implemented by the identity providers which can return their
+// authorization responses to the callback as JWTs (JARM).
+type jwtSecuredAuthorizationResponseValidator interface {
+	UsesJWTSecuredAuthorizationResponses() bool
+	ValidateJWTSecuredAuthorizationResponse(ctx context.Context, response string) (url.Values, error)
+}
+
+// unpackJWTSecuredAuthorizationResponse replaces the params of a JWT secured authorization response (JARM) with
+// the params which are carried by its JWT, e.g. the code and the state. The JWT cannot be validated yet, because
+// the upstream provider which signed it is only known after the state param has been read, so none of these params
+// may be trusted until validateJWTSecuredAuthorizationResponse has been called.
+func unpackJWTSecuredAuthorizationResponse(r *http.Request) error {
+	response := r.URL.Query().Get(jarm.ResponseParam)
+	if response == "" {
+		return nil
+	}
+
+	token, err := jwt.ParseSigned(response)
+	if err != nil {
+		return httperr.Wrap(http.StatusBadRequest, "error reading JWT secured authorization response", err)
+	}
+	var claims map[string]any
+	if err := token.UnsafeClaimsWithoutVerification(&claims); err != nil {
+		return httperr.Wrap(http.StatusBadRequest, "error reading JWT secured authorization response", err)
+	}
+
+	r.Form = url.Values{jarm.ResponseParam: {response}}
+	for name, value := range claims {
+		if stringValue, ok := value.(string); ok {
+			r.Form.Set(name, stringValue)
+		}
+	}
+	return nil
+}
+
+// validateJWTSecuredAuthorizationResponse checks that the upstream provider returned a JWT secured authorization
+// response if and only if it is configured to do so, and that the JWT was signed by the upstream provider.
+func validateJWTSecuredAuthorizationResponse(r *http.Request, idp resolvedprovider.FederationDomainResolvedIdentityProvider) error {
+	response := r.Form.Get(jarm.ResponseParam)
+
+	validator, ok := idp.(jwtSecuredAuthorizationResponseValidator)
+	if !ok || !validator.UsesJWTSecuredAuthorizationResponses() {
+		if response != "" {
+			return httperr.New(http.StatusBadRequest, "unexpected JWT secured authorization response")
+		}
+		return nil
+	}
+
+	if response == "" {
+		return httperr.New(http.StatusBadRequest, "JWT secured authorization response is required")
+	}
+	params, err := validator.ValidateJWTSecuredAuthorizationResponse(r.Context(), response)
+	if err != nil {
+		return err
+	}
+	// The params which have already been used must be the same as the params of the validated response.
+	if params.Get("state") != r.Form.Get("state") || params.Get("code") != authcode(r) {
+		return httperr.New(http.StatusBadRequest, "invalid JWT secured authorization response")
+	}
+	return nil
+}
@@ -14,19 +14,30 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
 	"github.com/gorilla/securecookie"
+	"github.com/ory/fosite"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes/fake"
 
 	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
 	supervisorfake "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/fake"
+	"go.pinniped.dev/internal/crud"
+	"go.pinniped.dev/internal/federationdomain/csrftoken"
+	"go.pinniped.dev/internal/federationdomain/endpoints/consent"
 	"go.pinniped.dev/internal/federationdomain/endpoints/jwks"
+	"go.pinniped.dev/internal/federationdomain/formposthtml"
 	"go.pinniped.dev/internal/federationdomain/oidc"
 	"go.pinniped.dev/internal/federationdomain/oidcclientvalidator"
 	"go.pinniped.dev/internal/federationdomain/storage"
 	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
+	"go.pinniped.dev/internal/fositestorage/authorizationcode"
+	consentstorage "go.pinniped.dev/internal/fositestorage/consent"
+	"go.pinniped.dev/internal/httputil/httperr"
 	"go.pinniped.dev/internal/psession"
 	"go.pinniped.dev/internal/testutil"
 	"go.pinniped.dev/internal/testutil/oidctestutil"
@@ -215,6 +226,15 @@ func TestCallbackEndpoint(t *testing.T) {
 		require.NoError(t, kubeClient.Tracker().Add(secret))
 	}
 
+	addFullyCapableDynamicClientRequiringConsentAndSecretToKubeResources := func(t *testing.T, supervisorClient *supervisorfake.Clientset, kubeClient *fake.Clientset) {
+		oidcClient, secret := testutil.FullyCapableOIDCClientAndStorageSecret(t,
+			"some-namespace", downstreamDynamicClientID, downstreamDynamicClientUID, downstreamRedirectURI, nil,
+			[]string{testutil.HashedPassword1AtGoMinCost}, oidcclientvalidator.Validate)
+		oidcClient.Spec.RequireConsent = true
+		require.NoError(t, supervisorClient.Tracker().Add(oidcClient))
+		require.NoError(t, kubeClient.Tracker().Add(secret))
+	}
+
 	prefixUsernameAndGroupsPipeline := transformtestutil.NewPrefixingPipeline(t, transformationUsernamePrefix, transformationGroupsPrefix)
 	rejectAuthPipeline := transformtestutil.NewRejectAllAuthPipeline(t)
 
@@ -231,6 +251,8 @@ func TestCallbackEndpoint(t *testing.T) {
 		wantContentType                   string
 		wantBody                          string
 		wantRedirectLocationRegexp        string
+		wantRedirectLocation              string
+		wantPendingConsentRequest         bool
 		wantBodyFormResponseRegexp        string
 		wantDownstreamGrantedScopes       []string
 		wantDownstreamIDTokenSubject      string
@@ -400,6 +422,23 @@ func TestCallbackEndpoint(t *testing.T) {
 				args:                    happyOIDCUpstreamExchangeAuthcodeAndValidateTokenArgs,
 			},
 		},
+		{
+			name:                      "GET with good state and cookie and successful upstream token exchange returns 303 to the consent page when using dynamic client which requires consent",
+			idps:                      testidplister.NewUpstreamIDPListerBuilder().WithOIDC(happyOIDCUpstream().Build()),
+			kubeResources:             addFullyCapableDynamicClientRequiringConsentAndSecretToKubeResources,
+			method:                    http.MethodGet,
+			path:                      newRequestPath().WithState(happyOIDCStateForDynamicClient).String(),
+			csrfCookie:                happyCSRFCookie,
+			wantStatus:                http.StatusSeeOther,
+			wantContentType:           htmlContentType,
+			wantRedirectLocation:      downstreamIssuer + "/consent?id=some-consent-id",
+			wantBody:                  "<a href=\"" + downstreamIssuer + "/consent?id=some-consent-id\">See Other</a>.\n\n",
+			wantPendingConsentRequest: true,
+			wantOIDCAuthcodeExchangeCall: &expectedOIDCAuthcodeExchange{
+				performedByUpstreamName: happyOIDCUpstreamIDPName,
+				args:                    happyOIDCUpstreamExchangeAuthcodeAndValidateTokenArgs,
+			},
+		},
 		{
 			name:                              "GET with authcode exchange that returns an access token but no refresh token when there is a userinfo endpoint returns 303 to downstream client callback with its state and code",
 			idps:                              testidplister.NewUpstreamIDPListerBuilder().WithOIDC(happyOIDCUpstream().WithEmptyRefreshToken().WithAccessToken(oidcUpstreamAccessToken, metav1.NewTime(time.Now().Add(9*time.Hour))).WithUserInfoURL().Build()),
@@ -1067,6 +1106,88 @@ func TestCallbackEndpoint(t *testing.T) {
 			wantContentType: htmlContentType,
 			wantBody:        "Method Not Allowed: DELETE (try GET)\n",
 		},
+		{
+			name: "GET with good state and cookie and a valid JWT secured authorization response returns 303 to downstream client callback with its state and code",
+			idps: testidplister.NewUpstreamIDPListerBuilder().WithOIDC(happyOIDCUpstream().
+				WithJWTSecuredAuthorizationResponses(url.Values{"code": {happyUpstreamAuthcode}, "state": {happyOIDCState}}, nil).
+				Build()),
+			method:                            http.MethodGet,
+			path:                              newRequestPath().WithState(happyOIDCState).WithJWTSecuredAuthorizationResponse(t).String(),
+			csrfCookie:                        happyCSRFCookie,
+			wantStatus:                        http.StatusSeeOther,
+			wantRedirectLocationRegexp:        happyDownstreamRedirectLocationRegexp,
+			wantBody:                          "",
+			wantDownstreamIDTokenSubject:      oidcUpstreamIssuer + "?idpName=" + happyOIDCUpstreamIDPName + "&sub=" + oidcUpstreamSubjectQueryEscaped,
+			wantDownstreamIDTokenUsername:     oidcUpstreamUsername,
+			wantDownstreamIDTokenGroups:       oidcUpstreamGroupMembership,
+			wantDownstreamRequestedScopes:     happyDownstreamScopesRequested,
+			wantDownstreamGrantedScopes:       happyDownstreamScopesGranted,
+			wantDownstreamNonce:               downstreamNonce,
+			wantDownstreamClientID:            downstreamPinnipedClientID,
+			wantDownstreamPKCEChallenge:       downstreamPKCEChallenge,
+			wantDownstreamPKCEChallengeMethod: downstreamPKCEChallengeMethod,
+			wantDownstreamCustomSessionData:   happyDownstreamCustomSessionDataForOIDCUpstream,
+			wantOIDCAuthcodeExchangeCall: &expectedOIDCAuthcodeExchange{
+				performedByUpstreamName: happyOIDCUpstreamIDPName,
+				args:                    happyOIDCUpstreamExchangeAuthcodeAndValidateTokenArgs,
+			},
+		},
+		{
+			name: "JWT secured authorization response is invalid",
+			idps: testidplister.NewUpstreamIDPListerBuilder().WithOIDC(happyOIDCUpstream().
+				WithJWTSecuredAuthorizationResponses(nil, httperr.New(http.StatusBadRequest, "received invalid JWT secured authorization response")).
+				Build()),
+			method:          http.MethodGet,
+			path:            newRequestPath().WithState(happyOIDCState).WithJWTSecuredAuthorizationResponse(t).String(),
+			csrfCookie:      happyCSRFCookie,
+			wantStatus:      http.StatusBadRequest,
+			wantContentType: htmlContentType,
+			wantBody:        "Bad Request: received invalid JWT secured authorization response\n",
+		},
+		{
+			name: "JWT secured authorization response does not match the params which were read before it was validated",
+			idps: testidplister.NewUpstreamIDPListerBuilder().WithOIDC(happyOIDCUpstream().
+				WithJWTSecuredAuthorizationResponses(url.Values{"code": {"some-other-code"}, "state": {happyOIDCState}}, nil).
+				Build()),
+			method:          http.MethodGet,
+			path:            newRequestPath().WithState(happyOIDCState).WithJWTSecuredAuthorizationResponse(t).String(),
+			csrfCookie:      happyCSRFCookie,
+			wantStatus:      http.StatusBadRequest,
+			wantContentType: htmlContentType,
+			wantBody:        "Bad Request: invalid JWT secured authorization response\n",
+		},
+		{
+			name: "JWT secured authorization response is required by the upstream provider but was not used",
+			idps: testidplister.NewUpstreamIDPListerBuilder().WithOIDC(happyOIDCUpstream().
+				WithJWTSecuredAuthorizationResponses(url.Values{"code": {happyUpstreamAuthcode}, "state": {happyOIDCState}}, nil).
+				Build()),
+			method:          http.MethodGet,
+			path:            newRequestPath().WithState(happyOIDCState).String(),
+			csrfCookie:      happyCSRFCookie,
+			wantStatus:      http.StatusBadRequest,
+			wantContentType: htmlContentType,
+			wantBody:        "Bad Request: JWT secured authorization response is required\n",
+		},
+		{
+			name:            "JWT secured authorization response is not expected from the upstream provider",
+			idps:            testidplister.NewUpstreamIDPListerBuilder().WithOIDC(happyOIDCUpstream().Build()),
+			method:          http.MethodGet,
+			path:            newRequestPath().WithState(happyOIDCState).WithJWTSecuredAuthorizationResponse(t).String(),
+			csrfCookie:      happyCSRFCookie,
+			wantStatus:      http.StatusBadRequest,
+			wantContentType: htmlContentType,
+			wantBody:        "Bad Request: unexpected JWT secured authorization response\n",
+		},
+		{
+			name:            "JWT secured authorization response is not a JWT",
+			idps:            testidplister.NewUpstreamIDPListerBuilder().WithOIDC(happyOIDCUpstream().Build()),
+			method:          http.MethodGet,
+			path:            "/downstream-provider-name/callback?response=not-a-jwt",
+			csrfCookie:      happyCSRFCookie,
+			wantStatus:      http.StatusBadRequest,
+			wantContentType: htmlContentType,
+			wantBody:        "Bad Request: error reading JWT secured authorization response\n",
+		},
 		{
 			name:            "code param was not included on request",
 			idps:            testidplister.NewUpstreamIDPListerBuilder().WithOIDC(happyOIDCUpstream().Build()),
@@ -1755,10 +1876,13 @@ func TestCallbackEndpoint(t *testing.T) {
 			hmacSecretFunc := func() []byte { return []byte("some secret - must have at least 32 bytes") }
 			require.GreaterOrEqual(t, len(hmacSecretFunc()), 32, "fosite requires that hmac secrets have at least 32 bytes")
 			jwksProviderIsUnused := jwks.NewDynamicJWKSProvider()
-			oauthHelper := oidc.FositeOauth2Helper(oauthStore, downstreamIssuer, hmacSecretFunc, jwksProviderIsUnused, timeoutsConfiguration)
+			oauthHelper := oidc.FositeOauth2Helper(oauthStore, downstreamIssuer, hmacSecretFunc, jwksProviderIsUnused, timeoutsConfiguration, formposthtml.TemplateForContext, nil, nil, nil)
+
+			consentStorage := consentstorage.New(secrets, time.Now)
+			consentPrompter := consent.NewPrompter(downstreamIssuer, consentStorage, func() (string, error) { return "some-consent-id", nil }, time.Now)
 
-			subject := NewHandler(test.idps.BuildFederationDomainIdentityProvidersListerFinder(), oauthHelper, happyStateCodec, happyCookieCodec, happyUpstreamRedirectURI)
-			reqContext := context.WithValue(context.Background(), struct{ name string }{name: "test"}, "request-context")
+			subject := NewHandler(test.idps.BuildFederationDomainIdentityProvidersListerFinder(), oauthHelper, happyStateCodec, happyCookieCodec, happyUpstreamRedirectURI, consentPrompter, nil)
+			reqContext := context.WithValue(context.Background(), testidplister.RequestContextKey{}, "request-context")
 			req := httptest.NewRequest(test.method, test.path, nil).WithContext(reqContext)
 			if test.csrfCookie != "" {
 				req.Header.Set("Cookie", test.csrfCookie)
@@ -1823,6 +1947,10 @@ func TestCallbackEndpoint(t *testing.T) {
 				require.Empty(t, rsp.Body.String())
 			}
 
+			if test.wantRedirectLocation != "" {
+				require.Equal(t, test.wantRedirectLocation, rsp.Header().Get("Location"))
+			}
+
 			if test.wantRedirectLocationRegexp != "" {
 				require.Len(t, rsp.Header().Values("Location"), 1)
 				oidctestutil.RequireAuthCodeRegexpMatch(
@@ -1846,6 +1974,19 @@ func TestCallbackEndpoint(t *testing.T) {
 					test.wantDownstreamAdditionalClaims,
 				)
 			}
+
+			// Check the pending consent request last, because reading it adds to the actions of the kubeClient.
+			pendingConsentRequest, err := consentStorage.GetPendingRequest(context.Background(), "some-consent-id")
+			if test.wantPendingConsentRequest {
+				require.NoError(t, err)
+				require.Equal(t, downstreamDynamicClientID, pendingConsentRequest.ClientID)
+				require.Equal(t, csrftoken.CSRFToken(happyDownstreamCSRF), pendingConsentRequest.CSRFToken)
+				require.Equal(t, oidcUpstreamUsername, pendingConsentRequest.Session.Custom.Username)
+				// No authcode was issued yet.
+				testutil.RequireNumberOfSecretsMatchingLabelSelector(t, secrets, labels.Set{crud.SecretLabelKey: authorizationcode.TypeLabelValue}, 0)
+			} else {
+				require.ErrorIs(t, err, fosite.ErrNotFound)
+			}
 		})
 	}
 }
@@ -1861,7 +2002,7 @@ type expectedGitHubAuthcodeExchange struct {
 }
 
 type requestPath struct {
-	code, state *string
+	code, state, response *string
 }
 
 func newRequestPath() *requestPath {
@@ -1893,6 +2034,25 @@ func (r *requestPath) WithoutState() *requestPath {
 	return r
 }
 
+// WithJWTSecuredAuthorizationResponse moves the code and state params into the JWT of a JWT secured authorization
+// response. The JWT is signed by an arbitrary key, because it is validated by the fake upstream provider.
+func (r *requestPath) WithJWTSecuredAuthorizationResponse(t *testing.T) *requestPath {
+	t.Helper()
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte("some-key-which-is-at-least-32-bytes-long")}, nil)
+	require.NoError(t, err)
+	claims := map[string]any{}
+	if r.code != nil {
+		claims["code"] = *r.code
+	}
+	if r.state != nil {
+		claims["state"] = *r.state
+	}
+	response, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	require.NoError(t, err)
+	r.code, r.state, r.response = nil, nil, &response
+	return r
+}
+
 func (r *requestPath) String() string {
 	path := "/downstream-provider-name/callback?"
 	params := url.Values{}
@@ -1902,6 +2062,9 @@ func (r *requestPath) String() string {
 	if r.state != nil {
 		params.Add("state", *r.state)
 	}
+	if r.response != nil {
+		params.Add("response", *r.response)
+	}
 	return path + params.Encode()
 }
 
@@ -7,21 +7,31 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"sort"
 
-	"go.pinniped.dev/generated/latest/apis/supervisor/oidc"
+	oidcapi "go.pinniped.dev/generated/latest/apis/supervisor/oidc"
 	"go.pinniped.dev/internal/federationdomain/endpoints/chooseidp/chooseidphtml"
 	"go.pinniped.dev/internal/federationdomain/federationdomainproviders"
+	"go.pinniped.dev/internal/federationdomain/oidc"
+	"go.pinniped.dev/internal/federationdomain/resolvedprovider"
 	"go.pinniped.dev/internal/httputil/httperr"
 	"go.pinniped.dev/internal/httputil/securityheader"
+	"go.pinniped.dev/internal/plog"
 )
 
 // NewHandler returns a http.Handler that serves an IDP chooser web page. The authorization endpoint may redirect
 // to this page, copying all the same parameters from the original authorization request. Each button on this page
 // simply adds the IDP's name as an additional request parameter to the original authorization request's parameters,
-// and sends the user back to the authorization endpoint, where the authorization flow can start from scratch using
-// the original params with the extra pinniped_idp_name param added.
-func NewHandler(authURL string, upstreamIDPs federationdomainproviders.FederationDomainIdentityProvidersListerI) http.Handler {
+// and sends the user back to this handler. This handler then remembers the user's choice in a cookie, so it can be
+// suggested first next time, and sends the user back to the authorization endpoint, where the authorization flow
+// can start from scratch using the original params with the extra pinniped_idp_name param added.
+func NewHandler(
+	issuerURL string,
+	upstreamIDPs federationdomainproviders.FederationDomainIdentityProvidersListerI,
+	cookieCodec oidc.Codec,
+) http.Handler {
+	authURL := issuerURL + oidc.AuthorizationEndpointPath
+	chooseIDPURL := issuerURL + oidc.ChooseIDPEndpointPath
+
 	handler := httperr.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
 		if r.Method != http.MethodGet {
 			return httperr.Newf(http.StatusMethodNotAllowed, "%s (try GET)", r.Method)
@@ -34,23 +44,46 @@ func NewHandler(authURL string, upstreamIDPs federationdomainproviders.Federatio
 			return httperr.New(http.StatusBadRequest, "missing required query params (must include client_id, redirect_uri, scope, and response_type)")
 		}
 
-		newIDPForPageData := func(displayName string) chooseidphtml.IdentityProvider {
+		upstreams := upstreamIDPs.GetIdentityProviders()
+
+		// The user clicked one of the buttons on this page, so remember their choice and continue the authorization.
+		if chosenIDPName := query.Get(oidcapi.AuthorizeUpstreamIDPNameParamName); chosenIDPName != "" {
+			if hasIDPWithDisplayName(upstreams, chosenIDPName) {
+				if err := addLastUsedIDPSetCookieHeader(w, r.URL.Path, chosenIDPName, cookieCodec); err != nil {
+					// Remembering the choice is only a convenience, so do not fail the login.
+					plog.Error("error setting last used identity provider cookie", err)
+				}
+			}
+			http.Redirect(w, r, fmt.Sprintf("%s?%s", authURL, query.Encode()), http.StatusSeeOther)
+			return nil
+		}
+
+		newIDPForPageData := func(displayName string, presentation resolvedprovider.Presentation) chooseidphtml.IdentityProvider {
 			return chooseidphtml.IdentityProvider{
 				DisplayName: displayName,
+				Description: presentation.Description,
+				IconURL:     presentation.IconURL,
 				URL: fmt.Sprintf("%s?%s&%s=%s",
-					authURL, r.URL.Query().Encode(), oidc.AuthorizeUpstreamIDPNameParamName, url.QueryEscape(displayName)),
+					chooseIDPURL, query.Encode(), oidcapi.AuthorizeUpstreamIDPNameParamName, url.QueryEscape(displayName)),
 			}
 		}
 
+		resolvedprovider.SortForPresentation(upstreams)
+
+		lastUsedIDPName := readLastUsedIDPCookie(r, cookieCodec)
+
 		var idps []chooseidphtml.IdentityProvider
-		for _, p := range upstreamIDPs.GetIdentityProviders() {
-			idps = append(idps, newIDPForPageData(p.GetDisplayName()))
+		for _, p := range upstreams {
+			idp := newIDPForPageData(p.GetDisplayName(), p.GetPresentation())
+			if lastUsedIDPName != "" && idp.DisplayName == lastUsedIDPName {
+				// Suggest the user's previous choice first.
+				idp.LastUsed = true
+				idps = append([]chooseidphtml.IdentityProvider{idp}, idps...)
+				continue
+			}
+			idps = append(idps, idp)
 		}
 
-		sort.SliceStable(idps, func(i, j int) bool {
-			return idps[i].DisplayName < idps[j].DisplayName
-		})
-
 		if len(idps) == 0 {
 			// This shouldn't normally happen in practice because the auth endpoint would not have redirected to here.
 			return httperr.New(http.StatusInternalServerError,
@@ -63,6 +96,50 @@ func NewHandler(authURL string, upstreamIDPs federationdomainproviders.Federatio
 	return wrapSecurityHeaders(handler)
 }
 
+func hasIDPWithDisplayName(idps []resolvedprovider.FederationDomainResolvedIdentityProvider, displayName string) bool {
+	for _, idp := range idps {
+		if idp.GetDisplayName() == displayName {
+			return true
+		}
+	}
+	return false
+}
+
+func addLastUsedIDPSetCookieHeader(w http.ResponseWriter, path string, idpDisplayName string, codec oidc.Encoder) error {
+	encodedIDPDisplayName, err := codec.Encode(oidc.LastUsedIDPCookieEncodingName, idpDisplayName)
+	if err != nil {
+		return fmt.Errorf("error encoding last used identity provider cookie: %w", err)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidc.LastUsedIDPCookieName,
+		Value:    encodedIDPDisplayName,
+		MaxAge:   int(oidc.LastUsedIDPCookieLifespan.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   true,
+		Path:     path,
+	})
+
+	return nil
+}
+
+func readLastUsedIDPCookie(r *http.Request, codec oidc.Decoder) string {
+	receivedCookie, err := r.Cookie(oidc.LastUsedIDPCookieName)
+	if err != nil {
+		// Error means that the cookie was not found.
+		return ""
+	}
+
+	var idpDisplayName string
+	if err := codec.Decode(oidc.LastUsedIDPCookieEncodingName, receivedCookie.Value, &idpDisplayName); err != nil {
+		// The cookie might have been signed by a previous cookie signing key, so just ignore it.
+		return ""
+	}
+
+	return idpDisplayName
+}
+
 func wrapSecurityHeaders(handler http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		wrapped := securityheader.WrapWithCustomCSP(handler, chooseidphtml.ContentSecurityPolicy())
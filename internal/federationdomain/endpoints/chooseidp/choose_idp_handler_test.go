@@ -9,11 +9,13 @@ import (
 	"net/url"
 	"testing"
 
+	"github.com/gorilla/securecookie"
 	"github.com/stretchr/testify/require"
 
 	"go.pinniped.dev/internal/federationdomain/endpoints/chooseidp/chooseidphtml"
 	"go.pinniped.dev/internal/federationdomain/federationdomainproviders"
 	"go.pinniped.dev/internal/federationdomain/oidc"
+	"go.pinniped.dev/internal/federationdomain/resolvedprovider"
 	"go.pinniped.dev/internal/testutil"
 	"go.pinniped.dev/internal/testutil/oidctestutil"
 	"go.pinniped.dev/internal/testutil/testidplister"
@@ -28,18 +30,30 @@ func TestChooseIDPHandler(t *testing.T) {
 		"scope":         []string{"baz"},
 		"response_type": []string{"bat"},
 	}
-	testIssuerWithTestReqQuery := testIssuer + "?" + testReqQuery.Encode()
+	testIssuerWithTestReqQuery := testIssuer + oidc.ChooseIDPEndpointPath + "?" + testReqQuery.Encode()
+
+	cookieCodec := securecookie.New([]byte("fake-hash-secret"), []byte("0123456789ABCDEF"))
+	cookieCodec.SetSerializer(securecookie.JSONEncoder{})
+
+	encodedLastUsedCookie := func(t *testing.T, idpDisplayName string) string {
+		encoded, err := cookieCodec.Encode("idp", idpDisplayName)
+		require.NoError(t, err)
+		return encoded
+	}
 
 	tests := []struct {
 		name string
 
-		method    string
-		reqTarget string
-		idps      federationdomainproviders.FederationDomainIdentityProvidersListerI
+		method         string
+		reqTarget      string
+		lastUsedCookie string
+		idps           federationdomainproviders.FederationDomainIdentityProvidersListerI
 
-		wantStatus      int
-		wantContentType string
-		wantBodyString  string
+		wantStatus            int
+		wantContentType       string
+		wantBodyString        string
+		wantLocation          string
+		wantLastUsedCookieIDP string
 	}{
 		{
 			name:      "happy path",
@@ -65,6 +79,94 @@ func TestChooseIDPHandler(t *testing.T) {
 				{DisplayName: "z-ad1", URL: testIssuerWithTestReqQuery + "&pinniped_idp_name=z-ad1"},
 			}),
 		},
+		{
+			name:      "happy path when the IDPs have presentation settings",
+			method:    http.MethodGet,
+			reqTarget: "/some/path" + oidc.ChooseIDPEndpointPath + "?" + testReqQuery.Encode(),
+			idps: testidplister.NewUpstreamIDPListerBuilder().
+				WithOIDC(oidctestutil.NewTestUpstreamOIDCIdentityProviderBuilder().WithName("oidc1").Build()).
+				WithLDAP(oidctestutil.NewTestUpstreamLDAPIdentityProviderBuilder().WithName("ldap1").Build()).
+				WithActiveDirectory(oidctestutil.NewTestUpstreamLDAPIdentityProviderBuilder().WithName("ad1").Build()).
+				WithPresentation("oidc1", resolvedprovider.Presentation{
+					Description: "Log in with your corporate account",
+					IconURL:     "https://example.com/icon.png",
+					Order:       -1,
+				}).
+				WithPresentation("ad1", resolvedprovider.Presentation{Order: 1}).
+				BuildFederationDomainIdentityProvidersListerFinder(),
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/html; charset=utf-8",
+			wantBodyString: testutil.ExpectedChooseIDPPageHTML(chooseidphtml.CSS(), chooseidphtml.JS(), []testutil.ChooseIDPPageExpectedValue{
+				// Should be sorted by order, and then alphabetically by displayName.
+				{
+					DisplayName: "oidc1",
+					Description: "Log in with your corporate account",
+					IconURL:     "https://example.com/icon.png",
+					URL:         testIssuerWithTestReqQuery + "&pinniped_idp_name=oidc1",
+				},
+				{DisplayName: "ldap1", URL: testIssuerWithTestReqQuery + "&pinniped_idp_name=ldap1"},
+				{DisplayName: "ad1", URL: testIssuerWithTestReqQuery + "&pinniped_idp_name=ad1"},
+			}),
+		},
+		{
+			name:           "happy path when the user has chosen an IDP before",
+			method:         http.MethodGet,
+			reqTarget:      "/some/path" + oidc.ChooseIDPEndpointPath + "?" + testReqQuery.Encode(),
+			lastUsedCookie: encodedLastUsedCookie(t, "ldap1"),
+			idps: testidplister.NewUpstreamIDPListerBuilder().
+				WithOIDC(oidctestutil.NewTestUpstreamOIDCIdentityProviderBuilder().WithName("oidc1").Build()).
+				WithLDAP(oidctestutil.NewTestUpstreamLDAPIdentityProviderBuilder().WithName("ldap1").Build()).
+				WithActiveDirectory(oidctestutil.NewTestUpstreamLDAPIdentityProviderBuilder().WithName("ad1").Build()).
+				BuildFederationDomainIdentityProvidersListerFinder(),
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/html; charset=utf-8",
+			wantBodyString: testutil.ExpectedChooseIDPPageHTML(chooseidphtml.CSS(), chooseidphtml.JS(), []testutil.ChooseIDPPageExpectedValue{
+				// The last used IDP should be first, followed by the others sorted alphabetically by displayName.
+				{DisplayName: "ldap1", URL: testIssuerWithTestReqQuery + "&pinniped_idp_name=ldap1", LastUsed: true},
+				{DisplayName: "ad1", URL: testIssuerWithTestReqQuery + "&pinniped_idp_name=ad1"},
+				{DisplayName: "oidc1", URL: testIssuerWithTestReqQuery + "&pinniped_idp_name=oidc1"},
+			}),
+		},
+		{
+			name:           "happy path when the last used IDP no longer exists or the cookie cannot be decoded",
+			method:         http.MethodGet,
+			reqTarget:      "/some/path" + oidc.ChooseIDPEndpointPath + "?" + testReqQuery.Encode(),
+			lastUsedCookie: "not-a-valid-cookie-value",
+			idps: testidplister.NewUpstreamIDPListerBuilder().
+				WithOIDC(oidctestutil.NewTestUpstreamOIDCIdentityProviderBuilder().WithName("oidc1").Build()).
+				WithLDAP(oidctestutil.NewTestUpstreamLDAPIdentityProviderBuilder().WithName("ldap1").Build()).
+				BuildFederationDomainIdentityProvidersListerFinder(),
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/html; charset=utf-8",
+			wantBodyString: testutil.ExpectedChooseIDPPageHTML(chooseidphtml.CSS(), chooseidphtml.JS(), []testutil.ChooseIDPPageExpectedValue{
+				{DisplayName: "ldap1", URL: testIssuerWithTestReqQuery + "&pinniped_idp_name=ldap1"},
+				{DisplayName: "oidc1", URL: testIssuerWithTestReqQuery + "&pinniped_idp_name=oidc1"},
+			}),
+		},
+		{
+			name:      "the user chose an IDP, so remember it and continue at the authorization endpoint",
+			method:    http.MethodGet,
+			reqTarget: "/some/path" + oidc.ChooseIDPEndpointPath + "?" + testReqQuery.Encode() + "&pinniped_idp_name=ldap1",
+			idps: testidplister.NewUpstreamIDPListerBuilder().
+				WithOIDC(oidctestutil.NewTestUpstreamOIDCIdentityProviderBuilder().WithName("oidc1").Build()).
+				WithLDAP(oidctestutil.NewTestUpstreamLDAPIdentityProviderBuilder().WithName("ldap1").Build()).
+				BuildFederationDomainIdentityProvidersListerFinder(),
+			wantStatus:            http.StatusSeeOther,
+			wantContentType:       "text/html; charset=utf-8",
+			wantLocation:          testIssuer + oidc.AuthorizationEndpointPath + "?client_id=foo&pinniped_idp_name=ldap1&redirect_uri=bar&response_type=bat&scope=baz",
+			wantLastUsedCookieIDP: "ldap1",
+		},
+		{
+			name:      "the user chose an IDP which does not exist, so continue at the authorization endpoint without remembering it",
+			method:    http.MethodGet,
+			reqTarget: "/some/path" + oidc.ChooseIDPEndpointPath + "?" + testReqQuery.Encode() + "&pinniped_idp_name=not-an-idp",
+			idps: testidplister.NewUpstreamIDPListerBuilder().
+				WithOIDC(oidctestutil.NewTestUpstreamOIDCIdentityProviderBuilder().WithName("oidc1").Build()).
+				BuildFederationDomainIdentityProvidersListerFinder(),
+			wantStatus:      http.StatusSeeOther,
+			wantContentType: "text/html; charset=utf-8",
+			wantLocation:    testIssuer + oidc.AuthorizationEndpointPath + "?client_id=foo&pinniped_idp_name=not-an-idp&redirect_uri=bar&response_type=bat&scope=baz",
+		},
 		{
 			name:      "happy path when there are special characters in the IDP name",
 			method:    http.MethodGet,
@@ -135,16 +237,39 @@ func TestChooseIDPHandler(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
 
-			handler := NewHandler(testIssuer, test.idps)
+			handler := NewHandler(testIssuer, test.idps, cookieCodec)
 
 			req := httptest.NewRequest(test.method, test.reqTarget, nil)
+			if test.lastUsedCookie != "" {
+				req.AddCookie(&http.Cookie{Name: "pinniped-last-idp", Value: test.lastUsedCookie})
+			}
 			rsp := httptest.NewRecorder()
 			handler.ServeHTTP(rsp, req)
 
 			require.Equal(t, test.wantStatus, rsp.Code)
 			require.Equal(t, test.wantContentType, rsp.Header().Get("Content-Type"))
-			require.Equal(t, test.wantBodyString, rsp.Body.String())
+			if test.wantLocation != "" {
+				require.Equal(t, test.wantLocation, rsp.Header().Get("Location"))
+			} else {
+				require.Equal(t, test.wantBodyString, rsp.Body.String())
+			}
 			testutil.RequireSecurityHeadersWithIDPChooserPageCSPs(t, rsp)
+
+			if test.wantLastUsedCookieIDP == "" {
+				require.Empty(t, rsp.Header().Values("Set-Cookie"))
+				return
+			}
+			require.Len(t, rsp.Result().Cookies(), 1)
+			cookie := rsp.Result().Cookies()[0]
+			require.Equal(t, "pinniped-last-idp", cookie.Name)
+			require.Equal(t, "/some/path"+oidc.ChooseIDPEndpointPath, cookie.Path)
+			require.Equal(t, 90*24*60*60, cookie.MaxAge)
+			require.True(t, cookie.HttpOnly)
+			require.True(t, cookie.Secure)
+			require.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+			var decodedIDPName string
+			require.NoError(t, cookieCodec.Decode("idp", cookie.Value, &decodedIDPName))
+			require.Equal(t, test.wantLastUsedCookieIDP, decodedIDPName)
 		})
 	}
 }
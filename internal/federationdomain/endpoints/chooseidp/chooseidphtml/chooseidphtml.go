@@ -1,4 +1,4 @@
-// Copyright 2023 the Pinniped contributors. All Rights Reserved.
+// Copyright 2023-2024 the Pinniped contributors. All Rights Reserved.
 // SPDX-License-Identifier: Apache-2.0
 
 package chooseidphtml
@@ -37,7 +37,7 @@ var (
 		`default-src 'none'`,
 		`script-src '` + csp.Hash(minifiedJS) + `'`,
 		`style-src '` + csp.Hash(minifiedCSS) + `'`,
-		`img-src data:`,
+		`img-src data: https:`, // allows the optional icons of the identity providers
 		`frame-ancestors 'none'`,
 	}, "; ")
 )
@@ -65,7 +65,11 @@ func JS() string { return minifiedJS }
 
 type IdentityProvider struct {
 	DisplayName string
+	Description string
+	IconURL     string
 	URL         string
+	// LastUsed is true for the identity provider which the user chose the last time they used this page.
+	LastUsed bool
 }
 
 // PageData represents the inputs to the template.
@@ -1,4 +1,4 @@
-// Copyright 2023 the Pinniped contributors. All Rights Reserved.
+// Copyright 2023-2024 the Pinniped contributors. All Rights Reserved.
 // SPDX-License-Identifier: Apache-2.0
 
 package chooseidphtml
@@ -23,7 +23,7 @@ var (
 	testExpectedCSP = `default-src 'none'; ` +
 		`script-src 'sha256-eyuE+qQfuMn4WbDizGOp1wSGReaMYRYmRMXpyEo+8ps='; ` +
 		`style-src 'sha256-SgeTG5HEbHNFgjH+EvLrC+VKZRZQ6iAI3oFnW7i/Tm4='; ` +
-		`img-src data:; ` +
+		`img-src data: https:; ` +
 		`frame-ancestors 'none'`
 )
 
@@ -33,18 +33,20 @@ func TestTemplate(t *testing.T) {
 		testUpstreamName2 = "test-idp-name2"
 		testURL1          = "https://pinniped.dev/path1?query=value"
 		testURL2          = "https://pinniped.dev/path2?query=value"
+		testDescription2  = "Log in with your corporate account"
+		testIconURL2      = "https://pinniped.dev/icon.png"
 	)
 
 	pageInputs := &PageData{
 		IdentityProviders: []IdentityProvider{
 			{DisplayName: testUpstreamName1, URL: testURL1},
-			{DisplayName: testUpstreamName2, URL: testURL2},
+			{DisplayName: testUpstreamName2, Description: testDescription2, IconURL: testIconURL2, URL: testURL2},
 		},
 	}
 
 	expectedHTML := testutil.ExpectedChooseIDPPageHTML(testExpectedCSS, testExpectedJS, []testutil.ChooseIDPPageExpectedValue{
 		{DisplayName: testUpstreamName1, URL: testURL1},
-		{DisplayName: testUpstreamName2, URL: testURL2},
+		{DisplayName: testUpstreamName2, Description: testDescription2, IconURL: testIconURL2, URL: testURL2},
 	})
 
 	var buf bytes.Buffer
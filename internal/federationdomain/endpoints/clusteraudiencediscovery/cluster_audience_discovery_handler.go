@@ -0,0 +1,48 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package clusteraudiencediscovery provides a handler for the cluster audience discovery endpoint.
+package clusteraudiencediscovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"go.pinniped.dev/generated/latest/apis/supervisor/idpdiscovery/v1alpha1"
+	"go.pinniped.dev/internal/federationdomain/clusteraudience"
+)
+
+// NewHandler returns an http.Handler that serves the cluster audience discovery endpoint.
+func NewHandler(clusterAudiences *clusteraudience.Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, `Method not allowed (try GET)`, http.StatusMethodNotAllowed)
+			return
+		}
+
+		encodedMetadata, encodeErr := responseAsJSON(clusterAudiences)
+		if encodeErr != nil {
+			http.Error(w, encodeErr.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(encodedMetadata); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+}
+
+func responseAsJSON(clusterAudiences *clusteraudience.Registry) ([]byte, error) {
+	// The ClusterAudiences could change at any time, so always recalculate the list.
+	published, err := clusterAudiences.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	encodeErr := json.NewEncoder(&b).Encode(&v1alpha1.ClusterAudiencesDiscoveryResponse{PinnipedClusterAudiences: published})
+	return b.Bytes(), encodeErr
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package clusteraudiencediscovery
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
+	configlisters "go.pinniped.dev/generated/latest/client/supervisor/listers/config/v1alpha1"
+	"go.pinniped.dev/internal/federationdomain/clusteraudience"
+	"go.pinniped.dev/internal/federationdomain/oidc"
+	"go.pinniped.dev/internal/here"
+)
+
+func TestClusterAudienceDiscovery(t *testing.T) {
+	const namespace = "some-namespace"
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	registry := clusteraudience.NewRegistry(configlisters.NewClusterAudienceLister(indexer).ClusterAudiences(namespace))
+
+	tests := []struct {
+		name string
+
+		method           string
+		clusterAudiences []*supervisorconfigv1alpha1.ClusterAudience
+
+		wantStatus      int
+		wantContentType string
+		wantBodyJSON    string
+		wantBodyString  string
+	}{
+		{
+			name:            "no ClusterAudiences",
+			method:          http.MethodGet,
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+			wantBodyJSON:    `{"pinniped_cluster_audiences": []}`,
+		},
+		{
+			name:   "happy path",
+			method: http.MethodGet,
+			clusterAudiences: []*supervisorconfigv1alpha1.ClusterAudience{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "z-cluster", Namespace: namespace},
+					Spec:       supervisorconfigv1alpha1.ClusterAudienceSpec{Audience: "z-audience"},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "a-cluster", Namespace: namespace},
+					Spec: supervisorconfigv1alpha1.ClusterAudienceSpec{
+						Audience:                 "a-audience",
+						Server:                   "https://a-cluster.example.com",
+						CertificateAuthorityData: "some-ca-data",
+					},
+				},
+			},
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+			wantBodyJSON: here.Doc(`{
+				"pinniped_cluster_audiences": [
+					{"name": "a-cluster", "audience": "a-audience", "server": "https://a-cluster.example.com", "certificate_authority_data": "some-ca-data"},
+					{"name": "z-cluster", "audience": "z-audience"}
+				]
+			}`),
+		},
+		{
+			name:            "bad method",
+			method:          http.MethodPost,
+			wantStatus:      http.StatusMethodNotAllowed,
+			wantContentType: "text/plain; charset=utf-8",
+			wantBodyString:  "Method not allowed (try GET)\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, obj := range indexer.List() {
+				require.NoError(t, indexer.Delete(obj))
+			}
+			for _, clusterAudience := range test.clusterAudiences {
+				require.NoError(t, indexer.Add(clusterAudience))
+			}
+
+			handler := NewHandler(registry)
+			req := httptest.NewRequest(test.method, "/some/path"+oidc.PinnipedClusterAudiencesPathV1Alpha1, nil)
+			rsp := httptest.NewRecorder()
+			handler.ServeHTTP(rsp, req)
+
+			require.Equal(t, test.wantStatus, rsp.Code)
+
+			require.Equal(t, test.wantContentType, rsp.Header().Get("Content-Type"))
+
+			if test.wantBodyJSON != "" {
+				require.JSONEq(t, test.wantBodyJSON, rsp.Body.String())
+			}
+
+			if test.wantBodyString != "" {
+				require.Equal(t, test.wantBodyString, rsp.Body.String())
+			}
+		})
+	}
+}
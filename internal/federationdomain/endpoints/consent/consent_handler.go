@@ -0,0 +1,257 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package consent provides a handler for the consent page, on which users approve or deny the OIDCClients which
+// require consent before they receive an authorization code.
+package consent
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ory/fosite"
+
+	"go.pinniped.dev/internal/federationdomain/branding"
+	"go.pinniped.dev/internal/federationdomain/csrftoken"
+	"go.pinniped.dev/internal/federationdomain/downstreamsession"
+	"go.pinniped.dev/internal/federationdomain/endpoints/consent/consenthtml"
+	"go.pinniped.dev/internal/federationdomain/formposthtml"
+	"go.pinniped.dev/internal/federationdomain/oidc"
+	"go.pinniped.dev/internal/federationdomain/resourceindicator"
+	consentstorage "go.pinniped.dev/internal/fositestorage/consent"
+	"go.pinniped.dev/internal/httputil/httperr"
+	"go.pinniped.dev/internal/httputil/securityheader"
+	"go.pinniped.dev/internal/i18n"
+	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/internal/psession"
+)
+
+const (
+	idParamName       = "id"
+	decisionParamName = "decision"
+
+	decisionApprove = "approve"
+	decisionDeny    = "deny"
+)
+
+// consentRequiringClient is implemented by the clients which may require the consent of the user.
+type consentRequiringClient interface {
+	RequiresConsent() bool
+}
+
+// Prompter sends the user to the consent page at the end of a login, when the client requires consent
+// and the user has not approved the client yet.
+type Prompter struct {
+	consentURL string
+	storage    consentstorage.Storage
+	generateID func() (string, error)
+	clock      func() time.Time
+}
+
+func NewPrompter(
+	issuerURL string,
+	storage consentstorage.Storage,
+	generateID func() (string, error), // use GeneratePendingRequestID() for production
+	clock func() time.Time,
+) *Prompter {
+	return &Prompter{
+		consentURL: issuerURL + oidc.ConsentEndpointPath,
+		storage:    storage,
+		generateID: generateID,
+		clock:      clock,
+	}
+}
+
+// GeneratePendingRequestID returns a new random ID for a pending consent request. The ID is sent to the browser,
+// so it must not be guessable.
+func GeneratePendingRequestID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("could not generate pending consent request ID: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// MaybeRedirectToConsentPage stores the login and redirects to the consent page, and returns true, when the client
+// requires consent which the user has not given yet. Otherwise, it returns false, and the caller should issue the
+// authorization code. A nil Prompter never redirects.
+func (p *Prompter) MaybeRedirectToConsentPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	authorizeRequester fosite.AuthorizeRequester,
+	session *psession.PinnipedSession,
+	csrfToken csrftoken.CSRFToken,
+) (bool, error) {
+	if p == nil {
+		return false, nil
+	}
+
+	client, ok := authorizeRequester.GetClient().(consentRequiringClient)
+	if !ok || !client.RequiresConsent() {
+		return false, nil
+	}
+
+	clientID := authorizeRequester.GetClient().GetID()
+	hasGrant, err := p.storage.HasGrant(r.Context(), clientID, session.Fosite.Claims.Subject)
+	if err != nil {
+		return false, err
+	}
+	if hasGrant {
+		return false, nil
+	}
+
+	id, err := p.generateID()
+	if err != nil {
+		return false, err
+	}
+
+	err = p.storage.CreatePendingRequest(r.Context(), id, &consentstorage.PendingRequest{
+		ClientID:  clientID,
+		Form:      authorizeRequester.GetRequestForm(),
+		Session:   session,
+		CSRFToken: csrfToken,
+		ExpiresAt: p.clock().Add(consentstorage.PendingRequestLifetime),
+	})
+	if err != nil {
+		return false, fmt.Errorf("could not save pending consent request: %w", err)
+	}
+
+	http.Redirect(w, r,
+		p.consentURL+"?"+url.Values{idParamName: {id}}.Encode(),
+		http.StatusSeeOther, // match fosite and https://tools.ietf.org/id/draft-ietf-oauth-security-topics-18.html#section-4.11
+	)
+	return true, nil
+}
+
+// NewHandler returns a http.Handler that serves the consent page. GET requests render the page for a pending consent
+// request, and POST requests record the decision of the user. An approval is remembered for the user and the client,
+// and finishes the login by redirecting to the client with an authorization code. A denial redirects to the client
+// with an access_denied error. Each pending consent request may only be decided once, and only by the browser
+// which started the login.
+func NewHandler(
+	consentPath string,
+	oauthHelper fosite.OAuth2Provider,
+	storage consentstorage.Storage,
+	cookieDecoder oidc.Decoder,
+	getBranding func() *branding.Branding,
+) http.Handler {
+	issuerPath := strings.TrimSuffix(consentPath, oidc.ConsentEndpointPath)
+
+	handler := httperr.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			return httperr.Newf(http.StatusMethodNotAllowed, "%s (try GET or POST)", r.Method)
+		}
+
+		id := r.FormValue(idParamName)
+		if id == "" {
+			return httperr.New(http.StatusBadRequest, "id param not found")
+		}
+
+		pending, err := storage.GetPendingRequest(r.Context(), id)
+		if err != nil {
+			return pendingRequestError(err)
+		}
+
+		if err := oidc.ValidateCSRFCookie(r, cookieDecoder, pending.CSRFToken); err != nil {
+			plog.InfoErr("CSRF error", err)
+			return err
+		}
+
+		if r.Method == http.MethodGet {
+			return consenthtml.Template().Execute(w, &consenthtml.PageData{
+				ID:        id,
+				ClientID:  pending.ClientID,
+				Username:  pending.Session.Custom.Username,
+				Scopes:    strings.Fields(pending.Form.Get("scope")),
+				PostPath:  consentPath,
+				Branding:  getBranding().ForPage(issuerPath),
+				Localizer: i18n.FromContext(r.Context()),
+			})
+		}
+
+		return handleDecision(w, r, oauthHelper, storage, id, pending)
+	})
+
+	return wrapSecurityHeaders(handler)
+}
+
+func handleDecision(
+	w http.ResponseWriter,
+	r *http.Request,
+	oauthHelper fosite.OAuth2Provider,
+	storage consentstorage.Storage,
+	id string,
+	pending *consentstorage.PendingRequest,
+) error {
+	decision := r.PostFormValue(decisionParamName)
+	if decision != decisionApprove && decision != decisionDeny {
+		return httperr.Newf(http.StatusBadRequest, "decision param must be %q or %q", decisionApprove, decisionDeny)
+	}
+
+	// Delete the pending request before acting on the decision, so that it cannot be decided twice.
+	if err := storage.DeletePendingRequest(r.Context(), id); err != nil {
+		return pendingRequestError(err)
+	}
+
+	// Recreate enough of the original authorize request so we can pass it to NewAuthorizeRequest().
+	reconstitutedAuthRequest := &http.Request{Form: pending.Form}
+	authorizeRequester, err := oauthHelper.NewAuthorizeRequest(r.Context(), reconstitutedAuthRequest)
+	if err != nil {
+		// This shouldn't really happen because the authorization endpoint has already validated these params
+		// by calling NewAuthorizeRequest() itself, unless the client was changed in the meantime.
+		plog.Error("error using consent request auth params", err,
+			"fositeErr", oidc.FositeErrorForLog(err))
+		return httperr.New(http.StatusBadRequest, "error using consent request auth params")
+	}
+
+	// Automatically grant certain scopes, but only if they were requested. The user has approved the client as a
+	// whole, so the scopes are granted in the same way as for clients which do not require consent.
+	downstreamsession.AutoApproveScopes(authorizeRequester)
+
+	if err := resourceindicator.GrantIfRequested(authorizeRequester); err != nil {
+		plog.Error("error granting resources from consent request auth params", err,
+			"fositeErr", oidc.FositeErrorForLog(err))
+		return httperr.New(http.StatusBadRequest, "error using consent request auth params")
+	}
+
+	if decision == decisionDeny {
+		plog.Info("user denied consent to client", "clientID", pending.ClientID)
+		oidc.WriteAuthorizeError(r, w, oauthHelper, authorizeRequester,
+			fosite.ErrAccessDenied.WithHint("The user denied consent to the client."), false)
+		return nil
+	}
+
+	if err := storage.CreateGrant(r.Context(), pending.ClientID, pending.Session.Fosite.Claims.Subject); err != nil {
+		plog.Error("error saving consent", err, "clientID", pending.ClientID)
+		return httperr.Wrap(http.StatusInternalServerError, "error saving consent", err)
+	}
+
+	oidc.PerformAuthcodeRedirect(r, w, oauthHelper, authorizeRequester, pending.Session, false)
+
+	return nil
+}
+
+func pendingRequestError(err error) error {
+	if errors.Is(err, fosite.ErrNotFound) {
+		return httperr.New(http.StatusNotFound, "consent request does not exist or has expired")
+	}
+	plog.Error("error reading pending consent request", err)
+	return httperr.Wrap(http.StatusInternalServerError, "error reading consent request", err)
+}
+
+func wrapSecurityHeaders(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wrapped := securityheader.WrapWithCustomCSP(handler, consenthtml.ContentSecurityPolicy())
+		if r.Method == http.MethodPost {
+			// POST requests can result in the form_post html page, so allow it with CSP headers.
+			wrapped = securityheader.WrapWithCustomCSP(handler, formposthtml.ContentSecurityPolicy())
+		}
+		wrapped.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,277 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package consent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/handler/openid"
+	"github.com/ory/fosite/token/jwt"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"k8s.io/client-go/kubernetes/fake"
+
+	supervisorfake "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/fake"
+	"go.pinniped.dev/internal/federationdomain/branding"
+	"go.pinniped.dev/internal/federationdomain/endpoints/jwks"
+	"go.pinniped.dev/internal/federationdomain/formposthtml"
+	"go.pinniped.dev/internal/federationdomain/oidc"
+	"go.pinniped.dev/internal/federationdomain/oidcclientvalidator"
+	"go.pinniped.dev/internal/federationdomain/storage"
+	consentstorage "go.pinniped.dev/internal/fositestorage/consent"
+	"go.pinniped.dev/internal/psession"
+	"go.pinniped.dev/internal/testutil"
+)
+
+const (
+	downstreamIssuer           = "https://my-downstream-issuer.com/path"
+	downstreamRedirectURI      = "http://127.0.0.1/callback"
+	downstreamDynamicClientID  = "client.oauth.pinniped.dev-test-name"
+	downstreamDynamicClientUID = "fake-client-uid"
+	downstreamSubject          = "https://my-upstream-issuer.com?idpName=some-idp&sub=some-subject"
+	happyCSRF                  = "test-csrf"
+	pendingRequestID           = "some-consent-id"
+	consentPath                = "/path/consent"
+)
+
+func TestConsentHandler(t *testing.T) {
+	cookieCodec := securecookie.New([]byte("fake-cookie-hash-secret"), []byte("0123456789ABCDEF")) // block encryption requires 16/24/32 bytes for AES
+	cookieCodec.SetSerializer(securecookie.JSONEncoder{})
+
+	encodedCSRF, err := cookieCodec.Encode("csrf", happyCSRF)
+	require.NoError(t, err)
+	happyCSRFCookie := "__Host-pinniped-csrf=" + encodedCSRF
+
+	encodedWrongCSRF, err := cookieCodec.Encode("csrf", "wrong-csrf")
+	require.NoError(t, err)
+	wrongCSRFCookie := "__Host-pinniped-csrf=" + encodedWrongCSRF
+
+	happyPendingRequest := func() *consentstorage.PendingRequest {
+		return &consentstorage.PendingRequest{
+			ClientID: downstreamDynamicClientID,
+			Form: url.Values{
+				"response_type":         {"code"},
+				"scope":                 {"openid username groups"},
+				"client_id":             {downstreamDynamicClientID},
+				"state":                 {"8b-state"},
+				"nonce":                 {"some-nonce-value"},
+				"code_challenge":        {"some-challenge"},
+				"code_challenge_method": {"S256"},
+				"redirect_uri":          {downstreamRedirectURI},
+			},
+			Session: &psession.PinnipedSession{
+				Fosite: &openid.DefaultSession{
+					Claims: &jwt.IDTokenClaims{Subject: downstreamSubject},
+				},
+				Custom: &psession.CustomSessionData{
+					Username:     "pinny",
+					ProviderUID:  "ldap-resource-uid",
+					ProviderName: "some-ldap-idp",
+					ProviderType: psession.ProviderTypeLDAP,
+				},
+			},
+			CSRFToken: happyCSRF,
+			ExpiresAt: time.Now().Add(consentstorage.PendingRequestLifetime),
+		}
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		id             string
+		decision       string
+		csrfCookie     string
+		pendingRequest *consentstorage.PendingRequest
+
+		wantStatus                int
+		wantBodyContains          string
+		wantBody                  string
+		wantRedirectLocationRegex string
+		wantGrant                 bool
+		wantPendingRequestDeleted bool
+	}{
+		{
+			name:             "GET renders the consent page",
+			method:           http.MethodGet,
+			id:               pendingRequestID,
+			csrfCookie:       happyCSRFCookie,
+			pendingRequest:   happyPendingRequest(),
+			wantStatus:       http.StatusOK,
+			wantBodyContains: "<h1>" + downstreamDynamicClientID + " would like to access your account</h1>",
+		},
+		{
+			name:                      "POST with approve redirects to the client with an authcode and remembers the consent",
+			method:                    http.MethodPost,
+			id:                        pendingRequestID,
+			decision:                  "approve",
+			csrfCookie:                happyCSRFCookie,
+			pendingRequest:            happyPendingRequest(),
+			wantStatus:                http.StatusSeeOther,
+			wantRedirectLocationRegex: `^` + downstreamRedirectURI + `\?code=([^&]+)&scope=openid\+username\+groups&state=8b-state$`,
+			wantGrant:                 true,
+			wantPendingRequestDeleted: true,
+		},
+		{
+			name:                      "POST with deny redirects to the client with an access_denied error",
+			method:                    http.MethodPost,
+			id:                        pendingRequestID,
+			decision:                  "deny",
+			csrfCookie:                happyCSRFCookie,
+			pendingRequest:            happyPendingRequest(),
+			wantStatus:                http.StatusSeeOther,
+			wantRedirectLocationRegex: `^` + downstreamRedirectURI + `\?error=access_denied&`,
+			wantPendingRequestDeleted: true,
+		},
+		{
+			name:           "POST with an invalid decision",
+			method:         http.MethodPost,
+			id:             pendingRequestID,
+			decision:       "maybe",
+			csrfCookie:     happyCSRFCookie,
+			pendingRequest: happyPendingRequest(),
+			wantStatus:     http.StatusBadRequest,
+			wantBody:       "Bad Request: decision param must be \"approve\" or \"deny\"\n",
+		},
+		{
+			name:           "wrong CSRF cookie",
+			method:         http.MethodPost,
+			id:             pendingRequestID,
+			decision:       "approve",
+			csrfCookie:     wrongCSRFCookie,
+			pendingRequest: happyPendingRequest(),
+			wantStatus:     http.StatusForbidden,
+			wantBody:       "Forbidden: CSRF value does not match\n",
+		},
+		{
+			name:           "missing CSRF cookie",
+			method:         http.MethodGet,
+			id:             pendingRequestID,
+			pendingRequest: happyPendingRequest(),
+			wantStatus:     http.StatusForbidden,
+			wantBody:       "Forbidden: CSRF cookie is missing\n",
+		},
+		{
+			name:       "missing id param",
+			method:     http.MethodGet,
+			csrfCookie: happyCSRFCookie,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "Bad Request: id param not found\n",
+		},
+		{
+			name:       "pending request does not exist",
+			method:     http.MethodPost,
+			id:         "some-other-id",
+			decision:   "approve",
+			csrfCookie: happyCSRFCookie,
+			wantStatus: http.StatusNotFound,
+			wantBody:   "Not Found: consent request does not exist or has expired\n",
+		},
+		{
+			name:       "wrong method",
+			method:     http.MethodPut,
+			id:         pendingRequestID,
+			csrfCookie: happyCSRFCookie,
+			wantStatus: http.StatusMethodNotAllowed,
+			wantBody:   "Method Not Allowed: PUT (try GET or POST)\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset()
+			supervisorClient := supervisorfake.NewSimpleClientset()
+			secrets := kubeClient.CoreV1().Secrets("some-namespace")
+			oidcClientsClient := supervisorClient.ConfigV1alpha1().OIDCClients("some-namespace")
+
+			oidcClient, secret := testutil.FullyCapableOIDCClientAndStorageSecret(t,
+				"some-namespace", downstreamDynamicClientID, downstreamDynamicClientUID, downstreamRedirectURI, nil,
+				[]string{testutil.HashedPassword1AtGoMinCost}, oidcclientvalidator.Validate)
+			oidcClient.Spec.RequireConsent = true
+			require.NoError(t, supervisorClient.Tracker().Add(oidcClient))
+			require.NoError(t, kubeClient.Tracker().Add(secret))
+
+			timeoutsConfiguration := oidc.DefaultOIDCTimeoutsConfiguration()
+			// Use lower minimum required bcrypt cost than we would use in production to keep unit the tests fast.
+			oauthStore := storage.NewKubeStorage(secrets, oidcClientsClient, timeoutsConfiguration, bcrypt.MinCost)
+			hmacSecretFunc := func() []byte { return []byte("some secret - must have at least 32 bytes") }
+			jwksProviderIsUnused := jwks.NewDynamicJWKSProvider()
+			oauthHelper := oidc.FositeOauth2Helper(oauthStore, downstreamIssuer, hmacSecretFunc, jwksProviderIsUnused, timeoutsConfiguration, formposthtml.TemplateForContext, nil, nil, nil)
+
+			consentStorage := consentstorage.New(secrets, time.Now)
+			if test.pendingRequest != nil {
+				require.NoError(t, consentStorage.CreatePendingRequest(context.Background(), pendingRequestID, test.pendingRequest))
+			}
+
+			subject := NewHandler(consentPath, oauthHelper, consentStorage, cookieCodec, nilBranding)
+
+			params := url.Values{}
+			if test.id != "" {
+				params.Set(idParamName, test.id)
+			}
+			if test.decision != "" {
+				params.Set(decisionParamName, test.decision)
+			}
+
+			var req *http.Request
+			if test.method == http.MethodPost {
+				req = httptest.NewRequest(test.method, consentPath, strings.NewReader(params.Encode()))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			} else {
+				req = httptest.NewRequest(test.method, consentPath+"?"+params.Encode(), nil)
+			}
+			if test.csrfCookie != "" {
+				req.Header.Set("Cookie", test.csrfCookie)
+			}
+			rsp := httptest.NewRecorder()
+
+			subject.ServeHTTP(rsp, req)
+
+			require.Equal(t, test.wantStatus, rsp.Code)
+			if test.wantBodyContains != "" {
+				require.Contains(t, rsp.Body.String(), test.wantBodyContains)
+			}
+			if test.wantBody != "" {
+				require.Equal(t, test.wantBody, rsp.Body.String())
+			}
+			if test.wantRedirectLocationRegex != "" {
+				require.Regexp(t, test.wantRedirectLocationRegex, rsp.Header().Get("Location"))
+			} else {
+				require.Empty(t, rsp.Header().Get("Location"))
+			}
+
+			hasGrant, err := consentStorage.HasGrant(context.Background(), downstreamDynamicClientID, downstreamSubject)
+			require.NoError(t, err)
+			require.Equal(t, test.wantGrant, hasGrant)
+
+			if test.pendingRequest != nil {
+				_, err = consentStorage.GetPendingRequest(context.Background(), pendingRequestID)
+				if test.wantPendingRequestDeleted {
+					require.ErrorIs(t, err, fosite.ErrNotFound)
+				} else {
+					require.NoError(t, err)
+				}
+			}
+		})
+	}
+}
+
+func TestMaybeRedirectToConsentPage(t *testing.T) {
+	var nilPrompter *Prompter
+	redirected, err := nilPrompter.MaybeRedirectToConsentPage(nil, nil, nil, nil, "")
+	require.NoError(t, err)
+	require.False(t, redirected)
+
+	_, err = GeneratePendingRequestID()
+	require.NoError(t, err)
+}
+
+func nilBranding() *branding.Branding { return nil }
@@ -0,0 +1,86 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package consenthtml defines the HTML template of the consent page of the Supervisor.
+package consenthtml
+
+import (
+	_ "embed" // Needed to trigger //go:embed directives below.
+	"html/template"
+	"strings"
+
+	"github.com/tdewolff/minify/v2/minify"
+
+	"go.pinniped.dev/internal/federationdomain/branding"
+	"go.pinniped.dev/internal/federationdomain/csp"
+	"go.pinniped.dev/internal/i18n"
+)
+
+//nolint:gochecknoglobals // This package uses globals to ensure that all parsing and minifying happens at init.
+var (
+	//go:embed consent.css
+	rawCSS      string
+	minifiedCSS = panicOnError(minify.CSS(rawCSS))
+
+	//go:embed consent.gohtml
+	rawHTMLTemplate string
+
+	// Parse the Go templated HTML and inject functions providing the minified inline CSS.
+	parsedHTMLTemplate = template.Must(template.New("consent.gohtml").Funcs(template.FuncMap{
+		"minifiedCSS": func() template.CSS { return template.CSS(CSS()) },
+	}).Parse(rawHTMLTemplate))
+
+	// Generate the CSP header value once since it's effectively constant.
+	cspValue = strings.Join([]string{
+		`default-src 'none'`,
+		`style-src '` + csp.Hash(minifiedCSS) + `' 'self'`, // 'self' allows the optional branding stylesheet
+		`img-src 'self'`, // allows the optional branding logo
+		`frame-ancestors 'none'`,
+	}, "; ")
+)
+
+func panicOnError(s string, err error) string {
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// ContentSecurityPolicy returns the Content-Security-Policy header value to make the Template() operate correctly.
+//
+// See https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Content-Security-Policy.
+func ContentSecurityPolicy() string { return cspValue }
+
+// Template returns the html/template.Template for rendering the consent page.
+func Template() *template.Template { return parsedHTMLTemplate }
+
+// CSS returns the minified CSS that will be embedded into the page template.
+func CSS() string { return minifiedCSS }
+
+// PageData represents the inputs to the template.
+type PageData struct {
+	ID        string   // the ID of the pending consent request
+	ClientID  string   // the client which is asking for consent
+	Username  string   // the downstream username of the user
+	Scopes    []string // the scopes which will be granted to the client
+	PostPath  string
+	Branding  *branding.PageBranding // nil when the default branding should be used
+	Localizer *i18n.Localizer        // nil when the page should be rendered in English
+}
+
+// T returns the localized message for the key. It is used by the template.
+func (d *PageData) T(key string, args ...any) string {
+	return d.localizer().T(key, args...)
+}
+
+// Language returns the language in which the page is rendered. It is used by the template.
+func (d *PageData) Language() string {
+	return d.localizer().Language()
+}
+
+func (d *PageData) localizer() *i18n.Localizer {
+	if d.Localizer == nil {
+		return i18n.Default()
+	}
+	return d.Localizer
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package consenthtml
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go.pinniped.dev/internal/federationdomain/branding"
+	"go.pinniped.dev/internal/federationdomain/csp"
+)
+
+func TestTemplate(t *testing.T) {
+	pageInputs := &PageData{
+		ID:       "test-id",
+		ClientID: "client.oauth.pinniped.dev-<webapp>",
+		Username: "pinny",
+		Scopes:   []string{"openid", "offline_access", "username"},
+		PostPath: "/issuer/consent",
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Template().Execute(&buf, pageInputs))
+	require.Contains(t, buf.String(), `<html lang="en">`)
+	require.Contains(t, buf.String(), "<title>Pinniped Consent</title>\n")
+	require.Contains(t, buf.String(), "<style>"+CSS()+"</style>\n")
+	require.Contains(t, buf.String(), "<h1>client.oauth.pinniped.dev-&lt;webapp&gt; would like to access your account</h1>")
+	require.Contains(t, buf.String(), "<span>You are logged in as pinny.</span>")
+	require.Contains(t, buf.String(), `<ul aria-label="scopes">`+"\n"+
+		"            <li>openid</li>\n"+
+		"            <li>offline_access</li>\n"+
+		"            <li>username</li>\n"+
+		"        </ul>")
+	require.Contains(t, buf.String(), `<form action="/issuer/consent" method="post">`+"\n"+
+		`        <input type="hidden" name="id" id="id" value="test-id">`)
+	require.Contains(t, buf.String(), `<button type="submit" name="decision" id="deny" value="deny" class="deny">Deny</button>`)
+	require.Contains(t, buf.String(), `<button type="submit" name="decision" id="approve" value="approve">Allow</button>`)
+	// The CSS always styles the logo and the footer, so only look for their markup.
+	require.NotContains(t, buf.String(), `<div class="form-field logo">`)
+	require.NotContains(t, buf.String(), `<img `)
+	require.NotContains(t, buf.String(), `<footer`)
+
+	// Render again with branding.
+	pageInputs.Branding = &branding.PageBranding{
+		StylesheetPath: "/issuer/branding/style.css",
+		LogoPath:       "/issuer/branding/logo",
+		FooterText:     "test-footer <text>",
+	}
+	buf = bytes.Buffer{} // clear previous result from buffer
+	require.NoError(t, Template().Execute(&buf, pageInputs))
+	require.Contains(t, buf.String(), `</style>`+"\n"+`    <link rel="stylesheet" href="/issuer/branding/style.css">`+"\n")
+	require.Contains(t, buf.String(), `<div class="box" aria-label="consent form" role="main">`+"\n"+
+		`    <div class="form-field logo">`+"\n"+
+		`        <img src="/issuer/branding/logo" alt="logo">`+"\n"+
+		`    </div>`+"\n")
+	require.Contains(t, buf.String(), "</div>\n"+`<footer class="footer">test-footer &lt;text&gt;</footer>`+"\n</body>")
+}
+
+func TestContentSecurityPolicy(t *testing.T) {
+	require.Equal(t, `default-src 'none'; `+
+		`style-src '`+csp.Hash(CSS())+`' 'self'; `+
+		`img-src 'self'; `+
+		`frame-ancestors 'none'`,
+		ContentSecurityPolicy())
+}
+
+func TestCSS(t *testing.T) {
+	require.NotEmpty(t, CSS())
+	require.NotContains(t, CSS(), "/*")
+}
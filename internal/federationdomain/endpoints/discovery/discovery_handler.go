@@ -1,4 +1,4 @@
-// Copyright 2020-2023 the Pinniped contributors. All Rights Reserved.
+// Copyright 2020-2024 the Pinniped contributors. All Rights Reserved.
 // SPDX-License-Identifier: Apache-2.0
 
 // Package discovery provides a handler for the OIDC discovery endpoint.
@@ -11,6 +11,8 @@ import (
 
 	"go.pinniped.dev/generated/latest/apis/supervisor/idpdiscovery/v1alpha1"
 	oidcapi "go.pinniped.dev/generated/latest/apis/supervisor/oidc"
+	"go.pinniped.dev/internal/federationdomain/dpop"
+	"go.pinniped.dev/internal/federationdomain/jarm"
 	"go.pinniped.dev/internal/federationdomain/oidc"
 )
 
@@ -39,9 +41,25 @@ type Metadata struct {
 	ScopesSupported                   []string `json:"scopes_supported"`
 	ClaimsSupported                   []string `json:"claims_supported"`
 
+	// https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderMetadata lists the algorithms which may be
+	// used to sign the JWTs of the private_key_jwt client authentication method.
+	TokenEndpointAuthSigningAlgValuesSupported []string `json:"token_endpoint_auth_signing_alg_values_supported"`
+
 	// https://datatracker.ietf.org/doc/html/rfc8414#section-2 says, “If omitted, the authorization server does not support PKCE.”
 	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported"`
 
+	// https://datatracker.ietf.org/doc/html/rfc9449#section-5.1 says, “The presence of this parameter in the metadata
+	// indicates that the authorization server supports DPoP.”
+	DPoPSigningAlgValuesSupported []string `json:"dpop_signing_alg_values_supported"`
+
+	// https://datatracker.ietf.org/doc/html/rfc9126#section-5 says, “This is the URL to which the client can post
+	// an authorization request.”
+	PushedAuthorizationRequestEndpoint string `json:"pushed_authorization_request_endpoint"`
+
+	// https://openid.net/specs/oauth-v2-jarm.html#section-3 describes the algorithms which may be used to sign
+	// the JWT secured authorization responses.
+	AuthorizationSigningAlgValuesSupported []string `json:"authorization_signing_alg_values_supported"`
+
 	// ^^^ Optional ^^^
 
 	// vvv Custom vvv
@@ -51,26 +69,49 @@ type Metadata struct {
 	// ^^^ Custom ^^^
 }
 
-// NewHandler returns an http.Handler that serves an OIDC discovery endpoint.
-func NewHandler(issuerURL string) http.Handler {
+// NewHandler returns an http.Handler that serves an OIDC discovery endpoint. The signingAlgorithm is the algorithm of
+// the signing keys of the FederationDomain, which is advertised as the only algorithm of its ID tokens and JWT
+// authorization responses.
+func NewHandler(issuerURL string, signingAlgorithm string) http.Handler {
+	return newHandler(issuerURL, "", signingAlgorithm)
+}
+
+// NewPreviousIssuerHandler returns an http.Handler that serves the OIDC discovery endpoint of the previous issuer of
+// a FederationDomain, which tells clients that the issuer was changed to issuerURL.
+func NewPreviousIssuerHandler(previousIssuerURL string, issuerURL string, signingAlgorithm string) http.Handler {
+	return newHandler(previousIssuerURL, issuerURL, signingAlgorithm)
+}
+
+func newHandler(issuerURL string, issuerMigratedTo string, signingAlgorithm string) http.Handler {
 	oidcConfig := Metadata{
-		Issuer:                issuerURL,
-		AuthorizationEndpoint: issuerURL + oidc.AuthorizationEndpointPath,
-		TokenEndpoint:         issuerURL + oidc.TokenEndpointPath,
-		JWKSURI:               issuerURL + oidc.JWKSEndpointPath,
+		Issuer:                             issuerURL,
+		AuthorizationEndpoint:              issuerURL + oidc.AuthorizationEndpointPath,
+		TokenEndpoint:                      issuerURL + oidc.TokenEndpointPath,
+		JWKSURI:                            issuerURL + oidc.JWKSEndpointPath,
+		PushedAuthorizationRequestEndpoint: issuerURL + oidc.PushedAuthorizeEndpointPath,
 		OIDCDiscoveryResponse: v1alpha1.OIDCDiscoveryResponse{
 			SupervisorDiscovery: v1alpha1.OIDCDiscoveryResponseIDPEndpoint{
 				PinnipedIDPsEndpoint: issuerURL + oidc.PinnipedIDPsPathV1Alpha1,
+				IssuerMigratedTo:     issuerMigratedTo,
 			},
 		},
-		ResponseTypesSupported:            []string{"code"},
-		ResponseModesSupported:            []string{"query", "form_post"},
-		SubjectTypesSupported:             []string{"public"},
-		IDTokenSigningAlgValuesSupported:  []string{"ES256"},
-		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic"},
-		CodeChallengeMethodsSupported:     []string{"S256"},
-		ScopesSupported:                   []string{oidcapi.ScopeOpenID, oidcapi.ScopeOfflineAccess, oidcapi.ScopeRequestAudience, oidcapi.ScopeUsername, oidcapi.ScopeGroups},
-		ClaimsSupported:                   []string{oidcapi.IDTokenClaimUsername, oidcapi.IDTokenClaimGroups, oidcapi.IDTokenClaimAdditionalClaims},
+		ResponseTypesSupported:                     []string{"code"},
+		ResponseModesSupported:                     []string{"query", "form_post", string(jarm.ResponseModeJWT), string(jarm.ResponseModeQueryJWT)},
+		SubjectTypesSupported:                      []string{"public"},
+		IDTokenSigningAlgValuesSupported:           []string{signingAlgorithm},
+		TokenEndpointAuthMethodsSupported:          []string{"client_secret_basic", "private_key_jwt"},
+		TokenEndpointAuthSigningAlgValuesSupported: []string{"ES256"},
+		CodeChallengeMethodsSupported:              []string{"S256"},
+		DPoPSigningAlgValuesSupported:              dpop.SupportedAlgorithms,
+		AuthorizationSigningAlgValuesSupported:     []string{signingAlgorithm},
+		ScopesSupported:                            []string{oidcapi.ScopeOpenID, oidcapi.ScopeOfflineAccess, oidcapi.ScopeRequestAudience, oidcapi.ScopeUsername, oidcapi.ScopeGroups},
+		ClaimsSupported:                            []string{oidcapi.IDTokenClaimUsername, oidcapi.IDTokenClaimGroups, oidcapi.IDTokenClaimAdditionalClaims},
+	}
+
+	// The previous issuer of a FederationDomain does not serve the cluster audience discovery endpoint, since clients
+	// should not generate new kubeconfigs for it.
+	if issuerMigratedTo == "" {
+		oidcConfig.SupervisorDiscovery.PinnipedClusterAudiencesEndpoint = issuerURL + oidc.PinnipedClusterAudiencesPathV1Alpha1
 	}
 
 	var b bytes.Buffer
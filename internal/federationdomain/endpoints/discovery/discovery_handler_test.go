@@ -18,9 +18,11 @@ func TestDiscovery(t *testing.T) {
 	tests := []struct {
 		name string
 
-		issuer string
-		method string
-		path   string
+		issuer           string
+		issuerMigratedTo string
+		signingAlgorithm string
+		method           string
+		path             string
 
 		wantStatus      int
 		wantContentType string
@@ -41,15 +43,54 @@ func TestDiscovery(t *testing.T) {
 				"token_endpoint": "https://some-issuer.com/some/path/oauth2/token",
 				"jwks_uri": "https://some-issuer.com/some/path/jwks.json",
 				"response_types_supported": ["code"],
-				"response_modes_supported": ["query", "form_post"],
+				"response_modes_supported": ["query", "form_post", "jwt", "query.jwt"],
 				"subject_types_supported": ["public"],
 				"id_token_signing_alg_values_supported": ["ES256"],
-				"token_endpoint_auth_methods_supported": ["client_secret_basic"],
+				"token_endpoint_auth_methods_supported": ["client_secret_basic", "private_key_jwt"],
+				"token_endpoint_auth_signing_alg_values_supported": ["ES256"],
 				"scopes_supported": ["openid", "offline_access", "pinniped:request-audience", "username", "groups"],
 				"code_challenge_methods_supported": ["S256"],
+				"dpop_signing_alg_values_supported": ["ES256", "ES384", "ES512", "RS256", "PS256", "EdDSA"],
+				"pushed_authorization_request_endpoint": "https://some-issuer.com/some/path/oauth2/par",
+				"authorization_signing_alg_values_supported": ["ES256"],
 				"claims_supported": ["username", "groups", "additionalClaims"],
 				"discovery.supervisor.pinniped.dev/v1alpha1": {
-					"pinniped_identity_providers_endpoint": "https://some-issuer.com/some/path/v1alpha1/pinniped_identity_providers"
+					"pinniped_identity_providers_endpoint": "https://some-issuer.com/some/path/v1alpha1/pinniped_identity_providers",
+					"pinniped_cluster_audiences_endpoint": "https://some-issuer.com/some/path/v1alpha1/pinniped_cluster_audiences"
+				}
+			}
+			`),
+		},
+		{
+			name:             "previous issuer",
+			issuer:           "https://old-issuer.com/some/path",
+			issuerMigratedTo: "https://some-issuer.com/some/path",
+			signingAlgorithm: "RS256",
+			method:           http.MethodGet,
+			path:             "/some/path" + oidc.WellKnownEndpointPath,
+			wantStatus:       http.StatusOK,
+			wantContentType:  "application/json",
+			wantBodyJSON: here.Doc(`
+			{
+				"issuer": "https://old-issuer.com/some/path",
+				"authorization_endpoint": "https://old-issuer.com/some/path/oauth2/authorize",
+				"token_endpoint": "https://old-issuer.com/some/path/oauth2/token",
+				"jwks_uri": "https://old-issuer.com/some/path/jwks.json",
+				"response_types_supported": ["code"],
+				"response_modes_supported": ["query", "form_post", "jwt", "query.jwt"],
+				"subject_types_supported": ["public"],
+				"id_token_signing_alg_values_supported": ["RS256"],
+				"token_endpoint_auth_methods_supported": ["client_secret_basic", "private_key_jwt"],
+				"token_endpoint_auth_signing_alg_values_supported": ["ES256"],
+				"scopes_supported": ["openid", "offline_access", "pinniped:request-audience", "username", "groups"],
+				"code_challenge_methods_supported": ["S256"],
+				"dpop_signing_alg_values_supported": ["ES256", "ES384", "ES512", "RS256", "PS256", "EdDSA"],
+				"pushed_authorization_request_endpoint": "https://old-issuer.com/some/path/oauth2/par",
+				"authorization_signing_alg_values_supported": ["RS256"],
+				"claims_supported": ["username", "groups", "additionalClaims"],
+				"discovery.supervisor.pinniped.dev/v1alpha1": {
+					"pinniped_identity_providers_endpoint": "https://old-issuer.com/some/path/v1alpha1/pinniped_identity_providers",
+					"issuer_migrated_to": "https://some-issuer.com/some/path"
 				}
 			}
 			`),
@@ -66,7 +107,14 @@ func TestDiscovery(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			handler := NewHandler(test.issuer)
+			signingAlgorithm := test.signingAlgorithm
+			if signingAlgorithm == "" {
+				signingAlgorithm = "ES256"
+			}
+			handler := NewHandler(test.issuer, signingAlgorithm)
+			if test.issuerMigratedTo != "" {
+				handler = NewPreviousIssuerHandler(test.issuer, test.issuerMigratedTo, signingAlgorithm)
+			}
 			req := httptest.NewRequest(test.method, test.path, nil)
 			rsp := httptest.NewRecorder()
 			handler.ServeHTTP(rsp, req)
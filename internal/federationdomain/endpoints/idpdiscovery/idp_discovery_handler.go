@@ -8,10 +8,10 @@ import (
 	"bytes"
 	"encoding/json"
 	"net/http"
-	"sort"
 
 	"go.pinniped.dev/generated/latest/apis/supervisor/idpdiscovery/v1alpha1"
 	"go.pinniped.dev/internal/federationdomain/federationdomainproviders"
+	"go.pinniped.dev/internal/federationdomain/resolvedprovider"
 )
 
 // NewHandler returns an http.Handler that serves the upstream IDP discovery endpoint.
@@ -40,28 +40,33 @@ func responseAsJSON(upstreamIDPs federationdomainproviders.FederationDomainIdent
 	r := v1alpha1.IDPDiscoveryResponse{
 		PinnipedSupportedIDPTypes: []v1alpha1.PinnipedSupportedIDPType{
 			{Type: v1alpha1.IDPTypeActiveDirectory},
+			{Type: v1alpha1.IDPTypeClientCertificate},
 			{Type: v1alpha1.IDPTypeGitHub},
 			{Type: v1alpha1.IDPTypeLDAP},
+			{Type: v1alpha1.IDPTypeMock},
 			{Type: v1alpha1.IDPTypeOIDC},
+			{Type: v1alpha1.IDPTypeOpenShift},
 		},
 	}
 
 	upstreams := upstreamIDPs.GetIdentityProviders()
+
+	// Nobody like an API that changes the results unnecessarily. :)
+	resolvedprovider.SortForPresentation(upstreams)
+
 	r.PinnipedIDPs = make([]v1alpha1.PinnipedIDP, len(upstreams))
 	// The cache of IDPs could change at any time, so always recalculate the list.
 	for i, federationDomainIdentityProvider := range upstreams {
+		presentation := federationDomainIdentityProvider.GetPresentation()
 		r.PinnipedIDPs[i] = v1alpha1.PinnipedIDP{
-			Name:  federationDomainIdentityProvider.GetDisplayName(),
-			Type:  federationDomainIdentityProvider.GetIDPDiscoveryType(),
-			Flows: federationDomainIdentityProvider.GetIDPDiscoveryFlows(),
+			Name:        federationDomainIdentityProvider.GetDisplayName(),
+			Type:        federationDomainIdentityProvider.GetIDPDiscoveryType(),
+			Flows:       federationDomainIdentityProvider.GetIDPDiscoveryFlows(),
+			Description: presentation.Description,
+			IconURL:     presentation.IconURL,
 		}
 	}
 
-	// Nobody like an API that changes the results unnecessarily. :)
-	sort.SliceStable(r.PinnipedIDPs, func(i, j int) bool {
-		return r.PinnipedIDPs[i].Name < r.PinnipedIDPs[j].Name
-	})
-
 	var b bytes.Buffer
 	encodeErr := json.NewEncoder(&b).Encode(&r)
 	encodedMetadata := b.Bytes()
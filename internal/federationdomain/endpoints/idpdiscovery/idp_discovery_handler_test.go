@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"go.pinniped.dev/internal/federationdomain/oidc"
+	"go.pinniped.dev/internal/federationdomain/resolvedprovider"
 	"go.pinniped.dev/internal/here"
 	"go.pinniped.dev/internal/testutil/oidctestutil"
 	"go.pinniped.dev/internal/testutil/testidplister"
@@ -61,9 +62,12 @@ func TestIDPDiscovery(t *testing.T) {
 				],
 				"pinniped_supported_identity_provider_types": [
 					{"type": "activedirectory"},
+					{"type": "clientcertificate"},
 					{"type": "github"},
 					{"type": "ldap"},
-					{"type": "oidc"}
+					{"type": "mock"},
+					{"type": "oidc"},
+					{"type": "openshift"}
 				]
 			}`),
 			wantSecondResponseBodyJSON: here.Doc(`{
@@ -78,9 +82,66 @@ func TestIDPDiscovery(t *testing.T) {
 				],
 				"pinniped_supported_identity_provider_types": [
 					{"type": "activedirectory"},
+					{"type": "clientcertificate"},
 					{"type": "github"},
 					{"type": "ldap"},
-					{"type": "oidc"}
+					{"type": "mock"},
+					{"type": "oidc"},
+					{"type": "openshift"}
+				]
+			}`),
+		},
+		{
+			name:   "IDPs with presentation settings are sorted by order and include their description and icon",
+			method: http.MethodGet,
+			path:   "/some/path" + oidc.WellKnownEndpointPath,
+			idpLister: testidplister.NewUpstreamIDPListerBuilder().
+				WithOIDC(oidctestutil.NewTestUpstreamOIDCIdentityProviderBuilder().WithName("z-some-oidc-idp").Build()).
+				WithLDAP(oidctestutil.NewTestUpstreamLDAPIdentityProviderBuilder().WithName("a-some-ldap-idp").Build()).
+				WithLDAP(oidctestutil.NewTestUpstreamLDAPIdentityProviderBuilder().WithName("b-some-ldap-idp").Build()).
+				WithPresentation("z-some-oidc-idp", resolvedprovider.Presentation{
+					Description: "Log in with your corporate account",
+					IconURL:     "https://example.com/icon.png",
+					Order:       -1,
+				}).
+				WithPresentation("a-some-ldap-idp", resolvedprovider.Presentation{Order: 1}).
+				WithPresentation("some-other-ldap-idp-1", resolvedprovider.Presentation{Order: 1}).
+				BuildFederationDomainIdentityProvidersListerFinder(),
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+			wantFirstResponseBodyJSON: here.Doc(`{
+				"pinniped_identity_providers": [
+					{"name": "z-some-oidc-idp", "type": "oidc", "flows": ["browser_authcode"], "description": "Log in with your corporate account", "icon_url": "https://example.com/icon.png"},
+					{"name": "b-some-ldap-idp", "type": "ldap", "flows": ["cli_password", "browser_authcode"]},
+					{"name": "a-some-ldap-idp", "type": "ldap", "flows": ["cli_password", "browser_authcode"]}
+				],
+				"pinniped_supported_identity_provider_types": [
+					{"type": "activedirectory"},
+					{"type": "clientcertificate"},
+					{"type": "github"},
+					{"type": "ldap"},
+					{"type": "mock"},
+					{"type": "oidc"},
+					{"type": "openshift"}
+				]
+			}`),
+			wantSecondResponseBodyJSON: here.Doc(`{
+				"pinniped_identity_providers": [
+					{"name": "some-other-ad-idp-1",   "type": "activedirectory", "flows": ["cli_password", "browser_authcode"]},
+					{"name": "some-other-ad-idp-2",   "type": "activedirectory", "flows": ["cli_password", "browser_authcode"]},
+					{"name": "some-other-ldap-idp-2", "type": "ldap",            "flows": ["cli_password", "browser_authcode"]},
+					{"name": "some-other-oidc-idp-1", "type": "oidc",            "flows": ["browser_authcode", "cli_password"]},
+					{"name": "some-other-oidc-idp-2", "type": "oidc",            "flows": ["browser_authcode"]},
+					{"name": "some-other-ldap-idp-1", "type": "ldap",            "flows": ["cli_password", "browser_authcode"]}
+				],
+				"pinniped_supported_identity_provider_types": [
+					{"type": "activedirectory"},
+					{"type": "clientcertificate"},
+					{"type": "github"},
+					{"type": "ldap"},
+					{"type": "mock"},
+					{"type": "oidc"},
+					{"type": "openshift"}
 				]
 			}`),
 		},
@@ -96,9 +157,12 @@ func TestIDPDiscovery(t *testing.T) {
 				"pinniped_identity_providers": [],
 				"pinniped_supported_identity_provider_types": [
 					{"type": "activedirectory"},
+					{"type": "clientcertificate"},
 					{"type": "github"},
 					{"type": "ldap"},
-					{"type": "oidc"}
+					{"type": "mock"},
+					{"type": "oidc"},
+					{"type": "openshift"}
 				]
 			}`),
 			wantSecondResponseBodyJSON: here.Doc(`{
@@ -112,9 +176,12 @@ func TestIDPDiscovery(t *testing.T) {
 				],
 				"pinniped_supported_identity_provider_types": [
 					{"type": "activedirectory"},
+					{"type": "clientcertificate"},
 					{"type": "github"},
 					{"type": "ldap"},
-					{"type": "oidc"}
+					{"type": "mock"},
+					{"type": "oidc"},
+					{"type": "openshift"}
 				]
 			}`),
 		},
@@ -0,0 +1,66 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package jwks
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"slices"
+
+	"github.com/go-jose/go-jose/v3"
+
+	"go.pinniped.dev/internal/constable"
+)
+
+// ErrUnsupportedSigningKey is returned by SigningAlgorithm for keys which a FederationDomain cannot sign with.
+const ErrUnsupportedSigningKey = constable.Error("JWK must be of type ecdsa (P-256), rsa or ed25519")
+
+// SupportedSigningAlgorithms are the JWS algorithms which the signing keys of a FederationDomain may use, and which
+// are advertised by its discovery document.
+func SupportedSigningAlgorithms() []string {
+	return []string{string(jose.ES256), string(jose.RS256), string(jose.EdDSA)}
+}
+
+// RequestSigner is a jose.OpaqueSigner which signs by calling a remote service, e.g. an external signing service.
+// It only signs after it was bound to the context of the request which needs the signature.
+type RequestSigner interface {
+	jose.OpaqueSigner
+
+	// WithContext returns a copy of the signer which sends its signing requests using the context.
+	WithContext(ctx context.Context) jose.OpaqueSigner
+}
+
+// SigningKey returns the key to sign with using the private key of the JWK during the request of the context.
+func SigningKey(ctx context.Context, jwk *jose.JSONWebKey) any {
+	if signer, ok := jwk.Key.(RequestSigner); ok {
+		return signer.WithContext(ctx)
+	}
+	return jwk.Key
+}
+
+// SigningAlgorithm returns the JWS algorithm which is used to sign tokens using the private key of the JWK. The key
+// may also be a jose.OpaqueSigner, e.g. when the private key is held by an external signing service.
+func SigningAlgorithm(jwk *jose.JSONWebKey) (jose.SignatureAlgorithm, error) {
+	switch key := jwk.Key.(type) {
+	case *ecdsa.PrivateKey:
+		if key.Curve != elliptic.P256() {
+			return "", ErrUnsupportedSigningKey
+		}
+		return jose.ES256, nil
+	case *rsa.PrivateKey:
+		return jose.RS256, nil
+	case ed25519.PrivateKey:
+		return jose.EdDSA, nil
+	case jose.OpaqueSigner:
+		if algs := key.Algs(); len(algs) == 1 && slices.Contains(SupportedSigningAlgorithms(), string(algs[0])) {
+			return algs[0], nil
+		}
+		return "", ErrUnsupportedSigningKey
+	default:
+		return "", ErrUnsupportedSigningKey
+	}
+}
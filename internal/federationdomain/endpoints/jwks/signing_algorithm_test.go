@@ -0,0 +1,90 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package jwks
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigningAlgorithm(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecP384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		key     any
+		wantAlg jose.SignatureAlgorithm
+		wantErr string
+	}{
+		{name: "ecdsa", key: ecKey, wantAlg: jose.ES256},
+		{name: "rsa", key: rsaKey, wantAlg: jose.RS256},
+		{name: "ed25519", key: edKey, wantAlg: jose.EdDSA},
+		{name: "opaque signer", key: &testOpaqueSigner{algs: []jose.SignatureAlgorithm{jose.RS256}}, wantAlg: jose.RS256},
+		{name: "opaque signer with unsupported algorithm", key: &testOpaqueSigner{algs: []jose.SignatureAlgorithm{jose.PS256}}, wantErr: "JWK must be of type ecdsa (P-256), rsa or ed25519"},
+		{name: "ecdsa with another curve", key: ecP384Key, wantErr: "JWK must be of type ecdsa (P-256), rsa or ed25519"},
+		{name: "public key", key: ecKey.Public(), wantErr: "JWK must be of type ecdsa (P-256), rsa or ed25519"},
+		{name: "nil", key: nil, wantErr: "JWK must be of type ecdsa (P-256), rsa or ed25519"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alg, err := SigningAlgorithm(&jose.JSONWebKey{Key: tt.key})
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantAlg, alg)
+		})
+	}
+}
+
+func TestSigningKey(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	require.Equal(t, ecKey, SigningKey(context.Background(), &jose.JSONWebKey{Key: ecKey}))
+
+	opaqueSigner := &testOpaqueSigner{algs: []jose.SignatureAlgorithm{jose.RS256}}
+	require.Equal(t, opaqueSigner, SigningKey(context.Background(), &jose.JSONWebKey{Key: opaqueSigner}))
+
+	ctx := context.WithValue(context.Background(), testContextKey{}, "some-request")
+	signer := SigningKey(ctx, &jose.JSONWebKey{Key: &testRequestSigner{}})
+	require.Equal(t, &testRequestSigner{ctx: ctx}, signer)
+}
+
+type testContextKey struct{}
+
+type testRequestSigner struct {
+	jose.OpaqueSigner // panic if any other methods called
+
+	ctx context.Context
+}
+
+func (s *testRequestSigner) WithContext(ctx context.Context) jose.OpaqueSigner {
+	return &testRequestSigner{ctx: ctx}
+}
+
+type testOpaqueSigner struct {
+	jose.OpaqueSigner // panic if any other methods called
+
+	algs []jose.SignatureAlgorithm
+}
+
+func (s *testOpaqueSigner) Algs() []jose.SignatureAlgorithm {
+	return s.algs
+}
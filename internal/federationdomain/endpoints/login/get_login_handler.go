@@ -4,40 +4,122 @@
 package login
 
 import (
+	"encoding/base64"
 	"net/http"
+	"strings"
 
+	"go.pinniped.dev/internal/federationdomain/branding"
 	"go.pinniped.dev/internal/federationdomain/endpoints/login/loginhtml"
 	"go.pinniped.dev/internal/federationdomain/endpoints/loginurl"
+	"go.pinniped.dev/internal/federationdomain/federationdomainproviders"
 	"go.pinniped.dev/internal/federationdomain/oidc"
+	"go.pinniped.dev/internal/federationdomain/secondfactor"
+	"go.pinniped.dev/internal/httputil/httperr"
+	"go.pinniped.dev/internal/i18n"
+	"go.pinniped.dev/internal/plog"
 )
 
-const (
-	internalErrorMessage                    = "An internal error occurred. Please contact your administrator for help."
-	incorrectUsernameOrPasswordErrorMessage = "Incorrect username or password."
-)
-
-func NewGetHandler(loginPath string) HandlerFunc {
+// NewGetHandler returns a HandlerFunc which renders the login page. getBranding returns the current
+// branding of the FederationDomain, or nil when the default branding should be used. The webAuthn may be nil,
+// in which case the page never asks for a WebAuthn credential.
+func NewGetHandler(
+	loginPath string,
+	upstreamIDPs federationdomainproviders.FederationDomainIdentityProvidersFinderI,
+	webAuthn *secondfactor.WebAuthnVerifier,
+	getBranding func() *branding.Branding,
+) HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request, encodedState string, decodedState *oidc.UpstreamStateParamData) error {
-		alertMessage, hasAlert := getAlert(r)
-
-		pageInputs := &loginhtml.PageData{
-			PostPath:      loginPath,
-			State:         encodedState,
-			IDPName:       decodedState.UpstreamName,
-			HasAlertError: hasAlert,
-			AlertMessage:  alertMessage,
+		errToDisplay := loginurl.ErrorParamValue(r.URL.Query().Get(loginurl.ErrParamName))
+		return renderLoginPage(w, r, loginPath, upstreamIDPs, webAuthn, getBranding(), encodedState, decodedState, errToDisplay, nil, "")
+	}
+}
+
+// renderLoginPage renders the login page. When the identity provider requires a second factor, the page also asks
+// for a one-time code. A non-nil totpEnrollment asks the user to add their secret to their authenticator app.
+// When the FederationDomain uses WebAuthn, the page asks the browser for a credential, or asks it to register a
+// new credential for the user when registerWebAuthnFor is the upstream username of the user.
+// When the page is the response to a POST request, then totpEnrollment or registerWebAuthnFor must be set.
+func renderLoginPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	loginPath string,
+	upstreamIDPs federationdomainproviders.FederationDomainIdentityProvidersFinderI,
+	webAuthn *secondfactor.WebAuthnVerifier,
+	b *branding.Branding,
+	encodedState string,
+	decodedState *oidc.UpstreamStateParamData,
+	errToDisplay loginurl.ErrorParamValue,
+	totpEnrollment *loginhtml.TOTPEnrollment,
+	registerWebAuthnFor string,
+) error {
+	askForOneTimeCode := false
+	var webAuthnPageData *loginhtml.WebAuthn
+	if idp, err := upstreamIDPs.FindUpstreamIDPByDisplayName(decodedState.UpstreamName); err == nil {
+		askForOneTimeCode = secondfactor.TOTPConfig(idp) != nil
+		if webAuthn.AppliesTo(idp) {
+			ceremony, err := webAuthn.NewCeremony(encodedState, registerWebAuthnFor != "", idp.GetProvider().GetResourceName(), registerWebAuthnFor)
+			if err != nil {
+				plog.Error("error starting WebAuthn ceremony", err)
+				return httperr.Wrap(http.StatusInternalServerError, "error starting WebAuthn ceremony", err)
+			}
+			webAuthnPageData = webAuthnForPage(ceremony)
 		}
-		return loginhtml.Template().Execute(w, pageInputs)
 	}
+
+	localizer := i18n.FromContext(r.Context())
+	alertMessage, hasAlert := getAlert(errToDisplay, b, localizer, askForOneTimeCode, webAuthnPageData != nil)
+
+	pageInputs := &loginhtml.PageData{
+		PostPath:          loginPath,
+		State:             encodedState,
+		IDPName:           decodedState.UpstreamName,
+		HasAlertError:     hasAlert,
+		AlertMessage:      alertMessage,
+		Branding:          b.ForPage(strings.TrimSuffix(loginPath, oidc.PinnipedLoginPath)),
+		Localizer:         localizer,
+		AskForOneTimeCode: askForOneTimeCode,
+		TOTPEnrollment:    totpEnrollment,
+		WebAuthn:          webAuthnPageData,
+	}
+	if totpEnrollment != nil || registerWebAuthnFor != "" {
+		// The user already submitted their username, so they only need to enter their password and second factor again.
+		pageInputs.Username = r.PostFormValue(loginurl.UsernameParamName)
+	}
+	return loginhtml.Template().Execute(w, pageInputs)
 }
 
-func getAlert(r *http.Request) (string, bool) {
-	errorParamValue := r.URL.Query().Get(loginurl.ErrParamName)
+// webAuthnForPage encodes the WebAuthn ceremony for the login page.
+func webAuthnForPage(ceremony *secondfactor.WebAuthnCeremony) *loginhtml.WebAuthn {
+	return &loginhtml.WebAuthn{
+		Register:       ceremony.Register,
+		Token:          ceremony.Token,
+		Challenge:      base64.RawURLEncoding.EncodeToString(ceremony.Challenge),
+		RelyingPartyID: ceremony.RelyingPartyID,
+		UserID:         base64.RawURLEncoding.EncodeToString(ceremony.UserID),
+		Username:       ceremony.Username,
+		Skippable:      ceremony.Skippable,
+	}
+}
 
-	message := internalErrorMessage
-	if errorParamValue == string(loginurl.ShowBadUserPassErr) {
-		message = incorrectUsernameOrPasswordErrorMessage
+func getAlert(errToDisplay loginurl.ErrorParamValue, b *branding.Branding, localizer *i18n.Localizer, askForOneTimeCode, askForPasskey bool) (string, bool) {
+	// Custom messages from the branding are not localized, so they take precedence over the localized defaults.
+	message := localizer.T("login.error.internal")
+	if b != nil && b.InternalErrorMessage != "" {
+		message = b.InternalErrorMessage
+	}
+	if errToDisplay == loginurl.ShowBadUserPassErr {
+		// Do not reveal whether it was the password or the second factor which was wrong.
+		message = localizer.T("login.error.incorrectUsernameOrPassword")
+		switch {
+		case askForPasskey:
+			message = localizer.T("login.error.incorrectUsernamePasswordOrPasskey")
+		case askForOneTimeCode:
+			message = localizer.T("login.error.incorrectUsernamePasswordOrOneTimeCode")
+		}
+		if b != nil && b.IncorrectUsernameOrPasswordMessage != "" {
+			message = b.IncorrectUsernameOrPasswordMessage
+		}
 	}
 
-	return message, errorParamValue != ""
+	return message, errToDisplay != loginurl.ShowNoError
 }
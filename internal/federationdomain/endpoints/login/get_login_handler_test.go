@@ -1,4 +1,4 @@
-// Copyright 2022-2023 the Pinniped contributors. All Rights Reserved.
+// Copyright 2022-2024 the Pinniped contributors. All Rights Reserved.
 // SPDX-License-Identifier: Apache-2.0
 
 package login
@@ -7,13 +7,23 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/gorilla/securecookie"
 	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
 
+	"go.pinniped.dev/internal/federationdomain/branding"
 	"go.pinniped.dev/internal/federationdomain/endpoints/login/loginhtml"
-	"go.pinniped.dev/internal/federationdomain/idplister"
 	"go.pinniped.dev/internal/federationdomain/oidc"
+	"go.pinniped.dev/internal/federationdomain/secondfactor"
+	"go.pinniped.dev/internal/i18n"
 	"go.pinniped.dev/internal/testutil"
+	"go.pinniped.dev/internal/testutil/oidctestutil"
+	"go.pinniped.dev/internal/testutil/testidplister"
+	"go.pinniped.dev/internal/totp"
+	"go.pinniped.dev/internal/webauthn"
+	"go.pinniped.dev/internal/webauthncredentialstorage"
 )
 
 func TestGetLogin(t *testing.T) {
@@ -29,10 +39,14 @@ func TestGetLogin(t *testing.T) {
 		decodedState    *oidc.UpstreamStateParamData
 		encodedState    string
 		errParam        string
-		idps            idplister.UpstreamIdentityProvidersLister
+		branding        *branding.Branding
+		language        string
+		idps            *testidplister.UpstreamIDPListerBuilder
+		webAuthn        bool
 		wantStatus      int
 		wantContentType string
 		wantBody        string
+		wantBodyParts   []string
 	}{
 		{
 			name: "Happy path ldap",
@@ -89,6 +103,98 @@ func TestGetLogin(t *testing.T) {
 				"An internal error occurred. Please contact your administrator for help.",
 			),
 		},
+		{
+			name: "displays customized error banner when err=login_error param is sent and branding is configured",
+			decodedState: &oidc.UpstreamStateParamData{
+				UpstreamName: testUpstreamName,
+				UpstreamType: testUpstreamType,
+			},
+			encodedState:    testEncodedState,
+			errParam:        "login_error",
+			branding:        &branding.Branding{IncorrectUsernameOrPasswordMessage: "Wrong! Try again."},
+			wantStatus:      http.StatusOK,
+			wantContentType: htmlContentType,
+			wantBody: testutil.ExpectedLoginPageHTML(loginhtml.CSS(), testUpstreamName, testPath, testEncodedState,
+				"Wrong! Try again.",
+			),
+		},
+		{
+			name: "displays customized error banner when err=internal_error param is sent and branding is configured",
+			decodedState: &oidc.UpstreamStateParamData{
+				UpstreamName: testUpstreamName,
+				UpstreamType: testUpstreamType,
+			},
+			encodedState:    testEncodedState,
+			errParam:        "internal_error",
+			branding:        &branding.Branding{InternalErrorMessage: "Something broke. Call the help desk."},
+			wantStatus:      http.StatusOK,
+			wantContentType: htmlContentType,
+			wantBody: testutil.ExpectedLoginPageHTML(loginhtml.CSS(), testUpstreamName, testPath, testEncodedState,
+				"Something broke. Call the help desk.",
+			),
+		},
+		{
+			name: "displays the page and the error banner in the language of the request",
+			decodedState: &oidc.UpstreamStateParamData{
+				UpstreamName: testUpstreamName,
+				UpstreamType: testUpstreamType,
+			},
+			encodedState:    testEncodedState,
+			errParam:        "login_error",
+			language:        "de-DE,de;q=0.9,en;q=0.8",
+			wantStatus:      http.StatusOK,
+			wantContentType: htmlContentType,
+			wantBodyParts: []string{
+				`<html lang="de">`,
+				`<title>Pinniped-Anmeldung</title>`,
+				`<h1>Bei some-ldap-idp anmelden</h1>`,
+				`id="alert">Benutzername oder Passwort ist falsch.</span>`,
+				`placeholder="Passwort"`,
+				`value="Anmelden"`,
+			},
+		},
+		{
+			name: "asks for a one-time code when the identity provider requires one",
+			decodedState: &oidc.UpstreamStateParamData{
+				UpstreamName: testUpstreamName,
+				UpstreamType: testUpstreamType,
+			},
+			encodedState: testEncodedState,
+			errParam:     "login_error",
+			idps: testidplister.NewUpstreamIDPListerBuilder().WithLDAP(
+				oidctestutil.NewTestUpstreamLDAPIdentityProviderBuilder().
+					WithName(testUpstreamName).
+					WithTOTPConfig(&totp.Config{Issuer: "some-issuer"}).
+					Build(),
+			),
+			wantStatus:      http.StatusOK,
+			wantContentType: htmlContentType,
+			wantBodyParts: []string{
+				`id="alert">Incorrect username, password, or one-time code.</span>`,
+				`<input type="text" name="one_time_code" id="one-time-code" inputmode="numeric" pattern="[0-9 ]*"` + "\n" +
+					`                   autocomplete="one-time-code" placeholder="One-time code" required>`,
+			},
+		},
+		{
+			name: "asks the browser for a passkey when the FederationDomain uses WebAuthn",
+			decodedState: &oidc.UpstreamStateParamData{
+				UpstreamName: testUpstreamName,
+				UpstreamType: testUpstreamType,
+			},
+			encodedState:    testEncodedState,
+			errParam:        "login_error",
+			webAuthn:        true,
+			wantStatus:      http.StatusOK,
+			wantContentType: htmlContentType,
+			wantBodyParts: []string{
+				`id="alert">Incorrect username, password, or passkey.</span>`,
+				`<input type="hidden" name="webauthn_challenge" id="webauthn-challenge" value="`,
+				`data-mode="get" data-challenge="`,
+				`data-rp-id="issuer.example.com">`,
+				`<input type="hidden" name="webauthn_response" id="webauthn-response" value="">`,
+				"<script>" + loginhtml.JS() + "</script>",
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -97,12 +203,29 @@ func TestGetLogin(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			handler := NewGetHandler(testPath)
+			idps := tt.idps
+			if idps == nil {
+				idps = testidplister.NewUpstreamIDPListerBuilder().WithLDAP(
+					oidctestutil.NewTestUpstreamLDAPIdentityProviderBuilder().WithName(testUpstreamName).Build(),
+				)
+			}
+			var webAuthn *secondfactor.WebAuthnVerifier
+			if tt.webAuthn {
+				relyingParty, err := webauthn.NewRelyingParty("https://issuer.example.com/some/path")
+				require.NoError(t, err)
+				webAuthn = secondfactor.NewWebAuthnVerifier(secondfactor.WebAuthnConfig{Enforcement: secondfactor.WebAuthnRequired},
+					relyingParty, webauthncredentialstorage.New(fake.NewSimpleClientset().CoreV1().Secrets("some-namespace")),
+					securecookie.New([]byte("fake-hash-secret"), nil), time.Now)
+			}
+			handler := NewGetHandler(testPath, idps.BuildFederationDomainIdentityProvidersListerFinder(), webAuthn, func() *branding.Branding { return tt.branding })
 			target := testPath + "?state=" + tt.encodedState
 			if tt.errParam != "" {
 				target += "&err=" + tt.errParam
 			}
 			req := httptest.NewRequest(http.MethodGet, target, nil)
+			if tt.language != "" {
+				req = req.WithContext(i18n.WithLocalizer(req.Context(), i18n.ForLanguage(tt.language)))
+			}
 			rsp := httptest.NewRecorder()
 			err := handler(rsp, req, tt.encodedState, tt.decodedState)
 			require.NoError(t, err)
@@ -111,6 +234,12 @@ func TestGetLogin(t *testing.T) {
 			testutil.RequireEqualContentType(t, rsp.Header().Get("Content-Type"), tt.wantContentType)
 			body := rsp.Body.String()
 			// t.Log("actual body:", body) // useful when updating expected values
+			if tt.wantBodyParts != nil {
+				for _, part := range tt.wantBodyParts {
+					require.Contains(t, body, part)
+				}
+				return
+			}
 			require.Equal(t, tt.wantBody, body)
 		})
 	}
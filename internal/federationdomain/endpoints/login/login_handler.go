@@ -57,7 +57,7 @@ func NewHandler(
 		}
 
 		switch decodedState.UpstreamType {
-		case string(idpdiscoveryv1alpha1.IDPTypeLDAP), string(idpdiscoveryv1alpha1.IDPTypeActiveDirectory):
+		case string(idpdiscoveryv1alpha1.IDPTypeLDAP), string(idpdiscoveryv1alpha1.IDPTypeActiveDirectory), string(idpdiscoveryv1alpha1.IDPTypeMock):
 			// these are the types supported by this endpoint, so no error here
 		default:
 			return httperr.Newf(http.StatusBadRequest, "not a supported upstream IDP type for this endpoint: %q", decodedState.UpstreamType)
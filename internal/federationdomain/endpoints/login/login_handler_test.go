@@ -74,6 +74,12 @@ func TestLoginEndpoint(t *testing.T) {
 		return s
 	}
 
+	expectedHappyDecodedUpstreamStateParamForMock := func() *oidc.UpstreamStateParamData {
+		s := expectedHappyDecodedUpstreamStateParam()
+		s.UpstreamType = "mock"
+		return s
+	}
+
 	happyUpstreamStateParam := func() *oidctestutil.UpstreamStateParamBuilder {
 		return &oidctestutil.UpstreamStateParamBuilder{
 			U: happyUpstreamIDPName,
@@ -102,6 +108,7 @@ func TestLoginEndpoint(t *testing.T) {
 	happyPathWithState := newRequestPath().WithState(happyState).String()
 
 	happyActiveDirectoryState := happyUpstreamStateParam().WithUpstreamIDPType("activedirectory").Build(t, happyStateCodec)
+	happyMockState := happyUpstreamStateParam().WithUpstreamIDPType("mock").Build(t, happyStateCodec)
 
 	encodedIncomingCookieCSRFValue, err := happyCookieCodec.Encode("csrf", happyDownstreamCSRF)
 	require.NoError(t, err)
@@ -364,6 +371,28 @@ func TestLoginEndpoint(t *testing.T) {
 			wantEncodedState: happyActiveDirectoryState,
 			wantDecodedState: expectedHappyDecodedUpstreamStateParamForActiveDirectory(),
 		},
+		{
+			name:             "happy GET request for mock upstream",
+			method:           http.MethodGet,
+			path:             newRequestPath().WithState(happyMockState).String(),
+			csrfCookie:       happyCSRFCookie,
+			wantStatus:       http.StatusOK,
+			wantContentType:  htmlContentType,
+			wantBody:         happyGetResult,
+			wantEncodedState: happyMockState,
+			wantDecodedState: expectedHappyDecodedUpstreamStateParamForMock(),
+		},
+		{
+			name:             "happy POST request for mock upstream",
+			method:           http.MethodPost,
+			path:             newRequestPath().WithState(happyMockState).String(),
+			csrfCookie:       happyCSRFCookie,
+			wantStatus:       http.StatusOK,
+			wantContentType:  htmlContentType,
+			wantBody:         happyPostResult,
+			wantEncodedState: happyMockState,
+			wantDecodedState: expectedHappyDecodedUpstreamStateParamForMock(),
+		},
 	}
 
 	for _, test := range tests {
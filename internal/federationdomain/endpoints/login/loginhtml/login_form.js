@@ -0,0 +1,60 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+window.onload = () => {
+    const challenge = document.getElementById('webauthn-challenge');
+    if (!challenge) {
+        return;
+    }
+    const form = challenge.form;
+    const response = document.getElementById('webauthn-response');
+
+    const decode = (s) => Uint8Array.from(atob(s.replace(/-/g, '+').replace(/_/g, '/')), (c) => c.charCodeAt(0));
+    const encode = (b) => btoa(String.fromCharCode(...new Uint8Array(b)))
+        .replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '');
+    // The form has an input named "submit", which hides the form's own submit method.
+    const submit = () => HTMLFormElement.prototype.submit.call(form);
+
+    form.addEventListener('submit', (event) => {
+        // Skipping the registration, and browsers without WebAuthn, submit the form without a response.
+        if (!window.PublicKeyCredential || (event.submitter && event.submitter.name === 'webauthn_skip')) {
+            return;
+        }
+        event.preventDefault();
+
+        const data = challenge.dataset;
+        let request;
+        if (data.mode === 'register') {
+            request = navigator.credentials.create({
+                publicKey: {
+                    challenge: decode(data.challenge),
+                    rp: {id: data.rpId, name: data.rpId},
+                    user: {id: decode(data.userId), name: data.userName, displayName: data.userName},
+                    // ES256, EdDSA, and RS256, in order of preference.
+                    pubKeyCredParams: [-7, -8, -257].map((alg) => ({type: 'public-key', alg: alg})),
+                    authenticatorSelection: {residentKey: 'required', userVerification: 'preferred'},
+                    attestation: 'none',
+                },
+            });
+        } else {
+            request = navigator.credentials.get({
+                publicKey: {challenge: decode(data.challenge), rpId: data.rpId, userVerification: 'preferred'},
+            });
+        }
+
+        request.then((credential) => {
+            const r = credential.response;
+            const encoded = {id: encode(credential.rawId), clientDataJSON: encode(r.clientDataJSON)};
+            if (r.attestationObject) {
+                encoded.attestationObject = encode(r.attestationObject);
+            } else {
+                encoded.authenticatorData = encode(r.authenticatorData);
+                encoded.signature = encode(r.signature);
+            }
+            response.value = JSON.stringify(encoded);
+        }).catch(() => {
+            // Submit without a response, e.g. when the user has no passkey yet. The Supervisor decides what is next.
+            response.value = '';
+        }).finally(submit);
+    });
+};
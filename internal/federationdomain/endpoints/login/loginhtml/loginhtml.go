@@ -1,4 +1,4 @@
-// Copyright 2022-2023 the Pinniped contributors. All Rights Reserved.
+// Copyright 2022-2024 the Pinniped contributors. All Rights Reserved.
 // SPDX-License-Identifier: Apache-2.0
 
 // Package loginhtml defines HTML templates used by the Supervisor.
@@ -11,7 +11,9 @@ import (
 
 	"github.com/tdewolff/minify/v2/minify"
 
+	"go.pinniped.dev/internal/federationdomain/branding"
 	"go.pinniped.dev/internal/federationdomain/csp"
+	"go.pinniped.dev/internal/i18n"
 )
 
 //nolint:gochecknoglobals // This package uses globals to ensure that all parsing and minifying happens at init.
@@ -20,18 +22,25 @@ var (
 	rawCSS      string
 	minifiedCSS = panicOnError(minify.CSS(rawCSS))
 
+	//go:embed login_form.js
+	rawJS      string
+	minifiedJS = panicOnError(minify.JS(rawJS))
+
 	//go:embed login_form.gohtml
 	rawHTMLTemplate string
 
 	// Parse the Go templated HTML and inject functions providing the minified inline CSS and JS.
 	parsedHTMLTemplate = template.Must(template.New("login_form.gohtml").Funcs(template.FuncMap{
 		"minifiedCSS": func() template.CSS { return template.CSS(CSS()) },
+		"minifiedJS":  func() template.JS { return template.JS(JS()) }, //nolint:gosec // This is 100% static input, not attacker-controlled.
 	}).Parse(rawHTMLTemplate))
 
 	// Generate the CSP header value once since it's effectively constant.
 	cspValue = strings.Join([]string{
 		`default-src 'none'`,
-		`style-src '` + csp.Hash(minifiedCSS) + `'`,
+		`script-src '` + csp.Hash(minifiedJS) + `'`,
+		`style-src '` + csp.Hash(minifiedCSS) + `' 'self'`, // 'self' allows the optional branding stylesheet
+		`img-src 'self'`,                                   // allows the optional branding logo
 		`frame-ancestors 'none'`,
 	}, "; ")
 )
@@ -54,6 +63,9 @@ func Template() *template.Template { return parsedHTMLTemplate }
 // CSS returns the minified CSS that will be embedded into the page template.
 func CSS() string { return minifiedCSS }
 
+// JS returns the minified JS that will be embedded into the page template.
+func JS() string { return minifiedJS }
+
 // PageData represents the inputs to the template.
 type PageData struct {
 	State         string
@@ -62,4 +74,58 @@ type PageData struct {
 	AlertMessage  string
 	MinifiedCSS   template.CSS
 	PostPath      string
+	Branding      *branding.PageBranding // nil when the default branding should be used
+	Localizer     *i18n.Localizer        // nil when the page should be rendered in English
+
+	// AskForOneTimeCode shows a field for the one-time code of the second factor of the identity provider.
+	AskForOneTimeCode bool
+	// TOTPEnrollment asks the user to add their TOTP secret to their authenticator app. Nil when the user
+	// does not need to enroll.
+	TOTPEnrollment *TOTPEnrollment
+	// Username pre-fills the username field. Empty when the user has not submitted it yet.
+	Username string
+	// WebAuthn asks the browser to use or to register a WebAuthn credential when the form is submitted. Nil when
+	// the FederationDomain does not use WebAuthn.
+	WebAuthn *WebAuthn
+}
+
+// WebAuthn is the ceremony which the browser must perform when the form is submitted. The binary values are
+// base64url encoded without padding.
+type WebAuthn struct {
+	// Register asks the browser to register a new credential instead of using an existing one.
+	Register bool
+	// Token is submitted along with the response of the browser.
+	Token          string
+	Challenge      string
+	RelyingPartyID string
+	// UserID and Username are only used when registering a credential.
+	UserID   string
+	Username string
+	// Skippable shows a button which logs in without registering a credential.
+	Skippable bool
+}
+
+// TOTPEnrollment is the TOTP secret which the user must add to their authenticator app.
+type TOTPEnrollment struct {
+	// Key is the secret in the form which users can type into their authenticator app.
+	Key string
+	// KeyURI is the otpauth:// URI which opens the authenticator app.
+	KeyURI template.URL
+}
+
+// T returns the localized message for the key. It is used by the template.
+func (d *PageData) T(key string, args ...any) string {
+	return d.localizer().T(key, args...)
+}
+
+// Language returns the language in which the page is rendered. It is used by the template.
+func (d *PageData) Language() string {
+	return d.localizer().Language()
+}
+
+func (d *PageData) localizer() *i18n.Localizer {
+	if d.Localizer == nil {
+		return i18n.Default()
+	}
+	return d.Localizer
 }
@@ -1,4 +1,4 @@
-// Copyright 2022-2023 the Pinniped contributors. All Rights Reserved.
+// Copyright 2022-2024 the Pinniped contributors. All Rights Reserved.
 // SPDX-License-Identifier: Apache-2.0
 
 package loginhtml
@@ -10,16 +10,20 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"go.pinniped.dev/internal/federationdomain/branding"
+	"go.pinniped.dev/internal/federationdomain/csp"
 	"go.pinniped.dev/internal/testutil"
 )
 
 var (
-	testExpectedCSS = `html{height:100%}body{font-family:metropolis-light,Helvetica,sans-serif;display:flex;flex-flow:column wrap;justify-content:flex-start;align-items:center;background:linear-gradient(to top,#f8f8f8,white);min-height:100%}h1{font-size:20px;margin:0}.box{display:flex;flex-direction:column;flex-wrap:nowrap;border-radius:4px;border-color:#ddd;border-width:1px;border-style:solid;width:400px;padding:30px 30px 0;margin:60px 20px 0;background:#fff;font-size:14px}input{color:inherit;font:inherit;border:0;margin:0;outline:0;padding:0}.form-field{display:flex;margin-bottom:30px}.form-field input[type=password],.form-field input[type=text],.form-field input[type=submit]{width:100%;padding:1em}.form-field input[type=password],.form-field input[type=text]{border-radius:3px;border-width:1px;border-style:solid;border-color:#a6a6a6}.form-field input[type=submit]{background-color:#218fcf;color:#eee;font-weight:700;cursor:pointer;transition:all .3s}.form-field input[type=submit]:focus,.form-field input[type=submit]:hover{background-color:#1abfd3}.form-field input[type=submit]:active{transform:scale(.99)}.hidden{border:0;clip:rect(0 0 0 0);height:1px;margin:-1px;overflow:hidden;padding:0;position:absolute;width:1px}.alert{color:crimson}`
+	testExpectedCSS = `html{height:100%}body{font-family:metropolis-light,Helvetica,sans-serif;display:flex;flex-flow:column wrap;justify-content:flex-start;align-items:center;background:linear-gradient(to top,#f8f8f8,white);min-height:100%}h1{font-size:20px;margin:0}.box{display:flex;flex-direction:column;flex-wrap:nowrap;border-radius:4px;border-color:#ddd;border-width:1px;border-style:solid;width:400px;padding:30px 30px 0;margin:60px 20px 0;background:#fff;font-size:14px}input{color:inherit;font:inherit;border:0;margin:0;outline:0;padding:0}.form-field{display:flex;margin-bottom:30px}.form-field input[type=password],.form-field input[type=text],.form-field input[type=submit]{width:100%;padding:1em}.form-field input[type=password],.form-field input[type=text]{border-radius:3px;border-width:1px;border-style:solid;border-color:#a6a6a6}.form-field input[type=submit]{background-color:#218fcf;color:#eee;font-weight:700;cursor:pointer;transition:all .3s}.form-field input[type=submit]:focus,.form-field input[type=submit]:hover{background-color:#1abfd3}.form-field input[type=submit]:active{transform:scale(.99)}.hidden{border:0;clip:rect(0 0 0 0);height:1px;margin:-1px;overflow:hidden;padding:0;position:absolute;width:1px}.alert{color:crimson}.logo img{max-width:100%;max-height:80px;margin:0 auto}.footer{margin:20px;font-size:12px;color:#666}`
 
 	// It's okay if this changes in the future, but this gives us a chance to eyeball the formatting.
 	// Our browser-based integration tests should find any incompatibilities.
 	testExpectedCSP = `default-src 'none'; ` +
-		`style-src 'sha256-QC9ckaUFAdcN0Ysmu8q8iqCazYFgrJSQDJPa/przPXU='; ` +
+		`script-src '` + csp.Hash(JS()) + `'; ` +
+		`style-src 'sha256-tM3qzI7R0qreYq39nofC2YWBh2cxlQsVlItgRuiYk5E=' 'self'; ` +
+		`img-src 'self'; ` +
 		`frame-ancestors 'none'`
 )
 
@@ -52,6 +56,68 @@ func TestTemplate(t *testing.T) {
 	buf = bytes.Buffer{} // clear previous result from buffer
 	require.NoError(t, Template().Execute(&buf, pageInputs))
 	require.Equal(t, expectedHTMLWithoutAlert, buf.String())
+
+	// Render again with branding.
+	pageInputs.Branding = &branding.PageBranding{
+		StylesheetPath: "/issuer/branding/style.css",
+		LogoPath:       "/issuer/branding/logo",
+		FooterText:     "test-footer <text>",
+	}
+	buf = bytes.Buffer{} // clear previous result from buffer
+	require.NoError(t, Template().Execute(&buf, pageInputs))
+	require.Contains(t, buf.String(), `</style>`+"\n"+`    <link rel="stylesheet" href="/issuer/branding/style.css">`+"\n")
+	require.Contains(t, buf.String(), `<div class="box" aria-label="login form" role="main">`+"\n"+
+		`    <div class="form-field logo">`+"\n"+
+		`        <img src="/issuer/branding/logo" alt="logo">`+"\n"+
+		`    </div>`+"\n")
+	require.Contains(t, buf.String(), "</div>\n"+`<footer class="footer">test-footer &lt;text&gt;</footer>`+"\n</body>")
+
+	// Render again with a one-time code and an enrollment.
+	pageInputs.Branding = nil
+	pageInputs.AskForOneTimeCode = true
+	pageInputs.Username = "test-username"
+	pageInputs.TOTPEnrollment = &TOTPEnrollment{
+		Key:    "TESTKEY",
+		KeyURI: "otpauth://totp/Pinniped:test-username?secret=TESTKEY",
+	}
+	buf = bytes.Buffer{} // clear previous result from buffer
+	require.NoError(t, Template().Execute(&buf, pageInputs))
+	require.Contains(t, buf.String(), `<span id="totp-key">Key: TESTKEY</span>`)
+	require.Contains(t, buf.String(), `<a href="otpauth://totp/Pinniped:test-username?secret=TESTKEY" id="totp-link">`)
+	require.Contains(t, buf.String(), `placeholder="Username" value="test-username" required>`)
+	require.Contains(t, buf.String(), `<input type="text" name="one_time_code" id="one-time-code"`)
+	require.NotContains(t, buf.String(), "<script>")
+
+	// Render again with a WebAuthn registration.
+	pageInputs.AskForOneTimeCode = false
+	pageInputs.TOTPEnrollment = nil
+	pageInputs.WebAuthn = &WebAuthn{
+		Register:       true,
+		Token:          "test-token",
+		Challenge:      "test-challenge",
+		RelyingPartyID: "issuer.example.com",
+		UserID:         "test-user-id",
+		Username:       "test-username",
+		Skippable:      true,
+	}
+	buf = bytes.Buffer{} // clear previous result from buffer
+	require.NoError(t, Template().Execute(&buf, pageInputs))
+	require.Contains(t, buf.String(), `<span id="webauthn-register">`)
+	require.Contains(t, buf.String(), `<input type="hidden" name="webauthn_challenge" id="webauthn-challenge" value="test-token"`+"\n"+
+		`               data-mode="register" data-challenge="test-challenge" data-rp-id="issuer.example.com"`+"\n"+
+		`               data-user-id="test-user-id" data-user-name="test-username">`)
+	require.Contains(t, buf.String(), `<input type="hidden" name="webauthn_response" id="webauthn-response" value="">`)
+	require.Contains(t, buf.String(), `<input type="submit" name="webauthn_skip" id="webauthn-skip"`)
+	require.Contains(t, buf.String(), "<script>"+JS()+"</script>")
+
+	// Render again with a WebAuthn assertion.
+	pageInputs.WebAuthn = &WebAuthn{Token: "test-token", Challenge: "test-challenge", RelyingPartyID: "issuer.example.com"}
+	buf = bytes.Buffer{} // clear previous result from buffer
+	require.NoError(t, Template().Execute(&buf, pageInputs))
+	require.NotContains(t, buf.String(), `<span id="webauthn-register">`)
+	require.NotContains(t, buf.String(), `<input type="submit" name="webauthn_skip"`) // the script refers to it, but the form has no skip button
+	require.Contains(t, buf.String(), `data-mode="get" data-challenge="test-challenge" data-rp-id="issuer.example.com">`)
+	require.Contains(t, buf.String(), "<script>"+JS()+"</script>")
 }
 
 func TestContentSecurityPolicy(t *testing.T) {
@@ -62,6 +128,11 @@ func TestCSS(t *testing.T) {
 	require.Equal(t, testExpectedCSS, CSS())
 }
 
+func TestJS(t *testing.T) {
+	require.NotEmpty(t, JS())
+	require.NotContains(t, JS(), "Copyright")
+}
+
 func TestHelpers(t *testing.T) {
 	require.Equal(t, "test", panicOnError("test", nil))
 	require.PanicsWithError(t, "some error", func() { panicOnError("", fmt.Errorf("some error")) })
@@ -5,21 +5,50 @@ package login
 
 import (
 	"errors"
+	"html/template"
 	"net/http"
 	"net/url"
 
 	"github.com/ory/fosite"
 
+	"go.pinniped.dev/internal/federationdomain/branding"
 	"go.pinniped.dev/internal/federationdomain/downstreamsession"
+	"go.pinniped.dev/internal/federationdomain/endpoints/consent"
+	"go.pinniped.dev/internal/federationdomain/endpoints/login/loginhtml"
 	"go.pinniped.dev/internal/federationdomain/endpoints/loginurl"
 	"go.pinniped.dev/internal/federationdomain/federationdomainproviders"
+	"go.pinniped.dev/internal/federationdomain/loginthrottle"
 	"go.pinniped.dev/internal/federationdomain/oidc"
 	"go.pinniped.dev/internal/federationdomain/resolvedprovider/resolvedldap"
+	"go.pinniped.dev/internal/federationdomain/resolvedprovider/resolvedmock"
+	"go.pinniped.dev/internal/federationdomain/resourceindicator"
+	"go.pinniped.dev/internal/federationdomain/secondfactor"
+	"go.pinniped.dev/internal/federationdomain/singlesignon"
 	"go.pinniped.dev/internal/httputil/httperr"
 	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/internal/totp"
+	"go.pinniped.dev/internal/tracing"
 )
 
-func NewPostHandler(issuerURL string, upstreamIDPs federationdomainproviders.FederationDomainIdentityProvidersFinderI, oauthHelper fosite.OAuth2Provider) HandlerFunc {
+// NewPostHandler returns a HandlerFunc which logs in the user with the username and password which they submitted.
+// The consentPrompter may be nil, in which case users are never asked for consent. The singleSignOn may be nil,
+// in which case logins are not remembered for other clients. When the identity provider requires a TOTP second
+// factor, the user must also submit a one-time code, which is verified by the totpVerifier. Users who have not
+// enrolled an authenticator app yet are shown their new secret on the login page, whose branding is returned
+// by getBranding. The webAuthn may be nil, in which case users never need a WebAuthn credential. Otherwise, users
+// who have not registered a credential yet are asked to register one on the login page. The loginThrottle may be
+// nil, in which case usernames are never locked out after failed logins.
+func NewPostHandler(
+	issuerURL string,
+	upstreamIDPs federationdomainproviders.FederationDomainIdentityProvidersFinderI,
+	oauthHelper fosite.OAuth2Provider,
+	consentPrompter *consent.Prompter,
+	singleSignOn *singlesignon.Sessions,
+	totpVerifier *secondfactor.TOTPVerifier,
+	webAuthn *secondfactor.WebAuthnVerifier,
+	getBranding func() *branding.Branding,
+	loginThrottle *loginthrottle.Throttle,
+) HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request, encodedState string, decodedState *oidc.UpstreamStateParamData) error {
 		// Note that the login handler prevents this handler from being called with OIDC upstreams.
 		idp, err := upstreamIDPs.FindUpstreamIDPByDisplayName(decodedState.UpstreamName)
@@ -54,6 +83,14 @@ func NewPostHandler(issuerURL string, upstreamIDPs federationdomainproviders.Fed
 		// an error if the client requested a scope that they are not allowed to request, so we don't need to worry about that here.
 		downstreamsession.AutoApproveScopes(authorizeRequester)
 
+		if err := resourceindicator.GrantIfRequested(authorizeRequester); err != nil {
+			// This shouldn't really happen because the authorization endpoint has already validated the resources,
+			// unless the client's allowed resources were changed in the meantime.
+			plog.Error("error granting resources from state downstream auth params", err,
+				"fositeErr", oidc.FositeErrorForLog(err))
+			return httperr.New(http.StatusBadRequest, "error using state downstream auth params")
+		}
+
 		// Get the username and password form params from the POST body.
 		submittedUsername := r.PostFormValue(loginurl.UsernameParamName)
 		submittedPassword := r.PostFormValue(loginurl.PasswordParamName)
@@ -65,17 +102,29 @@ func NewPostHandler(issuerURL string, upstreamIDPs federationdomainproviders.Fed
 			return redirectToLoginPage(r, w, issuerURL, encodedState, loginurl.ShowBadUserPassErr)
 		}
 
+		if loginThrottle != nil && loginThrottle.LockedOut(idp.GetDisplayName(), submittedUsername, loginThrottle.SourceIP(r)) {
+			// Do not check the password while the username is locked out, and do not reveal the lockout,
+			// which the throttle has already logged.
+			return redirectToLoginPage(r, w, issuerURL, encodedState, loginurl.ShowBadUserPassErr)
+		}
+
 		// Attempt to authenticate the user with the upstream IDP.
-		identity, loginExtras, err := idp.Login(r.Context(), submittedUsername, submittedPassword)
+		upstreamCtx, span := tracing.Start(r.Context(), "upstream login", tracing.AttributeIdentityProvider.String(idp.GetDisplayName()))
+		identity, loginExtras, err := idp.Login(upstreamCtx, submittedUsername, submittedPassword)
+		tracing.EndWithError(span, err)
 		if err != nil {
 			switch {
 			case errors.Is(err, resolvedldap.ErrUnexpectedUpstreamLDAPError):
 				// There was some problem during authentication with the upstream, aside from bad username/password.
 				// The user may try to log in again if they'd like, so redirect back to the login page with an error.
 				return redirectToLoginPage(r, w, issuerURL, encodedState, loginurl.ShowInternalError)
-			case err == resolvedldap.ErrAccessDeniedDueToUsernamePasswordNotAccepted:
+			case err == resolvedldap.ErrAccessDeniedDueToUsernamePasswordNotAccepted,
+				err == resolvedmock.ErrAccessDeniedDueToUsernamePasswordNotAccepted:
 				// The upstream did not accept the username/password combination.
 				// The user may try to log in again if they'd like, so redirect back to the login page with an error.
+				if loginThrottle != nil {
+					loginThrottle.RecordLoginFailure(idp.GetDisplayName(), submittedUsername, loginThrottle.SourceIP(r))
+				}
 				return redirectToLoginPage(r, w, issuerURL, encodedState, loginurl.ShowBadUserPassErr)
 			default:
 				// Some other error happened.
@@ -84,10 +133,82 @@ func NewPostHandler(issuerURL string, upstreamIDPs federationdomainproviders.Fed
 			}
 		}
 
+		if totpConfig := secondfactor.TOTPConfig(idp); totpConfig != nil {
+			submittedCode := r.PostFormValue(loginurl.OneTimeCodeParamName)
+			result, secret, err := totpVerifier.Verify(r.Context(), idp.GetProvider().GetResourceName(), identity.UpstreamUsername, submittedCode)
+			if err != nil {
+				plog.Error("error verifying one-time code", err)
+				return redirectToLoginPage(r, w, issuerURL, encodedState, loginurl.ShowInternalError)
+			}
+			switch result {
+			case secondfactor.Verified:
+				// Continue with the login.
+			case secondfactor.EnrollmentRequired:
+				// The user proved their password, so show them the secret for their authenticator app. They must
+				// log in again using a code from the app to confirm that the app was set up.
+				errToDisplay := loginurl.ShowNoError
+				if submittedCode != "" {
+					errToDisplay = loginurl.ShowBadUserPassErr
+				}
+				// Replace the CSP of the form_post page, since this response is the login page.
+				w.Header().Set("Content-Security-Policy", loginhtml.ContentSecurityPolicy())
+				return renderLoginPage(w, r, loginPathForIssuer(issuerURL), upstreamIDPs, webAuthn, getBranding(),
+					encodedState, decodedState, errToDisplay, &loginhtml.TOTPEnrollment{
+						Key:    totp.EncodeSecret(secret),
+						KeyURI: template.URL(totp.KeyURI(totpConfig.Issuer, identity.UpstreamUsername, secret)), //nolint:gosec // the URI is built by us from escaped values
+					}, "")
+			default:
+				// A wrong code counts as a failed login, so that codes cannot be guessed faster than passwords.
+				if loginThrottle != nil {
+					loginThrottle.RecordLoginFailure(idp.GetDisplayName(), submittedUsername, loginThrottle.SourceIP(r))
+				}
+				// The user may try to log in again if they'd like, so redirect back to the login page with an error.
+				// Do not reveal that the password was correct.
+				return redirectToLoginPage(r, w, issuerURL, encodedState, loginurl.ShowBadUserPassErr)
+			}
+		}
+
+		if webAuthn.AppliesTo(idp) {
+			submittedResponse := r.PostFormValue(loginurl.WebAuthnResponseParamName)
+			result, err := webAuthn.Verify(r.Context(), encodedState, idp.GetProvider().GetResourceName(), identity.UpstreamUsername,
+				&secondfactor.WebAuthnSubmission{
+					Token:    r.PostFormValue(loginurl.WebAuthnChallengeParamName),
+					Response: submittedResponse,
+					Skip:     r.PostFormValue(loginurl.WebAuthnSkipParamName) != "",
+				})
+			if err != nil {
+				plog.Error("error verifying WebAuthn credential", err)
+				return redirectToLoginPage(r, w, issuerURL, encodedState, loginurl.ShowInternalError)
+			}
+			switch result {
+			case secondfactor.Verified:
+				// Continue with the login.
+			case secondfactor.EnrollmentRequired:
+				// The user proved their password, so ask their browser to register a new credential. They must
+				// log in again, since the registration happens when the form is submitted.
+				errToDisplay := loginurl.ShowNoError
+				if submittedResponse != "" {
+					errToDisplay = loginurl.ShowBadUserPassErr
+				}
+				// Replace the CSP of the form_post page, since this response is the login page.
+				w.Header().Set("Content-Security-Policy", loginhtml.ContentSecurityPolicy())
+				return renderLoginPage(w, r, loginPathForIssuer(issuerURL), upstreamIDPs, webAuthn, getBranding(),
+					encodedState, decodedState, errToDisplay, nil, identity.UpstreamUsername)
+			default:
+				// The user may try to log in again if they'd like, so redirect back to the login page with an error.
+				// Do not reveal that the password was correct.
+				return redirectToLoginPage(r, w, issuerURL, encodedState, loginurl.ShowBadUserPassErr)
+			}
+		}
+
+		if loginThrottle != nil {
+			loginThrottle.RecordLoginSuccess(idp.GetDisplayName(), submittedUsername, loginThrottle.SourceIP(r))
+		}
+
 		session, err := downstreamsession.NewPinnipedSession(r.Context(), idp, &downstreamsession.SessionConfig{
 			UpstreamIdentity:    identity,
 			UpstreamLoginExtras: loginExtras,
-			ClientID:            authorizeRequester.GetClient().GetID(),
+			Client:              authorizeRequester.GetClient(),
 			GrantedScopes:       authorizeRequester.GetGrantedScopes(),
 		})
 		if err != nil {
@@ -96,12 +217,38 @@ func NewPostHandler(issuerURL string, upstreamIDPs federationdomainproviders.Fed
 			return nil
 		}
 
+		if err := singleSignOn.Remember(w, r, idp, identity, loginExtras, session); err != nil {
+			// Single sign-on is only a convenience, so do not fail the login.
+			plog.Error("error remembering login for single sign-on", err)
+		}
+
+		redirectedToConsentPage, err := consentPrompter.MaybeRedirectToConsentPage(w, r, authorizeRequester, session, decodedState.CSRFToken)
+		if err != nil {
+			plog.Error("error while requesting consent", err)
+			err = fosite.ErrServerError.WithHint("Error while requesting consent.").WithWrap(err)
+			oidc.WriteAuthorizeError(r, w, oauthHelper, authorizeRequester, err, false)
+			return nil
+		}
+		if redirectedToConsentPage {
+			return nil
+		}
+
 		oidc.PerformAuthcodeRedirect(r, w, oauthHelper, authorizeRequester, session, false)
 
 		return nil
 	}
 }
 
+// loginPathForIssuer returns the path of the login page of the specified issuer.
+func loginPathForIssuer(downstreamIssuer string) string {
+	issuerURL, err := url.Parse(downstreamIssuer)
+	if err != nil {
+		// This shouldn't happen because the issuer was validated when the FederationDomain was loaded.
+		return oidc.PinnipedLoginPath
+	}
+	return issuerURL.Path + oidc.PinnipedLoginPath
+}
+
 // redirectToLoginPage redirects to the GET /login page of the specified issuer.
 func redirectToLoginPage(
 	r *http.Request,
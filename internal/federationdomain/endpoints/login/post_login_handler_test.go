@@ -11,25 +11,40 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/gorilla/securecookie"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/client-go/kubernetes/fake"
+	clocktesting "k8s.io/utils/clock/testing"
 
 	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
 	supervisorfake "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/fake"
 	"go.pinniped.dev/internal/authenticators"
 	"go.pinniped.dev/internal/celtransformer"
+	"go.pinniped.dev/internal/federationdomain/branding"
+	"go.pinniped.dev/internal/federationdomain/endpoints/consent"
 	"go.pinniped.dev/internal/federationdomain/endpoints/jwks"
+	"go.pinniped.dev/internal/federationdomain/endpoints/login/loginhtml"
+	"go.pinniped.dev/internal/federationdomain/formposthtml"
+	"go.pinniped.dev/internal/federationdomain/loginthrottle"
 	"go.pinniped.dev/internal/federationdomain/oidc"
 	"go.pinniped.dev/internal/federationdomain/oidcclientvalidator"
+	"go.pinniped.dev/internal/federationdomain/secondfactor"
 	"go.pinniped.dev/internal/federationdomain/storage"
+	consentstorage "go.pinniped.dev/internal/fositestorage/consent"
+	"go.pinniped.dev/internal/plog"
 	"go.pinniped.dev/internal/psession"
 	"go.pinniped.dev/internal/testutil"
 	"go.pinniped.dev/internal/testutil/oidctestutil"
 	"go.pinniped.dev/internal/testutil/testidplister"
 	"go.pinniped.dev/internal/testutil/transformtestutil"
+	"go.pinniped.dev/internal/totp"
+	"go.pinniped.dev/internal/totpsecretstorage"
+	"go.pinniped.dev/internal/webauthn"
+	"go.pinniped.dev/internal/webauthncredentialstorage"
 )
 
 func TestPostLoginEndpoint(t *testing.T) {
@@ -62,6 +77,7 @@ func TestPostLoginEndpoint(t *testing.T) {
 
 		userParam                = "username"
 		passParam                = "password"
+		oneTimeCode              = "one_time_code"
 		badUserPassErrParamValue = "login_error"
 		internalErrParamValue    = "internal_error"
 
@@ -200,6 +216,19 @@ func TestPostLoginEndpoint(t *testing.T) {
 
 	upstreamActiveDirectoryIdentityProvider := upstreamActiveDirectoryIdentityProviderBuilder.Build()
 
+	upstreamLDAPIdentityProviderRequiringTOTP := oidctestutil.NewTestUpstreamLDAPIdentityProviderBuilder().
+		WithName(ldapUpstreamName).
+		WithResourceUID(ldapUpstreamResourceUID).
+		WithURL(parsedUpstreamLDAPURL).
+		WithAuthenticateFunc(ldapAuthenticateFunc).
+		WithTOTPConfig(&totp.Config{Issuer: "Some Issuer"}).
+		Build()
+
+	totpNow := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	totpSecret := []byte("some-totp-secret-of-20-bytes")
+	totpCurrentStep := totp.Step(totpNow)
+	totpCurrentCode := totp.Code(totpSecret, totpCurrentStep)
+
 	erroringUpstreamLDAPIdentityProvider := oidctestutil.NewTestUpstreamLDAPIdentityProviderBuilder().
 		WithName(ldapUpstreamName).
 		WithResourceUID(ldapUpstreamResourceUID).
@@ -263,6 +292,12 @@ func TestPostLoginEndpoint(t *testing.T) {
 
 	happyUsernamePasswordFormParams := url.Values{userParam: []string{happyLDAPUsername}, passParam: []string{happyLDAPPassword}}
 
+	happyUsernamePasswordAndCodeFormParams := url.Values{
+		userParam:   []string{happyLDAPUsername},
+		passParam:   []string{happyLDAPPassword},
+		oneTimeCode: []string{totpCurrentCode},
+	}
+
 	encodeQuery := func(query map[string]string) string {
 		values := url.Values{}
 		for k, v := range query {
@@ -286,6 +321,15 @@ func TestPostLoginEndpoint(t *testing.T) {
 		require.NoError(t, kubeClient.Tracker().Add(secret))
 	}
 
+	addFullyCapableDynamicClientRequiringConsentAndSecretToKubeResources := func(t *testing.T, supervisorClient *supervisorfake.Clientset, kubeClient *fake.Clientset) {
+		oidcClient, secret := testutil.FullyCapableOIDCClientAndStorageSecret(t,
+			"some-namespace", downstreamDynamicClientID, downstreamDynamicClientUID, downstreamRedirectURI, nil,
+			[]string{testutil.HashedPassword1AtGoMinCost}, oidcclientvalidator.Validate)
+		oidcClient.Spec.RequireConsent = true
+		require.NoError(t, supervisorClient.Tracker().Add(oidcClient))
+		require.NoError(t, kubeClient.Tracker().Add(secret))
+	}
+
 	prefixUsernameAndGroupsPipeline := transformtestutil.NewPrefixingPipeline(t, transformationUsernamePrefix, transformationGroupsPrefix)
 	rejectAuthPipeline := transformtestutil.NewRejectAllAuthPipeline(t)
 
@@ -296,12 +340,30 @@ func TestPostLoginEndpoint(t *testing.T) {
 		decodedState  *oidc.UpstreamStateParamData
 		formParams    url.Values
 		reqURIQuery   url.Values
+		// The number of failed logins of the happy username before the request.
+		previousLoginFailures int
+		// Whether the previous failed logins came from a different source IP than the request.
+		previousLoginFailuresFromOtherSourceIP bool
+
+		// The TOTP enrollment of the user before the request, if any.
+		totpEnrollment *totpsecretstorage.Enrollment
+		// The WebAuthn configuration of the FederationDomain, if any, and the credential of the user before the request.
+		webAuthn           *secondfactor.WebAuthnConfig
+		webAuthnCredential *webauthncredentialstorage.Credential
 
 		wantStatus      int
 		wantContentType string
 		wantBodyString  string
+		wantBodyParts   []string // for the login page, when it is rendered instead of redirecting
 		wantErr         string
 
+		// The TOTP enrollment of the user after the request, if any.
+		wantTOTPEnrollment *totpsecretstorage.Enrollment
+		// Whether the happy username should be locked out after the request.
+		wantLockedOut bool
+		// Whether the previous failed logins of the happy username should have been forgotten after the request.
+		wantLoginFailuresForgotten bool
+
 		// Assertion that the response should be a redirect to the login page with an error param.
 		wantRedirectToLoginPageError string
 
@@ -404,6 +466,20 @@ func TestPostLoginEndpoint(t *testing.T) {
 			wantDownstreamPKCEChallengeMethod: downstreamPKCEChallengeMethod,
 			wantDownstreamCustomSessionData:   expectedHappyLDAPUpstreamCustomSession,
 		},
+		{
+			name: "happy LDAP login with dynamic client which requires consent redirects to the consent page",
+			idps: testidplister.NewUpstreamIDPListerBuilder().
+				WithLDAP(upstreamLDAPIdentityProvider). // should pick this one
+				WithActiveDirectory(erroringUpstreamLDAPIdentityProvider),
+			kubeResources:                addFullyCapableDynamicClientRequiringConsentAndSecretToKubeResources,
+			decodedState:                 happyLDAPDecodedStateForDynamicClient,
+			formParams:                   happyUsernamePasswordFormParams,
+			wantStatus:                   http.StatusSeeOther,
+			wantContentType:              htmlContentType,
+			wantBodyString:               "",
+			wantRedirectLocationString:   downstreamIssuer + "/consent?id=some-consent-id",
+			wantUnnecessaryStoredRecords: 2, // looked up the consent, and then stored the pending consent request
+		},
 		{
 			name: "happy AD login",
 			idps: testidplister.NewUpstreamIDPListerBuilder().
@@ -771,6 +847,218 @@ func TestPostLoginEndpoint(t *testing.T) {
 			wantDownstreamPKCEChallengeMethod: downstreamPKCEChallengeMethod,
 			wantDownstreamCustomSessionData:   expectedHappyLDAPUpstreamCustomSession,
 		},
+		{
+			name:                              "happy LDAP login with a one-time code",
+			idps:                              testidplister.NewUpstreamIDPListerBuilder().WithLDAP(upstreamLDAPIdentityProviderRequiringTOTP),
+			decodedState:                      happyLDAPDecodedState,
+			formParams:                        happyUsernamePasswordAndCodeFormParams,
+			totpEnrollment:                    &totpsecretstorage.Enrollment{Secret: totpSecret, Confirmed: true, LastUsedStep: totpCurrentStep - 10},
+			wantStatus:                        http.StatusSeeOther,
+			wantContentType:                   htmlContentType,
+			wantBodyString:                    "",
+			wantRedirectLocationRegexp:        happyAuthcodeDownstreamRedirectLocationRegexp,
+			wantDownstreamIDTokenSubject:      upstreamLDAPURL + "&idpName=" + ldapUpstreamName + "&sub=" + happyLDAPUID,
+			wantDownstreamIDTokenUsername:     happyLDAPUsernameFromAuthenticator,
+			wantDownstreamIDTokenGroups:       happyLDAPGroups,
+			wantDownstreamRequestedScopes:     happyDownstreamScopesRequested,
+			wantDownstreamRedirectURI:         downstreamRedirectURI,
+			wantDownstreamGrantedScopes:       happyDownstreamScopesGranted,
+			wantDownstreamNonce:               downstreamNonce,
+			wantDownstreamClient:              downstreamPinnipedCLIClientID,
+			wantDownstreamPKCEChallenge:       downstreamPKCEChallenge,
+			wantDownstreamPKCEChallengeMethod: downstreamPKCEChallengeMethod,
+			wantDownstreamCustomSessionData:   expectedHappyLDAPUpstreamCustomSession,
+			wantTOTPEnrollment:                &totpsecretstorage.Enrollment{Secret: totpSecret, Confirmed: true, LastUsedStep: totpCurrentStep},
+		},
+		{
+			name:                              "happy LDAP login with a one-time code which confirms the enrollment",
+			idps:                              testidplister.NewUpstreamIDPListerBuilder().WithLDAP(upstreamLDAPIdentityProviderRequiringTOTP),
+			decodedState:                      happyLDAPDecodedState,
+			formParams:                        happyUsernamePasswordAndCodeFormParams,
+			totpEnrollment:                    &totpsecretstorage.Enrollment{Secret: totpSecret},
+			wantStatus:                        http.StatusSeeOther,
+			wantContentType:                   htmlContentType,
+			wantBodyString:                    "",
+			wantRedirectLocationRegexp:        happyAuthcodeDownstreamRedirectLocationRegexp,
+			wantDownstreamIDTokenSubject:      upstreamLDAPURL + "&idpName=" + ldapUpstreamName + "&sub=" + happyLDAPUID,
+			wantDownstreamIDTokenUsername:     happyLDAPUsernameFromAuthenticator,
+			wantDownstreamIDTokenGroups:       happyLDAPGroups,
+			wantDownstreamRequestedScopes:     happyDownstreamScopesRequested,
+			wantDownstreamRedirectURI:         downstreamRedirectURI,
+			wantDownstreamGrantedScopes:       happyDownstreamScopesGranted,
+			wantDownstreamNonce:               downstreamNonce,
+			wantDownstreamClient:              downstreamPinnipedCLIClientID,
+			wantDownstreamPKCEChallenge:       downstreamPKCEChallenge,
+			wantDownstreamPKCEChallengeMethod: downstreamPKCEChallengeMethod,
+			wantDownstreamCustomSessionData:   expectedHappyLDAPUpstreamCustomSession,
+			wantTOTPEnrollment:                &totpsecretstorage.Enrollment{Secret: totpSecret, Confirmed: true, LastUsedStep: totpCurrentStep},
+		},
+		{
+			name:                         "LDAP login with a wrong one-time code",
+			idps:                         testidplister.NewUpstreamIDPListerBuilder().WithLDAP(upstreamLDAPIdentityProviderRequiringTOTP),
+			decodedState:                 happyLDAPDecodedState,
+			formParams:                   shallowCopyAndModifyQuery(happyUsernamePasswordAndCodeFormParams, map[string]string{oneTimeCode: "123456"}),
+			totpEnrollment:               &totpsecretstorage.Enrollment{Secret: totpSecret, Confirmed: true},
+			wantStatus:                   http.StatusSeeOther,
+			wantContentType:              htmlContentType,
+			wantBodyString:               "",
+			wantRedirectToLoginPageError: badUserPassErrParamValue,
+			wantTOTPEnrollment:           &totpsecretstorage.Enrollment{Secret: totpSecret, Confirmed: true, FailedAttempts: 1, LastFailedStep: totpCurrentStep},
+		},
+		{
+			name:                         "LDAP login with a wrong one-time code which reaches the number of failed logins before the username is locked out",
+			idps:                         testidplister.NewUpstreamIDPListerBuilder().WithLDAP(upstreamLDAPIdentityProviderRequiringTOTP),
+			decodedState:                 happyLDAPDecodedState,
+			formParams:                   shallowCopyAndModifyQuery(happyUsernamePasswordAndCodeFormParams, map[string]string{oneTimeCode: "123456"}),
+			totpEnrollment:               &totpsecretstorage.Enrollment{Secret: totpSecret, Confirmed: true},
+			previousLoginFailures:        1,
+			wantStatus:                   http.StatusSeeOther,
+			wantContentType:              htmlContentType,
+			wantBodyString:               "",
+			wantRedirectToLoginPageError: badUserPassErrParamValue,
+			wantTOTPEnrollment:           &totpsecretstorage.Enrollment{Secret: totpSecret, Confirmed: true, FailedAttempts: 1, LastFailedStep: totpCurrentStep},
+			wantLockedOut:                true,
+		},
+		{
+			name:                         "LDAP login with a valid one-time code by a user who recently entered too many wrong codes",
+			idps:                         testidplister.NewUpstreamIDPListerBuilder().WithLDAP(upstreamLDAPIdentityProviderRequiringTOTP),
+			decodedState:                 happyLDAPDecodedState,
+			formParams:                   happyUsernamePasswordAndCodeFormParams,
+			totpEnrollment:               &totpsecretstorage.Enrollment{Secret: totpSecret, Confirmed: true, FailedAttempts: 5, LastFailedStep: totpCurrentStep - 1},
+			wantStatus:                   http.StatusSeeOther,
+			wantContentType:              htmlContentType,
+			wantBodyString:               "",
+			wantRedirectToLoginPageError: badUserPassErrParamValue,
+			wantTOTPEnrollment:           &totpsecretstorage.Enrollment{Secret: totpSecret, Confirmed: true, FailedAttempts: 5, LastFailedStep: totpCurrentStep - 1},
+		},
+		{
+			name:                         "LDAP login with a one-time code which was already used",
+			idps:                         testidplister.NewUpstreamIDPListerBuilder().WithLDAP(upstreamLDAPIdentityProviderRequiringTOTP),
+			decodedState:                 happyLDAPDecodedState,
+			formParams:                   happyUsernamePasswordAndCodeFormParams,
+			totpEnrollment:               &totpsecretstorage.Enrollment{Secret: totpSecret, Confirmed: true, LastUsedStep: totpCurrentStep},
+			wantStatus:                   http.StatusSeeOther,
+			wantContentType:              htmlContentType,
+			wantBodyString:               "",
+			wantRedirectToLoginPageError: badUserPassErrParamValue,
+			wantTOTPEnrollment:           &totpsecretstorage.Enrollment{Secret: totpSecret, Confirmed: true, LastUsedStep: totpCurrentStep, FailedAttempts: 1, LastFailedStep: totpCurrentStep},
+		},
+		{
+			name:            "LDAP login by a user who has not enrolled yet shows the enrollment on the login page",
+			idps:            testidplister.NewUpstreamIDPListerBuilder().WithLDAP(upstreamLDAPIdentityProviderRequiringTOTP),
+			decodedState:    happyLDAPDecodedState,
+			formParams:      happyUsernamePasswordFormParams,
+			wantStatus:      http.StatusOK,
+			wantContentType: htmlContentType,
+			wantBodyParts: []string{
+				`<span id="totp-enroll">A one-time code from an authenticator app is required to log in.`,
+				`<span id="totp-key">Key: `,
+				`<a href="otpauth://totp/Some%20Issuer:some-mapped-ldap-username?algorithm=SHA1&amp;digits=6&amp;issuer=Some&#43;Issuer&amp;period=30&amp;secret=`,
+				`value="some-ldap-user"`,
+				`name="one_time_code"`,
+				`<form action="/path/login" method="post">`,
+			},
+		},
+		{
+			name:            "LDAP login with a wrong one-time code by a user who has not confirmed their enrollment shows the enrollment again",
+			idps:            testidplister.NewUpstreamIDPListerBuilder().WithLDAP(upstreamLDAPIdentityProviderRequiringTOTP),
+			decodedState:    happyLDAPDecodedState,
+			formParams:      shallowCopyAndModifyQuery(happyUsernamePasswordAndCodeFormParams, map[string]string{oneTimeCode: "123456"}),
+			totpEnrollment:  &totpsecretstorage.Enrollment{Secret: totpSecret},
+			wantStatus:      http.StatusOK,
+			wantContentType: htmlContentType,
+			wantBodyParts: []string{
+				`id="alert">Incorrect username, password, or one-time code.</span>`,
+				`<span id="totp-key">Key: ` + totp.EncodeSecret(totpSecret) + `</span>`,
+			},
+			wantTOTPEnrollment: &totpsecretstorage.Enrollment{Secret: totpSecret},
+		},
+		{
+			name:                         "bad password LDAP login when the identity provider requires a one-time code does not enroll the user",
+			idps:                         testidplister.NewUpstreamIDPListerBuilder().WithLDAP(upstreamLDAPIdentityProviderRequiringTOTP),
+			decodedState:                 happyLDAPDecodedState,
+			formParams:                   shallowCopyAndModifyQuery(happyUsernamePasswordAndCodeFormParams, map[string]string{passParam: "wrong!"}),
+			wantStatus:                   http.StatusSeeOther,
+			wantContentType:              htmlContentType,
+			wantBodyString:               "",
+			wantRedirectToLoginPageError: badUserPassErrParamValue,
+		},
+		{
+			name:            "LDAP login by a user without a passkey asks the browser to register one",
+			idps:            testidplister.NewUpstreamIDPListerBuilder().WithLDAP(upstreamLDAPIdentityProvider),
+			decodedState:    happyLDAPDecodedState,
+			formParams:      happyUsernamePasswordFormParams,
+			webAuthn:        &secondfactor.WebAuthnConfig{Enforcement: secondfactor.WebAuthnRequired},
+			wantStatus:      http.StatusOK,
+			wantContentType: htmlContentType,
+			wantBodyParts: []string{
+				`<span id="webauthn-register">A passkey is required to log in.`,
+				`data-mode="register"`,
+				`data-rp-id="my-downstream-issuer.com"`,
+				`data-user-name="` + happyLDAPUsernameFromAuthenticator + `"`,
+				`value="some-ldap-user"`,
+				"<script>" + loginhtml.JS() + "</script>",
+			},
+		},
+		{
+			name:            "LDAP login by a user without a passkey cannot skip the registration when passkeys are required",
+			idps:            testidplister.NewUpstreamIDPListerBuilder().WithLDAP(upstreamLDAPIdentityProvider),
+			decodedState:    happyLDAPDecodedState,
+			formParams:      shallowCopyAndModifyQuery(happyUsernamePasswordFormParams, map[string]string{"webauthn_skip": "skip"}),
+			webAuthn:        &secondfactor.WebAuthnConfig{Enforcement: secondfactor.WebAuthnRequired},
+			wantStatus:      http.StatusOK,
+			wantContentType: htmlContentType,
+			wantBodyParts:   []string{`<span id="webauthn-register">A passkey is required to log in.`},
+		},
+		{
+			name:            "LDAP login by a user without a passkey may skip the registration when passkeys are optional",
+			idps:            testidplister.NewUpstreamIDPListerBuilder().WithLDAP(upstreamLDAPIdentityProvider),
+			decodedState:    happyLDAPDecodedState,
+			formParams:      happyUsernamePasswordFormParams,
+			webAuthn:        &secondfactor.WebAuthnConfig{Enforcement: secondfactor.WebAuthnOptional},
+			wantStatus:      http.StatusOK,
+			wantContentType: htmlContentType,
+			wantBodyParts: []string{
+				`<span id="webauthn-register">A passkey is required to log in.`,
+				`<input type="submit" name="webauthn_skip" id="webauthn-skip" value="Log in without a passkey"/>`,
+			},
+		},
+		{
+			name:                              "LDAP login by a user without a passkey who skips the registration when passkeys are optional",
+			idps:                              testidplister.NewUpstreamIDPListerBuilder().WithLDAP(upstreamLDAPIdentityProvider),
+			decodedState:                      happyLDAPDecodedState,
+			formParams:                        shallowCopyAndModifyQuery(happyUsernamePasswordFormParams, map[string]string{"webauthn_skip": "skip"}),
+			webAuthn:                          &secondfactor.WebAuthnConfig{Enforcement: secondfactor.WebAuthnOptional},
+			wantStatus:                        http.StatusSeeOther,
+			wantContentType:                   htmlContentType,
+			wantBodyString:                    "",
+			wantRedirectLocationRegexp:        happyAuthcodeDownstreamRedirectLocationRegexp,
+			wantDownstreamIDTokenSubject:      upstreamLDAPURL + "&idpName=" + ldapUpstreamName + "&sub=" + happyLDAPUID,
+			wantDownstreamIDTokenUsername:     happyLDAPUsernameFromAuthenticator,
+			wantDownstreamIDTokenGroups:       happyLDAPGroups,
+			wantDownstreamRequestedScopes:     happyDownstreamScopesRequested,
+			wantDownstreamRedirectURI:         downstreamRedirectURI,
+			wantDownstreamGrantedScopes:       happyDownstreamScopesGranted,
+			wantDownstreamNonce:               downstreamNonce,
+			wantDownstreamClient:              downstreamPinnipedCLIClientID,
+			wantDownstreamPKCEChallenge:       downstreamPKCEChallenge,
+			wantDownstreamPKCEChallengeMethod: downstreamPKCEChallengeMethod,
+			wantDownstreamCustomSessionData:   expectedHappyLDAPUpstreamCustomSession,
+		},
+		{
+			name:         "LDAP login by a user with a passkey who does not use it",
+			idps:         testidplister.NewUpstreamIDPListerBuilder().WithLDAP(upstreamLDAPIdentityProvider),
+			decodedState: happyLDAPDecodedState,
+			formParams:   shallowCopyAndModifyQuery(happyUsernamePasswordFormParams, map[string]string{"webauthn_skip": "skip"}),
+			webAuthn:     &secondfactor.WebAuthnConfig{Enforcement: secondfactor.WebAuthnOptional},
+			webAuthnCredential: &webauthncredentialstorage.Credential{
+				Credential: webauthn.Credential{ID: []byte("some-credential-id"), PublicKey: []byte("some-public-key")},
+			},
+			wantStatus:                   http.StatusSeeOther,
+			wantContentType:              htmlContentType,
+			wantBodyString:               "",
+			wantRedirectToLoginPageError: badUserPassErrParamValue,
+		},
 		{
 			name:                         "bad username LDAP login",
 			idps:                         testidplister.NewUpstreamIDPListerBuilder().WithLDAP(upstreamLDAPIdentityProvider),
@@ -791,6 +1079,76 @@ func TestPostLoginEndpoint(t *testing.T) {
 			wantBodyString:               "",
 			wantRedirectToLoginPageError: badUserPassErrParamValue,
 		},
+		{
+			name:                         "bad password LDAP login which reaches the number of failed logins before the username is locked out",
+			idps:                         testidplister.NewUpstreamIDPListerBuilder().WithLDAP(upstreamLDAPIdentityProvider),
+			decodedState:                 happyLDAPDecodedState,
+			formParams:                   url.Values{userParam: []string{happyLDAPUsername}, passParam: []string{"wrong!"}},
+			previousLoginFailures:        1,
+			wantStatus:                   http.StatusSeeOther,
+			wantContentType:              htmlContentType,
+			wantBodyString:               "",
+			wantRedirectToLoginPageError: badUserPassErrParamValue,
+			wantLockedOut:                true,
+		},
+		{
+			name:                         "LDAP login with the correct password by a username which is locked out",
+			idps:                         testidplister.NewUpstreamIDPListerBuilder().WithLDAP(upstreamLDAPIdentityProvider),
+			decodedState:                 happyLDAPDecodedState,
+			formParams:                   happyUsernamePasswordFormParams,
+			previousLoginFailures:        2,
+			wantStatus:                   http.StatusSeeOther,
+			wantContentType:              htmlContentType,
+			wantBodyString:               "",
+			wantRedirectToLoginPageError: badUserPassErrParamValue,
+			wantLockedOut:                true,
+		},
+		{
+			name:                              "happy LDAP login after a failed login forgets the failed login",
+			idps:                              testidplister.NewUpstreamIDPListerBuilder().WithLDAP(upstreamLDAPIdentityProvider),
+			decodedState:                      happyLDAPDecodedState,
+			formParams:                        happyUsernamePasswordFormParams,
+			previousLoginFailures:             1,
+			wantStatus:                        http.StatusSeeOther,
+			wantContentType:                   htmlContentType,
+			wantBodyString:                    "",
+			wantRedirectLocationRegexp:        happyAuthcodeDownstreamRedirectLocationRegexp,
+			wantDownstreamIDTokenSubject:      upstreamLDAPURL + "&idpName=" + ldapUpstreamName + "&sub=" + happyLDAPUID,
+			wantDownstreamIDTokenUsername:     happyLDAPUsernameFromAuthenticator,
+			wantDownstreamIDTokenGroups:       happyLDAPGroups,
+			wantDownstreamRequestedScopes:     happyDownstreamScopesRequested,
+			wantDownstreamRedirectURI:         downstreamRedirectURI,
+			wantDownstreamGrantedScopes:       happyDownstreamScopesGranted,
+			wantDownstreamNonce:               downstreamNonce,
+			wantDownstreamClient:              downstreamPinnipedCLIClientID,
+			wantDownstreamPKCEChallenge:       downstreamPKCEChallenge,
+			wantDownstreamPKCEChallengeMethod: downstreamPKCEChallengeMethod,
+			wantDownstreamCustomSessionData:   expectedHappyLDAPUpstreamCustomSession,
+			wantLoginFailuresForgotten:        true,
+		},
+		{
+			name:                                   "happy LDAP login by a username which is locked out for another source IP",
+			idps:                                   testidplister.NewUpstreamIDPListerBuilder().WithLDAP(upstreamLDAPIdentityProvider),
+			decodedState:                           happyLDAPDecodedState,
+			formParams:                             happyUsernamePasswordFormParams,
+			previousLoginFailures:                  2,
+			previousLoginFailuresFromOtherSourceIP: true,
+			wantStatus:                             http.StatusSeeOther,
+			wantContentType:                        htmlContentType,
+			wantBodyString:                         "",
+			wantRedirectLocationRegexp:             happyAuthcodeDownstreamRedirectLocationRegexp,
+			wantDownstreamIDTokenSubject:           upstreamLDAPURL + "&idpName=" + ldapUpstreamName + "&sub=" + happyLDAPUID,
+			wantDownstreamIDTokenUsername:          happyLDAPUsernameFromAuthenticator,
+			wantDownstreamIDTokenGroups:            happyLDAPGroups,
+			wantDownstreamRequestedScopes:          happyDownstreamScopesRequested,
+			wantDownstreamRedirectURI:              downstreamRedirectURI,
+			wantDownstreamGrantedScopes:            happyDownstreamScopesGranted,
+			wantDownstreamNonce:                    downstreamNonce,
+			wantDownstreamClient:                   downstreamPinnipedCLIClientID,
+			wantDownstreamPKCEChallenge:            downstreamPKCEChallenge,
+			wantDownstreamPKCEChallengeMethod:      downstreamPKCEChallengeMethod,
+			wantDownstreamCustomSessionData:        expectedHappyLDAPUpstreamCustomSession,
+		},
 		{
 			name:                         "blank username LDAP login",
 			idps:                         testidplister.NewUpstreamIDPListerBuilder().WithLDAP(upstreamLDAPIdentityProvider),
@@ -1136,7 +1494,7 @@ func TestPostLoginEndpoint(t *testing.T) {
 			hmacSecretFunc := func() []byte { return []byte("some secret - must have at least 32 bytes") }
 			require.GreaterOrEqual(t, len(hmacSecretFunc()), 32, "fosite requires that hmac secrets have at least 32 bytes")
 			jwksProviderIsUnused := jwks.NewDynamicJWKSProvider()
-			oauthHelper := oidc.FositeOauth2Helper(kubeOauthStore, downstreamIssuer, hmacSecretFunc, jwksProviderIsUnused, timeoutsConfiguration)
+			oauthHelper := oidc.FositeOauth2Helper(kubeOauthStore, downstreamIssuer, hmacSecretFunc, jwksProviderIsUnused, timeoutsConfiguration, formposthtml.TemplateForContext, nil, nil, nil)
 
 			req := httptest.NewRequest(http.MethodPost, "/ignored", strings.NewReader(tt.formParams.Encode()))
 			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
@@ -1146,7 +1504,46 @@ func TestPostLoginEndpoint(t *testing.T) {
 
 			rsp := httptest.NewRecorder()
 
-			subject := NewPostHandler(downstreamIssuer, tt.idps.BuildFederationDomainIdentityProvidersListerFinder(), oauthHelper)
+			consentPrompter := consent.NewPrompter(downstreamIssuer, consentstorage.New(secretsClient, time.Now),
+				func() (string, error) { return "some-consent-id", nil }, time.Now)
+
+			// Use separate storage for the TOTP secrets, so they do not interfere with the assertions about session storage.
+			totpKubeClient := fake.NewSimpleClientset()
+			testutil.AddSecretResourceVersionReactors(totpKubeClient)
+			totpStorage := totpsecretstorage.New(totpKubeClient.CoreV1().Secrets("some-namespace"),
+				func() []byte { return []byte("some-supervisor-key") })
+			if tt.totpEnrollment != nil {
+				require.NoError(t, totpStorage.Set(context.Background(), "", ldapUpstreamName, happyLDAPUsernameFromAuthenticator, tt.totpEnrollment))
+			}
+			totpVerifier := secondfactor.NewTOTPVerifier(totpStorage, func() time.Time { return totpNow })
+
+			var webAuthn *secondfactor.WebAuthnVerifier
+			if tt.webAuthn != nil {
+				relyingParty, err := webauthn.NewRelyingParty(downstreamIssuer)
+				require.NoError(t, err)
+				webAuthnStorage := webauthncredentialstorage.New(totpKubeClient.CoreV1().Secrets("some-namespace"))
+				if tt.webAuthnCredential != nil {
+					require.NoError(t, webAuthnStorage.Set(context.Background(), "", relyingParty.ID, ldapUpstreamName, happyLDAPUsernameFromAuthenticator, tt.webAuthnCredential))
+				}
+				webAuthn = secondfactor.NewWebAuthnVerifier(*tt.webAuthn, relyingParty, webAuthnStorage,
+					securecookie.New([]byte("fake-hash-secret"), nil), time.Now)
+			}
+
+			loginThrottle := loginthrottle.New(downstreamIssuer, loginthrottle.Config{
+				RequestsPerMinutePerSourceIP: 100,
+				FailedAttemptsBeforeLockout:  2,
+				LockoutDuration:              time.Minute,
+			}, clocktesting.NewFakeClock(time.Now()), plog.New())
+			previousLoginFailuresSourceIP := loginthrottle.SourceIP(req)
+			if tt.previousLoginFailuresFromOtherSourceIP {
+				previousLoginFailuresSourceIP = "1.2.3.4"
+			}
+			for range tt.previousLoginFailures {
+				loginThrottle.RecordLoginFailure(ldapUpstreamName, happyLDAPUsername, previousLoginFailuresSourceIP)
+			}
+
+			subject := NewPostHandler(downstreamIssuer, tt.idps.BuildFederationDomainIdentityProvidersListerFinder(), oauthHelper, consentPrompter, nil,
+				totpVerifier, webAuthn, func() *branding.Branding { return nil }, loginThrottle)
 
 			err := subject(rsp, req, happyEncodedUpstreamState, tt.decodedState)
 			if tt.wantErr != "" {
@@ -1223,10 +1620,38 @@ func TestPostLoginEndpoint(t *testing.T) {
 					tt.wantDownstreamCustomSessionData,
 					map[string]any{},
 				)
+			case tt.wantBodyParts != nil:
+				// Expecting the login page, e.g. to enroll an authenticator app.
+				require.Empty(t, actualLocation)
+				require.Equal(t, loginhtml.ContentSecurityPolicy(), rsp.Header().Get("Content-Security-Policy"))
+				for _, part := range tt.wantBodyParts {
+					require.Contains(t, rsp.Body.String(), part)
+				}
+				require.Len(t, oidctestutil.FilterClientSecretCreateActions(kubeClient.Actions()), tt.wantUnnecessaryStoredRecords)
 			default:
 				require.Failf(t, "test should have expected a redirect or form body",
 					"actual location was %q", actualLocation)
 			}
+
+			require.Equal(t, tt.wantLockedOut, loginThrottle.LockedOut(ldapUpstreamName, happyLDAPUsername, loginthrottle.SourceIP(req)))
+			if tt.wantLoginFailuresForgotten {
+				// Had the previous failed login not been forgotten, another failed login would lock out the username.
+				require.False(t, loginThrottle.RecordLoginFailure(ldapUpstreamName, happyLDAPUsername, loginthrottle.SourceIP(req)))
+			}
+
+			_, totpEnrollment, err := totpStorage.Get(context.Background(), ldapUpstreamName, happyLDAPUsernameFromAuthenticator)
+			require.NoError(t, err)
+			switch {
+			case tt.wantTOTPEnrollment != nil:
+				require.Equal(t, tt.wantTOTPEnrollment, totpEnrollment)
+			case tt.wantBodyParts != nil && tt.webAuthn == nil:
+				// A new enrollment was created, which must not be confirmed before the user entered a code.
+				require.NotNil(t, totpEnrollment)
+				require.False(t, totpEnrollment.Confirmed)
+				require.Contains(t, rsp.Body.String(), `<span id="totp-key">Key: `+totp.EncodeSecret(totpEnrollment.Secret)+`</span>`)
+			default:
+				require.Nil(t, totpEnrollment)
+			}
 		})
 	}
 }
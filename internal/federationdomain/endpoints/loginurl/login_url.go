@@ -10,10 +10,14 @@ import (
 )
 
 const (
-	UsernameParamName = "username"
-	PasswordParamName = "password"
-	StateParamName    = "state"
-	ErrParamName      = "err"
+	UsernameParamName          = "username"
+	PasswordParamName          = "password"
+	StateParamName             = "state"
+	ErrParamName               = "err"
+	OneTimeCodeParamName       = "one_time_code"
+	WebAuthnChallengeParamName = "webauthn_challenge"
+	WebAuthnResponseParamName  = "webauthn_response"
+	WebAuthnSkipParamName      = "webauthn_skip"
 
 	ShowNoError        ErrorParamValue = ""
 	ShowInternalError  ErrorParamValue = "internal_error"
@@ -0,0 +1,88 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package par provides a handler for the pushed authorization request endpoint, as described by RFC 9126.
+package par
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ory/fosite"
+
+	"go.pinniped.dev/internal/federationdomain/oidc"
+	"go.pinniped.dev/internal/fositestorage/pushedauthorizerequest"
+	"go.pinniped.dev/internal/plog"
+)
+
+// clientAuthenticationParams are the params which the client may use to authenticate itself to this endpoint.
+// They are not part of the authorization request, so they are not stored.
+//
+//nolint:gochecknoglobals // This is effectively a constant.
+var clientAuthenticationParams = []string{"client_secret", "client_assertion", "client_assertion_type"}
+
+// NewHandler returns an http.Handler that serves the pushed authorization request endpoint. The params of each
+// valid request are stored, and can be used at the authorization endpoint by referring to the returned request_uri.
+func NewHandler(
+	oauthHelper fosite.OAuth2Provider,
+	pushedAuthorizeRequests pushedauthorizerequest.Storage,
+	generateRequestID func() (string, error),
+	now func() time.Time,
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Authenticate the client and validate the authorization request in the same way as the authorization
+		// endpoint would, so the client learns about invalid requests before it sends the user anywhere.
+		// This also rejects any method other than POST.
+		authorizeRequester, err := oauthHelper.NewPushedAuthorizeRequest(r.Context(), r)
+		if err != nil {
+			plog.Info("pushed authorization request error", oidc.FositeErrorForLog(err)...)
+			oauthHelper.WritePushedAuthorizeError(r.Context(), w, authorizeRequester, err)
+			return
+		}
+
+		form := url.Values{}
+		for key, values := range authorizeRequester.GetRequestForm() {
+			form[key] = values
+		}
+		for _, param := range clientAuthenticationParams {
+			form.Del(param)
+		}
+		// The client may have used basic auth, in which case its ID is not in the form.
+		form.Set("client_id", authorizeRequester.GetClient().GetID())
+
+		requestID, err := generateRequestID()
+		if err == nil {
+			err = pushedAuthorizeRequests.Create(r.Context(), requestID, &pushedauthorizerequest.Request{
+				ClientID:  authorizeRequester.GetClient().GetID(),
+				Form:      form,
+				ExpiresAt: now().Add(pushedauthorizerequest.Lifetime),
+			})
+		}
+		if err != nil {
+			err = fosite.ErrServerError.WithHint("Could not store the pushed authorization request.").WithWrap(err).WithDebug(err.Error())
+			plog.Info("pushed authorization request error", oidc.FositeErrorForLog(err)...)
+			oauthHelper.WritePushedAuthorizeError(r.Context(), w, authorizeRequester, err)
+			return
+		}
+
+		oauthHelper.WritePushedAuthorizeResponse(r.Context(), w, authorizeRequester, &fosite.PushedAuthorizeResponse{
+			RequestURI: pushedauthorizerequest.RequestURI(requestID),
+			ExpiresIn:  int(pushedauthorizerequest.Lifetime.Seconds()),
+			Extra:      map[string]any{}, // fosite adds the request_uri and expires_in to this map when writing the response
+		})
+	})
+}
+
+// GenerateRequestID returns a new random ID for a pushed authorization request. The ID is the only secret part
+// of the request_uri, so it must not be guessable.
+func GenerateRequestID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("could not generate pushed authorization request ID: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,246 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package par
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	coretesting "k8s.io/client-go/testing"
+
+	supervisorfake "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/fake"
+	"go.pinniped.dev/internal/federationdomain/endpoints/jwks"
+	"go.pinniped.dev/internal/federationdomain/formposthtml"
+	"go.pinniped.dev/internal/federationdomain/oidc"
+	"go.pinniped.dev/internal/federationdomain/oidcclientvalidator"
+	"go.pinniped.dev/internal/federationdomain/storage"
+	"go.pinniped.dev/internal/fositestorage/pushedauthorizerequest"
+	"go.pinniped.dev/internal/testutil"
+)
+
+func TestPushedAuthorizeRequestEndpoint(t *testing.T) {
+	const (
+		downstreamIssuer = "https://my-downstream-issuer.com/some-path"
+		dynamicClientID  = "client.oauth.pinniped.dev-test-name"
+		dynamicClientUID = "fake-client-uid"
+	)
+
+	now := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	happyForm := func() url.Values {
+		return url.Values{
+			"response_type":         {"code"},
+			"scope":                 {"openid offline_access pinniped:request-audience username groups"},
+			"client_id":             {"pinniped-cli"},
+			"state":                 {"some-state-value-with-enough-bytes-to-exceed-min-allowed"},
+			"nonce":                 {"some-nonce-value-with-enough-bytes-to-exceed-min-allowed"},
+			"code_challenge":        {"ukiULVtsmFh2I5tz8m9MdT3aXAzrX9i4GBz1EfLBPwM"},
+			"code_challenge_method": {"S256"},
+			"redirect_uri":          {"http://127.0.0.1/callback"},
+			"pinniped_idp_name":     {"some-idp"},
+		}
+	}
+
+	dynamicClientForm := func() url.Values {
+		f := happyForm()
+		f.Del("client_id") // client auth for dynamic clients must be in basic auth header
+		return f
+	}
+
+	addFullyCapableDynamicClientAndSecretToKubeResources := func(t *testing.T, supervisorClient *supervisorfake.Clientset, kubeClient *fake.Clientset) {
+		oidcClient, secret := testutil.FullyCapableOIDCClientAndStorageSecret(t,
+			"some-namespace", dynamicClientID, dynamicClientUID, "http://127.0.0.1/callback", nil,
+			[]string{testutil.HashedPassword1AtGoMinCost}, oidcclientvalidator.Validate)
+		require.NoError(t, supervisorClient.Tracker().Add(oidcClient))
+		require.NoError(t, kubeClient.Tracker().Add(secret))
+	}
+
+	tests := []struct {
+		name              string
+		kubeResources     func(t *testing.T, supervisorClient *supervisorfake.Clientset, kubeClient *fake.Clientset)
+		method            string
+		form              url.Values
+		basicAuthPassword string
+		createSecretError error
+		wantStatus        int
+		wantError         string
+		wantRequestURI    string
+		wantStoredForm    url.Values
+		wantClientID      string
+	}{
+		{
+			name:           "happy path",
+			method:         http.MethodPost,
+			form:           happyForm(),
+			wantStatus:     http.StatusCreated,
+			wantRequestURI: "urn:ietf:params:oauth:request_uri:some-request-id",
+			wantStoredForm: happyForm(),
+			wantClientID:   "pinniped-cli",
+		},
+		{
+			name:              "happy path with a dynamic client which authenticates using basic auth",
+			kubeResources:     addFullyCapableDynamicClientAndSecretToKubeResources,
+			method:            http.MethodPost,
+			form:              dynamicClientForm(),
+			basicAuthPassword: testutil.PlaintextPassword1,
+			wantStatus:        http.StatusCreated,
+			wantRequestURI:    "urn:ietf:params:oauth:request_uri:some-request-id",
+			wantStoredForm: func() url.Values {
+				// The client_id is added to the stored params, since the authorization endpoint needs it.
+				f := dynamicClientForm()
+				f.Set("client_id", dynamicClientID)
+				return f
+			}(),
+			wantClientID: dynamicClientID,
+		},
+		{
+			name:              "dynamic client with the wrong client secret",
+			kubeResources:     addFullyCapableDynamicClientAndSecretToKubeResources,
+			method:            http.MethodPost,
+			form:              dynamicClientForm(),
+			basicAuthPassword: "wrong-password",
+			wantStatus:        http.StatusUnauthorized,
+			wantError:         "invalid_client",
+		},
+		{
+			name:       "wrong method",
+			method:     http.MethodGet,
+			form:       happyForm(),
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid_request",
+		},
+		{
+			name:   "unknown client",
+			method: http.MethodPost,
+			form: func() url.Values {
+				f := happyForm()
+				f.Set("client_id", "some-unknown-client")
+				return f
+			}(),
+			wantStatus: http.StatusUnauthorized,
+			wantError:  "invalid_client",
+		},
+		{
+			name:   "invalid redirect_uri",
+			method: http.MethodPost,
+			form: func() url.Values {
+				f := happyForm()
+				f.Set("redirect_uri", "http://127.0.0.1/does-not-match-what-is-configured-for-pinniped-cli-client")
+				return f
+			}(),
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid_request",
+		},
+		{
+			name:   "request_uri may not be pushed",
+			method: http.MethodPost,
+			form: func() url.Values {
+				f := happyForm()
+				f.Set("request_uri", "urn:ietf:params:oauth:request_uri:some-other-request-id")
+				return f
+			}(),
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid_request",
+		},
+		{
+			name:              "error while storing the request",
+			method:            http.MethodPost,
+			form:              happyForm(),
+			createSecretError: errors.New("some create error"),
+			wantStatus:        http.StatusInternalServerError,
+			wantError:         "server_error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset()
+			supervisorClient := supervisorfake.NewSimpleClientset()
+			if tt.kubeResources != nil {
+				tt.kubeResources(t, supervisorClient, kubeClient)
+			}
+			if tt.createSecretError != nil {
+				kubeClient.PrependReactor("create", "secrets", func(_ coretesting.Action) (bool, runtime.Object, error) {
+					return true, nil, tt.createSecretError
+				})
+			}
+			secretsClient := kubeClient.CoreV1().Secrets("some-namespace")
+			oidcClientsClient := supervisorClient.ConfigV1alpha1().OIDCClients("some-namespace")
+			oauthHelper := oidc.FositeOauth2Helper(
+				storage.NewKubeStorage(secretsClient, oidcClientsClient, oidc.DefaultOIDCTimeoutsConfiguration(), bcrypt.MinCost),
+				downstreamIssuer,
+				func() []byte { return []byte("some secret - must have at least 32 bytes") },
+				jwks.NewDynamicJWKSProvider(),
+				oidc.DefaultOIDCTimeoutsConfiguration(),
+				formposthtml.TemplateForContext,
+				nil,
+				nil,
+				nil,
+			)
+			pushedAuthorizeRequests := pushedauthorizerequest.New(secretsClient, func() time.Time { return now })
+
+			subject := NewHandler(
+				oauthHelper,
+				pushedAuthorizeRequests,
+				func() (string, error) { return "some-request-id", nil },
+				func() time.Time { return now },
+			)
+
+			req := httptest.NewRequest(tt.method, downstreamIssuer+oidc.PushedAuthorizeEndpointPath, strings.NewReader(tt.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.basicAuthPassword != "" {
+				req.SetBasicAuth(dynamicClientID, tt.basicAuthPassword)
+			}
+			rsp := httptest.NewRecorder()
+			subject.ServeHTTP(rsp, req)
+
+			require.Equal(t, tt.wantStatus, rsp.Code, rsp.Body.String())
+			require.Equal(t, "application/json;charset=UTF-8", rsp.Header().Get("Content-Type"))
+			require.Equal(t, "no-store", rsp.Header().Get("Cache-Control"))
+
+			var body map[string]any
+			require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &body))
+
+			if tt.wantError != "" {
+				require.Equal(t, tt.wantError, body["error"])
+				_, err := pushedAuthorizeRequests.Get(context.Background(), "some-request-id")
+				require.EqualError(t, err, "not_found")
+				return
+			}
+
+			require.Equal(t, map[string]any{
+				"request_uri": tt.wantRequestURI,
+				"expires_in":  float64(60),
+			}, body)
+
+			stored, err := pushedAuthorizeRequests.Get(context.Background(), "some-request-id")
+			require.NoError(t, err)
+			require.Equal(t, &pushedauthorizerequest.Request{
+				ClientID:  tt.wantClientID,
+				Form:      tt.wantStoredForm,
+				ExpiresAt: now.Add(time.Minute),
+			}, stored)
+		})
+	}
+}
+
+func TestGenerateRequestID(t *testing.T) {
+	id1, err := GenerateRequestID()
+	require.NoError(t, err)
+	require.Len(t, id1, 43)
+
+	id2, err := GenerateRequestID()
+	require.NoError(t, err)
+	require.NotEqual(t, id1, id2)
+}
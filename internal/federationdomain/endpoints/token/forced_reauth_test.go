@@ -0,0 +1,83 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
+	configlisters "go.pinniped.dev/generated/latest/client/supervisor/listers/config/v1alpha1"
+	"go.pinniped.dev/internal/federationdomain/errordetails"
+	"go.pinniped.dev/internal/federationdomain/forcedreauth"
+	"go.pinniped.dev/internal/psession"
+)
+
+func TestCheckForcedReauthentication(t *testing.T) {
+	flaggedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	require.NoError(t, indexer.Add(&supervisorconfigv1alpha1.ForcedReauthentication{
+		ObjectMeta: metav1.ObjectMeta{Name: "offboard-alice", Namespace: "some-namespace", CreationTimestamp: metav1.NewTime(flaggedAt)},
+		Spec: supervisorconfigv1alpha1.ForcedReauthenticationSpec{
+			Subject: supervisorconfigv1alpha1.ForcedReauthenticationSubject{Kind: "User", Name: "alice"},
+		},
+	}))
+	checker := forcedreauth.NewChecker(configlisters.NewForcedReauthenticationLister(indexer).ForcedReauthentications("some-namespace"))
+
+	sessionFor := func(username string, authTime time.Time) *psession.PinnipedSession {
+		session := psession.NewPinnipedSession()
+		session.Custom.Username = username
+		session.IDTokenClaims().AuthTime = authTime
+		return session
+	}
+
+	tests := []struct {
+		name     string
+		checker  *forcedreauth.Checker
+		session  *psession.PinnipedSession
+		wantHint string
+	}{
+		{
+			name:    "no checker",
+			session: sessionFor("alice", flaggedAt.Add(-time.Hour)),
+		},
+		{
+			name:     "session started before the flag",
+			checker:  checker,
+			session:  sessionFor("alice", flaggedAt.Add(-time.Hour)),
+			wantHint: "An administrator requires the user to log in again.",
+		},
+		{
+			name:    "session started by a fresh login after the flag",
+			checker: checker,
+			session: sessionFor("alice", flaggedAt.Add(time.Minute)),
+		},
+		{
+			name:    "session of another user",
+			checker: checker,
+			session: sessionFor("bob", flaggedAt.Add(-time.Hour)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkForcedReauthentication(tt.checker, tt.session, nil)
+			if tt.wantHint == "" {
+				require.NoError(t, err)
+				return
+			}
+			rfc6749Error := fosite.ErrorToRFC6749Error(err)
+			require.Equal(t, "error", rfc6749Error.ErrorField)
+			require.Equal(t, "Error during upstream refresh.", rfc6749Error.DescriptionField)
+			require.Equal(t, tt.wantHint, rfc6749Error.HintField)
+			require.Equal(t, `forcedreauthentication name: "offboard-alice"`, rfc6749Error.DebugField)
+			require.Equal(t, errordetails.ReauthenticationRequired, errordetails.FromError(err))
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package token
+
+import (
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/internal/psession"
+)
+
+const (
+	groupChangeEventName = "DownstreamRefreshGroupMembershipChanged"
+
+	groupChangeSeverityNormal = "normal"
+	groupChangeSeverityHigh   = "high"
+)
+
+// GroupChangeNotifier emits structured log events describing the changes in a user's group memberships
+// which were detected during a downstream refresh. These events are intended to be consumed by log
+// aggregation and alerting systems. When any of the added or removed groups are in the configured
+// list of sensitive groups, the event is emitted at a higher severity.
+type GroupChangeNotifier struct {
+	log             plog.Logger
+	sensitiveGroups sets.Set[string]
+}
+
+// NewGroupChangeNotifier returns a GroupChangeNotifier which writes to the given logger.
+func NewGroupChangeNotifier(log plog.Logger, sensitiveGroups []string) *GroupChangeNotifier {
+	return &GroupChangeNotifier{
+		log:             log,
+		sensitiveGroups: sets.New(sensitiveGroups...),
+	}
+}
+
+func (n *GroupChangeNotifier) notify(session *psession.PinnipedSession, clientID string, added, removed []string) {
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+
+	sensitiveAdded := n.filterSensitive(added)
+	sensitiveRemoved := n.filterSensitive(removed)
+
+	keysAndValues := []any{
+		"event", groupChangeEventName,
+		"subject", session.Fosite.Claims.Subject,
+		"username", session.Custom.Username,
+		"providerName", session.Custom.ProviderName,
+		"providerType", session.Custom.ProviderType,
+		"providerUID", session.Custom.ProviderUID,
+		"clientID", clientID,
+		"addedGroups", added,
+		"removedGroups", removed,
+	}
+
+	if len(sensitiveAdded) == 0 && len(sensitiveRemoved) == 0 {
+		n.log.Info("user's group memberships changed during refresh",
+			append(keysAndValues, "severity", groupChangeSeverityNormal)...)
+		return
+	}
+
+	n.log.Warning("user's sensitive group memberships changed during refresh",
+		append(keysAndValues,
+			"severity", groupChangeSeverityHigh,
+			"addedSensitiveGroups", sensitiveAdded,
+			"removedSensitiveGroups", sensitiveRemoved,
+		)...)
+}
+
+func (n *GroupChangeNotifier) filterSensitive(groups []string) []string {
+	sensitive := []string{}
+	for _, group := range groups {
+		if n.sensitiveGroups.Has(group) {
+			sensitive = append(sensitive, group)
+		}
+	}
+	return sensitive
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package token
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ory/fosite/handler/openid"
+	"github.com/ory/fosite/token/jwt"
+	"github.com/stretchr/testify/require"
+
+	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/internal/psession"
+	"go.pinniped.dev/internal/testutil"
+)
+
+func TestGroupChangeNotifier(t *testing.T) {
+	session := &psession.PinnipedSession{
+		Fosite: &openid.DefaultSession{
+			Claims: &jwt.IDTokenClaims{Subject: "some-subject"},
+		},
+		Custom: &psession.CustomSessionData{
+			Username:     "some-username",
+			ProviderUID:  "some-provider-uid",
+			ProviderName: "some-provider-name",
+			ProviderType: psession.ProviderTypeLDAP,
+		},
+	}
+
+	tests := []struct {
+		name     string
+		added    []string
+		removed  []string
+		wantLogs []string
+	}{
+		{
+			name:    "no changes",
+			added:   []string{},
+			removed: []string{},
+		},
+		{
+			name:    "only non-sensitive groups changed",
+			added:   []string{"a", "b"},
+			removed: []string{"c"},
+			wantLogs: []string{
+				`{"level":"info","timestamp":"2099-08-08T13:57:36.123456Z","logger":"token-test","caller":"token/group_change_events.go:<line>$token.(*GroupChangeNotifier).notify","message":"user's group memberships changed during refresh","event":"DownstreamRefreshGroupMembershipChanged","subject":"some-subject","username":"some-username","providerName":"some-provider-name","providerType":"ldap","providerUID":"some-provider-uid","clientID":"some-client","addedGroups":["a","b"],"removedGroups":["c"],"severity":"normal"}`,
+			},
+		},
+		{
+			name:    "sensitive groups changed",
+			added:   []string{"a", "admins"},
+			removed: []string{"c", "security"},
+			wantLogs: []string{
+				`{"level":"info","timestamp":"2099-08-08T13:57:36.123456Z","logger":"token-test","caller":"token/group_change_events.go:<line>$token.(*GroupChangeNotifier).notify","message":"user's sensitive group memberships changed during refresh","warning":true,"event":"DownstreamRefreshGroupMembershipChanged","subject":"some-subject","username":"some-username","providerName":"some-provider-name","providerType":"ldap","providerUID":"some-provider-uid","clientID":"some-client","addedGroups":["a","admins"],"removedGroups":["c","security"],"severity":"high","addedSensitiveGroups":["admins"],"removedSensitiveGroups":["security"]}`,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var log bytes.Buffer
+			subject := NewGroupChangeNotifier(plog.TestLogger(t, &log).WithName("token-test"), []string{"admins", "security"})
+
+			subject.notify(session, "some-client", test.added, test.removed)
+
+			require.Equal(t, test.wantLogs, testutil.SplitByNewline(log.String()))
+		})
+	}
+}
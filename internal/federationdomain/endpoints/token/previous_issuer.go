@@ -0,0 +1,41 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package token
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ory/fosite"
+	errorsx "github.com/pkg/errors"
+	"k8s.io/utils/clock"
+
+	"go.pinniped.dev/internal/federationdomain/errordetails"
+	"go.pinniped.dev/internal/federationdomain/oidc"
+	"go.pinniped.dev/internal/plog"
+)
+
+// NewPreviousIssuerHandler wraps the token endpoint handler of the previous issuer of a FederationDomain whose issuer
+// was changed to issuer. The refresh tokens which were issued by the previous issuer can be redeemed until
+// refreshGracePeriodEnd, after which all requests are refused, so that clients log in again at the new issuer.
+func NewPreviousIssuerHandler(
+	handler http.Handler,
+	oauthHelper fosite.OAuth2Provider,
+	issuer string,
+	refreshGracePeriodEnd time.Time,
+	clock clock.PassiveClock,
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if clock.Now().Before(refreshGracePeriodEnd) {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		err := errordetails.WithCode(errorsx.WithStack(fosite.ErrInvalidGrant.
+			WithHintf("The issuer has moved to %q. Log in again at the new issuer.", issuer).
+			WithDebugf("refresh grace period ended at %s", refreshGracePeriodEnd.UTC().Format(time.RFC3339))),
+			errordetails.IssuerMigrated)
+		plog.Info("token request error", oidc.FositeErrorForLog(err)...)
+		oidc.WriteAccessError(r.Context(), w, oauthHelper, nil, err)
+	})
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package token
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestNewPreviousIssuerHandler(t *testing.T) {
+	gracePeriodEnd := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	oauthHelper := &fosite.Fosite{Config: &fosite.Config{}}
+	delegate := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	tests := []struct {
+		name       string
+		now        time.Time
+		wantStatus int
+	}{
+		{
+			name:       "during the grace period",
+			now:        gracePeriodEnd.Add(-time.Minute),
+			wantStatus: http.StatusTeapot,
+		},
+		{
+			name:       "at the end of the grace period",
+			now:        gracePeriodEnd,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "after the grace period",
+			now:        gracePeriodEnd.Add(time.Hour),
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewPreviousIssuerHandler(delegate, oauthHelper, "https://new.example.com/issuer", gracePeriodEnd, clocktesting.NewFakeClock(tt.now))
+
+			rsp := httptest.NewRecorder()
+			handler.ServeHTTP(rsp, httptest.NewRequest(http.MethodPost, "/oauth2/token", nil))
+
+			require.Equal(t, tt.wantStatus, rsp.Code)
+			if tt.wantStatus != http.StatusBadRequest {
+				return
+			}
+			var body struct {
+				Error            string `json:"error"`
+				ErrorDescription string `json:"error_description"`
+				ErrorDetails     struct {
+					Code string `json:"code"`
+				} `json:"error_details"`
+			}
+			require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &body))
+			require.Equal(t, "invalid_grant", body.Error)
+			// fosite replaces the double quotes of hints with single quotes.
+			require.Contains(t, body.ErrorDescription, `The issuer has moved to 'https://new.example.com/issuer'. Log in again at the new issuer.`)
+			require.Equal(t, "PINNIPED_ISSUER_MIGRATED", body.ErrorDetails.Code)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package token
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/ory/fosite"
+	errorsx "github.com/pkg/errors"
+
+	oidcapi "go.pinniped.dev/generated/latest/apis/supervisor/oidc"
+	"go.pinniped.dev/internal/federationdomain/errordetails"
+	"go.pinniped.dev/internal/federationdomain/loginthrottle"
+	"go.pinniped.dev/internal/federationdomain/tokenenrichment"
+	"go.pinniped.dev/internal/psession"
+)
+
+func errTokenEnrichmentError() *fosite.RFC6749Error {
+	return &fosite.RFC6749Error{
+		ErrorField:       "error",
+		DescriptionField: "Error during token enrichment.",
+		CodeField:        http.StatusInternalServerError,
+	}
+}
+
+// enrichTokens calls the token enrichment webhook, when there is one, and applies its response to the session
+// which will be used to mint the downstream tokens. The groups are the user's current downstream groups.
+// Returns the downstream groups after enrichment, or an error when the webhook denied the issuance of the tokens.
+func enrichTokens(
+	r *http.Request,
+	webhook *tokenenrichment.Webhook,
+	issuer string,
+	accessRequest fosite.AccessRequester,
+	groups []string,
+) ([]string, error) {
+	if webhook == nil {
+		return groups, nil
+	}
+
+	session := accessRequest.GetSession().(*psession.PinnipedSession)
+	if session.Fosite.Claims.Extra == nil {
+		session.Fosite.Claims.Extra = map[string]any{}
+	}
+	grantedScopes := accessRequest.GetGrantedScopes()
+
+	resp, err := webhook.Enrich(r.Context(), &tokenenrichment.Request{
+		Issuer:        issuer,
+		ClientID:      accessRequest.GetClient().GetID(),
+		GrantType:     accessRequest.GetGrantTypes()[0],
+		GrantedScopes: grantedScopes,
+		Subject:       session.Fosite.Claims.Subject,
+		Username:      session.Custom.Username,
+		Groups:        groups,
+		ProviderName:  session.Custom.ProviderName,
+		ProviderType:  string(session.Custom.ProviderType),
+		SourceIP:      loginthrottle.SourceIP(r),
+		UserAgent:     r.UserAgent(),
+	})
+	if err != nil {
+		return nil, errordetails.WithCode(errorsx.WithStack(errTokenEnrichmentError().WithTrace(err).WithDebug(err.Error())),
+			errordetails.TokenEnrichmentFailed)
+	}
+
+	if !resp.Allowed {
+		return nil, errordetails.WithCode(errorsx.WithStack(fosite.ErrAccessDenied.WithHintf(
+			"Token issuance denied by token enrichment webhook: %s.", resp.Reason)), errordetails.TokenEnrichmentDenied)
+	}
+
+	if resp.Groups != nil {
+		groups = resp.Groups
+		if slices.Contains(grantedScopes, oidcapi.ScopeGroups) {
+			session.Fosite.Claims.Extra[oidcapi.IDTokenClaimGroups] = groups
+		}
+	}
+
+	if len(resp.AdditionalClaims) > 0 {
+		additionalClaims, _ := session.Fosite.Claims.Extra[oidcapi.IDTokenClaimAdditionalClaims].(map[string]any)
+		if additionalClaims == nil {
+			additionalClaims = make(map[string]any, len(resp.AdditionalClaims))
+		}
+		for k, v := range resp.AdditionalClaims {
+			additionalClaims[k] = v
+		}
+		session.Fosite.Claims.Extra[oidcapi.IDTokenClaimAdditionalClaims] = additionalClaims
+	}
+
+	return groups, nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package token
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/handler/openid"
+	"github.com/ory/fosite/token/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.pinniped.dev/internal/federationdomain/errordetails"
+	"go.pinniped.dev/internal/federationdomain/tokenenrichment"
+	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/internal/psession"
+	"go.pinniped.dev/internal/testutil/tlsserver"
+)
+
+func TestEnrichTokens(t *testing.T) {
+	tests := []struct {
+		name          string
+		noWebhook     bool
+		grantedScopes []string
+		webhookResp   string
+		wantGroups    []string
+		wantExtra     map[string]any
+		wantErr       string
+		wantHint      string
+		wantErrCode   errordetails.Code
+	}{
+		{
+			name:          "no webhook",
+			noWebhook:     true,
+			grantedScopes: []string{"openid", "groups"},
+			wantGroups:    []string{"a"},
+			wantExtra:     map[string]any{"groups": []string{"a"}},
+		},
+		{
+			name:          "allowed without changes",
+			grantedScopes: []string{"openid", "groups"},
+			webhookResp:   `{"allowed":true}`,
+			wantGroups:    []string{"a"},
+			wantExtra:     map[string]any{"groups": []string{"a"}},
+		},
+		{
+			name:          "allowed with new groups and additional claims",
+			grantedScopes: []string{"openid", "groups"},
+			webhookResp:   `{"allowed":true,"groups":["b","c"],"additionalClaims":{"device":"managed","upstream":"overridden"}}`,
+			wantGroups:    []string{"b", "c"},
+			wantExtra: map[string]any{
+				"groups":           []string{"b", "c"},
+				"additionalClaims": map[string]any{"device": "managed", "upstream": "overridden", "other": "kept"},
+			},
+		},
+		{
+			name:          "new groups are not added to the session when the groups scope was not granted",
+			grantedScopes: []string{"openid"},
+			webhookResp:   `{"allowed":true,"groups":["b"]}`,
+			wantGroups:    []string{"b"},
+			wantExtra:     map[string]any{"groups": []string{"a"}},
+		},
+		{
+			name:          "denied",
+			grantedScopes: []string{"openid", "groups"},
+			webhookResp:   `{"allowed":false,"reason":"device is not managed"}`,
+			wantErr:       "access_denied",
+			wantHint:      "Token issuance denied by token enrichment webhook: device is not managed.",
+			wantErrCode:   errordetails.TokenEnrichmentDenied,
+		},
+		{
+			name:          "invalid webhook response",
+			grantedScopes: []string{"openid", "groups"},
+			webhookResp:   `not json`,
+			wantErr:       "error",
+			wantErrCode:   errordetails.TokenEnrichmentFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var webhook *tokenenrichment.Webhook
+			if !tt.noWebhook {
+				server, serverCA := tlsserver.TestServerIPv4(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					var req tokenenrichment.Request
+					// use assert instead of require to not break the http.Handler with a panic
+					assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+					assert.Equal(t, tokenenrichment.Request{
+						APIVersion:    tokenenrichment.APIVersion,
+						Issuer:        "https://issuer.example.com",
+						ClientID:      "some-client",
+						GrantType:     "refresh_token",
+						GrantedScopes: tt.grantedScopes,
+						Subject:       "some-subject",
+						Username:      "some-username",
+						Groups:        []string{"a"},
+						ProviderName:  "some-provider-name",
+						ProviderType:  "ldap",
+						SourceIP:      "192.0.2.1",
+						UserAgent:     "some-agent",
+					}, req)
+					_, _ = w.Write([]byte(tt.webhookResp))
+				}), nil)
+				webhook = tokenenrichment.New(tokenenrichment.Config{
+					Endpoint:                 server.URL,
+					CertificateAuthorityData: base64.StdEncoding.EncodeToString(serverCA),
+					Timeout:                  time.Minute,
+					FailurePolicy:            tokenenrichment.FailurePolicyFail,
+				}, plog.New())
+			}
+
+			session := &psession.PinnipedSession{
+				Fosite: &openid.DefaultSession{
+					Claims: &jwt.IDTokenClaims{
+						Subject: "some-subject",
+						Extra:   map[string]any{"groups": []string{"a"}},
+					},
+				},
+				Custom: &psession.CustomSessionData{
+					Username:     "some-username",
+					ProviderName: "some-provider-name",
+					ProviderType: psession.ProviderTypeLDAP,
+				},
+			}
+			if _, ok := tt.wantExtra["additionalClaims"]; ok {
+				session.Fosite.Claims.Extra["additionalClaims"] = map[string]any{"upstream": "original", "other": "kept"}
+			}
+			accessRequest := fosite.NewAccessRequest(session)
+			accessRequest.Client = &fosite.DefaultClient{ID: "some-client"}
+			accessRequest.GrantTypes = fosite.Arguments{"refresh_token"}
+			for _, scope := range tt.grantedScopes {
+				accessRequest.GrantScope(scope)
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/token", nil)
+			r.Header.Set("User-Agent", "some-agent")
+
+			groups, err := enrichTokens(r, webhook, "https://issuer.example.com", accessRequest, []string{"a"})
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				rfcErr := fosite.ErrorToRFC6749Error(err)
+				require.Equal(t, tt.wantHint, rfcErr.HintField)
+				require.Equal(t, tt.wantErrCode, errordetails.FromError(err))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantGroups, groups)
+			require.Equal(t, tt.wantExtra, session.Fosite.Claims.Extra)
+		})
+	}
+}
@@ -19,15 +19,26 @@ import (
 	"k8s.io/apiserver/pkg/warning"
 
 	oidcapi "go.pinniped.dev/generated/latest/apis/supervisor/oidc"
+	"go.pinniped.dev/internal/federationdomain/clientregistry"
+	"go.pinniped.dev/internal/federationdomain/downstreamsession"
+	"go.pinniped.dev/internal/federationdomain/dpop"
+	"go.pinniped.dev/internal/federationdomain/errordetails"
 	"go.pinniped.dev/internal/federationdomain/federationdomainproviders"
+	"go.pinniped.dev/internal/federationdomain/forcedreauth"
 	"go.pinniped.dev/internal/federationdomain/idtokenlifespan"
 	"go.pinniped.dev/internal/federationdomain/oidc"
 	"go.pinniped.dev/internal/federationdomain/resolvedprovider"
+	"go.pinniped.dev/internal/federationdomain/resourceindicator"
+	"go.pinniped.dev/internal/federationdomain/singlesignon"
 	"go.pinniped.dev/internal/federationdomain/timeouts"
+	"go.pinniped.dev/internal/federationdomain/tokenenrichment"
+	singlesignonstorage "go.pinniped.dev/internal/fositestorage/singlesignon"
 	"go.pinniped.dev/internal/httputil/httperr"
 	"go.pinniped.dev/internal/idtransform"
+	"go.pinniped.dev/internal/lastauth"
 	"go.pinniped.dev/internal/plog"
 	"go.pinniped.dev/internal/psession"
+	"go.pinniped.dev/internal/tracing"
 )
 
 func NewHandler(
@@ -35,26 +46,52 @@ func NewHandler(
 	oauthHelper fosite.OAuth2Provider,
 	overrideAccessTokenLifespan timeouts.OverrideLifespan,
 	overrideIDTokenLifespan timeouts.OverrideLifespan,
+	groupChangeNotifier *GroupChangeNotifier,
+	issuer string,
+	tokenEnrichmentWebhook *tokenenrichment.Webhook, // may be nil, in which case no webhook is called
+	forcedReauthChecker *forcedreauth.Checker, // may be nil, in which case no reauthentication is forced
+	dpopValidator *dpop.Validator,
+	lastClientAuths *lastauth.Recorder[string], // may be nil, in which case the successful requests are not remembered
+	singleSignOnStorage singlesignonstorage.Storage, // holds the upstream refresh tokens shared by single sign-on
 ) http.Handler {
 	return httperr.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
 		session := psession.NewPinnipedSession()
 		accessRequest, err := oauthHelper.NewAccessRequest(r.Context(), r, session)
 		if err != nil {
 			plog.Info("token request error", oidc.FositeErrorForLog(err)...)
-			oauthHelper.WriteAccessError(r.Context(), w, accessRequest, err)
+			oidc.WriteAccessError(r.Context(), w, oauthHelper, accessRequest, err)
 			return nil
 		}
 
+		// Validate the DPoP proof of the request, if any, and bind the tokens to its key or check their binding.
+		jkt, err := handleDPoP(r, dpopValidator, issuer, accessRequest)
+		if err != nil {
+			plog.Info("token request error", oidc.FositeErrorForLog(err)...)
+			oidc.WriteAccessError(r.Context(), w, oauthHelper, accessRequest, err)
+			return nil
+		}
+
+		// RFC 8707 resources may be requested when refreshing to narrow the resources which were granted at the
+		// authorization endpoint. The token exchange grant handles the resource param itself.
+		if accessRequest.GetGrantTypes().ExactOne(oidcapi.GrantTypeAuthorizationCode) ||
+			accessRequest.GetGrantTypes().ExactOne(oidcapi.GrantTypeRefreshToken) {
+			if err = resourceindicator.NarrowIfRequested(accessRequest); err != nil {
+				plog.Info("token request error", oidc.FositeErrorForLog(err)...)
+				oidc.WriteAccessError(r.Context(), w, oauthHelper, accessRequest, err)
+				return nil
+			}
+		}
+
 		// Check if we are performing a refresh grant.
 		if accessRequest.GetGrantTypes().ExactOne(oidcapi.GrantTypeRefreshToken) {
 			// The above call to NewAccessRequest has loaded the session from storage into the accessRequest variable.
 			// The session, requested scopes, and requested audience from the original authorize request was retrieved
 			// from the Kube storage layer and added to the accessRequest. Additionally, the audience and scopes may
 			// have already been granted on the accessRequest.
-			err = upstreamRefresh(r.Context(), accessRequest, idpLister)
+			err = upstreamRefresh(r, accessRequest, idpLister, groupChangeNotifier, issuer, tokenEnrichmentWebhook, forcedReauthChecker, singleSignOnStorage)
 			if err != nil {
 				plog.Info("upstream refresh error", oidc.FositeErrorForLog(err)...)
-				oauthHelper.WriteAccessError(r.Context(), w, accessRequest, err)
+				oidc.WriteAccessError(r.Context(), w, oauthHelper, accessRequest, err)
 				return nil
 			}
 		}
@@ -69,6 +106,23 @@ func NewHandler(
 					warning.AddWarning(r.Context(), "", warningText)
 				}
 			}
+
+			if tokenEnrichmentWebhook != nil {
+				var groups []string
+				if slices.Contains(accessRequest.GetGrantedScopes(), oidcapi.ScopeGroups) {
+					groups, err = validateAndGetDownstreamGroupsFromSession(storedSession)
+					if err != nil {
+						plog.Info("token request error", oidc.FositeErrorForLog(err)...)
+						oidc.WriteAccessError(r.Context(), w, oauthHelper, accessRequest, err)
+						return nil
+					}
+				}
+				if _, err = enrichTokens(r, tokenEnrichmentWebhook, issuer, accessRequest, groups); err != nil {
+					plog.Info("token enrichment error", oidc.FositeErrorForLog(err)...)
+					oidc.WriteAccessError(r.Context(), w, oauthHelper, accessRequest, err)
+					return nil
+				}
+			}
 		}
 
 		// Lifetimes of the access and refresh tokens are determined by the above call to NewAccessRequest.
@@ -78,22 +132,61 @@ func NewHandler(
 		// Create the token response.
 		// The lifetime of the ID token will be determined inside the call NewAccessResponse.
 		// Depending on the request, sometimes override the default ID token lifespan by putting
-		// the override value onto the context.
+		// the override value onto the context. The token exchange grant checks the DPoP binding of its subject
+		// token using the thumbprint from the context.
 		accessResponse, err := oauthHelper.NewAccessResponse(
-			maybeOverrideDefaultIDTokenLifetime(r.Context(), overrideIDTokenLifespan, accessRequest),
+			maybeOverrideDefaultIDTokenLifetime(dpop.WithThumbprint(r.Context(), jkt), overrideIDTokenLifespan, accessRequest),
 			accessRequest)
 		if err != nil {
 			plog.Info("token response error", oidc.FositeErrorForLog(err)...)
-			oauthHelper.WriteAccessError(r.Context(), w, accessRequest, err)
+			oidc.WriteAccessError(r.Context(), w, oauthHelper, accessRequest, err)
 			return nil
 		}
 
+		// The token exchange grant returns a JWT which is not bound, so only the other grants use the DPoP token type.
+		if !accessRequest.GetGrantTypes().ExactOne(oidcapi.GrantTypeTokenExchange) &&
+			dpop.BoundThumbprint(accessRequest.GetSession().(*psession.PinnipedSession)) != "" {
+			accessResponse.SetTokenType(dpop.TokenType)
+		}
+
 		oauthHelper.WriteAccessResponse(r.Context(), w, accessRequest, accessResponse)
 
+		// Remember the successful request, so the OIDCClient watcher can publish it in the status of the OIDCClient.
+		lastClientAuths.Record(accessRequest.GetClient().GetID())
+
 		return nil
 	})
 }
 
+// handleDPoP validates the DPoP proof of the request, and returns the JWK thumbprint of its key, or an empty string
+// when the request has no proof. The tokens of an authorization code grant are bound to the key of the proof, while
+// the tokens of a refresh grant may only be refreshed with a proof signed by the key to which they are bound.
+func handleDPoP(r *http.Request, dpopValidator *dpop.Validator, issuer string, accessRequest fosite.AccessRequester) (string, error) {
+	jkt, err := dpopValidator.Validate(r, issuer+oidc.TokenEndpointPath)
+	if err != nil {
+		return "", err
+	}
+
+	client, ok := accessRequest.GetClient().(*clientregistry.Client)
+	if err = dpop.CheckRequired(ok && client.RequiresDPoP(), jkt); err != nil {
+		return "", err
+	}
+
+	session := accessRequest.GetSession().(*psession.PinnipedSession)
+	switch {
+	case accessRequest.GetGrantTypes().ExactOne(oidcapi.GrantTypeAuthorizationCode):
+		if jkt != "" {
+			dpop.Bind(session, jkt)
+		}
+	case accessRequest.GetGrantTypes().ExactOne(oidcapi.GrantTypeRefreshToken):
+		if err = dpop.CheckBinding(session, jkt); err != nil {
+			return "", err
+		}
+	}
+
+	return jkt, nil
+}
+
 func maybeOverrideDefaultAccessTokenLifetime(overrideAccessTokenLifespan timeouts.OverrideLifespan, accessRequest fosite.AccessRequester) {
 	if newLifespan, doOverride := overrideAccessTokenLifespan(accessRequest); doOverride {
 		accessRequest.GetSession().SetExpiresAt(fosite.AccessToken, time.Now().UTC().Add(newLifespan).Round(time.Second))
@@ -107,13 +200,13 @@ func maybeOverrideDefaultIDTokenLifetime(baseCtx context.Context, overrideIDToke
 	return baseCtx
 }
 
-func errMissingUpstreamSessionInternalError() *fosite.RFC6749Error {
-	return &fosite.RFC6749Error{
+func errMissingUpstreamSessionInternalError() error {
+	return errordetails.WithCode(&fosite.RFC6749Error{
 		ErrorField:       "error",
 		DescriptionField: "There was an internal server error.",
 		HintField:        "Required upstream data not found in session.",
 		CodeField:        http.StatusInternalServerError,
-	}
+	}, errordetails.SessionDataMissing)
 }
 
 func errUpstreamRefreshError() *fosite.RFC6749Error {
@@ -125,10 +218,16 @@ func errUpstreamRefreshError() *fosite.RFC6749Error {
 }
 
 func upstreamRefresh(
-	ctx context.Context,
+	r *http.Request,
 	accessRequest fosite.AccessRequester,
 	idpLister federationdomainproviders.FederationDomainIdentityProvidersListerI,
+	groupChangeNotifier *GroupChangeNotifier,
+	issuer string,
+	tokenEnrichmentWebhook *tokenenrichment.Webhook,
+	forcedReauthChecker *forcedreauth.Checker,
+	singleSignOnStorage singlesignonstorage.Storage,
 ) error {
+	ctx := r.Context()
 	session := accessRequest.GetSession().(*psession.PinnipedSession)
 
 	customSessionData := session.Custom
@@ -144,7 +243,7 @@ func upstreamRefresh(
 	skipGroups := !slices.Contains(accessRequest.GetGrantedScopes(), oidcapi.ScopeGroups)
 
 	if session.IDTokenClaims().AuthTime.IsZero() {
-		return errorsx.WithStack(resolvedprovider.ErrMissingUpstreamSessionInternalError())
+		return errorsx.WithStack(errMissingUpstreamSessionInternalError())
 	}
 
 	err := validateSessionHasUsername(session)
@@ -161,6 +260,12 @@ func upstreamRefresh(
 		}
 	}
 
+	// Refuse sessions which an admin flagged for reauthentication before calling the upstream provider.
+	err = checkForcedReauthentication(forcedReauthChecker, session, oldTransformedGroups)
+	if err != nil {
+		return err
+	}
+
 	idp, err := findProviderByNameAndType(providerName, customSessionData.ProviderType, providerUID, idpLister)
 	if err != nil {
 		return err
@@ -168,7 +273,7 @@ func upstreamRefresh(
 
 	cloneOfIDPSpecificSessionData := idp.CloneIDPSpecificSessionDataFromSession(session.Custom)
 	if cloneOfIDPSpecificSessionData == nil {
-		return errorsx.WithStack(resolvedprovider.ErrMissingUpstreamSessionInternalError())
+		return errorsx.WithStack(errMissingUpstreamSessionInternalError())
 	}
 
 	oldUntransformedUsername := session.Custom.UpstreamUsername
@@ -182,10 +287,12 @@ func upstreamRefresh(
 		IDPSpecificSessionData: cloneOfIDPSpecificSessionData,
 	}
 
-	// Perform the upstream refresh.
-	refreshedIdentity, err := idp.UpstreamRefresh(ctx, previousIdentity)
+	// Perform the upstream refresh, using the latest upstream refresh token when it is shared by single sign-on.
+	upstreamCtx, span := tracing.Start(ctx, "upstream refresh", tracing.AttributeIdentityProvider.String(idp.GetDisplayName()))
+	refreshedIdentity, err := singlesignon.UpstreamRefresh(upstreamCtx, singleSignOnStorage, idp, session, previousIdentity)
+	tracing.EndWithError(span, err)
 	if err != nil {
-		return err
+		return errordetails.WithCode(err, errordetails.UpstreamRefreshFailed)
 	}
 
 	// If the idp wants to update the session with new information from the refresh, then update it.
@@ -215,8 +322,42 @@ func upstreamRefresh(
 		return err
 	}
 
+	// The webhook sees the groups after the transformations, and may replace them before they are compared
+	// to the previous groups.
+	refreshedTransformedGroups, err = enrichTokens(r, tokenEnrichmentWebhook, issuer, accessRequest, refreshedTransformedGroups)
+	if err != nil {
+		return err
+	}
+
+	// The refreshed groups may include a flagged group which was not in the session, e.g. when the groups scope
+	// was not granted or when the user was added to the group since the last refresh.
+	err = checkForcedReauthentication(forcedReauthChecker, session, refreshedTransformedGroups)
+	if err != nil {
+		return err
+	}
+
+	// The scope policies of the client may have changed, or the user may have been removed from a required group.
+	err = downstreamsession.CheckScopePolicies(accessRequest.GetClient(), accessRequest.GetGrantedScopes(), refreshedTransformedGroups)
+	if err != nil {
+		return errordetails.WithCode(errorsx.WithStack(fosite.ErrAccessDenied.
+			WithHint("The user no longer meets the scope policies of the client.").
+			WithDebug(err.Error())),
+			errordetails.ScopePolicyRejected)
+	}
+
+	// The client may have started to require ACR values which the login of the user did not assert.
+	err = downstreamsession.CheckACRPolicy(accessRequest.GetClient(), session.IDTokenClaims().AuthenticationContextClassReference)
+	if err != nil {
+		return errordetails.WithCode(errorsx.WithStack(fosite.ErrAccessDenied.
+			WithHint("The login of the user no longer meets the ACR requirements of the client.").
+			WithDebug(err.Error())),
+			errordetails.ACRPolicyRejected)
+	}
+
 	if !skipGroups {
-		warnIfGroupsChanged(ctx, oldTransformedGroups, refreshedTransformedGroups, oldTransformedUsername, accessRequest.GetClient().GetID())
+		added, removed := diffSortedGroups(oldTransformedGroups, refreshedTransformedGroups)
+		warnIfGroupsChanged(ctx, added, removed, oldTransformedUsername, accessRequest.GetClient().GetID())
+		groupChangeNotifier.notify(session, accessRequest.GetClient().GetID(), added, removed)
 		// Replace the old value for the downstream groups in the user's session with the new value.
 		session.Fosite.Claims.Extra[oidcapi.IDTokenClaimGroups] = refreshedTransformedGroups
 	}
@@ -235,15 +376,37 @@ func findProviderByNameAndType(
 	for _, p := range idpLister.GetIdentityProviders() {
 		if p.GetSessionProviderType() == providerType && p.GetProvider().GetResourceName() == providerResourceName {
 			if p.GetProvider().GetResourceUID() != mustHaveResourceUID {
-				return nil, errorsx.WithStack(errUpstreamRefreshError().WithHint(
-					"Provider from upstream session data has changed its resource UID since authentication."))
+				return nil, errordetails.WithCode(errorsx.WithStack(errUpstreamRefreshError().WithHint(
+					"Provider from upstream session data has changed its resource UID since authentication.")),
+					errordetails.IDPChanged)
 			}
 			return p, nil
 		}
 	}
-	return nil, errorsx.WithStack(errUpstreamRefreshError().
+	return nil, errordetails.WithCode(errorsx.WithStack(errUpstreamRefreshError().
 		WithHint("Provider from upstream session data was not found.").
-		WithDebugf("provider name: %q, provider type: %q", providerResourceName, providerType))
+		WithDebugf("provider name: %q, provider type: %q", providerResourceName, providerType)),
+		errordetails.IDPNotReady)
+}
+
+// checkForcedReauthentication returns an error when a ForcedReauthentication applies to the downstream username or
+// to one of the downstream groups, and the session was started by a login before its notBefore time.
+func checkForcedReauthentication(forcedReauthChecker *forcedreauth.Checker, session *psession.PinnipedSession, groups []string) error {
+	forcedReauthentication, err := forcedReauthChecker.Find(session.Custom.Username, groups, session.IDTokenClaims().AuthTime)
+	if err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+	if forcedReauthentication != nil {
+		plog.Info("refresh refused due to forced reauthentication",
+			"username", session.Custom.Username,
+			"forcedReauthentication", forcedReauthentication.Name,
+			"authTime", session.IDTokenClaims().AuthTime)
+		return errordetails.WithCode(errorsx.WithStack(errUpstreamRefreshError().
+			WithHint("An administrator requires the user to log in again.").
+			WithDebugf("forcedreauthentication name: %q", forcedReauthentication.Name)),
+			errordetails.ReauthenticationRequired)
+	}
+	return nil
 }
 
 func validateSessionHasUsername(session *psession.PinnipedSession) error {
@@ -267,23 +430,26 @@ func applyIdentityTransformationsDuringRefresh(
 ) ([]string, error) {
 	transformationResult, err := transforms.Evaluate(ctx, upstreamUsername, upstreamGroups)
 	if err != nil {
-		return nil, errUpstreamRefreshError().WithHintf(
+		return nil, errordetails.WithCode(errUpstreamRefreshError().WithHintf(
 			"Upstream refresh error while applying configured identity transformations.").
 			WithTrace(err).
-			WithDebugf("provider name: %q, provider type: %q", providerName, providerType)
+			WithDebugf("provider name: %q, provider type: %q", providerName, providerType),
+			errordetails.IdentityTransformationFailed)
 	}
 
 	if !transformationResult.AuthenticationAllowed {
-		return nil, errUpstreamRefreshError().WithHintf(
+		return nil, errordetails.WithCode(errUpstreamRefreshError().WithHintf(
 			"Upstream refresh rejected by configured identity policy: %s.", transformationResult.RejectedAuthenticationMessage).
-			WithDebugf("provider name: %q, provider type: %q", providerName, providerType)
+			WithDebugf("provider name: %q, provider type: %q", providerName, providerType),
+			errordetails.IdentityPolicyRejected)
 	}
 
 	if oldTransformedUsername != transformationResult.Username {
-		return nil, errUpstreamRefreshError().WithHintf(
+		return nil, errordetails.WithCode(errUpstreamRefreshError().WithHintf(
 			"Upstream refresh failed.").
 			WithTrace(errors.New("username in upstream refresh does not match previous value")).
-			WithDebugf("provider name: %q, provider type: %q", providerName, providerType)
+			WithDebugf("provider name: %q, provider type: %q", providerName, providerType),
+			errordetails.UpstreamRefreshFailed)
 	}
 
 	return transformationResult.Groups, nil
@@ -314,7 +480,7 @@ func validateAndGetDownstreamGroupsFromSession(session *psession.PinnipedSession
 	return downstreamGroups, nil
 }
 
-func warnIfGroupsChanged(ctx context.Context, oldGroups, newGroups []string, username string, clientID string) {
+func warnIfGroupsChanged(ctx context.Context, added, removed []string, username string, clientID string) {
 	if clientID != oidcapi.ClientIDPinnipedCLI {
 		// Only send these warnings to the CLI client. They are intended for kubectl to print to the screen.
 		// A webapp using a dynamic client wouldn't know to look for these special warning headers, and
@@ -323,8 +489,6 @@ func warnIfGroupsChanged(ctx context.Context, oldGroups, newGroups []string, use
 		return
 	}
 
-	added, removed := diffSortedGroups(oldGroups, newGroups)
-
 	if len(added) > 0 {
 		warning.AddWarning(ctx, "", fmt.Sprintf("User %q has been added to the following groups: %q", username, added))
 	}
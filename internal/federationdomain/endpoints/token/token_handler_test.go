@@ -39,6 +39,7 @@ import (
 	"k8s.io/apiserver/pkg/warning"
 	"k8s.io/client-go/kubernetes/fake"
 	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/utils/clock"
 	"k8s.io/utils/ptr"
 
 	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
@@ -46,21 +47,27 @@ import (
 	"go.pinniped.dev/internal/celtransformer"
 	"go.pinniped.dev/internal/crud"
 	"go.pinniped.dev/internal/federationdomain/clientregistry"
+	"go.pinniped.dev/internal/federationdomain/dpop"
 	"go.pinniped.dev/internal/federationdomain/endpoints/jwks"
 	"go.pinniped.dev/internal/federationdomain/federationdomainproviders"
+	"go.pinniped.dev/internal/federationdomain/formposthtml"
 	"go.pinniped.dev/internal/federationdomain/oidc"
 	"go.pinniped.dev/internal/federationdomain/oidcclientvalidator"
+	"go.pinniped.dev/internal/federationdomain/resourceindicator"
 	"go.pinniped.dev/internal/federationdomain/storage"
+	"go.pinniped.dev/internal/federationdomain/strategy"
 	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
 	"go.pinniped.dev/internal/fositestorage/accesstoken"
 	"go.pinniped.dev/internal/fositestorage/authorizationcode"
 	"go.pinniped.dev/internal/fositestorage/openidconnect"
 	"go.pinniped.dev/internal/fositestorage/pkce"
 	"go.pinniped.dev/internal/fositestorage/refreshtoken"
+	singlesignonstorage "go.pinniped.dev/internal/fositestorage/singlesignon"
 	"go.pinniped.dev/internal/fositestoragei"
 	"go.pinniped.dev/internal/here"
 	"go.pinniped.dev/internal/httputil/httperr"
 	"go.pinniped.dev/internal/oidcclientsecretstorage"
+	"go.pinniped.dev/internal/plog"
 	"go.pinniped.dev/internal/psession"
 	"go.pinniped.dev/internal/testutil"
 	"go.pinniped.dev/internal/testutil/oidctestutil"
@@ -165,6 +172,13 @@ var (
 		}
 	`)
 
+	invalidTargetResourceDuringAuthcodeExchangeErrorBody = here.Doc(`
+		{
+			"error":             "invalid_target",
+			"error_description": "The requested resource is invalid, missing, unknown, or malformed. Resources must be requested at the authorization endpoint, and may only be narrowed when refreshing."
+		}
+	`)
+
 	fositeReusedAuthCodeErrorBody = here.Doc(`
 		{
 			"error":             "invalid_grant",
@@ -217,14 +231,32 @@ var (
 	pinnipedUpstreamSessionDataNotFoundErrorBody = here.Doc(`
 		{
 			"error":             "error",
-			"error_description": "There was an internal server error. Required upstream data not found in session."
+			"error_description": "There was an internal server error. Required upstream data not found in session.",
+			"error_details":     {"code": "PINNIPED_SESSION_DATA_MISSING"}
 		}
 	`)
 
 	fositeUpstreamGroupClaimErrorBody = here.Doc(`
 		{
 			"error":             "error",
-			"error_description": "Error during upstream refresh. Upstream refresh error while extracting groups claim."
+			"error_description": "Error during upstream refresh. Upstream refresh error while extracting groups claim.",
+			"error_details":     {"code": "PINNIPED_UPSTREAM_REFRESH_FAILED"}
+		}
+	`)
+
+	pinnipedInvalidDPoPProofErrorBody = here.Doc(`
+		{
+			"error":             "invalid_dpop_proof",
+			"error_description": "The DPoP proof is invalid. The DPoP proof is not a valid JWT.",
+			"error_details":     {"code": "PINNIPED_DPOP_PROOF_INVALID"}
+		}
+	`)
+
+	pinnipedDPoPProofRequiredErrorBody = here.Doc(`
+		{
+			"error":             "invalid_dpop_proof",
+			"error_description": "The DPoP proof is invalid. This client requires a DPoP proof.",
+			"error_details":     {"code": "PINNIPED_DPOP_PROOF_INVALID"}
 		}
 	`)
 
@@ -296,6 +328,7 @@ type tokenEndpointResponseExpectedValues struct {
 	wantClientID                           string
 	wantRequestedScopes                    []string
 	wantGrantedScopes                      []string
+	wantGrantedAudience                    []string
 	wantUsername                           string
 	wantGroups                             []string
 	wantOIDCUpstreamRefreshCall            *expectedOIDCUpstreamRefresh
@@ -339,6 +372,41 @@ func addFullyCapableDynamicClientAndSecretToKubeResources(t *testing.T, supervis
 	require.NoError(t, kubeClient.Tracker().Add(secret))
 }
 
+func addFullyCapableDynamicClientWithAllowedResourcesAndSecretToKubeResources(t *testing.T, supervisorClient *supervisorfake.Clientset, kubeClient *fake.Clientset) {
+	oidcClient, secret := testutil.FullyCapableOIDCClientAndStorageSecret(t,
+		"some-namespace",
+		dynamicClientID,
+		dynamicClientUID,
+		goodRedirectURI,
+		nil, // no custom ID token lifetime
+		[]string{testutil.HashedPassword1AtGoMinCost, testutil.HashedPassword2AtGoMinCost},
+		oidcclientvalidator.Validate,
+	)
+	oidcClient.Spec.AllowedResources = []supervisorconfigv1alpha1.ResourceURI{
+		"https://cluster1.example.com",
+		"https://cluster2.example.com",
+	}
+	require.NoError(t, supervisorClient.Tracker().Add(oidcClient))
+	require.NoError(t, kubeClient.Tracker().Add(secret))
+}
+
+func addFullyCapableDynamicClientWithDelegationAndSecretToKubeResources(allowedActors ...string) func(t *testing.T, supervisorClient *supervisorfake.Clientset, kubeClient *fake.Clientset) {
+	return func(t *testing.T, supervisorClient *supervisorfake.Clientset, kubeClient *fake.Clientset) {
+		oidcClient, secret := testutil.FullyCapableOIDCClientAndStorageSecret(t,
+			"some-namespace",
+			dynamicClientID,
+			dynamicClientUID,
+			goodRedirectURI,
+			nil, // no custom ID token lifetime
+			[]string{testutil.HashedPassword1AtGoMinCost, testutil.HashedPassword2AtGoMinCost},
+			oidcclientvalidator.Validate,
+		)
+		oidcClient.Spec.Delegation = &supervisorconfigv1alpha1.OIDCClientDelegation{AllowedActors: allowedActors}
+		require.NoError(t, supervisorClient.Tracker().Add(oidcClient))
+		require.NoError(t, kubeClient.Tracker().Add(secret))
+	}
+}
+
 func addFullyCapableDynamicClientWithCustomIDTokenLifetimeAndSecretToKubeResources(idTokenLifetime int32) func(t *testing.T, supervisorClient *supervisorfake.Clientset, kubeClient *fake.Clientset) {
 	return func(t *testing.T, supervisorClient *supervisorfake.Clientset, kubeClient *fake.Clientset) {
 		oidcClient, secret := testutil.FullyCapableOIDCClientAndStorageSecret(t,
@@ -355,6 +423,21 @@ func addFullyCapableDynamicClientWithCustomIDTokenLifetimeAndSecretToKubeResourc
 	}
 }
 
+func addFullyCapableDynamicClientRequiringDPoPAndSecretToKubeResources(t *testing.T, supervisorClient *supervisorfake.Clientset, kubeClient *fake.Clientset) {
+	oidcClient, secret := testutil.FullyCapableOIDCClientAndStorageSecret(t,
+		"some-namespace",
+		dynamicClientID,
+		dynamicClientUID,
+		goodRedirectURI,
+		nil, // no custom ID token lifetime
+		[]string{testutil.HashedPassword1AtGoMinCost, testutil.HashedPassword2AtGoMinCost},
+		oidcclientvalidator.Validate,
+	)
+	oidcClient.Spec.RequireDPoP = true
+	require.NoError(t, supervisorClient.Tracker().Add(oidcClient))
+	require.NoError(t, kubeClient.Tracker().Add(secret))
+}
+
 func modifyAuthcodeTokenRequestWithDynamicClientAuth(r *http.Request, authCode string) {
 	r.Body = happyAuthcodeRequestBody(authCode).WithClientID("").ReadCloser() // No client_id in body.
 	r.SetBasicAuth(dynamicClientID, testutil.PlaintextPassword1)              // Use basic auth header instead.
@@ -443,6 +526,47 @@ func TestTokenEndpointAuthcodeExchange(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:          "request is valid and tokens are issued for dynamic client with the resources granted at the authorization endpoint",
+			kubeResources: addFullyCapableDynamicClientWithAllowedResourcesAndSecretToKubeResources,
+			authcodeExchange: authcodeExchangeInputs{
+				modifyAuthRequest: func(r *http.Request) {
+					addDynamicClientIDToFormPostBody(r)
+					r.Form.Set("scope", "openid pinniped:request-audience username groups")
+					r.Form["resource"] = []string{"https://cluster1.example.com", "https://cluster2.example.com"}
+				},
+				modifyTokenRequest: modifyAuthcodeTokenRequestWithDynamicClientAuth,
+				want: tokenEndpointResponseExpectedValues{
+					wantStatus:            http.StatusOK,
+					wantClientID:          dynamicClientID,
+					wantSuccessBodyFields: []string{"id_token", "access_token", "token_type", "scope", "expires_in"}, // no refresh token
+					wantRequestedScopes:   []string{"openid", "pinniped:request-audience", "username", "groups"},
+					wantGrantedScopes:     []string{"openid", "pinniped:request-audience", "username", "groups"},
+					wantGrantedAudience:   []string{"https://cluster1.example.com", "https://cluster2.example.com"},
+					wantUsername:          goodUsername,
+					wantGroups:            goodGroups,
+				},
+			},
+		},
+		{
+			name:          "token request for dynamic client asks for resources during an authorization code grant",
+			kubeResources: addFullyCapableDynamicClientWithAllowedResourcesAndSecretToKubeResources,
+			authcodeExchange: authcodeExchangeInputs{
+				modifyAuthRequest: func(r *http.Request) {
+					addDynamicClientIDToFormPostBody(r)
+					r.Form.Set("scope", "openid pinniped:request-audience username groups")
+					r.Form["resource"] = []string{"https://cluster1.example.com", "https://cluster2.example.com"}
+				},
+				modifyTokenRequest: func(r *http.Request, authCode string) {
+					r.Body = happyAuthcodeRequestBody(authCode).WithClientID("").WithResource("https://cluster2.example.com").ReadCloser()
+					r.SetBasicAuth(dynamicClientID, testutil.PlaintextPassword1)
+				},
+				want: tokenEndpointResponseExpectedValues{
+					wantStatus:            http.StatusBadRequest,
+					wantErrorResponseBody: invalidTargetResourceDuringAuthcodeExchangeErrorBody,
+				},
+			},
+		},
 		{
 			name:          "request is valid and tokens are issued for dynamic client which has a custom ID token lifetime",
 			kubeResources: addFullyCapableDynamicClientWithCustomIDTokenLifetimeAndSecretToKubeResources(4242),
@@ -927,6 +1051,31 @@ func TestTokenEndpointAuthcodeExchange(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "DPoP proof is invalid",
+			authcodeExchange: authcodeExchangeInputs{
+				modifyTokenRequest: func(r *http.Request, authCode string) { r.Header.Set("DPoP", "not-a-jwt") },
+				want: tokenEndpointResponseExpectedValues{
+					wantStatus:            http.StatusBadRequest,
+					wantErrorResponseBody: pinnipedInvalidDPoPProofErrorBody,
+				},
+			},
+		},
+		{
+			name:          "dynamic client requires DPoP but there is no DPoP proof",
+			kubeResources: addFullyCapableDynamicClientRequiringDPoPAndSecretToKubeResources,
+			authcodeExchange: authcodeExchangeInputs{
+				modifyAuthRequest: func(r *http.Request) {
+					addDynamicClientIDToFormPostBody(r)
+					r.Form.Set("scope", "openid pinniped:request-audience username groups")
+				},
+				modifyTokenRequest: modifyAuthcodeTokenRequestWithDynamicClientAuth,
+				want: tokenEndpointResponseExpectedValues{
+					wantStatus:            http.StatusBadRequest,
+					wantErrorResponseBody: pinnipedDPoPProofRequiredErrorBody,
+				},
+			},
+		},
 		{
 			name: "private signing key for JWTs has not yet been provided by the controller who is responsible for dynamically providing it",
 			authcodeExchange: authcodeExchangeInputs{
@@ -1074,6 +1223,7 @@ func TestTokenEndpointTokenExchange(t *testing.T) { // tests for grant_type "urn
 		wantStatus            int
 		wantErrorType         string
 		wantErrorDescContains string
+		wantActorClaim        map[string]any
 	}{
 		{
 			name:              "happy path",
@@ -1455,11 +1605,313 @@ func TestTokenEndpointTokenExchange(t *testing.T) { // tests for grant_type "urn
 			authcodeExchange:  doValidAuthCodeExchange,
 			requestedAudience: "some-workload-cluster",
 			modifyRequestParams: func(t *testing.T, params url.Values) {
-				params.Set("resource", "some-resource-parameter-value")
+				params.Set("scope", "some-scope-parameter-value")
+			},
+			wantStatus:            http.StatusBadRequest,
+			wantErrorType:         "invalid_request",
+			wantErrorDescContains: `Unsupported parameter 'scope'.`,
+		},
+		{
+			name:              "happy path with resource parameter instead of audience parameter",
+			authcodeExchange:  doValidAuthCodeExchange,
+			requestedAudience: "https://cluster1.example.com",
+			modifyRequestParams: func(t *testing.T, params url.Values) {
+				params.Del("audience")
+				params.Set("resource", "https://cluster1.example.com")
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:              "happy path with equal resource and audience parameters",
+			authcodeExchange:  doValidAuthCodeExchange,
+			requestedAudience: "https://cluster1.example.com",
+			modifyRequestParams: func(t *testing.T, params url.Values) {
+				params.Set("resource", "https://cluster1.example.com")
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:              "resource parameter is not equal to audience parameter",
+			authcodeExchange:  doValidAuthCodeExchange,
+			requestedAudience: "some-workload-cluster",
+			modifyRequestParams: func(t *testing.T, params url.Values) {
+				params.Set("resource", "https://cluster1.example.com")
+			},
+			wantStatus:            http.StatusBadRequest,
+			wantErrorType:         "invalid_target",
+			wantErrorDescContains: `The 'resource' and 'audience' parameters must be equal when both are provided.`,
+		},
+		{
+			name:              "resource parameter is not an absolute URI",
+			authcodeExchange:  doValidAuthCodeExchange,
+			requestedAudience: "",
+			modifyRequestParams: func(t *testing.T, params url.Values) {
+				params.Set("resource", "some-workload-cluster")
+			},
+			wantStatus:            http.StatusBadRequest,
+			wantErrorType:         "invalid_target",
+			wantErrorDescContains: `The resource 'some-workload-cluster' must be an absolute URI without a fragment.`,
+		},
+		{
+			name:              "multiple resource parameters",
+			authcodeExchange:  doValidAuthCodeExchange,
+			requestedAudience: "",
+			modifyRequestParams: func(t *testing.T, params url.Values) {
+				params["resource"] = []string{"https://cluster1.example.com", "https://cluster2.example.com"}
+			},
+			wantStatus:            http.StatusBadRequest,
+			wantErrorType:         "invalid_target",
+			wantErrorDescContains: `Only one 'resource' parameter is supported.`,
+		},
+		{
+			name:          "dynamic client requests a resource which was granted at the authorization endpoint",
+			kubeResources: addFullyCapableDynamicClientWithAllowedResourcesAndSecretToKubeResources,
+			authcodeExchange: authcodeExchangeInputs{
+				modifyAuthRequest: func(authRequest *http.Request) {
+					addDynamicClientIDToFormPostBody(authRequest)
+					authRequest.Form.Set("scope", "openid pinniped:request-audience username groups")
+					authRequest.Form.Set("resource", "https://cluster1.example.com")
+				},
+				modifyTokenRequest: modifyAuthcodeTokenRequestWithDynamicClientAuth,
+				want: func() tokenEndpointResponseExpectedValues {
+					want := successfulAuthCodeExchangeUsingDynamicClient()
+					want.wantGrantedAudience = []string{"https://cluster1.example.com"}
+					return want
+				}(),
+			},
+			modifyRequestParams: func(t *testing.T, params url.Values) {
+				params.Del("client_id") // client auth for dynamic clients must be in basic auth header
+			},
+			modifyRequestHeaders: func(r *http.Request) {
+				r.SetBasicAuth(dynamicClientID, testutil.PlaintextPassword1)
+			},
+			requestedAudience: "https://cluster1.example.com",
+			wantStatus:        http.StatusOK,
+		},
+		{
+			name:          "dynamic client requests an audience which is not one of the resources granted at the authorization endpoint",
+			kubeResources: addFullyCapableDynamicClientWithAllowedResourcesAndSecretToKubeResources,
+			authcodeExchange: authcodeExchangeInputs{
+				modifyAuthRequest: func(authRequest *http.Request) {
+					addDynamicClientIDToFormPostBody(authRequest)
+					authRequest.Form.Set("scope", "openid pinniped:request-audience username groups")
+					authRequest.Form.Set("resource", "https://cluster1.example.com")
+				},
+				modifyTokenRequest: modifyAuthcodeTokenRequestWithDynamicClientAuth,
+				want: func() tokenEndpointResponseExpectedValues {
+					want := successfulAuthCodeExchangeUsingDynamicClient()
+					want.wantGrantedAudience = []string{"https://cluster1.example.com"}
+					return want
+				}(),
+			},
+			modifyRequestParams: func(t *testing.T, params url.Values) {
+				params.Del("client_id") // client auth for dynamic clients must be in basic auth header
+			},
+			modifyRequestHeaders: func(r *http.Request) {
+				r.SetBasicAuth(dynamicClientID, testutil.PlaintextPassword1)
+			},
+			requestedAudience:     "https://cluster2.example.com",
+			wantStatus:            http.StatusBadRequest,
+			wantErrorType:         "invalid_target",
+			wantErrorDescContains: `The requested audience 'https://cluster2.example.com' is not one of the resources granted to the 'subject_token'.`,
+		},
+		{
+			name:             "happy path with dynamic client acting on behalf of the user for an allowed actor",
+			kubeResources:    addFullyCapableDynamicClientWithDelegationAndSecretToKubeResources("some-other-actor", goodUsername),
+			authcodeExchange: doValidAuthCodeExchangeUsingDynamicClient(),
+			modifyRequestParams: func(t *testing.T, params url.Values) {
+				params.Del("client_id") // client auth for dynamic clients must be in basic auth header
+				// The actor is the same user in this test, since any access token of the FederationDomain identifies an actor.
+				params.Set("actor_token", params.Get("subject_token"))
+				params.Set("actor_token_type", "urn:ietf:params:oauth:token-type:access_token")
+			},
+			modifyRequestHeaders: func(r *http.Request) {
+				r.SetBasicAuth(dynamicClientID, testutil.PlaintextPassword1)
+			},
+			requestedAudience: "some-workload-cluster",
+			wantStatus:        http.StatusOK,
+			wantActorClaim:    map[string]any{"sub": goodSubject, "username": goodUsername},
+		},
+		{
+			name:             "dynamic client acting on behalf of the user for an actor which is not allowed",
+			kubeResources:    addFullyCapableDynamicClientWithDelegationAndSecretToKubeResources("some-other-actor"),
+			authcodeExchange: doValidAuthCodeExchangeUsingDynamicClient(),
+			modifyRequestParams: func(t *testing.T, params url.Values) {
+				params.Del("client_id") // client auth for dynamic clients must be in basic auth header
+				params.Set("actor_token", params.Get("subject_token"))
+				params.Set("actor_token_type", "urn:ietf:params:oauth:token-type:access_token")
+			},
+			modifyRequestHeaders: func(r *http.Request) {
+				r.SetBasicAuth(dynamicClientID, testutil.PlaintextPassword1)
+			},
+			requestedAudience:     "some-workload-cluster",
+			wantStatus:            http.StatusForbidden,
+			wantErrorType:         "access_denied",
+			wantErrorDescContains: `The actor may not act on behalf of the users of this client.`,
+		},
+		{
+			name:             "dynamic client which does not allow delegation sends an actor_token",
+			kubeResources:    addFullyCapableDynamicClientAndSecretToKubeResources,
+			authcodeExchange: doValidAuthCodeExchangeUsingDynamicClient(),
+			modifyRequestParams: func(t *testing.T, params url.Values) {
+				params.Del("client_id") // client auth for dynamic clients must be in basic auth header
+				params.Set("actor_token", params.Get("subject_token"))
+				params.Set("actor_token_type", "urn:ietf:params:oauth:token-type:access_token")
+			},
+			modifyRequestHeaders: func(r *http.Request) {
+				r.SetBasicAuth(dynamicClientID, testutil.PlaintextPassword1)
+			},
+			requestedAudience:     "some-workload-cluster",
+			wantStatus:            http.StatusForbidden,
+			wantErrorType:         "access_denied",
+			wantErrorDescContains: `The actor may not act on behalf of the users of this client.`,
+		},
+		{
+			name:             "dynamic client sends an actor_token which was issued to another client",
+			kubeResources:    addFullyCapableDynamicClientWithDelegationAndSecretToKubeResources(goodUsername),
+			authcodeExchange: doValidAuthCodeExchangeUsingDynamicClient(),
+			modifyStorage: func(t *testing.T, storage *storage.KubeStorage, secrets v1.SecretInterface, pendingRequest *http.Request) {
+				pendingRequest.Form.Set("actor_token", storeModifiedCopyOfSubjectToken(t, storage, pendingRequest, func(request *fosite.Request) {
+					request.Client.(*clientregistry.Client).ID = "some-other-client"
+				}))
+			},
+			modifyRequestParams: func(t *testing.T, params url.Values) {
+				params.Del("client_id") // client auth for dynamic clients must be in basic auth header
+				params.Set("actor_token_type", "urn:ietf:params:oauth:token-type:access_token")
+			},
+			modifyRequestHeaders: func(r *http.Request) {
+				r.SetBasicAuth(dynamicClientID, testutil.PlaintextPassword1)
+			},
+			requestedAudience:     "some-workload-cluster",
+			wantStatus:            http.StatusBadRequest,
+			wantErrorType:         "invalid_grant",
+			wantErrorDescContains: `The OAuth 2.0 Client ID from this request does not match the one of the 'actor_token'.`,
+		},
+		{
+			name:             "dynamic client sends an actor_token which was issued to the pinniped-cli client",
+			kubeResources:    addFullyCapableDynamicClientWithDelegationAndSecretToKubeResources(goodUsername),
+			authcodeExchange: doValidAuthCodeExchangeUsingDynamicClient(),
+			modifyStorage: func(t *testing.T, storage *storage.KubeStorage, secrets v1.SecretInterface, pendingRequest *http.Request) {
+				pendingRequest.Form.Set("actor_token", storeModifiedCopyOfSubjectToken(t, storage, pendingRequest, func(request *fosite.Request) {
+					request.Client = clientregistry.PinnipedCLI()
+				}))
+			},
+			modifyRequestParams: func(t *testing.T, params url.Values) {
+				params.Del("client_id") // client auth for dynamic clients must be in basic auth header
+				params.Set("actor_token_type", "urn:ietf:params:oauth:token-type:access_token")
+			},
+			modifyRequestHeaders: func(r *http.Request) {
+				r.SetBasicAuth(dynamicClientID, testutil.PlaintextPassword1)
+			},
+			requestedAudience:     "some-workload-cluster",
+			wantStatus:            http.StatusBadRequest,
+			wantErrorType:         "invalid_grant",
+			wantErrorDescContains: `The OAuth 2.0 Client ID from this request does not match the one of the 'actor_token'.`,
+		},
+		{
+			name:             "dynamic client sends an actor_token which was not granted the pinniped:request-audience scope",
+			kubeResources:    addFullyCapableDynamicClientWithDelegationAndSecretToKubeResources(goodUsername),
+			authcodeExchange: doValidAuthCodeExchangeUsingDynamicClient(),
+			modifyStorage: func(t *testing.T, storage *storage.KubeStorage, secrets v1.SecretInterface, pendingRequest *http.Request) {
+				pendingRequest.Form.Set("actor_token", storeModifiedCopyOfSubjectToken(t, storage, pendingRequest, func(request *fosite.Request) {
+					request.GrantedScope = fosite.Arguments{"openid", "username", "groups"}
+				}))
+			},
+			modifyRequestParams: func(t *testing.T, params url.Values) {
+				params.Del("client_id") // client auth for dynamic clients must be in basic auth header
+				params.Set("actor_token_type", "urn:ietf:params:oauth:token-type:access_token")
+			},
+			modifyRequestHeaders: func(r *http.Request) {
+				r.SetBasicAuth(dynamicClientID, testutil.PlaintextPassword1)
+			},
+			requestedAudience:     "some-workload-cluster",
+			wantStatus:            http.StatusForbidden,
+			wantErrorType:         "access_denied",
+			wantErrorDescContains: `Missing the 'pinniped:request-audience' scope in the 'actor_token'.`,
+		},
+		{
+			name:             "dynamic client sends an actor_token which was not granted the username scope",
+			kubeResources:    addFullyCapableDynamicClientWithDelegationAndSecretToKubeResources(goodUsername),
+			authcodeExchange: doValidAuthCodeExchangeUsingDynamicClient(),
+			modifyStorage: func(t *testing.T, storage *storage.KubeStorage, secrets v1.SecretInterface, pendingRequest *http.Request) {
+				pendingRequest.Form.Set("actor_token", storeModifiedCopyOfSubjectToken(t, storage, pendingRequest, func(request *fosite.Request) {
+					request.GrantedScope = fosite.Arguments{"openid", "pinniped:request-audience", "groups"}
+				}))
+			},
+			modifyRequestParams: func(t *testing.T, params url.Values) {
+				params.Del("client_id") // client auth for dynamic clients must be in basic auth header
+				params.Set("actor_token_type", "urn:ietf:params:oauth:token-type:access_token")
+			},
+			modifyRequestHeaders: func(r *http.Request) {
+				r.SetBasicAuth(dynamicClientID, testutil.PlaintextPassword1)
+			},
+			requestedAudience:     "some-workload-cluster",
+			wantStatus:            http.StatusForbidden,
+			wantErrorType:         "access_denied",
+			wantErrorDescContains: `Missing the 'username' scope in the 'actor_token'.`,
+		},
+		{
+			name:              "pinniped-cli sends an actor_token",
+			authcodeExchange:  doValidAuthCodeExchange,
+			requestedAudience: "some-workload-cluster",
+			modifyRequestParams: func(t *testing.T, params url.Values) {
+				params.Set("actor_token", params.Get("subject_token"))
+				params.Set("actor_token_type", "urn:ietf:params:oauth:token-type:access_token")
+			},
+			wantStatus:            http.StatusForbidden,
+			wantErrorType:         "access_denied",
+			wantErrorDescContains: `The actor may not act on behalf of the users of this client.`,
+		},
+		{
+			name:             "bogus actor_token",
+			kubeResources:    addFullyCapableDynamicClientWithDelegationAndSecretToKubeResources(goodUsername),
+			authcodeExchange: doValidAuthCodeExchangeUsingDynamicClient(),
+			modifyRequestParams: func(t *testing.T, params url.Values) {
+				params.Del("client_id") // client auth for dynamic clients must be in basic auth header
+				params.Set("actor_token", "some-bogus-value")
+				params.Set("actor_token_type", "urn:ietf:params:oauth:token-type:access_token")
+			},
+			modifyRequestHeaders: func(r *http.Request) {
+				r.SetBasicAuth(dynamicClientID, testutil.PlaintextPassword1)
+			},
+			requestedAudience:     "some-workload-cluster",
+			wantStatus:            http.StatusUnauthorized,
+			wantErrorType:         "request_unauthorized",
+			wantErrorDescContains: `The request could not be authorized. Invalid 'actor_token' parameter value.`,
+		},
+		{
+			name:              "actor_token without actor_token_type",
+			authcodeExchange:  doValidAuthCodeExchange,
+			requestedAudience: "some-workload-cluster",
+			modifyRequestParams: func(t *testing.T, params url.Values) {
+				params.Set("actor_token", params.Get("subject_token"))
 			},
 			wantStatus:            http.StatusBadRequest,
 			wantErrorType:         "invalid_request",
-			wantErrorDescContains: `Unsupported parameter 'resource'.`,
+			wantErrorDescContains: `Missing 'actor_token_type' parameter.`,
+		},
+		{
+			name:              "actor_token_type without actor_token",
+			authcodeExchange:  doValidAuthCodeExchange,
+			requestedAudience: "some-workload-cluster",
+			modifyRequestParams: func(t *testing.T, params url.Values) {
+				params.Set("actor_token_type", "urn:ietf:params:oauth:token-type:access_token")
+			},
+			wantStatus:            http.StatusBadRequest,
+			wantErrorType:         "invalid_request",
+			wantErrorDescContains: `Missing 'actor_token' parameter.`,
+		},
+		{
+			name:              "wrong actor_token_type",
+			authcodeExchange:  doValidAuthCodeExchange,
+			requestedAudience: "some-workload-cluster",
+			modifyRequestParams: func(t *testing.T, params url.Values) {
+				params.Set("actor_token", params.Get("subject_token"))
+				params.Set("actor_token_type", "urn:ietf:params:oauth:token-type:jwt")
+			},
+			wantStatus:            http.StatusBadRequest,
+			wantErrorType:         "invalid_request",
+			wantErrorDescContains: `Unsupported 'actor_token_type' parameter value, must be 'urn:ietf:params:oauth:token-type:access_token'.`,
 		},
 		{
 			name:              "bogus access token",
@@ -1738,7 +2190,13 @@ func TestTokenEndpointTokenExchange(t *testing.T) { // tests for grant_type "urn
 			if len(test.authcodeExchange.want.wantAdditionalClaims) > 0 {
 				idTokenFields = append(idTokenFields, "additionalClaims")
 			}
+			if test.wantActorClaim != nil {
+				idTokenFields = append(idTokenFields, "act")
+			}
 			require.ElementsMatch(t, idTokenFields, getMapKeys(tokenClaims))
+			if test.wantActorClaim != nil {
+				require.Equal(t, test.wantActorClaim, tokenClaims["act"])
+			}
 
 			// Assert that the returned token has expected claims values.
 			require.NotEmpty(t, tokenClaims["jti"])
@@ -2320,7 +2778,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "Error during upstream refresh. Upstream refresh failed."
+							"error_description": "Error during upstream refresh. Upstream refresh failed.",
+							"error_details":     {"code": "PINNIPED_UPSTREAM_REFRESH_FAILED"}
 						}
 					`),
 				},
@@ -2360,7 +2819,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "Error during upstream refresh. Upstream refresh rejected by configured identity policy: authentication was rejected by a configured policy."
+							"error_description": "Error during upstream refresh. Upstream refresh rejected by configured identity policy: authentication was rejected by a configured policy.",
+							"error_details":     {"code": "PINNIPED_IDENTITY_POLICY_REJECTED"}
 						}
 					`),
 				},
@@ -2457,6 +2917,87 @@ func TestRefreshGrant(t *testing.T) {
 				)),
 			},
 		},
+		{
+			name: "happy path refresh grant using dynamic client which narrows the resources granted at the authorization endpoint",
+			idps: testidplister.NewUpstreamIDPListerBuilder().WithOIDC(
+				upstreamOIDCIdentityProviderBuilder().WithValidatedAndMergedWithUserInfoTokens(&oidctypes.Token{
+					IDToken: &oidctypes.IDToken{
+						Claims: map[string]any{
+							"sub": goodUpstreamSubject,
+						},
+					},
+				}).WithRefreshedTokens(refreshedUpstreamTokensWithIDAndRefreshTokens()).Build()),
+			kubeResources: addFullyCapableDynamicClientWithAllowedResourcesAndSecretToKubeResources,
+			authcodeExchange: authcodeExchangeInputs{
+				customSessionData: initialUpstreamOIDCRefreshTokenCustomSessionData(),
+				modifyAuthRequest: func(r *http.Request) {
+					addDynamicClientIDToFormPostBody(r)
+					r.Form.Set("scope", "openid offline_access username groups")
+					r.Form["resource"] = []string{"https://cluster1.example.com", "https://cluster2.example.com"}
+				},
+				modifyTokenRequest: modifyAuthcodeTokenRequestWithDynamicClientAuth,
+				want: func() tokenEndpointResponseExpectedValues {
+					want := withWantDynamicClientID(happyAuthcodeExchangeTokenResponseForOpenIDAndOfflineAccess(initialUpstreamOIDCRefreshTokenCustomSessionData()))
+					want.wantGrantedAudience = []string{"https://cluster1.example.com", "https://cluster2.example.com"}
+					return want
+				}(),
+			},
+			refreshRequest: refreshRequestInputs{
+				modifyTokenRequest: func(tokenRequest *http.Request, refreshToken string, accessToken string) {
+					tokenRequest.Body = happyRefreshRequestBody(refreshToken).WithClientID("").WithResource("https://cluster2.example.com").ReadCloser()
+					tokenRequest.SetBasicAuth(dynamicClientID, testutil.PlaintextPassword1)
+				},
+				want: func() tokenEndpointResponseExpectedValues {
+					want := withWantDynamicClientID(happyRefreshTokenResponseForOpenIDAndOfflineAccess(
+						upstreamOIDCCustomSessionDataWithNewRefreshToken(oidcUpstreamRefreshedRefreshToken),
+						refreshedUpstreamTokensWithIDAndRefreshTokens(),
+					))
+					want.wantGrantedAudience = []string{"https://cluster2.example.com"}
+					return want
+				}(),
+			},
+		},
+		{
+			name: "refresh grant using dynamic client which requests a resource which was not granted at the authorization endpoint",
+			idps: testidplister.NewUpstreamIDPListerBuilder().WithOIDC(
+				upstreamOIDCIdentityProviderBuilder().WithValidatedAndMergedWithUserInfoTokens(&oidctypes.Token{
+					IDToken: &oidctypes.IDToken{
+						Claims: map[string]any{
+							"sub": goodUpstreamSubject,
+						},
+					},
+				}).WithRefreshedTokens(refreshedUpstreamTokensWithIDAndRefreshTokens()).Build()),
+			kubeResources: addFullyCapableDynamicClientWithAllowedResourcesAndSecretToKubeResources,
+			authcodeExchange: authcodeExchangeInputs{
+				customSessionData: initialUpstreamOIDCRefreshTokenCustomSessionData(),
+				modifyAuthRequest: func(r *http.Request) {
+					addDynamicClientIDToFormPostBody(r)
+					r.Form.Set("scope", "openid offline_access username groups")
+					r.Form.Set("resource", "https://cluster1.example.com")
+				},
+				modifyTokenRequest: modifyAuthcodeTokenRequestWithDynamicClientAuth,
+				want: func() tokenEndpointResponseExpectedValues {
+					want := withWantDynamicClientID(happyAuthcodeExchangeTokenResponseForOpenIDAndOfflineAccess(initialUpstreamOIDCRefreshTokenCustomSessionData()))
+					want.wantGrantedAudience = []string{"https://cluster1.example.com"}
+					return want
+				}(),
+			},
+			refreshRequest: refreshRequestInputs{
+				modifyTokenRequest: func(tokenRequest *http.Request, refreshToken string, accessToken string) {
+					tokenRequest.Body = happyRefreshRequestBody(refreshToken).WithClientID("").WithResource("https://cluster2.example.com").ReadCloser()
+					tokenRequest.SetBasicAuth(dynamicClientID, testutil.PlaintextPassword1)
+				},
+				want: tokenEndpointResponseExpectedValues{
+					wantStatus: http.StatusBadRequest,
+					wantErrorResponseBody: here.Doc(`
+						{
+							"error":             "invalid_target",
+							"error_description": "The requested resource is invalid, missing, unknown, or malformed. The resource 'https://cluster2.example.com' is not allowed."
+						}
+					`),
+				},
+			},
+		},
 		{
 			name: "happy path refresh grant with openid scope granted (id token returned) using dynamic client which has custom ID token lifetime configured",
 			idps: testidplister.NewUpstreamIDPListerBuilder().WithOIDC(
@@ -3240,7 +3781,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "Error during upstream refresh. Upstream refresh rejected by configured identity policy: users who belong to certain upstream group are not allowed."
+							"error_description": "Error during upstream refresh. Upstream refresh rejected by configured identity policy: users who belong to certain upstream group are not allowed.",
+							"error_details":     {"code": "PINNIPED_IDENTITY_POLICY_REJECTED"}
 						}
 					`),
 				},
@@ -3841,7 +4383,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "Error during upstream refresh. Provider from upstream session data was not found."
+							"error_description": "Error during upstream refresh. Provider from upstream session data was not found.",
+							"error_details":     {"code": "PINNIPED_IDP_NOT_READY"}
 						}
 					`),
 				},
@@ -3875,7 +4418,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "Error during upstream refresh. Provider from upstream session data has changed its resource UID since authentication."
+							"error_description": "Error during upstream refresh. Provider from upstream session data has changed its resource UID since authentication.",
+							"error_details":     {"code": "PINNIPED_IDP_CHANGED"}
 						}
 					`),
 				},
@@ -3893,7 +4437,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "Error during upstream refresh. Upstream refresh failed."
+							"error_description": "Error during upstream refresh. Upstream refresh failed.",
+							"error_details":     {"code": "PINNIPED_UPSTREAM_REFRESH_FAILED"}
 						}
 					`),
 				},
@@ -3911,7 +4456,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "Error during upstream refresh. Upstream refresh failed."
+							"error_description": "Error during upstream refresh. Upstream refresh failed.",
+							"error_details":     {"code": "PINNIPED_UPSTREAM_REFRESH_FAILED"}
 						}
 					`),
 				},
@@ -3933,7 +4479,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "Error during upstream refresh. Upstream refresh returned an invalid ID token or UserInfo response."
+							"error_description": "Error during upstream refresh. Upstream refresh returned an invalid ID token or UserInfo response.",
+							"error_details":     {"code": "PINNIPED_UPSTREAM_REFRESH_FAILED"}
 						}
 					`),
 				},
@@ -3961,7 +4508,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "Error during upstream refresh. Upstream refresh failed."
+							"error_description": "Error during upstream refresh. Upstream refresh failed.",
+							"error_details":     {"code": "PINNIPED_UPSTREAM_REFRESH_FAILED"}
 						}
 					`),
 				},
@@ -3986,7 +4534,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "Error during upstream refresh. Upstream refresh failed."
+							"error_description": "Error during upstream refresh. Upstream refresh failed.",
+							"error_details":     {"code": "PINNIPED_UPSTREAM_REFRESH_FAILED"}
 						}
 					`),
 				},
@@ -4013,7 +4562,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "Error during upstream refresh. Upstream refresh failed."
+							"error_description": "Error during upstream refresh. Upstream refresh failed.",
+							"error_details":     {"code": "PINNIPED_UPSTREAM_REFRESH_FAILED"}
 						}
 					`),
 				},
@@ -4040,7 +4590,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "Error during upstream refresh. Upstream refresh failed."
+							"error_description": "Error during upstream refresh. Upstream refresh failed.",
+							"error_details":     {"code": "PINNIPED_UPSTREAM_REFRESH_FAILED"}
 						}
 					`),
 				},
@@ -4129,7 +4680,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "Error during upstream refresh. Upstream refresh failed."
+							"error_description": "Error during upstream refresh. Upstream refresh failed.",
+							"error_details":     {"code": "PINNIPED_UPSTREAM_REFRESH_FAILED"}
 						}
 					`),
 				},
@@ -4169,7 +4721,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "Error during upstream refresh. Upstream refresh rejected by configured identity policy: authentication was rejected by a configured policy."
+							"error_description": "Error during upstream refresh. Upstream refresh rejected by configured identity policy: authentication was rejected by a configured policy.",
+							"error_details":     {"code": "PINNIPED_IDENTITY_POLICY_REJECTED"}
 						}
 					`),
 				},
@@ -4295,7 +4848,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "There was an internal server error. Required upstream data not found in session."
+							"error_description": "There was an internal server error. Required upstream data not found in session.",
+							"error_details":     {"code": "PINNIPED_SESSION_DATA_MISSING"}
 						}
 					`),
 				},
@@ -4332,7 +4886,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "There was an internal server error. Required upstream data not found in session."
+							"error_description": "There was an internal server error. Required upstream data not found in session.",
+							"error_details":     {"code": "PINNIPED_SESSION_DATA_MISSING"}
 						}
 					`),
 				},
@@ -4373,7 +4928,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "There was an internal server error. Required upstream data not found in session."
+							"error_description": "There was an internal server error. Required upstream data not found in session.",
+							"error_details":     {"code": "PINNIPED_SESSION_DATA_MISSING"}
 						}
 					`),
 				},
@@ -4414,7 +4970,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "There was an internal server error. Required upstream data not found in session."
+							"error_description": "There was an internal server error. Required upstream data not found in session.",
+							"error_details":     {"code": "PINNIPED_SESSION_DATA_MISSING"}
 						}
 					`),
 				},
@@ -4437,7 +4994,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "Error during upstream refresh. Upstream refresh failed."
+							"error_description": "Error during upstream refresh. Upstream refresh failed.",
+							"error_details":     {"code": "PINNIPED_UPSTREAM_REFRESH_FAILED"}
 						}
 					`),
 				},
@@ -4466,7 +5024,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "Error during upstream refresh. Upstream refresh failed."
+							"error_description": "Error during upstream refresh. Upstream refresh failed.",
+							"error_details":     {"code": "PINNIPED_UPSTREAM_REFRESH_FAILED"}
 						}
 					`),
 				},
@@ -4482,7 +5041,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "Error during upstream refresh. Provider from upstream session data was not found."
+							"error_description": "Error during upstream refresh. Provider from upstream session data was not found.",
+							"error_details":     {"code": "PINNIPED_IDP_NOT_READY"}
 						}
 					`),
 				},
@@ -4504,7 +5064,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "Error during upstream refresh. Provider from upstream session data was not found."
+							"error_description": "Error during upstream refresh. Provider from upstream session data was not found.",
+							"error_details":     {"code": "PINNIPED_IDP_NOT_READY"}
 						}
 					`),
 				},
@@ -4536,7 +5097,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "There was an internal server error. Required upstream data not found in session."
+							"error_description": "There was an internal server error. Required upstream data not found in session.",
+							"error_details":     {"code": "PINNIPED_SESSION_DATA_MISSING"}
 						}
 					`),
 				},
@@ -4574,7 +5136,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "There was an internal server error. Required upstream data not found in session."
+							"error_description": "There was an internal server error. Required upstream data not found in session.",
+							"error_details":     {"code": "PINNIPED_SESSION_DATA_MISSING"}
 						}
 					`),
 				},
@@ -4612,7 +5175,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "There was an internal server error. Required upstream data not found in session."
+							"error_description": "There was an internal server error. Required upstream data not found in session.",
+							"error_details":     {"code": "PINNIPED_SESSION_DATA_MISSING"}
 						}
 					`),
 				},
@@ -4633,7 +5197,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "Error during upstream refresh. Provider from upstream session data has changed its resource UID since authentication."
+							"error_description": "Error during upstream refresh. Provider from upstream session data has changed its resource UID since authentication.",
+							"error_details":     {"code": "PINNIPED_IDP_CHANGED"}
 						}
 					`),
 				},
@@ -4660,7 +5225,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "Error during upstream refresh. Provider from upstream session data has changed its resource UID since authentication."
+							"error_description": "Error during upstream refresh. Provider from upstream session data has changed its resource UID since authentication.",
+							"error_details":     {"code": "PINNIPED_IDP_CHANGED"}
 						}
 					`),
 				},
@@ -4694,7 +5260,8 @@ func TestRefreshGrant(t *testing.T) {
 					wantErrorResponseBody: here.Doc(`
 						{
 							"error":             "error",
-							"error_description": "There was an internal server error. Required upstream data not found in session."
+							"error_description": "There was an internal server error. Required upstream data not found in session.",
+							"error_details":     {"code": "PINNIPED_SESSION_DATA_MISSING"}
 						}
 					`),
 				},
@@ -4733,7 +5300,7 @@ func TestRefreshGrant(t *testing.T) {
 			}
 
 			reqContextWarningRecorder := &TestWarningRecorder{}
-			reqContext := warning.WithWarningRecorder(context.WithValue(context.Background(), struct{ name string }{name: "test"}, "request-context"), reqContextWarningRecorder)
+			reqContext := warning.WithWarningRecorder(context.WithValue(context.Background(), testidplister.RequestContextKey{}, "request-context"), reqContextWarningRecorder)
 			req := httptest.NewRequest("POST", "/path/shouldn't/matter",
 				happyRefreshRequestBody(firstRefreshToken).ReadCloser()).WithContext(reqContext)
 			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
@@ -4916,6 +5483,13 @@ func exchangeAuthcodeForTokens(
 		oauthHelper,
 		timeoutsConfiguration.OverrideDefaultAccessTokenLifespan,
 		timeoutsConfiguration.OverrideDefaultIDTokenLifespan,
+		NewGroupChangeNotifier(plog.New(), nil),
+		goodIssuer,
+		nil,
+		nil,
+		dpop.NewValidator(clock.RealClock{}),
+		nil,
+		singlesignonstorage.New(secrets, time.Now, timeoutsConfiguration.RefreshTokenLifespan),
 	)
 
 	authorizeEndpointGrantedOpenIDScope := strings.Contains(authRequest.Form.Get("scope"), "openid")
@@ -4984,7 +5558,7 @@ func requireTokenEndpointBehavior(
 		wantRefreshToken := slices.Contains(test.wantSuccessBodyFields, "refresh_token")
 
 		requireInvalidAuthCodeStorage(t, authCode, oauthStore, secrets, requestTime)
-		requireValidAccessTokenStorage(t, parsedResponseBody, oauthStore, test.wantClientID, test.wantRequestedScopes, test.wantGrantedScopes, test.wantUsername, test.wantGroups, test.wantCustomSessionDataStored, test.wantAdditionalClaims, secrets, requestTime)
+		requireValidAccessTokenStorage(t, parsedResponseBody, oauthStore, test.wantClientID, test.wantRequestedScopes, test.wantGrantedScopes, test.wantGrantedAudience, test.wantUsername, test.wantGroups, test.wantCustomSessionDataStored, test.wantAdditionalClaims, secrets, requestTime)
 		requireInvalidPKCEStorage(t, authCode, oauthStore)
 		requireDeletedOIDCStorage(t, authCode, oauthStore) // The OIDC storage was deleted during the authcode exchange.
 
@@ -4996,7 +5570,7 @@ func requireTokenEndpointBehavior(
 			requireValidIDToken(t, parsedResponseBody, jwtSigningKey, test.wantClientID, wantNonceValueInIDToken, test.wantUsername, test.wantGroups, test.wantAdditionalClaims, test.wantIDTokenLifetimeSeconds, parsedResponseBody["access_token"].(string), requestTime)
 		}
 		if wantRefreshToken {
-			requireValidRefreshTokenStorage(t, parsedResponseBody, oauthStore, test.wantClientID, test.wantRequestedScopes, test.wantGrantedScopes, test.wantUsername, test.wantGroups, test.wantCustomSessionDataStored, test.wantAdditionalClaims, secrets, requestTime)
+			requireValidRefreshTokenStorage(t, parsedResponseBody, oauthStore, test.wantClientID, test.wantRequestedScopes, test.wantGrantedScopes, test.wantGrantedAudience, test.wantUsername, test.wantGroups, test.wantCustomSessionDataStored, test.wantAdditionalClaims, secrets, requestTime)
 		}
 
 		testutil.RequireNumberOfSecretsMatchingLabelSelector(t, secrets, labels.Set{crud.SecretLabelKey: authorizationcode.TypeLabelValue}, 1)
@@ -5072,6 +5646,10 @@ func (b body) WithRedirectURI(redirectURI string) body {
 	return b.with("redirect_uri", redirectURI)
 }
 
+func (b body) WithResource(resource string) body {
+	return b.with("resource", resource)
+}
+
 func (b body) WithPKCE(verifier string) body {
 	return b.with("code_verifier", verifier)
 }
@@ -5111,7 +5689,7 @@ func makeHappyOauthHelper(
 	t.Helper()
 
 	jwtSigningKey, jwkProvider := makeJwksSigningKeyAndProvider(t, goodIssuer)
-	oauthHelper := oidc.FositeOauth2Helper(store, goodIssuer, hmacSecretFunc, jwkProvider, oidc.DefaultOIDCTimeoutsConfiguration())
+	oauthHelper := oidc.FositeOauth2Helper(store, goodIssuer, hmacSecretFunc, jwkProvider, oidc.DefaultOIDCTimeoutsConfiguration(), formposthtml.TemplateForContext, nil, nil, nil)
 	authResponder := simulateAuthEndpointHavingAlreadyRun(t, authRequest, oauthHelper, initialCustomSessionData, modifySession)
 	return oauthHelper, authResponder.GetCode(), jwtSigningKey
 }
@@ -5185,6 +5763,9 @@ func simulateAuthEndpointHavingAlreadyRun(
 		session.Fosite.Claims.Extra["groups"] = goodGroups
 	}
 
+	// The authorization endpoint grants the requested RFC 8707 resources.
+	require.NoError(t, resourceindicator.GrantIfRequested(authRequester))
+
 	// The authorization endpoint sets the authorized party to the client ID of the original requester.
 	session.Fosite.Claims.Extra["azp"] = authRequester.GetClient().GetID()
 
@@ -5238,6 +5819,7 @@ func requireValidRefreshTokenStorage(
 	wantClientID string,
 	wantRequestedScopes []string,
 	wantGrantedScopes []string,
+	wantGrantedAudience []string,
 	wantUsername string,
 	wantGroups []string,
 	wantCustomSessionData *psession.CustomSessionData,
@@ -5267,6 +5849,7 @@ func requireValidRefreshTokenStorage(
 		wantClientID,
 		wantRequestedScopes,
 		wantGrantedScopes,
+		wantGrantedAudience,
 		true,
 		wantUsername,
 		wantGroups,
@@ -5285,6 +5868,7 @@ func requireValidAccessTokenStorage(
 	wantClientID string,
 	wantRequestedScopes []string,
 	wantGrantedScopes []string,
+	wantGrantedAudience []string,
 	wantUsername string,
 	wantGroups []string,
 	wantCustomSessionData *psession.CustomSessionData,
@@ -5333,6 +5917,7 @@ func requireValidAccessTokenStorage(
 		wantClientID,
 		wantRequestedScopes,
 		wantGrantedScopes,
+		wantGrantedAudience,
 		true,
 		wantUsername,
 		wantGroups,
@@ -5387,6 +5972,7 @@ func requireValidStoredRequest(
 	wantClientID string,
 	wantRequestedScopes []string,
 	wantGrantedScopes []string,
+	wantGrantedAudience []string,
 	wantAccessTokenExpiresAt bool,
 	wantUsername string,
 	wantGroups []string,
@@ -5403,7 +5989,7 @@ func requireValidStoredRequest(
 	require.Equal(t, fosite.Arguments(wantRequestedScopes), request.GetRequestedScopes())
 	require.Equal(t, fosite.Arguments(wantGrantedScopes), request.GetGrantedScopes())
 	require.Empty(t, request.GetRequestedAudience())
-	require.Empty(t, request.GetGrantedAudience())
+	require.ElementsMatch(t, wantGrantedAudience, request.GetGrantedAudience())
 	require.Equal(t, wantRequestForm, request.GetRequestForm()) // Fosite stores access token request without form
 
 	// Cast session to the type we think it should be.
@@ -5700,6 +6286,22 @@ func (t *TestWarningRecorder) AddWarning(agent, text string) {
 	})
 }
 
+// storeModifiedCopyOfSubjectToken stores a new access token whose session is a copy of the session of the
+// subject_token of the pending request, after applying the given modification, and returns the new access token.
+func storeModifiedCopyOfSubjectToken(t *testing.T, storage *storage.KubeStorage, pendingRequest *http.Request, modify func(request *fosite.Request)) string {
+	t.Helper()
+	parts := strings.Split(pendingRequest.Form.Get("subject_token"), ".")
+	require.Len(t, parts, 2)
+	requester, err := storage.GetAccessTokenSession(context.Background(), parts[1], nil)
+	require.NoError(t, err)
+	request := requester.(*fosite.Request)
+	modify(request)
+	token, signature, err := strategy.NewDynamicOauth2HMACStrategy(&fosite.Config{}, hmacSecretFunc).GenerateAccessToken(context.Background(), request)
+	require.NoError(t, err)
+	require.NoError(t, storage.CreateAccessTokenSession(context.Background(), signature, request))
+	return token
+}
+
 func getSecretNameFromSignature(t *testing.T, signature string, typeLabel string) string {
 	t.Helper()
 	// try to decode base64 signatures to prevent double encoding of binary data
@@ -7,40 +7,74 @@ import (
 	"context"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/ory/fosite"
+	"github.com/ory/fosite/compose"
 	fositeoauth2 "github.com/ory/fosite/handler/oauth2"
 	"github.com/ory/fosite/handler/openid"
+	"github.com/ory/fosite/token/jwt"
 	"github.com/pkg/errors"
 
 	oidcapi "go.pinniped.dev/generated/latest/apis/supervisor/oidc"
+	"go.pinniped.dev/internal/federationdomain/clientregistry"
+	"go.pinniped.dev/internal/federationdomain/clusteraudience"
+	"go.pinniped.dev/internal/federationdomain/dpop"
+	"go.pinniped.dev/internal/federationdomain/resourceindicator"
+	"go.pinniped.dev/internal/federationdomain/workloadidentity"
 	"go.pinniped.dev/internal/psession"
 )
 
 const (
 	tokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token" //nolint:gosec
 	tokenTypeJWT         = "urn:ietf:params:oauth:token-type:jwt"          //nolint:gosec
+
+	// claimActor is the name of the RFC8693 claim which identifies the party which acts on behalf of the subject.
+	claimActor = "act"
 )
 
 type stsParams struct {
-	subjectAccessToken string
-	requestedAudience  string
+	subjectToken      string
+	subjectTokenType  string
+	requestedAudience string
+	actorToken        string
+	actorTokenType    string
+}
+
+// actorIdentity is the downstream identity of the party which acts on behalf of the subject of a token exchange.
+type actorIdentity struct {
+	subject  string
+	username string
+}
+
+// WorkloadAuthenticator authenticates the ServiceAccount tokens which workloads exchange for cluster-scoped ID tokens.
+type WorkloadAuthenticator interface {
+	Authenticate(ctx context.Context, token string) (*workloadidentity.Identity, error)
 }
 
-func HandlerFactory(config fosite.Configurator, storage any, strategy any) any {
-	return &tokenExchangeHandler{
-		idTokenStrategy:     strategy.(openid.OpenIDConnectTokenStrategy),
-		accessTokenStrategy: strategy.(fositeoauth2.AccessTokenStrategy),
-		accessTokenStorage:  storage.(fositeoauth2.AccessTokenStorage),
-		fositeConfig:        config,
+// HandlerFactory returns the factory of the token exchange grant handler. When workloadAuthenticator is not nil,
+// the handler also accepts ServiceAccount tokens as the subject token or actor token, in addition to access tokens.
+// When clusterAudiences is not nil, the requested audience must be allowed by it.
+func HandlerFactory(workloadAuthenticator WorkloadAuthenticator, clusterAudiences *clusteraudience.Registry) compose.Factory {
+	return func(config fosite.Configurator, storage any, strategy any) any {
+		return &tokenExchangeHandler{
+			idTokenStrategy:       strategy.(openid.OpenIDConnectTokenStrategy),
+			accessTokenStrategy:   strategy.(fositeoauth2.AccessTokenStrategy),
+			accessTokenStorage:    storage.(fositeoauth2.AccessTokenStorage),
+			fositeConfig:          config,
+			workloadAuthenticator: workloadAuthenticator,
+			clusterAudiences:      clusterAudiences,
+		}
 	}
 }
 
 type tokenExchangeHandler struct {
-	idTokenStrategy     openid.OpenIDConnectTokenStrategy
-	accessTokenStrategy fositeoauth2.AccessTokenStrategy
-	accessTokenStorage  fositeoauth2.AccessTokenStorage
-	fositeConfig        fosite.Configurator
+	idTokenStrategy       openid.OpenIDConnectTokenStrategy
+	accessTokenStrategy   fositeoauth2.AccessTokenStrategy
+	accessTokenStorage    fositeoauth2.AccessTokenStorage
+	fositeConfig          fosite.Configurator
+	workloadAuthenticator WorkloadAuthenticator
+	clusterAudiences      *clusteraudience.Registry
 }
 
 var _ fosite.TokenEndpointHandler = (*tokenExchangeHandler)(nil)
@@ -64,8 +98,13 @@ func (t *tokenExchangeHandler) PopulateTokenEndpointResponse(ctx context.Context
 		return errors.WithStack(err)
 	}
 
+	// ServiceAccount tokens of workloads are exchanged without any original authorize request.
+	if params.subjectTokenType == tokenTypeJWT {
+		return t.populateWorkloadTokenEndpointResponse(ctx, requester, responder, params)
+	}
+
 	// Validate the incoming access token and lookup the information about the original authorize request.
-	originalRequester, err := t.validateAccessToken(ctx, requester, params.subjectAccessToken)
+	originalRequester, err := t.validateAccessToken(ctx, requester, params.subjectToken, "subject_token")
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -76,6 +115,14 @@ func (t *tokenExchangeHandler) PopulateTokenEndpointResponse(ctx context.Context
 		return errors.WithStack(fosite.ErrInvalidGrant.WithHint("The OAuth 2.0 Client ID from this request does not match the one from the authorize request."))
 	}
 
+	// When the incoming access token is bound to a DPoP key, then this request must have a DPoP proof signed by that key.
+	// The token endpoint validated the proof of this request, and put the thumbprint of its key onto the context.
+	if pSession, ok := originalRequester.GetSession().(*psession.PinnipedSession); ok {
+		if err := dpop.CheckBinding(pSession, dpop.ThumbprintFromContext(ctx)); err != nil {
+			return err
+		}
+	}
+
 	// Check that the client is allowed to perform this grant type.
 	if !requester.GetClient().GetGrantTypes().Has(oidcapi.GrantTypeTokenExchange) {
 		// This error message is trying to be similar to the analogous one in fosite's flow_authorize_code_token.go.
@@ -90,13 +137,72 @@ func (t *tokenExchangeHandler) PopulateTokenEndpointResponse(ctx context.Context
 		return errors.WithStack(fosite.ErrAccessDenied.WithHintf("Missing the %q scope.", oidcapi.ScopeOpenID))
 	}
 
+	// When resources were granted at the authorization endpoint, then the access token is restricted to those resources.
+	if granted := originalRequester.GetGrantedAudience(); len(granted) > 0 && !granted.Has(params.requestedAudience) {
+		return errors.WithStack(resourceindicator.ErrInvalidTarget().WithHintf(
+			"The requested audience %q is not one of the resources granted to the 'subject_token'.", params.requestedAudience))
+	}
+
 	// Check that the stored session meets the minimum requirements for token exchange.
 	if err := t.validateSession(originalRequester); err != nil {
 		return errors.WithStack(err)
 	}
 
+	// When the client acts on behalf of the user for another party, then that party must be allowed by the client.
+	actor, err := t.authenticateActor(ctx, requester, params)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
 	// Use the original authorize request information, along with the requested audience, to mint a new JWT.
-	responseToken, err := t.mintJWT(ctx, originalRequester, params.requestedAudience)
+	responseToken, err := t.mintJWT(ctx, originalRequester, params.requestedAudience, actor)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Format the response parameters according to RFC8693.
+	responder.SetAccessToken(responseToken)
+	responder.SetTokenType("N_A")
+	responder.SetExtra("issued_token_type", tokenTypeJWT)
+	return nil
+}
+
+// populateWorkloadTokenEndpointResponse exchanges the ServiceAccount token of a workload for a cluster-scoped ID token
+// which has the downstream identity of the ServiceAccount as its username and groups.
+func (t *tokenExchangeHandler) populateWorkloadTokenEndpointResponse(ctx context.Context, requester fosite.AccessRequester, responder fosite.AccessResponder, params *stsParams) error {
+	// Check that the client is allowed to perform this grant type.
+	if !requester.GetClient().GetGrantTypes().Has(oidcapi.GrantTypeTokenExchange) {
+		return errors.WithStack(fosite.ErrUnauthorizedClient.WithHintf(`The OAuth 2.0 Client is not allowed to use token exchange grant "%s".`, oidcapi.GrantTypeTokenExchange))
+	}
+
+	identity, err := t.workloadAuthenticator.Authenticate(ctx, params.subjectToken)
+	if err != nil {
+		return errors.WithStack(fosite.ErrRequestUnauthorized.WithWrap(err).WithHint("Invalid 'subject_token' parameter value."))
+	}
+
+	actor, err := t.authenticateActor(ctx, requester, params)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	now := time.Now().UTC()
+	workloadSession := &psession.PinnipedSession{
+		Fosite: &openid.DefaultSession{
+			Claims: &jwt.IDTokenClaims{
+				Subject:     identity.Subject,
+				RequestedAt: now,
+				AuthTime:    now,
+				Extra: map[string]any{
+					oidcapi.IDTokenClaimAuthorizedParty: requester.GetClient().GetID(),
+					oidcapi.IDTokenClaimUsername:        identity.Username,
+					oidcapi.IDTokenClaimGroups:          identity.Groups,
+				},
+			},
+		},
+		Custom: &psession.CustomSessionData{Username: identity.Username},
+	}
+
+	responseToken, err := t.mintJWT(ctx, fosite.NewAccessRequest(workloadSession), params.requestedAudience, actor)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -108,8 +214,75 @@ func (t *tokenExchangeHandler) PopulateTokenEndpointResponse(ctx context.Context
 	return nil
 }
 
-func (t *tokenExchangeHandler) mintJWT(ctx context.Context, requester fosite.Requester, audience string) (string, error) {
-	downscoped := fosite.NewAccessRequest(requester.GetSession())
+// authenticateActor returns the identity of the actor of the token exchange, or nil when the request has no actor_token.
+// The actor must be allowed to act on behalf of the users of the client.
+func (t *tokenExchangeHandler) authenticateActor(ctx context.Context, requester fosite.AccessRequester, params *stsParams) (*actorIdentity, error) {
+	if params.actorToken == "" {
+		return nil, nil
+	}
+
+	var result *actorIdentity
+	if params.actorTokenType == tokenTypeJWT {
+		identity, err := t.workloadAuthenticator.Authenticate(ctx, params.actorToken)
+		if err != nil {
+			return nil, errors.WithStack(fosite.ErrRequestUnauthorized.WithWrap(err).WithHint("Invalid 'actor_token' parameter value."))
+		}
+		result = &actorIdentity{subject: identity.Subject, username: identity.Username}
+	} else {
+		actorRequester, err := t.validateAccessToken(ctx, requester, params.actorToken, "actor_token")
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		// Like the subject token, the actor token must have been issued to the client which performs the token exchange,
+		// so that a client can not use an access token which was issued to another client.
+		if actorRequester.GetClient().GetID() != requester.GetClient().GetID() {
+			return nil, errors.WithStack(fosite.ErrInvalidGrant.WithHint("The OAuth 2.0 Client ID from this request does not match the one of the 'actor_token'."))
+		}
+		// The actor must have consented to token exchanges, and to sharing its username.
+		for _, scope := range []string{oidcapi.ScopeRequestAudience, oidcapi.ScopeUsername} {
+			if !actorRequester.GetGrantedScopes().Has(scope) {
+				return nil, errors.WithStack(fosite.ErrAccessDenied.WithHintf("Missing the %q scope in the 'actor_token'.", scope))
+			}
+		}
+		pSession, ok := actorRequester.GetSession().(*psession.PinnipedSession)
+		if !ok {
+			// This shouldn't really happen.
+			return nil, fosite.ErrServerError.WithHint("Invalid session storage.")
+		}
+		// Like the subject token, an actor token which is bound to a DPoP key requires a proof signed by that key.
+		if err := dpop.CheckBinding(pSession, dpop.ThumbprintFromContext(ctx)); err != nil {
+			return nil, err
+		}
+		username, _ := pSession.IDTokenClaims().Extra[oidcapi.IDTokenClaimUsername].(string)
+		if username == "" {
+			return nil, fosite.ErrAccessDenied.WithHintf("No username found in the session of the 'actor_token'. Ensure that the %q scope was requested and granted at the authorization endpoint.", oidcapi.ScopeUsername)
+		}
+		result = &actorIdentity{subject: pSession.IDTokenClaims().Subject, username: username}
+	}
+
+	client, ok := requester.GetClient().(*clientregistry.Client)
+	if !ok {
+		// This shouldn't really happen.
+		return nil, fosite.ErrServerError.WithHint("Invalid client.")
+	}
+	if err := client.CheckActorPolicy(result.username); err != nil {
+		return nil, errors.WithStack(fosite.ErrAccessDenied.WithHint("The actor may not act on behalf of the users of this client.").WithDebug(err.Error()))
+	}
+	return result, nil
+}
+
+func (t *tokenExchangeHandler) mintJWT(ctx context.Context, requester fosite.Requester, audience string, actor *actorIdentity) (string, error) {
+	session := requester.GetSession()
+	if actor != nil {
+		// Add the act claim to a copy, so that the session of the subject token is not changed.
+		session = session.Clone()
+		session.(openid.Session).IDTokenClaims().Extra[claimActor] = map[string]any{
+			oidcapi.IDTokenClaimSubject:  actor.subject,
+			oidcapi.IDTokenClaimUsername: actor.username,
+		}
+	}
+
+	downscoped := fosite.NewAccessRequest(session)
 	downscoped.Client.(*fosite.DefaultClient).ID = audience
 
 	// Note: if we wanted to support clients with custom token lifespans, then we would need to call
@@ -138,30 +311,65 @@ func (t *tokenExchangeHandler) validateSession(requester fosite.Requester) error
 func (t *tokenExchangeHandler) validateParams(params url.Values) (*stsParams, error) {
 	var result stsParams
 
-	// Validate some required parameters.
+	// Validate some required parameters. An RFC 8707 resource may be used instead of, or in addition to, the audience.
 	result.requestedAudience = params.Get("audience")
+	resources := params[resourceindicator.ParamName]
+	if len(resources) > 1 {
+		return nil, errors.WithStack(resourceindicator.ErrInvalidTarget().WithHint("Only one 'resource' parameter is supported."))
+	}
+	if len(resources) == 1 {
+		if err := resourceindicator.ValidateURI(resources[0]); err != nil {
+			return nil, err
+		}
+		if result.requestedAudience == "" {
+			result.requestedAudience = resources[0]
+		} else if result.requestedAudience != resources[0] {
+			return nil, errors.WithStack(resourceindicator.ErrInvalidTarget().WithHint("The 'resource' and 'audience' parameters must be equal when both are provided."))
+		}
+	}
 	if result.requestedAudience == "" {
 		return nil, fosite.ErrInvalidRequest.WithHint("Missing 'audience' parameter.")
 	}
-	result.subjectAccessToken = params.Get("subject_token")
-	if result.subjectAccessToken == "" {
+	result.subjectToken = params.Get("subject_token")
+	if result.subjectToken == "" {
 		return nil, fosite.ErrInvalidRequest.WithHint("Missing 'subject_token' parameter.")
 	}
 
-	// Validate some parameters with hardcoded values we support.
-	if params.Get("subject_token_type") != tokenTypeAccessToken {
+	// Validate some parameters with hardcoded values we support. ServiceAccount tokens are JWTs, and are only
+	// supported when workload identity is enabled.
+	result.subjectTokenType = params.Get("subject_token_type")
+	switch {
+	case result.subjectTokenType == tokenTypeAccessToken:
+	case result.subjectTokenType == tokenTypeJWT && t.workloadAuthenticator != nil:
+	case t.workloadAuthenticator != nil:
+		return nil, fosite.ErrInvalidRequest.WithHintf("Unsupported 'subject_token_type' parameter value, must be %q or %q.", tokenTypeAccessToken, tokenTypeJWT)
+	default:
 		return nil, fosite.ErrInvalidRequest.WithHintf("Unsupported 'subject_token_type' parameter value, must be %q.", tokenTypeAccessToken)
 	}
 	if params.Get("requested_token_type") != tokenTypeJWT {
 		return nil, fosite.ErrInvalidRequest.WithHintf("Unsupported 'requested_token_type' parameter value, must be %q.", tokenTypeJWT)
 	}
 
+	// An actor is optional. When the actor_token is sent, then the actor_token_type is required, and vice versa.
+	result.actorToken = params.Get("actor_token")
+	result.actorTokenType = params.Get("actor_token_type")
+	switch {
+	case result.actorToken == "" && result.actorTokenType == "":
+	case result.actorToken == "":
+		return nil, fosite.ErrInvalidRequest.WithHint("Missing 'actor_token' parameter.")
+	case result.actorTokenType == "":
+		return nil, fosite.ErrInvalidRequest.WithHint("Missing 'actor_token_type' parameter.")
+	case result.actorTokenType == tokenTypeAccessToken:
+	case result.actorTokenType == tokenTypeJWT && t.workloadAuthenticator != nil:
+	case t.workloadAuthenticator != nil:
+		return nil, fosite.ErrInvalidRequest.WithHintf("Unsupported 'actor_token_type' parameter value, must be %q or %q.", tokenTypeAccessToken, tokenTypeJWT)
+	default:
+		return nil, fosite.ErrInvalidRequest.WithHintf("Unsupported 'actor_token_type' parameter value, must be %q.", tokenTypeAccessToken)
+	}
+
 	// Validate that none of these unsupported parameters were sent. These are optional and we do not currently support them.
 	for _, param := range []string{
-		"resource",
 		"scope",
-		"actor_token",
-		"actor_token_type",
 	} {
 		if params.Get(param) != "" {
 			return nil, fosite.ErrInvalidRequest.WithHintf("Unsupported parameter %q.", param)
@@ -186,16 +394,26 @@ func (t *tokenExchangeHandler) validateParams(params url.Values) (*stsParams, er
 		return nil, fosite.ErrInvalidRequest.WithHintf("requested audience cannot equal '%s'", oidcapi.ClientIDPinnipedCLI)
 	}
 
+	// Once an admin registered the audiences of the clusters using ClusterAudiences, only those may be requested.
+	allowed, err := t.clusterAudiences.Allows(result.requestedAudience)
+	if err != nil {
+		return nil, errors.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+	if !allowed {
+		return nil, errors.WithStack(resourceindicator.ErrInvalidTarget().WithHintf(
+			"The requested audience %q is not the audience of any ClusterAudience.", result.requestedAudience))
+	}
+
 	return &result, nil
 }
 
-func (t *tokenExchangeHandler) validateAccessToken(ctx context.Context, requester fosite.AccessRequester, accessToken string) (fosite.Requester, error) {
+func (t *tokenExchangeHandler) validateAccessToken(ctx context.Context, requester fosite.AccessRequester, accessToken string, paramName string) (fosite.Requester, error) {
 	// Look up the access token's stored session data.
 	signature := t.accessTokenStrategy.AccessTokenSignature(ctx, accessToken)
 	originalRequester, err := t.accessTokenStorage.GetAccessTokenSession(ctx, signature, requester.GetSession())
 	if err != nil {
 		// The access token was not found, or there was some other error while reading it.
-		return nil, fosite.ErrRequestUnauthorized.WithWrap(err).WithHint("Invalid 'subject_token' parameter value.")
+		return nil, fosite.ErrRequestUnauthorized.WithWrap(err).WithHintf("Invalid '%s' parameter value.", paramName)
 	}
 	// Validate the access token using its stored session data, which includes its expiration time.
 	if err := t.accessTokenStrategy.ValidateAccessToken(ctx, originalRequester, accessToken); err != nil {
@@ -4,73 +4,214 @@
 package endpointsmanager
 
 import (
+	"net"
 	"net/http"
+	"reflect"
 	"strings"
 	"sync"
+	"time"
 
+	"k8s.io/apiserver/pkg/server/healthz"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/utils/clock"
 
 	"go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/typed/config/v1alpha1"
+	"go.pinniped.dev/internal/federationdomain/accesslog"
+	"go.pinniped.dev/internal/federationdomain/branding"
+	"go.pinniped.dev/internal/federationdomain/clusteraudience"
+	"go.pinniped.dev/internal/federationdomain/cors"
 	"go.pinniped.dev/internal/federationdomain/csrftoken"
+	"go.pinniped.dev/internal/federationdomain/dpop"
 	"go.pinniped.dev/internal/federationdomain/dynamiccodec"
 	"go.pinniped.dev/internal/federationdomain/endpoints/auth"
 	"go.pinniped.dev/internal/federationdomain/endpoints/callback"
 	"go.pinniped.dev/internal/federationdomain/endpoints/chooseidp"
+	"go.pinniped.dev/internal/federationdomain/endpoints/clusteraudiencediscovery"
+	"go.pinniped.dev/internal/federationdomain/endpoints/consent"
 	"go.pinniped.dev/internal/federationdomain/endpoints/discovery"
 	"go.pinniped.dev/internal/federationdomain/endpoints/idpdiscovery"
 	"go.pinniped.dev/internal/federationdomain/endpoints/jwks"
 	"go.pinniped.dev/internal/federationdomain/endpoints/login"
+	"go.pinniped.dev/internal/federationdomain/endpoints/par"
 	"go.pinniped.dev/internal/federationdomain/endpoints/token"
+	"go.pinniped.dev/internal/federationdomain/endpoints/tokenexchange"
 	"go.pinniped.dev/internal/federationdomain/federationdomainproviders"
+	"go.pinniped.dev/internal/federationdomain/forcedreauth"
+	"go.pinniped.dev/internal/federationdomain/formposthtml"
 	"go.pinniped.dev/internal/federationdomain/idplister"
+	"go.pinniped.dev/internal/federationdomain/loadshed"
+	"go.pinniped.dev/internal/federationdomain/loginerrors"
+	"go.pinniped.dev/internal/federationdomain/loginthrottle"
 	"go.pinniped.dev/internal/federationdomain/oidc"
 	"go.pinniped.dev/internal/federationdomain/oidcclientvalidator"
+	"go.pinniped.dev/internal/federationdomain/secondfactor"
+	"go.pinniped.dev/internal/federationdomain/singlesignon"
 	"go.pinniped.dev/internal/federationdomain/storage"
+	"go.pinniped.dev/internal/federationdomain/timeouts"
+	"go.pinniped.dev/internal/federationdomain/tokenenrichment"
+	"go.pinniped.dev/internal/federationdomain/workloadidentity"
+	consentstorage "go.pinniped.dev/internal/fositestorage/consent"
+	"go.pinniped.dev/internal/fositestorage/pushedauthorizerequest"
+	singlesignonstorage "go.pinniped.dev/internal/fositestorage/singlesignon"
+	"go.pinniped.dev/internal/healthcheck"
 	"go.pinniped.dev/internal/httputil/requestutil"
+	"go.pinniped.dev/internal/i18n"
+	"go.pinniped.dev/internal/lastauth"
 	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/internal/psession"
 	"go.pinniped.dev/internal/secret"
+	"go.pinniped.dev/internal/totpsecretstorage"
+	"go.pinniped.dev/internal/webauthn"
+	"go.pinniped.dev/internal/webauthncredentialstorage"
 	"go.pinniped.dev/pkg/oidcclient/nonce"
 	"go.pinniped.dev/pkg/oidcclient/pkce"
 )
 
+// federationDomainEndpointPaths are the paths of all endpoints of each FederationDomain, relative to its issuer.
+//
+//nolint:gochecknoglobals // This is effectively a constant.
+var federationDomainEndpointPaths = []string{
+	oidc.WellKnownEndpointPath,
+	oidc.JWKSEndpointPath,
+	branding.StylesheetPath,
+	branding.LogoPath,
+	oidc.PinnipedIDPsPathV1Alpha1,
+	oidc.PinnipedClusterAudiencesPathV1Alpha1,
+	oidc.AuthorizationEndpointPath,
+	oidc.CallbackEndpointPath,
+	oidc.ChooseIDPEndpointPath,
+	oidc.TokenEndpointPath,
+	oidc.PushedAuthorizeEndpointPath,
+	oidc.PinnipedLoginPath,
+	oidc.ConsentEndpointPath,
+}
+
+// loginEndpointPaths are the paths of the endpoints of each FederationDomain which take part in logins, relative to
+// its issuer. Their errors are shown on the status page.
+//
+//nolint:gochecknoglobals // This is effectively a constant.
+var loginEndpointPaths = []string{
+	oidc.AuthorizationEndpointPath,
+	oidc.CallbackEndpointPath,
+	oidc.ChooseIDPEndpointPath,
+	oidc.TokenEndpointPath,
+	oidc.PushedAuthorizeEndpointPath,
+	oidc.PinnipedLoginPath,
+	oidc.ConsentEndpointPath,
+}
+
+// previousIssuerEndpointPaths are the paths of the endpoints which are still served by the previous issuer of
+// a FederationDomain which is migrating to a new issuer, relative to the previous issuer.
+//
+//nolint:gochecknoglobals // This is effectively a constant.
+var previousIssuerEndpointPaths = []string{
+	oidc.WellKnownEndpointPath,
+	oidc.JWKSEndpointPath,
+	oidc.PinnipedIDPsPathV1Alpha1,
+	oidc.TokenEndpointPath,
+}
+
+// corsEndpointMethods are the methods of the endpoints of each FederationDomain which browser-based clients may call
+// from other origins, by their paths relative to the issuer.
+//
+//nolint:gochecknoglobals // This is effectively a constant.
+var corsEndpointMethods = map[string][]string{
+	oidc.WellKnownEndpointPath: {http.MethodGet},
+	oidc.JWKSEndpointPath:      {http.MethodGet},
+	oidc.TokenEndpointPath:     {http.MethodPost},
+}
+
 // Manager can manage multiple active OIDC providers. It acts as a request router for them.
 //
 // It is thread-safe.
 type Manager struct {
-	mu                  sync.RWMutex
-	providers           []*federationdomainproviders.FederationDomainIssuer
-	providerHandlers    map[string]http.Handler                   // map of all routes for all providers
-	nextHandler         http.Handler                              // the next handler in a chain, called when this manager didn't know how to handle a request
-	dynamicJWKSProvider jwks.DynamicJWKSProvider                  // in-memory cache of per-issuer JWKS data
-	upstreamIDPs        idplister.UpstreamIdentityProvidersLister // in-memory cache of upstream IDPs
-	secretCache         *secret.Cache                             // in-memory cache of cryptographic material
-	secretsClient       corev1client.SecretInterface
-	oidcClientsClient   v1alpha1.OIDCClientInterface
+	mu                      sync.RWMutex
+	providers               []*federationdomainproviders.FederationDomainIssuer
+	providerHandlers        map[string]http.Handler                                                               // map of all routes for all providers
+	providerListeners       map[string]string                                                                     // map of all routes to the listener which serves them
+	idpListersByServerName  map[string][]*federationdomainproviders.FederationDomainIdentityProvidersListerFinder // the IDPs of the issuers at each TLS server name
+	nextHandler             http.Handler                                                                          // the next handler in a chain, called when this manager didn't know how to handle a request
+	dynamicJWKSProvider     jwks.DynamicJWKSProvider                                                              // in-memory cache of per-issuer JWKS data
+	dynamicBrandingProvider branding.DynamicBrandingProvider                                                      // in-memory cache of per-issuer branding
+	upstreamIDPs            idplister.UpstreamIdentityProvidersLister                                             // in-memory cache of upstream IDPs
+	secretCache             *secret.Cache                                                                         // in-memory cache of cryptographic material
+	secretsClient           corev1client.SecretInterface
+	oidcClientsClient       v1alpha1.OIDCClientInterface
+	groupChangeNotifier     *token.GroupChangeNotifier          // emits events for group membership changes found during refresh
+	loginThrottles          map[string]*loginthrottle.Throttle  // per-issuer login throttles, kept across updates
+	tokenEndpointLimiters   map[string]*loadshed.Limiter        // per-issuer token endpoint load shedding, kept across updates
+	tokenEnrichmentWebhooks map[string]*tokenenrichment.Webhook // per-issuer token enrichment webhooks, kept across updates
+	accessLogger            *accesslog.Logger                   // writes access logs for the issuers which enable them
+	forcedReauthChecker     *forcedreauth.Checker               // finds the sessions which admins require to log in again
+	dpopValidator           *dpop.Validator                     // validates DPoP proofs and remembers them to detect replays, kept across updates
+	loginErrors             *loginerrors.Recorder               // remembers the recent errors of the login endpoints for the status page
+	workloadIdentity        *workloadidentity.Validator         // validates the ServiceAccount tokens of workloads, shared by all issuers
+	clusterAudiences        *clusteraudience.Registry           // the audiences which may be requested using token exchange, shared by all issuers
+	lastClientAuths         *lastauth.Recorder[string]          // remembers the most recent successful token requests of each OIDCClient for its status
+	loaded                  *healthcheck.Latch                  // passes once the FederationDomains have been set for the first time
 }
 
 // NewManager returns an empty Manager.
 // nextHandler will be invoked for any requests that could not be handled by this manager's providers.
 // dynamicJWKSProvider will be used as an in-memory cache for per-issuer JWKS data.
+// dynamicBrandingProvider will be used as an in-memory cache for per-issuer branding of the web pages.
 // upstreamIDPs will be used as an in-memory cache of currently configured upstream IDPs.
+// sensitiveGroups lists the downstream group names whose membership changes should be reported at a higher severity.
+// accessLogger will write the access logs of the issuers which enable them.
+// forcedReauthChecker will be used to refuse refreshes of the sessions which admins require to log in again.
+// loginErrors will remember the recent errors of the login endpoints, and may be nil.
+// workloadIdentity will validate the ServiceAccount tokens of workloads for the issuers which accept them, and may be
+// nil, in which case no issuer accepts them.
+// clusterAudiences will restrict the audiences which may be requested using token exchange, and may be nil, in which
+// case any audience which is not reserved may be requested.
 func NewManager(
 	nextHandler http.Handler,
 	dynamicJWKSProvider jwks.DynamicJWKSProvider,
+	dynamicBrandingProvider branding.DynamicBrandingProvider,
 	upstreamIDPs idplister.UpstreamIdentityProvidersLister,
 	secretCache *secret.Cache,
 	secretsClient corev1client.SecretInterface,
 	oidcClientsClient v1alpha1.OIDCClientInterface,
+	sensitiveGroups []string,
+	accessLogger *accesslog.Logger,
+	forcedReauthChecker *forcedreauth.Checker,
+	loginErrors *loginerrors.Recorder,
+	workloadIdentity *workloadidentity.Validator,
+	clusterAudiences *clusteraudience.Registry,
+	lastClientAuths *lastauth.Recorder[string],
 ) *Manager {
 	return &Manager{
-		providerHandlers:    make(map[string]http.Handler),
-		nextHandler:         nextHandler,
-		dynamicJWKSProvider: dynamicJWKSProvider,
-		upstreamIDPs:        upstreamIDPs,
-		secretCache:         secretCache,
-		secretsClient:       secretsClient,
-		oidcClientsClient:   oidcClientsClient,
+		providerHandlers:        make(map[string]http.Handler),
+		providerListeners:       make(map[string]string),
+		idpListersByServerName:  make(map[string][]*federationdomainproviders.FederationDomainIdentityProvidersListerFinder),
+		nextHandler:             nextHandler,
+		dynamicJWKSProvider:     dynamicJWKSProvider,
+		dynamicBrandingProvider: dynamicBrandingProvider,
+		upstreamIDPs:            upstreamIDPs,
+		secretCache:             secretCache,
+		secretsClient:           secretsClient,
+		oidcClientsClient:       oidcClientsClient,
+		groupChangeNotifier:     token.NewGroupChangeNotifier(plog.New(), sensitiveGroups),
+		loginThrottles:          make(map[string]*loginthrottle.Throttle),
+		tokenEndpointLimiters:   make(map[string]*loadshed.Limiter),
+		tokenEnrichmentWebhooks: make(map[string]*tokenenrichment.Webhook),
+		accessLogger:            accessLogger,
+		forcedReauthChecker:     forcedReauthChecker,
+		dpopValidator:           dpop.NewValidator(clock.RealClock{}),
+		loginErrors:             loginErrors,
+		workloadIdentity:        workloadIdentity,
+		clusterAudiences:        clusterAudiences,
+		lastClientAuths:         lastClientAuths,
+		loaded:                  healthcheck.NewLatch("federation-domains", "FederationDomains have not been loaded yet"),
 	}
 }
 
+// ReadyzCheck returns a health check which fails until SetFederationDomains has been called for the first time,
+// so that a new process does not receive requests for the issuers before it can serve them.
+func (m *Manager) ReadyzCheck() healthz.HealthChecker {
+	return m.loaded
+}
+
 // SetFederationDomains adds or updates all the given providerHandlers using each provider's issuer string
 // as the name of the provider to decide if it is an add or update operation.
 //
@@ -85,6 +226,14 @@ func (m *Manager) SetFederationDomains(federationDomains ...*federationdomainpro
 
 	m.providers = federationDomains
 	m.providerHandlers = make(map[string]http.Handler)
+	m.providerListeners = make(map[string]string)
+	m.idpListersByServerName = make(map[string][]*federationdomainproviders.FederationDomainIdentityProvidersListerFinder)
+	previousLoginThrottles := m.loginThrottles
+	m.loginThrottles = make(map[string]*loginthrottle.Throttle)
+	previousTokenEndpointLimiters := m.tokenEndpointLimiters
+	m.tokenEndpointLimiters = make(map[string]*loadshed.Limiter)
+	previousTokenEnrichmentWebhooks := m.tokenEnrichmentWebhooks
+	m.tokenEnrichmentWebhooks = make(map[string]*tokenenrichment.Webhook)
 
 	csrfCookieEncoder := dynamiccodec.New(
 		oidc.CSRFCookieLifespan,
@@ -100,6 +249,15 @@ func (m *Manager) SetFederationDomains(federationDomains ...*federationdomainpro
 
 		timeoutsConfiguration := oidc.DefaultOIDCTimeoutsConfiguration()
 
+		// Only accept the ServiceAccount tokens of workloads when the FederationDomain enables workload identity.
+		var workloadAuthenticator tokenexchange.WorkloadAuthenticator
+		if workloadIdentityConfig := incomingFederationDomain.WorkloadIdentity(); workloadIdentityConfig != nil && m.workloadIdentity != nil {
+			workloadAuthenticator = workloadidentity.NewAuthenticator(m.workloadIdentity, issuerURL, *workloadIdentityConfig)
+		}
+
+		getBranding := func() *branding.Branding { return m.dynamicBrandingProvider.GetBranding(issuerURL) }
+		formPostHTMLTemplate := formposthtml.TemplateWithBranding(incomingFederationDomain.IssuerPath(), getBranding)
+
 		// Use NullStorage for the authorize endpoint because we do not actually want to store anything until
 		// the upstream callback endpoint is called later.
 		oauthHelperWithNullStorage := oidc.FositeOauth2Helper(
@@ -108,6 +266,10 @@ func (m *Manager) SetFederationDomains(federationDomains ...*federationdomainpro
 			tokenHMACKeyGetter,
 			nil,
 			timeoutsConfiguration,
+			formPostHTMLTemplate,
+			incomingFederationDomain.JWTAccessTokens(),
+			workloadAuthenticator,
+			m.clusterAudiences,
 		)
 
 		// For all the other endpoints, make another oauth helper with exactly the same settings except use real storage.
@@ -117,6 +279,10 @@ func (m *Manager) SetFederationDomains(federationDomains ...*federationdomainpro
 			tokenHMACKeyGetter,
 			m.dynamicJWKSProvider,
 			timeoutsConfiguration,
+			formPostHTMLTemplate,
+			incomingFederationDomain.JWTAccessTokens(),
+			workloadAuthenticator,
+			m.clusterAudiences,
 		)
 
 		upstreamStateEncoder := dynamiccodec.New(
@@ -126,13 +292,104 @@ func (m *Manager) SetFederationDomains(federationDomains ...*federationdomainpro
 		)
 
 		idpLister := federationdomainproviders.NewFederationDomainIdentityProvidersListerFinder(incomingFederationDomain, m.upstreamIDPs)
+		serverName := tlsServerName(incomingFederationDomain.IssuerHost())
+		m.idpListersByServerName[serverName] = append(m.idpListersByServerName[serverName], idpLister)
+
+		pushedAuthorizeRequests := pushedauthorizerequest.New(m.secretsClient, time.Now)
+
+		consentStorage := consentstorage.New(m.secretsClient, time.Now)
+		consentPrompter := consent.NewPrompter(issuerURL, consentStorage, consent.GeneratePendingRequestID, time.Now)
+
+		// The secrets are encrypted using a key derived from the Supervisor's own key, which is not specific to
+		// any FederationDomain, since the enrollments of users belong to the identity providers.
+		totpVerifier := secondfactor.NewTOTPVerifier(
+			totpsecretstorage.New(m.secretsClient, m.secretCache.GetCSRFCookieEncoderHashKey),
+			time.Now,
+		)
+
+		// The challenges of the login pages are encrypted like the upstream state param, but live long enough
+		// for the users to find their authenticators.
+		var webAuthn *secondfactor.WebAuthnVerifier
+		if webAuthnConfig := incomingFederationDomain.WebAuthn(); webAuthnConfig != nil {
+			relyingParty, err := webauthn.NewRelyingParty(issuerURL)
+			if err != nil {
+				// Do not serve this issuer at all, rather than letting its users log in without their credentials.
+				plog.Error("could not configure WebAuthn for issuer", err, "issuer", issuerURL)
+				continue
+			}
+			webAuthn = secondfactor.NewWebAuthnVerifier(
+				*webAuthnConfig,
+				relyingParty,
+				webauthncredentialstorage.New(m.secretsClient),
+				dynamiccodec.New(
+					secondfactor.WebAuthnChallengeLifetime,
+					wrapGetter(incomingFederationDomain.Issuer(), m.secretCache.GetStateEncoderHashKey),
+					wrapGetter(incomingFederationDomain.Issuer(), m.secretCache.GetStateEncoderBlockKey),
+				),
+				time.Now,
+			)
+		}
+
+		// The token endpoint needs the single sign-on sessions even when single sign-on is disabled, because the
+		// downstream sessions which were started with it keep sharing their upstream refresh tokens.
+		singleSignOnStorage := newSingleSignOnStorage(m.secretsClient, timeoutsConfiguration)
+		var singleSignOn *singlesignon.Sessions
+		if singleSignOnConfig := incomingFederationDomain.SingleSignOn(); singleSignOnConfig != nil {
+			singleSignOn = singlesignon.New(
+				incomingFederationDomain.IssuerPath(),
+				*singleSignOnConfig,
+				singleSignOnStorage,
+				csrfCookieEncoder,
+				m.forcedReauthChecker,
+				singlesignon.GenerateSessionID,
+				time.Now,
+			)
+		}
+
+		// Keep the previous throttle for this issuer when its settings did not change, so that the counts of
+		// requests and failed logins are not reset every time any FederationDomain is updated.
+		var loginThrottle *loginthrottle.Throttle
+		if loginRateLimits := incomingFederationDomain.LoginRateLimits(); loginRateLimits != nil {
+			loginThrottle = previousLoginThrottles[issuerURL]
+			if loginThrottle == nil || !reflect.DeepEqual(loginThrottle.Config(), *loginRateLimits) {
+				loginThrottle = loginthrottle.New(issuerURL, *loginRateLimits, clock.RealClock{}, plog.New())
+			}
+			m.loginThrottles[issuerURL] = loginThrottle
+		}
 
-		m.providerHandlers[(issuerHostWithPath + oidc.WellKnownEndpointPath)] = discovery.NewHandler(issuerURL)
+		// Likewise keep the previous token endpoint limiter for this issuer, so that the requests which are already
+		// being processed or queued still count towards the limit.
+		var tokenEndpointLimiter *loadshed.Limiter
+		if loadShedding := incomingFederationDomain.TokenEndpointLoadShedding(); loadShedding != nil {
+			tokenEndpointLimiter = previousTokenEndpointLimiters[issuerURL]
+			if tokenEndpointLimiter == nil || tokenEndpointLimiter.Config() != *loadShedding {
+				tokenEndpointLimiter = loadshed.New(issuerURL, *loadShedding, clock.RealClock{}, plog.New())
+			}
+			m.tokenEndpointLimiters[issuerURL] = tokenEndpointLimiter
+		}
+
+		// Likewise keep the previous webhook for this issuer, so its HTTP client can reuse its connections.
+		var tokenEnrichmentWebhook *tokenenrichment.Webhook
+		if webhookConfig := incomingFederationDomain.TokenEnrichmentWebhook(); webhookConfig != nil {
+			tokenEnrichmentWebhook = previousTokenEnrichmentWebhooks[issuerURL]
+			if tokenEnrichmentWebhook == nil || tokenEnrichmentWebhook.Config() != *webhookConfig {
+				tokenEnrichmentWebhook = tokenenrichment.New(*webhookConfig, plog.New())
+			}
+			m.tokenEnrichmentWebhooks[issuerURL] = tokenEnrichmentWebhook
+		}
+
+		m.providerHandlers[(issuerHostWithPath + oidc.WellKnownEndpointPath)] = discovery.NewHandler(issuerURL, incomingFederationDomain.SigningAlgorithm())
 
 		m.providerHandlers[(issuerHostWithPath + oidc.JWKSEndpointPath)] = jwks.NewHandler(issuerURL, m.dynamicJWKSProvider)
 
+		m.providerHandlers[(issuerHostWithPath + branding.StylesheetPath)] = branding.NewStylesheetHandler(issuerURL, m.dynamicBrandingProvider)
+
+		m.providerHandlers[(issuerHostWithPath + branding.LogoPath)] = branding.NewLogoHandler(issuerURL, m.dynamicBrandingProvider)
+
 		m.providerHandlers[(issuerHostWithPath + oidc.PinnipedIDPsPathV1Alpha1)] = idpdiscovery.NewHandler(idpLister)
 
+		m.providerHandlers[(issuerHostWithPath + oidc.PinnipedClusterAudiencesPathV1Alpha1)] = clusteraudiencediscovery.NewHandler(m.clusterAudiences)
+
 		m.providerHandlers[(issuerHostWithPath + oidc.AuthorizationEndpointPath)] = auth.NewHandler(
 			issuerURL,
 			idpLister,
@@ -143,6 +400,11 @@ func (m *Manager) SetFederationDomains(federationDomains ...*federationdomainpro
 			nonce.Generate,
 			upstreamStateEncoder,
 			csrfCookieEncoder,
+			pushedAuthorizeRequests,
+			loginThrottle,
+			singleSignOn,
+			consentPrompter,
+			webAuthn,
 		)
 
 		m.providerHandlers[(issuerHostWithPath + oidc.CallbackEndpointPath)] = callback.NewHandler(
@@ -151,11 +413,14 @@ func (m *Manager) SetFederationDomains(federationDomains ...*federationdomainpro
 			upstreamStateEncoder,
 			csrfCookieEncoder,
 			issuerURL+oidc.CallbackEndpointPath,
+			consentPrompter,
+			singleSignOn,
 		)
 
 		m.providerHandlers[(issuerHostWithPath + oidc.ChooseIDPEndpointPath)] = chooseidp.NewHandler(
-			issuerURL+oidc.AuthorizationEndpointPath,
+			issuerURL,
 			idpLister,
+			csrfCookieEncoder,
 		)
 
 		m.providerHandlers[(issuerHostWithPath + oidc.TokenEndpointPath)] = token.NewHandler(
@@ -163,22 +428,176 @@ func (m *Manager) SetFederationDomains(federationDomains ...*federationdomainpro
 			oauthHelperWithKubeStorage,
 			timeoutsConfiguration.OverrideDefaultAccessTokenLifespan,
 			timeoutsConfiguration.OverrideDefaultIDTokenLifespan,
+			m.groupChangeNotifier,
+			issuerURL,
+			tokenEnrichmentWebhook,
+			m.forcedReauthChecker,
+			m.dpopValidator,
+			m.lastClientAuths,
+			singleSignOnStorage,
+		)
+
+		m.providerHandlers[(issuerHostWithPath + oidc.PushedAuthorizeEndpointPath)] = par.NewHandler(
+			oauthHelperWithKubeStorage,
+			pushedAuthorizeRequests,
+			par.GenerateRequestID,
+			time.Now,
 		)
 
 		m.providerHandlers[(issuerHostWithPath + oidc.PinnipedLoginPath)] = login.NewHandler(
 			upstreamStateEncoder,
 			csrfCookieEncoder,
-			login.NewGetHandler(incomingFederationDomain.IssuerPath()+oidc.PinnipedLoginPath),
-			login.NewPostHandler(issuerURL, idpLister, oauthHelperWithKubeStorage),
+			login.NewGetHandler(incomingFederationDomain.IssuerPath()+oidc.PinnipedLoginPath, idpLister, webAuthn, getBranding),
+			login.NewPostHandler(issuerURL, idpLister, oauthHelperWithKubeStorage, consentPrompter, singleSignOn, totpVerifier, webAuthn, getBranding, loginThrottle),
 		)
 
+		m.providerHandlers[(issuerHostWithPath + oidc.ConsentEndpointPath)] = consent.NewHandler(
+			incomingFederationDomain.IssuerPath()+oidc.ConsentEndpointPath,
+			oauthHelperWithKubeStorage,
+			consentStorage,
+			csrfCookieEncoder,
+			getBranding,
+		)
+
+		if tokenEndpointLimiter != nil {
+			m.providerHandlers[issuerHostWithPath+oidc.TokenEndpointPath] = tokenEndpointLimiter.WrapHandler(m.providerHandlers[issuerHostWithPath+oidc.TokenEndpointPath])
+		}
+
+		// The token endpoint is not throttled, since most of its requests are refreshes and token exchanges rather than
+		// logins, and many clients behind a single NAT or CI runner would otherwise share one source IP's limit.
+		// Its load is limited by the load shedding above instead.
+		if loginThrottle != nil {
+			for _, path := range []string{oidc.AuthorizationEndpointPath, oidc.PushedAuthorizeEndpointPath, oidc.PinnipedLoginPath} {
+				m.providerHandlers[issuerHostWithPath+path] = loginThrottle.WrapHandler(m.providerHandlers[issuerHostWithPath+path])
+			}
+		}
+
+		// Remember the errors of the endpoints which take part in logins, including the requests which were throttled.
+		for _, path := range loginEndpointPaths {
+			m.providerHandlers[issuerHostWithPath+path] = m.loginErrors.WrapHandler(issuerURL, m.providerHandlers[issuerHostWithPath+path])
+		}
+
+		m.wrapCORSHandlers(incomingFederationDomain.CORS(), issuerHostWithPath)
+
+		// Wrap the access log around everything else, so it also records the requests which were throttled.
+		if incomingFederationDomain.AccessLogEnabled() && m.accessLogger != nil {
+			for _, path := range federationDomainEndpointPaths {
+				m.providerHandlers[issuerHostWithPath+path] = m.accessLogger.WrapHandler(issuerURL, m.providerHandlers[issuerHostWithPath+path])
+			}
+		}
+
+		for _, path := range federationDomainEndpointPaths {
+			m.providerListeners[issuerHostWithPath+path] = incomingFederationDomain.Listener()
+		}
+
+		if previousIssuer := incomingFederationDomain.PreviousIssuer(); previousIssuer != nil {
+			m.setPreviousIssuerHandlers(incomingFederationDomain, previousIssuer, idpLister, tokenHMACKeyGetter, tokenEnrichmentWebhook, tokenEndpointLimiter)
+		}
+
 		plog.Debug("oidc provider manager added or updated issuer", "issuer", issuerURL)
 	}
+
+	m.loaded.Set()
 }
 
-// ServeHTTP implements the http.Handler interface.
+// setPreviousIssuerHandlers serves the endpoints of the previous issuer of a FederationDomain which is migrating to
+// a new issuer. Its discovery endpoint tells clients where the issuer moved, and its JWKS and IDP discovery endpoints
+// are unchanged. Its token endpoint continues to redeem the refresh tokens which it issued until the end of the grace
+// period. The tokens are stored and signed exactly as for the new issuer, since the FederationDomain is unchanged.
+// All other endpoints are not served, so new logins must happen at the new issuer.
+func (m *Manager) setPreviousIssuerHandlers(
+	federationDomain *federationdomainproviders.FederationDomainIssuer,
+	previousIssuer *federationdomainproviders.PreviousIssuer,
+	idpLister *federationdomainproviders.FederationDomainIdentityProvidersListerFinder,
+	tokenHMACKeyGetter func() []byte,
+	tokenEnrichmentWebhook *tokenenrichment.Webhook,
+	tokenEndpointLimiter *loadshed.Limiter,
+) {
+	previousIssuerURL := previousIssuer.Issuer()
+	previousIssuerHostWithPath := strings.ToLower(previousIssuer.IssuerHost()) + "/" + previousIssuer.IssuerPath()
+
+	timeoutsConfiguration := oidc.DefaultOIDCTimeoutsConfiguration()
+
+	oauthHelperWithKubeStorage := oidc.FositeOauth2Helper(
+		storage.NewKubeStorage(m.secretsClient, m.oidcClientsClient, timeoutsConfiguration, oidcclientvalidator.DefaultMinBcryptCost),
+		previousIssuerURL,
+		tokenHMACKeyGetter,
+		m.dynamicJWKSProvider,
+		timeoutsConfiguration,
+		formposthtml.TemplateForContext, // the token endpoint does not render the form_post page
+		federationDomain.JWTAccessTokens(),
+		nil, // workloads must exchange their ServiceAccount tokens at the new issuer
+		m.clusterAudiences,
+	)
+
+	m.providerHandlers[(previousIssuerHostWithPath + oidc.WellKnownEndpointPath)] = discovery.NewPreviousIssuerHandler(previousIssuerURL, federationDomain.Issuer(), federationDomain.SigningAlgorithm())
+
+	m.providerHandlers[(previousIssuerHostWithPath + oidc.JWKSEndpointPath)] = jwks.NewHandler(previousIssuerURL, m.dynamicJWKSProvider)
+
+	m.providerHandlers[(previousIssuerHostWithPath + oidc.PinnipedIDPsPathV1Alpha1)] = idpdiscovery.NewHandler(idpLister)
+
+	m.providerHandlers[(previousIssuerHostWithPath + oidc.TokenEndpointPath)] = token.NewPreviousIssuerHandler(
+		token.NewHandler(
+			idpLister,
+			oauthHelperWithKubeStorage,
+			timeoutsConfiguration.OverrideDefaultAccessTokenLifespan,
+			timeoutsConfiguration.OverrideDefaultIDTokenLifespan,
+			m.groupChangeNotifier,
+			previousIssuerURL,
+			tokenEnrichmentWebhook,
+			m.forcedReauthChecker,
+			m.dpopValidator,
+			m.lastClientAuths,
+			newSingleSignOnStorage(m.secretsClient, timeoutsConfiguration),
+		),
+		oauthHelperWithKubeStorage,
+		federationDomain.Issuer(),
+		previousIssuer.RefreshGracePeriodEnd(),
+		clock.RealClock{},
+	)
+
+	// The token endpoints of both issuers share the limit, since they are both served by the same FederationDomain.
+	if tokenEndpointLimiter != nil {
+		m.providerHandlers[previousIssuerHostWithPath+oidc.TokenEndpointPath] = tokenEndpointLimiter.WrapHandler(m.providerHandlers[previousIssuerHostWithPath+oidc.TokenEndpointPath])
+	}
+
+	m.wrapCORSHandlers(federationDomain.CORS(), previousIssuerHostWithPath)
+
+	for _, path := range previousIssuerEndpointPaths {
+		if federationDomain.AccessLogEnabled() && m.accessLogger != nil {
+			m.providerHandlers[previousIssuerHostWithPath+path] = m.accessLogger.WrapHandler(previousIssuerURL, m.providerHandlers[previousIssuerHostWithPath+path])
+		}
+		m.providerListeners[previousIssuerHostWithPath+path] = federationDomain.Listener()
+	}
+}
+
+// wrapCORSHandlers allows the configured origins to call the endpoints in corsEndpointMethods of an issuer.
+// It does nothing when the config is nil, so browsers only allow the issuer's own origin.
+func (m *Manager) wrapCORSHandlers(config *cors.Config, issuerHostWithPath string) {
+	if config == nil {
+		return
+	}
+	for path, methods := range corsEndpointMethods {
+		m.providerHandlers[issuerHostWithPath+path] = cors.WrapHandler(*config, methods, m.providerHandlers[issuerHostWithPath+path])
+	}
+}
+
+// ServeHTTP implements the http.Handler interface. It serves the FederationDomains which do not select
+// a listener, so it should be used by the default HTTPS and HTTP listeners.
 func (m *Manager) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
-	requestHandler := m.findHandler(req)
+	m.serveHTTP(resp, req, "")
+}
+
+// HandlerForListener returns an http.Handler which only serves the FederationDomains which select the
+// named additional HTTPS listener. Requests for any other FederationDomain are passed to the next handler.
+func (m *Manager) HandlerForListener(listener string) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		m.serveHTTP(resp, req, listener)
+	})
+}
+
+func (m *Manager) serveHTTP(resp http.ResponseWriter, req *http.Request, listener string) {
+	requestHandler := m.findHandler(req, listener)
 
 	// Using Info level so the user can safely configure a production Supervisor to show this message if they choose.
 	plog.Info("received incoming request",
@@ -187,6 +606,7 @@ func (m *Manager) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
 		"host", req.Host,
 		"requestSNIServerName", requestutil.SNIServerName(req),
 		"path", req.URL.Path,
+		"listener", listener,
 		"remoteAddr", req.RemoteAddr,
 		"foundFederationDomainRequestHandler", requestHandler != nil,
 	)
@@ -194,14 +614,67 @@ func (m *Manager) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
 	if requestHandler == nil {
 		requestHandler = m.nextHandler // couldn't find an issuer to handle the request
 	}
+
+	// Make any client certificates from the TLS handshake available to the identity providers which use them.
+	if req.TLS != nil && len(req.TLS.PeerCertificates) > 0 {
+		req = req.WithContext(requestutil.WithPeerCertificates(req))
+	}
+
+	// Render the web pages in the language preferred by the user's browser, when it is supported.
+	req = req.WithContext(i18n.WithLocalizer(req.Context(), i18n.ForLanguage(req.Header.Get("Accept-Language"))))
+
 	requestHandler.ServeHTTP(resp, req)
 }
 
-func (m *Manager) findHandler(req *http.Request) http.Handler {
+// RequestsClientCertificates returns true when an issuer at the given TLS server name currently uses a
+// ClientCertificateIdentityProvider, so that clients are only asked for a certificate by the issuers which could use it.
+// Clients do not send a server name when they connect to an IP address, so an empty server name matches the issuers
+// whose hosts are IP addresses.
+func (m *Manager) RequestsClientCertificates(serverName string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, idpLister := range m.idpListersByServerName[strings.ToLower(serverName)] {
+		// The upstream IDPs can change without the FederationDomains changing, so check them for every connection.
+		for _, idp := range idpLister.GetIdentityProviders() {
+			if idp.GetSessionProviderType() == psession.ProviderTypeClientCertificate {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (m *Manager) findHandler(req *http.Request, listener string) http.Handler {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	return m.providerHandlers[strings.ToLower(req.Host)+"/"+req.URL.Path]
+	route := strings.ToLower(req.Host) + "/" + req.URL.Path
+	if m.providerListeners[route] != listener {
+		// Do not serve a FederationDomain on a listener which it did not select, to keep the issuers isolated.
+		return nil
+	}
+	return m.providerHandlers[route]
+}
+
+// newSingleSignOnStorage returns the storage of single sign-on sessions, which keeps their upstream refresh tokens for
+// as long as the downstream refresh tokens which share them. Those may be issued until the authorization codes of
+// the last logins with the single sign-on cookie expire.
+func newSingleSignOnStorage(secrets corev1client.SecretInterface, timeoutsConfiguration timeouts.Configuration) singlesignonstorage.Storage {
+	return singlesignonstorage.New(secrets, time.Now,
+		timeoutsConfiguration.AuthorizeCodeLifespan+timeoutsConfiguration.RefreshTokenSessionStorageLifetime(nil))
+}
+
+// tlsServerName returns the server name which TLS clients send for the host of an issuer, which is empty for IP addresses.
+func tlsServerName(issuerHost string) string {
+	if host, _, err := net.SplitHostPort(issuerHost); err == nil {
+		issuerHost = host
+	}
+	issuerHost = strings.Trim(issuerHost, "[]")
+	if net.ParseIP(issuerHost) != nil {
+		return ""
+	}
+	return strings.ToLower(issuerHost)
 }
 
 func wrapGetter(issuer string, getter func(string) []byte) func() []byte {
@@ -4,6 +4,7 @@
 package endpointsmanager
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"encoding/json"
 	"fmt"
@@ -13,23 +14,32 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-jose/go-jose/v3"
 	"github.com/sclevine/spec"
 	"github.com/stretchr/testify/require"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/clock"
 
 	supervisorfake "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/fake"
+	"go.pinniped.dev/internal/federationdomain/accesslog"
+	"go.pinniped.dev/internal/federationdomain/branding"
+	"go.pinniped.dev/internal/federationdomain/cors"
+	"go.pinniped.dev/internal/federationdomain/dynamicupstreamprovider"
 	"go.pinniped.dev/internal/federationdomain/endpoints/discovery"
 	"go.pinniped.dev/internal/federationdomain/endpoints/jwks"
 	"go.pinniped.dev/internal/federationdomain/federationdomainproviders"
+	"go.pinniped.dev/internal/federationdomain/loginthrottle"
 	"go.pinniped.dev/internal/federationdomain/oidc"
+	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
 	"go.pinniped.dev/internal/here"
 	"go.pinniped.dev/internal/idtransform"
 	"go.pinniped.dev/internal/secret"
 	"go.pinniped.dev/internal/testutil"
 	"go.pinniped.dev/internal/testutil/oidctestutil"
 	"go.pinniped.dev/internal/testutil/testidplister"
+	"go.pinniped.dev/internal/upstreamclientcert"
 )
 
 func TestManager(t *testing.T) {
@@ -40,8 +50,11 @@ func TestManager(t *testing.T) {
 			nextHandler              http.HandlerFunc
 			fallbackHandlerWasCalled bool
 			dynamicJWKSProvider      jwks.DynamicJWKSProvider
+			dynamicBrandingProvider  branding.DynamicBrandingProvider
 			federationDomainIDPs     []*federationdomainproviders.FederationDomainIdentityProvider
+			idpLister                dynamicupstreamprovider.DynamicUpstreamIDPProvider
 			kubeClient               *fake.Clientset
+			accessLogSink            *bytes.Buffer
 		)
 
 		const (
@@ -123,9 +136,12 @@ func TestManager(t *testing.T) {
 				"pinniped_identity_providers": [%s],
 				"pinniped_supported_identity_provider_types": [
 					{"type":"activedirectory"},
+					{"type":"clientcertificate"},
 					{"type":"github"},
 					{"type":"ldap"},
-					{"type":"oidc"}
+					{"type":"mock"},
+					{"type":"oidc"},
+					{"type":"openshift"}
 				]
 }`, strings.Join(expectedIDPJSONList, ","))
 
@@ -269,6 +285,18 @@ func TestManager(t *testing.T) {
 				"did not perform expected number of kube actions during the callback request")
 		}
 
+		requireBrandingStylesheetRequestToBeHandled := func(requestIssuer string, expectedStatus int, expectedBody string) {
+			recorder := httptest.NewRecorder()
+
+			subject.ServeHTTP(recorder, newGetRequest(requestIssuer+branding.StylesheetPath))
+
+			r.False(fallbackHandlerWasCalled)
+
+			// Minimal check to ensure that the right branding endpoint was called
+			r.Equal(expectedStatus, recorder.Code, "unexpected response:", recorder)
+			r.Equal(expectedBody, recorder.Body.String())
+		}
+
 		requireJWKSRequestToBeHandled := func(requestIssuer, requestURLSuffix, expectedJWKKeyID string) *jose.JSONWebKeySet {
 			recorder := httptest.NewRecorder()
 
@@ -294,6 +322,10 @@ func TestManager(t *testing.T) {
 				fallbackHandlerWasCalled = true
 			}
 			dynamicJWKSProvider = jwks.NewDynamicJWKSProvider()
+			dynamicBrandingProvider = branding.NewDynamicBrandingProvider()
+			dynamicBrandingProvider.SetIssuerToBrandingMap(map[string]*branding.Branding{
+				issuer1: {CustomCSS: "h1 { color: red; }"},
+			})
 
 			parsedUpstreamIDPAuthorizationURL1, err := url.Parse(upstreamIDPAuthorizationURL1)
 			r.NoError(err)
@@ -313,7 +345,7 @@ func TestManager(t *testing.T) {
 				},
 			}
 
-			idpLister := testidplister.NewUpstreamIDPListerBuilder().
+			idpLister = testidplister.NewUpstreamIDPListerBuilder().
 				WithOIDC(oidctestutil.NewTestUpstreamOIDCIdentityProviderBuilder().
 					WithName(upstreamIDPName1).
 					WithClientID("test-client-id-1").
@@ -358,7 +390,11 @@ func TestManager(t *testing.T) {
 			cache.SetStateEncoderHashKey(issuer2, []byte("some-state-encoder-hash-key-2"))
 			cache.SetStateEncoderBlockKey(issuer2, []byte("16-bytes-STATE02"))
 
-			subject = NewManager(nextHandler, dynamicJWKSProvider, idpLister, &cache, secretsClient, oidcClientsClient)
+			accessLogSink = &bytes.Buffer{}
+			accessLogger := accesslog.New(accessLogSink, accesslog.FormatJSON,
+				[]string{accesslog.FieldFederationDomain, accesslog.FieldURI, accesslog.FieldStatus}, clock.RealClock{})
+
+			subject = NewManager(nextHandler, dynamicJWKSProvider, dynamicBrandingProvider, idpLister, &cache, secretsClient, oidcClientsClient, nil, accessLogger, nil, nil, nil, nil, nil)
 		})
 
 		when("given no providers via SetFederationDomains()", func() {
@@ -367,6 +403,13 @@ func TestManager(t *testing.T) {
 				subject.ServeHTTP(httptest.NewRecorder(), newGetRequest("/anything"))
 				r.True(fallbackHandlerWasCalled)
 			})
+
+			it("is not ready until the FederationDomains are set, even when there are none", func() {
+				r.Equal("federation-domains", subject.ReadyzCheck().Name())
+				r.EqualError(subject.ReadyzCheck().Check(nil), "FederationDomains have not been loaded yet")
+				subject.SetFederationDomains()
+				r.NoError(subject.ReadyzCheck().Check(nil))
+			})
 		})
 
 		newTestJWK := func(keyID string) *jose.JSONWebKey {
@@ -415,6 +458,10 @@ func TestManager(t *testing.T) {
 			requireJWKSRequestToBeHandled(issuer2DifferentCaseHostname, "", issuer2KeyID)
 			requireJWKSRequestToBeHandled(issuer2DifferentCaseHostname, "?some=query", issuer2KeyID)
 
+			requireBrandingStylesheetRequestToBeHandled(issuer1, http.StatusOK, "h1 { color: red; }")
+			requireBrandingStylesheetRequestToBeHandled(issuer2, http.StatusNotFound, "branding not found for requested issuer\n")
+			requireBrandingStylesheetRequestToBeHandled(issuer1DifferentCaseHostname, http.StatusOK, "h1 { color: red; }")
+
 			requirePinnipedIDPChooserRequestToBeHandled(issuer1, []string{upstreamIDPDisplayName1, upstreamIDPDisplayName2})
 			requirePinnipedIDPChooserRequestToBeHandled(issuer2, []string{upstreamIDPDisplayName1, upstreamIDPDisplayName2})
 			requirePinnipedIDPChooserRequestToBeHandled(issuer1DifferentCaseHostname, []string{upstreamIDPDisplayName1, upstreamIDPDisplayName2})
@@ -551,5 +598,202 @@ func TestManager(t *testing.T) {
 				requireRoutesMatchingRequestsToAppropriateProvider()
 			})
 		})
+
+		when("given providers where only one enables access logs", func() {
+			it.Before(func() {
+				fd1, err := federationdomainproviders.NewFederationDomainIssuer(issuer1, federationDomainIDPs)
+				r.NoError(err)
+				fd1.SetAccessLogEnabled(true)
+				fd2, err := federationdomainproviders.NewFederationDomainIssuer(issuer2, federationDomainIDPs)
+				r.NoError(err)
+				subject.SetFederationDomains(fd1, fd2)
+			})
+
+			it("writes access logs only for the provider which enabled them", func() {
+				requireDiscoveryRequestToBeHandled(issuer1, "?state=some-state", issuer1)
+				requireDiscoveryRequestToBeHandled(issuer2, "", issuer2)
+				subject.ServeHTTP(httptest.NewRecorder(), newGetRequest(issuer1+"/unhandled-sub-path"))
+
+				r.Equal(
+					`{"federationDomain":"https://example.com/some/path","uri":"/some/path/.well-known/openid-configuration?state=redacted","status":200}`+"\n",
+					accessLogSink.String(),
+				)
+			})
+		})
+
+		when("given providers where only one configures CORS", func() {
+			it.Before(func() {
+				fd1, err := federationdomainproviders.NewFederationDomainIssuer(issuer1, federationDomainIDPs)
+				r.NoError(err)
+				fd1.SetCORS(&cors.Config{AllowedOrigins: []string{"https://app.example.com"}, AllowedHeaders: cors.DefaultAllowedHeaders()})
+				fd2, err := federationdomainproviders.NewFederationDomainIssuer(issuer2, federationDomainIDPs)
+				r.NoError(err)
+				subject.SetFederationDomains(fd1, fd2)
+			})
+
+			it("allows the configured origins to call only the endpoints of the provider which configured CORS", func() {
+				for _, issuer := range []string{issuer1, issuer2} {
+					request := newGetRequest(issuer + oidc.WellKnownEndpointPath)
+					request.Header.Set("Origin", "https://app.example.com")
+					recorder := httptest.NewRecorder()
+					subject.ServeHTTP(recorder, request)
+					r.False(fallbackHandlerWasCalled)
+					r.Equal(http.StatusOK, recorder.Code)
+					if issuer == issuer1 {
+						r.Equal("https://app.example.com", recorder.Header().Get("Access-Control-Allow-Origin"))
+					} else {
+						r.Empty(recorder.Header().Get("Access-Control-Allow-Origin"))
+					}
+				}
+
+				request := httptest.NewRequest(http.MethodOptions, issuer1+oidc.TokenEndpointPath, nil)
+				request.Header.Set("Origin", "https://app.example.com")
+				request.Header.Set("Access-Control-Request-Method", http.MethodPost)
+				recorder := httptest.NewRecorder()
+				subject.ServeHTTP(recorder, request)
+				r.Equal(http.StatusNoContent, recorder.Code)
+				r.Equal(http.MethodPost, recorder.Header().Get("Access-Control-Allow-Methods"))
+				r.Equal("Authorization, Content-Type, DPoP", recorder.Header().Get("Access-Control-Allow-Headers"))
+			})
+		})
+
+		when("given a provider which is migrating from a previous issuer", func() {
+			const previousIssuer = "https://old.example.com/old/path"
+
+			it.Before(func() {
+				fd1, err := federationdomainproviders.NewFederationDomainIssuer(issuer1, federationDomainIDPs)
+				r.NoError(err)
+				r.NoError(fd1.SetPreviousIssuer(previousIssuer, time.Now().Add(time.Hour)))
+				subject.SetFederationDomains(fd1)
+			})
+
+			it("serves discovery at the previous issuer, which points to the new issuer", func() {
+				requireDiscoveryRequestToBeHandled(issuer1, "", issuer1)
+
+				recorder := httptest.NewRecorder()
+				subject.ServeHTTP(recorder, newGetRequest(previousIssuer+oidc.WellKnownEndpointPath))
+				r.False(fallbackHandlerWasCalled)
+				r.Equal(http.StatusOK, recorder.Code)
+				parsedDiscoveryResult := discovery.Metadata{}
+				r.NoError(json.Unmarshal(recorder.Body.Bytes(), &parsedDiscoveryResult))
+				r.Equal(previousIssuer, parsedDiscoveryResult.Issuer)
+				r.Equal(issuer1, parsedDiscoveryResult.SupervisorDiscovery.IssuerMigratedTo)
+			})
+
+			it("does not serve new logins at the previous issuer", func() {
+				subject.ServeHTTP(httptest.NewRecorder(), newGetRequest(previousIssuer+oidc.AuthorizationEndpointPath))
+				r.True(fallbackHandlerWasCalled)
+			})
+		})
+
+		when("given providers where only one selects a listener", func() {
+			it.Before(func() {
+				fd1, err := federationdomainproviders.NewFederationDomainIssuer(issuer1, federationDomainIDPs)
+				r.NoError(err)
+				fd1.SetListener("tenant-a")
+				fd2, err := federationdomainproviders.NewFederationDomainIssuer(issuer2, federationDomainIDPs)
+				r.NoError(err)
+				subject.SetFederationDomains(fd1, fd2)
+			})
+
+			it("serves each provider only on the listener which it selected", func() {
+				requireDiscoveryRequestToBeHandled(issuer2, "", issuer2)
+
+				subject.ServeHTTP(httptest.NewRecorder(), newGetRequest(issuer1+oidc.WellKnownEndpointPath))
+				r.True(fallbackHandlerWasCalled)
+				fallbackHandlerWasCalled = false
+
+				tenantHandler := subject.HandlerForListener("tenant-a")
+				recorder := httptest.NewRecorder()
+				tenantHandler.ServeHTTP(recorder, newGetRequest(issuer1+oidc.WellKnownEndpointPath))
+				r.False(fallbackHandlerWasCalled)
+				r.Equal(http.StatusOK, recorder.Code)
+				r.Contains(recorder.Body.String(), `"issuer":"`+issuer1+`"`)
+
+				tenantHandler.ServeHTTP(httptest.NewRecorder(), newGetRequest(issuer2+oidc.WellKnownEndpointPath))
+				r.True(fallbackHandlerWasCalled)
+				fallbackHandlerWasCalled = false
+
+				subject.HandlerForListener("tenant-b").ServeHTTP(httptest.NewRecorder(), newGetRequest(issuer1+oidc.WellKnownEndpointPath))
+				r.True(fallbackHandlerWasCalled)
+			})
+		})
+
+		when("given a provider which configures login rate limits", func() {
+			it.Before(func() {
+				fd1, err := federationdomainproviders.NewFederationDomainIssuer(issuer1, federationDomainIDPs)
+				r.NoError(err)
+				fd1.SetLoginRateLimits(&loginthrottle.Config{
+					RequestsPerMinutePerSourceIP: 1,
+					FailedAttemptsBeforeLockout:  5,
+					LockoutDuration:              time.Minute,
+				})
+				subject.SetFederationDomains(fd1)
+			})
+
+			it("throttles the login endpoints but not the token endpoint", func() {
+				recorder := httptest.NewRecorder()
+				subject.ServeHTTP(recorder, newGetRequest(issuer1+oidc.AuthorizationEndpointPath))
+				r.NotEqual(http.StatusTooManyRequests, recorder.Code)
+
+				recorder = httptest.NewRecorder()
+				subject.ServeHTTP(recorder, newGetRequest(issuer1+oidc.AuthorizationEndpointPath))
+				r.Equal(http.StatusTooManyRequests, recorder.Code)
+
+				// Refreshes and token exchanges from the same source IP are not counted as logins.
+				for range 3 {
+					recorder = httptest.NewRecorder()
+					subject.ServeHTTP(recorder, newPostRequest(issuer1+oidc.TokenEndpointPath, "grant_type=refresh_token"))
+					r.NotEqual(http.StatusTooManyRequests, recorder.Code)
+				}
+			})
+		})
+
+		when("given providers where only one uses a ClientCertificateIdentityProvider", func() {
+			const (
+				clientCertIssuer          = "https://certs.example.com/some/path"
+				clientCertIDPResourceUID  = "test-client-cert-resource-uid"
+				clientCertIDPDisplayName  = "test-client-cert-idp-display-name"
+				ipAddressClientCertIssuer = "https://10.0.0.1:8443/some/path"
+			)
+
+			it.Before(func() {
+				idpLister.SetClientCertificateIdentityProviders([]upstreamprovider.UpstreamClientCertificateIdentityProviderI{
+					upstreamclientcert.New(upstreamclientcert.ProviderConfig{Name: "test-client-cert-idp", ResourceUID: clientCertIDPResourceUID}),
+				})
+				clientCertIDPs := []*federationdomainproviders.FederationDomainIdentityProvider{{
+					DisplayName: clientCertIDPDisplayName,
+					UID:         clientCertIDPResourceUID,
+					Transforms:  idtransform.NewTransformationPipeline(),
+				}}
+
+				fd1, err := federationdomainproviders.NewFederationDomainIssuer(issuer1, federationDomainIDPs)
+				r.NoError(err)
+				fd2, err := federationdomainproviders.NewFederationDomainIssuer(clientCertIssuer, clientCertIDPs)
+				r.NoError(err)
+				fd3, err := federationdomainproviders.NewFederationDomainIssuer(ipAddressClientCertIssuer, clientCertIDPs)
+				r.NoError(err)
+				subject.SetFederationDomains(fd1, fd2, fd3)
+			})
+
+			it("requests client certificates only for the server names of the providers which use it", func() {
+				r.True(subject.RequestsClientCertificates("certs.example.com"))
+				r.True(subject.RequestsClientCertificates("CERTS.example.com"))
+				// Clients do not send a server name when they connect to an IP address.
+				r.True(subject.RequestsClientCertificates(""))
+
+				// The provider at this server name has no ClientCertificateIdentityProvider.
+				r.False(subject.RequestsClientCertificates("example.com"))
+				// No provider has this server name.
+				r.False(subject.RequestsClientCertificates("other.example.com"))
+			})
+
+			it("stops requesting client certificates when the ClientCertificateIdentityProvider goes away", func() {
+				idpLister.SetClientCertificateIdentityProviders(nil)
+
+				r.False(subject.RequestsClientCertificates("certs.example.com"))
+				r.False(subject.RequestsClientCertificates(""))
+			})
+		})
 	})
 }
@@ -0,0 +1,111 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package errordetails defines the stable error codes which the Supervisor returns in the error_details member
+// of the OAuth error responses of its token endpoint, e.g.
+//
+//	{
+//	  "error": "error",
+//	  "error_description": "Error during upstream refresh. Upstream refresh failed.",
+//	  "error_details": {"code": "PINNIPED_UPSTREAM_REFRESH_FAILED"}
+//	}
+//
+// The error_description is meant for humans, and may be reworded in any release. The codes are meant for programs,
+// and are part of the API of the Supervisor: a code is never renamed or removed, and its meaning never changes.
+// New codes may be added in any release, so clients must tolerate unknown codes. Errors which do not have a code
+// are returned without the error_details member.
+package errordetails
+
+import (
+	"errors"
+)
+
+// Code is a stable error code.
+type Code string
+
+const (
+	// SessionDataMissing means that the session of the user lacks the data which is required to refresh it,
+	// e.g. because it was created by an older version of the Supervisor. The user must log in again.
+	SessionDataMissing Code = "PINNIPED_SESSION_DATA_MISSING"
+
+	// IDPNotReady means that the identity provider which authenticated the user was deleted, or is not ready.
+	// The user must log in again, possibly after the identity provider becomes ready.
+	IDPNotReady Code = "PINNIPED_IDP_NOT_READY"
+
+	// IDPChanged means that the identity provider which authenticated the user was deleted and recreated with
+	// the same name. The user must log in again.
+	IDPChanged Code = "PINNIPED_IDP_CHANGED"
+
+	// UpstreamRefreshFailed means that the upstream identity provider refused to refresh the session of the
+	// user, or that the identity of the user at the upstream identity provider has changed. The user must log
+	// in again.
+	UpstreamRefreshFailed Code = "PINNIPED_UPSTREAM_REFRESH_FAILED"
+
+	// IdentityTransformationFailed means that the identity transformations of the FederationDomain could not
+	// be applied to the refreshed identity of the user.
+	IdentityTransformationFailed Code = "PINNIPED_IDENTITY_TRANSFORMATION_FAILED"
+
+	// IdentityPolicyRejected means that the identity transformations of the FederationDomain rejected the
+	// refreshed identity of the user.
+	IdentityPolicyRejected Code = "PINNIPED_IDENTITY_POLICY_REJECTED"
+
+	// ReauthenticationRequired means that a ForcedReauthentication requires the user to log in again.
+	ReauthenticationRequired Code = "PINNIPED_REAUTHENTICATION_REQUIRED"
+
+	// TokenEnrichmentFailed means that the token enrichment webhook of the FederationDomain could not be called,
+	// or returned an invalid response.
+	TokenEnrichmentFailed Code = "PINNIPED_TOKEN_ENRICHMENT_FAILED"
+
+	// TokenEnrichmentDenied means that the token enrichment webhook of the FederationDomain denied the issuance
+	// of the tokens.
+	TokenEnrichmentDenied Code = "PINNIPED_TOKEN_ENRICHMENT_DENIED"
+
+	// IssuerMigrated means that the issuer of the FederationDomain was changed, and that the grace period during
+	// which the previous issuer still accepted refresh tokens has ended. The user must log in again at the new issuer.
+	IssuerMigrated Code = "PINNIPED_ISSUER_MIGRATED"
+
+	// DPoPProofInvalid means that the tokens of the session of the user are bound to a DPoP key, and that the
+	// request did not have a valid DPoP proof which was signed by that key.
+	DPoPProofInvalid Code = "PINNIPED_DPOP_PROOF_INVALID"
+
+	// ScopePolicyRejected means that the user no longer belongs to any of the groups which the scope policies of the
+	// client require for one of the scopes which were granted to the session of the user.
+	ScopePolicyRejected Code = "PINNIPED_SCOPE_POLICY_REJECTED"
+
+	// ACRPolicyRejected means that the client requires the upstream login of the user to assert one of some ACR values,
+	// and that the login which started the session of the user did not assert any of them.
+	ACRPolicyRejected Code = "PINNIPED_ACR_POLICY_REJECTED"
+)
+
+// Details is the value of the error_details member of an OAuth error response.
+type Details struct {
+	Code Code `json:"code"`
+}
+
+type codedError struct {
+	code Code
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+
+func (e *codedError) Unwrap() error { return e.err }
+
+// WithCode attaches the code to the err, which is usually a *fosite.RFC6749Error or wraps one. The returned error
+// still wraps the err, so fosite handles it like the err. When err already has a code, err is returned unchanged,
+// so that the most specific code which was attached closest to the cause of the error wins.
+func WithCode(err error, code Code) error {
+	if err == nil || FromError(err) != "" {
+		return err
+	}
+	return &codedError{code: code, err: err}
+}
+
+// FromError returns the code which was attached to the err, or an empty string when it does not have a code.
+func FromError(err error) Code {
+	var coded *codedError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+	return ""
+}
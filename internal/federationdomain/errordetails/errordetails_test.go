@@ -0,0 +1,53 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package errordetails
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ory/fosite"
+	errorsx "github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCodesAreStable protects the values of the codes, which clients may depend on. Never change an existing
+// entry of this test, only add new entries.
+func TestCodesAreStable(t *testing.T) {
+	for code, want := range map[Code]string{
+		SessionDataMissing:           "PINNIPED_SESSION_DATA_MISSING",
+		IDPNotReady:                  "PINNIPED_IDP_NOT_READY",
+		IDPChanged:                   "PINNIPED_IDP_CHANGED",
+		UpstreamRefreshFailed:        "PINNIPED_UPSTREAM_REFRESH_FAILED",
+		IdentityTransformationFailed: "PINNIPED_IDENTITY_TRANSFORMATION_FAILED",
+		IdentityPolicyRejected:       "PINNIPED_IDENTITY_POLICY_REJECTED",
+		ReauthenticationRequired:     "PINNIPED_REAUTHENTICATION_REQUIRED",
+		TokenEnrichmentFailed:        "PINNIPED_TOKEN_ENRICHMENT_FAILED",
+		TokenEnrichmentDenied:        "PINNIPED_TOKEN_ENRICHMENT_DENIED",
+		IssuerMigrated:               "PINNIPED_ISSUER_MIGRATED",
+		DPoPProofInvalid:             "PINNIPED_DPOP_PROOF_INVALID",
+		ScopePolicyRejected:          "PINNIPED_SCOPE_POLICY_REJECTED",
+		ACRPolicyRejected:            "PINNIPED_ACR_POLICY_REJECTED",
+	} {
+		require.Equal(t, want, string(code))
+	}
+}
+
+func TestWithCode(t *testing.T) {
+	require.NoError(t, WithCode(nil, UpstreamRefreshFailed))
+	require.Empty(t, FromError(nil))
+	require.Empty(t, FromError(errors.New("some error")))
+
+	fositeErr := fosite.ErrAccessDenied.WithHint("some hint")
+	err := WithCode(errorsx.WithStack(fositeErr), IDPNotReady)
+	require.Equal(t, IDPNotReady, FromError(err))
+	require.Equal(t, IDPNotReady, FromError(errorsx.WithStack(err)))
+	require.EqualError(t, err, "access_denied")
+
+	// Fosite still finds the wrapped error.
+	require.Equal(t, "some hint", fosite.ErrorToRFC6749Error(err).HintField)
+
+	// The first code wins.
+	require.Equal(t, IDPNotReady, FromError(WithCode(err, UpstreamRefreshFailed)))
+}
@@ -0,0 +1,202 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package externalsigner calls the external signing service of a FederationDomain, which holds its signing keys,
+// e.g. in a PKCS#11 HSM or in a cloud KMS, so that the private keys are never stored in Kubernetes Secrets.
+package externalsigner
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+
+	"go.pinniped.dev/internal/clientcredentials"
+	"go.pinniped.dev/internal/federationdomain/endpoints/jwks"
+)
+
+const (
+	// DefaultTimeout is how long to wait for each response of the signing service.
+	DefaultTimeout = 10 * time.Second
+
+	keysPath = "/keys"
+	signPath = "/sign"
+
+	// maxResponseBytes limits how much of the response of the signing service is read.
+	maxResponseBytes = 1 << 20
+)
+
+type signRequest struct {
+	KeyID     string `json:"keyID"`
+	Algorithm string `json:"algorithm"`
+	Payload   string `json:"payload"`
+}
+
+type signResponse struct {
+	Signature string `json:"signature"`
+}
+
+// Client calls an external signing service.
+//
+// It is thread-safe.
+type Client struct {
+	endpoint   string
+	httpClient *http.Client
+}
+
+// New returns a Client for the HTTPS endpoint of a signing service. The caBundleBase64 is the base64-encoded PEM
+// CA bundle of the endpoint, or empty to trust the system roots. Each request is authenticated using the credentials.
+func New(endpoint string, caBundleBase64 string, credentials *clientcredentials.Credentials) (*Client, error) {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	if endpointURL.Scheme != "https" {
+		return nil, fmt.Errorf("invalid endpoint %q: must use https", endpoint)
+	}
+
+	var rootCAs *x509.CertPool
+	if caBundleBase64 != "" {
+		caBundle, err := base64.StdEncoding.DecodeString(caBundleBase64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificateAuthorityData: %w", err)
+		}
+		rootCAs = x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(caBundle) {
+			return nil, errors.New("invalid certificateAuthorityData: no certificates found")
+		}
+	}
+
+	httpClient := credentials.Client(rootCAs)
+	httpClient.Timeout = DefaultTimeout
+	return &Client{endpoint: strings.TrimSuffix(endpoint, "/"), httpClient: httpClient}, nil
+}
+
+// Keys returns the public keys of the signing service. The first key is the one which should be used to sign tokens.
+func (c *Client) Keys(ctx context.Context) (*jose.JSONWebKeySet, error) {
+	var keySet jose.JSONWebKeySet
+	if err := c.do(ctx, http.MethodGet, keysPath, nil, &keySet); err != nil {
+		return nil, fmt.Errorf("keys request failed: %w", err)
+	}
+
+	if len(keySet.Keys) == 0 {
+		return nil, errors.New("keys request failed: response did not contain any keys")
+	}
+	for _, key := range keySet.Keys {
+		switch {
+		case !key.IsPublic() || !key.Valid():
+			return nil, fmt.Errorf("keys request failed: key %q is not a valid public key", key.KeyID)
+		case key.KeyID == "":
+			return nil, errors.New("keys request failed: each key must have a key ID")
+		case !slices.Contains(jwks.SupportedSigningAlgorithms(), key.Algorithm):
+			return nil, fmt.Errorf("keys request failed: key %q has unsupported algorithm %q", key.KeyID, key.Algorithm)
+		}
+	}
+
+	return &keySet, nil
+}
+
+// Signer returns a jwks.RequestSigner which asks the signing service to sign using the private key of the public key.
+// It only signs after it was bound to the context of a request using WithContext, e.g. by jwks.SigningKey.
+func (c *Client) Signer(publicKey jose.JSONWebKey) jwks.RequestSigner {
+	return &signer{client: c, publicKey: publicKey}
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in any, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("could not encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, c.endpoint+path, body)
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+	if in != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	request.Header.Set("Accept", "application/json")
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected http response status: %s", response.Status)
+	}
+
+	rawBody, err := io.ReadAll(io.LimitReader(response.Body, maxResponseBytes))
+	if err != nil {
+		return fmt.Errorf("could not read response body: %w", err)
+	}
+	if err := json.Unmarshal(rawBody, out); err != nil {
+		return fmt.Errorf("could not parse response JSON: %w", err)
+	}
+	return nil
+}
+
+type signer struct {
+	client    *Client
+	publicKey jose.JSONWebKey
+	// ctx is the context of the request which needs the signature, or nil until the signer is bound to a request.
+	ctx context.Context
+}
+
+var _ jwks.RequestSigner = (*signer)(nil)
+
+func (s *signer) WithContext(ctx context.Context) jose.OpaqueSigner {
+	return &signer{client: s.client, publicKey: s.publicKey, ctx: ctx}
+}
+
+func (s *signer) Public() *jose.JSONWebKey {
+	publicKey := s.publicKey
+	return &publicKey
+}
+
+func (s *signer) Algs() []jose.SignatureAlgorithm {
+	return []jose.SignatureAlgorithm{jose.SignatureAlgorithm(s.publicKey.Algorithm)}
+}
+
+func (s *signer) SignPayload(payload []byte, alg jose.SignatureAlgorithm) ([]byte, error) {
+	// jose.OpaqueSigner does not pass a context, so the signer must have been bound to the context of the request.
+	if s.ctx == nil {
+		return nil, errors.New("signing request failed: the signer is not bound to the context of a request")
+	}
+	var signed signResponse
+	err := s.client.do(s.ctx, http.MethodPost, signPath, &signRequest{
+		KeyID:     s.publicKey.KeyID,
+		Algorithm: string(alg),
+		Payload:   base64.RawURLEncoding.EncodeToString(payload),
+	}, &signed)
+	if err != nil {
+		return nil, fmt.Errorf("signing request failed: %w", err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(signed.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("signing request failed: invalid signature: %w", err)
+	}
+	if len(signature) == 0 {
+		return nil, errors.New("signing request failed: response did not contain a signature")
+	}
+	return signature, nil
+}
@@ -0,0 +1,212 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package externalsigner
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/stretchr/testify/require"
+
+	"go.pinniped.dev/internal/testutil/tlsserver"
+)
+
+func TestNew(t *testing.T) {
+	_, err := New("http://signer.example.com", "", nil)
+	require.EqualError(t, err, `invalid endpoint "http://signer.example.com": must use https`)
+
+	_, err = New("https://signer.example.com", "not base64!", nil)
+	require.ErrorContains(t, err, "invalid certificateAuthorityData: illegal base64 data")
+
+	_, err = New("https://signer.example.com", base64.StdEncoding.EncodeToString([]byte("not PEM")), nil)
+	require.EqualError(t, err, "invalid certificateAuthorityData: no certificates found")
+
+	client, err := New("https://signer.example.com/some/path/", "", nil)
+	require.NoError(t, err)
+	require.Equal(t, "https://signer.example.com/some/path", client.endpoint)
+}
+
+func TestKeys(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		response any
+		status   int
+		wantKeys []string
+		wantErr  string
+	}{
+		{
+			name: "success",
+			response: jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+				{Key: publicKey, KeyID: "key-1", Algorithm: "EdDSA", Use: "sig"},
+				{Key: publicKey, KeyID: "key-2", Algorithm: "EdDSA", Use: "sig"},
+			}},
+			wantKeys: []string{"key-1", "key-2"},
+		},
+		{
+			name:    "error status",
+			status:  http.StatusForbidden,
+			wantErr: "keys request failed: unexpected http response status: 403 Forbidden",
+		},
+		{
+			name:     "invalid JSON",
+			response: "not a key set",
+			wantErr:  "keys request failed: could not parse response JSON: json: cannot unmarshal string into Go value of type jose.JSONWebKeySet",
+		},
+		{
+			name:     "no keys",
+			response: jose.JSONWebKeySet{},
+			wantErr:  "keys request failed: response did not contain any keys",
+		},
+		{
+			name: "private key",
+			response: jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+				{Key: privateKey, KeyID: "key-1", Algorithm: "EdDSA", Use: "sig"},
+			}},
+			wantErr: `keys request failed: key "key-1" is not a valid public key`,
+		},
+		{
+			name: "no key ID",
+			response: jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+				{Key: publicKey, Algorithm: "EdDSA", Use: "sig"},
+			}},
+			wantErr: "keys request failed: each key must have a key ID",
+		},
+		{
+			name: "unsupported algorithm",
+			response: jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+				{Key: publicKey, KeyID: "key-1", Algorithm: "PS256", Use: "sig"},
+			}},
+			wantErr: `keys request failed: key "key-1" has unsupported algorithm "PS256"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, serverCA := tlsserver.TestServerIPv4(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodGet, r.Method)
+				require.Equal(t, "/signer/keys", r.URL.Path)
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+					return
+				}
+				require.NoError(t, json.NewEncoder(w).Encode(tt.response))
+			}), nil)
+
+			client, err := New(server.URL+"/signer", base64.StdEncoding.EncodeToString(serverCA), nil)
+			require.NoError(t, err)
+
+			keySet, err := client.Keys(context.Background())
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			keyIDs := make([]string, 0, len(keySet.Keys))
+			for _, key := range keySet.Keys {
+				keyIDs = append(keyIDs, key.KeyID)
+			}
+			require.Equal(t, tt.wantKeys, keyIDs)
+		})
+	}
+}
+
+func TestSigner(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	publicJWK := jose.JSONWebKey{Key: publicKey, KeyID: "some-key", Algorithm: "EdDSA", Use: "sig"}
+
+	tests := []struct {
+		name    string
+		handler func(t *testing.T, w http.ResponseWriter, r *http.Request)
+		unbound bool
+		wantErr string
+	}{
+		{
+			name: "success",
+			handler: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodPost, r.Method)
+				require.Equal(t, "/sign", r.URL.Path)
+				require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+				var body signRequest
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				require.Equal(t, "some-key", body.KeyID)
+				require.Equal(t, "EdDSA", body.Algorithm)
+				payload, err := base64.RawURLEncoding.DecodeString(body.Payload)
+				require.NoError(t, err)
+
+				require.NoError(t, json.NewEncoder(w).Encode(&signResponse{
+					Signature: base64.RawURLEncoding.EncodeToString(ed25519.Sign(privateKey, payload)),
+				}))
+			},
+		},
+		{
+			name: "not bound to a request",
+			handler: func(t *testing.T, _ http.ResponseWriter, _ *http.Request) {
+				t.Error("unexpected signing request")
+			},
+			unbound: true,
+			wantErr: "signing request failed: the signer is not bound to the context of a request",
+		},
+		{
+			name: "error status",
+			handler: func(_ *testing.T, w http.ResponseWriter, _ *http.Request) {
+				http.Error(w, "nope", http.StatusForbidden)
+			},
+			wantErr: "signing request failed: unexpected http response status: 403 Forbidden",
+		},
+		{
+			name: "invalid signature",
+			handler: func(_ *testing.T, w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(`{"signature":"not base64url!"}`))
+			},
+			wantErr: "signing request failed: invalid signature: illegal base64 data at input byte 3",
+		},
+		{
+			name: "no signature",
+			handler: func(_ *testing.T, w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte("{}"))
+			},
+			wantErr: "signing request failed: response did not contain a signature",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, serverCA := tlsserver.TestServerIPv4(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tt.handler(t, w, r)
+			}), nil)
+
+			client, err := New(server.URL, base64.StdEncoding.EncodeToString(serverCA), nil)
+			require.NoError(t, err)
+			signer := client.Signer(publicJWK)
+			require.Equal(t, &publicJWK, signer.Public())
+			require.Equal(t, []jose.SignatureAlgorithm{jose.EdDSA}, signer.Algs())
+
+			var key jose.OpaqueSigner = signer
+			if !tt.unbound {
+				key = signer.WithContext(context.Background())
+			}
+			joseSigner, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.EdDSA, Key: key}, nil)
+			require.NoError(t, err)
+			jws, err := joseSigner.Sign([]byte("some payload"))
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			payload, err := jws.Verify(publicKey)
+			require.NoError(t, err)
+			require.Equal(t, "some payload", string(payload))
+		})
+	}
+}
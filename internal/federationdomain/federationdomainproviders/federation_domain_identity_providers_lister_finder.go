@@ -11,20 +11,25 @@ import (
 
 	"go.pinniped.dev/internal/federationdomain/idplister"
 	"go.pinniped.dev/internal/federationdomain/resolvedprovider"
+	"go.pinniped.dev/internal/federationdomain/resolvedprovider/resolvedclientcert"
 	"go.pinniped.dev/internal/federationdomain/resolvedprovider/resolvedgithub"
 	"go.pinniped.dev/internal/federationdomain/resolvedprovider/resolvedldap"
+	"go.pinniped.dev/internal/federationdomain/resolvedprovider/resolvedmock"
 	"go.pinniped.dev/internal/federationdomain/resolvedprovider/resolvedoidc"
+	"go.pinniped.dev/internal/federationdomain/resolvedprovider/resolvedopenshift"
 	"go.pinniped.dev/internal/idtransform"
 	"go.pinniped.dev/internal/psession"
 )
 
 // FederationDomainIdentityProvider represents an identity provider as configured in a FederationDomain's spec.
-// All the fields are required and must be non-zero values. Note that this might be a reference to an IDP
-// which is not currently loaded into the cache of available IDPs, e.g. due to the IDP's CR having validation errors.
+// All the fields are required and must be non-zero values, except for Presentation, whose fields are optional.
+// Note that this might be a reference to an IDP which is not currently loaded into the cache of available IDPs,
+// e.g. due to the IDP's CR having validation errors.
 type FederationDomainIdentityProvider struct {
-	DisplayName string
-	UID         types.UID
-	Transforms  *idtransform.TransformationPipeline
+	DisplayName  string
+	UID          types.UID
+	Transforms   *idtransform.TransformationPipeline
+	Presentation resolvedprovider.Presentation
 }
 
 type FederationDomainIdentityProvidersFinderI interface {
@@ -58,6 +63,7 @@ type FederationDomainIdentityProvidersListerFinder struct {
 	defaultIdentityProvider          *FederationDomainIdentityProvider
 	idpDisplayNamesToResourceUIDsMap map[string]types.UID
 	allowedIDPResourceUIDs           sets.Set[types.UID]
+	notReadyIDPDisplayNames          sets.Set[string]
 }
 
 // NewFederationDomainIdentityProvidersListerFinder returns a new FederationDomainIdentityProvidersListerFinder
@@ -92,6 +98,7 @@ func NewFederationDomainIdentityProvidersListerFinder(
 		defaultIdentityProvider:          federationDomainIssuer.DefaultIdentityProvider(),
 		idpDisplayNamesToResourceUIDsMap: idpDisplayNamesToResourceUIDsMap,
 		allowedIDPResourceUIDs:           allowedResourceUIDs,
+		notReadyIDPDisplayNames:          sets.New(federationDomainIssuer.NotReadyIdentityProviderDisplayNames()...),
 	}
 }
 
@@ -109,6 +116,10 @@ func (u *FederationDomainIdentityProvidersListerFinder) FindUpstreamIDPByDisplay
 	// Given a display name, look up the identity provider's UID for that display name.
 	idpUIDForDisplayName, ok := u.idpDisplayNamesToResourceUIDsMap[upstreamIDPDisplayName]
 	if !ok {
+		if u.notReadyIDPDisplayNames.Has(upstreamIDPDisplayName) {
+			// The FederationDomain lists this identity provider, but its configuration is not valid.
+			return nil, fmt.Errorf("identity provider not ready: %q", upstreamIDPDisplayName)
+		}
 		return nil, fmt.Errorf("identity provider not found: %q", upstreamIDPDisplayName)
 	}
 	// Find the IDP with that UID. It could be any type, so look at all types to find it.
@@ -146,6 +157,9 @@ func (u *FederationDomainIdentityProvidersListerFinder) GetIdentityProviders() [
 	cachedLDAPProviders := u.wrappedLister.GetLDAPIdentityProviders()
 	cachedADProviders := u.wrappedLister.GetActiveDirectoryIdentityProviders()
 	cachedGitHubProviders := u.wrappedLister.GetGitHubIdentityProviders()
+	cachedClientCertProviders := u.wrappedLister.GetClientCertificateIdentityProviders()
+	cachedMockProviders := u.wrappedLister.GetMockIdentityProviders()
+	cachedOpenShiftProviders := u.wrappedLister.GetOpenShiftIdentityProviders()
 	providers := []resolvedprovider.FederationDomainResolvedIdentityProvider{}
 	// Every configured identityProvider on the FederationDomain uses an objetRef to an underlying IDP CR that might
 	// be available as a provider in the wrapped cache. For each configured identityProvider/displayName...
@@ -156,6 +170,7 @@ func (u *FederationDomainIdentityProvidersListerFinder) GetIdentityProviders() [
 				// Found it, so append it to the result.
 				providers = append(providers, &resolvedoidc.FederationDomainResolvedOIDCIdentityProvider{
 					DisplayName:         idp.DisplayName,
+					Presentation:        idp.Presentation,
 					Provider:            p,
 					SessionProviderType: psession.ProviderTypeOIDC,
 					Transforms:          idp.Transforms,
@@ -168,6 +183,7 @@ func (u *FederationDomainIdentityProvidersListerFinder) GetIdentityProviders() [
 				// Found it, so append it to the result.
 				providers = append(providers, &resolvedldap.FederationDomainResolvedLDAPIdentityProvider{
 					DisplayName:         idp.DisplayName,
+					Presentation:        idp.Presentation,
 					Provider:            p,
 					SessionProviderType: psession.ProviderTypeLDAP,
 					Transforms:          idp.Transforms,
@@ -180,6 +196,7 @@ func (u *FederationDomainIdentityProvidersListerFinder) GetIdentityProviders() [
 				// Found it, so append it to the result.
 				providers = append(providers, &resolvedldap.FederationDomainResolvedLDAPIdentityProvider{
 					DisplayName:         idp.DisplayName,
+					Presentation:        idp.Presentation,
 					Provider:            p,
 					SessionProviderType: psession.ProviderTypeActiveDirectory,
 					Transforms:          idp.Transforms,
@@ -190,12 +207,46 @@ func (u *FederationDomainIdentityProvidersListerFinder) GetIdentityProviders() [
 			if idp.UID == p.GetResourceUID() {
 				providers = append(providers, &resolvedgithub.FederationDomainResolvedGitHubIdentityProvider{
 					DisplayName:         idp.DisplayName,
+					Presentation:        idp.Presentation,
 					Provider:            p,
 					SessionProviderType: psession.ProviderTypeGitHub,
 					Transforms:          idp.Transforms,
 				})
 			}
 		}
+		for _, p := range cachedClientCertProviders {
+			if idp.UID == p.GetResourceUID() {
+				providers = append(providers, &resolvedclientcert.FederationDomainResolvedClientCertificateIdentityProvider{
+					DisplayName:         idp.DisplayName,
+					Presentation:        idp.Presentation,
+					Provider:            p,
+					SessionProviderType: psession.ProviderTypeClientCertificate,
+					Transforms:          idp.Transforms,
+				})
+			}
+		}
+		for _, p := range cachedMockProviders {
+			if idp.UID == p.GetResourceUID() {
+				providers = append(providers, &resolvedmock.FederationDomainResolvedMockIdentityProvider{
+					DisplayName:         idp.DisplayName,
+					Presentation:        idp.Presentation,
+					Provider:            p,
+					SessionProviderType: psession.ProviderTypeMock,
+					Transforms:          idp.Transforms,
+				})
+			}
+		}
+		for _, p := range cachedOpenShiftProviders {
+			if idp.UID == p.GetResourceUID() {
+				providers = append(providers, &resolvedopenshift.FederationDomainResolvedOpenShiftIdentityProvider{
+					DisplayName:         idp.DisplayName,
+					Presentation:        idp.Presentation,
+					Provider:            p,
+					SessionProviderType: psession.ProviderTypeOpenShift,
+					Transforms:          idp.Transforms,
+				})
+			}
+		}
 	}
 	return providers
 }
@@ -124,6 +124,12 @@ func TestFederationDomainIdentityProvidersListerFinder(t *testing.T) {
 	})
 	require.NoError(t, err)
 
+	fdIssuerWithNotReadyIDP, err := NewFederationDomainIssuer(fakeIssuerURL, []*FederationDomainIdentityProvider{
+		{DisplayName: "my-oidc-idp1", UID: "my-oidc-uid-idp1"},
+	})
+	require.NoError(t, err)
+	fdIssuerWithNotReadyIDP.SetNotReadyIdentityProviderDisplayNames([]string{"my-broken-idp"})
+
 	// Resolved IdPs
 	myOIDCIDP1Resolved := &resolvedoidc.FederationDomainResolvedOIDCIdentityProvider{
 		DisplayName:         "my-oidc-idp1",
@@ -293,6 +299,24 @@ func TestFederationDomainIdentityProvidersListerFinder(t *testing.T) {
 			federationDomainIssuer: fdIssuerWithIDPWithLostUID,
 			wantError:              `identity provider not available: "my-idp"`,
 		},
+		{
+			name:                 "FindUpstreamIDPByDisplayName will error if IDP by display name is listed by the FederationDomain but is not ready",
+			findIDPByDisplayName: "my-broken-idp",
+			wrappedLister: testidplister.NewUpstreamIDPListerBuilder().
+				WithOIDC(myOIDCIDP1).
+				BuildDynamicUpstreamIDPProvider(),
+			federationDomainIssuer: fdIssuerWithNotReadyIDP,
+			wantError:              `identity provider not ready: "my-broken-idp"`,
+		},
+		{
+			name:                 "FindUpstreamIDPByDisplayName will find a ready IDP when another IDP is not ready",
+			findIDPByDisplayName: "my-oidc-idp1",
+			wrappedLister: testidplister.NewUpstreamIDPListerBuilder().
+				WithOIDC(myOIDCIDP1).
+				BuildDynamicUpstreamIDPProvider(),
+			federationDomainIssuer:   fdIssuerWithNotReadyIDP,
+			wantOIDCIDPByDisplayName: myOIDCIDP1Resolved,
+		},
 	}
 
 	for _, tt := range testFindUpstreamIDPByDisplayName {
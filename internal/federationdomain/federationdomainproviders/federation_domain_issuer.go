@@ -1,4 +1,4 @@
-// Copyright 2020-2023 the Pinniped contributors. All Rights Reserved.
+// Copyright 2020-2024 the Pinniped contributors. All Rights Reserved.
 // SPDX-License-Identifier: Apache-2.0
 
 package federationdomainproviders
@@ -7,10 +7,22 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	"go.pinniped.dev/internal/constable"
+	"go.pinniped.dev/internal/federationdomain/cors"
+	"go.pinniped.dev/internal/federationdomain/loadshed"
+	"go.pinniped.dev/internal/federationdomain/loginthrottle"
+	"go.pinniped.dev/internal/federationdomain/secondfactor"
+	"go.pinniped.dev/internal/federationdomain/singlesignon"
+	"go.pinniped.dev/internal/federationdomain/strategy"
+	"go.pinniped.dev/internal/federationdomain/tokenenrichment"
+	"go.pinniped.dev/internal/federationdomain/workloadidentity"
 )
 
+// DefaultSigningAlgorithm is the JWS algorithm of the signing keys of a FederationDomain which does not configure it.
+const DefaultSigningAlgorithm = "ES256"
+
 // FederationDomainIssuer is a parsed FederationDomain representing all the settings for a downstream OIDC provider
 // and contains configuration representing a set of upstream identity providers.
 type FederationDomainIssuer struct {
@@ -24,6 +36,74 @@ type FederationDomainIssuer struct {
 	// are not explicitly specified in the FederationDomain's spec, and there is exactly one IDP CR defined in the
 	// Supervisor's namespace.
 	defaultIdentityProvider *FederationDomainIdentityProvider
+	// notReadyIdentityProviderDisplayNames are the display names of the identity providers which are listed in the
+	// FederationDomain's spec, but which cannot be used because they are not ready.
+	notReadyIdentityProviderDisplayNames []string
+
+	// loginRateLimits is nil when login attempts should not be throttled.
+	loginRateLimits *loginthrottle.Config
+
+	// tokenEndpointLoadShedding is nil when the number of concurrent token requests should not be limited.
+	tokenEndpointLoadShedding *loadshed.Config
+
+	accessLogEnabled bool
+
+	// tokenEnrichmentWebhook is nil when no token enrichment webhook should be called.
+	tokenEnrichmentWebhook *tokenenrichment.Config
+
+	// jwtAccessTokens is nil when opaque access tokens should be issued.
+	jwtAccessTokens *strategy.JWTAccessTokenConfig
+
+	// workloadIdentity is nil when the ServiceAccount tokens of workloads should not be accepted.
+	workloadIdentity *workloadidentity.Config
+
+	// signingAlgorithm is empty when the signing keys use the default algorithm.
+	signingAlgorithm string
+
+	// cors is nil when web pages of other origins should not be allowed to call the endpoints.
+	cors *cors.Config
+
+	// singleSignOn is nil when browsers should always log in at the upstream identity provider.
+	singleSignOn *singlesignon.Config
+
+	// webAuthn is nil when users never need a WebAuthn credential.
+	webAuthn *secondfactor.WebAuthnConfig
+
+	// listener is the name of the additional HTTPS listener which serves this FederationDomain,
+	// or empty when it is served by the default HTTPS and HTTP listeners.
+	listener string
+
+	// previousIssuer is nil unless the FederationDomain is migrating away from a previous issuer.
+	previousIssuer *PreviousIssuer
+}
+
+// PreviousIssuer is the issuer which a FederationDomain used before its issuer was changed.
+type PreviousIssuer struct {
+	issuer     string
+	issuerHost string
+	issuerPath string
+
+	refreshGracePeriodEnd time.Time
+}
+
+// Issuer returns the previous issuer.
+func (p *PreviousIssuer) Issuer() string {
+	return p.issuer
+}
+
+// IssuerHost returns the host of the previous issuer.
+func (p *PreviousIssuer) IssuerHost() string {
+	return p.issuerHost
+}
+
+// IssuerPath returns the path of the previous issuer.
+func (p *PreviousIssuer) IssuerPath() string {
+	return p.issuerPath
+}
+
+// RefreshGracePeriodEnd returns the time after which refresh tokens are no longer accepted by the previous issuer.
+func (p *PreviousIssuer) RefreshGracePeriodEnd() time.Time {
+	return p.refreshGracePeriodEnd
 }
 
 // NewFederationDomainIssuer returns a FederationDomainIssuer.
@@ -53,43 +133,50 @@ func NewFederationDomainIssuerWithDefaultIDP(
 }
 
 func (p *FederationDomainIssuer) validateURL() error {
-	if p.issuer == "" {
-		return constable.Error("federation domain must have an issuer")
+	issuerHost, issuerPath, err := parseIssuerURL(p.issuer)
+	if err != nil {
+		return err
+	}
+	p.issuerHost = issuerHost
+	p.issuerPath = issuerPath
+	return nil
+}
+
+func parseIssuerURL(issuer string) (string, string, error) {
+	if issuer == "" {
+		return "", "", constable.Error("federation domain must have an issuer")
 	}
 
-	issuerURL, err := url.Parse(p.issuer)
+	issuerURL, err := url.Parse(issuer)
 	if err != nil {
-		return fmt.Errorf("could not parse issuer as URL: %w", err)
+		return "", "", fmt.Errorf("could not parse issuer as URL: %w", err)
 	}
 
 	if issuerURL.Scheme != "https" {
-		return constable.Error(`issuer must have "https" scheme`)
+		return "", "", constable.Error(`issuer must have "https" scheme`)
 	}
 
 	if issuerURL.Hostname() == "" {
-		return constable.Error(`issuer must have a hostname`)
+		return "", "", constable.Error(`issuer must have a hostname`)
 	}
 
 	if issuerURL.User != nil {
-		return constable.Error(`issuer must not have username or password`)
+		return "", "", constable.Error(`issuer must not have username or password`)
 	}
 
 	if strings.HasSuffix(issuerURL.Path, "/") {
-		return constable.Error(`issuer must not have trailing slash in path`)
+		return "", "", constable.Error(`issuer must not have trailing slash in path`)
 	}
 
 	if issuerURL.RawQuery != "" {
-		return constable.Error(`issuer must not have query`)
+		return "", "", constable.Error(`issuer must not have query`)
 	}
 
 	if issuerURL.Fragment != "" {
-		return constable.Error(`issuer must not have fragment`)
+		return "", "", constable.Error(`issuer must not have fragment`)
 	}
 
-	p.issuerHost = issuerURL.Host
-	p.issuerPath = issuerURL.Path
-
-	return nil
+	return issuerURL.Host, issuerURL.Path, nil
 }
 
 // Issuer returns the issuer.
@@ -116,3 +203,164 @@ func (p *FederationDomainIssuer) IdentityProviders() []*FederationDomainIdentity
 func (p *FederationDomainIssuer) DefaultIdentityProvider() *FederationDomainIdentityProvider {
 	return p.defaultIdentityProvider
 }
+
+// SetLoginRateLimits configures throttling of login attempts. A nil config disables throttling.
+func (p *FederationDomainIssuer) SetLoginRateLimits(config *loginthrottle.Config) {
+	p.loginRateLimits = config
+}
+
+// LoginRateLimits returns the login throttling config, or nil when login attempts should not be throttled.
+func (p *FederationDomainIssuer) LoginRateLimits() *loginthrottle.Config {
+	return p.loginRateLimits
+}
+
+// SetTokenEndpointLoadShedding configures load-shedding for the token endpoint. A nil config disables it.
+func (p *FederationDomainIssuer) SetTokenEndpointLoadShedding(config *loadshed.Config) {
+	p.tokenEndpointLoadShedding = config
+}
+
+// TokenEndpointLoadShedding returns the load-shedding config for the token endpoint, or nil when the number of
+// concurrent token requests should not be limited.
+func (p *FederationDomainIssuer) TokenEndpointLoadShedding() *loadshed.Config {
+	return p.tokenEndpointLoadShedding
+}
+
+// SetAccessLogEnabled configures whether requests to the endpoints of this FederationDomain should be written
+// to the access log.
+func (p *FederationDomainIssuer) SetAccessLogEnabled(enabled bool) {
+	p.accessLogEnabled = enabled
+}
+
+// AccessLogEnabled returns true when requests to the endpoints of this FederationDomain should be written
+// to the access log.
+func (p *FederationDomainIssuer) AccessLogEnabled() bool {
+	return p.accessLogEnabled
+}
+
+// SetTokenEnrichmentWebhook configures the token enrichment webhook. A nil config disables the webhook.
+func (p *FederationDomainIssuer) SetTokenEnrichmentWebhook(config *tokenenrichment.Config) {
+	p.tokenEnrichmentWebhook = config
+}
+
+// TokenEnrichmentWebhook returns the token enrichment webhook config, or nil when no webhook should be called.
+func (p *FederationDomainIssuer) TokenEnrichmentWebhook() *tokenenrichment.Config {
+	return p.tokenEnrichmentWebhook
+}
+
+// SetJWTAccessTokens configures the FederationDomain to issue JWT access tokens. A nil config means that opaque
+// access tokens are issued.
+func (p *FederationDomainIssuer) SetJWTAccessTokens(config *strategy.JWTAccessTokenConfig) {
+	p.jwtAccessTokens = config
+}
+
+// JWTAccessTokens returns the config of the JWT access tokens, or nil when opaque access tokens should be issued.
+func (p *FederationDomainIssuer) JWTAccessTokens() *strategy.JWTAccessTokenConfig {
+	return p.jwtAccessTokens
+}
+
+// SetWorkloadIdentity configures which workloads may exchange their ServiceAccount tokens. A nil config means that
+// ServiceAccount tokens are not accepted.
+func (p *FederationDomainIssuer) SetWorkloadIdentity(config *workloadidentity.Config) {
+	p.workloadIdentity = config
+}
+
+// WorkloadIdentity returns the workload identity config, or nil when ServiceAccount tokens should not be accepted.
+func (p *FederationDomainIssuer) WorkloadIdentity() *workloadidentity.Config {
+	return p.workloadIdentity
+}
+
+// SetSigningAlgorithm configures the JWS algorithm of the signing keys of this FederationDomain. An empty algorithm
+// means the default algorithm.
+func (p *FederationDomainIssuer) SetSigningAlgorithm(algorithm string) {
+	p.signingAlgorithm = algorithm
+}
+
+// SigningAlgorithm returns the JWS algorithm of the signing keys of this FederationDomain, which defaults to ES256.
+func (p *FederationDomainIssuer) SigningAlgorithm() string {
+	if p.signingAlgorithm == "" {
+		return DefaultSigningAlgorithm
+	}
+	return p.signingAlgorithm
+}
+
+// SetCORS configures which web pages of other origins may call the discovery, JWKS and token endpoints. A nil config
+// means that they may not.
+func (p *FederationDomainIssuer) SetCORS(config *cors.Config) {
+	p.cors = config
+}
+
+// CORS returns the CORS config, or nil when web pages of other origins should not be allowed to call the endpoints.
+func (p *FederationDomainIssuer) CORS() *cors.Config {
+	return p.cors
+}
+
+// SetSingleSignOn configures how long the upstream logins of browsers are remembered for other clients. A nil config
+// means that browsers always log in at the upstream identity provider.
+func (p *FederationDomainIssuer) SetSingleSignOn(config *singlesignon.Config) {
+	p.singleSignOn = config
+}
+
+// SingleSignOn returns the single sign-on config, or nil when browsers should always log in at the upstream
+// identity provider.
+func (p *FederationDomainIssuer) SingleSignOn() *singlesignon.Config {
+	return p.singleSignOn
+}
+
+// SetWebAuthn configures the WebAuthn credentials which the users of the identity providers which use the login page
+// need. A nil config means that users never need a WebAuthn credential.
+func (p *FederationDomainIssuer) SetWebAuthn(config *secondfactor.WebAuthnConfig) {
+	p.webAuthn = config
+}
+
+// WebAuthn returns the WebAuthn config, or nil when users never need a WebAuthn credential.
+func (p *FederationDomainIssuer) WebAuthn() *secondfactor.WebAuthnConfig {
+	return p.webAuthn
+}
+
+// SetListener configures the name of the additional HTTPS listener which serves this FederationDomain.
+// An empty name means that it is served by the default HTTPS and HTTP listeners.
+func (p *FederationDomainIssuer) SetListener(listener string) {
+	p.listener = listener
+}
+
+// Listener returns the name of the additional HTTPS listener which serves this FederationDomain,
+// or empty when it is served by the default HTTPS and HTTP listeners.
+func (p *FederationDomainIssuer) Listener() string {
+	return p.listener
+}
+
+// SetNotReadyIdentityProviderDisplayNames records the display names of the identity providers which are listed in
+// the FederationDomain's spec but which are not ready, so that attempts to use them can be clearly rejected.
+func (p *FederationDomainIssuer) SetNotReadyIdentityProviderDisplayNames(displayNames []string) {
+	p.notReadyIdentityProviderDisplayNames = displayNames
+}
+
+// NotReadyIdentityProviderDisplayNames returns the display names of the identity providers which are not ready.
+func (p *FederationDomainIssuer) NotReadyIdentityProviderDisplayNames() []string {
+	return p.notReadyIdentityProviderDisplayNames
+}
+
+// SetPreviousIssuer configures the issuer which this FederationDomain used before its issuer was changed. The previous
+// issuer must be a valid issuer URL which is different from the issuer.
+func (p *FederationDomainIssuer) SetPreviousIssuer(issuer string, refreshGracePeriodEnd time.Time) error {
+	issuerHost, issuerPath, err := parseIssuerURL(issuer)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(issuerHost, p.issuerHost) && issuerPath == p.issuerPath {
+		return constable.Error("previous issuer must be different from the issuer")
+	}
+	p.previousIssuer = &PreviousIssuer{
+		issuer:                issuer,
+		issuerHost:            issuerHost,
+		issuerPath:            issuerPath,
+		refreshGracePeriodEnd: refreshGracePeriodEnd,
+	}
+	return nil
+}
+
+// PreviousIssuer returns the issuer which this FederationDomain used before its issuer was changed,
+// or nil when it is not migrating away from a previous issuer.
+func (p *FederationDomainIssuer) PreviousIssuer() *PreviousIssuer {
+	return p.previousIssuer
+}
@@ -6,9 +6,11 @@ package federationdomainproviders
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"go.pinniped.dev/internal/federationdomain/cors"
 	"go.pinniped.dev/internal/idtransform"
 )
 
@@ -135,4 +137,64 @@ func TestFederationDomainGetters(t *testing.T) {
 	require.Equal(t, issuerPath, fdi.IssuerPath())
 	require.Equal(t, []*FederationDomainIdentityProvider{provider1}, fdi.IdentityProviders())
 	require.Equal(t, provider1, fdi.DefaultIdentityProvider())
+	require.Equal(t, "ES256", fdi.SigningAlgorithm())
+
+	fdi.SetSigningAlgorithm("EdDSA")
+	require.Equal(t, "EdDSA", fdi.SigningAlgorithm())
+
+	require.Nil(t, fdi.CORS())
+	corsConfig := &cors.Config{AllowedOrigins: []string{"https://app.example.com"}}
+	fdi.SetCORS(corsConfig)
+	require.Equal(t, corsConfig, fdi.CORS())
+}
+
+func TestFederationDomainPreviousIssuer(t *testing.T) {
+	gracePeriodEnd := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		previousIssuer string
+		wantError      string
+	}{
+		{
+			name:           "different host",
+			previousIssuer: "https://old-issuer.com/some/path",
+		},
+		{
+			name:           "different path",
+			previousIssuer: "https://some-issuer.com/old/path",
+		},
+		{
+			name:           "same as the issuer",
+			previousIssuer: "https://some-issuer.com/some/path",
+			wantError:      "previous issuer must be different from the issuer",
+		},
+		{
+			name:           "same as the issuer except for the case of the host",
+			previousIssuer: "https://SOME-issuer.com/some/path",
+			wantError:      "previous issuer must be different from the issuer",
+		},
+		{
+			name:           "invalid issuer",
+			previousIssuer: "http://old-issuer.com",
+			wantError:      `issuer must have "https" scheme`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fdi, err := NewFederationDomainIssuer("https://some-issuer.com/some/path", nil)
+			require.NoError(t, err)
+			require.Nil(t, fdi.PreviousIssuer())
+
+			err = fdi.SetPreviousIssuer(tt.previousIssuer, gracePeriodEnd)
+			if tt.wantError != "" {
+				require.EqualError(t, err, tt.wantError)
+				require.Nil(t, fdi.PreviousIssuer())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.previousIssuer, fdi.PreviousIssuer().Issuer())
+			require.Equal(t, gracePeriodEnd, fdi.PreviousIssuer().RefreshGracePeriodEnd())
+		})
+	}
 }
@@ -0,0 +1,68 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package forcedreauth decides whether a downstream session must be refused a refresh because an admin created
+// a ForcedReauthentication for its user or for one of its groups.
+package forcedreauth
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
+	configlisters "go.pinniped.dev/generated/latest/client/supervisor/listers/config/v1alpha1"
+)
+
+// Checker finds the ForcedReauthentications which apply to a session. A nil Checker never finds any.
+type Checker struct {
+	lister configlisters.ForcedReauthenticationNamespaceLister
+}
+
+// NewChecker returns a Checker which reads the ForcedReauthentications from the lister of an informer.
+func NewChecker(lister configlisters.ForcedReauthenticationNamespaceLister) *Checker {
+	return &Checker{lister: lister}
+}
+
+// Find returns a ForcedReauthentication for the downstream username or one of the downstream groups whose notBefore
+// is after authTime, i.e. after the interactive login which started the session. It returns nil when there is none.
+func (c *Checker) Find(username string, groups []string, authTime time.Time) (*supervisorconfigv1alpha1.ForcedReauthentication, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	forcedReauthentications, err := c.lister.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("could not list ForcedReauthentications: %w", err)
+	}
+
+	for _, forcedReauthentication := range forcedReauthentications {
+		if !appliesTo(forcedReauthentication.Spec.Subject, username, groups) {
+			continue
+		}
+		if authTime.Before(notBefore(forcedReauthentication)) {
+			return forcedReauthentication, nil
+		}
+	}
+	return nil, nil
+}
+
+func appliesTo(subject supervisorconfigv1alpha1.ForcedReauthenticationSubject, username string, groups []string) bool {
+	switch subject.Kind {
+	case supervisorconfigv1alpha1.ForcedReauthenticationSubjectKindUser:
+		return subject.Name == username
+	case supervisorconfigv1alpha1.ForcedReauthenticationSubjectKindGroup:
+		return slices.Contains(groups, subject.Name)
+	default:
+		return false
+	}
+}
+
+func notBefore(forcedReauthentication *supervisorconfigv1alpha1.ForcedReauthentication) time.Time {
+	if forcedReauthentication.Spec.NotBefore != nil {
+		return forcedReauthentication.Spec.NotBefore.Time
+	}
+	return forcedReauthentication.CreationTimestamp.Time
+}
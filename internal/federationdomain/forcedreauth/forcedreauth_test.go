@@ -0,0 +1,119 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package forcedreauth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
+	configlisters "go.pinniped.dev/generated/latest/client/supervisor/listers/config/v1alpha1"
+)
+
+func TestFind(t *testing.T) {
+	const namespace = "some-namespace"
+	created := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	forcedReauthentication := func(name string, kind supervisorconfigv1alpha1.ForcedReauthenticationSubjectKind, subjectName string, notBefore *time.Time) *supervisorconfigv1alpha1.ForcedReauthentication {
+		fr := &supervisorconfigv1alpha1.ForcedReauthentication{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, CreationTimestamp: metav1.NewTime(created)},
+			Spec: supervisorconfigv1alpha1.ForcedReauthenticationSpec{
+				Subject: supervisorconfigv1alpha1.ForcedReauthenticationSubject{Kind: kind, Name: subjectName},
+			},
+		}
+		if notBefore != nil {
+			fr.Spec.NotBefore = &metav1.Time{Time: *notBefore}
+		}
+		return fr
+	}
+	later := created.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		existing []*supervisorconfigv1alpha1.ForcedReauthentication
+		username string
+		groups   []string
+		authTime time.Time
+		wantName string
+	}{
+		{
+			name:     "no ForcedReauthentications",
+			username: "alice",
+			authTime: created.Add(-time.Minute),
+		},
+		{
+			name:     "user logged in before the creation of a ForcedReauthentication for the user",
+			existing: []*supervisorconfigv1alpha1.ForcedReauthentication{forcedReauthentication("fr1", "User", "alice", nil)},
+			username: "alice",
+			authTime: created.Add(-time.Minute),
+			wantName: "fr1",
+		},
+		{
+			name:     "user logged in after the creation of a ForcedReauthentication for the user",
+			existing: []*supervisorconfigv1alpha1.ForcedReauthentication{forcedReauthentication("fr1", "User", "alice", nil)},
+			username: "alice",
+			authTime: created.Add(time.Minute),
+		},
+		{
+			name:     "user logged in before the notBefore of a ForcedReauthentication for the user",
+			existing: []*supervisorconfigv1alpha1.ForcedReauthentication{forcedReauthentication("fr1", "User", "alice", &later)},
+			username: "alice",
+			authTime: created.Add(time.Minute),
+			wantName: "fr1",
+		},
+		{
+			name:     "ForcedReauthentication for another user",
+			existing: []*supervisorconfigv1alpha1.ForcedReauthentication{forcedReauthentication("fr1", "User", "bob", nil)},
+			username: "alice",
+			groups:   []string{"bob"},
+			authTime: created.Add(-time.Minute),
+		},
+		{
+			name: "ForcedReauthentication for a group of the user",
+			existing: []*supervisorconfigv1alpha1.ForcedReauthentication{
+				forcedReauthentication("fr1", "Group", "other-group", nil),
+				forcedReauthentication("fr2", "Group", "group2", nil),
+			},
+			username: "alice",
+			groups:   []string{"group1", "group2"},
+			authTime: created.Add(-time.Minute),
+			wantName: "fr2",
+		},
+		{
+			name:     "ForcedReauthentication for a group which has the name of the user",
+			existing: []*supervisorconfigv1alpha1.ForcedReauthentication{forcedReauthentication("fr1", "Group", "alice", nil)},
+			username: "alice",
+			authTime: created.Add(-time.Minute),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, fr := range tt.existing {
+				require.NoError(t, indexer.Add(fr))
+			}
+			checker := NewChecker(configlisters.NewForcedReauthenticationLister(indexer).ForcedReauthentications(namespace))
+
+			found, err := checker.Find(tt.username, tt.groups, tt.authTime)
+			require.NoError(t, err)
+			if tt.wantName == "" {
+				require.Nil(t, found)
+				return
+			}
+			require.NotNil(t, found)
+			require.Equal(t, tt.wantName, found.Name)
+		})
+	}
+}
+
+func TestFindWithNilChecker(t *testing.T) {
+	var checker *Checker
+	found, err := checker.Find("alice", nil, time.Now())
+	require.NoError(t, err)
+	require.Nil(t, found)
+}
@@ -1,17 +1,21 @@
-// Copyright 2021-2023 the Pinniped contributors. All Rights Reserved.
+// Copyright 2021-2024 the Pinniped contributors. All Rights Reserved.
 // SPDX-License-Identifier: Apache-2.0
 
 // Package formposthtml defines HTML templates used by the Supervisor.
 package formposthtml
 
 import (
+	"context"
 	_ "embed" // Needed to trigger //go:embed directives below.
 	"html/template"
 	"strings"
+	"sync"
 
 	"github.com/tdewolff/minify/v2/minify"
 
+	"go.pinniped.dev/internal/federationdomain/branding"
 	"go.pinniped.dev/internal/federationdomain/csp"
+	"go.pinniped.dev/internal/i18n"
 )
 
 //nolint:gochecknoglobals // This package uses globals to ensure that all parsing and minifying happens at init.
@@ -27,23 +31,62 @@ var (
 	//go:embed form_post.gohtml
 	rawHTMLTemplate string
 
-	// Parse the Go templated HTML and inject functions providing the minified inline CSS and JS.
-	parsedHTMLTemplate = template.Must(template.New("form_post.gohtml").Funcs(template.FuncMap{
-		"minifiedCSS": func() template.CSS { return template.CSS(minifiedCSS) },
-		"minifiedJS":  func() template.JS { return template.JS(minifiedJS) }, //nolint:gosec // This is 100% static input, not attacker-controlled.
-	}).Parse(rawHTMLTemplate))
+	// Parse the Go templated HTML once for the default branding and language.
+	parsedHTMLTemplate = parseTemplate(func() *branding.PageBranding { return nil }, i18n.Default())
+
+	// The templates for the default branding in each language, which are parsed on first use.
+	unbrandedTemplates = newLocalizedTemplates(func() *branding.PageBranding { return nil })
 
 	// Generate the CSP header value once since it's effectively constant.
 	cspValue = strings.Join([]string{
 		`default-src 'none'`,
 		`script-src '` + csp.Hash(minifiedJS) + `'`,
-		`style-src '` + csp.Hash(minifiedCSS) + `'`,
-		`img-src data:`,
+		`style-src '` + csp.Hash(minifiedCSS) + `' 'self'`,
+		`img-src data: 'self'`,
 		`connect-src *`,
 		`frame-ancestors 'none'`,
 	}, "; ")
 )
 
+// parseTemplate parses the Go templated HTML and injects functions providing the minified inline CSS and JS,
+// the branding of the page, and the localized messages of the page.
+func parseTemplate(getBranding func() *branding.PageBranding, localizer *i18n.Localizer) *template.Template {
+	return template.Must(template.New("form_post.gohtml").Funcs(template.FuncMap{
+		"minifiedCSS": func() template.CSS { return template.CSS(minifiedCSS) },
+		"minifiedJS":  func() template.JS { return template.JS(minifiedJS) }, //nolint:gosec // This is 100% static input, not attacker-controlled.
+		"branding":    getBranding,
+		"lang":        localizer.Language,
+		"msg":         localizer.T,
+	}).Parse(rawHTMLTemplate))
+}
+
+// localizedTemplates lazily parses and caches one template per language, since the template functions which
+// provide the localized messages must be bound when the template is parsed.
+type localizedTemplates struct {
+	getBranding func() *branding.PageBranding
+
+	mu        sync.Mutex
+	templates map[string]*template.Template
+}
+
+func newLocalizedTemplates(getBranding func() *branding.PageBranding) *localizedTemplates {
+	return &localizedTemplates{getBranding: getBranding, templates: map[string]*template.Template{}}
+}
+
+func (l *localizedTemplates) forContext(ctx context.Context) *template.Template {
+	localizer := i18n.FromContext(ctx)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	t, ok := l.templates[localizer.Language()]
+	if !ok {
+		t = parseTemplate(l.getBranding, localizer)
+		l.templates[localizer.Language()] = t
+	}
+	return t
+}
+
 func panicOnError(s string, err error) string {
 	if err != nil {
 		panic(err)
@@ -56,5 +99,19 @@ func panicOnError(s string, err error) string {
 // See https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Content-Security-Policy.
 func ContentSecurityPolicy() string { return cspValue }
 
-// Template returns the html/template.Template for rendering the response_type=form_post response page.
+// Template returns the html/template.Template for rendering the response_type=form_post response page in English.
 func Template() *template.Template { return parsedHTMLTemplate }
+
+// TemplateForContext returns an html/template.Template like Template(), except that the page is rendered in the
+// language of the i18n.Localizer of the context.
+func TemplateForContext(ctx context.Context) *template.Template {
+	return unbrandedTemplates.forContext(ctx)
+}
+
+// TemplateWithBranding returns a function which returns an html/template.Template like TemplateForContext(), except
+// that each rendering of the page uses the branding returned by getBranding at that time. A nil branding renders the
+// same page as TemplateForContext(). The issuerPath is the path of the FederationDomain's issuer, which is used to
+// build the paths of the branding assets.
+func TemplateWithBranding(issuerPath string, getBranding func() *branding.Branding) func(ctx context.Context) *template.Template {
+	return newLocalizedTemplates(func() *branding.PageBranding { return getBranding().ForPage(issuerPath) }).forContext
+}
@@ -1,10 +1,11 @@
-// Copyright 2021-2023 the Pinniped contributors. All Rights Reserved.
+// Copyright 2021-2024 the Pinniped contributors. All Rights Reserved.
 // SPDX-License-Identifier: Apache-2.0
 
 package formposthtml
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net/url"
 	"testing"
@@ -12,7 +13,9 @@ import (
 	"github.com/ory/fosite"
 	"github.com/stretchr/testify/require"
 
+	"go.pinniped.dev/internal/federationdomain/branding"
 	"go.pinniped.dev/internal/here"
+	"go.pinniped.dev/internal/i18n"
 )
 
 var (
@@ -29,7 +32,7 @@ var (
         <html lang="en">
         <head>
             <meta charset="UTF-8">
-            <style>body{font-family:metropolis-light,Helvetica,sans-serif}h1{font-size:20px}.state{position:absolute;top:100px;left:50%;width:400px;height:80px;margin-top:-40px;margin-left:-200px;font-size:14px;line-height:24px}button{margin:-10px;padding:10px;text-align:left;width:100%;display:inline;border:none;background:0 0;cursor:pointer;transition:all .1s}button:hover{background-color:#eee;transform:scale(1.01)}button:active{background-color:#ddd;transform:scale(.99)}code{display:block;word-wrap:break-word;word-break:break-all;font-size:12px;font-family:monospace;color:#333}.copy-icon{float:left;width:36px;height:36px;margin-top:-3px;margin-right:10px;background-size:contain;background-repeat:no-repeat;background-image:url("data:image/svg+xml,%3Csvg version='1.1' width='36' height='36' viewBox='0 0 36 36' preserveAspectRatio='xMidYMid meet' xmlns='http://www.w3.org/2000/svg' xmlns:xlink='http://www.w3.org/1999/xlink'%3E%3Ctitle%3Ecopy-to-clipboard-line%3C/title%3E%3Cpath d='M22.6,4H21.55a3.89,3.89,0,0,0-7.31,0H13.4A2.41,2.41,0,0,0,11,6.4V10H25V6.4A2.41,2.41,0,0,0,22.6,4ZM23,8H13V6.25A.25.25,0,0,1,13.25,6h2.69l.12-1.11A1.24,1.24,0,0,1,16.61,4a2,2,0,0,1,3.15,1.18l.09.84h2.9a.25.25,0,0,1,.25.25Z' class='clr-i-outline clr-i-outline-path-1'%3E%3C/path%3E%3Cpath d='M33.25,18.06H21.33l2.84-2.83a1,1,0,1,0-1.42-1.42L17.5,19.06l5.25,5.25a1,1,0,0,0,.71.29,1,1,0,0,0,.71-1.7l-2.84-2.84H33.25a1,1,0,0,0,0-2Z' class='clr-i-outline clr-i-outline-path-2'%3E%3C/path%3E%3Cpath d='M29,16h2V6.68A1.66,1.66,0,0,0,29.35,5H27.08V7H29Z' class='clr-i-outline clr-i-outline-path-3'%3E%3C/path%3E%3Cpath d='M29,31H7V7H9V5H6.64A1.66,1.66,0,0,0,5,6.67V31.32A1.66,1.66,0,0,0,6.65,33H29.36A1.66,1.66,0,0,0,31,31.33V22.06H29Z' class='clr-i-outline clr-i-outline-path-4'%3E%3C/path%3E%3Crect x='0' y='0' width='36' height='36' fill-opacity='0'/%3E%3C/svg%3E")}.error{font-family:monospace}@keyframes loader{to{transform:rotate(360deg)}}#loading{content:'';box-sizing:border-box;width:80px;height:80px;margin-top:-40px;margin-left:-40px;border-radius:50%;border:2px solid #fff;border-top-color:#1b3951;animation:loader .6s linear infinite}</style>
+            <style>body{font-family:metropolis-light,Helvetica,sans-serif}h1{font-size:20px}.state{position:absolute;top:100px;left:50%;width:400px;height:80px;margin-top:-40px;margin-left:-200px;font-size:14px;line-height:24px}button{margin:-10px;padding:10px;text-align:left;width:100%;display:inline;border:none;background:0 0;cursor:pointer;transition:all .1s}button:hover{background-color:#eee;transform:scale(1.01)}button:active{background-color:#ddd;transform:scale(.99)}code{display:block;word-wrap:break-word;word-break:break-all;font-size:12px;font-family:monospace;color:#333}.copy-icon{float:left;width:36px;height:36px;margin-top:-3px;margin-right:10px;background-size:contain;background-repeat:no-repeat;background-image:url("data:image/svg+xml,%3Csvg version='1.1' width='36' height='36' viewBox='0 0 36 36' preserveAspectRatio='xMidYMid meet' xmlns='http://www.w3.org/2000/svg' xmlns:xlink='http://www.w3.org/1999/xlink'%3E%3Ctitle%3Ecopy-to-clipboard-line%3C/title%3E%3Cpath d='M22.6,4H21.55a3.89,3.89,0,0,0-7.31,0H13.4A2.41,2.41,0,0,0,11,6.4V10H25V6.4A2.41,2.41,0,0,0,22.6,4ZM23,8H13V6.25A.25.25,0,0,1,13.25,6h2.69l.12-1.11A1.24,1.24,0,0,1,16.61,4a2,2,0,0,1,3.15,1.18l.09.84h2.9a.25.25,0,0,1,.25.25Z' class='clr-i-outline clr-i-outline-path-1'%3E%3C/path%3E%3Cpath d='M33.25,18.06H21.33l2.84-2.83a1,1,0,1,0-1.42-1.42L17.5,19.06l5.25,5.25a1,1,0,0,0,.71.29,1,1,0,0,0,.71-1.7l-2.84-2.84H33.25a1,1,0,0,0,0-2Z' class='clr-i-outline clr-i-outline-path-2'%3E%3C/path%3E%3Cpath d='M29,16h2V6.68A1.66,1.66,0,0,0,29.35,5H27.08V7H29Z' class='clr-i-outline clr-i-outline-path-3'%3E%3C/path%3E%3Cpath d='M29,31H7V7H9V5H6.64A1.66,1.66,0,0,0,5,6.67V31.32A1.66,1.66,0,0,0,6.65,33H29.36A1.66,1.66,0,0,0,31,31.33V22.06H29Z' class='clr-i-outline clr-i-outline-path-4'%3E%3C/path%3E%3Crect x='0' y='0' width='36' height='36' fill-opacity='0'/%3E%3C/svg%3E")}.error{font-family:monospace}@keyframes loader{to{transform:rotate(360deg)}}#loading{content:'';box-sizing:border-box;width:80px;height:80px;margin-top:-40px;margin-left:-40px;border-radius:50%;border:2px solid #fff;border-top-color:#1b3951;animation:loader .6s linear infinite}.logo{margin-top:10px;text-align:center}.logo img{max-width:400px;max-height:80px}.footer{position:absolute;bottom:20px;width:100%;text-align:center;font-size:12px;color:#666}</style>
             <script>window.onload=()=>{const e=(e,t)=>{e==="error"&&(document.getElementById("message").innerText=t),Array.from(document.querySelectorAll(".state")).forEach(e=>e.hidden=!0);const n=document.getElementById(e);n.hidden=!1,document.title=n.dataset.title,document.getElementById("favicon").setAttribute("href","data:image/svg+xml,<svg xmlns=%22http://www.w3.org/2000/svg%22 viewBox=%220 0 100 100%22><text y=%22.9em%22 font-size=%2290%22>"+n.dataset.favicon+"</text></svg>")};e("loading"),window.history.replaceState(null,"","./"),document.getElementById("manual-copy-button").onclick=()=>{const e=document.getElementById("manual-copy-button").innerText;navigator.clipboard.writeText(e).then(()=>console.info("copied authorization code "+e+" to clipboard")).catch(t=>console.error("failed to copy code "+e+" to clipboard: "+t))};const n=setTimeout(()=>e("manual"),2e3),t=document.forms[0].elements;fetch(t.redirect_uri.value,{method:"POST",mode:"cors",headers:{"Content-Type":"application/x-www-form-urlencoded;charset=UTF-8"},body:t.encoded_params.value}).then(t=>{clearTimeout(n),t.ok?e("success"):t.text().then(function(n){e("error",t.status+": "+n)}).catch(n=>{console.error("error while reading response.text()",n),e("error",t.status+": [could not read response body]")})}).catch(()=>e("manual"))}</script>
             <link id="favicon" rel="icon"/>
         </head>
@@ -67,8 +70,8 @@ var (
 	// Our browser-based integration tests should find any incompatibilities.
 	testExpectedCSP = `default-src 'none'; ` +
 		`script-src 'sha256-fiAdxAQHPoodG4cbENki/1TI+cjBOXxw+ADCoCtepQo='; ` +
-		`style-src 'sha256-p+fPKq5SYyVeT46EkDVZx28MRQ6wlWHdDm3o3qZFGTA='; ` +
-		`img-src data:; ` +
+		`style-src 'sha256-L0IaU4ZvNzKjtFrDiZycMOr07Lhrc9sLugXsoEIflbA=' 'self'; ` +
+		`img-src data: 'self'; ` +
 		`connect-src *; ` +
 		`frame-ancestors 'none'`
 )
@@ -93,6 +96,58 @@ func TestTemplate(t *testing.T) {
 	require.Equal(t, testExpectedFormPostOutput, buf.String())
 }
 
+func TestTemplateWithBranding(t *testing.T) {
+	var currentBranding *branding.Branding
+	subject := TemplateWithBranding("/some/issuer", func() *branding.Branding { return currentBranding })
+
+	render := func() string {
+		var buf bytes.Buffer
+		fosite.WriteAuthorizeFormPostResponse(testRedirectURL, testResponseParams, subject(context.Background()), &buf)
+		return buf.String()
+	}
+
+	// Without branding, the page is the same as the default page.
+	require.Equal(t, testExpectedFormPostOutput, render())
+
+	// The branding is read again each time that the page is rendered.
+	currentBranding = &branding.Branding{
+		Logo:               []byte("fake-logo"),
+		CustomCSS:          "h1 { color: red; }",
+		FooterText:         "some <footer>",
+		LoginFailedMessage: "Please contact the help desk.",
+	}
+	page := render()
+	require.Contains(t, page, "</style>\n"+`    <link rel="stylesheet" href="/some/issuer/branding/style.css">`+"\n")
+	require.Contains(t, page, "<body>\n"+`<div class="logo"><img src="/some/issuer/branding/logo" alt="logo"></div>`+"\n<noscript>")
+	require.Contains(t, page, "<p>Please contact the help desk.</p>\n</div>\n"+`<footer class="footer">some &lt;footer&gt;</footer>`+"\n</body>")
+	require.NotContains(t, page, "Please try again.")
+
+	currentBranding = nil
+	require.Equal(t, testExpectedFormPostOutput, render())
+}
+
+func TestTemplateForContext(t *testing.T) {
+	render := func(ctx context.Context) string {
+		var buf bytes.Buffer
+		fosite.WriteAuthorizeFormPostResponse(testRedirectURL, testResponseParams, TemplateForContext(ctx), &buf)
+		return buf.String()
+	}
+
+	// Without a language, the page is the same as the default page.
+	require.Equal(t, testExpectedFormPostOutput, render(context.Background()))
+
+	page := render(i18n.WithLocalizer(context.Background(), i18n.ForLanguage("es")))
+	require.Contains(t, page, `<html lang="es">`)
+	require.Contains(t, page, `<div id="success" class="state" data-favicon="✅" data-title="Inicio de sesión correcto" hidden>`)
+	require.Contains(t, page, "<h1>Error durante el inicio de sesión</h1>")
+	require.Contains(t, page, "<p>Vuelva a intentarlo.</p>")
+	require.NotContains(t, page, "Please try again.")
+
+	// The template for each language is only parsed once.
+	ctx := i18n.WithLocalizer(context.Background(), i18n.ForLanguage("de"))
+	require.Same(t, TemplateForContext(ctx), TemplateForContext(ctx))
+}
+
 func TestContentSecurityPolicyHashes(t *testing.T) {
 	require.Equal(t, testExpectedCSP, ContentSecurityPolicy())
 }
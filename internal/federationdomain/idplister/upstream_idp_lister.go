@@ -23,9 +23,24 @@ type UpstreamGitHubIdentityProviderLister interface {
 	GetGitHubIdentityProviders() []upstreamprovider.UpstreamGithubIdentityProviderI
 }
 
+type UpstreamClientCertificateIdentityProviderLister interface {
+	GetClientCertificateIdentityProviders() []upstreamprovider.UpstreamClientCertificateIdentityProviderI
+}
+
+type UpstreamMockIdentityProviderLister interface {
+	GetMockIdentityProviders() []upstreamprovider.UpstreamMockIdentityProviderI
+}
+
+type UpstreamOpenShiftIdentityProviderLister interface {
+	GetOpenShiftIdentityProviders() []upstreamprovider.UpstreamOpenShiftIdentityProviderI
+}
+
 type UpstreamIdentityProvidersLister interface {
 	UpstreamOIDCIdentityProvidersLister
 	UpstreamLDAPIdentityProvidersLister
 	UpstreamActiveDirectoryIdentityProviderLister
 	UpstreamGitHubIdentityProviderLister
+	UpstreamClientCertificateIdentityProviderLister
+	UpstreamMockIdentityProviderLister
+	UpstreamOpenShiftIdentityProviderLister
 }
@@ -0,0 +1,159 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package jarm implements the JWT Secured Authorization Response Mode for OAuth 2.0 (JARM) for the downstream
+// authorization endpoint. Instead of returning the params of the authorization response individually, they are
+// returned in a single JWT which is signed by the FederationDomain, so the client can check that the response was
+// issued by the FederationDomain for that client, and that it was not changed on the way.
+package jarm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/ory/fosite"
+
+	"go.pinniped.dev/internal/federationdomain/endpoints/jwks"
+	"go.pinniped.dev/internal/plog"
+)
+
+const (
+	// ResponseModeJWT is the response_mode for a JWT response in the default mode of the response type. For the
+	// code response type, this is the same as ResponseModeQueryJWT.
+	ResponseModeJWT fosite.ResponseModeType = "jwt"
+
+	// ResponseModeQueryJWT is the response_mode for a JWT response which is sent in the query of the redirect URI.
+	ResponseModeQueryJWT fosite.ResponseModeType = "query.jwt"
+
+	// ResponseParam is the name of the param of the redirect URI which holds the JWT.
+	ResponseParam = "response"
+)
+
+// ResponseModes returns the JWT response modes which are supported.
+func ResponseModes() fosite.ResponseModeTypes {
+	return fosite.ResponseModeTypes{ResponseModeJWT, ResponseModeQueryJWT}
+}
+
+// ResponseModeHandler writes the authorization responses and errors for the JWT response modes.
+type ResponseModeHandler struct {
+	issuer       string
+	jwksProvider jwks.DynamicJWKSProvider
+	lifetime     time.Duration
+	now          func() time.Time
+}
+
+var _ fosite.ResponseModeHandler = (*ResponseModeHandler)(nil)
+
+// NewResponseModeHandler returns a ResponseModeHandler which signs the responses using the active key of the
+// issuer. Each response expires after the lifetime, which should be the lifetime of the authorization code that it
+// carries, since the response is useless after that.
+func NewResponseModeHandler(issuer string, jwksProvider jwks.DynamicJWKSProvider, lifetime time.Duration, now func() time.Time) *ResponseModeHandler {
+	return &ResponseModeHandler{
+		issuer:       issuer,
+		jwksProvider: jwksProvider,
+		lifetime:     lifetime,
+		now:          now,
+	}
+}
+
+func (h *ResponseModeHandler) ResponseModes() fosite.ResponseModeTypes {
+	return ResponseModes()
+}
+
+func (h *ResponseModeHandler) WriteAuthorizeResponse(ctx context.Context, rw http.ResponseWriter, ar fosite.AuthorizeRequester, resp fosite.AuthorizeResponder) {
+	for key := range resp.GetHeader() {
+		rw.Header().Set(key, resp.GetHeader().Get(key))
+	}
+	h.redirect(ctx, rw, ar, resp.GetParameters())
+}
+
+func (h *ResponseModeHandler) WriteAuthorizeError(ctx context.Context, rw http.ResponseWriter, ar fosite.AuthorizeRequester, err error) {
+	rfc6749Error := fosite.ErrorToRFC6749Error(err)
+
+	if !ar.IsRedirectURIValid() {
+		// There is nowhere safe to redirect to, so respond directly, as in the other response modes.
+		writeJSONError(rw, rfc6749Error)
+		return
+	}
+
+	params := rfc6749Error.ToValues()
+	if state := ar.GetState(); state != "" {
+		params.Set("state", state)
+	}
+	h.redirect(ctx, rw, ar, params)
+}
+
+func (h *ResponseModeHandler) redirect(ctx context.Context, rw http.ResponseWriter, ar fosite.AuthorizeRequester, params url.Values) {
+	response, err := h.sign(ctx, ar.GetClient().GetID(), params)
+	if err != nil {
+		plog.WarningErr("could not sign JWT secured authorization response", err, "issuer", h.issuer)
+		writeJSONError(rw, fosite.ErrorToRFC6749Error(err))
+		return
+	}
+
+	redirectURI := *ar.GetRedirectURI()
+	query := redirectURI.Query()
+	query.Set(ResponseParam, response)
+	redirectURI.RawQuery = query.Encode()
+
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.Header().Set("Pragma", "no-cache")
+	rw.Header().Set("Location", redirectURI.String())
+	rw.WriteHeader(http.StatusSeeOther)
+}
+
+// sign returns a JWT which holds the params of the authorization response, along with the claims which identify
+// the issuer and the intended audience of the response, as required by JARM.
+func (h *ResponseModeHandler) sign(ctx context.Context, clientID string, params url.Values) (string, error) {
+	_, activeJWK := h.jwksProvider.GetJWKS(h.issuer)
+	if activeJWK == nil {
+		return "", fosite.ErrTemporarilyUnavailable.WithDebug("no JWK found for issuer")
+	}
+	alg, err := jwks.SigningAlgorithm(activeJWK)
+	if err != nil {
+		return "", fosite.ErrServerError.WithDebug(err.Error())
+	}
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: alg, Key: jose.JSONWebKey{Key: jwks.SigningKey(ctx, activeJWK), KeyID: activeJWK.KeyID}},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	if err != nil {
+		return "", fosite.ErrServerError.WithWrap(err).WithDebug(err.Error())
+	}
+
+	paramClaims := make(map[string]any, len(params))
+	for name := range params {
+		paramClaims[name] = params.Get(name)
+	}
+
+	now := h.now()
+	response, err := jwt.Signed(signer).
+		Claims(paramClaims).
+		Claims(jwt.Claims{
+			Issuer:   h.issuer,
+			Audience: jwt.Audience{clientID},
+			Expiry:   jwt.NewNumericDate(now.Add(h.lifetime)),
+		}).
+		CompactSerialize()
+	if err != nil {
+		return "", fosite.ErrServerError.WithWrap(err).WithDebug(err.Error())
+	}
+	return response, nil
+}
+
+func writeJSONError(rw http.ResponseWriter, rfc6749Error *fosite.RFC6749Error) {
+	rw.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.Header().Set("Pragma", "no-cache")
+	rw.WriteHeader(rfc6749Error.StatusCode())
+	_ = json.NewEncoder(rw).Encode(map[string]string{
+		"error":             rfc6749Error.ErrorField,
+		"error_description": rfc6749Error.GetDescription(),
+	})
+}
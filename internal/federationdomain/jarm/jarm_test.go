@@ -0,0 +1,188 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package jarm
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/ory/fosite"
+	"github.com/stretchr/testify/require"
+
+	"go.pinniped.dev/internal/federationdomain/endpoints/jwks"
+)
+
+func TestResponseModeHandler(t *testing.T) {
+	const (
+		issuer      = "https://issuer.example.com/some/path"
+		clientID    = "client.oauth.pinniped.dev-some-client"
+		redirectURI = "https://client.example.com/callback?some-param=some-value"
+	)
+
+	now := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	newAuthorizeRequest := func(t *testing.T, redirectURI string) *fosite.AuthorizeRequest {
+		t.Helper()
+		parsedRedirectURI, err := url.Parse(redirectURI)
+		require.NoError(t, err)
+		ar := fosite.NewAuthorizeRequest()
+		ar.Client = &fosite.DefaultClient{ID: clientID, RedirectURIs: []string{redirectURI}}
+		ar.RedirectURI = parsedRedirectURI
+		ar.State = "some-state"
+		ar.ResponseMode = ResponseModeQueryJWT
+		return ar
+	}
+
+	newSubject := func(activeJWK *jose.JSONWebKey) *ResponseModeHandler {
+		jwksProvider := jwks.NewDynamicJWKSProvider()
+		if activeJWK != nil {
+			jwksProvider.SetIssuerToJWKSMap(
+				map[string]*jose.JSONWebKeySet{issuer: {Keys: []jose.JSONWebKey{activeJWK.Public()}}},
+				map[string]*jose.JSONWebKey{issuer: activeJWK},
+			)
+		}
+		return NewResponseModeHandler(issuer, jwksProvider, 10*time.Minute, func() time.Time { return now })
+	}
+
+	requireResponse := func(t *testing.T, rsp *httptest.ResponseRecorder, wantClaims map[string]any) {
+		t.Helper()
+		require.Equal(t, http.StatusSeeOther, rsp.Code)
+		require.Equal(t, "no-store", rsp.Header().Get("Cache-Control"))
+
+		location, err := url.Parse(rsp.Header().Get("Location"))
+		require.NoError(t, err)
+		require.Equal(t, "https://client.example.com/callback", location.Scheme+"://"+location.Host+location.Path)
+		require.Equal(t, []string{"response", "some-param"}, sortedKeys(location.Query()))
+		require.Equal(t, "some-value", location.Query().Get("some-param"))
+
+		token, err := jwt.ParseSigned(location.Query().Get(ResponseParam))
+		require.NoError(t, err)
+		require.Len(t, token.Headers, 1)
+		require.Equal(t, "some-key-id", token.Headers[0].KeyID)
+		require.Equal(t, "ES256", token.Headers[0].Algorithm)
+
+		var claims map[string]any
+		require.NoError(t, token.Claims(&key.PublicKey, &claims))
+		require.Equal(t, wantClaims, claims)
+	}
+
+	t.Run("ResponseModes", func(t *testing.T) {
+		require.Equal(t, fosite.ResponseModeTypes{"jwt", "query.jwt"}, newSubject(nil).ResponseModes())
+	})
+
+	t.Run("WriteAuthorizeResponse", func(t *testing.T) {
+		resp := fosite.NewAuthorizeResponse()
+		resp.AddParameter("code", "some-code")
+		resp.AddParameter("state", "some-state")
+		resp.AddParameter("scope", "openid username")
+		resp.AddHeader("Some-Header", "some-header-value")
+
+		rsp := httptest.NewRecorder()
+		newSubject(&jose.JSONWebKey{Key: key, KeyID: "some-key-id", Algorithm: "ES256", Use: "sig"}).
+			WriteAuthorizeResponse(context.Background(), rsp, newAuthorizeRequest(t, redirectURI), resp)
+
+		require.Equal(t, "some-header-value", rsp.Header().Get("Some-Header"))
+		requireResponse(t, rsp, map[string]any{
+			"iss":   issuer,
+			"aud":   clientID,
+			"exp":   float64(now.Add(10 * time.Minute).Unix()),
+			"code":  "some-code",
+			"state": "some-state",
+			"scope": "openid username",
+		})
+	})
+
+	t.Run("WriteAuthorizeError", func(t *testing.T) {
+		rsp := httptest.NewRecorder()
+		newSubject(&jose.JSONWebKey{Key: key, KeyID: "some-key-id", Algorithm: "ES256", Use: "sig"}).
+			WriteAuthorizeError(context.Background(), rsp, newAuthorizeRequest(t, redirectURI), fosite.ErrAccessDenied.WithHint("Some hint."))
+
+		requireResponse(t, rsp, map[string]any{
+			"iss":               issuer,
+			"aud":               clientID,
+			"exp":               float64(now.Add(10 * time.Minute).Unix()),
+			"error":             "access_denied",
+			"error_description": "The resource owner or authorization server denied the request. Some hint.",
+			"state":             "some-state",
+		})
+	})
+
+	t.Run("WriteAuthorizeError when the redirect URI is not valid", func(t *testing.T) {
+		ar := newAuthorizeRequest(t, redirectURI)
+		ar.Client = &fosite.DefaultClient{ID: clientID, RedirectURIs: []string{"https://some-other-uri.example.com"}}
+
+		rsp := httptest.NewRecorder()
+		newSubject(&jose.JSONWebKey{Key: key, KeyID: "some-key-id", Algorithm: "ES256", Use: "sig"}).
+			WriteAuthorizeError(context.Background(), rsp, ar, fosite.ErrInvalidRequest)
+
+		require.Equal(t, http.StatusBadRequest, rsp.Code)
+		require.Empty(t, rsp.Header().Get("Location"))
+		require.Equal(t, "application/json;charset=UTF-8", rsp.Header().Get("Content-Type"))
+		require.JSONEq(t, `{
+			"error": "invalid_request",
+			"error_description": "The request is missing a required parameter, includes an invalid parameter value, includes a parameter more than once, or is otherwise malformed. Make sure that the various parameters are correct, be aware of case sensitivity and trim your parameters. Make sure that the client you are using has exactly whitelisted the redirect_uri you specified."
+		}`, rsp.Body.String())
+	})
+
+	t.Run("WriteAuthorizeResponse signs using the algorithm of the active key", func(t *testing.T) {
+		_, edKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		resp := fosite.NewAuthorizeResponse()
+		resp.AddParameter("code", "some-code")
+
+		rsp := httptest.NewRecorder()
+		newSubject(&jose.JSONWebKey{Key: edKey, KeyID: "some-key-id", Algorithm: "EdDSA", Use: "sig"}).
+			WriteAuthorizeResponse(context.Background(), rsp, newAuthorizeRequest(t, redirectURI), resp)
+
+		require.Equal(t, http.StatusSeeOther, rsp.Code)
+		location, err := url.Parse(rsp.Header().Get("Location"))
+		require.NoError(t, err)
+		token, err := jwt.ParseSigned(location.Query().Get(ResponseParam))
+		require.NoError(t, err)
+		require.Len(t, token.Headers, 1)
+		require.Equal(t, "EdDSA", token.Headers[0].Algorithm)
+
+		var claims map[string]any
+		require.NoError(t, token.Claims(edKey.Public(), &claims))
+		require.Equal(t, "some-code", claims["code"])
+	})
+
+	t.Run("WriteAuthorizeResponse when there is no signing key", func(t *testing.T) {
+		resp := fosite.NewAuthorizeResponse()
+		resp.AddParameter("code", "some-code")
+
+		rsp := httptest.NewRecorder()
+		newSubject(nil).WriteAuthorizeResponse(context.Background(), rsp, newAuthorizeRequest(t, redirectURI), resp)
+
+		require.Equal(t, http.StatusServiceUnavailable, rsp.Code)
+		require.Empty(t, rsp.Header().Get("Location"))
+		require.JSONEq(t, `{
+			"error": "temporarily_unavailable",
+			"error_description": "The authorization server is currently unable to handle the request due to a temporary overloading or maintenance of the server."
+		}`, rsp.Body.String())
+	})
+}
+
+func sortedKeys(values url.Values) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,198 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package loadshed limits the number of concurrent requests to the token endpoint of a FederationDomain. When
+// the limit is reached, requests wait in a queue in which interactive requests are always served before bulk
+// requests, and requests which wait for too long are shed.
+package loadshed
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+
+	oidcapi "go.pinniped.dev/generated/latest/apis/supervisor/oidc"
+	"go.pinniped.dev/internal/constable"
+	"go.pinniped.dev/internal/plog"
+)
+
+const (
+	DefaultMaxConcurrentRequests = 50
+	DefaultQueueTimeout          = 5 * time.Second
+
+	// ErrQueueTimeout is returned by Acquire when a request waited in the queue for longer than the queue timeout.
+	ErrQueueTimeout = constable.Error("timed out waiting for the token endpoint to accept the request")
+)
+
+// Priority classifies requests to the token endpoint. Lower values are served first.
+type Priority int
+
+const (
+	// PriorityInteractive is for the grants which are used by interactive users, i.e. the authorization code and
+	// refresh token grants. A user is often waiting for these requests to finish.
+	PriorityInteractive Priority = iota
+	// PriorityBulk is for all other grants, e.g. the client credentials and RFC8693 token exchange grants, which
+	// are typically used by automation which can retry later.
+	PriorityBulk
+
+	numPriorities = int(PriorityBulk) + 1
+)
+
+func (p Priority) String() string {
+	if p == PriorityInteractive {
+		return "interactive"
+	}
+	return "bulk"
+}
+
+// Config holds the settings for a Limiter.
+type Config struct {
+	// MaxConcurrentRequests is the number of requests which may be processed at the same time.
+	MaxConcurrentRequests int
+	// QueueTimeout is how long a request may wait in the queue before it is shed.
+	QueueTimeout time.Duration
+}
+
+type waiter struct {
+	ready   chan struct{}
+	granted bool
+}
+
+// Limiter limits the number of requests which are processed concurrently.
+//
+// It is thread-safe.
+type Limiter struct {
+	config Config
+	clock  clock.Clock
+	log    plog.Logger
+
+	mu           sync.Mutex
+	inFlight     int
+	queues       [numPriorities]*list.List // of *waiter, in arrival order
+	issuerForLog string
+}
+
+// New returns a Limiter. The issuer is used for logging and metrics.
+func New(issuer string, config Config, clock clock.Clock, log plog.Logger) *Limiter {
+	l := &Limiter{
+		config:       config,
+		clock:        clock,
+		log:          log,
+		issuerForLog: issuer,
+	}
+	for i := range l.queues {
+		l.queues[i] = list.New()
+	}
+	return l
+}
+
+// Config returns the settings of this Limiter.
+func (l *Limiter) Config() Config {
+	return l.config
+}
+
+// Acquire waits until the request may be processed, and returns a function which must be called when the
+// request is finished. Returns ErrQueueTimeout when the request waited in the queue for longer than the queue
+// timeout, or the error of the context when it was canceled while waiting.
+func (l *Limiter) Acquire(ctx context.Context, priority Priority) (func(), error) {
+	l.mu.Lock()
+	// Slots are handed directly to waiting requests when they are released,
+	// so a free slot means that there are no waiting requests.
+	if l.inFlight < l.config.MaxConcurrentRequests {
+		l.inFlight++
+		l.mu.Unlock()
+		return l.release, nil
+	}
+	w := &waiter{ready: make(chan struct{})}
+	element := l.queues[priority].PushBack(w)
+	l.mu.Unlock()
+
+	timer := l.clock.NewTimer(l.config.QueueTimeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-w.ready:
+		return l.release, nil
+	case <-timer.C():
+		err = ErrQueueTimeout
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if w.granted {
+		// A slot was handed to this request at the same time as it gave up, so use it anyway.
+		return l.release, nil
+	}
+	l.queues[priority].Remove(element)
+	return nil, err
+}
+
+// release hands the slot of a finished request to the first waiting request of the highest priority,
+// or frees the slot when there are no waiting requests.
+func (l *Limiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, queue := range l.queues {
+		if front := queue.Front(); front != nil {
+			w := queue.Remove(front).(*waiter)
+			w.granted = true
+			close(w.ready)
+			return
+		}
+	}
+	l.inFlight--
+}
+
+// Waiting returns the number of requests of the given priority which are waiting in the queue.
+func (l *Limiter) Waiting(priority Priority) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.queues[priority].Len()
+}
+
+// WrapHandler returns a handler which limits the number of concurrent calls to the delegate handler, which should
+// be a token endpoint handler. Requests which are shed receive an HTTP 503 response with a Retry-After header.
+func (l *Limiter) WrapHandler(delegate http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		priority := Classify(r)
+		release, err := l.Acquire(r.Context(), priority)
+		if err != nil {
+			if !errors.Is(err, ErrQueueTimeout) {
+				return // the client went away, so there is nobody to respond to
+			}
+			shedRequests.WithLabelValues(l.issuerForLog, priority.String()).Inc()
+			l.log.Info("rejected token request because the token endpoint is overloaded",
+				"event", "TokenEndpointLoadShed",
+				"issuer", l.issuerForLog,
+				"priority", priority.String(),
+			)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(l.config.QueueTimeout.Seconds()))))
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		defer release()
+		delegate.ServeHTTP(w, r)
+	})
+}
+
+// Classify returns the priority of a request to the token endpoint, based on its grant type.
+func Classify(r *http.Request) Priority {
+	switch r.PostFormValue("grant_type") {
+	case oidcapi.GrantTypeAuthorizationCode, oidcapi.GrantTypeRefreshToken:
+		return PriorityInteractive
+	default: // e.g. client_credentials and token exchange
+		return PriorityBulk
+	}
+}
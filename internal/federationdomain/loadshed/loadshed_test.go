@@ -0,0 +1,168 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package loadshed
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/component-base/metrics/testutil"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"go.pinniped.dev/internal/plog"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		grantType string
+		want      Priority
+	}{
+		{grantType: "authorization_code", want: PriorityInteractive},
+		{grantType: "refresh_token", want: PriorityInteractive},
+		{grantType: "urn:ietf:params:oauth:grant-type:token-exchange", want: PriorityBulk},
+		{grantType: "client_credentials", want: PriorityBulk},
+		{grantType: "", want: PriorityBulk},
+	}
+	for _, tt := range tests {
+		t.Run(tt.grantType, func(t *testing.T) {
+			require.Equal(t, tt.want, Classify(tokenRequest(tt.grantType)))
+		})
+	}
+}
+
+func TestAcquire(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Date(2099, 8, 8, 13, 57, 36, 0, time.UTC))
+	limiter := New("https://issuer.example.com", Config{MaxConcurrentRequests: 2, QueueTimeout: 5 * time.Second}, fakeClock, plog.New())
+
+	release1, err := limiter.Acquire(context.Background(), PriorityBulk)
+	require.NoError(t, err)
+	release2, err := limiter.Acquire(context.Background(), PriorityBulk)
+	require.NoError(t, err)
+
+	// Both slots are used, so the next requests must wait. The interactive request arrives last but is served first.
+	type result struct {
+		priority Priority
+		release  func()
+		err      error
+	}
+	results := make(chan result, 2)
+	acquire := func(priority Priority) {
+		release, err := limiter.Acquire(context.Background(), priority)
+		results <- result{priority: priority, release: release, err: err}
+	}
+	go acquire(PriorityBulk)
+	require.Eventually(t, func() bool { return limiter.Waiting(PriorityBulk) == 1 }, time.Minute, time.Millisecond)
+	go acquire(PriorityInteractive)
+	require.Eventually(t, func() bool { return limiter.Waiting(PriorityInteractive) == 1 }, time.Minute, time.Millisecond)
+
+	release1()
+	got := <-results
+	require.Equal(t, PriorityInteractive, got.priority)
+	require.NoError(t, got.err)
+	releaseInteractive := got.release
+
+	release2()
+	got = <-results
+	require.Equal(t, PriorityBulk, got.priority)
+	require.NoError(t, got.err)
+	releaseBulk := got.release
+
+	// A request which waits for longer than the queue timeout is shed.
+	go acquire(PriorityInteractive)
+	require.Eventually(t, func() bool { return limiter.Waiting(PriorityInteractive) == 1 && fakeClock.HasWaiters() }, time.Minute, time.Millisecond)
+	fakeClock.Step(5 * time.Second)
+	got = <-results
+	require.ErrorIs(t, got.err, ErrQueueTimeout)
+	require.Nil(t, got.release)
+	require.Zero(t, limiter.Waiting(PriorityInteractive))
+
+	// A request whose context is canceled while waiting gives up.
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		_, err := limiter.Acquire(ctx, PriorityBulk)
+		results <- result{err: err}
+	}()
+	require.Eventually(t, func() bool { return limiter.Waiting(PriorityBulk) == 1 }, time.Minute, time.Millisecond)
+	cancel()
+	got = <-results
+	require.ErrorIs(t, got.err, context.Canceled)
+	require.Zero(t, limiter.Waiting(PriorityBulk))
+
+	// When there are no waiting requests, released slots are freed.
+	releaseInteractive()
+	releaseBulk()
+	require.Zero(t, limiter.inFlight)
+	release3, err := limiter.Acquire(context.Background(), PriorityBulk)
+	require.NoError(t, err)
+	release3()
+}
+
+func TestWrapHandler(t *testing.T) {
+	var log bytes.Buffer
+	logger := plog.TestLogger(t, &log)
+	fakeClock := clocktesting.NewFakeClock(time.Date(2099, 8, 8, 13, 57, 36, 0, time.UTC))
+	issuer := "https://wrap-handler-test.example.com"
+	limiter := New(issuer, Config{MaxConcurrentRequests: 1, QueueTimeout: 1500 * time.Millisecond}, fakeClock, logger)
+
+	unblock := make(chan struct{})
+	handler := limiter.WrapHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	firstResponse := make(chan *httptest.ResponseRecorder)
+	go func() {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, tokenRequest("refresh_token"))
+		firstResponse <- rec
+	}()
+	require.Eventually(t, func() bool {
+		limiter.mu.Lock()
+		defer limiter.mu.Unlock()
+		return limiter.inFlight == 1
+	}, time.Minute, time.Millisecond)
+
+	shedResponse := make(chan *httptest.ResponseRecorder)
+	go func() {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, tokenRequest("urn:ietf:params:oauth:grant-type:token-exchange"))
+		shedResponse <- rec
+	}()
+	require.Eventually(t, func() bool { return limiter.Waiting(PriorityBulk) == 1 && fakeClock.HasWaiters() }, time.Minute, time.Millisecond)
+	fakeClock.Step(1500 * time.Millisecond)
+
+	rec := <-shedResponse
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Equal(t, "2", rec.Header().Get("Retry-After"))
+	require.Equal(t, "Service Unavailable\n", rec.Body.String())
+	require.Contains(t, log.String(), `"message":"rejected token request because the token endpoint is overloaded","event":"TokenEndpointLoadShed","issuer":"https://wrap-handler-test.example.com","priority":"bulk"`)
+
+	shed, err := testutil.GetCounterMetricValue(shedRequests.WithLabelValues(issuer, "bulk"))
+	require.NoError(t, err)
+	require.Equal(t, float64(1), shed)
+	shed, err = testutil.GetCounterMetricValue(shedRequests.WithLabelValues(issuer, "interactive"))
+	require.NoError(t, err)
+	require.Zero(t, shed)
+
+	close(unblock)
+	require.Equal(t, http.StatusOK, (<-firstResponse).Code)
+
+	// The slot was released, so the next request does not wait.
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, tokenRequest("authorization_code"))
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func tokenRequest(grantType string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/oauth2/token", strings.NewReader(url.Values{"grant_type": {grantType}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
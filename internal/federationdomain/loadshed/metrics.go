@@ -0,0 +1,28 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package loadshed
+
+import (
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+// shedRequests is served by the /metrics endpoint of the Supervisor's aggregated API server.
+//
+//nolint:gochecknoglobals // Metrics are registered once per process.
+var shedRequests = metrics.NewCounterVec(
+	&metrics.CounterOpts{
+		Namespace: "pinniped",
+		Subsystem: "supervisor",
+		Name:      "token_endpoint_shed_requests_total",
+		Help: "Number of requests to the token endpoint of a FederationDomain which were rejected because they " +
+			"waited in the queue for longer than the queue timeout, by issuer and by priority.",
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"issuer", "priority"},
+)
+
+func init() { //nolint:gochecknoinits // This is the conventional way to register metrics with the legacy registry.
+	legacyregistry.MustRegister(shedRequests)
+}
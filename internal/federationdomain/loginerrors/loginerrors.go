@@ -0,0 +1,169 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package loginerrors remembers the most recent errors of the login endpoints of FederationDomains in memory,
+// so that they can be shown on the status page of the Supervisor without searching through its logs.
+package loginerrors
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"k8s.io/utils/clock"
+)
+
+const (
+	// DefaultCapacity is the number of errors which are remembered by default.
+	DefaultCapacity = 50
+
+	// maxDescriptionLength limits the size of each description, since response bodies could be arbitrarily long.
+	maxDescriptionLength = 256
+)
+
+// Error is a single failed request to a login endpoint. It never contains the credentials or the identity of the user.
+type Error struct {
+	Time        time.Time `json:"time"`
+	Issuer      string    `json:"issuer"`
+	Path        string    `json:"path"`
+	Status      int       `json:"status"`
+	Code        string    `json:"code"`
+	Description string    `json:"description,omitempty"`
+}
+
+// Recorder remembers the most recent errors in a ring buffer. A nil Recorder does not remember anything.
+type Recorder struct {
+	clock clock.PassiveClock
+
+	lock   sync.Mutex
+	errors []Error // ring buffer, with next being the index of the oldest error once it is full
+	next   int
+	full   bool
+}
+
+func New(capacity int, clock clock.PassiveClock) *Recorder {
+	return &Recorder{clock: clock, errors: make([]Error, capacity)}
+}
+
+// Recent returns the remembered errors, most recent first.
+func (r *Recorder) Recent() []Error {
+	if r == nil {
+		return nil
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	count := r.next
+	if r.full {
+		count = len(r.errors)
+	}
+	recent := make([]Error, 0, count)
+	for i := 1; i <= count; i++ {
+		recent = append(recent, r.errors[(r.next-i+len(r.errors))%len(r.errors)])
+	}
+	return recent
+}
+
+func (r *Recorder) add(e Error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if len(r.errors) == 0 {
+		return
+	}
+	r.errors[r.next] = e
+	r.next = (r.next + 1) % len(r.errors)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// WrapHandler returns a handler which remembers the failed responses of handler for the issuer. A response failed
+// when its status is an error, or when it redirects to the client or to the login page with an error.
+func (r *Recorder) WrapHandler(issuer string, handler http.Handler) http.Handler {
+	if r == nil {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler.ServeHTTP(recorder, req)
+
+		code, description, failed := recorder.failure()
+		if !failed {
+			return
+		}
+		r.add(Error{
+			Time:        r.clock.Now(),
+			Issuer:      issuer,
+			Path:        req.URL.Path,
+			Status:      recorder.status,
+			Code:        code,
+			Description: truncate(description),
+		})
+	})
+}
+
+// responseRecorder captures the status, the Location header, and the beginning of the body of a response.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        strings.Builder
+}
+
+func (w *responseRecorder) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	// Keep one byte more than a description may have, so that truncate() knows when the body was longer.
+	if w.status >= http.StatusBadRequest && w.body.Len() <= maxDescriptionLength {
+		w.body.Write(b[:min(len(b), maxDescriptionLength+1-w.body.Len())])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap allows http.ResponseController to find the original http.ResponseWriter.
+func (w *responseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *responseRecorder) failure() (string, string, bool) {
+	if w.status >= http.StatusBadRequest {
+		// Errors are written by httperr as plain text, and by fosite as JSON, so both are readable enough as is.
+		return http.StatusText(w.status), strings.TrimSpace(w.body.String()), true
+	}
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		return "", "", false
+	}
+	query := location.Query()
+	if code := query.Get("error"); code != "" {
+		// An OAuth error response which redirects back to the client.
+		return code, query.Get("error_description"), true
+	}
+	if code := query.Get("err"); code != "" {
+		// The login page of LDAP and Active Directory identity providers shows the error to the user.
+		return code, "", true
+	}
+	return "", "", false
+}
+
+func truncate(s string) string {
+	if len(s) <= maxDescriptionLength {
+		return s
+	}
+	s = s[:maxDescriptionLength]
+	for !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s + "..."
+}
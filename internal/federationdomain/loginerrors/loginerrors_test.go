@@ -0,0 +1,157 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package loginerrors
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestWrapHandler(t *testing.T) {
+	const issuer = "https://issuer.example.com/path"
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		path    string
+		handler http.HandlerFunc
+		want    []Error
+	}{
+		{
+			name: "success",
+			path: "/path/oauth2/authorize",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "https://client.example.com/callback?code=some-code&state=some-state", http.StatusSeeOther)
+			},
+		},
+		{
+			name: "error status",
+			path: "/path/callback",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "Bad Gateway: failed to exchange authcode with upstream OIDC provider", http.StatusBadGateway)
+			},
+			want: []Error{{
+				Time:        now,
+				Issuer:      issuer,
+				Path:        "/path/callback",
+				Status:      http.StatusBadGateway,
+				Code:        "Bad Gateway",
+				Description: "Bad Gateway: failed to exchange authcode with upstream OIDC provider",
+			}},
+		},
+		{
+			name: "error redirect to the client",
+			path: "/path/oauth2/authorize",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "https://client.example.com/callback?error=access_denied&error_description=The+resource+owner+denied+the+request.&state=some-state", http.StatusSeeOther)
+			},
+			want: []Error{{
+				Time:        now,
+				Issuer:      issuer,
+				Path:        "/path/oauth2/authorize",
+				Status:      http.StatusSeeOther,
+				Code:        "access_denied",
+				Description: "The resource owner denied the request.",
+			}},
+		},
+		{
+			name: "error redirect to the login page",
+			path: "/path/login",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "/path/login?err=login_error&state=some-state", http.StatusSeeOther)
+			},
+			want: []Error{{
+				Time:   now,
+				Issuer: issuer,
+				Path:   "/path/login",
+				Status: http.StatusSeeOther,
+				Code:   "login_error",
+			}},
+		},
+		{
+			name: "long error body is truncated",
+			path: "/path/oauth2/token",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(strings.Repeat("a", 200)))
+				_, _ = w.Write([]byte(strings.Repeat("b", 200)))
+			},
+			want: []Error{{
+				Time:        now,
+				Issuer:      issuer,
+				Path:        "/path/oauth2/token",
+				Status:      http.StatusBadRequest,
+				Code:        "Bad Request",
+				Description: strings.Repeat("a", 200) + strings.Repeat("b", 56) + "...",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := New(DefaultCapacity, clocktesting.NewFakeClock(now))
+
+			rsp := httptest.NewRecorder()
+			recorder.WrapHandler(issuer, tt.handler).ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			require.Equal(t, tt.want, nilIfEmpty(recorder.Recent()))
+		})
+	}
+}
+
+func TestRecent(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC))
+	recorder := New(3, fakeClock)
+
+	handler := recorder.WrapHandler("https://issuer.example.com", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, r.URL.Query().Get("n"), http.StatusBadRequest)
+	}))
+
+	descriptions := func() []string {
+		var result []string
+		for _, e := range recorder.Recent() {
+			result = append(result, e.Description)
+		}
+		return result
+	}
+
+	require.Empty(t, recorder.Recent())
+
+	for i := range 5 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, fmt.Sprintf("/?n=%d", i), nil))
+		fakeClock.Step(time.Second)
+
+		switch i {
+		case 1:
+			require.Equal(t, []string{"1", "0"}, descriptions())
+		case 2:
+			require.Equal(t, []string{"2", "1", "0"}, descriptions())
+		case 4:
+			// Only the most recent errors are remembered.
+			require.Equal(t, []string{"4", "3", "2"}, descriptions())
+		}
+	}
+}
+
+func TestNilRecorder(t *testing.T) {
+	var recorder *Recorder
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	require.NotNil(t, recorder.WrapHandler("https://issuer.example.com", handler))
+	require.Empty(t, recorder.Recent())
+}
+
+func nilIfEmpty(errors []Error) []Error {
+	if len(errors) == 0 {
+		return nil
+	}
+	return errors
+}
@@ -0,0 +1,259 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package loginthrottle limits the rate of login attempts made to a FederationDomain, both per source IP
+// address and per username of each identity provider.
+package loginthrottle
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+
+	"go.pinniped.dev/internal/plog"
+)
+
+const (
+	DefaultRequestsPerMinutePerSourceIP = 60
+	DefaultFailedAttemptsBeforeLockout  = 5
+	DefaultLockoutDuration              = 5 * time.Minute
+
+	requestWindow = time.Minute
+)
+
+// Config holds the settings for a Throttle.
+type Config struct {
+	// RequestsPerMinutePerSourceIP is the number of requests allowed from a single source IP per minute.
+	RequestsPerMinutePerSourceIP int
+	// FailedAttemptsBeforeLockout is the number of consecutive failed logins from a source IP which cause a username
+	// to be locked out for that source IP.
+	FailedAttemptsBeforeLockout int
+	// LockoutDuration is how long a username remains locked out for a source IP.
+	LockoutDuration time.Duration
+	// TrustedProxies are the address ranges of the proxies whose X-Forwarded-For headers are trusted.
+	// When empty, the source IP of a request is always its remote address.
+	TrustedProxies []netip.Prefix
+}
+
+type requestCount struct {
+	windowStart time.Time
+	count       int
+}
+
+// lockoutKey identifies the username of an identity provider for which logins from a source IP are counted.
+// Including the source IP means that failed logins from one source IP cannot lock out the user everywhere else.
+type lockoutKey struct {
+	idpDisplayName string
+	username       string
+	sourceIP       string
+}
+
+type failedLogins struct {
+	count       int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// Throttle tracks requests per source IP, and failed logins per username of each identity provider and source IP.
+//
+// It is thread-safe.
+type Throttle struct {
+	config Config
+	clock  clock.PassiveClock
+	log    plog.Logger
+
+	mu           sync.Mutex
+	requests     map[string]*requestCount
+	failures     map[lockoutKey]*failedLogins
+	lastCleanup  time.Time
+	issuerForLog string
+}
+
+// New returns a Throttle. The issuer is only used for logging.
+func New(issuer string, config Config, clock clock.PassiveClock, log plog.Logger) *Throttle {
+	return &Throttle{
+		config:       config,
+		clock:        clock,
+		log:          log,
+		requests:     map[string]*requestCount{},
+		failures:     map[lockoutKey]*failedLogins{},
+		lastCleanup:  clock.Now(),
+		issuerForLog: issuer,
+	}
+}
+
+// Config returns the settings of this Throttle.
+func (t *Throttle) Config() Config {
+	return t.config
+}
+
+// AllowRequest records a request from the given source IP, and returns false when that source IP
+// has exceeded its number of allowed requests for the current minute.
+func (t *Throttle) AllowRequest(sourceIP string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.clock.Now()
+	t.maybeCleanup(now)
+
+	r, ok := t.requests[sourceIP]
+	if !ok || now.Sub(r.windowStart) >= requestWindow {
+		r = &requestCount{windowStart: now}
+		t.requests[sourceIP] = r
+	}
+	r.count++
+	return r.count <= t.config.RequestsPerMinutePerSourceIP
+}
+
+// LockedOut returns true when the username of the identity provider is currently locked out for the source IP
+// due to too many failed logins from that source IP.
+func (t *Throttle) LockedOut(idpDisplayName, username, sourceIP string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	f, ok := t.failures[lockoutKey{idpDisplayName: idpDisplayName, username: username, sourceIP: sourceIP}]
+	return ok && t.clock.Now().Before(f.lockedUntil)
+}
+
+// RecordLoginFailure records a failed login for the username of the identity provider from the source IP, and locks
+// out the username for that source IP when it has reached the configured number of consecutive failed logins.
+// Returns true when this failure caused a lockout.
+func (t *Throttle) RecordLoginFailure(idpDisplayName, username, sourceIP string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.clock.Now()
+	t.maybeCleanup(now)
+
+	key := lockoutKey{idpDisplayName: idpDisplayName, username: username, sourceIP: sourceIP}
+	f, ok := t.failures[key]
+	if !ok || (now.Sub(f.lastFailure) >= t.config.LockoutDuration && !now.Before(f.lockedUntil)) {
+		// Failures older than the lockout duration are forgotten.
+		f = &failedLogins{}
+		t.failures[key] = f
+	}
+	f.count++
+	f.lastFailure = now
+
+	if f.count < t.config.FailedAttemptsBeforeLockout {
+		return false
+	}
+
+	f.count = 0
+	f.lockedUntil = now.Add(t.config.LockoutDuration)
+	t.log.Warning("username locked out due to too many failed login attempts",
+		"event", "DownstreamLoginLockedOut",
+		"issuer", t.issuerForLog,
+		"identityProvider", idpDisplayName,
+		"username", username,
+		"sourceIP", sourceIP,
+		"failedAttempts", t.config.FailedAttemptsBeforeLockout,
+		"lockedUntil", f.lockedUntil.UTC().Format(time.RFC3339),
+	)
+	return true
+}
+
+// RecordLoginSuccess forgets any previous failed logins for the username of the identity provider from the source IP.
+func (t *Throttle) RecordLoginSuccess(idpDisplayName, username, sourceIP string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.failures, lockoutKey{idpDisplayName: idpDisplayName, username: username, sourceIP: sourceIP})
+}
+
+// maybeCleanup removes stale entries so that the memory used by the Throttle does not grow without bound.
+// Must be called while holding the lock.
+func (t *Throttle) maybeCleanup(now time.Time) {
+	if now.Sub(t.lastCleanup) < requestWindow {
+		return
+	}
+	t.lastCleanup = now
+
+	for ip, r := range t.requests {
+		if now.Sub(r.windowStart) >= requestWindow {
+			delete(t.requests, ip)
+		}
+	}
+	for key, f := range t.failures {
+		if now.Sub(f.lastFailure) >= t.config.LockoutDuration && !now.Before(f.lockedUntil) {
+			delete(t.failures, key)
+		}
+	}
+}
+
+// WrapHandler returns a handler which responds with HTTP 429 to requests from source IPs which have exceeded
+// their allowed number of requests per minute, and otherwise calls the delegate handler.
+func (t *Throttle) WrapHandler(delegate http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sourceIP := t.SourceIP(r)
+		if !t.AllowRequest(sourceIP) {
+			t.log.Info("rejected request because the source IP exceeded the login rate limit",
+				"event", "DownstreamLoginRateLimited",
+				"issuer", t.issuerForLog,
+				"sourceIP", sourceIP,
+				"path", r.URL.Path,
+			)
+			w.Header().Set("Retry-After", strconv.Itoa(int(requestWindow.Seconds())))
+			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+			return
+		}
+		delegate.ServeHTTP(w, r)
+	})
+}
+
+// SourceIP returns the IP address of the client which made the request. This is the remote address of the request,
+// unless the remote address is one of the trusted proxies, in which case it is the rightmost address of the
+// X-Forwarded-For header which is not one of the trusted proxies. Addresses to the left of that one are ignored,
+// since any client can send an X-Forwarded-For header with made up addresses.
+func (t *Throttle) SourceIP(r *http.Request) string {
+	sourceIP := SourceIP(r)
+	if len(t.config.TrustedProxies) == 0 {
+		return sourceIP
+	}
+
+	var forwardedFor []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		forwardedFor = append(forwardedFor, strings.Split(header, ",")...)
+	}
+	for i := len(forwardedFor) - 1; i >= 0; i-- {
+		if !t.isTrustedProxy(sourceIP) {
+			break
+		}
+		addr, err := netip.ParseAddr(strings.TrimSpace(forwardedFor[i]))
+		if err != nil {
+			// The trusted proxy did not send a valid address, so the proxy is the last address which is known.
+			break
+		}
+		sourceIP = addr.Unmap().String()
+	}
+	return sourceIP
+}
+
+func (t *Throttle) isTrustedProxy(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range t.config.TrustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// SourceIP returns the IP address from the remote address of the request, ignoring any X-Forwarded-For header.
+func SourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
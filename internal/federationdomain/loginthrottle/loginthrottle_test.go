@@ -0,0 +1,233 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package loginthrottle
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/internal/testutil"
+)
+
+func TestAllowRequest(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Date(2099, 8, 8, 13, 57, 36, 0, time.UTC))
+	throttle := New("https://issuer.example.com", Config{
+		RequestsPerMinutePerSourceIP: 2,
+		FailedAttemptsBeforeLockout:  3,
+		LockoutDuration:              5 * time.Minute,
+	}, fakeClock, plog.New())
+
+	require.True(t, throttle.AllowRequest("1.2.3.4"))
+	require.True(t, throttle.AllowRequest("1.2.3.4"))
+	require.False(t, throttle.AllowRequest("1.2.3.4"))
+	require.True(t, throttle.AllowRequest("5.6.7.8"))
+
+	fakeClock.Step(59 * time.Second)
+	require.False(t, throttle.AllowRequest("1.2.3.4"))
+
+	fakeClock.Step(time.Second)
+	require.True(t, throttle.AllowRequest("1.2.3.4"))
+}
+
+func TestLockout(t *testing.T) {
+	var log bytes.Buffer
+	logger := plog.TestLogger(t, &log)
+	fakeClock := clocktesting.NewFakeClock(time.Date(2099, 8, 8, 13, 57, 36, 0, time.UTC))
+	throttle := New("https://issuer.example.com", Config{
+		RequestsPerMinutePerSourceIP: 100,
+		FailedAttemptsBeforeLockout:  3,
+		LockoutDuration:              5 * time.Minute,
+	}, fakeClock, logger)
+
+	require.False(t, throttle.RecordLoginFailure("some-idp", "alice", "1.2.3.4"))
+	require.False(t, throttle.RecordLoginFailure("some-idp", "alice", "1.2.3.4"))
+	require.False(t, throttle.LockedOut("some-idp", "alice", "1.2.3.4"))
+
+	// A successful login forgets the previous failures.
+	throttle.RecordLoginSuccess("some-idp", "alice", "1.2.3.4")
+	require.False(t, throttle.RecordLoginFailure("some-idp", "alice", "1.2.3.4"))
+	require.False(t, throttle.RecordLoginFailure("some-idp", "alice", "1.2.3.4"))
+	require.Empty(t, log.String())
+
+	require.True(t, throttle.RecordLoginFailure("some-idp", "alice", "1.2.3.4"))
+	require.True(t, throttle.LockedOut("some-idp", "alice", "1.2.3.4"))
+	require.False(t, throttle.LockedOut("some-idp", "bob", "1.2.3.4"))
+	require.Contains(t, log.String(), `"message":"username locked out due to too many failed login attempts","warning":true,"event":"DownstreamLoginLockedOut","issuer":"https://issuer.example.com","identityProvider":"some-idp","username":"alice","sourceIP":"1.2.3.4","failedAttempts":3,"lockedUntil":"2099-08-08T14:02:36Z"`)
+
+	fakeClock.Step(5*time.Minute - time.Second)
+	require.True(t, throttle.LockedOut("some-idp", "alice", "1.2.3.4"))
+
+	fakeClock.Step(time.Second)
+	require.False(t, throttle.LockedOut("some-idp", "alice", "1.2.3.4"))
+
+	// Failures which are older than the lockout duration are forgotten.
+	require.False(t, throttle.RecordLoginFailure("some-idp", "alice", "1.2.3.4"))
+	require.False(t, throttle.RecordLoginFailure("some-idp", "alice", "1.2.3.4"))
+	fakeClock.Step(5 * time.Minute)
+	require.False(t, throttle.RecordLoginFailure("some-idp", "alice", "1.2.3.4"))
+	require.False(t, throttle.LockedOut("some-idp", "alice", "1.2.3.4"))
+}
+
+func TestLockoutIsPerIdentityProviderAndSourceIP(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Date(2099, 8, 8, 13, 57, 36, 0, time.UTC))
+	throttle := New("https://issuer.example.com", Config{
+		RequestsPerMinutePerSourceIP: 100,
+		FailedAttemptsBeforeLockout:  2,
+		LockoutDuration:              5 * time.Minute,
+	}, fakeClock, plog.New())
+
+	require.False(t, throttle.RecordLoginFailure("some-idp", "alice", "1.2.3.4"))
+	require.True(t, throttle.RecordLoginFailure("some-idp", "alice", "1.2.3.4"))
+	require.True(t, throttle.LockedOut("some-idp", "alice", "1.2.3.4"))
+
+	// The same username is not locked out for other source IPs, so failed logins from one source IP cannot
+	// lock out the user everywhere.
+	require.False(t, throttle.LockedOut("some-idp", "alice", "5.6.7.8"))
+	// The same username of another identity provider is a different user.
+	require.False(t, throttle.LockedOut("other-idp", "alice", "1.2.3.4"))
+
+	// Failures from different source IPs and for different identity providers are counted separately.
+	require.False(t, throttle.RecordLoginFailure("some-idp", "alice", "5.6.7.8"))
+	require.False(t, throttle.RecordLoginFailure("other-idp", "alice", "1.2.3.4"))
+	require.False(t, throttle.LockedOut("some-idp", "alice", "5.6.7.8"))
+	require.False(t, throttle.LockedOut("other-idp", "alice", "1.2.3.4"))
+
+	// A successful login from another source IP does not unlock the username for the source IP which was locked out.
+	throttle.RecordLoginSuccess("some-idp", "alice", "5.6.7.8")
+	require.True(t, throttle.LockedOut("some-idp", "alice", "1.2.3.4"))
+}
+
+func TestWrapHandler(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	throttle := New("https://issuer.example.com", Config{
+		RequestsPerMinutePerSourceIP: 1,
+		FailedAttemptsBeforeLockout:  3,
+		LockoutDuration:              5 * time.Minute,
+	}, fakeClock, plog.New())
+
+	subject := throttle.WrapHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	serve := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/some/path", nil)
+		req.RemoteAddr = remoteAddr
+		rsp := httptest.NewRecorder()
+		subject.ServeHTTP(rsp, req)
+		return rsp
+	}
+
+	require.Equal(t, http.StatusTeapot, serve("1.2.3.4:1234").Code)
+
+	// A different port on the same IP address counts against the same limit.
+	rsp := serve("1.2.3.4:5678")
+	require.Equal(t, http.StatusTooManyRequests, rsp.Code)
+	require.Equal(t, "60", rsp.Header().Get("Retry-After"))
+	testutil.RequireEqualContentType(t, rsp.Header().Get("Content-Type"), "text/plain; charset=utf-8")
+
+	require.Equal(t, http.StatusTeapot, serve("[::1]:1234").Code)
+}
+
+func TestSourceIP(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		forwardedFor   []string
+		want           string
+	}{
+		{
+			name:         "no trusted proxies ignores X-Forwarded-For",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"1.2.3.4"},
+			want:         "10.0.0.1",
+		},
+		{
+			name:           "remote address which is not a trusted proxy ignores X-Forwarded-For",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "5.6.7.8:1234",
+			forwardedFor:   []string{"1.2.3.4"},
+			want:           "5.6.7.8",
+		},
+		{
+			name:           "trusted proxy without X-Forwarded-For",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.1:1234",
+			want:           "10.0.0.1",
+		},
+		{
+			name:           "trusted proxy uses X-Forwarded-For",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.1:1234",
+			forwardedFor:   []string{"1.2.3.4"},
+			want:           "1.2.3.4",
+		},
+		{
+			name:           "addresses to the left of the first untrusted address are ignored",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.1:1234",
+			forwardedFor:   []string{"9.9.9.9, 1.2.3.4, 10.0.0.2"},
+			want:           "1.2.3.4",
+		},
+		{
+			name:           "multiple X-Forwarded-For headers",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.1:1234",
+			forwardedFor:   []string{"9.9.9.9", "1.2.3.4,10.0.0.2"},
+			want:           "1.2.3.4",
+		},
+		{
+			name:           "only trusted proxies uses the leftmost address",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.1:1234",
+			forwardedFor:   []string{"10.0.0.3, 10.0.0.2"},
+			want:           "10.0.0.3",
+		},
+		{
+			name:           "invalid address uses the last trusted proxy",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.1:1234",
+			forwardedFor:   []string{"1.2.3.4, not-an-ip, 10.0.0.2"},
+			want:           "10.0.0.2",
+		},
+		{
+			name:           "IPv6",
+			trustedProxies: []string{"fd00::/8"},
+			remoteAddr:     "[fd00::1]:1234",
+			forwardedFor:   []string{"2001:db8::1"},
+			want:           "2001:db8::1",
+		},
+		{
+			name:           "IPv4-mapped IPv6 addresses",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "[::ffff:10.0.0.1]:1234",
+			forwardedFor:   []string{"::ffff:1.2.3.4"},
+			want:           "1.2.3.4",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var trustedProxies []netip.Prefix
+			for _, cidr := range tt.trustedProxies {
+				trustedProxies = append(trustedProxies, netip.MustParsePrefix(cidr))
+			}
+			throttle := New("https://issuer.example.com", Config{TrustedProxies: trustedProxies}, clocktesting.NewFakeClock(time.Now()), plog.New())
+
+			req := httptest.NewRequest(http.MethodGet, "/some/path", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, header := range tt.forwardedFor {
+				req.Header.Add("X-Forwarded-For", header)
+			}
+			require.Equal(t, tt.want, throttle.SourceIP(req))
+		})
+	}
+}
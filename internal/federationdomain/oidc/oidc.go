@@ -6,9 +6,13 @@
 package oidc
 
 import (
+	"bytes"
+	"context"
 	"crypto/subtle"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"html/template"
 	"net/http"
 	"reflect"
 	"time"
@@ -16,15 +20,18 @@ import (
 	"github.com/felixge/httpsnoop"
 	"github.com/ory/fosite"
 	"github.com/ory/fosite/compose"
+	fositeoauth2 "github.com/ory/fosite/handler/oauth2"
 	errorsx "github.com/pkg/errors"
 
 	oidcapi "go.pinniped.dev/generated/latest/apis/supervisor/oidc"
 	"go.pinniped.dev/internal/federationdomain/clientregistry"
+	"go.pinniped.dev/internal/federationdomain/clusteraudience"
 	"go.pinniped.dev/internal/federationdomain/csrftoken"
 	"go.pinniped.dev/internal/federationdomain/endpoints/jwks"
 	"go.pinniped.dev/internal/federationdomain/endpoints/tokenexchange"
-	"go.pinniped.dev/internal/federationdomain/formposthtml"
+	"go.pinniped.dev/internal/federationdomain/errordetails"
 	"go.pinniped.dev/internal/federationdomain/idtokenlifespan"
+	"go.pinniped.dev/internal/federationdomain/jarm"
 	"go.pinniped.dev/internal/federationdomain/strategy"
 	"go.pinniped.dev/internal/federationdomain/timeouts"
 	"go.pinniped.dev/internal/httputil/httperr"
@@ -35,14 +42,17 @@ import (
 )
 
 const (
-	WellKnownEndpointPath     = "/.well-known/openid-configuration"
-	AuthorizationEndpointPath = "/oauth2/authorize"
-	TokenEndpointPath         = "/oauth2/token" //nolint:gosec // ignore lint warning that this is a credential
-	CallbackEndpointPath      = "/callback"
-	ChooseIDPEndpointPath     = "/choose_identity_provider"
-	JWKSEndpointPath          = "/jwks.json"
-	PinnipedIDPsPathV1Alpha1  = "/v1alpha1/pinniped_identity_providers"
-	PinnipedLoginPath         = "/login"
+	WellKnownEndpointPath                = "/.well-known/openid-configuration"
+	AuthorizationEndpointPath            = "/oauth2/authorize"
+	TokenEndpointPath                    = "/oauth2/token" //nolint:gosec // ignore lint warning that this is a credential
+	PushedAuthorizeEndpointPath          = "/oauth2/par"
+	CallbackEndpointPath                 = "/callback"
+	ChooseIDPEndpointPath                = "/choose_identity_provider"
+	JWKSEndpointPath                     = "/jwks.json"
+	PinnipedIDPsPathV1Alpha1             = "/v1alpha1/pinniped_identity_providers"
+	PinnipedClusterAudiencesPathV1Alpha1 = "/v1alpha1/pinniped_cluster_audiences"
+	PinnipedLoginPath                    = "/login"
+	ConsentEndpointPath                  = "/consent"
 )
 
 const (
@@ -72,6 +82,28 @@ const (
 	// Supervisor's authorization endpoint should give the browser a new CSRF cookie. We set it to
 	// a week so that it is unlikely to expire during a login.
 	CSRFCookieLifespan = time.Hour * 24 * 7
+
+	// LastUsedIDPCookieName is the name of the browser cookie which remembers the display name of the identity
+	// provider which the user most recently chose on the IDP chooser page. The cookie is scoped to the path of the
+	// IDP chooser page, since display names are only unique within a FederationDomain, so it cannot use the
+	// `__Host` prefix.
+	LastUsedIDPCookieName = "pinniped-last-idp"
+
+	// LastUsedIDPCookieEncodingName is the `name` passed to the encoder for encoding and decoding the last used IDP
+	// cookie contents.
+	LastUsedIDPCookieEncodingName = "idp"
+
+	// LastUsedIDPCookieLifespan is the length of time that the browser remembers the last used IDP.
+	LastUsedIDPCookieLifespan = time.Hour * 24 * 90
+
+	// SingleSignOnCookieName is the name of the browser cookie which holds the ID of the single sign-on session of
+	// the browser. The cookie is scoped to the path of the issuer, since each FederationDomain has its own single
+	// sign-on sessions, so it cannot use the `__Host` prefix.
+	SingleSignOnCookieName = "pinniped-sso"
+
+	// SingleSignOnCookieEncodingName is the `name` passed to the encoder for encoding and decoding the single
+	// sign-on cookie contents.
+	SingleSignOnCookieEncodingName = "sso"
 )
 
 // Encoder is the encoding side of the securecookie.Codec interface.
@@ -230,10 +262,17 @@ func FositeOauth2Helper(
 	hmacSecretOfLengthAtLeast32Func func() []byte,
 	jwksProvider jwks.DynamicJWKSProvider,
 	timeoutsConfiguration timeouts.Configuration,
+	formPostHTMLTemplate func(ctx context.Context) *template.Template,
+	jwtAccessTokenConfig *strategy.JWTAccessTokenConfig, // nil to issue opaque access tokens
+	workloadAuthenticator tokenexchange.WorkloadAuthenticator, // nil to reject the ServiceAccount tokens of workloads
+	clusterAudiences *clusteraudience.Registry, // nil to allow any audience which is not reserved
 ) fosite.OAuth2Provider {
 	oauthConfig := &fosite.Config{
 		IDTokenIssuer: issuer,
 
+		// the audience which private_key_jwt client assertions must have, for the token and PAR endpoints alike
+		TokenURL: issuer + TokenEndpointPath,
+
 		AuthorizeCodeLifespan: timeoutsConfiguration.AuthorizeCodeLifespan,
 		IDTokenLifespan:       timeoutsConfiguration.IDTokenLifespan,
 		AccessTokenLifespan:   timeoutsConfiguration.AccessTokenLifespan,
@@ -255,19 +294,28 @@ func FositeOauth2Helper(
 		// do not allow custom scheme redirects, only https and http (on loopback)
 		RedirectSecureChecker: fosite.IsRedirectURISecureStrict,
 
-		// html template for rendering the authorization response when the request has response_mode=form_post
-		FormPostHTMLTemplate: formposthtml.Template(),
+		// the html template for rendering the authorization response when the request has response_mode=form_post
+		// is chosen per request by formPostConfig below, so it is not set here
+		FormPostHTMLTemplate: nil,
 
 		// defaults to using BCrypt when nil
 		ClientSecretsHasher: nil,
+
+		// writes the authorization response when the request has a JWT response_mode, e.g. response_mode=jwt
+		ResponseModeHandlerExtension: jarm.NewResponseModeHandler(issuer, jwksProvider, timeoutsConfiguration.AuthorizeCodeLifespan, time.Now),
+	}
+
+	// Note that Fosite requires the HMAC secret to be at least 32 bytes.
+	var coreStrategy fositeoauth2.CoreStrategy = strategy.NewDynamicOauth2HMACStrategy(oauthConfig, hmacSecretOfLengthAtLeast32Func)
+	if jwtAccessTokenConfig != nil {
+		coreStrategy = strategy.NewDynamicOauth2JWTAccessTokenStrategy(oauthConfig, hmacSecretOfLengthAtLeast32Func, jwksProvider, *jwtAccessTokenConfig)
 	}
 
 	oAuth2Provider := compose.Compose(
 		oauthConfig,
 		oauthStore,
 		&compose.CommonStrategy{
-			// Note that Fosite requires the HMAC secret to be at least 32 bytes.
-			CoreStrategy:               strategy.NewDynamicOauth2HMACStrategy(oauthConfig, hmacSecretOfLengthAtLeast32Func),
+			CoreStrategy:               coreStrategy,
 			OpenIDConnectTokenStrategy: strategy.NewDynamicOpenIDConnectECDSAStrategy(oauthConfig, jwksProvider),
 		},
 		compose.OAuth2AuthorizeExplicitFactory,
@@ -277,12 +325,27 @@ func FositeOauth2Helper(
 		// Use a custom factory to allow selective overrides of the ID token lifespan during refresh.
 		idtokenlifespan.OpenIDConnectRefreshFactory,
 		compose.OAuth2PKCEFactory,
-		tokenexchange.HandlerFactory, // handle the "urn:ietf:params:oauth:grant-type:token-exchange" grant type
+		tokenexchange.HandlerFactory(workloadAuthenticator, clusterAudiences), // handle the "urn:ietf:params:oauth:grant-type:token-exchange" grant type
 	)
 
+	// Compose registers the handlers into oauthConfig, so the provider can be given a wrapper around the same config
+	// which chooses the form_post html template for the language of each request.
+	oAuth2Provider.(*fosite.Fosite).Config = &formPostConfig{Config: oauthConfig, formPostHTMLTemplate: formPostHTMLTemplate}
+
 	return oAuth2Provider
 }
 
+// formPostConfig is a fosite.Configurator which renders the response_mode=form_post page using the template
+// returned by formPostHTMLTemplate for the context of each request, e.g. to localize the page.
+type formPostConfig struct {
+	*fosite.Config
+	formPostHTMLTemplate func(ctx context.Context) *template.Template
+}
+
+func (c *formPostConfig) GetFormPostHTMLTemplate(ctx context.Context) *template.Template {
+	return c.formPostHTMLTemplate(ctx)
+}
+
 // FositeErrorForLog generates a list of information about the provided Fosite error that can be
 // passed to a plog function (e.g., plog.Info()).
 //
@@ -391,6 +454,20 @@ func validateCSRFValue(state *UpstreamStateParamData, csrfCookieValue csrftoken.
 	return nil
 }
 
+// ValidateCSRFCookie checks that the CSRF cookie of the request has the expected value. It is used by the endpoints
+// which continue a login without the upstream state param, to check that the request comes from the same browser
+// which started the login.
+func ValidateCSRFCookie(r *http.Request, cookieDecoder Decoder, expectedCSRFValue csrftoken.CSRFToken) error {
+	csrfValue, err := readCSRFCookie(r, cookieDecoder)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(expectedCSRFValue), []byte(csrfValue)) != 1 {
+		return httperr.New(http.StatusForbidden, "CSRF value does not match")
+	}
+	return nil
+}
+
 // WriteAuthorizeError writes an authorization error as it should be returned by the authorization endpoint and other
 // similar endpoints that are the end of the downstream authcode flow. Errors responses are written in the usual fosite style.
 func WriteAuthorizeError(r *http.Request, w http.ResponseWriter, oauthHelper fosite.OAuth2Provider, authorizeRequester fosite.AuthorizeRequester, err error, isBrowserless bool) {
@@ -413,6 +490,46 @@ func WriteAuthorizeError(r *http.Request, w http.ResponseWriter, oauthHelper fos
 	oauthHelper.WriteAuthorizeError(r.Context(), w, authorizeRequester, err)
 }
 
+// WriteAccessError writes an error response of the token endpoint in the usual fosite style. When a code of the
+// errordetails package is attached to the err, the response also includes the code in its error_details member.
+func WriteAccessError(ctx context.Context, w http.ResponseWriter, oauthHelper fosite.OAuth2Provider, accessRequester fosite.AccessRequester, err error) {
+	code := errordetails.FromError(err)
+	if code == "" {
+		oauthHelper.WriteAccessError(ctx, w, accessRequester, err)
+		return
+	}
+
+	// Fosite cannot add members to its error responses, so buffer its response to add the member.
+	buffered := &bufferedResponseWriter{header: w.Header(), status: http.StatusOK}
+	oauthHelper.WriteAccessError(ctx, buffered, accessRequester, err)
+
+	body := buffered.body.Bytes()
+	var members map[string]json.RawMessage
+	if json.Unmarshal(body, &members) == nil {
+		members["error_details"], _ = json.Marshal(errordetails.Details{Code: code})
+		if bodyWithDetails, err := json.Marshal(members); err == nil {
+			body = bodyWithDetails
+		}
+	}
+
+	w.WriteHeader(buffered.status)
+	_, _ = w.Write(body)
+}
+
+// bufferedResponseWriter is an http.ResponseWriter which buffers the status and body of the response.
+// The headers are written to the header of the underlying response.
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponseWriter) Header() http.Header { return b.header }
+
+func (b *bufferedResponseWriter) WriteHeader(status int) { b.status = status }
+
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) { return b.body.Write(p) }
+
 // PerformAuthcodeRedirect successfully completes a downstream login by creating a session and
 // writing the authcode redirect response as it should be returned by the authorization endpoint and other
 // similar endpoints that are the end of the downstream authcode flow.
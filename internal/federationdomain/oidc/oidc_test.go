@@ -4,13 +4,18 @@
 package oidc
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/ory/fosite"
+	errorsx "github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 
 	"go.pinniped.dev/internal/federationdomain/clientregistry"
+	"go.pinniped.dev/internal/federationdomain/errordetails"
 )
 
 func TestDefaultLifespans(t *testing.T) {
@@ -114,3 +119,42 @@ func TestOverrideIDTokenLifespan(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteAccessError(t *testing.T) {
+	oauthHelper := &fosite.Fosite{Config: &fosite.Config{}}
+	upstreamRefreshErr := &fosite.RFC6749Error{
+		ErrorField:       "error",
+		DescriptionField: "Error during upstream refresh.",
+		HintField:        "Upstream refresh failed.",
+		CodeField:        http.StatusUnauthorized,
+	}
+
+	tests := []struct {
+		name     string
+		err      error
+		wantBody string
+	}{
+		{
+			name:     "error without a code",
+			err:      errorsx.WithStack(upstreamRefreshErr),
+			wantBody: `{"error":"error","error_description":"Error during upstream refresh. Upstream refresh failed."}`,
+		},
+		{
+			name: "error with a code",
+			err:  errordetails.WithCode(errorsx.WithStack(upstreamRefreshErr), errordetails.UpstreamRefreshFailed),
+			wantBody: `{"error":"error","error_description":"Error during upstream refresh. Upstream refresh failed.",` +
+				`"error_details":{"code":"PINNIPED_UPSTREAM_REFRESH_FAILED"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rsp := httptest.NewRecorder()
+			WriteAccessError(context.Background(), rsp, oauthHelper, nil, tt.err)
+
+			require.Equal(t, http.StatusUnauthorized, rsp.Code)
+			require.Equal(t, "application/json;charset=UTF-8", rsp.Header().Get("Content-Type"))
+			require.Equal(t, "no-store", rsp.Header().Get("Cache-Control"))
+			require.JSONEq(t, tt.wantBody, rsp.Body.String())
+		})
+	}
+}
@@ -4,13 +4,21 @@
 package oidcclientvalidator
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
 	"fmt"
 	"strings"
 
+	"github.com/go-jose/go-jose/v3"
 	"golang.org/x/crypto/bcrypt"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	configconditions "go.pinniped.dev/generated/latest/apis/supervisor/config/conditions"
 	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
 	oidcapi "go.pinniped.dev/generated/latest/apis/supervisor/oidc"
 	"go.pinniped.dev/internal/oidcclientsecretstorage"
@@ -19,17 +27,32 @@ import (
 const (
 	DefaultMinBcryptCost = 12
 
-	clientSecretExists     = "ClientSecretExists"
-	allowedGrantTypesValid = "AllowedGrantTypesValid"
-	allowedScopesValid     = "AllowedScopesValid"
+	clientSecretExists     = configconditions.TypeClientSecretExists
+	allowedGrantTypesValid = configconditions.TypeAllowedGrantTypesValid
+	allowedScopesValid     = configconditions.TypeAllowedScopesValid
+	privateKeyJWTValid     = configconditions.TypePrivateKeyJWTValid
 
-	reasonSuccess                  = "Success"
-	reasonMissingRequiredValue     = "MissingRequiredValue"
-	reasonNoClientSecretFound      = "NoClientSecretFound"
-	reasonInvalidClientSecretFound = "InvalidClientSecretFound"
+	reasonSuccess                     = configconditions.ReasonSuccess
+	reasonMissingRequiredValue        = configconditions.ReasonMissingRequiredValue
+	reasonNoClientSecretFound         = configconditions.ReasonNoClientSecretFound
+	reasonInvalidClientSecretFound    = configconditions.ReasonInvalidClientSecretFound
+	reasonPrivateKeyJWTSecretNotFound = configconditions.ReasonPrivateKeyJWTSecretNotFound
+	reasonInvalidPrivateKeyJWTSecret  = configconditions.ReasonInvalidPrivateKeyJWTSecret
 
 	allowedGrantTypesFieldName = "allowedGrantTypes"
 	allowedScopesFieldName     = "allowedScopes"
+	scopePoliciesFieldName     = "scopePolicies"
+	delegationFieldName        = "delegation"
+
+	// PrivateKeyJWTPublicKeysKey is the key of the Secret referenced by spec.privateKeyJWT.secretName which holds the
+	// PEM-encoded public keys of the client.
+	PrivateKeyJWTPublicKeysKey = "publicKeys"
+
+	// PrivateKeyJWTSigningAlgorithm is the only signing algorithm which is accepted for client assertions.
+	PrivateKeyJWTSigningAlgorithm = string(jose.ES256)
+
+	// PrivateKeyJWTSecretType is the required type of the Secret referenced by spec.privateKeyJWT.secretName.
+	PrivateKeyJWTSecretType corev1.SecretType = "secrets.pinniped.dev/oidc-client-public-keys"
 )
 
 // Validate validates the OIDCClient and its corresponding client secret storage Secret.
@@ -38,9 +61,35 @@ const (
 // along with a slice of conditions containing more details, and the list of client secrets in the
 // case that the client was valid.
 func Validate(oidcClient *supervisorconfigv1alpha1.OIDCClient, secret *corev1.Secret, minBcryptCost int) (bool, []*metav1.Condition, []string) {
-	conds := make([]*metav1.Condition, 0, 3)
+	valid, conds, clientSecrets, _ := ValidateWithPrivateKeyJWT(oidcClient, secret, nil, minBcryptCost)
+	return valid, conds, clientSecrets
+}
+
+// ValidateWithPrivateKeyJWT is like Validate, and also validates the Secret which is referenced by
+// spec.privateKeyJWT.secretName of the OIDCClient, when it has one. When that Secret was not found, pass nil to this
+// function to get the validation error for that case. When the client is valid and uses private_key_jwt client
+// authentication, it returns the client's public keys, and no client secrets, since those are not used by the client.
+func ValidateWithPrivateKeyJWT(
+	oidcClient *supervisorconfigv1alpha1.OIDCClient,
+	secret *corev1.Secret,
+	privateKeyJWTSecret *corev1.Secret,
+	minBcryptCost int,
+) (bool, []*metav1.Condition, []string, *jose.JSONWebKeySet) {
+	conds := make([]*metav1.Condition, 0, 4)
 
-	conds, clientSecrets := validateSecret(secret, conds, minBcryptCost)
+	var clientSecrets []string
+	var publicKeys *jose.JSONWebKeySet
+	if oidcClient.Spec.PrivateKeyJWT == nil {
+		conds, clientSecrets = validateSecret(secret, conds, minBcryptCost)
+	} else {
+		conds = append(conds, &metav1.Condition{
+			Type:    clientSecretExists,
+			Status:  metav1.ConditionTrue,
+			Reason:  reasonSuccess,
+			Message: "client secrets are not used because privateKeyJWT is configured",
+		})
+		conds, publicKeys = validatePrivateKeyJWTSecret(oidcClient, privateKeyJWTSecret, conds)
+	}
 	conds = validateAllowedGrantTypes(oidcClient, conds)
 	conds = validateAllowedScopes(oidcClient, conds)
 
@@ -51,7 +100,97 @@ func Validate(oidcClient *supervisorconfigv1alpha1.OIDCClient, secret *corev1.Se
 			break
 		}
 	}
-	return valid, conds, clientSecrets
+	if !valid {
+		publicKeys = nil
+	}
+	return valid, conds, clientSecrets, publicKeys
+}
+
+// validatePrivateKeyJWTSecret checks if the Secret which is referenced by spec.privateKeyJWT.secretName contains at
+// least one valid public key. It returns the updated conditions slice along with the public keys in the case that it
+// is valid.
+func validatePrivateKeyJWTSecret(
+	oidcClient *supervisorconfigv1alpha1.OIDCClient,
+	secret *corev1.Secret,
+	conditions []*metav1.Condition,
+) ([]*metav1.Condition, *jose.JSONWebKeySet) {
+	secretName := oidcClient.Spec.PrivateKeyJWT.SecretName
+
+	if secret == nil {
+		// Invalid: the Secret was not found.
+		conditions = append(conditions, &metav1.Condition{
+			Type:    privateKeyJWTValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  reasonPrivateKeyJWTSecretNotFound,
+			Message: fmt.Sprintf("secret %q not found", secretName),
+		})
+		return conditions, nil
+	}
+
+	if secret.Type != PrivateKeyJWTSecretType {
+		// Invalid: the Secret exists but has the wrong type.
+		conditions = append(conditions, &metav1.Condition{
+			Type:    privateKeyJWTValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  reasonInvalidPrivateKeyJWTSecret,
+			Message: fmt.Sprintf("secret %q has wrong type %q (should be %q)", secretName, secret.Type, PrivateKeyJWTSecretType),
+		})
+		return conditions, nil
+	}
+
+	publicKeys, err := ParsePrivateKeyJWTPublicKeys(secret.Data[PrivateKeyJWTPublicKeysKey])
+	if err != nil {
+		// Invalid: the Secret exists but its keys could not be parsed.
+		conditions = append(conditions, &metav1.Condition{
+			Type:    privateKeyJWTValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  reasonInvalidPrivateKeyJWTSecret,
+			Message: fmt.Sprintf("secret %q has invalid %q: %s", secretName, PrivateKeyJWTPublicKeysKey, err.Error()),
+		})
+		return conditions, nil
+	}
+
+	// Valid: the Secret has at least one public key, and all of its public keys are valid.
+	conditions = append(conditions, &metav1.Condition{
+		Type:    privateKeyJWTValid,
+		Status:  metav1.ConditionTrue,
+		Reason:  reasonSuccess,
+		Message: fmt.Sprintf("%d public key(s) found in secret %q", len(publicKeys.Keys), secretName),
+	})
+	return conditions, publicKeys
+}
+
+// ParsePrivateKeyJWTPublicKeys parses the PEM-encoded ECDSA P-256 public keys of a private_key_jwt client into a
+// JSON Web Key Set which can verify the client's ES256 client assertions. The ID of each key is its RFC 7638 JWK
+// thumbprint, which is the "kid" header that the client must use when it includes one in its assertions.
+func ParsePrivateKeyJWTPublicKeys(pemBytes []byte) (*jose.JSONWebKeySet, error) {
+	publicKeys := &jose.JSONWebKeySet{}
+	for i := 0; ; i++ {
+		var block *pem.Block
+		block, pemBytes = pem.Decode(pemBytes)
+		if block == nil {
+			break
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("public key at index %d: %w", i, err)
+		}
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || ecKey.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("public key at index %d: only ECDSA P-256 public keys are supported", i)
+		}
+		jwk := jose.JSONWebKey{Key: ecKey, Algorithm: PrivateKeyJWTSigningAlgorithm, Use: "sig"}
+		thumbprint, err := jwk.Thumbprint(crypto.SHA256)
+		if err != nil {
+			return nil, fmt.Errorf("public key at index %d: %w", i, err)
+		}
+		jwk.KeyID = base64.RawURLEncoding.EncodeToString(thumbprint)
+		publicKeys.Keys = append(publicKeys.Keys, jwk)
+	}
+	if len(publicKeys.Keys) == 0 {
+		return nil, fmt.Errorf("no PEM-encoded public keys found")
+	}
+	return publicKeys, nil
 }
 
 // validateAllowedScopes checks if allowedScopes is valid on the OIDCClient.
@@ -74,6 +213,15 @@ func validateAllowedScopes(oidcClient *supervisorconfigv1alpha1.OIDCClient, cond
 		m = append(m, fmt.Sprintf("%q must be included in %q when %q is included in %q",
 			oidcapi.ScopeRequestAudience, allowedScopesFieldName, oidcapi.GrantTypeTokenExchange, allowedGrantTypesFieldName))
 	}
+	for _, policy := range oidcClient.Spec.ScopePolicies {
+		switch {
+		case policy.Scope == oidcapi.ScopeOpenID:
+			m = append(m, fmt.Sprintf("%q must not be included in %q", oidcapi.ScopeOpenID, scopePoliciesFieldName))
+		case !allowedScopesContains(oidcClient, string(policy.Scope)):
+			m = append(m, fmt.Sprintf("%q must be included in %q when it is included in %q",
+				policy.Scope, allowedScopesFieldName, scopePoliciesFieldName))
+		}
+	}
 
 	if len(m) == 0 {
 		conditions = append(conditions, &metav1.Condition{
@@ -110,6 +258,10 @@ func validateAllowedGrantTypes(oidcClient *supervisorconfigv1alpha1.OIDCClient,
 		m = append(m, fmt.Sprintf("%q must be included in %q when %q is included in %q",
 			oidcapi.GrantTypeTokenExchange, allowedGrantTypesFieldName, oidcapi.ScopeRequestAudience, allowedScopesFieldName))
 	}
+	if oidcClient.Spec.Delegation != nil && !allowedGrantTypesContains(oidcClient, oidcapi.GrantTypeTokenExchange) {
+		m = append(m, fmt.Sprintf("%q must be included in %q when %q is specified",
+			oidcapi.GrantTypeTokenExchange, allowedGrantTypesFieldName, delegationFieldName))
+	}
 
 	if len(m) == 0 {
 		conditions = append(conditions, &metav1.Condition{
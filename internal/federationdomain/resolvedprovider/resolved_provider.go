@@ -6,10 +6,12 @@ package resolvedprovider
 import (
 	"context"
 	"net/http"
+	"sort"
 
 	"github.com/ory/fosite"
 
 	"go.pinniped.dev/generated/latest/apis/supervisor/idpdiscovery/v1alpha1"
+	"go.pinniped.dev/internal/federationdomain/errordetails"
 	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
 	"go.pinniped.dev/internal/idtransform"
 	"go.pinniped.dev/internal/psession"
@@ -53,6 +55,14 @@ type IdentityLoginExtras struct {
 
 	// Login warnings to show the user after they exchange their downstream authcode, if any.
 	Warnings []string
+
+	// The Authentication Context Class Reference (acr claim) which the upstream identity provider asserted for
+	// the login, if any.
+	AuthenticationContextClassReference string
+
+	// The Authentication Methods References (amr claim) which the upstream identity provider asserted for
+	// the login, if any.
+	AuthenticationMethodsReferences []string
 }
 
 // RefreshedIdentity represents the parts of an identity that an identity provider may update
@@ -85,16 +95,43 @@ type RefreshedIdentity struct {
 // the information needed to create the PKCE and nonce parameters for the upstream authorization request. If the
 // upstream authorization request does not allow PKCE, then implementations of
 // FederationDomainResolvedIdentityProvider.UpstreamAuthorizeRedirectURL may choose to ignore that struct field.
+// It also includes the ACR values which the downstream client requires the upstream login to assert one of, if any,
+// which implementations may ignore when the upstream authorization request cannot express them.
 type UpstreamAuthorizeRequestState struct {
 	EncodedStateParam string
 	PKCE              pkce.Code
 	Nonce             nonce.Nonce
+	ACRValues         []string
+}
+
+// Presentation describes how an identity provider is presented to end users by the IDP chooser page and the
+// IDP discovery endpoint, as configured in the FederationDomain. All fields are optional.
+type Presentation struct {
+	Description string
+	IconURL     string
+	Order       int32
+}
+
+// SortForPresentation sorts identity providers in the order in which they should be presented to end users,
+// i.e. by ascending Presentation.Order, and then by display name.
+func SortForPresentation(idps []FederationDomainResolvedIdentityProvider) {
+	sort.SliceStable(idps, func(i, j int) bool {
+		orderI, orderJ := idps[i].GetPresentation().Order, idps[j].GetPresentation().Order
+		if orderI != orderJ {
+			return orderI < orderJ
+		}
+		return idps[i].GetDisplayName() < idps[j].GetDisplayName()
+	})
 }
 
 type FederationDomainResolvedIdentityProvider interface {
 	// GetDisplayName returns the display name of this identity provider, as configured in the FederationDomain.
 	GetDisplayName() string
 
+	// GetPresentation returns how this identity provider is presented to end users, as configured in the
+	// FederationDomain.
+	GetPresentation() Presentation
+
 	// GetProvider returns a representation of the upstream identity provider custom resource related to this
 	// identity provider for the FederationDomain, e.g. the OIDCIdentityProvider.
 	GetProvider() upstreamprovider.UpstreamIdentityProviderI
@@ -154,13 +191,13 @@ type FederationDomainResolvedIdentityProvider interface {
 }
 
 // ErrMissingUpstreamSessionInternalError returns a common type of error that can happen during a login or refresh.
-func ErrMissingUpstreamSessionInternalError() *fosite.RFC6749Error {
-	return &fosite.RFC6749Error{
+func ErrMissingUpstreamSessionInternalError() error {
+	return errordetails.WithCode(&fosite.RFC6749Error{
 		ErrorField:       "error",
 		DescriptionField: "There was an internal server error.",
 		HintField:        "Required upstream data not found in session.",
 		CodeField:        http.StatusInternalServerError,
-	}
+	}, errordetails.SessionDataMissing)
 }
 
 // ErrUpstreamRefreshError returns a common type of error that can happen during a refresh.
@@ -0,0 +1,177 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package resolvedclientcert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ory/fosite"
+
+	"go.pinniped.dev/generated/latest/apis/supervisor/idpdiscovery/v1alpha1"
+	"go.pinniped.dev/internal/federationdomain/resolvedprovider"
+	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
+	"go.pinniped.dev/internal/httputil/httperr"
+	"go.pinniped.dev/internal/httputil/requestutil"
+	"go.pinniped.dev/internal/idtransform"
+	"go.pinniped.dev/internal/psession"
+	"go.pinniped.dev/pkg/oidcclient/nonce"
+	"go.pinniped.dev/pkg/oidcclient/pkce"
+)
+
+// CallbackAuthCode is the placeholder value of the "code" query param which is sent to the Supervisor's own
+// callback endpoint. There is no upstream authorization server, so there is no real authorization code. The
+// user's identity comes from the client certificate presented during the TLS handshake of the callback request.
+const CallbackAuthCode = "client-certificate"
+
+// FederationDomainResolvedClientCertificateIdentityProvider represents a FederationDomainIdentityProvider which has
+// been resolved dynamically based on the currently loaded IDP CRs to include the
+// provider.UpstreamClientCertificateIdentityProviderI and other metadata about the provider.
+type FederationDomainResolvedClientCertificateIdentityProvider struct {
+	DisplayName         string
+	Presentation        resolvedprovider.Presentation
+	Provider            upstreamprovider.UpstreamClientCertificateIdentityProviderI
+	SessionProviderType psession.ProviderType
+	Transforms          *idtransform.TransformationPipeline
+
+	// Now returns the current time. Defaults to time.Now when nil.
+	Now func() time.Time
+}
+
+var _ resolvedprovider.FederationDomainResolvedIdentityProvider = (*FederationDomainResolvedClientCertificateIdentityProvider)(nil)
+
+func (p *FederationDomainResolvedClientCertificateIdentityProvider) GetDisplayName() string {
+	return p.DisplayName
+}
+
+func (p *FederationDomainResolvedClientCertificateIdentityProvider) GetPresentation() resolvedprovider.Presentation {
+	return p.Presentation
+}
+
+func (p *FederationDomainResolvedClientCertificateIdentityProvider) GetProvider() upstreamprovider.UpstreamIdentityProviderI {
+	return p.Provider
+}
+
+func (p *FederationDomainResolvedClientCertificateIdentityProvider) GetSessionProviderType() psession.ProviderType {
+	return p.SessionProviderType
+}
+
+func (p *FederationDomainResolvedClientCertificateIdentityProvider) GetIDPDiscoveryType() v1alpha1.IDPType {
+	return v1alpha1.IDPTypeClientCertificate
+}
+
+func (p *FederationDomainResolvedClientCertificateIdentityProvider) GetIDPDiscoveryFlows() []v1alpha1.IDPFlow {
+	return []v1alpha1.IDPFlow{v1alpha1.IDPFlowBrowserAuthcode}
+}
+
+func (p *FederationDomainResolvedClientCertificateIdentityProvider) GetTransforms() *idtransform.TransformationPipeline {
+	return p.Transforms
+}
+
+func (p *FederationDomainResolvedClientCertificateIdentityProvider) CloneIDPSpecificSessionDataFromSession(session *psession.CustomSessionData) any {
+	if session.ClientCertificate == nil {
+		return nil
+	}
+	return session.ClientCertificate.Clone()
+}
+
+func (p *FederationDomainResolvedClientCertificateIdentityProvider) ApplyIDPSpecificSessionDataToSession(session *psession.CustomSessionData, idpSpecificSessionData any) {
+	session.ClientCertificate = idpSpecificSessionData.(*psession.ClientCertificateSessionData)
+}
+
+// UpstreamAuthorizeRedirectURL redirects the browser directly to the Supervisor's own callback endpoint,
+// since there is no upstream authorization server. The client certificate will be requested during the
+// TLS handshake of that callback request.
+func (p *FederationDomainResolvedClientCertificateIdentityProvider) UpstreamAuthorizeRedirectURL(
+	state *resolvedprovider.UpstreamAuthorizeRequestState,
+	downstreamIssuerURL string,
+) (string, error) {
+	params := url.Values{}
+	params.Set("code", CallbackAuthCode)
+	params.Set("state", state.EncodedStateParam)
+	return fmt.Sprintf("%s/callback?%s", downstreamIssuerURL, params.Encode()), nil
+}
+
+func (p *FederationDomainResolvedClientCertificateIdentityProvider) Login(
+	_ context.Context,
+	_ string,
+	_ string,
+) (*resolvedprovider.Identity, *resolvedprovider.IdentityLoginExtras, error) {
+	return nil, nil, errors.New("function Login not supported for client certificate IDP")
+}
+
+func (p *FederationDomainResolvedClientCertificateIdentityProvider) LoginFromCallback(
+	ctx context.Context,
+	_ string, // there is no upstream authcode to exchange
+	_ pkce.Code, // there is no upstream authorization server, so PKCE is not used
+	_ nonce.Nonce, // there is no upstream ID token that could contain the "nonce"
+	_ string,
+) (*resolvedprovider.Identity, *resolvedprovider.IdentityLoginExtras, error) {
+	user, err := p.Provider.AuthenticateCertificate(requestutil.PeerCertificatesFromContext(ctx), p.GetDisplayName())
+	if err != nil {
+		return nil, nil, httperr.Wrap(http.StatusUnauthorized,
+			fmt.Sprintf("failed to authenticate client certificate for ClientCertificateIdentityProvider with display name %q",
+				p.GetDisplayName()),
+			err,
+		)
+	}
+
+	return &resolvedprovider.Identity{
+			UpstreamUsername:  user.Username,
+			UpstreamGroups:    user.Groups,
+			DownstreamSubject: user.DownstreamSubject,
+			IDPSpecificSessionData: &psession.ClientCertificateSessionData{
+				CertificateFingerprint: user.Fingerprint,
+				CertificateNotAfter:    user.NotAfter,
+			},
+		},
+		&resolvedprovider.IdentityLoginExtras{
+			DownstreamAdditionalClaims: nil, // not using this for client certificates
+			Warnings:                   nil, // not using this for client certificates
+		},
+		nil // no error
+}
+
+// UpstreamRefresh allows refreshes until the client certificate which was used at login expires.
+// The certificate's contents cannot change, so the user's identity is not updated.
+func (p *FederationDomainResolvedClientCertificateIdentityProvider) UpstreamRefresh(
+	_ context.Context,
+	identity *resolvedprovider.Identity,
+) (*resolvedprovider.RefreshedIdentity, error) {
+	sessionData, ok := identity.IDPSpecificSessionData.(*psession.ClientCertificateSessionData)
+	if !ok {
+		// This should not really happen.
+		return nil, p.refreshErr(errors.New("wrong data type found for IDPSpecificSessionData"))
+	}
+	if len(sessionData.CertificateFingerprint) == 0 {
+		// This should not really happen.
+		return nil, p.refreshErr(errors.New("session is missing client certificate fingerprint"))
+	}
+
+	now := time.Now
+	if p.Now != nil {
+		now = p.Now
+	}
+	if !now().Before(sessionData.CertificateNotAfter) {
+		return nil, p.refreshErr(fmt.Errorf("client certificate expired at %s",
+			sessionData.CertificateNotAfter.UTC().Format(time.RFC3339)))
+	}
+
+	return &resolvedprovider.RefreshedIdentity{
+		UpstreamUsername:       identity.UpstreamUsername,
+		UpstreamGroups:         identity.UpstreamGroups,
+		IDPSpecificSessionData: nil, // nil means that no update to the client certificate portion of the session data is required
+	}, nil
+}
+
+func (p *FederationDomainResolvedClientCertificateIdentityProvider) refreshErr(err error) *fosite.RFC6749Error {
+	return resolvedprovider.ErrUpstreamRefreshError().
+		WithHint("Upstream refresh failed.").
+		WithTrace(err).
+		WithDebugf("provider name: %q, provider type: %q", p.Provider.GetResourceName(), p.GetSessionProviderType())
+}
@@ -0,0 +1,204 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package resolvedclientcert
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/stretchr/testify/require"
+
+	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
+	idpdiscoveryv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idpdiscovery/v1alpha1"
+	"go.pinniped.dev/internal/certauthority"
+	"go.pinniped.dev/internal/federationdomain/resolvedprovider"
+	"go.pinniped.dev/internal/httputil/httperr"
+	"go.pinniped.dev/internal/httputil/requestutil"
+	"go.pinniped.dev/internal/psession"
+	"go.pinniped.dev/internal/testutil/transformtestutil"
+	"go.pinniped.dev/internal/upstreamclientcert"
+)
+
+func TestFederationDomainResolvedClientCertificateIdentityProvider(t *testing.T) {
+	transforms := transformtestutil.NewRejectAllAuthPipeline(t)
+
+	ca, err := certauthority.New("fake-ca", time.Hour)
+	require.NoError(t, err)
+	tlsCert, err := ca.IssueClientCert("fake-username", []string{"fake-group1", "fake-group2"}, time.Hour)
+	require.NoError(t, err)
+	clientCert, err := x509.ParseCertificate(tlsCert.Certificate[0])
+	require.NoError(t, err)
+
+	provider := upstreamclientcert.New(upstreamclientcert.ProviderConfig{
+		Name:          "fake-provider-config",
+		ResourceUID:   "fake-resource-uid",
+		Roots:         ca.Pool(),
+		UsernameField: idpv1alpha1.ClientCertificateSubjectCommonName,
+		GroupsField:   idpv1alpha1.ClientCertificateSubjectOrganization,
+	})
+
+	subject := FederationDomainResolvedClientCertificateIdentityProvider{
+		DisplayName:         "fake-display-name",
+		Provider:            provider,
+		SessionProviderType: psession.ProviderTypeClientCertificate,
+		Transforms:          transforms,
+	}
+
+	require.Equal(t, "fake-display-name", subject.GetDisplayName())
+	require.Equal(t, provider, subject.GetProvider())
+	require.Equal(t, psession.ProviderTypeClientCertificate, subject.GetSessionProviderType())
+	require.Equal(t, idpdiscoveryv1alpha1.IDPTypeClientCertificate, subject.GetIDPDiscoveryType())
+	require.Equal(t, []idpdiscoveryv1alpha1.IDPFlow{idpdiscoveryv1alpha1.IDPFlowBrowserAuthcode}, subject.GetIDPDiscoveryFlows())
+	require.Equal(t, transforms, subject.GetTransforms())
+
+	notAfter := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	originalCustomSession := &psession.CustomSessionData{
+		Username:          "fake-username",
+		ClientCertificate: &psession.ClientCertificateSessionData{CertificateFingerprint: "fake-fingerprint", CertificateNotAfter: notAfter},
+	}
+	clonedCustomSession := subject.CloneIDPSpecificSessionDataFromSession(originalCustomSession)
+	require.Equal(t,
+		&psession.ClientCertificateSessionData{CertificateFingerprint: "fake-fingerprint", CertificateNotAfter: notAfter},
+		clonedCustomSession,
+	)
+	require.NotSame(t, originalCustomSession.ClientCertificate, clonedCustomSession)
+	require.Nil(t, subject.CloneIDPSpecificSessionDataFromSession(&psession.CustomSessionData{}))
+
+	customSessionToBeMutated := &psession.CustomSessionData{Username: "fake-username2"}
+	subject.ApplyIDPSpecificSessionDataToSession(customSessionToBeMutated,
+		&psession.ClientCertificateSessionData{CertificateFingerprint: "other-fingerprint"})
+	require.Equal(t, &psession.ClientCertificateSessionData{CertificateFingerprint: "other-fingerprint"}, customSessionToBeMutated.ClientCertificate)
+
+	redirectURL, err := subject.UpstreamAuthorizeRedirectURL(
+		&resolvedprovider.UpstreamAuthorizeRequestState{EncodedStateParam: "fake-state"},
+		"https://fake-downstream-issuer-url",
+	)
+	require.NoError(t, err)
+	require.Equal(t, "https://fake-downstream-issuer-url/callback?code=client-certificate&state=fake-state", redirectURL)
+
+	identity, loginExtras, err := subject.Login(context.Background(), "fake-username", "fake-password")
+	require.EqualError(t, err, "function Login not supported for client certificate IDP")
+	require.Nil(t, identity)
+	require.Nil(t, loginExtras)
+
+	t.Run("LoginFromCallback", func(t *testing.T) {
+		tests := []struct {
+			name         string
+			peerCerts    []*x509.Certificate
+			wantIdentity *resolvedprovider.Identity
+			wantErr      string
+		}{
+			{
+				name:      "happy path",
+				peerCerts: []*x509.Certificate{clientCert},
+				wantIdentity: &resolvedprovider.Identity{
+					UpstreamUsername:  "fake-username",
+					UpstreamGroups:    []string{"fake-group1", "fake-group2"},
+					DownstreamSubject: "x509:CN%3Dfake-ca?idpName=fake-display-name&sub=CN%3Dfake-username%2CO%3Dfake-group1%2BO%3Dfake-group2",
+				},
+			},
+			{
+				name:    "no client certificate presented",
+				wantErr: `failed to authenticate client certificate for ClientCertificateIdentityProvider with display name "fake-display-name": no client certificate was presented during the TLS handshake`,
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, "https://fake-downstream-issuer-url/callback", nil)
+				req.TLS = &tls.ConnectionState{PeerCertificates: test.peerCerts}
+
+				identity, loginExtras, err := subject.LoginFromCallback(requestutil.WithPeerCertificates(req),
+					"client-certificate", "", "", "https://fake-downstream-issuer-url/callback")
+				if test.wantErr != "" {
+					require.EqualError(t, err, test.wantErr)
+					require.Implements(t, (*httperr.Responder)(nil), err)
+					rec := httptest.NewRecorder()
+					err.(httperr.Responder).Respond(rec)
+					require.Equal(t, http.StatusUnauthorized, rec.Code)
+					require.Nil(t, identity)
+					require.Nil(t, loginExtras)
+					return
+				}
+				require.NoError(t, err)
+				sessionData, ok := identity.IDPSpecificSessionData.(*psession.ClientCertificateSessionData)
+				require.True(t, ok)
+				require.Len(t, sessionData.CertificateFingerprint, 64)
+				require.Equal(t, clientCert.NotAfter, sessionData.CertificateNotAfter)
+				identity.IDPSpecificSessionData = nil
+				require.Equal(t, test.wantIdentity, identity)
+				require.Equal(t, &resolvedprovider.IdentityLoginExtras{}, loginExtras)
+			})
+		}
+	})
+
+	t.Run("UpstreamRefresh", func(t *testing.T) {
+		tests := []struct {
+			name                  string
+			now                   time.Time
+			sessionData           any
+			wantRefreshedIdentity *resolvedprovider.RefreshedIdentity
+			wantErr               string
+			wantTrace             string
+		}{
+			{
+				name:        "happy path",
+				now:         notAfter.Add(-time.Minute),
+				sessionData: &psession.ClientCertificateSessionData{CertificateFingerprint: "fake-fingerprint", CertificateNotAfter: notAfter},
+				wantRefreshedIdentity: &resolvedprovider.RefreshedIdentity{
+					UpstreamUsername: "fake-username",
+					UpstreamGroups:   []string{"fake-group"},
+				},
+			},
+			{
+				name:        "certificate expired",
+				now:         notAfter,
+				sessionData: &psession.ClientCertificateSessionData{CertificateFingerprint: "fake-fingerprint", CertificateNotAfter: notAfter},
+				wantErr:     "Error during upstream refresh. Upstream refresh failed.",
+				wantTrace:   "client certificate expired at 2030-01-02T03:04:05Z",
+			},
+			{
+				name:        "wrong session data type",
+				now:         notAfter.Add(-time.Minute),
+				sessionData: &psession.GitHubSessionData{},
+				wantErr:     "Error during upstream refresh. Upstream refresh failed.",
+				wantTrace:   "wrong data type found for IDPSpecificSessionData",
+			},
+			{
+				name:        "missing fingerprint",
+				now:         notAfter.Add(-time.Minute),
+				sessionData: &psession.ClientCertificateSessionData{CertificateNotAfter: notAfter},
+				wantErr:     "Error during upstream refresh. Upstream refresh failed.",
+				wantTrace:   "session is missing client certificate fingerprint",
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				subject.Now = func() time.Time { return test.now }
+
+				refreshedIdentity, err := subject.UpstreamRefresh(context.Background(), &resolvedprovider.Identity{
+					UpstreamUsername:       "fake-username",
+					UpstreamGroups:         []string{"fake-group"},
+					DownstreamSubject:      "fake-subject",
+					IDPSpecificSessionData: test.sessionData,
+				})
+				if test.wantErr != "" {
+					var rfcErr *fosite.RFC6749Error
+					require.ErrorAs(t, err, &rfcErr)
+					require.Equal(t, test.wantErr, rfcErr.DescriptionField+" "+rfcErr.HintField)
+					require.EqualError(t, rfcErr.Unwrap(), test.wantTrace)
+					require.Nil(t, refreshedIdentity)
+					return
+				}
+				require.NoError(t, err)
+				require.Equal(t, test.wantRefreshedIdentity, refreshedIdentity)
+			})
+		}
+	})
+}
@@ -27,6 +27,7 @@ import (
 // and other metadata about the provider.
 type FederationDomainResolvedGitHubIdentityProvider struct {
 	DisplayName         string
+	Presentation        resolvedprovider.Presentation
 	Provider            upstreamprovider.UpstreamGithubIdentityProviderI
 	SessionProviderType psession.ProviderType
 	Transforms          *idtransform.TransformationPipeline
@@ -38,6 +39,10 @@ func (p *FederationDomainResolvedGitHubIdentityProvider) GetDisplayName() string
 	return p.DisplayName
 }
 
+func (p *FederationDomainResolvedGitHubIdentityProvider) GetPresentation() resolvedprovider.Presentation {
+	return p.Presentation
+}
+
 func (p *FederationDomainResolvedGitHubIdentityProvider) GetProvider() upstreamprovider.UpstreamIdentityProviderI {
 	return p.Provider
 }
@@ -21,6 +21,7 @@ import (
 	"go.pinniped.dev/internal/idtransform"
 	"go.pinniped.dev/internal/plog"
 	"go.pinniped.dev/internal/psession"
+	"go.pinniped.dev/internal/totp"
 	"go.pinniped.dev/pkg/oidcclient/nonce"
 	"go.pinniped.dev/pkg/oidcclient/pkce"
 )
@@ -30,6 +31,7 @@ import (
 // and other metadata about the provider.
 type FederationDomainResolvedLDAPIdentityProvider struct {
 	DisplayName         string
+	Presentation        resolvedprovider.Presentation
 	Provider            upstreamprovider.UpstreamLDAPIdentityProviderI
 	SessionProviderType psession.ProviderType
 	Transforms          *idtransform.TransformationPipeline
@@ -41,6 +43,10 @@ func (p *FederationDomainResolvedLDAPIdentityProvider) GetDisplayName() string {
 	return p.DisplayName
 }
 
+func (p *FederationDomainResolvedLDAPIdentityProvider) GetPresentation() resolvedprovider.Presentation {
+	return p.Presentation
+}
+
 func (p *FederationDomainResolvedLDAPIdentityProvider) GetProvider() upstreamprovider.UpstreamIdentityProviderI {
 	return p.Provider
 }
@@ -57,9 +63,18 @@ func (p *FederationDomainResolvedLDAPIdentityProvider) GetIDPDiscoveryType() v1a
 }
 
 func (p *FederationDomainResolvedLDAPIdentityProvider) GetIDPDiscoveryFlows() []v1alpha1.IDPFlow {
+	if p.GetTOTPConfig() != nil {
+		// The CLI-based password flow cannot ask for a one-time code.
+		return []v1alpha1.IDPFlow{v1alpha1.IDPFlowBrowserAuthcode}
+	}
 	return []v1alpha1.IDPFlow{v1alpha1.IDPFlowCLIPassword, v1alpha1.IDPFlowBrowserAuthcode}
 }
 
+// GetTOTPConfig returns the configuration of the TOTP second factor, or nil when it is not required.
+func (p *FederationDomainResolvedLDAPIdentityProvider) GetTOTPConfig() *totp.Config {
+	return p.Provider.GetTOTPConfig()
+}
+
 func (p *FederationDomainResolvedLDAPIdentityProvider) GetTransforms() *idtransform.TransformationPipeline {
 	return p.Transforms
 }
@@ -76,7 +91,7 @@ func (p *FederationDomainResolvedLDAPIdentityProvider) CloneIDPSpecificSessionDa
 			return nil
 		}
 		return session.ActiveDirectory.Clone()
-	case psession.ProviderTypeOIDC, psession.ProviderTypeGitHub: // this is just here to avoid a lint error about not handling all cases
+	case psession.ProviderTypeOIDC, psession.ProviderTypeGitHub, psession.ProviderTypeClientCertificate, psession.ProviderTypeMock, psession.ProviderTypeOpenShift: // this is just here to avoid a lint error about not handling all cases
 		fallthrough
 	default:
 		return nil
@@ -151,7 +166,7 @@ func (p *FederationDomainResolvedLDAPIdentityProvider) Login(
 			UserDN:                 authenticateResponse.DN,
 			ExtraRefreshAttributes: authenticateResponse.ExtraRefreshAttributes,
 		}
-	case psession.ProviderTypeOIDC, psession.ProviderTypeGitHub: // this is just here to avoid a lint error about not handling all cases
+	case psession.ProviderTypeOIDC, psession.ProviderTypeGitHub, psession.ProviderTypeClientCertificate, psession.ProviderTypeMock, psession.ProviderTypeOpenShift: // this is just here to avoid a lint error about not handling all cases
 		fallthrough
 	default:
 		return nil, nil, ErrUnexpectedUpstreamLDAPError.WithWrap(fmt.Errorf("unexpected provider type %q", p.GetSessionProviderType()))
@@ -205,7 +220,7 @@ func (p *FederationDomainResolvedLDAPIdentityProvider) UpstreamRefresh(
 		}
 		dn = sessionData.UserDN
 		additionalAttributes = sessionData.ExtraRefreshAttributes
-	case psession.ProviderTypeOIDC, psession.ProviderTypeGitHub: // this is just here to avoid a lint error about not handling all cases
+	case psession.ProviderTypeOIDC, psession.ProviderTypeGitHub, psession.ProviderTypeClientCertificate, psession.ProviderTypeMock, psession.ProviderTypeOpenShift: // this is just here to avoid a lint error about not handling all cases
 		fallthrough
 	default:
 		// This shouldn't really happen.
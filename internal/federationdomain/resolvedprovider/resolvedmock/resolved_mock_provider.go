@@ -0,0 +1,169 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package resolvedmock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ory/fosite"
+
+	"go.pinniped.dev/generated/latest/apis/supervisor/idpdiscovery/v1alpha1"
+	"go.pinniped.dev/internal/federationdomain/endpoints/loginurl"
+	"go.pinniped.dev/internal/federationdomain/resolvedprovider"
+	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
+	"go.pinniped.dev/internal/httputil/httperr"
+	"go.pinniped.dev/internal/idtransform"
+	"go.pinniped.dev/internal/psession"
+	"go.pinniped.dev/pkg/oidcclient/nonce"
+	"go.pinniped.dev/pkg/oidcclient/pkce"
+)
+
+// FederationDomainResolvedMockIdentityProvider represents a FederationDomainIdentityProvider which has
+// been resolved dynamically based on the currently loaded IDP CRs to include the
+// provider.UpstreamMockIdentityProviderI and other metadata about the provider.
+type FederationDomainResolvedMockIdentityProvider struct {
+	DisplayName         string
+	Presentation        resolvedprovider.Presentation
+	Provider            upstreamprovider.UpstreamMockIdentityProviderI
+	SessionProviderType psession.ProviderType
+	Transforms          *idtransform.TransformationPipeline
+}
+
+var _ resolvedprovider.FederationDomainResolvedIdentityProvider = (*FederationDomainResolvedMockIdentityProvider)(nil)
+
+// ErrAccessDeniedDueToUsernamePasswordNotAccepted is returned by Login when the username or password did not
+// match any of the static users. Due to the way that fosite implements RFC6749Error.Is(), you must use "=="
+// to compare this error to an error returned from Login().
+var ErrAccessDeniedDueToUsernamePasswordNotAccepted = &fosite.RFC6749Error{
+	ErrorField:       "access_denied", // this string matches what fosite uses for access denied errors
+	DescriptionField: "The resource owner or authorization server denied the request.",
+	HintField:        "Username/password not accepted by mock identity provider.",
+	CodeField:        http.StatusForbidden,
+}
+
+func (p *FederationDomainResolvedMockIdentityProvider) GetDisplayName() string {
+	return p.DisplayName
+}
+
+func (p *FederationDomainResolvedMockIdentityProvider) GetPresentation() resolvedprovider.Presentation {
+	return p.Presentation
+}
+
+func (p *FederationDomainResolvedMockIdentityProvider) GetProvider() upstreamprovider.UpstreamIdentityProviderI {
+	return p.Provider
+}
+
+func (p *FederationDomainResolvedMockIdentityProvider) GetSessionProviderType() psession.ProviderType {
+	return p.SessionProviderType
+}
+
+func (p *FederationDomainResolvedMockIdentityProvider) GetIDPDiscoveryType() v1alpha1.IDPType {
+	return v1alpha1.IDPTypeMock
+}
+
+func (p *FederationDomainResolvedMockIdentityProvider) GetIDPDiscoveryFlows() []v1alpha1.IDPFlow {
+	return []v1alpha1.IDPFlow{v1alpha1.IDPFlowCLIPassword, v1alpha1.IDPFlowBrowserAuthcode}
+}
+
+func (p *FederationDomainResolvedMockIdentityProvider) GetTransforms() *idtransform.TransformationPipeline {
+	return p.Transforms
+}
+
+func (p *FederationDomainResolvedMockIdentityProvider) CloneIDPSpecificSessionDataFromSession(session *psession.CustomSessionData) any {
+	if session.Mock == nil {
+		return nil
+	}
+	return session.Mock.Clone()
+}
+
+func (p *FederationDomainResolvedMockIdentityProvider) ApplyIDPSpecificSessionDataToSession(session *psession.CustomSessionData, idpSpecificSessionData any) {
+	session.Mock = idpSpecificSessionData.(*psession.MockSessionData)
+}
+
+// UpstreamAuthorizeRedirectURL redirects the browser to the Supervisor's own login form, like for LDAP.
+func (p *FederationDomainResolvedMockIdentityProvider) UpstreamAuthorizeRedirectURL(state *resolvedprovider.UpstreamAuthorizeRequestState, downstreamIssuerURL string) (string, error) {
+	loginURL, err := loginurl.URL(downstreamIssuerURL, state.EncodedStateParam, loginurl.ShowNoError)
+	if err != nil {
+		return "", fosite.ErrServerError.WithHint("Server could not formulate login UI URL for redirect.").WithWrap(err)
+	}
+
+	return loginURL, nil
+}
+
+func (p *FederationDomainResolvedMockIdentityProvider) Login(
+	_ context.Context,
+	submittedUsername string,
+	submittedPassword string,
+) (*resolvedprovider.Identity, *resolvedprovider.IdentityLoginExtras, error) {
+	user, authenticated := p.Provider.AuthenticateUser(submittedUsername, submittedPassword, p.GetDisplayName())
+	if !authenticated {
+		return nil, nil, ErrAccessDeniedDueToUsernamePasswordNotAccepted
+	}
+
+	return &resolvedprovider.Identity{
+			UpstreamUsername:  user.Username,
+			UpstreamGroups:    user.Groups,
+			DownstreamSubject: user.DownstreamSubject,
+			IDPSpecificSessionData: &psession.MockSessionData{
+				Username: user.Username,
+			},
+		},
+		&resolvedprovider.IdentityLoginExtras{
+			DownstreamAdditionalClaims: nil, // not using this for mock identity providers
+			Warnings:                   nil, // not using this for mock identity providers
+		},
+		nil
+}
+
+func (p *FederationDomainResolvedMockIdentityProvider) LoginFromCallback(
+	_ context.Context,
+	_ string,
+	_ pkce.Code,
+	_ nonce.Nonce,
+	_ string,
+) (*resolvedprovider.Identity, *resolvedprovider.IdentityLoginExtras, error) {
+	return nil, nil, httperr.New(http.StatusInternalServerError,
+		"LoginFromCallback() is not supported for mock identity providers")
+}
+
+// UpstreamRefresh looks up the user again, so that removing a user from the users Secret ends their sessions
+// and changing their groups takes effect on the next refresh.
+func (p *FederationDomainResolvedMockIdentityProvider) UpstreamRefresh(
+	_ context.Context,
+	identity *resolvedprovider.Identity,
+) (*resolvedprovider.RefreshedIdentity, error) {
+	sessionData, ok := identity.IDPSpecificSessionData.(*psession.MockSessionData)
+	if !ok {
+		// This should not really happen.
+		return nil, p.refreshErr(errors.New("wrong data type found for IDPSpecificSessionData"))
+	}
+	if len(sessionData.Username) == 0 {
+		// This should not really happen.
+		return nil, p.refreshErr(errors.New("session is missing username"))
+	}
+
+	user, found := p.Provider.GetUser(sessionData.Username, p.GetDisplayName())
+	if !found {
+		return nil, p.refreshErr(fmt.Errorf("user %q no longer exists", sessionData.Username))
+	}
+	if user.DownstreamSubject != identity.DownstreamSubject {
+		return nil, p.refreshErr(fmt.Errorf("user's calculated downstream subject at refresh time did not match original value: %q", user.DownstreamSubject))
+	}
+
+	return &resolvedprovider.RefreshedIdentity{
+		UpstreamUsername:       user.Username,
+		UpstreamGroups:         user.Groups,
+		IDPSpecificSessionData: nil, // nil means that no update to the mock portion of the session data is required
+	}, nil
+}
+
+func (p *FederationDomainResolvedMockIdentityProvider) refreshErr(err error) *fosite.RFC6749Error {
+	return resolvedprovider.ErrUpstreamRefreshError().
+		WithHint("Upstream refresh failed.").
+		WithTrace(err).
+		WithDebugf("provider name: %q, provider type: %q", p.Provider.GetResourceName(), p.GetSessionProviderType())
+}
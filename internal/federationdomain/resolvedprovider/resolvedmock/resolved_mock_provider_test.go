@@ -0,0 +1,163 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package resolvedmock
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ory/fosite"
+	"github.com/stretchr/testify/require"
+
+	idpdiscoveryv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idpdiscovery/v1alpha1"
+	"go.pinniped.dev/internal/federationdomain/resolvedprovider"
+	"go.pinniped.dev/internal/httputil/httperr"
+	"go.pinniped.dev/internal/psession"
+	"go.pinniped.dev/internal/testutil/transformtestutil"
+	"go.pinniped.dev/internal/upstreammock"
+)
+
+func TestFederationDomainResolvedMockIdentityProvider(t *testing.T) {
+	transforms := transformtestutil.NewRejectAllAuthPipeline(t)
+
+	provider := upstreammock.New(upstreammock.ProviderConfig{
+		Name:        "fake-provider-config",
+		ResourceUID: "fake-resource-uid",
+		Users: []upstreammock.User{
+			{Username: "fake-username", Password: "fake-password", Groups: []string{"fake-group1", "fake-group2"}},
+		},
+	})
+
+	subject := FederationDomainResolvedMockIdentityProvider{
+		DisplayName:         "fake-display-name",
+		Provider:            provider,
+		SessionProviderType: psession.ProviderTypeMock,
+		Transforms:          transforms,
+	}
+
+	require.Equal(t, "fake-display-name", subject.GetDisplayName())
+	require.Equal(t, provider, subject.GetProvider())
+	require.Equal(t, psession.ProviderTypeMock, subject.GetSessionProviderType())
+	require.Equal(t, idpdiscoveryv1alpha1.IDPTypeMock, subject.GetIDPDiscoveryType())
+	require.Equal(t, []idpdiscoveryv1alpha1.IDPFlow{
+		idpdiscoveryv1alpha1.IDPFlowCLIPassword,
+		idpdiscoveryv1alpha1.IDPFlowBrowserAuthcode,
+	}, subject.GetIDPDiscoveryFlows())
+	require.Equal(t, transforms, subject.GetTransforms())
+
+	originalCustomSession := &psession.CustomSessionData{
+		Username: "fake-username",
+		Mock:     &psession.MockSessionData{Username: "fake-username"},
+	}
+	clonedCustomSession := subject.CloneIDPSpecificSessionDataFromSession(originalCustomSession)
+	require.Equal(t, &psession.MockSessionData{Username: "fake-username"}, clonedCustomSession)
+	require.NotSame(t, originalCustomSession.Mock, clonedCustomSession)
+	require.Nil(t, subject.CloneIDPSpecificSessionDataFromSession(&psession.CustomSessionData{}))
+
+	customSessionToBeMutated := &psession.CustomSessionData{Username: "fake-username2"}
+	subject.ApplyIDPSpecificSessionDataToSession(customSessionToBeMutated, &psession.MockSessionData{Username: "fake-username2"})
+	require.Equal(t, &psession.MockSessionData{Username: "fake-username2"}, customSessionToBeMutated.Mock)
+
+	redirectURL, err := subject.UpstreamAuthorizeRedirectURL(
+		&resolvedprovider.UpstreamAuthorizeRequestState{EncodedStateParam: "fake-state"},
+		"https://fake-downstream-issuer-url",
+	)
+	require.NoError(t, err)
+	require.Equal(t, "https://fake-downstream-issuer-url/login?state=fake-state", redirectURL)
+
+	identity, loginExtras, err := subject.LoginFromCallback(context.Background(), "fake-code", "", "", "https://fake-downstream-issuer-url/callback")
+	require.EqualError(t, err, "LoginFromCallback() is not supported for mock identity providers")
+	require.Implements(t, (*httperr.Responder)(nil), err)
+	rec := httptest.NewRecorder()
+	err.(httperr.Responder).Respond(rec)
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	require.Nil(t, identity)
+	require.Nil(t, loginExtras)
+
+	t.Run("Login", func(t *testing.T) {
+		identity, loginExtras, err := subject.Login(context.Background(), "fake-username", "fake-password")
+		require.NoError(t, err)
+		require.Equal(t, &resolvedprovider.Identity{
+			UpstreamUsername:       "fake-username",
+			UpstreamGroups:         []string{"fake-group1", "fake-group2"},
+			DownstreamSubject:      "mock:fake-provider-config?idpName=fake-display-name&sub=fake-username",
+			IDPSpecificSessionData: &psession.MockSessionData{Username: "fake-username"},
+		}, identity)
+		require.Equal(t, &resolvedprovider.IdentityLoginExtras{}, loginExtras)
+
+		identity, loginExtras, err = subject.Login(context.Background(), "fake-username", "wrong-password")
+		require.True(t, err == ErrAccessDeniedDueToUsernamePasswordNotAccepted) //nolint:errorlint // fosite errors must be compared using ==
+		require.Nil(t, identity)
+		require.Nil(t, loginExtras)
+	})
+
+	t.Run("UpstreamRefresh", func(t *testing.T) {
+		tests := []struct {
+			name                  string
+			sessionData           any
+			downstreamSubject     string
+			wantRefreshedIdentity *resolvedprovider.RefreshedIdentity
+			wantErr               string
+			wantTrace             string
+		}{
+			{
+				name:              "happy path",
+				sessionData:       &psession.MockSessionData{Username: "fake-username"},
+				downstreamSubject: "mock:fake-provider-config?idpName=fake-display-name&sub=fake-username",
+				wantRefreshedIdentity: &resolvedprovider.RefreshedIdentity{
+					UpstreamUsername: "fake-username",
+					UpstreamGroups:   []string{"fake-group1", "fake-group2"},
+				},
+			},
+			{
+				name:              "user was removed",
+				sessionData:       &psession.MockSessionData{Username: "removed-username"},
+				downstreamSubject: "mock:fake-provider-config?idpName=fake-display-name&sub=removed-username",
+				wantErr:           "Error during upstream refresh. Upstream refresh failed.",
+				wantTrace:         `user "removed-username" no longer exists`,
+			},
+			{
+				name:              "subject changed",
+				sessionData:       &psession.MockSessionData{Username: "fake-username"},
+				downstreamSubject: "mock:other-provider-config?idpName=fake-display-name&sub=fake-username",
+				wantErr:           "Error during upstream refresh. Upstream refresh failed.",
+				wantTrace:         `user's calculated downstream subject at refresh time did not match original value: "mock:fake-provider-config?idpName=fake-display-name&sub=fake-username"`,
+			},
+			{
+				name:        "wrong session data type",
+				sessionData: &psession.GitHubSessionData{},
+				wantErr:     "Error during upstream refresh. Upstream refresh failed.",
+				wantTrace:   "wrong data type found for IDPSpecificSessionData",
+			},
+			{
+				name:        "missing username",
+				sessionData: &psession.MockSessionData{},
+				wantErr:     "Error during upstream refresh. Upstream refresh failed.",
+				wantTrace:   "session is missing username",
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				refreshedIdentity, err := subject.UpstreamRefresh(context.Background(), &resolvedprovider.Identity{
+					UpstreamUsername:       "fake-username",
+					UpstreamGroups:         []string{"fake-group"},
+					DownstreamSubject:      test.downstreamSubject,
+					IDPSpecificSessionData: test.sessionData,
+				})
+				if test.wantErr != "" {
+					var rfcErr *fosite.RFC6749Error
+					require.ErrorAs(t, err, &rfcErr)
+					require.Equal(t, test.wantErr, rfcErr.DescriptionField+" "+rfcErr.HintField)
+					require.EqualError(t, rfcErr.Unwrap(), test.wantTrace)
+					require.Nil(t, refreshedIdentity)
+					return
+				}
+				require.NoError(t, err)
+				require.Equal(t, test.wantRefreshedIdentity, refreshedIdentity)
+			})
+		}
+	})
+}
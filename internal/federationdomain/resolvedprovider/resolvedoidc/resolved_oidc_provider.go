@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/ory/fosite"
@@ -39,6 +40,13 @@ const (
 	// The name of the email_verified claim from https://openid.net/specs/openid-connect-core-1_0.html#StandardClaims
 	emailVerifiedClaimName = "email_verified"
 
+	// The names of the acr and amr claims from https://openid.net/specs/openid-connect-core-1_0.html#IDToken
+	acrClaimName = "acr"
+	amrClaimName = "amr"
+
+	// The name of the acr_values param from https://openid.net/specs/openid-connect-core-1_0.html#AuthRequest
+	acrValuesParamName = "acr_values"
+
 	requiredClaimMissingErr            = constable.Error("required claim in upstream ID token missing")
 	requiredClaimInvalidFormatErr      = constable.Error("required claim in upstream ID token has invalid format")
 	requiredClaimEmptyErr              = constable.Error("required claim in upstream ID token is empty")
@@ -51,6 +59,7 @@ const (
 // and other metadata about the provider.
 type FederationDomainResolvedOIDCIdentityProvider struct {
 	DisplayName         string
+	Presentation        resolvedprovider.Presentation
 	Provider            upstreamprovider.UpstreamOIDCIdentityProviderI
 	SessionProviderType psession.ProviderType
 	Transforms          *idtransform.TransformationPipeline
@@ -62,6 +71,10 @@ func (p *FederationDomainResolvedOIDCIdentityProvider) GetDisplayName() string {
 	return p.DisplayName
 }
 
+func (p *FederationDomainResolvedOIDCIdentityProvider) GetPresentation() resolvedprovider.Presentation {
+	return p.Presentation
+}
+
 func (p *FederationDomainResolvedOIDCIdentityProvider) GetProvider() upstreamprovider.UpstreamIdentityProviderI {
 	return p.Provider
 }
@@ -117,6 +130,11 @@ func (p *FederationDomainResolvedOIDCIdentityProvider) UpstreamAuthorizeRedirect
 		authCodeOptions = append(authCodeOptions, oauth2.SetAuthURLParam(key, val))
 	}
 
+	// Ask the upstream to authenticate the user in a way which the downstream client requires, e.g. with MFA.
+	if len(state.ACRValues) > 0 {
+		authCodeOptions = append(authCodeOptions, oauth2.SetAuthURLParam(acrValuesParamName, strings.Join(state.ACRValues, " ")))
+	}
+
 	redirectURL := upstreamOAuthConfig.AuthCodeURL(
 		state.EncodedStateParam,
 		authCodeOptions...,
@@ -157,6 +175,7 @@ func (p *FederationDomainResolvedOIDCIdentityProvider) Login(
 	}
 
 	additionalClaims := mapAdditionalClaimsFromUpstreamIDToken(p.Provider, token.IDToken.Claims)
+	acr, amr := getAuthenticationContextFromUpstreamIDToken(p.Provider, token.IDToken.Claims)
 
 	oidcSessionData, warnings, err := makeDownstreamOIDCSessionData(p.Provider, token)
 	if err != nil {
@@ -170,8 +189,10 @@ func (p *FederationDomainResolvedOIDCIdentityProvider) Login(
 			IDPSpecificSessionData: oidcSessionData,
 		},
 		&resolvedprovider.IdentityLoginExtras{
-			DownstreamAdditionalClaims: additionalClaims,
-			Warnings:                   warnings,
+			DownstreamAdditionalClaims:          additionalClaims,
+			Warnings:                            warnings,
+			AuthenticationContextClassReference: acr,
+			AuthenticationMethodsReferences:     amr,
 		},
 		nil
 }
@@ -202,6 +223,7 @@ func (p *FederationDomainResolvedOIDCIdentityProvider) LoginFromCallback(
 	}
 
 	additionalClaims := mapAdditionalClaimsFromUpstreamIDToken(p.Provider, token.IDToken.Claims)
+	acr, amr := getAuthenticationContextFromUpstreamIDToken(p.Provider, token.IDToken.Claims)
 
 	oidcSessionData, warnings, err := makeDownstreamOIDCSessionData(p.Provider, token)
 	if err != nil {
@@ -215,12 +237,26 @@ func (p *FederationDomainResolvedOIDCIdentityProvider) LoginFromCallback(
 			IDPSpecificSessionData: oidcSessionData,
 		},
 		&resolvedprovider.IdentityLoginExtras{
-			DownstreamAdditionalClaims: additionalClaims,
-			Warnings:                   warnings,
+			DownstreamAdditionalClaims:          additionalClaims,
+			Warnings:                            warnings,
+			AuthenticationContextClassReference: acr,
+			AuthenticationMethodsReferences:     amr,
 		},
 		nil
 }
 
+// UsesJWTSecuredAuthorizationResponses returns true when the upstream provider returns its authorization responses
+// to the callback as JWTs (JARM).
+func (p *FederationDomainResolvedOIDCIdentityProvider) UsesJWTSecuredAuthorizationResponses() bool {
+	return p.Provider.UsesJWTSecuredAuthorizationResponses()
+}
+
+// ValidateJWTSecuredAuthorizationResponse validates a JWT secured authorization response which was returned to the
+// callback by the upstream provider, and returns the params which it carried.
+func (p *FederationDomainResolvedOIDCIdentityProvider) ValidateJWTSecuredAuthorizationResponse(ctx context.Context, response string) (url.Values, error) {
+	return p.Provider.ValidateJWTSecuredAuthorizationResponse(ctx, response)
+}
+
 func (p *FederationDomainResolvedOIDCIdentityProvider) UpstreamRefresh(
 	ctx context.Context,
 	identity *resolvedprovider.Identity,
@@ -465,6 +501,28 @@ func mapAdditionalClaimsFromUpstreamIDToken(
 	return mapped
 }
 
+// getAuthenticationContextFromUpstreamIDToken returns the acr and amr claims of the upstream token, if any.
+// Claims which have an unexpected format are ignored, so they will not meet any required ACR values.
+func getAuthenticationContextFromUpstreamIDToken(
+	upstreamIDPConfig upstreamprovider.UpstreamOIDCIdentityProviderI,
+	idTokenClaims map[string]any,
+) (string, []string) {
+	acr, _ := getString(idTokenClaims, acrClaimName)
+
+	var amr []string
+	if amrAsInterface, ok := idTokenClaims[amrClaimName]; ok {
+		if amr, ok = extractGroups(amrAsInterface); !ok {
+			plog.Warning(
+				"amr claim in upstream ID token has invalid format",
+				"identityProviderResourceName", upstreamIDPConfig.GetResourceName(),
+			)
+			amr = nil
+		}
+	}
+
+	return acr, amr
+}
+
 func getDownstreamSubjectAndUpstreamUsernameFromUpstreamIDToken(
 	upstreamIDPConfig upstreamprovider.UpstreamOIDCIdentityProviderI,
 	idTokenClaims map[string]any,
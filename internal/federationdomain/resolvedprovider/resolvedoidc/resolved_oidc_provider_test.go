@@ -4,11 +4,15 @@
 package resolvedoidc
 
 import (
+	"net/url"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
+	"go.pinniped.dev/internal/federationdomain/resolvedprovider"
 	"go.pinniped.dev/internal/testutil/oidctestutil"
+	"go.pinniped.dev/pkg/oidcclient/nonce"
+	"go.pinniped.dev/pkg/oidcclient/pkce"
 )
 
 func TestMapAdditionalClaimsFromUpstreamIDToken(t *testing.T) {
@@ -69,3 +73,80 @@ func TestMapAdditionalClaimsFromUpstreamIDToken(t *testing.T) {
 		})
 	}
 }
+
+func TestGetAuthenticationContextFromUpstreamIDToken(t *testing.T) {
+	tests := []struct {
+		name           string
+		upstreamClaims map[string]any
+		wantACR        string
+		wantAMR        []string
+	}{
+		{
+			name:           "happy path",
+			upstreamClaims: map[string]any{"acr": "phrh", "amr": []any{"pwd", "otp"}},
+			wantACR:        "phrh",
+			wantAMR:        []string{"pwd", "otp"},
+		},
+		{
+			name:           "missing",
+			upstreamClaims: map[string]any{},
+		},
+		{
+			name:           "invalid formats",
+			upstreamClaims: map[string]any{"acr": 42, "amr": []any{"pwd", 42}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			idp := oidctestutil.NewTestUpstreamOIDCIdentityProviderBuilder().Build()
+			acr, amr := getAuthenticationContextFromUpstreamIDToken(idp, test.upstreamClaims)
+
+			require.Equal(t, test.wantACR, acr)
+			require.Equal(t, test.wantAMR, amr)
+		})
+	}
+}
+
+func TestUpstreamAuthorizeRedirectURLRequestsACRValues(t *testing.T) {
+	tests := []struct {
+		name          string
+		acrValues     []string
+		wantACRValues []string
+	}{
+		{
+			name:          "requests the ACR values which are required by the client",
+			acrValues:     []string{"phr", "phrh"},
+			wantACRValues: []string{"phr phrh"},
+		},
+		{
+			name: "does not request ACR values when the client does not require any",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := &FederationDomainResolvedOIDCIdentityProvider{
+				DisplayName: "some-idp",
+				Provider: oidctestutil.NewTestUpstreamOIDCIdentityProviderBuilder().
+					WithAuthorizationURL(url.URL{Scheme: "https", Host: "upstream.example.com", Path: "/authorize"}).
+					Build(),
+			}
+			redirectURL, err := p.UpstreamAuthorizeRedirectURL(&resolvedprovider.UpstreamAuthorizeRequestState{
+				EncodedStateParam: "some-state",
+				PKCE:              pkce.Code("test-pkce-0123456789012345678901234567890123456789"),
+				Nonce:             nonce.Nonce("some-nonce"),
+				ACRValues:         test.acrValues,
+			}, "https://issuer.example.com/some/path")
+			require.NoError(t, err)
+
+			parsed, err := url.Parse(redirectURL)
+			require.NoError(t, err)
+			require.Equal(t, test.wantACRValues, parsed.Query()["acr_values"])
+		})
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package resolvedopenshift
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ory/fosite"
+	"golang.org/x/oauth2"
+
+	"go.pinniped.dev/generated/latest/apis/supervisor/idpdiscovery/v1alpha1"
+	"go.pinniped.dev/internal/federationdomain/resolvedprovider"
+	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
+	"go.pinniped.dev/internal/httputil/httperr"
+	"go.pinniped.dev/internal/idtransform"
+	"go.pinniped.dev/internal/psession"
+	"go.pinniped.dev/pkg/oidcclient/nonce"
+	"go.pinniped.dev/pkg/oidcclient/pkce"
+)
+
+// FederationDomainResolvedOpenShiftIdentityProvider represents a FederationDomainIdentityProvider which has
+// been resolved dynamically based on the currently loaded IDP CRs to include the provider.UpstreamOpenShiftIdentityProviderI
+// and other metadata about the provider.
+type FederationDomainResolvedOpenShiftIdentityProvider struct {
+	DisplayName         string
+	Presentation        resolvedprovider.Presentation
+	Provider            upstreamprovider.UpstreamOpenShiftIdentityProviderI
+	SessionProviderType psession.ProviderType
+	Transforms          *idtransform.TransformationPipeline
+}
+
+var _ resolvedprovider.FederationDomainResolvedIdentityProvider = (*FederationDomainResolvedOpenShiftIdentityProvider)(nil)
+
+func (p *FederationDomainResolvedOpenShiftIdentityProvider) GetDisplayName() string {
+	return p.DisplayName
+}
+
+func (p *FederationDomainResolvedOpenShiftIdentityProvider) GetPresentation() resolvedprovider.Presentation {
+	return p.Presentation
+}
+
+func (p *FederationDomainResolvedOpenShiftIdentityProvider) GetProvider() upstreamprovider.UpstreamIdentityProviderI {
+	return p.Provider
+}
+
+func (p *FederationDomainResolvedOpenShiftIdentityProvider) GetSessionProviderType() psession.ProviderType {
+	return p.SessionProviderType
+}
+
+func (p *FederationDomainResolvedOpenShiftIdentityProvider) GetIDPDiscoveryType() v1alpha1.IDPType {
+	return v1alpha1.IDPTypeOpenShift
+}
+
+func (p *FederationDomainResolvedOpenShiftIdentityProvider) GetIDPDiscoveryFlows() []v1alpha1.IDPFlow {
+	return []v1alpha1.IDPFlow{v1alpha1.IDPFlowBrowserAuthcode}
+}
+
+func (p *FederationDomainResolvedOpenShiftIdentityProvider) GetTransforms() *idtransform.TransformationPipeline {
+	return p.Transforms
+}
+
+func (p *FederationDomainResolvedOpenShiftIdentityProvider) CloneIDPSpecificSessionDataFromSession(session *psession.CustomSessionData) any {
+	if session.OpenShift == nil {
+		return nil
+	}
+	return session.OpenShift.Clone()
+}
+
+func (p *FederationDomainResolvedOpenShiftIdentityProvider) ApplyIDPSpecificSessionDataToSession(session *psession.CustomSessionData, idpSpecificSessionData any) {
+	session.OpenShift = idpSpecificSessionData.(*psession.OpenShiftSessionData)
+}
+
+func (p *FederationDomainResolvedOpenShiftIdentityProvider) UpstreamAuthorizeRedirectURL(
+	state *resolvedprovider.UpstreamAuthorizeRequestState,
+	downstreamIssuerURL string,
+) (string, error) {
+	upstreamOAuthConfig := oauth2.Config{
+		ClientID: p.Provider.GetClientID(),
+		Endpoint: oauth2.Endpoint{
+			AuthURL: p.Provider.GetAuthorizationURL(),
+		},
+		RedirectURL: fmt.Sprintf("%s/callback", downstreamIssuerURL),
+		Scopes:      p.Provider.GetScopes(),
+	}
+
+	var authCodeOptions []oauth2.AuthCodeOption
+	if p.Provider.UsesPKCE() {
+		authCodeOptions = append(authCodeOptions, state.PKCE.Challenge(), state.PKCE.Method())
+	}
+
+	redirectURL := upstreamOAuthConfig.AuthCodeURL(state.EncodedStateParam, authCodeOptions...)
+	return redirectURL, nil
+}
+
+func (p *FederationDomainResolvedOpenShiftIdentityProvider) Login(
+	_ context.Context,
+	_ string,
+	_ string,
+) (*resolvedprovider.Identity, *resolvedprovider.IdentityLoginExtras, error) {
+	return nil, nil, errors.New("function Login not yet implemented for OpenShift IDP")
+}
+
+func (p *FederationDomainResolvedOpenShiftIdentityProvider) LoginFromCallback(
+	ctx context.Context,
+	authCode string,
+	pkceCodeVerifier pkce.Code,
+	_ nonce.Nonce, // OpenShift does not support OIDC, therefore there is no ID token that could contain the "nonce".
+	redirectURI string,
+) (*resolvedprovider.Identity, *resolvedprovider.IdentityLoginExtras, error) {
+	accessToken, err := p.Provider.ExchangeAuthcode(ctx, authCode, pkceCodeVerifier, redirectURI)
+	if err != nil {
+		return nil, nil, httperr.Wrap(http.StatusBadGateway,
+			"failed to exchange authcode using OpenShift OAuth server",
+			err,
+		)
+	}
+
+	user, err := p.Provider.GetUser(ctx, accessToken, p.GetDisplayName())
+	if err != nil {
+		return nil, nil, httperr.Wrap(http.StatusUnprocessableEntity,
+			"failed to get user info from OpenShift API",
+			err,
+		)
+	}
+
+	return &resolvedprovider.Identity{
+			UpstreamUsername:  user.Username,
+			UpstreamGroups:    user.Groups,
+			DownstreamSubject: user.DownstreamSubject,
+			IDPSpecificSessionData: &psession.OpenShiftSessionData{
+				UpstreamAccessToken: accessToken,
+			},
+		},
+		&resolvedprovider.IdentityLoginExtras{},
+		nil // no error
+}
+
+func (p *FederationDomainResolvedOpenShiftIdentityProvider) UpstreamRefresh(
+	ctx context.Context,
+	identity *resolvedprovider.Identity,
+) (*resolvedprovider.RefreshedIdentity, error) {
+	openShiftSessionData, ok := identity.IDPSpecificSessionData.(*psession.OpenShiftSessionData)
+	if !ok {
+		// This should not really happen.
+		return nil, p.refreshErr(errors.New("wrong data type found for IDPSpecificSessionData"))
+	}
+	if len(openShiftSessionData.UpstreamAccessToken) == 0 {
+		// This should not really happen.
+		return nil, p.refreshErr(errors.New("session is missing OpenShift access token"))
+	}
+
+	// The OAuth server of OpenShift does not issue refresh tokens, so get the user's identity and groups again
+	// using the cached access token. This fails after the access token expires, and then the user must log in again.
+	refreshedUserInfo, err := p.Provider.GetUser(ctx, openShiftSessionData.UpstreamAccessToken, p.GetDisplayName())
+	if err != nil {
+		return nil, p.refreshErr(err)
+	}
+
+	if refreshedUserInfo.DownstreamSubject != identity.DownstreamSubject {
+		// The user's upstream identity changed since the initial login in a surprising way.
+		return nil, p.refreshErr(fmt.Errorf("user's calculated downstream subject at initial login was %q but now is %q",
+			identity.DownstreamSubject, refreshedUserInfo.DownstreamSubject))
+	}
+
+	return &resolvedprovider.RefreshedIdentity{
+		UpstreamUsername:       refreshedUserInfo.Username,
+		UpstreamGroups:         refreshedUserInfo.Groups,
+		IDPSpecificSessionData: nil, // nil means that no update to the OpenShift-specific portion of the session data is required
+	}, nil
+}
+
+func (p *FederationDomainResolvedOpenShiftIdentityProvider) refreshErr(err error) *fosite.RFC6749Error {
+	return resolvedprovider.ErrUpstreamRefreshError().
+		WithHint("Upstream refresh failed.").
+		WithTrace(err).
+		WithDebugf("provider name: %q, provider type: %q", p.Provider.GetResourceName(), p.GetSessionProviderType())
+}
@@ -0,0 +1,211 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package resolvedopenshift
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ory/fosite"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
+	idpdiscoveryv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idpdiscovery/v1alpha1"
+	"go.pinniped.dev/internal/federationdomain/resolvedprovider"
+	"go.pinniped.dev/internal/httputil/httperr"
+	"go.pinniped.dev/internal/psession"
+	"go.pinniped.dev/internal/testutil/transformtestutil"
+	"go.pinniped.dev/internal/upstreamopenshift"
+)
+
+func TestFederationDomainResolvedOpenShiftIdentityProvider(t *testing.T) {
+	transforms := transformtestutil.NewRejectAllAuthPipeline(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth/token":
+			require.NoError(t, r.ParseForm())
+			if r.Form.Get("code") != "fake-code" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token": "fake-access-token", "token_type": "Bearer", "expires_in": 86400}`))
+		case upstreamopenshift.UserPath:
+			switch r.Header.Get("Authorization") {
+			case "Bearer fake-access-token":
+				_, _ = w.Write([]byte(`{"metadata": {"name": "fake-username", "uid": "fake-uid"}, "groups": ["system:authenticated", "fake-group"]}`))
+			case "Bearer other-user-access-token":
+				_, _ = w.Write([]byte(`{"metadata": {"name": "fake-username", "uid": "other-uid"}, "groups": ["fake-group"]}`))
+			default:
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	provider := upstreamopenshift.New(upstreamopenshift.ProviderConfig{
+		Name:              "fake-provider-config",
+		ResourceUID:       "fake-resource-uid",
+		APIServerURL:      server.URL,
+		UsernameAttribute: idpv1alpha1.OpenShiftUsernameName,
+		UsePKCE:           true,
+		HttpClient:        server.Client(),
+		OAuth2Config: &oauth2.Config{
+			ClientID:     "fake-client-id",
+			ClientSecret: "fake-client-secret",
+			Scopes:       []string{"user:info"},
+			Endpoint: oauth2.Endpoint{
+				AuthURL:   "https://fake-authorization-url",
+				TokenURL:  server.URL + "/oauth/token",
+				AuthStyle: oauth2.AuthStyleInParams,
+			},
+		},
+	})
+
+	subject := FederationDomainResolvedOpenShiftIdentityProvider{
+		DisplayName:         "fake-display-name",
+		Provider:            provider,
+		SessionProviderType: psession.ProviderTypeOpenShift,
+		Transforms:          transforms,
+	}
+
+	wantDownstreamSubject := server.URL + "?idpName=fake-display-name&sub=fake-uid"
+
+	require.Equal(t, "fake-display-name", subject.GetDisplayName())
+	require.Equal(t, provider, subject.GetProvider())
+	require.Equal(t, psession.ProviderTypeOpenShift, subject.GetSessionProviderType())
+	require.Equal(t, idpdiscoveryv1alpha1.IDPTypeOpenShift, subject.GetIDPDiscoveryType())
+	require.Equal(t, []idpdiscoveryv1alpha1.IDPFlow{idpdiscoveryv1alpha1.IDPFlowBrowserAuthcode}, subject.GetIDPDiscoveryFlows())
+	require.Equal(t, transforms, subject.GetTransforms())
+
+	originalCustomSession := &psession.CustomSessionData{
+		Username:  "fake-username",
+		OpenShift: &psession.OpenShiftSessionData{UpstreamAccessToken: "fake-access-token"},
+	}
+	clonedCustomSession := subject.CloneIDPSpecificSessionDataFromSession(originalCustomSession)
+	require.Equal(t, &psession.OpenShiftSessionData{UpstreamAccessToken: "fake-access-token"}, clonedCustomSession)
+	require.NotSame(t, originalCustomSession.OpenShift, clonedCustomSession)
+	require.Nil(t, subject.CloneIDPSpecificSessionDataFromSession(&psession.CustomSessionData{}))
+
+	customSessionToBeMutated := &psession.CustomSessionData{Username: "fake-username2"}
+	subject.ApplyIDPSpecificSessionDataToSession(customSessionToBeMutated, &psession.OpenShiftSessionData{UpstreamAccessToken: "OTHER-access-token"})
+	require.Equal(t, &psession.OpenShiftSessionData{UpstreamAccessToken: "OTHER-access-token"}, customSessionToBeMutated.OpenShift)
+
+	redirectURL, err := subject.UpstreamAuthorizeRedirectURL(
+		&resolvedprovider.UpstreamAuthorizeRequestState{
+			EncodedStateParam: "fake-state",
+			PKCE:              "fake-pkce-code-verifier-which-is-long-enough-1234567890",
+		},
+		"https://fake-downstream-issuer-url",
+	)
+	require.NoError(t, err)
+	require.Equal(t, "https://fake-authorization-url?"+
+		"client_id=fake-client-id&"+
+		"code_challenge=cF6UptiiRdU8oPP_lNs9sqeA_wrKAjmM5x36JZIsFTc&"+
+		"code_challenge_method=S256&"+
+		"redirect_uri=https%3A%2F%2Ffake-downstream-issuer-url%2Fcallback&"+
+		"response_type=code&"+
+		"scope=user%3Ainfo&"+
+		"state=fake-state",
+		redirectURL,
+	)
+
+	identity, loginExtras, err := subject.Login(context.Background(), "fake-username", "fake-password")
+	require.EqualError(t, err, "function Login not yet implemented for OpenShift IDP")
+	require.Nil(t, identity)
+	require.Nil(t, loginExtras)
+
+	t.Run("LoginFromCallback", func(t *testing.T) {
+		identity, loginExtras, err := subject.LoginFromCallback(context.Background(),
+			"fake-code", "fake-pkce-code-verifier-which-is-long-enough-1234567890", "", "https://fake-downstream-issuer-url/callback")
+		require.NoError(t, err)
+		require.Equal(t, &resolvedprovider.Identity{
+			UpstreamUsername:       "fake-username",
+			UpstreamGroups:         []string{"fake-group"},
+			DownstreamSubject:      wantDownstreamSubject,
+			IDPSpecificSessionData: &psession.OpenShiftSessionData{UpstreamAccessToken: "fake-access-token"},
+		}, identity)
+		require.Equal(t, &resolvedprovider.IdentityLoginExtras{}, loginExtras)
+
+		identity, loginExtras, err = subject.LoginFromCallback(context.Background(),
+			"wrong-code", "fake-pkce-code-verifier-which-is-long-enough-1234567890", "", "https://fake-downstream-issuer-url/callback")
+		require.ErrorContains(t, err, "failed to exchange authcode using OpenShift OAuth server")
+		require.Implements(t, (*httperr.Responder)(nil), err)
+		rec := httptest.NewRecorder()
+		err.(httperr.Responder).Respond(rec)
+		require.Equal(t, http.StatusBadGateway, rec.Code)
+		require.Nil(t, identity)
+		require.Nil(t, loginExtras)
+	})
+
+	t.Run("UpstreamRefresh", func(t *testing.T) {
+		tests := []struct {
+			name                  string
+			sessionData           any
+			wantRefreshedIdentity *resolvedprovider.RefreshedIdentity
+			wantErr               string
+			wantTrace             string
+		}{
+			{
+				name:        "happy path",
+				sessionData: &psession.OpenShiftSessionData{UpstreamAccessToken: "fake-access-token"},
+				wantRefreshedIdentity: &resolvedprovider.RefreshedIdentity{
+					UpstreamUsername: "fake-username",
+					UpstreamGroups:   []string{"fake-group"},
+				},
+			},
+			{
+				name:        "access token expired",
+				sessionData: &psession.OpenShiftSessionData{UpstreamAccessToken: "expired-access-token"},
+				wantErr:     "Error during upstream refresh. Upstream refresh failed.",
+				wantTrace:   `error calling OpenShift user API: unexpected response status "401 Unauthorized"`,
+			},
+			{
+				name:        "subject changed",
+				sessionData: &psession.OpenShiftSessionData{UpstreamAccessToken: "other-user-access-token"},
+				wantErr:     "Error during upstream refresh. Upstream refresh failed.",
+				wantTrace: `user's calculated downstream subject at initial login was "` + wantDownstreamSubject +
+					`" but now is "` + server.URL + `?idpName=fake-display-name&sub=other-uid"`,
+			},
+			{
+				name:        "wrong session data type",
+				sessionData: &psession.GitHubSessionData{},
+				wantErr:     "Error during upstream refresh. Upstream refresh failed.",
+				wantTrace:   "wrong data type found for IDPSpecificSessionData",
+			},
+			{
+				name:        "missing access token",
+				sessionData: &psession.OpenShiftSessionData{},
+				wantErr:     "Error during upstream refresh. Upstream refresh failed.",
+				wantTrace:   "session is missing OpenShift access token",
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				refreshedIdentity, err := subject.UpstreamRefresh(context.Background(), &resolvedprovider.Identity{
+					UpstreamUsername:       "fake-username",
+					UpstreamGroups:         []string{"fake-group"},
+					DownstreamSubject:      wantDownstreamSubject,
+					IDPSpecificSessionData: test.sessionData,
+				})
+				if test.wantErr != "" {
+					var rfcErr *fosite.RFC6749Error
+					require.ErrorAs(t, err, &rfcErr)
+					require.Equal(t, test.wantErr, rfcErr.DescriptionField+" "+rfcErr.HintField)
+					require.EqualError(t, rfcErr.Unwrap(), test.wantTrace)
+					require.Nil(t, refreshedIdentity)
+					return
+				}
+				require.NoError(t, err)
+				require.Equal(t, test.wantRefreshedIdentity, refreshedIdentity)
+			})
+		}
+	})
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package resourceindicator implements RFC 8707 resource indicators for the downstream authorization and
+// token endpoints. The resources which were granted at the authorization endpoint are stored as the granted
+// audience of the downstream session, which restricts the access token to those resources.
+package resourceindicator
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/ory/fosite"
+	"github.com/pkg/errors"
+
+	oidcapi "go.pinniped.dev/generated/latest/apis/supervisor/oidc"
+)
+
+// ParamName is the name of the resource param defined by RFC 8707.
+const ParamName = "resource"
+
+// ErrInvalidTarget returns the error defined by RFC 8707 for invalid resources.
+func ErrInvalidTarget() *fosite.RFC6749Error {
+	return &fosite.RFC6749Error{
+		ErrorField:       "invalid_target",
+		DescriptionField: "The requested resource is invalid, missing, unknown, or malformed.",
+		CodeField:        http.StatusBadRequest,
+	}
+}
+
+// Validate checks that each of the requested resources is an absolute URI without a fragment,
+// and that each is one of the allowed resources.
+func Validate(requested []string, allowed []string) error {
+	for _, resource := range requested {
+		if err := ValidateURI(resource); err != nil {
+			return err
+		}
+		if !slices.Contains(allowed, resource) {
+			return errors.WithStack(ErrInvalidTarget().WithHintf("The resource %q is not allowed.", resource))
+		}
+	}
+	return nil
+}
+
+// GrantIfRequested validates the resources requested by an authorize request against the resources which the client
+// is allowed to request, and grants them as the audience of the downstream session.
+func GrantIfRequested(authorizeRequester fosite.AuthorizeRequester) error {
+	requested := authorizeRequester.GetRequestForm()[ParamName]
+	if err := Validate(requested, authorizeRequester.GetClient().GetAudience()); err != nil {
+		return err
+	}
+	for _, resource := range requested {
+		authorizeRequester.GrantAudience(resource)
+	}
+	return nil
+}
+
+// NarrowIfRequested handles the resources requested by an authorization code or refresh grant. For a refresh grant,
+// they must be a subset of the resources which were previously granted, and replace the granted resources of the
+// downstream session. Note that the narrowed resources are also stored with the new refresh token, since it shares
+// the downstream session with the new access token.
+func NarrowIfRequested(accessRequester fosite.AccessRequester) error {
+	requested := accessRequester.GetRequestForm()[ParamName]
+	if len(requested) == 0 {
+		return nil
+	}
+	if !accessRequester.GetGrantTypes().ExactOne(oidcapi.GrantTypeRefreshToken) {
+		// Fosite copies the resources which were granted at the authorization endpoint onto the access request
+		// only while issuing the tokens of an authorization code grant, so they cannot be narrowed here.
+		return errors.WithStack(ErrInvalidTarget().WithHint(
+			"Resources must be requested at the authorization endpoint, and may only be narrowed when refreshing."))
+	}
+	if err := Validate(requested, accessRequester.GetGrantedAudience()); err != nil {
+		return err
+	}
+	accessRequest, ok := accessRequester.(*fosite.AccessRequest)
+	if !ok {
+		// This shouldn't really happen.
+		return errors.WithStack(fosite.ErrServerError.WithHint("Invalid access request."))
+	}
+	accessRequest.GrantedAudience = fosite.Arguments(slices.Clone(requested))
+	return nil
+}
+
+// ValidateURI checks that the resource is an absolute URI without a fragment.
+func ValidateURI(resource string) error {
+	parsed, err := url.Parse(resource)
+	if err != nil || !parsed.IsAbs() || strings.Contains(resource, "#") {
+		return errors.WithStack(ErrInvalidTarget().WithHintf("The resource %q must be an absolute URI without a fragment.", resource))
+	}
+	return nil
+}
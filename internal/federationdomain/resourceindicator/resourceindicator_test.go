@@ -0,0 +1,145 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package resourceindicator
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/ory/fosite"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrantIfRequested(t *testing.T) {
+	tests := []struct {
+		name          string
+		requested     []string
+		allowed       []string
+		wantGranted   fosite.Arguments
+		wantErrorHint string
+	}{
+		{
+			name:        "no resources requested",
+			allowed:     []string{"https://cluster1.example.com"},
+			wantGranted: fosite.Arguments{},
+		},
+		{
+			name:        "allowed resources requested",
+			requested:   []string{"https://cluster1.example.com", "https://cluster2.example.com"},
+			allowed:     []string{"https://cluster1.example.com", "https://cluster2.example.com", "https://cluster3.example.com"},
+			wantGranted: fosite.Arguments{"https://cluster1.example.com", "https://cluster2.example.com"},
+		},
+		{
+			name:          "resource requested by a client which is not allowed to request any resources",
+			requested:     []string{"https://cluster1.example.com"},
+			wantErrorHint: `The resource "https://cluster1.example.com" is not allowed.`,
+		},
+		{
+			name:          "disallowed resource requested",
+			requested:     []string{"https://cluster1.example.com", "https://cluster3.example.com"},
+			allowed:       []string{"https://cluster1.example.com", "https://cluster2.example.com"},
+			wantErrorHint: `The resource "https://cluster3.example.com" is not allowed.`,
+		},
+		{
+			name:          "relative resource requested",
+			requested:     []string{"cluster1"},
+			allowed:       []string{"cluster1"},
+			wantErrorHint: `The resource "cluster1" must be an absolute URI without a fragment.`,
+		},
+		{
+			name:          "resource with fragment requested",
+			requested:     []string{"https://cluster1.example.com#frag"},
+			allowed:       []string{"https://cluster1.example.com#frag"},
+			wantErrorHint: `The resource "https://cluster1.example.com#frag" must be an absolute URI without a fragment.`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authorizeRequest := fosite.NewAuthorizeRequest()
+			authorizeRequest.Client = &fosite.DefaultClient{ID: "some-client", Audience: tt.allowed}
+			authorizeRequest.Form = url.Values{ParamName: tt.requested}
+
+			err := GrantIfRequested(authorizeRequest)
+			if tt.wantErrorHint != "" {
+				requireInvalidTarget(t, err, tt.wantErrorHint)
+				require.Empty(t, authorizeRequest.GetGrantedAudience())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantGranted, authorizeRequest.GetGrantedAudience())
+		})
+	}
+}
+
+func TestNarrowIfRequested(t *testing.T) {
+	tests := []struct {
+		name          string
+		requested     []string
+		grantType     string
+		granted       []string
+		wantGranted   fosite.Arguments
+		wantErrorHint string
+	}{
+		{
+			name:        "no resources requested keeps the granted resources",
+			grantType:   "refresh_token",
+			granted:     []string{"https://cluster1.example.com", "https://cluster2.example.com"},
+			wantGranted: fosite.Arguments{"https://cluster1.example.com", "https://cluster2.example.com"},
+		},
+		{
+			name:        "subset of the granted resources requested",
+			grantType:   "refresh_token",
+			requested:   []string{"https://cluster2.example.com"},
+			granted:     []string{"https://cluster1.example.com", "https://cluster2.example.com"},
+			wantGranted: fosite.Arguments{"https://cluster2.example.com"},
+		},
+		{
+			name:          "resource which was not granted requested",
+			grantType:     "refresh_token",
+			requested:     []string{"https://cluster3.example.com"},
+			granted:       []string{"https://cluster1.example.com"},
+			wantErrorHint: `The resource "https://cluster3.example.com" is not allowed.`,
+		},
+		{
+			name:          "resource requested when none were granted",
+			grantType:     "refresh_token",
+			requested:     []string{"https://cluster1.example.com"},
+			wantErrorHint: `The resource "https://cluster1.example.com" is not allowed.`,
+		},
+		{
+			name:          "resource requested by an authorization code grant",
+			grantType:     "authorization_code",
+			requested:     []string{"https://cluster1.example.com"},
+			wantErrorHint: "Resources must be requested at the authorization endpoint, and may only be narrowed when refreshing.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accessRequest := fosite.NewAccessRequest(nil)
+			accessRequest.GrantTypes = fosite.Arguments{tt.grantType}
+			accessRequest.Form = url.Values{ParamName: tt.requested}
+			for _, resource := range tt.granted {
+				accessRequest.GrantAudience(resource)
+			}
+
+			err := NarrowIfRequested(accessRequest)
+			if tt.wantErrorHint != "" {
+				requireInvalidTarget(t, err, tt.wantErrorHint)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantGranted, accessRequest.GetGrantedAudience())
+		})
+	}
+}
+
+func requireInvalidTarget(t *testing.T, err error, wantHint string) {
+	t.Helper()
+
+	require.EqualError(t, err, "invalid_target")
+	rfcErr := fosite.ErrorToRFC6749Error(err)
+	require.Equal(t, "invalid_target", rfcErr.ErrorField)
+	require.Equal(t, 400, rfcErr.CodeField)
+	require.Equal(t, wantHint, rfcErr.HintField)
+}
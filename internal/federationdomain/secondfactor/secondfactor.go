@@ -0,0 +1,130 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package secondfactor verifies the second factor of the users of identity providers which require one in
+// addition to the password, and enrolls the users who do not have one yet.
+package secondfactor
+
+import (
+	"context"
+	"time"
+
+	"go.pinniped.dev/internal/constable"
+	"go.pinniped.dev/internal/federationdomain/resolvedprovider"
+	"go.pinniped.dev/internal/totp"
+	"go.pinniped.dev/internal/totpsecretstorage"
+)
+
+const (
+	ErrNotAvailable = constable.Error("second factor verification is not available")
+
+	// maxFailedTOTPAttempts is the number of consecutive codes which may be rejected before all codes of the
+	// user are rejected for totpLockoutSteps time steps, i.e. five minutes. This limits guessing codes to a
+	// handful of attempts per lockout, independently of the login throttling of the FederationDomain.
+	maxFailedTOTPAttempts = 5
+	totpLockoutSteps      = 10
+)
+
+// totpIdentityProvider is implemented by the resolved identity providers which can require a TOTP second factor.
+type totpIdentityProvider interface {
+	GetTOTPConfig() *totp.Config
+}
+
+// TOTPConfig returns the configuration of the TOTP second factor of the identity provider, or nil when the
+// identity provider does not require it.
+func TOTPConfig(idp resolvedprovider.FederationDomainResolvedIdentityProvider) *totp.Config {
+	p, ok := idp.(totpIdentityProvider)
+	if !ok {
+		return nil
+	}
+	return p.GetTOTPConfig()
+}
+
+// Result is the outcome of verifying the second factor of a user.
+type Result int
+
+const (
+	// Rejected means that the user did not provide a valid code.
+	Rejected Result = iota
+	// Verified means that the user provided a valid code, which cannot be used again.
+	Verified
+	// EnrollmentRequired means that the user must add their secret to their authenticator app, and then
+	// provide a valid code to confirm it, before they can log in.
+	EnrollmentRequired
+)
+
+// TOTPVerifier verifies the codes of the authenticator apps of users. Each user has their own secret for
+// each identity provider.
+type TOTPVerifier struct {
+	storage *totpsecretstorage.TOTPSecretStorage
+	clock   func() time.Time
+}
+
+// NewTOTPVerifier returns a TOTPVerifier which keeps the secrets of the users in storage.
+func NewTOTPVerifier(storage *totpsecretstorage.TOTPSecretStorage, clock func() time.Time) *TOTPVerifier {
+	return &TOTPVerifier{storage: storage, clock: clock}
+}
+
+// Verify checks the code which the user provided after they already authenticated with their password.
+// When the user has not confirmed an enrollment yet, it creates a new secret if necessary, and returns
+// EnrollmentRequired and the secret which must be shown to the user, unless the code confirms the secret.
+// Once the user entered too many wrong codes, all codes are rejected until the lockout has expired.
+// A nil TOTPVerifier never verifies any code.
+func (v *TOTPVerifier) Verify(ctx context.Context, idpResourceName, username, code string) (Result, []byte, error) {
+	if v == nil {
+		return Rejected, nil, ErrNotAvailable
+	}
+
+	resourceVersion, enrollment, err := v.storage.Get(ctx, idpResourceName, username)
+	if err != nil {
+		return Rejected, nil, err
+	}
+
+	if enrollment == nil {
+		secret, err := totp.GenerateSecret()
+		if err != nil {
+			return Rejected, nil, err
+		}
+		if err := v.storage.Set(ctx, "", idpResourceName, username, &totpsecretstorage.Enrollment{Secret: secret}); err != nil {
+			return Rejected, nil, err
+		}
+		return EnrollmentRequired, secret, nil
+	}
+
+	now := v.clock()
+	currentStep := totp.Step(now)
+	recentFailure := currentStep-enrollment.LastFailedStep < totpLockoutSteps
+	if enrollment.Confirmed && recentFailure && enrollment.FailedAttempts >= maxFailedTOTPAttempts {
+		// Do not even check the code, so that a correct guess cannot be noticed during the lockout.
+		return Rejected, nil, nil
+	}
+
+	step, valid := totp.Validate(enrollment.Secret, code, now, enrollment.LastUsedStep)
+	if !valid {
+		if !enrollment.Confirmed {
+			// Nobody has proven to have the secret yet, so it is still safe to show it again.
+			return EnrollmentRequired, enrollment.Secret, nil
+		}
+		if !recentFailure {
+			// Failures older than the lockout are forgotten.
+			enrollment.FailedAttempts = 0
+		}
+		enrollment.FailedAttempts++
+		enrollment.LastFailedStep = currentStep
+		if err := v.storage.Set(ctx, resourceVersion, idpResourceName, username, enrollment); err != nil {
+			return Rejected, nil, err
+		}
+		return Rejected, nil, nil
+	}
+
+	// Remember the step of the code, so it cannot be used again. The update fails when another login
+	// updated the enrollment in the meantime, so two concurrent logins cannot both use the same code.
+	enrollment.Confirmed = true
+	enrollment.LastUsedStep = step
+	enrollment.FailedAttempts = 0
+	enrollment.LastFailedStep = 0
+	if err := v.storage.Set(ctx, resourceVersion, idpResourceName, username, enrollment); err != nil {
+		return Rejected, nil, err
+	}
+	return Verified, nil, nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package secondfactor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"go.pinniped.dev/internal/testutil"
+	"go.pinniped.dev/internal/totp"
+	"go.pinniped.dev/internal/totpsecretstorage"
+)
+
+func TestTOTPVerifier(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	client := fake.NewSimpleClientset()
+	testutil.AddSecretResourceVersionReactors(client)
+	storage := totpsecretstorage.New(client.CoreV1().Secrets("some-namespace"),
+		func() []byte { return []byte("some-supervisor-key") })
+	subject := NewTOTPVerifier(storage, func() time.Time { return now })
+
+	// The first login creates a new secret which must be shown to the user.
+	result, secret, err := subject.Verify(ctx, "some-idp", "some-user", "")
+	require.NoError(t, err)
+	require.Equal(t, EnrollmentRequired, result)
+	require.NotEmpty(t, secret)
+
+	// A wrong code shows the same secret again, since the enrollment is not confirmed yet.
+	result, secretAgain, err := subject.Verify(ctx, "some-idp", "some-user", "000000")
+	require.NoError(t, err)
+	require.Equal(t, EnrollmentRequired, result)
+	require.Equal(t, secret, secretAgain)
+
+	// A valid code confirms the enrollment.
+	code := totp.Code(secret, totp.Step(now))
+	result, secretAgain, err = subject.Verify(ctx, "some-idp", "some-user", code)
+	require.NoError(t, err)
+	require.Equal(t, Verified, result)
+	require.Nil(t, secretAgain)
+
+	// The same code cannot be used again.
+	result, secretAgain, err = subject.Verify(ctx, "some-idp", "some-user", code)
+	require.NoError(t, err)
+	require.Equal(t, Rejected, result)
+	require.Nil(t, secretAgain)
+
+	// A wrong code is rejected without showing the secret, since the enrollment is confirmed.
+	result, secretAgain, err = subject.Verify(ctx, "some-idp", "some-user", "000000")
+	require.NoError(t, err)
+	require.Equal(t, Rejected, result)
+	require.Nil(t, secretAgain)
+
+	// The code of the next period works.
+	now = now.Add(totp.Period * time.Second)
+	result, _, err = subject.Verify(ctx, "some-idp", "some-user", totp.Code(secret, totp.Step(now)))
+	require.NoError(t, err)
+	require.Equal(t, Verified, result)
+
+	// Each identity provider has its own enrollment.
+	result, otherSecret, err := subject.Verify(ctx, "other-idp", "some-user", "")
+	require.NoError(t, err)
+	require.Equal(t, EnrollmentRequired, result)
+	require.NotEqual(t, secret, otherSecret)
+}
+
+func TestTOTPVerifierLocksOutAfterFailedAttempts(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	client := fake.NewSimpleClientset()
+	testutil.AddSecretResourceVersionReactors(client)
+	storage := totpsecretstorage.New(client.CoreV1().Secrets("some-namespace"),
+		func() []byte { return []byte("some-supervisor-key") })
+	secret := []byte("some-secret")
+	require.NoError(t, storage.Set(ctx, "", "some-idp", "some-user", &totpsecretstorage.Enrollment{Secret: secret, Confirmed: true}))
+	subject := NewTOTPVerifier(storage, func() time.Time { return now })
+
+	// A valid code after a few wrong codes works, and forgets the wrong codes.
+	for range maxFailedTOTPAttempts - 1 {
+		result, _, err := subject.Verify(ctx, "some-idp", "some-user", "000000")
+		require.NoError(t, err)
+		require.Equal(t, Rejected, result)
+	}
+	result, _, err := subject.Verify(ctx, "some-idp", "some-user", totp.Code(secret, totp.Step(now)))
+	require.NoError(t, err)
+	require.Equal(t, Verified, result)
+	_, enrollment, err := storage.Get(ctx, "some-idp", "some-user")
+	require.NoError(t, err)
+	require.Zero(t, enrollment.FailedAttempts)
+
+	// Too many wrong codes in a row lock out the user, so that even a valid code is rejected.
+	now = now.Add(totp.Period * time.Second)
+	for range maxFailedTOTPAttempts {
+		result, _, err = subject.Verify(ctx, "some-idp", "some-user", "000000")
+		require.NoError(t, err)
+		require.Equal(t, Rejected, result)
+	}
+	result, _, err = subject.Verify(ctx, "some-idp", "some-user", totp.Code(secret, totp.Step(now)))
+	require.NoError(t, err)
+	require.Equal(t, Rejected, result)
+
+	// The user is still locked out shortly before the lockout expires.
+	now = now.Add((totpLockoutSteps - 1) * totp.Period * time.Second)
+	result, _, err = subject.Verify(ctx, "some-idp", "some-user", totp.Code(secret, totp.Step(now)))
+	require.NoError(t, err)
+	require.Equal(t, Rejected, result)
+
+	// Once the lockout has expired, a valid code works again.
+	now = now.Add(totp.Period * time.Second)
+	result, _, err = subject.Verify(ctx, "some-idp", "some-user", totp.Code(secret, totp.Step(now)))
+	require.NoError(t, err)
+	require.Equal(t, Verified, result)
+}
+
+func TestNilTOTPVerifier(t *testing.T) {
+	var subject *TOTPVerifier
+	result, secret, err := subject.Verify(context.Background(), "some-idp", "some-user", "123456")
+	require.ErrorIs(t, err, ErrNotAvailable)
+	require.Equal(t, Rejected, result)
+	require.Nil(t, secret)
+}
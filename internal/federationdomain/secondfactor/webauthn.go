@@ -0,0 +1,314 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package secondfactor
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.pinniped.dev/internal/federationdomain/oidc"
+	"go.pinniped.dev/internal/federationdomain/resolvedprovider"
+	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/internal/psession"
+	"go.pinniped.dev/internal/webauthn"
+	"go.pinniped.dev/internal/webauthncredentialstorage"
+)
+
+// WebAuthnEnforcement determines whether users who have not registered a WebAuthn credential may log in without one.
+type WebAuthnEnforcement string
+
+const (
+	// WebAuthnRequired means that users who have not registered a credential must register one when they log in.
+	WebAuthnRequired WebAuthnEnforcement = "Required"
+	// WebAuthnOptional means that users who have not registered a credential are asked to register one when they
+	// log in, but may skip it. Users who registered a credential must always use it.
+	WebAuthnOptional WebAuthnEnforcement = "Optional"
+
+	// WebAuthnChallengeLifetime is how long users have to complete a WebAuthn ceremony after the login page was shown.
+	WebAuthnChallengeLifetime = 15 * time.Minute
+
+	// webAuthnChallengeName is the name which binds the encoded challenges to their purpose.
+	webAuthnChallengeName = "webauthn-challenge"
+)
+
+// WebAuthnConfig configures WebAuthn credentials as a second factor for the users of a FederationDomain.
+type WebAuthnConfig struct {
+	Enforcement WebAuthnEnforcement
+}
+
+// WebAuthnCeremony is what the login page needs to ask the browser to register a credential or to use one.
+type WebAuthnCeremony struct {
+	// Register is true when the browser must register a new credential, and false when it must use one.
+	Register bool
+	// Token is the encoded challenge, which the login page must submit along with the response of the browser.
+	Token string
+	// Challenge must be signed by the authenticator.
+	Challenge []byte
+	// RelyingPartyID is the hostname of the issuer.
+	RelyingPartyID string
+	// UserID and Username identify the user when registering a credential.
+	UserID   []byte
+	Username string
+	// Skippable is true when the user may log in without registering a credential.
+	Skippable bool
+}
+
+// WebAuthnSubmission is what the login page submitted for the WebAuthn ceremony.
+type WebAuthnSubmission struct {
+	// Token is the encoded challenge of the ceremony.
+	Token string
+	// Response is the JSON encoded response of the browser, or empty when the browser did not provide one.
+	Response string
+	// Skip is true when the user asked to log in without registering a credential.
+	Skip bool
+}
+
+// webAuthnChallenge is the encoded content of a WebAuthnCeremony's Token.
+type webAuthnChallenge struct {
+	Challenge []byte `json:"c"`
+	Register  bool   `json:"r"`
+	// StateHash binds the challenge to one login attempt.
+	StateHash []byte `json:"s"`
+	// UserID binds the challenge of a registration to one user.
+	UserID []byte `json:"u,omitempty"`
+}
+
+// webAuthnResponse is the response of the browser as encoded by the login page. All values are base64url encoded.
+type webAuthnResponse struct {
+	ID                string `json:"id"`
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AuthenticatorData string `json:"authenticatorData,omitempty"`
+	Signature         string `json:"signature,omitempty"`
+	AttestationObject string `json:"attestationObject,omitempty"`
+}
+
+// WebAuthnVerifier registers and verifies the WebAuthn credentials of the users of a FederationDomain. Each user
+// has their own credential for each identity provider.
+type WebAuthnVerifier struct {
+	config       WebAuthnConfig
+	relyingParty *webauthn.RelyingParty
+	storage      *webauthncredentialstorage.WebAuthnCredentialStorage
+	codec        oidc.Codec
+	clock        func() time.Time
+}
+
+// NewWebAuthnVerifier returns a WebAuthnVerifier which keeps the credentials of the users in storage. The codec
+// encodes the challenges of the login pages, and must reject encoded values which are older than the
+// WebAuthnChallengeLifetime.
+func NewWebAuthnVerifier(
+	config WebAuthnConfig,
+	relyingParty *webauthn.RelyingParty,
+	storage *webauthncredentialstorage.WebAuthnCredentialStorage,
+	codec oidc.Codec,
+	clock func() time.Time,
+) *WebAuthnVerifier {
+	return &WebAuthnVerifier{config: config, relyingParty: relyingParty, storage: storage, codec: codec, clock: clock}
+}
+
+// AppliesTo returns whether the users of the identity provider must use a WebAuthn credential. Only the users of
+// the identity providers which use the login page of the Supervisor can use one. A nil WebAuthnVerifier never
+// applies to any identity provider.
+func (v *WebAuthnVerifier) AppliesTo(idp resolvedprovider.FederationDomainResolvedIdentityProvider) bool {
+	if v == nil {
+		return false
+	}
+	switch idp.GetSessionProviderType() {
+	case psession.ProviderTypeLDAP, psession.ProviderTypeActiveDirectory:
+		return true
+	default:
+		return false
+	}
+}
+
+// NewCeremony returns a new WebAuthn ceremony for the login page of the login attempt with the encoded state.
+// When register is true, the browser must register a new credential for the user. Otherwise, the user is not
+// known yet, since they did not submit their username, so the browser may use any credential of the issuer.
+// A nil WebAuthnVerifier returns nil.
+func (v *WebAuthnVerifier) NewCeremony(encodedState string, register bool, idpResourceName, username string) (*WebAuthnCeremony, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	challenge, err := webauthn.GenerateChallenge()
+	if err != nil {
+		return nil, err
+	}
+	content := &webAuthnChallenge{Challenge: challenge, Register: register, StateHash: hash(encodedState)}
+	ceremony := &WebAuthnCeremony{Register: register, Challenge: challenge, RelyingPartyID: v.relyingParty.ID}
+	if register {
+		content.UserID = userID(idpResourceName, username)
+		ceremony.UserID = content.UserID
+		ceremony.Username = username
+		ceremony.Skippable = v.config.Enforcement == WebAuthnOptional
+	}
+
+	ceremony.Token, err = v.codec.Encode(webAuthnChallengeName, content)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode WebAuthn challenge: %w", err)
+	}
+	return ceremony, nil
+}
+
+// Verify checks the WebAuthn credential of the user after they already authenticated with their password.
+// When the user has not registered a credential yet, the submission may register one. Otherwise, it returns
+// EnrollmentRequired, unless the user skipped the registration and the enforcement allows it.
+// A nil WebAuthnVerifier never verifies any credential.
+func (v *WebAuthnVerifier) Verify(
+	ctx context.Context,
+	encodedState string,
+	idpResourceName string,
+	username string,
+	submission *WebAuthnSubmission,
+) (Result, error) {
+	if v == nil {
+		return Rejected, ErrNotAvailable
+	}
+
+	resourceVersion, credential, err := v.storage.Get(ctx, v.relyingParty.ID, idpResourceName, username)
+	if err != nil {
+		return Rejected, err
+	}
+
+	if credential == nil {
+		if submission.Response == "" {
+			if submission.Skip && v.config.Enforcement == WebAuthnOptional {
+				return Verified, nil
+			}
+			return EnrollmentRequired, nil
+		}
+
+		challenge, response, err := v.decode(encodedState, submission, true, userID(idpResourceName, username))
+		if err != nil {
+			plog.Info("WebAuthn registration rejected", "reason", err.Error())
+			return EnrollmentRequired, nil
+		}
+		registered, err := v.relyingParty.VerifyRegistration(challenge, &webauthn.Registration{
+			ClientDataJSON:    response.clientDataJSON,
+			AttestationObject: response.attestationObject,
+		})
+		if err != nil {
+			plog.Info("WebAuthn registration rejected", "reason", err.Error())
+			return EnrollmentRequired, nil
+		}
+
+		// The create fails when another login registered a credential in the meantime.
+		err = v.storage.Set(ctx, "", v.relyingParty.ID, idpResourceName, username, &webauthncredentialstorage.Credential{
+			Credential:     *registered,
+			UsedChallenges: map[string]time.Time{challengeKey(challenge): v.clock().Add(WebAuthnChallengeLifetime)},
+		})
+		if err != nil {
+			return Rejected, err
+		}
+		return Verified, nil
+	}
+
+	if submission.Response == "" {
+		return Rejected, nil
+	}
+	challenge, response, err := v.decode(encodedState, submission, false, nil)
+	if err != nil {
+		plog.Info("WebAuthn assertion rejected", "reason", err.Error())
+		return Rejected, nil
+	}
+
+	// Forget the challenges which can no longer be used anyway, and reject the challenges which were already used.
+	now := v.clock()
+	for key, expires := range credential.UsedChallenges {
+		if now.After(expires) {
+			delete(credential.UsedChallenges, key)
+		}
+	}
+	if _, used := credential.UsedChallenges[challengeKey(challenge)]; used {
+		plog.Info("WebAuthn assertion rejected", "reason", "challenge was already used")
+		return Rejected, nil
+	}
+
+	signCount, err := v.relyingParty.VerifyAssertion(challenge, &credential.Credential, &webauthn.Assertion{
+		CredentialID:      response.id,
+		ClientDataJSON:    response.clientDataJSON,
+		AuthenticatorData: response.authenticatorData,
+		Signature:         response.signature,
+	})
+	if err != nil {
+		plog.Info("WebAuthn assertion rejected", "reason", err.Error())
+		return Rejected, nil
+	}
+
+	// Remember the challenge, so the assertion cannot be used again. The update fails when another login
+	// updated the credential in the meantime, so two concurrent logins cannot both use the same assertion.
+	credential.SignCount = signCount
+	if credential.UsedChallenges == nil {
+		credential.UsedChallenges = map[string]time.Time{}
+	}
+	credential.UsedChallenges[challengeKey(challenge)] = now.Add(WebAuthnChallengeLifetime)
+	if err := v.storage.Set(ctx, resourceVersion, v.relyingParty.ID, idpResourceName, username, credential); err != nil {
+		return Rejected, err
+	}
+	return Verified, nil
+}
+
+// decodedWebAuthnResponse is the decoded webAuthnResponse.
+type decodedWebAuthnResponse struct {
+	id                []byte
+	clientDataJSON    []byte
+	authenticatorData []byte
+	signature         []byte
+	attestationObject []byte
+}
+
+// decode checks that the token of the submission was issued for the same purpose and for the same login attempt,
+// and returns its challenge along with the decoded response of the browser.
+func (v *WebAuthnVerifier) decode(encodedState string, submission *WebAuthnSubmission, register bool, userID []byte) ([]byte, *decodedWebAuthnResponse, error) {
+	var content webAuthnChallenge
+	if err := v.codec.Decode(webAuthnChallengeName, submission.Token, &content); err != nil {
+		return nil, nil, fmt.Errorf("could not decode challenge: %w", err)
+	}
+	if content.Register != register ||
+		subtle.ConstantTimeCompare(content.StateHash, hash(encodedState)) != 1 ||
+		subtle.ConstantTimeCompare(content.UserID, userID) != 1 {
+		return nil, nil, fmt.Errorf("challenge was issued for another login")
+	}
+
+	var encoded webAuthnResponse
+	if err := json.Unmarshal([]byte(submission.Response), &encoded); err != nil {
+		return nil, nil, fmt.Errorf("could not decode response: %w", err)
+	}
+	decoded := &decodedWebAuthnResponse{}
+	for _, field := range []struct {
+		encoded string
+		decoded *[]byte
+	}{
+		{encoded.ID, &decoded.id},
+		{encoded.ClientDataJSON, &decoded.clientDataJSON},
+		{encoded.AuthenticatorData, &decoded.authenticatorData},
+		{encoded.Signature, &decoded.signature},
+		{encoded.AttestationObject, &decoded.attestationObject},
+	} {
+		value, err := base64.RawURLEncoding.DecodeString(field.encoded)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not decode response: %w", err)
+		}
+		*field.decoded = value
+	}
+	return content.Challenge, decoded, nil
+}
+
+// userID returns the user handle of the credentials of the user, which must not contain personal information.
+func userID(idpResourceName, username string) []byte {
+	return hash(idpResourceName + "\x00" + username)
+}
+
+func challengeKey(challenge []byte) string {
+	return base64.RawURLEncoding.EncodeToString(hash(string(challenge)))
+}
+
+func hash(s string) []byte {
+	sum := sha256.Sum256([]byte(s))
+	return sum[:]
+}
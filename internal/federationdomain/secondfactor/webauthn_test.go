@@ -0,0 +1,242 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package secondfactor
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"go.pinniped.dev/internal/federationdomain/resolvedprovider/resolvedmock"
+	"go.pinniped.dev/internal/psession"
+	"go.pinniped.dev/internal/testutil"
+	"go.pinniped.dev/internal/webauthn"
+	"go.pinniped.dev/internal/webauthncredentialstorage"
+)
+
+// testPasskey simulates a browser and an authenticator with one ES256 credential.
+type testPasskey struct {
+	t         *testing.T
+	key       *ecdsa.PrivateKey
+	signCount uint32
+}
+
+func (p *testPasskey) clientData(typ string, ceremony *WebAuthnCeremony) []byte {
+	clientDataJSON, err := json.Marshal(map[string]any{
+		"type":      typ,
+		"challenge": base64.RawURLEncoding.EncodeToString(ceremony.Challenge),
+		"origin":    "https://issuer.example.com",
+	})
+	require.NoError(p.t, err)
+	return clientDataJSON
+}
+
+func (p *testPasskey) authData(ceremony *WebAuthnCeremony) []byte {
+	rpIDHash := sha256.Sum256([]byte(ceremony.RelyingPartyID))
+	data := binary.BigEndian.AppendUint32(append(rpIDHash[:], 0x01), p.signCount) // user present
+	if ceremony.Register {
+		data[32] |= 0x40 // attested credential data
+		data = append(data, make([]byte, 16)...)
+		data = binary.BigEndian.AppendUint16(data, 2)
+		data = append(data, "id"...)
+		// The COSE_Key of the credential: {1: 2, 3: -7, -1: 1, -2: x, -3: y}.
+		data = append(data, 0xa5, 0x01, 0x02, 0x03, 0x26, 0x20, 0x01, 0x21, 0x58, 0x20)
+		data = append(data, p.key.X.FillBytes(make([]byte, 32))...)
+		data = append(data, 0x22, 0x58, 0x20)
+		data = append(data, p.key.Y.FillBytes(make([]byte, 32))...)
+	}
+	return data
+}
+
+// respond returns the response of the browser to the ceremony, as encoded by the login page.
+func (p *testPasskey) respond(ceremony *WebAuthnCeremony) string {
+	p.signCount++
+	authData := p.authData(ceremony)
+
+	var response map[string]string
+	if ceremony.Register {
+		// The attestation object is {"fmt": "none", "attStmt": {}, "authData": authData}.
+		attestationObject := append([]byte{0xa3, 0x63}, "fmt"...)
+		attestationObject = append(append(attestationObject, 0x64), "none"...)
+		attestationObject = append(append(attestationObject, 0x67), "attStmt"...)
+		attestationObject = append(append(attestationObject, 0xa0, 0x68), "authData"...)
+		attestationObject = append(binary.BigEndian.AppendUint16(append(attestationObject, 0x59), uint16(len(authData))), authData...)
+		response = map[string]string{
+			"id":                base64.RawURLEncoding.EncodeToString([]byte("id")),
+			"clientDataJSON":    base64.RawURLEncoding.EncodeToString(p.clientData("webauthn.create", ceremony)),
+			"attestationObject": base64.RawURLEncoding.EncodeToString(attestationObject),
+		}
+	} else {
+		clientDataJSON := p.clientData("webauthn.get", ceremony)
+		clientDataHash := sha256.Sum256(clientDataJSON)
+		digest := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+		signature, err := ecdsa.SignASN1(rand.Reader, p.key, digest[:])
+		require.NoError(p.t, err)
+		response = map[string]string{
+			"id":                base64.RawURLEncoding.EncodeToString([]byte("id")),
+			"clientDataJSON":    base64.RawURLEncoding.EncodeToString(clientDataJSON),
+			"authenticatorData": base64.RawURLEncoding.EncodeToString(authData),
+			"signature":         base64.RawURLEncoding.EncodeToString(signature),
+		}
+	}
+
+	encoded, err := json.Marshal(response)
+	require.NoError(p.t, err)
+	return string(encoded)
+}
+
+func newTestWebAuthnVerifier(t *testing.T, enforcement WebAuthnEnforcement) (*WebAuthnVerifier, *webauthncredentialstorage.WebAuthnCredentialStorage) {
+	t.Helper()
+
+	client := fake.NewSimpleClientset()
+	testutil.AddSecretResourceVersionReactors(client)
+	storage := webauthncredentialstorage.New(client.CoreV1().Secrets("some-namespace"))
+	relyingParty, err := webauthn.NewRelyingParty("https://issuer.example.com/some/path")
+	require.NoError(t, err)
+	codec := securecookie.New([]byte("fake-hash-secret"), []byte("0123456789ABCDEF"))
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	return NewWebAuthnVerifier(WebAuthnConfig{Enforcement: enforcement}, relyingParty, storage, codec, func() time.Time { return now }), storage
+}
+
+func TestWebAuthnVerifier(t *testing.T) {
+	ctx := context.Background()
+	subject, storage := newTestWebAuthnVerifier(t, WebAuthnRequired)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	passkey := &testPasskey{t: t, key: key}
+
+	// Users without a credential must register one, and cannot skip it.
+	result, err := subject.Verify(ctx, "some-state", "some-idp", "some-user", &WebAuthnSubmission{})
+	require.NoError(t, err)
+	require.Equal(t, EnrollmentRequired, result)
+	result, err = subject.Verify(ctx, "some-state", "some-idp", "some-user", &WebAuthnSubmission{Skip: true})
+	require.NoError(t, err)
+	require.Equal(t, EnrollmentRequired, result)
+
+	registration, err := subject.NewCeremony("some-state", true, "some-idp", "some-user")
+	require.NoError(t, err)
+	require.True(t, registration.Register)
+	require.False(t, registration.Skippable)
+	require.Equal(t, "issuer.example.com", registration.RelyingPartyID)
+	require.Equal(t, "some-user", registration.Username)
+	require.Len(t, registration.Challenge, webauthn.ChallengeSize)
+	require.NotEmpty(t, registration.UserID)
+	require.NotContains(t, string(registration.UserID), "some-user")
+
+	// A registration cannot be used for another login, or for another user.
+	response := passkey.respond(registration)
+	result, err = subject.Verify(ctx, "some-other-state", "some-idp", "some-user", &WebAuthnSubmission{Token: registration.Token, Response: response})
+	require.NoError(t, err)
+	require.Equal(t, EnrollmentRequired, result)
+	result, err = subject.Verify(ctx, "some-state", "some-idp", "some-other-user", &WebAuthnSubmission{Token: registration.Token, Response: response})
+	require.NoError(t, err)
+	require.Equal(t, EnrollmentRequired, result)
+
+	// A valid registration stores the credential.
+	result, err = subject.Verify(ctx, "some-state", "some-idp", "some-user", &WebAuthnSubmission{Token: registration.Token, Response: response})
+	require.NoError(t, err)
+	require.Equal(t, Verified, result)
+	_, credential, err := storage.Get(ctx, "issuer.example.com", "some-idp", "some-user")
+	require.NoError(t, err)
+	require.Equal(t, []byte("id"), credential.ID)
+	require.Equal(t, uint32(1), credential.SignCount)
+
+	// Users with a credential must use it.
+	result, err = subject.Verify(ctx, "some-state", "some-idp", "some-user", &WebAuthnSubmission{})
+	require.NoError(t, err)
+	require.Equal(t, Rejected, result)
+	result, err = subject.Verify(ctx, "some-state", "some-idp", "some-user", &WebAuthnSubmission{Token: registration.Token, Response: passkey.respond(registration)})
+	require.NoError(t, err)
+	require.Equal(t, Rejected, result)
+
+	assertion, err := subject.NewCeremony("some-state", false, "", "")
+	require.NoError(t, err)
+	require.False(t, assertion.Register)
+	require.Empty(t, assertion.UserID)
+
+	// An assertion cannot be used for another login.
+	response = passkey.respond(assertion)
+	result, err = subject.Verify(ctx, "some-other-state", "some-idp", "some-user", &WebAuthnSubmission{Token: assertion.Token, Response: response})
+	require.NoError(t, err)
+	require.Equal(t, Rejected, result)
+
+	// A valid assertion is verified once.
+	result, err = subject.Verify(ctx, "some-state", "some-idp", "some-user", &WebAuthnSubmission{Token: assertion.Token, Response: response})
+	require.NoError(t, err)
+	require.Equal(t, Verified, result)
+	result, err = subject.Verify(ctx, "some-state", "some-idp", "some-user", &WebAuthnSubmission{Token: assertion.Token, Response: passkey.respond(assertion)})
+	require.NoError(t, err)
+	require.Equal(t, Rejected, result)
+	_, credential, err = storage.Get(ctx, "issuer.example.com", "some-idp", "some-user")
+	require.NoError(t, err)
+	require.Equal(t, uint32(3), credential.SignCount)
+	require.Len(t, credential.UsedChallenges, 2)
+
+	// A new assertion works.
+	assertion, err = subject.NewCeremony("some-state", false, "", "")
+	require.NoError(t, err)
+	result, err = subject.Verify(ctx, "some-state", "some-idp", "some-user", &WebAuthnSubmission{Token: assertion.Token, Response: passkey.respond(assertion)})
+	require.NoError(t, err)
+	require.Equal(t, Verified, result)
+
+	// The credential is only for this identity provider.
+	result, err = subject.Verify(ctx, "some-state", "some-other-idp", "some-user", &WebAuthnSubmission{Token: assertion.Token, Response: passkey.respond(assertion)})
+	require.NoError(t, err)
+	require.Equal(t, EnrollmentRequired, result)
+}
+
+func TestOptionalWebAuthnVerifier(t *testing.T) {
+	ctx := context.Background()
+	subject, _ := newTestWebAuthnVerifier(t, WebAuthnOptional)
+
+	registration, err := subject.NewCeremony("some-state", true, "some-idp", "some-user")
+	require.NoError(t, err)
+	require.True(t, registration.Skippable)
+
+	result, err := subject.Verify(ctx, "some-state", "some-idp", "some-user", &WebAuthnSubmission{})
+	require.NoError(t, err)
+	require.Equal(t, EnrollmentRequired, result)
+	result, err = subject.Verify(ctx, "some-state", "some-idp", "some-user", &WebAuthnSubmission{Skip: true})
+	require.NoError(t, err)
+	require.Equal(t, Verified, result)
+}
+
+func TestWebAuthnVerifierAppliesTo(t *testing.T) {
+	subject, _ := newTestWebAuthnVerifier(t, WebAuthnRequired)
+
+	for providerType, want := range map[psession.ProviderType]bool{
+		psession.ProviderTypeLDAP:            true,
+		psession.ProviderTypeActiveDirectory: true,
+		psession.ProviderTypeOIDC:            false,
+		psession.ProviderTypeGitHub:          false,
+	} {
+		idp := &resolvedmock.FederationDomainResolvedMockIdentityProvider{SessionProviderType: providerType}
+		require.Equal(t, want, subject.AppliesTo(idp), providerType)
+	}
+}
+
+func TestNilWebAuthnVerifier(t *testing.T) {
+	var subject *WebAuthnVerifier
+
+	require.False(t, subject.AppliesTo(&resolvedmock.FederationDomainResolvedMockIdentityProvider{SessionProviderType: psession.ProviderTypeLDAP}))
+
+	ceremony, err := subject.NewCeremony("some-state", false, "", "")
+	require.NoError(t, err)
+	require.Nil(t, ceremony)
+
+	result, err := subject.Verify(context.Background(), "some-state", "some-idp", "some-user", &WebAuthnSubmission{})
+	require.ErrorIs(t, err, ErrNotAvailable)
+	require.Equal(t, Rejected, result)
+}
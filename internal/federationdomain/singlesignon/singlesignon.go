@@ -0,0 +1,360 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package singlesignon remembers the upstream logins of browsers using a cookie, so that users who recently logged
+// in to one client of a FederationDomain can log in to its other clients without logging in at the upstream identity
+// provider again.
+//
+// The downstream sessions which are started by the same upstream login share its upstream refresh token. Upstream
+// providers may rotate their refresh tokens, i.e. accept each refresh token only once, so the latest upstream refresh
+// token is kept in the single sign-on session rather than copied into each downstream session. See UpstreamRefresh.
+package singlesignon
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ory/fosite"
+	errorsx "github.com/pkg/errors"
+	"k8s.io/client-go/util/retry"
+
+	"go.pinniped.dev/internal/federationdomain/downstreamsession"
+	"go.pinniped.dev/internal/federationdomain/forcedreauth"
+	"go.pinniped.dev/internal/federationdomain/oidc"
+	"go.pinniped.dev/internal/federationdomain/resolvedprovider"
+	singlesignonstorage "go.pinniped.dev/internal/fositestorage/singlesignon"
+	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/internal/psession"
+)
+
+const (
+	// DefaultCookieLifetime is how long a login is remembered when the lifetime is not configured.
+	DefaultCookieLifetime = 8 * time.Hour
+
+	promptParamName  = "prompt"
+	promptParamLogin = "login"
+	maxAgeParamName  = "max_age"
+
+	// maxUpstreamRefreshAttempts limits how often an upstream refresh is tried again when the shared upstream
+	// refresh token was rotated by another downstream session during the upstream refresh.
+	maxUpstreamRefreshAttempts = 3
+)
+
+// Config configures single sign-on for a FederationDomain.
+type Config struct {
+	// CookieLifetime is how long a login is remembered by the single sign-on cookie of the browser.
+	CookieLifetime time.Duration
+}
+
+// Sessions remembers the upstream logins of browsers. A nil Sessions never remembers any login.
+type Sessions struct {
+	config              Config
+	cookiePath          string
+	storage             singlesignonstorage.Storage
+	cookieCodec         oidc.Codec
+	forcedReauthChecker *forcedreauth.Checker
+	generateID          func() (string, error)
+	clock               func() time.Time
+}
+
+func New(
+	issuerPath string,
+	config Config,
+	storage singlesignonstorage.Storage,
+	cookieCodec oidc.Codec,
+	forcedReauthChecker *forcedreauth.Checker, // may be nil, in which case logins are never forgotten early
+	generateID func() (string, error), // use GenerateSessionID() for production
+	clock func() time.Time,
+) *Sessions {
+	cookiePath := issuerPath
+	if cookiePath == "" {
+		cookiePath = "/"
+	}
+	return &Sessions{
+		config:              config,
+		cookiePath:          cookiePath,
+		storage:             storage,
+		cookieCodec:         cookieCodec,
+		forcedReauthChecker: forcedReauthChecker,
+		generateID:          generateID,
+		clock:               clock,
+	}
+}
+
+// GenerateSessionID returns a new random ID for a single sign-on session. The ID is sent to the browser,
+// so it must not be guessable.
+func GenerateSessionID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("could not generate single sign-on session ID: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Remember stores the upstream login of the user and sets the single sign-on cookie of the browser,
+// so that the login can be resumed for other clients. The downstream session of the login starts to share
+// its upstream refresh token with the single sign-on session, if it has one.
+func (s *Sessions) Remember(
+	w http.ResponseWriter,
+	r *http.Request,
+	idp resolvedprovider.FederationDomainResolvedIdentityProvider,
+	identity *resolvedprovider.Identity,
+	loginExtras *resolvedprovider.IdentityLoginExtras,
+	session *psession.PinnipedSession,
+) error {
+	if s == nil {
+		return nil
+	}
+
+	id, err := s.generateID()
+	if err != nil {
+		return err
+	}
+
+	custom := &psession.CustomSessionData{
+		UpstreamUsername: identity.UpstreamUsername,
+		UpstreamGroups:   identity.UpstreamGroups,
+		ProviderUID:      idp.GetProvider().GetResourceUID(),
+		ProviderName:     idp.GetProvider().GetResourceName(),
+		ProviderType:     idp.GetSessionProviderType(),
+	}
+	idp.ApplyIDPSpecificSessionDataToSession(custom, identity.IDPSpecificSessionData)
+
+	now := s.clock()
+	err = s.storage.Create(r.Context(), id, &singlesignonstorage.Session{
+		IdentityProviderDisplayName: idp.GetDisplayName(),
+		DownstreamSubject:           identity.DownstreamSubject,
+		Custom:                      custom,
+		AdditionalClaims:            loginExtras.DownstreamAdditionalClaims,
+		AuthTime:                    now,
+		ExpiresAt:                   now.Add(s.config.CookieLifetime),
+
+		AuthenticationContextClassReference: loginExtras.AuthenticationContextClassReference,
+		AuthenticationMethodsReferences:     loginExtras.AuthenticationMethodsReferences,
+	})
+	if err != nil {
+		return fmt.Errorf("could not save single sign-on session: %w", err)
+	}
+	if sharesUpstreamRefreshToken(custom) {
+		session.Custom.SingleSignOnSessionID = id
+	}
+
+	encodedID, err := s.cookieCodec.Encode(oidc.SingleSignOnCookieEncodingName, id)
+	if err != nil {
+		return fmt.Errorf("error encoding single sign-on cookie: %w", err)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidc.SingleSignOnCookieName,
+		Value:    encodedID,
+		MaxAge:   int(s.config.CookieLifetime.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   true,
+		Path:     s.cookiePath,
+	})
+
+	return nil
+}
+
+// Resume returns a new downstream session for the client of the authorization request, using the upstream login
+// which is remembered by the single sign-on cookie of the browser. The identity transformations and policies of
+// the identity provider and the client are applied again, as for a new login. It returns nil when the browser has
+// no usable login for the identity provider, or when the request asks the user to log in again, in which case the
+// user must log in at the upstream identity provider.
+func (s *Sessions) Resume(
+	r *http.Request,
+	idp resolvedprovider.FederationDomainResolvedIdentityProvider,
+	authorizeRequester fosite.AuthorizeRequester,
+) *psession.PinnipedSession {
+	if s == nil {
+		return nil
+	}
+
+	form := authorizeRequester.GetRequestForm()
+	if slices.Contains(strings.Fields(form.Get(promptParamName)), promptParamLogin) {
+		return nil
+	}
+
+	id, stored := s.readSession(r)
+	if stored == nil {
+		return nil
+	}
+
+	// The identity provider might have been replaced by another one with the same display name.
+	if stored.IdentityProviderDisplayName != idp.GetDisplayName() ||
+		stored.Custom.ProviderUID != idp.GetProvider().GetResourceUID() ||
+		stored.Custom.ProviderType != idp.GetSessionProviderType() {
+		return nil
+	}
+
+	if maxAge := form.Get(maxAgeParamName); maxAge != "" {
+		maxAgeSeconds, err := strconv.ParseInt(maxAge, 10, 64)
+		if err != nil || s.clock().After(stored.AuthTime.Add(time.Duration(maxAgeSeconds)*time.Second)) {
+			return nil
+		}
+	}
+
+	session, err := downstreamsession.NewPinnipedSession(r.Context(), idp, &downstreamsession.SessionConfig{
+		UpstreamIdentity: &resolvedprovider.Identity{
+			UpstreamUsername:       stored.Custom.UpstreamUsername,
+			UpstreamGroups:         stored.Custom.UpstreamGroups,
+			DownstreamSubject:      stored.DownstreamSubject,
+			IDPSpecificSessionData: stored.Custom.IDPSpecificSessionData(),
+		},
+		UpstreamLoginExtras: &resolvedprovider.IdentityLoginExtras{
+			DownstreamAdditionalClaims:          stored.AdditionalClaims,
+			AuthenticationContextClassReference: stored.AuthenticationContextClassReference,
+			AuthenticationMethodsReferences:     stored.AuthenticationMethodsReferences,
+		},
+		Client:        authorizeRequester.GetClient(),
+		GrantedScopes: authorizeRequester.GetGrantedScopes(),
+	})
+	if err != nil {
+		// The same error would happen after logging in at the upstream, unless the user logs in as another user,
+		// or unless the new upstream login asserts an ACR value which is required by the client.
+		plog.Info("single sign-on session cannot be used for this login", "reason", err.Error())
+		return nil
+	}
+
+	// The user logged in at the time of the upstream login, not now.
+	session.IDTokenClaims().AuthTime = stored.AuthTime
+
+	// The downstream groups are only in the claims when the groups scope was granted, so evaluate the
+	// transformations again to find the ForcedReauthentications of the user's groups.
+	transformationResult, err := idp.GetTransforms().Evaluate(r.Context(), stored.Custom.UpstreamUsername, stored.Custom.UpstreamGroups)
+	if err != nil {
+		return nil
+	}
+	forcedReauthentication, err := s.forcedReauthChecker.Find(session.Custom.Username, transformationResult.Groups, stored.AuthTime)
+	if err != nil {
+		plog.Error("error finding forced reauthentications for single sign-on session", err)
+		return nil
+	}
+	if forcedReauthentication != nil {
+		plog.Info("single sign-on session refused due to forced reauthentication",
+			"username", session.Custom.Username,
+			"forcedReauthentication", forcedReauthentication.Name,
+			"authTime", stored.AuthTime)
+		return nil
+	}
+
+	// The copy of the upstream refresh token in the new session is not used, since it may be rotated by the
+	// upstream refreshes of the other sessions.
+	if sharesUpstreamRefreshToken(stored.Custom) {
+		session.Custom.SingleSignOnSessionID = id
+	}
+
+	return session
+}
+
+func (s *Sessions) readSession(r *http.Request) (string, *singlesignonstorage.Session) {
+	receivedCookie, err := r.Cookie(oidc.SingleSignOnCookieName)
+	if err != nil {
+		// Error means that the cookie was not found.
+		return "", nil
+	}
+
+	var id string
+	if err := s.cookieCodec.Decode(oidc.SingleSignOnCookieEncodingName, receivedCookie.Value, &id); err != nil {
+		// The cookie might have been signed by a previous cookie signing key, so just ignore it.
+		return "", nil
+	}
+
+	stored, err := s.storage.Get(r.Context(), id)
+	if err != nil {
+		// The session might have expired, so just ignore it.
+		plog.Debug("single sign-on session not found", "reason", err.Error())
+		return "", nil
+	}
+
+	return id, stored
+}
+
+// UpstreamRefresh performs the upstream refresh of a downstream session, like the UpstreamRefresh of the identity
+// provider. When the session shares the upstream refresh token of a single sign-on session, the IDPSpecificSessionData
+// of the identity is replaced by the latest one from the single sign-on session, and the refreshed one is stored there
+// again for the other sessions which share it. The storage may be nil when the session cannot share the token.
+func UpstreamRefresh(
+	ctx context.Context,
+	storage singlesignonstorage.Storage,
+	idp resolvedprovider.FederationDomainResolvedIdentityProvider,
+	session *psession.PinnipedSession,
+	identity *resolvedprovider.Identity,
+) (*resolvedprovider.RefreshedIdentity, error) {
+	id := session.Custom.SingleSignOnSessionID
+	if id == "" || storage == nil {
+		return idp.UpstreamRefresh(ctx, identity)
+	}
+
+	for attempt := 1; ; attempt++ {
+		stored, resourceVersion, err := storage.GetIncludingExpired(ctx, id)
+		if err != nil {
+			// The single sign-on session was deleted, so the user must log in again.
+			return nil, resolvedprovider.ErrUpstreamRefreshError().WithHint(
+				"Upstream refresh failed.",
+			).WithTrace(err).WithDebugf("the single sign-on session which holds the upstream refresh token could not be read: %s", err)
+		}
+
+		identity.IDPSpecificSessionData = idp.CloneIDPSpecificSessionDataFromSession(stored.Custom)
+		if identity.IDPSpecificSessionData == nil {
+			return nil, errorsx.WithStack(resolvedprovider.ErrMissingUpstreamSessionInternalError())
+		}
+
+		refreshedIdentity, err := idp.UpstreamRefresh(ctx, identity)
+		if err != nil {
+			// Another session might have rotated the upstream refresh token while this one was using it,
+			// in which case the upstream refresh can be tried again with the rotated token.
+			if attempt < maxUpstreamRefreshAttempts && wasUpdated(ctx, storage, id, resourceVersion) {
+				continue
+			}
+			return nil, err
+		}
+
+		if refreshedIdentity.IDPSpecificSessionData == nil {
+			return refreshedIdentity, nil
+		}
+
+		// Another session which refreshed at the same time might have stored its own refreshed token in the meantime,
+		// which the upstream provider could have accepted during a grace period. Both tokens are equally new, so just
+		// store this one.
+		err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			if stored == nil {
+				var getErr error
+				if stored, resourceVersion, getErr = storage.GetIncludingExpired(ctx, id); getErr != nil {
+					return getErr
+				}
+			}
+			idp.ApplyIDPSpecificSessionDataToSession(stored.Custom, refreshedIdentity.IDPSpecificSessionData)
+			updateErr := storage.Update(ctx, id, resourceVersion, stored)
+			stored = nil
+			return updateErr
+		})
+		if err != nil {
+			return nil, fosite.ErrServerError.WithHint(
+				"Could not save the refreshed upstream session.",
+			).WithWrap(err).WithDebug(err.Error())
+		}
+
+		return refreshedIdentity, nil
+	}
+}
+
+// wasUpdated returns whether the single sign-on session has changed since it was read at the resource version.
+func wasUpdated(ctx context.Context, storage singlesignonstorage.Storage, id, resourceVersion string) bool {
+	_, latestResourceVersion, err := storage.GetIncludingExpired(ctx, id)
+	return err == nil && latestResourceVersion != resourceVersion
+}
+
+// sharesUpstreamRefreshToken returns whether the downstream sessions of a single sign-on session share its upstream
+// refresh token, which they must read from the single sign-on session because it may be rotated by any of them.
+func sharesUpstreamRefreshToken(custom *psession.CustomSessionData) bool {
+	return custom.ProviderType == psession.ProviderTypeOIDC && custom.OIDC != nil && custom.OIDC.UpstreamRefreshToken != ""
+}
@@ -0,0 +1,375 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package singlesignon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/ory/fosite"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
+	configlisters "go.pinniped.dev/generated/latest/client/supervisor/listers/config/v1alpha1"
+	"go.pinniped.dev/internal/federationdomain/forcedreauth"
+	"go.pinniped.dev/internal/federationdomain/oidc"
+	"go.pinniped.dev/internal/federationdomain/resolvedprovider"
+	singlesignonstorage "go.pinniped.dev/internal/fositestorage/singlesignon"
+	"go.pinniped.dev/internal/psession"
+	"go.pinniped.dev/internal/testutil"
+	"go.pinniped.dev/internal/testutil/oidctestutil"
+	"go.pinniped.dev/internal/testutil/testidplister"
+	"go.pinniped.dev/pkg/oidcclient/oidctypes"
+)
+
+func TestSessions(t *testing.T) {
+	const namespace = "some-namespace"
+
+	authTime := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	makeIDP := func(displayName string, resourceUID types.UID) resolvedprovider.FederationDomainResolvedIdentityProvider {
+		upstream := oidctestutil.NewTestUpstreamOIDCIdentityProviderBuilder().
+			WithName(displayName).
+			WithResourceUID(resourceUID).
+			Build()
+		idp, err := testidplister.NewUpstreamIDPListerBuilder().
+			WithOIDC(upstream).
+			BuildFederationDomainIdentityProvidersListerFinder().
+			FindUpstreamIDPByDisplayName(displayName)
+		require.NoError(t, err)
+		return idp
+	}
+	someIDP := makeIDP("some-idp", "some-uid")
+
+	forcedReauthenticationForUser := func(username string, created time.Time) *supervisorconfigv1alpha1.ForcedReauthentication {
+		return &supervisorconfigv1alpha1.ForcedReauthentication{
+			ObjectMeta: metav1.ObjectMeta{Name: "some-forced-reauth", Namespace: namespace, CreationTimestamp: metav1.NewTime(created)},
+			Spec: supervisorconfigv1alpha1.ForcedReauthenticationSpec{
+				Subject: supervisorconfigv1alpha1.ForcedReauthenticationSubject{
+					Kind: supervisorconfigv1alpha1.ForcedReauthenticationSubjectKindUser,
+					Name: username,
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name                   string
+		disabled               bool
+		resumeIDP              resolvedprovider.FederationDomainResolvedIdentityProvider
+		form                   url.Values
+		timeSinceLogin         time.Duration
+		withoutCookie          bool
+		invalidCookie          bool
+		forcedReauthentication *supervisorconfigv1alpha1.ForcedReauthentication
+		wantResumed            bool
+	}{
+		{
+			name:        "resumes the login for the same identity provider",
+			wantResumed: true,
+		},
+		{
+			name:           "resumes the login just before the cookie expires",
+			timeSinceLogin: 8*time.Hour - time.Second,
+			wantResumed:    true,
+		},
+		{
+			name:           "does not resume an expired login",
+			timeSinceLogin: 8 * time.Hour,
+		},
+		{
+			name:     "does not remember or resume logins when disabled",
+			disabled: true,
+		},
+		{
+			name:          "does not resume without a cookie",
+			withoutCookie: true,
+		},
+		{
+			name:          "does not resume with an invalid cookie",
+			invalidCookie: true,
+		},
+		{
+			name:      "does not resume the login for another identity provider",
+			resumeIDP: makeIDP("some-other-idp", "some-other-uid"),
+		},
+		{
+			name:      "does not resume the login for an identity provider which was replaced using the same display name",
+			resumeIDP: makeIDP("some-idp", "some-new-uid"),
+		},
+		{
+			name: "does not resume when the client asks the user to log in again",
+			form: url.Values{"prompt": {"consent login"}},
+		},
+		{
+			name:           "resumes when the login is not older than max_age",
+			form:           url.Values{"max_age": {"3600"}},
+			timeSinceLogin: time.Hour,
+			wantResumed:    true,
+		},
+		{
+			name:           "does not resume when the login is older than max_age",
+			form:           url.Values{"max_age": {"3600"}},
+			timeSinceLogin: time.Hour + time.Second,
+		},
+		{
+			name:                   "does not resume when an admin requires the user to log in again",
+			forcedReauthentication: forcedReauthenticationForUser("some-username", authTime.Add(time.Minute)),
+		},
+		{
+			name:                   "resumes when an admin required the user to log in again before the login",
+			forcedReauthentication: forcedReauthenticationForUser("some-username", authTime.Add(-time.Minute)),
+			wantResumed:            true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClock := clocktesting.NewFakeClock(authTime)
+			secrets := fake.NewSimpleClientset().CoreV1().Secrets(namespace)
+			cookieCodec := securecookie.New([]byte("fake-hash-secret"), nil)
+
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			if tt.forcedReauthentication != nil {
+				require.NoError(t, indexer.Add(tt.forcedReauthentication))
+			}
+			checker := forcedreauth.NewChecker(configlisters.NewForcedReauthenticationLister(indexer).ForcedReauthentications(namespace))
+
+			var subject *Sessions
+			if !tt.disabled {
+				subject = New("/some/path",
+					Config{CookieLifetime: DefaultCookieLifetime},
+					singlesignonstorage.New(secrets, fakeClock.Now, time.Hour),
+					cookieCodec,
+					checker,
+					func() (string, error) { return "some-session-id", nil },
+					fakeClock.Now,
+				)
+			}
+
+			loginSession := psession.NewPinnipedSession()
+			rsp := httptest.NewRecorder()
+			err := subject.Remember(rsp, httptest.NewRequest(http.MethodGet, "/some/path/callback", nil), someIDP,
+				&resolvedprovider.Identity{
+					UpstreamUsername:       "some-username",
+					UpstreamGroups:         []string{"some-group"},
+					DownstreamSubject:      "some-subject",
+					IDPSpecificSessionData: &psession.OIDCSessionData{UpstreamRefreshToken: "some-refresh-token"},
+				},
+				&resolvedprovider.IdentityLoginExtras{
+					DownstreamAdditionalClaims: map[string]any{"some-claim": "some-value"},
+				},
+				loginSession,
+			)
+			require.NoError(t, err)
+
+			cookies := rsp.Result().Cookies()
+			if tt.disabled {
+				require.Empty(t, cookies)
+				require.Empty(t, loginSession.Custom.SingleSignOnSessionID)
+			} else {
+				require.Equal(t, "some-session-id", loginSession.Custom.SingleSignOnSessionID)
+				require.Len(t, cookies, 1)
+				require.Equal(t, oidc.SingleSignOnCookieName, cookies[0].Name)
+				require.Equal(t, "/some/path", cookies[0].Path)
+				require.Equal(t, 8*60*60, cookies[0].MaxAge)
+				require.True(t, cookies[0].HttpOnly)
+				require.True(t, cookies[0].Secure)
+				require.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+			}
+
+			fakeClock.Step(tt.timeSinceLogin)
+
+			req := httptest.NewRequest(http.MethodGet, "/some/path/oauth2/authorize", nil)
+			if !tt.withoutCookie && len(cookies) > 0 {
+				if tt.invalidCookie {
+					cookies[0].Value = "invalid"
+				}
+				req.AddCookie(cookies[0])
+			}
+
+			resumeIDP := tt.resumeIDP
+			if resumeIDP == nil {
+				resumeIDP = someIDP
+			}
+			form := tt.form
+			if form == nil {
+				form = url.Values{}
+			}
+			authorizeRequester := &fosite.AuthorizeRequest{
+				Request: fosite.Request{
+					Client:       &fosite.DefaultClient{ID: "some-client"},
+					Form:         form,
+					GrantedScope: fosite.Arguments{"openid", "username", "groups"},
+				},
+			}
+
+			session := subject.Resume(req, resumeIDP, authorizeRequester)
+			if !tt.wantResumed {
+				require.Nil(t, session)
+				return
+			}
+
+			require.NotNil(t, session)
+			require.Equal(t, "some-subject", session.Fosite.Claims.Subject)
+			require.True(t, authTime.Equal(session.Fosite.Claims.AuthTime), "auth_time should be the time of the upstream login")
+			require.Equal(t, "some-username", session.Custom.Username)
+			require.Equal(t, "some-username", session.Custom.UpstreamUsername)
+			require.Equal(t, []string{"some-group"}, session.Custom.UpstreamGroups)
+			require.Equal(t, types.UID("some-uid"), session.Custom.ProviderUID)
+			require.Equal(t, psession.ProviderTypeOIDC, session.Custom.ProviderType)
+			require.Equal(t, &psession.OIDCSessionData{UpstreamRefreshToken: "some-refresh-token"}, session.Custom.OIDC)
+			require.Equal(t, "some-session-id", session.Custom.SingleSignOnSessionID)
+			require.Equal(t, map[string]any{
+				"azp":              "some-client",
+				"username":         "some-username",
+				"groups":           []string{"some-group"},
+				"additionalClaims": map[string]any{"some-claim": "some-value"},
+			}, session.Fosite.Claims.Extra)
+		})
+	}
+}
+
+func TestUpstreamRefreshOfSessionsWhichShareTheUpstreamRefreshToken(t *testing.T) {
+	ctx := context.Background()
+	fakeClock := clocktesting.NewFakeClock(time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC))
+	client := fake.NewSimpleClientset()
+	testutil.AddSecretResourceVersionReactors(client)
+	storage := singlesignonstorage.New(client.CoreV1().Secrets("some-namespace"), fakeClock.Now, time.Hour)
+
+	upstream := oidctestutil.NewTestUpstreamOIDCIdentityProviderBuilder().
+		WithName("some-idp").
+		WithResourceUID("some-uid").
+		WithValidatedAndMergedWithUserInfoTokens(&oidctypes.Token{IDToken: &oidctypes.IDToken{Claims: map[string]any{}}}).
+		Build()
+	// The upstream provider rotates its refresh tokens, so each of them can only be used once.
+	latestRefreshToken := "some-refresh-token-0"
+	rotations := 0
+	var duringNextRefresh func()
+	upstream.PerformRefreshFunc = func(_ context.Context, refreshToken string) (*oauth2.Token, error) {
+		if f := duringNextRefresh; f != nil {
+			duringNextRefresh = nil
+			f()
+		}
+		if refreshToken != latestRefreshToken {
+			return nil, errors.New("refresh token has already been used")
+		}
+		rotations++
+		latestRefreshToken = fmt.Sprintf("some-refresh-token-%d", rotations)
+		return &oauth2.Token{AccessToken: "some-access-token", RefreshToken: latestRefreshToken}, nil
+	}
+	idp, err := testidplister.NewUpstreamIDPListerBuilder().
+		WithOIDC(upstream).
+		BuildFederationDomainIdentityProvidersListerFinder().
+		FindUpstreamIDPByDisplayName("some-idp")
+	require.NoError(t, err)
+
+	subject := New("/some/path",
+		Config{CookieLifetime: DefaultCookieLifetime},
+		storage,
+		securecookie.New([]byte("fake-hash-secret"), nil),
+		nil,
+		func() (string, error) { return "some-session-id", nil },
+		fakeClock.Now,
+	)
+
+	loginSession := psession.NewPinnipedSession()
+	rsp := httptest.NewRecorder()
+	err = subject.Remember(rsp, httptest.NewRequest(http.MethodGet, "/some/path/callback", nil), idp,
+		&resolvedprovider.Identity{
+			UpstreamUsername:       "some-username",
+			DownstreamSubject:      "some-subject",
+			IDPSpecificSessionData: &psession.OIDCSessionData{UpstreamRefreshToken: latestRefreshToken},
+		},
+		&resolvedprovider.IdentityLoginExtras{},
+		loginSession,
+	)
+	require.NoError(t, err)
+	loginSession.Custom.ProviderType = psession.ProviderTypeOIDC
+	loginSession.Custom.OIDC = &psession.OIDCSessionData{UpstreamRefreshToken: latestRefreshToken}
+
+	resume := func() *psession.PinnipedSession {
+		req := httptest.NewRequest(http.MethodGet, "/some/path/oauth2/authorize", nil)
+		req.AddCookie(rsp.Result().Cookies()[0])
+		session := subject.Resume(req, idp, &fosite.AuthorizeRequest{
+			Request: fosite.Request{Client: &fosite.DefaultClient{ID: "some-client"}, Form: url.Values{}},
+		})
+		require.NotNil(t, session)
+		return session
+	}
+	firstResumedSession := resume()
+	secondResumedSession := resume()
+
+	// Performs the upstream refresh of a downstream session like the token endpoint does.
+	refresh := func(session *psession.PinnipedSession) error {
+		refreshedIdentity, err := UpstreamRefresh(ctx, storage, idp, session, &resolvedprovider.Identity{
+			UpstreamUsername:       session.Custom.UpstreamUsername,
+			DownstreamSubject:      "some-subject",
+			IDPSpecificSessionData: idp.CloneIDPSpecificSessionDataFromSession(session.Custom),
+		})
+		if err != nil {
+			return err
+		}
+		idp.ApplyIDPSpecificSessionDataToSession(session.Custom, refreshedIdentity.IDPSpecificSessionData)
+		return nil
+	}
+	requireLatestRefreshTokenStored := func() {
+		stored, _, err := storage.GetIncludingExpired(ctx, "some-session-id")
+		require.NoError(t, err)
+		require.Equal(t, latestRefreshToken, stored.Custom.OIDC.UpstreamRefreshToken)
+	}
+
+	// All the sessions can refresh, in any order, although each refresh rotates the shared upstream refresh token.
+	require.NoError(t, refresh(firstResumedSession))
+	require.NoError(t, refresh(secondResumedSession))
+	require.NoError(t, refresh(firstResumedSession))
+	require.NoError(t, refresh(loginSession))
+	require.Equal(t, 4, rotations)
+	requireLatestRefreshTokenStored()
+
+	// A copy of an upstream refresh token which was rotated by another session cannot be used anymore.
+	sessionWithCopiedToken := psession.NewPinnipedSession()
+	sessionWithCopiedToken.Custom = &psession.CustomSessionData{
+		ProviderType: psession.ProviderTypeOIDC,
+		OIDC:         &psession.OIDCSessionData{UpstreamRefreshToken: "some-refresh-token-3"},
+	}
+	require.EqualError(t, refresh(sessionWithCopiedToken), "error")
+	require.Equal(t, 4, rotations)
+
+	// When another session rotates the upstream refresh token during the upstream refresh, it is tried again.
+	duringNextRefresh = func() { require.NoError(t, refresh(secondResumedSession)) }
+	require.NoError(t, refresh(firstResumedSession))
+	require.Equal(t, 6, rotations)
+	requireLatestRefreshTokenStored()
+
+	// The sessions can still refresh after the single sign-on cookie expired.
+	fakeClock.Step(DefaultCookieLifetime)
+	require.NoError(t, refresh(secondResumedSession))
+	require.Equal(t, 7, rotations)
+	requireLatestRefreshTokenStored()
+
+	// When the single sign-on session was deleted, the user must log in again.
+	secretList, err := client.CoreV1().Secrets("some-namespace").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, secretList.Items, 1)
+	err = client.CoreV1().Secrets("some-namespace").Delete(ctx, secretList.Items[0].Name, metav1.DeleteOptions{})
+	require.NoError(t, err)
+	err = refresh(firstResumedSession)
+	var rfc6749Error *fosite.RFC6749Error
+	require.ErrorAs(t, err, &rfc6749Error)
+	require.Equal(t, "Upstream refresh failed.", rfc6749Error.HintField)
+	require.Equal(t, 7, rotations)
+}
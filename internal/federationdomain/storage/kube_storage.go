@@ -18,6 +18,7 @@ import (
 	"go.pinniped.dev/internal/federationdomain/timeouts"
 	"go.pinniped.dev/internal/fositestorage/accesstoken"
 	"go.pinniped.dev/internal/fositestorage/authorizationcode"
+	"go.pinniped.dev/internal/fositestorage/clientassertion"
 	"go.pinniped.dev/internal/fositestorage/openidconnect"
 	"go.pinniped.dev/internal/fositestorage/pkce"
 	"go.pinniped.dev/internal/fositestorage/refreshtoken"
@@ -27,6 +28,7 @@ import (
 
 type KubeStorage struct {
 	clientManager            fosite.ClientManager
+	clientAssertionStorage   clientassertion.Storage
 	authorizationCodeStorage fositeoauth2.AuthorizeCodeStorage
 	pkceStorage              fositepkce.PKCERequestStorage
 	oidcStorage              openid.OpenIDConnectRequestStorage
@@ -44,7 +46,8 @@ func NewKubeStorage(
 ) *KubeStorage {
 	nowFunc := time.Now
 	return &KubeStorage{
-		clientManager:            clientregistry.NewClientManager(oidcClientsClient, oidcclientsecretstorage.New(secrets), minBcryptCost),
+		clientManager:            clientregistry.NewClientManager(oidcClientsClient, oidcclientsecretstorage.New(secrets), secrets, minBcryptCost),
+		clientAssertionStorage:   clientassertion.New(secrets, nowFunc),
 		authorizationCodeStorage: authorizationcode.New(secrets, nowFunc, timeoutsConfiguration.AuthorizationCodeSessionStorageLifetime),
 		pkceStorage:              pkce.New(secrets, nowFunc, timeoutsConfiguration.PKCESessionStorageLifetime),
 		oidcStorage:              openidconnect.New(secrets, nowFunc, timeoutsConfiguration.OIDCSessionStorageLifetime),
@@ -198,9 +201,9 @@ func (k KubeStorage) GetClient(ctx context.Context, id string) (fosite.Client, e
 }
 
 func (k KubeStorage) ClientAssertionJWTValid(ctx context.Context, jti string) error {
-	return k.clientManager.ClientAssertionJWTValid(ctx, jti)
+	return k.clientAssertionStorage.ClientAssertionJWTValid(ctx, jti)
 }
 
 func (k KubeStorage) SetClientAssertionJWT(ctx context.Context, jti string, exp time.Time) error {
-	return k.clientManager.SetClientAssertionJWT(ctx, jti, exp)
+	return k.clientAssertionStorage.SetClientAssertionJWT(ctx, jti, exp)
 }
@@ -31,7 +31,7 @@ func NewNullStorage(
 	minBcryptCost int,
 ) *NullStorage {
 	return &NullStorage{
-		ClientManager: clientregistry.NewClientManager(oidcClientsClient, oidcclientsecretstorage.New(secrets), minBcryptCost),
+		ClientManager: clientregistry.NewClientManager(oidcClientsClient, oidcclientsecretstorage.New(secrets), secrets, minBcryptCost),
 	}
 }
 
@@ -0,0 +1,202 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package strategy
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/google/uuid"
+	"github.com/ory/fosite"
+	fositeoauth2 "github.com/ory/fosite/handler/oauth2"
+	"github.com/ory/fosite/handler/openid"
+	errorsx "github.com/pkg/errors"
+
+	"go.pinniped.dev/internal/constable"
+	"go.pinniped.dev/internal/federationdomain/dpop"
+	"go.pinniped.dev/internal/federationdomain/endpoints/jwks"
+	"go.pinniped.dev/internal/plog"
+)
+
+const (
+	// JWTAccessTokenType is the typ header of JWT access tokens, as defined by RFC9068.
+	JWTAccessTokenType = "at+jwt"
+)
+
+// JWTAccessTokenConfig configures the JWT access tokens which are issued by a FederationDomain.
+type JWTAccessTokenConfig struct {
+	// Audience is the aud claim of the access tokens. When empty, the aud claim is the ID of the client.
+	Audience []string
+
+	// Claims are the names of the custom claims of the ID token which are also added to the access tokens,
+	// e.g. username and groups.
+	Claims []string
+}
+
+// DynamicOauth2JWTAccessTokenStrategy is an oauth2.CoreStrategy which issues access tokens as JWTs, as described by
+// RFC9068, so that workloads can validate them using the JWKS of the FederationDomain. Like the
+// DynamicOpenIDConnectECDSAStrategy, it dynamically loads the signing key of the issuer, and signs using the
+// algorithm of that key.
+//
+// Refresh tokens and authorization codes are still opaque, and are handled by the embedded DynamicOauth2HMACStrategy.
+// The opaque access tokens which were issued before the FederationDomain was configured to issue JWT access tokens
+// are also still accepted until they expire.
+//
+// The session of a JWT access token is stored using its signature, just like for an opaque access token, so the
+// endpoints of the Supervisor still reject JWT access tokens whose session was revoked. Workloads which validate the
+// JWTs by themselves cannot know about the revocation, which is why the lifetime of access tokens should stay short.
+type DynamicOauth2JWTAccessTokenStrategy struct {
+	*DynamicOauth2HMACStrategy
+	jwksProvider jwks.DynamicJWKSProvider
+	config       JWTAccessTokenConfig
+	now          func() time.Time
+}
+
+var _ fositeoauth2.CoreStrategy = &DynamicOauth2JWTAccessTokenStrategy{}
+
+func NewDynamicOauth2JWTAccessTokenStrategy(
+	fositeConfig *fosite.Config,
+	keyFunc func() []byte,
+	jwksProvider jwks.DynamicJWKSProvider,
+	config JWTAccessTokenConfig,
+) *DynamicOauth2JWTAccessTokenStrategy {
+	return &DynamicOauth2JWTAccessTokenStrategy{
+		DynamicOauth2HMACStrategy: NewDynamicOauth2HMACStrategy(fositeConfig, keyFunc),
+		jwksProvider:              jwksProvider,
+		config:                    config,
+		now:                       time.Now,
+	}
+}
+
+// AccessTokenSignature returns the signature of the JWS of a JWT access token, which identifies its stored session.
+func (s *DynamicOauth2JWTAccessTokenStrategy) AccessTokenSignature(ctx context.Context, token string) string {
+	if strings.HasPrefix(token, pinAccessTokenPrefix) {
+		return s.DynamicOauth2HMACStrategy.AccessTokenSignature(ctx, token)
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	return parts[2]
+}
+
+func (s *DynamicOauth2JWTAccessTokenStrategy) GenerateAccessToken(
+	ctx context.Context,
+	requester fosite.Requester,
+) (string, string, error) {
+	issuer := s.fositeConfig.IDTokenIssuer
+
+	_, activeJwk := s.jwksProvider.GetJWKS(issuer)
+	if activeJwk == nil {
+		plog.Debug("no JWK found for issuer", "issuer", issuer)
+		return "", "", fosite.ErrTemporarilyUnavailable.WithWrap(constable.Error("no JWK found for issuer"))
+	}
+	alg, err := jwks.SigningAlgorithm(activeJwk)
+	if err != nil {
+		plog.Debug("JWK has an unsupported type", "issuer", issuer)
+		return "", "", fosite.ErrServerError.WithWrap(err)
+	}
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: alg, Key: jose.JSONWebKey{Key: jwks.SigningKey(ctx, activeJwk), KeyID: activeJwk.KeyID}},
+		(&jose.SignerOptions{}).WithType(JWTAccessTokenType),
+	)
+	if err != nil {
+		return "", "", fosite.ErrServerError.WithWrap(err).WithDebug(err.Error())
+	}
+
+	now := s.now().UTC()
+	expiresAt := requester.GetSession().GetExpiresAt(fosite.AccessToken)
+	if expiresAt.IsZero() {
+		expiresAt = now.Add(s.fositeConfig.GetAccessTokenLifespan(ctx))
+	}
+
+	clientID := requester.GetClient().GetID()
+	audience := s.config.Audience
+	if len(audience) == 0 {
+		audience = []string{clientID}
+	}
+
+	customClaims := map[string]any{
+		"client_id": clientID,
+		"scope":     strings.Join(requester.GetGrantedScopes(), " "),
+	}
+	if session, ok := requester.GetSession().(openid.Session); ok {
+		extra := session.IDTokenClaims().Extra
+		for _, name := range s.config.Claims {
+			if value, ok := extra[name]; ok {
+				customClaims[name] = value
+			}
+		}
+		// Tokens which are bound to a DPoP key must carry the confirmation claim, as required by RFC9449.
+		if confirmation, ok := extra[dpop.ClaimConfirmation]; ok {
+			customClaims[dpop.ClaimConfirmation] = confirmation
+		}
+	}
+
+	token, err := jwt.Signed(signer).
+		Claims(customClaims).
+		Claims(jwt.Claims{
+			Issuer:   issuer,
+			Subject:  requester.GetSession().GetSubject(),
+			Audience: audience,
+			Expiry:   jwt.NewNumericDate(expiresAt),
+			IssuedAt: jwt.NewNumericDate(now),
+			ID:       uuid.NewString(),
+		}).
+		CompactSerialize()
+	if err != nil {
+		return "", "", fosite.ErrServerError.WithWrap(err).WithDebug(err.Error())
+	}
+
+	return token, s.AccessTokenSignature(ctx, token), nil
+}
+
+func (s *DynamicOauth2JWTAccessTokenStrategy) ValidateAccessToken(
+	ctx context.Context,
+	requester fosite.Requester,
+	token string,
+) error {
+	if strings.HasPrefix(token, pinAccessTokenPrefix) {
+		return s.DynamicOauth2HMACStrategy.ValidateAccessToken(ctx, requester, token)
+	}
+
+	parsed, err := jwt.ParseSigned(token)
+	if err != nil {
+		return errorsx.WithStack(fosite.ErrInvalidTokenFormat.WithWrap(err).WithDebug(err.Error()))
+	}
+	if len(parsed.Headers) != 1 || !slices.Contains(jwks.SupportedSigningAlgorithms(), parsed.Headers[0].Algorithm) {
+		return errorsx.WithStack(fosite.ErrInvalidTokenFormat.WithDebugf("Access token must be signed using one of %v", jwks.SupportedSigningAlgorithms()))
+	}
+
+	keySet, _ := s.jwksProvider.GetJWKS(s.fositeConfig.IDTokenIssuer)
+	if keySet == nil {
+		return fosite.ErrTemporarilyUnavailable.WithWrap(constable.Error("no JWKS found for issuer"))
+	}
+	keys := keySet.Key(parsed.Headers[0].KeyID)
+	if len(keys) == 0 {
+		return errorsx.WithStack(fosite.ErrTokenSignatureMismatch.WithDebug("Access token was not signed by a current key of the issuer"))
+	}
+	if keys[0].Algorithm != parsed.Headers[0].Algorithm {
+		return errorsx.WithStack(fosite.ErrTokenSignatureMismatch.WithDebugf("Access token must be signed using %s", keys[0].Algorithm))
+	}
+	var claims jwt.Claims
+	if err := parsed.Claims(keys[0], &claims); err != nil {
+		return errorsx.WithStack(fosite.ErrTokenSignatureMismatch.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	// Like for opaque access tokens, the stored session decides when the access token expires.
+	expiresAt := requester.GetSession().GetExpiresAt(fosite.AccessToken)
+	if expiresAt.IsZero() {
+		expiresAt = requester.GetRequestedAt().Add(s.fositeConfig.GetAccessTokenLifespan(ctx))
+	}
+	if expiresAt.Before(s.now().UTC()) {
+		return errorsx.WithStack(fosite.ErrTokenExpired.WithHintf("Access token expired at '%s'.", expiresAt))
+	}
+	return nil
+}
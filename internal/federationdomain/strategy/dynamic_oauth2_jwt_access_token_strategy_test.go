@@ -0,0 +1,240 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package strategy
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	josejwt "github.com/go-jose/go-jose/v3/jwt"
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/handler/openid"
+	"github.com/ory/fosite/token/jwt"
+	"github.com/stretchr/testify/require"
+
+	"go.pinniped.dev/internal/federationdomain/endpoints/jwks"
+)
+
+func TestDynamicOauth2JWTAccessTokenStrategy(t *testing.T) {
+	const (
+		goodIssuer  = "https://some-good-issuer.com"
+		clientID    = "some-client-id"
+		goodSubject = "some-subject"
+	)
+
+	now := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	expiresAt := now.Add(2 * time.Minute)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	activeJWK := &jose.JSONWebKey{Key: key, KeyID: "some-key-id", Algorithm: "ES256", Use: "sig"}
+
+	newSubject := func(activeJWK *jose.JSONWebKey, config JWTAccessTokenConfig) *DynamicOauth2JWTAccessTokenStrategy {
+		jwksProvider := jwks.NewDynamicJWKSProvider()
+		if activeJWK != nil {
+			jwksProvider.SetIssuerToJWKSMap(
+				map[string]*jose.JSONWebKeySet{goodIssuer: {Keys: []jose.JSONWebKey{activeJWK.Public()}}},
+				map[string]*jose.JSONWebKey{goodIssuer: activeJWK},
+			)
+		}
+		s := NewDynamicOauth2JWTAccessTokenStrategy(
+			&fosite.Config{IDTokenIssuer: goodIssuer},
+			func() []byte { return []byte("12345678901234567890123456789012") }, // 32 character secret key
+			jwksProvider,
+			config,
+		)
+		s.now = func() time.Time { return now }
+		return s
+	}
+
+	newRequester := func(expiresAt time.Time) *fosite.Request {
+		return &fosite.Request{
+			Client:       &fosite.DefaultClient{ID: clientID},
+			GrantedScope: fosite.Arguments{"openid", "username", "groups"},
+			RequestedAt:  now,
+			Session: &openid.DefaultSession{
+				Claims: &jwt.IDTokenClaims{
+					Subject: goodSubject,
+					Extra: map[string]any{
+						"username": "some-username",
+						"groups":   []any{"group1", "group2"},
+						"cnf":      map[string]any{"jkt": "some-thumbprint"},
+					},
+				},
+				Subject:   goodSubject,
+				ExpiresAt: map[fosite.TokenType]time.Time{fosite.AccessToken: expiresAt},
+			},
+		}
+	}
+
+	requireClaims := func(t *testing.T, token string, wantClaims map[string]any) {
+		t.Helper()
+		parsed, err := josejwt.ParseSigned(token)
+		require.NoError(t, err)
+		require.Len(t, parsed.Headers, 1)
+		require.Equal(t, "some-key-id", parsed.Headers[0].KeyID)
+		require.Equal(t, "at+jwt", parsed.Headers[0].ExtraHeaders[jose.HeaderType])
+
+		var claims map[string]any
+		require.NoError(t, parsed.Claims(&key.PublicKey, &claims))
+		require.NotEmpty(t, claims["jti"])
+		delete(claims, "jti")
+		require.Equal(t, wantClaims, claims)
+	}
+
+	t.Run("generates JWT access tokens which can be validated", func(t *testing.T) {
+		s := newSubject(activeJWK, JWTAccessTokenConfig{})
+		requester := newRequester(expiresAt)
+
+		token, signature, err := s.GenerateAccessToken(context.Background(), requester)
+		require.NoError(t, err)
+		require.Equal(t, strings.Split(token, ".")[2], signature)
+		require.Equal(t, signature, s.AccessTokenSignature(context.Background(), token))
+
+		requireClaims(t, token, map[string]any{
+			"iss":       goodIssuer,
+			"sub":       goodSubject,
+			"aud":       clientID,
+			"exp":       float64(expiresAt.Unix()),
+			"iat":       float64(now.Unix()),
+			"client_id": clientID,
+			"scope":     "openid username groups",
+			"cnf":       map[string]any{"jkt": "some-thumbprint"},
+		})
+
+		require.NoError(t, s.ValidateAccessToken(context.Background(), requester, token))
+	})
+
+	t.Run("generates JWT access tokens with the configured audience and claims", func(t *testing.T) {
+		s := newSubject(activeJWK, JWTAccessTokenConfig{
+			Audience: []string{"some-workload", "some-other-workload"},
+			Claims:   []string{"username", "groups", "not-in-session"},
+		})
+
+		token, _, err := s.GenerateAccessToken(context.Background(), newRequester(expiresAt))
+		require.NoError(t, err)
+
+		requireClaims(t, token, map[string]any{
+			"iss":       goodIssuer,
+			"sub":       goodSubject,
+			"aud":       []any{"some-workload", "some-other-workload"},
+			"exp":       float64(expiresAt.Unix()),
+			"iat":       float64(now.Unix()),
+			"client_id": clientID,
+			"scope":     "openid username groups",
+			"cnf":       map[string]any{"jkt": "some-thumbprint"},
+			"username":  "some-username",
+			"groups":    []any{"group1", "group2"},
+		})
+	})
+
+	t.Run("opaque access tokens are still accepted", func(t *testing.T) {
+		s := newSubject(activeJWK, JWTAccessTokenConfig{})
+		requester := newRequester(time.Now().Add(time.Hour))
+
+		token, signature, err := s.DynamicOauth2HMACStrategy.GenerateAccessToken(context.Background(), requester)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(token, "pin_at_"))
+		require.Equal(t, signature, s.AccessTokenSignature(context.Background(), token))
+		require.NoError(t, s.ValidateAccessToken(context.Background(), requester, token))
+	})
+
+	t.Run("rejects expired access tokens", func(t *testing.T) {
+		s := newSubject(activeJWK, JWTAccessTokenConfig{})
+
+		token, _, err := s.GenerateAccessToken(context.Background(), newRequester(now.Add(-time.Second)))
+		require.NoError(t, err)
+
+		err = s.ValidateAccessToken(context.Background(), newRequester(now.Add(-time.Second)), token)
+		require.True(t, errors.Is(err, fosite.ErrTokenExpired))
+	})
+
+	t.Run("rejects access tokens which were not signed by a key of the issuer", func(t *testing.T) {
+		s := newSubject(activeJWK, JWTAccessTokenConfig{})
+		otherJWK := &jose.JSONWebKey{Key: otherKey, KeyID: "some-key-id", Algorithm: "ES256", Use: "sig"}
+
+		token, _, err := newSubject(otherJWK, JWTAccessTokenConfig{}).GenerateAccessToken(context.Background(), newRequester(expiresAt))
+		require.NoError(t, err)
+
+		err = s.ValidateAccessToken(context.Background(), newRequester(expiresAt), token)
+		require.True(t, errors.Is(err, fosite.ErrTokenSignatureMismatch))
+	})
+
+	t.Run("rejects access tokens which are not JWTs", func(t *testing.T) {
+		s := newSubject(activeJWK, JWTAccessTokenConfig{})
+
+		require.Empty(t, s.AccessTokenSignature(context.Background(), "not-a-jwt"))
+		err := s.ValidateAccessToken(context.Background(), newRequester(expiresAt), "not-a-jwt")
+		require.True(t, errors.Is(err, fosite.ErrInvalidTokenFormat))
+	})
+
+	t.Run("signs using the algorithm of the active key", func(t *testing.T) {
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		_, edKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		for _, jwk := range []*jose.JSONWebKey{
+			{Key: rsaKey, KeyID: "some-key-id", Algorithm: "RS256", Use: "sig"},
+			{Key: edKey, KeyID: "some-key-id", Algorithm: "EdDSA", Use: "sig"},
+		} {
+			s := newSubject(jwk, JWTAccessTokenConfig{})
+			requester := newRequester(expiresAt)
+
+			token, _, err := s.GenerateAccessToken(context.Background(), requester)
+			require.NoError(t, err)
+
+			parsed, err := josejwt.ParseSigned(token)
+			require.NoError(t, err)
+			require.Len(t, parsed.Headers, 1)
+			require.Equal(t, jwk.Algorithm, parsed.Headers[0].Algorithm)
+
+			require.NoError(t, s.ValidateAccessToken(context.Background(), requester, token))
+		}
+	})
+
+	t.Run("rejects access tokens whose algorithm is not the algorithm of the key", func(t *testing.T) {
+		s := newSubject(activeJWK, JWTAccessTokenConfig{})
+		mislabeledJWK := *activeJWK
+		mislabeledJWK.Algorithm = "RS256"
+		s.jwksProvider.SetIssuerToJWKSMap(
+			map[string]*jose.JSONWebKeySet{goodIssuer: {Keys: []jose.JSONWebKey{mislabeledJWK.Public()}}},
+			map[string]*jose.JSONWebKey{goodIssuer: activeJWK},
+		)
+
+		token, _, err := s.GenerateAccessToken(context.Background(), newRequester(expiresAt))
+		require.NoError(t, err)
+
+		err = s.ValidateAccessToken(context.Background(), newRequester(expiresAt), token)
+		require.True(t, errors.Is(err, fosite.ErrTokenSignatureMismatch))
+	})
+
+	t.Run("returns an error when the signing key has an unsupported type", func(t *testing.T) {
+		s := newSubject(&jose.JSONWebKey{Key: []byte("some-symmetric-key"), KeyID: "some-key-id"}, JWTAccessTokenConfig{})
+
+		_, _, err := s.GenerateAccessToken(context.Background(), newRequester(expiresAt))
+		require.True(t, errors.Is(err, fosite.ErrServerError))
+		require.EqualError(t, err.(*fosite.RFC6749Error).Cause(), "JWK must be of type ecdsa (P-256), rsa or ed25519")
+	})
+
+	t.Run("returns an error when there is no signing key for the issuer", func(t *testing.T) {
+		s := newSubject(nil, JWTAccessTokenConfig{})
+
+		_, _, err := s.GenerateAccessToken(context.Background(), newRequester(expiresAt))
+		require.True(t, errors.Is(err, fosite.ErrTemporarilyUnavailable))
+		require.EqualError(t, err.(*fosite.RFC6749Error).Cause(), "no JWK found for issuer")
+	})
+}
@@ -5,10 +5,10 @@ package strategy
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"reflect"
 	"time"
 
+	"github.com/go-jose/go-jose/v3"
 	"github.com/ory/fosite"
 	"github.com/ory/fosite/compose"
 	"github.com/ory/fosite/handler/openid"
@@ -21,7 +21,8 @@ import (
 // DynamicOpenIDConnectECDSAStrategy is an openid.OpenIDConnectTokenStrategy that can dynamically
 // load a signing key to issue ID tokens. We want this dynamic capability since our controllers for
 // loading FederationDomain's and signing keys run in parallel, and thus the signing key might not be
-// ready when an FederationDomain is otherwise ready.
+// ready when an FederationDomain is otherwise ready. Despite its name, it signs the ID tokens using whichever
+// algorithm the active key of the issuer has, i.e. ES256, RS256 or EdDSA.
 //
 // If we ever update FederationDomain's to hold their signing key, we might not need this type, since we
 // could have an invariant that routes to an FederationDomain's endpoints are only wired up if an
@@ -53,24 +54,24 @@ func (s *DynamicOpenIDConnectECDSAStrategy) GenerateIDToken(
 		plog.Debug("no JWK found for issuer", "issuer", s.fositeConfig.IDTokenIssuer)
 		return "", fosite.ErrTemporarilyUnavailable.WithWrap(constable.Error("no JWK found for issuer"))
 	}
-	key, ok := activeJwk.Key.(*ecdsa.PrivateKey)
-	if !ok {
+	alg, err := jwks.SigningAlgorithm(activeJwk)
+	if err != nil {
 		actualType := "nil"
 		if t := reflect.TypeOf(activeJwk.Key); t != nil {
 			actualType = t.String()
 		}
 		plog.Debug(
-			"JWK must be of type ecdsa",
+			"JWK has an unsupported type",
 			"issuer",
 			s.fositeConfig.IDTokenIssuer,
 			"actualType",
 			actualType,
 		)
-		return "", fosite.ErrServerError.WithWrap(constable.Error("JWK must be of type ecdsa"))
+		return "", fosite.ErrServerError.WithWrap(err)
 	}
 
-	keyGetter := func(context.Context) (any, error) {
-		return key, nil
+	keyGetter := func(ctx context.Context) (any, error) {
+		return &jose.JSONWebKey{Key: jwks.SigningKey(ctx, activeJwk), Algorithm: string(alg)}, nil
 	}
 	strategy := compose.NewOpenIDConnectStrategy(keyGetter, s.fositeConfig)
 
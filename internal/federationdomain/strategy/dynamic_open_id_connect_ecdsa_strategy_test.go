@@ -6,6 +6,7 @@ package strategy
 import (
 	"context"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
@@ -15,6 +16,7 @@ import (
 	"time"
 
 	"github.com/go-jose/go-jose/v3"
+	josejwt "github.com/go-jose/go-jose/v3/jwt"
 	"github.com/ory/fosite"
 	"github.com/ory/fosite/handler/openid"
 	"github.com/ory/fosite/token/jwt"
@@ -39,6 +41,9 @@ func TestDynamicOpenIDConnectECDSAStrategy(t *testing.T) {
 	rsaPrivateKey, err := rsa.GenerateKey(rand.Reader, 2048)
 	require.NoError(t, err)
 
+	_, edPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
 	tests := []struct {
 		name           string
 		issuer         string
@@ -46,6 +51,7 @@ func TestDynamicOpenIDConnectECDSAStrategy(t *testing.T) {
 		wantErrorType  *fosite.RFC6749Error
 		wantErrorCause string
 		wantSigningJWK *jose.JSONWebKey
+		wantAlgorithm  jose.SignatureAlgorithm
 	}{
 		{
 			name:   "jwks provider does contain signing key for issuer",
@@ -63,6 +69,43 @@ func TestDynamicOpenIDConnectECDSAStrategy(t *testing.T) {
 			wantSigningJWK: &jose.JSONWebKey{
 				Key: ecPrivateKey,
 			},
+			wantAlgorithm: jose.ES256,
+		},
+		{
+			name:   "jwks provider does contain rsa signing key for issuer",
+			issuer: goodIssuer,
+			jwksProvider: func(provider jwks.DynamicJWKSProvider) {
+				provider.SetIssuerToJWKSMap(
+					nil,
+					map[string]*jose.JSONWebKey{
+						goodIssuer: {
+							Key: rsaPrivateKey,
+						},
+					},
+				)
+			},
+			wantSigningJWK: &jose.JSONWebKey{
+				Key: rsaPrivateKey,
+			},
+			wantAlgorithm: jose.RS256,
+		},
+		{
+			name:   "jwks provider does contain ed25519 signing key for issuer",
+			issuer: goodIssuer,
+			jwksProvider: func(provider jwks.DynamicJWKSProvider) {
+				provider.SetIssuerToJWKSMap(
+					nil,
+					map[string]*jose.JSONWebKey{
+						goodIssuer: {
+							Key: edPrivateKey,
+						},
+					},
+				)
+			},
+			wantSigningJWK: &jose.JSONWebKey{
+				Key: edPrivateKey,
+			},
+			wantAlgorithm: jose.EdDSA,
 		},
 		{
 			name:           "jwks provider does not contain signing key for issuer",
@@ -78,13 +121,13 @@ func TestDynamicOpenIDConnectECDSAStrategy(t *testing.T) {
 					nil,
 					map[string]*jose.JSONWebKey{
 						goodIssuer: {
-							Key: rsaPrivateKey,
+							Key: []byte("some-symmetric-key"),
 						},
 					},
 				)
 			},
 			wantErrorType:  fosite.ErrServerError,
-			wantErrorCause: "JWK must be of type ecdsa",
+			wantErrorCause: "JWK must be of type ecdsa (P-256), rsa or ed25519",
 		},
 	}
 	for _, test := range tests {
@@ -120,8 +163,19 @@ func TestDynamicOpenIDConnectECDSAStrategy(t *testing.T) {
 			} else {
 				require.NoError(t, err)
 
+				parsed, err := josejwt.ParseSigned(idToken)
+				require.NoError(t, err)
+				require.Len(t, parsed.Headers, 1)
+				require.Equal(t, string(test.wantAlgorithm), parsed.Headers[0].Algorithm)
+
 				privateKey, ok := test.wantSigningJWK.Key.(*ecdsa.PrivateKey)
-				require.True(t, ok, "wanted private key to be *ecdsa.PrivateKey, but was %T", test.wantSigningJWK)
+				if !ok {
+					// Other key types are only checked for the signature, since the ECDSA case below checks the claims.
+					var claims josejwt.Claims
+					require.NoError(t, parsed.Claims(test.wantSigningJWK.Public().Key, &claims))
+					require.Equal(t, goodSubject, claims.Subject)
+					return
+				}
 
 				// Perform a light validation on the token to make sure 1) we passed through the correct
 				// signing key and 2) we forwarded the fosite.Requester correctly. Token generation is
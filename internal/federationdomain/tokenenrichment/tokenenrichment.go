@@ -0,0 +1,185 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package tokenenrichment calls the token enrichment webhook of a FederationDomain, which can deny the issuance
+// of downstream tokens, replace the user's downstream groups, or add claims to the downstream ID token.
+package tokenenrichment
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.pinniped.dev/internal/net/phttp"
+	"go.pinniped.dev/internal/plog"
+)
+
+const (
+	// APIVersion is sent in each request to the webhook, so that the webhook can detect future changes to the
+	// format of the requests and responses.
+	APIVersion = "tokenenrichment.supervisor.pinniped.dev/v1alpha1"
+
+	DefaultTimeout = 10 * time.Second
+
+	// maxResponseBytes limits how much of the webhook's response will be read.
+	maxResponseBytes = 1 << 20
+)
+
+// FailurePolicy determines what happens when the webhook cannot be called, or returns an invalid response.
+type FailurePolicy string
+
+const (
+	FailurePolicyFail   FailurePolicy = "Fail"
+	FailurePolicyIgnore FailurePolicy = "Ignore"
+)
+
+// Config holds the settings for a Webhook.
+type Config struct {
+	// Endpoint is the HTTPS URL of the webhook.
+	Endpoint string
+	// CertificateAuthorityData is the base64 encoded PEM bundle of CA certificates to trust, or empty to
+	// use the system trust store.
+	CertificateAuthorityData string
+	// Timeout is how long to wait for a response from the webhook.
+	Timeout time.Duration
+	// FailurePolicy determines what happens when the webhook cannot be called.
+	FailurePolicy FailurePolicy
+}
+
+// Request is the JSON body which is sent to the webhook.
+type Request struct {
+	APIVersion string `json:"apiVersion"`
+
+	// Issuer is the issuer URL of the FederationDomain.
+	Issuer string `json:"issuer"`
+	// ClientID is the ID of the client which made the token request.
+	ClientID string `json:"clientID"`
+	// GrantType is the grant type of the token request, i.e. authorization_code or refresh_token.
+	GrantType string `json:"grantType"`
+	// GrantedScopes are the scopes which were granted to the client.
+	GrantedScopes []string `json:"grantedScopes"`
+
+	// Subject is the downstream subject of the user.
+	Subject string `json:"subject"`
+	// Username is the downstream username of the user, after identity transformations.
+	Username string `json:"username"`
+	// Groups are the downstream groups of the user, after identity transformations.
+	Groups []string `json:"groups"`
+	// ProviderName and ProviderType identify the identity provider which authenticated the user.
+	ProviderName string `json:"providerName"`
+	ProviderType string `json:"providerType"`
+
+	// SourceIP and UserAgent describe the client which made the token request.
+	SourceIP  string `json:"sourceIP"`
+	UserAgent string `json:"userAgent"`
+}
+
+// Response is the JSON body which is expected from the webhook.
+type Response struct {
+	// Allowed must be true for the tokens to be issued.
+	Allowed bool `json:"allowed"`
+	// Reason explains why the tokens were not allowed. It is returned to the client.
+	Reason string `json:"reason,omitempty"`
+	// Groups replaces the downstream groups of the user when it is not null. An empty list removes all groups.
+	Groups []string `json:"groups,omitempty"`
+	// AdditionalClaims are added to the additionalClaims claim of the downstream ID token.
+	AdditionalClaims map[string]any `json:"additionalClaims,omitempty"`
+}
+
+// Webhook calls a token enrichment webhook.
+//
+// It is thread-safe.
+type Webhook struct {
+	config Config
+	client *http.Client
+	log    plog.Logger
+
+	// configErr is returned by every call when the config was invalid, so that an invalid config
+	// cannot cause tokens to be issued without the webhook being consulted.
+	configErr error
+}
+
+// New returns a Webhook.
+func New(config Config, log plog.Logger) *Webhook {
+	w := &Webhook{config: config, log: log}
+
+	var rootCAs *x509.CertPool
+	if config.CertificateAuthorityData != "" {
+		bundle, err := base64.StdEncoding.DecodeString(config.CertificateAuthorityData)
+		if err != nil {
+			w.configErr = fmt.Errorf("certificateAuthorityData is not valid base64: %w", err)
+			return w
+		}
+		rootCAs = x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(bundle) {
+			w.configErr = errors.New("certificateAuthorityData does not contain any valid PEM certificates")
+			return w
+		}
+	}
+
+	w.client = phttp.Default(rootCAs)
+	w.client.Timeout = config.Timeout
+	return w
+}
+
+// Config returns the settings of this Webhook.
+func (w *Webhook) Config() Config {
+	return w.config
+}
+
+// Enrich calls the webhook. When the webhook cannot be called and the failure policy is Ignore, the error is
+// logged and a response which allows the tokens without changing them is returned.
+func (w *Webhook) Enrich(ctx context.Context, req *Request) (*Response, error) {
+	resp, err := w.call(ctx, req)
+	if err != nil {
+		if w.config.FailurePolicy == FailurePolicyIgnore {
+			w.log.WarningErr("ignoring token enrichment webhook error due to failure policy", err,
+				"endpoint", w.config.Endpoint, "issuer", req.Issuer)
+			return &Response{Allowed: true}, nil
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (w *Webhook) call(ctx context.Context, req *Request) (*Response, error) {
+	if w.configErr != nil {
+		return nil, w.configErr
+	}
+
+	req.APIVersion = APIVersion
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode token enrichment request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, w.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("could not build token enrichment request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+
+	httpResp, err := w.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("could not call token enrichment webhook: %w", err)
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token enrichment webhook returned unexpected status code %d", httpResp.StatusCode)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(io.LimitReader(httpResp.Body, maxResponseBytes)).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("could not decode token enrichment webhook response: %w", err)
+	}
+	return &resp, nil
+}
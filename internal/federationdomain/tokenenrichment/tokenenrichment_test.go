@@ -0,0 +1,141 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package tokenenrichment
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/internal/testutil/tlsserver"
+)
+
+func TestEnrich(t *testing.T) {
+	request := &Request{
+		Issuer:        "https://issuer.example.com",
+		ClientID:      "pinniped-cli",
+		GrantType:     "authorization_code",
+		GrantedScopes: []string{"openid", "groups"},
+		Subject:       "some-subject",
+		Username:      "some-username",
+		Groups:        []string{"a", "b"},
+		ProviderName:  "some-provider",
+		ProviderType:  "oidc",
+		SourceIP:      "1.2.3.4",
+		UserAgent:     "some-agent",
+	}
+	wantRequestJSON := `{"apiVersion":"tokenenrichment.supervisor.pinniped.dev/v1alpha1",` +
+		`"issuer":"https://issuer.example.com","clientID":"pinniped-cli","grantType":"authorization_code",` +
+		`"grantedScopes":["openid","groups"],"subject":"some-subject","username":"some-username",` +
+		`"groups":["a","b"],"providerName":"some-provider","providerType":"oidc",` +
+		`"sourceIP":"1.2.3.4","userAgent":"some-agent"}`
+
+	tests := []struct {
+		name          string
+		status        int
+		responseBody  string
+		failurePolicy FailurePolicy
+		badCA         bool
+		wantResponse  *Response
+		wantErr       string
+	}{
+		{
+			name:         "allowed with groups and claims",
+			status:       http.StatusOK,
+			responseBody: `{"allowed":true,"groups":["c"],"additionalClaims":{"device":"managed"}}`,
+			wantResponse: &Response{Allowed: true, Groups: []string{"c"}, AdditionalClaims: map[string]any{"device": "managed"}},
+		},
+		{
+			name:         "denied",
+			status:       http.StatusOK,
+			responseBody: `{"allowed":false,"reason":"outside of business hours"}`,
+			wantResponse: &Response{Allowed: false, Reason: "outside of business hours"},
+		},
+		{
+			name:         "error status with the Fail policy",
+			status:       http.StatusInternalServerError,
+			wantErr:      "token enrichment webhook returned unexpected status code 500",
+			responseBody: `{}`,
+		},
+		{
+			name:         "invalid response with the Fail policy",
+			status:       http.StatusOK,
+			responseBody: `not json`,
+			wantErr:      "could not decode token enrichment webhook response: invalid character 'o' in literal null (expecting 'u')",
+		},
+		{
+			name:          "error status with the Ignore policy",
+			status:        http.StatusInternalServerError,
+			responseBody:  `{}`,
+			failurePolicy: FailurePolicyIgnore,
+			wantResponse:  &Response{Allowed: true},
+		},
+		{
+			name:    "invalid CA data",
+			badCA:   true,
+			wantErr: "certificateAuthorityData does not contain any valid PEM certificates",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, serverCA := tlsserver.TestServerIPv4(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// use assert instead of require to not break the http.Handler with a panic
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				body, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+				assert.JSONEq(t, wantRequestJSON, string(body))
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.responseBody))
+			}), nil)
+
+			caData := base64.StdEncoding.EncodeToString(serverCA)
+			if tt.badCA {
+				caData = base64.StdEncoding.EncodeToString([]byte("not a certificate"))
+			}
+			failurePolicy := tt.failurePolicy
+			if failurePolicy == "" {
+				failurePolicy = FailurePolicyFail
+			}
+
+			webhook := New(Config{
+				Endpoint:                 server.URL,
+				CertificateAuthorityData: caData,
+				Timeout:                  time.Minute,
+				FailurePolicy:            failurePolicy,
+			}, plog.New())
+
+			reqCopy := *request
+			resp, err := webhook.Enrich(context.Background(), &reqCopy)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				require.Nil(t, resp)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantResponse, resp)
+		})
+	}
+}
+
+func TestResponseGroupsJSON(t *testing.T) {
+	var omitted, empty Response
+	require.NoError(t, json.Unmarshal([]byte(`{"allowed":true}`), &omitted))
+	require.NoError(t, json.Unmarshal([]byte(`{"allowed":true,"groups":[]}`), &empty))
+
+	// Omitting the groups leaves them unchanged, while an empty list removes all groups.
+	require.Nil(t, omitted.Groups)
+	require.NotNil(t, empty.Groups)
+	require.Empty(t, empty.Groups)
+}
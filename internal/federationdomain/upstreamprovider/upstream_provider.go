@@ -5,7 +5,9 @@ package upstreamprovider
 
 import (
 	"context"
+	"crypto/x509"
 	"net/url"
+	"time"
 
 	"golang.org/x/oauth2"
 	"k8s.io/apimachinery/pkg/types"
@@ -13,6 +15,7 @@ import (
 	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
 	"go.pinniped.dev/internal/authenticators"
 	"go.pinniped.dev/internal/setutil"
+	"go.pinniped.dev/internal/totp"
 	"go.pinniped.dev/pkg/oidcclient/nonce"
 	"go.pinniped.dev/pkg/oidcclient/oidctypes"
 	"go.pinniped.dev/pkg/oidcclient/pkce"
@@ -76,6 +79,15 @@ type UpstreamOIDCIdentityProviderI interface {
 	// flow with this upstream provider. When false, it should not be allowed.
 	AllowsPasswordGrant() bool
 
+	// UsesJWTSecuredAuthorizationResponses returns true if the upstream provider should be asked to return its
+	// authorization responses as JWTs (JARM), in which case the callback must only accept such responses.
+	UsesJWTSecuredAuthorizationResponses() bool
+
+	// ValidateJWTSecuredAuthorizationResponse validates the signature, issuer, audience, and expiry of a JWT
+	// secured authorization response (JARM) from the upstream provider. Returns the params of the authorization
+	// response which were carried by the JWT, e.g. the code and the state.
+	ValidateJWTSecuredAuthorizationResponse(ctx context.Context, response string) (url.Values, error)
+
 	// GetAdditionalAuthcodeParams returns additional params to be sent on authcode requests.
 	GetAdditionalAuthcodeParams() map[string]string
 
@@ -121,6 +133,10 @@ type UpstreamLDAPIdentityProviderI interface {
 	// identifier by being combined with the user's UID, since user UIDs are only unique within one provider.
 	GetURL() *url.URL
 
+	// GetTOTPConfig returns the configuration of the TOTP second factor which users must provide in addition
+	// to their password when they log in using a web browser, or nil when no second factor is required.
+	GetTOTPConfig() *totp.Config
+
 	// UserAuthenticator adds an interface method for performing user authentication against the upstream LDAP provider.
 	authenticators.UserAuthenticator
 
@@ -191,3 +207,74 @@ type UpstreamGithubIdentityProviderI interface {
 	// The IDP display name is passed to aid in building a suitable downstream subject string.
 	GetUser(ctx context.Context, accessToken string, idpDisplayName string) (*GitHubUser, error)
 }
+
+type ClientCertificateUser struct {
+	Username          string    // from the certificate's common name or email address
+	Groups            []string  // from the certificate subject's organizations or organizational units
+	DownstreamSubject string    // the whole downstream subject URI
+	Fingerprint       string    // the hex-encoded SHA-256 fingerprint of the client certificate
+	NotAfter          time.Time // the expiration time of the client certificate
+}
+
+type UpstreamClientCertificateIdentityProviderI interface {
+	UpstreamIdentityProviderI
+
+	// AuthenticateCertificate verifies that the given certificate chain, as presented by the client during the
+	// TLS handshake, was signed by the configured certificate authority and is currently valid for client
+	// authentication. The first certificate in the chain must be the client's leaf certificate.
+	// The IDP display name is passed to aid in building a suitable downstream subject string.
+	AuthenticateCertificate(peerCertificates []*x509.Certificate, idpDisplayName string) (*ClientCertificateUser, error)
+}
+
+type MockUser struct {
+	Username          string   // from the users Secret
+	Groups            []string // from the users Secret
+	DownstreamSubject string   // the whole downstream subject URI
+}
+
+type UpstreamMockIdentityProviderI interface {
+	UpstreamIdentityProviderI
+
+	// AuthenticateUser returns the user when the username and password match one of the static users of the
+	// provider. It returns false when they do not match.
+	// The IDP display name is passed to aid in building a suitable downstream subject string.
+	AuthenticateUser(username string, password string, idpDisplayName string) (*MockUser, bool)
+
+	// GetUser returns the current username and groups of the named user during a refresh. It returns false
+	// when the user has been removed from the provider since their initial login.
+	// The IDP display name is passed to aid in building a suitable downstream subject string.
+	GetUser(username string, idpDisplayName string) (*MockUser, bool)
+}
+
+type OpenShiftUser struct {
+	Username          string   // could be name, uid, or name:uid
+	Groups            []string // the names of the user's OpenShift groups
+	DownstreamSubject string   // the whole downstream subject URI
+}
+
+type UpstreamOpenShiftIdentityProviderI interface {
+	UpstreamIdentityProviderI
+
+	// GetClientID returns the name of the OAuthClient of the OpenShift cluster to be used in the authorization code flow.
+	GetClientID() string
+
+	// GetScopes returns the scopes to request in the authorization code flow.
+	GetScopes() []string
+
+	// GetAuthorizationURL returns the authorization endpoint of the OAuth server of the OpenShift cluster,
+	// as found by discovery. It will not include any query parameters or fragment.
+	GetAuthorizationURL() string
+
+	// UsesPKCE returns true when the OAuth server of the OpenShift cluster advertised support for PKCE
+	// during discovery, in which case PKCE should be used in the authorization code flow.
+	UsesPKCE() bool
+
+	// ExchangeAuthcode performs an authorization code exchange with the OAuth server of the OpenShift cluster.
+	// Returns the raw access token. The OAuth server of OpenShift does not issue refresh tokens.
+	ExchangeAuthcode(ctx context.Context, authcode string, pkceCodeVerifier pkce.Code, redirectURI string) (string, error)
+
+	// GetUser calls the user API of the OpenShift cluster using the accessToken to get the identity and the groups
+	// of the user. It returns a User or an error.
+	// The IDP display name is passed to aid in building a suitable downstream subject string.
+	GetUser(ctx context.Context, accessToken string, idpDisplayName string) (*OpenShiftUser, error)
+}
@@ -0,0 +1,237 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package workloadidentity authenticates the workloads which run in the Supervisor's own cluster using their
+// projected ServiceAccount tokens, so that they can exchange those tokens for cluster-scoped ID tokens at the token
+// endpoint of a FederationDomain without any human login. The tokens are validated using the ServiceAccount issuer
+// discovery document and keys which are served by the API server of the cluster.
+package workloadidentity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/clock"
+
+	"go.pinniped.dev/internal/federationdomain/downstreamsubject"
+)
+
+const (
+	// DefaultUsernamePrefix is prepended to the downstream username and groups of each ServiceAccount when the
+	// FederationDomain does not configure a prefix.
+	DefaultUsernamePrefix = "workload:"
+
+	// These paths are served by the API server when ServiceAccount issuer discovery is enabled, which is the default.
+	// The keys are always read from the API server, even when the discovery document names another jwks_uri.
+	discoveryPath = "/.well-known/openid-configuration"
+	jwksPath      = "/openid/v1/jwks"
+
+	// keysLifetime is how long the issuer and keys are used before they are fetched again.
+	keysLifetime = 10 * time.Minute
+
+	// minRefetchInterval limits how often the keys are fetched again when a token was signed by an unknown key,
+	// e.g. shortly after the ServiceAccount signing key of the cluster was rotated.
+	minRefetchInterval = 30 * time.Second
+
+	// Allow for the clock of the API server to be somewhat ahead of the clock of the Supervisor.
+	maxClockSkew = time.Minute
+)
+
+// supportedAlgorithms are the JWS algorithms with which the API server may sign ServiceAccount tokens.
+//
+//nolint:gochecknoglobals // Treated as a constant.
+var supportedAlgorithms = []jose.SignatureAlgorithm{jose.RS256, jose.ES256, jose.ES384, jose.ES512}
+
+// Config holds the workload identity settings of a FederationDomain.
+type Config struct {
+	// AllowedNamespaces are the namespaces whose ServiceAccounts may exchange their tokens.
+	AllowedNamespaces []string
+	// UsernamePrefix is prepended to the downstream username and groups of each ServiceAccount.
+	UsernamePrefix string
+}
+
+// Identity is the downstream identity of a workload.
+type Identity struct {
+	Subject  string
+	Username string
+	Groups   []string
+}
+
+type serviceAccountClaims struct {
+	Kubernetes struct {
+		Namespace      string `json:"namespace"`
+		ServiceAccount struct {
+			Name string `json:"name"`
+			UID  string `json:"uid"`
+		} `json:"serviceaccount"`
+	} `json:"kubernetes.io"`
+}
+
+type keys struct {
+	issuer    string
+	jwks      jose.JSONWebKeySet
+	fetchedAt time.Time
+}
+
+// Validator validates the ServiceAccount tokens of the cluster. It caches the issuer and keys of the cluster,
+// so it should be shared by all FederationDomains.
+type Validator struct {
+	get   func(ctx context.Context, path string) ([]byte, error)
+	clock clock.PassiveClock
+
+	mu   sync.Mutex
+	keys *keys
+}
+
+// NewValidator returns a Validator which reads the ServiceAccount issuer discovery document and keys using the
+// client, which must be allowed to get them, e.g. by the system:service-account-issuer-discovery ClusterRole.
+func NewValidator(client rest.Interface, clock clock.PassiveClock) *Validator {
+	return &Validator{
+		get: func(ctx context.Context, path string) ([]byte, error) {
+			return client.Get().AbsPath(path).Do(ctx).Raw()
+		},
+		clock: clock,
+	}
+}
+
+// Authenticator authenticates the workloads for one FederationDomain.
+type Authenticator struct {
+	validator *Validator
+	audience  string
+	config    Config
+}
+
+// NewAuthenticator returns an Authenticator which accepts the ServiceAccount tokens which have the audience,
+// i.e. the issuer of the FederationDomain, and which belong to the allowed namespaces of the config.
+func NewAuthenticator(validator *Validator, audience string, config Config) *Authenticator {
+	return &Authenticator{validator: validator, audience: audience, config: config}
+}
+
+// Authenticate validates the ServiceAccount token and returns the downstream identity of its ServiceAccount.
+func (a *Authenticator) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	issuer, claims, err := a.validator.validate(ctx, token, a.audience)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, name := claims.Kubernetes.Namespace, claims.Kubernetes.ServiceAccount.Name
+	if !slices.Contains(a.config.AllowedNamespaces, namespace) {
+		return nil, fmt.Errorf("ServiceAccounts of namespace %q are not allowed", namespace)
+	}
+
+	prefix := a.config.UsernamePrefix
+	if prefix == "" {
+		prefix = DefaultUsernamePrefix
+	}
+	serviceAccountUsername := "system:serviceaccount:" + namespace + ":" + name
+
+	return &Identity{
+		Subject:  downstreamsubject.ServiceAccount(issuer, serviceAccountUsername),
+		Username: prefix + serviceAccountUsername,
+		Groups: []string{
+			prefix + "system:serviceaccounts",
+			prefix + "system:serviceaccounts:" + namespace,
+		},
+	}, nil
+}
+
+// validate returns the issuer of the cluster and the claims of the token when the token is valid.
+func (v *Validator) validate(ctx context.Context, token string, audience string) (string, *serviceAccountClaims, error) {
+	parsed, err := jwt.ParseSigned(token)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not parse token: %w", err)
+	}
+	if len(parsed.Headers) != 1 || !slices.Contains(supportedAlgorithms, jose.SignatureAlgorithm(parsed.Headers[0].Algorithm)) {
+		return "", nil, errors.New("token is not signed with a supported algorithm")
+	}
+
+	k, key, err := v.key(ctx, parsed.Headers[0].KeyID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var registeredClaims jwt.Claims
+	var claims serviceAccountClaims
+	if err = parsed.Claims(key.Key, &registeredClaims, &claims); err != nil {
+		return "", nil, fmt.Errorf("could not verify token: %w", err)
+	}
+	if registeredClaims.Expiry == nil {
+		// Legacy ServiceAccount tokens never expire, so they are not accepted.
+		return "", nil, errors.New("token does not expire")
+	}
+	if err = registeredClaims.ValidateWithLeeway(jwt.Expected{
+		Issuer:   k.issuer,
+		Audience: jwt.Audience{audience},
+		Time:     v.clock.Now(),
+	}, maxClockSkew); err != nil {
+		return "", nil, fmt.Errorf("invalid token: %w", err)
+	}
+	if claims.Kubernetes.Namespace == "" || claims.Kubernetes.ServiceAccount.Name == "" {
+		return "", nil, errors.New("token is not a ServiceAccount token")
+	}
+
+	return k.issuer, &claims, nil
+}
+
+// key returns the current keys of the cluster and the key with the key ID. The keys are fetched again when they
+// are too old, or when they do not include the key ID and were not fetched very recently.
+func (v *Validator) key(ctx context.Context, keyID string) (*keys, *jose.JSONWebKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := v.clock.Now()
+	if v.keys == nil || now.Sub(v.keys.fetchedAt) > keysLifetime {
+		if err := v.fetch(ctx, now); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	found := v.keys.jwks.Key(keyID)
+	if len(found) == 0 && now.Sub(v.keys.fetchedAt) > minRefetchInterval {
+		if err := v.fetch(ctx, now); err != nil {
+			return nil, nil, err
+		}
+		found = v.keys.jwks.Key(keyID)
+	}
+	if len(found) == 0 {
+		return nil, nil, fmt.Errorf("token is signed by unknown key %q", keyID)
+	}
+
+	return v.keys, &found[0], nil
+}
+
+func (v *Validator) fetch(ctx context.Context, now time.Time) error {
+	discoveryJSON, err := v.get(ctx, discoveryPath)
+	if err != nil {
+		return fmt.Errorf("could not get ServiceAccount issuer discovery document: %w", err)
+	}
+	var discovery struct {
+		Issuer string `json:"issuer"`
+	}
+	if err = json.Unmarshal(discoveryJSON, &discovery); err != nil {
+		return fmt.Errorf("invalid ServiceAccount issuer discovery document: %w", err)
+	}
+	if discovery.Issuer == "" {
+		return errors.New("invalid ServiceAccount issuer discovery document: missing issuer")
+	}
+
+	jwksJSON, err := v.get(ctx, jwksPath)
+	if err != nil {
+		return fmt.Errorf("could not get ServiceAccount issuer keys: %w", err)
+	}
+	var jwks jose.JSONWebKeySet
+	if err = json.Unmarshal(jwksJSON, &jwks); err != nil {
+		return fmt.Errorf("invalid ServiceAccount issuer keys: %w", err)
+	}
+
+	v.keys = &keys{issuer: discovery.Issuer, jwks: jwks, fetchedAt: now}
+	return nil
+}
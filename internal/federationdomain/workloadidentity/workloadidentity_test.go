@@ -0,0 +1,290 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package workloadidentity
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+const (
+	serviceAccountIssuer = "https://kubernetes.default.svc.cluster.local"
+	federationDomain     = "https://some-issuer.example.com/some/path"
+)
+
+func TestAuthenticate(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	makeToken := func(t *testing.T, signingKey any, keyID string, editClaims func(map[string]any)) string {
+		t.Helper()
+		claims := map[string]any{
+			"iss": serviceAccountIssuer,
+			"sub": "system:serviceaccount:some-namespace:some-name",
+			"aud": []string{federationDomain},
+			"iat": now.Unix(),
+			"exp": now.Add(10 * time.Minute).Unix(),
+			"kubernetes.io": map[string]any{
+				"namespace": "some-namespace",
+				"serviceaccount": map[string]any{
+					"name": "some-name",
+					"uid":  "some-uid",
+				},
+			},
+		}
+		if editClaims != nil {
+			editClaims(claims)
+		}
+		signer, err := jose.NewSigner(
+			jose.SigningKey{Algorithm: jose.ES256, Key: jose.JSONWebKey{Key: signingKey, KeyID: keyID}},
+			(&jose.SignerOptions{}).WithType("JWT"),
+		)
+		require.NoError(t, err)
+		token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+		require.NoError(t, err)
+		return token
+	}
+
+	jwksJSON := func(t *testing.T, keys ...jose.JSONWebKey) []byte {
+		t.Helper()
+		b, err := json.Marshal(jose.JSONWebKeySet{Keys: keys})
+		require.NoError(t, err)
+		return b
+	}
+
+	tests := []struct {
+		name         string
+		config       Config
+		token        func(t *testing.T) string
+		jwks         func(t *testing.T) []byte
+		discovery    string
+		wantIdentity *Identity
+		wantErr      string
+	}{
+		{
+			name:   "valid token",
+			config: Config{AllowedNamespaces: []string{"other-namespace", "some-namespace"}, UsernamePrefix: "some-prefix:"},
+			token:  func(t *testing.T) string { return makeToken(t, key, "some-key", nil) },
+			wantIdentity: &Identity{
+				Subject:  "https://kubernetes.default.svc.cluster.local?sub=system%3Aserviceaccount%3Asome-namespace%3Asome-name",
+				Username: "some-prefix:system:serviceaccount:some-namespace:some-name",
+				Groups:   []string{"some-prefix:system:serviceaccounts", "some-prefix:system:serviceaccounts:some-namespace"},
+			},
+		},
+		{
+			name:   "default prefix",
+			config: Config{AllowedNamespaces: []string{"some-namespace"}},
+			token:  func(t *testing.T) string { return makeToken(t, key, "some-key", nil) },
+			wantIdentity: &Identity{
+				Subject:  "https://kubernetes.default.svc.cluster.local?sub=system%3Aserviceaccount%3Asome-namespace%3Asome-name",
+				Username: "workload:system:serviceaccount:some-namespace:some-name",
+				Groups:   []string{"workload:system:serviceaccounts", "workload:system:serviceaccounts:some-namespace"},
+			},
+		},
+		{
+			name:    "namespace is not allowed",
+			config:  Config{AllowedNamespaces: []string{"other-namespace"}},
+			token:   func(t *testing.T) string { return makeToken(t, key, "some-key", nil) },
+			wantErr: `ServiceAccounts of namespace "some-namespace" are not allowed`,
+		},
+		{
+			name:   "wrong audience",
+			config: Config{AllowedNamespaces: []string{"some-namespace"}},
+			token: func(t *testing.T) string {
+				return makeToken(t, key, "some-key", func(claims map[string]any) { claims["aud"] = []string{"https://kubernetes.default.svc"} })
+			},
+			wantErr: "invalid token: go-jose/go-jose/jwt: validation failed, invalid audience claim (aud)",
+		},
+		{
+			name:   "wrong issuer",
+			config: Config{AllowedNamespaces: []string{"some-namespace"}},
+			token: func(t *testing.T) string {
+				return makeToken(t, key, "some-key", func(claims map[string]any) { claims["iss"] = "https://other-issuer.example.com" })
+			},
+			wantErr: "invalid token: go-jose/go-jose/jwt: validation failed, invalid issuer claim (iss)",
+		},
+		{
+			name:   "expired",
+			config: Config{AllowedNamespaces: []string{"some-namespace"}},
+			token: func(t *testing.T) string {
+				return makeToken(t, key, "some-key", func(claims map[string]any) { claims["exp"] = now.Add(-2 * time.Minute).Unix() })
+			},
+			wantErr: "invalid token: go-jose/go-jose/jwt: validation failed, token is expired (exp)",
+		},
+		{
+			name:   "legacy token which does not expire",
+			config: Config{AllowedNamespaces: []string{"some-namespace"}},
+			token: func(t *testing.T) string {
+				return makeToken(t, key, "some-key", func(claims map[string]any) { delete(claims, "exp") })
+			},
+			wantErr: "token does not expire",
+		},
+		{
+			name:   "not a ServiceAccount token",
+			config: Config{AllowedNamespaces: []string{"some-namespace"}},
+			token: func(t *testing.T) string {
+				return makeToken(t, key, "some-key", func(claims map[string]any) { delete(claims, "kubernetes.io") })
+			},
+			wantErr: "token is not a ServiceAccount token",
+		},
+		{
+			name:    "signed by another key with the same key ID",
+			config:  Config{AllowedNamespaces: []string{"some-namespace"}},
+			token:   func(t *testing.T) string { return makeToken(t, otherKey, "some-key", nil) },
+			wantErr: "could not verify token: go-jose/go-jose: error in cryptographic primitive",
+		},
+		{
+			name:    "signed by unknown key",
+			config:  Config{AllowedNamespaces: []string{"some-namespace"}},
+			token:   func(t *testing.T) string { return makeToken(t, otherKey, "other-key", nil) },
+			wantErr: `token is signed by unknown key "other-key"`,
+		},
+		{
+			name:   "unsupported algorithm",
+			config: Config{AllowedNamespaces: []string{"some-namespace"}},
+			token: func(t *testing.T) string {
+				signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte("some-shared-secret-of-enough-length")}, nil)
+				require.NoError(t, err)
+				token, err := jwt.Signed(signer).Claims(jwt.Claims{Issuer: serviceAccountIssuer}).CompactSerialize()
+				require.NoError(t, err)
+				return token
+			},
+			wantErr: "token is not signed with a supported algorithm",
+		},
+		{
+			name:    "not a JWT",
+			config:  Config{AllowedNamespaces: []string{"some-namespace"}},
+			token:   func(*testing.T) string { return "not-a-jwt" },
+			wantErr: "could not parse token: go-jose/go-jose: compact JWS format must have three parts",
+		},
+		{
+			name:      "invalid discovery document",
+			config:    Config{AllowedNamespaces: []string{"some-namespace"}},
+			token:     func(t *testing.T) string { return makeToken(t, key, "some-key", nil) },
+			discovery: `{"jwks_uri": "https://example.com"}`,
+			wantErr:   "invalid ServiceAccount issuer discovery document: missing issuer",
+		},
+		{
+			name:    "invalid keys",
+			config:  Config{AllowedNamespaces: []string{"some-namespace"}},
+			token:   func(t *testing.T) string { return makeToken(t, key, "some-key", nil) },
+			jwks:    func(*testing.T) []byte { return []byte("not-json") },
+			wantErr: "invalid ServiceAccount issuer keys: invalid character 'o' in literal null (expecting 'u')",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			discovery := tt.discovery
+			if discovery == "" {
+				discovery = `{"issuer": "` + serviceAccountIssuer + `"}`
+			}
+			jwks := jwksJSON(t, jose.JSONWebKey{Key: key.Public(), KeyID: "some-key", Algorithm: "ES256", Use: "sig"})
+			if tt.jwks != nil {
+				jwks = tt.jwks(t)
+			}
+			validator := &Validator{
+				get: func(_ context.Context, path string) ([]byte, error) {
+					switch path {
+					case discoveryPath:
+						return []byte(discovery), nil
+					case jwksPath:
+						return jwks, nil
+					}
+					return nil, errors.New("unexpected path " + path)
+				},
+				clock: clocktesting.NewFakeClock(now),
+			}
+
+			identity, err := NewAuthenticator(validator, federationDomain, tt.config).Authenticate(context.Background(), tt.token(t))
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				require.Nil(t, identity)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantIdentity, identity)
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := clocktesting.NewFakeClock(now)
+
+	oldKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	currentKeys := []jose.JSONWebKey{{Key: oldKey.Public(), KeyID: "old-key"}}
+	fetches := 0
+	validator := &Validator{
+		get: func(_ context.Context, path string) ([]byte, error) {
+			if path == discoveryPath {
+				fetches++
+				return []byte(`{"issuer": "` + serviceAccountIssuer + `"}`), nil
+			}
+			return json.Marshal(jose.JSONWebKeySet{Keys: currentKeys})
+		},
+		clock: clock,
+	}
+	authenticator := NewAuthenticator(validator, federationDomain, Config{AllowedNamespaces: []string{"some-namespace"}})
+
+	makeToken := func(t *testing.T, signingKey *ecdsa.PrivateKey, keyID string) string {
+		t.Helper()
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: jose.JSONWebKey{Key: signingKey, KeyID: keyID}}, nil)
+		require.NoError(t, err)
+		token, err := jwt.Signed(signer).Claims(map[string]any{
+			"iss":           serviceAccountIssuer,
+			"aud":           federationDomain,
+			"exp":           clock.Now().Add(10 * time.Minute).Unix(),
+			"kubernetes.io": map[string]any{"namespace": "some-namespace", "serviceaccount": map[string]any{"name": "some-name"}},
+		}).CompactSerialize()
+		require.NoError(t, err)
+		return token
+	}
+
+	_, err = authenticator.Authenticate(context.Background(), makeToken(t, oldKey, "old-key"))
+	require.NoError(t, err)
+	require.Equal(t, 1, fetches)
+
+	// The cluster starts to sign with a new key. The keys were fetched too recently to fetch them again.
+	currentKeys = append(currentKeys, jose.JSONWebKey{Key: newKey.Public(), KeyID: "new-key"})
+	_, err = authenticator.Authenticate(context.Background(), makeToken(t, newKey, "new-key"))
+	require.EqualError(t, err, `token is signed by unknown key "new-key"`)
+	require.Equal(t, 1, fetches)
+
+	// Later, the keys are fetched again to find the new key.
+	clock.Step(minRefetchInterval + time.Second)
+	_, err = authenticator.Authenticate(context.Background(), makeToken(t, newKey, "new-key"))
+	require.NoError(t, err)
+	require.Equal(t, 2, fetches)
+
+	// Known keys do not cause the keys to be fetched again until they are too old.
+	clock.Step(minRefetchInterval + time.Second)
+	_, err = authenticator.Authenticate(context.Background(), makeToken(t, oldKey, "old-key"))
+	require.NoError(t, err)
+	require.Equal(t, 2, fetches)
+
+	clock.Step(keysLifetime)
+	currentKeys = currentKeys[1:]
+	_, err = authenticator.Authenticate(context.Background(), makeToken(t, oldKey, "old-key"))
+	require.EqualError(t, err, `token is signed by unknown key "old-key"`)
+	require.Equal(t, 3, fetches)
+}
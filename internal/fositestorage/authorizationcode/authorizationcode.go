@@ -37,7 +37,11 @@ const (
 	// Version 6 is when we upgraded fosite in Dec 2023.
 	// Version 7 is when OIDCClients were given configurable ID token lifetimes.
 	// Version 8 is when GitHubIdentityProvider was added.
-	authorizeCodeStorageVersion = "8"
+	// Version 9 is when ClientCertificateIdentityProvider was added.
+	// Version 10 is when MockIdentityProvider was added.
+	// Version 11 is when OpenShiftIdentityProvider was added.
+	// Version 12 is when the single sign-on session ID was added.
+	authorizeCodeStorageVersion = "12"
 )
 
 var _ fositeoauth2.AuthorizeCodeStorage = &authorizeCodeStorage{}
@@ -386,17 +390,26 @@ const ExpectedAuthorizeCodeSessionJSONFromFuzzing = `{
 				},
 				"github": {
 					"upstreamAccessToken": " 皦pSǬŝ社Vƅȭǝ*擦28ǅ"
-				}
+				},
+				"clientcertificate": {
+					"certificateFingerprint": "甍 ć\u003cʘ筫",
+					"certificateNotAfter": "1976-06-26T11:08:34.091243634Z"
+				},
+				"mock": {
+					"username": "\u0026錝D肁Ŷɽ蔒PR}Ųʓ"
+				},
+				"openshift": {
+					"upstreamAccessToken": "{鼐"
+				},
+				"singleSignOnSessionID": "$+溪ŸȢŒų崓ļ憽"
 			}
 		},
 		"requestedAudience": [
-			"甍 ć\u003cʘ筫",
-			"蛖a³2ʫ承dʬ)ġ,TÀqy_"
+			"蹐È_¸]fś酷ɂ/沴Ȃ僒鬎鉌"
 		],
 		"grantedAudience": [
-			"$+溪ŸȢŒų崓ļ憽",
-			"姧骦:駝重EȫʆɵʮGɃ"
+			"縆跣Šɞ"
 		]
 	},
-	"version": "8"
+	"version": "12"
 }`
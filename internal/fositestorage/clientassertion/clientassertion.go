@@ -0,0 +1,147 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package clientassertion remembers the IDs of the private_key_jwt client assertions which were already used,
+// so that each assertion may only be used once until it expires.
+package clientassertion
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/ory/fosite"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"go.pinniped.dev/internal/constable"
+	"go.pinniped.dev/internal/crud"
+)
+
+const (
+	TypeLabelValue = "client-assertion"
+
+	ErrInvalidClientAssertionVersion = constable.Error("client assertion data has wrong version")
+	ErrInvalidClientAssertionData    = constable.Error("client assertion data must be present")
+
+	// minLifetime is the shortest time for which a used assertion ID is remembered, in case the assertion
+	// has a very short or already passed expiry, to protect against clock skew between the client and the server.
+	minLifetime = time.Minute
+
+	// Version 1 was the initial release of storage.
+	clientAssertionStorageVersion = "1"
+)
+
+// Storage implements the part of the fosite.ClientManager interface which remembers the IDs (jti claims)
+// of client assertions.
+type Storage interface {
+	// ClientAssertionJWTValid returns fosite.ErrJTIKnown when the ID was already used by an assertion which has not
+	// expired, and nil when the ID is unknown.
+	ClientAssertionJWTValid(ctx context.Context, jti string) error
+	// SetClientAssertionJWT marks the ID as known until the given expiry time.
+	SetClientAssertionJWT(ctx context.Context, jti string, exp time.Time) error
+}
+
+type clientAssertionStorage struct {
+	storage crud.Storage
+	clock   func() time.Time
+}
+
+type usedClientAssertion struct {
+	JTI       string    `json:"jti"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type session struct {
+	Assertion *usedClientAssertion `json:"assertion"`
+	Version   string               `json:"version"`
+}
+
+func New(secrets corev1client.SecretInterface, clock func() time.Time) Storage {
+	return &clientAssertionStorage{storage: crud.New(TypeLabelValue, secrets, clock), clock: clock}
+}
+
+func (s *clientAssertionStorage) ClientAssertionJWTValid(ctx context.Context, jti string) error {
+	known, err := s.isKnown(ctx, jti)
+	if err != nil {
+		return err
+	}
+	if known {
+		return fosite.ErrJTIKnown
+	}
+	return nil
+}
+
+func (s *clientAssertionStorage) SetClientAssertionJWT(ctx context.Context, jti string, exp time.Time) error {
+	if jti == "" {
+		return ErrInvalidClientAssertionData
+	}
+
+	lifetime := exp.Sub(s.clock())
+	if lifetime < minLifetime {
+		lifetime = minLifetime
+	}
+	newSession := &session{
+		Assertion: &usedClientAssertion{JTI: jti, ExpiresAt: s.clock().Add(lifetime)},
+		Version:   clientAssertionStorageVersion,
+	}
+
+	_, err := s.storage.Create(ctx, signature(jti), newSession, nil, nil, lifetime)
+	if !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	// The ID might belong to an expired assertion which was not yet garbage collected, which may be replaced.
+	known, err := s.isKnown(ctx, jti)
+	if err != nil {
+		return err
+	}
+	if known {
+		return fosite.ErrJTIKnown
+	}
+	if err := s.storage.Delete(ctx, signature(jti)); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	_, err = s.storage.Create(ctx, signature(jti), newSession, nil, nil, lifetime)
+	if apierrors.IsAlreadyExists(err) {
+		// Another request used the same assertion concurrently.
+		return fosite.ErrJTIKnown
+	}
+	return err
+}
+
+// isKnown returns true when the ID was used by an assertion which has not expired.
+func (s *clientAssertionStorage) isKnown(ctx context.Context, jti string) (bool, error) {
+	stored := &session{}
+	_, err := s.storage.Get(ctx, signature(jti), stored)
+
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to get client assertion %s: %w", jti, err)
+	}
+
+	if version := stored.Version; version != clientAssertionStorageVersion {
+		return false, fmt.Errorf("%w: client assertion %s has version %s instead of %s",
+			ErrInvalidClientAssertionVersion, jti, version, clientAssertionStorageVersion)
+	}
+
+	if stored.Assertion == nil {
+		return false, fmt.Errorf("malformed client assertion %s: %w", jti, ErrInvalidClientAssertionData)
+	}
+
+	// Guard against the unlikely event of a hash collision, and ignore expired assertions which were not yet
+	// garbage collected.
+	return stored.Assertion.JTI == jti && s.clock().Before(stored.Assertion.ExpiresAt), nil
+}
+
+// signature returns a fixed length signature for the ID, since the IDs are chosen by the clients and may be much
+// longer than the names of Secrets may be.
+func signature(jti string) string {
+	hash := sha256.Sum256([]byte(jti))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
@@ -0,0 +1,93 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package clientassertion
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"go.pinniped.dev/internal/crud"
+)
+
+const namespace = "test-ns"
+
+var fakeNow = time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func TestClientAssertionStorage(t *testing.T) {
+	ctx, client, storage, _ := makeTestSubject()
+
+	require.NoError(t, storage.ClientAssertionJWTValid(ctx, "some-jti"))
+	require.NoError(t, storage.SetClientAssertionJWT(ctx, "some-jti", fakeNow.Add(5*time.Minute)))
+
+	secrets, err := client.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, secrets.Items, 1)
+	require.Equal(t, "client-assertion", secrets.Items[0].Labels[crud.SecretLabelKey])
+	require.Equal(t, corev1.SecretType("storage.pinniped.dev/client-assertion"), secrets.Items[0].Type)
+	require.Equal(t, metav1.Time{Time: fakeNow.Add(5 * time.Minute)}.Format(time.RFC3339),
+		secrets.Items[0].Annotations[crud.SecretLifetimeAnnotationKey])
+
+	err = storage.ClientAssertionJWTValid(ctx, "some-jti")
+	require.True(t, errors.Is(err, fosite.ErrJTIKnown))
+	err = storage.SetClientAssertionJWT(ctx, "some-jti", fakeNow.Add(5*time.Minute))
+	require.True(t, errors.Is(err, fosite.ErrJTIKnown))
+
+	require.NoError(t, storage.ClientAssertionJWTValid(ctx, "other-jti"))
+
+	require.Equal(t, ErrInvalidClientAssertionData, storage.SetClientAssertionJWT(ctx, "", fakeNow.Add(5*time.Minute)))
+}
+
+func TestClientAssertionExpired(t *testing.T) {
+	ctx, client, storage, fakeClock := makeTestSubject()
+
+	// Assertions which are about to expire are remembered for a minimum lifetime.
+	require.NoError(t, storage.SetClientAssertionJWT(ctx, "some-jti", fakeNow.Add(time.Second)))
+	fakeClock.Step(30 * time.Second)
+	require.True(t, errors.Is(storage.ClientAssertionJWTValid(ctx, "some-jti"), fosite.ErrJTIKnown))
+
+	// Expired assertions which were not yet garbage collected are ignored, and may be replaced.
+	fakeClock.Step(time.Minute)
+	require.NoError(t, storage.ClientAssertionJWTValid(ctx, "some-jti"))
+	require.NoError(t, storage.SetClientAssertionJWT(ctx, "some-jti", fakeClock.Now().Add(5*time.Minute)))
+	require.True(t, errors.Is(storage.ClientAssertionJWTValid(ctx, "some-jti"), fosite.ErrJTIKnown))
+
+	secrets, err := client.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, secrets.Items, 1)
+}
+
+func TestClientAssertionWrongVersion(t *testing.T) {
+	ctx, client, storage, _ := makeTestSubject()
+
+	require.NoError(t, storage.SetClientAssertionJWT(ctx, "some-jti", fakeNow.Add(5*time.Minute)))
+
+	secrets, err := client.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, secrets.Items, 1)
+	secret := secrets.Items[0]
+	secret.Data["pinniped-storage-data"] = []byte(`{"assertion":{"jti":"some-jti"},"version":"0"}`)
+	_, err = client.CoreV1().Secrets(namespace).Update(ctx, &secret, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	err = storage.ClientAssertionJWTValid(ctx, "some-jti")
+	require.EqualError(t, err, "client assertion data has wrong version: client assertion some-jti has version 0 instead of 1")
+}
+
+func makeTestSubject() (context.Context, *fake.Clientset, Storage, *clocktesting.FakeClock) {
+	client := fake.NewSimpleClientset()
+	fakeClock := clocktesting.NewFakeClock(fakeNow)
+	return context.Background(),
+		client,
+		New(client.CoreV1().Secrets(namespace), fakeClock.Now),
+		fakeClock
+}
@@ -0,0 +1,235 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package consent stores the logins which are waiting for the user to approve a client on the consent page,
+// and remembers which clients each user has approved.
+package consent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/ory/fosite"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"go.pinniped.dev/internal/constable"
+	"go.pinniped.dev/internal/crud"
+	"go.pinniped.dev/internal/federationdomain/csrftoken"
+	"go.pinniped.dev/internal/psession"
+)
+
+const (
+	PendingRequestTypeLabelValue = "pending-consent"
+	GrantTypeLabelValue          = "consent"
+
+	// PendingRequestLifetime is how long the user has to approve or deny a client on the consent page.
+	PendingRequestLifetime = 15 * time.Minute
+
+	// GrantLifetime is how long the approval of a client by a user is remembered.
+	GrantLifetime = 30 * 24 * time.Hour
+
+	ErrInvalidConsentVersion = constable.Error("consent data has wrong version")
+	ErrInvalidConsentData    = constable.Error("consent data must be present")
+
+	// Version 1 was the initial release of storage.
+	consentStorageVersion = "1"
+)
+
+// PendingRequest is a login which has already been authenticated, and which is waiting for the user to approve
+// or deny the client on the consent page before an authorization code is issued to the client.
+type PendingRequest struct {
+	// ClientID is the ID of the client which started the login.
+	ClientID string `json:"clientID"`
+	// Form holds the params of the original authorization request.
+	Form url.Values `json:"form"`
+	// Session is the downstream session which will be stored with the authorization code when the user approves.
+	Session *psession.PinnipedSession `json:"session"`
+	// CSRFToken is the value of the CSRF cookie of the browser which started the login, so that the decision
+	// can only be made from the same browser.
+	CSRFToken csrftoken.CSRFToken `json:"csrfToken"`
+	// ExpiresAt is the time after which the request may not be used anymore. Expired requests may remain stored
+	// until they are garbage collected.
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Storage stores pending consent requests by their ID, and remembers the approvals of clients by users.
+type Storage interface {
+	CreatePendingRequest(ctx context.Context, id string, request *PendingRequest) error
+	// GetPendingRequest returns an error which wraps fosite.ErrNotFound when the request does not exist or has expired.
+	GetPendingRequest(ctx context.Context, id string) (*PendingRequest, error)
+	DeletePendingRequest(ctx context.Context, id string) error
+
+	// HasGrant returns true when the user with the downstream subject has approved the client, and the approval
+	// has not expired.
+	HasGrant(ctx context.Context, clientID string, subject string) (bool, error)
+	// CreateGrant remembers that the user with the downstream subject has approved the client, replacing any
+	// previous approval.
+	CreateGrant(ctx context.Context, clientID string, subject string) error
+}
+
+type consentStorage struct {
+	pendingRequests crud.Storage
+	grants          crud.Storage
+	clock           func() time.Time
+}
+
+type pendingRequestSession struct {
+	Request *PendingRequest `json:"request"`
+	Version string          `json:"version"`
+}
+
+type grant struct {
+	ClientID  string    `json:"clientID"`
+	Subject   string    `json:"subject"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type grantSession struct {
+	Grant   *grant `json:"grant"`
+	Version string `json:"version"`
+}
+
+func New(secrets corev1client.SecretInterface, clock func() time.Time) Storage {
+	return &consentStorage{
+		pendingRequests: crud.New(PendingRequestTypeLabelValue, secrets, clock),
+		grants:          crud.New(GrantTypeLabelValue, secrets, clock),
+		clock:           clock,
+	}
+}
+
+// ReadPendingRequestFromSecret reads a pending consent request from a Secret which was created by this package.
+func ReadPendingRequestFromSecret(secret *corev1.Secret) (*PendingRequest, error) {
+	session := &pendingRequestSession{}
+	if err := crud.FromSecret(PendingRequestTypeLabelValue, secret, session); err != nil {
+		return nil, err
+	}
+	if session.Version != consentStorageVersion {
+		return nil, fmt.Errorf("%w: pending consent request has version %s instead of %s",
+			ErrInvalidConsentVersion, session.Version, consentStorageVersion)
+	}
+	if session.Request == nil || session.Request.Session == nil {
+		return nil, fmt.Errorf("malformed pending consent request: %w", ErrInvalidConsentData)
+	}
+	return session.Request, nil
+}
+
+func (s *consentStorage) CreatePendingRequest(ctx context.Context, id string, request *PendingRequest) error {
+	if request == nil || request.ClientID == "" || request.Session == nil {
+		return ErrInvalidConsentData
+	}
+
+	_, err := s.pendingRequests.Create(ctx,
+		id,
+		&pendingRequestSession{Request: request, Version: consentStorageVersion},
+		nil,
+		nil,
+		request.ExpiresAt.Sub(s.clock()),
+	)
+	return err
+}
+
+func (s *consentStorage) GetPendingRequest(ctx context.Context, id string) (*PendingRequest, error) {
+	session := &pendingRequestSession{}
+	_, err := s.pendingRequests.Get(ctx, id, session)
+
+	if apierrors.IsNotFound(err) {
+		return nil, fosite.ErrNotFound.WithWrap(err).WithDebug(err.Error())
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending consent request for %s: %w", id, err)
+	}
+
+	if version := session.Version; version != consentStorageVersion {
+		return nil, fmt.Errorf("%w: pending consent request for %s has version %s instead of %s",
+			ErrInvalidConsentVersion, id, version, consentStorageVersion)
+	}
+
+	if session.Request == nil || session.Request.ClientID == "" || session.Request.Session == nil {
+		return nil, fmt.Errorf("malformed pending consent request for %s: %w", id, ErrInvalidConsentData)
+	}
+
+	// The garbage collector only runs now and then, so expired requests might still exist.
+	if !s.clock().Before(session.Request.ExpiresAt) {
+		return nil, fosite.ErrNotFound.WithDebugf("pending consent request for %s has expired", id)
+	}
+
+	return session.Request, nil
+}
+
+func (s *consentStorage) DeletePendingRequest(ctx context.Context, id string) error {
+	err := s.pendingRequests.Delete(ctx, id)
+	if apierrors.IsNotFound(err) {
+		return fosite.ErrNotFound.WithWrap(err).WithDebug(err.Error())
+	}
+	return err
+}
+
+func (s *consentStorage) HasGrant(ctx context.Context, clientID string, subject string) (bool, error) {
+	session := &grantSession{}
+	_, err := s.grants.Get(ctx, grantSignature(clientID, subject), session)
+
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to get consent for client %s: %w", clientID, err)
+	}
+
+	if version := session.Version; version != consentStorageVersion {
+		return false, fmt.Errorf("%w: consent for client %s has version %s instead of %s",
+			ErrInvalidConsentVersion, clientID, version, consentStorageVersion)
+	}
+
+	if session.Grant == nil {
+		return false, fmt.Errorf("malformed consent for client %s: %w", clientID, ErrInvalidConsentData)
+	}
+
+	// Guard against the unlikely event of a hash collision, and ignore expired grants which were not yet
+	// garbage collected.
+	return session.Grant.ClientID == clientID && session.Grant.Subject == subject &&
+		s.clock().Before(session.Grant.ExpiresAt), nil
+}
+
+func (s *consentStorage) CreateGrant(ctx context.Context, clientID string, subject string) error {
+	if clientID == "" || subject == "" {
+		return ErrInvalidConsentData
+	}
+
+	signature := grantSignature(clientID, subject)
+
+	// Replace any expired grant which was not yet garbage collected.
+	if err := s.grants.Delete(ctx, signature); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	_, err := s.grants.Create(ctx,
+		signature,
+		&grantSession{
+			Grant:   &grant{ClientID: clientID, Subject: subject, ExpiresAt: s.clock().Add(GrantLifetime)},
+			Version: consentStorageVersion,
+		},
+		nil,
+		nil,
+		GrantLifetime,
+	)
+	if apierrors.IsAlreadyExists(err) {
+		// The user approved the same client concurrently in another browser tab.
+		return nil
+	}
+	return err
+}
+
+// grantSignature returns a fixed length signature for the client and the subject, since downstream subjects
+// can be much longer than the names of Secrets may be.
+func grantSignature(clientID string, subject string) string {
+	hash := sha256.Sum256([]byte(clientID + "\x00" + subject))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
@@ -0,0 +1,193 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package consent
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"go.pinniped.dev/internal/crud"
+	"go.pinniped.dev/internal/psession"
+)
+
+const namespace = "test-ns"
+
+var fakeNow = time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func TestPendingRequestStorage(t *testing.T) {
+	ctx, client, _, storage, _ := makeTestSubject()
+
+	request := &PendingRequest{
+		ClientID: "client.oauth.pinniped.dev-pinny",
+		Form:     url.Values{"client_id": {"client.oauth.pinniped.dev-pinny"}, "scope": {"openid"}},
+		Session: &psession.PinnipedSession{
+			Custom: &psession.CustomSessionData{Username: "pinny", ProviderType: psession.ProviderTypeLDAP},
+		},
+		CSRFToken: "some-csrf-token",
+		ExpiresAt: fakeNow.Add(PendingRequestLifetime),
+	}
+	err := storage.CreatePendingRequest(ctx, "fancy-id", request)
+	require.NoError(t, err)
+
+	secrets, err := client.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, secrets.Items, 1)
+	require.Equal(t, "pending-consent", secrets.Items[0].Labels[crud.SecretLabelKey])
+	require.Equal(t, corev1.SecretType("storage.pinniped.dev/pending-consent"), secrets.Items[0].Type)
+	require.Equal(t, metav1.Time{Time: fakeNow.Add(PendingRequestLifetime)}.Format(time.RFC3339),
+		secrets.Items[0].Annotations[crud.SecretLifetimeAnnotationKey])
+
+	readFromSecret, err := ReadPendingRequestFromSecret(&secrets.Items[0])
+	require.NoError(t, err)
+	require.Equal(t, request.Session.Custom, readFromSecret.Session.Custom)
+
+	newRequest, err := storage.GetPendingRequest(ctx, "fancy-id")
+	require.NoError(t, err)
+	require.Equal(t, request.ClientID, newRequest.ClientID)
+	require.Equal(t, request.Form, newRequest.Form)
+	require.Equal(t, request.Session.Custom, newRequest.Session.Custom)
+	require.Equal(t, request.CSRFToken, newRequest.CSRFToken)
+	require.Equal(t, request.ExpiresAt, newRequest.ExpiresAt)
+
+	err = storage.DeletePendingRequest(ctx, "fancy-id")
+	require.NoError(t, err)
+
+	_, err = storage.GetPendingRequest(ctx, "fancy-id")
+	require.EqualError(t, err, "not_found")
+	require.True(t, errors.Is(err, fosite.ErrNotFound))
+
+	err = storage.DeletePendingRequest(ctx, "fancy-id")
+	require.EqualError(t, err, "not_found")
+	require.True(t, errors.Is(err, fosite.ErrNotFound))
+}
+
+func TestPendingRequestExpired(t *testing.T) {
+	ctx, _, _, storage, fakeClock := makeTestSubject()
+
+	err := storage.CreatePendingRequest(ctx, "fancy-id", &PendingRequest{
+		ClientID:  "client.oauth.pinniped.dev-pinny",
+		Session:   &psession.PinnipedSession{},
+		ExpiresAt: fakeNow.Add(PendingRequestLifetime),
+	})
+	require.NoError(t, err)
+
+	fakeClock.Step(PendingRequestLifetime - time.Second)
+	_, err = storage.GetPendingRequest(ctx, "fancy-id")
+	require.NoError(t, err)
+
+	fakeClock.Step(time.Second)
+	_, err = storage.GetPendingRequest(ctx, "fancy-id")
+	require.EqualError(t, err, "not_found")
+	require.True(t, errors.Is(err, fosite.ErrNotFound))
+}
+
+func TestPendingRequestWrongVersion(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "pinniped-storage-pending-consent-pwu5zs7lekbhnln2w4",
+			Labels: map[string]string{
+				"storage.pinniped.dev/type": "pending-consent",
+			},
+		},
+		Data: map[string][]byte{
+			"pinniped-storage-data":    []byte(`{"request":{"clientID":"pinny","session":{}},"version":"not-the-right-version"}`),
+			"pinniped-storage-version": []byte("1"),
+		},
+		Type: "storage.pinniped.dev/pending-consent",
+	}
+
+	_, err := ReadPendingRequestFromSecret(secret)
+	require.EqualError(t, err, "consent data has wrong version: pending consent request has version not-the-right-version instead of 1")
+}
+
+func TestCreatePendingRequestWithInvalidRequest(t *testing.T) {
+	ctx, _, _, storage, _ := makeTestSubject()
+
+	err := storage.CreatePendingRequest(ctx, "id-doesnt-matter", nil)
+	require.EqualError(t, err, "consent data must be present")
+
+	err = storage.CreatePendingRequest(ctx, "id-doesnt-matter", &PendingRequest{ClientID: "pinny"})
+	require.EqualError(t, err, "consent data must be present")
+}
+
+func TestGrantStorage(t *testing.T) {
+	ctx, client, _, storage, fakeClock := makeTestSubject()
+
+	const (
+		clientID = "client.oauth.pinniped.dev-pinny"
+		subject  = "https://some-upstream-issuer?idpName=some-idp&sub=some-subject"
+	)
+
+	hasGrant, err := storage.HasGrant(ctx, clientID, subject)
+	require.NoError(t, err)
+	require.False(t, hasGrant)
+
+	require.NoError(t, storage.CreateGrant(ctx, clientID, subject))
+
+	hasGrant, err = storage.HasGrant(ctx, clientID, subject)
+	require.NoError(t, err)
+	require.True(t, hasGrant)
+
+	// The grant is only for this user and this client.
+	hasGrant, err = storage.HasGrant(ctx, clientID, "some-other-subject")
+	require.NoError(t, err)
+	require.False(t, hasGrant)
+	hasGrant, err = storage.HasGrant(ctx, "client.oauth.pinniped.dev-other", subject)
+	require.NoError(t, err)
+	require.False(t, hasGrant)
+
+	secrets, err := client.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, secrets.Items, 1)
+	require.Equal(t, "consent", secrets.Items[0].Labels[crud.SecretLabelKey])
+	require.Equal(t, metav1.Time{Time: fakeNow.Add(GrantLifetime)}.Format(time.RFC3339),
+		secrets.Items[0].Annotations[crud.SecretLifetimeAnnotationKey])
+
+	// Expired grants are ignored, even before they are garbage collected.
+	fakeClock.Step(GrantLifetime)
+	hasGrant, err = storage.HasGrant(ctx, clientID, subject)
+	require.NoError(t, err)
+	require.False(t, hasGrant)
+
+	// An expired grant is replaced by a new grant.
+	require.NoError(t, storage.CreateGrant(ctx, clientID, subject))
+	hasGrant, err = storage.HasGrant(ctx, clientID, subject)
+	require.NoError(t, err)
+	require.True(t, hasGrant)
+
+	secrets, err = client.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, secrets.Items, 1)
+	require.Equal(t, metav1.Time{Time: fakeNow.Add(2 * GrantLifetime)}.Format(time.RFC3339),
+		secrets.Items[0].Annotations[crud.SecretLifetimeAnnotationKey])
+}
+
+func TestCreateGrantWithInvalidData(t *testing.T) {
+	ctx, _, _, storage, _ := makeTestSubject()
+
+	require.EqualError(t, storage.CreateGrant(ctx, "", "some-subject"), "consent data must be present")
+	require.EqualError(t, storage.CreateGrant(ctx, "some-client", ""), "consent data must be present")
+}
+
+func makeTestSubject() (context.Context, *fake.Clientset, corev1client.SecretInterface, Storage, *clocktesting.FakeClock) {
+	client := fake.NewSimpleClientset()
+	secrets := client.CoreV1().Secrets(namespace)
+	fakeClock := clocktesting.NewFakeClock(fakeNow)
+	return context.Background(),
+		client,
+		secrets,
+		New(secrets, fakeClock.Now),
+		fakeClock
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package pushedauthorizerequest stores the params of pushed authorization requests, as described by RFC 9126,
+// until they are used at the authorization endpoint.
+package pushedauthorizerequest
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ory/fosite"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"go.pinniped.dev/internal/constable"
+	"go.pinniped.dev/internal/crud"
+)
+
+const (
+	TypeLabelValue = "pushed-authorize-request"
+
+	// RequestURIPrefix is the prefix of each request_uri returned by the pushed authorization request endpoint.
+	// The rest of the request_uri is the ID of the stored request.
+	RequestURIPrefix = "urn:ietf:params:oauth:request_uri:"
+
+	// Lifetime is how long a pushed authorization request may be used at the authorization endpoint.
+	// RFC 9126 recommends a short lifetime, since the client is expected to use the request_uri right away.
+	Lifetime = 60 * time.Second
+
+	ErrInvalidPushedAuthorizeRequestVersion = constable.Error("pushed authorization request data has wrong version")
+	ErrInvalidPushedAuthorizeRequestData    = constable.Error("pushed authorization request data must be present")
+
+	// Version 1 was the initial release of storage.
+	pushedAuthorizeRequestStorageVersion = "1"
+)
+
+// Request is a pushed authorization request.
+type Request struct {
+	// ClientID is the ID of the client which pushed the request.
+	ClientID string `json:"clientID"`
+	// Form holds the authorization request params, excluding any client authentication params.
+	Form url.Values `json:"form"`
+	// ExpiresAt is the time after which the request may not be used anymore. Expired requests may remain stored
+	// until they are garbage collected.
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Storage stores pushed authorization requests by their ID.
+type Storage interface {
+	Create(ctx context.Context, id string, request *Request) error
+	// Get returns an error which wraps fosite.ErrNotFound when the request does not exist or has expired.
+	Get(ctx context.Context, id string) (*Request, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type pushedAuthorizeRequestStorage struct {
+	storage crud.Storage
+	clock   func() time.Time
+}
+
+type session struct {
+	Request *Request `json:"request"`
+	Version string   `json:"version"`
+}
+
+func New(secrets corev1client.SecretInterface, clock func() time.Time) Storage {
+	return &pushedAuthorizeRequestStorage{storage: crud.New(TypeLabelValue, secrets, clock), clock: clock}
+}
+
+// RequestURI returns the request_uri for the request with the given ID.
+func RequestURI(id string) string {
+	return RequestURIPrefix + id
+}
+
+// IDFromRequestURI returns the ID of the request from the given request_uri, or false when the request_uri was not
+// returned by the pushed authorization request endpoint.
+func IDFromRequestURI(requestURI string) (string, bool) {
+	id, found := strings.CutPrefix(requestURI, RequestURIPrefix)
+	if !found || id == "" {
+		return "", false
+	}
+	return id, true
+}
+
+func (s *pushedAuthorizeRequestStorage) Create(ctx context.Context, id string, request *Request) error {
+	if request == nil || request.ClientID == "" {
+		return ErrInvalidPushedAuthorizeRequestData
+	}
+
+	_, err := s.storage.Create(ctx,
+		id,
+		&session{Request: request, Version: pushedAuthorizeRequestStorageVersion},
+		nil,
+		nil,
+		request.ExpiresAt.Sub(s.clock()),
+	)
+	return err
+}
+
+func (s *pushedAuthorizeRequestStorage) Get(ctx context.Context, id string) (*Request, error) {
+	session := &session{}
+	_, err := s.storage.Get(ctx, id, session)
+
+	if apierrors.IsNotFound(err) {
+		return nil, fosite.ErrNotFound.WithWrap(err).WithDebug(err.Error())
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pushed authorization request for %s: %w", id, err)
+	}
+
+	if version := session.Version; version != pushedAuthorizeRequestStorageVersion {
+		return nil, fmt.Errorf("%w: pushed authorization request for %s has version %s instead of %s",
+			ErrInvalidPushedAuthorizeRequestVersion, id, version, pushedAuthorizeRequestStorageVersion)
+	}
+
+	if session.Request == nil || session.Request.ClientID == "" {
+		return nil, fmt.Errorf("malformed pushed authorization request for %s: %w", id, ErrInvalidPushedAuthorizeRequestData)
+	}
+
+	// The garbage collector only runs now and then, so expired requests might still exist.
+	if !s.clock().Before(session.Request.ExpiresAt) {
+		return nil, fosite.ErrNotFound.WithDebugf("pushed authorization request for %s has expired", id)
+	}
+
+	return session.Request, nil
+}
+
+func (s *pushedAuthorizeRequestStorage) Delete(ctx context.Context, id string) error {
+	err := s.storage.Delete(ctx, id)
+	if apierrors.IsNotFound(err) {
+		return fosite.ErrNotFound.WithWrap(err).WithDebug(err.Error())
+	}
+	return err
+}
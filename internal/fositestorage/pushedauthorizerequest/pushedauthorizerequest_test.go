@@ -0,0 +1,197 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package pushedauthorizerequest
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	coretesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"go.pinniped.dev/internal/testutil"
+)
+
+const (
+	namespace       = "test-ns"
+	expectedVersion = "1" // update this when you update the storage version in the production code
+)
+
+var (
+	fakeNow                     = time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	fakeNowPlusLifetimeAsString = metav1.Time{Time: fakeNow.Add(Lifetime)}.Format(time.RFC3339)
+)
+
+func TestPushedAuthorizeRequestStorage(t *testing.T) {
+	secretsGVR := schema.GroupVersionResource{
+		Group:    "",
+		Version:  "v1",
+		Resource: "secrets",
+	}
+
+	wantActions := []coretesting.Action{
+		coretesting.NewCreateAction(secretsGVR, namespace, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "pinniped-storage-pushed-authorize-request-pwu5zs7lekbhnln2w4",
+				ResourceVersion: "",
+				Labels: map[string]string{
+					"storage.pinniped.dev/type": "pushed-authorize-request",
+				},
+				Annotations: map[string]string{
+					"storage.pinniped.dev/garbage-collect-after": fakeNowPlusLifetimeAsString,
+				},
+			},
+			Data: map[string][]byte{
+				"pinniped-storage-data":    []byte(`{"request":{"clientID":"pinny","form":{"client_id":["pinny"],"scope":["openid"]},"expiresAt":"2030-01-01T00:01:00Z"},"version":"` + expectedVersion + `"}`),
+				"pinniped-storage-version": []byte("1"),
+			},
+			Type: "storage.pinniped.dev/pushed-authorize-request",
+		}),
+		coretesting.NewGetAction(secretsGVR, namespace, "pinniped-storage-pushed-authorize-request-pwu5zs7lekbhnln2w4"),
+		coretesting.NewDeleteAction(secretsGVR, namespace, "pinniped-storage-pushed-authorize-request-pwu5zs7lekbhnln2w4"),
+	}
+
+	ctx, client, _, storage, _ := makeTestSubject()
+
+	request := &Request{
+		ClientID:  "pinny",
+		Form:      url.Values{"client_id": {"pinny"}, "scope": {"openid"}},
+		ExpiresAt: fakeNow.Add(Lifetime),
+	}
+	err := storage.Create(ctx, "fancy-signature", request)
+	require.NoError(t, err)
+
+	newRequest, err := storage.Get(ctx, "fancy-signature")
+	require.NoError(t, err)
+	require.Equal(t, request, newRequest)
+
+	err = storage.Delete(ctx, "fancy-signature")
+	require.NoError(t, err)
+
+	testutil.LogActualJSONFromCreateAction(t, client, 0) // makes it easier to update expected values when needed
+	require.Equal(t, wantActions, client.Actions())
+}
+
+func TestGetNotFound(t *testing.T) {
+	ctx, _, _, storage, _ := makeTestSubject()
+
+	_, notFoundErr := storage.Get(ctx, "non-existent-signature")
+	require.EqualError(t, notFoundErr, "not_found")
+	require.True(t, errors.Is(notFoundErr, fosite.ErrNotFound))
+
+	notFoundErr = storage.Delete(ctx, "non-existent-signature")
+	require.EqualError(t, notFoundErr, "not_found")
+	require.True(t, errors.Is(notFoundErr, fosite.ErrNotFound))
+}
+
+func TestGetExpired(t *testing.T) {
+	ctx, _, _, storage, fakeClock := makeTestSubject()
+
+	err := storage.Create(ctx, "fancy-signature", &Request{ClientID: "pinny", ExpiresAt: fakeNow.Add(Lifetime)})
+	require.NoError(t, err)
+
+	fakeClock.Step(Lifetime - time.Second)
+	_, err = storage.Get(ctx, "fancy-signature")
+	require.NoError(t, err)
+
+	fakeClock.Step(time.Second)
+	_, err = storage.Get(ctx, "fancy-signature")
+	require.EqualError(t, err, "not_found")
+	require.True(t, errors.Is(err, fosite.ErrNotFound))
+}
+
+func TestWrongVersion(t *testing.T) {
+	ctx, _, secrets, storage, _ := makeTestSubject()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "pinniped-storage-pushed-authorize-request-pwu5zs7lekbhnln2w4",
+			ResourceVersion: "",
+			Labels: map[string]string{
+				"storage.pinniped.dev/type": "pushed-authorize-request",
+			},
+		},
+		Data: map[string][]byte{
+			"pinniped-storage-data":    []byte(`{"request":{"clientID":"pinny"},"version":"not-the-right-version"}`),
+			"pinniped-storage-version": []byte("1"),
+		},
+		Type: "storage.pinniped.dev/pushed-authorize-request",
+	}
+	_, err := secrets.Create(ctx, secret, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	_, err = storage.Get(ctx, "fancy-signature")
+
+	require.EqualError(t, err, "pushed authorization request data has wrong version: pushed authorization request for fancy-signature has version not-the-right-version instead of "+expectedVersion)
+}
+
+func TestNilSessionRequest(t *testing.T) {
+	ctx, _, secrets, storage, _ := makeTestSubject()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "pinniped-storage-pushed-authorize-request-pwu5zs7lekbhnln2w4",
+			ResourceVersion: "",
+			Labels: map[string]string{
+				"storage.pinniped.dev/type": "pushed-authorize-request",
+			},
+		},
+		Data: map[string][]byte{
+			"pinniped-storage-data":    []byte(`{"nonsense-key": "nonsense-value","version":"` + expectedVersion + `"}`),
+			"pinniped-storage-version": []byte("1"),
+		},
+		Type: "storage.pinniped.dev/pushed-authorize-request",
+	}
+
+	_, err := secrets.Create(ctx, secret, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	_, err = storage.Get(ctx, "fancy-signature")
+	require.EqualError(t, err, "malformed pushed authorization request for fancy-signature: pushed authorization request data must be present")
+}
+
+func TestCreateWithInvalidRequest(t *testing.T) {
+	ctx, _, _, storage, _ := makeTestSubject()
+
+	err := storage.Create(ctx, "signature-doesnt-matter", nil)
+	require.EqualError(t, err, "pushed authorization request data must be present")
+
+	err = storage.Create(ctx, "signature-doesnt-matter", &Request{})
+	require.EqualError(t, err, "pushed authorization request data must be present")
+}
+
+func TestRequestURI(t *testing.T) {
+	requestURI := RequestURI("some-id")
+	require.Equal(t, "urn:ietf:params:oauth:request_uri:some-id", requestURI)
+
+	id, ok := IDFromRequestURI(requestURI)
+	require.True(t, ok)
+	require.Equal(t, "some-id", id)
+
+	for _, invalid := range []string{"", "some-id", "urn:ietf:params:oauth:request_uri:", "https://example.com/request"} {
+		_, ok = IDFromRequestURI(invalid)
+		require.False(t, ok, invalid)
+	}
+}
+
+func makeTestSubject() (context.Context, *fake.Clientset, corev1client.SecretInterface, Storage, *clocktesting.FakeClock) {
+	client := fake.NewSimpleClientset()
+	secrets := client.CoreV1().Secrets(namespace)
+	fakeClock := clocktesting.NewFakeClock(fakeNow)
+	return context.Background(),
+		client,
+		secrets,
+		New(secrets, fakeClock.Now),
+		fakeClock
+}
@@ -30,7 +30,7 @@ import (
 
 const (
 	namespace       = "test-ns"
-	expectedVersion = "8" // update this when you update the storage version in the production code
+	expectedVersion = "12" // update this when you update the storage version in the production code
 )
 
 var (
@@ -0,0 +1,186 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package singlesignon stores the upstream logins which are remembered by the single sign-on cookies of browsers,
+// so that users can log in to other clients of a FederationDomain without logging in at the upstream identity
+// provider again.
+package singlesignon
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ory/fosite"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"go.pinniped.dev/internal/constable"
+	"go.pinniped.dev/internal/crud"
+	"go.pinniped.dev/internal/psession"
+)
+
+const (
+	TypeLabelValue = "single-sign-on"
+
+	ErrInvalidSingleSignOnSessionVersion = constable.Error("single sign-on session data has wrong version")
+	ErrInvalidSingleSignOnSessionData    = constable.Error("single sign-on session data must be present")
+
+	// Version 1 was the initial release of storage.
+	// Version 2 is when the latest upstream refresh token started to be stored in the session, rather than in
+	// the downstream sessions which were started or resumed with it.
+	singleSignOnStorageVersion = "2"
+)
+
+// Session is an upstream login which may be used again by the browser which performed it.
+type Session struct {
+	// IdentityProviderDisplayName is the display name of the identity provider of the FederationDomain
+	// which the user logged in with.
+	IdentityProviderDisplayName string `json:"identityProviderDisplayName"`
+	// DownstreamSubject is the downstream subject which was determined for the user during the login.
+	DownstreamSubject string `json:"downstreamSubject"`
+	// Custom holds the upstream username and groups, the identity provider resource, and the session data which
+	// is specific to the type of the identity provider. Its downstream username is not set, since the identity
+	// transformations are applied again whenever the session is used.
+	Custom *psession.CustomSessionData `json:"custom"`
+	// AdditionalClaims holds the downstream additional claims which were determined for the user during the login.
+	AdditionalClaims map[string]any `json:"additionalClaims,omitempty"`
+	// AuthenticationContextClassReference is the acr claim which the upstream identity provider asserted for the
+	// login, if any.
+	AuthenticationContextClassReference string `json:"acr,omitempty"`
+	// AuthenticationMethodsReferences is the amr claim which the upstream identity provider asserted for the
+	// login, if any.
+	AuthenticationMethodsReferences []string `json:"amr,omitempty"`
+	// AuthTime is the time of the upstream login.
+	AuthTime time.Time `json:"authTime"`
+	// ExpiresAt is the time after which the session may not be used to log in anymore. Expired sessions remain
+	// stored for as long as the downstream sessions which share their upstream refresh token may be refreshed.
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Storage stores single sign-on sessions by their ID.
+type Storage interface {
+	Create(ctx context.Context, id string, session *Session) error
+	// Get returns an error which wraps fosite.ErrNotFound when the session does not exist or has expired.
+	Get(ctx context.Context, id string) (*Session, error)
+	// GetIncludingExpired is like Get, but also returns sessions which have expired, along with the resource
+	// version of their storage for a later Update.
+	GetIncludingExpired(ctx context.Context, id string) (*Session, string, error)
+	// Update replaces the session, which must not have changed since the resource version was read, and keeps it
+	// stored for the upstream token lifetime from now.
+	Update(ctx context.Context, id, resourceVersion string, session *Session) error
+}
+
+type singleSignOnStorage struct {
+	storage               crud.Storage
+	clock                 func() time.Time
+	upstreamTokenLifetime time.Duration
+}
+
+type session struct {
+	Session *Session `json:"session"`
+	Version string   `json:"version"`
+}
+
+// New returns the storage of single sign-on sessions. Sessions are kept for the upstreamTokenLifetime after they
+// expire or after they are updated, whichever is later, so that the downstream sessions which share their upstream
+// refresh token can still find it.
+func New(secrets corev1client.SecretInterface, clock func() time.Time, upstreamTokenLifetime time.Duration) Storage {
+	return &singleSignOnStorage{
+		storage:               crud.New(TypeLabelValue, secrets, clock),
+		clock:                 clock,
+		upstreamTokenLifetime: upstreamTokenLifetime,
+	}
+}
+
+// ReadFromSecret reads the contents of a Secret as a single sign-on session.
+func ReadFromSecret(secret *corev1.Secret) (*Session, error) {
+	stored := &session{}
+	if err := crud.FromSecret(TypeLabelValue, secret, stored); err != nil {
+		return nil, err
+	}
+	return validate(secret.Name, stored)
+}
+
+func (s *singleSignOnStorage) Create(ctx context.Context, id string, ssoSession *Session) error {
+	if ssoSession == nil || ssoSession.Custom == nil {
+		return ErrInvalidSingleSignOnSessionData
+	}
+
+	_, err := s.storage.Create(ctx,
+		id,
+		&session{Session: ssoSession, Version: singleSignOnStorageVersion},
+		nil,
+		nil,
+		s.lifetime(ssoSession),
+	)
+	return err
+}
+
+func (s *singleSignOnStorage) Get(ctx context.Context, id string) (*Session, error) {
+	ssoSession, _, err := s.GetIncludingExpired(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Expired sessions remain stored for the sake of their upstream refresh token.
+	if !s.clock().Before(ssoSession.ExpiresAt) {
+		return nil, fosite.ErrNotFound.WithDebugf("single sign-on session for %s has expired", id)
+	}
+
+	return ssoSession, nil
+}
+
+func (s *singleSignOnStorage) GetIncludingExpired(ctx context.Context, id string) (*Session, string, error) {
+	stored := &session{}
+	resourceVersion, err := s.storage.Get(ctx, id, stored)
+
+	if apierrors.IsNotFound(err) {
+		return nil, "", fosite.ErrNotFound.WithWrap(err).WithDebug(err.Error())
+	}
+
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get single sign-on session for %s: %w", id, err)
+	}
+
+	ssoSession, err := validate(id, stored)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return ssoSession, resourceVersion, nil
+}
+
+func (s *singleSignOnStorage) Update(ctx context.Context, id, resourceVersion string, ssoSession *Session) error {
+	if ssoSession == nil || ssoSession.Custom == nil {
+		return ErrInvalidSingleSignOnSessionData
+	}
+
+	_, err := s.storage.UpdateWithLifetime(ctx,
+		id,
+		resourceVersion,
+		&session{Session: ssoSession, Version: singleSignOnStorageVersion},
+		s.lifetime(ssoSession),
+	)
+	return err
+}
+
+// lifetime returns how long to keep the session from now. The downstream sessions which share its upstream refresh
+// token may be refreshed until the upstream token lifetime after the session expires, or after their last refresh.
+func (s *singleSignOnStorage) lifetime(ssoSession *Session) time.Duration {
+	return max(ssoSession.ExpiresAt.Sub(s.clock()), 0) + s.upstreamTokenLifetime
+}
+
+func validate(id string, stored *session) (*Session, error) {
+	if version := stored.Version; version != singleSignOnStorageVersion {
+		return nil, fmt.Errorf("%w: single sign-on session for %s has version %s instead of %s",
+			ErrInvalidSingleSignOnSessionVersion, id, version, singleSignOnStorageVersion)
+	}
+
+	if stored.Session == nil || stored.Session.Custom == nil {
+		return nil, fmt.Errorf("malformed single sign-on session for %s: %w", id, ErrInvalidSingleSignOnSessionData)
+	}
+
+	return stored.Session, nil
+}
@@ -0,0 +1,280 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package singlesignon
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	coretesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"go.pinniped.dev/internal/psession"
+	"go.pinniped.dev/internal/testutil"
+)
+
+const (
+	namespace             = "test-ns"
+	expectedVersion       = "2" // update this when you update the storage version in the production code
+	lifetime              = 8 * time.Hour
+	upstreamTokenLifetime = 9 * time.Hour
+)
+
+var (
+	fakeNow                                          = time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	fakeNowPlusLifetimeAndUpstreamTokenLifetimeAsStr = metav1.Time{Time: fakeNow.Add(lifetime + upstreamTokenLifetime)}.Format(time.RFC3339)
+)
+
+func TestSingleSignOnStorage(t *testing.T) {
+	secretsGVR := schema.GroupVersionResource{
+		Group:    "",
+		Version:  "v1",
+		Resource: "secrets",
+	}
+
+	wantActions := []coretesting.Action{
+		coretesting.NewCreateAction(secretsGVR, namespace, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "pinniped-storage-single-sign-on-pwu5zs7lekbhnln2w4",
+				ResourceVersion: "",
+				Labels: map[string]string{
+					"storage.pinniped.dev/type": "single-sign-on",
+				},
+				Annotations: map[string]string{
+					"storage.pinniped.dev/garbage-collect-after": fakeNowPlusLifetimeAndUpstreamTokenLifetimeAsStr,
+				},
+			},
+			Data: map[string][]byte{
+				"pinniped-storage-data": []byte(`{"session":{"identityProviderDisplayName":"some-idp","downstreamSubject":"some-subject",` +
+					`"custom":{"username":"","upstreamUsername":"some-upstream-username","upstreamGroups":["some-group"],` +
+					`"providerUID":"some-uid","providerName":"some-name","providerType":"oidc","warnings":null,` +
+					`"oidc":{"upstreamRefreshToken":"some-refresh-token","upstreamAccessToken":"","upstreamSubject":"","upstreamIssuer":""}},` +
+					`"additionalClaims":{"some-claim":"some-value"},"authTime":"2030-01-01T00:00:00Z","expiresAt":"2030-01-01T08:00:00Z"},` +
+					`"version":"` + expectedVersion + `"}`),
+				"pinniped-storage-version": []byte("1"),
+			},
+			Type: "storage.pinniped.dev/single-sign-on",
+		}),
+		coretesting.NewGetAction(secretsGVR, namespace, "pinniped-storage-single-sign-on-pwu5zs7lekbhnln2w4"),
+	}
+
+	ctx, client, _, storage, _ := makeTestSubject()
+
+	session := &Session{
+		IdentityProviderDisplayName: "some-idp",
+		DownstreamSubject:           "some-subject",
+		Custom: &psession.CustomSessionData{
+			UpstreamUsername: "some-upstream-username",
+			UpstreamGroups:   []string{"some-group"},
+			ProviderUID:      "some-uid",
+			ProviderName:     "some-name",
+			ProviderType:     psession.ProviderTypeOIDC,
+			OIDC:             &psession.OIDCSessionData{UpstreamRefreshToken: "some-refresh-token"},
+		},
+		AdditionalClaims: map[string]any{"some-claim": "some-value"},
+		AuthTime:         fakeNow,
+		ExpiresAt:        fakeNow.Add(lifetime),
+	}
+	err := storage.Create(ctx, "fancy-signature", session)
+	require.NoError(t, err)
+
+	newSession, err := storage.Get(ctx, "fancy-signature")
+	require.NoError(t, err)
+	require.Equal(t, session, newSession)
+
+	testutil.LogActualJSONFromCreateAction(t, client, 0) // makes it easier to update expected values when needed
+	require.Equal(t, wantActions, client.Actions())
+}
+
+func TestGetNotFound(t *testing.T) {
+	ctx, _, _, storage, _ := makeTestSubject()
+
+	_, notFoundErr := storage.Get(ctx, "non-existent-signature")
+	require.EqualError(t, notFoundErr, "not_found")
+	require.True(t, errors.Is(notFoundErr, fosite.ErrNotFound))
+}
+
+func TestGetExpired(t *testing.T) {
+	ctx, _, _, storage, fakeClock := makeTestSubject()
+
+	err := storage.Create(ctx, "fancy-signature", &Session{
+		Custom:    &psession.CustomSessionData{},
+		ExpiresAt: fakeNow.Add(lifetime),
+	})
+	require.NoError(t, err)
+
+	fakeClock.Step(lifetime - time.Second)
+	_, err = storage.Get(ctx, "fancy-signature")
+	require.NoError(t, err)
+
+	fakeClock.Step(time.Second)
+	_, err = storage.Get(ctx, "fancy-signature")
+	require.EqualError(t, err, "not_found")
+	require.True(t, errors.Is(err, fosite.ErrNotFound))
+
+	// Expired sessions can still be read for the sake of their upstream refresh token.
+	expiredSession, _, err := storage.GetIncludingExpired(ctx, "fancy-signature")
+	require.NoError(t, err)
+	require.Equal(t, fakeNow.Add(lifetime), expiredSession.ExpiresAt)
+}
+
+func TestUpdate(t *testing.T) {
+	ctx, client, secrets, storage, fakeClock := makeTestSubject()
+	testutil.AddSecretResourceVersionReactors(client)
+
+	err := storage.Create(ctx, "fancy-signature", &Session{
+		Custom: &psession.CustomSessionData{
+			ProviderType: psession.ProviderTypeOIDC,
+			OIDC:         &psession.OIDCSessionData{UpstreamRefreshToken: "some-refresh-token"},
+		},
+		ExpiresAt: fakeNow.Add(lifetime),
+	})
+	require.NoError(t, err)
+
+	session, resourceVersion, err := storage.GetIncludingExpired(ctx, "fancy-signature")
+	require.NoError(t, err)
+
+	// The session is kept for the upstream token lifetime after the update, which is later than after it expires.
+	fakeClock.Step(lifetime + time.Hour)
+	session.Custom.OIDC.UpstreamRefreshToken = "some-rotated-refresh-token"
+	err = storage.Update(ctx, "fancy-signature", resourceVersion, session)
+	require.NoError(t, err)
+
+	updatedSession, updatedResourceVersion, err := storage.GetIncludingExpired(ctx, "fancy-signature")
+	require.NoError(t, err)
+	require.Equal(t, session, updatedSession)
+	require.NotEqual(t, resourceVersion, updatedResourceVersion)
+
+	secret, err := secrets.Get(ctx, "pinniped-storage-single-sign-on-pwu5zs7lekbhnln2w4", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t,
+		metav1.Time{Time: fakeNow.Add(lifetime + time.Hour + upstreamTokenLifetime)}.Format(time.RFC3339),
+		secret.Annotations["storage.pinniped.dev/garbage-collect-after"])
+
+	// Updates which did not start from the latest version of the session fail.
+	err = storage.Update(ctx, "fancy-signature", resourceVersion, session)
+	require.ErrorContains(t, err, "the object has been modified")
+}
+
+func TestUpdateWithInvalidSession(t *testing.T) {
+	ctx, _, _, storage, _ := makeTestSubject()
+
+	err := storage.Update(ctx, "signature-doesnt-matter", "1", nil)
+	require.EqualError(t, err, "single sign-on session data must be present")
+
+	err = storage.Update(ctx, "signature-doesnt-matter", "1", &Session{})
+	require.EqualError(t, err, "single sign-on session data must be present")
+}
+
+func TestReadFromSecret(t *testing.T) {
+	ctx, _, secrets, storage, _ := makeTestSubject()
+
+	session := &Session{
+		Custom: &psession.CustomSessionData{
+			ProviderType: psession.ProviderTypeOIDC,
+			OIDC:         &psession.OIDCSessionData{UpstreamRefreshToken: "some-refresh-token"},
+		},
+		AuthTime:  fakeNow,
+		ExpiresAt: fakeNow.Add(lifetime),
+	}
+	err := storage.Create(ctx, "fancy-signature", session)
+	require.NoError(t, err)
+
+	secret, err := secrets.Get(ctx, "pinniped-storage-single-sign-on-pwu5zs7lekbhnln2w4", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	readSession, err := ReadFromSecret(secret)
+	require.NoError(t, err)
+	require.Equal(t, session, readSession)
+
+	secret.Data["pinniped-storage-data"] = []byte(`{"session":{"custom":{}},"version":"1"}`)
+	_, err = ReadFromSecret(secret)
+	require.ErrorIs(t, err, ErrInvalidSingleSignOnSessionVersion)
+	require.EqualError(t, err, "single sign-on session data has wrong version: single sign-on session for "+
+		"pinniped-storage-single-sign-on-pwu5zs7lekbhnln2w4 has version 1 instead of "+expectedVersion)
+
+	secret.Type = "storage.pinniped.dev/not-single-sign-on"
+	_, err = ReadFromSecret(secret)
+	require.EqualError(t, err, "secret storage data has incorrect type: storage.pinniped.dev/not-single-sign-on must equal storage.pinniped.dev/single-sign-on")
+}
+
+func TestWrongVersion(t *testing.T) {
+	ctx, _, secrets, storage, _ := makeTestSubject()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "pinniped-storage-single-sign-on-pwu5zs7lekbhnln2w4",
+			ResourceVersion: "",
+			Labels: map[string]string{
+				"storage.pinniped.dev/type": "single-sign-on",
+			},
+		},
+		Data: map[string][]byte{
+			"pinniped-storage-data":    []byte(`{"session":{"custom":{}},"version":"not-the-right-version"}`),
+			"pinniped-storage-version": []byte("1"),
+		},
+		Type: "storage.pinniped.dev/single-sign-on",
+	}
+	_, err := secrets.Create(ctx, secret, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	_, err = storage.Get(ctx, "fancy-signature")
+
+	require.EqualError(t, err, "single sign-on session data has wrong version: single sign-on session for fancy-signature has version not-the-right-version instead of "+expectedVersion)
+}
+
+func TestNilSession(t *testing.T) {
+	ctx, _, secrets, storage, _ := makeTestSubject()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "pinniped-storage-single-sign-on-pwu5zs7lekbhnln2w4",
+			ResourceVersion: "",
+			Labels: map[string]string{
+				"storage.pinniped.dev/type": "single-sign-on",
+			},
+		},
+		Data: map[string][]byte{
+			"pinniped-storage-data":    []byte(`{"nonsense-key": "nonsense-value","version":"` + expectedVersion + `"}`),
+			"pinniped-storage-version": []byte("1"),
+		},
+		Type: "storage.pinniped.dev/single-sign-on",
+	}
+
+	_, err := secrets.Create(ctx, secret, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	_, err = storage.Get(ctx, "fancy-signature")
+	require.EqualError(t, err, "malformed single sign-on session for fancy-signature: single sign-on session data must be present")
+}
+
+func TestCreateWithInvalidSession(t *testing.T) {
+	ctx, _, _, storage, _ := makeTestSubject()
+
+	err := storage.Create(ctx, "signature-doesnt-matter", nil)
+	require.EqualError(t, err, "single sign-on session data must be present")
+
+	err = storage.Create(ctx, "signature-doesnt-matter", &Session{})
+	require.EqualError(t, err, "single sign-on session data must be present")
+}
+
+func makeTestSubject() (context.Context, *fake.Clientset, corev1client.SecretInterface, Storage, *clocktesting.FakeClock) {
+	client := fake.NewSimpleClientset()
+	secrets := client.CoreV1().Secrets(namespace)
+	fakeClock := clocktesting.NewFakeClock(fakeNow)
+	return context.Background(),
+		client,
+		secrets,
+		New(secrets, fakeClock.Now, upstreamTokenLifetime),
+		fakeClock
+}
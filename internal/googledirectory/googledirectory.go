@@ -0,0 +1,206 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package googledirectory looks up the group memberships of Google Workspace users using the Directory API of the
+// Google Workspace Admin SDK, since Google never includes group memberships in ID tokens.
+package googledirectory
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jwt"
+
+	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
+)
+
+const (
+	// DefaultDirectoryURL is the URL of the Google Workspace Admin SDK.
+	DefaultDirectoryURL = "https://admin.googleapis.com"
+
+	// GroupReadonlyScope is the scope for which the service account must be granted domain-wide delegation.
+	GroupReadonlyScope = "https://www.googleapis.com/auth/admin.directory.group.readonly"
+
+	defaultTokenURL = "https://oauth2.googleapis.com/token"
+
+	emailClaim        = "email"
+	hostedDomainClaim = "hd"
+
+	serviceAccountKeyType = "service_account"
+)
+
+// Config holds the settings of a Resolver. Configs are comparable, so callers may keep using a Resolver for
+// as long as its Config does not change.
+type Config struct {
+	DirectoryURL      string
+	ServiceAccountKey string // the JSON key of the service account, as downloaded from Google Cloud
+	AdminEmail        string // the Google Workspace user which the service account impersonates
+	GroupsAttribute   idpv1alpha1.GoogleWorkspaceGroupsAttribute
+}
+
+// Resolver looks up group memberships using the Directory API. It caches the access token of its service account,
+// so the same Resolver should be reused for as long as its Config does not change.
+type Resolver struct {
+	config      Config
+	client      *http.Client
+	tokenSource oauth2.TokenSource
+}
+
+// New returns a Resolver, or an error when the service account key of the config is not usable.
+func New(config Config, client *http.Client) (*Resolver, error) {
+	config = withDefaults(config)
+
+	var key struct {
+		Type         string `json:"type"`
+		ClientEmail  string `json:"client_email"`
+		PrivateKey   string `json:"private_key"`
+		PrivateKeyID string `json:"private_key_id"`
+		TokenURI     string `json:"token_uri"`
+	}
+	if err := json.Unmarshal([]byte(config.ServiceAccountKey), &key); err != nil {
+		return nil, fmt.Errorf("service account key is not valid JSON: %w", err)
+	}
+	if key.Type != serviceAccountKeyType {
+		return nil, fmt.Errorf("service account key has type %q (should be %q)", key.Type, serviceAccountKeyType)
+	}
+	if key.ClientEmail == "" || key.PrivateKey == "" {
+		return nil, errors.New("service account key is missing the client_email or the private_key")
+	}
+	if err := validatePrivateKey(key.PrivateKey); err != nil {
+		return nil, fmt.Errorf("service account key contains an invalid private_key: %w", err)
+	}
+
+	tokenURL := key.TokenURI
+	if tokenURL == "" {
+		tokenURL = defaultTokenURL
+	}
+	jwtConfig := &jwt.Config{
+		Email:        key.ClientEmail,
+		PrivateKey:   []byte(key.PrivateKey),
+		PrivateKeyID: key.PrivateKeyID,
+		Subject:      config.AdminEmail,
+		Scopes:       []string{GroupReadonlyScope},
+		TokenURL:     tokenURL,
+	}
+
+	return &Resolver{
+		config: config,
+		client: client,
+		// The token source outlives any single request, so it must not use the context of a request.
+		tokenSource: jwtConfig.TokenSource(context.WithValue(context.Background(), oauth2.HTTPClient, client)),
+	}, nil
+}
+
+// Matches returns true when the Resolver was created with an equivalent Config.
+func (r *Resolver) Matches(config Config) bool {
+	return withDefaults(config) == r.config
+}
+
+// ResolveGroups returns the groups of which the Google Workspace user is a direct member. It returns false for
+// users of personal Google accounts, which do not belong to a Google Workspace and therefore have no groups.
+func (r *Resolver) ResolveGroups(ctx context.Context, claims map[string]any) ([]string, bool, error) {
+	if hostedDomain, _ := claims[hostedDomainClaim].(string); hostedDomain == "" {
+		return nil, false, nil
+	}
+
+	email, _ := claims[emailClaim].(string)
+	if email == "" {
+		return nil, true, fmt.Errorf("claims of Google Workspace user are missing the %q claim", emailClaim)
+	}
+
+	accessToken, err := r.tokenSource.Token()
+	if err != nil {
+		return nil, true, fmt.Errorf("could not get access token for Directory API: %w", err)
+	}
+
+	groups := []string{}
+	pageToken := ""
+	for {
+		page, err := r.listGroups(ctx, accessToken, email, pageToken)
+		if err != nil {
+			return nil, true, err
+		}
+		for _, group := range page.Groups {
+			if r.config.GroupsAttribute == idpv1alpha1.GoogleWorkspaceGroupsName {
+				groups = append(groups, group.Name)
+			} else {
+				groups = append(groups, group.Email)
+			}
+		}
+		if page.NextPageToken == "" {
+			return groups, true, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+type groupsPage struct {
+	Groups []struct {
+		Email string `json:"email"`
+		Name  string `json:"name"`
+	} `json:"groups"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+func (r *Resolver) listGroups(ctx context.Context, accessToken *oauth2.Token, email, pageToken string) (*groupsPage, error) {
+	query := url.Values{"userKey": {email}, "maxResults": {"200"}}
+	if pageToken != "" {
+		query.Set("pageToken", pageToken)
+	}
+	requestURL := fmt.Sprintf("%s/admin/directory/v1/groups?%s", r.config.DirectoryURL, query.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not build Directory API request: %w", err)
+	}
+	accessToken.SetAuthHeader(req)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling Directory API: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error calling Directory API: unexpected response status %q", resp.Status)
+	}
+
+	var page groupsPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("error parsing Directory API response: %w", err)
+	}
+	return &page, nil
+}
+
+func withDefaults(config Config) Config {
+	if config.DirectoryURL == "" {
+		config.DirectoryURL = DefaultDirectoryURL
+	}
+	if config.GroupsAttribute == "" {
+		config.GroupsAttribute = idpv1alpha1.GoogleWorkspaceGroupsEmail
+	}
+	config.DirectoryURL = strings.TrimSuffix(config.DirectoryURL, "/")
+	return config
+}
+
+// validatePrivateKey checks that the private key can be used to sign JWTs, so that a broken service account key
+// is reported when it is configured instead of when users log in.
+func validatePrivateKey(privateKeyPEM string) error {
+	block, _ := pem.Decode([]byte(privateKeyPEM))
+	if block == nil {
+		return errors.New("no PEM block found")
+	}
+	if _, err := x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+		if _, err := x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return errors.New("not a PKCS #8 or PKCS #1 private key")
+		}
+	}
+	return nil
+}
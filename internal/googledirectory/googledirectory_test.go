@@ -0,0 +1,234 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package googledirectory
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
+)
+
+func serviceAccountKey(t *testing.T, tokenURL string) string {
+	t.Helper()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+
+	key, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"client_email":   "pinniped@some-project.iam.gserviceaccount.com",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"private_key_id": "some-key-id",
+		"token_uri":      tokenURL,
+	})
+	require.NoError(t, err)
+	return string(key)
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr string
+	}{
+		{
+			name:    "not JSON",
+			key:     "not json",
+			wantErr: "service account key is not valid JSON: invalid character 'o' in literal null (expecting 'u')",
+		},
+		{
+			name:    "wrong type",
+			key:     `{"type": "authorized_user"}`,
+			wantErr: `service account key has type "authorized_user" (should be "service_account")`,
+		},
+		{
+			name:    "missing private key",
+			key:     `{"type": "service_account", "client_email": "pinniped@some-project.iam.gserviceaccount.com"}`,
+			wantErr: "service account key is missing the client_email or the private_key",
+		},
+		{
+			name:    "invalid private key",
+			key:     `{"type": "service_account", "client_email": "pinniped@some-project.iam.gserviceaccount.com", "private_key": "not a key"}`,
+			wantErr: "service account key contains an invalid private_key: no PEM block found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver, err := New(Config{ServiceAccountKey: tt.key, AdminEmail: "admin@example.com"}, http.DefaultClient)
+			require.EqualError(t, err, tt.wantErr)
+			require.Nil(t, resolver)
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	key := serviceAccountKey(t, "https://some-token-url")
+	subject, err := New(Config{ServiceAccountKey: key, AdminEmail: "admin@example.com"}, http.DefaultClient)
+	require.NoError(t, err)
+
+	require.True(t, subject.Matches(Config{ServiceAccountKey: key, AdminEmail: "admin@example.com"}))
+	require.True(t, subject.Matches(Config{
+		DirectoryURL:      "https://admin.googleapis.com/",
+		ServiceAccountKey: key,
+		AdminEmail:        "admin@example.com",
+		GroupsAttribute:   idpv1alpha1.GoogleWorkspaceGroupsEmail,
+	}))
+	require.False(t, subject.Matches(Config{ServiceAccountKey: key, AdminEmail: "other-admin@example.com"}))
+	require.False(t, subject.Matches(Config{ServiceAccountKey: key, AdminEmail: "admin@example.com", GroupsAttribute: idpv1alpha1.GoogleWorkspaceGroupsName}))
+}
+
+func TestResolveGroups(t *testing.T) {
+	workspaceUserClaims := map[string]any{"email": "pinny@example.com", "hd": "example.com"}
+
+	tests := []struct {
+		name            string
+		claims          map[string]any
+		groupsAttribute idpv1alpha1.GoogleWorkspaceGroupsAttribute
+		tokenStatus     int
+		directoryStatus int
+		directoryPages  []string
+		wantGroups      []string
+		wantResolved    bool
+		wantErr         string
+	}{
+		{
+			name:   "personal Google account",
+			claims: map[string]any{"email": "pinny@gmail.com"},
+		},
+		{
+			name:            "group emails across several pages",
+			claims:          workspaceUserClaims,
+			tokenStatus:     http.StatusOK,
+			directoryStatus: http.StatusOK,
+			directoryPages: []string{
+				`{"groups": [{"email": "eng@example.com", "name": "Engineering"}], "nextPageToken": "page-2"}`,
+				`{"groups": [{"email": "admins@example.com", "name": "Admins"}]}`,
+			},
+			wantGroups:   []string{"eng@example.com", "admins@example.com"},
+			wantResolved: true,
+		},
+		{
+			name:            "group names",
+			claims:          workspaceUserClaims,
+			groupsAttribute: idpv1alpha1.GoogleWorkspaceGroupsName,
+			tokenStatus:     http.StatusOK,
+			directoryStatus: http.StatusOK,
+			directoryPages:  []string{`{"groups": [{"email": "eng@example.com", "name": "Engineering"}]}`},
+			wantGroups:      []string{"Engineering"},
+			wantResolved:    true,
+		},
+		{
+			name:            "user is not a member of any groups",
+			claims:          workspaceUserClaims,
+			tokenStatus:     http.StatusOK,
+			directoryStatus: http.StatusOK,
+			directoryPages:  []string{`{"kind": "admin#directory#groups"}`},
+			wantGroups:      []string{},
+			wantResolved:    true,
+		},
+		{
+			name:         "missing email claim",
+			claims:       map[string]any{"hd": "example.com"},
+			wantResolved: true,
+			wantErr:      `claims of Google Workspace user are missing the "email" claim`,
+		},
+		{
+			name:         "token endpoint returns an error",
+			claims:       workspaceUserClaims,
+			tokenStatus:  http.StatusUnauthorized,
+			wantResolved: true,
+			wantErr:      "could not get access token for Directory API: oauth2: cannot fetch token: 401 Unauthorized",
+		},
+		{
+			name:            "directory API returns an error",
+			claims:          workspaceUserClaims,
+			tokenStatus:     http.StatusOK,
+			directoryStatus: http.StatusForbidden,
+			wantResolved:    true,
+			wantErr:         `error calling Directory API: unexpected response status "403 Forbidden"`,
+		},
+		{
+			name:            "directory API returns invalid JSON",
+			claims:          workspaceUserClaims,
+			tokenStatus:     http.StatusOK,
+			directoryStatus: http.StatusOK,
+			directoryPages:  []string{"not json"},
+			wantResolved:    true,
+			wantErr:         "error parsing Directory API response: invalid character 'o' in literal null (expecting 'u')",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/token":
+					require.NoError(t, r.ParseForm())
+					require.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.Form.Get("grant_type"))
+					assertionParts := strings.Split(r.Form.Get("assertion"), ".")
+					require.Len(t, assertionParts, 3)
+					payload, err := base64.RawURLEncoding.DecodeString(assertionParts[1])
+					require.NoError(t, err)
+					var assertionClaims map[string]any
+					require.NoError(t, json.Unmarshal(payload, &assertionClaims))
+					require.Equal(t, "pinniped@some-project.iam.gserviceaccount.com", assertionClaims["iss"])
+					require.Equal(t, "admin@example.com", assertionClaims["sub"])
+					require.Equal(t, GroupReadonlyScope, assertionClaims["scope"])
+					if tt.tokenStatus != http.StatusOK {
+						w.WriteHeader(tt.tokenStatus)
+						return
+					}
+					w.Header().Set("Content-Type", "application/json")
+					_, _ = w.Write([]byte(`{"access_token": "some-access-token", "token_type": "Bearer", "expires_in": 3600}`))
+				case "/admin/directory/v1/groups":
+					require.Equal(t, "Bearer some-access-token", r.Header.Get("Authorization"))
+					require.Equal(t, "pinny@example.com", r.URL.Query().Get("userKey"))
+					page := 0
+					if r.URL.Query().Get("pageToken") == "page-2" {
+						page = 1
+					}
+					w.WriteHeader(tt.directoryStatus)
+					if page < len(tt.directoryPages) {
+						_, _ = w.Write([]byte(tt.directoryPages[page]))
+					}
+				default:
+					t.Errorf("unexpected request to %s", r.URL.Path)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			t.Cleanup(server.Close)
+
+			subject, err := New(Config{
+				DirectoryURL:      server.URL,
+				ServiceAccountKey: serviceAccountKey(t, server.URL+"/token"),
+				AdminEmail:        "admin@example.com",
+				GroupsAttribute:   tt.groupsAttribute,
+			}, server.Client())
+			require.NoError(t, err)
+
+			groups, resolved, err := subject.ResolveGroups(context.Background(), tt.claims)
+			require.Equal(t, tt.wantResolved, resolved)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				require.Nil(t, groups)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantGroups, groups)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package healthcheck provides the checks which the Supervisor and Concierge use to report on their
+// /healthz, /livez and /readyz endpoints, in addition to the checks which come with k8s.io/apiserver.
+package healthcheck
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"k8s.io/apiserver/pkg/server/dynamiccertificates"
+	"k8s.io/apiserver/pkg/server/healthz"
+	"k8s.io/utils/clock"
+)
+
+// CacheSyncWaiter is the subset of SharedInformerFactory needed to find out whether its informers have synced.
+type CacheSyncWaiter interface {
+	WaitForCacheSync(stopCh <-chan struct{}) map[reflect.Type]bool
+}
+
+// InformerSync returns a check which fails until each of the factories has started its informers and
+// all of them have synced. Unlike healthz.NewInformerSyncHealthz, it also fails while a factory has not
+// started any informers yet, since the informers of Pinniped are only started once its controllers start.
+func InformerSync(factories ...CacheSyncWaiter) healthz.HealthChecker {
+	return healthz.NamedCheck("informer-sync", func(_ *http.Request) error {
+		stopCh := make(chan struct{})
+		close(stopCh) // do not wait, only report the current status
+
+		var unsynced []string
+		for _, factory := range factories {
+			status := factory.WaitForCacheSync(stopCh)
+			if len(status) == 0 {
+				unsynced = append(unsynced, fmt.Sprintf("%T:all", factory))
+			}
+			for typ, synced := range status {
+				if !synced {
+					unsynced = append(unsynced, typ.String())
+				}
+			}
+		}
+		if len(unsynced) > 0 {
+			sort.Strings(unsynced)
+			return fmt.Errorf("informers not started or not synced yet: %s", strings.Join(unsynced, ", "))
+		}
+		return nil
+	})
+}
+
+// ServingCert returns a check which fails while the provider does not have a certificate which is
+// currently valid, e.g. before the certificate was first loaded or after it expired without being rotated.
+func ServingCert(provider dynamiccertificates.CertKeyContentProvider, clock clock.PassiveClock) healthz.HealthChecker {
+	return healthz.NamedCheck("serving-cert", func(_ *http.Request) error {
+		certPEM, _ := provider.CurrentCertKeyContent()
+		block, _ := pem.Decode(certPEM)
+		if block == nil {
+			return fmt.Errorf("%s does not have a certificate yet", provider.Name())
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("%s has an invalid certificate: %w", provider.Name(), err)
+		}
+		now := clock.Now()
+		if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+			return fmt.Errorf("%s has a certificate which is not valid now (valid from %s until %s)",
+				provider.Name(), cert.NotBefore.UTC().Format(http.TimeFormat), cert.NotAfter.UTC().Format(http.TimeFormat))
+		}
+		return nil
+	})
+}
+
+// Latch is a check which fails until Set is called, e.g. to report that a cache has been populated for
+// the first time. It keeps passing after that.
+type Latch struct {
+	name    string
+	message string
+	set     atomic.Bool
+}
+
+var _ healthz.HealthChecker = (*Latch)(nil)
+
+// NewLatch returns a Latch with the given check name, which fails with the given message until Set is called.
+func NewLatch(name, message string) *Latch {
+	return &Latch{name: name, message: message}
+}
+
+// Set makes the check pass from now on. It is safe to call it many times and from many goroutines.
+func (l *Latch) Set() {
+	l.set.Store(true)
+}
+
+func (l *Latch) Name() string {
+	return l.name
+}
+
+func (l *Latch) Check(_ *http.Request) error {
+	if !l.set.Load() {
+		return errors.New(l.message)
+	}
+	return nil
+}
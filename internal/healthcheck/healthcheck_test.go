@@ -0,0 +1,69 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package healthcheck
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	k8sinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"go.pinniped.dev/internal/certauthority"
+	"go.pinniped.dev/internal/dynamiccert"
+)
+
+func TestInformerSync(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	factory := k8sinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), 0)
+	check := InformerSync(factory)
+	require.Equal(t, "informer-sync", check.Name())
+
+	// Nothing was started yet.
+	require.EqualError(t, check.Check(nil),
+		"informers not started or not synced yet: *informers.sharedInformerFactory:all")
+
+	_ = factory.Core().V1().Secrets().Informer()
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+
+	require.NoError(t, check.Check(nil))
+}
+
+func TestServingCert(t *testing.T) {
+	ca, err := certauthority.New("Test CA", time.Hour)
+	require.NoError(t, err)
+	certPEM, keyPEM, err := ca.IssueServerCertPEM([]string{"example.com"}, nil, time.Hour)
+	require.NoError(t, err)
+
+	provider := dynamiccert.NewServingCert("test-serving-cert")
+	clock := clocktesting.NewFakeClock(time.Now())
+	check := ServingCert(provider, clock)
+	require.Equal(t, "serving-cert", check.Name())
+
+	require.EqualError(t, check.Check(nil), "test-serving-cert does not have a certificate yet")
+
+	require.NoError(t, provider.SetCertKeyContent(certPEM, keyPEM))
+	require.NoError(t, check.Check(nil))
+
+	clock.Step(2 * time.Hour)
+	require.ErrorContains(t, check.Check(nil), "test-serving-cert has a certificate which is not valid now (valid from ")
+}
+
+func TestLatch(t *testing.T) {
+	latch := NewLatch("some-check", "not done yet")
+	require.Equal(t, "some-check", latch.Name())
+	require.EqualError(t, latch.Check(nil), "not done yet")
+
+	latch.Set()
+	require.NoError(t, latch.Check(nil))
+
+	latch.Set()
+	require.NoError(t, latch.Check(nil))
+}
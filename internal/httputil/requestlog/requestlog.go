@@ -0,0 +1,275 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package requestlog logs the HTTP requests served by the Supervisor and Concierge servers using plog, so they
+// appear in the server's own application logs next to any other logs about the same requests.
+package requestlog
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/clock"
+
+	"go.pinniped.dev/internal/plog"
+)
+
+// redactedValue replaces the values of sensitive query parameters and headers.
+const redactedValue = "redacted"
+
+// Spec configures the request logs of a server.
+type Spec struct {
+	// Enabled turns on the request logs. Defaults to false.
+	Enabled bool `json:"enabled"`
+	// Level is the log level of the request logs: one of "info", "debug" or "trace". When empty, which is the default,
+	// the request logs are written regardless of the log level of the server.
+	Level plog.LogLevel `json:"level,omitempty"`
+	// SampleRate is the fraction of the successful requests which are logged, between 0 and 1. Requests which fail,
+	// i.e. those with a response status of 400 or greater, are always logged. Defaults to 1.
+	SampleRate *float64 `json:"sampleRate,omitempty"`
+	// Headers lists the request headers which are included in each request log.
+	Headers []string `json:"headers,omitempty"`
+	// RedactQueryParams lists the query parameters whose values are redacted, in addition to the defaults.
+	RedactQueryParams []string `json:"redactQueryParams,omitempty"`
+	// RedactHeaders lists the request headers whose values are redacted, in addition to the defaults.
+	RedactHeaders []string `json:"redactHeaders,omitempty"`
+}
+
+// DefaultRedactedQueryParams returns the names of the query parameters whose values may contain tokens, secrets, or
+// personally identifiable information, so they are never logged.
+func DefaultRedactedQueryParams() []string {
+	return []string{
+		"code",
+		"state",
+		"nonce",
+		"code_challenge",
+		"code_verifier",
+		"client_secret",
+		"client_assertion",
+		"access_token",
+		"id_token",
+		"refresh_token",
+		"token",
+		"subject_token",
+		"login_hint",
+		"username",
+		"password",
+	}
+}
+
+// DefaultRedactedHeaders returns the names of the request headers whose values contain credentials, so they are
+// never logged.
+func DefaultRedactedHeaders() []string {
+	return []string{
+		"Authorization",
+		"Proxy-Authorization",
+		"Cookie",
+		"DPoP",
+		"Pinniped-Username",
+		"Pinniped-Password",
+	}
+}
+
+// Validate returns an error when the spec is invalid.
+func Validate(spec Spec) error {
+	switch spec.Level {
+	case plog.LevelWarning, plog.LevelInfo, plog.LevelDebug, plog.LevelTrace:
+	default:
+		return fmt.Errorf("invalid level %q, valid choices are the empty string, info, debug and trace", spec.Level)
+	}
+	if spec.SampleRate != nil && (*spec.SampleRate < 0 || *spec.SampleRate > 1) {
+		return errors.New("sampleRate must be between 0 and 1")
+	}
+	for _, header := range slices.Concat(spec.Headers, spec.RedactHeaders) {
+		if header == "" {
+			return errors.New("header names must not be empty")
+		}
+	}
+	for _, param := range spec.RedactQueryParams {
+		if param == "" {
+			return errors.New("query parameter names must not be empty")
+		}
+	}
+	return nil
+}
+
+// Logger logs the requests which are handled by the handlers that it wraps.
+//
+// It is thread-safe.
+type Logger struct {
+	log               func(msg string, keysAndValues ...any)
+	sampleRate        float64
+	sample            func() float64
+	headers           []string
+	redactQueryParams sets.Set[string]
+	redactHeaders     sets.Set[string]
+	clock             clock.PassiveClock
+}
+
+// New returns a Logger for the validated spec which writes to the logger, or nil when the spec is not enabled.
+func New(spec Spec, logger plog.Logger, clock clock.PassiveClock) *Logger {
+	if !spec.Enabled {
+		return nil
+	}
+
+	l := &Logger{
+		sampleRate:        1,
+		sample:            rand.Float64, //nolint:gosec // sampling does not need a secure random number
+		redactQueryParams: sets.New[string](),
+		redactHeaders:     sets.New[string](),
+		clock:             clock,
+	}
+
+	switch spec.Level {
+	case plog.LevelInfo:
+		l.log = logger.Info
+	case plog.LevelDebug:
+		l.log = logger.Debug
+	case plog.LevelTrace:
+		l.log = logger.Trace
+	default:
+		l.log = logger.Always
+	}
+
+	if spec.SampleRate != nil {
+		l.sampleRate = *spec.SampleRate
+	}
+
+	for _, header := range spec.Headers {
+		l.headers = append(l.headers, http.CanonicalHeaderKey(header))
+	}
+	for _, param := range slices.Concat(DefaultRedactedQueryParams(), spec.RedactQueryParams) {
+		l.redactQueryParams.Insert(strings.ToLower(param))
+	}
+	for _, header := range slices.Concat(DefaultRedactedHeaders(), spec.RedactHeaders) {
+		l.redactHeaders.Insert(http.CanonicalHeaderKey(header))
+	}
+
+	return l
+}
+
+// WrapHandler returns a handler which logs each request handled by the delegate. The name of the server is included
+// in each log. Returns the delegate when the Logger is nil, so callers do not need to check whether logs are enabled.
+func (l *Logger) WrapHandler(server string, delegate http.Handler) http.Handler {
+	if l == nil {
+		return delegate
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := l.clock.Now()
+		recorder := &responseRecorder{ResponseWriter: w}
+
+		delegate.ServeHTTP(recorder, r)
+
+		status := recorder.statusCode()
+		if status < http.StatusBadRequest && l.sample() >= l.sampleRate {
+			return
+		}
+
+		keysAndValues := []any{
+			"server", server,
+			"method", r.Method,
+			"path", r.URL.Path,
+			"query", l.redactQuery(r.URL.RawQuery),
+			"proto", r.Proto,
+			"host", r.Host,
+			"remoteAddr", r.RemoteAddr,
+			"userAgent", r.UserAgent(),
+			"status", status,
+			"bytes", recorder.bytes,
+			"durationMillis", l.clock.Since(start).Milliseconds(),
+		}
+		if headers := l.loggedHeaders(r.Header); len(headers) > 0 {
+			keysAndValues = append(keysAndValues, "headers", headers)
+		}
+
+		l.log("http request", keysAndValues...)
+	})
+}
+
+// loggedHeaders returns the values of the configured request headers which are present, with the values of
+// sensitive headers redacted.
+func (l *Logger) loggedHeaders(header http.Header) map[string]string {
+	headers := map[string]string{}
+	for _, name := range l.headers {
+		values := header.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+		if l.redactHeaders.Has(name) {
+			headers[name] = redactedValue
+			continue
+		}
+		headers[name] = strings.Join(values, ", ")
+	}
+	return headers
+}
+
+// redactQuery returns the raw query with the values of sensitive query parameters redacted.
+func (l *Logger) redactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	parts := strings.Split(rawQuery, "&")
+	for i, part := range parts {
+		name, _, hasValue := strings.Cut(part, "=")
+		unescapedName, err := url.QueryUnescape(name)
+		if err != nil {
+			// Could not understand the name of this param, so don't risk logging its value.
+			parts[i] = name + "=" + redactedValue
+			continue
+		}
+		if hasValue && l.redactQueryParams.Has(strings.ToLower(unescapedName)) {
+			parts[i] = name + "=" + redactedValue
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// responseRecorder remembers the status code and the size of the response body.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if r.status == 0 {
+		r.status = statusCode
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Flush allows streaming responses, e.g. watches of the aggregated APIs, to be flushed through the recorder.
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to find the original http.ResponseWriter.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *responseRecorder) statusCode() int {
+	if r.status == 0 {
+		// The handler did not write anything, so the server will send an empty 200 response.
+		return http.StatusOK
+	}
+	return r.status
+}
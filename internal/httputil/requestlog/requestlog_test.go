@@ -0,0 +1,125 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package requestlog
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+
+	"go.pinniped.dev/internal/plog"
+)
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Validate(Spec{}))
+	require.NoError(t, Validate(Spec{Enabled: true, Level: plog.LevelDebug, SampleRate: ptr.To(0.25), Headers: []string{"User-Agent"}}))
+
+	require.EqualError(t, Validate(Spec{Level: "all"}),
+		`invalid level "all", valid choices are the empty string, info, debug and trace`)
+	require.EqualError(t, Validate(Spec{SampleRate: ptr.To(1.5)}), "sampleRate must be between 0 and 1")
+	require.EqualError(t, Validate(Spec{SampleRate: ptr.To(-0.1)}), "sampleRate must be between 0 and 1")
+	require.EqualError(t, Validate(Spec{RedactHeaders: []string{""}}), "header names must not be empty")
+	require.EqualError(t, Validate(Spec{RedactQueryParams: []string{""}}), "query parameter names must not be empty")
+}
+
+func TestNew(t *testing.T) {
+	require.Nil(t, New(Spec{}, plog.New(), clocktesting.NewFakeClock(time.Now())))
+
+	// A nil Logger does not wrap the handler.
+	var nilLogger *Logger
+	handler := http.NewServeMux()
+	require.Same(t, handler, nilLogger.WrapHandler("some-server", handler))
+}
+
+func TestWrapHandler(t *testing.T) {
+	tests := []struct {
+		name      string
+		spec      Spec
+		sample    float64
+		url       string
+		headers   map[string]string
+		status    int
+		wantLog   string
+		wantNoLog bool
+	}{
+		{
+			name:    "sensitive query params are redacted",
+			spec:    Spec{Enabled: true, RedactQueryParams: []string{"Custom"}},
+			url:     "/callback?code=secret-code&scope=openid&custom=secret&flag",
+			status:  http.StatusOK,
+			wantLog: `"path":"/callback","query":"code=redacted&scope=openid&custom=redacted&flag",`,
+		},
+		{
+			name: "configured headers are included and sensitive headers are redacted",
+			spec: Spec{Enabled: true, Headers: []string{"x-request-id", "Authorization", "X-Missing", "X-Secret"}, RedactHeaders: []string{"x-secret"}},
+			url:  "/token",
+			headers: map[string]string{
+				"X-Request-Id":  "some-request-id",
+				"Authorization": "Bearer some-token",
+				"X-Secret":      "some-secret",
+			},
+			status:  http.StatusOK,
+			wantLog: `"headers":{"Authorization":"redacted","X-Request-Id":"some-request-id","X-Secret":"redacted"}`,
+		},
+		{
+			name:      "successful requests which are not sampled are not logged",
+			spec:      Spec{Enabled: true, SampleRate: ptr.To(0.5)},
+			sample:    0.5,
+			url:       "/healthz",
+			status:    http.StatusOK,
+			wantNoLog: true,
+		},
+		{
+			name:    "successful requests which are sampled are logged",
+			spec:    Spec{Enabled: true, SampleRate: ptr.To(0.5)},
+			sample:  0.4,
+			url:     "/healthz",
+			status:  http.StatusOK,
+			wantLog: `"path":"/healthz","query":"",`,
+		},
+		{
+			name:    "failed requests are always logged",
+			spec:    Spec{Enabled: true, SampleRate: ptr.To(0.0)},
+			sample:  0.9,
+			url:     "/token",
+			status:  http.StatusUnauthorized,
+			wantLog: `"status":401,"bytes":5,`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log bytes.Buffer
+			logger := New(tt.spec, plog.TestLogger(t, &log), clocktesting.NewFakeClock(time.Now()))
+			logger.sample = func() float64 { return tt.sample }
+
+			handler := logger.WrapHandler("some-server", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte("hello"))
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			req.Header.Set("User-Agent", "some-agent")
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rsp := httptest.NewRecorder()
+			handler.ServeHTTP(rsp, req)
+			require.Equal(t, tt.status, rsp.Code)
+
+			if tt.wantNoLog {
+				require.Empty(t, log.String())
+				return
+			}
+			require.Contains(t, log.String(), `"message":"http request","server":"some-server","method":"GET",`)
+			require.Contains(t, log.String(), `"host":"example.com","remoteAddr":"192.0.2.1:1234","userAgent":"some-agent",`)
+			require.Contains(t, log.String(), tt.wantLog)
+		})
+	}
+}
@@ -1,9 +1,15 @@
-// Copyright 2023 the Pinniped contributors. All Rights Reserved.
+// Copyright 2023-2024 the Pinniped contributors. All Rights Reserved.
 // SPDX-License-Identifier: Apache-2.0
 
 package requestutil
 
-import "net/http"
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+)
+
+type peerCertificatesContextKey struct{}
 
 func SNIServerName(req *http.Request) string {
 	name := ""
@@ -12,3 +18,20 @@ func SNIServerName(req *http.Request) string {
 	}
 	return name
 }
+
+// WithPeerCertificates returns a copy of the request's context which carries any client certificates
+// that were presented by the client during the TLS handshake.
+func WithPeerCertificates(req *http.Request) context.Context {
+	var certs []*x509.Certificate
+	if req.TLS != nil {
+		certs = req.TLS.PeerCertificates
+	}
+	return context.WithValue(req.Context(), peerCertificatesContextKey{}, certs)
+}
+
+// PeerCertificatesFromContext returns the client certificates which were added to the context by
+// WithPeerCertificates, or nil when there were none.
+func PeerCertificatesFromContext(ctx context.Context) []*x509.Certificate {
+	certs, _ := ctx.Value(peerCertificatesContextKey{}).([]*x509.Certificate)
+	return certs
+}
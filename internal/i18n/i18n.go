@@ -0,0 +1,174 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package i18n provides localized messages for the Supervisor's web pages and for the CLI's interactive prompts.
+//
+// The message catalogs are JSON files in the catalogs directory, named by their BCP 47 language tag (e.g. "de.json"),
+// which are embedded into the binaries at build time. Downstream distributors may add or extend catalogs by adding
+// files to that directory before building, or by calling Register at startup. English is the fallback language for
+// any message which is missing from a catalog.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+//go:embed catalogs/*.json
+var embeddedCatalogs embed.FS
+
+//nolint:gochecknoglobals // The catalogs are loaded once at init and may be extended by Register.
+var catalogs = mustLoadEmbeddedCatalogs()
+
+type registry struct {
+	mu sync.RWMutex
+
+	// messages maps each language to its catalog. The catalogs are never mutated after they are added,
+	// so a Localizer may safely hold a reference to one without holding the lock.
+	messages map[language.Tag]map[string]string
+	tags     []language.Tag // always starts with English, which makes English the default of the matcher
+	matcher  language.Matcher
+}
+
+func mustLoadEmbeddedCatalogs() *registry {
+	r := &registry{messages: map[language.Tag]map[string]string{}}
+
+	entries, err := embeddedCatalogs.ReadDir("catalogs")
+	if err != nil {
+		panic(err)
+	}
+	for _, entry := range entries {
+		data, err := embeddedCatalogs.ReadFile(path.Join("catalogs", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Errorf("invalid message catalog %q: %w", entry.Name(), err))
+		}
+		if err := r.register(strings.TrimSuffix(entry.Name(), ".json"), messages); err != nil {
+			panic(err)
+		}
+	}
+
+	if _, ok := r.messages[language.English]; !ok {
+		panic("the English message catalog is required")
+	}
+	return r
+}
+
+// Register adds the messages to the catalog of the specified language, adding the language if it was not already
+// supported. Messages which already existed for the language are replaced. This allows downstream distributors to
+// add languages or to customize individual messages. It is safe to call concurrently with the rest of this package.
+func Register(lang string, messages map[string]string) error {
+	return catalogs.register(lang, messages)
+}
+
+func (r *registry) register(lang string, messages map[string]string) error {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return fmt.Errorf("invalid language %q: %w", lang, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	merged := map[string]string{}
+	for k, v := range r.messages[tag] {
+		merged[k] = v
+	}
+	for k, v := range messages {
+		merged[k] = v
+	}
+	r.messages[tag] = merged
+
+	r.tags = []language.Tag{language.English}
+	for t := range r.messages {
+		if t != language.English {
+			r.tags = append(r.tags, t)
+		}
+	}
+	sort.Slice(r.tags[1:], func(i, j int) bool { return r.tags[i+1].String() < r.tags[j+1].String() })
+	r.matcher = language.NewMatcher(r.tags)
+
+	return nil
+}
+
+// Localizer looks up the messages of one language.
+type Localizer struct {
+	tag      language.Tag
+	messages map[string]string
+	fallback map[string]string
+}
+
+// Default returns a Localizer for English.
+func Default() *Localizer {
+	return ForLanguage("")
+}
+
+// ForLanguage returns a Localizer for the supported language which best matches the requested languages.
+// The argument may be a single BCP 47 language tag (e.g. "de" or "es-MX") or the value of an HTTP Accept-Language
+// header (e.g. "de-CH, de;q=0.9, en;q=0.8"). English is used when none of the requested languages are supported,
+// or when the argument is empty or cannot be parsed.
+func ForLanguage(lang string) *Localizer {
+	catalogs.mu.RLock()
+	defer catalogs.mu.RUnlock()
+
+	tag := language.English
+	if requested, _, err := language.ParseAcceptLanguage(lang); err == nil && len(requested) > 0 {
+		_, index, _ := catalogs.matcher.Match(requested...)
+		tag = catalogs.tags[index]
+	}
+
+	return &Localizer{
+		tag:      tag,
+		messages: catalogs.messages[tag],
+		fallback: catalogs.messages[language.English],
+	}
+}
+
+// Language returns the BCP 47 language tag of the Localizer, e.g. for use in the lang attribute of an HTML page.
+func (l *Localizer) Language() string {
+	return l.tag.String()
+}
+
+// T returns the localized message for the key, formatted with the optional args using fmt.Sprintf.
+// When the message is missing from the catalog of the Localizer's language, the English message is used.
+// When it is also missing from the English catalog, the key itself is returned.
+func (l *Localizer) T(key string, args ...any) string {
+	msg, ok := l.messages[key]
+	if !ok {
+		msg, ok = l.fallback[key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+type localizerContextKey struct{}
+
+// WithLocalizer returns a copy of the context which carries the Localizer.
+func WithLocalizer(ctx context.Context, l *Localizer) context.Context {
+	return context.WithValue(ctx, localizerContextKey{}, l)
+}
+
+// FromContext returns the Localizer which was added to the context by WithLocalizer,
+// or the Default Localizer when there was none.
+func FromContext(ctx context.Context) *Localizer {
+	if l, ok := ctx.Value(localizerContextKey{}).(*Localizer); ok && l != nil {
+		return l
+	}
+	return Default()
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package i18n
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+func TestEmbeddedCatalogsAreComplete(t *testing.T) {
+	english := catalogs.messages[language.English]
+	require.NotEmpty(t, english)
+
+	for tag, messages := range catalogs.messages {
+		for key := range english {
+			require.Contains(t, messages, key, "catalog %q is missing a message", tag)
+		}
+		for key, msg := range messages {
+			require.Contains(t, english, key, "catalog %q has a message which is not in the English catalog", tag)
+			require.Equal(t, strings.Count(english[key], "%"), strings.Count(msg, "%"),
+				"catalog %q has a message with different formatting verbs than the English message %q", tag, key)
+		}
+	}
+}
+
+func TestForLanguage(t *testing.T) {
+	tests := []struct {
+		lang     string
+		wantLang string
+	}{
+		{lang: "", wantLang: "en"},
+		{lang: "en", wantLang: "en"},
+		{lang: "de", wantLang: "de"},
+		{lang: "de-CH", wantLang: "de"},
+		{lang: "es-MX", wantLang: "es"},
+		{lang: "fr-CH, fr;q=0.9, es;q=0.8, *;q=0.5", wantLang: "es"},
+		{lang: "fr", wantLang: "en"},
+		{lang: "not a valid language!", wantLang: "en"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			require.Equal(t, tt.wantLang, ForLanguage(tt.lang).Language())
+		})
+	}
+}
+
+func TestT(t *testing.T) {
+	require.Equal(t, "Log in to some-idp", Default().T("login.heading", "some-idp"))
+	require.Equal(t, "Bei some-idp anmelden", ForLanguage("de").T("login.heading", "some-idp"))
+	require.Equal(t, "Contraseña", ForLanguage("es").T("login.password"))
+	require.Equal(t, "some.unknown.key", ForLanguage("es").T("some.unknown.key"))
+}
+
+func TestRegister(t *testing.T) {
+	require.EqualError(t, Register("not a valid language!", nil),
+		`invalid language "not a valid language!": language: tag is not well-formed`)
+
+	require.Equal(t, "en", ForLanguage("nl").Language())
+	require.NoError(t, Register("nl", map[string]string{"login.password": "Wachtwoord"}))
+	t.Cleanup(func() {
+		catalogs = mustLoadEmbeddedCatalogs()
+	})
+
+	dutch := ForLanguage("nl-BE")
+	require.Equal(t, "nl", dutch.Language())
+	require.Equal(t, "Wachtwoord", dutch.T("login.password"))
+	require.Equal(t, "Username", dutch.T("login.username"), "should fall back to English for missing messages")
+
+	// Registering messages for an existing language overrides only those messages.
+	require.NoError(t, Register("de", map[string]string{"login.submit": "Los geht's"}))
+	require.Equal(t, "Los geht's", ForLanguage("de").T("login.submit"))
+	require.Equal(t, "Passwort", ForLanguage("de").T("login.password"))
+}
+
+func TestContext(t *testing.T) {
+	require.Equal(t, "en", FromContext(context.Background()).Language())
+	require.Equal(t, "es", FromContext(WithLocalizer(context.Background(), ForLanguage("es"))).Language())
+}
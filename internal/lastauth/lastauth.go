@@ -0,0 +1,75 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package lastauth remembers the time of the most recent successful authentication of each OIDCClient or
+// authenticator in memory, so that their controllers can publish it in the status of the resources without
+// writing to the Kubernetes API during each request.
+package lastauth
+
+import (
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+)
+
+// Recorder remembers the time of the most recent successful authentication for each key.
+// A nil Recorder does not remember anything.
+//
+// It is thread-safe.
+type Recorder[K comparable] struct {
+	clock clock.PassiveClock
+
+	lock  sync.RWMutex
+	times map[K]time.Time
+}
+
+func New[K comparable](clock clock.PassiveClock) *Recorder[K] {
+	return &Recorder[K]{clock: clock, times: map[K]time.Time{}}
+}
+
+// Record remembers that the key successfully authenticated now.
+func (r *Recorder[K]) Record(key K) {
+	if r == nil {
+		return
+	}
+	now := r.clock.Now()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.times[key] = now
+}
+
+// Forget removes the key, e.g. when its resource was deleted.
+func (r *Recorder[K]) Forget(key K) {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.times, key)
+}
+
+// Latest returns the time of the most recent successful authentication of the key when it is later than current,
+// which is the timestamp that is already in the status of the resource. Otherwise, it returns current.
+// The time is truncated to seconds like all timestamps of the Kubernetes API, so that it does not cause needless
+// updates of the status. Each server only knows about the requests which it served itself, so the timestamp never
+// moves backwards when another server recorded a later time.
+func (r *Recorder[K]) Latest(key K, current *metav1.Time) *metav1.Time {
+	if r == nil {
+		return current
+	}
+	r.lock.RLock()
+	last, ok := r.times[key]
+	r.lock.RUnlock()
+
+	if !ok {
+		return current
+	}
+	last = last.UTC().Truncate(time.Second)
+	if current != nil && !current.Time.Before(last) {
+		return current
+	}
+	return &metav1.Time{Time: last}
+}
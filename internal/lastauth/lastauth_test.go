@@ -0,0 +1,42 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package lastauth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestRecorder(t *testing.T) {
+	now := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC)
+	frozenClock := clocktesting.NewFakeClock(now)
+	nowInSeconds := metav1.NewTime(time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC))
+	earlier := metav1.NewTime(now.Add(-time.Hour))
+	later := metav1.NewTime(now.Add(time.Hour))
+
+	r := New[string](frozenClock)
+	require.Nil(t, r.Latest("some-client", nil))
+	require.Equal(t, &earlier, r.Latest("some-client", &earlier))
+
+	r.Record("some-client")
+	require.Equal(t, &nowInSeconds, r.Latest("some-client", nil))
+	require.Equal(t, &nowInSeconds, r.Latest("some-client", &earlier))
+	require.Equal(t, &later, r.Latest("some-client", &later))
+	// The timestamp in the status was already updated, so it does not change.
+	require.Equal(t, &nowInSeconds, r.Latest("some-client", &nowInSeconds))
+	require.Nil(t, r.Latest("other-client", nil))
+
+	r.Forget("some-client")
+	require.Nil(t, r.Latest("some-client", nil))
+
+	// A nil Recorder does not remember anything.
+	var nilRecorder *Recorder[string]
+	nilRecorder.Record("some-client")
+	nilRecorder.Forget("some-client")
+	require.Equal(t, &earlier, nilRecorder.Latest("some-client", &earlier))
+}
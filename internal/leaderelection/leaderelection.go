@@ -1,4 +1,4 @@
-// Copyright 2021-2023 the Pinniped contributors. All Rights Reserved.
+// Copyright 2021-2024 the Pinniped contributors. All Rights Reserved.
 // SPDX-License-Identifier: Apache-2.0
 
 package leaderelection
@@ -6,11 +6,13 @@ package leaderelection
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"sync/atomic"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/server/healthz"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/leaderelection"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
@@ -18,12 +20,33 @@ import (
 	"go.pinniped.dev/internal/constable"
 	"go.pinniped.dev/internal/controllerinit"
 	"go.pinniped.dev/internal/downward"
+	"go.pinniped.dev/internal/healthcheck"
 	"go.pinniped.dev/internal/kubeclient"
 	"go.pinniped.dev/internal/plog"
 )
 
 const ErrNotLeader constable.Error = "write attempt rejected as client is not leader"
 
+// LeaseTimings tune the leader election, see leaderelection.LeaderElectionConfig for the meaning of each field.
+// Fields which are zero use the defaults.
+type LeaseTimings struct {
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+}
+
+func (t LeaseTimings) applyTo(config *leaderelection.LeaderElectionConfig) {
+	if t.LeaseDuration != 0 {
+		config.LeaseDuration = t.LeaseDuration
+	}
+	if t.RenewDeadline != 0 {
+		config.RenewDeadline = t.RenewDeadline
+	}
+	if t.RetryPeriod != 0 {
+		config.RetryPeriod = t.RetryPeriod
+	}
+}
+
 // New returns a client that has a leader election middleware injected into it.
 // This middleware will prevent all non-read requests to the Kubernetes API when
 // the current process does not hold the leader election lock.  Unlike normal
@@ -35,26 +58,36 @@ const ErrNotLeader constable.Error = "write attempt rejected as client is not le
 //
 // The returned function is blocking and will run the leader election polling
 // logic and will coordinate lease release with the input controller starter function.
-func New(podInfo *downward.PodInfo, deployment *appsv1.Deployment, opts ...kubeclient.Option) (
+//
+// The returned Status reports whether the current process holds the lock, so that
+// controllers can skip work which only the leader should do.  It is also a health
+// check which fails until the process has observed which process holds the lock,
+// whether it is the current process or another one.  The health check does not
+// depend on whether the current process is the leader, so it can be used as a
+// readiness check by every process.
+func New(podInfo *downward.PodInfo, deployment *appsv1.Deployment, timings LeaseTimings, opts ...kubeclient.Option) (
 	*kubeclient.Client,
 	controllerinit.RunnerWrapper,
+	*Status,
 	error,
 ) {
 	internalClient, err := kubeclient.New(opts...)
 	if err != nil {
-		return nil, nil, fmt.Errorf("could not create internal client for leader election: %w", err)
+		return nil, nil, nil, fmt.Errorf("could not create internal client for leader election: %w", err)
 	}
 
 	isLeader := &isLeaderTracker{tracker: &atomic.Bool{}}
+	leaderObserved := healthcheck.NewLatch("leader-election", "the current leader has not been observed yet")
 
 	identity := podInfo.Name
 	leaseName := deployment.Name
 
-	leaderElectionConfig := newLeaderElectionConfig(podInfo.Namespace, leaseName, identity, internalClient.Kubernetes, isLeader)
+	leaderElectionConfig := newLeaderElectionConfig(podInfo.Namespace, leaseName, identity, internalClient.Kubernetes, isLeader, leaderObserved)
+	timings.applyTo(&leaderElectionConfig)
 
 	// validate our config here before we rely on it being functioning below
 	if _, err := leaderelection.NewLeaderElector(leaderElectionConfig); err != nil {
-		return nil, nil, fmt.Errorf("invalid config - could not create leader elector: %w", err)
+		return nil, nil, nil, fmt.Errorf("invalid config - could not create leader elector: %w", err)
 	}
 
 	writeOnlyWhenLeader := kubeclient.MiddlewareFunc(func(_ context.Context, rt kubeclient.RoundTrip) {
@@ -87,7 +120,7 @@ func New(podInfo *downward.PodInfo, deployment *appsv1.Deployment, opts ...kubec
 
 	client, err := kubeclient.New(leaderElectionOpts...)
 	if err != nil {
-		return nil, nil, fmt.Errorf("could not create leader election client: %w", err)
+		return nil, nil, nil, fmt.Errorf("could not create leader election client: %w", err)
 	}
 
 	controllersWithLeaderElector := func(ctx context.Context, controllers controllerinit.Runner) {
@@ -121,10 +154,36 @@ func New(podInfo *downward.PodInfo, deployment *appsv1.Deployment, opts ...kubec
 		}
 	}
 
-	return client, controllersWithLeaderElector, nil
+	return client, controllersWithLeaderElector, &Status{isLeader: isLeader, leaderObserved: leaderObserved}, nil
+}
+
+// Status reports on the leader election of the current process.
+type Status struct {
+	isLeader       *isLeaderTracker
+	leaderObserved *healthcheck.Latch
+}
+
+var _ healthz.HealthChecker = (*Status)(nil)
+
+// IsLeader returns whether the current process currently holds the lock, i.e. whether its writes are allowed.
+func (s *Status) IsLeader() bool {
+	return s.isLeader.canWrite()
+}
+
+func (s *Status) Name() string {
+	return s.leaderObserved.Name()
+}
+
+func (s *Status) Check(req *http.Request) error {
+	return s.leaderObserved.Check(req)
 }
 
-func newLeaderElectionConfig(namespace, leaseName, identity string, internalClient kubernetes.Interface, isLeader *isLeaderTracker) leaderelection.LeaderElectionConfig {
+func newLeaderElectionConfig(
+	namespace, leaseName, identity string,
+	internalClient kubernetes.Interface,
+	isLeader *isLeaderTracker,
+	leaderObserved *healthcheck.Latch,
+) leaderelection.LeaderElectionConfig {
 	return leaderelection.LeaderElectionConfig{
 		Lock: &releaseLock{
 			delegate: &resourcelock.LeaseLock{
@@ -152,6 +211,7 @@ func newLeaderElectionConfig(namespace, leaseName, identity string, internalClie
 			OnStartedLeading: func(_ context.Context) {
 				plog.Debug("leader gained", "identity", identity)
 				isLeader.start()
+				leaderObserved.Set()
 			},
 			OnStoppedLeading: func() {
 				if isLeader.stop() { // barring changes to client-go, this branch should only be taken on a panic
@@ -159,6 +219,7 @@ func newLeaderElectionConfig(namespace, leaseName, identity string, internalClie
 				}
 			},
 			OnNewLeader: func(newLeader string) {
+				leaderObserved.Set()
 				if newLeader == identity {
 					return
 				}
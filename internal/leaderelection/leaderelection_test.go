@@ -17,6 +17,8 @@ import (
 	kubetesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/leaderelection"
 	"k8s.io/utils/ptr"
+
+	"go.pinniped.dev/internal/healthcheck"
 )
 
 // see test/integration/leaderelection_test.go for the bulk of the testing related to this code
@@ -68,15 +70,38 @@ func Test_releaseLock_Update(t *testing.T) {
 
 			tt.f(t, internalClient, isLeader, cancel)
 
-			leaderElectionConfig := newLeaderElectionConfig("ns-001", "lease-001", "foo-001", internalClient, isLeader)
+			leaderObserved := healthcheck.NewLatch("leader-election", "not observed")
+
+			leaderElectionConfig := newLeaderElectionConfig("ns-001", "lease-001", "foo-001", internalClient, isLeader, leaderObserved)
 
 			// make the tests run quicker
-			leaderElectionConfig.LeaseDuration = 2 * time.Second
-			leaderElectionConfig.RenewDeadline = 1 * time.Second
-			leaderElectionConfig.RetryPeriod = 250 * time.Millisecond
+			LeaseTimings{
+				LeaseDuration: 2 * time.Second,
+				RenewDeadline: 1 * time.Second,
+				RetryPeriod:   250 * time.Millisecond,
+			}.applyTo(&leaderElectionConfig)
 
 			// note that this will block until it exits on its own or tt.f calls cancel()
 			leaderelection.RunOrDie(leaderElectorCtx, leaderElectionConfig)
+
+			// the process acquired the lease before losing it, so it has observed a leader
+			require.NoError(t, leaderObserved.Check(nil))
 		})
 	}
 }
+
+func TestLeaseTimings(t *testing.T) {
+	isLeader := &isLeaderTracker{tracker: &atomic.Bool{}}
+	leaderObserved := healthcheck.NewLatch("leader-election", "not observed")
+
+	leaderElectionConfig := newLeaderElectionConfig("ns-001", "lease-001", "foo-001", kubefake.NewSimpleClientset(), isLeader, leaderObserved)
+	LeaseTimings{}.applyTo(&leaderElectionConfig)
+	require.Equal(t, 137*time.Second, leaderElectionConfig.LeaseDuration)
+	require.Equal(t, 107*time.Second, leaderElectionConfig.RenewDeadline)
+	require.Equal(t, 26*time.Second, leaderElectionConfig.RetryPeriod)
+
+	LeaseTimings{LeaseDuration: time.Minute, RetryPeriod: 10 * time.Second}.applyTo(&leaderElectionConfig)
+	require.Equal(t, time.Minute, leaderElectionConfig.LeaseDuration)
+	require.Equal(t, 107*time.Second, leaderElectionConfig.RenewDeadline)
+	require.Equal(t, 10*time.Second, leaderElectionConfig.RetryPeriod)
+}
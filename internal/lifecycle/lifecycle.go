@@ -0,0 +1,196 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package lifecycle owns the long-running background goroutines of the Supervisor and Concierge, so that
+// they can be shut down in a consistent order when the process is asked to terminate.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apiserver/pkg/server/healthz"
+
+	"go.pinniped.dev/internal/plog"
+)
+
+// defaultWatchdogInterval is how often the names of the components which are blocking shutdown are logged.
+const defaultWatchdogInterval = 5 * time.Second
+
+// Manager starts components in background goroutines and stops them in the reverse order in which they were
+// started, so a component may safely depend on any component which was started before it. Each component has
+// its own deadline for returning after it has been asked to stop, and a watchdog logs the name of any component
+// which is blocking shutdown. The zero value is not usable; use New instead.
+type Manager struct {
+	watchdogInterval time.Duration
+
+	shuttingDown atomic.Bool
+
+	mu         sync.Mutex
+	components []*component
+
+	// failed is closed when a component returns an error before shutdown has started.
+	failed     chan struct{}
+	failedOnce sync.Once
+	failedErr  error
+
+	shutdownOnce sync.Once
+	shutdownErr  error
+}
+
+type component struct {
+	name     string
+	deadline time.Duration
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// New returns a Manager without any components.
+func New() *Manager {
+	return &Manager{
+		watchdogInterval: defaultWatchdogInterval,
+		failed:           make(chan struct{}),
+	}
+}
+
+// Go starts run in a new goroutine as the component called name. The context passed to run is derived from ctx
+// and is cancelled when the component is stopped by Shutdown, after which run must return within the deadline.
+// Returning an error from run before shutdown has started causes Run to shut down all other components.
+// Components cannot be started after shutdown has started.
+func (m *Manager) Go(ctx context.Context, name string, deadline time.Duration, run func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.shuttingDown.Load() {
+		plog.Warning("not starting component because shutdown has already started", "component", name)
+		return
+	}
+
+	componentCtx, cancel := context.WithCancel(ctx)
+	c := &component{name: name, deadline: deadline, cancel: cancel, done: make(chan struct{})}
+	m.components = append(m.components, c)
+
+	go func() {
+		defer close(c.done)
+		defer cancel()
+
+		err := run(componentCtx)
+
+		switch {
+		case m.shuttingDown.Load():
+			plog.Debug("component stopped", "component", name, "err", err)
+		case err != nil:
+			plog.Error("component failed", err, "component", name)
+			m.failedOnce.Do(func() {
+				m.failedErr = fmt.Errorf("component %q failed: %w", name, err)
+				close(m.failed)
+			})
+		default:
+			plog.Debug("component finished", "component", name)
+		}
+	}()
+}
+
+// Run blocks until ctx is cancelled or until a component fails, and then shuts down all components.
+// It returns the error of the failed component, if any, along with any error from Shutdown.
+func (m *Manager) Run(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		plog.Debug("shutdown initiated", "reason", context.Cause(ctx))
+	case <-m.failed:
+		plog.Debug("shutdown initiated", "reason", m.failedErr)
+	}
+
+	shutdownErr := m.Shutdown()
+
+	select {
+	case <-m.failed:
+		return utilerrors.NewAggregate([]error{m.failedErr, shutdownErr})
+	default:
+		return shutdownErr
+	}
+}
+
+// Shutdown stops the components one at a time, in the reverse order in which they were started. A component which
+// does not return within its deadline is abandoned so that the remaining components can still be stopped, and the
+// returned error names every such component. Readiness fails as soon as Shutdown is called. Calling Shutdown more
+// than once has no additional effect.
+func (m *Manager) Shutdown() error {
+	m.shutdownOnce.Do(func() {
+		m.mu.Lock()
+		m.shuttingDown.Store(true)
+		components := m.components
+		m.mu.Unlock()
+
+		var blocked []string
+		for i := len(components) - 1; i >= 0; i-- {
+			if !m.stop(components[i]) {
+				blocked = append(blocked, components[i].name)
+			}
+		}
+
+		if len(blocked) > 0 {
+			m.shutdownErr = fmt.Errorf("components did not stop before their deadlines: %q", blocked)
+		}
+	})
+
+	return m.shutdownErr
+}
+
+// stop cancels the component and waits for it to return, logging while it blocks. It returns false when the
+// component did not return within its deadline.
+func (m *Manager) stop(c *component) bool {
+	start := time.Now()
+	plog.Debug("stopping component", "component", c.name, "deadline", c.deadline)
+
+	c.cancel()
+
+	deadline := time.NewTimer(c.deadline)
+	defer deadline.Stop()
+
+	watchdog := time.NewTicker(m.watchdogInterval)
+	defer watchdog.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			plog.Debug("component stopped", "component", c.name, "duration", time.Since(start))
+			return true
+		case <-watchdog.C:
+			plog.Warning("shutdown is blocked waiting for component to stop",
+				"component", c.name,
+				"waited", time.Since(start).Round(time.Second),
+				"deadline", c.deadline,
+			)
+		case <-deadline.C:
+			plog.Error("component did not stop before its deadline, continuing shutdown without it",
+				errors.New("shutdown deadline exceeded"),
+				"component", c.name,
+				"deadline", c.deadline,
+			)
+			return false
+		}
+	}
+}
+
+// ShuttingDown returns true once Shutdown has been called.
+func (m *Manager) ShuttingDown() bool {
+	return m.shuttingDown.Load()
+}
+
+// ReadyzCheck returns a health check which fails as soon as Shutdown has been called, so that the process
+// is removed from the endpoints of its Service before any of its components are stopped.
+func (m *Manager) ReadyzCheck() healthz.HealthChecker {
+	return healthz.NamedCheck("pinniped-shutdown", func(_ *http.Request) error {
+		if m.ShuttingDown() {
+			return errors.New("shutting down")
+		}
+		return nil
+	})
+}
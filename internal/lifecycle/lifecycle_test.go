@@ -0,0 +1,136 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShutdownStopsComponentsInReverseOrder(t *testing.T) {
+	m := New()
+
+	var mu sync.Mutex
+	var stopped []string
+	for _, name := range []string{"first", "second", "third"} {
+		m.Go(context.Background(), name, time.Second, func(ctx context.Context) error {
+			<-ctx.Done()
+			mu.Lock()
+			defer mu.Unlock()
+			stopped = append(stopped, name)
+			return nil
+		})
+	}
+
+	require.False(t, m.ShuttingDown())
+	require.NoError(t, m.Shutdown())
+	require.True(t, m.ShuttingDown())
+	require.Equal(t, []string{"third", "second", "first"}, stopped)
+
+	// Calling it again has no additional effect.
+	require.NoError(t, m.Shutdown())
+
+	// Components cannot be started after shutdown.
+	m.Go(context.Background(), "too late", time.Second, func(ctx context.Context) error {
+		t.Error("should not have been started")
+		return nil
+	})
+}
+
+func TestShutdownAbandonsComponentsWhichMissTheirDeadline(t *testing.T) {
+	m := New()
+	m.watchdogInterval = 10 * time.Millisecond
+
+	unblock := make(chan struct{})
+	t.Cleanup(func() { close(unblock) })
+
+	firstStopped := make(chan struct{})
+	m.Go(context.Background(), "first", time.Second, func(ctx context.Context) error {
+		defer close(firstStopped)
+		<-ctx.Done()
+		return nil
+	})
+	m.Go(context.Background(), "stuck", 50*time.Millisecond, func(_ context.Context) error {
+		<-unblock // ignores its context
+		return nil
+	})
+
+	require.EqualError(t, m.Shutdown(), `components did not stop before their deadlines: ["stuck"]`)
+
+	// The component which was started before the stuck component was still stopped.
+	select {
+	case <-firstStopped:
+	default:
+		t.Fatal("expected the first component to be stopped")
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Run("shuts down when the context is cancelled", func(t *testing.T) {
+		m := New()
+		stopped := make(chan struct{})
+		m.Go(context.Background(), "some-component", time.Second, func(ctx context.Context) error {
+			defer close(stopped)
+			<-ctx.Done()
+			return errors.New("errors are ignored after shutdown starts")
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.NoError(t, m.Run(ctx))
+		require.True(t, m.ShuttingDown())
+		<-stopped
+	})
+
+	t.Run("shuts down when a component fails", func(t *testing.T) {
+		m := New()
+		stopped := make(chan struct{})
+		m.Go(context.Background(), "healthy-component", time.Second, func(ctx context.Context) error {
+			defer close(stopped)
+			<-ctx.Done()
+			return nil
+		})
+		m.Go(context.Background(), "failing-component", time.Second, func(_ context.Context) error {
+			return errors.New("some error")
+		})
+
+		require.EqualError(t, m.Run(context.Background()), `component "failing-component" failed: some error`)
+		require.True(t, m.ShuttingDown())
+		<-stopped
+	})
+
+	t.Run("components which finish without an error do not cause a shutdown", func(t *testing.T) {
+		m := New()
+		finished := make(chan struct{})
+		m.Go(context.Background(), "short-lived-component", time.Second, func(_ context.Context) error {
+			defer close(finished)
+			return nil
+		})
+		<-finished
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		require.NoError(t, m.Run(ctx))
+		require.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+	})
+}
+
+func TestReadyzCheck(t *testing.T) {
+	m := New()
+	check := m.ReadyzCheck()
+	require.Equal(t, "pinniped-shutdown", check.Name())
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/readyz", nil)
+	require.NoError(t, err)
+
+	require.NoError(t, check.Check(req))
+	require.NoError(t, m.Shutdown())
+	require.EqualError(t, check.Check(req), "shutting down")
+}
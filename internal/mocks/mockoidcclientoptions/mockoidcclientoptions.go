@@ -46,6 +46,20 @@ func (m *MockOIDCClientOptions) EXPECT() *MockOIDCClientOptionsMockRecorder {
 	return m.recorder
 }
 
+// WithCABundles mocks base method.
+func (m *MockOIDCClientOptions) WithCABundles(arg0 bool, arg1 [][]byte) oidcclient.Option {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithCABundles", arg0, arg1)
+	ret0, _ := ret[0].(oidcclient.Option)
+	return ret0
+}
+
+// WithCABundles indicates an expected call of WithCABundles.
+func (mr *MockOIDCClientOptionsMockRecorder) WithCABundles(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithCABundles", reflect.TypeOf((*MockOIDCClientOptions)(nil).WithCABundles), arg0, arg1)
+}
+
 // WithClient mocks base method.
 func (m *MockOIDCClientOptions) WithClient(arg0 *http.Client) oidcclient.Option {
 	m.ctrl.T.Helper()
@@ -60,6 +74,20 @@ func (mr *MockOIDCClientOptionsMockRecorder) WithClient(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithClient", reflect.TypeOf((*MockOIDCClientOptions)(nil).WithClient), arg0)
 }
 
+// WithClientAssertionKeyFile mocks base method.
+func (m *MockOIDCClientOptions) WithClientAssertionKeyFile(arg0 string) oidcclient.Option {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithClientAssertionKeyFile", arg0)
+	ret0, _ := ret[0].(oidcclient.Option)
+	return ret0
+}
+
+// WithClientAssertionKeyFile indicates an expected call of WithClientAssertionKeyFile.
+func (mr *MockOIDCClientOptionsMockRecorder) WithClientAssertionKeyFile(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithClientAssertionKeyFile", reflect.TypeOf((*MockOIDCClientOptions)(nil).WithClientAssertionKeyFile), arg0)
+}
+
 // WithContext mocks base method.
 func (m *MockOIDCClientOptions) WithContext(arg0 context.Context) oidcclient.Option {
 	m.ctrl.T.Helper()
@@ -74,6 +102,34 @@ func (mr *MockOIDCClientOptionsMockRecorder) WithContext(arg0 any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithContext", reflect.TypeOf((*MockOIDCClientOptions)(nil).WithContext), arg0)
 }
 
+// WithDPoP mocks base method.
+func (m *MockOIDCClientOptions) WithDPoP() oidcclient.Option {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithDPoP")
+	ret0, _ := ret[0].(oidcclient.Option)
+	return ret0
+}
+
+// WithDPoP indicates an expected call of WithDPoP.
+func (mr *MockOIDCClientOptionsMockRecorder) WithDPoP() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithDPoP", reflect.TypeOf((*MockOIDCClientOptions)(nil).WithDPoP))
+}
+
+// WithLanguage mocks base method.
+func (m *MockOIDCClientOptions) WithLanguage(arg0 string) oidcclient.Option {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithLanguage", arg0)
+	ret0, _ := ret[0].(oidcclient.Option)
+	return ret0
+}
+
+// WithLanguage indicates an expected call of WithLanguage.
+func (mr *MockOIDCClientOptionsMockRecorder) WithLanguage(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithLanguage", reflect.TypeOf((*MockOIDCClientOptions)(nil).WithLanguage), arg0)
+}
+
 // WithListenPort mocks base method.
 func (m *MockOIDCClientOptions) WithListenPort(arg0 uint16) oidcclient.Option {
 	m.ctrl.T.Helper()
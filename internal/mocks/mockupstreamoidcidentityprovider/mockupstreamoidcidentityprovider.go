@@ -263,6 +263,35 @@ func (mr *MockUpstreamOIDCIdentityProviderIMockRecorder) RevokeToken(arg0, arg1,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*MockUpstreamOIDCIdentityProviderI)(nil).RevokeToken), arg0, arg1, arg2)
 }
 
+// UsesJWTSecuredAuthorizationResponses mocks base method.
+func (m *MockUpstreamOIDCIdentityProviderI) UsesJWTSecuredAuthorizationResponses() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UsesJWTSecuredAuthorizationResponses")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// UsesJWTSecuredAuthorizationResponses indicates an expected call of UsesJWTSecuredAuthorizationResponses.
+func (mr *MockUpstreamOIDCIdentityProviderIMockRecorder) UsesJWTSecuredAuthorizationResponses() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UsesJWTSecuredAuthorizationResponses", reflect.TypeOf((*MockUpstreamOIDCIdentityProviderI)(nil).UsesJWTSecuredAuthorizationResponses))
+}
+
+// ValidateJWTSecuredAuthorizationResponse mocks base method.
+func (m *MockUpstreamOIDCIdentityProviderI) ValidateJWTSecuredAuthorizationResponse(arg0 context.Context, arg1 string) (url.Values, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateJWTSecuredAuthorizationResponse", arg0, arg1)
+	ret0, _ := ret[0].(url.Values)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateJWTSecuredAuthorizationResponse indicates an expected call of ValidateJWTSecuredAuthorizationResponse.
+func (mr *MockUpstreamOIDCIdentityProviderIMockRecorder) ValidateJWTSecuredAuthorizationResponse(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateJWTSecuredAuthorizationResponse", reflect.TypeOf((*MockUpstreamOIDCIdentityProviderI)(nil).ValidateJWTSecuredAuthorizationResponse), arg0, arg1)
+}
+
 // ValidateTokenAndMergeWithUserInfo mocks base method.
 func (m *MockUpstreamOIDCIdentityProviderI) ValidateTokenAndMergeWithUserInfo(arg0 context.Context, arg1 *oauth2.Token, arg2 nonce.Nonce, arg3, arg4 bool) (*oidctypes.Token, error) {
 	m.ctrl.T.Helper()
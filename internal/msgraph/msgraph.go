@@ -0,0 +1,168 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package msgraph looks up the group memberships of Microsoft Entra ID users using the Microsoft Graph API,
+// for when the ID token of a user contains a groups overage indicator instead of the groups claim.
+package msgraph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+const (
+	// DefaultGraphURL is the URL of the Microsoft Graph API in the global cloud.
+	DefaultGraphURL = "https://graph.microsoft.com"
+
+	// DefaultAuthorityURL is the URL of the Microsoft identity platform in the global cloud.
+	DefaultAuthorityURL = "https://login.microsoftonline.com"
+
+	claimNamesClaim = "_claim_names"
+	hasGroupsClaim  = "hasgroups"
+	objectIDClaim   = "oid"
+	tenantIDClaim   = "tid"
+)
+
+// Config holds the settings of a Resolver. Configs are comparable, so callers may keep using a Resolver for
+// as long as its Config does not change.
+type Config struct {
+	GraphURL            string
+	AuthorityURL        string
+	ClientID            string
+	ClientSecret        string
+	SecurityEnabledOnly bool
+}
+
+// Resolver looks up group memberships using the Microsoft Graph API. It caches the access tokens of its app
+// registration per tenant, so the same Resolver should be reused for as long as its Config does not change.
+type Resolver struct {
+	config Config
+	client *http.Client
+
+	mu           sync.Mutex
+	tokenSources map[string]oauth2.TokenSource
+}
+
+func New(config Config, client *http.Client) *Resolver {
+	if config.GraphURL == "" {
+		config.GraphURL = DefaultGraphURL
+	}
+	if config.AuthorityURL == "" {
+		config.AuthorityURL = DefaultAuthorityURL
+	}
+	config.GraphURL = strings.TrimSuffix(config.GraphURL, "/")
+	config.AuthorityURL = strings.TrimSuffix(config.AuthorityURL, "/")
+	return &Resolver{config: config, client: client, tokenSources: map[string]oauth2.TokenSource{}}
+}
+
+// Matches returns true when the Resolver was created with an equivalent Config.
+func (r *Resolver) Matches(config Config) bool {
+	return New(config, nil).config == r.config
+}
+
+// ResolveGroups returns the IDs of the groups of which the user is a member, when the claims of the user contain
+// a groups overage indicator. Otherwise, it returns false to indicate that there was nothing to look up.
+func (r *Resolver) ResolveGroups(ctx context.Context, claims map[string]any) ([]string, bool, error) {
+	if !hasGroupsOverage(claims) {
+		return nil, false, nil
+	}
+
+	objectID, _ := claims[objectIDClaim].(string)
+	tenantID, _ := claims[tenantIDClaim].(string)
+	if objectID == "" || tenantID == "" {
+		return nil, true, fmt.Errorf("claims indicate a groups overage but are missing the %q or %q claim", objectIDClaim, tenantIDClaim)
+	}
+
+	accessToken, err := r.tokenSource(tenantID).Token()
+	if err != nil {
+		return nil, true, fmt.Errorf("could not get access token for Microsoft Graph API: %w", err)
+	}
+
+	groups, err := r.getMemberGroups(ctx, accessToken, objectID)
+	if err != nil {
+		return nil, true, err
+	}
+	return groups, true, nil
+}
+
+func (r *Resolver) getMemberGroups(ctx context.Context, accessToken *oauth2.Token, objectID string) ([]string, error) {
+	body, err := json.Marshal(map[string]bool{"securityEnabledOnly": r.config.SecurityEnabledOnly})
+	if err != nil {
+		return nil, err // should not happen
+	}
+
+	requestURL := fmt.Sprintf("%s/v1.0/users/%s/getMemberGroups", r.config.GraphURL, url.PathEscape(objectID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, requestURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("could not build Microsoft Graph API request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	accessToken.SetAuthHeader(req)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling Microsoft Graph API: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error calling Microsoft Graph API: unexpected response status %q", resp.Status)
+	}
+
+	var memberGroups struct {
+		Value []string `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&memberGroups); err != nil {
+		return nil, fmt.Errorf("error parsing Microsoft Graph API response: %w", err)
+	}
+	if memberGroups.Value == nil {
+		return nil, errors.New("response of Microsoft Graph API is missing the value of the groups")
+	}
+	return memberGroups.Value, nil
+}
+
+// tokenSource returns the cached token source of the app registration in the given tenant, so that access
+// tokens are reused until they expire. Users from several tenants may log in using a multi-tenant app.
+func (r *Resolver) tokenSource(tenantID string) oauth2.TokenSource {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tokenSource, ok := r.tokenSources[tenantID]; ok {
+		return tokenSource
+	}
+
+	config := clientcredentials.Config{
+		ClientID:     r.config.ClientID,
+		ClientSecret: r.config.ClientSecret,
+		TokenURL:     fmt.Sprintf("%s/%s/oauth2/v2.0/token", r.config.AuthorityURL, url.PathEscape(tenantID)),
+		Scopes:       []string{r.config.GraphURL + "/.default"},
+		AuthStyle:    oauth2.AuthStyleInParams,
+	}
+	// The token source outlives any single request, so it must not use the context of a request.
+	tokenSource := config.TokenSource(context.WithValue(context.Background(), oauth2.HTTPClient, r.client))
+	r.tokenSources[tenantID] = tokenSource
+	return tokenSource
+}
+
+// hasGroupsOverage returns true when the claims indicate that the user is a member of too many groups for them
+// to be included in the token. Entra ID uses the "_claim_names" claim in JWTs and the "hasgroups" claim in
+// tokens which are returned in URLs.
+func hasGroupsOverage(claims map[string]any) bool {
+	if claimNames, ok := claims[claimNamesClaim].(map[string]any); ok {
+		if _, ok := claimNames["groups"]; ok {
+			return true
+		}
+	}
+	hasGroups, _ := claims[hasGroupsClaim].(bool)
+	return hasGroups
+}
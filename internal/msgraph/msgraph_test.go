@@ -0,0 +1,176 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package msgraph
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveGroups(t *testing.T) {
+	overageClaims := func() map[string]any {
+		return map[string]any{
+			"oid":          "some-object-id",
+			"tid":          "some-tenant-id",
+			"_claim_names": map[string]any{"groups": "src1"},
+		}
+	}
+
+	tests := []struct {
+		name                    string
+		claims                  map[string]any
+		securityEnabledOnly     bool
+		tokenStatus             int
+		graphStatus             int
+		graphBody               string
+		wantSecurityEnabledOnly bool
+		wantGroups              []string
+		wantResolved            bool
+		wantErr                 string
+	}{
+		{
+			name:   "no overage",
+			claims: map[string]any{"oid": "some-object-id", "tid": "some-tenant-id", "groups": []any{"a"}},
+		},
+		{
+			name:         "overage indicated by _claim_names",
+			claims:       overageClaims(),
+			tokenStatus:  http.StatusOK,
+			graphStatus:  http.StatusOK,
+			graphBody:    `{"value": ["group-1", "group-2"]}`,
+			wantGroups:   []string{"group-1", "group-2"},
+			wantResolved: true,
+		},
+		{
+			name:                    "overage indicated by hasgroups, security groups only",
+			claims:                  map[string]any{"oid": "some-object-id", "tid": "some-tenant-id", "hasgroups": true},
+			securityEnabledOnly:     true,
+			tokenStatus:             http.StatusOK,
+			graphStatus:             http.StatusOK,
+			graphBody:               `{"value": []}`,
+			wantSecurityEnabledOnly: true,
+			wantGroups:              []string{},
+			wantResolved:            true,
+		},
+		{
+			name:         "missing tid claim",
+			claims:       map[string]any{"oid": "some-object-id", "hasgroups": true},
+			wantResolved: true,
+			wantErr:      `claims indicate a groups overage but are missing the "oid" or "tid" claim`,
+		},
+		{
+			name:         "token endpoint returns an error",
+			claims:       overageClaims(),
+			tokenStatus:  http.StatusUnauthorized,
+			wantResolved: true,
+			wantErr:      "could not get access token for Microsoft Graph API: oauth2: cannot fetch token: 401 Unauthorized",
+		},
+		{
+			name:         "graph API returns an error",
+			claims:       overageClaims(),
+			tokenStatus:  http.StatusOK,
+			graphStatus:  http.StatusForbidden,
+			wantResolved: true,
+			wantErr:      `error calling Microsoft Graph API: unexpected response status "403 Forbidden"`,
+		},
+		{
+			name:         "graph API returns invalid JSON",
+			claims:       overageClaims(),
+			tokenStatus:  http.StatusOK,
+			graphStatus:  http.StatusOK,
+			graphBody:    "not json",
+			wantResolved: true,
+			wantErr:      "error parsing Microsoft Graph API response: invalid character 'o' in literal null (expecting 'u')",
+		},
+		{
+			name:         "graph API response is missing the value",
+			claims:       overageClaims(),
+			tokenStatus:  http.StatusOK,
+			graphStatus:  http.StatusOK,
+			graphBody:    `{}`,
+			wantResolved: true,
+			wantErr:      "response of Microsoft Graph API is missing the value of the groups",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var serverURL string
+			tokenRequests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/some-tenant-id/oauth2/v2.0/token":
+					tokenRequests++
+					require.NoError(t, r.ParseForm())
+					require.Equal(t, "client_credentials", r.Form.Get("grant_type"))
+					require.Equal(t, "some-client-id", r.Form.Get("client_id"))
+					require.Equal(t, "some-client-secret", r.Form.Get("client_secret"))
+					require.Equal(t, serverURL+"/.default", r.Form.Get("scope"))
+					if tt.tokenStatus != http.StatusOK {
+						w.WriteHeader(tt.tokenStatus)
+						return
+					}
+					w.Header().Set("Content-Type", "application/json")
+					_, _ = w.Write([]byte(`{"access_token": "some-access-token", "token_type": "Bearer", "expires_in": 3600}`))
+				case "/v1.0/users/some-object-id/getMemberGroups":
+					require.Equal(t, http.MethodPost, r.Method)
+					require.Equal(t, "Bearer some-access-token", r.Header.Get("Authorization"))
+					var body map[string]bool
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+					require.Equal(t, map[string]bool{"securityEnabledOnly": tt.wantSecurityEnabledOnly}, body)
+					w.WriteHeader(tt.graphStatus)
+					_, _ = w.Write([]byte(tt.graphBody))
+				default:
+					t.Errorf("unexpected request to %s", r.URL.Path)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			t.Cleanup(server.Close)
+			serverURL = server.URL
+
+			subject := New(Config{
+				GraphURL:            server.URL + "/",
+				AuthorityURL:        server.URL,
+				ClientID:            "some-client-id",
+				ClientSecret:        "some-client-secret",
+				SecurityEnabledOnly: tt.securityEnabledOnly,
+			}, server.Client())
+
+			groups, resolved, err := subject.ResolveGroups(context.Background(), tt.claims)
+			require.Equal(t, tt.wantResolved, resolved)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				require.Nil(t, groups)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantGroups, groups)
+
+			if tt.wantResolved {
+				// The access token is cached for the tenant.
+				_, _, err = subject.ResolveGroups(context.Background(), tt.claims)
+				require.NoError(t, err)
+				require.Equal(t, 1, tokenRequests)
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	subject := New(Config{ClientID: "some-client-id", ClientSecret: "some-client-secret"}, nil)
+
+	require.True(t, subject.Matches(Config{ClientID: "some-client-id", ClientSecret: "some-client-secret"}))
+	require.True(t, subject.Matches(Config{
+		GraphURL:     "https://graph.microsoft.com/",
+		AuthorityURL: "https://login.microsoftonline.com",
+		ClientID:     "some-client-id",
+		ClientSecret: "some-client-secret",
+	}))
+	require.False(t, subject.Matches(Config{ClientID: "some-client-id", ClientSecret: "other-client-secret"}))
+	require.False(t, subject.Matches(Config{ClientID: "some-client-id", ClientSecret: "some-client-secret", SecurityEnabledOnly: true}))
+}
@@ -1,9 +1,10 @@
-// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// Copyright 2021-2024 the Pinniped contributors. All Rights Reserved.
 // SPDX-License-Identifier: Apache-2.0
 
 package phttp
 
 import (
+	"crypto/tls"
 	"crypto/x509"
 	"net/http"
 	"time"
@@ -14,6 +15,7 @@ import (
 
 	"go.pinniped.dev/internal/crypto/ptls"
 	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/internal/tracing"
 )
 
 func Default(rootCAs *x509.CertPool) *http.Client {
@@ -24,6 +26,18 @@ func Secure(rootCAs *x509.CertPool) *http.Client {
 	return buildClient(ptls.Secure, rootCAs)
 }
 
+// DefaultWithClientCertificate is like Default, but presents the client certificate to servers which request one.
+// A nil client certificate is the same as Default.
+func DefaultWithClientCertificate(rootCAs *x509.CertPool, clientCertificate *tls.Certificate) *http.Client {
+	return buildClient(func(rootCAs *x509.CertPool) *tls.Config {
+		tlsConfig := ptls.Default(rootCAs)
+		if clientCertificate != nil {
+			tlsConfig.Certificates = []tls.Certificate{*clientCertificate}
+		}
+		return tlsConfig
+	}, rootCAs)
+}
+
 func buildClient(tlsConfigFunc ptls.ConfigFunc, rootCAs *x509.CertPool) *http.Client {
 	baseRT := defaultTransport()
 	baseRT.TLSClientConfig = tlsConfigFunc(rootCAs)
@@ -45,5 +59,6 @@ func defaultWrap(rt http.RoundTripper) http.RoundTripper {
 	rt = safeDebugWrappers(rt, transport.DebugWrappers, func() bool { return plog.Enabled(plog.LevelTrace) })
 	rt = transport.NewUserAgentRoundTripper(rest.DefaultKubernetesUserAgent(), rt)
 	rt = warningWrapper(rt, getWarningHandler())
+	rt = tracing.WrapTransport(rt)
 	return rt
 }
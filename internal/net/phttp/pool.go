@@ -0,0 +1,152 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package phttp
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+
+	"go.pinniped.dev/internal/crypto/ptls"
+	"go.pinniped.dev/internal/httputil/roundtripper"
+)
+
+const (
+	connectionNew    = "new"
+	connectionReused = "reused"
+)
+
+// openConnections and requests are served by the /metrics endpoint of the Supervisor's aggregated API server.
+//
+//nolint:gochecknoglobals // Metrics are registered once per process.
+var (
+	openConnections = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      "pinniped",
+			Subsystem:      "upstream_http",
+			Name:           "open_connections",
+			Help:           "Number of open connections in the connection pool of an upstream identity provider, by pool.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"pool"},
+	)
+	requests = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace: "pinniped",
+			Subsystem: "upstream_http",
+			Name:      "requests_total",
+			Help: "Number of requests sent to an upstream identity provider, by pool and by whether the request " +
+				"used a new connection or reused a pooled connection.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"pool", "connection"},
+	)
+)
+
+func init() { //nolint:gochecknoinits // This is the conventional way to register metrics with the legacy registry.
+	legacyregistry.MustRegister(openConnections, requests)
+}
+
+// ConnectionPool configures the connection pool of a client which makes requests to an upstream identity provider.
+// The zero value uses the same settings as Default.
+type ConnectionPool struct {
+	// Name identifies the pool in metrics, e.g. the host of the upstream identity provider.
+	Name string
+
+	// MaxConnsPerHost limits the total number of connections per host, including connections which are in use,
+	// idle, or being dialed. Requests wait for a connection when the limit is reached. Zero means no limit.
+	MaxConnsPerHost int
+
+	// MaxIdleConnsPerHost is the number of idle connections to keep per host. Zero means the default.
+	MaxIdleConnsPerHost int
+
+	// IdleConnTimeout is how long an idle connection is kept before it is closed. Zero means the default.
+	IdleConnTimeout time.Duration
+
+	// Proxy is the outbound proxy to use. Nil means the proxy environment variables.
+	Proxy *Proxy
+
+	// ClientCertificate is presented to servers which request a client certificate. Nil means no client certificate.
+	ClientCertificate *tls.Certificate
+}
+
+// DefaultWithConnectionPool is like Default, but applies the connection pool settings to the transport and
+// records metrics about the connections of the pool. Connections are reused across requests, and HTTP/2 is
+// used whenever the server supports it. Callers should reuse the returned client for as long as possible,
+// since each client has its own pool.
+func DefaultWithConnectionPool(rootCAs *x509.CertPool, pool ConnectionPool) *http.Client {
+	baseRT := defaultTransport()
+	baseRT.TLSClientConfig = ptls.Default(rootCAs)
+	baseRT.ForceAttemptHTTP2 = true
+
+	if pool.MaxConnsPerHost > 0 {
+		baseRT.MaxConnsPerHost = pool.MaxConnsPerHost
+	}
+	if pool.MaxIdleConnsPerHost > 0 {
+		baseRT.MaxIdleConnsPerHost = pool.MaxIdleConnsPerHost
+	}
+	if pool.IdleConnTimeout > 0 {
+		baseRT.IdleConnTimeout = pool.IdleConnTimeout
+	}
+	if pool.Proxy != nil {
+		baseRT.Proxy = pool.Proxy.ProxyFunc()
+	}
+	if pool.ClientCertificate != nil {
+		baseRT.TLSClientConfig.Certificates = []tls.Certificate{*pool.ClientCertificate}
+	}
+
+	dial := baseRT.DialContext
+	gauge := openConnections.WithLabelValues(pool.Name)
+	baseRT.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		gauge.Inc()
+		return &countedConn{Conn: conn, closed: gauge.Dec}, nil
+	}
+
+	return &http.Client{
+		Transport: defaultWrap(connectionMetricsWrapper(baseRT, pool.Name)),
+		Timeout:   3 * time.Hour, // make it impossible for requests to hang indefinitely
+	}
+}
+
+// countedConn calls closed exactly once when the connection is closed.
+type countedConn struct {
+	net.Conn
+	once   sync.Once
+	closed func()
+}
+
+func (c *countedConn) Close() error {
+	c.once.Do(c.closed)
+	return c.Conn.Close()
+}
+
+// connectionMetricsWrapper counts whether each request was sent using a new or a reused connection.
+func connectionMetricsWrapper(rt http.RoundTripper, poolName string) http.RoundTripper {
+	newConn := requests.WithLabelValues(poolName, connectionNew)
+	reusedConn := requests.WithLabelValues(poolName, connectionReused)
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				reusedConn.Inc()
+			} else {
+				newConn.Inc()
+			}
+		},
+	}
+	return roundtripper.WrapFunc(rt, func(req *http.Request) (*http.Response, error) {
+		return rt.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	})
+}
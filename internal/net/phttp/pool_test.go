@@ -0,0 +1,137 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package phttp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/component-base/metrics/testutil"
+
+	"go.pinniped.dev/internal/crypto/ptls"
+	"go.pinniped.dev/internal/testutil/tlsserver"
+)
+
+func TestDefaultWithConnectionPool(t *testing.T) {
+	tests := []struct {
+		name             string
+		pool             ConnectionPool
+		http2            bool
+		wantProtoMajor   int
+		wantMaxConns     int
+		wantMaxIdleConns int
+		wantIdleTimeout  time.Duration
+		wantNewConns     float64
+		wantReusedConns  float64
+	}{
+		{
+			name:             "defaults with HTTP/2 server",
+			pool:             ConnectionPool{Name: "test-defaults-http2"},
+			http2:            true,
+			wantProtoMajor:   2,
+			wantMaxIdleConns: 25,
+			wantIdleTimeout:  90 * time.Second,
+			wantNewConns:     1,
+			wantReusedConns:  2,
+		},
+		{
+			name: "custom caps with HTTP/1.1 server",
+			pool: ConnectionPool{
+				Name:                "test-custom-http1",
+				MaxConnsPerHost:     2,
+				MaxIdleConnsPerHost: 1,
+				IdleConnTimeout:     time.Minute,
+			},
+			wantProtoMajor:   1,
+			wantMaxConns:     2,
+			wantMaxIdleConns: 1,
+			wantIdleTimeout:  time.Minute,
+			wantNewConns:     1,
+			wantReusedConns:  2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, serverCA := tlsserver.TestServerIPv4(t, http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				tlsserver.AssertTLS(t, r, ptls.Default)
+				assertUserAgent(t, r)
+				// use assert instead of require to not break the http.Handler with a panic
+				assert.Equal(t, tt.wantProtoMajor, r.ProtoMajor)
+			}), func(s *httptest.Server) {
+				tlsserver.RecordTLSHello(s)
+				s.EnableHTTP2 = tt.http2
+				if !tt.http2 {
+					s.TLS.NextProtos = []string{"http/1.1"}
+				}
+			})
+
+			rootCAs, err := cert.NewPoolFromBytes(serverCA)
+			require.NoError(t, err)
+
+			c := DefaultWithConnectionPool(rootCAs, tt.pool)
+
+			rt, err := net.TLSClientConfig(c.Transport)
+			require.NoError(t, err)
+			require.Equal(t, rootCAs, rt.RootCAs)
+
+			var baseRT *http.Transport
+			for wrapped := c.Transport; baseRT == nil; {
+				switch r := wrapped.(type) {
+				case *http.Transport:
+					baseRT = r
+				case net.RoundTripperWrapper:
+					wrapped = r.WrappedRoundTripper()
+				default:
+					require.Failf(t, "unexpected round tripper", "%T", r)
+				}
+			}
+			require.Equal(t, tt.wantMaxConns, baseRT.MaxConnsPerHost)
+			require.Equal(t, tt.wantMaxIdleConns, baseRT.MaxIdleConnsPerHost)
+			require.Equal(t, tt.wantIdleTimeout, baseRT.IdleConnTimeout)
+
+			for range 3 {
+				req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+				require.NoError(t, err)
+				resp, err := c.Do(req)
+				require.NoError(t, err)
+				_, err = io.Copy(io.Discard, resp.Body)
+				require.NoError(t, err)
+				require.NoError(t, resp.Body.Close())
+				require.Equal(t, tt.wantProtoMajor, resp.ProtoMajor)
+				if tt.http2 {
+					require.Equal(t, "h2", resp.TLS.NegotiatedProtocol)
+				}
+			}
+
+			requireCounter(t, tt.wantNewConns, tt.pool.Name, connectionNew)
+			requireCounter(t, tt.wantReusedConns, tt.pool.Name, connectionReused)
+
+			open, err := testutil.GetGaugeMetricValue(openConnections.WithLabelValues(tt.pool.Name))
+			require.NoError(t, err)
+			require.Equal(t, float64(1), open)
+
+			baseRT.CloseIdleConnections()
+			require.Eventually(t, func() bool {
+				open, err := testutil.GetGaugeMetricValue(openConnections.WithLabelValues(tt.pool.Name))
+				return err == nil && open == 0
+			}, 10*time.Second, 10*time.Millisecond)
+		})
+	}
+}
+
+func requireCounter(t *testing.T, want float64, labels ...string) {
+	t.Helper()
+
+	got, err := testutil.GetCounterMetricValue(requests.WithLabelValues(labels...))
+	require.NoError(t, err)
+	require.Equal(t, want, got, "for labels %v", labels)
+}
@@ -0,0 +1,189 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package phttp
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+	"golang.org/x/net/proxy"
+
+	"go.pinniped.dev/internal/crypto/ptls"
+)
+
+// Proxy is an outbound proxy which is used instead of the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment
+// variables to connect to an upstream identity provider.
+type Proxy struct {
+	proxyFunc func(*url.URL) (*url.URL, error)
+}
+
+// NewProxy parses the URL of an HTTP, HTTPS or SOCKS5 proxy. The noProxy hosts are connected to directly,
+// using the same syntax as the entries of the NO_PROXY environment variable.
+func NewProxy(proxyURL string, noProxy []string) (*Proxy, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxy URL: unsupported scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL: missing host")
+	}
+
+	return &Proxy{
+		proxyFunc: (&httpproxy.Config{
+			HTTPProxy:  u.String(),
+			HTTPSProxy: u.String(),
+			NoProxy:    strings.Join(noProxy, ","),
+		}).ProxyFunc(),
+	}, nil
+}
+
+// ProxyFunc returns a function which is suitable for use as the Proxy of an http.Transport.
+func (p *Proxy) ProxyFunc() func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		return p.proxyFunc(req.URL)
+	}
+}
+
+// DialContext connects to addr through the proxy, or directly using dialer when addr matches the no-proxy hosts.
+// HTTP and HTTPS proxies are asked to open a tunnel using the CONNECT method.
+func (p *Proxy) DialContext(ctx context.Context, dialer *net.Dialer, network, addr string) (net.Conn, error) {
+	proxyURL, err := p.proxyFunc(&url.URL{Scheme: "https", Host: addr})
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL == nil {
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	if proxyURL.Scheme == "socks5" {
+		socksDialer, err := proxy.FromURL(proxyURL, dialer)
+		if err != nil {
+			return nil, err
+		}
+		contextDialer, ok := socksDialer.(proxy.ContextDialer)
+		if !ok { // should not happen, since the SOCKS5 dialer of golang.org/x/net/proxy supports contexts
+			return nil, fmt.Errorf("SOCKS5 proxy dialer does not support contexts")
+		}
+		return contextDialer.DialContext(ctx, network, addr)
+	}
+
+	return dialConnect(ctx, dialer, proxyURL, addr)
+}
+
+// DialTLSContext is like DialContext, but also performs a TLS handshake with addr using config.
+func (p *Proxy) DialTLSContext(ctx context.Context, dialer *net.Dialer, network, addr string, config *tls.Config) (*tls.Conn, error) {
+	conn, err := p.DialContext(ctx, dialer, network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	config = config.Clone()
+	if config.ServerName == "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		config.ServerName = host
+	}
+
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// dialConnect opens a tunnel to addr through an HTTP or HTTPS proxy, similar to what http.Transport does.
+func dialConnect(ctx context.Context, dialer *net.Dialer, proxyURL *url.URL, addr string) (net.Conn, error) {
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		port := "80"
+		if proxyURL.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), port)
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	if proxyURL.Scheme == "https" {
+		tlsConfig := ptls.Default(nil)
+		tlsConfig.ServerName = proxyURL.Hostname()
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	// Do not let a proxy which never responds hang the caller forever.
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		defer func() { _ = conn.SetDeadline(time.Time{}) }()
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	// The body of a successful response to CONNECT is the tunnel itself, so it must not be closed or drained.
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req) //nolint:bodyclose // see above
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = conn.Close()
+		return nil, fmt.Errorf("proxy %s refused to connect to %s: %s", proxyURL.Redacted(), addr, resp.Status)
+	}
+
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn reads any bytes which were buffered while reading the response of the proxy before reading from
+// the underlying connection.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
@@ -77,15 +77,57 @@ type CustomSessionData struct {
 
 	// Only used when ProviderType == "github".
 	GitHub *GitHubSessionData `json:"github,omitempty"`
+
+	// Only used when ProviderType == "clientcertificate".
+	ClientCertificate *ClientCertificateSessionData `json:"clientcertificate,omitempty"`
+
+	// Only used when ProviderType == "mock".
+	Mock *MockSessionData `json:"mock,omitempty"`
+
+	// Only used when ProviderType == "openshift".
+	OpenShift *OpenShiftSessionData `json:"openshift,omitempty"`
+
+	// SingleSignOnSessionID is the ID of the single sign-on session which started or resumed this session, when
+	// the upstream refresh token of the session is shared with the other sessions of the same single sign-on
+	// session. The latest upstream refresh token is then stored in the single sign-on session instead, because
+	// upstream providers which rotate their refresh tokens invalidate the previous refresh token during each
+	// upstream refresh. Empty when the session does not share its upstream refresh token.
+	SingleSignOnSessionID string `json:"singleSignOnSessionID,omitempty"`
+}
+
+// IDPSpecificSessionData returns the field whose type is specific to the ProviderType, i.e. the opposite of
+// resolvedprovider.FederationDomainResolvedIdentityProvider.ApplyIDPSpecificSessionDataToSession.
+func (s *CustomSessionData) IDPSpecificSessionData() any {
+	switch s.ProviderType {
+	case ProviderTypeOIDC:
+		return s.OIDC
+	case ProviderTypeLDAP:
+		return s.LDAP
+	case ProviderTypeActiveDirectory:
+		return s.ActiveDirectory
+	case ProviderTypeGitHub:
+		return s.GitHub
+	case ProviderTypeClientCertificate:
+		return s.ClientCertificate
+	case ProviderTypeMock:
+		return s.Mock
+	case ProviderTypeOpenShift:
+		return s.OpenShift
+	default:
+		return nil
+	}
 }
 
 type ProviderType string
 
 const (
-	ProviderTypeOIDC            ProviderType = "oidc"
-	ProviderTypeLDAP            ProviderType = "ldap"
-	ProviderTypeActiveDirectory ProviderType = "activedirectory"
-	ProviderTypeGitHub          ProviderType = "github"
+	ProviderTypeOIDC              ProviderType = "oidc"
+	ProviderTypeLDAP              ProviderType = "ldap"
+	ProviderTypeActiveDirectory   ProviderType = "activedirectory"
+	ProviderTypeGitHub            ProviderType = "github"
+	ProviderTypeClientCertificate ProviderType = "clientcertificate"
+	ProviderTypeMock              ProviderType = "mock"
+	ProviderTypeOpenShift         ProviderType = "openshift"
 )
 
 // OIDCSessionData is the additional data needed by Pinniped when the upstream IDP is an OIDC provider.
@@ -153,6 +195,48 @@ func (s *GitHubSessionData) Clone() *GitHubSessionData {
 	return &dataCopy
 }
 
+// ClientCertificateSessionData is the additional data needed by Pinniped when the upstream IDP is a client
+// certificate provider.
+type ClientCertificateSessionData struct {
+	// CertificateFingerprint is the hex-encoded SHA-256 fingerprint of the client certificate which was
+	// presented during the user's initial login.
+	CertificateFingerprint string `json:"certificateFingerprint"`
+
+	// CertificateNotAfter is the expiration time of the client certificate which was presented during the user's
+	// initial login. Refreshes are not allowed after the certificate has expired.
+	CertificateNotAfter time.Time `json:"certificateNotAfter"`
+}
+
+func (s *ClientCertificateSessionData) Clone() *ClientCertificateSessionData {
+	dataCopy := *s // this shortcut works because all fields in this type are currently values (no pointers)
+	return &dataCopy
+}
+
+// MockSessionData is the additional data needed by Pinniped when the upstream IDP is a mock provider.
+type MockSessionData struct {
+	// Username is the username of the static user who logged in, which is used to find the user again
+	// during a refresh.
+	Username string `json:"username"`
+}
+
+func (s *MockSessionData) Clone() *MockSessionData {
+	dataCopy := *s // this shortcut works because all fields in this type are currently values (no pointers)
+	return &dataCopy
+}
+
+// OpenShiftSessionData is the additional data needed by Pinniped when the upstream IDP is an OpenShift provider.
+type OpenShiftSessionData struct {
+	// UpstreamAccessToken is the access token which was issued by the OAuth server of the OpenShift cluster during
+	// the user's initial login. The OAuth server does not issue refresh tokens, so this access token is used to read
+	// the user's identity and groups again during each refresh until it expires.
+	UpstreamAccessToken string `json:"upstreamAccessToken"`
+}
+
+func (s *OpenShiftSessionData) Clone() *OpenShiftSessionData {
+	dataCopy := *s // this shortcut works because all fields in this type are currently strings (no pointers)
+	return &dataCopy
+}
+
 // NewPinnipedSession returns a new empty session.
 func NewPinnipedSession() *PinnipedSession {
 	return &PinnipedSession{
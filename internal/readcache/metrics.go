@@ -0,0 +1,50 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package readcache
+
+import (
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	resultHit      = "hit"
+	resultMiss     = "miss"
+	resultStale    = "stale"
+	resultUnsynced = "unsynced"
+	resultBypass   = "bypass"
+)
+
+// reads is served by the /metrics endpoint of the Supervisor's aggregated API server.
+//
+//nolint:gochecknoglobals // Metrics are registered once per process.
+var reads = metrics.NewCounterVec(
+	&metrics.CounterOpts{
+		Namespace: "pinniped",
+		Subsystem: "supervisor",
+		Name:      "secret_reads_total",
+		Help: "Number of reads of Secrets on the request path, by result: hit when served from the informer cache, " +
+			"miss when the Secret was not in the cache, stale when the cached Secret was older than a known write, " +
+			"unsynced when the cache was not synced yet, and bypass when a specific resource version was requested. " +
+			"All results other than hit were served by a live read.",
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"result"},
+)
+
+// readCounters holds the child of reads for each result, so that each read does not need to allocate to find it.
+//
+//nolint:gochecknoglobals // Metrics are registered once per process.
+var readCounters = map[string]metrics.CounterMetric{}
+
+func init() { //nolint:gochecknoinits // This is the conventional way to register metrics with the legacy registry.
+	legacyregistry.MustRegister(reads)
+	for _, result := range []string{resultHit, resultMiss, resultStale, resultUnsynced, resultBypass} {
+		readCounters[result] = reads.WithLabelValues(result)
+	}
+}
+
+func recordRead(result string) {
+	readCounters[result].Inc()
+}
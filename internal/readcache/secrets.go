@@ -0,0 +1,228 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package readcache provides read facades for the Kubernetes API which serve the hot lookups of the request path
+// from informer caches, instead of making a live GET to the Kubernetes API for each lookup.
+package readcache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/utils/clock"
+)
+
+const (
+	// DefaultLiveReadQPS and DefaultLiveReadBurst limit the live reads which are made when the cache cannot be used.
+	DefaultLiveReadQPS   = 50
+	DefaultLiveReadBurst = 100
+
+	// floorRetention is how long the facade remembers the resource version of its own writes. The informer should
+	// have observed a write long before then, after which the floor is no longer needed.
+	floorRetention = 10 * time.Minute
+	// floorSweepInterval is how often the expired floors are removed, at most.
+	floorSweepInterval = time.Minute
+)
+
+// floor is the minimum resource version which a cached object must have to be served from the cache.
+type floor struct {
+	resourceVersion string // empty when the object was deleted
+	expiresAt       time.Time
+}
+
+type secrets struct {
+	corev1client.SecretInterface // all methods which are not overridden below are live calls
+
+	lister    corev1listers.SecretNamespaceLister
+	hasSynced func() bool
+	limiter   flowcontrol.RateLimiter
+	clock     clock.Clock
+
+	lock      sync.Mutex
+	floors    map[string]floor
+	lastSweep time.Time
+}
+
+var _ corev1client.SecretInterface = (*secrets)(nil)
+
+// NewSecrets returns a corev1client.SecretInterface which serves Get from the lister of an informer, and which makes
+// all other calls using the live client. The lister and the live client must be for the same namespace.
+//
+// Reads fall back to a live GET when the informer has not synced yet, when the Secret is not in the cache, or when
+// the cached Secret is older than the last write to it which was made through the returned interface. The fallback
+// reads are rate limited to protect the Kubernetes API, e.g. from lookups of tokens which do not exist.
+//
+// Writes made by other Supervisor pods are observed once the informer receives them. Callers which must never act
+// on a stale read should make their writes conditional on the resource version of the read (as Update does),
+// so that a stale read results in a conflict error.
+func NewSecrets(
+	live corev1client.SecretInterface,
+	lister corev1listers.SecretNamespaceLister,
+	hasSynced func() bool,
+	limiter flowcontrol.RateLimiter,
+) corev1client.SecretInterface {
+	return newSecrets(live, lister, hasSynced, limiter, clock.RealClock{})
+}
+
+func newSecrets(
+	live corev1client.SecretInterface,
+	lister corev1listers.SecretNamespaceLister,
+	hasSynced func() bool,
+	limiter flowcontrol.RateLimiter,
+	clock clock.Clock,
+) *secrets {
+	return &secrets{
+		SecretInterface: live,
+		lister:          lister,
+		hasSynced:       hasSynced,
+		limiter:         limiter,
+		clock:           clock,
+		floors:          map[string]floor{},
+	}
+}
+
+func (s *secrets) Get(ctx context.Context, name string, opts metav1.GetOptions) (*corev1.Secret, error) {
+	// A specific resource version was requested, so let the Kubernetes API decide how to serve it.
+	if opts.ResourceVersion != "" {
+		return s.liveGet(ctx, name, opts, resultBypass)
+	}
+
+	if !s.hasSynced() {
+		return s.liveGet(ctx, name, opts, resultUnsynced)
+	}
+
+	cached, err := s.lister.Get(name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		// This shouldn't really happen, since listers only return not found errors.
+		return s.liveGet(ctx, name, opts, resultMiss)
+	}
+
+	f, hasFloor := s.getFloor(name)
+
+	switch {
+	case cached == nil && hasFloor && f.resourceVersion == "":
+		// The Secret was deleted through this facade and the informer has observed the delete.
+		s.clearFloor(name)
+		recordRead(resultHit)
+		return nil, err
+	case cached == nil:
+		// The Secret might have been created recently, e.g. by another Supervisor pod, so check the live API.
+		return s.liveGet(ctx, name, opts, resultMiss)
+	case hasFloor && !atLeast(cached.ResourceVersion, f.resourceVersion):
+		// The informer has not yet observed the last write of this Secret which was made through this facade.
+		return s.liveGet(ctx, name, opts, resultStale)
+	}
+
+	if hasFloor {
+		s.clearFloor(name)
+	}
+	recordRead(resultHit)
+	// Objects from the lister are shared with the informer's cache, so callers must not be able to mutate them.
+	return cached.DeepCopy(), nil
+}
+
+func (s *secrets) Create(ctx context.Context, secret *corev1.Secret, opts metav1.CreateOptions) (*corev1.Secret, error) {
+	created, err := s.SecretInterface.Create(ctx, secret, opts)
+	if err == nil {
+		s.setFloor(created.Name, created.ResourceVersion)
+	}
+	return created, err
+}
+
+func (s *secrets) Update(ctx context.Context, secret *corev1.Secret, opts metav1.UpdateOptions) (*corev1.Secret, error) {
+	updated, err := s.SecretInterface.Update(ctx, secret, opts)
+	if err == nil {
+		s.setFloor(updated.Name, updated.ResourceVersion)
+	}
+	return updated, err
+}
+
+func (s *secrets) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*corev1.Secret, error) {
+	patched, err := s.SecretInterface.Patch(ctx, name, pt, data, opts, subresources...)
+	if err == nil {
+		s.setFloor(patched.Name, patched.ResourceVersion)
+	}
+	return patched, err
+}
+
+func (s *secrets) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	err := s.SecretInterface.Delete(ctx, name, opts)
+	if err == nil || apierrors.IsNotFound(err) {
+		s.setFloor(name, "")
+	}
+	return err
+}
+
+func (s *secrets) liveGet(ctx context.Context, name string, opts metav1.GetOptions, result string) (*corev1.Secret, error) {
+	recordRead(result)
+	if err := s.limiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limited live read of secret %q: %w", name, err)
+	}
+	return s.SecretInterface.Get(ctx, name, opts)
+}
+
+func (s *secrets) getFloor(name string) (floor, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	f, ok := s.floors[name]
+	if ok && s.clock.Now().After(f.expiresAt) {
+		delete(s.floors, name)
+		return floor{}, false
+	}
+	return f, ok
+}
+
+func (s *secrets) setFloor(name, resourceVersion string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := s.clock.Now()
+	s.floors[name] = floor{resourceVersion: resourceVersion, expiresAt: now.Add(floorRetention)}
+
+	// Remove the floors of Secrets which were written but never read again, e.g. most access tokens.
+	if now.Sub(s.lastSweep) < floorSweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for n, f := range s.floors {
+		if now.After(f.expiresAt) {
+			delete(s.floors, n)
+		}
+	}
+}
+
+func (s *secrets) clearFloor(name string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.floors, name)
+}
+
+// atLeast returns true when the resource version is the same as or newer than the floor. Resource versions are
+// opaque, but are integers in practice. When either one is not an integer, then only an exact match is known to
+// be fresh enough.
+func atLeast(resourceVersion, floor string) bool {
+	if resourceVersion == floor {
+		return true
+	}
+	rv, err := strconv.ParseUint(resourceVersion, 10, 64)
+	if err != nil {
+		return false
+	}
+	f, err := strconv.ParseUint(floor, 10, 64)
+	if err != nil {
+		return false
+	}
+	return rv >= f
+}
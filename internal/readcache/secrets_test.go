@@ -0,0 +1,266 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package readcache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/component-base/metrics/testutil"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+const namespace = "some-namespace"
+
+func secretWithRV(name, resourceVersion, data string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, ResourceVersion: resourceVersion},
+		Data:       map[string][]byte{"key": []byte(data)},
+	}
+}
+
+type testSecrets struct {
+	*secrets
+	kubeClient *kubefake.Clientset
+	indexer    cache.Indexer
+	synced     bool
+	clock      *clocktesting.FakeClock
+}
+
+func newTestSecrets(t *testing.T, limiter flowcontrol.RateLimiter, live ...*corev1.Secret) *testSecrets {
+	t.Helper()
+
+	kubeClient := kubefake.NewSimpleClientset()
+	for _, s := range live {
+		require.NoError(t, kubeClient.Tracker().Add(s))
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	ts := &testSecrets{
+		kubeClient: kubeClient,
+		indexer:    indexer,
+		synced:     true,
+		clock:      clocktesting.NewFakeClock(time.Now()),
+	}
+	ts.secrets = newSecrets(
+		kubeClient.CoreV1().Secrets(namespace),
+		corev1listers.NewSecretLister(indexer).Secrets(namespace),
+		func() bool { return ts.synced },
+		limiter,
+		ts.clock,
+	)
+	return ts
+}
+
+func (ts *testSecrets) cacheHas(t *testing.T, secret *corev1.Secret) {
+	t.Helper()
+	require.NoError(t, ts.indexer.Update(secret))
+}
+
+func (ts *testSecrets) requireLiveGets(t *testing.T, want int) {
+	t.Helper()
+	gets := 0
+	for _, action := range ts.kubeClient.Actions() {
+		if action.GetVerb() == "get" {
+			gets++
+		}
+	}
+	require.Equal(t, want, gets)
+}
+
+func requireReadsRecorded(t *testing.T, result string, want float64, do func()) {
+	t.Helper()
+	before, err := testutil.GetCounterMetricValue(readCounters[result])
+	require.NoError(t, err)
+	do()
+	after, err := testutil.GetCounterMetricValue(readCounters[result])
+	require.NoError(t, err)
+	require.Equal(t, want, after-before)
+}
+
+func TestGetServesFromCache(t *testing.T) {
+	ts := newTestSecrets(t, flowcontrol.NewFakeAlwaysRateLimiter(), secretWithRV("s", "5", "live"))
+	ts.cacheHas(t, secretWithRV("s", "5", "cached"))
+
+	requireReadsRecorded(t, resultHit, 1, func() {
+		got, err := ts.Get(context.Background(), "s", metav1.GetOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "cached", string(got.Data["key"]))
+
+		// The caller gets a copy which it may mutate without changing the cache.
+		got.Data["key"] = []byte("mutated")
+	})
+	ts.requireLiveGets(t, 0)
+
+	fromCache, err := ts.lister.Get("s")
+	require.NoError(t, err)
+	require.Equal(t, "cached", string(fromCache.Data["key"]))
+}
+
+func TestGetFallsBackToLiveRead(t *testing.T) {
+	tests := []struct {
+		name       string
+		notSynced  bool
+		notCached  bool
+		getOptions metav1.GetOptions
+		wantResult string
+	}{
+		{
+			name:       "secret is not in the cache",
+			notCached:  true,
+			wantResult: resultMiss,
+		},
+		{
+			name:       "cache has not synced",
+			notSynced:  true,
+			wantResult: resultUnsynced,
+		},
+		{
+			name:       "specific resource version was requested",
+			getOptions: metav1.GetOptions{ResourceVersion: "5"},
+			wantResult: resultBypass,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestSecrets(t, flowcontrol.NewFakeAlwaysRateLimiter(), secretWithRV("s", "5", "live"))
+			if !tt.notCached {
+				ts.cacheHas(t, secretWithRV("s", "5", "cached"))
+			}
+			ts.synced = !tt.notSynced
+
+			requireReadsRecorded(t, tt.wantResult, 1, func() {
+				got, err := ts.Get(context.Background(), "s", tt.getOptions)
+				require.NoError(t, err)
+				require.Equal(t, "live", string(got.Data["key"]))
+			})
+			ts.requireLiveGets(t, 1)
+		})
+	}
+}
+
+func TestGetMissForSecretWhichDoesNotExist(t *testing.T) {
+	ts := newTestSecrets(t, flowcontrol.NewFakeAlwaysRateLimiter())
+
+	_, err := ts.Get(context.Background(), "s", metav1.GetOptions{})
+	require.True(t, apierrors.IsNotFound(err))
+	ts.requireLiveGets(t, 1)
+}
+
+func TestGetAfterUpdateThroughFacade(t *testing.T) {
+	ts := newTestSecrets(t, flowcontrol.NewFakeAlwaysRateLimiter(), secretWithRV("s", "5", "old"))
+	ts.cacheHas(t, secretWithRV("s", "5", "old"))
+
+	updated, err := ts.Update(context.Background(), secretWithRV("s", "6", "new"), metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "6", updated.ResourceVersion)
+
+	// The informer has not observed the update yet, so the cached Secret must not be used.
+	requireReadsRecorded(t, resultStale, 1, func() {
+		got, err := ts.Get(context.Background(), "s", metav1.GetOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "new", string(got.Data["key"]))
+	})
+	ts.requireLiveGets(t, 1)
+
+	// Once the informer observes the update, the cached Secret is fresh enough again.
+	ts.cacheHas(t, secretWithRV("s", "6", "new"))
+	requireReadsRecorded(t, resultHit, 1, func() {
+		got, err := ts.Get(context.Background(), "s", metav1.GetOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "new", string(got.Data["key"]))
+	})
+	ts.requireLiveGets(t, 1)
+	require.Empty(t, ts.floors)
+}
+
+func TestGetAfterCreateThroughFacade(t *testing.T) {
+	ts := newTestSecrets(t, flowcontrol.NewFakeAlwaysRateLimiter())
+
+	_, err := ts.Create(context.Background(), secretWithRV("s", "7", "new"), metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// An older cached Secret of the same name, e.g. from before a delete and re-create, is stale.
+	ts.cacheHas(t, secretWithRV("s", "3", "old"))
+	requireReadsRecorded(t, resultStale, 1, func() {
+		got, err := ts.Get(context.Background(), "s", metav1.GetOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "new", string(got.Data["key"]))
+	})
+}
+
+func TestGetAfterDeleteThroughFacade(t *testing.T) {
+	ts := newTestSecrets(t, flowcontrol.NewFakeAlwaysRateLimiter(), secretWithRV("s", "5", "live"))
+	ts.cacheHas(t, secretWithRV("s", "5", "cached"))
+
+	require.NoError(t, ts.Delete(context.Background(), "s", metav1.DeleteOptions{}))
+
+	// The informer has not observed the delete yet, so the cached Secret must not be used.
+	requireReadsRecorded(t, resultStale, 1, func() {
+		_, err := ts.Get(context.Background(), "s", metav1.GetOptions{})
+		require.True(t, apierrors.IsNotFound(err))
+	})
+	ts.requireLiveGets(t, 1)
+
+	// Once the informer observes the delete, the absence of the Secret can be served from the cache.
+	require.NoError(t, ts.indexer.Delete(secretWithRV("s", "5", "cached")))
+	requireReadsRecorded(t, resultHit, 1, func() {
+		_, err := ts.Get(context.Background(), "s", metav1.GetOptions{})
+		require.True(t, apierrors.IsNotFound(err))
+	})
+	ts.requireLiveGets(t, 1)
+	require.Empty(t, ts.floors)
+}
+
+func TestFloorsExpire(t *testing.T) {
+	ts := newTestSecrets(t, flowcontrol.NewFakeAlwaysRateLimiter(), secretWithRV("a", "5", "old"), secretWithRV("b", "5", "old"))
+	ts.cacheHas(t, secretWithRV("a", "5", "old"))
+
+	_, err := ts.Update(context.Background(), secretWithRV("a", "6", "new"), metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.Len(t, ts.floors, 1)
+
+	// A floor is no longer used after its retention, since the informer should have observed the write by then.
+	ts.clock.Step(floorRetention + time.Second)
+	got, err := ts.Get(context.Background(), "a", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "old", string(got.Data["key"]))
+	ts.requireLiveGets(t, 0)
+	require.Empty(t, ts.floors)
+
+	// Expired floors of Secrets which are never read again are swept during later writes.
+	_, err = ts.Update(context.Background(), secretWithRV("a", "7", "newer"), metav1.UpdateOptions{})
+	require.NoError(t, err)
+	ts.clock.Step(floorRetention + time.Second)
+	_, err = ts.Update(context.Background(), secretWithRV("b", "8", "new"), metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.Len(t, ts.floors, 1)
+	require.Contains(t, ts.floors, "b")
+}
+
+func TestLiveReadsAreRateLimited(t *testing.T) {
+	ts := newTestSecrets(t, flowcontrol.NewFakeNeverRateLimiter(), secretWithRV("s", "5", "live"))
+
+	_, err := ts.Get(context.Background(), "s", metav1.GetOptions{})
+	require.EqualError(t, err, `rate limited live read of secret "s": can not be accept`)
+	ts.requireLiveGets(t, 0)
+}
+
+func TestAtLeast(t *testing.T) {
+	require.True(t, atLeast("5", "5"))
+	require.True(t, atLeast("6", "5"))
+	require.False(t, atLeast("4", "5"))
+	require.False(t, atLeast("10", ""))
+	require.True(t, atLeast("opaque", "opaque"))
+	require.False(t, atLeast("opaque", "other"))
+	require.False(t, atLeast("5", "opaque"))
+}
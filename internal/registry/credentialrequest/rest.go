@@ -20,21 +20,46 @@ import (
 	"k8s.io/apiserver/pkg/registry/rest"
 	"k8s.io/utils/trace"
 
+	conciergeconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/config/v1alpha1"
 	loginapi "go.pinniped.dev/generated/latest/apis/concierge/login"
 	"go.pinniped.dev/internal/clientcertissuer"
+	"go.pinniped.dev/internal/constable"
+	"go.pinniped.dev/internal/serviceaccounttokenissuer"
+	"go.pinniped.dev/internal/tracing"
 )
 
 // clientCertificateTTL is the TTL for short-lived client certificates returned by this API.
 const clientCertificateTTL = 5 * time.Minute
 
+// serviceAccountTokenTTL is the TTL for short-lived ServiceAccount tokens returned by this API.
+// This is the shortest TTL allowed by the Kubernetes TokenRequest API.
+const serviceAccountTokenTTL = serviceaccounttokenissuer.MinimumTTL
+
+const errNoServiceAccountTokenIssuer = constable.Error("no service account token issuer is configured")
+
 type TokenCredentialRequestAuthenticator interface {
 	AuthenticateTokenCredentialRequest(ctx context.Context, req *loginapi.TokenCredentialRequest) (user.Info, error)
 }
 
-func NewREST(authenticator TokenCredentialRequestAuthenticator, issuer clientcertissuer.ClientCertIssuer, resource schema.GroupResource) *REST {
+// CredentialTypeProvider provides the currently configured type of credential which should be returned.
+type CredentialTypeProvider interface {
+	CredentialType() conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialType
+}
+
+// NewREST returns the storage of the TokenCredentialRequest API. When credentialType is nil, or when it provides
+// no credential type, client certificates are issued.
+func NewREST(
+	authenticator TokenCredentialRequestAuthenticator,
+	issuer clientcertissuer.ClientCertIssuer,
+	tokenIssuer serviceaccounttokenissuer.ServiceAccountTokenIssuer,
+	credentialType CredentialTypeProvider,
+	resource schema.GroupResource,
+) *REST {
 	return &REST{
 		authenticator:  authenticator,
 		issuer:         issuer,
+		tokenIssuer:    tokenIssuer,
+		credentialType: credentialType,
 		tableConvertor: rest.NewDefaultTableConvertor(resource),
 	}
 }
@@ -42,6 +67,8 @@ func NewREST(authenticator TokenCredentialRequestAuthenticator, issuer clientcer
 type REST struct {
 	authenticator  TokenCredentialRequestAuthenticator
 	issuer         clientcertissuer.ClientCertIssuer
+	tokenIssuer    serviceaccounttokenissuer.ServiceAccountTokenIssuer
+	credentialType CredentialTypeProvider
 	tableConvertor rest.TableConvertor
 }
 
@@ -103,7 +130,11 @@ func (r *REST) Create(ctx context.Context, obj runtime.Object, createValidation
 		return nil, err
 	}
 
-	userInfo, err := r.authenticator.AuthenticateTokenCredentialRequest(ctx, credentialRequest)
+	authenticator := credentialRequest.Spec.Authenticator
+	authCtx, span := tracing.Start(ctx, "authenticate token",
+		tracing.AttributeAuthenticator.String(authenticator.Kind+"/"+authenticator.Name))
+	userInfo, err := r.authenticator.AuthenticateTokenCredentialRequest(authCtx, credentialRequest)
+	tracing.EndWithError(span, err)
 	if err != nil {
 		traceFailureWithError(t, "token authentication", err)
 		return failureResponse(), nil
@@ -113,27 +144,63 @@ func (r *REST) Create(ctx context.Context, obj runtime.Object, createValidation
 		return failureResponse(), nil
 	}
 
-	// this timestamp should be returned from IssueClientCertPEM but this is a safe approximation
-	expires := metav1.NewTime(time.Now().UTC().Add(clientCertificateTTL))
-	certPEM, keyPEM, err := r.issuer.IssueClientCertPEM(userInfo.GetName(), userInfo.GetGroups(), clientCertificateTTL)
-	if err != nil {
-		traceFailureWithError(t, "cert issuer", err)
-		return failureResponse(), nil
+	var credential *loginapi.ClusterCredential
+	if r.useServiceAccountTokens() {
+		credential, err = r.issueServiceAccountToken(ctx, userInfo)
+		if err != nil {
+			traceFailureWithError(t, "service account token issuer", err)
+			return failureResponse(), nil
+		}
+	} else {
+		credential, err = r.issueClientCert(userInfo)
+		if err != nil {
+			traceFailureWithError(t, "cert issuer", err)
+			return failureResponse(), nil
+		}
 	}
 
 	traceSuccess(t, userInfo, true)
 
 	return &loginapi.TokenCredentialRequest{
 		Status: loginapi.TokenCredentialRequestStatus{
-			Credential: &loginapi.ClusterCredential{
-				ExpirationTimestamp:   expires,
-				ClientCertificateData: string(certPEM),
-				ClientKeyData:         string(keyPEM),
-			},
+			Credential: credential,
 		},
 	}, nil
 }
 
+func (r *REST) useServiceAccountTokens() bool {
+	return r.credentialType != nil &&
+		r.credentialType.CredentialType() == conciergeconfigv1alpha1.TokenCredentialRequestAPICredentialTypeServiceAccountToken
+}
+
+func (r *REST) issueClientCert(userInfo user.Info) (*loginapi.ClusterCredential, error) {
+	// this timestamp should be returned from IssueClientCertPEM but this is a safe approximation
+	expires := metav1.NewTime(time.Now().UTC().Add(clientCertificateTTL))
+	certPEM, keyPEM, err := r.issuer.IssueClientCertPEM(userInfo.GetName(), userInfo.GetGroups(), clientCertificateTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &loginapi.ClusterCredential{
+		ExpirationTimestamp:   expires,
+		ClientCertificateData: string(certPEM),
+		ClientKeyData:         string(keyPEM),
+	}, nil
+}
+
+func (r *REST) issueServiceAccountToken(ctx context.Context, userInfo user.Info) (*loginapi.ClusterCredential, error) {
+	if r.tokenIssuer == nil {
+		return nil, errNoServiceAccountTokenIssuer
+	}
+	token, expires, err := r.tokenIssuer.IssueServiceAccountToken(ctx, userInfo.GetName(), userInfo.GetGroups(), serviceAccountTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &loginapi.ClusterCredential{
+		ExpirationTimestamp: metav1.NewTime(expires.UTC()),
+		Token:               token,
+	}, nil
+}
+
 func validateRequest(ctx context.Context, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions, t *trace.Trace) (*loginapi.TokenCredentialRequest, error) {
 	credentialRequest, ok := obj.(*loginapi.TokenCredentialRequest)
 	if !ok {
@@ -182,8 +249,8 @@ func isUserInfoValid(userInfo user.Info) bool {
 	switch {
 	case userInfo == nil, // must be non-nil
 		len(userInfo.GetName()) == 0,  // must have a username, groups are optional
-		len(userInfo.GetUID()) != 0,   // certs cannot assert UID
-		len(userInfo.GetExtra()) != 0: // certs cannot assert extra
+		len(userInfo.GetUID()) != 0,   // certs and service account tokens cannot assert UID
+		len(userInfo.GetExtra()) != 0: // certs and service account tokens cannot assert extra
 		return false
 
 	default:
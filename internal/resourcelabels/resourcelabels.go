@@ -0,0 +1,53 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package resourcelabels adds labels to the objects which Pinniped creates, so that they are selected by the
+// label selectors of Pinniped's own informers.
+package resourcelabels
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"go.pinniped.dev/internal/kubeclient"
+)
+
+// ParseSelector parses a label selector which only uses equality-based requirements, e.g. "a=b,c=d".
+// It returns the labels which every selected object has. An empty selector selects everything.
+func ParseSelector(selector string) (labels.Set, error) {
+	return labels.ConvertSelectorToLabelsMap(selector)
+}
+
+// New returns a middleware which adds the labels to the objects of the resource when they are created.
+// The labels take precedence over any other values of the same keys, since objects without them would be
+// invisible to the informers.
+func New(resource schema.GroupResource, labelsToAdd labels.Set) kubeclient.Middleware {
+	return kubeclient.MiddlewareFunc(func(_ context.Context, rt kubeclient.RoundTrip) {
+		if len(labelsToAdd) == 0 {
+			return
+		}
+
+		// only objects that we create are labeled
+		if rt.Verb() != kubeclient.VerbCreate {
+			return
+		}
+
+		if len(rt.Subresource()) != 0 || rt.Resource().GroupResource() != resource {
+			return
+		}
+
+		rt.MutateRequest(func(obj kubeclient.Object) error {
+			objLabels := obj.GetLabels()
+			if objLabels == nil {
+				objLabels = make(map[string]string, len(labelsToAdd))
+			}
+			for key, value := range labelsToAdd {
+				objLabels[key] = value
+			}
+			obj.SetLabels(objLabels)
+			return nil
+		})
+	})
+}
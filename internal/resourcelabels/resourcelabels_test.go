@@ -0,0 +1,143 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package resourcelabels
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"go.pinniped.dev/internal/kubeclient"
+	"go.pinniped.dev/internal/testutil"
+)
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+		want     labels.Set
+		wantErr  string
+	}{
+		{
+			name:     "empty",
+			selector: "",
+			want:     labels.Set{},
+		},
+		{
+			name:     "equality-based requirements",
+			selector: "pinniped.dev/managed=true, app=pinniped",
+			want:     labels.Set{"pinniped.dev/managed": "true", "app": "pinniped"},
+		},
+		{
+			name:     "set-based requirement",
+			selector: "app in (pinniped)",
+			wantErr:  "invalid selector: [app in (pinniped)]",
+		},
+		{
+			name:     "inequality requirement",
+			selector: "app!=pinniped",
+			wantErr:  `Invalid value: "app!"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSelector(tt.selector)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResourceLabelsMiddleware(t *testing.T) {
+	secretsGVR := corev1.SchemeGroupVersion.WithResource("secrets")
+	configMapsGVR := corev1.SchemeGroupVersion.WithResource("configmaps")
+	labelsToAdd := labels.Set{"pinniped.dev/managed": "true"}
+
+	tests := []struct {
+		name        string
+		labels      labels.Set
+		verb        kubeclient.Verb
+		gvr         schema.GroupVersionResource
+		subresource string
+		obj         kubeclient.Object
+		wantHandles bool
+		wantLabels  map[string]string
+	}{
+		{
+			name:        "on create of a secret",
+			labels:      labelsToAdd,
+			verb:        kubeclient.VerbCreate,
+			gvr:         secretsGVR,
+			obj:         &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "some-secret"}},
+			wantHandles: true,
+			wantLabels:  map[string]string{"pinniped.dev/managed": "true"},
+		},
+		{
+			name:        "on create of a secret with other labels",
+			labels:      labelsToAdd,
+			verb:        kubeclient.VerbCreate,
+			gvr:         secretsGVR,
+			obj:         &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "some-secret", Labels: map[string]string{"app": "pinniped", "pinniped.dev/managed": "false"}}},
+			wantHandles: true,
+			wantLabels:  map[string]string{"app": "pinniped", "pinniped.dev/managed": "true"},
+		},
+		{
+			name:   "on create of another resource",
+			labels: labelsToAdd,
+			verb:   kubeclient.VerbCreate,
+			gvr:    configMapsGVR,
+			obj:    &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "some-config-map"}},
+		},
+		{
+			name:        "on create of a subresource",
+			labels:      labelsToAdd,
+			verb:        kubeclient.VerbCreate,
+			gvr:         secretsGVR,
+			subresource: "status",
+			obj:         &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "some-secret"}},
+		},
+		{
+			name:   "on update",
+			labels: labelsToAdd,
+			verb:   kubeclient.VerbUpdate,
+			gvr:    secretsGVR,
+			obj:    &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "some-secret"}},
+		},
+		{
+			name: "without labels",
+			verb: kubeclient.VerbCreate,
+			gvr:  secretsGVR,
+			obj:  &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "some-secret"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			middleware := New(secretsGVR.GroupResource(), tt.labels)
+			rt := (&testutil.RoundTrip{}).
+				WithVerb(tt.verb).
+				WithNamespace("some-namespace").
+				WithResource(tt.gvr).
+				WithSubresource(tt.subresource)
+			middleware.Handle(context.Background(), rt)
+			require.Empty(t, rt.MutateResponses)
+			if !tt.wantHandles {
+				require.Empty(t, rt.MutateRequests)
+				return
+			}
+			require.Len(t, rt.MutateRequests, 1)
+
+			require.NoError(t, rt.MutateRequests[0](tt.obj))
+			require.Equal(t, tt.wantLabels, tt.obj.GetLabels())
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package serviceaccounttokenissuer issues short-lived bound ServiceAccount tokens on behalf of users, for clusters
+// where the Kubernetes API server does not accept client certificates.
+package serviceaccounttokenissuer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+const (
+	// UsernameAnnotation is the annotation of a per-user ServiceAccount which records the username of its user.
+	UsernameAnnotation = "credentialissuer.pinniped.dev/username"
+
+	// GroupsAnnotation is the annotation of a per-user ServiceAccount which records the groups of its user,
+	// as of the last time that a token was issued, separated by commas.
+	GroupsAnnotation = "credentialissuer.pinniped.dev/groups"
+
+	// MinimumTTL is the shortest lifetime that the Kubernetes TokenRequest API allows for a token.
+	MinimumTTL = 10 * time.Minute
+
+	serviceAccountNamePrefix = "pinniped-user-"
+)
+
+// ServiceAccountTokenIssuer issues tokens which the Kubernetes API server accepts for a user.
+type ServiceAccountTokenIssuer interface {
+	IssueServiceAccountToken(ctx context.Context, username string, groups []string, ttl time.Duration) (token string, expiresAt time.Time, err error)
+}
+
+type issuer struct {
+	serviceAccounts corev1client.ServiceAccountInterface
+	labels          map[string]string
+}
+
+var _ ServiceAccountTokenIssuer = (*issuer)(nil)
+
+// New returns a ServiceAccountTokenIssuer which creates a ServiceAccount for each user in the namespace of the
+// provided client, and requests short-lived tokens for that ServiceAccount using the TokenRequest API.
+//
+// Note that the Kubernetes API server authenticates the holder of such a token as the ServiceAccount, not as the
+// user, so RBAC policies must grant permissions to the per-user ServiceAccounts. The ServiceAccounts are annotated
+// with the username and groups of their user to make that possible.
+func New(serviceAccounts corev1client.ServiceAccountInterface, labels map[string]string) ServiceAccountTokenIssuer {
+	return &issuer{
+		serviceAccounts: serviceAccounts,
+		labels:          labels,
+	}
+}
+
+// ServiceAccountName returns the name of the ServiceAccount of a user. Usernames can contain characters which are
+// not allowed in the names of Kubernetes objects, so the name is derived from a hash of the username.
+func ServiceAccountName(username string) string {
+	hash := sha256.Sum256([]byte(username))
+	return serviceAccountNamePrefix + hex.EncodeToString(hash[:16])
+}
+
+func (i *issuer) IssueServiceAccountToken(ctx context.Context, username string, groups []string, ttl time.Duration) (string, time.Time, error) {
+	if ttl < MinimumTTL {
+		ttl = MinimumTTL
+	}
+
+	name, err := i.ensureServiceAccount(ctx, username, groups)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	expirationSeconds := int64(ttl.Seconds())
+	tokenRequest, err := i.serviceAccounts.CreateToken(ctx, name, &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			ExpirationSeconds: &expirationSeconds,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("could not create token for ServiceAccount %q: %w", name, err)
+	}
+
+	return tokenRequest.Status.Token, tokenRequest.Status.ExpirationTimestamp.Time, nil
+}
+
+func (i *issuer) ensureServiceAccount(ctx context.Context, username string, groups []string) (string, error) {
+	name := ServiceAccountName(username)
+	annotations := map[string]string{
+		UsernameAnnotation: username,
+		GroupsAnnotation:   strings.Join(groups, ","),
+	}
+
+	serviceAccount, err := i.serviceAccounts.Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err = i.serviceAccounts.Create(ctx, &corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Labels:      i.labels,
+				Annotations: annotations,
+			},
+		}, metav1.CreateOptions{})
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			return "", fmt.Errorf("could not create ServiceAccount %q: %w", name, err)
+		}
+		return name, nil
+
+	case err != nil:
+		return "", fmt.Errorf("could not get ServiceAccount %q: %w", name, err)
+	}
+
+	if serviceAccount.Annotations[UsernameAnnotation] != username {
+		// Practically impossible, but never issue a token for a ServiceAccount of a different user.
+		return "", fmt.Errorf("ServiceAccount %q does not belong to the user", name)
+	}
+
+	if serviceAccount.Annotations[GroupsAnnotation] != annotations[GroupsAnnotation] {
+		updated := serviceAccount.DeepCopy()
+		updated.Annotations = maps.Clone(updated.Annotations)
+		maps.Copy(updated.Annotations, annotations)
+		if _, err := i.serviceAccounts.Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+			return "", fmt.Errorf("could not update ServiceAccount %q: %w", name, err)
+		}
+	}
+
+	return name, nil
+}
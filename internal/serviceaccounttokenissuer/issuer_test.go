@@ -0,0 +1,148 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package serviceaccounttokenissuer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	coretesting "k8s.io/client-go/testing"
+)
+
+func TestServiceAccountName(t *testing.T) {
+	name := ServiceAccountName("some-user@example.com")
+	require.Regexp(t, `^pinniped-user-[0-9a-f]{32}$`, name)
+	require.Equal(t, name, ServiceAccountName("some-user@example.com"))
+	require.NotEqual(t, name, ServiceAccountName("some-other-user@example.com"))
+}
+
+func TestIssueServiceAccountToken(t *testing.T) {
+	const namespace = "concierge"
+
+	expiresAt := time.Date(2030, time.January, 1, 0, 10, 0, 0, time.UTC)
+	serviceAccountName := ServiceAccountName("some-user")
+	labels := map[string]string{"app": "concierge"}
+
+	tests := []struct {
+		name                  string
+		objects               []runtime.Object
+		ttl                   time.Duration
+		tokenErr              error
+		wantExpirationSeconds int64
+		wantErr               string
+		wantAnnotations       map[string]string
+	}{
+		{
+			name:                  "creates the ServiceAccount of the user",
+			ttl:                   20 * time.Minute,
+			wantExpirationSeconds: 1200,
+			wantAnnotations: map[string]string{
+				UsernameAnnotation: "some-user",
+				GroupsAnnotation:   "group1,group2",
+			},
+		},
+		{
+			name:                  "requests at least the minimum lifetime",
+			ttl:                   5 * time.Minute,
+			wantExpirationSeconds: 600,
+			wantAnnotations: map[string]string{
+				UsernameAnnotation: "some-user",
+				GroupsAnnotation:   "group1,group2",
+			},
+		},
+		{
+			name: "updates the groups of an existing ServiceAccount",
+			objects: []runtime.Object{
+				&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{
+					Name:      serviceAccountName,
+					Namespace: namespace,
+					Labels:    labels,
+					Annotations: map[string]string{
+						UsernameAnnotation: "some-user",
+						GroupsAnnotation:   "old-group",
+						"some-other":       "annotation",
+					},
+				}},
+			},
+			ttl:                   10 * time.Minute,
+			wantExpirationSeconds: 600,
+			wantAnnotations: map[string]string{
+				UsernameAnnotation: "some-user",
+				GroupsAnnotation:   "group1,group2",
+				"some-other":       "annotation",
+			},
+		},
+		{
+			name: "refuses to use a ServiceAccount of another user",
+			objects: []runtime.Object{
+				&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{
+					Name:        serviceAccountName,
+					Namespace:   namespace,
+					Annotations: map[string]string{UsernameAnnotation: "some-other-user"},
+				}},
+			},
+			ttl:     10 * time.Minute,
+			wantErr: `ServiceAccount "` + serviceAccountName + `" does not belong to the user`,
+			wantAnnotations: map[string]string{
+				UsernameAnnotation: "some-other-user",
+			},
+		},
+		{
+			name:                  "token request fails",
+			ttl:                   10 * time.Minute,
+			tokenErr:              errors.New("some token error"),
+			wantExpirationSeconds: 600,
+			wantErr:               `could not create token for ServiceAccount "` + serviceAccountName + `": some token error`,
+			wantAnnotations: map[string]string{
+				UsernameAnnotation: "some-user",
+				GroupsAnnotation:   "group1,group2",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(tt.objects...)
+			client.PrependReactor("create", "serviceaccounts/token", func(action coretesting.Action) (bool, runtime.Object, error) {
+				require.Equal(t, serviceAccountName, action.(coretesting.CreateActionImpl).Name)
+				tokenRequest := action.(coretesting.CreateAction).GetObject().(*authenticationv1.TokenRequest)
+				require.Equal(t, tt.wantExpirationSeconds, *tokenRequest.Spec.ExpirationSeconds)
+				if tt.tokenErr != nil {
+					return true, nil, tt.tokenErr
+				}
+				return true, &authenticationv1.TokenRequest{
+					Status: authenticationv1.TokenRequestStatus{
+						Token:               "some-token",
+						ExpirationTimestamp: metav1.NewTime(expiresAt),
+					},
+				}, nil
+			})
+
+			subject := New(client.CoreV1().ServiceAccounts(namespace), labels)
+			token, gotExpiresAt, err := subject.IssueServiceAccountToken(context.Background(), "some-user", []string{"group1", "group2"}, tt.ttl)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				require.Empty(t, token)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, "some-token", token)
+				require.Equal(t, expiresAt, gotExpiresAt.UTC())
+			}
+
+			serviceAccount, err := client.CoreV1().ServiceAccounts(namespace).Get(context.Background(), serviceAccountName, metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, tt.wantAnnotations, serviceAccount.Annotations)
+			if len(tt.objects) == 0 {
+				require.Equal(t, labels, serviceAccount.Labels)
+			}
+		})
+	}
+}
@@ -7,7 +7,7 @@ import (
 	"context"
 	"crypto/rand"
 	"fmt"
-	"sync"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -20,11 +20,15 @@ import (
 
 	configv1alpha1clientset "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/typed/config/v1alpha1"
 	"go.pinniped.dev/internal/controllerinit"
+	"go.pinniped.dev/internal/lifecycle"
 	"go.pinniped.dev/internal/plog"
 	"go.pinniped.dev/internal/pversion"
 	"go.pinniped.dev/internal/registry/clientsecretrequest"
 )
 
+// controllersShutdownDeadline is how long the controllers may take to stop before shutdown continues without them.
+const controllersShutdownDeadline = time.Minute
+
 type Config struct {
 	GenericConfig *genericapiserver.RecommendedConfig
 	ExtraConfig   ExtraConfig
@@ -113,7 +117,8 @@ func (c completedConfig) New() (*PinnipedServer, error) {
 		return nil, fmt.Errorf("could not install API groups: %w", err)
 	}
 
-	controllersShutdownWaitGroup := &sync.WaitGroup{}
+	// The informers of the controllers use controllersCtx, so it is cancelled only after the controllers have stopped.
+	controllers := lifecycle.New()
 	controllersCtx, cancelControllerCtx := context.WithCancel(context.Background())
 
 	s.GenericAPIServer.AddPostStartHookOrDie("start-controllers",
@@ -126,15 +131,12 @@ func (c completedConfig) New() (*PinnipedServer, error) {
 				return fmt.Errorf("cannot create run controller func: %w", err)
 			}
 
-			controllersShutdownWaitGroup.Add(1)
-			go func() {
-				// When this goroutine ends, then also end the WaitGroup, allowing anyone who called Wait() to proceed.
-				defer controllersShutdownWaitGroup.Done()
-
+			controllers.Go(controllersCtx, "controllers", controllersShutdownDeadline, func(ctx context.Context) error {
 				// Start the controllers and block until their context is cancelled and they have shut down.
-				runControllers(controllersCtx)
+				runControllers(ctx)
 				plog.Debug("start-controllers post start hook's background goroutine saw runControllers() finish")
-			}()
+				return nil
+			})
 
 			return nil
 		},
@@ -146,14 +148,15 @@ func (c completedConfig) New() (*PinnipedServer, error) {
 			defer plog.Debug("stop-controllers pre shutdown hook completed")
 
 			// The generic api server is telling us that it wants to shut down, so tell our controllers that we
-			// want them to shut down by cancelling their context.
-			cancelControllerCtx()
+			// want them to shut down and wait for them to finish shutting down. By blocking here, we prevent the
+			// generic api server's graceful shutdown process from continuing until we are finished shutting down
+			// our own controllers.
+			err := controllers.Shutdown()
 
-			// Now wait for the controllers to finish shutting down. By blocking here, we prevent the generic api server's
-			// graceful shutdown process from continuing until we are finished shutting down our own controllers.
-			controllersShutdownWaitGroup.Wait()
+			// Now that the controllers are stopped, also stop their informers.
+			cancelControllerCtx()
 
-			return nil
+			return err
 		},
 	)
 
@@ -9,19 +9,22 @@ import (
 	"crypto/rand"
 	"crypto/tls"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"strconv"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
 
 	"github.com/joshlf/go-acl"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
@@ -30,19 +33,28 @@ import (
 	openapinamer "k8s.io/apiserver/pkg/endpoints/openapi"
 	"k8s.io/apiserver/pkg/features"
 	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/apiserver/pkg/server/healthz"
 	genericoptions "k8s.io/apiserver/pkg/server/options"
+	"k8s.io/client-go/dynamic"
 	k8sinformers "k8s.io/client-go/informers"
+	corev1informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/client-go/util/flowcontrol"
 	aggregatorclient "k8s.io/kube-aggregator/pkg/client/clientset_generated/clientset"
 	"k8s.io/utils/clock"
 
 	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
 	supervisorclientset "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned"
+	supervisorclientsetscheme "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/scheme"
 	"go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/typed/config/v1alpha1"
 	supervisorinformers "go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions"
+	idpinformers "go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions/idp/v1alpha1"
 	supervisoropenapi "go.pinniped.dev/generated/latest/client/supervisor/openapi"
+	"go.pinniped.dev/internal/acmecert"
 	"go.pinniped.dev/internal/admissionpluginconfig"
 	"go.pinniped.dev/internal/apiserviceref"
 	"go.pinniped.dev/internal/config/featuregates"
@@ -50,11 +62,14 @@ import (
 	"go.pinniped.dev/internal/controller/apicerts"
 	"go.pinniped.dev/internal/controller/supervisorconfig"
 	"go.pinniped.dev/internal/controller/supervisorconfig/activedirectoryupstreamwatcher"
+	"go.pinniped.dev/internal/controller/supervisorconfig/clientcertupstreamwatcher"
 	"go.pinniped.dev/internal/controller/supervisorconfig/generator"
 	"go.pinniped.dev/internal/controller/supervisorconfig/githubupstreamwatcher"
 	"go.pinniped.dev/internal/controller/supervisorconfig/ldapupstreamwatcher"
+	"go.pinniped.dev/internal/controller/supervisorconfig/mockupstreamwatcher"
 	"go.pinniped.dev/internal/controller/supervisorconfig/oidcclientwatcher"
 	"go.pinniped.dev/internal/controller/supervisorconfig/oidcupstreamwatcher"
+	"go.pinniped.dev/internal/controller/supervisorconfig/openshiftupstreamwatcher"
 	"go.pinniped.dev/internal/controller/supervisorstorage"
 	"go.pinniped.dev/internal/controllerinit"
 	"go.pinniped.dev/internal/controllerlib"
@@ -62,28 +77,52 @@ import (
 	"go.pinniped.dev/internal/deploymentref"
 	"go.pinniped.dev/internal/downward"
 	"go.pinniped.dev/internal/dynamiccert"
+	"go.pinniped.dev/internal/federationdomain/accesslog"
+	"go.pinniped.dev/internal/federationdomain/branding"
+	"go.pinniped.dev/internal/federationdomain/clusteraudience"
 	"go.pinniped.dev/internal/federationdomain/dynamictlscertprovider"
 	"go.pinniped.dev/internal/federationdomain/dynamicupstreamprovider"
 	"go.pinniped.dev/internal/federationdomain/endpoints/jwks"
 	"go.pinniped.dev/internal/federationdomain/endpointsmanager"
+	"go.pinniped.dev/internal/federationdomain/forcedreauth"
+	"go.pinniped.dev/internal/federationdomain/loginerrors"
+	"go.pinniped.dev/internal/federationdomain/workloadidentity"
 	"go.pinniped.dev/internal/groupsuffix"
+	"go.pinniped.dev/internal/healthcheck"
+	"go.pinniped.dev/internal/httputil/requestlog"
 	"go.pinniped.dev/internal/kubeclient"
+	"go.pinniped.dev/internal/lastauth"
 	"go.pinniped.dev/internal/leaderelection"
+	"go.pinniped.dev/internal/lifecycle"
+	"go.pinniped.dev/internal/net/phttp"
 	"go.pinniped.dev/internal/plog"
 	"go.pinniped.dev/internal/pversion"
+	"go.pinniped.dev/internal/readcache"
+	"go.pinniped.dev/internal/resourcelabels"
 	"go.pinniped.dev/internal/secret"
 	"go.pinniped.dev/internal/supervisor/apiserver"
 	supervisorscheme "go.pinniped.dev/internal/supervisor/scheme"
+	"go.pinniped.dev/internal/supervisor/statuspage"
+	"go.pinniped.dev/internal/tracing"
 )
 
 const (
 	singletonWorker       = 1
 	defaultResyncInterval = 3 * time.Minute
+
+	// serverShutdownGracePeriod is how long the http and https listeners wait for active connections to become idle.
+	serverShutdownGracePeriod = time.Minute
+
+	// apiServerShutdownDeadline is how long the aggregated API server, including its controllers, may take to stop.
+	apiServerShutdownDeadline = 2 * time.Minute
 )
 
-func startServer(ctx context.Context, shutdown *sync.WaitGroup, l net.Listener, handler http.Handler) {
+func startServer(lifecycleManager *lifecycle.Manager, name string, l net.Listener, handler http.Handler, requestLogger *requestlog.Logger) {
 	handler = genericapifilters.WithWarningRecorder(handler)
-	handler = withBootstrapPaths(handler, "/healthz") // only health checks are allowed for bootstrap connections
+	handler = withBootstrapPaths(handler, "/healthz", "/livez", "/readyz") // only health checks are allowed for bootstrap connections
+	// Log the requests which were rejected above too.
+	handler = requestLogger.WrapHandler(name, handler)
+	handler = tracing.WrapHandler(handler, "supervisor "+name)
 
 	server := http.Server{
 		Handler:           handler,
@@ -91,29 +130,89 @@ func startServer(ctx context.Context, shutdown *sync.WaitGroup, l net.Listener,
 		ReadHeaderTimeout: 10 * time.Second,
 	}
 
-	shutdown.Add(1)
-	go func() {
-		defer shutdown.Done()
-
-		err := server.Serve(l)
-		plog.Debug("server exited", "err", err)
-	}()
-
-	shutdown.Add(1)
-	go func() {
-		defer shutdown.Done()
-
-		<-ctx.Done()
-		plog.Debug("server context cancelled", "err", ctx.Err())
+	// Allow a few more seconds than the grace period for the server to finish shutting down.
+	lifecycleManager.Go(context.Background(), name, serverShutdownGracePeriod+5*time.Second, func(ctx context.Context) error {
+		serveErr := make(chan error, 1)
+		go func() {
+			serveErr <- server.Serve(l)
+		}()
+
+		select {
+		case err := <-serveErr:
+			plog.Debug("server exited", "err", err)
+			return nil
+		case <-ctx.Done():
+			plog.Debug("server context cancelled", "err", ctx.Err())
+		}
 
-		// allow up to a minute grace period for active connections to return to idle
-		connectionsCtx, connectionsCancel := context.WithTimeout(context.Background(), time.Minute)
+		// allow a grace period for active connections to return to idle
+		connectionsCtx, connectionsCancel := context.WithTimeout(context.Background(), serverShutdownGracePeriod)
 		defer connectionsCancel()
 
 		if err := server.Shutdown(connectionsCtx); err != nil {
 			plog.Debug("server shutdown failed", "err", err)
 		}
-	}()
+
+		plog.Debug("server exited", "err", <-serveErr)
+		return nil
+	})
+}
+
+// readSecretTypes are the types of Secrets whose contents the Supervisor may read: the Secrets of its own storage and
+// configuration, which have the storage.pinniped.dev/* and secrets.pinniped.dev/* types, and the Secrets which are
+// referenced by its configuration, which must be of one of these types. Opaque Secrets are not included, since most
+// unrelated Secrets have that type.
+//
+//nolint:gochecknoglobals // This is effectively a constant.
+var readSecretTypes = []corev1.SecretType{
+	corev1.SecretTypeBasicAuth,
+	corev1.SecretTypeTLS,
+}
+
+// readSecretTypePrefixes are the prefixes of the types of the Secrets which are managed by Pinniped.
+//
+//nolint:gochecknoglobals // This is effectively a constant.
+var readSecretTypePrefixes = []string{
+	"secrets.pinniped.dev/",
+	"storage.pinniped.dev/",
+}
+
+// isReadSecretType returns true when the Supervisor may read the contents of Secrets of the given type.
+func isReadSecretType(secretType corev1.SecretType) bool {
+	if slices.Contains(readSecretTypes, secretType) {
+		return true
+	}
+	for _, prefix := range readSecretTypePrefixes {
+		if strings.HasPrefix(string(secretType), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// dropUnreadSecretData is an informer transform which drops the contents of the Secrets which are not of any of the
+// readSecretTypes, to reduce the memory used by the informer, since the namespace may hold many unrelated Secrets,
+// e.g. the release Secrets of Helm. Their metadata is kept, so that the controllers which look up a Secret by name
+// can still report that it has the wrong type. Note that a controller which reads the data of a Secret of any other
+// type would silently see no data, so every controller must check the type of a Secret before reading its data, and
+// report a Secret of the wrong type in its status. Field selectors of Secrets can only combine conditions with AND,
+// so they cannot select several types, and the Secrets which are referenced by the configuration of the Supervisor
+// have no label in common which a label selector could select.
+func dropUnreadSecretData(obj any) (any, error) {
+	if secret, ok := obj.(*corev1.Secret); ok && !isReadSecretType(secret.Type) {
+		secret.Data = nil
+		secret.StringData = nil
+	}
+	return stripManagedFields(obj)
+}
+
+// stripManagedFields is an informer transform which drops the managed fields of the cached objects,
+// since the Supervisor never uses them, to reduce the memory used by the informers.
+func stripManagedFields(obj any) (any, error) {
+	if accessor, err := meta.Accessor(obj); err == nil {
+		accessor.SetManagedFields(nil)
+	}
+	return obj, nil
 }
 
 func signalCtx() context.Context {
@@ -136,6 +235,7 @@ func prepareControllers(
 	cfg *supervisor.Config,
 	issuerManager *endpointsmanager.Manager,
 	dynamicJWKSProvider jwks.DynamicJWKSProvider,
+	dynamicBrandingProvider branding.DynamicBrandingProvider,
 	dynamicTLSCertProvider dynamictlscertprovider.DynamicTLSCertProvider,
 	dynamicUpstreamIDPProvider dynamicupstreamprovider.DynamicUpstreamIDPProvider,
 	dynamicServingCertProvider dynamiccert.Private,
@@ -144,16 +244,35 @@ func prepareControllers(
 	kubeClient kubernetes.Interface,
 	pinnipedClient supervisorclientset.Interface,
 	aggregatorClient aggregatorclient.Interface,
+	dynamicClient dynamic.Interface,
 	kubeInformers k8sinformers.SharedInformerFactory,
+	secretInformers k8sinformers.SharedInformerFactory,
 	pinnipedInformers supervisorinformers.SharedInformerFactory,
 	leaderElector controllerinit.RunnerWrapper,
+	isLeader func() bool,
 	podInfo *downward.PodInfo,
+	lastClientAuths *lastauth.Recorder[string],
 ) controllerinit.RunnerBuilder {
 	const certificateName string = "pinniped-supervisor-api-tls-serving-certificate"
 	clientSecretSupervisorGroupData := groupsuffix.SupervisorAggregatedGroups(*cfg.APIGroupSuffix)
 	federationDomainInformer := pinnipedInformers.Config().V1alpha1().FederationDomains()
 	oidcClientInformer := pinnipedInformers.Config().V1alpha1().OIDCClients()
-	secretInformer := kubeInformers.Core().V1().Secrets()
+	secretInformer := secretInformers.Core().V1().Secrets()
+
+	// MockIdentityProviders are only watched when their feature gate is enabled. Otherwise, FederationDomains
+	// are not allowed to refer to them and no informer is started for them.
+	var mockIdentityProviderInformer idpinformers.MockIdentityProviderInformer
+	if cfg.FeatureEnabled(supervisor.FeatureMockIdentityProvider) {
+		plog.Warning("the MockIdentityProvider feature gate is enabled, which should only be used for demo and test environments")
+		mockIdentityProviderInformer = pinnipedInformers.IDP().V1alpha1().MockIdentityProviders()
+	}
+
+	// The FederationDomain and identity provider controllers record Events when their conditions change, so the
+	// changes are visible to "kubectl describe" and "kubectl get events". The recorder must know the Supervisor's
+	// custom resource types to be able to refer to them. The Events are only written while leading, like any other
+	// writes of the controllers.
+	eventBroadcaster := events.NewBroadcaster(&events.EventSinkImpl{Interface: kubeClient.EventsV1()})
+	eventRecorder := eventBroadcaster.NewRecorder(supervisorclientsetscheme.Scheme, "pinniped-supervisor")
 
 	// Create controller manager.
 	controllerManager := controllerlib.
@@ -165,6 +284,7 @@ func prepareControllers(
 				kubeClient,
 				secretInformer,
 				controllerlib.WithInformer,
+				isLeader,
 			),
 			singletonWorker,
 		).
@@ -179,7 +299,11 @@ func prepareControllers(
 				pinnipedInformers.IDP().V1alpha1().LDAPIdentityProviders(),
 				pinnipedInformers.IDP().V1alpha1().ActiveDirectoryIdentityProviders(),
 				pinnipedInformers.IDP().V1alpha1().GitHubIdentityProviders(),
+				pinnipedInformers.IDP().V1alpha1().ClientCertificateIdentityProviders(),
+				pinnipedInformers.IDP().V1alpha1().OpenShiftIdentityProviders(),
+				mockIdentityProviderInformer,
 				controllerlib.WithInformer,
+				eventRecorder,
 			),
 			singletonWorker,
 		).
@@ -191,6 +315,8 @@ func prepareControllers(
 				secretInformer,
 				federationDomainInformer,
 				controllerlib.WithInformer,
+				clock.RealClock{},
+				isLeader,
 			),
 			singletonWorker,
 		).
@@ -203,12 +329,60 @@ func prepareControllers(
 			),
 			singletonWorker,
 		).
+		WithController(
+			supervisorconfig.NewBrandingObserverController(
+				dynamicBrandingProvider,
+				kubeInformers.Core().V1().ConfigMaps(),
+				federationDomainInformer,
+				controllerlib.WithInformer,
+			),
+			singletonWorker,
+		).
 		WithController(
 			supervisorconfig.NewTLSCertObserverController(
 				dynamicTLSCertProvider,
 				cfg.NamesConfig.DefaultTLSCertificateSecret,
+				pinnipedClient,
 				secretInformer,
 				federationDomainInformer,
+				clock.RealClock{},
+				controllerlib.WithInformer,
+			),
+			singletonWorker,
+		).
+		WithController(
+			supervisorconfig.NewCertManagerCertificateWriterController(
+				*cfg.APIGroupSuffix,
+				dynamicClient,
+				pinnipedClient,
+				federationDomainInformer,
+				clock.RealClock{},
+				controllerlib.WithInformer,
+			),
+			singletonWorker,
+		).
+		WithController(
+			supervisorconfig.NewFederationDomainExposureWriterController(
+				*cfg.APIGroupSuffix,
+				kubeClient,
+				dynamicClient,
+				pinnipedClient,
+				kubeInformers.Networking().V1().Ingresses(),
+				federationDomainInformer,
+				phttp.Default(nil),
+				clock.RealClock{},
+				controllerlib.WithInformer,
+			),
+			singletonWorker,
+		).
+		WithController(
+			supervisorconfig.NewACMECertificateWriterController(
+				cfg.Labels,
+				kubeClient,
+				pinnipedClient,
+				secretInformer,
+				federationDomainInformer,
+				clock.RealClock{},
 				controllerlib.WithInformer,
 			),
 			singletonWorker,
@@ -305,6 +479,8 @@ func prepareControllers(
 				secretInformer,
 				plog.New(),
 				controllerlib.WithInformer,
+				eventRecorder,
+				cfg.ExternalClientSecrets.AllowedDirectories,
 			),
 			singletonWorker).
 		WithController(
@@ -314,6 +490,7 @@ func prepareControllers(
 				pinnipedInformers.IDP().V1alpha1().LDAPIdentityProviders(),
 				secretInformer,
 				controllerlib.WithInformer,
+				eventRecorder,
 			),
 			singletonWorker).
 		WithController(
@@ -323,6 +500,7 @@ func prepareControllers(
 				pinnipedInformers.IDP().V1alpha1().ActiveDirectoryIdentityProviders(),
 				secretInformer,
 				controllerlib.WithInformer,
+				eventRecorder,
 			),
 			singletonWorker).
 		WithController(
@@ -334,8 +512,35 @@ func prepareControllers(
 				secretInformer,
 				plog.New(),
 				controllerlib.WithInformer,
+				eventRecorder,
 				clock.RealClock{},
 				tls.Dial,
+				cfg.ExternalClientSecrets.AllowedDirectories,
+			),
+			singletonWorker).
+		WithController(
+			clientcertupstreamwatcher.New(
+				podInfo.Namespace,
+				dynamicUpstreamIDPProvider,
+				pinnipedClient,
+				pinnipedInformers.IDP().V1alpha1().ClientCertificateIdentityProviders(),
+				plog.New(),
+				controllerlib.WithInformer,
+				eventRecorder,
+				clock.RealClock{},
+			),
+			singletonWorker).
+		WithController(
+			openshiftupstreamwatcher.New(
+				podInfo.Namespace,
+				dynamicUpstreamIDPProvider,
+				pinnipedClient,
+				pinnipedInformers.IDP().V1alpha1().OpenShiftIdentityProviders(),
+				secretInformer,
+				plog.New(),
+				controllerlib.WithInformer,
+				eventRecorder,
+				clock.RealClock{},
 			),
 			singletonWorker).
 		WithController(
@@ -392,12 +597,38 @@ func prepareControllers(
 				pinnipedClient,
 				secretInformer,
 				oidcClientInformer,
+				lastClientAuths,
+				controllerlib.WithInformer,
+			),
+			singletonWorker,
+		)
+
+	if mockIdentityProviderInformer != nil {
+		controllerManager.WithController(
+			mockupstreamwatcher.New(
+				podInfo.Namespace,
+				dynamicUpstreamIDPProvider,
+				pinnipedClient,
+				mockIdentityProviderInformer,
+				secretInformer,
+				plog.New(),
 				controllerlib.WithInformer,
+				eventRecorder,
+				clock.RealClock{},
 			),
 			singletonWorker,
 		)
+	}
+
+	runControllers := func(ctx context.Context) {
+		if err := eventBroadcaster.StartRecordingToSinkWithContext(ctx); err != nil {
+			plog.Error("could not start recording events", err)
+		}
+		defer eventBroadcaster.Shutdown()
+		controllerManager.Start(ctx)
+	}
 
-	return controllerinit.Prepare(controllerManager.Start, leaderElector, kubeInformers, pinnipedInformers)
+	return controllerinit.Prepare(runControllers, leaderElector, kubeInformers, secretInformers, pinnipedInformers)
 }
 
 // Boot the aggregated API server, which will in turn boot the controllers. Also open the appropriate network ports
@@ -422,15 +653,28 @@ func runSupervisor(ctx context.Context, podInfo *downward.PodInfo, cfg *supervis
 		return fmt.Errorf("cannot create deployment ref: %w", err)
 	}
 
+	secretLabels, err := resourcelabels.ParseSelector(cfg.Informers.SecretLabelSelector)
+	if err != nil {
+		return fmt.Errorf("invalid informers.secretLabelSelector: %w", err)
+	}
+	configMapLabels, err := resourcelabels.ParseSelector(cfg.Informers.ConfigMapLabelSelector)
+	if err != nil {
+		return fmt.Errorf("invalid informers.configMapLabelSelector: %w", err)
+	}
+
 	opts := []kubeclient.Option{
 		dref,
 		apiServiceRef,
 		kubeclient.WithMiddleware(groupsuffix.New(*cfg.APIGroupSuffix)),
+		// label the Secrets and ConfigMaps that we create so that they are selected by our own informers
+		kubeclient.WithMiddleware(resourcelabels.New(corev1.Resource("secrets"), secretLabels)),
+		kubeclient.WithMiddleware(resourcelabels.New(corev1.Resource("configmaps"), configMapLabels)),
 	}
 
-	client, leaderElector, err := leaderelection.New(
+	client, leaderElector, leaderStatus, err := leaderelection.New(
 		podInfo,
 		supervisorDeployment,
+		leaderelection.LeaseTimings{},
 		opts...,
 	)
 	if err != nil {
@@ -442,10 +686,45 @@ func runSupervisor(ctx context.Context, podInfo *downward.PodInfo, cfg *supervis
 		return fmt.Errorf("cannot create k8s client without leader election: %w", err)
 	}
 
+	// The dynamic client is used for optional APIs of other projects, such as cert-manager, which have no generated
+	// clients in this module. Like the JSONConfig itself, it is not subject to leader election.
+	dynamicClient, err := dynamic.NewForConfig(client.JSONConfig)
+	if err != nil {
+		return fmt.Errorf("cannot create dynamic k8s client: %w", err)
+	}
+
 	kubeInformers := k8sinformers.NewSharedInformerFactoryWithOptions(
 		client.Kubernetes,
 		defaultResyncInterval,
 		k8sinformers.WithNamespace(serverInstallationNamespace),
+		k8sinformers.WithTransform(stripManagedFields),
+	)
+
+	// ConfigMaps are only listed when they are selected by the configured label selector. This registers the
+	// ConfigMap informer of the factory before any controller asks for it, so that all of them share this one.
+	kubeInformers.InformerFor(&corev1.ConfigMap{}, func(kubeClient kubernetes.Interface, resync time.Duration) cache.SharedIndexInformer {
+		return corev1informers.NewFilteredConfigMapInformer(
+			kubeClient,
+			serverInstallationNamespace,
+			resync,
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+			func(options *metav1.ListOptions) {
+				options.LabelSelector = configMapLabels.AsSelector().String()
+			},
+		)
+	})
+
+	// Secrets are watched by a separate informer factory which only caches the contents of the types of Secrets that
+	// the Supervisor may read, because the namespace may hold many unrelated Secrets, e.g. the release Secrets of Helm.
+	// They may be further restricted by the configured label selector.
+	secretInformers := k8sinformers.NewSharedInformerFactoryWithOptions(
+		client.Kubernetes,
+		defaultResyncInterval,
+		k8sinformers.WithNamespace(serverInstallationNamespace),
+		k8sinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = secretLabels.AsSelector().String()
+		}),
+		k8sinformers.WithTransform(dropUnreadSecretData),
 	)
 
 	pinnipedInformers := supervisorinformers.NewSharedInformerFactoryWithOptions(
@@ -454,29 +733,93 @@ func runSupervisor(ctx context.Context, podInfo *downward.PodInfo, cfg *supervis
 		supervisorinformers.WithNamespace(serverInstallationNamespace),
 	)
 
-	// Serve the /healthz endpoint and make all other paths result in 404.
+	// The lifecycle manager owns the background goroutines of the servers and stops them in order upon shutdown.
+	lifecycleManager := lifecycle.New()
+
+	// The health checks are served by this mux, and all other paths result in 404.
+	// Its endpoints are added below, once all the components which they check have been created.
 	healthMux := http.NewServeMux()
-	healthMux.Handle("/healthz", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-		_, _ = w.Write([]byte("ok"))
-	}))
 
 	dynamicServingCertProvider := dynamiccert.NewServingCert("supervisor-serving-cert")
 
 	dynamicJWKSProvider := jwks.NewDynamicJWKSProvider()
+	dynamicBrandingProvider := branding.NewDynamicBrandingProvider()
 	dynamicTLSCertProvider := dynamictlscertprovider.NewDynamicTLSCertProvider()
 	dynamicUpstreamIDPProvider := dynamicupstreamprovider.NewDynamicUpstreamIDPProvider()
 	secretCache := secret.Cache{}
 
+	accessLogger, closeAccessLogSink := newAccessLogger(cfg.AccessLog)
+	defer closeAccessLogSink()
+
+	// Logs the requests of all listeners when enabled, unlike the access logs, which are per FederationDomain.
+	requestLogger := requestlog.New(cfg.RequestLog, plog.New(), clock.RealClock{})
+
+	// Reads of the kube storage on the request path are served from the Secret informer whenever it is fresh enough.
+	secretInformer := secretInformers.Core().V1().Secrets()
+	requestPathSecrets := readcache.NewSecrets(
+		clientWithoutLeaderElection.Kubernetes.CoreV1().Secrets(serverInstallationNamespace), // writes to kube storage are allowed for non-leaders
+		secretInformer.Lister().Secrets(serverInstallationNamespace),
+		secretInformer.Informer().HasSynced,
+		flowcontrol.NewTokenBucketRateLimiter(readcache.DefaultLiveReadQPS, readcache.DefaultLiveReadBurst),
+	)
+
+	// Only remember the recent login errors when the status page can show them.
+	var loginErrors *loginerrors.Recorder
+	if cfg.StatusPage.Enabled {
+		loginErrors = loginerrors.New(loginerrors.DefaultCapacity, clock.RealClock{})
+	}
+
+	// Remembers the successful token requests of the OIDCClients, so the OIDCClient watcher can show them in their status.
+	lastClientAuths := lastauth.New[string](clock.RealClock{})
+
 	// OIDC endpoints will be served by the endpoints manager, and any non-OIDC paths will fallback to the healthMux.
 	oidProvidersManager := endpointsmanager.NewManager(
 		healthMux,
 		dynamicJWKSProvider,
+		dynamicBrandingProvider,
 		dynamicUpstreamIDPProvider,
 		&secretCache,
-		clientWithoutLeaderElection.Kubernetes.CoreV1().Secrets(serverInstallationNamespace), // writes to kube storage are allowed for non-leaders
+		requestPathSecrets,
 		client.PinnipedSupervisor.ConfigV1alpha1().OIDCClients(serverInstallationNamespace),
+		cfg.Audit.SensitiveGroups,
+		accessLogger,
+		forcedreauth.NewChecker(pinnipedInformers.Config().V1alpha1().ForcedReauthentications().Lister().ForcedReauthentications(serverInstallationNamespace)),
+		loginErrors,
+		workloadidentity.NewValidator(clientWithoutLeaderElection.Kubernetes.CoreV1().RESTClient(), clock.RealClock{}),
+		clusteraudience.NewRegistry(pinnipedInformers.Config().V1alpha1().ClusterAudiences().Lister().ClusterAudiences(serverInstallationNamespace)),
+		lastClientAuths,
 	)
 
+	// Serve the /healthz, /livez and /readyz endpoints. Readiness fails as soon as shutdown starts, while the servers
+	// are still draining their connections. It also fails until the informers have synced, the leader has been observed,
+	// the FederationDomains have been loaded, and the serving certificate of the aggregated API server is valid.
+	healthz.InstallHandler(healthMux, healthz.PingHealthz)
+	healthz.InstallLivezHandler(healthMux, healthz.PingHealthz)
+	healthz.InstallReadyzHandler(healthMux,
+		lifecycleManager.ReadyzCheck(),
+		leaderStatus,
+		healthcheck.InformerSync(kubeInformers, secretInformers, pinnipedInformers),
+		oidProvidersManager.ReadyzCheck(),
+		healthcheck.ServingCert(dynamicServingCertProvider, clock.RealClock{}),
+	)
+
+	// The status page reads from the same informer caches as the controllers, so create its listers before the
+	// informers are started by the controllers.
+	var statusPageHandler http.Handler
+	if cfg.StatusPage.Enabled {
+		idpInformers := pinnipedInformers.IDP().V1alpha1()
+		statusPageHandler = statuspage.NewHandler(&statuspage.Listers{
+			FederationDomains:                  pinnipedInformers.Config().V1alpha1().FederationDomains().Lister().FederationDomains(serverInstallationNamespace),
+			OIDCIdentityProviders:              idpInformers.OIDCIdentityProviders().Lister().OIDCIdentityProviders(serverInstallationNamespace),
+			LDAPIdentityProviders:              idpInformers.LDAPIdentityProviders().Lister().LDAPIdentityProviders(serverInstallationNamespace),
+			ActiveDirectoryIdentityProviders:   idpInformers.ActiveDirectoryIdentityProviders().Lister().ActiveDirectoryIdentityProviders(serverInstallationNamespace),
+			GitHubIdentityProviders:            idpInformers.GitHubIdentityProviders().Lister().GitHubIdentityProviders(serverInstallationNamespace),
+			ClientCertificateIdentityProviders: idpInformers.ClientCertificateIdentityProviders().Lister().ClientCertificateIdentityProviders(serverInstallationNamespace),
+			OpenShiftIdentityProviders:         idpInformers.OpenShiftIdentityProviders().Lister().OpenShiftIdentityProviders(serverInstallationNamespace),
+			Secrets:                            secretInformer.Lister().Secrets(serverInstallationNamespace),
+		}, loginErrors, cfg.StatusPage.CredentialsSecretName, clock.RealClock{})
+	}
+
 	// Get the "real" name of the client secret supervisor API group (i.e., the API group name with the
 	// injected suffix).
 	scheme, clientSecretGV := supervisorscheme.New(*cfg.APIGroupSuffix)
@@ -485,6 +828,7 @@ func runSupervisor(ctx context.Context, podInfo *downward.PodInfo, cfg *supervis
 		cfg,
 		oidProvidersManager,
 		dynamicJWKSProvider,
+		dynamicBrandingProvider,
 		dynamicTLSCertProvider,
 		dynamicUpstreamIDPProvider,
 		dynamicServingCertProvider,
@@ -493,14 +837,16 @@ func runSupervisor(ctx context.Context, podInfo *downward.PodInfo, cfg *supervis
 		client.Kubernetes,
 		client.PinnipedSupervisor,
 		client.Aggregation,
+		dynamicClient,
 		kubeInformers,
+		secretInformers,
 		pinnipedInformers,
 		leaderElector,
+		leaderStatus.IsLeader,
 		podInfo,
+		lastClientAuths,
 	)
 
-	shutdown := &sync.WaitGroup{}
-
 	// Get the aggregated API server config.
 	aggregatedAPIServerConfig, err := getAggregatedAPIServerConfig(
 		dynamicServingCertProvider,
@@ -522,6 +868,20 @@ func runSupervisor(ctx context.Context, podInfo *downward.PodInfo, cfg *supervis
 	if err != nil {
 		return fmt.Errorf("could not create aggregated API server: %w", err)
 	}
+	if err := server.GenericAPIServer.AddReadyzChecks(lifecycleManager.ReadyzCheck()); err != nil {
+		return fmt.Errorf("could not add readyz checks to aggregated API server: %w", err)
+	}
+
+	// Stop anything which was already started if we fail to start the listeners below.
+	defer func() { _ = lifecycleManager.Shutdown() }()
+
+	// Run the server. Its post-start hook will start the controllers. Its pre shutdown hook will be called when it is
+	// stopped, and that hook should graceful stop the controllers and give up the leader election lease. See the
+	// code for these hooks in internal/supervisor/apiserver.go.
+	preparedServer := server.GenericAPIServer.PrepareRun()
+	lifecycleManager.Go(context.Background(), "aggregated API server", apiServerShutdownDeadline, func(ctx context.Context) error {
+		return preparedServer.Run(ctx.Done())
+	})
 
 	if e := cfg.Endpoints.HTTP; e.Network != supervisor.NetworkDisabled {
 		finishSetupPerms := maybeSetupUnixPerms(e, supervisorPod)
@@ -536,13 +896,55 @@ func runSupervisor(ctx context.Context, podInfo *downward.PodInfo, cfg *supervis
 		}
 
 		defer func() { _ = httpListener.Close() }()
-		startServer(ctx, shutdown, httpListener, oidProvidersManager)
+		startServer(lifecycleManager, "http listener", httpListener, oidProvidersManager, requestLogger)
 		plog.Debug("supervisor http listener started", "address", httpListener.Addr().String())
 	}
 
-	if e := cfg.Endpoints.HTTPS; e.Network != supervisor.NetworkDisabled { //nolint:nestif
+	if e := cfg.Endpoints.ACMEHTTP01; e.Network != supervisor.NetworkDisabled {
 		finishSetupPerms := maybeSetupUnixPerms(e, supervisorPod)
 
+		acmeHTTP01Listener, err := net.Listen(e.Network, e.Address)
+		if err != nil {
+			return fmt.Errorf("cannot create acme http-01 listener with network %q and address %q: %w", e.Network, e.Address, err)
+		}
+
+		if err := finishSetupPerms(); err != nil {
+			return fmt.Errorf("cannot setup acme http-01 listener permissions for network %q and address %q: %w", e.Network, e.Address, err)
+		}
+
+		defer func() { _ = acmeHTTP01Listener.Close() }()
+		// This listener only serves the responses to ACME HTTP-01 challenges, which are not secret.
+		startServer(lifecycleManager, "acme http-01 listener", acmeHTTP01Listener,
+			acmecert.NewHTTP01Handler(secretInformer.Lister().Secrets(serverInstallationNamespace)), requestLogger)
+		plog.Debug("supervisor acme http-01 listener started", "address", acmeHTTP01Listener.Addr().String())
+	}
+
+	if e := cfg.Endpoints.Operational; e.Network != supervisor.NetworkDisabled {
+		finishSetupPerms := maybeSetupUnixPerms(e, supervisorPod)
+
+		operationalListener, err := net.Listen(e.Network, e.Address)
+		if err != nil {
+			return fmt.Errorf("cannot create operational listener with network %q and address %q: %w", e.Network, e.Address, err)
+		}
+
+		if err := finishSetupPerms(); err != nil {
+			return fmt.Errorf("cannot setup operational listener permissions for network %q and address %q: %w", e.Network, e.Address, err)
+		}
+
+		defer func() { _ = operationalListener.Close() }()
+		// This listener serves the health checks, and the status page when it is enabled.
+		operationalMux := http.NewServeMux()
+		operationalMux.Handle("/", healthMux)
+		if statusPageHandler != nil {
+			operationalMux.Handle(statuspage.Path, statusPageHandler)
+			operationalMux.Handle(statuspage.JSONPath, statusPageHandler)
+		}
+		startServer(lifecycleManager, "operational listener", operationalListener, operationalMux, requestLogger)
+		plog.Debug("supervisor operational listener started", "address", operationalListener.Addr().String())
+	}
+
+	httpsEndpointEnabled := cfg.Endpoints.HTTPS.Network != supervisor.NetworkDisabled
+	if httpsEndpointEnabled || len(cfg.Endpoints.AdditionalHTTPS) > 0 { //nolint:nestif
 		bootstrapCert, err := getBootstrapCert() // generate this in-memory once per process startup
 		if err != nil {
 			return fmt.Errorf("https listener bootstrap error: %w", err)
@@ -550,11 +952,9 @@ func runSupervisor(ctx context.Context, podInfo *downward.PodInfo, cfg *supervis
 
 		c := ptls.Default(nil)
 		c.GetCertificate = func(info *tls.ClientHelloInfo) (*tls.Certificate, error) {
-			cert := dynamicTLSCertProvider.GetTLSCert(strings.ToLower(info.ServerName))
+			cert, defaultCert := dynamicTLSCertProvider.LookupTLSCert(info.ServerName)
 			foundServerNameCert := cert != nil
 
-			defaultCert := dynamicTLSCertProvider.GetDefaultTLSCert()
-
 			if !foundServerNameCert {
 				cert = defaultCert
 			}
@@ -583,33 +983,54 @@ func runSupervisor(ctx context.Context, podInfo *downward.PodInfo, cfg *supervis
 			return cert, nil
 		}
 
-		httpsListener, err := tls.Listen(e.Network, e.Address, c)
-		if err != nil {
-			return fmt.Errorf("cannot create https listener with network %q and address %q: %w", e.Network, e.Address, err)
+		startHTTPSListener := func(name string, e supervisor.Endpoint, c *tls.Config, handler http.Handler) (net.Listener, error) {
+			c.GetConfigForClient = configForClient(c.Clone(), oidProvidersManager)
+
+			finishSetupPerms := maybeSetupUnixPerms(&e, supervisorPod)
+
+			httpsListener, err := tls.Listen(e.Network, e.Address, c)
+			if err != nil {
+				return nil, fmt.Errorf("cannot create %s with network %q and address %q: %w", name, e.Network, e.Address, err)
+			}
+
+			if err := finishSetupPerms(); err != nil {
+				_ = httpsListener.Close()
+				return nil, fmt.Errorf("cannot setup %s permissions for network %q and address %q: %w", name, e.Network, e.Address, err)
+			}
+
+			startServer(lifecycleManager, name, httpsListener, handler, requestLogger)
+			plog.Debug("supervisor "+name+" started", "address", httpsListener.Addr().String())
+			return httpsListener, nil
 		}
 
-		if err := finishSetupPerms(); err != nil {
-			return fmt.Errorf("cannot setup https listener permissions for network %q and address %q: %w", e.Network, e.Address, err)
+		if httpsEndpointEnabled {
+			httpsListener, err := startHTTPSListener("https listener", *cfg.Endpoints.HTTPS, c.Clone(), oidProvidersManager)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = httpsListener.Close() }()
 		}
 
-		defer func() { _ = httpsListener.Close() }()
-		startServer(ctx, shutdown, httpsListener, oidProvidersManager)
-		plog.Debug("supervisor https listener started", "address", httpsListener.Addr().String())
+		for _, e := range cfg.Endpoints.AdditionalHTTPS {
+			listenerConfig, err := e.TLSConfig(c)
+			if err != nil {
+				return fmt.Errorf("cannot configure tls for additional https listener %q: %w", e.Name, err)
+			}
+			name := fmt.Sprintf("additional https listener %q", e.Name)
+			additionalHTTPSListener, err := startHTTPSListener(name, e.Endpoint, listenerConfig, oidProvidersManager.HandlerForListener(e.Name))
+			if err != nil {
+				return err
+			}
+			defer func() { _ = additionalHTTPSListener.Close() }()
+		}
 	}
 
 	plog.Debug("supervisor started")
 	defer plog.Debug("supervisor exiting")
 
-	// Run the server. Its post-start hook will start the controllers. Its pre shutdown hook will be called when ctx is
-	// cancelled, and that hook should graceful stop the controllers and give up the leader election lease. See the
-	// code for these hooks in internal/supervisor/apiserver.go.
-	err = server.GenericAPIServer.PrepareRun().Run(ctx.Done())
-	if err != nil {
-		return err
-	}
-	shutdown.Wait()
-
-	return nil
+	// Block until ctx is cancelled, and then stop the listeners before stopping the aggregated API server
+	// and its controllers, since they were started in the opposite order.
+	return lifecycleManager.Run(ctx)
 }
 
 func getAggregatedAPIServerConfig(
@@ -693,6 +1114,26 @@ func getAggregatedAPIServerConfig(
 	return apiServerConfig, nil
 }
 
+// clientCertificateRequester decides which TLS server names should ask clients for a certificate.
+type clientCertificateRequester interface {
+	RequestsClientCertificates(serverName string) bool
+}
+
+// configForClient returns a function for tls.Config.GetConfigForClient which only asks clients for a certificate when
+// the issuer at the server name of their connection uses a ClientCertificateIdentityProvider, so that browsers are not
+// prompted to choose a certificate by the issuers which would not use it.
+// The certificate is optional at the TLS layer and is verified later by the provider itself.
+func configForClient(defaultConfig *tls.Config, requester clientCertificateRequester) func(*tls.ClientHelloInfo) (*tls.Config, error) {
+	return func(info *tls.ClientHelloInfo) (*tls.Config, error) {
+		if !requester.RequestsClientCertificates(info.ServerName) {
+			return defaultConfig, nil
+		}
+		configWithClientAuth := defaultConfig.Clone()
+		configWithClientAuth.ClientAuth = tls.RequestClientCert
+		return configWithClientAuth, nil
+	}
+}
+
 func maybeSetupUnixPerms(endpoint *supervisor.Endpoint, pod *corev1.Pod) func() error {
 	if endpoint.Network != supervisor.NetworkUnix {
 		return func() error { return nil }
@@ -724,6 +1165,44 @@ func maybeSetupUnixPerms(endpoint *supervisor.Endpoint, pod *corev1.Pod) func()
 	}
 }
 
+// newAccessLogger returns the logger for the access logs of the FederationDomains which enable them,
+// and a func to close its sink.
+func newAccessLogger(spec supervisor.AccessLogSpec) (*accesslog.Logger, func()) {
+	var sink io.Writer = os.Stdout
+	closeSink := func() {}
+
+	switch spec.Sink.Type {
+	case supervisor.AccessLogSinkFile:
+		fileSink := accesslog.NewFileSink(
+			spec.Sink.File.Path,
+			spec.Sink.File.MaxSizeMegabytes,
+			spec.Sink.File.MaxBackups,
+			spec.Sink.File.MaxAgeDays,
+		)
+		sink = fileSink
+		closeSink = func() { _ = fileSink.Close() }
+	case supervisor.AccessLogSinkCloudEvents:
+		cloudEvents := spec.Sink.CloudEvents
+		var transport accesslog.CloudEventsTransport
+		if cloudEvents.HTTP != nil {
+			transport = accesslog.NewCloudEventsHTTPTransport(cloudEvents.HTTP.URL, phttp.Default(nil))
+		} else {
+			transport = accesslog.NewCloudEventsKafkaTransport(cloudEvents.Kafka.RESTProxyURL, cloudEvents.Kafka.Topic, phttp.Default(nil))
+		}
+		cloudEventsSink := accesslog.NewCloudEventsSink(accesslog.CloudEventsConfig{
+			Source:        cloudEvents.Source,
+			Transport:     transport,
+			MaxBatchSize:  cloudEvents.MaxBatchSize,
+			FlushInterval: time.Duration(cloudEvents.FlushIntervalSeconds) * time.Second,
+			MaxRetries:    cloudEvents.MaxRetries,
+		}, clock.RealClock{})
+		sink = cloudEventsSink
+		closeSink = func() { _ = cloudEventsSink.Close() }
+	}
+
+	return accesslog.New(sink, accesslog.Format(spec.Format), spec.Fields, clock.RealClock{}), closeSink
+}
+
 func main() error { // return an error instead of plog.Fatal to allow defer statements to run
 	defer plog.Setup()()
 
@@ -742,7 +1221,7 @@ func main() error { // return an error instead of plog.Fatal to allow defer stat
 	ctx := signalCtx()
 
 	// Read the server config file.
-	cfg, err := supervisor.FromPath(ctx, os.Args[2], ptls.SetUserConfiguredAllowedCipherSuitesForTLSOneDotTwo)
+	cfg, err := supervisor.FromPath(ctx, os.Args[2], ptls.SetUserConfiguredSettings)
 	if err != nil {
 		return fmt.Errorf("could not load config: %w", err)
 	}
@@ -750,6 +1229,12 @@ func main() error { // return an error instead of plog.Fatal to allow defer stat
 	// The above server config should have set the allowed ciphers global, so now log the ciphers for all profiles.
 	ptls.LogAllProfiles(plog.New())
 
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing, "pinniped-supervisor")
+	if err != nil {
+		return fmt.Errorf("could not set up tracing: %w", err)
+	}
+	defer shutdownTracing()
+
 	return runSupervisor(ctx, podInfo, cfg)
 }
 
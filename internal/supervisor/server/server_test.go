@@ -0,0 +1,115 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestDropUnreadSecretData(t *testing.T) {
+	managedFields := []metav1.ManagedFieldsEntry{{Manager: "some-manager", Operation: metav1.ManagedFieldsOperationApply}}
+	secretOfType := func(secretType corev1.SecretType) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "some-secret", Namespace: "some-namespace", ManagedFields: managedFields},
+			Type:       secretType,
+			Data:       map[string][]byte{"some-key": []byte("some-value")},
+			StringData: map[string]string{"some-other-key": "some-other-value"},
+		}
+	}
+
+	for _, secretType := range []corev1.SecretType{
+		corev1.SecretTypeBasicAuth,
+		corev1.SecretTypeTLS,
+		"secrets.pinniped.dev/oidc-client",
+		"storage.pinniped.dev/access-token",
+	} {
+		got, err := dropUnreadSecretData(secretOfType(secretType))
+		require.NoError(t, err)
+		want := secretOfType(secretType)
+		want.ManagedFields = nil
+		require.Equal(t, want, got, "type %s should be kept", secretType)
+	}
+
+	for _, secretType := range []corev1.SecretType{
+		corev1.SecretTypeOpaque,
+		corev1.SecretTypeServiceAccountToken,
+		corev1.SecretTypeDockercfg,
+		corev1.SecretTypeDockerConfigJson,
+		corev1.SecretTypeBootstrapToken,
+		"helm.sh/release.v1",
+		"example.com/secrets.pinniped.dev/oidc-client",
+	} {
+		got, err := dropUnreadSecretData(secretOfType(secretType))
+		require.NoError(t, err)
+		require.Equal(t, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "some-secret", Namespace: "some-namespace"},
+			Type:       secretType,
+		}, got, "type %s should be dropped", secretType)
+	}
+
+	// Objects which are not Secrets, such as the tombstones of deleted Secrets, are passed through unchanged.
+	tombstone := cache.DeletedFinalStateUnknown{Key: "some-namespace/some-secret", Obj: secretOfType("helm.sh/release.v1")}
+	got, err := dropUnreadSecretData(tombstone)
+	require.NoError(t, err)
+	require.Equal(t, tombstone, got)
+}
+
+func TestStripManagedFields(t *testing.T) {
+	managedFields := []metav1.ManagedFieldsEntry{{Manager: "some-manager", Operation: metav1.ManagedFieldsOperationApply}}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "some-secret", Namespace: "some-namespace", ManagedFields: managedFields},
+		Data:       map[string][]byte{"some-key": []byte("some-value")},
+	}
+	got, err := stripManagedFields(secret)
+	require.NoError(t, err)
+	require.Equal(t, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "some-secret", Namespace: "some-namespace"},
+		Data:       map[string][]byte{"some-key": []byte("some-value")},
+	}, got)
+
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "some-config-map", ManagedFields: managedFields}}
+	got, err = stripManagedFields(configMap)
+	require.NoError(t, err)
+	require.Equal(t, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "some-config-map"}}, got)
+
+	// Objects without metadata, such as the tombstones of deleted objects, are passed through unchanged.
+	for _, obj := range []any{
+		"not an object",
+		cache.DeletedFinalStateUnknown{Key: "some-namespace/some-secret", Obj: secret},
+	} {
+		got, err = stripManagedFields(obj)
+		require.NoError(t, err)
+		require.Equal(t, obj, got)
+	}
+}
+
+type fakeClientCertificateRequester map[string]bool
+
+func (f fakeClientCertificateRequester) RequestsClientCertificates(serverName string) bool {
+	return f[serverName]
+}
+
+func TestConfigForClient(t *testing.T) {
+	defaultConfig := &tls.Config{MinVersion: tls.VersionTLS12, NextProtos: []string{"h2"}}
+	getConfigForClient := configForClient(defaultConfig, fakeClientCertificateRequester{"certs.example.com": true})
+
+	got, err := getConfigForClient(&tls.ClientHelloInfo{ServerName: "certs.example.com"})
+	require.NoError(t, err)
+	require.Equal(t, tls.RequestClientCert, got.ClientAuth)
+	require.Equal(t, []string{"h2"}, got.NextProtos)
+	require.Equal(t, tls.NoClientCert, defaultConfig.ClientAuth, "the default config should not be changed")
+
+	// A FederationDomain which does not use a ClientCertificateIdentityProvider does not prompt browsers for a certificate.
+	got, err = getConfigForClient(&tls.ClientHelloInfo{ServerName: "example.com"})
+	require.NoError(t, err)
+	require.Same(t, defaultConfig, got)
+	require.Equal(t, tls.NoClientCert, got.ClientAuth)
+}
@@ -0,0 +1,323 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package statuspage implements the read-only status page of the Supervisor, which summarizes the health of its
+// FederationDomains and identity providers, its recent logins and login errors, and its storage garbage collection
+// backlog. The same status is also served as JSON for scripts and dashboards.
+package statuspage
+
+import (
+	"bytes"
+	"crypto/subtle"
+	_ "embed" // Needed to trigger //go:embed directives below.
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tdewolff/minify/v2/minify"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/utils/clock"
+
+	configv1alpha1listers "go.pinniped.dev/generated/latest/client/supervisor/listers/config/v1alpha1"
+	idpv1alpha1listers "go.pinniped.dev/generated/latest/client/supervisor/listers/idp/v1alpha1"
+	"go.pinniped.dev/internal/crud"
+	"go.pinniped.dev/internal/federationdomain/csp"
+	"go.pinniped.dev/internal/federationdomain/loginerrors"
+	"go.pinniped.dev/internal/fositestorage/authorizationcode"
+	"go.pinniped.dev/internal/httputil/securityheader"
+	"go.pinniped.dev/internal/plog"
+)
+
+const (
+	// Path is the path at which the status page is served.
+	Path = "/status"
+	// JSONPath is the path at which the same status is served as JSON.
+	JSONPath = "/status.json"
+)
+
+//nolint:gochecknoglobals // This package uses globals to ensure that all parsing and minifying happens at init.
+var (
+	//go:embed status.css
+	rawCSS      string
+	minifiedCSS = panicOnError(minify.CSS(rawCSS))
+
+	//go:embed status.gohtml
+	rawHTMLTemplate string
+
+	parsedHTMLTemplate = template.Must(template.New("status.gohtml").Funcs(template.FuncMap{
+		"minifiedCSS": func() template.CSS { return template.CSS(minifiedCSS) },
+	}).Parse(rawHTMLTemplate))
+
+	// Only the storage Secrets have this label.
+	storageSelector = labels.NewSelector().Add(*panicOnRequirementError(labels.NewRequirement(crud.SecretLabelKey, selection.Exists, nil)))
+
+	// The page has no scripts and no external resources.
+	cspValue = strings.Join([]string{
+		`default-src 'none'`,
+		`style-src '` + csp.Hash(minifiedCSS) + `'`,
+		`frame-ancestors 'none'`,
+	}, "; ")
+)
+
+// Listers are the informer caches from which the status page is assembled. They are the same caches from which the
+// controllers derive the status conditions of the resources, so the page never makes calls to the Kubernetes API.
+type Listers struct {
+	FederationDomains                  configv1alpha1listers.FederationDomainNamespaceLister
+	OIDCIdentityProviders              idpv1alpha1listers.OIDCIdentityProviderNamespaceLister
+	LDAPIdentityProviders              idpv1alpha1listers.LDAPIdentityProviderNamespaceLister
+	ActiveDirectoryIdentityProviders   idpv1alpha1listers.ActiveDirectoryIdentityProviderNamespaceLister
+	GitHubIdentityProviders            idpv1alpha1listers.GitHubIdentityProviderNamespaceLister
+	ClientCertificateIdentityProviders idpv1alpha1listers.ClientCertificateIdentityProviderNamespaceLister
+	OpenShiftIdentityProviders         idpv1alpha1listers.OpenShiftIdentityProviderNamespaceLister
+	Secrets                            corev1listers.SecretNamespaceLister
+}
+
+// Status is the data which is rendered by the status page.
+type Status struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+
+	Resources        []Resource `json:"resources"`
+	ReadyResources   int        `json:"readyResources"`
+	UnreadyResources int        `json:"unreadyResources"`
+
+	LoginsLastFiveMinutes int `json:"loginsLastFiveMinutes"`
+	LoginsLastHour        int `json:"loginsLastHour"`
+
+	// RecentLoginErrors lists the most recent errors of the login endpoints of all FederationDomains, most recent
+	// first. They are only remembered in memory, so they are lost when the Supervisor pod restarts, and each pod
+	// only knows its own errors.
+	RecentLoginErrors []loginerrors.Error `json:"recentLoginErrors"`
+
+	// StoredSessions counts the storage Secrets by their storage type, e.g. "refresh-token".
+	StoredSessions map[string]int `json:"storedSessions"`
+
+	// GCPending counts the storage Secrets which will be garbage collected in the future, while GCOverdue counts
+	// those which should already have been garbage collected. A growing GCOverdue indicates that the garbage
+	// collector is not keeping up.
+	GCPending       int           `json:"gcPending"`
+	GCOverdue       int           `json:"gcOverdue"`
+	GCOldestOverdue time.Duration `json:"-"`
+	// GCOldestOverdueSeconds is GCOldestOverdue for the JSON representation.
+	GCOldestOverdueSeconds int64 `json:"gcOldestOverdueSeconds"`
+}
+
+// Resource summarizes the status of a FederationDomain or an identity provider.
+type Resource struct {
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+	Phase string `json:"phase"`
+	// Problems lists the messages of the conditions which are not True.
+	Problems []string `json:"problems,omitempty"`
+}
+
+// Ready returns true when the phase of the resource is "Ready".
+func (r Resource) Ready() bool {
+	return r.Phase == "Ready"
+}
+
+// ContentSecurityPolicy returns the Content-Security-Policy header value of the status page.
+func ContentSecurityPolicy() string { return cspValue }
+
+// NewHandler returns an http.Handler which serves the status page at Path, and its JSON representation at JSONPath.
+// Each request must authenticate using HTTP basic authentication with the username and password of the
+// kubernetes.io/basic-auth Secret which is named by credentialsSecretName. loginErrors may be nil.
+func NewHandler(listers *Listers, loginErrors *loginerrors.Recorder, credentialsSecretName string, clock clock.PassiveClock) http.Handler {
+	return securityheader.WrapWithCustomCSP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != Path && r.URL.Path != JSONPath {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !authenticated(r, listers.Secrets, credentialsSecretName) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="Pinniped Supervisor status", charset="UTF-8"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		status, err := Build(listers, loginErrors, clock.Now())
+		if err != nil {
+			plog.WarningErr("could not build status page", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		if r.URL.Path == JSONPath {
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(status); err != nil {
+				plog.WarningErr("could not write status as JSON", err)
+			}
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := parsedHTMLTemplate.Execute(&buf, status); err != nil {
+			plog.WarningErr("could not render status page", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(buf.Bytes())
+	}), cspValue)
+}
+
+func authenticated(r *http.Request, secrets corev1listers.SecretNamespaceLister, credentialsSecretName string) bool {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	secret, err := secrets.Get(credentialsSecretName)
+	if err != nil {
+		plog.WarningErr("could not get status page credentials secret", err, "secretName", credentialsSecretName)
+		return false
+	}
+	if secret.Type != corev1.SecretTypeBasicAuth || len(secret.Data[corev1.BasicAuthPasswordKey]) == 0 {
+		plog.Warning("status page credentials secret is not a valid kubernetes.io/basic-auth secret", "secretName", credentialsSecretName)
+		return false
+	}
+	usernameMatches := subtle.ConstantTimeCompare([]byte(username), secret.Data[corev1.BasicAuthUsernameKey]) == 1
+	passwordMatches := subtle.ConstantTimeCompare([]byte(password), secret.Data[corev1.BasicAuthPasswordKey]) == 1
+	return usernameMatches && passwordMatches
+}
+
+// Build assembles the Status from the listers and the recent login errors as of now. loginErrors may be nil.
+func Build(listers *Listers, loginErrors *loginerrors.Recorder, now time.Time) (*Status, error) {
+	status := &Status{GeneratedAt: now, StoredSessions: map[string]int{}, RecentLoginErrors: loginErrors.Recent()}
+
+	federationDomains, err := listers.FederationDomains.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, fd := range federationDomains {
+		status.add("FederationDomain", fd.Name, string(fd.Status.Phase), fd.Status.Conditions)
+	}
+
+	oidcIDPs, err := listers.OIDCIdentityProviders.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, idp := range oidcIDPs {
+		status.add("OIDCIdentityProvider", idp.Name, string(idp.Status.Phase), idp.Status.Conditions)
+	}
+
+	ldapIDPs, err := listers.LDAPIdentityProviders.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, idp := range ldapIDPs {
+		status.add("LDAPIdentityProvider", idp.Name, string(idp.Status.Phase), idp.Status.Conditions)
+	}
+
+	adIDPs, err := listers.ActiveDirectoryIdentityProviders.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, idp := range adIDPs {
+		status.add("ActiveDirectoryIdentityProvider", idp.Name, string(idp.Status.Phase), idp.Status.Conditions)
+	}
+
+	githubIDPs, err := listers.GitHubIdentityProviders.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, idp := range githubIDPs {
+		status.add("GitHubIdentityProvider", idp.Name, string(idp.Status.Phase), idp.Status.Conditions)
+	}
+
+	clientCertIDPs, err := listers.ClientCertificateIdentityProviders.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, idp := range clientCertIDPs {
+		status.add("ClientCertificateIdentityProvider", idp.Name, string(idp.Status.Phase), idp.Status.Conditions)
+	}
+
+	openShiftIDPs, err := listers.OpenShiftIdentityProviders.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, idp := range openShiftIDPs {
+		status.add("OpenShiftIdentityProvider", idp.Name, string(idp.Status.Phase), idp.Status.Conditions)
+	}
+
+	slices.SortFunc(status.Resources, func(a, b Resource) int {
+		if c := strings.Compare(a.Kind, b.Kind); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	storageSecrets, err := listers.Secrets.List(storageSelector)
+	if err != nil {
+		return nil, err
+	}
+	for _, secret := range storageSecrets {
+		storageType := secret.Labels[crud.SecretLabelKey]
+		status.StoredSessions[storageType]++
+
+		if storageType == authorizationcode.TypeLabelValue {
+			age := now.Sub(secret.CreationTimestamp.Time)
+			if age <= time.Hour {
+				status.LoginsLastHour++
+			}
+			if age <= 5*time.Minute {
+				status.LoginsLastFiveMinutes++
+			}
+		}
+
+		garbageCollectAfter, err := time.Parse(crud.SecretLifetimeAnnotationDateFormat, secret.Annotations[crud.SecretLifetimeAnnotationKey])
+		if err != nil {
+			continue // not eligible for garbage collection
+		}
+		if overdue := now.Sub(garbageCollectAfter); overdue > 0 {
+			status.GCOverdue++
+			status.GCOldestOverdue = max(status.GCOldestOverdue, overdue.Truncate(time.Second))
+		} else {
+			status.GCPending++
+		}
+	}
+	status.GCOldestOverdueSeconds = int64(status.GCOldestOverdue.Seconds())
+
+	return status, nil
+}
+
+func (s *Status) add(kind, name, phase string, conditions []metav1.Condition) {
+	resource := Resource{Kind: kind, Name: name, Phase: phase}
+	if resource.Phase == "" {
+		resource.Phase = "Pending"
+	}
+	for _, c := range conditions {
+		if c.Status != metav1.ConditionTrue {
+			resource.Problems = append(resource.Problems, c.Type+": "+c.Message)
+		}
+	}
+	if resource.Ready() {
+		s.ReadyResources++
+	} else {
+		s.UnreadyResources++
+	}
+	s.Resources = append(s.Resources, resource)
+}
+
+func panicOnError(s string, err error) string {
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func panicOnRequirementError(r *labels.Requirement, err error) *labels.Requirement {
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
@@ -0,0 +1,290 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package statuspage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
+	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
+	supervisorfake "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/fake"
+	supervisorinformers "go.pinniped.dev/generated/latest/client/supervisor/informers/externalversions"
+	"go.pinniped.dev/internal/crud"
+	"go.pinniped.dev/internal/federationdomain/loginerrors"
+)
+
+func TestStatusPage(t *testing.T) {
+	const namespace = "some-namespace"
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	storageSecret := func(name, storageType string, created time.Time, garbageCollectAfter time.Time) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         namespace,
+				CreationTimestamp: metav1.NewTime(created),
+				Labels:            map[string]string{crud.SecretLabelKey: storageType},
+				Annotations: map[string]string{
+					crud.SecretLifetimeAnnotationKey: garbageCollectAfter.Format(crud.SecretLifetimeAnnotationDateFormat),
+				},
+			},
+			Type: corev1.SecretType("storage.pinniped.dev/" + storageType),
+		}
+	}
+
+	kubeObjects := []runtime.Object{
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "status-credentials", Namespace: namespace},
+			Type:       corev1.SecretTypeBasicAuth,
+			Data: map[string][]byte{
+				corev1.BasicAuthUsernameKey: []byte("admin"),
+				corev1.BasicAuthPasswordKey: []byte("some-password"),
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "wrong-type-credentials", Namespace: namespace},
+			Type:       corev1.SecretTypeOpaque,
+			Data: map[string][]byte{
+				corev1.BasicAuthUsernameKey: []byte("admin"),
+				corev1.BasicAuthPasswordKey: []byte("some-password"),
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "not-storage", Namespace: namespace},
+			Type:       corev1.SecretTypeOpaque,
+		},
+		storageSecret("authcode-1", "authcode", now.Add(-time.Minute), now.Add(time.Hour)),
+		storageSecret("authcode-2", "authcode", now.Add(-30*time.Minute), now.Add(time.Hour)),
+		storageSecret("authcode-3", "authcode", now.Add(-2*time.Hour), now.Add(-10*time.Minute)),
+		storageSecret("refresh-token-1", "refresh-token", now.Add(-time.Minute), now.Add(time.Hour)),
+		storageSecret("refresh-token-2", "refresh-token", now.Add(-3*time.Hour), now.Add(-time.Hour)),
+	}
+	pinnipedObjects := []runtime.Object{
+		&supervisorconfigv1alpha1.FederationDomain{
+			ObjectMeta: metav1.ObjectMeta{Name: "some-fd", Namespace: namespace},
+			Status: supervisorconfigv1alpha1.FederationDomainStatus{
+				Phase:      supervisorconfigv1alpha1.FederationDomainPhaseReady,
+				Conditions: []metav1.Condition{{Type: "Ready", Status: metav1.ConditionTrue, Message: "all good"}},
+			},
+		},
+		&idpv1alpha1.OIDCIdentityProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "some-oidc", Namespace: namespace},
+			Status: idpv1alpha1.OIDCIdentityProviderStatus{
+				Phase: idpv1alpha1.PhaseError,
+				Conditions: []metav1.Condition{
+					{Type: "ClientCredentialsSecretValid", Status: metav1.ConditionTrue, Message: "loaded client credentials"},
+					{Type: "OIDCDiscoverySucceeded", Status: metav1.ConditionFalse, Message: "<b>could not reach the issuer</b>"},
+				},
+			},
+		},
+		&idpv1alpha1.GitHubIdentityProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "some-github", Namespace: namespace},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	kubeInformers := kubeinformers.NewSharedInformerFactoryWithOptions(
+		kubernetesfake.NewSimpleClientset(kubeObjects...), 0, kubeinformers.WithNamespace(namespace))
+	pinnipedInformers := supervisorinformers.NewSharedInformerFactoryWithOptions(
+		supervisorfake.NewSimpleClientset(pinnipedObjects...), 0, supervisorinformers.WithNamespace(namespace))
+	listers := &Listers{
+		FederationDomains:                  pinnipedInformers.Config().V1alpha1().FederationDomains().Lister().FederationDomains(namespace),
+		OIDCIdentityProviders:              pinnipedInformers.IDP().V1alpha1().OIDCIdentityProviders().Lister().OIDCIdentityProviders(namespace),
+		LDAPIdentityProviders:              pinnipedInformers.IDP().V1alpha1().LDAPIdentityProviders().Lister().LDAPIdentityProviders(namespace),
+		ActiveDirectoryIdentityProviders:   pinnipedInformers.IDP().V1alpha1().ActiveDirectoryIdentityProviders().Lister().ActiveDirectoryIdentityProviders(namespace),
+		GitHubIdentityProviders:            pinnipedInformers.IDP().V1alpha1().GitHubIdentityProviders().Lister().GitHubIdentityProviders(namespace),
+		ClientCertificateIdentityProviders: pinnipedInformers.IDP().V1alpha1().ClientCertificateIdentityProviders().Lister().ClientCertificateIdentityProviders(namespace),
+		OpenShiftIdentityProviders:         pinnipedInformers.IDP().V1alpha1().OpenShiftIdentityProviders().Lister().OpenShiftIdentityProviders(namespace),
+		Secrets:                            kubeInformers.Core().V1().Secrets().Lister().Secrets(namespace),
+	}
+	kubeInformers.Start(ctx.Done())
+	pinnipedInformers.Start(ctx.Done())
+	for _, synced := range kubeInformers.WaitForCacheSync(ctx.Done()) {
+		require.True(t, synced)
+	}
+	for _, synced := range pinnipedInformers.WaitForCacheSync(ctx.Done()) {
+		require.True(t, synced)
+	}
+
+	loginErrors := loginerrors.New(loginerrors.DefaultCapacity, clocktesting.NewFakeClock(now.Add(-time.Minute)))
+	loginErrors.WrapHandler("https://issuer.example.com", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://client.example.com/callback?error=access_denied&error_description=<b>denied</b>", http.StatusSeeOther)
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/oauth2/authorize", nil))
+
+	t.Run("Build", func(t *testing.T) {
+		status, err := Build(listers, loginErrors, now)
+		require.NoError(t, err)
+		require.Equal(t, &Status{
+			GeneratedAt: now,
+			Resources: []Resource{
+				{Kind: "FederationDomain", Name: "some-fd", Phase: "Ready"},
+				{Kind: "GitHubIdentityProvider", Name: "some-github", Phase: "Pending"},
+				{Kind: "OIDCIdentityProvider", Name: "some-oidc", Phase: "Error", Problems: []string{"OIDCDiscoverySucceeded: <b>could not reach the issuer</b>"}},
+			},
+			ReadyResources:        1,
+			UnreadyResources:      2,
+			LoginsLastFiveMinutes: 1,
+			LoginsLastHour:        2,
+			RecentLoginErrors: []loginerrors.Error{{
+				Time:        now.Add(-time.Minute),
+				Issuer:      "https://issuer.example.com",
+				Path:        "/oauth2/authorize",
+				Status:      http.StatusSeeOther,
+				Code:        "access_denied",
+				Description: "<b>denied</b>",
+			}},
+			StoredSessions:         map[string]int{"authcode": 3, "refresh-token": 2},
+			GCPending:              3,
+			GCOverdue:              2,
+			GCOldestOverdue:        time.Hour,
+			GCOldestOverdueSeconds: 3600,
+		}, status)
+	})
+
+	tests := []struct {
+		name                  string
+		method                string
+		path                  string
+		username, password    string
+		credentialsSecretName string
+		wantStatus            int
+		wantContentType       string
+		wantBodyContains      []string
+	}{
+		{
+			name:                  "success",
+			method:                http.MethodGet,
+			path:                  "/status",
+			username:              "admin",
+			password:              "some-password",
+			credentialsSecretName: "status-credentials",
+			wantStatus:            http.StatusOK,
+			wantContentType:       "text/html; charset=utf-8",
+			wantBodyContains: []string{
+				"<td>some-oidc</td>",
+				"OIDCDiscoverySucceeded: &lt;b&gt;could not reach the issuer&lt;/b&gt;",
+				"<tr><th>Last hour</th><td>2</td></tr>",
+				"<td>access_denied</td>",
+				"<td>&lt;b&gt;denied&lt;/b&gt;</td>",
+				"<tr><td>refresh-token</td><td>2</td></tr>",
+				"<td>1h0m0s</td>",
+			},
+		},
+		{
+			name:                  "success as JSON",
+			method:                http.MethodGet,
+			path:                  "/status.json",
+			username:              "admin",
+			password:              "some-password",
+			credentialsSecretName: "status-credentials",
+			wantStatus:            http.StatusOK,
+			wantContentType:       "application/json",
+			wantBodyContains: []string{
+				`{"kind":"OIDCIdentityProvider","name":"some-oidc","phase":"Error","problems":["OIDCDiscoverySucceeded: \u003cb\u003ecould not reach the issuer\u003c/b\u003e"]}`,
+				`"loginsLastHour":2,`,
+				`"recentLoginErrors":[{"time":"2024-06-01T11:59:00Z","issuer":"https://issuer.example.com","path":"/oauth2/authorize","status":303,"code":"access_denied","description":"\u003cb\u003edenied\u003c/b\u003e"}]`,
+				`"storedSessions":{"authcode":3,"refresh-token":2}`,
+				`"gcOldestOverdueSeconds":3600}`,
+			},
+		},
+		{
+			name:                  "JSON requires authentication too",
+			method:                http.MethodGet,
+			path:                  "/status.json",
+			credentialsSecretName: "status-credentials",
+			wantStatus:            http.StatusUnauthorized,
+		},
+		{
+			name:                  "wrong password",
+			method:                http.MethodGet,
+			path:                  "/status",
+			username:              "admin",
+			password:              "wrong",
+			credentialsSecretName: "status-credentials",
+			wantStatus:            http.StatusUnauthorized,
+		},
+		{
+			name:                  "no credentials",
+			method:                http.MethodGet,
+			path:                  "/status",
+			credentialsSecretName: "status-credentials",
+			wantStatus:            http.StatusUnauthorized,
+		},
+		{
+			name:                  "credentials secret has the wrong type",
+			method:                http.MethodGet,
+			path:                  "/status",
+			username:              "admin",
+			password:              "some-password",
+			credentialsSecretName: "wrong-type-credentials",
+			wantStatus:            http.StatusUnauthorized,
+		},
+		{
+			name:                  "credentials secret does not exist",
+			method:                http.MethodGet,
+			path:                  "/status",
+			username:              "admin",
+			password:              "some-password",
+			credentialsSecretName: "does-not-exist",
+			wantStatus:            http.StatusUnauthorized,
+		},
+		{
+			name:                  "wrong method",
+			method:                http.MethodPost,
+			path:                  "/status",
+			username:              "admin",
+			password:              "some-password",
+			credentialsSecretName: "status-credentials",
+			wantStatus:            http.StatusMethodNotAllowed,
+		},
+		{
+			name:                  "wrong path",
+			method:                http.MethodGet,
+			path:                  "/status/other",
+			username:              "admin",
+			password:              "some-password",
+			credentialsSecretName: "status-credentials",
+			wantStatus:            http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(listers, loginErrors, tt.credentialsSecretName, clocktesting.NewFakeClock(now))
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.username != "" {
+				req.SetBasicAuth(tt.username, tt.password)
+			}
+			rsp := httptest.NewRecorder()
+			handler.ServeHTTP(rsp, req)
+
+			require.Equal(t, tt.wantStatus, rsp.Code)
+			require.Equal(t, ContentSecurityPolicy(), rsp.Header().Get("Content-Security-Policy"))
+			if tt.wantContentType != "" {
+				require.Equal(t, tt.wantContentType, rsp.Header().Get("Content-Type"))
+			}
+			if tt.wantStatus == http.StatusUnauthorized {
+				require.Equal(t, `Basic realm="Pinniped Supervisor status", charset="UTF-8"`, rsp.Header().Get("WWW-Authenticate"))
+			}
+			for _, want := range tt.wantBodyContains {
+				require.Contains(t, rsp.Body.String(), want)
+			}
+		})
+	}
+}
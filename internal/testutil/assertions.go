@@ -89,7 +89,7 @@ func RequireSecurityHeadersWithLoginPageCSPs(t *testing.T, response *httptest.Re
 	// Loosely confirm that the unique CSPs needed for the login page were used.
 	cspHeader := response.Header().Get("Content-Security-Policy")
 	require.Contains(t, cspHeader, "style-src '")      // loose assertion
-	require.NotContains(t, cspHeader, "script-src")    // only needed by form_post page
+	require.Contains(t, cspHeader, "script-src '")     // loose assertion, needed for the WebAuthn script
 	require.NotContains(t, cspHeader, "img-src data:") // only needed by form_post page
 	require.NotContains(t, cspHeader, "connect-src *") // only needed by form_post page
 
@@ -1,4 +1,4 @@
-// Copyright 2023 the Pinniped contributors. All Rights Reserved.
+// Copyright 2023-2024 the Pinniped contributors. All Rights Reserved.
 // SPDX-License-Identifier: Apache-2.0
 
 package testutil
@@ -13,7 +13,38 @@ import (
 
 type ChooseIDPPageExpectedValue struct {
 	DisplayName string
+	Description string
+	IconURL     string
 	URL         string
+	LastUsed    bool
+}
+
+func (v ChooseIDPPageExpectedValue) iconHTML() string {
+	if v.IconURL == "" {
+		return ""
+	}
+	return `<img src="` + htmlEscapedForHTMLTemplate(v.IconURL) + `" alt="" width="16" height="16"> `
+}
+
+func (v ChooseIDPPageExpectedValue) descriptionHTML() string {
+	if v.Description == "" {
+		return ""
+	}
+	return `<br><small>` + htmlEscapedForHTMLTemplate(v.Description) + `</small>`
+}
+
+func (v ChooseIDPPageExpectedValue) lastUsedHTML() string {
+	if !v.LastUsed {
+		return ""
+	}
+	return `<br><small>Last used</small>`
+}
+
+func (v ChooseIDPPageExpectedValue) autofocusHTML() string {
+	if !v.LastUsed {
+		return ""
+	}
+	return ` autofocus`
 }
 
 func spaces(howMany int) string {
@@ -53,7 +84,7 @@ func ExpectedChooseIDPPageHTML(wantCSS string, wantJS string, wantIDPs []ChooseI
 		withNewline(spaces(12)+`<ul>`) +
 		withNewline(spaces(16))
 	for _, wantIDP := range wantIDPs {
-		noscript += withNewline(spaces(20)+`<li><a href="`+htmlEscapedForHTMLTemplate(wantIDP.URL)+`">`+htmlEscapedForHTMLTemplate(wantIDP.DisplayName)+`</a></li>`) +
+		noscript += withNewline(spaces(20)+`<li><a href="`+htmlEscapedForHTMLTemplate(wantIDP.URL)+`">`+wantIDP.iconHTML()+htmlEscapedForHTMLTemplate(wantIDP.DisplayName)+`</a>`+wantIDP.descriptionHTML()+wantIDP.lastUsedHTML()+`</li>`) +
 			withNewline(spaces(16))
 	}
 	noscript += withNewline(spaces(12)+`</ul>`) +
@@ -64,7 +95,7 @@ func ExpectedChooseIDPPageHTML(wantCSS string, wantJS string, wantIDPs []ChooseI
 		withNewline(spaces(8))
 	for _, wantIDP := range wantIDPs {
 		buttons += withNewline(spaces(12)+`<div class="form-field">`) +
-			withNewline(spaces(16)+`<button data-url="`+htmlEscapedForHTMLTemplate(wantIDP.URL)+`"><span>`+htmlEscapedForHTMLTemplate(wantIDP.DisplayName)+`</span></button>`) +
+			withNewline(spaces(16)+`<button data-url="`+htmlEscapedForHTMLTemplate(wantIDP.URL)+`"`+wantIDP.autofocusHTML()+`>`+wantIDP.iconHTML()+`<span>`+htmlEscapedForHTMLTemplate(wantIDP.DisplayName)+`</span>`+wantIDP.descriptionHTML()+wantIDP.lastUsedHTML()+`</button>`) +
 			withNewline(spaces(12)+`</div>`) +
 			withNewline(spaces(8))
 	}
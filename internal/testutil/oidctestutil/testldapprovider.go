@@ -12,6 +12,7 @@ import (
 	"go.pinniped.dev/internal/authenticators"
 	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
 	"go.pinniped.dev/internal/idtransform"
+	"go.pinniped.dev/internal/totp"
 )
 
 type PerformLDAPRefreshArgs struct {
@@ -33,6 +34,7 @@ type TestUpstreamLDAPIdentityProviderBuilder struct {
 	performRefreshGroups           []string
 	displayNameForFederationDomain string
 	transformsForFederationDomain  *idtransform.TransformationPipeline
+	totpConfig                     *totp.Config
 }
 
 func (t *TestUpstreamLDAPIdentityProviderBuilder) WithName(name string) *TestUpstreamLDAPIdentityProviderBuilder {
@@ -75,6 +77,11 @@ func (t *TestUpstreamLDAPIdentityProviderBuilder) WithTransformsForFederationDom
 	return t
 }
 
+func (t *TestUpstreamLDAPIdentityProviderBuilder) WithTOTPConfig(config *totp.Config) *TestUpstreamLDAPIdentityProviderBuilder {
+	t.totpConfig = config
+	return t
+}
+
 func (t *TestUpstreamLDAPIdentityProviderBuilder) Build() *TestUpstreamLDAPIdentityProvider {
 	if t.displayNameForFederationDomain == "" {
 		// default it to the CR name
@@ -93,6 +100,7 @@ func (t *TestUpstreamLDAPIdentityProviderBuilder) Build() *TestUpstreamLDAPIdent
 		PerformRefreshGroups:           t.performRefreshGroups,
 		DisplayNameForFederationDomain: t.displayNameForFederationDomain,
 		TransformsForFederationDomain:  t.transformsForFederationDomain,
+		TOTPConfig:                     t.totpConfig,
 	}
 }
 
@@ -105,6 +113,7 @@ type TestUpstreamLDAPIdentityProvider struct {
 	PerformRefreshGroups           []string
 	DisplayNameForFederationDomain string
 	TransformsForFederationDomain  *idtransform.TransformationPipeline
+	TOTPConfig                     *totp.Config
 
 	// Fields for tracking actual calls make to mock functions.
 	performRefreshCallCount int
@@ -129,6 +138,10 @@ func (u *TestUpstreamLDAPIdentityProvider) GetURL() *url.URL {
 	return u.URL
 }
 
+func (u *TestUpstreamLDAPIdentityProvider) GetTOTPConfig() *totp.Config {
+	return u.TOTPConfig
+}
+
 func (u *TestUpstreamLDAPIdentityProvider) PerformRefresh(ctx context.Context, storedRefreshAttributes upstreamprovider.LDAPRefreshAttributes, idpDisplayName string) ([]string, error) {
 	if u.performRefreshArgs == nil {
 		u.performRefreshArgs = make([]*PerformLDAPRefreshArgs, 0)
@@ -62,20 +62,21 @@ type ValidateTokenAndMergeWithUserInfoArgs struct {
 }
 
 type TestUpstreamOIDCIdentityProvider struct {
-	Name                           string
-	ClientID                       string
-	ResourceUID                    types.UID
-	AuthorizationURL               url.URL
-	UserInfoURL                    bool
-	RevocationURL                  *url.URL
-	UsernameClaim                  string
-	GroupsClaim                    string
-	Scopes                         []string
-	AdditionalAuthcodeParams       map[string]string
-	AdditionalClaimMappings        map[string]string
-	AllowPasswordGrant             bool
-	DisplayNameForFederationDomain string
-	TransformsForFederationDomain  *idtransform.TransformationPipeline
+	Name                                string
+	ClientID                            string
+	ResourceUID                         types.UID
+	AuthorizationURL                    url.URL
+	UserInfoURL                         bool
+	RevocationURL                       *url.URL
+	UsernameClaim                       string
+	GroupsClaim                         string
+	Scopes                              []string
+	AdditionalAuthcodeParams            map[string]string
+	AdditionalClaimMappings             map[string]string
+	AllowPasswordGrant                  bool
+	UseJWTSecuredAuthorizationResponses bool
+	DisplayNameForFederationDomain      string
+	TransformsForFederationDomain       *idtransform.TransformationPipeline
 
 	ExchangeAuthcodeAndValidateTokensFunc func(
 		ctx context.Context,
@@ -96,6 +97,8 @@ type TestUpstreamOIDCIdentityProvider struct {
 
 	ValidateTokenAndMergeWithUserInfoFunc func(ctx context.Context, tok *oauth2.Token, expectedIDTokenNonce nonce.Nonce) (*oidctypes.Token, error)
 
+	ValidateJWTSecuredAuthorizationResponseFunc func(ctx context.Context, response string) (url.Values, error)
+
 	// Fields for tracking actual calls make to mock functions.
 	exchangeAuthcodeAndValidateTokensCallCount         int
 	exchangeAuthcodeAndValidateTokensArgs              []*ExchangeAuthcodeAndValidateTokenArgs
@@ -107,6 +110,7 @@ type TestUpstreamOIDCIdentityProvider struct {
 	revokeTokenArgs                                    []*RevokeTokenArgs
 	validateTokenAndMergeWithUserInfoCallCount         int
 	validateTokenAndMergeWithUserInfoArgs              []*ValidateTokenAndMergeWithUserInfoArgs
+	validateJWTSecuredAuthorizationResponseArgs        []string
 }
 
 var _ upstreamprovider.UpstreamOIDCIdentityProviderI = &TestUpstreamOIDCIdentityProvider{}
@@ -159,6 +163,21 @@ func (u *TestUpstreamOIDCIdentityProvider) AllowsPasswordGrant() bool {
 	return u.AllowPasswordGrant
 }
 
+func (u *TestUpstreamOIDCIdentityProvider) UsesJWTSecuredAuthorizationResponses() bool {
+	return u.UseJWTSecuredAuthorizationResponses
+}
+
+func (u *TestUpstreamOIDCIdentityProvider) ValidateJWTSecuredAuthorizationResponse(ctx context.Context, response string) (url.Values, error) {
+	u.validateJWTSecuredAuthorizationResponseArgs = append(u.validateJWTSecuredAuthorizationResponseArgs, response)
+	return u.ValidateJWTSecuredAuthorizationResponseFunc(ctx, response)
+}
+
+// ValidateJWTSecuredAuthorizationResponseArgs returns the responses which were given to each call of
+// ValidateJWTSecuredAuthorizationResponse.
+func (u *TestUpstreamOIDCIdentityProvider) ValidateJWTSecuredAuthorizationResponseArgs() []string {
+	return u.validateJWTSecuredAuthorizationResponseArgs
+}
+
 func (u *TestUpstreamOIDCIdentityProvider) PasswordCredentialsGrantAndValidateTokens(ctx context.Context, username, password string) (*oidctypes.Token, error) {
 	u.passwordCredentialsGrantAndValidateTokensCallCount++
 	u.passwordCredentialsGrantAndValidateTokensArgs = append(u.passwordCredentialsGrantAndValidateTokensArgs, &PasswordCredentialsGrantAndValidateTokensArgs{
@@ -302,6 +321,9 @@ type TestUpstreamOIDCIdentityProviderBuilder struct {
 	additionalAuthcodeParams             map[string]string
 	additionalClaimMappings              map[string]string
 	allowPasswordGrant                   bool
+	useJWTSecuredAuthorizationResponses  bool
+	jwtSecuredAuthorizationResponse      url.Values
+	jwtSecuredAuthorizationResponseErr   error
 	authcodeExchangeErr                  error
 	passwordGrantErr                     error
 	performRefreshErr                    error
@@ -346,6 +368,15 @@ func (u *TestUpstreamOIDCIdentityProviderBuilder) WithAllowPasswordGrant(value b
 	return u
 }
 
+// WithJWTSecuredAuthorizationResponses makes the provider use JWT secured authorization responses, and makes the
+// validation of each response return the given params, or the given error if it is not nil.
+func (u *TestUpstreamOIDCIdentityProviderBuilder) WithJWTSecuredAuthorizationResponses(params url.Values, err error) *TestUpstreamOIDCIdentityProviderBuilder {
+	u.useJWTSecuredAuthorizationResponses = true
+	u.jwtSecuredAuthorizationResponse = params
+	u.jwtSecuredAuthorizationResponseErr = err
+	return u
+}
+
 func (u *TestUpstreamOIDCIdentityProviderBuilder) WithScopes(values []string) *TestUpstreamOIDCIdentityProviderBuilder {
 	u.scopes = values
 	return u
@@ -479,19 +510,20 @@ func (u *TestUpstreamOIDCIdentityProviderBuilder) Build() *TestUpstreamOIDCIdent
 	}
 
 	return &TestUpstreamOIDCIdentityProvider{
-		Name:                           u.name,
-		ClientID:                       u.clientID,
-		ResourceUID:                    u.resourceUID,
-		UsernameClaim:                  u.usernameClaim,
-		GroupsClaim:                    u.groupsClaim,
-		Scopes:                         u.scopes,
-		AllowPasswordGrant:             u.allowPasswordGrant,
-		AuthorizationURL:               u.authorizationURL,
-		UserInfoURL:                    u.hasUserInfoURL,
-		AdditionalAuthcodeParams:       u.additionalAuthcodeParams,
-		AdditionalClaimMappings:        u.additionalClaimMappings,
-		DisplayNameForFederationDomain: u.displayNameForFederationDomain,
-		TransformsForFederationDomain:  u.transformsForFederationDomain,
+		Name:                                u.name,
+		ClientID:                            u.clientID,
+		ResourceUID:                         u.resourceUID,
+		UsernameClaim:                       u.usernameClaim,
+		GroupsClaim:                         u.groupsClaim,
+		Scopes:                              u.scopes,
+		AllowPasswordGrant:                  u.allowPasswordGrant,
+		UseJWTSecuredAuthorizationResponses: u.useJWTSecuredAuthorizationResponses,
+		AuthorizationURL:                    u.authorizationURL,
+		UserInfoURL:                         u.hasUserInfoURL,
+		AdditionalAuthcodeParams:            u.additionalAuthcodeParams,
+		AdditionalClaimMappings:             u.additionalClaimMappings,
+		DisplayNameForFederationDomain:      u.displayNameForFederationDomain,
+		TransformsForFederationDomain:       u.transformsForFederationDomain,
 		ExchangeAuthcodeAndValidateTokensFunc: func(ctx context.Context, authcode string, pkceCodeVerifier oidcpkce.Code, expectedIDTokenNonce nonce.Nonce) (*oidctypes.Token, error) {
 			if u.authcodeExchangeErr != nil {
 				return nil, u.authcodeExchangeErr
@@ -513,6 +545,12 @@ func (u *TestUpstreamOIDCIdentityProviderBuilder) Build() *TestUpstreamOIDCIdent
 		RevokeTokenFunc: func(ctx context.Context, refreshToken string, tokenType upstreamprovider.RevocableTokenType) error {
 			return u.revokeTokenErr
 		},
+		ValidateJWTSecuredAuthorizationResponseFunc: func(ctx context.Context, response string) (url.Values, error) {
+			if u.jwtSecuredAuthorizationResponseErr != nil {
+				return nil, u.jwtSecuredAuthorizationResponseErr
+			}
+			return u.jwtSecuredAuthorizationResponse, nil
+		},
 		ValidateTokenAndMergeWithUserInfoFunc: func(ctx context.Context, tok *oauth2.Token, expectedIDTokenNonce nonce.Nonce) (*oidctypes.Token, error) {
 			if u.validateTokenAndMergeWithUserInfoErr != nil {
 				return nil, u.validateTokenAndMergeWithUserInfoErr
@@ -0,0 +1,42 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package testutil
+
+import (
+	"errors"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	kubetesting "k8s.io/client-go/testing"
+)
+
+// AddSecretResourceVersionReactors makes the fake client maintain the resourceVersion of Secrets like a real
+// API server does, which the fake client does not do by itself. Creates start at version 1, each update
+// increments the version, and updates of an outdated version are rejected with a conflict error.
+func AddSecretResourceVersionReactors(client *fake.Clientset) {
+	client.PrependReactor("create", "secrets", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		secret := action.(kubetesting.CreateAction).GetObject().(*corev1.Secret)
+		secret.ResourceVersion = "1"
+		return false, nil, nil
+	})
+
+	client.PrependReactor("update", "secrets", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		secret := action.(kubetesting.UpdateAction).GetObject().(*corev1.Secret)
+		existing, err := client.Tracker().Get(action.GetResource(), action.GetNamespace(), secret.Name)
+		if err != nil {
+			return false, nil, nil // let the tracker return the error
+		}
+		existingVersion := existing.(*corev1.Secret).ResourceVersion
+		if secret.ResourceVersion != existingVersion {
+			return true, nil, apierrors.NewConflict(action.GetResource().GroupResource(), secret.Name,
+				errors.New("the object has been modified; please apply your changes to the latest version and try again"))
+		}
+		version, _ := strconv.Atoi(existingVersion)
+		secret.ResourceVersion = strconv.Itoa(version + 1)
+		return false, nil, nil
+	})
+}
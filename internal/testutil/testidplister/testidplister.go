@@ -4,6 +4,7 @@
 package testidplister
 
 import (
+	"context"
 	"fmt"
 	"slices"
 	"testing"
@@ -20,6 +21,11 @@ import (
 	"go.pinniped.dev/internal/testutil/oidctestutil"
 )
 
+// RequestContextKey is the key of a value which tests may put into the context of their requests. When the expected args
+// of a call to an upstream have a context with this value, the context which was passed to the upstream is only required
+// to have the same value, since it may be derived from the context of the request, e.g. to start a tracing span.
+type RequestContextKey struct{}
+
 // TestFederationDomainIdentityProvidersListerFinder implements FederationDomainIdentityProvidersListerFinderI
 // for testing purposes.
 type TestFederationDomainIdentityProvidersListerFinder struct {
@@ -28,6 +34,7 @@ type TestFederationDomainIdentityProvidersListerFinder struct {
 	upstreamActiveDirectoryIdentityProviders []*oidctestutil.TestUpstreamLDAPIdentityProvider
 	upstreamGitHubIdentityProviders          []*oidctestutil.TestUpstreamGitHubIdentityProvider
 	defaultIDPDisplayName                    string
+	presentations                            map[string]resolvedprovider.Presentation
 }
 
 func (t *TestFederationDomainIdentityProvidersListerFinder) HasDefaultIDP() bool {
@@ -45,6 +52,7 @@ func (t *TestFederationDomainIdentityProvidersListerFinder) GetIdentityProviders
 	for _, testIDP := range t.upstreamOIDCIdentityProviders {
 		fdIDP := &resolvedoidc.FederationDomainResolvedOIDCIdentityProvider{
 			DisplayName:         testIDP.DisplayNameForFederationDomain,
+			Presentation:        t.presentations[testIDP.DisplayNameForFederationDomain],
 			Provider:            testIDP,
 			SessionProviderType: psession.ProviderTypeOIDC,
 			Transforms:          testIDP.TransformsForFederationDomain,
@@ -55,6 +63,7 @@ func (t *TestFederationDomainIdentityProvidersListerFinder) GetIdentityProviders
 	for _, testIDP := range t.upstreamLDAPIdentityProviders {
 		fdIDP := &resolvedldap.FederationDomainResolvedLDAPIdentityProvider{
 			DisplayName:         testIDP.DisplayNameForFederationDomain,
+			Presentation:        t.presentations[testIDP.DisplayNameForFederationDomain],
 			Provider:            testIDP,
 			SessionProviderType: psession.ProviderTypeLDAP,
 			Transforms:          testIDP.TransformsForFederationDomain,
@@ -65,6 +74,7 @@ func (t *TestFederationDomainIdentityProvidersListerFinder) GetIdentityProviders
 	for _, testIDP := range t.upstreamActiveDirectoryIdentityProviders {
 		fdIDP := &resolvedldap.FederationDomainResolvedLDAPIdentityProvider{
 			DisplayName:         testIDP.DisplayNameForFederationDomain,
+			Presentation:        t.presentations[testIDP.DisplayNameForFederationDomain],
 			Provider:            testIDP,
 			SessionProviderType: psession.ProviderTypeActiveDirectory,
 			Transforms:          testIDP.TransformsForFederationDomain,
@@ -75,6 +85,7 @@ func (t *TestFederationDomainIdentityProvidersListerFinder) GetIdentityProviders
 	for _, testIDP := range t.upstreamGitHubIdentityProviders {
 		fdIDP := &resolvedgithub.FederationDomainResolvedGitHubIdentityProvider{
 			DisplayName:         testIDP.DisplayNameForFederationDomain,
+			Presentation:        t.presentations[testIDP.DisplayNameForFederationDomain],
 			Provider:            testIDP,
 			SessionProviderType: psession.ProviderTypeGitHub,
 			Transforms:          testIDP.TransformsForFederationDomain,
@@ -97,6 +108,7 @@ func (t *TestFederationDomainIdentityProvidersListerFinder) FindUpstreamIDPByDis
 		if upstreamIDPDisplayName == testIDP.DisplayNameForFederationDomain {
 			return &resolvedoidc.FederationDomainResolvedOIDCIdentityProvider{
 				DisplayName:         testIDP.DisplayNameForFederationDomain,
+				Presentation:        t.presentations[testIDP.DisplayNameForFederationDomain],
 				Provider:            testIDP,
 				SessionProviderType: psession.ProviderTypeOIDC,
 				Transforms:          testIDP.TransformsForFederationDomain,
@@ -107,6 +119,7 @@ func (t *TestFederationDomainIdentityProvidersListerFinder) FindUpstreamIDPByDis
 		if upstreamIDPDisplayName == testIDP.DisplayNameForFederationDomain {
 			return &resolvedldap.FederationDomainResolvedLDAPIdentityProvider{
 				DisplayName:         testIDP.DisplayNameForFederationDomain,
+				Presentation:        t.presentations[testIDP.DisplayNameForFederationDomain],
 				Provider:            testIDP,
 				SessionProviderType: psession.ProviderTypeLDAP,
 				Transforms:          testIDP.TransformsForFederationDomain,
@@ -117,6 +130,7 @@ func (t *TestFederationDomainIdentityProvidersListerFinder) FindUpstreamIDPByDis
 		if upstreamIDPDisplayName == testIDP.DisplayNameForFederationDomain {
 			return &resolvedldap.FederationDomainResolvedLDAPIdentityProvider{
 				DisplayName:         testIDP.DisplayNameForFederationDomain,
+				Presentation:        t.presentations[testIDP.DisplayNameForFederationDomain],
 				Provider:            testIDP,
 				SessionProviderType: psession.ProviderTypeActiveDirectory,
 				Transforms:          testIDP.TransformsForFederationDomain,
@@ -127,6 +141,7 @@ func (t *TestFederationDomainIdentityProvidersListerFinder) FindUpstreamIDPByDis
 		if upstreamIDPDisplayName == testIDP.DisplayNameForFederationDomain {
 			return &resolvedgithub.FederationDomainResolvedGitHubIdentityProvider{
 				DisplayName:         testIDP.DisplayNameForFederationDomain,
+				Presentation:        t.presentations[testIDP.DisplayNameForFederationDomain],
 				Provider:            testIDP,
 				SessionProviderType: psession.ProviderTypeGitHub,
 				Transforms:          testIDP.TransformsForFederationDomain,
@@ -160,6 +175,7 @@ type UpstreamIDPListerBuilder struct {
 	upstreamActiveDirectoryIdentityProviders []*oidctestutil.TestUpstreamLDAPIdentityProvider
 	upstreamGitHubIdentityProviders          []*oidctestutil.TestUpstreamGitHubIdentityProvider
 	defaultIDPDisplayName                    string
+	presentations                            map[string]resolvedprovider.Presentation
 }
 
 func (b *UpstreamIDPListerBuilder) WithOIDC(upstreamOIDCIdentityProviders ...*oidctestutil.TestUpstreamOIDCIdentityProvider) *UpstreamIDPListerBuilder {
@@ -187,6 +203,16 @@ func (b *UpstreamIDPListerBuilder) WithDefaultIDPDisplayName(defaultIDPDisplayNa
 	return b
 }
 
+// WithPresentation configures how the IDP with the given display name is presented to end users by the
+// FederationDomainIdentityProvidersListerFinderI built by this builder.
+func (b *UpstreamIDPListerBuilder) WithPresentation(displayName string, presentation resolvedprovider.Presentation) *UpstreamIDPListerBuilder {
+	if b.presentations == nil {
+		b.presentations = map[string]resolvedprovider.Presentation{}
+	}
+	b.presentations[displayName] = presentation
+	return b
+}
+
 func (b *UpstreamIDPListerBuilder) BuildFederationDomainIdentityProvidersListerFinder() *TestFederationDomainIdentityProvidersListerFinder {
 	return &TestFederationDomainIdentityProvidersListerFinder{
 		upstreamOIDCIdentityProviders:            b.upstreamOIDCIdentityProviders,
@@ -194,6 +220,7 @@ func (b *UpstreamIDPListerBuilder) BuildFederationDomainIdentityProvidersListerF
 		upstreamActiveDirectoryIdentityProviders: b.upstreamActiveDirectoryIdentityProviders,
 		upstreamGitHubIdentityProviders:          b.upstreamGitHubIdentityProviders,
 		defaultIDPDisplayName:                    b.defaultIDPDisplayName,
+		presentations:                            b.presentations,
 	}
 }
 
@@ -250,7 +277,9 @@ func (b *UpstreamIDPListerBuilder) RequireExactlyOneCallToPasswordCredentialsGra
 	require.Equal(t, expectedPerformedByUpstreamName, actualNameOfUpstreamWhichMadeCall,
 		"PasswordCredentialsGrantAndValidateTokens() was called on the wrong OIDC upstream",
 	)
-	require.Equal(t, expectedArgs, actualArgs)
+	requireEqualArgs(t, expectedArgs, actualArgs, func(args *oidctestutil.PasswordCredentialsGrantAndValidateTokensArgs) *context.Context {
+		return &args.Ctx
+	})
 }
 
 func (b *UpstreamIDPListerBuilder) RequireExactlyZeroCallsToPasswordCredentialsGrantAndValidateTokens(t *testing.T) {
@@ -287,7 +316,7 @@ func (b *UpstreamIDPListerBuilder) RequireExactlyOneOIDCAuthcodeExchange(
 	require.Equal(t, expectedPerformedByUpstreamName, actualNameOfUpstreamWhichMadeCall,
 		"OIDC ExchangeAuthcodeAndValidateTokens() was called on the wrong upstream name",
 	)
-	require.Equal(t, expectedArgs, actualArgs)
+	requireEqualArgs(t, expectedArgs, actualArgs, func(args *oidctestutil.ExchangeAuthcodeAndValidateTokenArgs) *context.Context { return &args.Ctx })
 }
 
 func (b *UpstreamIDPListerBuilder) RequireExactlyOneGitHubAuthcodeExchange(
@@ -313,7 +342,7 @@ func (b *UpstreamIDPListerBuilder) RequireExactlyOneGitHubAuthcodeExchange(
 	require.Equal(t, expectedPerformedByUpstreamName, actualNameOfUpstreamWhichMadeCall,
 		"GitHub ExchangeAuthcode() was called on the wrong upstream name",
 	)
-	require.Equal(t, expectedArgs, actualArgs)
+	requireEqualArgs(t, expectedArgs, actualArgs, func(args *oidctestutil.ExchangeAuthcodeArgs) *context.Context { return &args.Ctx })
 }
 
 func (b *UpstreamIDPListerBuilder) RequireExactlyZeroAuthcodeExchanges(t *testing.T) {
@@ -352,7 +381,7 @@ func (b *UpstreamIDPListerBuilder) RequireExactlyOneCallToOIDCPerformRefresh(
 	require.Equal(t, expectedPerformedByUpstreamName, actualNameOfUpstreamWhichMadeCall,
 		"upstream refresh was called on the wrong upstream",
 	)
-	require.Equal(t, expectedArgs, actualArgs)
+	requireEqualArgs(t, expectedArgs, actualArgs, func(args *oidctestutil.PerformOIDCRefreshArgs) *context.Context { return &args.Ctx })
 }
 
 func (b *UpstreamIDPListerBuilder) RequireExactlyOneCallToActiveDirectoryPerformRefresh(
@@ -376,7 +405,7 @@ func (b *UpstreamIDPListerBuilder) RequireExactlyOneCallToActiveDirectoryPerform
 	require.Equal(t, expectedPerformedByUpstreamName, actualNameOfUpstreamWhichMadeCall,
 		"upstream refresh was called on the wrong upstream",
 	)
-	require.Equal(t, expectedArgs, actualArgs)
+	requireEqualArgs(t, expectedArgs, actualArgs, func(args *oidctestutil.PerformLDAPRefreshArgs) *context.Context { return &args.Ctx })
 }
 
 func (b *UpstreamIDPListerBuilder) RequireExactlyOneCallToLDAPPerformRefresh(
@@ -400,7 +429,7 @@ func (b *UpstreamIDPListerBuilder) RequireExactlyOneCallToLDAPPerformRefresh(
 	require.Equal(t, expectedPerformedByUpstreamName, actualNameOfUpstreamWhichMadeCall,
 		"upstream refresh was called on the wrong upstream",
 	)
-	require.Equal(t, expectedArgs, actualArgs)
+	requireEqualArgs(t, expectedArgs, actualArgs, func(args *oidctestutil.PerformLDAPRefreshArgs) *context.Context { return &args.Ctx })
 }
 
 func (b *UpstreamIDPListerBuilder) RequireExactlyOneCallToGithubGetUser(
@@ -425,7 +454,7 @@ func (b *UpstreamIDPListerBuilder) RequireExactlyOneCallToGithubGetUser(
 	require.Equal(t, expectedPerformedByUpstreamName, actualNameOfUpstreamWhichMadeCall,
 		"upstream refresh was called on the wrong upstream",
 	)
-	require.Equal(t, expectedArgs, actualArgs)
+	requireEqualArgs(t, expectedArgs, actualArgs, func(args *oidctestutil.GetUserArgs) *context.Context { return &args.Ctx })
 }
 
 func (b *UpstreamIDPListerBuilder) RequireExactlyZeroCallsToAnyUpstreamRefresh(t *testing.T) {
@@ -475,7 +504,9 @@ func (b *UpstreamIDPListerBuilder) RequireExactlyOneCallToValidateToken(
 	require.Equal(t, expectedPerformedByUpstreamName, actualNameOfUpstreamWhichMadeCall,
 		"ValidateTokenAndMergeWithUserInfo() was called on the wrong OIDC upstream",
 	)
-	require.Equal(t, expectedArgs, actualArgs)
+	requireEqualArgs(t, expectedArgs, actualArgs, func(args *oidctestutil.ValidateTokenAndMergeWithUserInfoArgs) *context.Context {
+		return &args.Ctx
+	})
 }
 
 func (b *UpstreamIDPListerBuilder) RequireExactlyZeroCallsToValidateToken(t *testing.T) {
@@ -512,7 +543,9 @@ func (b *UpstreamIDPListerBuilder) RequireExactlyOneCallToRevokeToken(
 	require.Equal(t, expectedPerformedByUpstreamName, actualNameOfUpstreamWhichMadeCall,
 		"RevokeToken() was called on the wrong OIDC upstream",
 	)
-	require.Equal(t, expectedArgs, actualArgs)
+	requireEqualArgs(t, expectedArgs, actualArgs, func(args *oidctestutil.RevokeTokenArgs) *context.Context {
+		return &args.Ctx
+	})
 }
 
 func (b *UpstreamIDPListerBuilder) RequireExactlyZeroCallsToRevokeToken(t *testing.T) {
@@ -529,3 +562,23 @@ func (b *UpstreamIDPListerBuilder) RequireExactlyZeroCallsToRevokeToken(t *testi
 func NewUpstreamIDPListerBuilder() *UpstreamIDPListerBuilder {
 	return &UpstreamIDPListerBuilder{}
 }
+
+// requireEqualArgs requires that the args of a call to an upstream are equal to the expected args. The contexts are
+// compared by the value of RequestContextKey when the expected context has one, and are otherwise compared as usual.
+func requireEqualArgs[T any](t *testing.T, expectedArgs, actualArgs *T, contextOf func(args *T) *context.Context) {
+	t.Helper()
+	if expectedArgs == nil || actualArgs == nil {
+		require.Equal(t, expectedArgs, actualArgs)
+		return
+	}
+	expected, actual := *expectedArgs, *actualArgs
+	expectedCtx, actualCtx := contextOf(&expected), contextOf(&actual)
+	if *expectedCtx != nil && (*expectedCtx).Value(RequestContextKey{}) != nil {
+		require.NotNil(t, *actualCtx, "the upstream was not called with a context")
+		require.Equal(t, (*expectedCtx).Value(RequestContextKey{}), (*actualCtx).Value(RequestContextKey{}),
+			"the upstream was not called with a context which was derived from the context of the request",
+		)
+		*expectedCtx, *actualCtx = nil, nil
+	}
+	require.Equal(t, &expected, &actual)
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package totp implements the time-based one-time passwords of RFC 6238, which are generated by authenticator apps.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // This is an implementation of an RFC that used SHA-1, which is also what authenticator apps expect
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Period is the number of seconds for which each code is valid.
+	Period = 30
+
+	// Digits is the number of digits of each code.
+	Digits = 6
+
+	// DefaultIssuer is shown by authenticator apps next to the account name when no issuer was configured.
+	DefaultIssuer = "Pinniped"
+
+	// secretSize is the size of generated secrets in bytes, as recommended by RFC 4226 section 4.
+	secretSize = 20
+
+	// skew is the number of periods before and after the current period whose codes are also accepted,
+	// to allow for clock drift and for the time which it takes the user to type the code.
+	skew = 1
+)
+
+// Config is the configuration of the TOTP second factor of an identity provider.
+type Config struct {
+	// Issuer is shown by authenticator apps next to the account name.
+	Issuer string
+}
+
+// GenerateSecret returns a new random secret for a user's authenticator app.
+func GenerateSecret() ([]byte, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("could not generate TOTP secret: %w", err)
+	}
+	return secret, nil
+}
+
+// EncodeSecret returns the secret in the base32 form which users can type into their authenticator app.
+func EncodeSecret(secret []byte) string {
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret)
+}
+
+// KeyURI returns the otpauth:// URI which authenticator apps use to enroll the secret for the account.
+func KeyURI(issuer, accountName string, secret []byte) string {
+	query := url.Values{}
+	query.Set("secret", EncodeSecret(secret))
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(Digits))
+	query.Set("period", fmt.Sprint(Period))
+	return (&url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + accountName,
+		RawQuery: query.Encode(),
+	}).String()
+}
+
+// Step returns the time step which contains t.
+func Step(t time.Time) int64 {
+	return t.Unix() / Period
+}
+
+// Code returns the code for the given time step.
+func Code(secret []byte, step int64) string {
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step))
+
+	mac := hmac.New(sha1.New, secret)
+	_, _ = mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation, see RFC 4226 section 5.3.
+	offset := sum[len(sum)-1] & 0xf
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", Digits, value%1_000_000)
+}
+
+// Validate checks the code which the user entered at time t. When the code is valid, it returns the time step
+// of the code, which the caller must remember and pass as lastUsedStep next time, so that each code can be used
+// only once. Codes of the steps up to and including lastUsedStep are rejected.
+func Validate(secret []byte, code string, t time.Time, lastUsedStep int64) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != Digits {
+		return 0, false
+	}
+
+	current := Step(t)
+	for step := current - skew; step <= current+skew; step++ {
+		if step <= lastUsedStep {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(Code(secret, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,142 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The secret of the SHA-1 test vectors of RFC 6238 appendix B.
+var rfcSecret = []byte("12345678901234567890")
+
+func TestCode(t *testing.T) {
+	// RFC 6238 uses 8 digit codes for its test vectors, so the expected values are their last 6 digits.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			require.Equal(t, tt.want, Code(rfcSecret, Step(time.Unix(tt.unix, 0))))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	currentStep := Step(now)
+
+	tests := []struct {
+		name         string
+		code         string
+		lastUsedStep int64
+		wantStep     int64
+		wantValid    bool
+	}{
+		{
+			name:      "current code",
+			code:      "005924",
+			wantStep:  currentStep,
+			wantValid: true,
+		},
+		{
+			name:      "current code typed with spaces",
+			code:      " 005 924 ",
+			wantStep:  currentStep,
+			wantValid: true,
+		},
+		{
+			name:      "code of the previous period",
+			code:      Code(rfcSecret, currentStep-1),
+			wantStep:  currentStep - 1,
+			wantValid: true,
+		},
+		{
+			name:      "code of the next period",
+			code:      Code(rfcSecret, currentStep+1),
+			wantStep:  currentStep + 1,
+			wantValid: true,
+		},
+		{
+			name: "code from too long ago",
+			code: Code(rfcSecret, currentStep-2),
+		},
+		{
+			name: "code from too far in the future",
+			code: Code(rfcSecret, currentStep+2),
+		},
+		{
+			name:         "code which was already used",
+			code:         "005924",
+			lastUsedStep: currentStep,
+		},
+		{
+			name:         "code which is older than the last used code",
+			code:         Code(rfcSecret, currentStep-1),
+			lastUsedStep: currentStep,
+		},
+		{
+			name: "wrong code",
+			code: "123456",
+		},
+		{
+			name: "too short",
+			code: "05924",
+		},
+		{
+			name: "empty",
+			code: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, valid := Validate(rfcSecret, tt.code, now, tt.lastUsedStep)
+			require.Equal(t, tt.wantValid, valid)
+			require.Equal(t, tt.wantStep, step)
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret1, err := GenerateSecret()
+	require.NoError(t, err)
+	require.Len(t, secret1, 20)
+
+	secret2, err := GenerateSecret()
+	require.NoError(t, err)
+	require.NotEqual(t, secret1, secret2)
+}
+
+func TestKeyURI(t *testing.T) {
+	uri := KeyURI("Some Issuer", "some-user@example.com", rfcSecret)
+
+	parsed, err := url.Parse(uri)
+	require.NoError(t, err)
+	require.Equal(t, "otpauth", parsed.Scheme)
+	require.Equal(t, "totp", parsed.Host)
+	require.Equal(t, "/Some Issuer:some-user@example.com", parsed.Path)
+
+	query := parsed.Query()
+	require.Equal(t, "Some Issuer", query.Get("issuer"))
+	require.Equal(t, "SHA1", query.Get("algorithm"))
+	require.Equal(t, "6", query.Get("digits"))
+	require.Equal(t, "30", query.Get("period"))
+
+	decoded, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(query.Get("secret"))
+	require.NoError(t, err)
+	require.Equal(t, rfcSecret, decoded)
+	require.Equal(t, query.Get("secret"), EncodeSecret(rfcSecret))
+}
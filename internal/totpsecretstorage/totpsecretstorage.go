@@ -0,0 +1,192 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package totpsecretstorage stores the TOTP secrets of the users of the identity providers which require
+// a second factor. The secrets are encrypted before they are written into Secrets.
+package totpsecretstorage
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"go.pinniped.dev/internal/constable"
+	"go.pinniped.dev/internal/crud"
+)
+
+const (
+	TypeLabelValue = "totp-secret"
+
+	ErrTOTPSecretStorageVersion = constable.Error("TOTP secret storage data has wrong version")
+	ErrNoEncryptionKey          = constable.Error("the key for encrypting TOTP secrets is not available yet")
+
+	// Version 1 was the initial release of the TOTP second factor.
+	totpSecretStorageVersion = "1"
+
+	// encryptionKeyInfo is used to derive the encryption key for TOTP secrets from the Supervisor's key,
+	// so that the same key is never used for two purposes.
+	encryptionKeyInfo = "pinniped-totp-secret-encryption-key"
+)
+
+// Enrollment is the TOTP enrollment of a user of an identity provider.
+type Enrollment struct {
+	// Secret is shared with the authenticator app of the user.
+	Secret []byte
+	// Confirmed is true once the user entered a valid code for the secret, which proves that their
+	// authenticator app was set up.
+	Confirmed bool
+	// LastUsedStep is the time step of the last code which the user entered, so that codes cannot be reused.
+	LastUsedStep int64
+	// FailedAttempts is the number of consecutive codes which were rejected, and LastFailedStep is the time step
+	// at which the last one was rejected, so that guessing codes can be limited.
+	FailedAttempts int
+	LastFailedStep int64
+}
+
+// storedEnrollment defines the format of an enrollment when stored in a Secret as a JSON string value.
+type storedEnrollment struct {
+	// The secret encrypted using AES-GCM, prefixed by the nonce.
+	EncryptedSecret []byte `json:"encryptedSecret"`
+	Confirmed       bool   `json:"confirmed"`
+	LastUsedStep    int64  `json:"lastUsedStep"`
+	FailedAttempts  int    `json:"failedAttempts,omitempty"`
+	LastFailedStep  int64  `json:"lastFailedStep,omitempty"`
+	// The format version. Take care when updating. We cannot simply bump the storage version and drop/ignore old data.
+	// Updating this would require some form of migration of existing stored data.
+	Version string `json:"version"`
+}
+
+type TOTPSecretStorage struct {
+	storage          crud.Storage
+	getSupervisorKey func() []byte
+}
+
+// New returns storage which encrypts the secrets using a key derived from the key returned by getSupervisorKey.
+// When that key changes, for example because its Secret was deleted, then all stored enrollments become unreadable
+// and users will need to enroll again.
+func New(secrets corev1client.SecretInterface, getSupervisorKey func() []byte) *TOTPSecretStorage {
+	return &TOTPSecretStorage{
+		storage:          crud.New(TypeLabelValue, secrets, nil), // can use nil clock because we are using infinite lifetime for creates
+		getSupervisorKey: getSupervisorKey,
+	}
+}
+
+// Get returns the resourceVersion of the storage secret, the enrollment of the user, and an error.
+// When the user has not enrolled yet, it will simply return "", nil, nil to make it easy to pass the
+// results of Get directly to Set.
+func (s *TOTPSecretStorage) Get(ctx context.Context, idpResourceName, username string) (string, *Enrollment, error) {
+	stored := &storedEnrollment{}
+	name := toName(idpResourceName, username)
+	rv, err := s.storage.Get(ctx, name, stored)
+	if apierrors.IsNotFound(err) {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get TOTP secret: %w", err)
+	}
+	if stored.Version != totpSecretStorageVersion {
+		return "", nil, fmt.Errorf("%w: TOTP secret storage has version %s instead of %s",
+			ErrTOTPSecretStorageVersion, stored.Version, totpSecretStorageVersion)
+	}
+
+	aead, err := s.aead()
+	if err != nil {
+		return "", nil, err
+	}
+	nonceSize := aead.NonceSize()
+	if len(stored.EncryptedSecret) < nonceSize {
+		return "", nil, fmt.Errorf("failed to decrypt TOTP secret: ciphertext too short")
+	}
+	secret, err := aead.Open(nil, stored.EncryptedSecret[:nonceSize], stored.EncryptedSecret[nonceSize:], []byte(name))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to decrypt TOTP secret: %w", err)
+	}
+
+	return rv, &Enrollment{
+		Secret:         secret,
+		Confirmed:      stored.Confirmed,
+		LastUsedStep:   stored.LastUsedStep,
+		FailedAttempts: stored.FailedAttempts,
+		LastFailedStep: stored.LastFailedStep,
+	}, nil
+}
+
+// Set will create or update the enrollment of the user.
+// Set takes the resourceVersion to know if we are doing a create or update and to ensure we do not edit an old
+// version of the storage secret, which also ensures that a code cannot be used by two concurrent logins.
+func (s *TOTPSecretStorage) Set(ctx context.Context, resourceVersion, idpResourceName, username string, enrollment *Enrollment) error {
+	aead, err := s.aead()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce for TOTP secret: %w", err)
+	}
+
+	name := toName(idpResourceName, username)
+	stored := &storedEnrollment{
+		EncryptedSecret: aead.Seal(nonce, nonce, enrollment.Secret, []byte(name)),
+		Confirmed:       enrollment.Confirmed,
+		LastUsedStep:    enrollment.LastUsedStep,
+		FailedAttempts:  enrollment.FailedAttempts,
+		LastFailedStep:  enrollment.LastFailedStep,
+		Version:         totpSecretStorageVersion,
+	}
+
+	if mustBeCreate := len(resourceVersion) == 0; mustBeCreate {
+		if _, err := s.storage.Create(ctx, name, stored, nil, nil, 0); err != nil { // 0 is infinite lifetime
+			return fmt.Errorf("failed to create TOTP secret: %w", err)
+		}
+		return nil
+	}
+
+	if _, err := s.storage.Update(ctx, name, resourceVersion, stored); err != nil {
+		return fmt.Errorf("failed to update TOTP secret: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the enrollment of the user, so that they will be asked to enroll again at their next login.
+func (s *TOTPSecretStorage) Delete(ctx context.Context, idpResourceName, username string) error {
+	err := s.storage.Delete(ctx, toName(idpResourceName, username))
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete TOTP secret: %w", err)
+	}
+	return nil
+}
+
+// GetName returns the name of the Secret which would be used to store the enrollment of the user.
+func (s *TOTPSecretStorage) GetName(idpResourceName, username string) string {
+	return s.storage.GetName(toName(idpResourceName, username))
+}
+
+func (s *TOTPSecretStorage) aead() (cipher.AEAD, error) {
+	supervisorKey := s.getSupervisorKey()
+	if len(supervisorKey) == 0 {
+		return nil, ErrNoEncryptionKey
+	}
+	mac := hmac.New(sha256.New, supervisorKey)
+	_, _ = mac.Write([]byte(encryptionKeyInfo))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher for TOTP secret: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func toName(idpResourceName, username string) string {
+	// Hash the names, since usernames may be long and may contain characters which are not allowed in
+	// the name of a Secret. Encode the hash here, since s.storage.GetName() will base64 decode it anyway.
+	sum := sha256.Sum256([]byte(idpResourceName + "\x00" + username))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
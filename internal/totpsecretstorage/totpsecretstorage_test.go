@@ -0,0 +1,114 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package totpsecretstorage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"go.pinniped.dev/internal/testutil"
+)
+
+const namespace = "some-namespace"
+
+func TestSetAndGet(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	testutil.AddSecretResourceVersionReactors(client)
+	secrets := client.CoreV1().Secrets(namespace)
+	key := []byte("some-supervisor-key")
+	subject := New(secrets, func() []byte { return key })
+
+	rv, enrollment, err := subject.Get(ctx, "some-idp", "some-user")
+	require.NoError(t, err)
+	require.Empty(t, rv)
+	require.Nil(t, enrollment)
+
+	err = subject.Set(ctx, rv, "some-idp", "some-user", &Enrollment{Secret: []byte("some-secret")})
+	require.NoError(t, err)
+
+	stored, err := secrets.Get(ctx, subject.GetName("some-idp", "some-user"), metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, corev1.SecretType("storage.pinniped.dev/totp-secret"), stored.Type)
+	require.Equal(t, "totp-secret", stored.Labels["storage.pinniped.dev/type"])
+	require.NotContains(t, string(stored.Data["pinniped-storage-data"]), "some-secret")
+
+	rv, enrollment, err = subject.Get(ctx, "some-idp", "some-user")
+	require.NoError(t, err)
+	require.Equal(t, &Enrollment{Secret: []byte("some-secret")}, enrollment)
+
+	err = subject.Set(ctx, rv, "some-idp", "some-user", &Enrollment{Secret: []byte("some-secret"), Confirmed: true, LastUsedStep: 42, FailedAttempts: 2, LastFailedStep: 41})
+	require.NoError(t, err)
+
+	_, enrollment, err = subject.Get(ctx, "some-idp", "some-user")
+	require.NoError(t, err)
+	require.Equal(t, &Enrollment{Secret: []byte("some-secret"), Confirmed: true, LastUsedStep: 42, FailedAttempts: 2, LastFailedStep: 41}, enrollment)
+
+	// Updating an old version fails, so two concurrent logins cannot both use the same code.
+	err = subject.Set(ctx, rv, "some-idp", "some-user", &Enrollment{Secret: []byte("some-secret"), Confirmed: true, LastUsedStep: 43})
+	require.ErrorContains(t, err, "failed to update TOTP secret")
+
+	// Other users and other identity providers have their own enrollments.
+	_, enrollment, err = subject.Get(ctx, "some-idp", "some-other-user")
+	require.NoError(t, err)
+	require.Nil(t, enrollment)
+	_, enrollment, err = subject.Get(ctx, "some-other-idp", "some-user")
+	require.NoError(t, err)
+	require.Nil(t, enrollment)
+
+	require.NoError(t, subject.Delete(ctx, "some-idp", "some-user"))
+	require.NoError(t, subject.Delete(ctx, "some-idp", "some-user"))
+	_, enrollment, err = subject.Get(ctx, "some-idp", "some-user")
+	require.NoError(t, err)
+	require.Nil(t, enrollment)
+}
+
+func TestGetWithChangedKey(t *testing.T) {
+	ctx := context.Background()
+	secrets := fake.NewSimpleClientset().CoreV1().Secrets(namespace)
+
+	require.NoError(t, New(secrets, func() []byte { return []byte("some-supervisor-key") }).
+		Set(ctx, "", "some-idp", "some-user", &Enrollment{Secret: []byte("some-secret")}))
+
+	_, _, err := New(secrets, func() []byte { return []byte("some-other-supervisor-key") }).
+		Get(ctx, "some-idp", "some-user")
+	require.ErrorContains(t, err, "failed to decrypt TOTP secret")
+}
+
+func TestWithoutKey(t *testing.T) {
+	ctx := context.Background()
+	secrets := fake.NewSimpleClientset().CoreV1().Secrets(namespace)
+	subject := New(secrets, func() []byte { return nil })
+
+	err := subject.Set(ctx, "", "some-idp", "some-user", &Enrollment{Secret: []byte("some-secret")})
+	require.ErrorIs(t, err, ErrNoEncryptionKey)
+}
+
+func TestGetWrongVersion(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	subject := New(client.CoreV1().Secrets(namespace), func() []byte { return []byte("some-supervisor-key") })
+
+	_, err := client.CoreV1().Secrets(namespace).Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      subject.GetName("some-idp", "some-user"),
+			Namespace: namespace,
+			Labels:    map[string]string{"storage.pinniped.dev/type": "totp-secret"},
+		},
+		Type: "storage.pinniped.dev/totp-secret",
+		Data: map[string][]byte{
+			"pinniped-storage-data":    []byte(`{"encryptedSecret":"","version":"wrong"}`),
+			"pinniped-storage-version": []byte("1"),
+		},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	_, _, err = subject.Get(ctx, "some-idp", "some-user")
+	require.EqualError(t, err, "TOTP secret storage data has wrong version: TOTP secret storage has version wrong instead of 1")
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package tracing configures the OpenTelemetry tracing of the Supervisor and Concierge.
+//
+// The spans are exported to an OTLP collector using the same implementation as the Kubernetes components,
+// so the configuration is the same as that of the tracing of the Kubernetes API server. The TracerProvider is
+// set globally, so that the HTTP servers and clients of all packages use it without needing to be passed it.
+// When tracing is not configured, the global TracerProvider does nothing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	componenttracing "k8s.io/component-base/tracing"
+	tracingapiv1 "k8s.io/component-base/tracing/api/v1"
+
+	"go.pinniped.dev/internal/httputil/roundtripper"
+	"go.pinniped.dev/internal/plog"
+)
+
+const (
+	// shutdownTimeout is how long to wait for the remaining spans to be exported when the server stops.
+	shutdownTimeout = 5 * time.Second
+
+	// instrumentationScope identifies the spans which are started by Pinniped's own code.
+	instrumentationScope = "go.pinniped.dev"
+
+	// AttributeIdentityProvider is the display name of the identity provider of a login.
+	AttributeIdentityProvider = attribute.Key("pinniped.identity_provider")
+	// AttributeAuthenticator is the kind and name of the authenticator of a TokenCredentialRequest.
+	AttributeAuthenticator = attribute.Key("pinniped.authenticator")
+)
+
+// tracerProvider is the TracerProvider which was created by Setup, if any.
+var tracerProvider = componenttracing.NewNoopTracerProvider()
+
+// Validate returns an error when the config is invalid. A nil config is valid, and disables tracing.
+func Validate(config *tracingapiv1.TracingConfiguration) error {
+	return tracingapiv1.ValidateTracingConfiguration(config, nil, field.NewPath("tracing")).ToAggregate()
+}
+
+// Setup sets the global TracerProvider and propagators according to the validated config. The serviceName
+// identifies the server in the exported spans. The returned func exports the remaining spans, and should be
+// called before the server exits. When the config is nil, Setup does nothing.
+func Setup(ctx context.Context, config *tracingapiv1.TracingConfiguration, serviceName string) (func(), error) {
+	if config == nil {
+		return func() {}, nil
+	}
+
+	provider, err := componenttracing.NewProvider(ctx, config, nil, []resource.Option{
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create tracer provider: %w", err)
+	}
+
+	tracerProvider = provider
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(componenttracing.Propagators())
+
+	return func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := provider.Shutdown(shutdownCtx); err != nil {
+			plog.Warning("could not export the remaining spans", "err", err)
+		}
+	}, nil
+}
+
+// TracerProvider returns the TracerProvider which was created by Setup, or a TracerProvider which does nothing when
+// tracing is not configured. Unlike otel.GetTracerProvider, it can be shut down, so it can be used in the config of
+// the Kubernetes generic API server.
+func TracerProvider() componenttracing.TracerProvider {
+	return tracerProvider
+}
+
+// Start starts a span using the global TracerProvider. The span is a child of the span in the context, if any.
+// The caller must end the span.
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationScope).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// EndWithError records the error on the span, if any, and ends the span.
+func EndWithError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// WrapHandler returns a handler which starts a server span for each request, using the global TracerProvider.
+// The span continues the trace of the incoming request when it carries a trace context.
+func WrapHandler(handler http.Handler, spanName string) http.Handler {
+	return componenttracing.WithTracing(handler, otel.GetTracerProvider(), spanName)
+}
+
+// WrapTransport returns a RoundTripper which starts a client span for each request, using the global TracerProvider,
+// and which propagates the trace context to the server. The span is a child of the span in the request's context.
+func WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	// Keep the delegate reachable, so callers can still find the TLS config of the underlying transport.
+	return roundtripper.WrapFunc(rt, otelhttp.NewTransport(rt,
+		otelhttp.WithTracerProvider(otel.GetTracerProvider()),
+		otelhttp.WithPropagators(otel.GetTextMapPropagator()),
+	).RoundTrip)
+}
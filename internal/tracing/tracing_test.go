@@ -0,0 +1,108 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	componenttracing "k8s.io/component-base/tracing"
+	tracingapiv1 "k8s.io/component-base/tracing/api/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Validate(nil))
+	require.NoError(t, Validate(&tracingapiv1.TracingConfiguration{
+		Endpoint:               ptr.To("otel-collector.observability.svc:4317"),
+		SamplingRatePerMillion: ptr.To[int32](1000),
+	}))
+	require.EqualError(t, Validate(&tracingapiv1.TracingConfiguration{SamplingRatePerMillion: ptr.To[int32](-1)}),
+		"tracing.samplingRatePerMillion: Invalid value: -1: sampling rate must be positive")
+}
+
+func TestSetupWithoutConfig(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	shutdown, err := Setup(context.Background(), nil, "some-service")
+	require.NoError(t, err)
+	shutdown()
+	require.Equal(t, previous, otel.GetTracerProvider())
+}
+
+// useSpanRecorder sets a global TracerProvider which records all spans for the duration of the test.
+func useSpanRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(componenttracing.Propagators())
+	return recorder
+}
+
+func TestStartAndEndWithError(t *testing.T) {
+	recorder := useSpanRecorder(t)
+
+	ctx, parent := Start(context.Background(), "parent")
+	_, child := Start(ctx, "child", AttributeIdentityProvider.String("some-idp"))
+	EndWithError(child, errors.New("some error"))
+	EndWithError(parent, nil)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	require.Equal(t, "child", spans[0].Name())
+	require.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	require.Equal(t, codes.Error, spans[0].Status().Code)
+	require.Equal(t, "some error", spans[0].Status().Description)
+	require.Len(t, spans[0].Events(), 1)
+	require.Equal(t, "some-idp", spans[0].Attributes()[0].Value.AsString())
+
+	require.Equal(t, "parent", spans[1].Name())
+	require.Equal(t, codes.Unset, spans[1].Status().Code)
+}
+
+func TestWrapHandlerAndTransport(t *testing.T) {
+	recorder := useSpanRecorder(t)
+
+	var gotTraceParent string
+	server := httptest.NewServer(WrapHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTraceParent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusNoContent)
+	}), "some-server"))
+	t.Cleanup(server.Close)
+
+	rt := WrapTransport(http.DefaultTransport)
+	wrapper, ok := rt.(interface{ WrappedRoundTripper() http.RoundTripper })
+	require.True(t, ok)
+	require.Equal(t, http.DefaultTransport, wrapper.WrappedRoundTripper())
+
+	ctx, parent := Start(context.Background(), "parent")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	rsp, err := (&http.Client{Transport: rt}).Do(req)
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+	parent.End()
+
+	// The server continued the trace of the client.
+	require.Contains(t, gotTraceParent, parent.SpanContext().TraceID().String())
+	for _, span := range recorder.Ended() {
+		require.Equal(t, parent.SpanContext().TraceID(), span.SpanContext().TraceID(), span.Name())
+	}
+	require.Len(t, recorder.Ended(), 3)
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package upstreamclientcert implements an abstraction of upstream client certificate (mutual TLS) authentication.
+package upstreamclientcert
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
+	"go.pinniped.dev/internal/federationdomain/downstreamsubject"
+	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
+)
+
+// ProviderConfig holds the active configuration of an upstream client certificate provider.
+type ProviderConfig struct {
+	Name        string
+	ResourceUID types.UID
+
+	// Roots is the pool of certificate authorities which may have signed the client certificates.
+	Roots *x509.CertPool
+
+	UsernameField idpv1alpha1.ClientCertificateSubjectField
+	GroupsField   idpv1alpha1.ClientCertificateSubjectField
+
+	// Now returns the current time. It is used when verifying the certificate chain. Defaults to time.Now.
+	Now func() time.Time
+}
+
+type Provider struct {
+	c ProviderConfig
+}
+
+var _ upstreamprovider.UpstreamClientCertificateIdentityProviderI = &Provider{}
+
+// New creates a Provider. The config is not a pointer to ensure that a copy of the config is created,
+// making the resulting Provider use an effectively read-only configuration.
+func New(config ProviderConfig) *Provider {
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	return &Provider{c: config}
+}
+
+func (p *Provider) GetResourceName() string {
+	return p.c.Name
+}
+
+func (p *Provider) GetResourceUID() types.UID {
+	return p.c.ResourceUID
+}
+
+// AuthenticateCertificate verifies the client certificate chain against the configured certificate authorities
+// and then maps the fields of the leaf certificate to a username and groups.
+func (p *Provider) AuthenticateCertificate(peerCertificates []*x509.Certificate, idpDisplayName string) (*upstreamprovider.ClientCertificateUser, error) {
+	if len(peerCertificates) == 0 {
+		return nil, errors.New("no client certificate was presented during the TLS handshake")
+	}
+
+	leaf := peerCertificates[0]
+	intermediates := x509.NewCertPool()
+	for _, cert := range peerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         p.c.Roots,
+		Intermediates: intermediates,
+		CurrentTime:   p.c.Now(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return nil, fmt.Errorf("client certificate verification failed: %w", err)
+	}
+
+	var username string
+	switch p.c.UsernameField {
+	case idpv1alpha1.ClientCertificateSubjectCommonName:
+		username = leaf.Subject.CommonName
+	case idpv1alpha1.ClientCertificateSubjectEmailAddress:
+		if len(leaf.EmailAddresses) > 0 {
+			username = leaf.EmailAddresses[0]
+		}
+	case idpv1alpha1.ClientCertificateSubjectOrganization, idpv1alpha1.ClientCertificateSubjectOrganizationalUnit:
+		fallthrough
+	default:
+		return nil, fmt.Errorf("bad configuration: unknown client certificate username field: %s", p.c.UsernameField)
+	}
+	if username == "" {
+		return nil, fmt.Errorf("client certificate does not contain a value for the configured username field %s", p.c.UsernameField)
+	}
+
+	var groups []string
+	switch p.c.GroupsField {
+	case idpv1alpha1.ClientCertificateSubjectOrganization:
+		groups = leaf.Subject.Organization
+	case idpv1alpha1.ClientCertificateSubjectOrganizationalUnit:
+		groups = leaf.Subject.OrganizationalUnit
+	case idpv1alpha1.ClientCertificateSubjectCommonName, idpv1alpha1.ClientCertificateSubjectEmailAddress:
+		fallthrough
+	default:
+		return nil, fmt.Errorf("bad configuration: unknown client certificate groups field: %s", p.c.GroupsField)
+	}
+
+	fingerprint := sha256.Sum256(leaf.Raw)
+
+	return &upstreamprovider.ClientCertificateUser{
+		Username:          username,
+		Groups:            append([]string{}, groups...),
+		DownstreamSubject: downstreamsubject.ClientCertificate(leaf.Issuer.String(), idpDisplayName, leaf.Subject.String()),
+		Fingerprint:       hex.EncodeToString(fingerprint[:]),
+		NotAfter:          leaf.NotAfter,
+	}, nil
+}
+
+// GetConfig returns the config. This is not part of the UpstreamClientCertificateIdentityProviderI interface and is just for testing.
+func (p *Provider) GetConfig() ProviderConfig {
+	return p.c
+}
@@ -0,0 +1,143 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package upstreamclientcert
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+
+	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
+	"go.pinniped.dev/internal/certauthority"
+	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
+)
+
+func TestAuthenticateCertificate(t *testing.T) {
+	t.Parallel()
+
+	ca, err := certauthority.New("some-ca", time.Hour)
+	require.NoError(t, err)
+
+	otherCA, err := certauthority.New("some-other-ca", time.Hour)
+	require.NoError(t, err)
+
+	issue := func(t *testing.T, ca *certauthority.CA, username string, groups []string) *x509.Certificate {
+		t.Helper()
+		tlsCert, err := ca.IssueClientCert(username, groups, time.Hour)
+		require.NoError(t, err)
+		cert, err := x509.ParseCertificate(tlsCert.Certificate[0])
+		require.NoError(t, err)
+		return cert
+	}
+
+	validCert := issue(t, ca, "some-user", []string{"group1", "group2"})
+
+	tests := []struct {
+		name          string
+		usernameField idpv1alpha1.ClientCertificateSubjectField
+		groupsField   idpv1alpha1.ClientCertificateSubjectField
+		chain         []*x509.Certificate
+		now           func() time.Time
+		wantUser      *upstreamprovider.ClientCertificateUser
+		wantErr       string
+	}{
+		{
+			name:          "happy path",
+			usernameField: idpv1alpha1.ClientCertificateSubjectCommonName,
+			groupsField:   idpv1alpha1.ClientCertificateSubjectOrganization,
+			chain:         []*x509.Certificate{validCert},
+			wantUser: &upstreamprovider.ClientCertificateUser{
+				Username:          "some-user",
+				Groups:            []string{"group1", "group2"},
+				DownstreamSubject: "x509:CN%3Dsome-ca?idpName=some-display-name&sub=CN%3Dsome-user%2CO%3Dgroup1%2BO%3Dgroup2",
+				NotAfter:          validCert.NotAfter,
+			},
+		},
+		{
+			name:          "using organizational units for groups",
+			usernameField: idpv1alpha1.ClientCertificateSubjectCommonName,
+			groupsField:   idpv1alpha1.ClientCertificateSubjectOrganizationalUnit,
+			chain:         []*x509.Certificate{validCert},
+			wantUser: &upstreamprovider.ClientCertificateUser{
+				Username:          "some-user",
+				Groups:            []string{},
+				DownstreamSubject: "x509:CN%3Dsome-ca?idpName=some-display-name&sub=CN%3Dsome-user%2CO%3Dgroup1%2BO%3Dgroup2",
+				NotAfter:          validCert.NotAfter,
+			},
+		},
+		{
+			name:          "no certificates",
+			usernameField: idpv1alpha1.ClientCertificateSubjectCommonName,
+			groupsField:   idpv1alpha1.ClientCertificateSubjectOrganization,
+			wantErr:       "no client certificate was presented during the TLS handshake",
+		},
+		{
+			name:          "certificate signed by a different CA",
+			usernameField: idpv1alpha1.ClientCertificateSubjectCommonName,
+			groupsField:   idpv1alpha1.ClientCertificateSubjectOrganization,
+			chain:         []*x509.Certificate{issue(t, otherCA, "some-user", nil)},
+			wantErr:       "client certificate verification failed: x509: certificate signed by unknown authority",
+		},
+		{
+			name:          "expired certificate",
+			usernameField: idpv1alpha1.ClientCertificateSubjectCommonName,
+			groupsField:   idpv1alpha1.ClientCertificateSubjectOrganization,
+			chain:         []*x509.Certificate{validCert},
+			now:           func() time.Time { return validCert.NotAfter.Add(time.Minute) },
+			wantErr:       "client certificate verification failed: x509: certificate has expired or is not yet valid",
+		},
+		{
+			name:          "certificate has no email address",
+			usernameField: idpv1alpha1.ClientCertificateSubjectEmailAddress,
+			groupsField:   idpv1alpha1.ClientCertificateSubjectOrganization,
+			chain:         []*x509.Certificate{validCert},
+			wantErr:       "client certificate does not contain a value for the configured username field EmailAddress",
+		},
+		{
+			name:          "bad username field",
+			usernameField: idpv1alpha1.ClientCertificateSubjectOrganization,
+			groupsField:   idpv1alpha1.ClientCertificateSubjectOrganization,
+			chain:         []*x509.Certificate{validCert},
+			wantErr:       "bad configuration: unknown client certificate username field: Organization",
+		},
+		{
+			name:          "bad groups field",
+			usernameField: idpv1alpha1.ClientCertificateSubjectCommonName,
+			groupsField:   idpv1alpha1.ClientCertificateSubjectCommonName,
+			chain:         []*x509.Certificate{validCert},
+			wantErr:       "bad configuration: unknown client certificate groups field: CommonName",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := New(ProviderConfig{
+				Name:          "some-name",
+				ResourceUID:   types.UID("some-uid"),
+				Roots:         ca.Pool(),
+				UsernameField: test.usernameField,
+				GroupsField:   test.groupsField,
+				Now:           test.now,
+			})
+			require.Equal(t, "some-name", p.GetResourceName())
+			require.Equal(t, types.UID("some-uid"), p.GetResourceUID())
+
+			user, err := p.AuthenticateCertificate(test.chain, "some-display-name")
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+				require.Nil(t, user)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, user.Fingerprint, 64)
+			user.Fingerprint = ""
+			require.Equal(t, test.wantUser, user)
+		})
+	}
+}
@@ -27,7 +27,9 @@ import (
 	"go.pinniped.dev/internal/endpointaddr"
 	"go.pinniped.dev/internal/federationdomain/downstreamsubject"
 	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
+	"go.pinniped.dev/internal/net/phttp"
 	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/internal/totp"
 )
 
 const (
@@ -94,6 +96,9 @@ type ProviderConfig struct {
 	// PEM-encoded CA cert bundle to trust when connecting to the LDAP server. Can be nil.
 	CABundle []byte
 
+	// Proxy is the outbound proxy to use when connecting to the LDAP server. When nil, connect directly.
+	Proxy *phttp.Proxy
+
 	// BindUsername is the username to use when performing a bind with the upstream LDAP IDP.
 	BindUsername string
 
@@ -119,6 +124,10 @@ type ProviderConfig struct {
 
 	// RefreshAttributeChecks are extra checks that attributes in a refresh response are as expected.
 	RefreshAttributeChecks map[string]func(*ldap.Entry, upstreamprovider.LDAPRefreshAttributes) error
+
+	// TOTP is the configuration of the TOTP second factor which users must provide in addition to their password.
+	// When nil, users only provide their password.
+	TOTP *totp.Config
 }
 
 // UserSearchConfig contains information about how to search for users in the upstream LDAP IDP.
@@ -319,8 +328,13 @@ func (p *Provider) dialTLS(ctx context.Context, addr endpointaddr.HostPort) (Con
 		return nil, ldap.NewError(ldap.ErrorNetwork, err)
 	}
 
-	dialer := &tls.Dialer{NetDialer: netDialer(), Config: tlsConfig}
-	c, err := dialer.DialContext(ctx, "tcp", addr.Endpoint())
+	var c net.Conn
+	if p.c.Proxy != nil {
+		c, err = p.c.Proxy.DialTLSContext(ctx, netDialer(), "tcp", addr.Endpoint(), tlsConfig)
+	} else {
+		dialer := &tls.Dialer{NetDialer: netDialer(), Config: tlsConfig}
+		c, err = dialer.DialContext(ctx, "tcp", addr.Endpoint())
+	}
 	if err != nil {
 		return nil, ldap.NewError(ldap.ErrorNetwork, err)
 	}
@@ -342,7 +356,12 @@ func (p *Provider) dialStartTLS(ctx context.Context, addr endpointaddr.HostPort)
 	// Unfortunately, this seems to be required for StartTLS, even though it is not needed for regular TLS.
 	tlsConfig.ServerName = addr.Host
 
-	c, err := netDialer().DialContext(ctx, "tcp", addr.Endpoint())
+	var c net.Conn
+	if p.c.Proxy != nil {
+		c, err = p.c.Proxy.DialContext(ctx, netDialer(), "tcp", addr.Endpoint())
+	} else {
+		c, err = netDialer().DialContext(ctx, "tcp", addr.Endpoint())
+	}
 	if err != nil {
 		return nil, ldap.NewError(ldap.ErrorNetwork, err)
 	}
@@ -392,6 +411,11 @@ func (p *Provider) GetURL() *url.URL {
 	return u
 }
 
+// GetTOTPConfig returns the configuration of the TOTP second factor, or nil when it is not required.
+func (p *Provider) GetTOTPConfig() *totp.Config {
+	return p.c.TOTP
+}
+
 // TestConnection provides a method for testing the connection and bind settings. It performs a dial and bind
 // and returns any errors that we encountered.
 func (p *Provider) TestConnection(ctx context.Context) error {
@@ -0,0 +1,113 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package upstreammock implements an identity provider which authenticates a static list of users.
+// It is only intended for demo and test environments.
+package upstreammock
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+
+	"go.pinniped.dev/internal/federationdomain/downstreamsubject"
+	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
+)
+
+// User is a static user of a mock identity provider, as read from the users Secret.
+type User struct {
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	Groups   []string `json:"groups,omitempty"`
+}
+
+// ParseUsers reads and validates the YAML list of users from the users Secret.
+func ParseUsers(data []byte) ([]User, error) {
+	var users []User
+	if err := yaml.UnmarshalStrict(data, &users); err != nil {
+		return nil, fmt.Errorf("could not parse users: %w", err)
+	}
+	if len(users) == 0 {
+		return nil, errors.New("no users found")
+	}
+
+	seen := make(map[string]bool, len(users))
+	for i, user := range users {
+		switch {
+		case user.Username == "":
+			return nil, fmt.Errorf("user at index %d is missing a username", i)
+		case user.Password == "":
+			return nil, fmt.Errorf("user %q is missing a password", user.Username)
+		case seen[user.Username]:
+			return nil, fmt.Errorf("user %q is listed more than once", user.Username)
+		}
+		seen[user.Username] = true
+	}
+	return users, nil
+}
+
+// ProviderConfig holds the active configuration of a mock provider.
+type ProviderConfig struct {
+	Name        string
+	ResourceUID types.UID
+	Users       []User
+}
+
+type Provider struct {
+	c     ProviderConfig
+	users map[string]User
+}
+
+var _ upstreamprovider.UpstreamMockIdentityProviderI = &Provider{}
+
+// New creates a Provider. The config is not a pointer to ensure that a copy of the config is created,
+// making the resulting Provider use an effectively read-only configuration.
+func New(config ProviderConfig) *Provider {
+	users := make(map[string]User, len(config.Users))
+	for _, user := range config.Users {
+		users[user.Username] = user
+	}
+	return &Provider{c: config, users: users}
+}
+
+func (p *Provider) GetResourceName() string {
+	return p.c.Name
+}
+
+func (p *Provider) GetResourceUID() types.UID {
+	return p.c.ResourceUID
+}
+
+// AuthenticateUser checks the password of the named user.
+func (p *Provider) AuthenticateUser(username string, password string, idpDisplayName string) (*upstreamprovider.MockUser, bool) {
+	user, found := p.users[username]
+	if !found || subtle.ConstantTimeCompare([]byte(user.Password), []byte(password)) != 1 {
+		return nil, false
+	}
+	return p.mockUser(user, idpDisplayName), true
+}
+
+// GetUser looks up the named user without checking their password.
+func (p *Provider) GetUser(username string, idpDisplayName string) (*upstreamprovider.MockUser, bool) {
+	user, found := p.users[username]
+	if !found {
+		return nil, false
+	}
+	return p.mockUser(user, idpDisplayName), true
+}
+
+func (p *Provider) mockUser(user User, idpDisplayName string) *upstreamprovider.MockUser {
+	return &upstreamprovider.MockUser{
+		Username:          user.Username,
+		Groups:            append([]string{}, user.Groups...),
+		DownstreamSubject: downstreamsubject.Mock(p.c.Name, idpDisplayName, user.Username),
+	}
+}
+
+// GetConfig returns the config. This is not part of the UpstreamMockIdentityProviderI interface and is just for testing.
+func (p *Provider) GetConfig() ProviderConfig {
+	return p.c
+}
@@ -0,0 +1,151 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package upstreammock
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
+	"go.pinniped.dev/internal/here"
+)
+
+func TestParseUsers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		data      string
+		wantUsers []User
+		wantErr   string
+	}{
+		{
+			name: "happy path",
+			data: here.Doc(`
+				- username: alice
+				  password: alice-password
+				  groups: [developers, admins]
+				- username: bob
+				  password: bob-password
+			`),
+			wantUsers: []User{
+				{Username: "alice", Password: "alice-password", Groups: []string{"developers", "admins"}},
+				{Username: "bob", Password: "bob-password"},
+			},
+		},
+		{
+			name:    "not YAML",
+			data:    "this is not a list of users",
+			wantErr: "could not parse users: error unmarshaling JSON: while decoding JSON: json: cannot unmarshal string into Go value of type []upstreammock.User",
+		},
+		{
+			name: "unknown field",
+			data: here.Doc(`
+				- username: alice
+				  pasword: alice-password
+			`),
+			wantErr: `could not parse users: error unmarshaling JSON: while decoding JSON: json: unknown field "pasword"`,
+		},
+		{
+			name:    "empty",
+			data:    "",
+			wantErr: "no users found",
+		},
+		{
+			name: "missing username",
+			data: here.Doc(`
+				- username: alice
+				  password: alice-password
+				- password: some-password
+			`),
+			wantErr: "user at index 1 is missing a username",
+		},
+		{
+			name: "missing password",
+			data: here.Doc(`
+				- username: alice
+			`),
+			wantErr: `user "alice" is missing a password`,
+		},
+		{
+			name: "duplicate username",
+			data: here.Doc(`
+				- username: alice
+				  password: alice-password
+				- username: alice
+				  password: other-password
+			`),
+			wantErr: `user "alice" is listed more than once`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			users, err := ParseUsers([]byte(tt.data))
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				require.Nil(t, users)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantUsers, users)
+		})
+	}
+}
+
+func TestProvider(t *testing.T) {
+	t.Parallel()
+
+	p := New(ProviderConfig{
+		Name:        "some-mock-idp",
+		ResourceUID: "some-uid",
+		Users: []User{
+			{Username: "alice", Password: "alice-password", Groups: []string{"developers", "admins"}},
+			{Username: "bob", Password: "bob-password"},
+		},
+	})
+	require.Equal(t, "some-mock-idp", p.GetResourceName())
+	require.Equal(t, "some-uid", string(p.GetResourceUID()))
+
+	wantAlice := &upstreamprovider.MockUser{
+		Username:          "alice",
+		Groups:            []string{"developers", "admins"},
+		DownstreamSubject: "mock:some-mock-idp?idpName=some-display-name&sub=alice",
+	}
+
+	user, ok := p.AuthenticateUser("alice", "alice-password", "some-display-name")
+	require.True(t, ok)
+	require.Equal(t, wantAlice, user)
+
+	// The returned groups are a copy.
+	user.Groups[0] = "changed"
+	user, ok = p.GetUser("alice", "some-display-name")
+	require.True(t, ok)
+	require.Equal(t, wantAlice, user)
+
+	user, ok = p.AuthenticateUser("bob", "bob-password", "some-display-name")
+	require.True(t, ok)
+	require.Equal(t, &upstreamprovider.MockUser{
+		Username:          "bob",
+		Groups:            []string{},
+		DownstreamSubject: "mock:some-mock-idp?idpName=some-display-name&sub=bob",
+	}, user)
+
+	for _, tc := range []struct{ username, password string }{
+		{"alice", "bob-password"},
+		{"alice", ""},
+		{"Alice", "alice-password"},
+		{"carol", "alice-password"},
+	} {
+		user, ok = p.AuthenticateUser(tc.username, tc.password, "some-display-name")
+		require.False(t, ok, "username %q with password %q should not be accepted", tc.username, tc.password)
+		require.Nil(t, user)
+	}
+
+	user, ok = p.GetUser("carol", "some-display-name")
+	require.False(t, ok)
+	require.Nil(t, user)
+}
@@ -7,11 +7,13 @@ package upstreamoidc
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	coreosoidc "github.com/coreos/go-oidc/v3/oidc"
@@ -24,6 +26,7 @@ import (
 	"go.pinniped.dev/internal/federationdomain/dynamicupstreamprovider"
 	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
 	"go.pinniped.dev/internal/httputil/httperr"
+	"go.pinniped.dev/internal/httputil/roundtripper"
 	"go.pinniped.dev/internal/plog"
 	"go.pinniped.dev/pkg/oidcclient/nonce"
 	"go.pinniped.dev/pkg/oidcclient/oidctypes"
@@ -36,17 +39,22 @@ func New(config *oauth2.Config, provider *coreosoidc.Provider, client *http.Clie
 
 // ProviderConfig holds the active configuration of an upstream OIDC provider.
 type ProviderConfig struct {
-	Name                     string
-	ResourceUID              types.UID
-	UsernameClaim            string
-	GroupsClaim              string
-	Config                   *oauth2.Config
-	Client                   *http.Client
-	AllowPasswordGrant       bool
-	AdditionalAuthcodeParams map[string]string
-	AdditionalClaimMappings  map[string]string
-	RevocationURL            *url.URL // will commonly be nil: many providers do not offer this
-	Provider                 interface {
+	Name                                string
+	ResourceUID                         types.UID
+	UsernameClaim                       string
+	GroupsClaim                         string
+	Config                              *oauth2.Config
+	Client                              *http.Client
+	AllowPasswordGrant                  bool
+	UseJWTSecuredAuthorizationResponses bool
+	AdditionalAuthcodeParams            map[string]string
+	AdditionalClaimMappings             map[string]string
+	Resource                            string                // the RFC8707 resource indicator to send to the token endpoint, if any
+	RevocationURL                       *url.URL              // will commonly be nil: many providers do not offer this
+	ClientSecretRotation                *ClientSecretRotation // nil unless there is a next client secret to rotate to
+	GroupsResolver                      GroupsResolver        // nil unless group memberships are looked up in a directory
+	GroupsExpression                    GroupsExpression      // nil unless the groups are computed from the claims
+	Provider                            interface {
 		Verifier(*coreosoidc.Config) *coreosoidc.IDTokenVerifier
 		Claims(v any) error
 		UserInfo(ctx context.Context, tokenSource oauth2.TokenSource) (*coreosoidc.UserInfo, error)
@@ -55,6 +63,53 @@ type ProviderConfig struct {
 
 var _ upstreamprovider.UpstreamOIDCIdentityProviderI = (*ProviderConfig)(nil)
 
+// GroupsResolver looks up the group memberships of a user in a directory, for providers which do not always
+// include them in the ID token. It returns false when the claims of the user do not require a lookup.
+type GroupsResolver interface {
+	ResolveGroups(ctx context.Context, claims map[string]any) ([]string, bool, error)
+}
+
+// GroupsExpression computes the groups of a user from the claims of the user, e.g. from nested claims.
+type GroupsExpression interface {
+	EvaluateGroups(ctx context.Context, claims map[string]any) ([]string, error)
+}
+
+// ClientSecretRotation holds the current and the next client secret of an upstream provider during a rotation of its
+// client secret, and remembers which of them was most recently accepted by the provider. The same ClientSecretRotation
+// should be used by each ProviderConfig of an upstream provider, for as long as its client secrets do not change.
+type ClientSecretRotation struct {
+	current      string
+	next         string
+	nextIsActive atomic.Bool
+}
+
+func NewClientSecretRotation(current, next string) *ClientSecretRotation {
+	return &ClientSecretRotation{current: current, next: next}
+}
+
+// Matches returns true when the ClientSecretRotation is for the given client secrets.
+func (r *ClientSecretRotation) Matches(current, next string) bool {
+	return r.current == current && r.next == next
+}
+
+// NextIsActive returns true when the next client secret was the most recently accepted by the provider.
+func (r *ClientSecretRotation) NextIsActive() bool {
+	return r.nextIsActive.Load()
+}
+
+// secrets returns the client secret which should be tried first, and then the one which should be tried when
+// the provider rejects the first one.
+func (r *ClientSecretRotation) secrets() (string, string) {
+	if r.nextIsActive.Load() {
+		return r.next, r.current
+	}
+	return r.current, r.next
+}
+
+func (r *ClientSecretRotation) setActive(secret string) {
+	r.nextIsActive.Store(secret == r.next)
+}
+
 func (p *ProviderConfig) GetResourceUID() types.UID {
 	return p.ResourceUID
 }
@@ -113,6 +168,35 @@ func (p *ProviderConfig) AllowsPasswordGrant() bool {
 	return p.AllowPasswordGrant
 }
 
+func (p *ProviderConfig) UsesJWTSecuredAuthorizationResponses() bool {
+	return p.UseJWTSecuredAuthorizationResponses
+}
+
+func (p *ProviderConfig) ValidateJWTSecuredAuthorizationResponse(ctx context.Context, response string) (url.Values, error) {
+	// The response is signed by the same keys as the ID tokens, and must have the same iss, aud, and exp claims.
+	validated, err := p.Provider.Verifier(&coreosoidc.Config{ClientID: p.GetClientID()}).Verify(coreosoidc.ClientContext(ctx, p.Client), response)
+	if err != nil {
+		return nil, httperr.Wrap(http.StatusBadRequest, "received invalid JWT secured authorization response", err)
+	}
+
+	var claims map[string]any
+	if err := validated.Claims(&claims); err != nil {
+		return nil, httperr.Wrap(http.StatusInternalServerError, "could not unmarshal JWT secured authorization response claims", err)
+	}
+
+	params := url.Values{}
+	for name, value := range claims {
+		switch name {
+		case oidcapi.IDTokenClaimIssuer, "aud", "exp", "iat":
+			continue // these claims secure the response, but are not params of the response
+		}
+		if stringValue, ok := value.(string); ok {
+			params.Set(name, stringValue)
+		}
+	}
+	return params, nil
+}
+
 func (p *ProviderConfig) PasswordCredentialsGrantAndValidateTokens(ctx context.Context, username, password string) (*oidctypes.Token, error) {
 	// Disallow this grant when requested.
 	if !p.AllowPasswordGrant {
@@ -120,11 +204,16 @@ func (p *ProviderConfig) PasswordCredentialsGrantAndValidateTokens(ctx context.C
 	}
 
 	// Note that this implicitly uses the scopes from p.Config.Scopes.
-	tok, err := p.Config.PasswordCredentialsToken(
-		coreosoidc.ClientContext(ctx, p.Client),
-		username,
-		password,
-	)
+	var tok *oauth2.Token
+	err := p.withClientSecretFallback(func(config *oauth2.Config) error {
+		var err error
+		tok, err = config.PasswordCredentialsToken(
+			coreosoidc.ClientContext(ctx, p.tokenClient()),
+			username,
+			password,
+		)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -136,12 +225,17 @@ func (p *ProviderConfig) PasswordCredentialsGrantAndValidateTokens(ctx context.C
 }
 
 func (p *ProviderConfig) ExchangeAuthcodeAndValidateTokens(ctx context.Context, authcode string, pkceCodeVerifier pkce.Code, expectedIDTokenNonce nonce.Nonce, redirectURI string) (*oidctypes.Token, error) {
-	tok, err := p.Config.Exchange(
-		coreosoidc.ClientContext(ctx, p.Client),
-		authcode,
-		pkceCodeVerifier.Verifier(),
-		oauth2.SetAuthURLParam("redirect_uri", redirectURI),
-	)
+	var tok *oauth2.Token
+	err := p.withClientSecretFallback(func(config *oauth2.Config) error {
+		var err error
+		tok, err = config.Exchange(
+			coreosoidc.ClientContext(ctx, p.tokenClient()),
+			authcode,
+			pkceCodeVerifier.Verifier(),
+			oauth2.SetAuthURLParam("redirect_uri", redirectURI),
+		)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -151,10 +245,100 @@ func (p *ProviderConfig) ExchangeAuthcodeAndValidateTokens(ctx context.Context,
 
 func (p *ProviderConfig) PerformRefresh(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
 	// Use the provided HTTP client to benefit from its CA, proxy, and other settings.
-	httpClientContext := coreosoidc.ClientContext(ctx, p.Client)
+	httpClientContext := coreosoidc.ClientContext(ctx, p.tokenClient())
 	// Create a TokenSource without an access token, so it thinks that a refresh is immediately required.
 	// Then ask it for the tokens to cause it to perform the refresh and return the results.
-	return p.Config.TokenSource(httpClientContext, &oauth2.Token{RefreshToken: refreshToken}).Token()
+	var tok *oauth2.Token
+	err := p.withClientSecretFallback(func(config *oauth2.Config) error {
+		var err error
+		tok, err = config.TokenSource(httpClientContext, &oauth2.Token{RefreshToken: refreshToken}).Token()
+		return err
+	})
+	return tok, err
+}
+
+// withClientSecretFallback calls tokenRequest with the oauth2.Config which uses the active client secret. During
+// a client secret rotation, when the provider rejects the client secret, then tokenRequest is called again using
+// the other client secret, which becomes the active client secret when it is accepted.
+func (p *ProviderConfig) withClientSecretFallback(tokenRequest func(config *oauth2.Config) error) error {
+	if p.ClientSecretRotation == nil {
+		return tokenRequest(p.Config)
+	}
+
+	first, second := p.ClientSecretRotation.secrets()
+	err := tokenRequest(p.configWithClientSecret(first))
+	if !isInvalidClientError(err) {
+		return err
+	}
+
+	plog.Info("upstream provider rejected the client secret, so trying the other client secret of the rotation",
+		"providerName", p.Name)
+	if err := tokenRequest(p.configWithClientSecret(second)); err != nil {
+		return err
+	}
+	p.ClientSecretRotation.setActive(second)
+	return nil
+}
+
+func (p *ProviderConfig) configWithClientSecret(clientSecret string) *oauth2.Config {
+	config := *p.Config
+	config.ClientSecret = clientSecret
+	return &config
+}
+
+// isInvalidClientError returns true when the token endpoint rejected the authentication of the client.
+// See https://datatracker.ietf.org/doc/html/rfc6749#section-5.2.
+func isInvalidClientError(err error) bool {
+	var retrieveErr *oauth2.RetrieveError
+	if !errors.As(err, &retrieveErr) {
+		return false
+	}
+	return retrieveErr.ErrorCode == "invalid_client" ||
+		(retrieveErr.Response != nil && retrieveErr.Response.StatusCode == http.StatusUnauthorized)
+}
+
+// tokenClient returns the HTTP client to use for requests to the token endpoint. When there is a resource indicator,
+// the client adds it to the body of each token request, since the oauth2 library has no way to add parameters to
+// password grant and refresh requests. Some providers, e.g. AD FS, require the resource parameter on every request.
+func (p *ProviderConfig) tokenClient() *http.Client {
+	if p.Resource == "" {
+		return p.Client
+	}
+
+	client := http.Client{}
+	if p.Client != nil {
+		client = *p.Client
+	}
+	rt := client.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	client.Transport = roundtripper.WrapFunc(rt, func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodPost || req.URL.String() != p.Config.Endpoint.TokenURL || req.Body == nil {
+			return rt.RoundTrip(req)
+		}
+
+		body, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, err
+		}
+		if !form.Has("resource") {
+			form.Set("resource", p.Resource)
+		}
+		encoded := form.Encode()
+
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(strings.NewReader(encoded))
+		req.ContentLength = int64(len(encoded))
+		req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader(encoded)), nil }
+		return rt.RoundTrip(req)
+	})
+	return &client
 }
 
 // RevokeToken will attempt to revoke the given token, if the provider has a revocation endpoint.
@@ -169,14 +353,40 @@ func (p *ProviderConfig) RevokeToken(ctx context.Context, token string, tokenTyp
 		)
 		return nil
 	}
+	if p.ClientSecretRotation == nil {
+		_, err := p.revokeTokenWithClientSecret(ctx, token, tokenType, p.Config.ClientSecret)
+		return err
+	}
+
+	first, second := p.ClientSecretRotation.secrets()
+	invalidClient, err := p.revokeTokenWithClientSecret(ctx, token, tokenType, first)
+	if !invalidClient {
+		return err
+	}
+	// Both client auth methods got an "invalid_client" response, so try the other client secret of the rotation.
+	_, err = p.revokeTokenWithClientSecret(ctx, token, tokenType, second)
+	if err == nil {
+		p.ClientSecretRotation.setActive(second)
+	}
+	return err
+}
+
+// revokeTokenWithClientSecret tries each client auth method to revoke the token using the given client secret.
+// It returns true when the last attempt got an "invalid_client" response.
+func (p *ProviderConfig) revokeTokenWithClientSecret(
+	ctx context.Context,
+	token string,
+	tokenType upstreamprovider.RevocableTokenType,
+	clientSecret string,
+) (bool, error) {
 	// First try using client auth in the request params.
-	tryAnotherClientAuthMethod, err := p.tryRevokeToken(ctx, token, tokenType, false)
+	tryAnotherClientAuthMethod, err := p.tryRevokeToken(ctx, token, tokenType, clientSecret, false)
 	if tryAnotherClientAuthMethod {
 		// Try again using basic auth this time. Overwrite the first client auth error,
 		// which isn't useful anymore when retrying.
-		_, err = p.tryRevokeToken(ctx, token, tokenType, true)
+		tryAnotherClientAuthMethod, err = p.tryRevokeToken(ctx, token, tokenType, clientSecret, true)
 	}
-	return err
+	return tryAnotherClientAuthMethod, err
 }
 
 // tryRevokeToken will call the revocation endpoint using either basic auth or by including
@@ -190,10 +400,10 @@ func (p *ProviderConfig) tryRevokeToken(
 	ctx context.Context,
 	token string,
 	tokenType upstreamprovider.RevocableTokenType,
+	clientSecret string,
 	useBasicAuth bool,
 ) (tryAnotherClientAuthMethod bool, err error) {
 	clientID := p.Config.ClientID
-	clientSecret := p.Config.ClientSecret
 	// Use the provided HTTP client to benefit from its CA, proxy, and other settings.
 	httpClient := p.Client
 
@@ -302,6 +512,14 @@ func (p *ProviderConfig) ValidateTokenAndMergeWithUserInfo(ctx context.Context,
 		}
 	}
 
+	if err := p.maybeResolveGroups(ctx, validatedClaims); err != nil {
+		return nil, httperr.Wrap(http.StatusInternalServerError, "could not look up group memberships", err)
+	}
+
+	if err := p.maybeEvaluateGroupsExpression(ctx, validatedClaims); err != nil {
+		return nil, httperr.Wrap(http.StatusInternalServerError, "could not evaluate groups expression", err)
+	}
+
 	return &oidctypes.Token{
 		AccessToken: &oidctypes.AccessToken{
 			Token:  tok.AccessToken,
@@ -319,6 +537,41 @@ func (p *ProviderConfig) ValidateTokenAndMergeWithUserInfo(ctx context.Context,
 	}, nil
 }
 
+// maybeResolveGroups sets the groups claim to the groups which are looked up by the GroupsResolver, when the
+// provider did not include the groups claim because the user is a member of too many groups.
+func (p *ProviderConfig) maybeResolveGroups(ctx context.Context, claims map[string]any) error {
+	if p.GroupsResolver == nil || p.GroupsClaim == "" {
+		return nil
+	}
+	if _, ok := claims[p.GroupsClaim]; ok {
+		return nil
+	}
+
+	groups, resolved, err := p.GroupsResolver.ResolveGroups(ctx, claims)
+	if err != nil {
+		return err
+	}
+	if resolved {
+		plog.Debug("looked up group memberships", "providerName", p.Name, "groupsCount", len(groups))
+		claims[p.GroupsClaim] = groups
+	}
+	return nil
+}
+
+// maybeEvaluateGroupsExpression sets the groups claim to the groups which are computed by the GroupsExpression.
+func (p *ProviderConfig) maybeEvaluateGroupsExpression(ctx context.Context, claims map[string]any) error {
+	if p.GroupsExpression == nil || p.GroupsClaim == "" {
+		return nil
+	}
+
+	groups, err := p.GroupsExpression.EvaluateGroups(ctx, claims)
+	if err != nil {
+		return err
+	}
+	claims[p.GroupsClaim] = groups
+	return nil
+}
+
 func (p *ProviderConfig) validateIDToken(ctx context.Context, tok *oauth2.Token, expectedIDTokenNonce nonce.Nonce, validatedClaims map[string]any, requireIDToken bool) (time.Time, string, error) {
 	idTok, hasIDTok := tok.Extra("id_token").(string)
 	if !hasIDTok && !requireIDToken {
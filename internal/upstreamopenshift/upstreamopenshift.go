@@ -0,0 +1,185 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package upstreamopenshift implements an abstraction of upstream OpenShift provider interactions.
+package upstreamopenshift
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	coreosoidc "github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+	"k8s.io/apimachinery/pkg/types"
+
+	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
+	"go.pinniped.dev/internal/federationdomain/downstreamsubject"
+	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
+	"go.pinniped.dev/pkg/oidcclient/pkce"
+)
+
+const (
+	// UserPath is the path of the API of the OpenShift API server which returns the user who owns the access token.
+	UserPath = "/apis/user.openshift.io/v1/users/~"
+
+	// systemGroupPrefix is the prefix of the names of the virtual groups of an OpenShift cluster,
+	// e.g. "system:authenticated" and "system:authenticated:oauth".
+	systemGroupPrefix = "system:"
+
+	// maxUserResponseBytes limits how much of the user API response is read.
+	maxUserResponseBytes = 1 << 20
+)
+
+// ProviderConfig holds the active configuration of an upstream OpenShift provider.
+type ProviderConfig struct {
+	Name        string
+	ResourceUID types.UID
+
+	// APIServerURL is the URL of the Kubernetes API server of the OpenShift cluster, without a trailing slash.
+	APIServerURL string
+
+	UsernameAttribute idpv1alpha1.OpenShiftUsernameAttribute
+
+	// IncludeSystemGroups, when false, means that the virtual "system:" groups are removed from the user's groups.
+	IncludeSystemGroups bool
+
+	// UsePKCE is true when the OAuth server of the OpenShift cluster advertised support for the S256 PKCE method.
+	UsePKCE bool
+
+	// HttpClient is a client that can be used to call the API server and the token endpoint of the OAuth server.
+	// This client should be configured with the user-provided CA bundle and a timeout.
+	HttpClient *http.Client
+
+	// OAuth2Config contains ClientID, ClientSecret, Scopes, and Endpoint (which contains auth and token endpoint URLs,
+	// and auth style for the token endpoint).
+	// OAuth2Config will not be used to compute the authorize URL because the redirect back to the Supervisor's
+	// callback must be different per FederationDomain. It can be used to call the token endpoint.
+	OAuth2Config *oauth2.Config
+}
+
+type Provider struct {
+	c ProviderConfig
+}
+
+var _ upstreamprovider.UpstreamOpenShiftIdentityProviderI = &Provider{}
+
+// New creates a Provider. The config is not a pointer to ensure that a copy of the config is created,
+// making the resulting Provider use an effectively read-only configuration.
+func New(config ProviderConfig) *Provider {
+	return &Provider{c: config}
+}
+
+func (p *Provider) GetResourceName() string {
+	return p.c.Name
+}
+
+func (p *Provider) GetResourceUID() types.UID {
+	return p.c.ResourceUID
+}
+
+func (p *Provider) GetClientID() string {
+	return p.c.OAuth2Config.ClientID
+}
+
+func (p *Provider) GetScopes() []string {
+	return p.c.OAuth2Config.Scopes
+}
+
+func (p *Provider) GetAuthorizationURL() string {
+	return p.c.OAuth2Config.Endpoint.AuthURL
+}
+
+func (p *Provider) UsesPKCE() bool {
+	return p.c.UsePKCE
+}
+
+func (p *Provider) ExchangeAuthcode(ctx context.Context, authcode string, pkceCodeVerifier pkce.Code, redirectURI string) (string, error) {
+	opts := []oauth2.AuthCodeOption{oauth2.SetAuthURLParam("redirect_uri", redirectURI)}
+	if p.c.UsePKCE {
+		opts = append(opts, pkceCodeVerifier.Verifier())
+	}
+
+	tok, err := p.c.OAuth2Config.Exchange(coreosoidc.ClientContext(ctx, p.c.HttpClient), authcode, opts...)
+	if err != nil {
+		return "", fmt.Errorf("error exchanging authorization code using OpenShift OAuth server: %w", err)
+	}
+	return tok.AccessToken, nil
+}
+
+// userResponse is the subset of the user.openshift.io/v1 User resource which is needed to log in.
+type userResponse struct {
+	Metadata struct {
+		Name string `json:"name"`
+		UID  string `json:"uid"`
+	} `json:"metadata"`
+	Groups []string `json:"groups"`
+}
+
+// GetUser calls the user API of the OpenShift API server using the access token to get the identity of the
+// authenticated user and their group memberships. Group memberships which were synchronized from an LDAP
+// directory by "oc adm groups sync" are ordinary OpenShift groups, so they are included.
+func (p *Provider) GetUser(ctx context.Context, accessToken string, idpDisplayName string) (*upstreamprovider.OpenShiftUser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.c.APIServerURL+UserPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error building request to OpenShift user API: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.c.HttpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling OpenShift user API: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error calling OpenShift user API: unexpected response status %q", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxUserResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("error reading OpenShift user API response: %w", err)
+	}
+
+	var user userResponse
+	if err := json.Unmarshal(body, &user); err != nil {
+		return nil, fmt.Errorf("error parsing OpenShift user API response: %w", err)
+	}
+	if user.Metadata.Name == "" || user.Metadata.UID == "" {
+		return nil, errors.New("OpenShift user API response is missing the name or the uid of the user")
+	}
+
+	openShiftUser := upstreamprovider.OpenShiftUser{
+		DownstreamSubject: downstreamsubject.OpenShift(p.c.APIServerURL, idpDisplayName, user.Metadata.UID),
+	}
+
+	switch p.c.UsernameAttribute {
+	case idpv1alpha1.OpenShiftUsernameName:
+		openShiftUser.Username = user.Metadata.Name
+	case idpv1alpha1.OpenShiftUsernameUID:
+		openShiftUser.Username = user.Metadata.UID
+	case idpv1alpha1.OpenShiftUsernameNameAndUID:
+		openShiftUser.Username = fmt.Sprintf("%s:%s", user.Metadata.Name, user.Metadata.UID)
+	default:
+		return nil, fmt.Errorf("bad configuration: unknown OpenShift username attribute: %s", p.c.UsernameAttribute)
+	}
+
+	for _, group := range user.Groups {
+		if !p.c.IncludeSystemGroups && strings.HasPrefix(group, systemGroupPrefix) {
+			continue
+		}
+		openShiftUser.Groups = append(openShiftUser.Groups, group)
+	}
+
+	return &openShiftUser, nil
+}
+
+// GetConfig returns the config. This is not part of the UpstreamOpenShiftIdentityProviderI interface and is just for testing.
+func (p *Provider) GetConfig() ProviderConfig {
+	return p.c
+}
@@ -0,0 +1,225 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package upstreamopenshift
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	"k8s.io/apimachinery/pkg/types"
+
+	idpv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idp/v1alpha1"
+	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
+	"go.pinniped.dev/pkg/oidcclient/pkce"
+)
+
+func TestOpenShiftProvider(t *testing.T) {
+	subject := New(ProviderConfig{
+		Name:         "foo",
+		ResourceUID:  "resource-uid-12345",
+		APIServerURL: "https://fake-api-server-url",
+		UsePKCE:      true,
+		OAuth2Config: &oauth2.Config{
+			ClientID:     "fake-client-id",
+			ClientSecret: "fake-client-secret",
+			Scopes:       []string{"user:info"},
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  "https://fake-authorization-url",
+				TokenURL: "https://fake-token-url",
+			},
+		},
+	})
+
+	require.Equal(t, "foo", subject.GetResourceName())
+	require.Equal(t, types.UID("resource-uid-12345"), subject.GetResourceUID())
+	require.Equal(t, "fake-client-id", subject.GetClientID())
+	require.Equal(t, []string{"user:info"}, subject.GetScopes())
+	require.Equal(t, "https://fake-authorization-url", subject.GetAuthorizationURL())
+	require.True(t, subject.UsesPKCE())
+}
+
+func TestExchangeAuthcode(t *testing.T) {
+	pkceCode := pkce.Code("test-pkce-code-verifier-which-is-long-enough-1234567890")
+
+	tests := []struct {
+		name            string
+		usePKCE         bool
+		tokenStatus     int
+		wantVerifier    string
+		wantAccessToken string
+		wantErr         string
+	}{
+		{
+			name:            "exchanges the authcode with PKCE",
+			usePKCE:         true,
+			tokenStatus:     http.StatusOK,
+			wantVerifier:    string(pkceCode),
+			wantAccessToken: "sha256~some-access-token",
+		},
+		{
+			name:            "exchanges the authcode without PKCE",
+			tokenStatus:     http.StatusOK,
+			wantAccessToken: "sha256~some-access-token",
+		},
+		{
+			name:        "token endpoint returns an error",
+			tokenStatus: http.StatusBadRequest,
+			wantErr:     "error exchanging authorization code using OpenShift OAuth server: oauth2: cannot fetch token: 400 Bad Request",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.NoError(t, r.ParseForm())
+				require.Equal(t, "some-authcode", r.Form.Get("code"))
+				require.Equal(t, "https://some-redirect-uri/callback", r.Form.Get("redirect_uri"))
+				require.Equal(t, tt.wantVerifier, r.Form.Get("code_verifier"))
+				if tt.tokenStatus != http.StatusOK {
+					w.WriteHeader(tt.tokenStatus)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				require.NoError(t, json.NewEncoder(w).Encode(map[string]any{
+					"access_token": "sha256~some-access-token",
+					"token_type":   "Bearer",
+					"expires_in":   86400,
+				}))
+			}))
+			t.Cleanup(server.Close)
+
+			subject := New(ProviderConfig{
+				UsePKCE:    tt.usePKCE,
+				HttpClient: server.Client(),
+				OAuth2Config: &oauth2.Config{
+					ClientID:     "fake-client-id",
+					ClientSecret: "fake-client-secret",
+					Endpoint:     oauth2.Endpoint{TokenURL: server.URL + "/oauth/token", AuthStyle: oauth2.AuthStyleInParams},
+				},
+			})
+
+			accessToken, err := subject.ExchangeAuthcode(context.Background(), "some-authcode", pkceCode, "https://some-redirect-uri/callback")
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantAccessToken, accessToken)
+		})
+	}
+}
+
+func TestGetUser(t *testing.T) {
+	const userJSON = `{
+		"kind": "User",
+		"apiVersion": "user.openshift.io/v1",
+		"metadata": {"name": "alice", "uid": "0b9a8b5f-d4c6-4a5e-9e6f-5bd0e4b3a6d1"},
+		"identities": ["htpasswd:alice"],
+		"groups": ["system:authenticated", "system:authenticated:oauth", "cluster-admins", "ldap-synced-group"]
+	}`
+
+	tests := []struct {
+		name                string
+		usernameAttribute   idpv1alpha1.OpenShiftUsernameAttribute
+		includeSystemGroups bool
+		responseStatus      int
+		responseBody        string
+		wantUser            *upstreamprovider.OpenShiftUser
+		wantErr             string
+	}{
+		{
+			name:              "username from name, without system groups",
+			usernameAttribute: idpv1alpha1.OpenShiftUsernameName,
+			responseStatus:    http.StatusOK,
+			responseBody:      userJSON,
+			wantUser: &upstreamprovider.OpenShiftUser{
+				Username:          "alice",
+				Groups:            []string{"cluster-admins", "ldap-synced-group"},
+				DownstreamSubject: "SERVER_URL?idpName=some-display-name&sub=0b9a8b5f-d4c6-4a5e-9e6f-5bd0e4b3a6d1",
+			},
+		},
+		{
+			name:                "username from uid, with system groups",
+			usernameAttribute:   idpv1alpha1.OpenShiftUsernameUID,
+			includeSystemGroups: true,
+			responseStatus:      http.StatusOK,
+			responseBody:        userJSON,
+			wantUser: &upstreamprovider.OpenShiftUser{
+				Username:          "0b9a8b5f-d4c6-4a5e-9e6f-5bd0e4b3a6d1",
+				Groups:            []string{"system:authenticated", "system:authenticated:oauth", "cluster-admins", "ldap-synced-group"},
+				DownstreamSubject: "SERVER_URL?idpName=some-display-name&sub=0b9a8b5f-d4c6-4a5e-9e6f-5bd0e4b3a6d1",
+			},
+		},
+		{
+			name:              "username from name and uid",
+			usernameAttribute: idpv1alpha1.OpenShiftUsernameNameAndUID,
+			responseStatus:    http.StatusOK,
+			responseBody:      userJSON,
+			wantUser: &upstreamprovider.OpenShiftUser{
+				Username:          "alice:0b9a8b5f-d4c6-4a5e-9e6f-5bd0e4b3a6d1",
+				Groups:            []string{"cluster-admins", "ldap-synced-group"},
+				DownstreamSubject: "SERVER_URL?idpName=some-display-name&sub=0b9a8b5f-d4c6-4a5e-9e6f-5bd0e4b3a6d1",
+			},
+		},
+		{
+			name:              "unknown username attribute",
+			usernameAttribute: "bad",
+			responseStatus:    http.StatusOK,
+			responseBody:      userJSON,
+			wantErr:           "bad configuration: unknown OpenShift username attribute: bad",
+		},
+		{
+			name:              "access token was rejected",
+			usernameAttribute: idpv1alpha1.OpenShiftUsernameName,
+			responseStatus:    http.StatusUnauthorized,
+			wantErr:           `error calling OpenShift user API: unexpected response status "401 Unauthorized"`,
+		},
+		{
+			name:              "invalid JSON",
+			usernameAttribute: idpv1alpha1.OpenShiftUsernameName,
+			responseStatus:    http.StatusOK,
+			responseBody:      "not json",
+			wantErr:           "error parsing OpenShift user API response: invalid character 'o' in literal null (expecting 'u')",
+		},
+		{
+			name:              "missing uid",
+			usernameAttribute: idpv1alpha1.OpenShiftUsernameName,
+			responseStatus:    http.StatusOK,
+			responseBody:      `{"metadata": {"name": "alice"}}`,
+			wantErr:           "OpenShift user API response is missing the name or the uid of the user",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, UserPath, r.URL.Path)
+				require.Equal(t, "Bearer some-access-token", r.Header.Get("Authorization"))
+				w.WriteHeader(tt.responseStatus)
+				_, _ = w.Write([]byte(tt.responseBody))
+			}))
+			t.Cleanup(server.Close)
+
+			subject := New(ProviderConfig{
+				APIServerURL:        server.URL,
+				UsernameAttribute:   tt.usernameAttribute,
+				IncludeSystemGroups: tt.includeSystemGroups,
+				HttpClient:          server.Client(),
+			})
+
+			user, err := subject.GetUser(context.Background(), "some-access-token", "some-display-name")
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				require.Nil(t, user)
+				return
+			}
+			require.NoError(t, err)
+			tt.wantUser.DownstreamSubject = server.URL + tt.wantUser.DownstreamSubject[len("SERVER_URL"):]
+			require.Equal(t, tt.wantUser, user)
+		})
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package webauthn
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// maxCBORDepth limits the nesting of arrays and maps, since the decoded data comes from the browser.
+const maxCBORDepth = 8
+
+// decodeCBOR decodes the first CBOR data item (RFC 8949) in data, and returns it along with the number of bytes
+// which it used. Only the subset of CBOR which is used by WebAuthn authenticators is supported: definite-length
+// integers, byte strings, text strings, arrays and maps, and the simple values false, true and null. Integers are
+// returned as int64, byte strings as []byte, text strings as string, arrays as []any, and maps as map[any]any
+// whose keys are either int64 or string.
+func decodeCBOR(data []byte) (any, int, error) {
+	d := &cborDecoder{data: data}
+	value, err := d.decode(0)
+	if err != nil {
+		return nil, 0, err
+	}
+	return value, d.offset, nil
+}
+
+type cborDecoder struct {
+	data   []byte
+	offset int
+}
+
+func (d *cborDecoder) decode(depth int) (any, error) {
+	if depth > maxCBORDepth {
+		return nil, fmt.Errorf("cbor: data is nested too deeply")
+	}
+
+	majorType, argument, err := d.readHead()
+	if err != nil {
+		return nil, err
+	}
+
+	switch majorType {
+	case 0: // unsigned integer
+		if argument > math.MaxInt64 {
+			return nil, fmt.Errorf("cbor: integer overflows int64")
+		}
+		return int64(argument), nil
+	case 1: // negative integer
+		if argument > math.MaxInt64 {
+			return nil, fmt.Errorf("cbor: integer overflows int64")
+		}
+		return -1 - int64(argument), nil
+	case 2: // byte string
+		b, err := d.read(argument)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case 3: // text string
+		b, err := d.read(argument)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case 4: // array
+		if argument > uint64(len(d.data)-d.offset) {
+			// Each item uses at least one byte.
+			return nil, fmt.Errorf("cbor: unexpected end of data")
+		}
+		array := make([]any, 0, argument)
+		for i := uint64(0); i < argument; i++ {
+			item, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, item)
+		}
+		return array, nil
+	case 5: // map
+		if argument > uint64(len(d.data)-d.offset)/2 {
+			// Each key and each value uses at least one byte.
+			return nil, fmt.Errorf("cbor: unexpected end of data")
+		}
+		m := make(map[any]any, argument)
+		for i := uint64(0); i < argument; i++ {
+			key, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, fmt.Errorf("cbor: unsupported map key type %T", key)
+			}
+			if _, duplicate := m[key]; duplicate {
+				return nil, fmt.Errorf("cbor: duplicate map key %v", key)
+			}
+			value, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = value
+		}
+		return m, nil
+	case 7: // simple values
+		switch argument {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22:
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cbor: unsupported simple value %d", argument)
+	default: // tags
+		return nil, fmt.Errorf("cbor: unsupported major type %d", majorType)
+	}
+}
+
+// readHead reads the initial byte and the argument of a data item.
+func (d *cborDecoder) readHead() (byte, uint64, error) {
+	b, err := d.read(1)
+	if err != nil {
+		return 0, 0, err
+	}
+	majorType := b[0] >> 5
+	additionalInfo := b[0] & 0x1f
+
+	switch {
+	case additionalInfo < 24:
+		return majorType, uint64(additionalInfo), nil
+	case additionalInfo == 24:
+		b, err = d.read(1)
+		if err != nil {
+			return 0, 0, err
+		}
+		return majorType, uint64(b[0]), nil
+	case additionalInfo == 25:
+		b, err = d.read(2)
+		if err != nil {
+			return 0, 0, err
+		}
+		return majorType, uint64(binary.BigEndian.Uint16(b)), nil
+	case additionalInfo == 26:
+		b, err = d.read(4)
+		if err != nil {
+			return 0, 0, err
+		}
+		return majorType, uint64(binary.BigEndian.Uint32(b)), nil
+	case additionalInfo == 27:
+		b, err = d.read(8)
+		if err != nil {
+			return 0, 0, err
+		}
+		return majorType, binary.BigEndian.Uint64(b), nil
+	default:
+		// Indefinite lengths are not used by authenticators, since CTAP2 requires the canonical encoding.
+		return 0, 0, fmt.Errorf("cbor: unsupported additional information %d", additionalInfo)
+	}
+}
+
+func (d *cborDecoder) read(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.offset) {
+		return nil, fmt.Errorf("cbor: unexpected end of data")
+	}
+	b := d.data[d.offset : d.offset+int(n)]
+	d.offset += int(n)
+	return b, nil
+}
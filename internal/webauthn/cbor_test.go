@@ -0,0 +1,143 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package webauthn
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeCBOR(t *testing.T) {
+	tests := []struct {
+		name      string
+		hex       string
+		want      any
+		wantUsed  int
+		wantError string
+	}{
+		// Examples from appendix A of RFC 8949.
+		{name: "zero", hex: "00", want: int64(0)},
+		{name: "small integer", hex: "17", want: int64(23)},
+		{name: "one byte integer", hex: "1818", want: int64(24)},
+		{name: "two byte integer", hex: "1903e8", want: int64(1000)},
+		{name: "four byte integer", hex: "1a000f4240", want: int64(1000000)},
+		{name: "eight byte integer", hex: "1b000000e8d4a51000", want: int64(1000000000000)},
+		{name: "negative integer", hex: "20", want: int64(-1)},
+		{name: "negative two byte integer", hex: "3903e7", want: int64(-1000)},
+		{name: "byte string", hex: "4401020304", want: []byte{1, 2, 3, 4}},
+		{name: "text string", hex: "6449455446", want: "IETF"},
+		{name: "array", hex: "83010203", want: []any{int64(1), int64(2), int64(3)}},
+		{name: "nested array", hex: "8301820203820405", want: []any{int64(1), []any{int64(2), int64(3)}, []any{int64(4), int64(5)}}},
+		{name: "map", hex: "a201020304", want: map[any]any{int64(1): int64(2), int64(3): int64(4)}},
+		{name: "map with text keys", hex: "a26161016162820203", want: map[any]any{"a": int64(1), "b": []any{int64(2), int64(3)}}},
+		{name: "false", hex: "f4", want: false},
+		{name: "true", hex: "f5", want: true},
+		{name: "null", hex: "f6", want: nil},
+		{name: "trailing data is not used", hex: "0102", want: int64(1), wantUsed: 1},
+
+		{name: "integer which overflows int64", hex: "1bffffffffffffffff", wantError: "cbor: integer overflows int64"},
+		{name: "truncated byte string", hex: "440102", wantError: "cbor: unexpected end of data"},
+		{name: "truncated array", hex: "830102", wantError: "cbor: unexpected end of data"},
+		{name: "array which is longer than the data", hex: "9bffffffffffffffff", wantError: "cbor: unexpected end of data"},
+		{name: "map which is longer than the data", hex: "bbffffffffffffffff", wantError: "cbor: unexpected end of data"},
+		{name: "duplicate map key", hex: "a201020103", wantError: "cbor: duplicate map key 1"},
+		{name: "byte string map key", hex: "a1410102", wantError: "cbor: unsupported map key type []uint8"},
+		{name: "indefinite length", hex: "9f01ff", wantError: "cbor: unsupported additional information 31"},
+		{name: "tag", hex: "c11a514b67b0", wantError: "cbor: unsupported major type 6"},
+		{name: "float", hex: "f93c00", wantError: "cbor: unsupported simple value 15360"},
+		{name: "empty", hex: "", wantError: "cbor: unexpected end of data"},
+		{name: "too deeply nested", hex: "818181818181818181818100", wantError: "cbor: data is nested too deeply"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := hex.DecodeString(tt.hex)
+			require.NoError(t, err)
+
+			got, used, err := decodeCBOR(data)
+			if tt.wantError != "" {
+				require.EqualError(t, err, tt.wantError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+			wantUsed := tt.wantUsed
+			if wantUsed == 0 {
+				wantUsed = len(data)
+			}
+			require.Equal(t, wantUsed, used)
+		})
+	}
+}
+
+// encodeCBOR encodes the types which are returned by decodeCBOR, so that tests can build the responses of
+// authenticators. The keys of maps are not sorted, so the encoding is not canonical.
+func encodeCBOR(value any) []byte {
+	head := func(majorType byte, argument uint64) []byte {
+		switch {
+		case argument < 24:
+			return []byte{majorType<<5 | byte(argument)}
+		case argument <= 0xff:
+			return []byte{majorType<<5 | 24, byte(argument)}
+		case argument <= 0xffff:
+			return binary.BigEndian.AppendUint16([]byte{majorType<<5 | 25}, uint16(argument))
+		case argument <= 0xffffffff:
+			return binary.BigEndian.AppendUint32([]byte{majorType<<5 | 26}, uint32(argument))
+		default:
+			return binary.BigEndian.AppendUint64([]byte{majorType<<5 | 27}, argument)
+		}
+	}
+
+	switch v := value.(type) {
+	case int:
+		return encodeCBOR(int64(v))
+	case int64:
+		if v < 0 {
+			return head(1, uint64(-1-v))
+		}
+		return head(0, uint64(v))
+	case []byte:
+		return append(head(2, uint64(len(v))), v...)
+	case string:
+		return append(head(3, uint64(len(v))), v...)
+	case []any:
+		encoded := head(4, uint64(len(v)))
+		for _, item := range v {
+			encoded = append(encoded, encodeCBOR(item)...)
+		}
+		return encoded
+	case map[any]any:
+		encoded := head(5, uint64(len(v)))
+		for key, item := range v {
+			encoded = append(encoded, encodeCBOR(key)...)
+			encoded = append(encoded, encodeCBOR(item)...)
+		}
+		return encoded
+	case bool:
+		if v {
+			return []byte{0xf5}
+		}
+		return []byte{0xf4}
+	case nil:
+		return []byte{0xf6}
+	default:
+		panic("unsupported type")
+	}
+}
+
+func TestEncodeCBORRoundTrip(t *testing.T) {
+	value := map[any]any{
+		"fmt":     "none",
+		"attStmt": map[any]any{},
+		int64(-1): []byte{1, 2, 3},
+		int64(3):  []any{int64(-257), "x", true, nil},
+		"big":     int64(1 << 40),
+	}
+	got, used, err := decodeCBOR(encodeCBOR(value))
+	require.NoError(t, err)
+	require.Equal(t, value, got)
+	require.Equal(t, len(encodeCBOR(value)), used)
+}
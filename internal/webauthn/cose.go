@@ -0,0 +1,131 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+)
+
+// The COSE algorithms (RFC 9053) which are supported for credential public keys.
+const (
+	AlgorithmES256 = -7
+	AlgorithmEdDSA = -8
+	AlgorithmRS256 = -257
+)
+
+// SupportedAlgorithms are the COSE algorithms of the credentials which can be registered, in order of preference.
+var SupportedAlgorithms = []int64{AlgorithmES256, AlgorithmEdDSA, AlgorithmRS256} //nolint:gochecknoglobals // This is effectively a constant.
+
+// The parameters of COSE keys (RFC 9052 and RFC 9053).
+const (
+	coseKeyType      = 1
+	coseKeyAlgorithm = 3
+	coseKeyCurve     = -1 // for EC2 and OKP keys
+	coseKeyX         = -2 // for EC2 and OKP keys
+	coseKeyY         = -3 // for EC2 keys
+	coseKeyN         = -1 // for RSA keys
+	coseKeyE         = -2 // for RSA keys
+
+	coseKeyTypeOKP = 1
+	coseKeyTypeEC2 = 2
+	coseKeyTypeRSA = 3
+
+	coseCurveP256    = 1
+	coseCurveEd25519 = 6
+
+	minRSAKeyBits = 2048
+)
+
+// publicKey is the public key of a credential. The type of the key determines the algorithm which it is used with.
+type publicKey struct {
+	key crypto.PublicKey
+}
+
+// parsePublicKey parses a COSE_Key, which must use one of the SupportedAlgorithms.
+func parsePublicKey(coseKey []byte) (*publicKey, error) {
+	decoded, n, err := decodeCBOR(coseKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credential public key: %w", err)
+	}
+	if n != len(coseKey) {
+		return nil, fmt.Errorf("invalid credential public key: unexpected trailing data")
+	}
+	return publicKeyFromCOSE(decoded)
+}
+
+func publicKeyFromCOSE(decoded any) (*publicKey, error) {
+	m, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid credential public key: not a map")
+	}
+	keyType, _ := m[int64(coseKeyType)].(int64)
+	algorithm, _ := m[int64(coseKeyAlgorithm)].(int64)
+
+	switch {
+	case keyType == coseKeyTypeEC2 && algorithm == AlgorithmES256:
+		curve, _ := m[int64(coseKeyCurve)].(int64)
+		x, _ := m[int64(coseKeyX)].([]byte)
+		y, _ := m[int64(coseKeyY)].([]byte)
+		if curve != coseCurveP256 || len(x) != 32 || len(y) != 32 {
+			return nil, fmt.Errorf("invalid credential public key: unsupported ES256 key parameters")
+		}
+		// Use crypto/ecdh to check that the point is on the curve.
+		if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return nil, fmt.Errorf("invalid credential public key: point is not on the curve")
+		}
+		return &publicKey{key: &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}}, nil
+
+	case keyType == coseKeyTypeOKP && algorithm == AlgorithmEdDSA:
+		curve, _ := m[int64(coseKeyCurve)].(int64)
+		x, _ := m[int64(coseKeyX)].([]byte)
+		if curve != coseCurveEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid credential public key: unsupported EdDSA key parameters")
+		}
+		return &publicKey{key: ed25519.PublicKey(x)}, nil
+
+	case keyType == coseKeyTypeRSA && algorithm == AlgorithmRS256:
+		n, _ := m[int64(coseKeyN)].([]byte)
+		e, _ := m[int64(coseKeyE)].([]byte)
+		modulus := new(big.Int).SetBytes(n)
+		exponent := new(big.Int).SetBytes(e)
+		if modulus.BitLen() < minRSAKeyBits || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid credential public key: unsupported RS256 key parameters")
+		}
+		return &publicKey{key: &rsa.PublicKey{N: modulus, E: int(exponent.Int64())}}, nil
+
+	default:
+		return nil, fmt.Errorf("invalid credential public key: unsupported key type %d with algorithm %d", keyType, algorithm)
+	}
+}
+
+// verify checks the signature of the data.
+func (k *publicKey) verify(data, signature []byte) error {
+	valid := false
+	switch key := k.key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		valid = ecdsa.VerifyASN1(key, digest[:], signature)
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, data, signature)
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(data)
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	}
+	if !valid {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
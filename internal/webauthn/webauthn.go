@@ -0,0 +1,264 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package webauthn implements the checks of a WebAuthn relying party (https://www.w3.org/TR/webauthn-2/) for the
+// registration of credentials and for the assertions which prove that a user has a registered credential.
+//
+// Attestation statements are not verified, since the Supervisor asks browsers for "none" attestation, so any
+// authenticator may be registered.
+package webauthn
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+
+	"go.pinniped.dev/internal/constable"
+)
+
+const (
+	ErrInvalidResponse = constable.Error("invalid WebAuthn response")
+
+	// ChallengeSize is the number of random bytes in each challenge, which must be at least 16.
+	ChallengeSize = 32
+
+	// maxCredentialIDSize is the maximum length of a credential ID which is allowed by the specification.
+	maxCredentialIDSize = 1023
+
+	clientDataTypeCreate = "webauthn.create"
+	clientDataTypeGet    = "webauthn.get"
+
+	// The flags of the authenticator data.
+	flagUserPresent            = 0x01
+	flagUserVerified           = 0x04
+	flagAttestedCredentialData = 0x40
+)
+
+// RelyingParty checks the responses of the authenticators for one relying party, i.e. for one origin.
+type RelyingParty struct {
+	// ID is the relying party ID, which is the hostname of the origin.
+	ID string
+	// Origin is the scheme, hostname, and port which the browser must report in its client data.
+	Origin string
+	// RequireUserVerification requires the authenticator to verify the user, e.g. with a PIN or biometrics,
+	// in addition to checking that the user is present.
+	RequireUserVerification bool
+}
+
+// NewRelyingParty returns the relying party for the pages of the issuer.
+func NewRelyingParty(issuer string) (*RelyingParty, error) {
+	issuerURL, err := url.Parse(issuer)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse issuer: %w", err)
+	}
+	if issuerURL.Scheme != "https" || issuerURL.Hostname() == "" {
+		return nil, fmt.Errorf("issuer must be an https URL with a hostname")
+	}
+	return &RelyingParty{
+		ID:     issuerURL.Hostname(),
+		Origin: issuerURL.Scheme + "://" + issuerURL.Host,
+	}, nil
+}
+
+// GenerateChallenge returns a new random challenge for a registration or an assertion.
+func GenerateChallenge() ([]byte, error) {
+	challenge := make([]byte, ChallengeSize)
+	if _, err := io.ReadFull(rand.Reader, challenge); err != nil {
+		return nil, fmt.Errorf("could not generate WebAuthn challenge: %w", err)
+	}
+	return challenge, nil
+}
+
+// Credential is a registered public key credential.
+type Credential struct {
+	// ID identifies the credential at the authenticator.
+	ID []byte
+	// PublicKey is the COSE_Key of the credential.
+	PublicKey []byte
+	// SignCount is the last signature counter which was reported by the authenticator, or zero when the
+	// authenticator does not implement a counter.
+	SignCount uint32
+}
+
+// Registration is the response of the browser to navigator.credentials.create().
+type Registration struct {
+	ClientDataJSON    []byte
+	AttestationObject []byte
+}
+
+// Assertion is the response of the browser to navigator.credentials.get().
+type Assertion struct {
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+}
+
+// clientData is the part of the client data which is checked by the relying party.
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// authenticatorData is the parsed authenticator data.
+type authenticatorData struct {
+	rpIDHash  []byte
+	flags     byte
+	signCount uint32
+	// credentialID and credentialPublicKey are only set when the authenticator data has attested credential data.
+	credentialID        []byte
+	credentialPublicKey []byte
+}
+
+// VerifyRegistration checks the response to a registration with the challenge, and returns the new credential.
+func (rp *RelyingParty) VerifyRegistration(challenge []byte, registration *Registration) (*Credential, error) {
+	if err := rp.verifyClientData(registration.ClientDataJSON, clientDataTypeCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	decoded, n, err := decodeCBOR(registration.AttestationObject)
+	if err != nil || n != len(registration.AttestationObject) {
+		return nil, fmt.Errorf("%w: invalid attestation object", ErrInvalidResponse)
+	}
+	attestationObject, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid attestation object", ErrInvalidResponse)
+	}
+	rawAuthData, ok := attestationObject["authData"].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("%w: attestation object has no authenticator data", ErrInvalidResponse)
+	}
+
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.verifyAuthenticatorData(authData); err != nil {
+		return nil, err
+	}
+	if authData.credentialID == nil {
+		return nil, fmt.Errorf("%w: authenticator data has no attested credential data", ErrInvalidResponse)
+	}
+	if _, err := parsePublicKey(authData.credentialPublicKey); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+	}
+
+	return &Credential{
+		ID:        authData.credentialID,
+		PublicKey: authData.credentialPublicKey,
+		SignCount: authData.signCount,
+	}, nil
+}
+
+// VerifyAssertion checks the response to an assertion with the challenge, which must be signed by the credential.
+// It returns the new signature counter of the credential, which must be stored for the next assertion.
+func (rp *RelyingParty) VerifyAssertion(challenge []byte, credential *Credential, assertion *Assertion) (uint32, error) {
+	if subtle.ConstantTimeCompare(assertion.CredentialID, credential.ID) != 1 {
+		return 0, fmt.Errorf("%w: unknown credential", ErrInvalidResponse)
+	}
+	if err := rp.verifyClientData(assertion.ClientDataJSON, clientDataTypeGet, challenge); err != nil {
+		return 0, err
+	}
+
+	authData, err := parseAuthenticatorData(assertion.AuthenticatorData)
+	if err != nil {
+		return 0, err
+	}
+	if err := rp.verifyAuthenticatorData(authData); err != nil {
+		return 0, err
+	}
+
+	key, err := parsePublicKey(credential.PublicKey)
+	if err != nil {
+		return 0, err
+	}
+	clientDataHash := sha256.Sum256(assertion.ClientDataJSON)
+	signedData := append(append([]byte(nil), assertion.AuthenticatorData...), clientDataHash[:]...)
+	if err := key.verify(signedData, assertion.Signature); err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+	}
+
+	// A counter which did not increase means that the authenticator may have been cloned. Authenticators which
+	// do not implement a counter always report zero.
+	if (authData.signCount != 0 || credential.SignCount != 0) && authData.signCount <= credential.SignCount {
+		return 0, fmt.Errorf("%w: signature counter did not increase", ErrInvalidResponse)
+	}
+
+	return authData.signCount, nil
+}
+
+func (rp *RelyingParty) verifyClientData(clientDataJSON []byte, wantType string, challenge []byte) error {
+	var data clientData
+	if err := json.Unmarshal(clientDataJSON, &data); err != nil {
+		return fmt.Errorf("%w: invalid client data: %w", ErrInvalidResponse, err)
+	}
+	if data.Type != wantType {
+		return fmt.Errorf("%w: client data has type %q instead of %q", ErrInvalidResponse, data.Type, wantType)
+	}
+	if subtle.ConstantTimeCompare([]byte(data.Challenge), []byte(base64.RawURLEncoding.EncodeToString(challenge))) != 1 {
+		return fmt.Errorf("%w: client data has the wrong challenge", ErrInvalidResponse)
+	}
+	if data.Origin != rp.Origin || data.CrossOrigin {
+		return fmt.Errorf("%w: client data has origin %q instead of %q", ErrInvalidResponse, data.Origin, rp.Origin)
+	}
+	return nil
+}
+
+func (rp *RelyingParty) verifyAuthenticatorData(authData *authenticatorData) error {
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if subtle.ConstantTimeCompare(authData.rpIDHash, rpIDHash[:]) != 1 {
+		return fmt.Errorf("%w: authenticator data is for the wrong relying party", ErrInvalidResponse)
+	}
+	if authData.flags&flagUserPresent == 0 {
+		return fmt.Errorf("%w: user was not present", ErrInvalidResponse)
+	}
+	if rp.RequireUserVerification && authData.flags&flagUserVerified == 0 {
+		return fmt.Errorf("%w: user was not verified", ErrInvalidResponse)
+	}
+	return nil
+}
+
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	// The RP ID hash (32 bytes), the flags (1 byte), and the signature counter (4 bytes) are always present.
+	const minLength = 32 + 1 + 4
+	if len(data) < minLength {
+		return nil, fmt.Errorf("%w: authenticator data is too short", ErrInvalidResponse)
+	}
+	authData := &authenticatorData{
+		rpIDHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	if authData.flags&flagAttestedCredentialData == 0 {
+		return authData, nil
+	}
+
+	// The attested credential data consists of the AAGUID (16 bytes), the length of the credential ID (2 bytes),
+	// the credential ID, and the credential public key. Extensions may follow, which are ignored.
+	rest := data[minLength:]
+	if len(rest) < 16+2 {
+		return nil, fmt.Errorf("%w: attested credential data is too short", ErrInvalidResponse)
+	}
+	idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if idLength == 0 || idLength > maxCredentialIDSize || len(rest) < idLength {
+		return nil, fmt.Errorf("%w: invalid credential ID", ErrInvalidResponse)
+	}
+	authData.credentialID = append([]byte(nil), rest[:idLength]...)
+	rest = rest[idLength:]
+
+	_, keyLength, err := decodeCBOR(rest)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid credential public key: %w", ErrInvalidResponse, err)
+	}
+	authData.credentialPublicKey = append([]byte(nil), rest[:keyLength]...)
+	return authData, nil
+}
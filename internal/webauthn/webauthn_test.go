@@ -0,0 +1,341 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testAuthenticator simulates an authenticator and the browser which talks to it.
+type testAuthenticator struct {
+	t            *testing.T
+	origin       string
+	rpID         string
+	credentialID []byte
+	signer       crypto.Signer
+	coseKey      map[any]any
+	signCount    uint32
+	flags        byte
+}
+
+func newTestAuthenticator(t *testing.T, signer crypto.Signer) *testAuthenticator {
+	t.Helper()
+
+	var coseKey map[any]any
+	switch key := signer.Public().(type) {
+	case *ecdsa.PublicKey:
+		coseKey = map[any]any{
+			int64(coseKeyType): int64(coseKeyTypeEC2), int64(coseKeyAlgorithm): int64(AlgorithmES256),
+			int64(coseKeyCurve): int64(coseCurveP256), int64(coseKeyX): key.X.FillBytes(make([]byte, 32)), int64(coseKeyY): key.Y.FillBytes(make([]byte, 32)),
+		}
+	case ed25519.PublicKey:
+		coseKey = map[any]any{
+			int64(coseKeyType): int64(coseKeyTypeOKP), int64(coseKeyAlgorithm): int64(AlgorithmEdDSA),
+			int64(coseKeyCurve): int64(coseCurveEd25519), int64(coseKeyX): []byte(key),
+		}
+	case *rsa.PublicKey:
+		coseKey = map[any]any{
+			int64(coseKeyType): int64(coseKeyTypeRSA), int64(coseKeyAlgorithm): int64(AlgorithmRS256),
+			int64(coseKeyN): key.N.Bytes(), int64(coseKeyE): []byte{1, 0, 1},
+		}
+	}
+
+	return &testAuthenticator{
+		t:            t,
+		origin:       "https://issuer.example.com:8443",
+		rpID:         "issuer.example.com",
+		credentialID: []byte("some-credential-id"),
+		signer:       signer,
+		coseKey:      coseKey,
+		flags:        flagUserPresent,
+	}
+}
+
+func (a *testAuthenticator) clientData(typ string, challenge []byte) []byte {
+	clientDataJSON, err := json.Marshal(map[string]any{
+		"type":      typ,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    a.origin,
+	})
+	require.NoError(a.t, err)
+	return clientDataJSON
+}
+
+func (a *testAuthenticator) authData(withCredential bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	data := append(rpIDHash[:], a.flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if withCredential {
+		data[32] |= flagAttestedCredentialData
+		data = append(data, make([]byte, 16)...) // AAGUID
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, encodeCBOR(a.coseKey)...)
+	}
+	return data
+}
+
+func (a *testAuthenticator) register(challenge []byte) *Registration {
+	return &Registration{
+		ClientDataJSON: a.clientData(clientDataTypeCreate, challenge),
+		AttestationObject: encodeCBOR(map[any]any{
+			"fmt":      "none",
+			"attStmt":  map[any]any{},
+			"authData": a.authData(true),
+		}),
+	}
+}
+
+func (a *testAuthenticator) assert(challenge []byte) *Assertion {
+	clientDataJSON := a.clientData(clientDataTypeGet, challenge)
+	authData := a.authData(false)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signedData := append(append([]byte(nil), authData...), clientDataHash[:]...)
+
+	var signature []byte
+	var err error
+	if _, ok := a.signer.(ed25519.PrivateKey); ok {
+		signature, err = a.signer.Sign(rand.Reader, signedData, crypto.Hash(0))
+	} else {
+		digest := sha256.Sum256(signedData)
+		signature, err = a.signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	require.NoError(a.t, err)
+
+	return &Assertion{
+		CredentialID:      a.credentialID,
+		ClientDataJSON:    clientDataJSON,
+		AuthenticatorData: authData,
+		Signature:         signature,
+	}
+}
+
+func TestNewRelyingParty(t *testing.T) {
+	rp, err := NewRelyingParty("https://issuer.example.com:8443/some/path")
+	require.NoError(t, err)
+	require.Equal(t, &RelyingParty{ID: "issuer.example.com", Origin: "https://issuer.example.com:8443"}, rp)
+
+	_, err = NewRelyingParty("http://issuer.example.com")
+	require.EqualError(t, err, "issuer must be an https URL with a hostname")
+}
+
+func TestRegisterAndAssert(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	for name, signer := range map[string]crypto.Signer{"ES256": ecKey, "EdDSA": edKey, "RS256": rsaKey} {
+		t.Run(name, func(t *testing.T) {
+			rp, err := NewRelyingParty("https://issuer.example.com:8443/some/path")
+			require.NoError(t, err)
+			authenticator := newTestAuthenticator(t, signer)
+
+			challenge, err := GenerateChallenge()
+			require.NoError(t, err)
+			credential, err := rp.VerifyRegistration(challenge, authenticator.register(challenge))
+			require.NoError(t, err)
+			require.Equal(t, authenticator.credentialID, credential.ID)
+			require.Zero(t, credential.SignCount)
+
+			// Authenticators without a counter always report zero.
+			challenge, err = GenerateChallenge()
+			require.NoError(t, err)
+			signCount, err := rp.VerifyAssertion(challenge, credential, authenticator.assert(challenge))
+			require.NoError(t, err)
+			require.Zero(t, signCount)
+
+			// Authenticators with a counter must increase it.
+			authenticator.signCount = 5
+			signCount, err = rp.VerifyAssertion(challenge, credential, authenticator.assert(challenge))
+			require.NoError(t, err)
+			require.Equal(t, uint32(5), signCount)
+			credential.SignCount = signCount
+			_, err = rp.VerifyAssertion(challenge, credential, authenticator.assert(challenge))
+			require.EqualError(t, err, "invalid WebAuthn response: signature counter did not increase")
+		})
+	}
+}
+
+func TestVerifyRegistrationErrors(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	challenge := []byte("some-challenge-which-is-long-enough")
+
+	tests := []struct {
+		name         string
+		rp           *RelyingParty
+		edit         func(a *testAuthenticator)
+		editResponse func(r *Registration)
+		wantError    string
+	}{
+		{
+			name: "wrong challenge",
+			editResponse: func(r *Registration) {
+				r.ClientDataJSON = newTestAuthenticator(t, key).clientData(clientDataTypeCreate, []byte("other-challenge"))
+			},
+			wantError: "invalid WebAuthn response: client data has the wrong challenge",
+		},
+		{
+			name: "wrong type",
+			editResponse: func(r *Registration) {
+				r.ClientDataJSON = newTestAuthenticator(t, key).clientData(clientDataTypeGet, challenge)
+			},
+			wantError: `invalid WebAuthn response: client data has type "webauthn.get" instead of "webauthn.create"`,
+		},
+		{
+			name:      "wrong origin",
+			edit:      func(a *testAuthenticator) { a.origin = "https://evil.example.com" },
+			wantError: `invalid WebAuthn response: client data has origin "https://evil.example.com" instead of "https://issuer.example.com:8443"`,
+		},
+		{
+			name:      "wrong relying party ID",
+			edit:      func(a *testAuthenticator) { a.rpID = "example.com" },
+			wantError: "invalid WebAuthn response: authenticator data is for the wrong relying party",
+		},
+		{
+			name:      "user not present",
+			edit:      func(a *testAuthenticator) { a.flags = 0 },
+			wantError: "invalid WebAuthn response: user was not present",
+		},
+		{
+			name:      "user not verified when required",
+			rp:        &RelyingParty{ID: "issuer.example.com", Origin: "https://issuer.example.com:8443", RequireUserVerification: true},
+			wantError: "invalid WebAuthn response: user was not verified",
+		},
+		{
+			name: "unsupported algorithm",
+			edit: func(a *testAuthenticator) {
+				a.coseKey[int64(coseKeyAlgorithm)] = int64(-35) // ES384
+			},
+			wantError: "invalid WebAuthn response: invalid credential public key: unsupported key type 2 with algorithm -35",
+		},
+		{
+			name: "point not on the curve",
+			edit: func(a *testAuthenticator) {
+				a.coseKey[int64(coseKeyY)] = make([]byte, 32)
+			},
+			wantError: "invalid WebAuthn response: invalid credential public key: point is not on the curve",
+		},
+		{
+			name: "invalid attestation object",
+			editResponse: func(r *Registration) {
+				r.AttestationObject = []byte("not cbor")
+			},
+			wantError: "invalid WebAuthn response: invalid attestation object",
+		},
+		{
+			name: "no credential",
+			editResponse: func(r *Registration) {
+				a := newTestAuthenticator(t, key)
+				r.AttestationObject = encodeCBOR(map[any]any{"fmt": "none", "attStmt": map[any]any{}, "authData": a.authData(false)})
+			},
+			wantError: "invalid WebAuthn response: authenticator data has no attested credential data",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := tt.rp
+			if rp == nil {
+				rp = &RelyingParty{ID: "issuer.example.com", Origin: "https://issuer.example.com:8443"}
+			}
+			authenticator := newTestAuthenticator(t, key)
+			if tt.edit != nil {
+				tt.edit(authenticator)
+			}
+			registration := authenticator.register(challenge)
+			if tt.editResponse != nil {
+				tt.editResponse(registration)
+			}
+
+			credential, err := rp.VerifyRegistration(challenge, registration)
+			require.EqualError(t, err, tt.wantError)
+			require.ErrorIs(t, err, ErrInvalidResponse)
+			require.Nil(t, credential)
+		})
+	}
+}
+
+func TestVerifyAssertionErrors(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rp := &RelyingParty{ID: "issuer.example.com", Origin: "https://issuer.example.com:8443"}
+	challenge := []byte("some-challenge-which-is-long-enough")
+
+	registering := newTestAuthenticator(t, key)
+	credential, err := rp.VerifyRegistration(challenge, registering.register(challenge))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		edit      func(a *testAuthenticator)
+		wantError string
+	}{
+		{
+			name:      "other credential",
+			edit:      func(a *testAuthenticator) { a.credentialID = []byte("other-credential-id") },
+			wantError: "invalid WebAuthn response: unknown credential",
+		},
+		{
+			name:      "signed by another key",
+			edit:      func(a *testAuthenticator) { a.signer = otherKey },
+			wantError: "invalid WebAuthn response: invalid signature",
+		},
+		{
+			name:      "wrong relying party ID",
+			edit:      func(a *testAuthenticator) { a.rpID = "example.com" },
+			wantError: "invalid WebAuthn response: authenticator data is for the wrong relying party",
+		},
+		{
+			name:      "wrong origin",
+			edit:      func(a *testAuthenticator) { a.origin = "https://issuer.example.com" },
+			wantError: `invalid WebAuthn response: client data has origin "https://issuer.example.com" instead of "https://issuer.example.com:8443"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator := newTestAuthenticator(t, key)
+			tt.edit(authenticator)
+
+			_, err := rp.VerifyAssertion(challenge, credential, authenticator.assert(challenge))
+			require.EqualError(t, err, tt.wantError)
+			require.ErrorIs(t, err, ErrInvalidResponse)
+		})
+	}
+
+	t.Run("wrong challenge", func(t *testing.T) {
+		_, err := rp.VerifyAssertion([]byte("other-challenge"), credential, newTestAuthenticator(t, key).assert(challenge))
+		require.EqualError(t, err, "invalid WebAuthn response: client data has the wrong challenge")
+	})
+
+	t.Run("registration response used as assertion", func(t *testing.T) {
+		assertion := newTestAuthenticator(t, key).assert(challenge)
+		assertion.ClientDataJSON = registering.clientData(clientDataTypeCreate, challenge)
+		_, err := rp.VerifyAssertion(challenge, credential, assertion)
+		require.EqualError(t, err, `invalid WebAuthn response: client data has type "webauthn.create" instead of "webauthn.get"`)
+	})
+
+	t.Run("truncated authenticator data", func(t *testing.T) {
+		assertion := newTestAuthenticator(t, key).assert(challenge)
+		assertion.AuthenticatorData = assertion.AuthenticatorData[:36]
+		_, err := rp.VerifyAssertion(challenge, credential, assertion)
+		require.EqualError(t, err, "invalid WebAuthn response: authenticator data is too short")
+	})
+}
@@ -0,0 +1,135 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package webauthncredentialstorage stores the WebAuthn credentials of the users of the identity providers of the
+// FederationDomains which require them. Only the public keys of the credentials are stored.
+package webauthncredentialstorage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"go.pinniped.dev/internal/constable"
+	"go.pinniped.dev/internal/crud"
+	"go.pinniped.dev/internal/webauthn"
+)
+
+const (
+	TypeLabelValue = "webauthn-credential"
+
+	ErrWebAuthnCredentialStorageVersion = constable.Error("WebAuthn credential storage data has wrong version")
+
+	// Version 1 was the initial release of WebAuthn credentials.
+	webAuthnCredentialStorageVersion = "1"
+)
+
+// Credential is the registered WebAuthn credential of a user of an identity provider.
+type Credential struct {
+	webauthn.Credential
+	// UsedChallenges maps the hashes of the challenges of the recent assertions to the times when the challenges
+	// expire, so that an assertion cannot be used twice.
+	UsedChallenges map[string]time.Time
+}
+
+// storedCredential defines the format of a credential when stored in a Secret as a JSON string value.
+type storedCredential struct {
+	CredentialID   []byte               `json:"credentialID"`
+	PublicKey      []byte               `json:"publicKey"`
+	SignCount      uint32               `json:"signCount"`
+	UsedChallenges map[string]time.Time `json:"usedChallenges,omitempty"`
+	// The format version. Take care when updating. We cannot simply bump the storage version and drop/ignore old data.
+	// Updating this would require some form of migration of existing stored data.
+	Version string `json:"version"`
+}
+
+type WebAuthnCredentialStorage struct {
+	storage crud.Storage
+}
+
+func New(secrets corev1client.SecretInterface) *WebAuthnCredentialStorage {
+	return &WebAuthnCredentialStorage{
+		storage: crud.New(TypeLabelValue, secrets, nil), // can use nil clock because we are using infinite lifetime for creates
+	}
+}
+
+// Get returns the resourceVersion of the storage secret, the credential of the user, and an error.
+// When the user has not registered a credential yet, it will simply return "", nil, nil to make it easy to pass
+// the results of Get directly to Set.
+func (s *WebAuthnCredentialStorage) Get(ctx context.Context, rpID, idpResourceName, username string) (string, *Credential, error) {
+	stored := &storedCredential{}
+	rv, err := s.storage.Get(ctx, toName(rpID, idpResourceName, username), stored)
+	if apierrors.IsNotFound(err) {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get WebAuthn credential: %w", err)
+	}
+	if stored.Version != webAuthnCredentialStorageVersion {
+		return "", nil, fmt.Errorf("%w: WebAuthn credential storage has version %s instead of %s",
+			ErrWebAuthnCredentialStorageVersion, stored.Version, webAuthnCredentialStorageVersion)
+	}
+
+	return rv, &Credential{
+		Credential: webauthn.Credential{
+			ID:        stored.CredentialID,
+			PublicKey: stored.PublicKey,
+			SignCount: stored.SignCount,
+		},
+		UsedChallenges: stored.UsedChallenges,
+	}, nil
+}
+
+// Set will create or update the credential of the user.
+// Set takes the resourceVersion to know if we are doing a create or update and to ensure we do not edit an old
+// version of the storage secret, which also ensures that an assertion cannot be used by two concurrent logins.
+func (s *WebAuthnCredentialStorage) Set(ctx context.Context, resourceVersion, rpID, idpResourceName, username string, credential *Credential) error {
+	name := toName(rpID, idpResourceName, username)
+	stored := &storedCredential{
+		CredentialID:   credential.ID,
+		PublicKey:      credential.PublicKey,
+		SignCount:      credential.SignCount,
+		UsedChallenges: credential.UsedChallenges,
+		Version:        webAuthnCredentialStorageVersion,
+	}
+
+	if mustBeCreate := len(resourceVersion) == 0; mustBeCreate {
+		if _, err := s.storage.Create(ctx, name, stored, nil, nil, 0); err != nil { // 0 is infinite lifetime
+			return fmt.Errorf("failed to create WebAuthn credential: %w", err)
+		}
+		return nil
+	}
+
+	if _, err := s.storage.Update(ctx, name, resourceVersion, stored); err != nil {
+		return fmt.Errorf("failed to update WebAuthn credential: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the credential of the user, so that they will be asked to register a credential again at their
+// next login.
+func (s *WebAuthnCredentialStorage) Delete(ctx context.Context, rpID, idpResourceName, username string) error {
+	err := s.storage.Delete(ctx, toName(rpID, idpResourceName, username))
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete WebAuthn credential: %w", err)
+	}
+	return nil
+}
+
+// GetName returns the name of the Secret which would be used to store the credential of the user.
+func (s *WebAuthnCredentialStorage) GetName(rpID, idpResourceName, username string) string {
+	return s.storage.GetName(toName(rpID, idpResourceName, username))
+}
+
+func toName(rpID, idpResourceName, username string) string {
+	// Credentials are scoped to a relying party ID, i.e. to the hostname of the issuer, so include it in the name.
+	// Hash the names, since usernames may be long and may contain characters which are not allowed in the name
+	// of a Secret. Encode the hash here, since s.storage.GetName() will base64 decode it anyway.
+	sum := sha256.Sum256([]byte(rpID + "\x00" + idpResourceName + "\x00" + username))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,102 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package webauthncredentialstorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"go.pinniped.dev/internal/testutil"
+	"go.pinniped.dev/internal/webauthn"
+)
+
+const namespace = "some-namespace"
+
+func TestSetAndGet(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	testutil.AddSecretResourceVersionReactors(client)
+	secrets := client.CoreV1().Secrets(namespace)
+	subject := New(secrets)
+
+	rv, credential, err := subject.Get(ctx, "issuer.example.com", "some-idp", "some-user")
+	require.NoError(t, err)
+	require.Empty(t, rv)
+	require.Nil(t, credential)
+
+	registered := &Credential{Credential: webauthn.Credential{ID: []byte("some-id"), PublicKey: []byte("some-key"), SignCount: 1}}
+	err = subject.Set(ctx, rv, "issuer.example.com", "some-idp", "some-user", registered)
+	require.NoError(t, err)
+
+	stored, err := secrets.Get(ctx, subject.GetName("issuer.example.com", "some-idp", "some-user"), metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, corev1.SecretType("storage.pinniped.dev/webauthn-credential"), stored.Type)
+	require.Equal(t, "webauthn-credential", stored.Labels["storage.pinniped.dev/type"])
+
+	rv, credential, err = subject.Get(ctx, "issuer.example.com", "some-idp", "some-user")
+	require.NoError(t, err)
+	require.Equal(t, registered, credential)
+
+	expires := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	updated := &Credential{
+		Credential:     webauthn.Credential{ID: []byte("some-id"), PublicKey: []byte("some-key"), SignCount: 2},
+		UsedChallenges: map[string]time.Time{"some-challenge-hash": expires},
+	}
+	err = subject.Set(ctx, rv, "issuer.example.com", "some-idp", "some-user", updated)
+	require.NoError(t, err)
+
+	_, credential, err = subject.Get(ctx, "issuer.example.com", "some-idp", "some-user")
+	require.NoError(t, err)
+	require.Equal(t, updated, credential)
+
+	// Updating an old version fails, so two concurrent logins cannot both use the same assertion.
+	err = subject.Set(ctx, rv, "issuer.example.com", "some-idp", "some-user", updated)
+	require.ErrorContains(t, err, "failed to update WebAuthn credential")
+
+	// Other relying parties, users, and identity providers have their own credentials.
+	_, credential, err = subject.Get(ctx, "other.example.com", "some-idp", "some-user")
+	require.NoError(t, err)
+	require.Nil(t, credential)
+	_, credential, err = subject.Get(ctx, "issuer.example.com", "some-idp", "some-other-user")
+	require.NoError(t, err)
+	require.Nil(t, credential)
+	_, credential, err = subject.Get(ctx, "issuer.example.com", "some-other-idp", "some-user")
+	require.NoError(t, err)
+	require.Nil(t, credential)
+
+	require.NoError(t, subject.Delete(ctx, "issuer.example.com", "some-idp", "some-user"))
+	require.NoError(t, subject.Delete(ctx, "issuer.example.com", "some-idp", "some-user"))
+	_, credential, err = subject.Get(ctx, "issuer.example.com", "some-idp", "some-user")
+	require.NoError(t, err)
+	require.Nil(t, credential)
+}
+
+func TestGetWrongVersion(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	subject := New(client.CoreV1().Secrets(namespace))
+
+	_, err := client.CoreV1().Secrets(namespace).Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      subject.GetName("issuer.example.com", "some-idp", "some-user"),
+			Namespace: namespace,
+			Labels:    map[string]string{"storage.pinniped.dev/type": "webauthn-credential"},
+		},
+		Type: "storage.pinniped.dev/webauthn-credential",
+		Data: map[string][]byte{
+			"pinniped-storage-data":    []byte(`{"credentialID":"","version":"wrong"}`),
+			"pinniped-storage-version": []byte("1"),
+		},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	_, _, err = subject.Get(ctx, "issuer.example.com", "some-idp", "some-user")
+	require.EqualError(t, err, "WebAuthn credential storage data has wrong version: WebAuthn credential storage has version wrong instead of 1")
+}
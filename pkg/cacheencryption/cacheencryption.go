@@ -0,0 +1,97 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package cacheencryption encrypts the files of the session and credential caches, so that the tokens and
+// certificates in them cannot be read by anyone who can only read the files.
+//
+// Only AES-256-GCM with a key from a file is provided. Keys from the keychain of the operating system and the
+// format of the age encryption tool are intentionally not supported, to avoid adding platform-specific and
+// third-party dependencies. Embedders who need them can implement Provider themselves.
+package cacheencryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.pinniped.dev/internal/constable"
+)
+
+// ErrNotEncrypted is returned by Decrypt when the data was not encrypted by this kind of Provider, e.g. because
+// the cache file was written before encryption was enabled.
+const ErrNotEncrypted = constable.Error("cache file is not encrypted with this key type")
+
+// Provider encrypts and decrypts the whole contents of a cache file. Embedders may implement it to wrap the
+// contents with their own keys, e.g. from a KMS or from the keyring of the operating system.
+type Provider interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// aesGCMHeader starts every file encrypted by aesGCM, and is also authenticated as additional data.
+var aesGCMHeader = []byte("pinniped-aes-256-gcm-v1\n") //nolint:gochecknoglobals
+
+type aesGCM struct {
+	aead cipher.AEAD
+}
+
+// NewAESGCM returns a Provider which encrypts with AES-256-GCM using the key, which must be 32 bytes long.
+func NewAESGCM(key []byte) (Provider, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("cache encryption key must be 32 bytes long, but was %d bytes", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("could not create cache encryption cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("could not create cache encryption cipher: %w", err)
+	}
+	return &aesGCM{aead: aead}, nil
+}
+
+// NewAESGCMFromKeyFile returns a Provider like NewAESGCM, with the base64 encoded key read from the file at path.
+// Such a key can be generated with `openssl rand -base64 32`.
+func NewAESGCMFromKeyFile(path string) (Provider, error) {
+	encoded, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read cache encryption key file: %w", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		return nil, fmt.Errorf("could not decode cache encryption key file %s: %w", path, err)
+	}
+	return NewAESGCM(key)
+}
+
+func (a *aesGCM) Encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, a.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("could not generate nonce: %w", err)
+	}
+	ciphertext := append(bytes.Clone(aesGCMHeader), nonce...)
+	return a.aead.Seal(ciphertext, nonce, plaintext, aesGCMHeader), nil
+}
+
+func (a *aesGCM) Decrypt(ciphertext []byte) ([]byte, error) {
+	sealed, ok := bytes.CutPrefix(ciphertext, aesGCMHeader)
+	if !ok {
+		return nil, ErrNotEncrypted
+	}
+	if len(sealed) < a.aead.NonceSize() {
+		return nil, fmt.Errorf("could not decrypt cache file: too short")
+	}
+	nonce, sealed := sealed[:a.aead.NonceSize()], sealed[a.aead.NonceSize():]
+	plaintext, err := a.aead.Open(nil, nonce, sealed, aesGCMHeader)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt cache file, it may have been encrypted with a different key: %w", err)
+	}
+	return plaintext, nil
+}
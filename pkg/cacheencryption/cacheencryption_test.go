@@ -0,0 +1,79 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cacheencryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAESGCM(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	provider, err := NewAESGCM(key)
+	require.NoError(t, err)
+
+	plaintext := []byte("apiVersion: config.supervisor.pinniped.dev/v1alpha1\nkind: SessionCache\n")
+	ciphertext, err := provider.Encrypt(plaintext)
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(ciphertext, []byte("pinniped-aes-256-gcm-v1\n")))
+	require.NotContains(t, string(ciphertext), "SessionCache")
+
+	// Every encryption uses a new nonce.
+	again, err := provider.Encrypt(plaintext)
+	require.NoError(t, err)
+	require.NotEqual(t, ciphertext, again)
+
+	decrypted, err := provider.Decrypt(ciphertext)
+	require.NoError(t, err)
+	require.Equal(t, plaintext, decrypted)
+
+	_, err = provider.Decrypt(plaintext)
+	require.ErrorIs(t, err, ErrNotEncrypted)
+
+	_, err = provider.Decrypt([]byte("pinniped-aes-256-gcm-v1\nshort"))
+	require.EqualError(t, err, "could not decrypt cache file: too short")
+
+	tampered := bytes.Clone(ciphertext)
+	tampered[len(tampered)-1] ^= 1
+	_, err = provider.Decrypt(tampered)
+	require.EqualError(t, err, "could not decrypt cache file, it may have been encrypted with a different key: cipher: message authentication failed")
+
+	otherProvider, err := NewAESGCM(bytes.Repeat([]byte{2}, 32))
+	require.NoError(t, err)
+	_, err = otherProvider.Decrypt(ciphertext)
+	require.EqualError(t, err, "could not decrypt cache file, it may have been encrypted with a different key: cipher: message authentication failed")
+
+	_, err = NewAESGCM([]byte("too short"))
+	require.EqualError(t, err, "cache encryption key must be 32 bytes long, but was 9 bytes")
+}
+
+func TestNewAESGCMFromKeyFile(t *testing.T) {
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{1}, 32)
+
+	keyPath := filepath.Join(dir, "key")
+	require.NoError(t, os.WriteFile(keyPath, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0600))
+	fromFile, err := NewAESGCMFromKeyFile(keyPath)
+	require.NoError(t, err)
+	fromKey, err := NewAESGCM(key)
+	require.NoError(t, err)
+	ciphertext, err := fromFile.Encrypt([]byte("some-data"))
+	require.NoError(t, err)
+	plaintext, err := fromKey.Decrypt(ciphertext)
+	require.NoError(t, err)
+	require.Equal(t, []byte("some-data"), plaintext)
+
+	invalidPath := filepath.Join(dir, "invalid")
+	require.NoError(t, os.WriteFile(invalidPath, []byte("not base64!"), 0600))
+	_, err = NewAESGCMFromKeyFile(invalidPath)
+	require.EqualError(t, err, "could not decode cache encryption key file "+invalidPath+": illegal base64 data at input byte 3")
+
+	_, err = NewAESGCMFromKeyFile(filepath.Join(dir, "does-not-exist"))
+	require.ErrorContains(t, err, "could not read cache encryption key file: ")
+}
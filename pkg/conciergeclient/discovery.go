@@ -0,0 +1,174 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package conciergeclient
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	conciergeconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/config/v1alpha1"
+	conciergeclientset "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned"
+	"go.pinniped.dev/internal/groupsuffix"
+	"go.pinniped.dev/internal/kubeclient"
+)
+
+// Endpoint is where the Concierge accepts TokenCredentialRequests, as discovered from a CredentialIssuer.
+type Endpoint struct {
+	// Frontend is either the cluster's own API server, or the Concierge's impersonation proxy.
+	Frontend conciergeconfigv1alpha1.FrontendType
+	// Server is the base URL of the endpoint.
+	Server string
+	// CABundle is the PEM-formatted certificate authority bundle which issued the endpoint's serving certificate.
+	// It is empty when the cluster's configuration does not have one, in which case the system's roots are trusted.
+	CABundle []byte
+}
+
+// Options returns the Options which configure New() to use the endpoint.
+func (e *Endpoint) Options() []Option {
+	return []Option{WithEndpoint(e.Server), WithCABundle(string(e.CABundle))}
+}
+
+// DiscoveryOption is an optional configuration for Discover().
+type DiscoveryOption func(*discovery)
+
+type discovery struct {
+	credentialIssuer string
+	frontend         conciergeconfigv1alpha1.FrontendType
+	apiGroupSuffix   string
+}
+
+// WithCredentialIssuer configures the name of the CredentialIssuer to read. By default, the cluster must have
+// exactly one CredentialIssuer.
+func WithCredentialIssuer(name string) DiscoveryOption {
+	return func(d *discovery) {
+		d.credentialIssuer = name
+	}
+}
+
+// WithFrontend configures Discover() to only use strategies with the given frontend. By default, any frontend is used.
+func WithFrontend(frontend conciergeconfigv1alpha1.FrontendType) DiscoveryOption {
+	return func(d *discovery) {
+		d.frontend = frontend
+	}
+}
+
+// WithDiscoveryAPIGroupSuffix configures the Concierge's API group suffix (e.g., "pinniped.dev").
+func WithDiscoveryAPIGroupSuffix(apiGroupSuffix string) DiscoveryOption {
+	return func(d *discovery) {
+		d.apiGroupSuffix = apiGroupSuffix
+	}
+}
+
+// Discover reads the CredentialIssuer of the cluster which config points at, and returns the endpoint of its
+// preferred healthy strategy. The Concierge lists its strategies in order of preference, so when the cluster's
+// signing key is not available to the Concierge, the impersonation proxy is used instead.
+//
+// Reading CredentialIssuers usually requires the permissions of a cluster administrator, so this is meant to be
+// used once while configuring a client. The returned Endpoint can be used by clients with no other credentials.
+func Discover(ctx context.Context, config *rest.Config, opts ...DiscoveryOption) (*Endpoint, error) {
+	d := discovery{apiGroupSuffix: groupsuffix.PinnipedDefaultSuffix}
+	for _, opt := range opts {
+		opt(&d)
+	}
+	if err := groupsuffix.Validate(d.apiGroupSuffix); err != nil {
+		return nil, fmt.Errorf("invalid API group suffix: %w", err)
+	}
+
+	client, err := kubeclient.New(
+		kubeclient.WithConfig(config),
+		kubeclient.WithMiddleware(groupsuffix.New(d.apiGroupSuffix)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	caBundle := config.CAData
+	if len(caBundle) == 0 && config.CAFile != "" {
+		if caBundle, err = os.ReadFile(config.CAFile); err != nil {
+			return nil, fmt.Errorf("could not read the cluster's CA bundle: %w", err)
+		}
+	}
+
+	return discover(ctx, client.PinnipedConcierge, config.Host, caBundle, d)
+}
+
+func discover(ctx context.Context, clientset conciergeclientset.Interface, clusterServer string, clusterCABundle []byte, d discovery) (*Endpoint, error) {
+	credentialIssuer, err := getCredentialIssuer(ctx, clientset, d.credentialIssuer)
+	if err != nil {
+		return nil, err
+	}
+	return EndpointForCredentialIssuer(credentialIssuer, d.frontend, clusterServer, clusterCABundle)
+}
+
+func getCredentialIssuer(ctx context.Context, clientset conciergeclientset.Interface, name string) (*conciergeconfigv1alpha1.CredentialIssuer, error) {
+	if name != "" {
+		credentialIssuer, err := clientset.ConfigV1alpha1().CredentialIssuers().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("could not get CredentialIssuer %q: %w", name, err)
+		}
+		return credentialIssuer, nil
+	}
+
+	credentialIssuers, err := clientset.ConfigV1alpha1().CredentialIssuers().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("could not list CredentialIssuers: %w", err)
+	}
+	switch len(credentialIssuers.Items) {
+	case 0:
+		return nil, fmt.Errorf("no CredentialIssuers were found")
+	case 1:
+		return &credentialIssuers.Items[0], nil
+	default:
+		return nil, fmt.Errorf("multiple CredentialIssuers were found, so WithCredentialIssuer must be specified")
+	}
+}
+
+// EndpointForCredentialIssuer returns the endpoint of the first healthy strategy of the CredentialIssuer which has
+// the given frontend, or of any frontend when it is empty. The TokenCredentialRequest API frontend is served by the
+// cluster itself, so its endpoint is the given cluster server and CA bundle.
+func EndpointForCredentialIssuer(
+	credentialIssuer *conciergeconfigv1alpha1.CredentialIssuer,
+	frontendType conciergeconfigv1alpha1.FrontendType,
+	clusterServer string,
+	clusterCABundle []byte,
+) (*Endpoint, error) {
+	for _, strategy := range credentialIssuer.Status.Strategies {
+		if strategy.Status != conciergeconfigv1alpha1.SuccessStrategyStatus {
+			continue
+		}
+
+		frontend := strategy.Frontend
+		// Older Concierges only describe the TokenCredentialRequest API in .status.kubeConfigInfo.
+		if frontend == nil && strategy.Type == conciergeconfigv1alpha1.KubeClusterSigningCertificateStrategyType && credentialIssuer.Status.KubeConfigInfo != nil {
+			frontend = &conciergeconfigv1alpha1.CredentialIssuerFrontend{Type: conciergeconfigv1alpha1.TokenCredentialRequestAPIFrontendType}
+		}
+		if frontend == nil || (frontendType != "" && frontend.Type != frontendType) {
+			continue
+		}
+
+		switch frontend.Type {
+		case conciergeconfigv1alpha1.TokenCredentialRequestAPIFrontendType:
+			return &Endpoint{Frontend: frontend.Type, Server: clusterServer, CABundle: clusterCABundle}, nil
+		case conciergeconfigv1alpha1.ImpersonationProxyFrontendType:
+			if frontend.ImpersonationProxyInfo == nil {
+				continue
+			}
+			caBundle, err := base64.StdEncoding.DecodeString(frontend.ImpersonationProxyInfo.CertificateAuthorityData)
+			if err != nil {
+				return nil, fmt.Errorf("CredentialIssuer %q has an invalid impersonation proxy CA bundle: %w", credentialIssuer.Name, err)
+			}
+			return &Endpoint{Frontend: frontend.Type, Server: frontend.ImpersonationProxyInfo.Endpoint, CABundle: caBundle}, nil
+		}
+	}
+
+	if frontendType != "" {
+		return nil, fmt.Errorf("CredentialIssuer %q has no successful strategy with frontend %s", credentialIssuer.Name, frontendType)
+	}
+	return nil, fmt.Errorf("CredentialIssuer %q has no successful strategy", credentialIssuer.Name)
+}
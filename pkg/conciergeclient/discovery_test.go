@@ -0,0 +1,227 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package conciergeclient
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+
+	conciergeconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/config/v1alpha1"
+	conciergefake "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned/fake"
+)
+
+func TestEndpointForCredentialIssuer(t *testing.T) {
+	const (
+		clusterServer = "https://cluster.example.com"
+		proxyServer   = "https://proxy.example.com"
+	)
+	clusterCABundle := []byte("cluster-ca-bundle")
+
+	tcrStrategy := func(status conciergeconfigv1alpha1.StrategyStatus) conciergeconfigv1alpha1.CredentialIssuerStrategy {
+		return conciergeconfigv1alpha1.CredentialIssuerStrategy{
+			Type:   conciergeconfigv1alpha1.KubeClusterSigningCertificateStrategyType,
+			Status: status,
+			Frontend: &conciergeconfigv1alpha1.CredentialIssuerFrontend{
+				Type: conciergeconfigv1alpha1.TokenCredentialRequestAPIFrontendType,
+			},
+		}
+	}
+	proxyStrategy := func(status conciergeconfigv1alpha1.StrategyStatus, caBundle string) conciergeconfigv1alpha1.CredentialIssuerStrategy {
+		return conciergeconfigv1alpha1.CredentialIssuerStrategy{
+			Type:   conciergeconfigv1alpha1.ImpersonationProxyStrategyType,
+			Status: status,
+			Frontend: &conciergeconfigv1alpha1.CredentialIssuerFrontend{
+				Type: conciergeconfigv1alpha1.ImpersonationProxyFrontendType,
+				ImpersonationProxyInfo: &conciergeconfigv1alpha1.ImpersonationProxyInfo{
+					Endpoint:                 proxyServer,
+					CertificateAuthorityData: caBundle,
+				},
+			},
+		}
+	}
+	proxyCABundle := base64.StdEncoding.EncodeToString([]byte("proxy-ca-bundle"))
+
+	tests := []struct {
+		name     string
+		status   conciergeconfigv1alpha1.CredentialIssuerStatus
+		frontend conciergeconfigv1alpha1.FrontendType
+		want     *Endpoint
+		wantErr  string
+	}{
+		{
+			name: "TokenCredentialRequest API is preferred",
+			status: conciergeconfigv1alpha1.CredentialIssuerStatus{Strategies: []conciergeconfigv1alpha1.CredentialIssuerStrategy{
+				tcrStrategy(conciergeconfigv1alpha1.SuccessStrategyStatus),
+				proxyStrategy(conciergeconfigv1alpha1.SuccessStrategyStatus, proxyCABundle),
+			}},
+			want: &Endpoint{
+				Frontend: conciergeconfigv1alpha1.TokenCredentialRequestAPIFrontendType,
+				Server:   clusterServer,
+				CABundle: clusterCABundle,
+			},
+		},
+		{
+			name: "falls back to the impersonation proxy",
+			status: conciergeconfigv1alpha1.CredentialIssuerStatus{Strategies: []conciergeconfigv1alpha1.CredentialIssuerStrategy{
+				tcrStrategy(conciergeconfigv1alpha1.ErrorStrategyStatus),
+				proxyStrategy(conciergeconfigv1alpha1.SuccessStrategyStatus, proxyCABundle),
+			}},
+			want: &Endpoint{
+				Frontend: conciergeconfigv1alpha1.ImpersonationProxyFrontendType,
+				Server:   proxyServer,
+				CABundle: []byte("proxy-ca-bundle"),
+			},
+		},
+		{
+			name: "frontend is specified",
+			status: conciergeconfigv1alpha1.CredentialIssuerStatus{Strategies: []conciergeconfigv1alpha1.CredentialIssuerStrategy{
+				tcrStrategy(conciergeconfigv1alpha1.SuccessStrategyStatus),
+				proxyStrategy(conciergeconfigv1alpha1.SuccessStrategyStatus, proxyCABundle),
+			}},
+			frontend: conciergeconfigv1alpha1.ImpersonationProxyFrontendType,
+			want: &Endpoint{
+				Frontend: conciergeconfigv1alpha1.ImpersonationProxyFrontendType,
+				Server:   proxyServer,
+				CABundle: []byte("proxy-ca-bundle"),
+			},
+		},
+		{
+			name: "older Concierge without frontends",
+			status: conciergeconfigv1alpha1.CredentialIssuerStatus{
+				Strategies: []conciergeconfigv1alpha1.CredentialIssuerStrategy{{
+					Type:   conciergeconfigv1alpha1.KubeClusterSigningCertificateStrategyType,
+					Status: conciergeconfigv1alpha1.SuccessStrategyStatus,
+				}},
+				KubeConfigInfo: &conciergeconfigv1alpha1.CredentialIssuerKubeConfigInfo{Server: clusterServer},
+			},
+			want: &Endpoint{
+				Frontend: conciergeconfigv1alpha1.TokenCredentialRequestAPIFrontendType,
+				Server:   clusterServer,
+				CABundle: clusterCABundle,
+			},
+		},
+		{
+			name: "invalid impersonation proxy CA bundle",
+			status: conciergeconfigv1alpha1.CredentialIssuerStatus{Strategies: []conciergeconfigv1alpha1.CredentialIssuerStrategy{
+				proxyStrategy(conciergeconfigv1alpha1.SuccessStrategyStatus, "invalid-base64"),
+			}},
+			wantErr: `CredentialIssuer "test-credential-issuer" has an invalid impersonation proxy CA bundle: illegal base64 data at input byte 7`,
+		},
+		{
+			name: "no successful strategy",
+			status: conciergeconfigv1alpha1.CredentialIssuerStatus{Strategies: []conciergeconfigv1alpha1.CredentialIssuerStrategy{
+				tcrStrategy(conciergeconfigv1alpha1.ErrorStrategyStatus),
+				proxyStrategy(conciergeconfigv1alpha1.ErrorStrategyStatus, proxyCABundle),
+			}},
+			wantErr: `CredentialIssuer "test-credential-issuer" has no successful strategy`,
+		},
+		{
+			name: "no successful strategy with the specified frontend",
+			status: conciergeconfigv1alpha1.CredentialIssuerStatus{Strategies: []conciergeconfigv1alpha1.CredentialIssuerStrategy{
+				tcrStrategy(conciergeconfigv1alpha1.SuccessStrategyStatus),
+			}},
+			frontend: conciergeconfigv1alpha1.ImpersonationProxyFrontendType,
+			wantErr:  `CredentialIssuer "test-credential-issuer" has no successful strategy with frontend ImpersonationProxy`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credentialIssuer := &conciergeconfigv1alpha1.CredentialIssuer{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+				Status:     tt.status,
+			}
+			got, err := EndpointForCredentialIssuer(credentialIssuer, tt.frontend, clusterServer, clusterCABundle)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	credentialIssuer := func(name string) runtime.Object {
+		return &conciergeconfigv1alpha1.CredentialIssuer{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: conciergeconfigv1alpha1.CredentialIssuerStatus{Strategies: []conciergeconfigv1alpha1.CredentialIssuerStrategy{{
+				Type:     conciergeconfigv1alpha1.KubeClusterSigningCertificateStrategyType,
+				Status:   conciergeconfigv1alpha1.SuccessStrategyStatus,
+				Frontend: &conciergeconfigv1alpha1.CredentialIssuerFrontend{Type: conciergeconfigv1alpha1.TokenCredentialRequestAPIFrontendType},
+			}}},
+		}
+	}
+	wantEndpoint := &Endpoint{
+		Frontend: conciergeconfigv1alpha1.TokenCredentialRequestAPIFrontendType,
+		Server:   "https://cluster.example.com",
+	}
+
+	tests := []struct {
+		name    string
+		objects []runtime.Object
+		opts    []DiscoveryOption
+		want    *Endpoint
+		wantErr string
+	}{
+		{
+			name:    "the only CredentialIssuer",
+			objects: []runtime.Object{credentialIssuer("some-credential-issuer")},
+			want:    wantEndpoint,
+		},
+		{
+			name:    "no CredentialIssuers",
+			wantErr: "no CredentialIssuers were found",
+		},
+		{
+			name:    "multiple CredentialIssuers",
+			objects: []runtime.Object{credentialIssuer("some-credential-issuer"), credentialIssuer("other-credential-issuer")},
+			wantErr: "multiple CredentialIssuers were found, so WithCredentialIssuer must be specified",
+		},
+		{
+			name:    "named CredentialIssuer",
+			objects: []runtime.Object{credentialIssuer("some-credential-issuer"), credentialIssuer("other-credential-issuer")},
+			opts:    []DiscoveryOption{WithCredentialIssuer("other-credential-issuer")},
+			want:    wantEndpoint,
+		},
+		{
+			name:    "named CredentialIssuer does not exist",
+			objects: []runtime.Object{credentialIssuer("some-credential-issuer")},
+			opts:    []DiscoveryOption{WithCredentialIssuer("other-credential-issuer")},
+			wantErr: `could not get CredentialIssuer "other-credential-issuer": credentialissuers.config.concierge.pinniped.dev "other-credential-issuer" not found`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := discovery{}
+			for _, opt := range tt.opts {
+				opt(&d)
+			}
+			got, err := discover(context.Background(), conciergefake.NewSimpleClientset(tt.objects...), "https://cluster.example.com", nil, d)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDiscoverInvalidAPIGroupSuffix(t *testing.T) {
+	_, err := Discover(context.Background(), &rest.Config{Host: "https://cluster.example.com"}, WithDiscoveryAPIGroupSuffix("invalid"))
+	require.EqualError(t, err, "invalid API group suffix: must contain '.'")
+}
+
+func TestEndpointOptions(t *testing.T) {
+	client, err := New(append((&Endpoint{Server: "https://cluster.example.com"}).Options(), WithAuthenticator("jwt", "some-authenticator"))...)
+	require.NoError(t, err)
+	require.Equal(t, "https://cluster.example.com", client.endpoint.String())
+}
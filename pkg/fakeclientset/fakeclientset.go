@@ -0,0 +1,217 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package fakeclientset helps to write tests for controllers which use the generated Concierge and Supervisor
+// clientsets. It adds reactors to the generated fake clientsets which simulate behaviors of a real Kubernetes API
+// server that the fakes do not have on their own, like conflicts, slow watches and status subresources.
+//
+// For example:
+//
+//	client := supervisorfake.NewSimpleClientset(federationDomain)
+//	fakeclientset.StatusSubresource(client, "federationdomains")
+//	fakeclientset.ConflictOnUpdate(client, "federationdomains", 1)
+package fakeclientset
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	kubetesting "k8s.io/client-go/testing"
+)
+
+// Fake is implemented by the generated fake clientsets, e.g. the Clientset of
+// go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/fake.
+type Fake interface {
+	PrependReactor(verb, resource string, reaction kubetesting.ReactionFunc)
+	PrependWatchReactor(resource string, reaction kubetesting.WatchReactionFunc)
+	Tracker() kubetesting.ObjectTracker
+}
+
+// ConflictOnUpdate makes the first times updates of the resource fail with a conflict error, like the errors
+// which a real API server returns when the resourceVersion of the update is stale. Updates of subresources,
+// like the status, also count. Later updates are handled by the other reactors of the fake.
+func ConflictOnUpdate(fake Fake, resource string, times int) {
+	var lock sync.Mutex
+	remaining := times
+
+	fake.PrependReactor("update", resource, func(action kubetesting.Action) (bool, runtime.Object, error) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if remaining <= 0 {
+			return false, nil, nil
+		}
+		remaining--
+
+		objMeta, err := meta.Accessor(action.(kubetesting.UpdateAction).GetObject())
+		if err != nil {
+			return true, nil, err
+		}
+		return true, nil, apierrors.NewConflict(action.GetResource().GroupResource(), objMeta.GetName(),
+			errors.New("the object has been modified; please apply your changes to the latest version and try again"))
+	})
+}
+
+// SlowWatch delays each event of the watches of the resource, to simulate a slow API server or network.
+func SlowWatch(fake Fake, resource string, delay time.Duration) {
+	fake.PrependWatchReactor(resource, func(action kubetesting.Action) (bool, watch.Interface, error) {
+		source, err := fake.Tracker().Watch(action.GetResource(), action.GetNamespace())
+		if err != nil {
+			return true, nil, err
+		}
+		return true, newDelayedWatch(source, delay), nil
+	})
+}
+
+// StatusSubresource makes updates of the resources behave as if they had a status subresource, like all
+// Pinniped custom resources with a status. Updates of the resource ignore changes to the status and increment the
+// metadata.generation when the spec changes, while updates of the status subresource ignore all other changes.
+// Without this, the fakes store the whole object for both kinds of updates.
+func StatusSubresource(fake Fake, resources ...string) {
+	for _, resource := range resources {
+		fake.PrependReactor("update", resource, func(action kubetesting.Action) (bool, runtime.Object, error) {
+			updateAction := action.(kubetesting.UpdateAction)
+			switch updateAction.GetSubresource() {
+			case "", "status":
+			default:
+				return false, nil, nil
+			}
+
+			gvr, ns := action.GetResource(), action.GetNamespace()
+			updated := updateAction.GetObject().DeepCopyObject()
+			updatedMeta, err := meta.Accessor(updated)
+			if err != nil {
+				return true, nil, err
+			}
+
+			existing, err := fake.Tracker().Get(gvr, ns, updatedMeta.GetName())
+			if err != nil {
+				return true, nil, err
+			}
+
+			merged, err := mergeForSubresource(existing, updated, updateAction.GetSubresource())
+			if err != nil {
+				return true, nil, err
+			}
+
+			if err := fake.Tracker().Update(gvr, merged, ns); err != nil {
+				return true, nil, err
+			}
+			stored, err := fake.Tracker().Get(gvr, ns, updatedMeta.GetName())
+			return true, stored, err
+		})
+	}
+}
+
+// mergeForSubresource returns the updated object, but with the fields which the subresource may not change
+// taken from the existing object.
+func mergeForSubresource(existing, updated runtime.Object, subresource string) (runtime.Object, error) {
+	existingFields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(existing)
+	if err != nil {
+		return nil, err
+	}
+	updatedFields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(updated)
+	if err != nil {
+		return nil, err
+	}
+
+	var mergedFields map[string]any
+	if subresource == "status" {
+		// Only the status may change.
+		mergedFields = existingFields
+		mergedFields["status"] = updatedFields["status"]
+		if metadata, ok := mergedFields["metadata"].(map[string]any); ok {
+			if updatedMetadata, ok := updatedFields["metadata"].(map[string]any); ok && updatedMetadata["resourceVersion"] != nil {
+				metadata["resourceVersion"] = updatedMetadata["resourceVersion"]
+			}
+		}
+	} else {
+		// Everything except the status may change.
+		mergedFields = updatedFields
+		mergedFields["status"] = existingFields["status"]
+	}
+	for key, value := range mergedFields {
+		if value == nil {
+			delete(mergedFields, key)
+		}
+	}
+
+	merged := reflect.New(reflect.TypeOf(updated).Elem()).Interface().(runtime.Object)
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(mergedFields, merged); err != nil {
+		return nil, err
+	}
+
+	if subresource == "" {
+		// Only the API server sets the generation.
+		existingMeta, err := meta.Accessor(existing)
+		if err != nil {
+			return nil, err
+		}
+		mergedMeta, err := meta.Accessor(merged)
+		if err != nil {
+			return nil, err
+		}
+		generation := existingMeta.GetGeneration()
+		if !equality.Semantic.DeepEqual(existingFields["spec"], updatedFields["spec"]) {
+			generation++
+		}
+		mergedMeta.SetGeneration(generation)
+	}
+
+	return merged, nil
+}
+
+// delayedWatch forwards the events of another watch after a delay.
+type delayedWatch struct {
+	source   watch.Interface
+	result   chan watch.Event
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+var _ watch.Interface = (*delayedWatch)(nil)
+
+func newDelayedWatch(source watch.Interface, delay time.Duration) *delayedWatch {
+	w := &delayedWatch{
+		source: source,
+		result: make(chan watch.Event),
+		stop:   make(chan struct{}),
+	}
+	go w.run(delay)
+	return w
+}
+
+func (w *delayedWatch) run(delay time.Duration) {
+	defer close(w.result)
+
+	for event := range w.source.ResultChan() {
+		select {
+		case <-time.After(delay):
+		case <-w.stop:
+			return
+		}
+		select {
+		case w.result <- event:
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+func (w *delayedWatch) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+		w.source.Stop()
+	})
+}
+
+func (w *delayedWatch) ResultChan() <-chan watch.Event {
+	return w.result
+}
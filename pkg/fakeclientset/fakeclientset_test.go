@@ -0,0 +1,107 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package fakeclientset
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+
+	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
+	supervisorfake "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/fake"
+)
+
+func newFederationDomain() *supervisorconfigv1alpha1.FederationDomain {
+	return &supervisorconfigv1alpha1.FederationDomain{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "some-namespace", Name: "some-name", Generation: 1},
+		Spec:       supervisorconfigv1alpha1.FederationDomainSpec{Issuer: "https://issuer.example.com"},
+		Status:     supervisorconfigv1alpha1.FederationDomainStatus{Phase: supervisorconfigv1alpha1.FederationDomainPhaseReady},
+	}
+}
+
+func TestConflictOnUpdate(t *testing.T) {
+	ctx := context.Background()
+	client := supervisorfake.NewSimpleClientset(newFederationDomain())
+	ConflictOnUpdate(client, "federationdomains", 2)
+	federationDomains := client.ConfigV1alpha1().FederationDomains("some-namespace")
+
+	_, err := federationDomains.Update(ctx, newFederationDomain(), metav1.UpdateOptions{})
+	require.True(t, apierrors.IsConflict(err), err)
+	_, err = federationDomains.UpdateStatus(ctx, newFederationDomain(), metav1.UpdateOptions{})
+	require.True(t, apierrors.IsConflict(err), err)
+	_, err = federationDomains.Update(ctx, newFederationDomain(), metav1.UpdateOptions{})
+	require.NoError(t, err)
+}
+
+func TestSlowWatch(t *testing.T) {
+	ctx := context.Background()
+	client := supervisorfake.NewSimpleClientset()
+	delay := 100 * time.Millisecond
+	SlowWatch(client, "federationdomains", delay)
+	federationDomains := client.ConfigV1alpha1().FederationDomains("some-namespace")
+
+	w, err := federationDomains.Watch(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	t.Cleanup(w.Stop)
+
+	start := time.Now()
+	_, err = federationDomains.Create(ctx, newFederationDomain(), metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	select {
+	case event := <-w.ResultChan():
+		require.Equal(t, watch.Added, event.Type)
+		require.GreaterOrEqual(t, time.Since(start), delay)
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "timed out waiting for the watch event")
+	}
+
+	w.Stop()
+	_, ok := <-w.ResultChan()
+	require.False(t, ok)
+}
+
+func TestStatusSubresource(t *testing.T) {
+	ctx := context.Background()
+	client := supervisorfake.NewSimpleClientset(newFederationDomain())
+	StatusSubresource(client, "federationdomains")
+	federationDomains := client.ConfigV1alpha1().FederationDomains("some-namespace")
+
+	// Updating the status does not change the spec.
+	updated := newFederationDomain()
+	updated.Spec.Issuer = "https://other-issuer.example.com"
+	updated.Status.Phase = supervisorconfigv1alpha1.FederationDomainPhaseError
+	stored, err := federationDomains.UpdateStatus(ctx, updated, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "https://issuer.example.com", stored.Spec.Issuer)
+	require.Equal(t, supervisorconfigv1alpha1.FederationDomainPhaseError, stored.Status.Phase)
+	require.Equal(t, int64(1), stored.Generation)
+
+	// Updating the object without changing the spec does not change the generation.
+	updated = stored.DeepCopy()
+	updated.Labels = map[string]string{"some-label": "some-value"}
+	stored, err = federationDomains.Update(ctx, updated, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "some-value", stored.Labels["some-label"])
+	require.Equal(t, int64(1), stored.Generation)
+
+	// Updating the spec does not change the status, and increments the generation.
+	updated = stored.DeepCopy()
+	updated.Spec.Issuer = "https://other-issuer.example.com"
+	updated.Status.Phase = supervisorconfigv1alpha1.FederationDomainPhaseReady
+	stored, err = federationDomains.Update(ctx, updated, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "https://other-issuer.example.com", stored.Spec.Issuer)
+	require.Equal(t, supervisorconfigv1alpha1.FederationDomainPhaseError, stored.Status.Phase)
+	require.Equal(t, int64(2), stored.Generation)
+
+	fetched, err := federationDomains.Get(ctx, "some-name", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, stored, fetched)
+}
@@ -0,0 +1,225 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package loginrequest provides a non-interactive login helper for API clients which are not kubectl, such as
+// dashboards and continuous delivery systems, which need credentials for a cluster without configuring a
+// client-go credential plugin.
+package loginrequest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	clientauthenticationv1beta1 "k8s.io/client-go/pkg/apis/clientauthentication/v1beta1"
+	"k8s.io/client-go/rest"
+
+	idpdiscoveryv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idpdiscovery/v1alpha1"
+	"go.pinniped.dev/pkg/conciergeclient"
+	"go.pinniped.dev/pkg/oidcclient"
+	"go.pinniped.dev/pkg/oidcclient/oidctypes"
+)
+
+// Option is an optional configuration for RESTConfig().
+type Option func(*request) error
+
+type request struct {
+	ctx context.Context
+
+	// Parameters for logging in to a Supervisor.
+	issuer                       string
+	clientID                     string
+	username                     string
+	password                     string
+	upstreamIdentityProviderName string
+	upstreamIdentityProviderType string
+	requestedAudience            string
+	httpClient                   *http.Client
+
+	// Parameters for using a workload identity token instead of logging in to a Supervisor.
+	workloadIdentityTokenFile string
+
+	// Optional Concierge to exchange the token for a cluster credential.
+	concierge *conciergeclient.Client
+
+	// External calls for things.
+	login         func(issuer string, clientID string, opts ...oidcclient.Option) (*oidctypes.Token, error)
+	readFile      func(name string) ([]byte, error)
+	exchangeToken func(ctx context.Context, client *conciergeclient.Client, token string) (*clientauthenticationv1beta1.ExecCredential, error)
+}
+
+// WithContext specifies a specific context.Context under which to perform the login. If this option is not specified,
+// login happens under context.Background().
+func WithContext(ctx context.Context) Option {
+	return func(r *request) error {
+		r.ctx = ctx
+		return nil
+	}
+}
+
+// WithClientCredentials configures a login to the Supervisor at the specified issuer using the specified OAuth client ID
+// (typically "pinniped-cli") and the username and password of a service account. The credentials are sent directly to the
+// Supervisor's authorize endpoint using the CLI-based password flow, so the upstream identity provider must support that
+// flow. Currently, this is supported by LDAPIdentityProviders, ActiveDirectoryIdentityProviders, and by
+// OIDCIdentityProviders which optionally enable the resource owner password credentials grant flow.
+func WithClientCredentials(issuer, clientID, username, password string) Option {
+	return func(r *request) error {
+		if issuer == "" || clientID == "" {
+			return fmt.Errorf("WithClientCredentials error: issuer and client ID must not be empty")
+		}
+		if username == "" || password == "" {
+			return fmt.Errorf("WithClientCredentials error: username and password must not be empty")
+		}
+		r.issuer = issuer
+		r.clientID = clientID
+		r.username = username
+		r.password = password
+		return nil
+	}
+}
+
+// WithWorkloadIdentityTokenFile configures the login to use a JWT which was issued to the workload by its platform,
+// such as a projected Kubernetes service account token, instead of logging in to a Supervisor. The file is read each
+// time that RESTConfig() is called, so a token which is rotated on disk by the platform will always be current.
+// This token is typically exchanged for a cluster credential by a Concierge JWTAuthenticator which trusts the
+// workload's token issuer. See the WithConcierge() option.
+func WithWorkloadIdentityTokenFile(path string) Option {
+	return func(r *request) error {
+		if path == "" {
+			return fmt.Errorf("WithWorkloadIdentityTokenFile error: path must not be empty")
+		}
+		r.workloadIdentityTokenFile = path
+		return nil
+	}
+}
+
+// WithUpstreamIdentityProvider chooses which of the Supervisor's upstream identity providers to use during a login.
+// See oidcclient.WithUpstreamIdentityProvider().
+func WithUpstreamIdentityProvider(upstreamName, upstreamType string) Option {
+	return func(r *request) error {
+		r.upstreamIdentityProviderName = upstreamName
+		r.upstreamIdentityProviderType = upstreamType
+		return nil
+	}
+}
+
+// WithRequestAudience causes the login to perform an additional RFC8693 token exchange with the Supervisor to get
+// a token which has the specified cluster audience.
+func WithRequestAudience(audience string) Option {
+	return func(r *request) error {
+		r.requestedAudience = audience
+		return nil
+	}
+}
+
+// WithClient sets the HTTP client used to make requests to the Supervisor.
+func WithClient(httpClient *http.Client) Option {
+	return func(r *request) error {
+		r.httpClient = httpClient
+		return nil
+	}
+}
+
+// WithConcierge causes the token to be exchanged for a cluster credential using a TokenCredentialRequest
+// against the specified Concierge. When this option is not used, the token is used directly as a bearer token.
+func WithConcierge(concierge *conciergeclient.Client) Option {
+	return func(r *request) error {
+		r.concierge = concierge
+		return nil
+	}
+}
+
+// RESTConfig performs a non-interactive login and returns a *rest.Config which has the credentials for the cluster
+// described by the specified *rest.Config. Only the host and TLS settings of the specified config are used. Any
+// credentials or credential plugins configured in it are ignored.
+//
+// Exactly one of WithClientCredentials() or WithWorkloadIdentityTokenFile() must be specified. The returned
+// credentials are not refreshed, so callers should call RESTConfig() again to get new credentials before
+// they expire.
+func RESTConfig(cluster *rest.Config, opts ...Option) (*rest.Config, error) {
+	r := request{
+		ctx: context.Background(),
+
+		// Default implementations of external dependencies (to be mocked in tests).
+		login:    oidcclient.Login,
+		readFile: os.ReadFile,
+		exchangeToken: func(ctx context.Context, client *conciergeclient.Client, token string) (*clientauthenticationv1beta1.ExecCredential, error) {
+			return client.ExchangeToken(ctx, token)
+		},
+	}
+	for _, opt := range opts {
+		if err := opt(&r); err != nil {
+			return nil, err
+		}
+	}
+
+	if cluster == nil || cluster.Host == "" {
+		return nil, fmt.Errorf("cluster config must specify a host")
+	}
+	if (r.issuer == "") == (r.workloadIdentityTokenFile == "") {
+		return nil, fmt.Errorf("exactly one of WithClientCredentials or WithWorkloadIdentityTokenFile must be specified")
+	}
+
+	token, err := r.getToken()
+	if err != nil {
+		return nil, err
+	}
+
+	config := rest.AnonymousClientConfig(cluster)
+
+	if r.concierge == nil {
+		config.BearerToken = token
+		return config, nil
+	}
+
+	cred, err := r.exchangeToken(r.ctx, r.concierge, token)
+	if err != nil {
+		return nil, fmt.Errorf("could not complete Concierge credential exchange: %w", err)
+	}
+	config.BearerToken = cred.Status.Token
+	config.CertData = []byte(cred.Status.ClientCertificateData)
+	config.KeyData = []byte(cred.Status.ClientKeyData)
+	return config, nil
+}
+
+func (r *request) getToken() (string, error) {
+	if r.workloadIdentityTokenFile != "" {
+		tokenBytes, err := r.readFile(r.workloadIdentityTokenFile)
+		if err != nil {
+			return "", fmt.Errorf("could not read workload identity token file: %w", err)
+		}
+		token := strings.TrimSpace(string(tokenBytes))
+		if token == "" {
+			return "", fmt.Errorf("workload identity token file %q is empty", r.workloadIdentityTokenFile)
+		}
+		return token, nil
+	}
+
+	loginOpts := []oidcclient.Option{
+		oidcclient.WithContext(r.ctx),
+		oidcclient.WithLoginFlow(idpdiscoveryv1alpha1.IDPFlowCLIPassword, "loginrequest"),
+		oidcclient.WithUsernameAndPassword(r.username, r.password),
+		oidcclient.WithSkipBrowserOpen(),
+		oidcclient.WithSkipListen(),
+	}
+	if r.upstreamIdentityProviderName != "" {
+		loginOpts = append(loginOpts, oidcclient.WithUpstreamIdentityProvider(r.upstreamIdentityProviderName, r.upstreamIdentityProviderType))
+	}
+	if r.requestedAudience != "" {
+		loginOpts = append(loginOpts, oidcclient.WithRequestAudience(r.requestedAudience))
+	}
+	if r.httpClient != nil {
+		loginOpts = append(loginOpts, oidcclient.WithClient(r.httpClient))
+	}
+
+	token, err := r.login(r.issuer, r.clientID, loginOpts...)
+	if err != nil {
+		return "", fmt.Errorf("could not complete Supervisor login: %w", err)
+	}
+	if token.IDToken == nil || token.IDToken.Token == "" {
+		return "", fmt.Errorf("could not complete Supervisor login: no ID token was returned")
+	}
+	return token.IDToken.Token, nil
+}
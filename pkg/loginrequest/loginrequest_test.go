@@ -0,0 +1,259 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package loginrequest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	clientauthenticationv1beta1 "k8s.io/client-go/pkg/apis/clientauthentication/v1beta1"
+	"k8s.io/client-go/rest"
+
+	"go.pinniped.dev/pkg/conciergeclient"
+	"go.pinniped.dev/pkg/oidcclient"
+	"go.pinniped.dev/pkg/oidcclient/oidctypes"
+)
+
+func TestRESTConfig(t *testing.T) {
+	cluster := &rest.Config{
+		Host:            "https://cluster.example.com",
+		TLSClientConfig: rest.TLSClientConfig{CAData: []byte("some-ca-data")},
+		BearerToken:     "should-be-ignored",
+	}
+
+	concierge, err := conciergeclient.New(
+		conciergeclient.WithEndpoint("https://cluster.example.com"),
+		conciergeclient.WithAuthenticator("jwt", "some-authenticator"),
+	)
+	require.NoError(t, err)
+
+	successfulLogin := func(t *testing.T, wantOptionCount int) func(string, string, ...oidcclient.Option) (*oidctypes.Token, error) {
+		return func(issuer string, clientID string, opts ...oidcclient.Option) (*oidctypes.Token, error) {
+			require.Equal(t, "https://supervisor.example.com/issuer", issuer)
+			require.Equal(t, "pinniped-cli", clientID)
+			require.Len(t, opts, wantOptionCount)
+			return &oidctypes.Token{IDToken: &oidctypes.IDToken{Token: "some-id-token"}}, nil
+		}
+	}
+
+	successfulExchange := func(t *testing.T, wantToken string) func(context.Context, *conciergeclient.Client, string) (*clientauthenticationv1beta1.ExecCredential, error) {
+		return func(_ context.Context, client *conciergeclient.Client, token string) (*clientauthenticationv1beta1.ExecCredential, error) {
+			require.Equal(t, concierge, client)
+			require.Equal(t, wantToken, token)
+			return &clientauthenticationv1beta1.ExecCredential{
+				Status: &clientauthenticationv1beta1.ExecCredentialStatus{
+					ClientCertificateData: "some-cert-data",
+					ClientKeyData:         "some-key-data",
+				},
+			}, nil
+		}
+	}
+
+	tests := []struct {
+		name       string
+		cluster    *rest.Config
+		opts       func(t *testing.T) []Option
+		wantConfig *rest.Config
+		wantErr    string
+	}{
+		{
+			name:    "option error",
+			cluster: cluster,
+			opts: func(t *testing.T) []Option {
+				return []Option{WithClientCredentials("https://supervisor.example.com/issuer", "pinniped-cli", "some-username", "")}
+			},
+			wantErr: "WithClientCredentials error: username and password must not be empty",
+		},
+		{
+			name:    "missing cluster host",
+			cluster: &rest.Config{},
+			opts: func(t *testing.T) []Option {
+				return []Option{WithWorkloadIdentityTokenFile("some-file")}
+			},
+			wantErr: "cluster config must specify a host",
+		},
+		{
+			name:    "no credentials",
+			cluster: cluster,
+			opts: func(t *testing.T) []Option {
+				return nil
+			},
+			wantErr: "exactly one of WithClientCredentials or WithWorkloadIdentityTokenFile must be specified",
+		},
+		{
+			name:    "too many credentials",
+			cluster: cluster,
+			opts: func(t *testing.T) []Option {
+				return []Option{
+					WithClientCredentials("https://supervisor.example.com/issuer", "pinniped-cli", "some-username", "some-password"),
+					WithWorkloadIdentityTokenFile("some-file"),
+				}
+			},
+			wantErr: "exactly one of WithClientCredentials or WithWorkloadIdentityTokenFile must be specified",
+		},
+		{
+			name:    "client credentials without Concierge",
+			cluster: cluster,
+			opts: func(t *testing.T) []Option {
+				return []Option{
+					WithClientCredentials("https://supervisor.example.com/issuer", "pinniped-cli", "some-username", "some-password"),
+					func(r *request) error {
+						r.login = successfulLogin(t, 5)
+						return nil
+					},
+				}
+			},
+			wantConfig: &rest.Config{
+				Host:            "https://cluster.example.com",
+				TLSClientConfig: rest.TLSClientConfig{CAData: []byte("some-ca-data")},
+				BearerToken:     "some-id-token",
+			},
+		},
+		{
+			name:    "client credentials with upstream identity provider, audience, HTTP client, and Concierge",
+			cluster: cluster,
+			opts: func(t *testing.T) []Option {
+				return []Option{
+					WithClientCredentials("https://supervisor.example.com/issuer", "pinniped-cli", "some-username", "some-password"),
+					WithUpstreamIdentityProvider("some-upstream-name", "ldap"),
+					WithRequestAudience("some-cluster-audience"),
+					WithClient(&http.Client{}),
+					WithConcierge(concierge),
+					func(r *request) error {
+						r.login = successfulLogin(t, 8)
+						r.exchangeToken = successfulExchange(t, "some-id-token")
+						return nil
+					},
+				}
+			},
+			wantConfig: &rest.Config{
+				Host: "https://cluster.example.com",
+				TLSClientConfig: rest.TLSClientConfig{
+					CAData:   []byte("some-ca-data"),
+					CertData: []byte("some-cert-data"),
+					KeyData:  []byte("some-key-data"),
+				},
+			},
+		},
+		{
+			name:    "client credentials login error",
+			cluster: cluster,
+			opts: func(t *testing.T) []Option {
+				return []Option{
+					WithClientCredentials("https://supervisor.example.com/issuer", "pinniped-cli", "some-username", "some-password"),
+					func(r *request) error {
+						r.login = func(_ string, _ string, _ ...oidcclient.Option) (*oidctypes.Token, error) {
+							return nil, errors.New("some login error")
+						}
+						return nil
+					},
+				}
+			},
+			wantErr: "could not complete Supervisor login: some login error",
+		},
+		{
+			name:    "client credentials login returns no ID token",
+			cluster: cluster,
+			opts: func(t *testing.T) []Option {
+				return []Option{
+					WithClientCredentials("https://supervisor.example.com/issuer", "pinniped-cli", "some-username", "some-password"),
+					func(r *request) error {
+						r.login = func(_ string, _ string, _ ...oidcclient.Option) (*oidctypes.Token, error) {
+							return &oidctypes.Token{AccessToken: &oidctypes.AccessToken{Token: "some-access-token"}}, nil
+						}
+						return nil
+					},
+				}
+			},
+			wantErr: "could not complete Supervisor login: no ID token was returned",
+		},
+		{
+			name:    "workload identity token with Concierge",
+			cluster: cluster,
+			opts: func(t *testing.T) []Option {
+				return []Option{
+					WithWorkloadIdentityTokenFile("/var/run/secrets/tokens/token"),
+					WithConcierge(concierge),
+					func(r *request) error {
+						r.readFile = func(name string) ([]byte, error) {
+							require.Equal(t, "/var/run/secrets/tokens/token", name)
+							return []byte("some-workload-token\n"), nil
+						}
+						r.exchangeToken = successfulExchange(t, "some-workload-token")
+						return nil
+					},
+				}
+			},
+			wantConfig: &rest.Config{
+				Host: "https://cluster.example.com",
+				TLSClientConfig: rest.TLSClientConfig{
+					CAData:   []byte("some-ca-data"),
+					CertData: []byte("some-cert-data"),
+					KeyData:  []byte("some-key-data"),
+				},
+			},
+		},
+		{
+			name:    "workload identity token file cannot be read",
+			cluster: cluster,
+			opts: func(t *testing.T) []Option {
+				return []Option{
+					WithWorkloadIdentityTokenFile("some-file"),
+					func(r *request) error {
+						r.readFile = func(_ string) ([]byte, error) { return nil, errors.New("some read error") }
+						return nil
+					},
+				}
+			},
+			wantErr: "could not read workload identity token file: some read error",
+		},
+		{
+			name:    "workload identity token file is empty",
+			cluster: cluster,
+			opts: func(t *testing.T) []Option {
+				return []Option{
+					WithWorkloadIdentityTokenFile("some-file"),
+					func(r *request) error {
+						r.readFile = func(_ string) ([]byte, error) { return []byte(" \n"), nil }
+						return nil
+					},
+				}
+			},
+			wantErr: `workload identity token file "some-file" is empty`,
+		},
+		{
+			name:    "Concierge exchange error",
+			cluster: cluster,
+			opts: func(t *testing.T) []Option {
+				return []Option{
+					WithWorkloadIdentityTokenFile("some-file"),
+					WithConcierge(concierge),
+					func(r *request) error {
+						r.readFile = func(_ string) ([]byte, error) { return []byte("some-workload-token"), nil }
+						r.exchangeToken = func(_ context.Context, _ *conciergeclient.Client, _ string) (*clientauthenticationv1beta1.ExecCredential, error) {
+							return nil, conciergeclient.ErrLoginFailed
+						}
+						return nil
+					},
+				}
+			},
+			wantErr: "could not complete Concierge credential exchange: login failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := RESTConfig(tt.cluster, tt.opts(t)...)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				require.Nil(t, config)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantConfig, config)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package oidcclient
+
+import (
+	"crypto/x509"
+	"fmt"
+
+	"go.pinniped.dev/internal/net/phttp"
+)
+
+// WithCABundles sets the certificate authorities which are trusted when connecting to the issuer. The PEM-encoded
+// bundles may come from different sources, e.g. files, flags, or the kubeconfig of the cluster, and are merged into
+// one pool, along with the system's roots when includeSystemRoots is true. Each bundle must contain at least one
+// certificate. This replaces the HTTP client which was set by WithClient, and vice versa, so only one of them
+// should be used.
+func WithCABundles(includeSystemRoots bool, pemBundles [][]byte) Option {
+	return func(h *handlerState) error {
+		pool := x509.NewCertPool()
+		if includeSystemRoots {
+			systemRoots, err := x509.SystemCertPool()
+			if err != nil {
+				return fmt.Errorf("WithCABundles error: could not load the system's roots: %w", err)
+			}
+			pool = systemRoots
+		}
+		for i, pemBundle := range pemBundles {
+			if !pool.AppendCertsFromPEM(pemBundle) {
+				return fmt.Errorf("WithCABundles error: could not load any certificates from CA bundle %d", i+1)
+			}
+		}
+		h.httpClient = phttp.Default(pool)
+		return nil
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package oidcclient
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go.pinniped.dev/internal/certauthority"
+	"go.pinniped.dev/internal/testutil/tlsserver"
+)
+
+func TestCABundlesOption(t *testing.T) {
+	server, serverCA := tlsserver.TestServerIPv4(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), nil)
+
+	otherCA, err := certauthority.New("Some Other CA", time.Hour)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		opt           Option
+		wantErr       string
+		wantReachable bool
+	}{
+		{
+			name:          "bundle of the issuer",
+			opt:           WithCABundles(false, [][]byte{serverCA}),
+			wantReachable: true,
+		},
+		{
+			name:          "bundles from several sources are merged",
+			opt:           WithCABundles(false, [][]byte{otherCA.Bundle(), serverCA}),
+			wantReachable: true,
+		},
+		{
+			name:          "bundle and system roots",
+			opt:           WithCABundles(true, [][]byte{serverCA}),
+			wantReachable: true,
+		},
+		{
+			name: "bundle of another CA",
+			opt:  WithCABundles(false, [][]byte{otherCA.Bundle()}),
+		},
+		{
+			name:    "bundle without certificates",
+			opt:     WithCABundles(false, [][]byte{serverCA, []byte("not PEM")}),
+			wantErr: "WithCABundles error: could not load any certificates from CA bundle 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &handlerState{}
+			err := tt.opt(h)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				require.Nil(t, h.httpClient)
+				return
+			}
+			require.NoError(t, err)
+
+			resp, err := h.httpClient.Get(server.URL)
+			if !tt.wantReachable {
+				require.ErrorContains(t, err, "certificate signed by unknown authority")
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+		})
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package oidcclient
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+)
+
+const (
+	clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+	// clientAssertionLifetime is how long each client assertion may be used. Each assertion is only used once,
+	// so it only needs to be valid for the duration of its request.
+	clientAssertionLifetime = time.Minute
+)
+
+// WithClientAssertionKey causes the login to authenticate the client to the token endpoint and the pushed
+// authorization request endpoint of the issuer with a client assertion which is signed by the key, using the
+// private_key_jwt client authentication method of OpenID Connect Core 1.0 section 9. This is meant for confidential
+// clients, e.g. automation clients which were configured with the public key of the key, in which case no client
+// secret needs to be distributed to the client. The key must be an ECDSA P-256 key, and the assertions are signed
+// with ES256. The "kid" header of each assertion is the RFC 7638 JWK thumbprint of the public key.
+func WithClientAssertionKey(key *ecdsa.PrivateKey) Option {
+	return func(h *handlerState) error {
+		if key == nil || key.Curve != elliptic.P256() {
+			return fmt.Errorf("WithClientAssertionKey error: key must be an ECDSA P-256 key")
+		}
+		h.clientAssertionKey = key
+		return nil
+	}
+}
+
+// WithClientAssertionKeyFile is like WithClientAssertionKey, with the PEM-encoded key read from the file at path.
+// Both the "EC PRIVATE KEY" and the PKCS #8 "PRIVATE KEY" PEM formats are supported. Such a key can be generated
+// with `openssl ecparam -name prime256v1 -genkey -noout`.
+func WithClientAssertionKeyFile(path string) Option {
+	return func(h *handlerState) error {
+		pemBytes, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("WithClientAssertionKeyFile error: could not read key file: %w", err)
+		}
+		key, err := parseClientAssertionKey(pemBytes)
+		if err != nil {
+			return fmt.Errorf("WithClientAssertionKeyFile error: %s: %w", path, err)
+		}
+		return WithClientAssertionKey(key)(h)
+	}
+}
+
+func parseClientAssertionKey(pemBytes []byte) (*ecdsa.PrivateKey, error) {
+	for {
+		var block *pem.Block
+		block, pemBytes = pem.Decode(pemBytes)
+		if block == nil {
+			return nil, fmt.Errorf("no %q or %q PEM block found", "EC PRIVATE KEY", "PRIVATE KEY")
+		}
+		switch block.Type {
+		case "EC PRIVATE KEY":
+			return x509.ParseECPrivateKey(block.Bytes)
+		case "PRIVATE KEY":
+			key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			ecKey, ok := key.(*ecdsa.PrivateKey)
+			if !ok {
+				return nil, fmt.Errorf("key must be an ECDSA P-256 key")
+			}
+			return ecKey, nil
+		}
+		// Skip other blocks, e.g. the "EC PARAMETERS" block which is written by openssl.
+	}
+}
+
+// signClientAssertion returns a client assertion of the client for the given token endpoint, as described by
+// RFC 7523 section 3.
+func signClientAssertion(key *ecdsa.PrivateKey, clientID string, tokenURL string, now time.Time) (string, error) {
+	thumbprint, err := (&jose.JSONWebKey{Key: key.Public()}).Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", fmt.Errorf("could not compute client assertion key ID: %w", err)
+	}
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.ES256, Key: key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader(jose.HeaderKey("kid"), base64.RawURLEncoding.EncodeToString(thumbprint)),
+	)
+	if err != nil {
+		return "", fmt.Errorf("could not create client assertion signer: %w", err)
+	}
+
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", fmt.Errorf("could not generate client assertion ID: %w", err)
+	}
+
+	assertion, err := jwt.Signed(signer).Claims(jwt.Claims{
+		Issuer:   clientID,
+		Subject:  clientID,
+		Audience: jwt.Audience{tokenURL},
+		ID:       base64.RawURLEncoding.EncodeToString(jti),
+		IssuedAt: jwt.NewNumericDate(now),
+		Expiry:   jwt.NewNumericDate(now.Add(clientAssertionLifetime)),
+	}).CompactSerialize()
+	if err != nil {
+		return "", fmt.Errorf("could not sign client assertion: %w", err)
+	}
+	return assertion, nil
+}
+
+// clientAssertionRoundTripper adds a client assertion to the body of each request to the token endpoint and to the
+// pushed authorization request endpoint of the issuer, while the login has a client assertion key. Other requests,
+// e.g. to the discovery endpoints of the issuer, are sent unchanged.
+type clientAssertionRoundTripper struct {
+	base http.RoundTripper
+	h    *handlerState
+}
+
+var _ http.RoundTripper = (*clientAssertionRoundTripper)(nil)
+
+func (rt *clientAssertionRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if rt.h.clientAssertionKey == nil || rt.h.provider == nil || req.Method != http.MethodPost || req.Body == nil ||
+		!(isSameURLPath(req.URL, rt.h.provider.Endpoint().TokenURL) || (rt.h.parURL != "" && isSameURLPath(req.URL, rt.h.parURL))) {
+		return rt.base.RoundTrip(req)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+
+	// The audience is always the token endpoint, also for pushed authorization requests, as RFC 9126 section 2 says.
+	assertion, err := signClientAssertion(rt.h.clientAssertionKey, rt.h.clientID, rt.h.provider.Endpoint().TokenURL, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	form.Set("client_assertion_type", clientAssertionType)
+	form.Set("client_assertion", assertion)
+	encoded := form.Encode()
+
+	// A RoundTripper must not modify the request, so send a copy of it.
+	req = req.Clone(req.Context())
+	req.Header.Del("Authorization")
+	req.Body = io.NopCloser(strings.NewReader(encoded))
+	req.ContentLength = int64(len(encoded))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader(encoded)), nil }
+	return rt.base.RoundTrip(req)
+}
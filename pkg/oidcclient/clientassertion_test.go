@@ -0,0 +1,184 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package oidcclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	coreosoidc "github.com/coreos/go-oidc/v3/oidc"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/stretchr/testify/require"
+
+	"go.pinniped.dev/internal/federationdomain/oidcclientvalidator"
+	"go.pinniped.dev/internal/httputil/roundtripper"
+)
+
+func TestClientAssertionKeyOptions(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	pkcs8DER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	writeKeyFile := func(name string, contents []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, contents, 0600))
+		return path
+	}
+
+	tests := []struct {
+		name    string
+		opt     Option
+		wantErr string
+	}{
+		{
+			name: "key",
+			opt:  WithClientAssertionKey(key),
+		},
+		{
+			name:    "key is not P-256",
+			opt:     WithClientAssertionKey(p384Key),
+			wantErr: "WithClientAssertionKey error: key must be an ECDSA P-256 key",
+		},
+		{
+			name: "EC PRIVATE KEY file with EC PARAMETERS",
+			opt: WithClientAssertionKeyFile(writeKeyFile("ec.pem", append(
+				pem.EncodeToMemory(&pem.Block{Type: "EC PARAMETERS", Bytes: []byte{0x06, 0x08, 0x2a, 0x86, 0x48, 0xce, 0x3d, 0x03, 0x01, 0x07}}),
+				pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER})...,
+			))),
+		},
+		{
+			name: "PKCS #8 PRIVATE KEY file",
+			opt:  WithClientAssertionKeyFile(writeKeyFile("pkcs8.pem", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8DER}))),
+		},
+		{
+			name:    "file without a key",
+			opt:     WithClientAssertionKeyFile(writeKeyFile("invalid.pem", []byte("not PEM"))),
+			wantErr: fmt.Sprintf(`WithClientAssertionKeyFile error: %s: no "EC PRIVATE KEY" or "PRIVATE KEY" PEM block found`, filepath.Join(dir, "invalid.pem")),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &handlerState{}
+			err := tt.opt(h)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				require.Nil(t, h.clientAssertionKey)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, key.Equal(h.clientAssertionKey))
+		})
+	}
+}
+
+func TestClientAssertionRoundTripper(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	var issuer string
+	discoveryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"issuer": %q, "token_endpoint": "https://issuer.example.com/oauth2/token"}`, issuer)
+	}))
+	t.Cleanup(discoveryServer.Close)
+	issuer = discoveryServer.URL
+	provider, err := coreosoidc.NewProvider(context.Background(), issuer)
+	require.NoError(t, err)
+
+	var gotForms []url.Values
+	var gotAuthorizations []string
+	h := &handlerState{clientID: "client.oauth.pinniped.dev-automation"}
+	rt := &clientAssertionRoundTripper{
+		base: roundtripper.Func(func(req *http.Request) (*http.Response, error) {
+			gotAuthorizations = append(gotAuthorizations, req.Header.Get("Authorization"))
+			body, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			form, err := url.ParseQuery(string(body))
+			require.NoError(t, err)
+			gotForms = append(gotForms, form)
+			return &http.Response{StatusCode: http.StatusOK}, nil
+		}),
+		h: h,
+	}
+
+	send := func(method, url string) *http.Request {
+		req := httptest.NewRequest(method, url, strings.NewReader("grant_type=authorization_code"))
+		req.SetBasicAuth("client.oauth.pinniped.dev-automation", "")
+		_, err := rt.RoundTrip(req)
+		require.NoError(t, err)
+		return req
+	}
+
+	// No assertions without a key or before discovery.
+	send(http.MethodPost, "https://issuer.example.com/oauth2/token")
+	h.clientAssertionKey = key
+	send(http.MethodPost, "https://issuer.example.com/oauth2/token")
+	for i := range gotForms {
+		require.False(t, gotForms[i].Has("client_assertion"))
+		require.NotEmpty(t, gotAuthorizations[i])
+	}
+
+	// After discovery, assertions are only added to the requests to the token and PAR endpoints.
+	h.provider = provider
+	h.parURL = "https://issuer.example.com/oauth2/par"
+	gotForms, gotAuthorizations = nil, nil
+	send(http.MethodPost, "https://issuer.example.com/some/other/endpoint")
+	original := send(http.MethodPost, "https://ISSUER.example.com/oauth2/token")
+	send(http.MethodPost, "https://issuer.example.com/oauth2/par")
+	require.Len(t, gotForms, 3)
+	require.False(t, gotForms[0].Has("client_assertion"))
+	require.Equal(t, []string{gotAuthorizations[0], "", ""}, gotAuthorizations, "the basic authorization should be removed")
+	require.NotEmpty(t, original.Header.Get("Authorization"), "the original request should not be modified")
+	gotForms = gotForms[1:]
+
+	// The assertions are accepted by the public keys which the Supervisor would read from the OIDCClient's Secret.
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(key.Public())
+	require.NoError(t, err)
+	publicKeys, err := oidcclientvalidator.ParsePrivateKeyJWTPublicKeys(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER}))
+	require.NoError(t, err)
+
+	var jtis []string
+	for _, form := range gotForms {
+		require.Equal(t, "authorization_code", form.Get("grant_type"))
+		require.Equal(t, "urn:ietf:params:oauth:client-assertion-type:jwt-bearer", form.Get("client_assertion_type"))
+
+		assertion, err := jwt.ParseSigned(form.Get("client_assertion"))
+		require.NoError(t, err)
+		require.Len(t, assertion.Headers, 1)
+		require.Equal(t, "ES256", assertion.Headers[0].Algorithm)
+		require.Equal(t, publicKeys.Keys[0].KeyID, assertion.Headers[0].KeyID)
+
+		var claims jwt.Claims
+		require.NoError(t, assertion.Claims(publicKeys.Keys[0].Key, &claims))
+		require.NoError(t, claims.Validate(jwt.Expected{
+			Issuer:   "client.oauth.pinniped.dev-automation",
+			Subject:  "client.oauth.pinniped.dev-automation",
+			Audience: jwt.Audience{"https://issuer.example.com/oauth2/token"},
+			Time:     time.Now(),
+		}))
+		require.NotEmpty(t, claims.ID)
+		jtis = append(jtis, claims.ID)
+	}
+	require.NotEqual(t, jtis[0], jtis[1], "each assertion should have a new ID")
+}
@@ -0,0 +1,146 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package oidcclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+
+	"go.pinniped.dev/pkg/oidcclient/oidctypes"
+)
+
+const (
+	dpopHeaderName = "DPoP"
+	dpopTokenType  = "DPoP"
+	dpopProofType  = "dpop+jwt"
+	dpopPEMType    = "EC PRIVATE KEY"
+)
+
+// WithDPoP causes the login to request DPoP-bound tokens, as described by RFC 9449. A new key is generated for each
+// new session, and is stored in the session cache along with the tokens. Each request to the token endpoint, which
+// includes the authcode exchange, refresh, and RFC8693 token exchange requests, will have a DPoP proof signed by that
+// key. This only has an effect when the issuer supports DPoP. Sessions which were already bound to a key always
+// use that key, even when this option is not used.
+func WithDPoP() Option {
+	return func(h *handlerState) error {
+		h.useDPoP = true
+		return nil
+	}
+}
+
+func generateDPoPKey() (*ecdsa.PrivateKey, error) {
+	return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+}
+
+func encodeDPoPKey(key *ecdsa.PrivateKey) (*oidctypes.DPoPKey, error) {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode DPoP key: %w", err)
+	}
+	return &oidctypes.DPoPKey{PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: dpopPEMType, Bytes: der}))}, nil
+}
+
+func parseDPoPKey(dpopKey *oidctypes.DPoPKey) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(dpopKey.PrivateKey))
+	if block == nil || block.Type != dpopPEMType {
+		return nil, fmt.Errorf("could not decode DPoP key: no %q PEM block found", dpopPEMType)
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse DPoP key: %w", err)
+	}
+	return key, nil
+}
+
+// signDPoPProof returns a DPoP proof for a request with the given method to the given URL.
+func signDPoPProof(key *ecdsa.PrivateKey, method string, requestURL *url.URL, now time.Time) (string, error) {
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.ES256, Key: key},
+		(&jose.SignerOptions{EmbedJWK: true}).WithType(dpopProofType),
+	)
+	if err != nil {
+		return "", fmt.Errorf("could not create DPoP proof signer: %w", err)
+	}
+
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", fmt.Errorf("could not generate DPoP proof ID: %w", err)
+	}
+
+	// The htu claim is the URL of the request without its query and fragment.
+	htu := url.URL{Scheme: requestURL.Scheme, Host: requestURL.Host, Path: requestURL.Path, RawPath: requestURL.RawPath}
+
+	proof, err := jwt.Signed(signer).Claims(map[string]any{
+		"jti": base64.RawURLEncoding.EncodeToString(jti),
+		"htm": method,
+		"htu": htu.String(),
+		"iat": now.Unix(),
+	}).CompactSerialize()
+	if err != nil {
+		return "", fmt.Errorf("could not sign DPoP proof: %w", err)
+	}
+	return proof, nil
+}
+
+// dpopRoundTripper adds a DPoP proof to each request to the token endpoint of the issuer, while the login has a
+// DPoP key. Other requests, e.g. to the discovery endpoints of the issuer, are sent without a proof.
+type dpopRoundTripper struct {
+	base http.RoundTripper
+	h    *handlerState
+}
+
+var _ http.RoundTripper = (*dpopRoundTripper)(nil)
+
+func (rt *dpopRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if rt.h.dpopKey == nil || rt.h.provider == nil || !isSameURLPath(req.URL, rt.h.provider.Endpoint().TokenURL) {
+		return rt.base.RoundTrip(req)
+	}
+
+	proof, err := signDPoPProof(rt.h.dpopKey, req.Method, req.URL, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// A RoundTripper must not modify the request, so send a copy of it.
+	req = req.Clone(req.Context())
+	req.Header.Set(dpopHeaderName, proof)
+	return rt.base.RoundTrip(req)
+}
+
+// isSameURLPath returns true when the requestURL has the same scheme, host, and path as the otherURL.
+func isSameURLPath(requestURL *url.URL, otherURL string) bool {
+	other, err := url.Parse(otherURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(requestURL.Scheme, other.Scheme) &&
+		strings.EqualFold(requestURL.Host, other.Host) &&
+		requestURL.EscapedPath() == other.EscapedPath()
+}
+
+// bindToDPoPKey stores the DPoP key with the token when the issuer has bound the token to the key, so that the
+// key is cached along with the token.
+func (h *handlerState) bindToDPoPKey(token *oidctypes.Token) error {
+	if h.dpopKey == nil || token.AccessToken == nil || !strings.EqualFold(token.AccessToken.Type, dpopTokenType) {
+		return nil
+	}
+	dpopKey, err := encodeDPoPKey(h.dpopKey)
+	if err != nil {
+		return err
+	}
+	token.DPoPKey = dpopKey
+	return nil
+}
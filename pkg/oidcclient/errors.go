@@ -0,0 +1,71 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package oidcclient
+
+import (
+	"go.pinniped.dev/internal/constable"
+)
+
+// The errors returned by Login and LoginAndExchangeForAudiences can be checked using errors.Is against these
+// sentinel errors to decide how to react to a failure, e.g. whether to retry or to ask the user to log in again.
+// Classifying an error does not change its message, so the message of the sentinel error does not appear in
+// the messages of the returned errors.
+const (
+	// ErrInvalidOption means that the options or arguments were invalid. Retrying will not help.
+	ErrInvalidOption = constable.Error("invalid option")
+
+	// ErrDiscoveryFailed means that the OIDC discovery or Pinniped IDP discovery against the issuer failed.
+	// This is often caused by a temporary network problem, so it may be worth retrying.
+	ErrDiscoveryFailed = constable.Error("discovery failed")
+
+	// ErrUpstreamIDPNotFound means that the requested upstream identity provider or flow is not offered by the issuer.
+	ErrUpstreamIDPNotFound = constable.Error("upstream identity provider not found")
+
+	// ErrRefreshRejected means that the tokens from refreshing the cached session were rejected.
+	// The user needs to log in again.
+	ErrRefreshRejected = constable.Error("refresh rejected")
+
+	// ErrIDTokenInvalid means that an ID token issued by the issuer failed validation.
+	ErrIDTokenInvalid = constable.Error("ID token invalid")
+
+	// ErrAuthorizationFailed means that the issuer did not authorize the user, or that the authorization
+	// code could not be exchanged for tokens.
+	ErrAuthorizationFailed = constable.Error("authorization failed")
+
+	// ErrCallbackTimeout means that the login timed out or was canceled while waiting for the user to finish
+	// logging in with their web browser.
+	ErrCallbackTimeout = constable.Error("timed out waiting for callback")
+
+	// ErrPromptFailed means that it was not possible to interact with the user, e.g. because stdin is not a TTY.
+	ErrPromptFailed = constable.Error("prompt failed")
+
+	// ErrTokenExchangeFailed means that the RFC8693 token exchange for the requested audience failed.
+	ErrTokenExchangeFailed = constable.Error("token exchange failed")
+)
+
+// classifiedError adds sentinel errors to the chain of an error without changing its message.
+type classifiedError struct {
+	err     error
+	classes []error
+}
+
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classifiedError) Unwrap() []error {
+	return append([]error{e.err}, e.classes...)
+}
+
+// classify returns err classified as all the given sentinel errors, or nil when err is nil.
+func classify(err error, classes ...constable.Error) error {
+	if err == nil {
+		return nil
+	}
+	c := &classifiedError{err: err, classes: make([]error, 0, len(classes))}
+	for _, class := range classes {
+		c.classes = append(c.classes, class)
+	}
+	return c
+}
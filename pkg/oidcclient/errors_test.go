@@ -0,0 +1,36 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package oidcclient
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassify(t *testing.T) {
+	require.NoError(t, classify(nil, ErrDiscoveryFailed))
+
+	original := fmt.Errorf("some error: %w", context.Canceled)
+	err := classify(original, ErrRefreshRejected, ErrIDTokenInvalid)
+	require.EqualError(t, err, "some error: context canceled")
+	require.ErrorIs(t, err, ErrRefreshRejected)
+	require.ErrorIs(t, err, ErrIDTokenInvalid)
+	require.ErrorIs(t, err, original)
+	require.ErrorIs(t, err, context.Canceled)
+	require.NotErrorIs(t, err, ErrDiscoveryFailed)
+
+	// Classifications are kept when the error is wrapped again.
+	wrapped := classify(fmt.Errorf("outer: %w", err), ErrTokenExchangeFailed)
+	require.EqualError(t, wrapped, "outer: some error: context canceled")
+	require.ErrorIs(t, wrapped, ErrTokenExchangeFailed)
+	require.ErrorIs(t, wrapped, ErrRefreshRejected)
+}
+
+func TestRefreshRequiredErrorClassification(t *testing.T) {
+	require.NotErrorIs(t, &RefreshRequiredError{Reason: "no cached session found"}, ErrRefreshRejected)
+	require.ErrorIs(t, &RefreshRequiredError{Reason: "cached session could not be refreshed", Err: ErrRefreshRejected}, ErrRefreshRejected)
+}
@@ -5,6 +5,9 @@
 package filesession
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -16,6 +19,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/yaml"
 
+	"go.pinniped.dev/pkg/cacheencryption"
 	"go.pinniped.dev/pkg/oidcclient"
 	"go.pinniped.dev/pkg/oidcclient/oidctypes"
 )
@@ -53,8 +57,9 @@ type (
 	}
 )
 
-// readSessionCache loads a sessionCache from a path on disk. If the requested path does not exist, it returns an empty cache.
-func readSessionCache(path string) (*sessionCache, error) {
+// readSessionCache loads a sessionCache from a path on disk, decrypting it when encryption is not nil.
+// If the requested path does not exist, it returns an empty cache.
+func readSessionCache(path string, encryption cacheencryption.Provider) (*sessionCache, error) {
 	cacheYAML, err := os.ReadFile(path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -65,6 +70,12 @@ func readSessionCache(path string) (*sessionCache, error) {
 		return nil, fmt.Errorf("could not read session file: %w", err)
 	}
 
+	if encryption != nil {
+		if cacheYAML, err = encryption.Decrypt(cacheYAML); err != nil {
+			return nil, fmt.Errorf("invalid session file: %w", err)
+		}
+	}
+
 	// If we read the file successfully, unmarshal it from YAML.
 	var cache sessionCache
 	if err := yaml.Unmarshal(cacheYAML, &cache); err != nil {
@@ -86,10 +97,13 @@ func emptySessionCache() *sessionCache {
 	}
 }
 
-// writeTo writes the cache to the specified file path.
-func (c *sessionCache) writeTo(path string) error {
+// writeTo writes the cache to the specified file path, encrypting it when encryption is not nil.
+func (c *sessionCache) writeTo(path string, encryption cacheencryption.Provider) error {
 	// Marshal the session back to YAML and save it to the file.
 	cacheYAML, err := yaml.Marshal(c)
+	if err == nil && encryption != nil {
+		cacheYAML, err = encryption.Encrypt(cacheYAML)
+	}
 	if err == nil {
 		err = os.WriteFile(path, cacheYAML, 0600)
 	}
@@ -156,3 +170,29 @@ func (c *sessionCache) lookup(key oidcclient.SessionCacheKey) *sessionEntry {
 func (c *sessionCache) insert(entries ...sessionEntry) {
 	c.Sessions = slices.Concat(c.Sessions, entries)
 }
+
+// summary describes the entry without any of its tokens.
+func (e *sessionEntry) summary() SessionSummary {
+	summary := SessionSummary{
+		ID:              sessionID(e.Key),
+		Key:             e.Key,
+		LastUsed:        e.LastUsedTimestamp.Time,
+		HasRefreshToken: e.Tokens.RefreshToken != nil,
+	}
+	if e.Tokens.IDToken != nil {
+		summary.IDTokenExpiry = e.Tokens.IDToken.Expiry.Time
+	}
+	if e.Tokens.AccessToken != nil {
+		summary.AccessTokenExpiry = e.Tokens.AccessToken.Expiry.Time
+	}
+	return summary
+}
+
+// sessionID returns the first 8 hex characters of the SHA-256 hash of the JSON encoding of the key.
+func sessionID(key oidcclient.SessionCacheKey) string {
+	hash := sha256.New()
+	if err := json.NewEncoder(hash).Encode(key); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:8]
+}
@@ -93,7 +93,7 @@ func TestReadSessionCache(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			got, err := readSessionCache(tt.path)
+			got, err := readSessionCache(tt.path, nil)
 			if tt.wantErr != "" {
 				require.EqualError(t, err, tt.wantErr)
 				require.Nil(t, got)
@@ -120,13 +120,13 @@ func TestWriteTo(t *testing.T) {
 		t.Parallel()
 		tmp := t.TempDir() + "/sessions.yaml"
 		require.NoError(t, os.Mkdir(tmp, 0700))
-		err := validSession.writeTo(tmp)
+		err := validSession.writeTo(tmp, nil)
 		require.EqualError(t, err, "open "+tmp+": is a directory")
 	})
 
 	t.Run("success", func(t *testing.T) {
 		t.Parallel()
-		require.NoError(t, validSession.writeTo(t.TempDir()+"/sessions.yaml"))
+		require.NoError(t, validSession.writeTo(t.TempDir()+"/sessions.yaml", nil))
 	})
 }
 
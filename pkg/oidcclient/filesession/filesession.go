@@ -1,4 +1,4 @@
-// Copyright 2020-2021 the Pinniped contributors. All Rights Reserved.
+// Copyright 2020-2024 the Pinniped contributors. All Rights Reserved.
 // SPDX-License-Identifier: Apache-2.0
 
 // Package filesession implements a simple YAML file-based login.sessionCache.
@@ -10,11 +10,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/gofrs/flock"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"go.pinniped.dev/pkg/cacheencryption"
 	"go.pinniped.dev/pkg/oidcclient"
 	"go.pinniped.dev/pkg/oidcclient/oidctypes"
 )
@@ -38,6 +41,23 @@ func WithErrorReporter(reporter func(error)) Option {
 	}
 }
 
+// WithMemoryOnly is an Option that keeps the session cache in memory instead of in the file at the specified path.
+// Nothing is ever read from or written to disk, so cached sessions only last for the lifetime of the Cache.
+// This is useful in ephemeral environments, such as CI jobs, which should never persist tokens to disk.
+func WithMemoryOnly() Option {
+	return func(c *Cache) {
+		c.memoryOnly = true
+	}
+}
+
+// WithEncryption is an Option that encrypts the session cache file with the provided encryption. Session cache files
+// which were not encrypted with it cannot be read, so they are replaced by the next update.
+func WithEncryption(encryption cacheencryption.Provider) Option {
+	return func(c *Cache) {
+		c.encryption = encryption
+	}
+}
+
 // New returns a login.SessionCache implementation backed by the specified file path.
 func New(path string, options ...Option) *Cache {
 	lock := flock.New(path + ".lock")
@@ -63,13 +83,21 @@ type Cache struct {
 	errReporter func(error)
 	trylockFunc func() error
 	unlockFunc  func() error
+	encryption  cacheencryption.Provider
+
+	// These are only used when memoryOnly is true.
+	memoryOnly  bool
+	memoryLock  sync.Mutex
+	memoryCache *sessionCache
 }
 
 // GetToken looks up the cached data for the given parameters. It may return nil if no valid matching session is cached.
 func (c *Cache) GetToken(key oidcclient.SessionCacheKey) *oidctypes.Token {
 	// If the cache file does not exist, exit immediately with no error log
-	if _, err := os.Stat(c.path); errors.Is(err, os.ErrNotExist) {
-		return nil
+	if !c.memoryOnly {
+		if _, err := os.Stat(c.path); errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
 	}
 
 	// Read the cache and lookup the matching entry. If one exists, update its last used timestamp and return it.
@@ -87,9 +115,11 @@ func (c *Cache) GetToken(key oidcclient.SessionCacheKey) *oidctypes.Token {
 // but may silently fail to update the session cache.
 func (c *Cache) PutToken(key oidcclient.SessionCacheKey, token *oidctypes.Token) {
 	// Create the cache directory if it does not exist.
-	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil && !errors.Is(err, os.ErrExist) {
-		c.errReporter(fmt.Errorf("could not create session cache directory: %w", err))
-		return
+	if !c.memoryOnly {
+		if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil && !errors.Is(err, os.ErrExist) {
+			c.errReporter(fmt.Errorf("could not create session cache directory: %w", err))
+			return
+		}
 	}
 
 	// Mutate the cache to upsert the new session entry.
@@ -116,6 +146,11 @@ func (c *Cache) PutToken(key oidcclient.SessionCacheKey, token *oidctypes.Token)
 // withCache is an internal helper which locks, reads the cache, processes/mutates it with the provided function, then
 // saves it back to the file.
 func (c *Cache) withCache(transact func(*sessionCache)) {
+	if c.memoryOnly {
+		c.withMemoryCache(transact)
+		return
+	}
+
 	// Grab the file lock so we have exclusive access to read the file.
 	if err := c.trylockFunc(); err != nil {
 		c.errReporter(fmt.Errorf("could not lock session file: %w", err))
@@ -130,7 +165,7 @@ func (c *Cache) withCache(transact func(*sessionCache)) {
 	}()
 
 	// Try to read the existing cache.
-	cache, err := readSessionCache(c.path)
+	cache, err := readSessionCache(c.path, c.encryption)
 	if err != nil {
 		// If that fails, fall back to resetting to a blank slate.
 		c.errReporter(fmt.Errorf("failed to read cache, resetting: %w", err))
@@ -147,7 +182,75 @@ func (c *Cache) withCache(transact func(*sessionCache)) {
 	cache = cache.normalized()
 
 	// Marshal the session back to YAML and save it to the file.
-	if err := cache.writeTo(c.path); err != nil {
+	if err := cache.writeTo(c.path, c.encryption); err != nil {
 		c.errReporter(fmt.Errorf("could not write session cache: %w", err))
 	}
 }
+
+// withMemoryCache is like withCache, but processes/mutates the in-memory cache instead of the file.
+func (c *Cache) withMemoryCache(transact func(*sessionCache)) {
+	c.memoryLock.Lock()
+	defer c.memoryLock.Unlock()
+
+	if c.memoryCache == nil {
+		c.memoryCache = emptySessionCache()
+	}
+
+	cache := c.memoryCache.normalized()
+	transact(cache)
+	c.memoryCache = cache.normalized()
+}
+
+// SessionSummary describes a cached session without revealing any of its tokens.
+type SessionSummary struct {
+	// ID is a short, stable identifier of the session, derived from its key.
+	ID       string
+	Key      oidcclient.SessionCacheKey
+	LastUsed time.Time
+	// IDTokenExpiry and AccessTokenExpiry are zero when the session has no such token, or when it has expired.
+	IDTokenExpiry     time.Time
+	AccessTokenExpiry time.Time
+	// HasRefreshToken is true when the session can be refreshed without logging in again, unless the
+	// refresh token was revoked or has expired on the server.
+	HasRefreshToken bool
+}
+
+// ReadSessionSummaries returns summaries of the unexpired sessions in the session cache file at path, in the order
+// in which they were created. It returns no sessions when the file does not exist. It never modifies the file.
+// Only the WithEncryption option applies.
+func ReadSessionSummaries(path string, options ...Option) ([]SessionSummary, error) {
+	cache, err := readSessionCache(path, New(path, options...).encryption)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := cache.normalized().Sessions
+	summaries := make([]SessionSummary, 0, len(sessions))
+	for _, s := range sessions {
+		summaries = append(summaries, s.summary())
+	}
+	return summaries, nil
+}
+
+// DeleteSessions removes the sessions for which match returns true from the session cache, and returns how many
+// sessions were removed. It does not return an error but may silently fail to update the session cache.
+func (c *Cache) DeleteSessions(match func(SessionSummary) bool) int {
+	// If the cache file does not exist, there is nothing to delete.
+	if !c.memoryOnly {
+		if _, err := os.Stat(c.path); errors.Is(err, os.ErrNotExist) {
+			return 0
+		}
+	}
+
+	deleted := 0
+	c.withCache(func(cache *sessionCache) {
+		cache.Sessions = slices.DeleteFunc(cache.Sessions, func(s sessionEntry) bool {
+			if match(s.summary()) {
+				deleted++
+				return true
+			}
+			return false
+		})
+	})
+	return deleted
+}
@@ -4,6 +4,7 @@
 package filesession
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -14,6 +15,7 @@ import (
 	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"go.pinniped.dev/pkg/cacheencryption"
 	"go.pinniped.dev/pkg/oidcclient"
 	"go.pinniped.dev/pkg/oidcclient/oidctypes"
 )
@@ -28,6 +30,46 @@ func TestNew(t *testing.T) {
 	c.errReporter(fmt.Errorf("some error"))
 }
 
+func TestMemoryOnly(t *testing.T) {
+	t.Parallel()
+	now := time.Now().Round(1 * time.Second)
+	tmp := filepath.Join(t.TempDir(), "sessions.yaml")
+
+	var errs []error
+	c := New(tmp, WithMemoryOnly(), WithErrorReporter(func(err error) { errs = append(errs, err) }))
+	c.trylockFunc = func() error { require.Fail(t, "should not be called"); return nil }
+	c.unlockFunc = func() error { require.Fail(t, "should not be called"); return nil }
+
+	key := oidcclient.SessionCacheKey{
+		Issuer:      "test-issuer",
+		ClientID:    "test-client-id",
+		Scopes:      []string{"email", "offline_access", "openid", "profile"},
+		RedirectURI: "http://localhost:0/callback",
+	}
+	token := &oidctypes.Token{
+		IDToken: &oidctypes.IDToken{
+			Token:  "test-id-token",
+			Expiry: metav1.NewTime(now.Add(1 * time.Hour)),
+		},
+		RefreshToken: &oidctypes.RefreshToken{
+			Token: "test-refresh-token",
+		},
+	}
+
+	require.Nil(t, c.GetToken(key))
+	c.PutToken(key, token)
+	require.Equal(t, token, c.GetToken(key))
+	require.Nil(t, c.GetToken(oidcclient.SessionCacheKey{Issuer: "other-issuer"}))
+
+	// Nothing should have been written to disk.
+	require.NoFileExists(t, tmp)
+	require.NoFileExists(t, tmp+".lock")
+	require.Empty(t, errs)
+
+	// A separate in-memory cache does not share any sessions.
+	require.Nil(t, New(tmp, WithMemoryOnly()).GetToken(key))
+}
+
 func TestGetToken(t *testing.T) {
 	t.Parallel()
 	now := time.Now().Round(1 * time.Second)
@@ -113,7 +155,7 @@ func TestGetToken(t *testing.T) {
 						},
 					},
 				})
-				require.NoError(t, validCache.writeTo(tmp))
+				require.NoError(t, validCache.writeTo(tmp, nil))
 			},
 			key: oidcclient.SessionCacheKey{
 				Issuer:      "test-issuer",
@@ -148,7 +190,7 @@ func TestGetToken(t *testing.T) {
 						},
 					},
 				})
-				require.NoError(t, validCache.writeTo(tmp))
+				require.NoError(t, validCache.writeTo(tmp, nil))
 			},
 			key: oidcclient.SessionCacheKey{
 				Issuer:      "test-issuer",
@@ -186,7 +228,7 @@ func TestGetToken(t *testing.T) {
 						},
 					},
 				})
-				require.NoError(t, validCache.writeTo(tmp))
+				require.NoError(t, validCache.writeTo(tmp, nil))
 			},
 			key: oidcclient.SessionCacheKey{
 				Issuer:      "test-issuer",
@@ -210,7 +252,7 @@ func TestGetToken(t *testing.T) {
 				},
 			},
 			wantTestFile: func(t *testing.T, tmp string) {
-				cache, err := readSessionCache(tmp)
+				cache, err := readSessionCache(tmp, nil)
 				require.NoError(t, err)
 				require.Len(t, cache.Sessions, 1)
 				require.Less(t, time.Since(cache.Sessions[0].LastUsedTimestamp.Time).Nanoseconds(), (5 * time.Second).Nanoseconds())
@@ -321,7 +363,7 @@ func TestPutToken(t *testing.T) {
 				})
 
 				require.NoError(t, os.MkdirAll(filepath.Dir(tmp), 0700))
-				require.NoError(t, validCache.writeTo(tmp))
+				require.NoError(t, validCache.writeTo(tmp, nil))
 			},
 			key: oidcclient.SessionCacheKey{
 				Issuer:      "test-issuer",
@@ -344,7 +386,7 @@ func TestPutToken(t *testing.T) {
 				},
 			},
 			wantTestFile: func(t *testing.T, tmp string) {
-				cache, err := readSessionCache(tmp)
+				cache, err := readSessionCache(tmp, nil)
 				require.NoError(t, err)
 				require.Len(t, cache.Sessions, 1)
 				require.Less(t, time.Since(cache.Sessions[0].LastUsedTimestamp.Time).Nanoseconds(), (5 * time.Second).Nanoseconds())
@@ -393,7 +435,7 @@ func TestPutToken(t *testing.T) {
 					},
 				})
 				require.NoError(t, os.MkdirAll(filepath.Dir(tmp), 0700))
-				require.NoError(t, validCache.writeTo(tmp))
+				require.NoError(t, validCache.writeTo(tmp, nil))
 			},
 			key: oidcclient.SessionCacheKey{
 				Issuer:      "test-issuer",
@@ -416,7 +458,7 @@ func TestPutToken(t *testing.T) {
 				},
 			},
 			wantTestFile: func(t *testing.T, tmp string) {
-				cache, err := readSessionCache(tmp)
+				cache, err := readSessionCache(tmp, nil)
 				require.NoError(t, err)
 				require.Len(t, cache.Sessions, 2)
 				require.Less(t, time.Since(cache.Sessions[1].LastUsedTimestamp.Time).Nanoseconds(), (5 * time.Second).Nanoseconds())
@@ -440,7 +482,7 @@ func TestPutToken(t *testing.T) {
 			name: "error writing cache",
 			makeTestFile: func(t *testing.T, tmp string) {
 				require.NoError(t, os.MkdirAll(tmp, 0700))
-				// require.NoError(t, emptySessionCache().writeTo(tmp))
+				// require.NoError(t, emptySessionCache().writeTo(tmp, nil))
 				// require.NoError(t, os.Chmod(tmp, 0400))
 			},
 			key: oidcclient.SessionCacheKey{
@@ -468,7 +510,7 @@ func TestPutToken(t *testing.T) {
 				"could not write session cache: open TEMPFILE: is a directory",
 			},
 			wantTestFile: func(t *testing.T, tmp string) {
-				// cache, err := readSessionCache(tmp)
+				// cache, err := readSessionCache(tmp, nil)
 				// require.NoError(t, err)
 				// require.Len(t, cache.Sessions, 0)
 			},
@@ -513,3 +555,107 @@ func (e *errorCollector) require(want []string, subs ...string) {
 		require.EqualError(e.t, e.saw[i], w)
 	}
 }
+
+func TestReadSessionSummaries(t *testing.T) {
+	t.Parallel()
+	now := time.Now().Round(1 * time.Second)
+	tmp := filepath.Join(t.TempDir(), "sessions.yaml")
+
+	summaries, err := ReadSessionSummaries(tmp)
+	require.NoError(t, err)
+	require.Empty(t, summaries)
+
+	key1 := oidcclient.SessionCacheKey{Issuer: "test-issuer", ClientID: "test-client-id", Scopes: []string{"openid"}}
+	key2 := oidcclient.SessionCacheKey{Issuer: "other-issuer", ClientID: "test-client-id", Scopes: []string{"openid"}}
+	c := New(tmp)
+	c.PutToken(key1, &oidctypes.Token{
+		IDToken:      &oidctypes.IDToken{Token: "test-id-token", Expiry: metav1.NewTime(now.Add(1 * time.Hour))},
+		AccessToken:  &oidctypes.AccessToken{Token: "test-access-token", Expiry: metav1.NewTime(now.Add(2 * time.Hour))},
+		RefreshToken: &oidctypes.RefreshToken{Token: "test-refresh-token"},
+	})
+	c.PutToken(key2, &oidctypes.Token{
+		IDToken: &oidctypes.IDToken{Token: "test-id-token", Expiry: metav1.NewTime(now.Add(3 * time.Hour))},
+	})
+
+	summaries, err = ReadSessionSummaries(tmp)
+	require.NoError(t, err)
+	require.Len(t, summaries, 2)
+	require.Len(t, summaries[0].ID, 8)
+	require.NotEqual(t, summaries[0].ID, summaries[1].ID)
+	require.Equal(t, key1, summaries[0].Key)
+	require.Equal(t, now.Add(1*time.Hour).UTC(), summaries[0].IDTokenExpiry.UTC())
+	require.Equal(t, now.Add(2*time.Hour).UTC(), summaries[0].AccessTokenExpiry.UTC())
+	require.True(t, summaries[0].HasRefreshToken)
+	require.WithinDuration(t, now, summaries[0].LastUsed, time.Minute)
+	require.Equal(t, key2, summaries[1].Key)
+	require.Equal(t, now.Add(3*time.Hour).UTC(), summaries[1].IDTokenExpiry.UTC())
+	require.True(t, summaries[1].AccessTokenExpiry.IsZero())
+	require.False(t, summaries[1].HasRefreshToken)
+
+	_, err = ReadSessionSummaries("./testdata/invalid.yaml")
+	require.ErrorContains(t, err, "invalid session file")
+}
+
+func TestDeleteSessions(t *testing.T) {
+	t.Parallel()
+	now := time.Now().Round(1 * time.Second)
+	tmp := filepath.Join(t.TempDir(), "sessions.yaml")
+
+	c := New(tmp)
+	require.Zero(t, c.DeleteSessions(func(SessionSummary) bool { return true }))
+	require.NoFileExists(t, tmp)
+
+	key1 := oidcclient.SessionCacheKey{Issuer: "test-issuer", ClientID: "test-client-id", Scopes: []string{"openid"}}
+	key2 := oidcclient.SessionCacheKey{Issuer: "other-issuer", ClientID: "test-client-id", Scopes: []string{"openid"}}
+	key3 := oidcclient.SessionCacheKey{Issuer: "test-issuer", ClientID: "other-client-id", Scopes: []string{"openid"}}
+	for _, key := range []oidcclient.SessionCacheKey{key1, key2, key3} {
+		c.PutToken(key, &oidctypes.Token{
+			IDToken: &oidctypes.IDToken{Token: "test-id-token", Expiry: metav1.NewTime(now.Add(1 * time.Hour))},
+		})
+	}
+
+	require.Equal(t, 2, c.DeleteSessions(func(s SessionSummary) bool { return s.Key.Issuer == "test-issuer" }))
+	require.Nil(t, c.GetToken(key1))
+	require.NotNil(t, c.GetToken(key2))
+	require.Nil(t, c.GetToken(key3))
+
+	summaries, err := ReadSessionSummaries(tmp)
+	require.NoError(t, err)
+	require.Len(t, summaries, 1)
+	require.Equal(t, 1, c.DeleteSessions(func(s SessionSummary) bool { return s.ID == summaries[0].ID }))
+	require.Nil(t, c.GetToken(key2))
+}
+
+func TestEncryption(t *testing.T) {
+	t.Parallel()
+	now := time.Now().Round(1 * time.Second)
+	tmp := filepath.Join(t.TempDir(), "sessions.yaml")
+	encryption, err := cacheencryption.NewAESGCM(bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+
+	key := oidcclient.SessionCacheKey{Issuer: "test-issuer", ClientID: "test-client-id", Scopes: []string{"openid"}}
+	token := &oidctypes.Token{
+		IDToken: &oidctypes.IDToken{Token: "test-id-token", Expiry: metav1.NewTime(now.Add(1 * time.Hour))},
+	}
+	c := New(tmp, WithEncryption(encryption))
+	c.PutToken(key, token)
+	require.Equal(t, token, c.GetToken(key))
+
+	contents, err := os.ReadFile(tmp)
+	require.NoError(t, err)
+	require.NotContains(t, string(contents), "test-id-token")
+	require.NotContains(t, string(contents), "SessionCache")
+
+	summaries, err := ReadSessionSummaries(tmp, WithEncryption(encryption))
+	require.NoError(t, err)
+	require.Len(t, summaries, 1)
+
+	_, err = ReadSessionSummaries(tmp)
+	require.ErrorContains(t, err, "invalid session file: ")
+
+	// A cache without the key can not read the encrypted file, so it starts over.
+	var errs []error
+	plain := New(tmp, WithErrorReporter(func(err error) { errs = append(errs, err) }))
+	require.Nil(t, plain.GetToken(key))
+	require.NotEmpty(t, errs)
+}
@@ -0,0 +1,148 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package idpdiscovery is a client for the IDP discovery document of the Pinniped Supervisor, which lists the
+// upstream identity providers of a FederationDomain along with the client flows that each of them supports.
+package idpdiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	coreosoidc "github.com/coreos/go-oidc/v3/oidc"
+
+	idpdiscoveryv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idpdiscovery/v1alpha1"
+	"go.pinniped.dev/internal/constable"
+)
+
+// ErrNotSupervisor is returned by Client.Discover when the issuer does not advertise an IDP discovery document,
+// which means that it is not a Pinniped Supervisor.
+const ErrNotSupervisor = constable.Error("issuer is not a Pinniped Supervisor")
+
+// Option is an optional configuration for New().
+type Option func(*Client)
+
+// WithHTTPClient configures the HTTP client used to fetch the discovery documents. By default, http.DefaultClient
+// is used, so this is needed to trust a private certificate authority.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// Client fetches IDP discovery documents.
+type Client struct {
+	httpClient *http.Client
+}
+
+// New returns a newly initialized *Client.
+func New(opts ...Option) *Client {
+	c := Client{httpClient: http.DefaultClient}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return &c
+}
+
+// Discover performs OIDC discovery for the issuer, and then fetches the IDP discovery document which it advertises.
+// It returns ErrNotSupervisor when the issuer does not advertise one.
+func (c *Client) Discover(ctx context.Context, issuer string) ([]idpdiscoveryv1alpha1.PinnipedIDP, error) {
+	provider, err := coreosoidc.NewProvider(coreosoidc.ClientContext(ctx, c.httpClient), issuer)
+	if err != nil {
+		return nil, fmt.Errorf("while fetching OIDC discovery data from issuer: %w", err)
+	}
+
+	var claims idpdiscoveryv1alpha1.OIDCDiscoveryResponse
+	if err := provider.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("while fetching OIDC discovery data from issuer: %w", err)
+	}
+	endpoint := claims.SupervisorDiscovery.PinnipedIDPsEndpoint
+	if endpoint == "" {
+		return nil, ErrNotSupervisor
+	}
+	// The Supervisor always hosts the IDP discovery document below its issuer, so do not follow a
+	// discovery response which points somewhere else.
+	if !strings.HasPrefix(endpoint, issuer) {
+		return nil, fmt.Errorf("the Pinniped IDP discovery document must always be hosted by the issuer: %q", issuer)
+	}
+
+	return c.IdentityProviders(ctx, endpoint)
+}
+
+// IdentityProviders fetches the IDP discovery document from its endpoint, which is advertised by the
+// Supervisor's OIDC discovery document, and returns the upstream identity providers which it lists.
+func (c *Client) IdentityProviders(ctx context.Context, endpoint string) ([]idpdiscoveryv1alpha1.PinnipedIDP, error) {
+	var body idpdiscoveryv1alpha1.IDPDiscoveryResponse
+	if err := c.get(ctx, endpoint, "IDP", &body); err != nil {
+		return nil, err
+	}
+	return body.PinnipedIDPs, nil
+}
+
+// ClusterAudiences fetches the cluster audience discovery document from its endpoint, which is advertised by the
+// Supervisor's OIDC discovery document, and returns the ClusterAudiences which it lists.
+func (c *Client) ClusterAudiences(ctx context.Context, endpoint string) ([]idpdiscoveryv1alpha1.PinnipedClusterAudience, error) {
+	var body idpdiscoveryv1alpha1.ClusterAudiencesDiscoveryResponse
+	if err := c.get(ctx, endpoint, "cluster audience", &body); err != nil {
+		return nil, err
+	}
+	return body.PinnipedClusterAudiences, nil
+}
+
+// get fetches the JSON document from the endpoint into body. The kind of the document is used in error messages.
+func (c *Client) get(ctx context.Context, endpoint string, kind string, body any) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("while forming request to %s discovery URL: %w", kind, err)
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("unable to fetch %s discovery data from issuer: %w", kind, err)
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to fetch %s discovery data from issuer: unexpected http response status: %s", kind, response.Status)
+	}
+
+	rawBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("unable to fetch %s discovery data from issuer: could not read response body: %w", kind, err)
+	}
+
+	if err := json.Unmarshal(rawBody, body); err != nil {
+		return fmt.Errorf("unable to fetch %s discovery data from issuer: could not parse response JSON: %w", kind, err)
+	}
+	return nil
+}
+
+// Find returns the identity providers which have the given name and type. An empty name or type matches any.
+func Find(idps []idpdiscoveryv1alpha1.PinnipedIDP, name, idpType string) []idpdiscoveryv1alpha1.PinnipedIDP {
+	var found []idpdiscoveryv1alpha1.PinnipedIDP
+	for _, idp := range idps {
+		if (name == "" || idp.Name == name) && (idpType == "" || idp.Type.Equals(idpType)) {
+			found = append(found, idp)
+		}
+	}
+	return found
+}
+
+// SupportsFlow returns whether the identity provider supports the client flow. Supervisors from before client
+// flows were advertised do not list any, in which case this returns true.
+func SupportsFlow(idp idpdiscoveryv1alpha1.PinnipedIDP, flow string) bool {
+	if len(idp.Flows) == 0 {
+		return true
+	}
+	for _, f := range idp.Flows {
+		if f.Equals(flow) {
+			return true
+		}
+	}
+	return false
+}
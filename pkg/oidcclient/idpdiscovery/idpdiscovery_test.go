@@ -0,0 +1,171 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package idpdiscovery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	idpdiscoveryv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idpdiscovery/v1alpha1"
+)
+
+func TestDiscover(t *testing.T) {
+	const idpsPath = "/path/v1alpha1/pinniped_identity_providers"
+
+	tests := []struct {
+		name              string
+		idpsEndpoint      func(issuer string) string
+		idpsStatus        int
+		idpsResponse      string
+		want              []idpdiscoveryv1alpha1.PinnipedIDP
+		wantErr           func(issuer string) string
+		wantNotSupervisor bool
+	}{
+		{
+			name:         "success",
+			idpsEndpoint: func(issuer string) string { return issuer + "/v1alpha1/pinniped_identity_providers" },
+			idpsResponse: `{"pinniped_identity_providers": [
+				{"name": "some-ldap-idp", "type": "ldap", "flows": ["cli_password", "browser_authcode"]},
+				{"name": "some-oidc-idp", "type": "oidc"}
+			]}`,
+			want: []idpdiscoveryv1alpha1.PinnipedIDP{
+				{
+					Name:  "some-ldap-idp",
+					Type:  idpdiscoveryv1alpha1.IDPTypeLDAP,
+					Flows: []idpdiscoveryv1alpha1.IDPFlow{idpdiscoveryv1alpha1.IDPFlowCLIPassword, idpdiscoveryv1alpha1.IDPFlowBrowserAuthcode},
+				},
+				{Name: "some-oidc-idp", Type: idpdiscoveryv1alpha1.IDPTypeOIDC},
+			},
+		},
+		{
+			name:              "not a Supervisor",
+			idpsEndpoint:      func(string) string { return "" },
+			wantNotSupervisor: true,
+		},
+		{
+			name:         "IDP discovery document is not hosted by the issuer",
+			idpsEndpoint: func(string) string { return "https://other.example.com/v1alpha1/pinniped_identity_providers" },
+			wantErr: func(issuer string) string {
+				return fmt.Sprintf("the Pinniped IDP discovery document must always be hosted by the issuer: %q", issuer)
+			},
+		},
+		{
+			name:         "error status",
+			idpsEndpoint: func(issuer string) string { return issuer + "/v1alpha1/pinniped_identity_providers" },
+			idpsStatus:   http.StatusBadRequest,
+			wantErr: func(string) string {
+				return "unable to fetch IDP discovery data from issuer: unexpected http response status: 400 Bad Request"
+			},
+		},
+		{
+			name:         "invalid JSON",
+			idpsEndpoint: func(issuer string) string { return issuer + "/v1alpha1/pinniped_identity_providers" },
+			idpsResponse: "not JSON",
+			wantErr: func(string) string {
+				return "unable to fetch IDP discovery data from issuer: could not parse response JSON: invalid character 'o' in literal null (expecting 'u')"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var issuer string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/path/.well-known/openid-configuration":
+					w.Header().Set("Content-Type", "application/json")
+					_, _ = fmt.Fprintf(w, `{"issuer": %q, "discovery.supervisor.pinniped.dev/v1alpha1": {"pinniped_identity_providers_endpoint": %q}}`,
+						issuer, tt.idpsEndpoint(issuer))
+				case idpsPath:
+					if tt.idpsStatus != 0 {
+						w.WriteHeader(tt.idpsStatus)
+						return
+					}
+					_, _ = w.Write([]byte(tt.idpsResponse))
+				default:
+					t.Fatalf("unexpected request to %s", r.URL.Path)
+				}
+			}))
+			t.Cleanup(server.Close)
+			issuer = server.URL + "/path"
+
+			got, err := New(WithHTTPClient(server.Client())).Discover(context.Background(), issuer)
+			switch {
+			case tt.wantNotSupervisor:
+				require.ErrorIs(t, err, ErrNotSupervisor)
+			case tt.wantErr != nil:
+				require.EqualError(t, err, tt.wantErr(issuer))
+			default:
+				require.NoError(t, err)
+				require.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDiscoverOIDCDiscoveryError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+
+	_, err := New(WithHTTPClient(server.Client())).Discover(context.Background(), server.URL)
+	require.ErrorContains(t, err, "while fetching OIDC discovery data from issuer: 404 Not Found")
+}
+
+func TestClusterAudiences(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1alpha1/pinniped_cluster_audiences":
+			_, _ = w.Write([]byte(`{"pinniped_cluster_audiences": [
+				{"name": "cluster1", "audience": "audience1", "server": "https://cluster1.example.com", "certificate_authority_data": "some-ca-data"},
+				{"name": "cluster2", "audience": "audience2"}
+			]}`))
+		case "/invalid-json":
+			_, _ = w.Write([]byte("not JSON"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	client := New(WithHTTPClient(server.Client()))
+
+	got, err := client.ClusterAudiences(context.Background(), server.URL+"/v1alpha1/pinniped_cluster_audiences")
+	require.NoError(t, err)
+	require.Equal(t, []idpdiscoveryv1alpha1.PinnipedClusterAudience{
+		{Name: "cluster1", Audience: "audience1", Server: "https://cluster1.example.com", CertificateAuthorityData: "some-ca-data"},
+		{Name: "cluster2", Audience: "audience2"},
+	}, got)
+
+	_, err = client.ClusterAudiences(context.Background(), server.URL+"/not-found")
+	require.EqualError(t, err, "unable to fetch cluster audience discovery data from issuer: unexpected http response status: 404 Not Found")
+
+	_, err = client.ClusterAudiences(context.Background(), server.URL+"/invalid-json")
+	require.EqualError(t, err, "unable to fetch cluster audience discovery data from issuer: could not parse response JSON: invalid character 'o' in literal null (expecting 'u')")
+}
+
+func TestFind(t *testing.T) {
+	idps := []idpdiscoveryv1alpha1.PinnipedIDP{
+		{Name: "some-ldap-idp", Type: idpdiscoveryv1alpha1.IDPTypeLDAP},
+		{Name: "some-oidc-idp", Type: idpdiscoveryv1alpha1.IDPTypeOIDC},
+		{Name: "some-idp", Type: idpdiscoveryv1alpha1.IDPTypeOIDC},
+		{Name: "some-idp", Type: idpdiscoveryv1alpha1.IDPTypeGitHub},
+	}
+
+	require.Equal(t, idps, Find(idps, "", ""))
+	require.Equal(t, idps[1:3], Find(idps, "", "oidc"))
+	require.Equal(t, idps[2:4], Find(idps, "some-idp", ""))
+	require.Equal(t, idps[3:4], Find(idps, "some-idp", "github"))
+	require.Empty(t, Find(idps, "some-idp", "ldap"))
+}
+
+func TestSupportsFlow(t *testing.T) {
+	require.True(t, SupportsFlow(idpdiscoveryv1alpha1.PinnipedIDP{}, "cli_password"))
+
+	idp := idpdiscoveryv1alpha1.PinnipedIDP{Flows: []idpdiscoveryv1alpha1.IDPFlow{idpdiscoveryv1alpha1.IDPFlowBrowserAuthcode}}
+	require.True(t, SupportsFlow(idp, "browser_authcode"))
+	require.False(t, SupportsFlow(idp, "cli_password"))
+}
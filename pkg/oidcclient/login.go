@@ -7,6 +7,7 @@ package oidcclient
 import (
 	"bufio"
 	"context"
+	"crypto/ecdsa"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -26,14 +27,17 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/pkg/browser"
 	"golang.org/x/oauth2"
+	"golang.org/x/sync/errgroup"
 	"golang.org/x/term"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	idpdiscoveryv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/idpdiscovery/v1alpha1"
 	oidcapi "go.pinniped.dev/generated/latest/apis/supervisor/oidc"
 	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
 	"go.pinniped.dev/internal/httputil/httperr"
 	"go.pinniped.dev/internal/httputil/securityheader"
+	"go.pinniped.dev/internal/i18n"
 	"go.pinniped.dev/internal/net/phttp"
 	"go.pinniped.dev/internal/plog"
 	"go.pinniped.dev/internal/upstreamoidc"
@@ -63,8 +67,9 @@ const (
 	// we set this to be relatively long.
 	overallTimeout = 90 * time.Minute
 
-	usernamePrompt = "Username: "
-	passwordPrompt = "Password: "
+	// maxConcurrentTokenExchanges limits the number of RFC8693 token exchanges which LoginAndExchangeForAudiences
+	// performs at the same time, to avoid overwhelming the Supervisor when there are many audiences.
+	maxConcurrentTokenExchanges = 10
 
 	// For CLI-based auth, such as with LDAP upstream identity providers, the user may use these environment variables
 	// to avoid getting interactively prompted for username and password.
@@ -74,6 +79,27 @@ const (
 	httpLocationHeaderName = "Location"
 )
 
+// disallowedCustomAuthorizeParameters are the parameters which WithCustomAuthorizeParameters cannot set, because
+// Login() always sets them itself, or because they would replace the parameters of the whole authorization request.
+// This map should be treated as read-only since it is a global variable.
+var disallowedCustomAuthorizeParameters = map[string]bool{ //nolint:gochecknoglobals
+	"response_type":         true,
+	"response_mode":         true,
+	"scope":                 true,
+	"client_id":             true,
+	"state":                 true,
+	"nonce":                 true,
+	"code_challenge":        true,
+	"code_challenge_method": true,
+	"redirect_uri":          true,
+	"access_type":           true,
+	"request":               true,
+	"request_uri":           true,
+
+	oidcapi.AuthorizeUpstreamIDPNameParamName: true,
+	oidcapi.AuthorizeUpstreamIDPTypeParamName: true,
+}
+
 // stdin returns the file descriptor for stdin as an int.
 func stdin() int { return int(os.Stdin.Fd()) }
 
@@ -87,17 +113,31 @@ type handlerState struct {
 	cache    SessionCache
 	out      io.Writer // this is stderr except in unit tests
 
+	// The language of the prompts which are printed to out.
+	localizer *i18n.Localizer
+
 	loggerOptionsCount int
 
 	// Tracking the usage of some other functional options.
 	upstreamIdentityProviderName string
 	upstreamIdentityProviderType idpdiscoveryv1alpha1.IDPType
 	cliToSendCredentials         bool
+	username                     string
+	password                     string
 	loginFlow                    idpdiscoveryv1alpha1.IDPFlow
 	skipBrowser                  bool
 	skipPrintLoginURL            bool
+	refreshOnly                  bool
+	discoveryTimeout             time.Duration
+	callbackTimeout              time.Duration
+	tokenExchangeTimeout         time.Duration
+	refreshTimeout               time.Duration
 	requestedAudience            string
+	exchangeAudiences            []string
+	customAuthorizeParameters    map[string]string
 	httpClient                   *http.Client
+	useDPoP                      bool
+	clientAssertionKey           *ecdsa.PrivateKey
 
 	// Parameters of the localhost listener.
 	listenAddr   string
@@ -108,9 +148,15 @@ type handlerState struct {
 	idpDiscovery *idpdiscoveryv1alpha1.IDPDiscoveryResponse
 	oauth2Config *oauth2.Config
 	useFormPost  bool
+	parURL       string
 	state        state.State
 	nonce        nonce.Nonce
 	pkce         pkce.Code
+	dpopKey      *ecdsa.PrivateKey
+
+	// The new issuer announced by the discovery of a Supervisor's previous issuer, which is followed only once.
+	issuerMigratedTo        string
+	followedIssuerMigration bool
 
 	// External calls for things.
 	generateState   func() (state.State, error)
@@ -279,6 +325,85 @@ func WithClient(httpClient *http.Client) Option {
 	}
 }
 
+// WithRefreshOnly causes the login to only use the cached session, refreshing it when needed. When there is no
+// cached session, or when the cached session cannot be refreshed, the login fails with a *RefreshRequiredError
+// instead of starting an interactive login using a web browser or the terminal. This is useful for automation
+// which cannot interact with a user.
+func WithRefreshOnly() Option {
+	return func(h *handlerState) error {
+		h.refreshOnly = true
+		return nil
+	}
+}
+
+// WithDiscoveryTimeout sets the maximum time allowed for the OIDC discovery and Pinniped IDP discovery requests
+// to the issuer. When not used, this defaults to one minute.
+func WithDiscoveryTimeout(timeout time.Duration) Option {
+	return func(h *handlerState) error {
+		if timeout <= 0 {
+			return fmt.Errorf("WithDiscoveryTimeout error: timeout must be positive, but was %s", timeout)
+		}
+		h.discoveryTimeout = timeout
+		return nil
+	}
+}
+
+// WithCallbackTimeout sets the maximum time allowed for the user to finish logging in with their web browser,
+// i.e. the time spent waiting for the callback to the localhost listener or for a pasted authorization code.
+// When not used, the wait is only limited by the overall timeout of the login.
+func WithCallbackTimeout(timeout time.Duration) Option {
+	return func(h *handlerState) error {
+		if timeout <= 0 {
+			return fmt.Errorf("WithCallbackTimeout error: timeout must be positive, but was %s", timeout)
+		}
+		h.callbackTimeout = timeout
+		return nil
+	}
+}
+
+// WithTokenExchangeTimeout sets the maximum time allowed for each RFC8693 token exchange, including the validation
+// of the resulting ID token. When not used, this defaults to one minute.
+func WithTokenExchangeTimeout(timeout time.Duration) Option {
+	return func(h *handlerState) error {
+		if timeout <= 0 {
+			return fmt.Errorf("WithTokenExchangeTimeout error: timeout must be positive, but was %s", timeout)
+		}
+		h.tokenExchangeTimeout = timeout
+		return nil
+	}
+}
+
+// WithRefreshTimeout sets the maximum time allowed for refreshing the cached session, including the validation
+// of the refreshed tokens. When the refresh times out, it is treated like any other failed refresh.
+// When not used, this defaults to one minute.
+func WithRefreshTimeout(timeout time.Duration) Option {
+	return func(h *handlerState) error {
+		if timeout <= 0 {
+			return fmt.Errorf("WithRefreshTimeout error: timeout must be positive, but was %s", timeout)
+		}
+		h.refreshTimeout = timeout
+		return nil
+	}
+}
+
+// RefreshRequiredError is returned by Login when WithRefreshOnly was used and the login would have required
+// user interaction.
+type RefreshRequiredError struct {
+	// Reason describes why the cached session could not be used.
+	Reason string
+
+	// Err is ErrRefreshRejected when the cached session could not be refreshed, or nil when there was no cached session.
+	Err error
+}
+
+func (e *RefreshRequiredError) Error() string {
+	return fmt.Sprintf("interactive login required but not allowed because refresh only mode was requested: %s", e.Reason)
+}
+
+func (e *RefreshRequiredError) Unwrap() error {
+	return e.Err
+}
+
 // WithRequestAudience causes the login flow to perform an additional token exchange using the RFC8693 flow.
 func WithRequestAudience(audience string) Option {
 	return func(h *handlerState) error {
@@ -304,6 +429,32 @@ func WithCLISendingCredentials() Option {
 	}
 }
 
+// WithUsernameAndPassword provides the username and password to send during the CLI-based login flow, instead of reading
+// them from the PINNIPED_USERNAME and PINNIPED_PASSWORD environment variables or interactively prompting for them.
+// This is intended for non-interactive API clients, such as automation which logs in using a service account of
+// an LDAPIdentityProvider or ActiveDirectoryIdentityProvider. It only has an effect when the CLI-based login flow
+// is used. See the WithLoginFlow() option.
+func WithUsernameAndPassword(username, password string) Option {
+	return func(h *handlerState) error {
+		if username == "" || password == "" {
+			return fmt.Errorf("WithUsernameAndPassword error: username and password must not be empty")
+		}
+		h.username = username
+		h.password = password
+		return nil
+	}
+}
+
+// WithLanguage causes the interactive prompts of the login flow to be printed in the requested language, when it
+// is supported. The argument is a BCP 47 language tag, e.g. "de" or "es-MX". When not used, or when the language is
+// not supported, the prompts are printed in English.
+func WithLanguage(lang string) Option {
+	return func(h *handlerState) error {
+		h.localizer = i18n.ForLanguage(lang)
+		return nil
+	}
+}
+
 // WithLoginFlow chooses the login flow.
 // When the argument is equal to idpdiscoveryv1alpha1.IDPFlowCLIPassword, it causes the login flow to use CLI-based
 // prompts for username and password and causes the call to the Issuer's authorize endpoint to be made directly (no web
@@ -336,6 +487,27 @@ func WithLoginFlow(loginFlow idpdiscoveryv1alpha1.IDPFlow, flowSource string) Op
 	}
 }
 
+// WithCustomAuthorizeParameters causes the specified parameters to be added to the authorization request of an
+// interactive login, e.g. "prompt=login" to force the user to authenticate again, or an identity provider specific
+// hint such as "kc_idp_hint". The parameters which are always set by Login(), such as "scope" and "redirect_uri",
+// cannot be overridden, so specifying one of them is an error. This option may be used more than once.
+// Note that a Pinniped Supervisor only passes these parameters to its upstream identity provider when the
+// OIDCIdentityProvider allows them.
+func WithCustomAuthorizeParameters(params map[string]string) Option {
+	return func(h *handlerState) error {
+		for name, value := range params {
+			if disallowedCustomAuthorizeParameters[name] {
+				return fmt.Errorf("WithCustomAuthorizeParameters error: parameter %q cannot be overridden", name)
+			}
+			if h.customAuthorizeParameters == nil {
+				h.customAuthorizeParameters = map[string]string{}
+			}
+			h.customAuthorizeParameters[name] = value
+		}
+		return nil
+	}
+}
+
 // WithUpstreamIdentityProvider causes the specified name and type to be sent as custom query parameters to the
 // issuer's authorize endpoint. This is only intended to be used when the issuer is a Pinniped Supervisor, in which
 // case it provides a mechanism to choose among several upstream identity providers.
@@ -359,6 +531,97 @@ func (*nopCache) PutToken(SessionCacheKey, *oidctypes.Token) {}
 
 // Login performs an OAuth2/OIDC authorization code login using a localhost listener.
 func Login(issuer string, clientID string, opts ...Option) (*oidctypes.Token, error) {
+	h, cancel, err := newHandlerState(issuer, clientID, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	// Do the basic login to get an access and ID token issued to our main client ID.
+	token, err := h.baseLogin()
+	if err != nil {
+		return nil, err
+	}
+
+	// Perform the RFC8693 token exchange, if needed. Note that the new ID token returned by this exchange
+	// does not need to be cached because the new ID token is intended to be a very short-lived token.
+	if h.needRFC8693TokenExchange(token, h.requestedAudience) {
+		token, err = h.tokenExchangeRFC8693(token, h.requestedAudience)
+		if err != nil {
+			return nil, classify(fmt.Errorf("failed to exchange token: %w", err), ErrTokenExchangeFailed)
+		}
+	}
+
+	return token, nil
+}
+
+// LoginAndExchangeForAudiences performs a single OAuth2/OIDC login, exactly like Login, and then performs one
+// RFC8693 token exchange per audience concurrently. It returns the ID token for each audience in a map keyed by
+// audience. This is more efficient than calling Login once per audience when a client needs tokens for many
+// clusters. It fails if any of the token exchanges fail. The WithRequestAudience option cannot be used with this func.
+func LoginAndExchangeForAudiences(issuer string, clientID string, audiences []string, opts ...Option) (map[string]*oidctypes.Token, error) {
+	if len(audiences) == 0 {
+		return nil, classify(fmt.Errorf("at least one audience is required"), ErrInvalidOption)
+	}
+	for _, audience := range audiences {
+		if audience == "" {
+			return nil, classify(fmt.Errorf("audiences must not be empty"), ErrInvalidOption)
+		}
+	}
+
+	h, cancel, err := newHandlerState(issuer, clientID, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	if h.requestedAudience != "" {
+		return nil, classify(fmt.Errorf("do not use option WithRequestAudience when exchanging tokens for multiple audiences"), ErrInvalidOption)
+	}
+	h.exchangeAudiences = sets.List(sets.New(audiences...))
+
+	// Do the basic login to get an access and ID token issued to our main client ID.
+	token, err := h.baseLogin()
+	if err != nil {
+		return nil, err
+	}
+
+	// Perform OIDC discovery before starting the exchanges, so they can share the results without racing.
+	// This may have already been performed if there was not a cached base token.
+	if err := h.initOIDCDiscovery(); err != nil {
+		return nil, classify(err, ErrDiscoveryFailed)
+	}
+
+	var mu sync.Mutex
+	tokens := make(map[string]*oidctypes.Token, len(audiences))
+	eg := errgroup.Group{}
+	eg.SetLimit(maxConcurrentTokenExchanges)
+	for _, audience := range h.exchangeAudiences {
+		eg.Go(func() error {
+			exchangedToken := token
+			if h.needRFC8693TokenExchange(token, audience) {
+				var err error
+				exchangedToken, err = h.tokenExchangeRFC8693(token, audience)
+				if err != nil {
+					return classify(fmt.Errorf("failed to exchange token for audience %q: %w", audience, err), ErrTokenExchangeFailed)
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			tokens[audience] = exchangedToken
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// newHandlerState applies the options and initializes the login parameters which are shared by Login and
+// LoginAndExchangeForAudiences. The returned func must be called to release the context of the login.
+func newHandlerState(issuer string, clientID string, opts []Option) (*handlerState, context.CancelFunc, error) {
 	h := handlerState{
 		issuer:       issuer,
 		clientID:     clientID,
@@ -371,6 +634,12 @@ func Login(issuer string, clientID string, opts ...Option) (*oidctypes.Token, er
 		callbacks:    make(chan callbackResult, 2),
 		httpClient:   phttp.Default(nil),
 
+		// Default timeouts of each phase of the login.
+		discoveryTimeout:     httpRequestTimeout,
+		callbackTimeout:      overallTimeout,
+		tokenExchangeTimeout: httpRequestTimeout,
+		refreshTimeout:       httpRequestTimeout,
+
 		// Default implementations of external dependencies (to be mocked in tests).
 		generateState: state.Generate,
 		generateNonce: nonce.Generate,
@@ -386,32 +655,39 @@ func Login(issuer string, clientID string, opts ...Option) (*oidctypes.Token, er
 		promptForValue:  promptForValue,
 		promptForSecret: promptForSecret,
 		out:             os.Stderr,
+		localizer:       i18n.Default(),
 	}
 	for _, opt := range opts {
 		if err := opt(&h); err != nil {
-			return nil, err
+			return nil, nil, classify(err, ErrInvalidOption)
 		}
 	}
 
 	if h.cliToSendCredentials {
 		if h.loginFlow != "" {
-			return nil, fmt.Errorf("do not use deprecated option WithCLISendingCredentials when using option WithLoginFlow")
+			return nil, nil, classify(fmt.Errorf("do not use deprecated option WithCLISendingCredentials when using option WithLoginFlow"), ErrInvalidOption)
 		}
 		h.loginFlow = idpdiscoveryv1alpha1.IDPFlowCLIPassword
 	}
 
 	if h.loggerOptionsCount > 1 {
-		return nil, fmt.Errorf("please use only one mechanism to specify the logger")
+		return nil, nil, classify(fmt.Errorf("please use only one mechanism to specify the logger"), ErrInvalidOption)
 	}
 
 	// Copy the configured HTTP client to set a request timeout (the Go default client has no timeout configured).
+	// Its transport adds DPoP proofs to the requests to the token endpoint, once the login has a DPoP key, and adds
+	// client assertions to the requests to the token and PAR endpoints, when the login has a client assertion key.
 	httpClientWithTimeout := *h.httpClient
 	httpClientWithTimeout.Timeout = httpRequestTimeout
+	baseTransport := httpClientWithTimeout.Transport
+	if baseTransport == nil {
+		baseTransport = http.DefaultTransport
+	}
+	httpClientWithTimeout.Transport = &dpopRoundTripper{base: &clientAssertionRoundTripper{base: baseTransport, h: &h}, h: &h}
 	h.httpClient = &httpClientWithTimeout
 
 	// Always set a long, but non-infinite timeout for this operation.
 	ctx, cancel := context.WithTimeout(h.ctx, overallTimeout)
-	defer cancel()
 	ctx = coreosoidc.ClientContext(ctx, h.httpClient)
 	h.ctx = ctx
 
@@ -419,42 +695,44 @@ func Login(issuer string, clientID string, opts ...Option) (*oidctypes.Token, er
 	var err error
 	h.state, err = h.generateState()
 	if err != nil {
-		return nil, err
+		cancel()
+		return nil, nil, err
 	}
 	h.nonce, err = h.generateNonce()
 	if err != nil {
-		return nil, err
+		cancel()
+		return nil, nil, err
 	}
 	h.pkce, err = h.generatePKCE()
 	if err != nil {
-		return nil, err
-	}
-
-	// Do the basic login to get an access and ID token issued to our main client ID.
-	token, err := h.baseLogin()
-	if err != nil {
-		return nil, err
+		cancel()
+		return nil, nil, err
 	}
-
-	// Perform the RFC8693 token exchange, if needed. Note that the new ID token returned by this exchange
-	// does not need to be cached because the new ID token is intended to be a very short-lived token.
-	if h.needRFC8693TokenExchange(token) {
-		token, err = h.tokenExchangeRFC8693(token)
+	if h.useDPoP {
+		h.dpopKey, err = generateDPoPKey()
 		if err != nil {
-			return nil, fmt.Errorf("failed to exchange token: %w", err)
+			cancel()
+			return nil, nil, err
 		}
 	}
 
-	return token, nil
+	return &h, cancel, nil
 }
 
-func (h *handlerState) needRFC8693TokenExchange(token *oidctypes.Token) bool {
+func (h *handlerState) needRFC8693TokenExchange(token *oidctypes.Token, requestedAudience string) bool {
 	// Need a new ID token if there is a requested audience value and any of the following are true...
-	return h.requestedAudience != "" &&
+	return requestedAudience != "" &&
 		// we don't have an ID token (maybe it expired or was otherwise removed from the session cache)
 		(token.IDToken == nil ||
 			// or, our current ID token has a different audience
-			h.requestedAudience != token.IDToken.Claims["aud"])
+			requestedAudience != token.IDToken.Claims["aud"])
+}
+
+func (h *handlerState) needAnyRFC8693TokenExchange(token *oidctypes.Token) bool {
+	return h.needRFC8693TokenExchange(token, h.requestedAudience) ||
+		slices.ContainsFunc(h.exchangeAudiences, func(audience string) bool {
+			return h.needRFC8693TokenExchange(token, audience)
+		})
 }
 
 func (h *handlerState) tokenValidForNearFuture(token *oidctypes.Token) (bool, string) {
@@ -464,7 +742,7 @@ func (h *handlerState) tokenValidForNearFuture(token *oidctypes.Token) (bool, st
 	// If we plan to do an RFC8693 token exchange, then we need an access token that will still be valid when we do the
 	// exchange (which will happen momentarily). Otherwise, we need an ID token that will be valid for a little while
 	// (long enough for multistep k8s API operations).
-	if h.needRFC8693TokenExchange(token) {
+	if h.needAnyRFC8693TokenExchange(token) {
 		return !accessTokenExpiredOrCloseToExpiring(token.AccessToken), "access_token"
 	}
 	return !idTokenExpiredOrCloseToExpiring(token.IDToken), "id_token"
@@ -495,6 +773,17 @@ func (h *handlerState) baseLogin() (*oidctypes.Token, error) {
 	// If the cached tokens include the token type that we need, and that token is still valid for a bit,
 	// return the cached tokens immediately and skip the rest of the flow.
 	cached := h.cache.GetToken(cacheKey)
+
+	// When the cached tokens are bound to a DPoP key, then they can only be refreshed or exchanged using that key.
+	if cached != nil && cached.DPoPKey != nil {
+		dpopKey, err := parseDPoPKey(cached.DPoPKey)
+		if err != nil {
+			h.logger.Info("Pinniped: Ignoring invalid cached DPoP key.", "error", err.Error())
+		} else {
+			h.dpopKey = dpopKey
+		}
+	}
+
 	if valid, whichTokenWasValid := h.tokenValidForNearFuture(cached); valid {
 		h.logger.Info("Pinniped: Found unexpired cached token.", "type", whichTokenWasValid)
 		return cached, nil
@@ -502,20 +791,37 @@ func (h *handlerState) baseLogin() (*oidctypes.Token, error) {
 
 	// Perform OIDC discovery.
 	if err := h.initOIDCDiscovery(); err != nil {
-		return nil, err
+		return nil, classify(err, ErrDiscoveryFailed)
 	}
 
 	// If there was a cached refresh token, attempt to use the refresh flow instead of a fresh login.
+	refreshRequiredErr := &RefreshRequiredError{Reason: "no cached session found"}
 	if cached != nil && cached.RefreshToken != nil && cached.RefreshToken.Token != "" {
-		freshToken, err := h.handleRefresh(h.ctx, cached.RefreshToken)
+		refreshCtx, refreshCtxCancelFunc := context.WithTimeout(h.ctx, h.refreshTimeout)
+		freshToken, err := h.handleRefresh(refreshCtx, cached.RefreshToken)
+		refreshCtxCancelFunc()
 		if err != nil {
 			return nil, err
 		}
 		// If we got a fresh token, update the cache and return it. Otherwise, fall through to the full login flow.
 		if freshToken != nil {
+			if err := h.bindToDPoPKey(freshToken); err != nil {
+				return nil, err
+			}
 			h.cache.PutToken(cacheKey, freshToken)
 			return freshToken, nil
 		}
+		refreshRequiredErr = &RefreshRequiredError{Reason: "cached session could not be refreshed", Err: ErrRefreshRejected}
+	}
+
+	// When the issuer has moved, continue at the new issuer instead, where the session is cached using the new issuer.
+	if h.issuerMigratedTo != "" {
+		return h.followIssuerMigration()
+	}
+
+	// In refresh only mode, fail fast instead of starting an interactive login.
+	if h.refreshOnly {
+		return nil, refreshRequiredErr
 	}
 
 	// We couldn't refresh, so now we need to perform a fresh login attempt.
@@ -529,11 +835,15 @@ func (h *handlerState) baseLogin() (*oidctypes.Token, error) {
 
 	loginFlow, pinnipedSupervisorOptions, err := h.maybePerformPinnipedSupervisorValidations()
 	if err != nil {
-		return nil, err
+		return nil, classify(err, ErrUpstreamIDPNotFound)
 	}
 	h.loginFlow = loginFlow
 	authorizeOptions = slices.Concat(authorizeOptions, pinnipedSupervisorOptions)
 
+	for name, value := range h.customAuthorizeParameters {
+		authorizeOptions = append(authorizeOptions, oauth2.SetAuthURLParam(name, value))
+	}
+
 	// Preserve the legacy behavior where browser-based auth is preferred
 	authFunc := h.webBrowserBasedAuth
 
@@ -551,12 +861,28 @@ func (h *handlerState) baseLogin() (*oidctypes.Token, error) {
 
 	// If we got tokens, put them in the cache.
 	if err == nil {
+		if err := h.bindToDPoPKey(token); err != nil {
+			return nil, err
+		}
 		h.cache.PutToken(cacheKey, token)
 	}
 
 	return token, err
 }
 
+// followIssuerMigration restarts the login at the new issuer of a Pinniped Supervisor FederationDomain.
+// It is only done once, so a misconfigured chain of migrations cannot cause a loop.
+func (h *handlerState) followIssuerMigration() (*oidctypes.Token, error) {
+	h.logger.Info("Pinniped: The issuer has moved, so logging in at the new issuer.", "previousIssuer", h.issuer, "issuer", h.issuerMigratedTo)
+	h.issuer = h.issuerMigratedTo
+	h.issuerMigratedTo = ""
+	h.followedIssuerMigration = true
+	h.provider = nil
+	h.oauth2Config = nil
+	h.idpDiscovery = nil
+	return h.baseLogin()
+}
+
 // maybePerformPinnipedSupervisorValidations will return the flow and some authorization options.
 // When the IDP name is unset, it will assume that the server is not a Pinniped Supervisor, and will return immediately.
 // Otherwise, when the flow is unset, it will infer the flow from the server, or when the flow is set, it will return that flow unchanged.
@@ -665,7 +991,7 @@ func (h *handlerState) cliBasedAuth(authorizeOptions *[]oauth2.AuthCodeOption) (
 	// Ask the user for their username and password, or get them from env vars.
 	username, password, err := h.getUsernameAndPassword()
 	if err != nil {
-		return nil, err
+		return nil, classify(err, ErrPromptFailed)
 	}
 
 	// Make a callback URL even though we won't be listening on this port, because providing a redirect URL is
@@ -680,7 +1006,10 @@ func (h *handlerState) cliBasedAuth(authorizeOptions *[]oauth2.AuthCodeOption) (
 	}).String()
 
 	// Now that we have a redirect URL, we can build the authorize URL.
-	authorizeURL := h.oauth2Config.AuthCodeURL(h.state.String(), *authorizeOptions...)
+	authorizeURL, err := h.maybePushAuthorizationRequest(h.oauth2Config.AuthCodeURL(h.state.String(), *authorizeOptions...))
+	if err != nil {
+		return nil, classify(err, ErrAuthorizationFailed)
+	}
 
 	// Don't follow redirects automatically because we want to handle redirects here.
 	var sawRedirect bool
@@ -694,36 +1023,36 @@ func (h *handlerState) cliBasedAuth(authorizeOptions *[]oauth2.AuthCodeOption) (
 	defer authorizeCtxCancelFunc()
 	authReq, err := http.NewRequestWithContext(authCtx, http.MethodGet, authorizeURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("could not build authorize request: %w", err)
+		return nil, classify(fmt.Errorf("could not build authorize request: %w", err), ErrAuthorizationFailed)
 	}
 	authReq.Header.Set(oidcapi.AuthorizeUsernameHeaderName, username)
 	authReq.Header.Set(oidcapi.AuthorizePasswordHeaderName, password)
 	authRes, err := h.httpClient.Do(authReq)
 	if err != nil {
-		return nil, fmt.Errorf("authorization response error: %w", err)
+		return nil, classify(fmt.Errorf("authorization response error: %w", err), ErrAuthorizationFailed)
 	}
 	_ = authRes.Body.Close() // don't need the response body, and okay if it fails to close
 
 	// A successful authorization always results in a redirect (we are flexible on the exact status code).
 	if !sawRedirect {
-		return nil, fmt.Errorf(
-			"error getting authorization: expected to be redirected, but response status was %s", authRes.Status)
+		return nil, classify(fmt.Errorf(
+			"error getting authorization: expected to be redirected, but response status was %s", authRes.Status), ErrAuthorizationFailed)
 	}
 	rawLocation := authRes.Header.Get(httpLocationHeaderName)
 	location, err := url.Parse(rawLocation)
 	if err != nil {
 		// This shouldn't be possible in practice because httpClient.Do() already parses the Location header.
-		return nil, fmt.Errorf("error getting authorization: could not parse redirect location: %w", err)
+		return nil, classify(fmt.Errorf("error getting authorization: could not parse redirect location: %w", err), ErrAuthorizationFailed)
 	}
 
 	// Check that the redirect was to the expected location.
 	if location.Scheme != "http" || location.Host != localhostAddr || location.Path != h.callbackPath {
-		return nil, fmt.Errorf("error getting authorization: redirected to the wrong location: %s", rawLocation)
+		return nil, classify(fmt.Errorf("error getting authorization: redirected to the wrong location: %s", rawLocation), ErrAuthorizationFailed)
 	}
 
 	// Validate OAuth2 state and fail if it's incorrect (to block CSRF).
 	if err := h.state.Validate(location.Query().Get("state")); err != nil {
-		return nil, fmt.Errorf("missing or invalid state parameter in authorization response: %s", rawLocation)
+		return nil, classify(fmt.Errorf("missing or invalid state parameter in authorization response: %s", rawLocation), ErrAuthorizationFailed)
 	}
 
 	// Get the auth code or return the error from the server.
@@ -733,9 +1062,9 @@ func (h *handlerState) cliBasedAuth(authorizeOptions *[]oauth2.AuthCodeOption) (
 		requiredErrorCode := location.Query().Get("error")
 		optionalErrorDescription := location.Query().Get("error_description")
 		if optionalErrorDescription == "" {
-			return nil, fmt.Errorf("login failed with code %q", requiredErrorCode)
+			return nil, classify(fmt.Errorf("login failed with code %q", requiredErrorCode), ErrAuthorizationFailed)
 		}
-		return nil, fmt.Errorf("login failed with code %q: %s", requiredErrorCode, optionalErrorDescription)
+		return nil, classify(fmt.Errorf("login failed with code %q: %s", requiredErrorCode, optionalErrorDescription), ErrAuthorizationFailed)
 	}
 
 	// Exchange the authorization code for access, ID, and refresh tokens and perform required
@@ -744,7 +1073,7 @@ func (h *handlerState) cliBasedAuth(authorizeOptions *[]oauth2.AuthCodeOption) (
 	defer tokenCtxCancelFunc()
 	token, err := h.redeemAuthCode(tokenCtx, authCode)
 	if err != nil {
-		return nil, fmt.Errorf("could not complete authorization code exchange: %w", err)
+		return nil, classify(fmt.Errorf("could not complete authorization code exchange: %w", err), ErrAuthorizationFailed)
 	}
 
 	return token, nil
@@ -754,13 +1083,18 @@ func (h *handlerState) cliBasedAuth(authorizeOptions *[]oauth2.AuthCodeOption) (
 func (h *handlerState) getUsernameAndPassword() (string, string, error) {
 	var err error
 
+	if h.username != "" && h.password != "" {
+		// These were provided by the caller using an option, so there is nothing to read or prompt for.
+		return h.username, h.password, nil
+	}
+
 	if h.upstreamIdentityProviderName != "" {
-		_, _ = fmt.Fprintf(h.out, "\nLog in to %s\n\n", h.upstreamIdentityProviderName)
+		_, _ = fmt.Fprintf(h.out, "\n%s\n\n", h.localizer.T("cli.loginTo", h.upstreamIdentityProviderName))
 	}
 
 	username := h.getEnv(defaultUsernameEnvVarName)
 	if username == "" {
-		username, err = h.promptForValue(h.ctx, usernamePrompt, h.out)
+		username, err = h.promptForValue(h.ctx, h.localizer.T("cli.usernamePrompt"), h.out)
 		if err != nil {
 			return "", "", fmt.Errorf("error prompting for username: %w", err)
 		}
@@ -770,7 +1104,7 @@ func (h *handlerState) getUsernameAndPassword() (string, string, error) {
 
 	password := h.getEnv(defaultPasswordEnvVarName)
 	if password == "" {
-		password, err = h.promptForSecret(passwordPrompt, h.out)
+		password, err = h.promptForSecret(h.localizer.T("cli.passwordPrompt"), h.out)
 		if err != nil {
 			return "", "", fmt.Errorf("error prompting for password: %w", err)
 		}
@@ -793,7 +1127,7 @@ func (h *handlerState) webBrowserBasedAuth(authorizeOptions *[]oauth2.AuthCodeOp
 	// If the listener failed to start and stdin is not a TTY, then we have no hope of succeeding,
 	// since we won't be able to receive the web callback and we can't prompt for the manual auth code.
 	if listener == nil && !h.stdinIsTTY() {
-		return nil, fmt.Errorf("login failed: must have either a localhost listener or stdin must be a TTY")
+		return nil, classify(fmt.Errorf("login failed: must have either a localhost listener or stdin must be a TTY"), ErrPromptFailed)
 	}
 
 	// Update the OAuth2 redirect_uri to match the actual listener address (if there is one), or just use
@@ -813,7 +1147,10 @@ func (h *handlerState) webBrowserBasedAuth(authorizeOptions *[]oauth2.AuthCodeOp
 	}
 
 	// Now that we have a redirect URL with the listener port, we can build the authorize URL.
-	authorizeURL := h.oauth2Config.AuthCodeURL(h.state.String(), authParams...)
+	authorizeURL, err := h.maybePushAuthorizationRequest(h.oauth2Config.AuthCodeURL(h.state.String(), authParams...))
+	if err != nil {
+		return nil, classify(err, ErrAuthorizationFailed)
+	}
 
 	// If there is a listener running, start serving the callback handler in a background goroutine.
 	if listener != nil {
@@ -839,7 +1176,7 @@ func (h *handlerState) webBrowserBasedAuth(authorizeOptions *[]oauth2.AuthCodeOp
 	printAuthorizeURL := !openedBrowser || !h.skipPrintLoginURL
 
 	// Prompt the user to visit the authorize URL, and to paste a manually-copied auth code (if possible).
-	ctx, cancel := context.WithCancel(h.ctx)
+	ctx, cancel := context.WithTimeout(h.ctx, h.callbackTimeout)
 	cleanupPrompt := h.promptForWebLogin(ctx, authorizeURL, printAuthorizeURL)
 	defer func() {
 		cancel()
@@ -848,11 +1185,11 @@ func (h *handlerState) webBrowserBasedAuth(authorizeOptions *[]oauth2.AuthCodeOp
 
 	// Wait for either the web callback, a pasted auth code, or a timeout.
 	select {
-	case <-h.ctx.Done():
-		return nil, fmt.Errorf("timed out waiting for token callback: %w", h.ctx.Err())
+	case <-ctx.Done():
+		return nil, classify(fmt.Errorf("timed out waiting for token callback: %w", ctx.Err()), ErrCallbackTimeout)
 	case callback := <-h.callbacks:
 		if callback.err != nil {
-			return nil, fmt.Errorf("error handling callback: %w", callback.err)
+			return nil, classify(fmt.Errorf("error handling callback: %w", callback.err), ErrAuthorizationFailed)
 		}
 		return callback.token, nil
 	}
@@ -865,7 +1202,7 @@ func (h *handlerState) promptForWebLogin(ctx context.Context, authorizeURL strin
 	if !printAuthorizeURL {
 		return func() {}
 	}
-	_, _ = fmt.Fprintf(h.out, "Log in by visiting this link:\n\n    %s\n\n", authorizeURL)
+	_, _ = fmt.Fprintf(h.out, "%s\n\n    %s\n\n", h.localizer.T("cli.visitLink"), authorizeURL)
 
 	// If stdin is not a TTY, don't prompt for the manual paste, since we have no way of reading it.
 	if !h.stdinIsTTY() {
@@ -889,13 +1226,13 @@ func (h *handlerState) promptForWebLogin(ctx context.Context, authorizeURL strin
 
 			wg.Done()
 		}()
-		code, err := h.promptForValue(ctx, "    Optionally, paste your authorization code: ", h.out)
+		code, err := h.promptForValue(ctx, "    "+h.localizer.T("cli.pasteAuthCodePrompt"), h.out)
 		if err != nil {
 			// Print a visual marker to show the the prompt is no longer waiting for user input, plus a trailing
 			// newline that simulates the user having pressed "enter".
 			_, _ = fmt.Fprint(h.out, "[...]\n")
 
-			h.callbacks <- callbackResult{err: fmt.Errorf("failed to prompt for manual authorization code: %v", err)}
+			h.callbacks <- callbackResult{err: classify(fmt.Errorf("failed to prompt for manual authorization code: %v", err), ErrPromptFailed)}
 			return
 		}
 
@@ -922,7 +1259,8 @@ func promptForValue(ctx context.Context, promptLabel string, out io.Writer) (str
 		text string
 		err  error
 	}
-	readResults := make(chan readResult)
+	// Buffer the channel so the background goroutine can always finish sending, even after we stop listening.
+	readResults := make(chan readResult, 1)
 	go func() {
 		text, err := bufio.NewReader(os.Stdin).ReadString('\n')
 		readResults <- readResult{text, err}
@@ -930,9 +1268,7 @@ func promptForValue(ctx context.Context, promptLabel string, out io.Writer) (str
 	}()
 
 	// If the context is canceled, return immediately. The ReadString() operation will stay hung in the background
-	// goroutine indefinitely.
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
+	// goroutine until the user presses enter or stdin is closed.
 	select {
 	case <-ctx.Done():
 		return "", ctx.Err()
@@ -976,12 +1312,15 @@ func (h *handlerState) initOIDCDiscovery() error {
 		return err
 	}
 
+	ctx, cancel := context.WithTimeout(h.ctx, h.discoveryTimeout)
+	defer cancel()
+
 	h.logger.Info("Pinniped: Performing OIDC discovery", "issuer", h.issuer)
-	var err error
-	h.provider, err = coreosoidc.NewProvider(h.ctx, h.issuer)
+	provider, err := coreosoidc.NewProvider(ctx, h.issuer)
 	if err != nil {
 		return fmt.Errorf("could not perform OIDC discovery for %q: %w", h.issuer, err)
 	}
+	h.provider = provider
 
 	// Build an OAuth2 configuration based on the OIDC discovery data and our callback endpoint.
 	h.oauth2Config = &oauth2.Config{
@@ -989,6 +1328,11 @@ func (h *handlerState) initOIDCDiscovery() error {
 		Endpoint: h.provider.Endpoint(),
 		Scopes:   h.scopes,
 	}
+	if h.clientAssertionKey != nil {
+		// Send the client_id in the body of token requests, next to the client assertion, instead of in a basic
+		// authorization header with an empty client secret.
+		h.oauth2Config.Endpoint.AuthStyle = oauth2.AuthStyleInParams
+	}
 
 	// Validate that the discovered auth and token URLs use https. The OIDC spec for the authcode flow says:
 	// "Communication with the Authorization Endpoint MUST utilize TLS"
@@ -1011,10 +1355,24 @@ func (h *handlerState) initOIDCDiscovery() error {
 	}
 	h.useFormPost = slices.Contains(discoveryClaims.ResponseModesSupported, "form_post")
 
-	return h.maybePerformPinnipedSupervisorIDPDiscovery()
+	// Push the authorization requests if the provider supports it (RFC 9126).
+	var parClaims struct {
+		PushedAuthorizationRequestEndpoint string `json:"pushed_authorization_request_endpoint"`
+	}
+	if err := h.provider.Claims(&parClaims); err != nil {
+		return fmt.Errorf("could not decode pushed_authorization_request_endpoint in OIDC discovery from %q: %w", h.issuer, err)
+	}
+	h.parURL = parClaims.PushedAuthorizationRequestEndpoint
+	if h.parURL != "" {
+		if err := validateURLUsesHTTPS(h.parURL, "discovered pushed authorization request URL from issuer"); err != nil {
+			return err
+		}
+	}
+
+	return h.maybePerformPinnipedSupervisorIDPDiscovery(ctx)
 }
 
-func (h *handlerState) maybePerformPinnipedSupervisorIDPDiscovery() error {
+func (h *handlerState) maybePerformPinnipedSupervisorIDPDiscovery(ctx context.Context) error {
 	// If this OIDC IDP is a Pinniped Supervisor, it will have a reference to the IDP discovery document.
 	// Go to that document and retrieve the IDPs.
 	var pinnipedSupervisorClaims idpdiscoveryv1alpha1.OIDCDiscoveryResponse
@@ -1022,6 +1380,11 @@ func (h *handlerState) maybePerformPinnipedSupervisorIDPDiscovery() error {
 		return fmt.Errorf("could not decode the Pinniped IDP discovery document URL in OIDC discovery from %q: %w", h.issuer, err)
 	}
 
+	// A Supervisor's previous issuer announces its new issuer, which should be used for fresh logins.
+	if !h.followedIssuerMigration {
+		h.issuerMigratedTo = pinnipedSupervisorClaims.SupervisorDiscovery.IssuerMigratedTo
+	}
+
 	// This is not an error - it just means that this issuer is not a Pinniped Supervisor.
 	// Note that this package can be used with OIDC IDPs other than Pinniped Supervisor.
 	if pinnipedSupervisorClaims.SupervisorDiscovery.PinnipedIDPsEndpoint == "" {
@@ -1034,9 +1397,7 @@ func (h *handlerState) maybePerformPinnipedSupervisorIDPDiscovery() error {
 		return fmt.Errorf("the Pinniped IDP discovery document must always be hosted by the issuer: %q", h.issuer)
 	}
 
-	idpDiscoveryCtx, idpDiscoveryCtxCancelFunc := context.WithTimeout(h.ctx, httpRequestTimeout)
-	defer idpDiscoveryCtxCancelFunc()
-	idpDiscoveryReq, err := http.NewRequestWithContext(idpDiscoveryCtx, http.MethodGet, pinnipedSupervisorClaims.SupervisorDiscovery.PinnipedIDPsEndpoint, nil)
+	idpDiscoveryReq, err := http.NewRequestWithContext(ctx, http.MethodGet, pinnipedSupervisorClaims.SupervisorDiscovery.PinnipedIDPsEndpoint, nil)
 	if err != nil { // untested
 		return fmt.Errorf("could not build IDP Discovery request: %w", err)
 	}
@@ -1078,23 +1439,25 @@ func validateURLUsesHTTPS(uri string, uriName string) error {
 	return nil
 }
 
-func (h *handlerState) tokenExchangeRFC8693(baseToken *oidctypes.Token) (*oidctypes.Token, error) {
-	h.logger.Info("Pinniped: Performing RFC8693 token exchange", "requestedAudience", h.requestedAudience)
+func (h *handlerState) tokenExchangeRFC8693(baseToken *oidctypes.Token, requestedAudience string) (*oidctypes.Token, error) {
+	h.logger.Info("Pinniped: Performing RFC8693 token exchange", "requestedAudience", requestedAudience)
 	// Perform OIDC discovery. This may have already been performed if there was not a cached base token.
 	if err := h.initOIDCDiscovery(); err != nil {
-		return nil, err
+		return nil, classify(err, ErrDiscoveryFailed)
 	}
 
 	// Form the HTTP POST request with the parameters specified by RFC8693.
 	reqBody := strings.NewReader(url.Values{
 		"client_id":            []string{h.clientID},
 		"grant_type":           []string{oidcapi.GrantTypeTokenExchange},
-		"audience":             []string{h.requestedAudience},
+		"audience":             []string{requestedAudience},
 		"subject_token":        []string{baseToken.AccessToken.Token},
 		"subject_token_type":   []string{"urn:ietf:params:oauth:token-type:access_token"},
 		"requested_token_type": []string{"urn:ietf:params:oauth:token-type:jwt"},
 	}.Encode())
-	req, err := http.NewRequestWithContext(h.ctx, http.MethodPost, h.oauth2Config.Endpoint.TokenURL, reqBody)
+	ctx, cancel := context.WithTimeout(h.ctx, h.tokenExchangeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.oauth2Config.Endpoint.TokenURL, reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("could not build RFC8693 request: %w", err)
 	}
@@ -1138,9 +1501,9 @@ func (h *handlerState) tokenExchangeRFC8693(baseToken *oidctypes.Token) (*oidcty
 	}
 
 	// Validate the returned JWT to make sure we got the audience we wanted and extract the expiration time.
-	stsToken, err := h.validateIDToken(h.ctx, h.provider, h.requestedAudience, respBody.AccessToken)
+	stsToken, err := h.validateIDToken(ctx, h.provider, requestedAudience, respBody.AccessToken)
 	if err != nil {
-		return nil, fmt.Errorf("received invalid JWT: %w", err)
+		return nil, classify(fmt.Errorf("received invalid JWT: %w", err), ErrIDTokenInvalid)
 	}
 
 	return &oidctypes.Token{IDToken: &oidctypes.IDToken{
@@ -1162,7 +1525,11 @@ func (h *handlerState) handleRefresh(ctx context.Context, refreshToken *oidctype
 
 	// The spec is not 100% clear about whether an ID token from the refresh flow should include a nonce, and at least
 	// some providers do not include one, so we skip the nonce validation here (but not other validations).
-	return upstreamOIDCIdentityProvider.ValidateTokenAndMergeWithUserInfo(ctx, refreshed, "", true, false)
+	token, err := upstreamOIDCIdentityProvider.ValidateTokenAndMergeWithUserInfo(ctx, refreshed, "", true, false)
+	if err != nil {
+		return nil, classify(err, ErrRefreshRejected, ErrIDTokenInvalid)
+	}
+	return token, nil
 }
 
 // handleAuthCodeCallback is used as an http handler, so it does not run in the CLI's main goroutine.
@@ -1302,9 +1669,13 @@ func (h *handlerState) serve(listener net.Listener) func() {
 	go func() { _ = srv.Serve(listener) }()
 	return func() {
 		// Gracefully shut down the server, allowing up to 100ms for
-		// clients to receive any in-flight responses.
-		shutdownCtx, cancel := context.WithTimeout(h.ctx, 100*time.Millisecond)
-		_ = srv.Shutdown(shutdownCtx)
-		cancel()
+		// clients to receive any in-flight responses. This must not be bound by the
+		// login's context, which may already be canceled when the login timed out.
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(h.ctx), 100*time.Millisecond)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			// Forcibly close any connections which are still active, so nothing is left running in the background.
+			_ = srv.Close()
+		}
 	}
 }
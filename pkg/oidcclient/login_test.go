@@ -18,6 +18,7 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -37,6 +38,7 @@ import (
 	"go.pinniped.dev/internal/federationdomain/upstreamprovider"
 	"go.pinniped.dev/internal/httputil/httperr"
 	"go.pinniped.dev/internal/httputil/roundtripper"
+	"go.pinniped.dev/internal/i18n"
 	"go.pinniped.dev/internal/mocks/mockupstreamoidcidentityprovider"
 	"go.pinniped.dev/internal/net/phttp"
 	"go.pinniped.dev/internal/plog"
@@ -99,6 +101,11 @@ func TestLogin(t *testing.T) { //nolint:gocyclo
 		http.Error(w, "some discovery error", http.StatusInternalServerError)
 	}), nil)
 
+	// Start a test server that never responds until the client gives up.
+	slowServer, slowServerCA := tlsserver.TestServerIPv4(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}), nil)
+
 	// Start a test server that returns discovery data with a broken response_modes_supported value.
 	brokenResponseModeMux := http.NewServeMux()
 	brokenResponseModeServer, brokenResponseModeServerCA := tlsserver.TestServerIPv4(t, brokenResponseModeMux, nil)
@@ -408,6 +415,25 @@ func TestLogin(t *testing.T) { //nolint:gocyclo
 	providerMux.HandleFunc("/.well-known/openid-configuration", discoveryHandler(successServer, nil))
 	providerMux.HandleFunc(federationdomainoidc.PinnipedIDPsPathV1Alpha1, idpDiscoveryHandler(successServer))
 	providerMux.HandleFunc("/token", tokenHandler)
+	// Also serve the discovery of a previous issuer, which announces that the issuer has moved to successServer.URL.
+	providerMux.HandleFunc("/migrated/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "application/json")
+		_ = json.NewEncoder(w).Encode(&struct {
+			Issuer              string                                                `json:"issuer"`
+			AuthURL             string                                                `json:"authorization_endpoint"`
+			TokenURL            string                                                `json:"token_endpoint"`
+			SupervisorDiscovery idpdiscoveryv1alpha1.OIDCDiscoveryResponseIDPEndpoint `json:"discovery.supervisor.pinniped.dev/v1alpha1"`
+		}{
+			Issuer:   successServer.URL + "/migrated",
+			AuthURL:  successServer.URL + "/migrated/authorize",
+			TokenURL: successServer.URL + "/migrated/token",
+			SupervisorDiscovery: idpdiscoveryv1alpha1.OIDCDiscoveryResponseIDPEndpoint{
+				PinnipedIDPsEndpoint: successServer.URL + "/migrated" + federationdomainoidc.PinnipedIDPsPathV1Alpha1,
+				IssuerMigratedTo:     successServer.URL,
+			},
+		})
+	})
+	providerMux.HandleFunc("/migrated"+federationdomainoidc.PinnipedIDPsPathV1Alpha1, idpDiscoveryHandler(successServer))
 
 	// Start a test server that returns a real discovery document and answers refresh requests, _and_ supports form_mode=post.
 	formPostProviderMux := http.NewServeMux()
@@ -475,6 +501,7 @@ func TestLogin(t *testing.T) { //nolint:gocyclo
 		issuer     string
 		clientID   string
 		wantErr    string
+		wantErrIs  error
 		wantToken  *oidctypes.Token
 		wantLogs   []string
 		wantStdErr string
@@ -486,7 +513,8 @@ func TestLogin(t *testing.T) { //nolint:gocyclo
 					return fmt.Errorf("some option error")
 				}
 			},
-			wantErr: "some option error",
+			wantErr:   "some option error",
+			wantErrIs: ErrInvalidOption,
 		},
 		{
 			name: "WithLoginFlow option and deprecated WithCLISendingCredentials option cannot be used together (with CLI flow selected)",
@@ -524,6 +552,21 @@ func TestLogin(t *testing.T) { //nolint:gocyclo
 			},
 			wantErr: "WithLoginFlow error: loginFlow '' from 'other-flow-source' must be 'cli_password' or 'browser_authcode'",
 		},
+		{
+			name: "WithCustomAuthorizeParameters option rejects a parameter which is always set by Login",
+			opt: func(t *testing.T) Option {
+				return WithCustomAuthorizeParameters(map[string]string{"prompt": "login", "redirect_uri": "https://example.com"})
+			},
+			wantErr:   `WithCustomAuthorizeParameters error: parameter "redirect_uri" cannot be overridden`,
+			wantErrIs: ErrInvalidOption,
+		},
+		{
+			name: "WithUsernameAndPassword option rejects an empty password",
+			opt: func(t *testing.T) Option {
+				return WithUsernameAndPassword("some-username", "")
+			},
+			wantErr: "WithUsernameAndPassword error: username and password must not be empty",
+		},
 		{
 			name: "error generating state",
 			opt: func(t *testing.T) Option {
@@ -603,8 +646,9 @@ func TestLogin(t *testing.T) { //nolint:gocyclo
 					return WithSessionCache(cache)(h)
 				}
 			},
-			wantLogs: []string{`"level"=4 "msg"="Pinniped: Performing OIDC discovery"  "issuer"="` + errorServer.URL + `"`},
-			wantErr:  `could not perform OIDC discovery for "` + errorServer.URL + `": 500 Internal Server Error: some discovery error` + "\n",
+			wantLogs:  []string{`"level"=4 "msg"="Pinniped: Performing OIDC discovery"  "issuer"="` + errorServer.URL + `"`},
+			wantErr:   `could not perform OIDC discovery for "` + errorServer.URL + `": 500 Internal Server Error: some discovery error` + "\n",
+			wantErrIs: ErrDiscoveryFailed,
 		},
 		{
 			name:     "without request audience, session cache hit with valid ID token",
@@ -641,6 +685,51 @@ func TestLogin(t *testing.T) { //nolint:gocyclo
 			wantLogs: []string{`"level"=4 "msg"="Pinniped: Performing OIDC discovery"  "issuer"="` + errorServer.URL + `"`},
 			wantErr:  fmt.Sprintf("could not perform OIDC discovery for %q: 500 Internal Server Error: some discovery error\n", errorServer.URL),
 		},
+		{
+			name: "discovery failure due to discovery timeout",
+			opt: func(t *testing.T) Option {
+				return func(h *handlerState) error {
+					require.NoError(t, WithClient(buildHTTPClientForPEM(slowServerCA))(h))
+					return WithDiscoveryTimeout(10 * time.Millisecond)(h)
+				}
+			},
+			issuer:    slowServer.URL,
+			wantLogs:  []string{`"level"=4 "msg"="Pinniped: Performing OIDC discovery"  "issuer"="` + slowServer.URL + `"`},
+			wantErr:   fmt.Sprintf(`could not perform OIDC discovery for %q: Get "%s/.well-known/openid-configuration": context deadline exceeded`, slowServer.URL, slowServer.URL),
+			wantErrIs: ErrDiscoveryFailed,
+		},
+		{
+			name: "invalid discovery timeout",
+			opt: func(t *testing.T) Option {
+				return WithDiscoveryTimeout(0)
+			},
+			wantErr:   "WithDiscoveryTimeout error: timeout must be positive, but was 0s",
+			wantErrIs: ErrInvalidOption,
+		},
+		{
+			name: "invalid callback timeout",
+			opt: func(t *testing.T) Option {
+				return WithCallbackTimeout(-time.Second)
+			},
+			wantErr:   "WithCallbackTimeout error: timeout must be positive, but was -1s",
+			wantErrIs: ErrInvalidOption,
+		},
+		{
+			name: "invalid token exchange timeout",
+			opt: func(t *testing.T) Option {
+				return WithTokenExchangeTimeout(0)
+			},
+			wantErr:   "WithTokenExchangeTimeout error: timeout must be positive, but was 0s",
+			wantErrIs: ErrInvalidOption,
+		},
+		{
+			name: "invalid refresh timeout",
+			opt: func(t *testing.T) Option {
+				return WithRefreshTimeout(0)
+			},
+			wantErr:   "WithRefreshTimeout error: timeout must be positive, but was 0s",
+			wantErrIs: ErrInvalidOption,
+		},
 		{
 			name: "discovery failure due to invalid response_modes_supported",
 			opt: func(t *testing.T) Option {
@@ -745,7 +834,8 @@ func TestLogin(t *testing.T) { //nolint:gocyclo
 				`"level"=4 "msg"="Pinniped: Performing OIDC discovery"  "issuer"="` + successServer.URL + `"`,
 				`"level"=4 "msg"="Pinniped: Refreshing cached tokens."`,
 			},
-			wantErr: "some validation error",
+			wantErr:   "some validation error",
+			wantErrIs: ErrRefreshRejected,
 		},
 		{
 			name:     "session cache hit but refresh fails",
@@ -780,7 +870,104 @@ func TestLogin(t *testing.T) { //nolint:gocyclo
 				`"msg"="could not open callback listener" "error"="some listen error"`,
 			},
 			// Expect this to fall through to the authorization code flow, so it fails here.
-			wantErr: "login failed: must have either a localhost listener or stdin must be a TTY",
+			wantErr:   "login failed: must have either a localhost listener or stdin must be a TTY",
+			wantErrIs: ErrPromptFailed,
+		},
+		{
+			name:     "refresh only mode, session cache hit but refresh fails",
+			issuer:   successServer.URL,
+			clientID: "not-the-test-client-id",
+			opt: func(t *testing.T) Option {
+				return func(h *handlerState) error {
+					require.NoError(t, WithClient(buildHTTPClientForPEM(successServerCA))(h))
+					require.NoError(t, WithRefreshOnly()(h))
+
+					cache := &mockSessionCache{t: t, getReturnsToken: &oidctypes.Token{
+						IDToken: &oidctypes.IDToken{
+							Token:  "expired-test-id-token",
+							Expiry: metav1.NewTime(time.Now().Add(9 * time.Minute)), // less than Now() + minIDTokenValidity
+						},
+						RefreshToken: &oidctypes.RefreshToken{Token: "test-refresh-token"},
+					}}
+					t.Cleanup(func() {
+						require.Empty(t, cache.sawPutKeys)
+						require.Empty(t, cache.sawPutTokens)
+					})
+					h.cache = cache
+
+					h.listen = func(string, string) (net.Listener, error) {
+						t.Error("unexpected attempt to start an interactive login")
+						return nil, nil
+					}
+					return nil
+				}
+			},
+			wantLogs: []string{
+				`"level"=4 "msg"="Pinniped: Performing OIDC discovery"  "issuer"="` + successServer.URL + `"`,
+				`"level"=4 "msg"="Pinniped: Refreshing cached tokens."`,
+				`"level"=4 "msg"="Pinniped: Refresh failed."  "error"="oauth2: cannot fetch token: 400 Bad Request\nResponse: expected client_id 'test-client-id'\n"`,
+			},
+			wantErr:   "interactive login required but not allowed because refresh only mode was requested: cached session could not be refreshed",
+			wantErrIs: ErrRefreshRejected,
+		},
+		{
+			name:     "refresh only mode, session cache miss",
+			issuer:   successServer.URL,
+			clientID: "test-client-id",
+			opt: func(t *testing.T) Option {
+				return func(h *handlerState) error {
+					require.NoError(t, WithClient(buildHTTPClientForPEM(successServerCA))(h))
+					require.NoError(t, WithRefreshOnly()(h))
+
+					h.listen = func(string, string) (net.Listener, error) {
+						t.Error("unexpected attempt to start an interactive login")
+						return nil, nil
+					}
+					return nil
+				}
+			},
+			wantLogs: []string{
+				`"level"=4 "msg"="Pinniped: Performing OIDC discovery"  "issuer"="` + successServer.URL + `"`,
+			},
+			wantErr: "interactive login required but not allowed because refresh only mode was requested: no cached session found",
+		},
+		{
+			name:     "refresh only mode, issuer has moved, session cache miss at both issuers",
+			issuer:   successServer.URL + "/migrated",
+			clientID: "test-client-id",
+			opt: func(t *testing.T) Option {
+				return func(h *handlerState) error {
+					require.NoError(t, WithClient(buildHTTPClientForPEM(successServerCA))(h))
+					require.NoError(t, WithRefreshOnly()(h))
+
+					cache := &mockSessionCache{t: t}
+					t.Cleanup(func() {
+						cacheKey := SessionCacheKey{
+							Issuer:      successServer.URL + "/migrated",
+							ClientID:    "test-client-id",
+							Scopes:      []string{"test-scope"},
+							RedirectURI: "http://localhost:0/callback",
+						}
+						newCacheKey := cacheKey
+						newCacheKey.Issuer = successServer.URL
+						require.Equal(t, []SessionCacheKey{cacheKey, newCacheKey}, cache.sawGetKeys)
+						require.Empty(t, cache.sawPutKeys)
+					})
+					h.cache = cache
+
+					h.listen = func(string, string) (net.Listener, error) {
+						t.Error("unexpected attempt to start an interactive login")
+						return nil, nil
+					}
+					return nil
+				}
+			},
+			wantLogs: []string{
+				`"level"=4 "msg"="Pinniped: Performing OIDC discovery"  "issuer"="` + successServer.URL + `/migrated"`,
+				`"level"=4 "msg"="Pinniped: The issuer has moved, so logging in at the new issuer."  "issuer"="` + successServer.URL + `" "previousIssuer"="` + successServer.URL + `/migrated"`,
+				`"level"=4 "msg"="Pinniped: Performing OIDC discovery"  "issuer"="` + successServer.URL + `"`,
+			},
+			wantErr: "interactive login required but not allowed because refresh only mode was requested: no cached session found",
 		},
 		{
 			name: "issuer has invalid token URL",
@@ -913,7 +1100,52 @@ func TestLogin(t *testing.T) { //nolint:gocyclo
 					"&response_mode=form_post&response_type=code&scope=test-scope&state=test-state") +
 				regexp.QuoteMeta("\n\n[...]\n\n") +
 				"$",
-			wantErr: "error handling callback: failed to prompt for manual authorization code: some prompt error",
+			wantErr:   "error handling callback: failed to prompt for manual authorization code: some prompt error",
+			wantErrIs: ErrPromptFailed,
+		},
+		{
+			name: "custom authorize parameters are added to the authorize URL",
+			opt: func(t *testing.T) Option {
+				return func(h *handlerState) error {
+					h.generateState = func() (state.State, error) { return "test-state", nil }
+					h.generatePKCE = func() (pkce.Code, error) { return "test-pkce", nil }
+					h.generateNonce = func() (nonce.Nonce, error) { return "test-nonce", nil }
+					h.stdinIsTTY = func() bool { return true }
+					require.NoError(t, WithClient(buildHTTPClientForPEM(formPostSuccessServerCA))(h))
+					require.NoError(t, WithSkipListen()(h))
+					require.NoError(t, WithCustomAuthorizeParameters(map[string]string{"prompt": "select_account"})(h))
+					require.NoError(t, WithCustomAuthorizeParameters(map[string]string{"prompt": "login", "kc_idp_hint": "some-idp"})(h))
+					h.skipBrowser = false // don't skip calling the following openURL func
+					h.openURL = func(authorizeURL string) error {
+						parsed, err := url.Parse(authorizeURL)
+						require.NoError(t, err)
+						require.Equal(t, "login", parsed.Query().Get("prompt"))
+						require.Equal(t, "some-idp", parsed.Query().Get("kc_idp_hint"))
+						return fmt.Errorf("some browser open error")
+					}
+					h.promptForValue = func(_ context.Context, promptLabel string, _ io.Writer) (string, error) {
+						return "", fmt.Errorf("some prompt error")
+					}
+					return nil
+				}
+			},
+			issuer: formPostSuccessServer.URL,
+			wantLogs: []string{
+				`"level"=4 "msg"="Pinniped: Performing OIDC discovery"  "issuer"="` + formPostSuccessServer.URL + `"`,
+				`"msg"="could not open browser" "error"="some browser open error"`,
+			},
+			wantStdErr: "^" +
+				regexp.QuoteMeta("Log in by visiting this link:\n\n") +
+				regexp.QuoteMeta("    https://127.0.0.1:") +
+				"[0-9]+" + // random port
+				regexp.QuoteMeta("/authorize?access_type=offline&client_id=&code_challenge="+testCodeChallenge+
+					"&code_challenge_method=S256&kc_idp_hint=some-idp&nonce=test-nonce&prompt=login"+
+					"&redirect_uri=http%3A%2F%2F127.0.0.1%3A0%2Fcallback"+
+					"&response_mode=form_post&response_type=code&scope=test-scope&state=test-state") +
+				regexp.QuoteMeta("\n\n[...]\n\n") +
+				"$",
+			wantErr:   "error handling callback: failed to prompt for manual authorization code: some prompt error",
+			wantErrIs: ErrPromptFailed,
 		},
 		{
 			name: "listening fails and manual prompt fails",
@@ -989,7 +1221,37 @@ func TestLogin(t *testing.T) { //nolint:gocyclo
 				regexp.QuoteMeta("%2Fcallback&response_type=code&scope=test-scope&state=test-state") +
 				regexp.QuoteMeta("\n\n") +
 				"$",
-			wantErr: "timed out waiting for token callback: context canceled",
+			wantErr:   "timed out waiting for token callback: context canceled",
+			wantErrIs: ErrCallbackTimeout,
+		},
+		{
+			name: "callback timeout elapsed while waiting for callback",
+			opt: func(t *testing.T) Option {
+				return func(h *handlerState) error {
+					h.generateState = func() (state.State, error) { return "test-state", nil }
+					h.generatePKCE = func() (pkce.Code, error) { return "test-pkce", nil }
+					h.generateNonce = func() (nonce.Nonce, error) { return "test-nonce", nil }
+					h.stdinIsTTY = func() bool { return true }
+
+					require.NoError(t, WithClient(buildHTTPClientForPEM(successServerCA))(h))
+
+					return WithCallbackTimeout(time.Nanosecond)(h)
+				}
+			},
+			issuer:   successServer.URL,
+			wantLogs: []string{`"level"=4 "msg"="Pinniped: Performing OIDC discovery"  "issuer"="` + successServer.URL + `"`},
+			wantStdErr: "^" +
+				regexp.QuoteMeta("Log in by visiting this link:\n\n") +
+				regexp.QuoteMeta("    https://127.0.0.1:") +
+				"[0-9]+" + // random port
+				regexp.QuoteMeta("/authorize?access_type=offline&client_id=&code_challenge="+testCodeChallenge+
+					"&code_challenge_method=S256&nonce=test-nonce&redirect_uri=http%3A%2F%2F127.0.0.1%3A") +
+				"[0-9]+" + // random port
+				regexp.QuoteMeta("%2Fcallback&response_type=code&scope=test-scope&state=test-state") +
+				regexp.QuoteMeta("\n\n") +
+				"$",
+			wantErr:   "timed out waiting for token callback: context deadline exceeded",
+			wantErrIs: ErrCallbackTimeout,
 		},
 		{
 			name: "callback returns error",
@@ -1022,7 +1284,8 @@ func TestLogin(t *testing.T) { //nolint:gocyclo
 				regexp.QuoteMeta("%2Fcallback&response_type=code&scope=test-scope&state=test-state") +
 				regexp.QuoteMeta("\n\n") +
 				"$",
-			wantErr: "error handling callback: some callback error",
+			wantErr:   "error handling callback: some callback error",
+			wantErrIs: ErrAuthorizationFailed,
 		},
 		{
 			name:     "callback returns success",
@@ -1488,6 +1751,7 @@ func TestLogin(t *testing.T) { //nolint:gocyclo
 			wantLogs:   []string{`"level"=4 "msg"="Pinniped: Performing OIDC discovery"  "issuer"="` + successServer.URL + `"`},
 			wantStdErr: "^\nLog in to upstream-idp-name-with-cli-password-flow-first\n\n$",
 			wantErr:    "error prompting for username: some prompt error",
+			wantErrIs:  ErrPromptFailed,
 		},
 		{
 			name:     "ldap login when prompting for password returns an error",
@@ -1593,6 +1857,7 @@ func TestLogin(t *testing.T) { //nolint:gocyclo
 			wantLogs:   []string{`"level"=4 "msg"="Pinniped: Performing OIDC discovery"  "issuer"="` + successServer.URL + `"`},
 			wantStdErr: "^\nLog in to upstream-idp-name-with-cli-password-flow-first\n\n$",
 			wantErr:    `login failed with code "access_denied": optional-error-description`,
+			wantErrIs:  ErrAuthorizationFailed,
 		},
 		{
 			name:     "ldap login when the OIDC provider authorization endpoint redirects us to a different server",
@@ -1838,9 +2103,10 @@ func TestLogin(t *testing.T) { //nolint:gocyclo
 					return nil
 				}
 			},
-			issuer:   successServer.URL,
-			wantLogs: []string{`"level"=4 "msg"="Pinniped: Performing OIDC discovery"  "issuer"="` + successServer.URL + `"`},
-			wantErr:  `unable to find upstream identity provider with type "INVALID UPSTREAM TYPE", this Pinniped Supervisor supports IDP types ["upstream-idp-type-with-browser-authcode-flow-first", "upstream-idp-type-with-cli-password-flow-first"]`,
+			issuer:    successServer.URL,
+			wantLogs:  []string{`"level"=4 "msg"="Pinniped: Performing OIDC discovery"  "issuer"="` + successServer.URL + `"`},
+			wantErr:   `unable to find upstream identity provider with type "INVALID UPSTREAM TYPE", this Pinniped Supervisor supports IDP types ["upstream-idp-type-with-browser-authcode-flow-first", "upstream-idp-type-with-cli-password-flow-first"]`,
+			wantErrIs: ErrUpstreamIDPNotFound,
 		},
 		{
 			name:     "successful ldap login with prompts for username and password, using deprecated WithCLISendingCredentials option",
@@ -2153,6 +2419,104 @@ func TestLogin(t *testing.T) { //nolint:gocyclo
 			},
 			wantToken: &testToken,
 		},
+		{
+			name:     "successful ldap login with username and password provided by option",
+			clientID: "test-client-id",
+			opt: func(t *testing.T) Option {
+				return func(h *handlerState) error {
+					fakeAuthCode := "test-authcode-value"
+
+					h.getProvider = func(_ *oauth2.Config, _ *coreosoidc.Provider, _ *http.Client) upstreamprovider.UpstreamOIDCIdentityProviderI {
+						mock := mockUpstream(t)
+						mock.EXPECT().
+							ExchangeAuthcodeAndValidateTokens(
+								gomock.Any(), fakeAuthCode, pkce.Code("test-pkce"), nonce.Nonce("test-nonce"), "http://127.0.0.1:0/callback").
+							Return(&testToken, nil)
+						return mock
+					}
+
+					h.generateState = func() (state.State, error) { return "test-state", nil }
+					h.generatePKCE = func() (pkce.Code, error) { return "test-pkce", nil }
+					h.generateNonce = func() (nonce.Nonce, error) { return "test-nonce", nil }
+					h.getEnv = func(key string) string {
+						require.FailNow(t, fmt.Sprintf("saw unexpected env var lookup from the CLI: %q", key))
+						return ""
+					}
+					h.promptForValue = func(_ context.Context, promptLabel string, _ io.Writer) (string, error) {
+						require.FailNow(t, fmt.Sprintf("saw unexpected prompt from the CLI: %q", promptLabel))
+						return "", nil
+					}
+					h.promptForSecret = func(promptLabel string, _ io.Writer) (string, error) {
+						require.FailNow(t, fmt.Sprintf("saw unexpected prompt from the CLI: %q", promptLabel))
+						return "", nil
+					}
+
+					cache := &mockSessionCache{t: t, getReturnsToken: nil}
+					cacheKey := SessionCacheKey{
+						Issuer:      successServer.URL,
+						ClientID:    "test-client-id",
+						Scopes:      []string{"test-scope"},
+						RedirectURI: "http://localhost:0/callback",
+					}
+					t.Cleanup(func() {
+						require.Equal(t, []SessionCacheKey{cacheKey}, cache.sawGetKeys)
+						require.Equal(t, []SessionCacheKey{cacheKey}, cache.sawPutKeys)
+						require.Equal(t, []*oidctypes.Token{&testToken}, cache.sawPutTokens)
+					})
+					require.NoError(t, WithSessionCache(cache)(h))
+					require.NoError(t, WithLoginFlow(idpdiscoveryv1alpha1.IDPFlowCLIPassword, "flowSource")(h))
+					require.NoError(t, WithUsernameAndPassword("some-upstream-username", "some-upstream-password")(h))
+
+					discoveryRequestWasMade := false
+					authorizeRequestWasMade := false
+					t.Cleanup(func() {
+						require.True(t, discoveryRequestWasMade, "should have made an discovery request")
+						require.True(t, authorizeRequestWasMade, "should have made an authorize request")
+					})
+
+					client := buildHTTPClientForPEM(successServerCA)
+					client.Transport = roundtripper.Func(func(req *http.Request) (*http.Response, error) {
+						switch req.URL.Scheme + "://" + req.URL.Host + req.URL.Path {
+						case "https://" + successServer.Listener.Addr().String() + "/.well-known/openid-configuration":
+							discoveryRequestWasMade = true
+							return defaultDiscoveryResponse(req)
+						case "https://" + successServer.Listener.Addr().String() + federationdomainoidc.PinnipedIDPsPathV1Alpha1:
+							return defaultDiscoveryResponse(req)
+						case "https://" + successServer.Listener.Addr().String() + "/authorize":
+							authorizeRequestWasMade = true
+							require.Equal(t, "some-upstream-username", req.Header.Get("Pinniped-Username"))
+							require.Equal(t, "some-upstream-password", req.Header.Get("Pinniped-Password"))
+							require.Equal(t, url.Values{
+								"code_challenge":        []string{testCodeChallenge},
+								"code_challenge_method": []string{"S256"},
+								"response_type":         []string{"code"},
+								"scope":                 []string{"test-scope"},
+								"nonce":                 []string{"test-nonce"},
+								"state":                 []string{"test-state"},
+								"access_type":           []string{"offline"},
+								"client_id":             []string{"test-client-id"},
+								"redirect_uri":          []string{"http://127.0.0.1:0/callback"},
+							}, req.URL.Query())
+							return &http.Response{
+								StatusCode: http.StatusFound,
+								Header: http.Header{"Location": []string{
+									fmt.Sprintf("http://127.0.0.1:0/callback?code=%s&state=test-state", fakeAuthCode),
+								}},
+							}, nil
+						default:
+							// Note that "/token" requests should not be made. They are mocked by mocking calls to ExchangeAuthcodeAndValidateTokens().
+							require.FailNow(t, fmt.Sprintf("saw unexpected http call from the CLI: %s", req.URL.String()))
+							return nil, nil
+						}
+					})
+					require.NoError(t, WithClient(client)(h))
+					return nil
+				}
+			},
+			issuer:    successServer.URL,
+			wantLogs:  []string{`"level"=4 "msg"="Pinniped: Performing OIDC discovery"  "issuer"="` + successServer.URL + `"`},
+			wantToken: &testToken,
+		},
 		{
 			name:     "successful ldap login with env vars for username and password, http.StatusSeeOther redirect",
 			clientID: "test-client-id",
@@ -2408,7 +2772,8 @@ func TestLogin(t *testing.T) { //nolint:gocyclo
 				`"level"=4 "msg"="Pinniped: Performing RFC8693 token exchange"  "requestedAudience"="test-audience-produce-http-400"`,
 				`"level"=4 "msg"="Pinniped: Performing OIDC discovery"  "issuer"="` + successServer.URL + `"`,
 			},
-			wantErr: `failed to exchange token: unexpected HTTP response status 400`,
+			wantErr:   `failed to exchange token: unexpected HTTP response status 400`,
+			wantErrIs: ErrTokenExchangeFailed,
 		},
 		{
 			name:     "with requested audience, session cache hit with valid access token, but token exchange request returns invalid content-type header",
@@ -2582,7 +2947,8 @@ func TestLogin(t *testing.T) { //nolint:gocyclo
 				`"level"=4 "msg"="Pinniped: Performing RFC8693 token exchange"  "requestedAudience"="test-audience-produce-invalid-jwt"`,
 				`"level"=4 "msg"="Pinniped: Performing OIDC discovery"  "issuer"="` + successServer.URL + `"`,
 			},
-			wantErr: `failed to exchange token: received invalid JWT: oidc: malformed jwt: oidc: malformed jwt, expected 3 parts got 1`,
+			wantErr:   `failed to exchange token: received invalid JWT: oidc: malformed jwt: oidc: malformed jwt, expected 3 parts got 1`,
+			wantErrIs: ErrIDTokenInvalid,
 		},
 		{
 			name:     "with requested audience, session cache hit with valid access token, ID token has wrong audience, and token exchange request succeeds",
@@ -2870,6 +3236,9 @@ func TestLogin(t *testing.T) { //nolint:gocyclo
 
 			if tt.wantErr != "" {
 				require.EqualError(t, err, tt.wantErr)
+				if tt.wantErrIs != nil {
+					require.ErrorIs(t, err, tt.wantErrIs)
+				}
 				require.Nil(t, tok)
 				return
 			}
@@ -2910,6 +3279,161 @@ func withOutWriter(t *testing.T, out io.Writer) Option {
 	}
 }
 
+func TestLoginAndExchangeForAudiences(t *testing.T) {
+	distantFutureTime := time.Date(2065, 10, 12, 13, 14, 15, 16, time.UTC)
+
+	cachedToken := oidctypes.Token{
+		AccessToken:  &oidctypes.AccessToken{Token: "test-access-token", Expiry: metav1.NewTime(distantFutureTime.Add(1 * time.Minute))},
+		RefreshToken: &oidctypes.RefreshToken{Token: "test-refresh-token"},
+		IDToken: &oidctypes.IDToken{
+			Token:  "test-id-token",
+			Expiry: metav1.NewTime(distantFutureTime.Add(2 * time.Minute)),
+			Claims: map[string]any{"aud": "test-client-id"},
+		},
+	}
+
+	var exchangeCount atomic.Int32
+	providerMux := http.NewServeMux()
+	server, serverCA := tlsserver.TestServerIPv4(t, providerMux, nil)
+	providerMux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "application/json")
+		_ = json.NewEncoder(w).Encode(&struct {
+			Issuer   string `json:"issuer"`
+			AuthURL  string `json:"authorization_endpoint"`
+			TokenURL string `json:"token_endpoint"`
+			JWKSURL  string `json:"jwks_uri"`
+		}{
+			Issuer:   server.URL,
+			AuthURL:  server.URL + "/authorize",
+			TokenURL: server.URL + "/token",
+			JWKSURL:  server.URL + "/keys",
+		})
+	})
+	providerMux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		exchangeCount.Add(1)
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.Form.Get("grant_type") != "urn:ietf:params:oauth:grant-type:token-exchange" ||
+			r.Form.Get("subject_token") != "test-access-token" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		if r.Form.Get("audience") == "test-audience-produce-http-400" {
+			http.Error(w, "some server error", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("content-type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"access_token":      "test-id-token-for-" + r.Form.Get("audience"),
+			"token_type":        "N_A",
+			"issued_token_type": "urn:ietf:params:oauth:token-type:jwt",
+		})
+	})
+
+	withCachedTokenAndFakeValidation := func(t *testing.T, token *oidctypes.Token) Option {
+		return func(h *handlerState) error {
+			require.NoError(t, WithClient(buildHTTPClientForPEM(serverCA))(h))
+			require.NoError(t, WithSessionCache(&mockSessionCache{t: t, getReturnsToken: token})(h))
+			h.validateIDToken = func(ctx context.Context, provider *coreosoidc.Provider, audience string, token string) (*coreosoidc.IDToken, error) {
+				require.Equal(t, "test-id-token-for-"+audience, token)
+				return &coreosoidc.IDToken{Expiry: distantFutureTime}, nil
+			}
+			return nil
+		}
+	}
+
+	tests := []struct {
+		name              string
+		audiences         []string
+		opts              func(t *testing.T) []Option
+		wantTokens        map[string]string
+		wantExchangeCount int32
+		wantErr           string
+	}{
+		{
+			name:      "exchanges once per unique audience, and reuses the ID token which already has an audience",
+			audiences: []string{"cluster-1", "cluster-2", "cluster-1", "test-client-id", "cluster-3"},
+			opts: func(t *testing.T) []Option {
+				return []Option{withCachedTokenAndFakeValidation(t, &cachedToken)}
+			},
+			wantTokens: map[string]string{
+				"cluster-1":      "test-id-token-for-cluster-1",
+				"cluster-2":      "test-id-token-for-cluster-2",
+				"cluster-3":      "test-id-token-for-cluster-3",
+				"test-client-id": "test-id-token",
+			},
+			wantExchangeCount: 3,
+		},
+		{
+			name:      "any failed exchange fails the whole operation",
+			audiences: []string{"cluster-1", "test-audience-produce-http-400"},
+			opts: func(t *testing.T) []Option {
+				return []Option{withCachedTokenAndFakeValidation(t, &cachedToken)}
+			},
+			wantErr: `failed to exchange token for audience "test-audience-produce-http-400": unexpected HTTP response status 400`,
+		},
+		{
+			name:      "no audiences",
+			audiences: nil,
+			opts:      func(t *testing.T) []Option { return nil },
+			wantErr:   "at least one audience is required",
+		},
+		{
+			name:      "empty audience",
+			audiences: []string{"cluster-1", ""},
+			opts:      func(t *testing.T) []Option { return nil },
+			wantErr:   "audiences must not be empty",
+		},
+		{
+			name:      "cannot be combined with WithRequestAudience",
+			audiences: []string{"cluster-1"},
+			opts: func(t *testing.T) []Option {
+				return []Option{withCachedTokenAndFakeValidation(t, &cachedToken), WithRequestAudience("cluster-2")}
+			},
+			wantErr: "do not use option WithRequestAudience when exchanging tokens for multiple audiences",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exchangeCount.Store(0)
+
+			opts := append([]Option{
+				WithContext(context.Background()),
+				WithListenPort(0),
+				WithSkipBrowserOpen(),
+			}, tt.opts(t)...)
+
+			tokens, err := LoginAndExchangeForAudiences(server.URL, "test-client-id", tt.audiences, opts...)
+
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				require.Nil(t, tokens)
+				return
+			}
+			require.NoError(t, err)
+
+			gotTokens := make(map[string]string, len(tokens))
+			for audience, token := range tokens {
+				require.NotNil(t, token.IDToken)
+				gotTokens[audience] = token.IDToken.Token
+			}
+			require.Equal(t, tt.wantTokens, gotTokens)
+			require.Equal(t, tt.wantExchangeCount, exchangeCount.Load())
+		})
+	}
+}
+
+func TestRefreshRequiredError(t *testing.T) {
+	err := fmt.Errorf("could not login: %w", &RefreshRequiredError{Reason: "some reason"})
+
+	var refreshRequiredErr *RefreshRequiredError
+	require.ErrorAs(t, err, &refreshRequiredErr)
+	require.Equal(t, "some reason", refreshRequiredErr.Reason)
+	require.EqualError(t, err, "could not login: interactive login required but not allowed because refresh only mode was requested: some reason")
+}
+
 func TestHandlePasteCallback(t *testing.T) {
 	const testRedirectURI = "http://127.0.0.1:12324/callback"
 	const testAuthURL = "https://test-authorize-url/"
@@ -2972,7 +3496,7 @@ func TestHandlePasteCallback(t *testing.T) {
 			printAuthorizeURL: true,
 			wantStderr:        expectedAuthURLOutput(testAuthURL) + cancelledAuthcodePromptOutput + newlineAfterEveryAuthcodePromptOutput,
 			wantCallback: &callbackResult{
-				err: fmt.Errorf("failed to prompt for manual authorization code: some prompt error"),
+				err: classify(fmt.Errorf("failed to prompt for manual authorization code: some prompt error"), ErrPromptFailed),
 			},
 		},
 		{
@@ -3029,6 +3553,35 @@ func TestHandlePasteCallback(t *testing.T) {
 				token: &oidctypes.Token{IDToken: &oidctypes.IDToken{Token: "test-id-token"}},
 			},
 		},
+		{
+			name: "success, with printing auth url and prompting for authcode in another language",
+			opt: func(t *testing.T) Option {
+				return func(h *handlerState) error {
+					require.NoError(t, WithLanguage("de-DE")(h))
+					h.stdinIsTTY = func() bool { return true }
+					h.useFormPost = true
+					h.promptForValue = func(_ context.Context, promptLabel string, _ io.Writer) (string, error) {
+						assert.Equal(t, "    Optional können Sie Ihren Autorisierungscode hier einfügen: ", promptLabel)
+						return "valid", nil
+					}
+					h.oauth2Config = &oauth2.Config{RedirectURL: testRedirectURI}
+					h.getProvider = func(_ *oauth2.Config, _ *coreosoidc.Provider, _ *http.Client) upstreamprovider.UpstreamOIDCIdentityProviderI {
+						mock := mockUpstream(t)
+						mock.EXPECT().
+							ExchangeAuthcodeAndValidateTokens(gomock.Any(), "valid", pkce.Code("test-pkce"), nonce.Nonce("test-nonce"), testRedirectURI).
+							Return(&oidctypes.Token{IDToken: &oidctypes.IDToken{Token: "test-id-token"}}, nil)
+						return mock
+					}
+					return nil
+				}
+			},
+			authorizeURL:      testAuthURL,
+			printAuthorizeURL: true,
+			wantStderr:        "Melden Sie sich über diesen Link an:\n\n    " + testAuthURL + "\n\n" + newlineAfterEveryAuthcodePromptOutput,
+			wantCallback: &callbackResult{
+				token: &oidctypes.Token{IDToken: &oidctypes.IDToken{Token: "test-id-token"}},
+			},
+		},
 		{
 			name: "skipping printing auth url (also skips prompting for authcode)",
 			opt: func(t *testing.T) Option {
@@ -3063,6 +3616,7 @@ func TestHandlePasteCallback(t *testing.T) {
 				pkce:      pkce.Code("test-pkce"),
 				nonce:     nonce.Nonce("test-nonce"),
 				out:       buf,
+				localizer: i18n.Default(),
 			}
 			if tt.opt != nil {
 				require.NoError(t, tt.opt(t)(h))
@@ -3535,7 +4089,7 @@ func TestMaybePerformPinnipedSupervisorIDPDiscovery(t *testing.T) {
 		require.NoError(t, WithClient(buildHTTPClientForPEM(issuerServerCA))(&h))
 		require.NoError(t, withContextAndProvider(t, issuerServer.URL)(&h))
 
-		actualError := h.maybePerformPinnipedSupervisorIDPDiscovery()
+		actualError := h.maybePerformPinnipedSupervisorIDPDiscovery(h.ctx)
 		require.NoError(t, actualError)
 		require.Equal(t, idpDiscoveryMetadata, h.idpDiscovery)
 	})
@@ -3569,7 +4123,7 @@ func TestMaybePerformPinnipedSupervisorIDPDiscovery(t *testing.T) {
 		require.NoError(t, WithClient(buildHTTPClientForPEM(issuerServerCA))(&h))
 		require.NoError(t, withContextAndProvider(t, issuerServer.URL)(&h))
 
-		actualError := h.maybePerformPinnipedSupervisorIDPDiscovery()
+		actualError := h.maybePerformPinnipedSupervisorIDPDiscovery(h.ctx)
 		require.EqualError(t, actualError, "unable to fetch IDP discovery data from issuer: unexpected http response status: 500 Internal Server Error")
 		require.Empty(t, h.idpDiscovery)
 	})
@@ -3605,7 +4159,7 @@ func TestMaybePerformPinnipedSupervisorIDPDiscovery(t *testing.T) {
 		require.NoError(t, WithClient(buildHTTPClientForPEM(issuerServerCA))(&h))
 		require.NoError(t, withContextAndProvider(t, issuerServer.URL)(&h))
 
-		actualError := h.maybePerformPinnipedSupervisorIDPDiscovery()
+		actualError := h.maybePerformPinnipedSupervisorIDPDiscovery(h.ctx)
 		require.EqualError(t, actualError, "unable to fetch the Pinniped IDP discovery document: could not parse response JSON: invalid character 'o' in literal false (expecting 'a')")
 		require.Empty(t, h.idpDiscovery)
 	})
@@ -3643,7 +4197,7 @@ func TestMaybePerformPinnipedSupervisorIDPDiscovery(t *testing.T) {
 			return fmt.Errorf("redirect error")
 		}
 
-		actualError := h.maybePerformPinnipedSupervisorIDPDiscovery()
+		actualError := h.maybePerformPinnipedSupervisorIDPDiscovery(h.ctx)
 		require.EqualError(t, actualError, `IDP Discovery response error: Get "foo": redirect error`)
 		require.Empty(t, h.idpDiscovery)
 	})
@@ -3693,7 +4247,7 @@ func TestMaybePerformPinnipedSupervisorIDPDiscovery(t *testing.T) {
 			require.NoError(t, WithClient(buildHTTPClientForPEM(issuerServerCA))(&h))
 			require.NoError(t, withContextAndProvider(t, issuerServer.URL)(&h))
 
-			actualError := h.maybePerformPinnipedSupervisorIDPDiscovery()
+			actualError := h.maybePerformPinnipedSupervisorIDPDiscovery(h.ctx)
 
 			if test.wantErr != "" {
 				require.EqualError(t, actualError, test.wantErr)
@@ -48,4 +48,14 @@ type Token struct {
 
 	// IDToken is an OpenID Connect ID token.
 	IDToken *IDToken `json:"id,omitempty"`
+
+	// DPoPKey is the optional private key to which the access and refresh tokens are bound.
+	DPoPKey *DPoPKey `json:"dpop,omitempty"`
+}
+
+// DPoPKey is a private key which signs the DPoP proofs defined by RFC 9449, to prove possession of the key to which
+// the tokens of a session are bound.
+type DPoPKey struct {
+	// PrivateKey is the PEM encoded private key.
+	PrivateKey string `json:"privateKey"`
 }
@@ -0,0 +1,80 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package oidcclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// parResponse is the response of a pushed authorization request endpoint, as described by RFC 9126.
+type parResponse struct {
+	RequestURI       string `json:"request_uri"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// maybePushAuthorizationRequest sends the params of the authorizeURL to the pushed authorization request endpoint of
+// the issuer, when the issuer has one, and returns an authorize URL which only refers to the pushed request. This keeps
+// the params out of the browser history and logs, and out of the reach of anything which could change them on the way
+// to the issuer. When the issuer does not have such an endpoint, the authorizeURL is returned unchanged.
+func (h *handlerState) maybePushAuthorizationRequest(authorizeURL string) (string, error) {
+	if h.parURL == "" {
+		return authorizeURL, nil
+	}
+
+	parsedAuthorizeURL, err := url.Parse(authorizeURL)
+	if err != nil {
+		return "", fmt.Errorf("could not parse authorize URL: %w", err)
+	}
+	params := parsedAuthorizeURL.Query()
+
+	ctx, cancel := context.WithTimeout(h.ctx, httpRequestTimeout)
+	defer cancel()
+
+	h.logger.Info("Pinniped: Pushing authorization request", "url", h.parURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.parURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("could not build pushed authorization request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := h.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("pushed authorization request failed: %w", err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("could not read pushed authorization response: %w", err)
+	}
+
+	var response parResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("pushed authorization request failed with status %s", res.Status)
+	}
+	if res.StatusCode != http.StatusCreated {
+		if response.Error != "" {
+			return "", fmt.Errorf("pushed authorization request failed with status %s: %s: %s", res.Status, response.Error, response.ErrorDescription)
+		}
+		return "", fmt.Errorf("pushed authorization request failed with status %s", res.Status)
+	}
+	if response.RequestURI == "" {
+		return "", fmt.Errorf("pushed authorization response did not include a request_uri")
+	}
+
+	// RFC 9126 says that the client_id must be included along with the request_uri.
+	parsedAuthorizeURL.RawQuery = url.Values{
+		"client_id":   {params.Get("client_id")},
+		"request_uri": {response.RequestURI},
+	}.Encode()
+	return parsedAuthorizeURL.String(), nil
+}
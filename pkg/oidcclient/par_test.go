@@ -0,0 +1,112 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package oidcclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go.pinniped.dev/internal/plog"
+)
+
+func TestMaybePushAuthorizationRequest(t *testing.T) {
+	const authorizeURL = "https://issuer.example.com/oauth2/authorize?client_id=test-client-id&redirect_uri=http%3A%2F%2F127.0.0.1%3A1234%2Fcallback&response_type=code&scope=openid&state=test-state"
+
+	tests := []struct {
+		name       string
+		noPARURL   bool
+		statusCode int
+		body       string
+		wantURL    string
+		wantErr    string
+	}{
+		{
+			name:     "the issuer does not support pushed authorization requests",
+			noPARURL: true,
+			wantURL:  authorizeURL,
+		},
+		{
+			name:       "success",
+			statusCode: http.StatusCreated,
+			body:       `{"request_uri": "urn:ietf:params:oauth:request_uri:some-request-id", "expires_in": 60}`,
+			wantURL:    "https://issuer.example.com/oauth2/authorize?client_id=test-client-id&request_uri=urn%3Aietf%3Aparams%3Aoauth%3Arequest_uri%3Asome-request-id",
+		},
+		{
+			name:       "error response",
+			statusCode: http.StatusBadRequest,
+			body:       `{"error": "invalid_request", "error_description": "some description"}`,
+			wantErr:    "pushed authorization request failed with status 400 Bad Request: invalid_request: some description",
+		},
+		{
+			name:       "error response which is not JSON",
+			statusCode: http.StatusBadGateway,
+			body:       `some proxy error`,
+			wantErr:    "pushed authorization request failed with status 502 Bad Gateway",
+		},
+		{
+			name:       "error response without an error",
+			statusCode: http.StatusInternalServerError,
+			body:       `{}`,
+			wantErr:    "pushed authorization request failed with status 500 Internal Server Error",
+		},
+		{
+			name:       "success response without a request_uri",
+			statusCode: http.StatusCreated,
+			body:       `{"expires_in": 60}`,
+			wantErr:    "pushed authorization response did not include a request_uri",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotForm url.Values
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodPost, r.Method)
+				require.Equal(t, "/oauth2/par", r.URL.Path)
+				require.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+				require.NoError(t, r.ParseForm())
+				gotForm = r.PostForm
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			t.Cleanup(server.Close)
+
+			h := &handlerState{
+				ctx:        context.Background(),
+				logger:     plog.New(),
+				httpClient: server.Client(),
+				parURL:     server.URL + "/oauth2/par",
+			}
+			if tt.noPARURL {
+				h.parURL = ""
+			}
+
+			gotURL, err := h.maybePushAuthorizationRequest(authorizeURL)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				require.Empty(t, gotURL)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.wantURL, gotURL)
+			}
+
+			if tt.noPARURL {
+				require.Nil(t, gotForm)
+				return
+			}
+			require.Equal(t, url.Values{
+				"client_id":     {"test-client-id"},
+				"redirect_uri":  {"http://127.0.0.1:1234/callback"},
+				"response_type": {"code"},
+				"scope":         {"openid"},
+				"state":         {"test-state"},
+			}, gotForm)
+		})
+	}
+}
@@ -0,0 +1,361 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package tokenvalidator validates the cluster-scoped ID tokens which are issued by the token exchange of a Pinniped
+// Supervisor FederationDomain, for services which accept those tokens as bearer tokens. The identity of a valid token
+// is the same as the identity which a Concierge JWTAuthenticator with the same settings would compute for it.
+//
+// Tokens are validated locally. The issuer is only contacted to discover its signing keys, and not at all when
+// the signing keys are pinned using WithJWKS.
+package tokenvalidator
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	coreosoidc "github.com/coreos/go-oidc/v3/oidc"
+	"github.com/go-jose/go-jose/v3"
+
+	oidcapi "go.pinniped.dev/generated/latest/apis/supervisor/oidc"
+	"go.pinniped.dev/internal/constable"
+	"go.pinniped.dev/internal/net/phttp"
+)
+
+// DefaultClockSkew is the default tolerance for differences between the clocks of the Supervisor and the
+// validating service, which is applied to the "exp" and "nbf" claims.
+const DefaultClockSkew = time.Minute
+
+// ErrInvalidToken is wrapped by the errors which are returned by Validator.Validate when the token is not valid,
+// as opposed to when the token could not be validated, e.g. because discovery of the issuer failed.
+const ErrInvalidToken = constable.Error("invalid token")
+
+// Option is an optional configuration for New().
+type Option func(*Validator) error
+
+// Identity is the identity of a user which was asserted by a valid token.
+type Identity struct {
+	// Username is the value of the username claim.
+	Username string
+	// Groups is the value of the groups claim. It is empty when the token has no groups claim.
+	Groups []string
+	// Subject is the value of the "sub" claim, which identifies the user within the upstream identity provider.
+	Subject string
+	// AdditionalClaims are the claims which the FederationDomain's identity transformations or the upstream
+	// identity provider's additionalClaimMappings added to the token, if any.
+	AdditionalClaims map[string]any
+	// Expiry is the value of the "exp" claim.
+	Expiry time.Time
+	// Actor is the party which acted on behalf of the user during the token exchange, according to the "act" claim.
+	// It is nil when the token has no "act" claim.
+	Actor *Actor
+}
+
+// Actor is the identity of a party which acted on behalf of a user.
+type Actor struct {
+	// Subject is the value of the "sub" claim of the actor.
+	Subject string
+	// Username is the downstream username of the actor.
+	Username string
+}
+
+// Validator validates tokens for a single issuer and audience. It is safe for concurrent use.
+type Validator struct {
+	issuer        string
+	audience      string
+	usernameClaim string
+	groupsClaim   string
+	clockSkew     time.Duration
+	httpClient    *http.Client
+	now           func() time.Time
+	pinnedKeySet  coreosoidc.KeySet
+
+	lock     sync.Mutex
+	verifier *coreosoidc.IDTokenVerifier
+}
+
+// WithCABundle configures the PEM-encoded CA bundle which is trusted when making requests to the issuer,
+// instead of the system trust store.
+func WithCABundle(caBundle []byte) Option {
+	return func(v *Validator) error {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caBundle) {
+			return fmt.Errorf("invalid CA bundle: no certificates found")
+		}
+		v.httpClient = phttp.Default(pool)
+		return nil
+	}
+}
+
+// WithHTTPClient configures the HTTP client which is used to make requests to the issuer. It overrides WithCABundle.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(v *Validator) error {
+		if httpClient == nil {
+			return fmt.Errorf("HTTP client must not be nil")
+		}
+		v.httpClient = httpClient
+		return nil
+	}
+}
+
+// WithJWKS pins the signing keys of the issuer to the keys of the JSON Web Key Set, e.g. a copy of the response
+// of the issuer's jwks_uri, so that tokens are validated without making any requests to the issuer. Tokens which
+// are signed by other keys are invalid, so the key set must be updated before the FederationDomain starts to sign
+// tokens with a new key. It overrides WithCABundle and WithHTTPClient.
+func WithJWKS(jwks []byte) Option {
+	return func(v *Validator) error {
+		var keySet jose.JSONWebKeySet
+		if err := json.Unmarshal(jwks, &keySet); err != nil {
+			return fmt.Errorf("invalid JWKS: %w", err)
+		}
+
+		publicKeys := make([]crypto.PublicKey, 0, len(keySet.Keys))
+		for _, key := range keySet.Keys {
+			if !key.Valid() || !key.IsPublic() {
+				return fmt.Errorf("invalid JWKS: key %q is not a public key", key.KeyID)
+			}
+			if key.Use != "" && key.Use != "sig" {
+				continue
+			}
+			publicKeys = append(publicKeys, key.Key)
+		}
+		if len(publicKeys) == 0 {
+			return fmt.Errorf("invalid JWKS: no signing keys found")
+		}
+
+		v.pinnedKeySet = &coreosoidc.StaticKeySet{PublicKeys: publicKeys}
+		return nil
+	}
+}
+
+// WithClaims configures the names of the claims which contain the username and groups, like spec.claims of a
+// JWTAuthenticator. An empty name keeps the default of "username" or "groups" respectively.
+func WithClaims(usernameClaim, groupsClaim string) Option {
+	return func(v *Validator) error {
+		if usernameClaim != "" {
+			v.usernameClaim = usernameClaim
+		}
+		if groupsClaim != "" {
+			v.groupsClaim = groupsClaim
+		}
+		return nil
+	}
+}
+
+// WithClockSkew configures the tolerance for differences between the clocks of the Supervisor and the validating
+// service. The default is DefaultClockSkew.
+func WithClockSkew(clockSkew time.Duration) Option {
+	return func(v *Validator) error {
+		if clockSkew < 0 {
+			return fmt.Errorf("clock skew must not be negative")
+		}
+		v.clockSkew = clockSkew
+		return nil
+	}
+}
+
+// New returns a Validator for tokens which were issued by the FederationDomain with the specified issuer and which
+// have the specified audience, i.e. the audience which was requested during the token exchange.
+//
+// The issuer's discovery document is fetched during the first validation, and again when that fails, so New
+// does not fail when the Supervisor is temporarily unavailable. The issuer's signing keys are cached, and are
+// fetched again when a token is signed by an unknown key. Neither happens when the keys are pinned using WithJWKS.
+func New(issuer, audience string, opts ...Option) (*Validator, error) {
+	if !strings.HasPrefix(issuer, "https://") {
+		return nil, fmt.Errorf("issuer must be an https URL, but got %q", issuer)
+	}
+	if audience == "" {
+		return nil, fmt.Errorf("audience must not be empty")
+	}
+
+	v := &Validator{
+		issuer:        issuer,
+		audience:      audience,
+		usernameClaim: oidcapi.IDTokenClaimUsername,
+		groupsClaim:   oidcapi.IDTokenClaimGroups,
+		clockSkew:     DefaultClockSkew,
+		httpClient:    phttp.Default(nil),
+		now:           time.Now,
+	}
+	for _, opt := range opts {
+		if err := opt(v); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// Validate checks the signature, issuer, audience, and lifetime of the token, and returns the identity which it asserts.
+// When the token is not valid, the returned error wraps ErrInvalidToken.
+func (v *Validator) Validate(ctx context.Context, token string) (*Identity, error) {
+	verifier, err := v.getVerifier(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	idToken, err := verifier.Verify(coreosoidc.ClientContext(ctx, v.httpClient), token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("%w: could not decode claims: %w", ErrInvalidToken, err)
+	}
+
+	if err := v.validateLifetime(idToken, claims); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	username, err := v.username(claims)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	groups, err := v.groups(claims)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	additionalClaims, _ := claims[oidcapi.IDTokenClaimAdditionalClaims].(map[string]any)
+	actor, err := tokenActor(claims)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	return &Identity{
+		Username:         username,
+		Groups:           groups,
+		Subject:          idToken.Subject,
+		AdditionalClaims: additionalClaims,
+		Expiry:           idToken.Expiry,
+		Actor:            actor,
+	}, nil
+}
+
+// tokenActor returns the actor of the "act" claim, which the Supervisor adds when a token exchange had an actor.
+func tokenActor(claims map[string]any) (*Actor, error) {
+	act, present := claims["act"]
+	if !present {
+		return nil, nil
+	}
+	actClaims, ok := act.(map[string]any)
+	if !ok {
+		return nil, errors.New(`claim "act" must be an object`)
+	}
+	subject, _ := actClaims[oidcapi.IDTokenClaimSubject].(string)
+	username, _ := actClaims[oidcapi.IDTokenClaimUsername].(string)
+	if subject == "" || username == "" {
+		return nil, errors.New(`claim "act" must have a non-empty "sub" and "username"`)
+	}
+	return &Actor{Subject: subject, Username: username}, nil
+}
+
+// getVerifier performs discovery of the issuer, unless it was already performed successfully or the signing keys
+// are pinned.
+func (v *Validator) getVerifier(ctx context.Context) (*coreosoidc.IDTokenVerifier, error) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if v.verifier != nil {
+		return v.verifier, nil
+	}
+
+	keySet := v.pinnedKeySet
+	if keySet == nil {
+		var err error
+		if keySet, err = v.discoverKeySet(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	v.verifier = coreosoidc.NewVerifier(v.issuer, keySet, &coreosoidc.Config{
+		ClientID: v.audience,
+		// The Supervisor signs with ES256 by default, and with RS256 or EdDSA when a FederationDomain configures it.
+		SupportedSigningAlgs: []string{coreosoidc.ES256, coreosoidc.RS256, coreosoidc.EdDSA},
+		// The lifetime is checked by validateLifetime, to allow for clock skew.
+		SkipExpiryCheck: true,
+	})
+	return v.verifier, nil
+}
+
+// discoverKeySet returns a key set which fetches the signing keys from the jwks_uri of the issuer's discovery document.
+func (v *Validator) discoverKeySet(ctx context.Context) (coreosoidc.KeySet, error) {
+	provider, err := coreosoidc.NewProvider(coreosoidc.ClientContext(ctx, v.httpClient), v.issuer)
+	if err != nil {
+		return nil, fmt.Errorf("could not perform OIDC discovery for %q: %w", v.issuer, err)
+	}
+
+	// The key set is used long after this request, so it must not use the request's context.
+	keySetCtx := coreosoidc.ClientContext(context.Background(), v.httpClient)
+	var discovered struct {
+		JWKSURL string `json:"jwks_uri"`
+	}
+	if err := provider.Claims(&discovered); err != nil || discovered.JWKSURL == "" {
+		return nil, fmt.Errorf("could not find jwks_uri in discovery document of %q", v.issuer)
+	}
+	return coreosoidc.NewRemoteKeySet(keySetCtx, discovered.JWKSURL), nil
+}
+
+func (v *Validator) validateLifetime(idToken *coreosoidc.IDToken, claims map[string]any) error {
+	now := v.now()
+	if idToken.Expiry.IsZero() {
+		return errors.New("token has no exp claim")
+	}
+	if now.After(idToken.Expiry.Add(v.clockSkew)) {
+		return fmt.Errorf("token expired at %s", idToken.Expiry.UTC().Format(time.RFC3339))
+	}
+	if nbf, ok := claims["nbf"].(float64); ok {
+		notBefore := time.Unix(int64(nbf), 0)
+		if now.Add(v.clockSkew).Before(notBefore) {
+			return fmt.Errorf("token is not valid before %s", notBefore.UTC().Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// username returns the value of the username claim, in the same way as a JWTAuthenticator.
+func (v *Validator) username(claims map[string]any) (string, error) {
+	username, ok := claims[v.usernameClaim].(string)
+	if !ok || username == "" {
+		return "", fmt.Errorf("claim %q must be a non-empty string", v.usernameClaim)
+	}
+
+	// Like Kubernetes, only trust an email address as the username when it is not known to be unverified.
+	if v.usernameClaim == "email" {
+		if verified, present := claims["email_verified"]; present {
+			if verified, ok := verified.(bool); !ok || !verified {
+				return "", fmt.Errorf("claim %q is not verified", v.usernameClaim)
+			}
+		}
+	}
+	return username, nil
+}
+
+// groups returns the value of the groups claim, in the same way as a JWTAuthenticator.
+func (v *Validator) groups(claims map[string]any) ([]string, error) {
+	switch groups := claims[v.groupsClaim].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		// A single group may be a string instead of an array.
+		return []string{groups}, nil
+	case []any:
+		result := make([]string, 0, len(groups))
+		for _, group := range groups {
+			group, ok := group.(string)
+			if !ok {
+				return nil, fmt.Errorf("claim %q must be a string or an array of strings", v.groupsClaim)
+			}
+			result = append(result, group)
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("claim %q must be a string or an array of strings", v.groupsClaim)
+	}
+}
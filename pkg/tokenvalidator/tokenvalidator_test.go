@@ -0,0 +1,423 @@
+// Copyright 2024 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package tokenvalidator
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/stretchr/testify/require"
+
+	"go.pinniped.dev/internal/testutil/tlsserver"
+)
+
+const testAudience = "some-cluster-audience"
+
+type testIssuer struct {
+	url           string
+	caBundle      []byte
+	key           *ecdsa.PrivateKey
+	discoveryHits atomic.Int32
+	failDiscovery atomic.Bool
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	issuer := &testIssuer{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		issuer.discoveryHits.Add(1)
+		if issuer.failDiscovery.Load() {
+			http.Error(w, "fake error", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("content-type", "application/json")
+		_, err := fmt.Fprintf(w, `{"issuer": %q, "jwks_uri": %q}`, issuer.url, issuer.url+"/jwks.json")
+		require.NoError(t, err)
+	})
+	mux.HandleFunc("/jwks.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: key.Public(), KeyID: "some-key", Algorithm: string(jose.ES256), Use: "sig"},
+		}}))
+	})
+	server, caBundle := tlsserver.TestServerIPv4(t, mux, nil)
+	issuer.url = server.URL
+	issuer.caBundle = caBundle
+	return issuer
+}
+
+func (i *testIssuer) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	return signWithKey(t, i.key, claims)
+}
+
+func signWithKey(t *testing.T, key *ecdsa.PrivateKey, claims map[string]any) string {
+	t.Helper()
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.ES256, Key: jose.JSONWebKey{Key: key, KeyID: "some-key"}},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	require.NoError(t, err)
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	require.NoError(t, err)
+	return token
+}
+
+func TestValidate(t *testing.T) {
+	issuer := newTestIssuer(t)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC).Local() // times parsed from tokens are local
+
+	validClaims := func(issuerURL string) map[string]any {
+		return map[string]any{
+			"iss":      issuerURL,
+			"aud":      testAudience,
+			"sub":      issuerURL + "?idpName=some-idp&sub=some-subject",
+			"exp":      now.Add(2 * time.Minute).Unix(),
+			"iat":      now.Unix(),
+			"username": "some-user",
+			"groups":   []string{"group1", "group2"},
+		}
+	}
+	withClaims := func(overrides map[string]any) map[string]any {
+		claims := validClaims(issuer.url)
+		for k, v := range overrides {
+			if v == nil {
+				delete(claims, k)
+			} else {
+				claims[k] = v
+			}
+		}
+		return claims
+	}
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		opts         []Option
+		token        string
+		wantIdentity *Identity
+		wantErr      string
+	}{
+		{
+			name:  "valid token",
+			token: issuer.sign(t, validClaims(issuer.url)),
+			wantIdentity: &Identity{
+				Username: "some-user",
+				Groups:   []string{"group1", "group2"},
+				Subject:  issuer.url + "?idpName=some-idp&sub=some-subject",
+				Expiry:   now.Add(2 * time.Minute),
+			},
+		},
+		{
+			name: "valid token with additional claims and a single group as a string",
+			token: issuer.sign(t, withClaims(map[string]any{
+				"groups":           "group1",
+				"additionalClaims": map[string]any{"department": "engineering"},
+			})),
+			wantIdentity: &Identity{
+				Username:         "some-user",
+				Groups:           []string{"group1"},
+				Subject:          issuer.url + "?idpName=some-idp&sub=some-subject",
+				AdditionalClaims: map[string]any{"department": "engineering"},
+				Expiry:           now.Add(2 * time.Minute),
+			},
+		},
+		{
+			name: "valid token with an actor",
+			token: issuer.sign(t, withClaims(map[string]any{
+				"act": map[string]any{"sub": "some-actor-subject", "username": "some-actor"},
+			})),
+			wantIdentity: &Identity{
+				Username: "some-user",
+				Groups:   []string{"group1", "group2"},
+				Subject:  issuer.url + "?idpName=some-idp&sub=some-subject",
+				Expiry:   now.Add(2 * time.Minute),
+				Actor:    &Actor{Subject: "some-actor-subject", Username: "some-actor"},
+			},
+		},
+		{
+			name:    "actor without username",
+			token:   issuer.sign(t, withClaims(map[string]any{"act": map[string]any{"sub": "some-actor-subject"}})),
+			wantErr: `invalid token: claim "act" must have a non-empty "sub" and "username"`,
+		},
+		{
+			name:    "actor of the wrong type",
+			token:   issuer.sign(t, withClaims(map[string]any{"act": "some-actor"})),
+			wantErr: `invalid token: claim "act" must be an object`,
+		},
+		{
+			name:  "valid token without groups",
+			token: issuer.sign(t, withClaims(map[string]any{"groups": nil})),
+			wantIdentity: &Identity{
+				Username: "some-user",
+				Subject:  issuer.url + "?idpName=some-idp&sub=some-subject",
+				Expiry:   now.Add(2 * time.Minute),
+			},
+		},
+		{
+			name:  "valid token using custom claims",
+			opts:  []Option{WithClaims("email", "roles")},
+			token: issuer.sign(t, withClaims(map[string]any{"email": "user@example.com", "email_verified": true, "roles": []string{"admin"}})),
+			wantIdentity: &Identity{
+				Username: "user@example.com",
+				Groups:   []string{"admin"},
+				Subject:  issuer.url + "?idpName=some-idp&sub=some-subject",
+				Expiry:   now.Add(2 * time.Minute),
+			},
+		},
+		{
+			name:  "token expired less than the clock skew ago",
+			token: issuer.sign(t, withClaims(map[string]any{"exp": now.Add(-30 * time.Second).Unix()})),
+			wantIdentity: &Identity{
+				Username: "some-user",
+				Groups:   []string{"group1", "group2"},
+				Subject:  issuer.url + "?idpName=some-idp&sub=some-subject",
+				Expiry:   now.Add(-30 * time.Second),
+			},
+		},
+		{
+			name:    "token expired more than the clock skew ago",
+			token:   issuer.sign(t, withClaims(map[string]any{"exp": now.Add(-2 * time.Minute).Unix()})),
+			wantErr: "invalid token: token expired at 2024-06-01T11:58:00Z",
+		},
+		{
+			name:    "token expired with no clock skew allowed",
+			opts:    []Option{WithClockSkew(0)},
+			token:   issuer.sign(t, withClaims(map[string]any{"exp": now.Add(-time.Second).Unix()})),
+			wantErr: "invalid token: token expired at 2024-06-01T11:59:59Z",
+		},
+		{
+			name:    "token not valid yet",
+			token:   issuer.sign(t, withClaims(map[string]any{"nbf": now.Add(5 * time.Minute).Unix()})),
+			wantErr: "invalid token: token is not valid before 2024-06-01T12:05:00Z",
+		},
+		{
+			name:    "token without exp",
+			token:   issuer.sign(t, withClaims(map[string]any{"exp": nil})),
+			wantErr: "invalid token: token has no exp claim",
+		},
+		{
+			name:    "wrong audience",
+			token:   issuer.sign(t, withClaims(map[string]any{"aud": "some-other-audience"})),
+			wantErr: `invalid token: oidc: expected audience "some-cluster-audience" got ["some-other-audience"]`,
+		},
+		{
+			name:    "wrong issuer",
+			token:   issuer.sign(t, withClaims(map[string]any{"iss": "https://other.example.com"})),
+			wantErr: fmt.Sprintf(`invalid token: oidc: id token issued by a different provider, expected %q got "https://other.example.com"`, issuer.url),
+		},
+		{
+			name:    "signed by an unknown key",
+			token:   signWithKey(t, otherKey, validClaims(issuer.url)),
+			wantErr: "invalid token: failed to verify signature: failed to verify id token signature",
+		},
+		{
+			name:    "missing username",
+			token:   issuer.sign(t, withClaims(map[string]any{"username": nil})),
+			wantErr: `invalid token: claim "username" must be a non-empty string`,
+		},
+		{
+			name:    "unverified email as username",
+			opts:    []Option{WithClaims("email", "")},
+			token:   issuer.sign(t, withClaims(map[string]any{"email": "user@example.com", "email_verified": false})),
+			wantErr: `invalid token: claim "email" is not verified`,
+		},
+		{
+			name:    "groups of the wrong type",
+			token:   issuer.sign(t, withClaims(map[string]any{"groups": []any{"group1", 42}})),
+			wantErr: `invalid token: claim "groups" must be a string or an array of strings`,
+		},
+		{
+			name:    "not a JWT",
+			token:   "not-a-jwt",
+			wantErr: "invalid token: oidc: malformed jwt: oidc: malformed jwt, expected 3 parts got 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := New(issuer.url, testAudience, append([]Option{WithCABundle(issuer.caBundle)}, tt.opts...)...)
+			require.NoError(t, err)
+			v.now = func() time.Time { return now }
+
+			identity, err := v.Validate(context.Background(), tt.token)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				require.ErrorIs(t, err, ErrInvalidToken)
+				require.Nil(t, identity)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantIdentity, identity)
+		})
+	}
+}
+
+func TestValidateRetriesFailedDiscovery(t *testing.T) {
+	issuer := newTestIssuer(t)
+	issuer.failDiscovery.Store(true)
+
+	v, err := New(issuer.url, testAudience, WithCABundle(issuer.caBundle))
+	require.NoError(t, err)
+
+	token := issuer.sign(t, map[string]any{
+		"iss":      issuer.url,
+		"aud":      testAudience,
+		"sub":      "some-subject",
+		"exp":      time.Now().Add(time.Minute).Unix(),
+		"username": "some-user",
+	})
+
+	_, err = v.Validate(context.Background(), token)
+	require.ErrorContains(t, err, fmt.Sprintf("could not perform OIDC discovery for %q: 503 Service Unavailable", issuer.url))
+	require.False(t, errors.Is(err, ErrInvalidToken))
+
+	issuer.failDiscovery.Store(false)
+	identity, err := v.Validate(context.Background(), token)
+	require.NoError(t, err)
+	require.Equal(t, "some-user", identity.Username)
+
+	// Discovery is only performed until it succeeds.
+	_, err = v.Validate(context.Background(), token)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), issuer.discoveryHits.Load())
+}
+
+func TestValidateWithPinnedJWKS(t *testing.T) {
+	const issuerURL = "https://issuer.example.com" // never contacted
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	jwks, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: key.Public(), KeyID: "some-key", Algorithm: string(jose.ES256), Use: "sig"},
+		{Key: otherKey.Public(), KeyID: "some-encryption-key", Algorithm: string(jose.ECDH_ES), Use: "enc"},
+	}})
+	require.NoError(t, err)
+
+	v, err := New(issuerURL, testAudience, WithJWKS(jwks))
+	require.NoError(t, err)
+
+	claims := map[string]any{
+		"iss":      issuerURL,
+		"aud":      testAudience,
+		"sub":      "some-subject",
+		"exp":      time.Now().Add(time.Minute).Unix(),
+		"username": "some-user",
+		"groups":   []string{"group1"},
+	}
+
+	identity, err := v.Validate(context.Background(), signWithKey(t, key, claims))
+	require.NoError(t, err)
+	require.Equal(t, "some-user", identity.Username)
+	require.Equal(t, []string{"group1"}, identity.Groups)
+
+	// Keys which are not for signatures are ignored.
+	_, err = v.Validate(context.Background(), signWithKey(t, otherKey, claims))
+	require.EqualError(t, err, "invalid token: failed to verify signature: no public keys able to verify jwt")
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		issuer   string
+		audience string
+		opts     []Option
+		wantErr  string
+	}{
+		{
+			name:     "issuer is not https",
+			issuer:   "http://example.com",
+			audience: testAudience,
+			wantErr:  `issuer must be an https URL, but got "http://example.com"`,
+		},
+		{
+			name:    "audience is empty",
+			issuer:  "https://example.com",
+			wantErr: "audience must not be empty",
+		},
+		{
+			name:     "invalid CA bundle",
+			issuer:   "https://example.com",
+			audience: testAudience,
+			opts:     []Option{WithCABundle([]byte("not a certificate"))},
+			wantErr:  "invalid CA bundle: no certificates found",
+		},
+		{
+			name:     "unparsable JWKS",
+			issuer:   "https://example.com",
+			audience: testAudience,
+			opts:     []Option{WithJWKS([]byte("not json"))},
+			wantErr:  "invalid JWKS: invalid character 'o' in literal null (expecting 'u')",
+		},
+		{
+			name:     "JWKS with a private key",
+			issuer:   "https://example.com",
+			audience: testAudience,
+			opts:     []Option{WithJWKS(privateJWKS(t))},
+			wantErr:  `invalid JWKS: key "some-private-key" is not a public key`,
+		},
+		{
+			name:     "JWKS without signing keys",
+			issuer:   "https://example.com",
+			audience: testAudience,
+			opts:     []Option{WithJWKS([]byte(`{"keys": []}`))},
+			wantErr:  "invalid JWKS: no signing keys found",
+		},
+		{
+			name:     "nil HTTP client",
+			issuer:   "https://example.com",
+			audience: testAudience,
+			opts:     []Option{WithHTTPClient(nil)},
+			wantErr:  "HTTP client must not be nil",
+		},
+		{
+			name:     "negative clock skew",
+			issuer:   "https://example.com",
+			audience: testAudience,
+			opts:     []Option{WithClockSkew(-time.Second)},
+			wantErr:  "clock skew must not be negative",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := New(tt.issuer, tt.audience, tt.opts...)
+			require.EqualError(t, err, tt.wantErr)
+			require.Nil(t, v)
+		})
+	}
+}
+
+func privateJWKS(t *testing.T) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	jwks, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: key, KeyID: "some-private-key", Algorithm: string(jose.ES256), Use: "sig"},
+	}})
+	require.NoError(t, err)
+	return jwks
+}
@@ -48,7 +48,8 @@ func TestConciergeJWTAuthenticatorStatus_Parallel(t *testing.T) {
 		{
 			name: "valid spec with invalid CA in TLS config will result in a jwt authenticator that is not ready",
 			run: func(t *testing.T) {
-				caBundleString := "invalid base64-encoded data"
+				// Passes the CRD validations, which only check the characters, but cannot be decoded.
+				caBundleString := "invalid base64 encoded data"
 				jwtAuthenticator := testlib.CreateTestJWTAuthenticator(ctx, t, authenticationv1alpha1.JWTAuthenticatorSpec{
 					Issuer:   env.SupervisorUpstreamOIDC.Issuer,
 					Audience: "some-fake-audience",
@@ -324,7 +325,7 @@ func TestConciergeJWTAuthenticatorCRDValidations_Parallel(t *testing.T) {
 						Username: "",
 					},
 					TLS: &authenticationv1alpha1.TLSSpec{
-						CertificateAuthorityData: "pretend-this-is-a-certificate",
+						CertificateAuthorityData: base64.StdEncoding.EncodeToString([]byte("pretend-this-is-a-certificate")),
 					},
 				},
 			},
@@ -5,6 +5,7 @@ package integration
 
 import (
 	"context"
+	"encoding/base64"
 	"testing"
 	"time"
 
@@ -41,7 +42,8 @@ func TestConciergeWebhookAuthenticatorStatus_Parallel(t *testing.T) {
 		{
 			name: "valid spec with invalid CA in TLS config will result in a WebhookAuthenticator that is not ready",
 			spec: func() *authenticationv1alpha1.WebhookAuthenticatorSpec {
-				caBundleString := "invalid base64-encoded data"
+				// Passes the CRD validations, which only check the characters, but cannot be decoded.
+				caBundleString := "invalid base64 encoded data"
 				webhookSpec := testEnv.TestWebhook.DeepCopy()
 				webhookSpec.TLS = &authenticationv1alpha1.TLSSpec{
 					CertificateAuthorityData: caBundleString,
@@ -228,14 +230,14 @@ func TestConciergeWebhookAuthenticatorCRDValidations_Parallel(t *testing.T) {
 			},
 		},
 		{
-			// since the CRD validations do not assess fitness of the value provided
+			// since the CRD validations only check that the value is base64-encoded, not that it is a certificate
 			name: "valid authenticator can have TLS CertificateAuthorityData string that is an invalid certificate",
 			webhookAuthenticator: &authenticationv1alpha1.WebhookAuthenticator{
 				ObjectMeta: testlib.ObjectMetaWithRandomName(t, "jwtauthenticator"),
 				Spec: authenticationv1alpha1.WebhookAuthenticatorSpec{
 					Endpoint: "https://localhost/webhook-isnt-actually-here",
 					TLS: &authenticationv1alpha1.TLSSpec{
-						CertificateAuthorityData: "pretend-this-is-a-certificate",
+						CertificateAuthorityData: base64.StdEncoding.EncodeToString([]byte("pretend-this-is-a-certificate")),
 					},
 				},
 			},
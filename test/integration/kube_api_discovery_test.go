@@ -159,6 +159,14 @@ func TestGetAPIResourceList(t *testing.T) { //nolint:gocyclo // each t.Run is pr
 			},
 			resourceByVersion: map[string][]metav1.APIResource{
 				configSupervisorGV.String(): {
+					{
+						Name:         "clusteraudiences",
+						SingularName: "clusteraudience",
+						Namespaced:   true,
+						Kind:         "ClusterAudience",
+						Verbs:        []string{"delete", "deletecollection", "get", "list", "patch", "create", "update", "watch"},
+						Categories:   []string{"pinniped"},
+					},
 					{
 						Name:         "federationdomains",
 						SingularName: "federationdomain",
@@ -173,6 +181,14 @@ func TestGetAPIResourceList(t *testing.T) { //nolint:gocyclo // each t.Run is pr
 						Kind:       "FederationDomain",
 						Verbs:      []string{"get", "patch", "update"},
 					},
+					{
+						Name:         "forcedreauthentications",
+						SingularName: "forcedreauthentication",
+						Namespaced:   true,
+						Kind:         "ForcedReauthentication",
+						Verbs:        []string{"delete", "deletecollection", "get", "list", "patch", "create", "update", "watch"},
+						Categories:   []string{"pinniped"},
+					},
 					{
 						Name:         "oidcclients",
 						SingularName: "oidcclient",
@@ -342,6 +358,14 @@ func TestGetAPIResourceList(t *testing.T) { //nolint:gocyclo // each t.Run is pr
 						Kind:       "JWTAuthenticator",
 						Verbs:      []string{"get", "patch", "update"},
 					},
+					{
+						Name:         "clustertokendenylists",
+						SingularName: "clustertokendenylist",
+						Namespaced:   false,
+						Kind:         "ClusterTokenDenylist",
+						Verbs:        []string{"delete", "deletecollection", "get", "list", "patch", "create", "update", "watch"},
+						Categories:   []string{"pinniped", "pinniped-authenticator", "pinniped-authenticators"},
+					},
 				},
 			},
 		},
@@ -564,6 +588,11 @@ func TestCRDAdditionalPrinterColumns_Parallel(t *testing.T) {
 				{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
 			},
 		},
+		addSuffix("clustertokendenylists.authentication.concierge"): {
+			"v1alpha1": []apiextensionsv1.CustomResourceColumnDefinition{
+				{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+			},
+		},
 		addSuffix("activedirectoryidentityproviders.idp.supervisor"): {
 			"v1alpha1": []apiextensionsv1.CustomResourceColumnDefinition{
 				{Name: "Host", Type: "string", JSONPath: ".spec.host"},
@@ -599,6 +628,21 @@ func TestCRDAdditionalPrinterColumns_Parallel(t *testing.T) {
 				{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
 			},
 		},
+		addSuffix("clusteraudiences.config.supervisor"): {
+			"v1alpha1": []apiextensionsv1.CustomResourceColumnDefinition{
+				{Name: "Audience", Type: "string", JSONPath: ".spec.audience"},
+				{Name: "Server", Type: "string", JSONPath: ".spec.server"},
+				{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+			},
+		},
+		addSuffix("forcedreauthentications.config.supervisor"): {
+			"v1alpha1": []apiextensionsv1.CustomResourceColumnDefinition{
+				{Name: "Kind", Type: "string", JSONPath: ".spec.subject.kind"},
+				{Name: "Name", Type: "string", JSONPath: ".spec.subject.name"},
+				{Name: "Not Before", Type: "date", JSONPath: ".spec.notBefore"},
+				{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+			},
+		},
 		addSuffix("oidcclients.config.supervisor"): {
 			"v1alpha1": []apiextensionsv1.CustomResourceColumnDefinition{
 				{Name: "Privileged Scopes", Type: "string", JSONPath: `.spec.allowedScopes[?(@ == "pinniped:request-audience")]`},
@@ -612,8 +656,11 @@ func TestCRDAdditionalPrinterColumns_Parallel(t *testing.T) {
 	// the current CRDs that we ship as part of Pinniped
 	expectedPinnipedCRDNames := []string{
 		"activedirectoryidentityproviders.idp.supervisor." + env.APIGroupSuffix,
+		"clusteraudiences.config.supervisor." + env.APIGroupSuffix,
+		"clustertokendenylists.authentication.concierge." + env.APIGroupSuffix,
 		"credentialissuers.config.concierge." + env.APIGroupSuffix,
 		"federationdomains.config.supervisor." + env.APIGroupSuffix,
+		"forcedreauthentications.config.supervisor." + env.APIGroupSuffix,
 		"githubidentityproviders.idp.supervisor." + env.APIGroupSuffix,
 		"jwtauthenticators.authentication.concierge." + env.APIGroupSuffix,
 		"ldapidentityproviders.idp.supervisor." + env.APIGroupSuffix,
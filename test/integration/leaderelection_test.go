@@ -120,7 +120,7 @@ func leaderElectionClient(t *testing.T, namespace *corev1.Namespace, leaseName,
 	}
 	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: leaseName}}
 
-	client, leaderElector, err := leaderelection.New(podInfo, deployment, testlib.NewKubeclientOptions(t, testlib.NewClientConfig(t))...)
+	client, leaderElector, _, err := leaderelection.New(podInfo, deployment, leaderelection.LeaseTimings{}, testlib.NewKubeclientOptions(t, testlib.NewClientConfig(t))...)
 	require.NoError(t, err)
 
 	controllerCtx, controllerCancel := context.WithCancel(context.Background())
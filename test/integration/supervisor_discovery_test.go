@@ -33,6 +33,7 @@ import (
 	"go.pinniped.dev/internal/certauthority"
 	"go.pinniped.dev/internal/crypto/ptls"
 	"go.pinniped.dev/internal/here"
+	"go.pinniped.dev/internal/testutil"
 	"go.pinniped.dev/test/testlib"
 )
 
@@ -151,11 +152,20 @@ func TestSupervisorOIDCDiscovery_Disruptive(t *testing.T) {
 			config7, _ := requireCreatingFederationDomainCausesDiscoveryEndpointsToAppear(ctx, t, scheme, addr, caBundle, issuer7, client)
 			requireDeletingFederationDomainCausesDiscoveryEndpointsToDisappear(t, config7, client, ns, scheme, addr, caBundle, issuer7)
 
-			// When we create a FederationDomain with an invalid issuer url, the status is set to invalid.
-			badConfig := testlib.CreateTestFederationDomain(ctx, t, supervisorconfigv1alpha1.FederationDomainSpec{Issuer: badIssuer}, supervisorconfigv1alpha1.FederationDomainPhaseError)
-			requireStatus(t, client, ns, badConfig.Name, supervisorconfigv1alpha1.FederationDomainPhaseError, withFalseConditions([]string{"Ready", "IssuerURLValid"}))
-			requireDiscoveryEndpointsAreNotFound(t, scheme, addr, caBundle, badIssuer)
-			requireDeletingFederationDomainCausesDiscoveryEndpointsToDisappear(t, badConfig, client, ns, scheme, addr, caBundle, badIssuer)
+			// When we create a FederationDomain with an invalid issuer url, it is rejected by the CRD validations on
+			// versions of Kubernetes which support CEL. Otherwise, the status is set to invalid.
+			if testutil.KubeServerMinorVersionAtLeastInclusive(t, kubeClient.Discovery(), 26) {
+				_, err := client.ConfigV1alpha1().FederationDomains(ns).Create(ctx, &supervisorconfigv1alpha1.FederationDomain{
+					ObjectMeta: testlib.ObjectMetaWithRandomName(t, "federation-domain"),
+					Spec:       supervisorconfigv1alpha1.FederationDomainSpec{Issuer: badIssuer},
+				}, metav1.CreateOptions{})
+				require.ErrorContains(t, err, "spec.issuer: Invalid value: \"string\": issuer must not have query or fragment")
+			} else {
+				badConfig := testlib.CreateTestFederationDomain(ctx, t, supervisorconfigv1alpha1.FederationDomainSpec{Issuer: badIssuer}, supervisorconfigv1alpha1.FederationDomainPhaseError)
+				requireStatus(t, client, ns, badConfig.Name, supervisorconfigv1alpha1.FederationDomainPhaseError, withFalseConditions([]string{"Ready", "IssuerURLValid"}))
+				requireDiscoveryEndpointsAreNotFound(t, scheme, addr, caBundle, badIssuer)
+				requireDeletingFederationDomainCausesDiscoveryEndpointsToDisappear(t, badConfig, client, ns, scheme, addr, caBundle, badIssuer)
+			}
 
 			issuer8 := fmt.Sprintf("https://%s/issuer8multipleIDP", addr)
 			config8 := requireIDPsListedByIDPDiscoveryEndpoint(t, env, ctx, kubeClient, ns, scheme, addr, caBundle, issuer8)
@@ -197,13 +207,14 @@ func TestSupervisorTLSTerminationWithSNI_Disruptive(t *testing.T) {
 			Issuer: issuer1,
 			TLS:    &supervisorconfigv1alpha1.FederationDomainTLSSpec{SecretName: certSecretName1},
 		}, supervisorconfigv1alpha1.FederationDomainPhaseReady)
-	requireStatus(t, pinnipedClient, federationDomain1.Namespace, federationDomain1.Name, supervisorconfigv1alpha1.FederationDomainPhaseReady, withAllSuccessfulConditions())
+	requireStatus(t, pinnipedClient, federationDomain1.Namespace, federationDomain1.Name, supervisorconfigv1alpha1.FederationDomainPhaseReady, withTLSSecretValidCondition(metav1.ConditionFalse))
 
 	// The spec.tls.secretName Secret does not exist, so the endpoints should fail with TLS errors.
 	requireEndpointHasBootstrapTLSErrorBecauseCertificatesAreNotReady(t, issuer1)
 
 	// Create the Secret.
 	ca1 := createTLSCertificateSecret(ctx, t, ns, hostname1, nil, certSecretName1, kubeClient)
+	requireStatus(t, pinnipedClient, federationDomain1.Namespace, federationDomain1.Name, supervisorconfigv1alpha1.FederationDomainPhaseReady, withTLSSecretValidCondition(metav1.ConditionTrue))
 
 	// Now that the Secret exists, we should be able to access the endpoints by hostname using the CA.
 	_ = requireStandardDiscoveryEndpointsAreWorking(t, scheme, address, string(ca1.Bundle()), issuer1, nil)
@@ -241,7 +252,7 @@ func TestSupervisorTLSTerminationWithSNI_Disruptive(t *testing.T) {
 			Issuer: issuer2,
 			TLS:    &supervisorconfigv1alpha1.FederationDomainTLSSpec{SecretName: certSecretName2},
 		}, supervisorconfigv1alpha1.FederationDomainPhaseReady)
-	requireStatus(t, pinnipedClient, federationDomain2.Namespace, federationDomain2.Name, supervisorconfigv1alpha1.FederationDomainPhaseReady, withAllSuccessfulConditions())
+	requireStatus(t, pinnipedClient, federationDomain2.Namespace, federationDomain2.Name, supervisorconfigv1alpha1.FederationDomainPhaseReady, withTLSSecretValidCondition(metav1.ConditionFalse))
 
 	// Create the Secret.
 	ca2 := createTLSCertificateSecret(ctx, t, ns, hostname2, nil, certSecretName2, kubeClient)
@@ -309,7 +320,7 @@ func TestSupervisorTLSTerminationWithDefaultCerts_Disruptive(t *testing.T) {
 			Issuer: issuerUsingHostname,
 			TLS:    &supervisorconfigv1alpha1.FederationDomainTLSSpec{SecretName: certSecretName},
 		}, supervisorconfigv1alpha1.FederationDomainPhaseReady)
-	requireStatus(t, pinnipedClient, federationDomain2.Namespace, federationDomain2.Name, supervisorconfigv1alpha1.FederationDomainPhaseReady, withAllSuccessfulConditions())
+	requireStatus(t, pinnipedClient, federationDomain2.Namespace, federationDomain2.Name, supervisorconfigv1alpha1.FederationDomainPhaseReady, withTLSSecretValidCondition(metav1.ConditionFalse))
 
 	// Create the Secret.
 	certCA := createTLSCertificateSecret(ctx, t, ns, hostname, nil, certSecretName, kubeClient)
@@ -538,18 +549,25 @@ func requireWellKnownEndpointIsWorking(t *testing.T, supervisorScheme, superviso
       "issuer": "%s",
       "authorization_endpoint": "%s/oauth2/authorize",
       "token_endpoint": "%s/oauth2/token",
-      "token_endpoint_auth_methods_supported": ["client_secret_basic"],
+      "token_endpoint_auth_methods_supported": ["client_secret_basic", "private_key_jwt"],
+      "token_endpoint_auth_signing_alg_values_supported": ["ES256"],
       "jwks_uri": "%s/jwks.json",
       "scopes_supported": ["openid", "offline_access", "pinniped:request-audience", "username", "groups"],
       "response_types_supported": ["code"],
-      "response_modes_supported": ["query", "form_post"],
+      "response_modes_supported": ["query", "form_post", "jwt", "query.jwt"],
       "code_challenge_methods_supported": ["S256"],
+      "dpop_signing_alg_values_supported": ["ES256", "ES384", "ES512", "RS256", "PS256", "EdDSA"],
+      "pushed_authorization_request_endpoint": "%s/oauth2/par",
+      "authorization_signing_alg_values_supported": ["ES256"],
       "claims_supported": ["username", "groups", "additionalClaims"],
-      "discovery.supervisor.pinniped.dev/v1alpha1": {"pinniped_identity_providers_endpoint": "%s/v1alpha1/pinniped_identity_providers"},
+      "discovery.supervisor.pinniped.dev/v1alpha1": {
+        "pinniped_identity_providers_endpoint": "%s/v1alpha1/pinniped_identity_providers",
+        "pinniped_cluster_audiences_endpoint": "%s/v1alpha1/pinniped_cluster_audiences"
+      },
       "subject_types_supported": ["public"],
       "id_token_signing_alg_values_supported": ["ES256"]
     }`)
-	expectedJSON := fmt.Sprintf(expectedResultTemplate, issuerName, issuerName, issuerName, issuerName, issuerName)
+	expectedJSON := fmt.Sprintf(expectedResultTemplate, issuerName, issuerName, issuerName, issuerName, issuerName, issuerName, issuerName)
 
 	require.Equal(t, "application/json", response.Header.Get("content-type"))
 	require.JSONEq(t, expectedJSON, responseBody)
@@ -680,6 +698,13 @@ func withAllSuccessfulConditions() map[string]metav1.ConditionStatus {
 	}
 }
 
+// withTLSSecretValidCondition is for a FederationDomain which names a Secret in its spec.tls.secretName.
+func withTLSSecretValidCondition(status metav1.ConditionStatus) map[string]metav1.ConditionStatus {
+	c := withAllSuccessfulConditions()
+	c["TLSSecretValid"] = status
+	return c
+}
+
 func withFalseConditions(falseConditionTypes []string) map[string]metav1.ConditionStatus {
 	c := map[string]metav1.ConditionStatus{}
 	for k, v := range withAllSuccessfulConditions() {
@@ -46,6 +46,12 @@ Get "https://127.0.0.1:444444/invalid-url-that-is-really-really-long-nananananan
 				Reason:  "Success",
 				Message: "additionalAuthorizeParameters parameter names are allowed",
 			},
+			{
+				Type:    "ClaimsValid",
+				Status:  "True",
+				Reason:  "Success",
+				Message: "spec.claims are valid",
+			},
 		})
 	})
 
@@ -84,6 +90,12 @@ oidc: issuer did not match the issuer returned by provider, expected "` + env.Su
 				Reason:  "Success",
 				Message: "additionalAuthorizeParameters parameter names are allowed",
 			},
+			{
+				Type:    "ClaimsValid",
+				Status:  "True",
+				Reason:  "Success",
+				Message: "spec.claims are valid",
+			},
 		})
 	})
 
@@ -121,6 +133,12 @@ oidc: issuer did not match the issuer returned by provider, expected "` + env.Su
 				Reason:  "Success",
 				Message: "additionalAuthorizeParameters parameter names are allowed",
 			},
+			{
+				Type:    "ClaimsValid",
+				Status:  "True",
+				Reason:  "Success",
+				Message: "spec.claims are valid",
+			},
 		})
 	})
 }